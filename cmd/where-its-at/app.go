@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/yair/where-its-at/pkg/config"
+	"github.com/yair/where-its-at/pkg/metrics"
+	"github.com/yair/where-its-at/pkg/whereitsat"
+)
+
+// app is what the commands share: the engine, and the logger and metrics
+// the server reports through
+type app struct {
+	*whereitsat.Client
+	logger  *slog.Logger
+	metrics *metrics.Metrics
+}
+
+func newApp(cfg *config.Config, databasePath string, logger *slog.Logger, appMetrics *metrics.Metrics) (*app, error) {
+	client, err := whereitsat.New(cfg, whereitsat.Options{
+		DatabasePath: databasePath,
+		Logger:       logger,
+		Metrics:      appMetrics,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &app{Client: client, logger: logger, metrics: appMetrics}, nil
+}
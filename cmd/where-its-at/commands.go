@@ -0,0 +1,545 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/collectors"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/export"
+	"github.com/yair/where-its-at/pkg/integrations"
+	"github.com/yair/where-its-at/pkg/interfaces"
+	"github.com/yair/where-its-at/pkg/whereitsat"
+)
+
+// errUsage is returned once a command has printed what was wrong with its
+// arguments
+var errUsage = errors.New("invalid usage")
+
+// parseFlags reports bad flags as errUsage; the flag set prints them
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		return errUsage
+	}
+	return err
+}
+
+// usageError prints message and the command's flags
+func usageError(flags *flag.FlagSet, message string) error {
+	fmt.Fprintf(flags.Output(), "%s\n", message)
+	flags.Usage()
+	return errUsage
+}
+
+// tourLimit is how many events are fetched when they're filtered or stored
+// afterwards, enough for an artist's whole tour
+const tourLimit = 200
+
+// runSearch is `search events` and `search artists`: the same searches the
+// API runs, printed as a table or as the API's JSON
+func runSearch(a *app, args []string) error {
+	if len(args) == 0 || (args[0] != "events" && args[0] != "artists") {
+		fmt.Fprint(os.Stderr, "Usage: where-its-at search events|artists [flags]\n")
+		return errUsage
+	}
+	kind, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("search "+kind, flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "maximum number of results")
+	asJSON := flags.Bool("json", false, "print JSON instead of a table")
+
+	ctx := context.Background()
+
+	if kind == "artists" {
+		query := flags.String("query", "", "artist name to search for")
+		if err := parseFlags(flags, args); err != nil {
+			return err
+		}
+		if *query == "" {
+			return usageError(flags, "--query is required")
+		}
+		results, err := a.SearchArtists(ctx, *query, *limit)
+		if err != nil {
+			return fmt.Errorf("failed to search artists: %w", err)
+		}
+		if *asJSON {
+			return writeJSON(os.Stdout, results)
+		}
+		return writeArtistTable(os.Stdout, results.Artists)
+	}
+
+	artist := flags.String("artist", "", "artist to search events for")
+	city := flags.String("city", "", "only events in this city")
+	country := flags.String("country", "", "only events in this country")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	var results *integrations.AggregatedResults
+	var err error
+	switch {
+	case *artist != "" && *city != "":
+		results, err = a.SearchEvents(ctx, *artist, tourLimit)
+		if err == nil {
+			results.Events = inCity(results.Events, *city, *country)
+			if len(results.Events) > *limit {
+				results.Events = results.Events[:*limit]
+			}
+			results.TotalResults = len(results.Events)
+		}
+	case *artist != "":
+		results, err = a.SearchEvents(ctx, *artist, *limit)
+	case *city != "":
+		results, err = a.SearchEventsByLocation(ctx, *city, *country, *limit)
+	default:
+		return usageError(flags, "--artist or --city is required")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to search events: %w", err)
+	}
+
+	if *asJSON {
+		return writeJSON(os.Stdout, results)
+	}
+	for _, sourceErr := range results.Errors {
+		a.logger.Warn("source failed", "error", sourceErr)
+	}
+	return writeEventTable(os.Stdout, results.Events)
+}
+
+// inCity keeps the events whose venue is in city, and in country if set
+func inCity(events []domain.Event, city, country string) []domain.Event {
+	matched := []domain.Event{}
+	for _, event := range events {
+		if !domain.SameCity(event.Venue.City, city) {
+			continue
+		}
+		if country != "" && !domain.SameCountry(event.Venue.Country, country) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	return matched
+}
+
+// runSync refreshes one artist's events, or those of every tracked artist
+// whose events are older than the event cache allows
+func runSync(a *app, args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	artist := flags.String("artist", "", "artist to resync")
+	tracked := flags.Bool("tracked", false, "sync every tracked artist that is due")
+	limit := flags.Int("limit", 50, "maximum number of tracked artists to sync")
+	lease := flags.Duration("lease", 10*time.Minute, "with --tracked, how long the sync lock lasts unrenewed before another instance may take it over")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if (*artist == "") == !*tracked {
+		return usageError(flags, "either --artist or --tracked is required")
+	}
+
+	ctx := context.Background()
+
+	if *artist != "" {
+		results, err := a.Resync(ctx, *artist)
+		if err != nil {
+			return fmt.Errorf("failed to resync %s: %w", *artist, err)
+		}
+		for _, sourceErr := range results.Errors {
+			a.logger.Warn("source failed", "error", sourceErr)
+		}
+		fmt.Printf("%s: %d events\n", *artist, len(results.Events))
+		return nil
+	}
+
+	// Replicas scheduling the same sync would each spend the upstream
+	// quotas on it, so only the one holding the lease runs
+	ran, err := a.RunExclusive(ctx, "sync:tracked", *lease, func(ctx context.Context) error {
+		return syncTracked(ctx, a, *limit)
+	})
+	if err != nil {
+		return err
+	}
+	if !ran {
+		fmt.Println("another instance is syncing tracked artists, skipping")
+	}
+	return nil
+}
+
+func syncTracked(ctx context.Context, a *app, limit int) error {
+	now := time.Now()
+	eventCacheTTL := time.Duration(a.Config.Cache.EventCacheDuration) * time.Hour
+	due, err := a.TrackedArtists.ListDueForSync(ctx, now.Add(-eventCacheTTL), limit)
+	if err != nil {
+		return fmt.Errorf("failed to list tracked artists: %w", err)
+	}
+
+	failed := 0
+	for _, trackedArtist := range due {
+		results, err := a.ArtistEvents(ctx, trackedArtist.ArtistID, tourLimit)
+		if err != nil {
+			a.logger.Warn("failed to sync artist", "artist_id", trackedArtist.ArtistID, "error", err)
+			failed++
+			continue
+		}
+		if err := a.TrackedArtists.MarkSynced(ctx, trackedArtist.ArtistID, now); err != nil {
+			return fmt.Errorf("failed to mark %s synced: %w", trackedArtist.ArtistID, err)
+		}
+		name := trackedArtist.ArtistID
+		if len(results.Artists) > 0 {
+			name = results.Artists[0].Name
+		}
+		fmt.Printf("%s: %d events\n", name, len(results.Events))
+	}
+
+	fmt.Printf("synced %d of %d tracked artists\n", len(due)-failed, len(due))
+	if failed > 0 {
+		return fmt.Errorf("%d artists failed to sync", failed)
+	}
+	return nil
+}
+
+// runBackfill archives the concert history of every artist in a file back
+// to a date, from the history sources. It checkpoints every page, so after
+// an interruption or failure running it again picks up where it stopped.
+func runBackfill(a *app, args []string) error {
+	flags := flag.NewFlagSet("backfill", flag.ContinueOnError)
+	artistsFile := flags.String("artists", "", "file with one artist name per line; # starts a comment")
+	sinceFlag := flags.String("since", "", "oldest concerts to store, a year or YYYY-MM-DD; all of them when empty")
+	delay := flags.Duration("delay", time.Second, "pause between the pages asked of a source")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *artistsFile == "" {
+		return usageError(flags, "--artists is required")
+	}
+	since, err := parseSince(*sinceFlag)
+	if err != nil {
+		return usageError(flags, "--since must be a year or a date (YYYY-MM-DD)")
+	}
+
+	names, err := readArtistNames(*artistsFile)
+	if err != nil {
+		return err
+	}
+	sources := a.Aggregator.HistorySources()
+	if len(sources) == 0 {
+		return fmt.Errorf("no history sources are configured; set a setlist.fm API key")
+	}
+
+	concerts, err := collectors.NewPastConcertRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create past concert repository: %w", err)
+	}
+	checkpoints, err := collectors.NewBackfillCheckpointRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create backfill checkpoint repository: %w", err)
+	}
+	backfiller := interfaces.NewHistoryBackfiller(interfaces.HistoryBackfillConfig{
+		Sources:     sources,
+		Concerts:    concerts,
+		Checkpoints: checkpoints,
+		PageDelay:   *delay,
+		Logger:      a.logger,
+	})
+
+	// Stopping is safe, so Ctrl-C ends the current page rather than the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	failed := 0
+	for _, name := range names {
+		result, err := backfiller.Backfill(ctx, storedArtist(ctx, a, name), since)
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted at %s; run the backfill again to resume", name)
+		}
+		if result != nil {
+			fmt.Printf("%s: %d concerts from %d pages", name, result.Stored, result.Pages)
+			if len(result.Skipped) > 0 {
+				fmt.Printf(" (%s already backfilled)", strings.Join(result.Skipped, ", "))
+			}
+			fmt.Println()
+		}
+		if err != nil {
+			a.logger.Warn("failed to backfill artist", "artist", name, "error", err)
+			failed++
+		}
+	}
+
+	fmt.Printf("backfilled %d of %d artists\n", len(names)-failed, len(names))
+	if failed > 0 {
+		return fmt.Errorf("%d artists failed to backfill; run again to resume them", failed)
+	}
+	return nil
+}
+
+// storedArtist is the stored artist named name, whose MusicBrainz ID saves
+// the history sources a lookup, or just the name when there's none
+func storedArtist(ctx context.Context, a *app, name string) domain.Artist {
+	matches, err := a.Artists.Search(ctx, name, 5)
+	if err == nil {
+		for _, artist := range matches {
+			if strings.EqualFold(artist.Name, name) {
+				return artist
+			}
+		}
+	}
+	return domain.Artist{Name: name}
+}
+
+// readArtistNames reads one artist per line, skipping blank lines and
+// comments
+func readArtistNames(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s lists no artists", path)
+	}
+	return names, nil
+}
+
+// parseSince reads a year, like 2015, as its first day, or a date
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if year, err := strconv.Atoi(value); err == nil {
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// runExport writes stored events the way GET /api/events/export does, or an
+// artist's upcoming events as a calendar
+func runExport(a *app, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "csv", "csv, jsonl or ics")
+	artist := flags.String("artist", "", "only this artist's events; required for ics")
+	city := flags.String("city", "", "only events in this city")
+	fromFlag := flags.String("from", "", "first date, YYYY-MM-DD or RFC 3339")
+	toFlag := flags.String("to", "", "last date, YYYY-MM-DD or RFC 3339")
+	output := flags.String("output", "", "file to write to instead of stdout")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	var newWriter func(io.Writer) export.EventWriter
+	switch *format {
+	case "csv":
+		newWriter = export.NewCSVEventWriter
+	case "jsonl":
+		newWriter = export.NewJSONLEventWriter
+	case "ics":
+		if *artist == "" {
+			return usageError(flags, "--artist is required for ics")
+		}
+	default:
+		return usageError(flags, "--format must be csv, jsonl or ics")
+	}
+
+	from, err := export.ParseDate(*fromFlag, false)
+	if err != nil {
+		return usageError(flags, "--from must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+	to, err := export.ParseDate(*toFlag, true)
+	if err != nil {
+		return usageError(flags, "--to must be a date (YYYY-MM-DD) or RFC 3339 time")
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", *output, err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	ctx := context.Background()
+
+	if *format == "ics" {
+		results, err := a.SearchEvents(ctx, *artist, tourLimit)
+		if err != nil {
+			return fmt.Errorf("failed to search events: %w", err)
+		}
+		now := time.Now()
+		return export.WriteICS(w, export.Calendar{
+			Name:      *artist + " – Where It's At",
+			Events:    export.Upcoming(results.Events, now),
+			Generated: now,
+		})
+	}
+
+	writer := newWriter(w)
+	filter := domain.EventFilter{Artist: *artist, City: *city, From: from, To: to}
+	if err := a.Events.Each(ctx, filter, writer.Write); err != nil {
+		return fmt.Errorf("failed to export events: %w", err)
+	}
+	return writer.Flush()
+}
+
+// runVenues is `venues duplicates`, which lists probable duplicate venues,
+// `venues merge`, which merges one into another, and `venues merges`, which
+// lists past merges
+func runVenues(a *app, args []string) error {
+	if len(args) == 0 || (args[0] != "duplicates" && args[0] != "merge" && args[0] != "merges") {
+		fmt.Fprint(os.Stderr, "Usage: where-its-at venues duplicates|merge|merges [flags]\n")
+		return errUsage
+	}
+	action, args := args[0], args[1:]
+
+	flags := flag.NewFlagSet("venues "+action, flag.ContinueOnError)
+	ctx := context.Background()
+
+	switch action {
+	case "duplicates":
+		city := flags.String("city", "", "only venues in this city")
+		minSimilarity := flags.Float64("min-similarity", 0, "how alike names must be, 0 to 1 (default 0.6)")
+		maxDistance := flags.Float64("max-distance", 0, "how far apart venues may be in km (default 1)")
+		limit := flags.Int("limit", 50, "maximum number of pairs")
+		asJSON := flags.Bool("json", false, "print JSON instead of a table")
+		if err := parseFlags(flags, args); err != nil {
+			return err
+		}
+
+		duplicates, err := a.Events.FindDuplicateVenues(ctx, domain.VenueDuplicateFilter{
+			City:          *city,
+			MinSimilarity: *minSimilarity,
+			MaxDistanceKm: *maxDistance,
+		}, *limit)
+		if err != nil {
+			return fmt.Errorf("failed to find duplicate venues: %w", err)
+		}
+		if *asJSON {
+			return writeJSON(os.Stdout, duplicates)
+		}
+		return writeVenueDuplicateTable(os.Stdout, duplicates)
+
+	case "merge":
+		from := flags.String("from", "", "slug of the venue to merge away")
+		into := flags.String("into", "", "slug of the venue to keep")
+		if err := parseFlags(flags, args); err != nil {
+			return err
+		}
+		if *from == "" || *into == "" {
+			return usageError(flags, "--from and --into are required")
+		}
+		if *from == *into {
+			return usageError(flags, "--into must be another venue than --from")
+		}
+
+		merge, err := a.Events.MergeVenues(ctx, *from, *into)
+		if err != nil {
+			return fmt.Errorf("failed to merge %s into %s: %w", *from, *into, err)
+		}
+		fmt.Printf("merged %s into %s, moving %d events\n", merge.From, merge.Into, merge.Events)
+		return nil
+
+	default:
+		limit := flags.Int("limit", 50, "maximum number of merges")
+		asJSON := flags.Bool("json", false, "print JSON instead of a table")
+		if err := parseFlags(flags, args); err != nil {
+			return err
+		}
+
+		merges, err := a.Events.ListVenueMerges(ctx, *limit)
+		if err != nil {
+			return fmt.Errorf("failed to list venue merges: %w", err)
+		}
+		if *asJSON {
+			return writeJSON(os.Stdout, merges)
+		}
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "MERGED\tFROM\tINTO\tEVENTS")
+		for _, merge := range merges {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%d\n", merge.MergedAt.Format("2006-01-02 15:04"), merge.From, merge.Into, merge.Events)
+		}
+		return table.Flush()
+	}
+}
+
+// runMigrate only needs the database, so it runs without any sources set up
+func runMigrate(_ *app, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	db, err := whereitsat.OpenDatabase(whereitsat.DefaultDatabasePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := whereitsat.Migrate(db); err != nil {
+		return err
+	}
+	fmt.Printf("%s is up to date\n", whereitsat.DefaultDatabasePath)
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func writeEventTable(w io.Writer, events []domain.Event) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "DATE\tARTIST\tVENUE\tCITY\tCOUNTRY\tTICKETS")
+	for _, event := range events {
+		date := "TBA"
+		if event.DateKnown() {
+			date = event.DateTime.Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			date, event.ArtistName, event.Venue.Name, event.Venue.City, event.Venue.Country, event.TicketURL)
+	}
+	return table.Flush()
+}
+
+func writeArtistTable(w io.Writer, artists []domain.Artist) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "NAME\tGENRES\tPOPULARITY")
+	for _, artist := range artists {
+		fmt.Fprintf(table, "%s\t%s\t%d\n", artist.Name, strings.Join(artist.Genres, ", "), artist.Popularity)
+	}
+	return table.Flush()
+}
+
+func writeVenueDuplicateTable(w io.Writer, duplicates []domain.VenueDuplicate) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "KEEP\tDUPLICATE\tCITY\tSIMILARITY\tDISTANCE")
+	for _, duplicate := range duplicates {
+		distance := "-"
+		if duplicate.DistanceKm != nil {
+			distance = fmt.Sprintf("%.2f km", *duplicate.DistanceKm)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%.2f\t%s\n",
+			duplicate.Venue.Slug, duplicate.Duplicate.Slug, duplicate.Venue.City, duplicate.Similarity, distance)
+	}
+	return table.Flush()
+}
@@ -2,24 +2,72 @@ package main
 
 import (
 	"context"
-	"database/sql"
-	"log"
-	"net/http"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
+	"strconv"
+	"strings"
 	"time"
+	// Venue timezones resolve even where the host has no zoneinfo
+	_ "time/tzdata"
 
-	"github.com/gorilla/mux"
-	_ "github.com/mattn/go-sqlite3"
 	"github.com/yair/where-its-at/pkg/collectors"
 	"github.com/yair/where-its-at/pkg/config"
-	"github.com/yair/where-its-at/pkg/integrations"
-	"github.com/yair/where-its-at/pkg/interfaces"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/logging"
+	"github.com/yair/where-its-at/pkg/metrics"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+	"github.com/yair/where-its-at/pkg/tracing"
+	"github.com/yair/where-its-at/pkg/whereitsat"
 )
 
+const usage = `Usage: where-its-at [command] [flags]
+
+Commands:
+  serve     run the HTTP API (the default)
+  search    search events or artists across every source
+  sync      refresh stored events from the sources
+  backfill  archive years of artists' past concerts
+  export    write stored events as CSV, JSON lines or iCalendar
+  venues    find and merge venues stored twice under different names
+  migrate   create or update the database tables
+
+Run 'where-its-at <command> -h' for a command's flags.
+`
+
+// command runs with the app built from the config. Commands that don't need
+// the sources, like migrate, leave needsApp unset and get a nil app.
+type command struct {
+	run      func(a *app, args []string) error
+	needsApp bool
+}
+
+var commands = map[string]command{
+	"serve":    {run: runServe, needsApp: true},
+	"search":   {run: runSearch, needsApp: true},
+	"sync":     {run: runSync, needsApp: true},
+	"backfill": {run: runBackfill, needsApp: true},
+	"export":   {run: runExport, needsApp: true},
+	"venues":   {run: runVenues, needsApp: true},
+	"migrate":  {run: runMigrate},
+}
+
 func main() {
-	log.Println("Starting Where It's At...")
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		fmt.Print(usage)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
 
 	// Load configuration
 	configPath := os.Getenv("CONFIG_PATH")
@@ -27,100 +75,109 @@ func main() {
 		configPath = "config.json"
 	}
 
-	cfg, err := config.Load(configPath)
-	if err != nil {
-		log.Printf("Warning: Failed to load config: %v. Using defaults.", err)
+	cfg, loadErr := config.Load(configPath)
+	if loadErr != nil {
 		cfg = &config.Config{}
 	}
 
-	// Initialize database
-	db, err := sql.Open("sqlite3", "./where-its-at.db")
-	if err != nil {
-		log.Fatalf("Failed to open database: %v", err)
-	}
-	defer db.Close()
+	logger := logging.New(os.Stderr, cfg.Logging.Format, cfg.Logging.Level)
+	slog.SetDefault(logger)
 
-	// Initialize repositories
-	artistRepo, err := collectors.NewArtistRepository(db)
-	if err != nil {
-		log.Fatalf("Failed to create artist repository: %v", err)
+	if loadErr != nil {
+		logger.Warn("failed to load config, using defaults", "path", configPath, "error", loadErr)
 	}
 
-	// Initialize integrations (optional - only if configured)
-	var artistAggregator *integrations.ArtistAggregator
-	if cfg.APIs.Spotify.ClientID != "" {
-		spotifyClient, err := integrations.NewSpotifyClient(integrations.SpotifyConfig{
-			ClientID:     cfg.APIs.Spotify.ClientID,
-			ClientSecret: cfg.APIs.Spotify.ClientSecret,
+	if cfg.Tracing.Enabled {
+		shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+			Endpoint:    cfg.Tracing.Endpoint,
+			Insecure:    cfg.Tracing.Insecure,
+			ServiceName: cfg.Tracing.ServiceName,
+			SampleRatio: cfg.Tracing.SampleRatio,
 		})
 		if err != nil {
-			log.Printf("Warning: Failed to create Spotify client: %v", err)
+			logger.Warn("failed to set up tracing", "error", err)
 		} else {
-			artistAggregator = integrations.NewArtistAggregator(spotifyClient, nil)
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := shutdownTracing(ctx); err != nil {
+					logger.Warn("failed to flush traces", "error", err)
+				}
+			}()
+			logger.Info("tracing enabled", "endpoint", cfg.Tracing.Endpoint)
 		}
 	}
 
-	// Initialize services
-	artistService := interfaces.NewArtistService(artistRepo, artistAggregator)
-
-	// Initialize HTTP handlers
-	artistHandler := interfaces.NewArtistHandler(artistService)
-
-	// Setup router
-	router := mux.NewRouter()
-	artistHandler.RegisterRoutes(router)
-
-	// Health check endpoint
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ok"}`))
-	}).Methods("GET")
-
-	// Log available routes
-	log.Println("Available routes:")
-	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
-		path, _ := route.GetPathTemplate()
-		methods, _ := route.GetMethods()
-		log.Printf("  %v %s", methods, path)
-		return nil
+	appMetrics := metrics.New()
+	collectors.SetQueryObserver(appMetrics)
+	ratelimit.SetRejectionObserver(appMetrics)
+	httpclient.SetDefaults(httpclient.RetryConfig{
+		MaxRetries: cfg.APIs.Retry.MaxRetries,
+		BaseDelay:  time.Duration(cfg.APIs.Retry.BaseDelayMS) * time.Millisecond,
+		MaxDelay:   time.Duration(cfg.APIs.Retry.MaxDelayMS) * time.Millisecond,
 	})
-
-	// Setup HTTP server
-	port := cfg.Server.Port
-	if port == "" {
-		port = "8080"
+	proxies := make(map[string]httpclient.ProxyConfig, len(cfg.Sources.Proxies))
+	for source, proxy := range cfg.Sources.Proxies {
+		proxies[source] = httpclient.ProxyConfig(proxy)
 	}
-
-	srv := &http.Server{
-		Addr:         ":" + port,
-		Handler:      router,
-		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
-		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	if err := httpclient.SetProxies(proxies); err != nil {
+		fatal(logger, "failed to configure proxies", err)
+	}
+	httpclient.SetConditionalCacheSize(int64(cfg.APIs.ConditionalCacheMB) << 20)
+
+	// --demo decides which sources and database the app is built with, so
+	// it's read ahead of serve parsing its flags
+	databasePath := whereitsat.DefaultDatabasePath
+	if name == "serve" && demoRequested(args) {
+		cfg.Environment = config.EnvironmentDevelopment
+		databasePath = demoDatabasePath
 	}
 
-	// Start server in goroutine
-	go func() {
-		log.Printf("Server listening on port %s", port)
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+	var a *app
+	if cmd.needsApp {
+		var err error
+		if a, err = newApp(cfg, databasePath, logger, appMetrics); err != nil {
+			fatal(logger, "failed to start", err)
 		}
-	}()
-
-	// Wait for interrupt signal
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	}
 
-	log.Println("Shutting down server...")
+	err := cmd.run(a, args)
+	if a != nil {
+		a.Close()
+	}
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, errUsage):
+		os.Exit(2)
+	case err != nil:
+		fatal(logger, name+" failed", err)
+	}
+}
 
-	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// demoDatabasePath keeps fixture data out of the real event store
+const demoDatabasePath = "./where-its-at-demo.db"
 
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+// demoRequested reports whether args turn on -demo or --demo
+func demoRequested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" || !strings.HasPrefix(arg, "-") {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name != "demo" {
+			continue
+		}
+		if !hasValue {
+			return true
+		}
+		on, err := strconv.ParseBool(value)
+		return err == nil && on
 	}
+	return false
+}
 
-	log.Println("Server stopped. That was a good drum break.")
+// fatal logs err and exits
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
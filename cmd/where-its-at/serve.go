@@ -0,0 +1,369 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/collectors"
+	"github.com/yair/where-its-at/pkg/config"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/interfaces"
+	"github.com/yair/where-its-at/pkg/notifications"
+	"github.com/yair/where-its-at/pkg/notifications/telegram"
+	"github.com/yair/where-its-at/pkg/whereitsat"
+)
+
+// runServe runs the HTTP API and the background notifiers until SIGINT or
+// SIGTERM
+func runServe(a *app, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	port := flags.String("port", a.Config.Server.Port, "port to listen on")
+	// Read by main before the app is built; declared so it parses and shows in -h
+	flags.Bool("demo", false, "serve fixture artists and events without API keys, from a separate demo database")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	cfg, logger := a.Config, a.logger
+	logger.Info("Starting Where It's At...")
+
+	// Setup router
+	router := mux.NewRouter()
+	router.NotFoundHandler = interfaces.RouteNotFound()
+	router.MethodNotAllowedHandler = interfaces.MethodNotAllowed()
+	interfaces.NewArtistHandler(a.ArtistService).RegisterRoutes(router)
+	interfaces.NewAggregatorHandler(a.EventService).RegisterRoutes(router)
+	interfaces.NewGraphQLHandler(a.ArtistService, a.EventService).RegisterRoutes(router)
+	interfaces.NewExportHandler(a.EventService, a.Events).RegisterRoutes(router)
+	interfaces.NewArchiveHandler(a.Events).RegisterRoutes(router)
+	interfaces.NewChangeFeedHandler(a.Events).RegisterRoutes(router)
+	interfaces.NewVenueHandler(a.Events).RegisterRoutes(router)
+	interfaces.NewEventLookupHandler(a.EventLookup).RegisterRoutes(router)
+	interfaces.NewFeedHandler(a.Events, a.Artists).RegisterRoutes(router)
+	interfaces.NewPriceHandler(a.Events, a.Events).RegisterRoutes(router)
+	interfaces.NewMapHandler(a.Events).RegisterRoutes(router)
+	interfaces.NewRecommendationHandler(interfaces.NewRecommendationService(a.EventService, a.SimilarArtists, a.Events)).RegisterRoutes(router)
+	interfaces.NewLocalSearchHandler(a.SearchIndex).RegisterRoutes(router)
+	interfaces.NewSuggestHandler(a.Suggester).RegisterRoutes(router)
+	interfaces.NewHistoryHandler(a.Artists, a.Aggregator).RegisterRoutes(router)
+	interfaces.NewTouringHandler(a.Artists, a.EventService, a.Aggregator).RegisterRoutes(router)
+	interfaces.NewTracksHandler(a.Artists, a.TracksAggregator).RegisterRoutes(router)
+	interfaces.NewGenreHandler().RegisterRoutes(router)
+	profileCacheTTL := time.Duration(cfg.Cache.ProfileCacheDuration) * time.Hour
+	interfaces.NewArtistProfileHandler(a.Artists, a.ArtistProfiles, a.Jobs, profileCacheTTL).RegisterRoutes(router)
+	interfaces.NewJobHandler(a.Jobs).RegisterRoutes(router)
+	if a.SetlistFM != nil {
+		interfaces.NewSetlistHandler(a.Events, a.SetlistFM, a.Deezer).RegisterRoutes(router)
+	}
+
+	// User accounts
+	userRepo, err := collectors.NewUserRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create user repository: %w", err)
+	}
+	jwtSecret := []byte(cfg.Auth.JWTSecret)
+	if len(jwtSecret) == 0 {
+		jwtSecret = make([]byte, 32)
+		if _, err := rand.Read(jwtSecret); err != nil {
+			return fmt.Errorf("failed to generate JWT secret: %w", err)
+		}
+		logger.Warn("no JWT secret configured, sessions won't survive a restart")
+	}
+	authService := interfaces.NewAuthService(userRepo, interfaces.AuthConfig{
+		Secret:          jwtSecret,
+		AccessTokenTTL:  time.Duration(cfg.Auth.AccessTokenTTLMinutes) * time.Minute,
+		RefreshTokenTTL: time.Duration(cfg.Auth.RefreshTokenTTLHours) * time.Hour,
+	})
+	interfaces.NewAuthHandler(authService).RegisterRoutes(router)
+
+	// City browse pages, analytics and operator stats all aggregate stored
+	// events
+	statsRepo, err := collectors.NewStatsRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create stats repository: %w", err)
+	}
+	interfaces.NewCityHandler(statsRepo).RegisterRoutes(router)
+	interfaces.NewStatsHandler(statsRepo, 0).RegisterRoutes(router)
+
+	// Cache and data management for operators
+	if cfg.Auth.AdminToken != "" {
+		interfaces.NewAdminHandler(cfg.Auth.AdminToken, a.Aggregator, a.Events, statsRepo, a.EventService, a.Aggregator).RegisterRoutes(router)
+		interfaces.NewSourceDebugHandler(cfg.Auth.AdminToken, a.Aggregator).RegisterRoutes(router)
+		interfaces.NewVenueMergeHandler(cfg.Auth.AdminToken, a.Events).RegisterRoutes(router)
+		interfaces.NewSourceSettingsHandler(cfg.Auth.AdminToken, a.Aggregator, a.SourceSettings).RegisterRoutes(router)
+	}
+
+	// Per-user follows, saved searches and digest preferences
+	followRepo, err := collectors.NewFollowRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create follow repository: %w", err)
+	}
+	savedSearchRepo, err := collectors.NewSavedSearchRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create saved search repository: %w", err)
+	}
+	preferencesRepo, err := collectors.NewNotificationPreferencesRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create notification preferences repository: %w", err)
+	}
+	interfaces.NewSubscriptionHandler(authService, followRepo, savedSearchRepo, preferencesRepo).RegisterRoutes(router)
+
+	// Public playlists only need client credentials, unlike library import
+	if a.Spotify != nil {
+		playlistImport := interfaces.NewSpotifyPlaylistImportService(a.Spotify, a.Artists, a.TrackedArtists, followRepo, a.Jobs)
+		interfaces.NewSpotifyPlaylistHandler(authService, playlistImport).RegisterRoutes(router)
+	}
+
+	// Spotify library import needs a redirect URI for the user authorization flow
+	if a.Spotify != nil && cfg.APIs.Spotify.RedirectURI != "" {
+		tokenRepo, err := collectors.NewOAuthTokenRepository(a.DB)
+		if err != nil {
+			return fmt.Errorf("failed to create oauth token repository: %w", err)
+		}
+		importService := interfaces.NewSpotifyImportService(a.Spotify, tokenRepo, a.Artists, a.TrackedArtists)
+		interfaces.NewSpotifyAuthHandler(authService, importService).RegisterRoutes(router)
+	}
+
+	if a.LastFM != nil {
+		importService := interfaces.NewLastFMImportService(a.LastFM, a.Artists, a.TrackedArtists, followRepo)
+		interfaces.NewLastFMImportHandler(authService, importService).RegisterRoutes(router)
+	}
+
+	onSaleRepo, err := collectors.NewOnSaleAlertRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create on-sale alert repository: %w", err)
+	}
+	interfaces.NewOnSaleHandler(authService, a.Events, onSaleRepo).RegisterRoutes(router)
+
+	// Charts and new releases come from Deezer, which needs no key
+	interfaces.NewDiscoverHandler(authService, followRepo, a.Deezer, a.Deezer).RegisterRoutes(router)
+	releaseRepo, err := collectors.NewReleaseAlertRepository(a.DB)
+	if err != nil {
+		return fmt.Errorf("failed to create release alert repository: %w", err)
+	}
+
+	// Local bands come from MusicBrainz areas
+	if a.MusicBrainz != nil {
+		interfaces.NewLocalArtistHandler(a.MusicBrainz, a.Artists, a.Events).RegisterRoutes(router)
+	}
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	// Expired searches leave the cache even when nobody repeats them
+	go a.Aggregator.RunCacheSweeps(backgroundCtx, time.Minute)
+
+	// Request history no rate limit window reaches back to anymore
+	go a.Quotas.RunPrunes(backgroundCtx, time.Hour, whereitsat.QuotaRetention)
+
+	// Backups and vacuums of the event store
+	sqliteConfig := cfg.Database.SQLite
+	maintainer, err := collectors.NewMaintainer(a.DB, collectors.MaintainerConfig{
+		BackupDir:      sqliteConfig.BackupDir,
+		BackupInterval: time.Duration(sqliteConfig.BackupIntervalHours) * time.Hour,
+		BackupsKept:    sqliteConfig.BackupsKept,
+		VacuumInterval: time.Duration(sqliteConfig.VacuumIntervalHours) * time.Hour,
+		Logger:         logger,
+
+		ArchiveRetention: time.Duration(sqliteConfig.ArchiveRetentionDays) * 24 * time.Hour,
+		FeedRetention:    time.Duration(sqliteConfig.FeedRetentionDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create database maintainer: %w", err)
+	}
+	go maintainer.Run(backgroundCtx)
+
+	// Type-ahead picks up artists cached since the last refresh
+	go a.Suggester.RunRefresh(backgroundCtx, 10*time.Minute)
+
+	// Playlist syncs and artist profiles are built by the job workers
+	go a.Jobs.Run(backgroundCtx)
+
+	// Live pushes over /ws of newly stored events and on-sale alerts
+	hub := notifications.NewHub(logger)
+	go hub.RunDiscoveries(backgroundCtx, a.Events, time.Duration(cfg.Notifications.LiveCheckSeconds)*time.Second)
+	a.EventService.SetChangeNotifier(hub)
+	interfaces.NewLiveHandler(hub, logger).RegisterRoutes(router)
+
+	// Webhooks and live pushes need no setup; email and Telegram join when
+	// configured below
+	onSaleNotifiers := []notifications.OnSaleNotifier{notifications.NewWebhookNotifier(nil), hub}
+	var releaseNotifiers []notifications.ReleaseNotifier
+
+	if cfg.Notifications.SMTP.Host != "" {
+		mailer, err := notifications.NewSMTPMailer(notifications.SMTPConfig{
+			Host:     cfg.Notifications.SMTP.Host,
+			Port:     cfg.Notifications.SMTP.Port,
+			Username: cfg.Notifications.SMTP.Username,
+			Password: cfg.Notifications.SMTP.Password,
+			From:     cfg.Notifications.SMTP.From,
+		})
+		if err != nil {
+			logger.Warn("failed to set up email digests", "error", err)
+		} else {
+			notifier := notifications.NewDigestNotifier(notifications.DigestNotifierConfig{
+				Users:         userRepo,
+				Follows:       followRepo,
+				SavedSearches: savedSearchRepo,
+				Preferences:   preferencesRepo,
+				Events:        a.Events,
+				Mailer:        mailer,
+				BaseURL:       cfg.Notifications.BaseURL,
+				Logger:        logger,
+			})
+			go notifier.Run(backgroundCtx, time.Duration(cfg.Notifications.DigestCheckMinutes)*time.Minute)
+			onSaleNotifiers = append(onSaleNotifiers, notifications.NewEmailOnSaleNotifier(mailer))
+			releaseNotifiers = append(releaseNotifiers, notifications.NewEmailReleaseNotifier(mailer, preferencesRepo))
+			logger.Info("email digests enabled", "smtp_host", cfg.Notifications.SMTP.Host)
+		}
+	}
+
+	if cfg.Notifications.Telegram.BotToken != "" {
+		bot, err := startTelegramBot(backgroundCtx, cfg.Notifications.Telegram, a.DB, authService, followRepo, a.Events, router, logger)
+		if err != nil {
+			logger.Warn("failed to start telegram bot", "error", err)
+		} else {
+			onSaleNotifiers = append(onSaleNotifiers, bot)
+			releaseNotifiers = append(releaseNotifiers, bot)
+		}
+	}
+
+	onSaleWatcher := notifications.NewOnSaleWatcher(notifications.OnSaleWatcherConfig{
+		Users:         userRepo,
+		Follows:       followRepo,
+		SavedSearches: savedSearchRepo,
+		Alerts:        onSaleRepo,
+		Events:        a.Events,
+		Notifiers:     onSaleNotifiers,
+		Lead:          time.Duration(cfg.Notifications.OnSale.LeadMinutes) * time.Minute,
+		Logger:        logger,
+	})
+	go onSaleWatcher.Run(backgroundCtx, time.Duration(cfg.Notifications.OnSale.CheckMinutes)*time.Minute)
+
+	// New albums only go out by email and Telegram, so there is nothing to
+	// watch for without either
+	if len(releaseNotifiers) > 0 {
+		releaseWatcher := notifications.NewReleaseWatcher(notifications.ReleaseWatcherConfig{
+			Users:     userRepo,
+			Follows:   followRepo,
+			Alerts:    releaseRepo,
+			Releases:  a.Deezer,
+			Notifiers: releaseNotifiers,
+			Window:    time.Duration(cfg.Notifications.Releases.WindowDays) * 24 * time.Hour,
+			Logger:    logger,
+		})
+		go releaseWatcher.Run(backgroundCtx, time.Duration(cfg.Notifications.Releases.CheckHours)*time.Hour)
+	}
+
+	// Liveness and readiness probes
+	interfaces.NewHealthHandler(a.DB, a.EventService).RegisterRoutes(router)
+
+	router.Handle("/metrics", a.metrics.Handler()).Methods("GET")
+	router.Use(interfaces.RequestLogging(logger), interfaces.RequestTracing(), interfaces.RequestMetrics(a.metrics), interfaces.Compression(), interfaces.FieldSelection())
+
+	// Log available routes
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, _ := route.GetPathTemplate()
+		methods, _ := route.GetMethods()
+		logger.Debug("route registered", "methods", methods, "path", path)
+		return nil
+	})
+
+	// Setup HTTP server
+	if *port == "" {
+		*port = "8080"
+	}
+
+	cors := cfg.Server.CORS
+	handler := interfaces.CORS(router, interfaces.CORSConfig{
+		AllowedOrigins:   cors.AllowedOrigins,
+		AllowedMethods:   cors.AllowedMethods,
+		AllowedHeaders:   cors.AllowedHeaders,
+		ExposedHeaders:   cors.ExposedHeaders,
+		AllowCredentials: cors.AllowCredentials,
+		MaxAge:           cors.MaxAgeSeconds,
+	})
+
+	srv := &http.Server{
+		Addr:         ":" + *port,
+		Handler:      handler,
+		ReadTimeout:  time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+
+	// Start server in goroutine
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("server listening", "port", *port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serveErr <- err
+		}
+	}()
+
+	// Wait for interrupt signal
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to start server: %w", err)
+	case <-quit:
+	}
+
+	logger.Info("shutting down server")
+
+	// Graceful shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("server forced to shutdown", "error", err)
+	}
+
+	logger.Info("Server stopped. That was a good drum break.")
+	return nil
+}
+
+// startTelegramBot links chats through /start and pushes new events, and
+// cancellations or date changes of stored ones, for followed artists until
+// ctx is done. The bot is returned so it can carry
+// other alerts too.
+func startTelegramBot(ctx context.Context, cfg config.TelegramConfig, db *sql.DB, auth *interfaces.AuthService, follows domain.FollowRepository, events *collectors.EventRepository, router *mux.Router, logger *slog.Logger) (*telegram.Bot, error) {
+	client, err := telegram.NewClient(cfg.BotToken)
+	if err != nil {
+		return nil, err
+	}
+	me, err := client.GetMe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	linkRepo, err := collectors.NewTelegramLinkRepository(db)
+	if err != nil {
+		return nil, err
+	}
+
+	bot := telegram.NewBot(telegram.BotConfig{
+		Client:  client,
+		Links:   linkRepo,
+		Follows: follows,
+		Events:  events,
+		Changes: events,
+		Logger:  logger,
+	})
+	go bot.Run(ctx)
+	go bot.RunAlerts(ctx, time.Duration(cfg.AlertCheckMinutes)*time.Minute)
+
+	interfaces.NewTelegramHandler(auth, linkRepo, me.Username).RegisterRoutes(router)
+	logger.Info("telegram alerts enabled", "bot", me.Username)
+	return bot, nil
+}
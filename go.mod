@@ -6,16 +6,66 @@ toolchain go1.24.5
 
 require (
 	github.com/gorilla/mux v1.8.1
-	github.com/mattn/go-sqlite3 v1.14.32
 	github.com/yair/where-its-at/pkg/collectors v0.0.0
 	github.com/yair/where-its-at/pkg/config v0.0.0
+	github.com/yair/where-its-at/pkg/domain v0.0.0
+	github.com/yair/where-its-at/pkg/export v0.0.0
 	github.com/yair/where-its-at/pkg/integrations v0.0.0
 	github.com/yair/where-its-at/pkg/interfaces v0.0.0
+	github.com/yair/where-its-at/pkg/logging v0.0.0
+	github.com/yair/where-its-at/pkg/metrics v0.0.0
+	github.com/yair/where-its-at/pkg/notifications v0.0.0
+	github.com/yair/where-its-at/pkg/ratelimit v0.0.0
+	github.com/yair/where-its-at/pkg/tracing v0.0.0
+	github.com/yair/where-its-at/pkg/whereitsat v0.0.0
 )
 
 require (
-	github.com/yair/where-its-at/pkg/domain v0.0.0 // indirect
+	github.com/andybalholm/brotli v1.2.0 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cenkalti/backoff/v4 v4.3.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/chromedp/cdproto v0.0.0-20250724212937-08a3db8b4327 // indirect
+	github.com/chromedp/chromedp v0.14.2 // indirect
+	github.com/chromedp/sysutil v1.1.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/felixge/httpsnoop v1.0.4 // indirect
+	github.com/go-json-experiment/json v0.0.0-20250725192818-e39067aee2d2 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/gobwas/httphead v0.1.0 // indirect
+	github.com/gobwas/pool v0.2.1 // indirect
+	github.com/gobwas/ws v1.4.0 // indirect
+	github.com/golang-jwt/jwt/v5 v5.2.3 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/grpc-ecosystem/grpc-gateway/v2 v2.26.1 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/mattn/go-sqlite3 v1.14.32 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_golang v1.20.5 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	github.com/redis/go-redis/v9 v9.17.2 // indirect
+	github.com/yair/where-its-at/pkg/cache v0.0.0 // indirect
+	github.com/yair/where-its-at/pkg/scoring v0.0.0 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp v0.60.0 // indirect
+	go.opentelemetry.io/otel v1.35.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace v1.35.0 // indirect
+	go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp v1.35.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
+	go.opentelemetry.io/otel/sdk v1.35.0 // indirect
+	go.opentelemetry.io/otel/trace v1.35.0 // indirect
+	go.opentelemetry.io/proto/otlp v1.5.0 // indirect
+	golang.org/x/crypto v0.43.0 // indirect
 	golang.org/x/net v0.46.0 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+	golang.org/x/text v0.30.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20250218202821-56aae31c358a // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250218202821-56aae31c358a // indirect
+	google.golang.org/grpc v1.71.0 // indirect
+	google.golang.org/protobuf v1.36.5 // indirect
 )
 
 replace github.com/yair/where-its-at/pkg/domain => ./pkg/domain
@@ -27,3 +77,21 @@ replace github.com/yair/where-its-at/pkg/config => ./pkg/config
 replace github.com/yair/where-its-at/pkg/integrations => ./pkg/integrations
 
 replace github.com/yair/where-its-at/pkg/interfaces => ./pkg/interfaces
+
+replace github.com/yair/where-its-at/pkg/ratelimit => ./pkg/ratelimit
+
+replace github.com/yair/where-its-at/pkg/export => ./pkg/export
+
+replace github.com/yair/where-its-at/pkg/logging => ./pkg/logging
+
+replace github.com/yair/where-its-at/pkg/metrics => ./pkg/metrics
+
+replace github.com/yair/where-its-at/pkg/tracing => ./pkg/tracing
+
+replace github.com/yair/where-its-at/pkg/notifications => ./pkg/notifications
+
+replace github.com/yair/where-its-at/pkg/whereitsat => ./pkg/whereitsat
+
+replace github.com/yair/where-its-at/pkg/scoring => ./pkg/scoring
+
+replace github.com/yair/where-its-at/pkg/cache => ./pkg/cache
@@ -0,0 +1,33 @@
+// Package cache holds values shared by every instance of the service, so
+// instances behind a load balancer answer from the same cached searches and
+// spend the same upstream request budgets.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when nothing is stored under the key, or it
+// expired
+var ErrMiss = errors.New("cache miss")
+
+// Store is a key-value cache that expires values on its own
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key for ttl; zero keeps it until deleted
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// DeletePrefix drops every key starting with prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// Counter counts under a key that expires, like requests in a rate limit
+// window
+type Counter interface {
+	// Increment adds n to key, or takes it away when n is negative, and
+	// returns the new count. The key expires ttl after the first increment.
+	Increment(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+	// Count is key's count, zero when it expired or was never incremented
+	Count(ctx context.Context, key string) (int64, error)
+}
@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const defaultNamespace = "whereitsat"
+
+type RedisConfig struct {
+	Addr     string
+	Password string
+	DB       int
+	// Namespace prefixes every key, so instances sharing a Redis with other
+	// services or environments don't collide. Defaults to "whereitsat".
+	Namespace string
+}
+
+// RedisStore is a Store and Counter in Redis. TTLs are set on the keys, so
+// Redis expires them whichever instance wrote them.
+type RedisStore struct {
+	client    *redis.Client
+	namespace string
+}
+
+// NewRedisStore connects to Redis and checks it answers
+func NewRedisStore(ctx context.Context, config RedisConfig) (*RedisStore, error) {
+	if config.Namespace == "" {
+		config.Namespace = defaultNamespace
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     config.Addr,
+		Password: config.Password,
+		DB:       config.DB,
+	})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", config.Addr, err)
+	}
+
+	return &RedisStore{client: client, namespace: config.Namespace}, nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, s.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", key, err)
+	}
+	return value, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := s.client.Set(ctx, s.key(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to set %s: %w", key, err)
+	}
+	return nil
+}
+
+// DeletePrefix scans for the keys rather than using KEYS, so Redis keeps
+// serving other clients while a large cache is cleared
+func (s *RedisStore) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := s.client.Scan(ctx, 0, escapePattern(s.key(prefix))+"*", 500).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 500 {
+			if err := s.client.Unlink(ctx, keys...).Err(); err != nil {
+				return fmt.Errorf("failed to delete %s*: %w", prefix, err)
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan %s*: %w", prefix, err)
+	}
+	if len(keys) > 0 {
+		if err := s.client.Unlink(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to delete %s*: %w", prefix, err)
+		}
+	}
+	return nil
+}
+
+func (s *RedisStore) Increment(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	key = s.key(key)
+	var incr *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.IncrBy(ctx, key, n)
+		// NX leaves the expiry of a window already counting alone
+		pipe.ExpireNX(ctx, key, ttl)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment %s: %w", key, err)
+	}
+	return incr.Val(), nil
+}
+
+func (s *RedisStore) Count(ctx context.Context, key string) (int64, error) {
+	count, err := s.client.Get(ctx, s.key(key)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to count %s: %w", key, err)
+	}
+	return count, nil
+}
+
+// acquireScript sets the lease unless someone else holds it, so the same
+// call takes a free lease and renews a held one
+var acquireScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder and holder ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Acquire takes the lease for holder, or renews it when holder has it. Redis
+// expires a lease nobody renews, so another instance can take it over.
+func (s *RedisStore) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if name == "" || holder == "" {
+		return false, fmt.Errorf("lease name and holder are required")
+	}
+
+	acquired, err := acquireScript.Run(ctx, s.client, []string{s.leaseKey(name)}, holder, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	return acquired == 1, nil
+}
+
+// Release drops the lease if holder still has it, leaving one that was taken
+// over alone
+func (s *RedisStore) Release(ctx context.Context, name, holder string) error {
+	if err := releaseScript.Run(ctx, s.client, []string{s.leaseKey(name)}, holder).Err(); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
+
+func (s *RedisStore) key(key string) string {
+	return s.namespace + ":" + key
+}
+
+func (s *RedisStore) leaseKey(name string) string {
+	return s.key("lease:" + name)
+}
+
+// escapePattern quotes the glob characters SCAN's MATCH reads, since keys
+// hold artist names and those can have any of them
+func escapePattern(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	store, err := NewRedisStore(context.Background(), RedisConfig{Addr: server.Addr()})
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store, server
+}
+
+func TestRedisStore_GetSet(t *testing.T) {
+	store, server := newTestStore(t)
+	ctx := context.Background()
+
+	if _, err := store.Get(ctx, "search:events:Radiohead"); !errors.Is(err, ErrMiss) {
+		t.Fatalf("expected a miss, got %v", err)
+	}
+	if err := store.Set(ctx, "search:events:Radiohead", []byte(`{"events":[]}`), time.Hour); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+
+	value, err := store.Get(ctx, "search:events:Radiohead")
+	if err != nil || string(value) != `{"events":[]}` {
+		t.Fatalf("expected the value back, got %q (%v)", value, err)
+	}
+	// Keys are namespaced and Redis expires them itself
+	if ttl := server.TTL("whereitsat:search:events:Radiohead"); ttl != time.Hour {
+		t.Errorf("expected the key to expire in an hour, got %v", ttl)
+	}
+	server.FastForward(time.Hour)
+	if _, err := store.Get(ctx, "search:events:Radiohead"); !errors.Is(err, ErrMiss) {
+		t.Errorf("expected the value to expire, got %v", err)
+	}
+}
+
+func TestRedisStore_DeletePrefix(t *testing.T) {
+	store, server := newTestStore(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"events:AC*DC__10", "events:AC*DC|only=songkick_10", "events:ACxDC__10", "artists:AC*DC_10"} {
+		store.Set(ctx, key, []byte("{}"), time.Hour)
+	}
+	server.Set("other:events:AC*DC__10", "{}")
+
+	if err := store.DeletePrefix(ctx, "events:AC*DC"); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+
+	for key, kept := range map[string]bool{
+		"whereitsat:events:AC*DC__10":              false,
+		"whereitsat:events:AC*DC|only=songkick_10": false,
+		"whereitsat:events:ACxDC__10":              true,
+		"whereitsat:artists:AC*DC_10":              true,
+		"other:events:AC*DC__10":                   true,
+	} {
+		if server.Exists(key) != kept {
+			t.Errorf("expected %s kept=%v", key, kept)
+		}
+	}
+}
+
+func TestRedisStore_Increment(t *testing.T) {
+	store, server := newTestStore(t)
+	ctx := context.Background()
+
+	for want := int64(1); want <= 3; want++ {
+		count, err := store.Increment(ctx, "ratelimit:songkick:1", 1, time.Minute)
+		if err != nil || count != want {
+			t.Fatalf("expected %d, got %d (%v)", want, count, err)
+		}
+		server.FastForward(10 * time.Second)
+	}
+	if count, _ := store.Count(ctx, "ratelimit:songkick:1"); count != 3 {
+		t.Errorf("expected a count of 3, got %d", count)
+	}
+
+	// The window expires a minute after its first request, not its last
+	server.FastForward(30 * time.Second)
+	if count, err := store.Count(ctx, "ratelimit:songkick:1"); err != nil || count != 0 {
+		t.Errorf("expected the window to expire, got %d (%v)", count, err)
+	}
+}
+
+func TestRedisStore_Lease(t *testing.T) {
+	store, server := newTestStore(t)
+	ctx := context.Background()
+
+	acquire := func(holder string) bool {
+		t.Helper()
+		ok, err := store.Acquire(ctx, "sync:tracked", holder, time.Minute)
+		if err != nil {
+			t.Fatalf("failed to acquire: %v", err)
+		}
+		return ok
+	}
+
+	if !acquire("a") {
+		t.Fatal("expected a to acquire the free lease")
+	}
+	if acquire("b") {
+		t.Fatal("expected b to be refused while a holds the lease")
+	}
+
+	server.FastForward(50 * time.Second)
+	if !acquire("a") {
+		t.Fatal("expected a to renew its lease")
+	}
+	if ttl := server.TTL("whereitsat:lease:sync:tracked"); ttl != time.Minute {
+		t.Errorf("expected the renewal to reset the expiry, got %v", ttl)
+	}
+
+	// a stops renewing, as if it crashed
+	server.FastForward(time.Minute)
+	if !acquire("b") {
+		t.Fatal("expected b to take over the expired lease")
+	}
+
+	if err := store.Release(ctx, "sync:tracked", "a"); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if acquire("a") {
+		t.Fatal("expected releasing someone else's lease to be a no-op")
+	}
+	if err := store.Release(ctx, "sync:tracked", "b"); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if !acquire("a") {
+		t.Fatal("expected a to acquire the released lease")
+	}
+}
+
+func TestNewRedisStore_Unreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	if _, err := NewRedisStore(context.Background(), RedisConfig{Addr: addr}); err == nil {
+		t.Error("expected an error connecting to a closed server")
+	}
+}
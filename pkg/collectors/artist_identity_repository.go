@@ -0,0 +1,104 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ArtistIdentityRepository remembers the MusicBrainz IDs artist names
+// resolved to, whichever source resolved them
+type ArtistIdentityRepository struct {
+	db *timedDB
+}
+
+func NewArtistIdentityRepository(db *sql.DB) (*ArtistIdentityRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &ArtistIdentityRepository{db: newTimedDB(db, "artist_identities")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *ArtistIdentityRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS artist_identities (
+		name_key TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		mbid TEXT NOT NULL,
+		source TEXT NOT NULL,
+		resolved_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_artist_identities_mbid ON artist_identities(mbid);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// identityKey is what names are matched by
+func identityKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func (r *ArtistIdentityRepository) GetIdentity(ctx context.Context, name string) (*domain.ArtistIdentity, error) {
+	query := `
+	SELECT name, mbid, source, resolved_at
+	FROM artist_identities
+	WHERE name_key = ?
+	`
+
+	var identity domain.ArtistIdentity
+	err := r.db.QueryRowContext(ctx, query, identityKey(name)).Scan(
+		&identity.Name, &identity.MBID, &identity.Source, &identity.ResolvedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrIdentityNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist identity: %w", err)
+	}
+
+	return &identity, nil
+}
+
+// SaveIdentity records what the name resolved to. A name that matched
+// nothing doesn't replace an MBID another source found for it.
+func (r *ArtistIdentityRepository) SaveIdentity(ctx context.Context, identity *domain.ArtistIdentity) error {
+	if identity == nil || identityKey(identity.Name) == "" || identity.Source == "" {
+		return fmt.Errorf("artist identity name and source are required")
+	}
+	if identity.ResolvedAt.IsZero() {
+		identity.ResolvedAt = time.Now()
+	}
+
+	query := `
+	INSERT INTO artist_identities (name_key, name, mbid, source, resolved_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(name_key) DO UPDATE SET
+		name = excluded.name,
+		mbid = excluded.mbid,
+		source = excluded.source,
+		resolved_at = excluded.resolved_at
+	WHERE excluded.mbid != '' OR artist_identities.mbid = ''
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		identityKey(identity.Name), strings.TrimSpace(identity.Name), identity.MBID, identity.Source, identity.ResolvedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save artist identity: %w", err)
+	}
+
+	return nil
+}
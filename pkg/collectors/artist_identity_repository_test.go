@@ -0,0 +1,55 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestArtistIdentityRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewArtistIdentityRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := repo.GetIdentity(ctx, "Radiohead"); !errors.Is(err, domain.ErrIdentityNotFound) {
+		t.Fatalf("expected ErrIdentityNotFound, got %v", err)
+	}
+
+	radiohead := "a74b1b7f-71a5-4011-9441-d0b5e4122711"
+	if err := repo.SaveIdentity(ctx, &domain.ArtistIdentity{Name: "Radiohead", MBID: radiohead, Source: "musicbrainz"}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	identity, err := repo.GetIdentity(ctx, " RADIOHEAD ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if identity.MBID != radiohead || identity.Source != "musicbrainz" || identity.ResolvedAt.IsZero() {
+		t.Errorf("unexpected identity %+v", identity)
+	}
+
+	// A source finding nothing leaves the MBID another one found
+	if err := repo.SaveIdentity(ctx, &domain.ArtistIdentity{Name: "radiohead", Source: "setlistfm"}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	if identity, _ := repo.GetIdentity(ctx, "Radiohead"); identity.MBID != radiohead {
+		t.Errorf("expected the MBID kept, got %+v", identity)
+	}
+
+	if err := repo.SaveIdentity(ctx, &domain.ArtistIdentity{Name: "Unknown Band", Source: "setlistfm"}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	if identity, err := repo.GetIdentity(ctx, "unknown band"); err != nil || identity.MBID != "" {
+		t.Errorf("expected the miss remembered, got %+v and %v", identity, err)
+	}
+
+	if err := repo.SaveIdentity(ctx, &domain.ArtistIdentity{Name: " ", Source: "setlistfm"}); err == nil {
+		t.Error("expected an error without a name")
+	}
+}
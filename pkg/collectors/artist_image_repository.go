@@ -0,0 +1,96 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ArtistImageRepository remembers the pictures found for artist names, and
+// the names nothing was found for
+type ArtistImageRepository struct {
+	db *timedDB
+}
+
+func NewArtistImageRepository(db *sql.DB) (*ArtistImageRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &ArtistImageRepository{db: newTimedDB(db, "artist_images")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *ArtistImageRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS artist_images (
+		name_key TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		image_url TEXT NOT NULL,
+		source TEXT NOT NULL,
+		checked_at TIMESTAMP NOT NULL
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *ArtistImageRepository) GetImage(ctx context.Context, name string) (*domain.ArtistImage, error) {
+	query := `
+	SELECT name, image_url, source, checked_at
+	FROM artist_images
+	WHERE name_key = ?
+	`
+
+	var image domain.ArtistImage
+	err := r.db.QueryRowContext(ctx, query, identityKey(name)).Scan(
+		&image.Name, &image.ImageURL, &image.Source, &image.CheckedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrImageNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist image: %w", err)
+	}
+
+	return &image, nil
+}
+
+// SaveImage records the picture the name resolved to, replacing the one
+// found before
+func (r *ArtistImageRepository) SaveImage(ctx context.Context, image *domain.ArtistImage) error {
+	if image == nil || identityKey(image.Name) == "" {
+		return fmt.Errorf("artist image name is required")
+	}
+	if image.CheckedAt.IsZero() {
+		image.CheckedAt = time.Now()
+	}
+
+	query := `
+	INSERT INTO artist_images (name_key, name, image_url, source, checked_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT(name_key) DO UPDATE SET
+		name = excluded.name,
+		image_url = excluded.image_url,
+		source = excluded.source,
+		checked_at = excluded.checked_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		identityKey(image.Name), strings.TrimSpace(image.Name), image.ImageURL, image.Source, image.CheckedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save artist image: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestArtistImageRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewArtistImageRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := repo.GetImage(ctx, "Radiohead"); !errors.Is(err, domain.ErrImageNotFound) {
+		t.Fatalf("expected ErrImageNotFound, got %v", err)
+	}
+
+	if err := repo.SaveImage(ctx, &domain.ArtistImage{Name: "Radiohead", ImageURL: "https://e-cdns-images.dzcdn.net/images/artist/1.jpg", Source: "deezer"}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	image, err := repo.GetImage(ctx, " RADIOHEAD ")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if image.Source != "deezer" || image.CheckedAt.IsZero() {
+		t.Errorf("unexpected image %+v", image)
+	}
+
+	// A later check replaces what was found, misses included
+	if err := repo.SaveImage(ctx, &domain.ArtistImage{Name: "radiohead"}); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	if image, _ := repo.GetImage(ctx, "Radiohead"); image.ImageURL != "" {
+		t.Errorf("expected the miss recorded, got %+v", image)
+	}
+
+	if err := repo.SaveImage(ctx, &domain.ArtistImage{Name: " "}); err == nil {
+		t.Error("expected an error without a name")
+	}
+}
@@ -0,0 +1,86 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ArtistProfileRepository stores enriched profiles as JSON documents. They
+// are only ever read whole, so there is nothing to gain from columns.
+type ArtistProfileRepository struct {
+	db *timedDB
+}
+
+func NewArtistProfileRepository(db *sql.DB) (*ArtistProfileRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &ArtistProfileRepository{db: newTimedDB(db, "artist_profiles")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *ArtistProfileRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS artist_profiles (
+		artist_id TEXT PRIMARY KEY,
+		profile TEXT NOT NULL,
+		fetched_at TIMESTAMP NOT NULL
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// Save replaces the artist's stored profile
+func (r *ArtistProfileRepository) Save(ctx context.Context, profile *domain.ArtistProfile) error {
+	if profile == nil || profile.Artist.ID == "" {
+		return fmt.Errorf("profile artist ID is required")
+	}
+
+	document, err := json.Marshal(profile)
+	if err != nil {
+		return fmt.Errorf("failed to encode artist profile: %w", err)
+	}
+
+	query := `
+	INSERT INTO artist_profiles (artist_id, profile, fetched_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(artist_id) DO UPDATE SET
+		profile = excluded.profile,
+		fetched_at = excluded.fetched_at
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, profile.Artist.ID, string(document), profile.FetchedAt); err != nil {
+		return fmt.Errorf("failed to save artist profile: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ArtistProfileRepository) Get(ctx context.Context, artistID string) (*domain.ArtistProfile, error) {
+	var document string
+	err := r.db.QueryRowContext(ctx, `SELECT profile FROM artist_profiles WHERE artist_id = ?`, artistID).Scan(&document)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrProfileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get artist profile: %w", err)
+	}
+
+	var profile domain.ArtistProfile
+	if err := json.Unmarshal([]byte(document), &profile); err != nil {
+		return nil, fmt.Errorf("failed to decode artist profile: %w", err)
+	}
+
+	return &profile, nil
+}
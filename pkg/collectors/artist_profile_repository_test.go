@@ -0,0 +1,68 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewArtistProfileRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewArtistProfileRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestArtistProfileRepository_SaveAndGet(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewArtistProfileRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	fetchedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	if _, err := repo.Get(ctx, "artist-1"); !errors.Is(err, domain.ErrProfileNotFound) {
+		t.Fatalf("expected ErrProfileNotFound, got %v", err)
+	}
+
+	profile := &domain.ArtistProfile{
+		Artist:    domain.Artist{ID: "artist-1", Name: "Radiohead"},
+		Albums:    []domain.Album{{ID: "1", Title: "OK Computer", Source: "deezer"}},
+		Releases:  []domain.Release{{ID: "mb-1", Title: "OK Computer", Date: "1997-05-21", Source: "musicbrainz"}},
+		Sources:   []string{"deezer", "musicbrainz"},
+		FetchedAt: fetchedAt,
+	}
+	if err := repo.Save(ctx, profile); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	found, err := repo.Get(ctx, "artist-1")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if found.Artist.Name != "Radiohead" || len(found.Albums) != 1 || len(found.Releases) != 1 || !found.FetchedAt.Equal(fetchedAt) {
+		t.Errorf("unexpected profile %+v", found)
+	}
+
+	profile.Albums = nil
+	profile.FetchedAt = fetchedAt.Add(time.Hour)
+	if err := repo.Save(ctx, profile); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if found, _ := repo.Get(ctx, "artist-1"); len(found.Albums) != 0 || !found.FetchedAt.Equal(profile.FetchedAt) {
+		t.Errorf("expected the profile to be replaced, got %+v", found)
+	}
+
+	if err := repo.Save(ctx, &domain.ArtistProfile{}); err == nil {
+		t.Error("expected an error for a profile without an artist ID")
+	}
+}
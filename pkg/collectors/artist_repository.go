@@ -12,7 +12,7 @@ import (
 )
 
 type ArtistRepository struct {
-	db *sql.DB
+	db *timedDB
 }
 
 func NewArtistRepository(db *sql.DB) (*ArtistRepository, error) {
@@ -20,7 +20,7 @@ func NewArtistRepository(db *sql.DB) (*ArtistRepository, error) {
 		return nil, fmt.Errorf("database connection is required")
 	}
 
-	repo := &ArtistRepository{db: db}
+	repo := &ArtistRepository{db: newTimedDB(db, "artists")}
 	if err := repo.createTables(); err != nil {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
@@ -35,6 +35,7 @@ func (r *ArtistRepository) createTables() error {
 		name TEXT NOT NULL,
 		spotify_id TEXT,
 		lastfm_id TEXT,
+		musicbrainz_id TEXT,
 		genres TEXT,
 		popularity INTEGER,
 		image_url TEXT,
@@ -47,7 +48,16 @@ func (r *ArtistRepository) createTables() error {
 	CREATE INDEX IF NOT EXISTS idx_artists_name ON artists(name);
 	`
 
-	_, err := r.db.Exec(query)
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+
+	// Tables created before MusicBrainz IDs were stored
+	if err := addMissingColumns(r.db.DB, "artists", []string{"musicbrainz_id"}); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_artists_musicbrainz_id ON artists(musicbrainz_id)`)
 	return err
 }
 
@@ -57,8 +67,8 @@ func (r *ArtistRepository) Create(ctx context.Context, artist *domain.Artist) er
 	}
 
 	query := `
-	INSERT INTO artists (id, name, spotify_id, lastfm_id, genres, popularity, image_url, created_at, updated_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO artists (id, name, spotify_id, lastfm_id, musicbrainz_id, genres, popularity, image_url, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	// Use pipe separator to avoid issues with commas in genre names
@@ -72,6 +82,7 @@ func (r *ArtistRepository) Create(ctx context.Context, artist *domain.Artist) er
 		artist.Name,
 		artist.ExternalIDs.SpotifyID,
 		artist.ExternalIDs.LastFMID,
+		artist.ExternalIDs.MusicBrainzID,
 		genres,
 		artist.Popularity,
 		artist.ImageURL,
@@ -91,7 +102,7 @@ func (r *ArtistRepository) Create(ctx context.Context, artist *domain.Artist) er
 
 func (r *ArtistRepository) GetByID(ctx context.Context, id string) (*domain.Artist, error) {
 	query := `
-	SELECT id, name, spotify_id, lastfm_id, genres, popularity, image_url, created_at, updated_at
+	SELECT id, name, spotify_id, lastfm_id, musicbrainz_id, genres, popularity, image_url, created_at, updated_at
 	FROM artists
 	WHERE id = ?
 	`
@@ -104,6 +115,7 @@ func (r *ArtistRepository) GetByID(ctx context.Context, id string) (*domain.Arti
 		&artist.Name,
 		&artist.ExternalIDs.SpotifyID,
 		&artist.ExternalIDs.LastFMID,
+		&artist.ExternalIDs.MusicBrainzID,
 		&genres,
 		&artist.Popularity,
 		&artist.ImageURL,
@@ -130,16 +142,22 @@ func (r *ArtistRepository) GetByExternalID(ctx context.Context, externalID strin
 	switch source {
 	case "spotify":
 		query = `
-		SELECT id, name, spotify_id, lastfm_id, genres, popularity, image_url, created_at, updated_at
+		SELECT id, name, spotify_id, lastfm_id, musicbrainz_id, genres, popularity, image_url, created_at, updated_at
 		FROM artists
 		WHERE spotify_id = ?
 		`
 	case "lastfm":
 		query = `
-		SELECT id, name, spotify_id, lastfm_id, genres, popularity, image_url, created_at, updated_at
+		SELECT id, name, spotify_id, lastfm_id, musicbrainz_id, genres, popularity, image_url, created_at, updated_at
 		FROM artists
 		WHERE lastfm_id = ?
 		`
+	case "musicbrainz":
+		query = `
+		SELECT id, name, spotify_id, lastfm_id, musicbrainz_id, genres, popularity, image_url, created_at, updated_at
+		FROM artists
+		WHERE musicbrainz_id = ?
+		`
 	default:
 		return nil, fmt.Errorf("invalid source: %s", source)
 	}
@@ -152,6 +170,7 @@ func (r *ArtistRepository) GetByExternalID(ctx context.Context, externalID strin
 		&artist.Name,
 		&artist.ExternalIDs.SpotifyID,
 		&artist.ExternalIDs.LastFMID,
+		&artist.ExternalIDs.MusicBrainzID,
 		&genres,
 		&artist.Popularity,
 		&artist.ImageURL,
@@ -182,7 +201,7 @@ func (r *ArtistRepository) Search(ctx context.Context, query string, limit int)
 	}
 
 	sqlQuery := `
-	SELECT id, name, spotify_id, lastfm_id, genres, popularity, image_url, created_at, updated_at
+	SELECT id, name, spotify_id, lastfm_id, musicbrainz_id, genres, popularity, image_url, created_at, updated_at
 	FROM artists
 	WHERE name LIKE ?
 	ORDER BY popularity DESC
@@ -205,6 +224,7 @@ func (r *ArtistRepository) Search(ctx context.Context, query string, limit int)
 			&artist.Name,
 			&artist.ExternalIDs.SpotifyID,
 			&artist.ExternalIDs.LastFMID,
+			&artist.ExternalIDs.MusicBrainzID,
 			&genres,
 			&artist.Popularity,
 			&artist.ImageURL,
@@ -229,6 +249,56 @@ func (r *ArtistRepository) Search(ctx context.Context, query string, limit int)
 	return artists, nil
 }
 
+// Each calls fn for every artist, most popular first, without loading them
+// all at once
+func (r *ArtistRepository) Each(ctx context.Context, fn func(domain.Artist) error) error {
+	query := `
+	SELECT id, name, spotify_id, lastfm_id, musicbrainz_id, genres, popularity, image_url, created_at, updated_at
+	FROM artists
+	ORDER BY popularity DESC, name ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("failed to query artists: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var artist domain.Artist
+		var genres sql.NullString
+
+		err := rows.Scan(
+			&artist.ID,
+			&artist.Name,
+			&artist.ExternalIDs.SpotifyID,
+			&artist.ExternalIDs.LastFMID,
+			&artist.ExternalIDs.MusicBrainzID,
+			&genres,
+			&artist.Popularity,
+			&artist.ImageURL,
+			&artist.CreatedAt,
+			&artist.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan artist: %w", err)
+		}
+
+		if genres.Valid && genres.String != "" {
+			artist.Genres = strings.Split(genres.String, "|")
+		}
+
+		if err := fn(artist); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate rows: %w", err)
+	}
+	return nil
+}
+
 func (r *ArtistRepository) Update(ctx context.Context, artist *domain.Artist) error {
 	if artist == nil {
 		return fmt.Errorf("artist cannot be nil")
@@ -236,7 +306,7 @@ func (r *ArtistRepository) Update(ctx context.Context, artist *domain.Artist) er
 
 	query := `
 	UPDATE artists
-	SET name = ?, spotify_id = ?, lastfm_id = ?, genres = ?, popularity = ?, image_url = ?, updated_at = ?
+	SET name = ?, spotify_id = ?, lastfm_id = ?, musicbrainz_id = ?, genres = ?, popularity = ?, image_url = ?, updated_at = ?
 	WHERE id = ?
 	`
 
@@ -248,6 +318,7 @@ func (r *ArtistRepository) Update(ctx context.Context, artist *domain.Artist) er
 		artist.Name,
 		artist.ExternalIDs.SpotifyID,
 		artist.ExternalIDs.LastFMID,
+		artist.ExternalIDs.MusicBrainzID,
 		genres,
 		artist.Popularity,
 		artist.ImageURL,
@@ -3,6 +3,7 @@ package collectors
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"os"
 	"testing"
 
@@ -274,6 +275,50 @@ func TestArtistRepository_Search(t *testing.T) {
 	})
 }
 
+func TestArtistRepository_Each(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewArtistRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	for _, a := range []*domain.Artist{
+		{ID: "1", Name: "Portishead", Popularity: 70},
+		{ID: "2", Name: "Radiohead", Popularity: 90, Genres: []string{"rock"}},
+		{ID: "3", Name: "Radio Moscow", Popularity: 50},
+	} {
+		if err := repo.Create(ctx, a); err != nil {
+			t.Fatalf("failed to create artist: %v", err)
+		}
+	}
+
+	var names []string
+	err = repo.Each(ctx, func(artist domain.Artist) error {
+		names = append(names, artist.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(names) != 3 || names[0] != "Radiohead" || names[2] != "Radio Moscow" {
+		t.Errorf("expected every artist most popular first, got %v", names)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = repo.Each(ctx, func(artist domain.Artist) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("expected fn's error to stop the iteration, got %v after %d calls", err, calls)
+	}
+}
+
 func TestArtistRepository_Update(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -381,3 +426,36 @@ func TestArtistRepository_Delete(t *testing.T) {
 		}
 	})
 }
+
+func TestArtistRepository_MusicBrainzID(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewArtistRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	artist := &domain.Artist{
+		ID:   "artist-mbid",
+		Name: "MBID Artist",
+		ExternalIDs: domain.ExternalIDs{
+			MusicBrainzID: "a74b1b7f-71a5-4011-9441-d0b5e4122711",
+		},
+	}
+	if err := repo.Create(ctx, artist); err != nil {
+		t.Fatalf("failed to create artist: %v", err)
+	}
+
+	found, err := repo.GetByExternalID(ctx, "a74b1b7f-71a5-4011-9441-d0b5e4122711", "musicbrainz")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if found.ID != "artist-mbid" {
+		t.Errorf("expected artist-mbid, got %s", found.ID)
+	}
+	if found.ExternalIDs.MusicBrainzID != artist.ExternalIDs.MusicBrainzID {
+		t.Errorf("expected MBID to round-trip, got %s", found.ExternalIDs.MusicBrainzID)
+	}
+}
@@ -0,0 +1,98 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// BackfillCheckpointRepository remembers how far each history backfill got
+type BackfillCheckpointRepository struct {
+	db *timedDB
+}
+
+func NewBackfillCheckpointRepository(db *sql.DB) (*BackfillCheckpointRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &BackfillCheckpointRepository{db: newTimedDB(db, "backfill_checkpoints")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *BackfillCheckpointRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS backfill_checkpoints (
+		artist TEXT NOT NULL,
+		source TEXT NOT NULL,
+		page INTEGER NOT NULL,
+		oldest_concert TIMESTAMP,
+		complete BOOLEAN NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (artist, source)
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *BackfillCheckpointRepository) Get(ctx context.Context, artist, source string) (*domain.BackfillCheckpoint, error) {
+	query := `
+	SELECT artist, source, page, oldest_concert, complete, updated_at
+	FROM backfill_checkpoints
+	WHERE artist = ? AND source = ?
+	`
+
+	var checkpoint domain.BackfillCheckpoint
+	var oldest sql.NullTime
+	err := r.db.QueryRowContext(ctx, query, artist, source).Scan(
+		&checkpoint.Artist, &checkpoint.Source, &checkpoint.Page, &oldest, &checkpoint.Complete, &checkpoint.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrCheckpointNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backfill checkpoint: %w", err)
+	}
+	if oldest.Valid {
+		checkpoint.OldestConcert = &oldest.Time
+	}
+
+	return &checkpoint, nil
+}
+
+func (r *BackfillCheckpointRepository) Save(ctx context.Context, checkpoint *domain.BackfillCheckpoint) error {
+	if checkpoint == nil || checkpoint.Artist == "" || checkpoint.Source == "" {
+		return fmt.Errorf("checkpoint artist and source are required")
+	}
+	if checkpoint.UpdatedAt.IsZero() {
+		checkpoint.UpdatedAt = time.Now()
+	}
+
+	query := `
+	INSERT INTO backfill_checkpoints (artist, source, page, oldest_concert, complete, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?)
+	ON CONFLICT(artist, source) DO UPDATE SET
+		page = excluded.page,
+		oldest_concert = excluded.oldest_concert,
+		complete = excluded.complete,
+		updated_at = excluded.updated_at
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		checkpoint.Artist, checkpoint.Source, checkpoint.Page, checkpoint.OldestConcert, checkpoint.Complete, checkpoint.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save backfill checkpoint: %w", err)
+	}
+
+	return nil
+}
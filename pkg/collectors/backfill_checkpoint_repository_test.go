@@ -0,0 +1,58 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewBackfillCheckpointRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewBackfillCheckpointRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestBackfillCheckpointRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewBackfillCheckpointRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	if _, err := repo.Get(ctx, "radiohead", "setlistfm"); !errors.Is(err, domain.ErrCheckpointNotFound) {
+		t.Fatalf("expected ErrCheckpointNotFound, got %v", err)
+	}
+
+	oldest := time.Date(2012, 7, 1, 0, 0, 0, 0, time.UTC)
+	checkpoint := &domain.BackfillCheckpoint{Artist: "radiohead", Source: "setlistfm", Page: 3, OldestConcert: &oldest}
+	if err := repo.Save(ctx, checkpoint); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	checkpoint.Page = 4
+	checkpoint.Complete = true
+	if err := repo.Save(ctx, checkpoint); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	got, err := repo.Get(ctx, "radiohead", "setlistfm")
+	if err != nil {
+		t.Fatalf("failed to get: %v", err)
+	}
+	if got.Page != 4 || !got.Complete || got.OldestConcert == nil || !got.OldestConcert.Equal(oldest) {
+		t.Errorf("expected the updated checkpoint, got %+v", got)
+	}
+
+	if err := repo.Save(ctx, &domain.BackfillCheckpoint{Artist: "radiohead"}); err == nil {
+		t.Error("expected error for a checkpoint without a source")
+	}
+}
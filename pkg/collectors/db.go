@@ -1,21 +1,213 @@
 package collectors
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "github.com/yair/where-its-at/pkg/collectors"
+
+const (
+	defaultBusyTimeout  = 5 * time.Second
+	defaultMaxOpenConns = 8
+)
+
+// SQLiteConfig opens a database. Zero values take the defaults.
+type SQLiteConfig struct {
+	Path         string
+	BusyTimeout  time.Duration // how long a write waits on another, 5 seconds by default
+	MaxOpenConns int           // 8 by default
+}
+
 func NewSQLiteDB(dataSourceName string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", dataSourceName)
+	return OpenSQLite(SQLiteConfig{Path: dataSourceName})
+}
+
+// OpenSQLite opens the database for concurrent searches and syncs. WAL lets
+// reads go on while a sync writes; transactions take the write lock when
+// they begin and, like every write, wait up to the busy timeout for it
+// rather than failing with "database is locked". Foreign keys are enforced
+// and the pool is bounded, every connection set up the same way.
+func OpenSQLite(config SQLiteConfig) (*sql.DB, error) {
+	if config.BusyTimeout <= 0 {
+		config.BusyTimeout = defaultBusyTimeout
+	}
+	if config.MaxOpenConns <= 0 {
+		config.MaxOpenConns = defaultMaxOpenConns
+	}
+
+	db, err := sql.Open("sqlite3", sqliteDSN(config.Path, config.BusyTimeout))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxOpenConns)
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	return db, nil
 }
+
+// sqliteDSN adds the connection settings to path, leaving any it sets itself
+func sqliteDSN(path string, busyTimeout time.Duration) string {
+	settings := []string{
+		"_journal_mode=WAL",
+		"_synchronous=NORMAL",
+		fmt.Sprintf("_busy_timeout=%d", busyTimeout.Milliseconds()),
+		"_foreign_keys=on",
+		"_txlock=immediate",
+	}
+
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	for _, setting := range settings {
+		name, _, _ := strings.Cut(setting, "=")
+		if strings.Contains(path, name+"=") {
+			continue
+		}
+		path += separator + setting
+		separator = "&"
+	}
+	return path
+}
+
+// QueryObserver is told how long each repository query took
+type QueryObserver interface {
+	ObserveQuery(repository, operation string, duration time.Duration, err error)
+}
+
+var queryObserver QueryObserver
+
+// SetQueryObserver reports every repository query to observer. It must be
+// called before the repositories are used.
+func SetQueryObserver(observer QueryObserver) {
+	queryObserver = observer
+}
+
+// timedDB times and traces the queries a repository runs and hands them to
+// the query observer. Schema setup goes straight to the embedded *sql.DB.
+type timedDB struct {
+	*sql.DB
+	repository string
+}
+
+func newTimedDB(db *sql.DB, repository string) *timedDB {
+	return &timedDB{DB: db, repository: repository}
+}
+
+func (t *timedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span, start := t.start(ctx, "exec")
+	result, err := t.DB.ExecContext(ctx, query, args...)
+	t.observe(span, "exec", start, err)
+	return result, err
+}
+
+func (t *timedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span, start := t.start(ctx, "query")
+	rows, err := t.DB.QueryContext(ctx, query, args...)
+	t.observe(span, "query", start, err)
+	return rows, err
+}
+
+func (t *timedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span, start := t.start(ctx, "query_row")
+	row := t.DB.QueryRowContext(ctx, query, args...)
+	t.observe(span, "query_row", start, row.Err())
+	return row
+}
+
+func (t *timedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	ctx, span, start := t.start(ctx, "begin")
+	tx, err := t.DB.BeginTx(ctx, opts)
+	t.observe(span, "begin", start, err)
+	return tx, err
+}
+
+func (t *timedDB) start(ctx context.Context, operation string) (context.Context, trace.Span, time.Time) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "sqlite "+t.repository+"."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "sqlite"),
+			attribute.String("db.operation", operation),
+			attribute.String("repository", t.repository),
+		),
+	)
+	return ctx, span, time.Now()
+}
+
+func (t *timedDB) observe(span trace.Span, operation string, start time.Time, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+
+	if queryObserver != nil {
+		queryObserver.ObserveQuery(t.repository, operation, time.Since(start), err)
+	}
+}
+
+// addMissingColumns adds TEXT columns that were introduced after a table was
+// first created. Existing rows get an empty string so they scan into strings.
+func addMissingColumns(db *sql.DB, table string, columns []string) error {
+	return addMissingTypedColumns(db, table, columns, "TEXT DEFAULT ''")
+}
+
+// addMissingIntegerColumns is addMissingColumns for INTEGER columns.
+// Existing rows get 0 so they scan into ints.
+func addMissingIntegerColumns(db *sql.DB, table string, columns []string) error {
+	return addMissingTypedColumns(db, table, columns, "INTEGER NOT NULL DEFAULT 0")
+}
+
+func addMissingTypedColumns(db *sql.DB, table string, columns []string, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read %s schema: %w", table, err)
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan %s schema: %w", table, err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if existing[column] {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+		}
+	}
+
+	return nil
+}
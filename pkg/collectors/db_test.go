@@ -1,8 +1,10 @@
 package collectors
 
 import (
+	"context"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestNewSQLiteDB(t *testing.T) {
@@ -29,6 +31,62 @@ func TestNewSQLiteDB(t *testing.T) {
 		}
 	})
 
+	t.Run("connection settings", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		var journalMode string
+		var busyTimeout, foreignKeys int
+		db.QueryRow(`PRAGMA journal_mode`).Scan(&journalMode)
+		db.QueryRow(`PRAGMA busy_timeout`).Scan(&busyTimeout)
+		db.QueryRow(`PRAGMA foreign_keys`).Scan(&foreignKeys)
+		if journalMode != "wal" || busyTimeout != 5000 || foreignKeys != 1 {
+			t.Errorf("expected WAL, a 5s busy timeout and foreign keys, got %s, %d, %d", journalMode, busyTimeout, foreignKeys)
+		}
+		if max := db.Stats().MaxOpenConnections; max != defaultMaxOpenConns {
+			t.Errorf("expected a pool of %d, got %d", defaultMaxOpenConns, max)
+		}
+
+		if dsn := sqliteDSN("events.db?_busy_timeout=100", time.Second); dsn != "events.db?_busy_timeout=100&_journal_mode=WAL&_synchronous=NORMAL&_foreign_keys=on&_txlock=immediate" {
+			t.Errorf("expected the path's own busy timeout kept, got %s", dsn)
+		}
+	})
+
+	t.Run("concurrent writers wait their turn", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		if _, err := db.Exec(`CREATE TABLE counts (n INTEGER)`); err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+
+		errs := make(chan error, 16)
+		for i := 0; i < cap(errs); i++ {
+			go func() {
+				tx, err := db.Begin()
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer tx.Rollback()
+				var n int
+				tx.QueryRow(`SELECT COUNT(*) FROM counts`).Scan(&n)
+				// Others read meanwhile, as a search would
+				time.Sleep(5 * time.Millisecond)
+				if _, err := tx.Exec(`INSERT INTO counts (n) VALUES (?)`, n); err != nil {
+					errs <- err
+					return
+				}
+				errs <- tx.Commit()
+			}()
+		}
+		for i := 0; i < cap(errs); i++ {
+			if err := <-errs; err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+		}
+	})
+
 	t.Run("invalid path", func(t *testing.T) {
 		_, err := NewSQLiteDB("/invalid/path/to/database.db")
 		if err == nil {
@@ -36,3 +94,33 @@ func TestNewSQLiteDB(t *testing.T) {
 		}
 	})
 }
+
+type recordingQueryObserver struct {
+	observed []string
+}
+
+func (r *recordingQueryObserver) ObserveQuery(repository, operation string, duration time.Duration, err error) {
+	r.observed = append(r.observed, repository+"."+operation)
+}
+
+func TestSetQueryObserver(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewQuotaRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	observer := &recordingQueryObserver{}
+	SetQueryObserver(observer)
+	defer SetQueryObserver(nil)
+
+	ctx := context.Background()
+	repo.RecordRequest(ctx, "songkick", time.Now(), 1)
+	repo.GetRequestsSince(ctx, "songkick", time.Now().Add(-time.Hour))
+
+	if len(observer.observed) != 2 || observer.observed[0] != "quotas.exec" || observer.observed[1] != "quotas.query" {
+		t.Errorf("expected an exec then a query, got %v", observer.observed)
+	}
+}
@@ -0,0 +1,169 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// archiveColumns are the event columns copied into events_archive
+const archiveColumns = `id, artist_id, artist_name, title, datetime, timezone, date_confidence,
+	venue_id, venue_name, venue_city, venue_region, venue_country,
+	venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
+	on_sale_date, bandsintown_id, ticketmaster_id,
+	songkick_id, eventbrite_id, setlistfm_id,
+	age_restriction, accessibility,
+	created_at, updated_at, cached_until`
+
+// Events leave the hot table for events_archive rather than being dropped,
+// so history and stats can still find them. A later write of the same
+// event takes it back out.
+const (
+	archiveEventsQuery       = `INSERT OR REPLACE INTO events_archive (` + archiveColumns + `, archived_at) SELECT ` + archiveColumns + `, ? FROM events`
+	unarchiveEventQuery      = `DELETE FROM events_archive WHERE id = ?`
+	pruneArchiveQuery        = `DELETE FROM events_archive WHERE archived_at < ?`
+	createArchiveTablesQuery = `
+	CREATE TABLE IF NOT EXISTS events_archive (
+		id TEXT PRIMARY KEY,
+		artist_id TEXT,
+		artist_name TEXT NOT NULL,
+		title TEXT,
+		datetime TIMESTAMP,
+		timezone TEXT,
+		date_confidence TEXT,
+		venue_id TEXT,
+		venue_name TEXT,
+		venue_city TEXT,
+		venue_region TEXT,
+		venue_country TEXT,
+		venue_latitude REAL,
+		venue_longitude REAL,
+		venue_capacity INTEGER,
+		ticket_url TEXT,
+		ticket_status TEXT,
+		status TEXT,
+		on_sale_date TIMESTAMP,
+		bandsintown_id TEXT,
+		ticketmaster_id TEXT,
+		songkick_id TEXT,
+		eventbrite_id TEXT,
+		setlistfm_id TEXT,
+		age_restriction TEXT,
+		accessibility TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		cached_until TIMESTAMP NOT NULL,
+		archived_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_events_archive_artist_name ON events_archive(artist_name COLLATE NOCASE);
+	CREATE INDEX IF NOT EXISTS idx_events_archive_datetime ON events_archive(datetime);
+	CREATE INDEX IF NOT EXISTS idx_events_archive_archived_at ON events_archive(archived_at);
+	`
+)
+
+// ListArchived returns archived events, latest date first. The filter
+// matches the way it does for Each.
+func (r *EventRepository) ListArchived(ctx context.Context, filter domain.EventFilter, limit int) ([]domain.Event, error) {
+	query := `SELECT ` + archiveColumns + `, archived_at FROM events_archive WHERE 1 = 1`
+	args := []interface{}{}
+
+	if artist := strings.TrimSpace(filter.Artist); artist != "" {
+		query += " AND (artist_id = ? OR artist_name = ? COLLATE NOCASE)"
+		args = append(args, artist, artist)
+	}
+	if city := strings.TrimSpace(filter.City); city != "" {
+		condition, cityArgs := cityMatch("venue_city", city)
+		query += " AND " + condition
+		args = append(args, cityArgs...)
+	}
+	if filter.From != nil {
+		query += " AND datetime >= ?"
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		query += " AND datetime <= ?"
+		args = append(args, filter.To.UTC())
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	query += " ORDER BY datetime DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archived events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.Event
+	for rows.Next() {
+		event, err := r.scanArchivedEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list archived events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *EventRepository) scanArchivedEvent(rows *sql.Rows) (*domain.Event, error) {
+	var event domain.Event
+	var onSaleDate sql.NullTime
+	var archivedAt time.Time
+
+	err := rows.Scan(
+		&event.ID,
+		&event.ArtistID,
+		&event.ArtistName,
+		&event.Title,
+		&event.DateTime,
+		&event.Timezone,
+		&event.DateConfidence,
+		&event.Venue.ID,
+		&event.Venue.Name,
+		&event.Venue.City,
+		&event.Venue.Region,
+		&event.Venue.Country,
+		&event.Venue.Latitude,
+		&event.Venue.Longitude,
+		&event.Venue.Capacity,
+		&event.TicketURL,
+		&event.TicketStatus,
+		&event.Status,
+		&onSaleDate,
+		&event.ExternalIDs.BandsintownID,
+		&event.ExternalIDs.TicketmasterID,
+		&event.ExternalIDs.SongkickID,
+		&event.ExternalIDs.EventbriteID,
+		&event.ExternalIDs.SetlistFMID,
+		&event.AgeRestriction,
+		&event.Accessibility,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+		&event.CachedUntil,
+		&archivedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan archived event: %w", err)
+	}
+
+	event.DateTime = event.LocalDateTime()
+	event.Venue.NormalizeCountry()
+	if onSaleDate.Valid {
+		event.OnSaleDate = &onSaleDate.Time
+	}
+	event.ArchivedAt = &archivedAt
+
+	return &event, nil
+}
@@ -0,0 +1,169 @@
+package collectors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// Every write that changes what we'd serve for an event appends to
+// event_feed, so mirrors can follow /api/changes from a cursor instead of
+// downloading everything again. seq is the cursor; AUTOINCREMENT keeps it
+// from ever being reused once old entries are pruned.
+const (
+	createEventFeedTableQuery = `
+	CREATE TABLE IF NOT EXISTS event_feed (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		event_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		changed_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_event_feed_event_id ON event_feed(event_id);
+	CREATE INDEX IF NOT EXISTS idx_event_feed_changed_at ON event_feed(changed_at);
+	`
+	recordFeedQuery        = `INSERT INTO event_feed (event_id, kind, changed_at) VALUES (?, ?, ?)`
+	recordFeedDeletesQuery = `INSERT INTO event_feed (event_id, kind, changed_at) SELECT id, '` + string(domain.FeedDelete) + `', ? FROM events`
+	pruneFeedQuery         = `DELETE FROM event_feed WHERE changed_at < ?`
+	// firstFeedCursorQuery is the oldest cursor still in the feed, or the
+	// next one to be handed out when it's empty
+	firstFeedCursorQuery = `
+	SELECT COALESCE(MIN(seq), (SELECT seq FROM sqlite_sequence WHERE name = 'event_feed') + 1, 1)
+	FROM event_feed
+	`
+)
+
+// eventFingerprint hashes what we serve for an event, leaving out the
+// bookkeeping times that change on every write, so a sync that finds the
+// event as it was records nothing
+func eventFingerprint(event domain.Event) string {
+	event.CreatedAt = time.Time{}
+	event.UpdatedAt = time.Time{}
+	event.CachedUntil = time.Time{}
+	event.DiscoveredAt = nil
+	event.ArchivedAt = nil
+	event.DateTime = event.DateTime.UTC()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// feedKind is the feed entry for an event written over its stored state
+func feedKind(event, stored domain.Event) domain.FeedChangeKind {
+	if event.Status == domain.EventCancelled && stored.Status != domain.EventCancelled {
+		return domain.FeedCancel
+	}
+	return domain.FeedUpdate
+}
+
+// ListFeed returns up to limit feed entries after the cursor since, oldest
+// first, each with its event as stored now unless it's gone. A cursor
+// older than the pruned feed returns domain.ErrCursorExpired, since the
+// changes it missed can't be replayed.
+func (r *EventRepository) ListFeed(ctx context.Context, since int64, limit int) ([]domain.EventFeedEntry, error) {
+	if since < 0 {
+		return nil, domain.ErrInvalidRequest
+	}
+	if since > 0 {
+		var first int64
+		if err := r.db.QueryRowContext(ctx, firstFeedCursorQuery).Scan(&first); err != nil {
+			return nil, fmt.Errorf("failed to get first feed cursor: %w", err)
+		}
+		if since+1 < first {
+			return nil, domain.ErrCursorExpired
+		}
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT seq, event_id, kind, changed_at
+		FROM event_feed
+		WHERE seq > ?
+		ORDER BY seq ASC
+		LIMIT ?
+	`, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feed: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []domain.EventFeedEntry
+	for rows.Next() {
+		var entry domain.EventFeedEntry
+		if err := rows.Scan(&entry.Cursor, &entry.EventID, &entry.Kind, &entry.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feed entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list feed: %w", err)
+	}
+	rows.Close()
+
+	if len(entries) == 0 {
+		return entries, nil
+	}
+	events, err := r.feedEvents(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		if event, ok := events[entries[i].EventID]; ok && entries[i].Kind != domain.FeedDelete {
+			entries[i].Event = &event
+		}
+	}
+	return entries, nil
+}
+
+// feedEvents loads the stored events the entries point at, by ID
+func (r *EventRepository) feedEvents(ctx context.Context, entries []domain.EventFeedEntry) (map[string]domain.Event, error) {
+	seen := make(map[string]bool, len(entries))
+	placeholders := make([]string, 0, len(entries))
+	args := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		if !seen[entry.EventID] {
+			seen[entry.EventID] = true
+			placeholders = append(placeholders, "?")
+			args = append(args, entry.EventID)
+		}
+	}
+
+	query := `
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
+		venue_id, venue_name, venue_city, venue_region, venue_country,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
+		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until
+	FROM events
+	WHERE id IN (` + strings.Join(placeholders, ", ") + `)
+	`
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feed events: %w", err)
+	}
+	defer rows.Close()
+
+	events, err := r.scanEvents(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]domain.Event, len(events))
+	for _, event := range events {
+		byID[event.ID] = event
+	}
+	return byID, nil
+}
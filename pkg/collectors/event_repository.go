@@ -4,14 +4,61 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"math"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
 )
 
+// recordDiscoveryQuery notes when an event was first stored; later writes of
+// the same event keep the original time
+const recordDiscoveryQuery = `INSERT OR IGNORE INTO event_discoveries (event_id, discovered_at) VALUES (?, ?)`
+
+// recordPriceQuery adds one price range to an event's history; every range
+// written by the same sync shares recorded_at
+const recordPriceQuery = `
+	INSERT INTO event_prices (event_id, recorded_at, price_type, min_price, max_price, currency)
+	VALUES (?, ?, ?, ?, ?, ?)
+`
+
+// Lineups are rewritten whole on every write of the event
+const (
+	deleteLineupQuery = `DELETE FROM event_artists WHERE event_id = ?`
+	insertLineupQuery = `
+	INSERT INTO event_artists (event_id, billing, artist_id, artist_name, headliner)
+	VALUES (?, ?, ?, ?, ?)
+`
+)
+
+// Ticket offers are rewritten whole too, as a re-sync may drop a vendor
+const (
+	deleteTicketOffersQuery = `DELETE FROM event_ticket_offers WHERE event_id = ?`
+	insertTicketOfferQuery  = `
+	INSERT INTO event_ticket_offers (event_id, position, source, url, status, min_price, max_price, currency)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+)
+
+// So are the sources that listed the event, and the ID each gave it
+const (
+	deleteSourcesQuery = `DELETE FROM event_sources WHERE event_id = ?`
+	insertSourceQuery  = `
+	INSERT INTO event_sources (event_id, position, source, source_id)
+	VALUES (?, ?, ?, ?)
+`
+)
+
+// A re-synced event is compared with its stored state; cancellations,
+// postponements and date changes are kept in event_changes
+const (
+	storedStateQuery  = `SELECT status, datetime, venue_id, venue_name, venue_city, created_at, COALESCE(fingerprint, '') FROM events WHERE id = ?`
+	recordChangeQuery = `INSERT INTO event_changes (event_id, status, previous_datetime, changed_at) VALUES (?, ?, ?, ?)`
+)
+
 type EventRepository struct {
-	db *sql.DB
+	db *timedDB
 }
 
 func NewEventRepository(db *sql.DB) (*EventRepository, error) {
@@ -19,7 +66,7 @@ func NewEventRepository(db *sql.DB) (*EventRepository, error) {
 		return nil, fmt.Errorf("database connection is required")
 	}
 
-	repo := &EventRepository{db: db}
+	repo := &EventRepository{db: newTimedDB(db, "events")}
 	if err := repo.createTables(); err != nil {
 		return nil, fmt.Errorf("failed to create tables: %w", err)
 	}
@@ -35,6 +82,8 @@ func (r *EventRepository) createTables() error {
 		artist_name TEXT NOT NULL,
 		title TEXT,
 		datetime TIMESTAMP NOT NULL,
+		timezone TEXT,
+		date_confidence TEXT,
 		venue_id TEXT,
 		venue_name TEXT NOT NULL,
 		venue_city TEXT NOT NULL,
@@ -42,11 +91,18 @@ func (r *EventRepository) createTables() error {
 		venue_country TEXT NOT NULL,
 		venue_latitude REAL,
 		venue_longitude REAL,
+		venue_capacity INTEGER NOT NULL DEFAULT 0,
 		ticket_url TEXT,
 		ticket_status TEXT,
+		status TEXT,
 		on_sale_date TIMESTAMP,
 		bandsintown_id TEXT,
 		ticketmaster_id TEXT,
+		songkick_id TEXT,
+		eventbrite_id TEXT,
+		setlistfm_id TEXT,
+		age_restriction TEXT,
+		accessibility TEXT,
 		created_at TIMESTAMP NOT NULL,
 		updated_at TIMESTAMP NOT NULL,
 		cached_until TIMESTAMP NOT NULL
@@ -55,11 +111,100 @@ func (r *EventRepository) createTables() error {
 	CREATE INDEX IF NOT EXISTS idx_events_artist_id ON events(artist_id);
 	CREATE INDEX IF NOT EXISTS idx_events_datetime ON events(datetime);
 	CREATE INDEX IF NOT EXISTS idx_events_bandsintown_id ON events(bandsintown_id);
+	CREATE INDEX IF NOT EXISTS idx_events_artist_name ON events(artist_name COLLATE NOCASE);
 	CREATE INDEX IF NOT EXISTS idx_events_cached_until ON events(cached_until);
+	CREATE INDEX IF NOT EXISTS idx_events_on_sale_date ON events(on_sale_date);
 	CREATE INDEX IF NOT EXISTS idx_events_location ON events(venue_latitude, venue_longitude);
+
+	-- Kept apart from events so expiring a cached event doesn't forget when
+	-- it was first seen
+	CREATE TABLE IF NOT EXISTS event_discoveries (
+		event_id TEXT PRIMARY KEY,
+		discovered_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_event_discoveries_discovered_at ON event_discoveries(discovered_at);
+
+	-- Price ranges as seen on every sync, so changes between syncs show up
+	CREATE TABLE IF NOT EXISTS event_prices (
+		event_id TEXT NOT NULL,
+		recorded_at TIMESTAMP NOT NULL,
+		price_type TEXT,
+		min_price REAL NOT NULL,
+		max_price REAL NOT NULL,
+		currency TEXT
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_event_prices_event_id ON event_prices(event_id, recorded_at);
+
+	-- Everyone on an event's bill, so support acts can be searched for
+	CREATE TABLE IF NOT EXISTS event_artists (
+		event_id TEXT NOT NULL,
+		billing INTEGER NOT NULL,
+		artist_id TEXT,
+		artist_name TEXT NOT NULL,
+		headliner BOOLEAN NOT NULL DEFAULT 0,
+		PRIMARY KEY (event_id, billing)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_event_artists_artist_name ON event_artists(artist_name COLLATE NOCASE);
+
+	-- Every source's ticket link for an event, in the order they were merged
+	CREATE TABLE IF NOT EXISTS event_ticket_offers (
+		event_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		url TEXT NOT NULL,
+		status TEXT,
+		min_price REAL,
+		max_price REAL,
+		currency TEXT,
+		PRIMARY KEY (event_id, position)
+	);
+
+	-- Where an event came from, in the order its copies were merged
+	CREATE TABLE IF NOT EXISTS event_sources (
+		event_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		source TEXT NOT NULL,
+		source_id TEXT,
+		PRIMARY KEY (event_id, position)
+	);
+
+	CREATE TABLE IF NOT EXISTS event_changes (
+		event_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		previous_datetime TIMESTAMP NOT NULL,
+		changed_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_event_changes_changed_at ON event_changes(changed_at);
 	`
 
-	_, err := r.db.Exec(query)
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(createArchiveTablesQuery); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(createVenuesTableQuery); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(createEventFeedTableQuery); err != nil {
+		return err
+	}
+
+	// Tables created before per-source external IDs were stored
+	if err := addMissingColumns(r.db.DB, "events", []string{"songkick_id", "eventbrite_id", "setlistfm_id", "status", "timezone", "date_confidence", "venue_slug", "age_restriction", "accessibility", "fingerprint"}); err != nil {
+		return err
+	}
+	if err := addMissingIntegerColumns(r.db.DB, "events", []string{"venue_capacity"}); err != nil {
+		return err
+	}
+	if err := addMissingColumns(r.db.DB, "events_archive", []string{"age_restriction", "accessibility"}); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(`CREATE INDEX IF NOT EXISTS idx_events_venue_slug ON events(venue_slug, datetime)`)
 	return err
 }
 
@@ -70,12 +215,14 @@ func (r *EventRepository) Create(ctx context.Context, event *domain.Event) error
 
 	query := `
 	INSERT INTO events (
-		id, artist_id, artist_name, title, datetime,
+		id, artist_id, artist_name, title, datetime, timezone, date_confidence,
 		venue_id, venue_name, venue_city, venue_region, venue_country,
-		venue_latitude, venue_longitude, ticket_url, ticket_status,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
 		on_sale_date, bandsintown_id, ticketmaster_id,
-		created_at, updated_at, cached_until
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until, venue_slug, fingerprint
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ` + mergedVenueSlug + `, ?)
 	`
 
 	now := time.Now()
@@ -92,7 +239,9 @@ func (r *EventRepository) Create(ctx context.Context, event *domain.Event) error
 		event.ArtistID,
 		event.ArtistName,
 		event.Title,
-		event.DateTime,
+		event.DateTime.UTC(),
+		event.Timezone,
+		event.DateConfidence,
 		event.Venue.ID,
 		event.Venue.Name,
 		event.Venue.City,
@@ -100,14 +249,23 @@ func (r *EventRepository) Create(ctx context.Context, event *domain.Event) error
 		event.Venue.Country,
 		event.Venue.Latitude,
 		event.Venue.Longitude,
+		event.Venue.Capacity,
 		event.TicketURL,
 		event.TicketStatus,
+		event.Status,
 		onSaleDate,
 		event.ExternalIDs.BandsintownID,
 		event.ExternalIDs.TicketmasterID,
+		event.ExternalIDs.SongkickID,
+		event.ExternalIDs.EventbriteID,
+		event.ExternalIDs.SetlistFMID,
+		event.AgeRestriction,
+		event.Accessibility,
 		event.CreatedAt,
 		event.UpdatedAt,
 		event.CachedUntil,
+		event.Venue.Slug(), event.Venue.Slug(),
+		eventFingerprint(*event),
 	)
 
 	if err != nil {
@@ -117,34 +275,153 @@ func (r *EventRepository) Create(ctx context.Context, event *domain.Event) error
 		return fmt.Errorf("failed to create event: %w", err)
 	}
 
-	return nil
+	if _, err := r.db.ExecContext(ctx, recordDiscoveryQuery, event.ID, now); err != nil {
+		return fmt.Errorf("failed to record event discovery: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, recordFeedQuery, event.ID, domain.FeedCreate, now); err != nil {
+		return fmt.Errorf("failed to record feed entry: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, unarchiveEventQuery, event.ID); err != nil {
+		return fmt.Errorf("failed to unarchive event: %w", err)
+	}
+	if slug := event.Venue.Slug(); slug != "" {
+		if _, err := r.db.ExecContext(ctx, saveVenueQuery, venueArgs(slug, event.Venue, now)...); err != nil {
+			return fmt.Errorf("failed to save venue: %w", err)
+		}
+	}
+
+	for _, price := range event.PriceRanges {
+		if _, err := r.db.ExecContext(ctx, recordPriceQuery, event.ID, now, price.Type, price.Min, price.Max, price.Currency); err != nil {
+			return fmt.Errorf("failed to record event price: %w", err)
+		}
+	}
+
+	return r.saveDetails(ctx, event)
 }
 
-func (r *EventRepository) CreateBatch(ctx context.Context, events []domain.Event) error {
+// CreateBatch upserts the events in one transaction. A stored event keeps
+// its created_at; the returned change set lists the events that weren't
+// stored yet and those that moved to another date or venue.
+func (r *EventRepository) CreateBatch(ctx context.Context, events []domain.Event) (*domain.EventChangeSet, error) {
 	if len(events) == 0 {
-		return nil
+		return &domain.EventChangeSet{}, nil
 	}
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
 	stmt, err := tx.PrepareContext(ctx, `
-		INSERT OR REPLACE INTO events (
-			id, artist_id, artist_name, title, datetime,
+		INSERT INTO events (
+			id, artist_id, artist_name, title, datetime, timezone, date_confidence,
 			venue_id, venue_name, venue_city, venue_region, venue_country,
-			venue_latitude, venue_longitude, ticket_url, ticket_status,
+			venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
 			on_sale_date, bandsintown_id, ticketmaster_id,
-			created_at, updated_at, cached_until
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			songkick_id, eventbrite_id, setlistfm_id,
+			age_restriction, accessibility,
+			created_at, updated_at, cached_until, venue_slug, fingerprint
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, `+mergedVenueSlug+`, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			artist_id = excluded.artist_id, artist_name = excluded.artist_name, title = excluded.title,
+			datetime = excluded.datetime, timezone = excluded.timezone, date_confidence = excluded.date_confidence,
+			venue_id = excluded.venue_id, venue_name = excluded.venue_name, venue_city = excluded.venue_city,
+			venue_region = excluded.venue_region, venue_country = excluded.venue_country,
+			venue_latitude = excluded.venue_latitude, venue_longitude = excluded.venue_longitude,
+			venue_capacity = excluded.venue_capacity, ticket_url = excluded.ticket_url,
+			ticket_status = excluded.ticket_status, status = excluded.status,
+			on_sale_date = excluded.on_sale_date, bandsintown_id = excluded.bandsintown_id,
+			ticketmaster_id = excluded.ticketmaster_id, songkick_id = excluded.songkick_id,
+			eventbrite_id = excluded.eventbrite_id, setlistfm_id = excluded.setlistfm_id,
+			age_restriction = excluded.age_restriction, accessibility = excluded.accessibility,
+			updated_at = excluded.updated_at, cached_until = excluded.cached_until,
+			venue_slug = excluded.venue_slug, fingerprint = excluded.fingerprint
 	`)
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
+	discoveryStmt, err := tx.PrepareContext(ctx, recordDiscoveryQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer discoveryStmt.Close()
+
+	priceStmt, err := tx.PrepareContext(ctx, recordPriceQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer priceStmt.Close()
+
+	deleteLineupStmt, err := tx.PrepareContext(ctx, deleteLineupQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer deleteLineupStmt.Close()
+
+	lineupStmt, err := tx.PrepareContext(ctx, insertLineupQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer lineupStmt.Close()
+
+	deleteOffersStmt, err := tx.PrepareContext(ctx, deleteTicketOffersQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer deleteOffersStmt.Close()
+
+	offerStmt, err := tx.PrepareContext(ctx, insertTicketOfferQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer offerStmt.Close()
+
+	deleteSourcesStmt, err := tx.PrepareContext(ctx, deleteSourcesQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer deleteSourcesStmt.Close()
+
+	sourceStmt, err := tx.PrepareContext(ctx, insertSourceQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer sourceStmt.Close()
+
+	stateStmt, err := tx.PrepareContext(ctx, storedStateQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stateStmt.Close()
+
+	changeStmt, err := tx.PrepareContext(ctx, recordChangeQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer changeStmt.Close()
+
+	unarchiveStmt, err := tx.PrepareContext(ctx, unarchiveEventQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer unarchiveStmt.Close()
+
+	venueStmt, err := tx.PrepareContext(ctx, saveVenueQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer venueStmt.Close()
+
+	feedStmt, err := tx.PrepareContext(ctx, recordFeedQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer feedStmt.Close()
+
+	changes := &domain.EventChangeSet{}
 	now := time.Now()
 	for _, event := range events {
 		event.CreatedAt = now
@@ -155,12 +432,52 @@ func (r *EventRepository) CreateBatch(ctx context.Context, events []domain.Event
 			onSaleDate = sql.NullTime{Time: *event.OnSaleDate, Valid: true}
 		}
 
-		_, err := stmt.ExecContext(ctx,
+		var stored domain.Event
+		var storedFingerprint string
+		fingerprint := eventFingerprint(event)
+		err := stateStmt.QueryRowContext(ctx, event.ID).Scan(&stored.Status, &stored.DateTime,
+			&stored.Venue.ID, &stored.Venue.Name, &stored.Venue.City, &stored.CreatedAt, &storedFingerprint)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := unarchiveStmt.ExecContext(ctx, event.ID); err != nil {
+				return nil, fmt.Errorf("failed to unarchive event: %w", err)
+			}
+			if _, err := feedStmt.ExecContext(ctx, event.ID, domain.FeedCreate, now); err != nil {
+				return nil, fmt.Errorf("failed to record feed entry: %w", err)
+			}
+			changes.New = append(changes.New, event)
+		case err != nil:
+			return nil, fmt.Errorf("failed to read stored event: %w", err)
+		default:
+			event.CreatedAt = stored.CreatedAt
+			if status := event.ChangeFrom(stored); status != "" {
+				if _, err := changeStmt.ExecContext(ctx, event.ID, status, stored.DateTime, now); err != nil {
+					return nil, fmt.Errorf("failed to record event change: %w", err)
+				}
+			}
+			if fingerprint != storedFingerprint {
+				if _, err := feedStmt.ExecContext(ctx, event.ID, feedKind(event, stored), now); err != nil {
+					return nil, fmt.Errorf("failed to record feed entry: %w", err)
+				}
+			}
+
+			update := domain.EventUpdate{Event: event, PreviousDateTime: stored.DateTime, PreviousVenue: stored.Venue}
+			if event.DateChangedFrom(stored) {
+				changes.DateChanged = append(changes.DateChanged, update)
+			}
+			if event.VenueChangedFrom(stored) {
+				changes.VenueChanged = append(changes.VenueChanged, update)
+			}
+		}
+
+		_, err = stmt.ExecContext(ctx,
 			event.ID,
 			event.ArtistID,
 			event.ArtistName,
 			event.Title,
-			event.DateTime,
+			event.DateTime.UTC(),
+			event.Timezone,
+			event.DateConfidence,
 			event.Venue.ID,
 			event.Venue.Name,
 			event.Venue.City,
@@ -168,29 +485,86 @@ func (r *EventRepository) CreateBatch(ctx context.Context, events []domain.Event
 			event.Venue.Country,
 			event.Venue.Latitude,
 			event.Venue.Longitude,
+			event.Venue.Capacity,
 			event.TicketURL,
 			event.TicketStatus,
+			event.Status,
 			onSaleDate,
 			event.ExternalIDs.BandsintownID,
 			event.ExternalIDs.TicketmasterID,
+			event.ExternalIDs.SongkickID,
+			event.ExternalIDs.EventbriteID,
+			event.ExternalIDs.SetlistFMID,
+			event.AgeRestriction,
+			event.Accessibility,
 			event.CreatedAt,
 			event.UpdatedAt,
 			event.CachedUntil,
+			event.Venue.Slug(), event.Venue.Slug(),
+			fingerprint,
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert event: %w", err)
+			return nil, fmt.Errorf("failed to upsert event: %w", err)
+		}
+
+		if slug := event.Venue.Slug(); slug != "" {
+			if _, err := venueStmt.ExecContext(ctx, venueArgs(slug, event.Venue, now)...); err != nil {
+				return nil, fmt.Errorf("failed to save venue: %w", err)
+			}
+		}
+
+		if _, err := discoveryStmt.ExecContext(ctx, event.ID, now); err != nil {
+			return nil, fmt.Errorf("failed to record event discovery: %w", err)
+		}
+
+		for _, price := range event.PriceRanges {
+			if _, err := priceStmt.ExecContext(ctx, event.ID, now, price.Type, price.Min, price.Max, price.Currency); err != nil {
+				return nil, fmt.Errorf("failed to record event price: %w", err)
+			}
+		}
+
+		if _, err := deleteLineupStmt.ExecContext(ctx, event.ID); err != nil {
+			return nil, fmt.Errorf("failed to clear event lineup: %w", err)
+		}
+		for i, artist := range event.Lineup {
+			if _, err := lineupStmt.ExecContext(ctx, event.ID, lineupBilling(artist, i), artist.ID, artist.Name, artist.Headliner); err != nil {
+				return nil, fmt.Errorf("failed to record event lineup: %w", err)
+			}
+		}
+
+		if _, err := deleteOffersStmt.ExecContext(ctx, event.ID); err != nil {
+			return nil, fmt.Errorf("failed to clear event ticket offers: %w", err)
+		}
+		for i, offer := range event.TicketOffers {
+			if _, err := offerStmt.ExecContext(ctx, ticketOfferArgs(event.ID, i, offer)...); err != nil {
+				return nil, fmt.Errorf("failed to record event ticket offer: %w", err)
+			}
+		}
+
+		if _, err := deleteSourcesStmt.ExecContext(ctx, event.ID); err != nil {
+			return nil, fmt.Errorf("failed to clear event sources: %w", err)
+		}
+		for i, source := range event.Sources {
+			if _, err := sourceStmt.ExecContext(ctx, event.ID, i+1, source, event.SourceIDs[source]); err != nil {
+				return nil, fmt.Errorf("failed to record event source: %w", err)
+			}
 		}
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changes, nil
 }
 
 func (r *EventRepository) GetByID(ctx context.Context, id string) (*domain.Event, error) {
 	query := `
-	SELECT id, artist_id, artist_name, title, datetime,
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
 		venue_id, venue_name, venue_city, venue_region, venue_country,
-		venue_latitude, venue_longitude, ticket_url, ticket_status,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
 		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
 		created_at, updated_at, cached_until
 	FROM events
 	WHERE id = ?
@@ -204,36 +578,30 @@ func (r *EventRepository) GetByID(ctx context.Context, id string) (*domain.Event
 		return nil, fmt.Errorf("failed to get event by id: %w", err)
 	}
 
-	return event, nil
+	return r.withDetails(ctx, event)
 }
 
 func (r *EventRepository) GetByExternalID(ctx context.Context, externalID string, source string) (*domain.Event, error) {
-	var query string
+	var column string
 	switch source {
-	case "bandsintown":
-		query = `
-		SELECT id, artist_id, artist_name, title, datetime,
-			venue_id, venue_name, venue_city, venue_region, venue_country,
-			venue_latitude, venue_longitude, ticket_url, ticket_status,
-			on_sale_date, bandsintown_id, ticketmaster_id,
-			created_at, updated_at, cached_until
-		FROM events
-		WHERE bandsintown_id = ?
-		`
-	case "ticketmaster":
-		query = `
-		SELECT id, artist_id, artist_name, title, datetime,
-			venue_id, venue_name, venue_city, venue_region, venue_country,
-			venue_latitude, venue_longitude, ticket_url, ticket_status,
-			on_sale_date, bandsintown_id, ticketmaster_id,
-			created_at, updated_at, cached_until
-		FROM events
-		WHERE ticketmaster_id = ?
-		`
+	case "bandsintown", "ticketmaster", "songkick", "eventbrite", "setlistfm":
+		column = source + "_id"
 	default:
 		return nil, fmt.Errorf("invalid source: %s", source)
 	}
 
+	query := `
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
+		venue_id, venue_name, venue_city, venue_region, venue_country,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
+		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until
+	FROM events
+	WHERE ` + column + ` = ?
+	`
+
 	event, err := r.scanEvent(r.db.QueryRowContext(ctx, query, externalID))
 	if err == sql.ErrNoRows {
 		return nil, domain.ErrEventNotFound
@@ -242,15 +610,17 @@ func (r *EventRepository) GetByExternalID(ctx context.Context, externalID string
 		return nil, fmt.Errorf("failed to get event by external id: %w", err)
 	}
 
-	return event, nil
+	return r.withDetails(ctx, event)
 }
 
 func (r *EventRepository) SearchByArtist(ctx context.Context, artistID string, startDate, endDate *time.Time) ([]domain.Event, error) {
 	query := `
-	SELECT id, artist_id, artist_name, title, datetime,
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
 		venue_id, venue_name, venue_city, venue_region, venue_country,
-		venue_latitude, venue_longitude, ticket_url, ticket_status,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
 		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
 		created_at, updated_at, cached_until
 	FROM events
 	WHERE artist_id = ?
@@ -259,11 +629,11 @@ func (r *EventRepository) SearchByArtist(ctx context.Context, artistID string, s
 
 	if startDate != nil {
 		query += " AND datetime >= ?"
-		args = append(args, *startDate)
+		args = append(args, startDate.UTC())
 	}
 	if endDate != nil {
 		query += " AND datetime <= ?"
-		args = append(args, *endDate)
+		args = append(args, endDate.UTC())
 	}
 
 	query += " ORDER BY datetime ASC"
@@ -274,40 +644,97 @@ func (r *EventRepository) SearchByArtist(ctx context.Context, artistID string, s
 	}
 	defer rows.Close()
 
-	return r.scanEvents(rows)
+	return r.scanEvents(ctx, rows)
+}
+
+// SearchByArtistName matches the artist name case-insensitively, for events
+// stored from sources that don't share our artist IDs. Support acts in an
+// event's lineup match as well as the headliner.
+func (r *EventRepository) SearchByArtistName(ctx context.Context, artistName string, startDate, endDate *time.Time) ([]domain.Event, error) {
+	query := `
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
+		venue_id, venue_name, venue_city, venue_region, venue_country,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
+		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until
+	FROM events
+	WHERE (artist_name = ? COLLATE NOCASE
+		OR id IN (SELECT event_id FROM event_artists WHERE artist_name = ? COLLATE NOCASE))
+	`
+	args := []interface{}{strings.TrimSpace(artistName), strings.TrimSpace(artistName)}
+
+	if startDate != nil {
+		query += " AND datetime >= ?"
+		args = append(args, startDate.UTC())
+	}
+	if endDate != nil {
+		query += " AND datetime <= ?"
+		args = append(args, endDate.UTC())
+	}
+
+	query += " ORDER BY datetime ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search events by artist name: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanEvents(ctx, rows)
 }
 
+// SearchByLocation returns stored events at venues within radius km of lat,
+// lng, nearest first and then by date. SQLite has no trigonometry, so the
+// query narrows to a bounding box and the distance is measured here.
 func (r *EventRepository) SearchByLocation(ctx context.Context, lat, lng float64, radius int, startDate, endDate *time.Time) ([]domain.Event, error) {
+	radiusKm := float64(radius)
+	dLat := radiusKm / 111.0
+	dLng := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.01 {
+		dLng = math.Min(180, radiusKm/(111.0*cos))
+	}
+
 	query := `
-	SELECT id, artist_id, artist_name, title, datetime,
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
 		venue_id, venue_name, venue_city, venue_region, venue_country,
-		venue_latitude, venue_longitude, ticket_url, ticket_status,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
 		on_sale_date, bandsintown_id, ticketmaster_id,
-		created_at, updated_at, cached_until,
-		(
-			6371 * acos(
-				cos(radians(?)) * cos(radians(venue_latitude)) *
-				cos(radians(venue_longitude) - radians(?)) +
-				sin(radians(?)) * sin(radians(venue_latitude))
-			)
-		) AS distance
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until
 	FROM events
 	WHERE venue_latitude IS NOT NULL AND venue_longitude IS NOT NULL
+		AND NOT (venue_latitude = 0 AND venue_longitude = 0)
+		AND venue_latitude BETWEEN ? AND ?
 	`
-	args := []interface{}{lat, lng, lat}
+	args := []interface{}{lat - dLat, lat + dLat}
+
+	// Near the antimeridian the box wraps, so either side of it matches
+	minLng, maxLng := lng-dLng, lng+dLng
+	switch {
+	case dLng >= 180:
+	case minLng < -180:
+		query += " AND (venue_longitude >= ? OR venue_longitude <= ?)"
+		args = append(args, minLng+360, maxLng)
+	case maxLng > 180:
+		query += " AND (venue_longitude >= ? OR venue_longitude <= ?)"
+		args = append(args, minLng, maxLng-360)
+	default:
+		query += " AND venue_longitude BETWEEN ? AND ?"
+		args = append(args, minLng, maxLng)
+	}
 
 	if startDate != nil {
 		query += " AND datetime >= ?"
-		args = append(args, *startDate)
+		args = append(args, startDate.UTC())
 	}
 	if endDate != nil {
 		query += " AND datetime <= ?"
-		args = append(args, *endDate)
+		args = append(args, endDate.UTC())
 	}
 
-	query += " HAVING distance <= ? ORDER BY distance ASC, datetime ASC"
-	args = append(args, radius)
-
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search events by location: %w", err)
@@ -315,118 +742,846 @@ func (r *EventRepository) SearchByLocation(ctx context.Context, lat, lng float64
 	defer rows.Close()
 
 	var events []domain.Event
+	distances := make(map[string]float64)
 	for rows.Next() {
-		event, err := r.scanEventWithDistance(rows)
+		event, err := r.scanEventRow(rows)
 		if err != nil {
 			return nil, err
 		}
+		distance := event.Venue.DistanceKm(lat, lng)
+		if distance > radiusKm {
+			continue
+		}
+		distances[event.ID] = distance
 		events = append(events, *event)
 	}
-
-	return events, rows.Err()
-}
-
-func (r *EventRepository) Update(ctx context.Context, event *domain.Event) error {
-	if event == nil {
-		return fmt.Errorf("event cannot be nil")
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	query := `
-	UPDATE events
-	SET artist_id = ?, artist_name = ?, title = ?, datetime = ?,
-		venue_id = ?, venue_name = ?, venue_city = ?, venue_region = ?, venue_country = ?,
-		venue_latitude = ?, venue_longitude = ?, ticket_url = ?, ticket_status = ?,
-		on_sale_date = ?, bandsintown_id = ?, ticketmaster_id = ?,
-		updated_at = ?, cached_until = ?
-	WHERE id = ?
-	`
+	sort.SliceStable(events, func(i, j int) bool {
+		di, dj := distances[events[i].ID], distances[events[j].ID]
+		if di != dj {
+			return di < dj
+		}
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
 
-	event.UpdatedAt = time.Now()
+	return events, r.loadDetails(ctx, events)
+}
 
-	var onSaleDate sql.NullTime
-	if event.OnSaleDate != nil {
-		onSaleDate = sql.NullTime{Time: *event.OnSaleDate, Valid: true}
+// ClusterEvents groups the events within the filter's bounds by grid cell
+// in one query, so a dense city comes back as a few rows per cell rather
+// than every event. idx_events_location narrows the scan to the bounds.
+func (r *EventRepository) ClusterEvents(ctx context.Context, filter domain.ClusterFilter) ([]domain.EventCluster, error) {
+	if filter.CellSize <= 0 {
+		return nil, fmt.Errorf("failed to cluster events: cell size must be positive")
+	}
+	perCell := filter.PerCell
+	if perCell < 1 {
+		perCell = 1
+	}
+	bounds := filter.Bounds
+
+	// Across the antimeridian, longitudes west of it are moved on by 360 so
+	// cells keep counting eastwards from West
+	lng := "venue_longitude"
+	lngFilter := "venue_longitude BETWEEN ? AND ?"
+	var lngArgs []interface{}
+	if bounds.West > bounds.East {
+		lng = "CASE WHEN venue_longitude < ? THEN venue_longitude + 360 ELSE venue_longitude END"
+		lngFilter = "(venue_longitude >= ? OR venue_longitude <= ?)"
+		lngArgs = append(lngArgs, bounds.West)
 	}
 
-	result, err := r.db.ExecContext(ctx, query,
-		event.ArtistID,
-		event.ArtistName,
-		event.Title,
-		event.DateTime,
-		event.Venue.ID,
-		event.Venue.Name,
-		event.Venue.City,
-		event.Venue.Region,
-		event.Venue.Country,
-		event.Venue.Latitude,
-		event.Venue.Longitude,
-		event.TicketURL,
-		event.TicketStatus,
-		onSaleDate,
-		event.ExternalIDs.BandsintownID,
-		event.ExternalIDs.TicketmasterID,
-		event.UpdatedAt,
-		event.CachedUntil,
-		event.ID,
+	query := `
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
+		venue_id, venue_name, venue_city, venue_region, venue_country,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
+		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until,
+		cell_row, cell_col, cell_count, cell_latitude, cell_longitude
+	FROM (
+		SELECT *,
+			COUNT(*) OVER cell AS cell_count,
+			AVG(venue_latitude) OVER cell AS cell_latitude,
+			AVG(lng) OVER cell AS cell_longitude,
+			ROW_NUMBER() OVER (cell ORDER BY datetime, id) AS cell_rank
+		FROM (
+			SELECT *,
+				CAST((venue_latitude - ?) / ? AS INTEGER) AS cell_row,
+				CAST((lng - ?) / ? AS INTEGER) AS cell_col
+			FROM (
+				SELECT *, ` + lng + ` AS lng
+				FROM events
+				WHERE venue_latitude BETWEEN ? AND ?
+					AND ` + lngFilter + `
+					AND NOT (venue_latitude = 0 AND venue_longitude = 0)
+					AND datetime >= ?
+			)
+		)
+		WINDOW cell AS (PARTITION BY cell_row, cell_col)
 	)
+	WHERE cell_rank <= ?
+	ORDER BY cell_count DESC, cell_row, cell_col, cell_rank
+	`
+	args := []interface{}{bounds.South, filter.CellSize, bounds.West, filter.CellSize}
+	args = append(args, lngArgs...)
+	args = append(args, bounds.South, bounds.North, bounds.West, bounds.East, filter.From.UTC(), perCell)
 
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to update event: %w", err)
+		return nil, fmt.Errorf("failed to cluster events: %w", err)
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	// Rows come a cell at a time; starts is where each cell's events begin
+	var clusters []domain.EventCluster
+	var events []domain.Event
+	var starts []int
+	var last *eventCell
+	for rows.Next() {
+		event, cell, err := r.scanEventInCell(rows)
+		if err != nil {
+			return nil, err
+		}
+		if last == nil || cell.row != last.row || cell.col != last.col {
+			if cell.longitude > 180 {
+				cell.longitude -= 360
+			}
+			clusters = append(clusters, domain.EventCluster{
+				Latitude:  cell.latitude,
+				Longitude: cell.longitude,
+				Count:     cell.count,
+			})
+			starts = append(starts, len(events))
+			last = cell
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	if rowsAffected == 0 {
-		return domain.ErrEventNotFound
+	if err := r.loadDetails(ctx, events); err != nil {
+		return nil, err
+	}
+	for i := range clusters {
+		end := len(events)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		clusters[i].Events = events[starts[i]:end]
 	}
 
-	return nil
+	return clusters, nil
 }
 
-func (r *EventRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM events WHERE id = ?`
+// ListDiscovered returns stored events newest discovery first. An artist ID
+// and name in the filter match either; a city matches the venue city.
+// Since keeps only events discovered after it.
+func (r *EventRepository) ListDiscovered(ctx context.Context, filter domain.DiscoveryFilter, limit int) ([]domain.Event, error) {
+	query := `
+	SELECT e.id, e.artist_id, e.artist_name, e.title, e.datetime, e.timezone, e.date_confidence,
+		e.venue_id, e.venue_name, e.venue_city, e.venue_region, e.venue_country,
+		e.venue_latitude, e.venue_longitude, e.venue_capacity, e.ticket_url, e.ticket_status, e.status,
+		e.on_sale_date, e.bandsintown_id, e.ticketmaster_id,
+		e.songkick_id, e.eventbrite_id, e.setlistfm_id,
+		e.age_restriction, e.accessibility,
+		e.created_at, e.updated_at, e.cached_until,
+		d.discovered_at
+	FROM events e
+	JOIN event_discoveries d ON d.event_id = e.id
+	WHERE 1 = 1
+	`
+	args := []interface{}{}
+
+	switch {
+	case filter.ArtistID != "" && filter.ArtistName != "":
+		query += " AND (e.artist_id = ? OR e.artist_name = ? COLLATE NOCASE)"
+		args = append(args, filter.ArtistID, strings.TrimSpace(filter.ArtistName))
+	case filter.ArtistID != "":
+		query += " AND e.artist_id = ?"
+		args = append(args, filter.ArtistID)
+	case filter.ArtistName != "":
+		query += " AND e.artist_name = ? COLLATE NOCASE"
+		args = append(args, strings.TrimSpace(filter.ArtistName))
+	}
 
-	result, err := r.db.ExecContext(ctx, query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete event: %w", err)
+	if filter.City != "" {
+		condition, cityArgs := cityMatch("e.venue_city", filter.City)
+		query += " AND " + condition
+		args = append(args, cityArgs...)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if filter.Since != nil {
+		query += " AND d.discovered_at > ?"
+		args = append(args, *filter.Since)
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	query += " ORDER BY d.discovered_at DESC, e.datetime ASC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return nil, fmt.Errorf("failed to list discovered events: %w", err)
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return domain.ErrEventNotFound
+	events := []domain.Event{}
+	for rows.Next() {
+		event, err := r.scanEventWithDiscovery(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return events, r.loadDetails(ctx, events)
 }
 
-func (r *EventRepository) DeleteExpiredCache(ctx context.Context) error {
-	query := `DELETE FROM events WHERE cached_until < ?`
+// ListChanges returns the cancellations, postponements and date changes
+// found in stored events, newest first. The filter matches events the way
+// ListDiscovered does, with Since applying to when the change was found.
+func (r *EventRepository) ListChanges(ctx context.Context, filter domain.DiscoveryFilter, limit int) ([]domain.EventChange, error) {
+	query := `
+	SELECT e.id, e.artist_id, e.artist_name, e.title, e.datetime, e.timezone, e.date_confidence,
+		e.venue_id, e.venue_name, e.venue_city, e.venue_region, e.venue_country,
+		e.venue_latitude, e.venue_longitude, e.venue_capacity, e.ticket_url, e.ticket_status, e.status,
+		e.on_sale_date, e.bandsintown_id, e.ticketmaster_id,
+		e.songkick_id, e.eventbrite_id, e.setlistfm_id,
+		e.age_restriction, e.accessibility,
+		e.created_at, e.updated_at, e.cached_until,
+		c.status, c.previous_datetime, c.changed_at
+	FROM event_changes c
+	JOIN events e ON e.id = c.event_id
+	WHERE 1 = 1
+	`
+	args := []interface{}{}
+
+	switch {
+	case filter.ArtistID != "" && filter.ArtistName != "":
+		query += " AND (e.artist_id = ? OR e.artist_name = ? COLLATE NOCASE)"
+		args = append(args, filter.ArtistID, strings.TrimSpace(filter.ArtistName))
+	case filter.ArtistID != "":
+		query += " AND e.artist_id = ?"
+		args = append(args, filter.ArtistID)
+	case filter.ArtistName != "":
+		query += " AND e.artist_name = ? COLLATE NOCASE"
+		args = append(args, strings.TrimSpace(filter.ArtistName))
+	}
 
-	_, err := r.db.ExecContext(ctx, query, time.Now())
-	if err != nil {
-		return fmt.Errorf("failed to delete expired cache: %w", err)
+	if filter.City != "" {
+		condition, cityArgs := cityMatch("e.venue_city", filter.City)
+		query += " AND " + condition
+		args = append(args, cityArgs...)
 	}
 
-	return nil
-}
+	if filter.Since != nil {
+		query += " AND c.changed_at > ?"
+		args = append(args, *filter.Since)
+	}
 
-func (r *EventRepository) scanEvent(row *sql.Row) (*domain.Event, error) {
-	var event domain.Event
-	var onSaleDate sql.NullTime
+	if limit <= 0 {
+		limit = 50
+	}
+	query += " ORDER BY c.changed_at DESC, e.datetime ASC LIMIT ?"
+	args = append(args, limit)
 
-	err := row.Scan(
-		&event.ID,
-		&event.ArtistID,
-		&event.ArtistName,
-		&event.Title,
-		&event.DateTime,
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list event changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := []domain.EventChange{}
+	for rows.Next() {
+		change, err := r.scanEventChange(rows)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, *change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.Event, len(changes))
+	for i, change := range changes {
+		events[i] = change.Event
+	}
+	if err := r.loadDetails(ctx, events); err != nil {
+		return nil, err
+	}
+	for i := range changes {
+		changes[i].Event = events[i]
+	}
+
+	return changes, nil
+}
+
+func (r *EventRepository) ListUpcomingOnSales(ctx context.Context, filter domain.OnSaleFilter, limit int) ([]domain.Event, error) {
+	query := `
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
+		venue_id, venue_name, venue_city, venue_region, venue_country,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
+		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until
+	FROM events
+	WHERE on_sale_date IS NOT NULL AND on_sale_date > ? AND on_sale_date <= ?
+	`
+	args := []interface{}{filter.From, filter.To}
+
+	switch {
+	case filter.ArtistID != "" && filter.ArtistName != "":
+		query += " AND (artist_id = ? OR artist_name = ? COLLATE NOCASE)"
+		args = append(args, filter.ArtistID, strings.TrimSpace(filter.ArtistName))
+	case filter.ArtistID != "":
+		query += " AND artist_id = ?"
+		args = append(args, filter.ArtistID)
+	case filter.ArtistName != "":
+		query += " AND artist_name = ? COLLATE NOCASE"
+		args = append(args, strings.TrimSpace(filter.ArtistName))
+	}
+
+	if filter.City != "" {
+		condition, cityArgs := cityMatch("venue_city", filter.City)
+		query += " AND " + condition
+		args = append(args, cityArgs...)
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	query += " ORDER BY on_sale_date ASC, datetime ASC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list upcoming on-sales: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanEvents(ctx, rows)
+}
+
+func (r *EventRepository) Each(ctx context.Context, filter domain.EventFilter, fn func(domain.Event) error) error {
+	query := `
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
+		venue_id, venue_name, venue_city, venue_region, venue_country,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
+		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until
+	FROM events
+	WHERE 1 = 1
+	`
+	args := []interface{}{}
+
+	if artist := strings.TrimSpace(filter.Artist); artist != "" {
+		query += " AND (artist_id = ? OR artist_name = ? COLLATE NOCASE)"
+		args = append(args, artist, artist)
+	}
+	if city := strings.TrimSpace(filter.City); city != "" {
+		condition, cityArgs := cityMatch("venue_city", city)
+		query += " AND " + condition
+		args = append(args, cityArgs...)
+	}
+	if filter.From != nil {
+		query += " AND datetime >= ?"
+		args = append(args, filter.From.UTC())
+	}
+	if filter.To != nil {
+		query += " AND datetime <= ?"
+		args = append(args, filter.To.UTC())
+	}
+
+	query += " ORDER BY datetime ASC, id ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		event, err := r.scanEventRow(rows)
+		if err != nil {
+			return err
+		}
+		if event, err = r.withDetails(ctx, event); err != nil {
+			return err
+		}
+		if err := fn(*event); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *EventRepository) Update(ctx context.Context, event *domain.Event) error {
+	if event == nil {
+		return fmt.Errorf("event cannot be nil")
+	}
+
+	query := `
+	UPDATE events
+	SET artist_id = ?, artist_name = ?, title = ?, datetime = ?, timezone = ?, date_confidence = ?,
+		venue_id = ?, venue_name = ?, venue_city = ?, venue_region = ?, venue_country = ?,
+		venue_latitude = ?, venue_longitude = ?, venue_capacity = ?, ticket_url = ?, ticket_status = ?, status = ?,
+		on_sale_date = ?, bandsintown_id = ?, ticketmaster_id = ?,
+		songkick_id = ?, eventbrite_id = ?, setlistfm_id = ?,
+		age_restriction = ?, accessibility = ?,
+		updated_at = ?, cached_until = ?, venue_slug = ` + mergedVenueSlug + `, fingerprint = ?
+	WHERE id = ?
+	`
+
+	event.UpdatedAt = time.Now()
+
+	var onSaleDate sql.NullTime
+	if event.OnSaleDate != nil {
+		onSaleDate = sql.NullTime{Time: *event.OnSaleDate, Valid: true}
+	}
+
+	var stored domain.Event
+	var storedFingerprint string
+	fingerprint := eventFingerprint(*event)
+	err := r.db.QueryRowContext(ctx, storedStateQuery, event.ID).Scan(&stored.Status, &stored.DateTime,
+		&stored.Venue.ID, &stored.Venue.Name, &stored.Venue.City, &stored.CreatedAt, &storedFingerprint)
+	if err == sql.ErrNoRows {
+		return domain.ErrEventNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read stored event: %w", err)
+	}
+	if status := event.ChangeFrom(stored); status != "" {
+		if _, err := r.db.ExecContext(ctx, recordChangeQuery, event.ID, status, stored.DateTime, event.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to record event change: %w", err)
+		}
+	}
+	if fingerprint != storedFingerprint {
+		if _, err := r.db.ExecContext(ctx, recordFeedQuery, event.ID, feedKind(*event, stored), event.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to record feed entry: %w", err)
+		}
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		event.ArtistID,
+		event.ArtistName,
+		event.Title,
+		event.DateTime.UTC(),
+		event.Timezone,
+		event.DateConfidence,
+		event.Venue.ID,
+		event.Venue.Name,
+		event.Venue.City,
+		event.Venue.Region,
+		event.Venue.Country,
+		event.Venue.Latitude,
+		event.Venue.Longitude,
+		event.Venue.Capacity,
+		event.TicketURL,
+		event.TicketStatus,
+		event.Status,
+		onSaleDate,
+		event.ExternalIDs.BandsintownID,
+		event.ExternalIDs.TicketmasterID,
+		event.ExternalIDs.SongkickID,
+		event.ExternalIDs.EventbriteID,
+		event.ExternalIDs.SetlistFMID,
+		event.AgeRestriction,
+		event.Accessibility,
+		event.UpdatedAt,
+		event.CachedUntil,
+		event.Venue.Slug(), event.Venue.Slug(),
+		fingerprint,
+		event.ID,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrEventNotFound
+	}
+
+	if slug := event.Venue.Slug(); slug != "" {
+		if _, err := r.db.ExecContext(ctx, saveVenueQuery, venueArgs(slug, event.Venue, event.UpdatedAt)...); err != nil {
+			return fmt.Errorf("failed to save venue: %w", err)
+		}
+	}
+
+	for _, price := range event.PriceRanges {
+		if _, err := r.db.ExecContext(ctx, recordPriceQuery, event.ID, event.UpdatedAt, price.Type, price.Min, price.Max, price.Currency); err != nil {
+			return fmt.Errorf("failed to record event price: %w", err)
+		}
+	}
+
+	return r.saveDetails(ctx, event)
+}
+
+// saveDetails rewrites the event's lineup and ticket offers
+func (r *EventRepository) saveDetails(ctx context.Context, event *domain.Event) error {
+	if _, err := r.db.ExecContext(ctx, deleteLineupQuery, event.ID); err != nil {
+		return fmt.Errorf("failed to clear event lineup: %w", err)
+	}
+
+	for i, artist := range event.Lineup {
+		if _, err := r.db.ExecContext(ctx, insertLineupQuery, event.ID, lineupBilling(artist, i), artist.ID, artist.Name, artist.Headliner); err != nil {
+			return fmt.Errorf("failed to record event lineup: %w", err)
+		}
+	}
+
+	if _, err := r.db.ExecContext(ctx, deleteTicketOffersQuery, event.ID); err != nil {
+		return fmt.Errorf("failed to clear event ticket offers: %w", err)
+	}
+
+	for i, offer := range event.TicketOffers {
+		if _, err := r.db.ExecContext(ctx, insertTicketOfferQuery, ticketOfferArgs(event.ID, i, offer)...); err != nil {
+			return fmt.Errorf("failed to record event ticket offer: %w", err)
+		}
+	}
+
+	if _, err := r.db.ExecContext(ctx, deleteSourcesQuery, event.ID); err != nil {
+		return fmt.Errorf("failed to clear event sources: %w", err)
+	}
+
+	for i, source := range event.Sources {
+		if _, err := r.db.ExecContext(ctx, insertSourceQuery, event.ID, i+1, source, event.SourceIDs[source]); err != nil {
+			return fmt.Errorf("failed to record event source: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ticketOfferArgs are insertTicketOfferQuery's arguments; an offer without
+// prices stores them as NULL
+func ticketOfferArgs(eventID string, position int, offer domain.TicketOffer) []interface{} {
+	var minPrice, maxPrice sql.NullFloat64
+	var currency sql.NullString
+	if offer.PriceRange != nil {
+		minPrice = sql.NullFloat64{Float64: offer.PriceRange.Min, Valid: true}
+		maxPrice = sql.NullFloat64{Float64: offer.PriceRange.Max, Valid: true}
+		currency = sql.NullString{String: offer.PriceRange.Currency, Valid: true}
+	}
+	return []interface{}{eventID, position + 1, offer.Source, offer.URL, offer.Status, minPrice, maxPrice, currency}
+}
+
+// lineupBilling falls back to the artist's place in the lineup for sources
+// that don't number their bills
+func lineupBilling(artist domain.EventArtist, index int) int {
+	if artist.Billing > 0 {
+		return artist.Billing
+	}
+	return index + 1
+}
+
+// loadDetails fills in the lineups and ticket offers of events already read
+func (r *EventRepository) loadDetails(ctx context.Context, events []domain.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	byID := make(map[string][]int, len(events))
+	placeholders := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events))
+	for i, event := range events {
+		if _, seen := byID[event.ID]; !seen {
+			placeholders = append(placeholders, "?")
+			args = append(args, event.ID)
+		}
+		byID[event.ID] = append(byID[event.ID], i)
+	}
+
+	if err := r.loadLineups(ctx, events, byID, strings.Join(placeholders, ", "), args); err != nil {
+		return err
+	}
+	if err := r.loadTicketOffers(ctx, events, byID, strings.Join(placeholders, ", "), args); err != nil {
+		return err
+	}
+	if err := r.loadSources(ctx, events, byID, strings.Join(placeholders, ", "), args); err != nil {
+		return err
+	}
+	return r.loadVenueDetails(ctx, events, byID, strings.Join(placeholders, ", "), args)
+}
+
+// loadLineups fills in lineups, given the events' positions by ID and the
+// placeholders and arguments that select their IDs
+func (r *EventRepository) loadLineups(ctx context.Context, events []domain.Event, byID map[string][]int, placeholders string, args []interface{}) error {
+	query := `
+	SELECT event_id, billing, artist_id, artist_name, headliner
+	FROM event_artists
+	WHERE event_id IN (` + placeholders + `)
+	ORDER BY event_id, billing ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get event lineups: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventID string
+		var artist domain.EventArtist
+		var artistID sql.NullString
+
+		if err := rows.Scan(&eventID, &artist.Billing, &artistID, &artist.Name, &artist.Headliner); err != nil {
+			return fmt.Errorf("failed to scan lineup: %w", err)
+		}
+		artist.ID = artistID.String
+
+		for _, i := range byID[eventID] {
+			events[i].Lineup = append(events[i].Lineup, artist)
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadTicketOffers fills in ticket offers the same way loadLineups does
+func (r *EventRepository) loadTicketOffers(ctx context.Context, events []domain.Event, byID map[string][]int, placeholders string, args []interface{}) error {
+	query := `
+	SELECT event_id, source, url, status, min_price, max_price, currency
+	FROM event_ticket_offers
+	WHERE event_id IN (` + placeholders + `)
+	ORDER BY event_id, position ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get event ticket offers: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventID string
+		var offer domain.TicketOffer
+		var status, currency sql.NullString
+		var minPrice, maxPrice sql.NullFloat64
+
+		if err := rows.Scan(&eventID, &offer.Source, &offer.URL, &status, &minPrice, &maxPrice, &currency); err != nil {
+			return fmt.Errorf("failed to scan ticket offer: %w", err)
+		}
+		offer.Status = status.String
+		if minPrice.Valid {
+			offer.PriceRange = &domain.PriceRange{Min: minPrice.Float64, Max: maxPrice.Float64, Currency: currency.String}
+		}
+
+		for _, i := range byID[eventID] {
+			events[i].TicketOffers = append(events[i].TicketOffers, offer)
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadSources fills in sources and their IDs the same way loadLineups does
+func (r *EventRepository) loadSources(ctx context.Context, events []domain.Event, byID map[string][]int, placeholders string, args []interface{}) error {
+	query := `
+	SELECT event_id, source, source_id
+	FROM event_sources
+	WHERE event_id IN (` + placeholders + `)
+	ORDER BY event_id, position ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get event sources: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventID, source string
+		var sourceID sql.NullString
+
+		if err := rows.Scan(&eventID, &source, &sourceID); err != nil {
+			return fmt.Errorf("failed to scan event source: %w", err)
+		}
+
+		for _, i := range byID[eventID] {
+			events[i].Sources = append(events[i].Sources, source)
+			if sourceID.String != "" {
+				if events[i].SourceIDs == nil {
+					events[i].SourceIDs = make(map[string]string)
+				}
+				events[i].SourceIDs[source] = sourceID.String
+			}
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetPriceHistory groups the recorded price ranges by sync. Prices outlive
+// the cached event, so history is kept after the event expires.
+func (r *EventRepository) GetPriceHistory(ctx context.Context, eventID string) ([]domain.PriceSnapshot, error) {
+	query := `
+	SELECT recorded_at, price_type, min_price, max_price, currency
+	FROM event_prices
+	WHERE event_id = ?
+	ORDER BY recorded_at ASC, rowid ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, eventID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []domain.PriceSnapshot{}
+	for rows.Next() {
+		var recordedAt time.Time
+		var price domain.PriceRange
+		var priceType, currency sql.NullString
+
+		if err := rows.Scan(&recordedAt, &priceType, &price.Min, &price.Max, &currency); err != nil {
+			return nil, fmt.Errorf("failed to scan price: %w", err)
+		}
+		price.Type = priceType.String
+		price.Currency = currency.String
+
+		if n := len(history); n == 0 || !history[n-1].RecordedAt.Equal(recordedAt) {
+			history = append(history, domain.PriceSnapshot{RecordedAt: recordedAt})
+		}
+		last := &history[len(history)-1]
+		last.Ranges = append(last.Ranges, price)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate prices: %w", err)
+	}
+
+	return history, nil
+}
+
+// Delete moves the event into the archive, out of searches and listings
+func (r *EventRepository) Delete(ctx context.Context, id string) error {
+	now := time.Now()
+	if _, err := r.db.ExecContext(ctx, archiveEventsQuery+` WHERE id = ?`, now, id); err != nil {
+		return fmt.Errorf("failed to archive event: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, recordFeedDeletesQuery+` WHERE id = ?`, now, id); err != nil {
+		return fmt.Errorf("failed to record feed entry: %w", err)
+	}
+
+	query := `DELETE FROM events WHERE id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrEventNotFound
+	}
+
+	if _, err := r.db.ExecContext(ctx, deleteLineupQuery, id); err != nil {
+		return fmt.Errorf("failed to delete event lineup: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, deleteTicketOffersQuery, id); err != nil {
+		return fmt.Errorf("failed to delete event ticket offers: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, deleteSourcesQuery, id); err != nil {
+		return fmt.Errorf("failed to delete event sources: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EventRepository) DeleteExpiredCache(ctx context.Context) error {
+	_, err := r.PurgeExpired(ctx)
+	return err
+}
+
+// PurgeExpired moves events past their cached_until into the archive,
+// deletes their lineups, ticket offers, changes and sources, and returns how
+// many events went
+func (r *EventRepository) PurgeExpired(ctx context.Context) (int64, error) {
+	now := time.Now()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, archiveEventsQuery+` WHERE cached_until < ?`, now, now); err != nil {
+		return 0, fmt.Errorf("failed to archive expired events: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, recordFeedDeletesQuery+` WHERE cached_until < ?`, now, now); err != nil {
+		return 0, fmt.Errorf("failed to record feed entries: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `DELETE FROM events WHERE cached_until < ?`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired cache: %w", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count expired events: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Unlike prices, lineups, offers, changes and sources mean nothing
+	// without their event
+	_, err = r.db.ExecContext(ctx, `DELETE FROM event_artists WHERE event_id NOT IN (SELECT id FROM events)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired lineups: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `DELETE FROM event_ticket_offers WHERE event_id NOT IN (SELECT id FROM events)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired ticket offers: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `DELETE FROM event_changes WHERE event_id NOT IN (SELECT id FROM events)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired changes: %w", err)
+	}
+	_, err = r.db.ExecContext(ctx, `DELETE FROM event_sources WHERE event_id NOT IN (SELECT id FROM events)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sources: %w", err)
+	}
+
+	return purged, nil
+}
+
+func (r *EventRepository) scanEvent(row *sql.Row) (*domain.Event, error) {
+	var event domain.Event
+	var onSaleDate sql.NullTime
+
+	err := row.Scan(
+		&event.ID,
+		&event.ArtistID,
+		&event.ArtistName,
+		&event.Title,
+		&event.DateTime,
+		&event.Timezone,
+		&event.DateConfidence,
 		&event.Venue.ID,
 		&event.Venue.Name,
 		&event.Venue.City,
@@ -434,11 +1589,18 @@ func (r *EventRepository) scanEvent(row *sql.Row) (*domain.Event, error) {
 		&event.Venue.Country,
 		&event.Venue.Latitude,
 		&event.Venue.Longitude,
+		&event.Venue.Capacity,
 		&event.TicketURL,
 		&event.TicketStatus,
+		&event.Status,
 		&onSaleDate,
 		&event.ExternalIDs.BandsintownID,
 		&event.ExternalIDs.TicketmasterID,
+		&event.ExternalIDs.SongkickID,
+		&event.ExternalIDs.EventbriteID,
+		&event.ExternalIDs.SetlistFMID,
+		&event.AgeRestriction,
+		&event.Accessibility,
 		&event.CreatedAt,
 		&event.UpdatedAt,
 		&event.CachedUntil,
@@ -448,6 +1610,11 @@ func (r *EventRepository) scanEvent(row *sql.Row) (*domain.Event, error) {
 		return nil, err
 	}
 
+	// Stored in UTC, read back on the venue's clock
+	event.DateTime = event.LocalDateTime()
+	// Only the name is stored; the code follows from it, and older rows
+	// get both
+	event.Venue.NormalizeCountry()
 	if onSaleDate.Valid {
 		event.OnSaleDate = &onSaleDate.Time
 	}
@@ -455,54 +1622,84 @@ func (r *EventRepository) scanEvent(row *sql.Row) (*domain.Event, error) {
 	return &event, nil
 }
 
-func (r *EventRepository) scanEvents(rows *sql.Rows) ([]domain.Event, error) {
+func (r *EventRepository) scanEvents(ctx context.Context, rows *sql.Rows) ([]domain.Event, error) {
 	var events []domain.Event
 
 	for rows.Next() {
-		var event domain.Event
-		var onSaleDate sql.NullTime
-
-		err := rows.Scan(
-			&event.ID,
-			&event.ArtistID,
-			&event.ArtistName,
-			&event.Title,
-			&event.DateTime,
-			&event.Venue.ID,
-			&event.Venue.Name,
-			&event.Venue.City,
-			&event.Venue.Region,
-			&event.Venue.Country,
-			&event.Venue.Latitude,
-			&event.Venue.Longitude,
-			&event.TicketURL,
-			&event.TicketStatus,
-			&onSaleDate,
-			&event.ExternalIDs.BandsintownID,
-			&event.ExternalIDs.TicketmasterID,
-			&event.CreatedAt,
-			&event.UpdatedAt,
-			&event.CachedUntil,
-		)
-
+		event, err := r.scanEventRow(rows)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan event: %w", err)
+			return nil, err
 		}
+		events = append(events, *event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		if onSaleDate.Valid {
-			event.OnSaleDate = &onSaleDate.Time
-		}
+	return events, r.loadDetails(ctx, events)
+}
 
-		events = append(events, event)
+func (r *EventRepository) withDetails(ctx context.Context, event *domain.Event) (*domain.Event, error) {
+	events := []domain.Event{*event}
+	if err := r.loadDetails(ctx, events); err != nil {
+		return nil, err
 	}
+	return &events[0], nil
+}
 
-	return events, rows.Err()
+func (r *EventRepository) scanEventRow(rows *sql.Rows) (*domain.Event, error) {
+	var event domain.Event
+	var onSaleDate sql.NullTime
+
+	err := rows.Scan(
+		&event.ID,
+		&event.ArtistID,
+		&event.ArtistName,
+		&event.Title,
+		&event.DateTime,
+		&event.Timezone,
+		&event.DateConfidence,
+		&event.Venue.ID,
+		&event.Venue.Name,
+		&event.Venue.City,
+		&event.Venue.Region,
+		&event.Venue.Country,
+		&event.Venue.Latitude,
+		&event.Venue.Longitude,
+		&event.Venue.Capacity,
+		&event.TicketURL,
+		&event.TicketStatus,
+		&event.Status,
+		&onSaleDate,
+		&event.ExternalIDs.BandsintownID,
+		&event.ExternalIDs.TicketmasterID,
+		&event.ExternalIDs.SongkickID,
+		&event.ExternalIDs.EventbriteID,
+		&event.ExternalIDs.SetlistFMID,
+		&event.AgeRestriction,
+		&event.Accessibility,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+		&event.CachedUntil,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan event: %w", err)
+	}
+
+	event.DateTime = event.LocalDateTime()
+	event.Venue.NormalizeCountry()
+	if onSaleDate.Valid {
+		event.OnSaleDate = &onSaleDate.Time
+	}
+
+	return &event, nil
 }
 
-func (r *EventRepository) scanEventWithDistance(rows *sql.Rows) (*domain.Event, error) {
+func (r *EventRepository) scanEventWithDiscovery(rows *sql.Rows) (*domain.Event, error) {
 	var event domain.Event
 	var onSaleDate sql.NullTime
-	var distance float64
+	var discoveredAt time.Time
 
 	err := rows.Scan(
 		&event.ID,
@@ -510,6 +1707,8 @@ func (r *EventRepository) scanEventWithDistance(rows *sql.Rows) (*domain.Event,
 		&event.ArtistName,
 		&event.Title,
 		&event.DateTime,
+		&event.Timezone,
+		&event.DateConfidence,
 		&event.Venue.ID,
 		&event.Venue.Name,
 		&event.Venue.City,
@@ -517,24 +1716,161 @@ func (r *EventRepository) scanEventWithDistance(rows *sql.Rows) (*domain.Event,
 		&event.Venue.Country,
 		&event.Venue.Latitude,
 		&event.Venue.Longitude,
+		&event.Venue.Capacity,
 		&event.TicketURL,
 		&event.TicketStatus,
+		&event.Status,
 		&onSaleDate,
 		&event.ExternalIDs.BandsintownID,
 		&event.ExternalIDs.TicketmasterID,
+		&event.ExternalIDs.SongkickID,
+		&event.ExternalIDs.EventbriteID,
+		&event.ExternalIDs.SetlistFMID,
+		&event.AgeRestriction,
+		&event.Accessibility,
 		&event.CreatedAt,
 		&event.UpdatedAt,
 		&event.CachedUntil,
-		&distance,
+		&discoveredAt,
 	)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to scan event with distance: %w", err)
+		return nil, fmt.Errorf("failed to scan discovered event: %w", err)
 	}
 
+	event.DateTime = event.LocalDateTime()
+	event.Venue.NormalizeCountry()
 	if onSaleDate.Valid {
 		event.OnSaleDate = &onSaleDate.Time
 	}
+	event.DiscoveredAt = &discoveredAt
 
 	return &event, nil
 }
+
+// eventCell is the grid cell a clustered event was counted in
+type eventCell struct {
+	row, col            int64
+	count               int
+	latitude, longitude float64
+}
+
+func (r *EventRepository) scanEventInCell(rows *sql.Rows) (*domain.Event, *eventCell, error) {
+	var event domain.Event
+	var cell eventCell
+	var onSaleDate sql.NullTime
+
+	err := rows.Scan(
+		&event.ID,
+		&event.ArtistID,
+		&event.ArtistName,
+		&event.Title,
+		&event.DateTime,
+		&event.Timezone,
+		&event.DateConfidence,
+		&event.Venue.ID,
+		&event.Venue.Name,
+		&event.Venue.City,
+		&event.Venue.Region,
+		&event.Venue.Country,
+		&event.Venue.Latitude,
+		&event.Venue.Longitude,
+		&event.Venue.Capacity,
+		&event.TicketURL,
+		&event.TicketStatus,
+		&event.Status,
+		&onSaleDate,
+		&event.ExternalIDs.BandsintownID,
+		&event.ExternalIDs.TicketmasterID,
+		&event.ExternalIDs.SongkickID,
+		&event.ExternalIDs.EventbriteID,
+		&event.ExternalIDs.SetlistFMID,
+		&event.AgeRestriction,
+		&event.Accessibility,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+		&event.CachedUntil,
+		&cell.row,
+		&cell.col,
+		&cell.count,
+		&cell.latitude,
+		&cell.longitude,
+	)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to scan clustered event: %w", err)
+	}
+
+	event.DateTime = event.LocalDateTime()
+	event.Venue.NormalizeCountry()
+	if onSaleDate.Valid {
+		event.OnSaleDate = &onSaleDate.Time
+	}
+
+	return &event, &cell, nil
+}
+
+func (r *EventRepository) scanEventChange(rows *sql.Rows) (*domain.EventChange, error) {
+	var change domain.EventChange
+	var onSaleDate sql.NullTime
+	event := &change.Event
+
+	err := rows.Scan(
+		&event.ID,
+		&event.ArtistID,
+		&event.ArtistName,
+		&event.Title,
+		&event.DateTime,
+		&event.Timezone,
+		&event.DateConfidence,
+		&event.Venue.ID,
+		&event.Venue.Name,
+		&event.Venue.City,
+		&event.Venue.Region,
+		&event.Venue.Country,
+		&event.Venue.Latitude,
+		&event.Venue.Longitude,
+		&event.Venue.Capacity,
+		&event.TicketURL,
+		&event.TicketStatus,
+		&event.Status,
+		&onSaleDate,
+		&event.ExternalIDs.BandsintownID,
+		&event.ExternalIDs.TicketmasterID,
+		&event.ExternalIDs.SongkickID,
+		&event.ExternalIDs.EventbriteID,
+		&event.ExternalIDs.SetlistFMID,
+		&event.AgeRestriction,
+		&event.Accessibility,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+		&event.CachedUntil,
+		&change.Status,
+		&change.PreviousDateTime,
+		&change.ChangedAt,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan event change: %w", err)
+	}
+	change.PreviousDateTime = domain.InTimezone(change.PreviousDateTime, event.Timezone)
+
+	event.DateTime = event.LocalDateTime()
+	event.Venue.NormalizeCountry()
+	if onSaleDate.Valid {
+		event.OnSaleDate = &onSaleDate.Time
+	}
+
+	return &change, nil
+}
+
+// cityMatch is an SQL condition matching column against every name a city
+// is known by, so events stored as "Köln" turn up for "Cologne"
+func cityMatch(column, city string) (string, []interface{}) {
+	names := domain.CityNames(city)
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	return column + " COLLATE NOCASE IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ") + ")", args
+}
@@ -0,0 +1,1313 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func newTestEvent(id, artistName string, at time.Time) domain.Event {
+	return domain.Event{
+		ID:         id,
+		ArtistID:   "songkick_artist_" + id,
+		ArtistName: artistName,
+		DateTime:   at,
+		Venue: domain.Venue{
+			Name:    "Test Venue",
+			City:    "Berlin",
+			Country: "Germany",
+		},
+		CachedUntil: time.Now().Add(time.Hour),
+	}
+}
+
+func TestEventRepository_VenueCountry(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	event := newTestEvent("country", "Test Artist", time.Now().Add(24*time.Hour))
+	event.Venue.Country = "Deutschland"
+	event.Venue.Capacity = 1500
+	if err := repo.Create(ctx, &event); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+
+	stored, err := repo.GetByID(ctx, "country")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stored.Venue.Country != "Germany" || stored.Venue.CountryCode != "DE" {
+		t.Errorf("expected Germany, DE, got %q, %q", stored.Venue.Country, stored.Venue.CountryCode)
+	}
+	if stored.Venue.Capacity != 1500 {
+		t.Errorf("expected the venue capacity to be kept, got %d", stored.Venue.Capacity)
+	}
+}
+
+func TestEventRepository_AgeRestriction(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	event := newTestEvent("restricted", "Test Artist", time.Now().Add(24*time.Hour))
+	event.AgeRestriction = "18+"
+	event.Accessibility = "Wheelchair platform, book through the box office"
+	if _, err := repo.CreateBatch(ctx, []domain.Event{event}); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+
+	stored, err := repo.GetByID(ctx, "restricted")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stored.AgeRestriction != "18+" || stored.Accessibility != event.Accessibility {
+		t.Errorf("expected the age restriction and accessibility kept, got %q, %q", stored.AgeRestriction, stored.Accessibility)
+	}
+
+	stored.AgeRestriction = "All Ages"
+	if err := repo.Update(ctx, stored); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	if updated, _ := repo.GetByID(ctx, "restricted"); updated.AgeRestriction != "All Ages" {
+		t.Errorf("expected the updated age restriction, got %q", updated.AgeRestriction)
+	}
+}
+
+func TestEventRepository_ExternalIDs(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	event := newTestEvent("songkick_1", "Test Artist", time.Now().Add(24*time.Hour))
+	event.ExternalIDs = domain.EventExternalIDs{
+		SongkickID:   "1",
+		EventbriteID: "eb-1",
+		SetlistFMID:  "sl-1",
+	}
+
+	if _, err := repo.CreateBatch(ctx, []domain.Event{event}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	for source, externalID := range map[string]string{"songkick": "1", "eventbrite": "eb-1", "setlistfm": "sl-1"} {
+		found, err := repo.GetByExternalID(ctx, externalID, source)
+		if err != nil {
+			t.Fatalf("expected %s lookup to succeed, got %v", source, err)
+		}
+		if found.ID != "songkick_1" {
+			t.Errorf("expected songkick_1 for %s, got %s", source, found.ID)
+		}
+	}
+
+	if _, err := repo.GetByExternalID(ctx, "missing", "songkick"); err != domain.ErrEventNotFound {
+		t.Errorf("expected ErrEventNotFound, got %v", err)
+	}
+	if _, err := repo.GetByExternalID(ctx, "1", "myspace"); err == nil {
+		t.Error("expected error for unknown source")
+	}
+}
+
+func TestEventRepository_SearchByArtistName(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	events := []domain.Event{
+		newTestEvent("e2", "Test Artist", now.Add(48*time.Hour)),
+		newTestEvent("e1", "test artist", now.Add(24*time.Hour)),
+		newTestEvent("e0", "Test Artist", now.Add(-24*time.Hour)),
+		newTestEvent("other", "Someone Else", now.Add(24*time.Hour)),
+	}
+	if _, err := repo.CreateBatch(ctx, events); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	t.Run("case insensitive and ordered", func(t *testing.T) {
+		found, err := repo.SearchByArtistName(ctx, "TEST ARTIST", &now, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 2 {
+			t.Fatalf("expected 2 upcoming events, got %d", len(found))
+		}
+		if found[0].ID != "e1" || found[1].ID != "e2" {
+			t.Errorf("expected e1 then e2, got %s then %s", found[0].ID, found[1].ID)
+		}
+	})
+
+	t.Run("no date bounds", func(t *testing.T) {
+		found, err := repo.SearchByArtistName(ctx, "Test Artist", nil, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 3 {
+			t.Errorf("expected 3 events, got %d", len(found))
+		}
+	})
+}
+
+func TestEventRepository_SearchByLocation(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	at := func(id string, lat, lng float64, when time.Time) domain.Event {
+		event := newTestEvent(id, "Test Artist", when)
+		event.Venue.Latitude, event.Venue.Longitude = lat, lng
+		return event
+	}
+	events := []domain.Event{
+		at("kreuzberg_later", 52.4990, 13.4180, now.Add(72*time.Hour)),
+		at("kreuzberg", 52.4990, 13.4180, now.Add(24*time.Hour)),
+		at("mitte", 52.5200, 13.4050, now.Add(48*time.Hour)),
+		at("potsdam", 52.3906, 13.0645, now.Add(24*time.Hour)),
+		at("past", 52.5200, 13.4050, now.Add(-24*time.Hour)),
+		at("no_position", 0, 0, now.Add(24*time.Hour)),
+	}
+	if _, err := repo.CreateBatch(ctx, events); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	found, err := repo.SearchByLocation(ctx, 52.5200, 13.4050, 10, &now, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var ids []string
+	for _, event := range found {
+		ids = append(ids, event.ID)
+	}
+	if strings.Join(ids, ",") != "mitte,kreuzberg,kreuzberg_later" {
+		t.Errorf("expected upcoming events within 10 km nearest first, got %v", ids)
+	}
+
+	found, err = repo.SearchByLocation(ctx, 52.5200, 13.4050, 50, &now, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(found) != 4 || found[3].ID != "potsdam" {
+		t.Errorf("expected Potsdam last within 50 km, got %d events", len(found))
+	}
+}
+
+func TestEventRepository_ClusterEvents(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	at := func(id string, lat, lng float64, when time.Time) domain.Event {
+		event := newTestEvent(id, "Test Artist", when)
+		event.Venue.Latitude, event.Venue.Longitude = lat, lng
+		return event
+	}
+	events := []domain.Event{
+		at("mitte_3", 52.5200, 13.4050, now.Add(72*time.Hour)),
+		at("mitte_1", 52.5200, 13.4050, now.Add(24*time.Hour)),
+		at("mitte_2", 52.5210, 13.4060, now.Add(48*time.Hour)),
+		at("mitte_past", 52.5200, 13.4050, now.Add(-24*time.Hour)),
+		at("potsdam", 52.3906, 13.0645, now.Add(24*time.Hour)),
+		at("hamburg", 53.5511, 9.9937, now.Add(24*time.Hour)),
+		at("fiji", -18.1416, 179.9, now.Add(24*time.Hour)),
+		at("samoa", -13.8333, -171.7500, now.Add(24*time.Hour)),
+	}
+	if _, err := repo.CreateBatch(ctx, events); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	clusters, err := repo.ClusterEvents(ctx, domain.ClusterFilter{
+		Bounds:   domain.Bounds{West: 12.9, South: 52.3, East: 13.8, North: 52.7},
+		CellSize: 0.1,
+		From:     now,
+		PerCell:  2,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("expected Mitte and Potsdam cells, got %d", len(clusters))
+	}
+	mitte := clusters[0]
+	if mitte.Count != 3 || len(mitte.Events) != 2 || mitte.Events[0].ID != "mitte_1" || mitte.Events[1].ID != "mitte_2" {
+		t.Errorf("expected 3 upcoming in Mitte with the soonest 2, got %d %+v", mitte.Count, mitte.Events)
+	}
+	if mitte.Latitude < 52.52 || mitte.Latitude > 52.521 {
+		t.Errorf("expected the mean position, got %f", mitte.Latitude)
+	}
+	if clusters[1].Count != 1 || clusters[1].Events[0].ID != "potsdam" {
+		t.Errorf("expected Potsdam alone, got %+v", clusters[1])
+	}
+
+	clusters, err = repo.ClusterEvents(ctx, domain.ClusterFilter{
+		Bounds:   domain.Bounds{West: 170, South: -25, East: -165, North: -5},
+		CellSize: 5,
+		From:     now,
+		PerCell:  1,
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("expected Fiji and Samoa across the antimeridian, got %d", len(clusters))
+	}
+	for _, cluster := range clusters {
+		if cluster.Longitude < -180 || cluster.Longitude > 180 {
+			t.Errorf("expected a longitude on the map, got %f", cluster.Longitude)
+		}
+	}
+
+	if _, err := repo.ClusterEvents(ctx, domain.ClusterFilter{}); err == nil {
+		t.Error("expected an error without a cell size")
+	}
+}
+
+func TestEventRepository_Timezones(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	losAngeles, _ := time.LoadLocation("America/Los_Angeles")
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	ctx := context.Background()
+	day := time.Now().AddDate(0, 1, 0)
+	// Later on the West Coast's clock, but the Berlin show starts first
+	west := newTestEvent("west", "Test Artist", time.Date(day.Year(), day.Month(), day.Day(), 20, 0, 0, 0, losAngeles))
+	west.Timezone = "America/Los_Angeles"
+	east := newTestEvent("east", "Test Artist", time.Date(day.Year(), day.Month(), day.Day(), 21, 0, 0, 0, berlin))
+	east.Timezone = "Europe/Berlin"
+	if _, err := repo.CreateBatch(ctx, []domain.Event{west, east}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	from := east.DateTime.Add(time.Minute).In(berlin)
+	found, err := repo.SearchByArtistName(ctx, "Test Artist", nil, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(found) != 2 || found[0].ID != "east" || found[1].ID != "west" {
+		t.Fatalf("expected events in start order, got %v", found)
+	}
+	if !found[1].DateTime.Equal(west.DateTime) || found[1].Timezone != "America/Los_Angeles" {
+		t.Errorf("expected the instant and timezone back, got %v in %q", found[1].DateTime, found[1].Timezone)
+	}
+	if found[1].DateTime.Hour() != 20 {
+		t.Errorf("expected 20:00 on the venue's clock, got %v", found[1].DateTime)
+	}
+
+	found, err = repo.SearchByArtistName(ctx, "Test Artist", &from, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "west" {
+		t.Errorf("expected bounds in another timezone to compare instants, got %v", found)
+	}
+}
+
+func TestEventRepository_Lineup(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	event := newTestEvent("songkick_1", "Headliner", time.Now().Add(24*time.Hour))
+	event.Lineup = []domain.EventArtist{
+		{ID: "sk-1", Name: "Headliner", Billing: 1, Headliner: true},
+		{ID: "sk-2", Name: "Support Act", Billing: 2},
+	}
+	if _, err := repo.CreateBatch(ctx, []domain.Event{event}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	found, err := repo.SearchByArtistName(ctx, "support act", nil, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(found) != 1 || found[0].ID != "songkick_1" {
+		t.Fatalf("expected the support act to find songkick_1, got %+v", found)
+	}
+	if len(found[0].Lineup) != 2 || !found[0].Lineup[0].Headliner || found[0].Lineup[1].Name != "Support Act" {
+		t.Errorf("expected the lineup in billing order, got %+v", found[0].Lineup)
+	}
+
+	// Rewriting the event replaces its lineup
+	event.Lineup = event.Lineup[:1]
+	if err := repo.Update(ctx, &event); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	stored, err := repo.GetByID(ctx, "songkick_1")
+	if err != nil {
+		t.Fatalf("failed to get event: %v", err)
+	}
+	if len(stored.Lineup) != 1 || stored.Lineup[0].ID != "sk-1" {
+		t.Errorf("expected only the headliner after the update, got %+v", stored.Lineup)
+	}
+	if found, _ := repo.SearchByArtistName(ctx, "Support Act", nil, nil); len(found) != 0 {
+		t.Errorf("expected the dropped support act not to match, got %d events", len(found))
+	}
+}
+
+func TestEventRepository_TicketOffers(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	event := newTestEvent("ticketmaster_1", "Test Artist", time.Now().Add(24*time.Hour))
+	event.TicketOffers = []domain.TicketOffer{
+		{Source: "ticketmaster", URL: "https://tm.example/1", Status: "onsale", PriceRange: &domain.PriceRange{Min: 40, Max: 90, Currency: "EUR"}},
+		{Source: "bandsintown", URL: "https://bit.example/1"},
+	}
+	if _, err := repo.CreateBatch(ctx, []domain.Event{event}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	found, err := repo.SearchByArtistName(ctx, "Test Artist", nil, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(found) != 1 || len(found[0].TicketOffers) != 2 {
+		t.Fatalf("expected both offers, got %+v", found)
+	}
+	first, second := found[0].TicketOffers[0], found[0].TicketOffers[1]
+	if first.Source != "ticketmaster" || first.Status != "onsale" || first.PriceRange == nil || first.PriceRange.Max != 90 || first.PriceRange.Currency != "EUR" {
+		t.Errorf("expected Ticketmaster's offer first with its prices, got %+v", first)
+	}
+	if second.URL != "https://bit.example/1" || second.PriceRange != nil {
+		t.Errorf("expected Bandsintown's offer without prices, got %+v", second)
+	}
+
+	// Rewriting the event replaces its offers
+	event.TicketOffers = event.TicketOffers[1:]
+	if err := repo.Update(ctx, &event); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	stored, err := repo.GetByID(ctx, "ticketmaster_1")
+	if err != nil {
+		t.Fatalf("failed to get event: %v", err)
+	}
+	if len(stored.TicketOffers) != 1 || stored.TicketOffers[0].Source != "bandsintown" {
+		t.Errorf("expected only Bandsintown's offer after the update, got %+v", stored.TicketOffers)
+	}
+
+	if err := repo.Delete(ctx, "ticketmaster_1"); err != nil {
+		t.Fatalf("failed to delete event: %v", err)
+	}
+	var left int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM event_ticket_offers`).Scan(&left); err != nil {
+		t.Fatalf("failed to count offers: %v", err)
+	}
+	if left != 0 {
+		t.Errorf("expected the offers deleted with the event, got %d", left)
+	}
+}
+
+func TestEventRepository_Sources(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	event := newTestEvent("songkick_1", "Test Artist", time.Now().Add(24*time.Hour))
+	event.Sources = []string{"songkick", "resident_advisor", "fixtures"}
+	event.SourceIDs = map[string]string{"songkick": "1", "resident_advisor": "ra_9"}
+	if _, err := repo.CreateBatch(ctx, []domain.Event{event}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	found, err := repo.SearchByArtistName(ctx, "Test Artist", nil, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(found) != 1 || !slices.Equal(found[0].Sources, event.Sources) {
+		t.Fatalf("expected the sources in order, got %+v", found)
+	}
+	if len(found[0].SourceIDs) != 2 || found[0].SourceIDs["songkick"] != "1" || found[0].SourceIDs["resident_advisor"] != "ra_9" {
+		t.Errorf("expected the sources' IDs, got %v", found[0].SourceIDs)
+	}
+
+	// Rewriting the event replaces its sources
+	event.Sources = []string{"songkick"}
+	if err := repo.Update(ctx, &event); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+	stored, err := repo.GetByID(ctx, "songkick_1")
+	if err != nil {
+		t.Fatalf("failed to get event: %v", err)
+	}
+	if !slices.Equal(stored.Sources, []string{"songkick"}) || len(stored.SourceIDs) != 1 {
+		t.Errorf("expected only Songkick after the update, got %v, %v", stored.Sources, stored.SourceIDs)
+	}
+
+	if err := repo.Delete(ctx, "songkick_1"); err != nil {
+		t.Fatalf("failed to delete event: %v", err)
+	}
+	var left int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM event_sources`).Scan(&left); err != nil {
+		t.Fatalf("failed to count sources: %v", err)
+	}
+	if left != 0 {
+		t.Errorf("expected the sources deleted with the event, got %d", left)
+	}
+}
+
+func TestEventRepository_CreateBatchChanges(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+
+	rescheduled := newTestEvent("e1", "Test Artist", at)
+	moved := newTestEvent("e2", "Test Artist", at)
+	untouched := newTestEvent("e3", "Test Artist", at)
+	changes, err := repo.CreateBatch(ctx, []domain.Event{rescheduled, moved, untouched})
+	if err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+	if len(changes.New) != 3 || len(changes.DateChanged) != 0 || len(changes.VenueChanged) != 0 {
+		t.Fatalf("expected 3 new events, got %+v", changes)
+	}
+	stored, err := repo.GetByID(ctx, "e3")
+	if err != nil {
+		t.Fatalf("failed to get event: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	rescheduled.DateTime = at.Add(24 * time.Hour)
+	moved.Venue = domain.Venue{Name: "Columbiahalle", City: "Berlin", Country: "Germany"}
+	fresh := newTestEvent("e4", "Test Artist", at)
+	changes, err = repo.CreateBatch(ctx, []domain.Event{rescheduled, moved, untouched, fresh})
+	if err != nil {
+		t.Fatalf("failed to re-sync events: %v", err)
+	}
+
+	if len(changes.New) != 1 || changes.New[0].ID != "e4" {
+		t.Errorf("expected only e4 new, got %+v", changes.New)
+	}
+	if len(changes.DateChanged) != 1 || changes.DateChanged[0].Event.ID != "e1" || !changes.DateChanged[0].PreviousDateTime.Equal(at) {
+		t.Errorf("expected e1 rescheduled from %v, got %+v", at, changes.DateChanged)
+	}
+	if len(changes.VenueChanged) != 1 || changes.VenueChanged[0].Event.ID != "e2" || changes.VenueChanged[0].PreviousVenue.Name != "Test Venue" {
+		t.Errorf("expected e2 moved from Test Venue, got %+v", changes.VenueChanged)
+	}
+
+	// The update keeps when the event was first stored
+	updated, err := repo.GetByID(ctx, "e3")
+	if err != nil {
+		t.Fatalf("failed to get event: %v", err)
+	}
+	if !updated.CreatedAt.Equal(stored.CreatedAt) || !updated.UpdatedAt.After(stored.UpdatedAt) {
+		t.Errorf("expected created_at %v kept and updated_at moved on, got %v and %v", stored.CreatedAt, updated.CreatedAt, updated.UpdatedAt)
+	}
+
+	if changes, err := repo.CreateBatch(ctx, []domain.Event{rescheduled, moved, untouched, fresh}); err != nil || !changes.Empty() {
+		t.Errorf("expected an unchanged re-sync to change nothing, got %+v, %v", changes, err)
+	}
+}
+
+func TestEventRepository_ListChanges(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	before := time.Now().Add(-time.Second)
+
+	cancelled := newTestEvent("e1", "Test Artist", at)
+	cancelled.Status = domain.EventScheduled
+	moved := newTestEvent("e2", "Test Artist", at)
+	moved.Status = domain.EventScheduled
+	untouched := newTestEvent("e3", "Test Artist", at)
+	untouched.Status = domain.EventScheduled
+	if _, err := repo.CreateBatch(ctx, []domain.Event{cancelled, moved, untouched}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	cancelled.Status = domain.EventCancelled
+	moved.DateTime = at.Add(7 * 24 * time.Hour)
+	if _, err := repo.CreateBatch(ctx, []domain.Event{cancelled, moved, untouched}); err != nil {
+		t.Fatalf("failed to re-sync events: %v", err)
+	}
+	// Syncing the same state again is not another change
+	if _, err := repo.CreateBatch(ctx, []domain.Event{cancelled, moved, untouched}); err != nil {
+		t.Fatalf("failed to re-sync events: %v", err)
+	}
+
+	changes, err := repo.ListChanges(ctx, domain.DiscoveryFilter{ArtistName: "test artist", Since: &before}, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %+v", changes)
+	}
+
+	byID := map[string]domain.EventChange{}
+	for _, change := range changes {
+		byID[change.Event.ID] = change
+	}
+	if change := byID["e1"]; change.Status != domain.EventCancelled || change.Event.Status != domain.EventCancelled {
+		t.Errorf("expected e1 to be cancelled, got %+v", change)
+	}
+	if change := byID["e2"]; change.Status != domain.EventRescheduled || !change.PreviousDateTime.Equal(at) || !change.Event.DateTime.Equal(moved.DateTime) {
+		t.Errorf("expected e2 to move from %v to %v, got %+v", at, moved.DateTime, change)
+	}
+
+	after := time.Now()
+	if changes, _ := repo.ListChanges(ctx, domain.DiscoveryFilter{Since: &after}, 10); len(changes) != 0 {
+		t.Errorf("expected no changes after %v, got %d", after, len(changes))
+	}
+}
+
+func TestEventRepository_AddsMissingColumns(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	// Schema from before the per-source external ID columns
+	_, err := db.Exec(`
+	CREATE TABLE events (
+		id TEXT PRIMARY KEY,
+		artist_id TEXT NOT NULL,
+		artist_name TEXT NOT NULL,
+		title TEXT,
+		datetime TIMESTAMP NOT NULL,
+		venue_id TEXT,
+		venue_name TEXT NOT NULL,
+		venue_city TEXT NOT NULL,
+		venue_region TEXT,
+		venue_country TEXT NOT NULL,
+		venue_latitude REAL,
+		venue_longitude REAL,
+		ticket_url TEXT,
+		ticket_status TEXT,
+		on_sale_date TIMESTAMP,
+		bandsintown_id TEXT,
+		ticketmaster_id TEXT,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		cached_until TIMESTAMP NOT NULL
+	);
+	INSERT INTO events VALUES ('old', 'a', 'Old Artist', '', CURRENT_TIMESTAMP, '', 'Venue', 'City', '', 'Country',
+		0, 0, '', '', NULL, '', '', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP);
+	`)
+	if err != nil {
+		t.Fatalf("failed to create legacy table: %v", err)
+	}
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("expected migration to succeed, got %v", err)
+	}
+
+	event, err := repo.GetByID(context.Background(), "old")
+	if err != nil {
+		t.Fatalf("expected legacy row to be readable, got %v", err)
+	}
+	if event.ExternalIDs.SongkickID != "" {
+		t.Errorf("expected empty songkick ID, got %s", event.ExternalIDs.SongkickID)
+	}
+	if event.Venue.Capacity != 0 {
+		t.Errorf("expected an unknown capacity, got %d", event.Venue.Capacity)
+	}
+
+	// Running again must not try to re-add the columns
+	if _, err := NewEventRepository(db); err != nil {
+		t.Errorf("expected second migration to be a no-op, got %v", err)
+	}
+}
+
+func TestEventRepository_ListDiscovered(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+
+	first := newTestEvent("first", "Test Artist", now.Add(48*time.Hour))
+	if _, err := repo.CreateBatch(ctx, []domain.Event{first}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	// Make sure the second batch gets a later discovery time
+	time.Sleep(10 * time.Millisecond)
+	betweenBatches := time.Now()
+
+	second := newTestEvent("second", "Test Artist", now.Add(24*time.Hour))
+	second.Venue.City = "Paris"
+	other := newTestEvent("other", "Someone Else", now.Add(24*time.Hour))
+	if _, err := repo.CreateBatch(ctx, []domain.Event{first, second, other}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	t.Run("newest discovery first", func(t *testing.T) {
+		found, err := repo.ListDiscovered(ctx, domain.DiscoveryFilter{ArtistName: "test artist"}, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(found))
+		}
+		if found[0].ID != "second" || found[1].ID != "first" {
+			t.Errorf("expected second then first, got %s then %s", found[0].ID, found[1].ID)
+		}
+		if found[1].DiscoveredAt == nil || !found[1].DiscoveredAt.Before(*found[0].DiscoveredAt) {
+			t.Error("expected storing an event again to keep its discovery time")
+		}
+	})
+
+	t.Run("artist ID or name", func(t *testing.T) {
+		found, err := repo.ListDiscovered(ctx, domain.DiscoveryFilter{ArtistID: other.ArtistID, ArtistName: "Test Artist"}, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 3 {
+			t.Errorf("expected 3 events, got %d", len(found))
+		}
+	})
+
+	t.Run("discovered since", func(t *testing.T) {
+		found, err := repo.ListDiscovered(ctx, domain.DiscoveryFilter{ArtistName: "Test Artist", Since: &betweenBatches}, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 1 || found[0].ID != "second" {
+			t.Errorf("expected only the later discovery, got %+v", found)
+		}
+	})
+
+	t.Run("by city with limit", func(t *testing.T) {
+		found, err := repo.ListDiscovered(ctx, domain.DiscoveryFilter{City: "berlin"}, 1)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 1 || found[0].Venue.City != "Berlin" {
+			t.Errorf("expected one Berlin event, got %+v", found)
+		}
+	})
+
+	t.Run("discovery survives cache expiry", func(t *testing.T) {
+		if err := repo.Delete(ctx, "first"); err != nil {
+			t.Fatalf("failed to delete event: %v", err)
+		}
+		if _, err := repo.CreateBatch(ctx, []domain.Event{first}); err != nil {
+			t.Fatalf("failed to store events: %v", err)
+		}
+
+		found, err := repo.ListDiscovered(ctx, domain.DiscoveryFilter{ArtistName: "Test Artist"}, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if found[0].ID != "second" {
+			t.Errorf("expected re-stored event to keep its place, got %s first", found[0].ID)
+		}
+	})
+}
+
+func TestEventRepository_ListUpcomingOnSales(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	onSale := func(id, artist string, in time.Duration) domain.Event {
+		event := newTestEvent(id, artist, now.Add(30*24*time.Hour))
+		at := now.Add(in)
+		event.OnSaleDate = &at
+		return event
+	}
+
+	later := onSale("later", "Test Artist", 3*time.Hour)
+	soon := onSale("soon", "Test Artist", time.Hour)
+	soon.Venue.City = "Paris"
+	past := onSale("past", "Test Artist", -time.Hour)
+	other := onSale("other", "Someone Else", 2*time.Hour)
+	unknown := newTestEvent("unknown", "Test Artist", now.Add(24*time.Hour))
+
+	if _, err := repo.CreateBatch(ctx, []domain.Event{later, soon, past, other, unknown}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	window := domain.OnSaleFilter{From: now, To: now.Add(24 * time.Hour)}
+
+	t.Run("soonest first within window", func(t *testing.T) {
+		found, err := repo.ListUpcomingOnSales(ctx, window, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 3 {
+			t.Fatalf("expected 3 events, got %d", len(found))
+		}
+		if found[0].ID != "soon" || found[1].ID != "other" || found[2].ID != "later" {
+			t.Errorf("expected soon, other, later; got %s, %s, %s", found[0].ID, found[1].ID, found[2].ID)
+		}
+	})
+
+	t.Run("by artist and city", func(t *testing.T) {
+		filter := window
+		filter.ArtistName = "test artist"
+		filter.City = "berlin"
+
+		found, err := repo.ListUpcomingOnSales(ctx, filter, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 1 || found[0].ID != "later" {
+			t.Errorf("expected only the Berlin event, got %+v", found)
+		}
+	})
+
+	t.Run("window end", func(t *testing.T) {
+		filter := window
+		filter.To = now.Add(90 * time.Minute)
+
+		found, err := repo.ListUpcomingOnSales(ctx, filter, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 1 || found[0].ID != "soon" {
+			t.Errorf("expected only the next on-sale, got %+v", found)
+		}
+	})
+}
+
+func TestEventRepository_GetPriceHistory(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	event := newTestEvent("priced", "Test Artist", time.Now().Add(24*time.Hour))
+	event.PriceRanges = []domain.PriceRange{
+		{Type: "standard", Min: 35, Max: 55, Currency: "EUR"},
+		{Type: "vip", Min: 120, Max: 120, Currency: "EUR"},
+	}
+	if _, err := repo.CreateBatch(ctx, []domain.Event{event}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	event.PriceRanges = []domain.PriceRange{{Type: "standard", Min: 45, Max: 65, Currency: "EUR"}}
+	if err := repo.Update(ctx, &event); err != nil {
+		t.Fatalf("failed to update event: %v", err)
+	}
+
+	history, err := repo.GetPriceHistory(ctx, "priced")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("expected 2 snapshots, got %d", len(history))
+	}
+	if len(history[0].Ranges) != 2 || history[0].Ranges[1].Type != "vip" {
+		t.Errorf("expected both ranges in the first snapshot, got %+v", history[0].Ranges)
+	}
+	if len(history[1].Ranges) != 1 || history[1].Ranges[0].Min != 45 {
+		t.Errorf("expected the raised price in the second snapshot, got %+v", history[1].Ranges)
+	}
+	if !history[0].RecordedAt.Before(history[1].RecordedAt) {
+		t.Error("expected snapshots oldest first")
+	}
+
+	t.Run("unpriced event", func(t *testing.T) {
+		history, err := repo.GetPriceHistory(ctx, "missing")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(history) != 0 {
+			t.Errorf("expected no history, got %+v", history)
+		}
+	})
+}
+
+func TestEventRepository_Each(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+	paris := newTestEvent("paris", "Test Artist", now.Add(72*time.Hour))
+	paris.Venue.City = "Paris"
+	events := []domain.Event{
+		newTestEvent("later", "Test Artist", now.Add(48*time.Hour)),
+		newTestEvent("sooner", "Test Artist", now.Add(24*time.Hour)),
+		newTestEvent("past", "Test Artist", now.Add(-24*time.Hour)),
+		newTestEvent("other", "Someone Else", now.Add(24*time.Hour)),
+		paris,
+	}
+	if _, err := repo.CreateBatch(ctx, events); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	collect := func(filter domain.EventFilter) []string {
+		ids := []string{}
+		if err := repo.Each(ctx, filter, func(event domain.Event) error {
+			ids = append(ids, event.ID)
+			return nil
+		}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return ids
+	}
+
+	t.Run("filters and date order", func(t *testing.T) {
+		ids := collect(domain.EventFilter{Artist: "test artist", City: "berlin", From: &now})
+		if strings.Join(ids, ",") != "sooner,later" {
+			t.Errorf("expected sooner,later, got %v", ids)
+		}
+	})
+
+	t.Run("artist ID", func(t *testing.T) {
+		ids := collect(domain.EventFilter{Artist: "songkick_artist_other"})
+		if strings.Join(ids, ",") != "other" {
+			t.Errorf("expected other, got %v", ids)
+		}
+	})
+
+	t.Run("city under another name", func(t *testing.T) {
+		ids := collect(domain.EventFilter{City: "Parigi"})
+		if strings.Join(ids, ",") != "paris" {
+			t.Errorf("expected paris, got %v", ids)
+		}
+	})
+
+	t.Run("no filter", func(t *testing.T) {
+		if ids := collect(domain.EventFilter{}); len(ids) != 5 {
+			t.Errorf("expected 5 events, got %v", ids)
+		}
+	})
+
+	t.Run("callback error stops iteration", func(t *testing.T) {
+		stop := errors.New("stop")
+		calls := 0
+		err := repo.Each(ctx, domain.EventFilter{}, func(event domain.Event) error {
+			calls++
+			return stop
+		})
+		if err != stop || calls != 1 {
+			t.Errorf("expected to stop after one call, got %d calls and %v", calls, err)
+		}
+	})
+}
+
+func TestEventRepository_PurgeExpired(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(24 * time.Hour)
+	expired := newTestEvent("expired", "Test Artist", at)
+	expired.CachedUntil = time.Now().Add(-time.Minute)
+	if _, err := repo.CreateBatch(ctx, []domain.Event{expired, newTestEvent("fresh", "Test Artist", at)}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	purged, err := repo.PurgeExpired(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("expected 1 event purged, got %d", purged)
+	}
+	if _, err := repo.GetByID(ctx, "expired"); !errors.Is(err, domain.ErrEventNotFound) {
+		t.Errorf("expected the expired event to be gone, got %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "fresh"); err != nil {
+		t.Errorf("expected the fresh event to stay, got %v", err)
+	}
+}
+
+func TestEventRepository_Archive(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(-30 * 24 * time.Hour).Truncate(time.Second)
+	played := newTestEvent("played", "Test Artist", at)
+	played.CachedUntil = time.Now().Add(-time.Minute)
+	deleted := newTestEvent("deleted", "Other Artist", at.Add(24*time.Hour))
+	if _, err := repo.CreateBatch(ctx, []domain.Event{played, deleted, newTestEvent("fresh", "Test Artist", at)}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	if _, err := repo.PurgeExpired(ctx); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := repo.Delete(ctx, "deleted"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	archived, err := repo.ListArchived(ctx, domain.EventFilter{}, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(archived) != 2 || archived[0].ID != "deleted" || archived[1].ID != "played" {
+		t.Fatalf("expected the deleted and expired events latest first, got %+v", archived)
+	}
+	if archived[1].ArchivedAt == nil || !archived[1].DateTime.Equal(at) || archived[1].Venue.Name != "Test Venue" {
+		t.Errorf("expected the event as it was stored, got %+v", archived[1])
+	}
+
+	if archived, _ := repo.ListArchived(ctx, domain.EventFilter{Artist: "test artist", City: "Berlin"}, 10); len(archived) != 1 || archived[0].ID != "played" {
+		t.Errorf("expected only the artist's event, got %+v", archived)
+	}
+	from := at.Add(time.Hour)
+	if archived, _ := repo.ListArchived(ctx, domain.EventFilter{From: &from}, 10); len(archived) != 1 || archived[0].ID != "deleted" {
+		t.Errorf("expected only the later event, got %+v", archived)
+	}
+
+	// Storing an archived event again takes it out of the archive
+	if _, err := repo.CreateBatch(ctx, []domain.Event{deleted}); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+	if archived, _ := repo.ListArchived(ctx, domain.EventFilter{}, 10); len(archived) != 1 || archived[0].ID != "played" {
+		t.Errorf("expected the stored event out of the archive, got %+v", archived)
+	}
+}
+
+func TestEventRepository_Feed(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	first, second := newTestEvent("first", "Test Artist", at), newTestEvent("second", "Other Artist", at)
+	if _, err := repo.CreateBatch(ctx, []domain.Event{first, second}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	// Found again as it was: only the cache window moves, nothing to record
+	first.CachedUntil = time.Now().Add(2 * time.Hour)
+	if _, err := repo.CreateBatch(ctx, []domain.Event{first}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	first.TicketURL = "https://tickets.example.com/first"
+	second.Status = domain.EventCancelled
+	if _, err := repo.CreateBatch(ctx, []domain.Event{first, second}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+	if err := repo.Delete(ctx, "first"); err != nil {
+		t.Fatalf("failed to delete event: %v", err)
+	}
+
+	entries, err := repo.ListFeed(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var got []string
+	for _, entry := range entries {
+		got = append(got, entry.EventID+":"+string(entry.Kind))
+	}
+	want := []string{"first:create", "second:create", "first:update", "second:cancel", "first:delete"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Cursor <= entries[i-1].Cursor {
+			t.Errorf("expected cursors to increase, got %d after %d", entries[i].Cursor, entries[i-1].Cursor)
+		}
+	}
+	if entries[0].Event != nil || entries[4].Event != nil {
+		t.Error("expected no event for a deleted one")
+	}
+	if entries[3].Event == nil || entries[3].Event.Status != domain.EventCancelled {
+		t.Errorf("expected the cancelled event as stored now, got %+v", entries[3].Event)
+	}
+
+	page, err := repo.ListFeed(ctx, entries[1].Cursor, 2)
+	if err != nil || len(page) != 2 || page[0].Cursor != entries[2].Cursor {
+		t.Errorf("expected the two entries after the cursor, got %+v, %v", page, err)
+	}
+
+	maintainer, err := NewMaintainer(db, MaintainerConfig{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	maintainer.now = func() time.Time { return time.Now().Add(31 * 24 * time.Hour) }
+	if pruned, err := maintainer.PruneFeed(ctx); err != nil || pruned != 5 {
+		t.Fatalf("expected the feed pruned, got %d, %v", pruned, err)
+	}
+
+	// The latest cursor has missed nothing; older ones have
+	last := entries[4].Cursor
+	if page, err := repo.ListFeed(ctx, last, 10); err != nil || len(page) != 0 {
+		t.Errorf("expected no entries after the latest cursor, got %+v, %v", page, err)
+	}
+	if _, err := repo.ListFeed(ctx, last-1, 10); !errors.Is(err, domain.ErrCursorExpired) {
+		t.Errorf("expected an expired cursor, got %v", err)
+	}
+	if _, err := repo.ListFeed(ctx, 0, 10); err != nil {
+		t.Errorf("expected starting over to work, got %v", err)
+	}
+}
+
+func TestEventRepository_Venues(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	songkick := newTestEvent("songkick", "Test Artist", at)
+	songkick.Venue.Phone = "+49 30 123456"
+	songkick.Venue.Website = "https://testvenue.example"
+	ticketmaster := newTestEvent("ticketmaster", "Other Artist", at.Add(24*time.Hour))
+	ticketmaster.Venue.Name = "TEST VENUE"
+	ticketmaster.Venue.Capacity = 1500
+	ticketmaster.Venue.Address = "Teststraße 1, 10115"
+	past := newTestEvent("past", "Test Artist", time.Now().Add(-24*time.Hour))
+	elsewhere := newTestEvent("elsewhere", "Test Artist", at)
+	elsewhere.Venue.Name = "Other Venue"
+	elsewhere.Venue.City = "Hamburg"
+
+	if err := repo.Create(ctx, &songkick); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+	if _, err := repo.CreateBatch(ctx, []domain.Event{ticketmaster, past, elsewhere}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	// Each source's details are kept, a blank one not replacing them
+	venue, err := repo.GetVenue(ctx, "test-venue-berlin")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if venue.Capacity != 1500 || venue.Address != "Teststraße 1, 10115" || venue.Phone != "+49 30 123456" || venue.Website != "https://testvenue.example" {
+		t.Errorf("expected the details merged from both sources, got %+v", venue)
+	}
+	if venue.UpcomingEvents != 2 {
+		t.Errorf("expected 2 upcoming events, got %d", venue.UpcomingEvents)
+	}
+	if _, err := repo.GetVenue(ctx, "nowhere-berlin"); err != domain.ErrVenueNotFound {
+		t.Errorf("expected ErrVenueNotFound, got %v", err)
+	}
+
+	venues, err := repo.ListVenues(ctx, domain.VenueFilter{}, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(venues) != 2 || venues[0].Slug != "test-venue-berlin" || venues[1].Slug != "other-venue-hamburg" {
+		t.Errorf("expected the venues with the most upcoming events first, got %+v", venues)
+	}
+	if venues, _ := repo.ListVenues(ctx, domain.VenueFilter{City: "hamburg"}, 10); len(venues) != 1 || venues[0].Name != "Other Venue" {
+		t.Errorf("expected only the Hamburg venue, got %+v", venues)
+	}
+	if venues, _ := repo.ListVenues(ctx, domain.VenueFilter{Query: "test ven"}, 10); len(venues) != 1 || venues[0].Slug != "test-venue-berlin" {
+		t.Errorf("expected only the matching venue, got %+v", venues)
+	}
+
+	events, err := repo.ListVenueEvents(ctx, "test-venue-berlin", time.Now(), 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(events) != 2 || events[0].ID != "songkick" || events[1].ID != "ticketmaster" {
+		t.Fatalf("expected the venue's upcoming events soonest first, got %+v", events)
+	}
+
+	// Events read back carry the venue's details from every source
+	stored, err := repo.GetByID(ctx, "songkick")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stored.Venue.Capacity != 1500 || stored.Venue.Phone != "+49 30 123456" || stored.Venue.Address != "Teststraße 1, 10115" {
+		t.Errorf("expected the venue's merged details, got %+v", stored.Venue)
+	}
+}
+
+func TestEventRepository_MergeVenues(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	venue := func(id, name string, lat, lng float64) domain.Event {
+		event := newTestEvent(id, "Test Artist", at)
+		event.Venue.Name = name
+		event.Venue.Latitude, event.Venue.Longitude = lat, lng
+		return event
+	}
+	berghain := venue("berghain_1", "Berghain", 52.5111, 13.4432)
+	berghain2 := venue("berghain_2", "Berghain", 52.5111, 13.4432)
+	panorama := venue("panorama", "Berghain / Panorama Bar", 52.5112, 13.4430)
+	panorama.Venue.Capacity = 1500
+	far := venue("far", "Berghain Kantine", 52.4, 13.2)
+	other := venue("other", "SO36", 52.5003, 13.4220)
+	if _, err := repo.CreateBatch(ctx, []domain.Event{berghain, berghain2, panorama, far, other}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	duplicates, err := repo.FindDuplicateVenues(ctx, domain.VenueDuplicateFilter{}, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(duplicates) != 1 {
+		t.Fatalf("expected only the nearby Berghain pair, got %+v", duplicates)
+	}
+	if duplicates[0].Venue.Slug != "berghain-berlin" || duplicates[0].Duplicate.Slug != "berghain-panorama-bar-berlin" {
+		t.Errorf("expected the venue with more events kept, got %+v", duplicates[0])
+	}
+	if duplicates[0].Similarity != 1 || duplicates[0].DistanceKm == nil || *duplicates[0].DistanceKm > 0.1 {
+		t.Errorf("unexpected similarity %v and distance %v", duplicates[0].Similarity, duplicates[0].DistanceKm)
+	}
+	if duplicates, _ := repo.FindDuplicateVenues(ctx, domain.VenueDuplicateFilter{MaxDistanceKm: 50}, 10); len(duplicates) != 2 || duplicates[1].Duplicate.Slug != "berghain-kantine-berlin" {
+		t.Errorf("expected the far venue within a wider distance, got %+v", duplicates)
+	}
+
+	if _, err := repo.MergeVenues(ctx, "nowhere-berlin", "berghain-berlin"); err != domain.ErrVenueNotFound {
+		t.Errorf("expected ErrVenueNotFound, got %v", err)
+	}
+	merge, err := repo.MergeVenues(ctx, "berghain-panorama-bar-berlin", "berghain-berlin")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if merge.Events != 1 || merge.FromName != "Berghain / Panorama Bar" {
+		t.Errorf("unexpected merge %+v", merge)
+	}
+
+	kept, err := repo.GetVenue(ctx, "berghain-berlin")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if kept.UpcomingEvents != 3 || kept.Capacity != 1500 || kept.Name != "Berghain" {
+		t.Errorf("expected the merged events and details, got %+v", kept)
+	}
+	if _, err := repo.GetVenue(ctx, "berghain-panorama-bar-berlin"); err != domain.ErrVenueNotFound {
+		t.Errorf("expected the duplicate gone, got %v", err)
+	}
+
+	// A later sync of the merged venue's event stays with the kept venue
+	panorama.Venue.Phone = "+49 30 000000"
+	if _, err := repo.CreateBatch(ctx, []domain.Event{panorama}); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+	if _, err := repo.GetVenue(ctx, "berghain-panorama-bar-berlin"); err != domain.ErrVenueNotFound {
+		t.Errorf("expected the duplicate not recreated, got %v", err)
+	}
+	stored, _ := repo.GetByID(ctx, "panorama")
+	if stored.Venue.Phone != "+49 30 000000" || stored.Venue.Name != "Berghain / Panorama Bar" {
+		t.Errorf("expected the event at the kept venue with its own name, got %+v", stored.Venue)
+	}
+	if events, _ := repo.ListVenueEvents(ctx, "berghain-berlin", time.Now(), 10); len(events) != 3 {
+		t.Errorf("expected 3 events at the kept venue, got %d", len(events))
+	}
+
+	// Merging the kept venue on repoints the earlier merge
+	if _, err := repo.MergeVenues(ctx, "berghain-berlin", "so36-berlin"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	merges, err := repo.ListVenueMerges(ctx, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(merges) != 2 || merges[0].From != "berghain-berlin" || merges[1].Into != "so36-berlin" {
+		t.Errorf("expected both merges into the last venue, newest first, got %+v", merges)
+	}
+}
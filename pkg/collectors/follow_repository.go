@@ -0,0 +1,138 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// FollowRepository stores the artists each user follows
+type FollowRepository struct {
+	db *timedDB
+}
+
+func NewFollowRepository(db *sql.DB) (*FollowRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &FollowRepository{db: newTimedDB(db, "user_follows")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *FollowRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS user_follows (
+		user_id TEXT NOT NULL,
+		artist_name TEXT NOT NULL COLLATE NOCASE,
+		artist_id TEXT,
+		created_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (user_id, artist_name)
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// Follow adds an artist to the user's follows. Following an artist again
+// only fills in an artist ID that wasn't known before.
+func (r *FollowRepository) Follow(ctx context.Context, follow *domain.Follow) error {
+	if follow == nil || follow.UserID == "" || strings.TrimSpace(follow.ArtistName) == "" {
+		return fmt.Errorf("user ID and artist name are required")
+	}
+
+	query := `
+	INSERT INTO user_follows (user_id, artist_name, artist_id, created_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(user_id, artist_name) DO UPDATE SET
+		artist_id = COALESCE(NULLIF(excluded.artist_id, ''), user_follows.artist_id)
+	`
+
+	follow.ArtistName = strings.TrimSpace(follow.ArtistName)
+	follow.CreatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, query, follow.UserID, follow.ArtistName, follow.ArtistID, follow.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to follow artist: %w", err)
+	}
+
+	return nil
+}
+
+func (r *FollowRepository) Unfollow(ctx context.Context, userID, artistName string) error {
+	query := `DELETE FROM user_follows WHERE user_id = ? AND artist_name = ?`
+
+	result, err := r.db.ExecContext(ctx, query, userID, strings.TrimSpace(artistName))
+	if err != nil {
+		return fmt.Errorf("failed to unfollow artist: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrFollowNotFound
+	}
+
+	return nil
+}
+
+func (r *FollowRepository) ListFollows(ctx context.Context, userID string) ([]domain.Follow, error) {
+	query := `
+	SELECT user_id, artist_name, artist_id, created_at
+	FROM user_follows
+	WHERE user_id = ?
+	ORDER BY artist_name
+	`
+
+	return r.listFollows(ctx, query, userID)
+}
+
+// ListAllFollows returns every user's follows, grouped by artist
+func (r *FollowRepository) ListAllFollows(ctx context.Context) ([]domain.Follow, error) {
+	query := `
+	SELECT user_id, artist_name, artist_id, created_at
+	FROM user_follows
+	ORDER BY artist_name COLLATE NOCASE, user_id
+	`
+
+	return r.listFollows(ctx, query)
+}
+
+func (r *FollowRepository) listFollows(ctx context.Context, query string, args ...any) ([]domain.Follow, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list follows: %w", err)
+	}
+	defer rows.Close()
+
+	follows := []domain.Follow{}
+	for rows.Next() {
+		var follow domain.Follow
+		var artistID sql.NullString
+
+		if err := rows.Scan(&follow.UserID, &follow.ArtistName, &artistID, &follow.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan follow: %w", err)
+		}
+		follow.ArtistID = artistID.String
+
+		follows = append(follows, follow)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate follows: %w", err)
+	}
+
+	return follows, nil
+}
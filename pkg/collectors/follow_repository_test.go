@@ -0,0 +1,85 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestFollowRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewFollowRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	t.Run("follow and list", func(t *testing.T) {
+		for _, follow := range []*domain.Follow{
+			{UserID: "user_1", ArtistName: "Radiohead"},
+			{UserID: "user_1", ArtistName: " Bicep "},
+			{UserID: "user_2", ArtistName: "Radiohead"},
+		} {
+			if err := repo.Follow(ctx, follow); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		follows, err := repo.ListFollows(ctx, "user_1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(follows) != 2 || follows[0].ArtistName != "Bicep" || follows[1].ArtistName != "Radiohead" {
+			t.Errorf("expected Bicep and Radiohead, got %+v", follows)
+		}
+	})
+
+	t.Run("following again fills in the artist ID", func(t *testing.T) {
+		if err := repo.Follow(ctx, &domain.Follow{UserID: "user_1", ArtistName: "RADIOHEAD", ArtistID: "artist_1"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.Follow(ctx, &domain.Follow{UserID: "user_1", ArtistName: "radiohead"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		follows, _ := repo.ListFollows(ctx, "user_1")
+		if len(follows) != 2 || follows[1].ArtistID != "artist_1" {
+			t.Errorf("expected one Radiohead follow with its ID, got %+v", follows)
+		}
+	})
+
+	t.Run("unfollow", func(t *testing.T) {
+		if err := repo.Unfollow(ctx, "user_1", "radiohead"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.Unfollow(ctx, "user_1", "Radiohead"); !errors.Is(err, domain.ErrFollowNotFound) {
+			t.Errorf("expected ErrFollowNotFound, got %v", err)
+		}
+
+		others, _ := repo.ListFollows(ctx, "user_2")
+		if len(others) != 1 {
+			t.Errorf("expected other users' follows to stay, got %+v", others)
+		}
+	})
+
+	t.Run("list all follows", func(t *testing.T) {
+		follows, err := repo.ListAllFollows(ctx)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(follows) != 2 || follows[0].UserID != "user_1" || follows[1].UserID != "user_2" {
+			t.Errorf("expected every user's follows, got %+v", follows)
+		}
+	})
+
+	t.Run("artist name is required", func(t *testing.T) {
+		if err := repo.Follow(ctx, &domain.Follow{UserID: "user_1", ArtistName: "  "}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
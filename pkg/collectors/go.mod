@@ -7,6 +7,15 @@ toolchain go1.24.5
 require (
 	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/yair/where-its-at/pkg/domain v0.0.0
+	go.opentelemetry.io/otel v1.35.0
+	go.opentelemetry.io/otel/trace v1.35.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
 )
 
 replace github.com/yair/where-its-at/pkg/domain => ../domain
@@ -0,0 +1,220 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// JobRepository keeps the job queue in SQLite, so queued work survives a
+// restart and is shared by instances using the same database
+type JobRepository struct {
+	db *timedDB
+}
+
+func NewJobRepository(db *sql.DB) (*JobRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &JobRepository{db: newTimedDB(db, "jobs")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *JobRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		dedupe_key TEXT NOT NULL DEFAULT '',
+		payload TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL,
+		last_error TEXT NOT NULL DEFAULT '',
+		run_at TIMESTAMP NOT NULL,
+		locked_until TIMESTAMP,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		finished_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_jobs_status_run_at ON jobs(status, run_at);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active_key ON jobs(dedupe_key)
+		WHERE dedupe_key != '' AND status IN ('queued', 'running');
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *JobRepository) Enqueue(ctx context.Context, job *domain.Job) (*domain.Job, error) {
+	if job == nil || job.ID == "" || job.Kind == "" {
+		return nil, fmt.Errorf("job ID and kind are required")
+	}
+
+	query := `
+	INSERT INTO jobs (id, kind, dedupe_key, payload, status, max_attempts, run_at, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		job.ID, job.Kind, job.Key, string(job.Payload), domain.JobQueued, job.MaxAttempts,
+		job.RunAt.UTC(), job.CreatedAt.UTC(), job.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected > 0 {
+		return r.Get(ctx, job.ID)
+	}
+
+	// The key is taken by a job that's still queued or running
+	row := r.db.QueryRowContext(ctx, `
+	SELECT `+jobColumns+` FROM jobs
+	WHERE dedupe_key = ? AND status IN ('queued', 'running')
+	`, job.Key)
+	existing, err := scanJob(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queued job: %w", err)
+	}
+	return existing, nil
+}
+
+func (r *JobRepository) Get(ctx context.Context, id string) (*domain.Job, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id)
+	job, err := scanJob(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// Claim picks the job in one statement, so two workers can't both claim it.
+// A running job whose lease expired belongs to a worker that died.
+func (r *JobRepository) Claim(ctx context.Context, now time.Time, lease time.Duration) (*domain.Job, error) {
+	now = now.UTC()
+	query := `
+	UPDATE jobs
+	SET status = 'running', attempts = attempts + 1, locked_until = ?, updated_at = ?
+	WHERE id = (
+		SELECT id FROM jobs
+		WHERE (status = 'queued' AND run_at <= ?) OR (status = 'running' AND locked_until <= ?)
+		ORDER BY run_at ASC
+		LIMIT 1
+	)
+	RETURNING id
+	`
+
+	var id string
+	err := r.db.QueryRowContext(ctx, query, now.Add(lease), now, now, now).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	return r.Get(ctx, id)
+}
+
+func (r *JobRepository) Complete(ctx context.Context, id string, finishedAt time.Time) error {
+	query := `
+	UPDATE jobs
+	SET status = 'succeeded', locked_until = NULL, updated_at = ?, finished_at = ?
+	WHERE id = ?
+	`
+
+	return r.update(ctx, query, finishedAt.UTC(), finishedAt.UTC(), id)
+}
+
+func (r *JobRepository) Retry(ctx context.Context, id, message string, runAt time.Time) error {
+	query := `
+	UPDATE jobs
+	SET status = 'queued', last_error = ?, run_at = ?, locked_until = NULL, updated_at = ?
+	WHERE id = ?
+	`
+
+	return r.update(ctx, query, message, runAt.UTC(), time.Now().UTC(), id)
+}
+
+func (r *JobRepository) Bury(ctx context.Context, id, message string, finishedAt time.Time) error {
+	query := `
+	UPDATE jobs
+	SET status = 'dead', last_error = ?, locked_until = NULL, updated_at = ?, finished_at = ?
+	WHERE id = ?
+	`
+
+	return r.update(ctx, query, message, finishedAt.UTC(), finishedAt.UTC(), id)
+}
+
+func (r *JobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM jobs WHERE status IN ('succeeded', 'dead') AND finished_at < ?`
+
+	result, err := r.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *JobRepository) update(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrJobNotFound
+	}
+
+	return nil
+}
+
+const jobColumns = `id, kind, dedupe_key, payload, status, attempts, max_attempts, last_error, run_at, created_at, updated_at, finished_at`
+
+func scanJob(row *sql.Row) (*domain.Job, error) {
+	var job domain.Job
+	var payload string
+	var finishedAt sql.NullTime
+
+	err := row.Scan(
+		&job.ID, &job.Kind, &job.Key, &payload, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.LastError, &job.RunAt, &job.CreatedAt, &job.UpdatedAt, &finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if payload != "" {
+		job.Payload = []byte(payload)
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,151 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewJobRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewJobRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestJobRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewJobRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	newJob := func(id, key string, runAt time.Time) *domain.Job {
+		return &domain.Job{
+			ID:          id,
+			Kind:        "build_profile",
+			Key:         key,
+			Payload:     json.RawMessage(`{"artist_id":"a"}`),
+			MaxAttempts: 3,
+			RunAt:       runAt,
+			CreatedAt:   now,
+		}
+	}
+
+	job, err := repo.Enqueue(ctx, newJob("job_1", "profile:a", now))
+	if err != nil {
+		t.Fatalf("failed to enqueue: %v", err)
+	}
+	if job.Status != domain.JobQueued || string(job.Payload) != `{"artist_id":"a"}` || job.Attempts != 0 {
+		t.Errorf("unexpected job %+v", job)
+	}
+
+	t.Run("same key returns the queued job", func(t *testing.T) {
+		job, err := repo.Enqueue(ctx, newJob("job_2", "profile:a", now))
+		if err != nil {
+			t.Fatalf("failed to enqueue: %v", err)
+		}
+		if job.ID != "job_1" {
+			t.Errorf("expected the queued job back, got %s", job.ID)
+		}
+	})
+
+	t.Run("claims due jobs in order", func(t *testing.T) {
+		if _, err := repo.Enqueue(ctx, newJob("job_3", "", now.Add(time.Hour))); err != nil {
+			t.Fatalf("failed to enqueue: %v", err)
+		}
+
+		claimed, err := repo.Claim(ctx, now, time.Minute)
+		if err != nil {
+			t.Fatalf("failed to claim: %v", err)
+		}
+		if claimed == nil || claimed.ID != "job_1" || claimed.Status != domain.JobRunning || claimed.Attempts != 1 {
+			t.Fatalf("expected job_1 running on its first attempt, got %+v", claimed)
+		}
+
+		// job_3 isn't due yet and job_1 is leased
+		if claimed, err := repo.Claim(ctx, now, time.Minute); err != nil || claimed != nil {
+			t.Fatalf("expected nothing to claim, got %+v, %v", claimed, err)
+		}
+	})
+
+	t.Run("running jobs keep their key", func(t *testing.T) {
+		job, err := repo.Enqueue(ctx, newJob("job_4", "profile:a", now))
+		if err != nil || job.ID != "job_1" {
+			t.Errorf("expected the running job back, got %+v, %v", job, err)
+		}
+	})
+
+	t.Run("an expired lease is claimed again", func(t *testing.T) {
+		claimed, err := repo.Claim(ctx, now.Add(2*time.Minute), time.Minute)
+		if err != nil {
+			t.Fatalf("failed to claim: %v", err)
+		}
+		if claimed == nil || claimed.ID != "job_1" || claimed.Attempts != 2 {
+			t.Fatalf("expected job_1 on its second attempt, got %+v", claimed)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		if err := repo.Retry(ctx, "job_1", "sources down", now.Add(10*time.Minute)); err != nil {
+			t.Fatalf("failed to retry: %v", err)
+		}
+		job, _ := repo.Get(ctx, "job_1")
+		if job.Status != domain.JobQueued || job.LastError != "sources down" {
+			t.Errorf("expected job_1 queued again, got %+v", job)
+		}
+		if claimed, _ := repo.Claim(ctx, now.Add(5*time.Minute), time.Minute); claimed != nil {
+			t.Errorf("expected the retry to wait, got %+v", claimed)
+		}
+	})
+
+	t.Run("complete and bury", func(t *testing.T) {
+		if err := repo.Complete(ctx, "job_1", now); err != nil {
+			t.Fatalf("failed to complete: %v", err)
+		}
+		if err := repo.Bury(ctx, "job_3", "gave up", now.Add(time.Hour)); err != nil {
+			t.Fatalf("failed to bury: %v", err)
+		}
+
+		done, _ := repo.Get(ctx, "job_1")
+		dead, _ := repo.Get(ctx, "job_3")
+		if done.Status != domain.JobSucceeded || done.FinishedAt == nil || !done.Done() {
+			t.Errorf("expected job_1 succeeded, got %+v", done)
+		}
+		if dead.Status != domain.JobDead || dead.LastError != "gave up" || !dead.Done() {
+			t.Errorf("expected job_3 dead, got %+v", dead)
+		}
+
+		// A finished job frees its key
+		job, err := repo.Enqueue(ctx, newJob("job_5", "profile:a", now))
+		if err != nil || job.ID != "job_5" {
+			t.Errorf("expected a new job, got %+v, %v", job, err)
+		}
+	})
+
+	t.Run("delete finished", func(t *testing.T) {
+		deleted, err := repo.DeleteFinishedBefore(ctx, now.Add(30*time.Minute))
+		if err != nil || deleted != 1 {
+			t.Fatalf("expected job_1 deleted, got %d, %v", deleted, err)
+		}
+		if _, err := repo.Get(ctx, "job_1"); !errors.Is(err, domain.ErrJobNotFound) {
+			t.Errorf("expected job_1 gone, got %v", err)
+		}
+	})
+
+	t.Run("unknown job", func(t *testing.T) {
+		if err := repo.Complete(ctx, "missing", now); !errors.Is(err, domain.ErrJobNotFound) {
+			t.Errorf("expected ErrJobNotFound, got %v", err)
+		}
+	})
+}
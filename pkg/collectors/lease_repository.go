@@ -0,0 +1,81 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LeaseRepository keeps leases in a row per name, for instances sharing the
+// database
+type LeaseRepository struct {
+	db  *timedDB
+	now func() time.Time
+}
+
+func NewLeaseRepository(db *sql.DB) (*LeaseRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &LeaseRepository{db: newTimedDB(db, "leases"), now: time.Now}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *LeaseRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS leases (
+		name TEXT PRIMARY KEY,
+		holder TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// Acquire takes the lease in a single upsert, so two instances racing for an
+// expired lease can't both win it
+func (r *LeaseRepository) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if name == "" || holder == "" {
+		return false, fmt.Errorf("lease name and holder are required")
+	}
+
+	now := r.now().UTC()
+	query := `
+	INSERT INTO leases (name, holder, expires_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(name) DO UPDATE SET
+		holder = excluded.holder,
+		expires_at = excluded.expires_at
+	WHERE leases.holder = excluded.holder OR leases.expires_at <= ?
+	`
+
+	result, err := r.db.ExecContext(ctx, query, name, holder, now.Add(ttl), now)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *LeaseRepository) Release(ctx context.Context, name, holder string) error {
+	query := `DELETE FROM leases WHERE name = ? AND holder = ?`
+
+	if _, err := r.db.ExecContext(ctx, query, name, holder); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewLeaseRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewLeaseRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestLeaseRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewLeaseRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+
+	acquire := func(holder string) bool {
+		t.Helper()
+		ok, err := repo.Acquire(ctx, "sync", holder, time.Minute)
+		if err != nil {
+			t.Fatalf("failed to acquire lease: %v", err)
+		}
+		return ok
+	}
+
+	if !acquire("a") {
+		t.Fatal("expected a to acquire the free lease")
+	}
+	if acquire("b") {
+		t.Fatal("expected b to be refused while a holds the lease")
+	}
+
+	t.Run("the holder renews", func(t *testing.T) {
+		now = now.Add(50 * time.Second)
+		if !acquire("a") {
+			t.Fatal("expected a to renew its lease")
+		}
+		// Still inside the renewed minute
+		now = now.Add(50 * time.Second)
+		if acquire("b") {
+			t.Fatal("expected b to be refused after a renewed")
+		}
+	})
+
+	t.Run("an expired lease is taken over", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		if !acquire("b") {
+			t.Fatal("expected b to take over the expired lease")
+		}
+		if acquire("a") {
+			t.Fatal("expected a to have lost the lease")
+		}
+	})
+
+	t.Run("release", func(t *testing.T) {
+		if err := repo.Release(ctx, "sync", "a"); err != nil {
+			t.Fatalf("failed to release: %v", err)
+		}
+		if acquire("a") {
+			t.Fatal("expected releasing someone else's lease to be a no-op")
+		}
+
+		if err := repo.Release(ctx, "sync", "b"); err != nil {
+			t.Fatalf("failed to release: %v", err)
+		}
+		if !acquire("a") {
+			t.Fatal("expected a to acquire the released lease")
+		}
+	})
+
+	t.Run("leases are independent", func(t *testing.T) {
+		ok, err := repo.Acquire(ctx, "backfill", "b", time.Minute)
+		if err != nil || !ok {
+			t.Fatalf("expected b to acquire another lease, got %v, %v", ok, err)
+		}
+	})
+
+	t.Run("missing holder", func(t *testing.T) {
+		if _, err := repo.Acquire(ctx, "sync", "", time.Minute); err == nil {
+			t.Fatal("expected error for empty holder")
+		}
+	})
+}
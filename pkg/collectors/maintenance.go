@@ -0,0 +1,215 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBackupInterval = 24 * time.Hour
+	defaultBackupsKept    = 7
+	defaultVacuumInterval = 7 * 24 * time.Hour
+	// defaultArchiveRetention keeps archived events for a year
+	defaultArchiveRetention = 365 * 24 * time.Hour
+	// defaultFeedRetention keeps change feed entries for a month
+	defaultFeedRetention = 30 * 24 * time.Hour
+	// backupPrefix names the backups a Maintainer writes, and the files it
+	// may prune
+	backupPrefix = "where-its-at-"
+	backupLayout = "20060102T150405Z"
+)
+
+// MaintainerConfig schedules a database's upkeep. Backups go to BackupDir
+// every BackupInterval (a day by default), the newest BackupsKept (7) kept;
+// without a BackupDir there are none. The database is vacuumed every
+// VacuumInterval (a week), first dropping events archived longer than
+// ArchiveRetention (a year) ago and change feed entries older than
+// FeedRetention (30 days).
+type MaintainerConfig struct {
+	BackupDir      string
+	BackupInterval time.Duration
+	BackupsKept    int
+	VacuumInterval time.Duration
+	Logger         *slog.Logger
+
+	ArchiveRetention time.Duration
+	FeedRetention    time.Duration
+}
+
+// Maintainer backs up and vacuums a database on a schedule
+type Maintainer struct {
+	db     *sql.DB
+	config MaintainerConfig
+	now    func() time.Time
+}
+
+func NewMaintainer(db *sql.DB, config MaintainerConfig) (*Maintainer, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+	if config.BackupInterval <= 0 {
+		config.BackupInterval = defaultBackupInterval
+	}
+	if config.BackupsKept <= 0 {
+		config.BackupsKept = defaultBackupsKept
+	}
+	if config.VacuumInterval <= 0 {
+		config.VacuumInterval = defaultVacuumInterval
+	}
+	if config.ArchiveRetention <= 0 {
+		config.ArchiveRetention = defaultArchiveRetention
+	}
+	if config.FeedRetention <= 0 {
+		config.FeedRetention = defaultFeedRetention
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &Maintainer{db: db, config: config, now: time.Now}, nil
+}
+
+// Run backs up, prunes the event archive and change feed and vacuums the database on
+// schedule until ctx is done. The first backup is taken at once, the first
+// prune and vacuum after an interval.
+func (m *Maintainer) Run(ctx context.Context) {
+	vacuums := time.NewTicker(m.config.VacuumInterval)
+	defer vacuums.Stop()
+
+	var backups <-chan time.Time
+	if m.config.BackupDir != "" {
+		ticker := time.NewTicker(m.config.BackupInterval)
+		defer ticker.Stop()
+		backups = ticker.C
+		m.backup(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-backups:
+			m.backup(ctx)
+		case <-vacuums.C:
+			if pruned, err := m.PruneArchive(ctx); err != nil {
+				if ctx.Err() == nil {
+					m.config.Logger.Warn("failed to prune event archive", "error", err)
+				}
+			} else if pruned > 0 {
+				m.config.Logger.Info("pruned event archive", "events", pruned)
+			}
+			if pruned, err := m.PruneFeed(ctx); err != nil {
+				if ctx.Err() == nil {
+					m.config.Logger.Warn("failed to prune change feed", "error", err)
+				}
+			} else if pruned > 0 {
+				m.config.Logger.Info("pruned change feed", "entries", pruned)
+			}
+			if err := m.Vacuum(ctx); err != nil && ctx.Err() == nil {
+				m.config.Logger.Warn("failed to vacuum database", "error", err)
+			}
+		}
+	}
+}
+
+func (m *Maintainer) backup(ctx context.Context) {
+	path, err := m.Backup(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			m.config.Logger.Warn("failed to back up database", "error", err)
+		}
+		return
+	}
+	m.config.Logger.Info("backed up database", "path", path)
+}
+
+// Backup writes a consistent copy of the database into the backup dir and
+// prunes the oldest backups beyond BackupsKept, returning the copy's path.
+// VACUUM INTO reads a snapshot, so searches and syncs go on meanwhile.
+func (m *Maintainer) Backup(ctx context.Context) (string, error) {
+	if m.config.BackupDir == "" {
+		return "", fmt.Errorf("no backup directory is configured")
+	}
+	if err := os.MkdirAll(m.config.BackupDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	path := filepath.Join(m.config.BackupDir, backupPrefix+m.now().UTC().Format(backupLayout)+".db")
+	if _, err := m.db.ExecContext(ctx, `VACUUM INTO ?`, path); err != nil {
+		return "", fmt.Errorf("failed to back up database: %w", err)
+	}
+
+	if err := m.prune(); err != nil {
+		return path, fmt.Errorf("failed to prune backups: %w", err)
+	}
+	return path, nil
+}
+
+// prune removes the oldest backups beyond BackupsKept. Their names sort by
+// when they were taken.
+func (m *Maintainer) prune() error {
+	entries, err := os.ReadDir(m.config.BackupDir)
+	if err != nil {
+		return err
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, ".db") {
+			backups = append(backups, name)
+		}
+	}
+	slices.Sort(backups)
+
+	for len(backups) > m.config.BackupsKept {
+		if err := os.Remove(filepath.Join(m.config.BackupDir, backups[0])); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// PruneArchive drops events archived longer than ArchiveRetention ago and
+// returns how many it dropped
+func (m *Maintainer) PruneArchive(ctx context.Context) (int64, error) {
+	result, err := m.db.ExecContext(ctx, pruneArchiveQuery, m.now().Add(-m.config.ArchiveRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune event archive: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// PruneFeed drops change feed entries older than FeedRetention and returns
+// how many it dropped. Mirrors whose cursor is older start over.
+func (m *Maintainer) PruneFeed(ctx context.Context) (int64, error) {
+	result, err := m.db.ExecContext(ctx, pruneFeedQuery, m.now().Add(-m.config.FeedRetention))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune change feed: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Vacuum folds the write-ahead log back into the database, rebuilds it to
+// reclaim the space purged events left and refreshes the query planner's
+// statistics
+func (m *Maintainer) Vacuum(ctx context.Context) error {
+	for _, statement := range []string{
+		`PRAGMA wal_checkpoint(TRUNCATE)`,
+		`VACUUM`,
+		`PRAGMA optimize`,
+	} {
+		if _, err := m.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to run %s: %w", statement, err)
+		}
+	}
+	return nil
+}
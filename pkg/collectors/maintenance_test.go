@@ -0,0 +1,123 @@
+package collectors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestMaintainer(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	artists, err := NewArtistRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	ctx := context.Background()
+	if err := artists.Create(ctx, &domain.Artist{ID: "1", Name: "Radiohead"}); err != nil {
+		t.Fatalf("failed to create artist: %v", err)
+	}
+
+	if _, err := NewMaintainer(nil, MaintainerConfig{}); err == nil {
+		t.Error("expected error for nil database")
+	}
+
+	dir := t.TempDir()
+	maintainer, err := NewMaintainer(db, MaintainerConfig{BackupDir: dir, BackupsKept: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	now := time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC)
+	maintainer.now = func() time.Time { return now }
+
+	path, err := maintainer.Backup(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if path != filepath.Join(dir, "where-its-at-20261016T030000Z.db") {
+		t.Errorf("unexpected backup path %s", path)
+	}
+
+	// The backup is a database of its own
+	backup, err := NewSQLiteDB(path)
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	var name string
+	backup.QueryRow(`SELECT name FROM artists WHERE id = '1'`).Scan(&name)
+	backup.Close()
+	if name != "Radiohead" {
+		t.Errorf("expected the artist in the backup, got %q", name)
+	}
+
+	// Only the newest backups are kept, next to other files
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0o644)
+	for i := 0; i < 3; i++ {
+		now = now.Add(24 * time.Hour)
+		if _, err := maintainer.Backup(ctx); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != 3 || names[0] != "notes.txt" || names[1] != "where-its-at-20261018T030000Z.db" {
+		t.Errorf("expected the two newest backups and the notes, got %v", names)
+	}
+
+	if err := maintainer.Vacuum(ctx); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := artists.GetByID(ctx, "1"); err != nil {
+		t.Errorf("expected the artist to survive the vacuum, got %v", err)
+	}
+
+	unconfigured, _ := NewMaintainer(db, MaintainerConfig{})
+	if _, err := unconfigured.Backup(ctx); err == nil {
+		t.Error("expected an error without a backup directory")
+	}
+}
+
+func TestMaintainer_PruneArchive(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	events, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+	ctx := context.Background()
+	at := time.Now().Add(-400 * 24 * time.Hour)
+	expired := newTestEvent("expired", "Test Artist", at)
+	expired.CachedUntil = at
+	if _, err := events.CreateBatch(ctx, []domain.Event{expired}); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+	if _, err := events.PurgeExpired(ctx); err != nil {
+		t.Fatalf("failed to archive event: %v", err)
+	}
+
+	maintainer, err := NewMaintainer(db, MaintainerConfig{ArchiveRetention: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Archived just now, so kept until the retention passes
+	if pruned, err := maintainer.PruneArchive(ctx); err != nil || pruned != 0 {
+		t.Errorf("expected nothing pruned, got %d, %v", pruned, err)
+	}
+	maintainer.now = func() time.Time { return time.Now().Add(31 * 24 * time.Hour) }
+	if pruned, err := maintainer.PruneArchive(ctx); err != nil || pruned != 1 {
+		t.Errorf("expected the event pruned, got %d, %v", pruned, err)
+	}
+	if archived, _ := events.ListArchived(ctx, domain.EventFilter{}, 10); len(archived) != 0 {
+		t.Errorf("expected an empty archive, got %+v", archived)
+	}
+}
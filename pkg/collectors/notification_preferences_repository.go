@@ -0,0 +1,172 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// NotificationPreferencesRepository stores each user's digest settings
+type NotificationPreferencesRepository struct {
+	db *timedDB
+}
+
+func NewNotificationPreferencesRepository(db *sql.DB) (*NotificationPreferencesRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &NotificationPreferencesRepository{db: newTimedDB(db, "notification_preferences")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *NotificationPreferencesRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id TEXT PRIMARY KEY,
+		digest_frequency TEXT NOT NULL,
+		unsubscribe_token TEXT NOT NULL UNIQUE,
+		last_digest_at TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *NotificationPreferencesRepository) Get(ctx context.Context, userID string) (*domain.NotificationPreferences, error) {
+	return r.getBy(ctx, "user_id", userID)
+}
+
+func (r *NotificationPreferencesRepository) GetByUnsubscribeToken(ctx context.Context, token string) (*domain.NotificationPreferences, error) {
+	return r.getBy(ctx, "unsubscribe_token", token)
+}
+
+func (r *NotificationPreferencesRepository) getBy(ctx context.Context, column, value string) (*domain.NotificationPreferences, error) {
+	query := fmt.Sprintf(`
+	SELECT user_id, digest_frequency, unsubscribe_token, last_digest_at, updated_at
+	FROM notification_preferences
+	WHERE %s = ?
+	`, column)
+
+	prefs, err := scanNotificationPreferences(r.db.QueryRowContext(ctx, query, value))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrPreferencesNotSet
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+
+	return prefs, nil
+}
+
+// Save creates or updates the user's preferences, keeping when the last
+// digest went out
+func (r *NotificationPreferencesRepository) Save(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	if prefs == nil || prefs.UserID == "" || prefs.UnsubscribeToken == "" {
+		return fmt.Errorf("user ID and unsubscribe token are required")
+	}
+	if !prefs.DigestFrequency.Valid() {
+		return fmt.Errorf("invalid digest frequency %q", prefs.DigestFrequency)
+	}
+
+	query := `
+	INSERT INTO notification_preferences (user_id, digest_frequency, unsubscribe_token, updated_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(user_id) DO UPDATE SET
+		digest_frequency = excluded.digest_frequency,
+		unsubscribe_token = excluded.unsubscribe_token,
+		updated_at = excluded.updated_at
+	`
+
+	prefs.UpdatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, query, prefs.UserID, string(prefs.DigestFrequency), prefs.UnsubscribeToken, prefs.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NotificationPreferencesRepository) ListDueDigests(ctx context.Context, now time.Time) ([]domain.NotificationPreferences, error) {
+	query := `
+	SELECT user_id, digest_frequency, unsubscribe_token, last_digest_at, updated_at
+	FROM notification_preferences
+	WHERE (digest_frequency = ? AND (last_digest_at IS NULL OR last_digest_at <= ?))
+		OR (digest_frequency = ? AND (last_digest_at IS NULL OR last_digest_at <= ?))
+	ORDER BY last_digest_at IS NOT NULL, last_digest_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		string(domain.DigestDaily), now.Add(-domain.DigestDaily.Period()),
+		string(domain.DigestWeekly), now.Add(-domain.DigestWeekly.Period()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list due digests: %w", err)
+	}
+	defer rows.Close()
+
+	due := []domain.NotificationPreferences{}
+	for rows.Next() {
+		prefs, err := scanNotificationPreferences(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification preferences: %w", err)
+		}
+		due = append(due, *prefs)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification preferences: %w", err)
+	}
+
+	return due, nil
+}
+
+func (r *NotificationPreferencesRepository) MarkDigestSent(ctx context.Context, userID string, sentAt time.Time) error {
+	query := `UPDATE notification_preferences SET last_digest_at = ? WHERE user_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, sentAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest sent: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrPreferencesNotSet
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanNotificationPreferences(row rowScanner) (*domain.NotificationPreferences, error) {
+	var prefs domain.NotificationPreferences
+	var frequency string
+	var lastDigestAt sql.NullTime
+
+	if err := row.Scan(&prefs.UserID, &frequency, &prefs.UnsubscribeToken, &lastDigestAt, &prefs.UpdatedAt); err != nil {
+		return nil, err
+	}
+	prefs.DigestFrequency = domain.DigestFrequency(frequency)
+	if lastDigestAt.Valid {
+		prefs.LastDigestAt = &lastDigestAt.Time
+	}
+
+	return &prefs, nil
+}
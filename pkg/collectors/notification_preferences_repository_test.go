@@ -0,0 +1,83 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNotificationPreferencesRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewNotificationPreferencesRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("not set", func(t *testing.T) {
+		if _, err := repo.Get(ctx, "user_1"); !errors.Is(err, domain.ErrPreferencesNotSet) {
+			t.Errorf("expected ErrPreferencesNotSet, got %v", err)
+		}
+	})
+
+	for _, prefs := range []*domain.NotificationPreferences{
+		{UserID: "daily_new", DigestFrequency: domain.DigestDaily, UnsubscribeToken: "t1"},
+		{UserID: "daily_sent", DigestFrequency: domain.DigestDaily, UnsubscribeToken: "t2"},
+		{UserID: "weekly_sent", DigestFrequency: domain.DigestWeekly, UnsubscribeToken: "t3"},
+		{UserID: "off", DigestFrequency: domain.DigestOff, UnsubscribeToken: "t4"},
+	} {
+		if err := repo.Save(ctx, prefs); err != nil {
+			t.Fatalf("failed to save preferences: %v", err)
+		}
+	}
+	repo.MarkDigestSent(ctx, "daily_sent", now.Add(-25*time.Hour))
+	repo.MarkDigestSent(ctx, "weekly_sent", now.Add(-25*time.Hour))
+
+	t.Run("due digests", func(t *testing.T) {
+		due, err := repo.ListDueDigests(ctx, now)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(due) != 2 || due[0].UserID != "daily_new" || due[1].UserID != "daily_sent" {
+			t.Errorf("expected the two daily users, got %+v", due)
+		}
+		if due[1].LastDigestAt == nil {
+			t.Error("expected the last digest time")
+		}
+	})
+
+	t.Run("saving keeps the last digest time", func(t *testing.T) {
+		prefs := &domain.NotificationPreferences{UserID: "daily_sent", DigestFrequency: domain.DigestWeekly, UnsubscribeToken: "t2"}
+		if err := repo.Save(ctx, prefs); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		found, err := repo.GetByUnsubscribeToken(ctx, "t2")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if found.UserID != "daily_sent" || found.DigestFrequency != domain.DigestWeekly || found.LastDigestAt == nil {
+			t.Errorf("unexpected preferences: %+v", found)
+		}
+	})
+
+	t.Run("invalid frequency", func(t *testing.T) {
+		prefs := &domain.NotificationPreferences{UserID: "user_1", DigestFrequency: "hourly", UnsubscribeToken: "t5"}
+		if err := repo.Save(ctx, prefs); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("mark sent for unknown user", func(t *testing.T) {
+		if err := repo.MarkDigestSent(ctx, "nobody", now); !errors.Is(err, domain.ErrPreferencesNotSet) {
+			t.Errorf("expected ErrPreferencesNotSet, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type OAuthTokenRepository struct {
+	db *timedDB
+}
+
+func NewOAuthTokenRepository(db *sql.DB) (*OAuthTokenRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &OAuthTokenRepository{db: newTimedDB(db, "oauth_tokens")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *OAuthTokenRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS oauth_tokens (
+		provider TEXT NOT NULL,
+		account_id TEXT NOT NULL,
+		access_token TEXT NOT NULL,
+		refresh_token TEXT,
+		scope TEXT,
+		expires_at TIMESTAMP NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (provider, account_id)
+	);
+	`
+
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+	return addMissingColumns(r.db.DB, "oauth_tokens", []string{"user_id"})
+}
+
+// Save inserts or replaces the token for the provider account. A refresh
+// response without a new refresh token keeps the stored one.
+func (r *OAuthTokenRepository) Save(ctx context.Context, token *domain.OAuthToken) error {
+	if token == nil || token.Provider == "" || token.AccountID == "" {
+		return fmt.Errorf("token provider and account ID are required")
+	}
+
+	query := `
+	INSERT INTO oauth_tokens (provider, account_id, user_id, access_token, refresh_token, scope, expires_at, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(provider, account_id) DO UPDATE SET
+		user_id = COALESCE(NULLIF(excluded.user_id, ''), oauth_tokens.user_id),
+		access_token = excluded.access_token,
+		refresh_token = COALESCE(NULLIF(excluded.refresh_token, ''), oauth_tokens.refresh_token),
+		scope = excluded.scope,
+		expires_at = excluded.expires_at,
+		updated_at = excluded.updated_at
+	`
+
+	now := time.Now()
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = now
+	}
+	token.UpdatedAt = now
+
+	_, err := r.db.ExecContext(ctx, query,
+		token.Provider,
+		token.AccountID,
+		token.UserID,
+		token.AccessToken,
+		token.RefreshToken,
+		token.Scope,
+		token.ExpiresAt,
+		token.CreatedAt,
+		token.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save oauth token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OAuthTokenRepository) Get(ctx context.Context, provider, accountID string) (*domain.OAuthToken, error) {
+	query := `
+	SELECT provider, account_id, user_id, access_token, refresh_token, scope, expires_at, created_at, updated_at
+	FROM oauth_tokens
+	WHERE provider = ? AND account_id = ?
+	`
+
+	return r.scanToken(r.db.QueryRowContext(ctx, query, provider, accountID))
+}
+
+func (r *OAuthTokenRepository) GetForUser(ctx context.Context, provider, userID string) (*domain.OAuthToken, error) {
+	if userID == "" {
+		return nil, domain.ErrTokenNotFound
+	}
+
+	query := `
+	SELECT provider, account_id, user_id, access_token, refresh_token, scope, expires_at, created_at, updated_at
+	FROM oauth_tokens
+	WHERE provider = ? AND user_id = ?
+	ORDER BY updated_at DESC
+	LIMIT 1
+	`
+
+	return r.scanToken(r.db.QueryRowContext(ctx, query, provider, userID))
+}
+
+func (r *OAuthTokenRepository) scanToken(row *sql.Row) (*domain.OAuthToken, error) {
+	var token domain.OAuthToken
+	var refreshToken, scope sql.NullString
+
+	err := row.Scan(
+		&token.Provider,
+		&token.AccountID,
+		&token.UserID,
+		&token.AccessToken,
+		&refreshToken,
+		&scope,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth token: %w", err)
+	}
+
+	token.RefreshToken = refreshToken.String
+	token.Scope = scope.String
+
+	return &token, nil
+}
@@ -0,0 +1,122 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewOAuthTokenRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewOAuthTokenRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestOAuthTokenRepository_SaveAndGet(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewOAuthTokenRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	t.Run("save and get", func(t *testing.T) {
+		token := &domain.OAuthToken{
+			Provider:     "spotify",
+			AccountID:    "user-1",
+			AccessToken:  "access-1",
+			RefreshToken: "refresh-1",
+			Scope:        "user-follow-read",
+			ExpiresAt:    expiresAt,
+		}
+		if err := repo.Save(ctx, token); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		found, err := repo.Get(ctx, "spotify", "user-1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if found.AccessToken != "access-1" || found.RefreshToken != "refresh-1" {
+			t.Errorf("unexpected tokens: %+v", found)
+		}
+		if !found.ExpiresAt.Equal(expiresAt) {
+			t.Errorf("expected expiry %v, got %v", expiresAt, found.ExpiresAt)
+		}
+	})
+
+	t.Run("refresh keeps existing refresh token", func(t *testing.T) {
+		token := &domain.OAuthToken{
+			Provider:    "spotify",
+			AccountID:   "user-1",
+			AccessToken: "access-2",
+			ExpiresAt:   expiresAt.Add(time.Hour),
+		}
+		if err := repo.Save(ctx, token); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		found, err := repo.Get(ctx, "spotify", "user-1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if found.AccessToken != "access-2" {
+			t.Errorf("expected access-2, got %s", found.AccessToken)
+		}
+		if found.RefreshToken != "refresh-1" {
+			t.Errorf("expected refresh token to be kept, got %s", found.RefreshToken)
+		}
+	})
+
+	t.Run("get for user", func(t *testing.T) {
+		token := &domain.OAuthToken{
+			Provider:    "spotify",
+			AccountID:   "user-2",
+			UserID:      "u1",
+			AccessToken: "access-3",
+			ExpiresAt:   expiresAt,
+		}
+		if err := repo.Save(ctx, token); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// A refresh doesn't know the user and keeps the stored one
+		if err := repo.Save(ctx, &domain.OAuthToken{Provider: "spotify", AccountID: "user-2", AccessToken: "access-4", ExpiresAt: expiresAt}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		found, err := repo.GetForUser(ctx, "spotify", "u1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if found.AccountID != "user-2" || found.UserID != "u1" || found.AccessToken != "access-4" {
+			t.Errorf("unexpected token: %+v", found)
+		}
+
+		if _, err := repo.GetForUser(ctx, "spotify", "u2"); err != domain.ErrTokenNotFound {
+			t.Errorf("expected ErrTokenNotFound for another user, got %v", err)
+		}
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		_, err := repo.Get(ctx, "spotify", "unknown")
+		if err != domain.ErrTokenNotFound {
+			t.Errorf("expected ErrTokenNotFound, got %v", err)
+		}
+	})
+
+	t.Run("missing account", func(t *testing.T) {
+		if err := repo.Save(ctx, &domain.OAuthToken{Provider: "spotify"}); err == nil {
+			t.Error("expected error for missing account ID")
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// OnSaleAlertRepository stores who wants on-sale alerts and which events
+// each of them was already alerted about
+type OnSaleAlertRepository struct {
+	db *timedDB
+}
+
+func NewOnSaleAlertRepository(db *sql.DB) (*OnSaleAlertRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &OnSaleAlertRepository{db: newTimedDB(db, "onsale_alerts")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *OnSaleAlertRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS onsale_subscriptions (
+		user_id TEXT PRIMARY KEY,
+		webhook_url TEXT,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS onsale_alerts (
+		user_id TEXT NOT NULL,
+		event_id TEXT NOT NULL,
+		alerted_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (user_id, event_id)
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// Subscribe turns alerts on, or updates the webhook of an existing
+// subscription
+func (r *OnSaleAlertRepository) Subscribe(ctx context.Context, sub *domain.OnSaleSubscription) error {
+	if sub == nil || sub.UserID == "" {
+		return fmt.Errorf("user ID is required")
+	}
+
+	query := `
+	INSERT INTO onsale_subscriptions (user_id, webhook_url, created_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(user_id) DO UPDATE SET webhook_url = excluded.webhook_url
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, sub.UserID, sub.WebhookURL, time.Now()); err != nil {
+		return fmt.Errorf("failed to save on-sale subscription: %w", err)
+	}
+
+	saved, err := r.GetSubscription(ctx, sub.UserID)
+	if err != nil {
+		return err
+	}
+	sub.CreatedAt = saved.CreatedAt
+
+	return nil
+}
+
+func (r *OnSaleAlertRepository) GetSubscription(ctx context.Context, userID string) (*domain.OnSaleSubscription, error) {
+	query := `SELECT user_id, webhook_url, created_at FROM onsale_subscriptions WHERE user_id = ?`
+
+	sub, err := scanOnSaleSubscription(r.db.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotSubscribed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get on-sale subscription: %w", err)
+	}
+
+	return sub, nil
+}
+
+func (r *OnSaleAlertRepository) Unsubscribe(ctx context.Context, userID string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM onsale_subscriptions WHERE user_id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete on-sale subscription: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrNotSubscribed
+	}
+
+	return nil
+}
+
+func (r *OnSaleAlertRepository) ListSubscriptions(ctx context.Context) ([]domain.OnSaleSubscription, error) {
+	query := `SELECT user_id, webhook_url, created_at FROM onsale_subscriptions ORDER BY created_at ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list on-sale subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []domain.OnSaleSubscription{}
+	for rows.Next() {
+		sub, err := scanOnSaleSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan on-sale subscription: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate on-sale subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+func (r *OnSaleAlertRepository) HasAlerted(ctx context.Context, userID, eventID string) (bool, error) {
+	var exists int
+	err := r.db.QueryRowContext(ctx, `SELECT 1 FROM onsale_alerts WHERE user_id = ? AND event_id = ?`, userID, eventID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check on-sale alert: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *OnSaleAlertRepository) MarkAlerted(ctx context.Context, userID, eventID string, alertedAt time.Time) error {
+	query := `INSERT OR IGNORE INTO onsale_alerts (user_id, event_id, alerted_at) VALUES (?, ?, ?)`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, eventID, alertedAt); err != nil {
+		return fmt.Errorf("failed to mark on-sale alert: %w", err)
+	}
+
+	return nil
+}
+
+func scanOnSaleSubscription(row rowScanner) (*domain.OnSaleSubscription, error) {
+	var sub domain.OnSaleSubscription
+	var webhookURL sql.NullString
+
+	if err := row.Scan(&sub.UserID, &webhookURL, &sub.CreatedAt); err != nil {
+		return nil, err
+	}
+	sub.WebhookURL = webhookURL.String
+
+	return &sub, nil
+}
@@ -0,0 +1,91 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestOnSaleAlertRepository_Subscriptions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewOnSaleAlertRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := repo.GetSubscription(ctx, "user_1"); !errors.Is(err, domain.ErrNotSubscribed) {
+		t.Fatalf("expected ErrNotSubscribed, got %v", err)
+	}
+
+	sub := &domain.OnSaleSubscription{UserID: "user_1"}
+	if err := repo.Subscribe(ctx, sub); err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	if sub.CreatedAt.IsZero() {
+		t.Error("expected the subscription time to be set")
+	}
+
+	t.Run("resubscribing updates the webhook", func(t *testing.T) {
+		update := &domain.OnSaleSubscription{UserID: "user_1", WebhookURL: "https://example.com/hook"}
+		if err := repo.Subscribe(ctx, update); err != nil {
+			t.Fatalf("failed to subscribe: %v", err)
+		}
+		if !update.CreatedAt.Equal(sub.CreatedAt) {
+			t.Error("expected the original subscription time to be kept")
+		}
+
+		subs, err := repo.ListSubscriptions(ctx)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(subs) != 1 || subs[0].WebhookURL != "https://example.com/hook" {
+			t.Errorf("expected one subscription with the webhook, got %+v", subs)
+		}
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		if err := repo.Unsubscribe(ctx, "user_1"); err != nil {
+			t.Fatalf("failed to unsubscribe: %v", err)
+		}
+		if err := repo.Unsubscribe(ctx, "user_1"); !errors.Is(err, domain.ErrNotSubscribed) {
+			t.Errorf("expected ErrNotSubscribed, got %v", err)
+		}
+	})
+}
+
+func TestOnSaleAlertRepository_Alerts(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewOnSaleAlertRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	alerted, err := repo.HasAlerted(ctx, "user_1", "event_1")
+	if err != nil || alerted {
+		t.Fatalf("expected no alert yet, got %v (%v)", alerted, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repo.MarkAlerted(ctx, "user_1", "event_1", time.Now()); err != nil {
+			t.Fatalf("failed to mark alert: %v", err)
+		}
+	}
+
+	if alerted, err := repo.HasAlerted(ctx, "user_1", "event_1"); err != nil || !alerted {
+		t.Errorf("expected the alert to be recorded, got %v (%v)", alerted, err)
+	}
+	if alerted, err := repo.HasAlerted(ctx, "user_2", "event_1"); err != nil || alerted {
+		t.Errorf("expected alerts to be per user, got %v (%v)", alerted, err)
+	}
+}
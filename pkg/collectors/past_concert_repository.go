@@ -0,0 +1,175 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// PastConcertRepository archives concerts from the history sources, like
+// setlist.fm, for looking back over years of an artist's shows
+type PastConcertRepository struct {
+	db *timedDB
+}
+
+func NewPastConcertRepository(db *sql.DB) (*PastConcertRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &PastConcertRepository{db: newTimedDB(db, "past_concerts")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *PastConcertRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS past_concerts (
+		source TEXT NOT NULL,
+		id TEXT NOT NULL,
+		artist_id TEXT,
+		artist_name TEXT NOT NULL,
+		title TEXT,
+		datetime TIMESTAMP NOT NULL,
+		timezone TEXT,
+		venue_name TEXT,
+		venue_city TEXT,
+		venue_region TEXT,
+		venue_country TEXT,
+		venue_country_code TEXT,
+		venue_latitude REAL,
+		venue_longitude REAL,
+		tour TEXT,
+		url TEXT,
+		songs TEXT,
+		stored_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (source, id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_past_concerts_artist_name ON past_concerts(artist_name COLLATE NOCASE, datetime);
+	CREATE INDEX IF NOT EXISTS idx_past_concerts_datetime ON past_concerts(datetime);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *PastConcertRepository) SaveBatch(ctx context.Context, concerts []domain.PastConcert) error {
+	if len(concerts) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT OR REPLACE INTO past_concerts (
+			source, id, artist_id, artist_name, title, datetime, timezone,
+			venue_name, venue_city, venue_region, venue_country, venue_country_code,
+			venue_latitude, venue_longitude, tour, url, songs, stored_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now()
+	for _, concert := range concerts {
+		if concert.Source == "" || concert.ID == "" {
+			return fmt.Errorf("concert source and ID are required")
+		}
+		songs, err := json.Marshal(concert.Songs)
+		if err != nil {
+			return fmt.Errorf("failed to encode songs: %w", err)
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			concert.Source, concert.ID, concert.ArtistID, concert.ArtistName, concert.Title,
+			concert.DateTime, concert.Timezone,
+			concert.Venue.Name, concert.Venue.City, concert.Venue.Region, concert.Venue.Country, concert.Venue.CountryCode,
+			concert.Venue.Latitude, concert.Venue.Longitude,
+			concert.Tour, concert.URL, string(songs), now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store concert %s: %w", concert.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PastConcertRepository) ListByArtist(ctx context.Context, artistName string) ([]domain.PastConcert, error) {
+	query := `
+	SELECT source, id, artist_id, artist_name, title, datetime, timezone,
+		venue_name, venue_city, venue_region, venue_country, venue_country_code,
+		venue_latitude, venue_longitude, tour, url, songs
+	FROM past_concerts
+	WHERE artist_name = ? COLLATE NOCASE
+	ORDER BY datetime DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, artistName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list past concerts: %w", err)
+	}
+	defer rows.Close()
+
+	concerts := []domain.PastConcert{}
+	for rows.Next() {
+		var concert domain.PastConcert
+		var artistID, title, timezone, venueName, city, region, country, countryCode, tour, url, songs sql.NullString
+		var latitude, longitude sql.NullFloat64
+
+		err := rows.Scan(
+			&concert.Source, &concert.ID, &artistID, &concert.ArtistName, &title, &concert.DateTime, &timezone,
+			&venueName, &city, &region, &country, &countryCode,
+			&latitude, &longitude, &tour, &url, &songs,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan past concert: %w", err)
+		}
+
+		concert.ArtistID = artistID.String
+		concert.Title = title.String
+		concert.Timezone = timezone.String
+		concert.Venue = domain.Venue{
+			Name:        venueName.String,
+			City:        city.String,
+			Region:      region.String,
+			Country:     country.String,
+			CountryCode: countryCode.String,
+			Latitude:    latitude.Float64,
+			Longitude:   longitude.Float64,
+		}
+		concert.Tour = tour.String
+		concert.URL = url.String
+		if songs.String != "" {
+			if err := json.Unmarshal([]byte(songs.String), &concert.Songs); err != nil {
+				return nil, fmt.Errorf("failed to decode songs: %w", err)
+			}
+		}
+
+		concerts = append(concerts, concert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate past concerts: %w", err)
+	}
+
+	return concerts, nil
+}
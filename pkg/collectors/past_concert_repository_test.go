@@ -0,0 +1,78 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewPastConcertRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewPastConcertRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestPastConcertRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewPastConcertRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	concert := func(id string, date time.Time) domain.PastConcert {
+		return domain.PastConcert{
+			Event: domain.Event{
+				ID:         id,
+				ArtistID:   "artist-1",
+				ArtistName: "Radiohead",
+				DateTime:   date,
+				Venue:      domain.Venue{Name: "Roundhouse", City: "London", Country: "United Kingdom", CountryCode: "GB", Latitude: 51.54, Longitude: -0.15},
+			},
+			Source: "setlistfm",
+			Tour:   "In Rainbows",
+			Songs:  []domain.SetlistSong{{Name: "15 Step", SetName: "Set 1"}},
+		}
+	}
+
+	err = repo.SaveBatch(ctx, []domain.PastConcert{
+		concert("setlistfm_1", time.Date(2008, 6, 24, 0, 0, 0, 0, time.UTC)),
+		concert("setlistfm_2", time.Date(2016, 5, 20, 0, 0, 0, 0, time.UTC)),
+	})
+	if err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	t.Run("saving again replaces", func(t *testing.T) {
+		updated := concert("setlistfm_1", time.Date(2008, 6, 24, 0, 0, 0, 0, time.UTC))
+		updated.Tour = "In Rainbows Tour"
+		if err := repo.SaveBatch(ctx, []domain.PastConcert{updated}); err != nil {
+			t.Fatalf("failed to save: %v", err)
+		}
+	})
+
+	concerts, err := repo.ListByArtist(ctx, "radiohead")
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(concerts) != 2 || concerts[0].ID != "setlistfm_2" {
+		t.Fatalf("expected both concerts newest first, got %+v", concerts)
+	}
+	old := concerts[1]
+	if old.Tour != "In Rainbows Tour" || old.Venue.CountryCode != "GB" || len(old.Songs) != 1 || old.Songs[0].Name != "15 Step" || old.Source != "setlistfm" {
+		t.Errorf("expected the concert stored in full, got %+v", old)
+	}
+
+	t.Run("requires an ID", func(t *testing.T) {
+		if err := repo.SaveBatch(ctx, []domain.PastConcert{concert("", time.Now())}); err == nil {
+			t.Error("expected error for a concert without an ID")
+		}
+	})
+}
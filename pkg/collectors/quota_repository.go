@@ -0,0 +1,123 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// QuotaRepository persists outbound API request timestamps so rate limit
+// windows survive restarts
+type QuotaRepository struct {
+	db *timedDB
+}
+
+func NewQuotaRepository(db *sql.DB) (*QuotaRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &QuotaRepository{db: newTimedDB(db, "quotas")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *QuotaRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS source_requests (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		source TEXT NOT NULL,
+		requested_at TIMESTAMP NOT NULL,
+		cost INTEGER NOT NULL DEFAULT 1
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_source_requests_source_time ON source_requests(source, requested_at);
+	`
+
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+	return addMissingTypedColumns(r.db.DB, "source_requests", []string{"cost"}, "INTEGER NOT NULL DEFAULT 1")
+}
+
+// RecordRequest stores a request that used cost units of the source's
+// budget as a single row
+func (r *QuotaRepository) RecordRequest(ctx context.Context, source string, requestedAt time.Time, cost int) error {
+	if source == "" {
+		return fmt.Errorf("source is required")
+	}
+	if cost < 1 {
+		cost = 1
+	}
+
+	query := `INSERT INTO source_requests (source, requested_at, cost) VALUES (?, ?, ?)`
+
+	_, err := r.db.ExecContext(ctx, query, source, requestedAt.UTC(), cost)
+	if err != nil {
+		return fmt.Errorf("failed to record request: %w", err)
+	}
+
+	return nil
+}
+
+func (r *QuotaRepository) GetRequestsSince(ctx context.Context, source string, since time.Time) ([]domain.SourceRequest, error) {
+	query := `
+	SELECT requested_at, cost FROM source_requests
+	WHERE source = ? AND requested_at > ?
+	ORDER BY requested_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, source, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query requests: %w", err)
+	}
+	defer rows.Close()
+
+	requests := []domain.SourceRequest{}
+	for rows.Next() {
+		var request domain.SourceRequest
+		if err := rows.Scan(&request.RequestedAt, &request.Cost); err != nil {
+			return nil, fmt.Errorf("failed to scan request: %w", err)
+		}
+		requests = append(requests, request)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate requests: %w", err)
+	}
+
+	return requests, nil
+}
+
+func (r *QuotaRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM source_requests WHERE requested_at < ?`
+
+	_, err := r.db.ExecContext(ctx, query, before.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to delete old requests: %w", err)
+	}
+
+	return nil
+}
+
+// RunPrunes drops requests older than retention every interval until ctx is
+// done. A failed prune is tried again at the next tick.
+func (r *QuotaRepository) RunPrunes(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.DeleteBefore(ctx, time.Now().Add(-retention))
+		}
+	}
+}
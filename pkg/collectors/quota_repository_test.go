@@ -0,0 +1,113 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewQuotaRepository(t *testing.T) {
+	t.Run("successful creation", func(t *testing.T) {
+		db, cleanup := setupTestDB(t)
+		defer cleanup()
+
+		repo, err := NewQuotaRepository(db)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if repo == nil {
+			t.Fatal("expected repository, got nil")
+		}
+	})
+
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewQuotaRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestQuotaRepository_Requests(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewQuotaRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+
+	requests := map[string][]time.Time{
+		"songkick":  {now.Add(-30 * time.Hour), now.Add(-2 * time.Hour), now.Add(-time.Minute)},
+		"setlistfm": {now.Add(-time.Hour)},
+	}
+	for source, times := range requests {
+		for _, requestedAt := range times {
+			if err := repo.RecordRequest(ctx, source, requestedAt, 1); err != nil {
+				t.Fatalf("failed to record request: %v", err)
+			}
+		}
+	}
+	if err := repo.RecordRequest(ctx, "youtube", now.Add(-time.Minute), 100); err != nil {
+		t.Fatalf("failed to record request: %v", err)
+	}
+
+	t.Run("returns requests inside window", func(t *testing.T) {
+		found, err := repo.GetRequestsSince(ctx, "songkick", now.Add(-24*time.Hour))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 2 {
+			t.Fatalf("expected 2 requests, got %d", len(found))
+		}
+		if !found[0].RequestedAt.Before(found[1].RequestedAt) {
+			t.Error("expected requests in chronological order")
+		}
+		if found[0].Cost != 1 {
+			t.Errorf("expected cost 1, got %d", found[0].Cost)
+		}
+	})
+
+	t.Run("keeps what a request cost", func(t *testing.T) {
+		found, err := repo.GetRequestsSince(ctx, "youtube", now.Add(-24*time.Hour))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 1 || found[0].Cost != 100 {
+			t.Errorf("expected one request costing 100, got %+v", found)
+		}
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		found, err := repo.GetRequestsSince(ctx, "unknown", now.Add(-24*time.Hour))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 0 {
+			t.Errorf("expected 0 requests, got %d", len(found))
+		}
+	})
+
+	t.Run("missing source", func(t *testing.T) {
+		if err := repo.RecordRequest(ctx, "", now, 1); err == nil {
+			t.Error("expected error for missing source")
+		}
+	})
+
+	t.Run("delete before", func(t *testing.T) {
+		if err := repo.DeleteBefore(ctx, now.Add(-24*time.Hour)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		found, err := repo.GetRequestsSince(ctx, "songkick", now.Add(-48*time.Hour))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(found) != 2 {
+			t.Errorf("expected 2 requests after cleanup, got %d", len(found))
+		}
+	})
+}
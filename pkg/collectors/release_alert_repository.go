@@ -0,0 +1,64 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ReleaseAlertRepository stores which new albums each user was already
+// alerted about
+type ReleaseAlertRepository struct {
+	db *timedDB
+}
+
+func NewReleaseAlertRepository(db *sql.DB) (*ReleaseAlertRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &ReleaseAlertRepository{db: newTimedDB(db, "release_alerts")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *ReleaseAlertRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS release_alerts (
+		user_id TEXT NOT NULL,
+		album_id TEXT NOT NULL,
+		alerted_at TIMESTAMP NOT NULL,
+		PRIMARY KEY (user_id, album_id)
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *ReleaseAlertRepository) HasAlerted(ctx context.Context, userID, albumID string) (bool, error) {
+	var exists int
+	err := r.db.QueryRowContext(ctx, `SELECT 1 FROM release_alerts WHERE user_id = ? AND album_id = ?`, userID, albumID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check release alert: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *ReleaseAlertRepository) MarkAlerted(ctx context.Context, userID, albumID string, alertedAt time.Time) error {
+	query := `INSERT OR IGNORE INTO release_alerts (user_id, album_id, alerted_at) VALUES (?, ?, ?)`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, albumID, alertedAt); err != nil {
+		return fmt.Errorf("failed to mark release alert: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReleaseAlertRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewReleaseAlertRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	alerted, err := repo.HasAlerted(ctx, "user_1", "deezer_302127")
+	if err != nil || alerted {
+		t.Fatalf("expected no alert yet, got %v (%v)", alerted, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := repo.MarkAlerted(ctx, "user_1", "deezer_302127", time.Now()); err != nil {
+			t.Fatalf("failed to mark alert: %v", err)
+		}
+	}
+
+	if alerted, err := repo.HasAlerted(ctx, "user_1", "deezer_302127"); err != nil || !alerted {
+		t.Errorf("expected the alert to be recorded, got %v (%v)", alerted, err)
+	}
+	if alerted, err := repo.HasAlerted(ctx, "user_2", "deezer_302127"); err != nil || alerted {
+		t.Errorf("expected alerts to be per user, got %v (%v)", alerted, err)
+	}
+}
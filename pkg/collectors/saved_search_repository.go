@@ -0,0 +1,130 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// SavedSearchRepository stores the artist/city searches users want new
+// events for
+type SavedSearchRepository struct {
+	db *timedDB
+}
+
+func NewSavedSearchRepository(db *sql.DB) (*SavedSearchRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &SavedSearchRepository{db: newTimedDB(db, "saved_searches")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *SavedSearchRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS saved_searches (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		artist TEXT,
+		city TEXT,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_saved_searches_user_id ON saved_searches(user_id);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *SavedSearchRepository) Create(ctx context.Context, search *domain.SavedSearch) error {
+	if search == nil || search.ID == "" || search.UserID == "" {
+		return fmt.Errorf("search ID and user ID are required")
+	}
+
+	search.Artist = strings.TrimSpace(search.Artist)
+	search.City = strings.TrimSpace(search.City)
+	if search.Artist == "" && search.City == "" {
+		return fmt.Errorf("an artist or city is required")
+	}
+
+	query := `
+	INSERT INTO saved_searches (id, user_id, artist, city, created_at)
+	VALUES (?, ?, ?, ?, ?)
+	`
+
+	search.CreatedAt = time.Now()
+
+	_, err := r.db.ExecContext(ctx, query, search.ID, search.UserID, search.Artist, search.City, search.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create saved search: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes one of the user's searches; other users' searches are
+// reported as not found
+func (r *SavedSearchRepository) Delete(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM saved_searches WHERE id = ? AND user_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSearchNotFound
+	}
+
+	return nil
+}
+
+func (r *SavedSearchRepository) List(ctx context.Context, userID string) ([]domain.SavedSearch, error) {
+	query := `
+	SELECT id, user_id, artist, city, created_at
+	FROM saved_searches
+	WHERE user_id = ?
+	ORDER BY created_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []domain.SavedSearch{}
+	for rows.Next() {
+		var search domain.SavedSearch
+		var artist, city sql.NullString
+
+		if err := rows.Scan(&search.ID, &search.UserID, &artist, &city, &search.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan saved search: %w", err)
+		}
+		search.Artist = artist.String
+		search.City = city.String
+
+		searches = append(searches, search)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate saved searches: %w", err)
+	}
+
+	return searches, nil
+}
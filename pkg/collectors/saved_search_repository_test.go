@@ -0,0 +1,58 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestSavedSearchRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewSavedSearchRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	t.Run("create and list", func(t *testing.T) {
+		if err := repo.Create(ctx, &domain.SavedSearch{ID: "search_1", UserID: "user_1", City: " Berlin "}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.Create(ctx, &domain.SavedSearch{ID: "search_2", UserID: "user_1", Artist: "Bicep", City: "London"}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		searches, err := repo.List(ctx, "user_1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(searches) != 2 || searches[0].City != "Berlin" || searches[1].Artist != "Bicep" {
+			t.Errorf("unexpected searches: %+v", searches)
+		}
+	})
+
+	t.Run("empty search is rejected", func(t *testing.T) {
+		if err := repo.Create(ctx, &domain.SavedSearch{ID: "search_3", UserID: "user_1"}); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("delete is scoped to the user", func(t *testing.T) {
+		if err := repo.Delete(ctx, "user_2", "search_1"); !errors.Is(err, domain.ErrSearchNotFound) {
+			t.Errorf("expected ErrSearchNotFound, got %v", err)
+		}
+		if err := repo.Delete(ctx, "user_1", "search_1"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		searches, _ := repo.List(ctx, "user_1")
+		if len(searches) != 1 || searches[0].ID != "search_2" {
+			t.Errorf("expected only search_2 left, got %+v", searches)
+		}
+	})
+}
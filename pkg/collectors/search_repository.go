@@ -0,0 +1,369 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// searchColumnWeights rank a match on the name above the event title, the
+// venue and the genres, in index column order
+var searchColumnWeights = []float64{10, 5, 2, 1}
+
+// SearchRepository keeps a full-text index over cached artists and events.
+// Triggers on the artists and events tables keep it current, so it must be
+// created after both repositories.
+//
+// FTS5 is used when the SQLite driver is built with it (-tags sqlite_fts5),
+// otherwise the index falls back to FTS4 and ranks in Go.
+type SearchRepository struct {
+	db      *timedDB
+	fts5    bool
+	artists *ArtistRepository
+	events  *EventRepository
+}
+
+func NewSearchRepository(db *sql.DB) (*SearchRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	timed := newTimedDB(db, "search")
+	repo := &SearchRepository{
+		db:      timed,
+		artists: &ArtistRepository{db: timed},
+		events:  &EventRepository{db: timed},
+	}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *SearchRepository) createTables() error {
+	// search_docs gives every artist and event a stable integer rowid in
+	// the index, which INSERT OR REPLACE on the base tables would not
+	query := `
+	CREATE TABLE IF NOT EXISTS search_docs (
+		doc_id INTEGER PRIMARY KEY,
+		kind TEXT NOT NULL,
+		ref_id TEXT NOT NULL,
+		UNIQUE (kind, ref_id)
+	);
+	`
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+
+	if err := r.createIndex(); err != nil {
+		return err
+	}
+
+	triggers := []string{
+		searchIndexTrigger("search_artists_insert", "INSERT", "artists", domain.SearchHitArtist,
+			"NEW.name, '', '', replace(COALESCE(NEW.genres, ''), '|', ' ')"),
+		searchIndexTrigger("search_artists_update", "UPDATE", "artists", domain.SearchHitArtist,
+			"NEW.name, '', '', replace(COALESCE(NEW.genres, ''), '|', ' ')"),
+		searchDeleteTrigger("search_artists_delete", "artists", domain.SearchHitArtist),
+		searchIndexTrigger("search_events_insert", "INSERT", "events", domain.SearchHitEvent,
+			"NEW.artist_name, COALESCE(NEW.title, ''), COALESCE(NEW.venue_name, '') || ' ' || COALESCE(NEW.venue_city, ''), ''"),
+		searchIndexTrigger("search_events_update", "UPDATE", "events", domain.SearchHitEvent,
+			"NEW.artist_name, COALESCE(NEW.title, ''), COALESCE(NEW.venue_name, '') || ' ' || COALESCE(NEW.venue_city, ''), ''"),
+		searchDeleteTrigger("search_events_delete", "events", domain.SearchHitEvent),
+	}
+	for _, trigger := range triggers {
+		if _, err := r.db.Exec(trigger); err != nil {
+			return err
+		}
+	}
+
+	return r.backfill()
+}
+
+// createIndex prefers FTS5 and keeps whichever kind of index already exists
+func (r *SearchRepository) createIndex() error {
+	var existing string
+	err := r.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'search_index'`).Scan(&existing)
+	if err == nil {
+		r.fts5 = strings.Contains(strings.ToLower(existing), "fts5")
+		return nil
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	_, err = r.db.Exec(`CREATE VIRTUAL TABLE search_index USING fts5(name, title, venue, genres, tokenize = 'unicode61 remove_diacritics 2')`)
+	if err == nil {
+		r.fts5 = true
+		return nil
+	}
+	if !strings.Contains(err.Error(), "no such module") {
+		return err
+	}
+
+	_, err = r.db.Exec(`CREATE VIRTUAL TABLE search_index USING fts4(name, title, venue, genres, tokenize=unicode61 "remove_diacritics=2")`)
+	return err
+}
+
+// searchIndexTrigger (re)indexes a row after it is written. values fill the
+// name, title, venue and genres columns. An upsert's conflict handling
+// overrides an OR IGNORE in the triggers it fires, so the doc is only added
+// when missing; triggers from before that are replaced.
+func searchIndexTrigger(name, event, table string, kind domain.SearchHitType, values string) string {
+	return fmt.Sprintf(`
+	DROP TRIGGER IF EXISTS %[1]s;
+	CREATE TRIGGER %[1]s AFTER %[2]s ON %[3]s BEGIN
+		INSERT INTO search_docs (kind, ref_id) SELECT '%[4]s', NEW.id
+		WHERE NOT EXISTS (SELECT 1 FROM search_docs WHERE kind = '%[4]s' AND ref_id = NEW.id);
+		DELETE FROM search_index WHERE rowid = (SELECT doc_id FROM search_docs WHERE kind = '%[4]s' AND ref_id = NEW.id);
+		INSERT INTO search_index (rowid, name, title, venue, genres)
+		SELECT doc_id, %[5]s FROM search_docs WHERE kind = '%[4]s' AND ref_id = NEW.id;
+	END;
+	`, name, event, table, kind, values)
+}
+
+func searchDeleteTrigger(name, table string, kind domain.SearchHitType) string {
+	return fmt.Sprintf(`
+	CREATE TRIGGER IF NOT EXISTS %[1]s AFTER DELETE ON %[2]s BEGIN
+		DELETE FROM search_index WHERE rowid = (SELECT doc_id FROM search_docs WHERE kind = '%[3]s' AND ref_id = OLD.id);
+		DELETE FROM search_docs WHERE kind = '%[3]s' AND ref_id = OLD.id;
+	END;
+	`, name, table, kind)
+}
+
+// backfill indexes rows stored before the index existed
+func (r *SearchRepository) backfill() error {
+	var indexed int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM search_docs`).Scan(&indexed); err != nil {
+		return err
+	}
+	if indexed > 0 {
+		return nil
+	}
+
+	query := `
+	INSERT OR IGNORE INTO search_docs (kind, ref_id) SELECT 'artist', id FROM artists;
+	INSERT OR IGNORE INTO search_docs (kind, ref_id) SELECT 'event', id FROM events;
+
+	INSERT INTO search_index (rowid, name, title, venue, genres)
+	SELECT d.doc_id, a.name, '', '', replace(COALESCE(a.genres, ''), '|', ' ')
+	FROM search_docs d JOIN artists a ON d.kind = 'artist' AND a.id = d.ref_id;
+
+	INSERT INTO search_index (rowid, name, title, venue, genres)
+	SELECT d.doc_id, e.artist_name, COALESCE(e.title, ''), COALESCE(e.venue_name, '') || ' ' || COALESCE(e.venue_city, ''), ''
+	FROM search_docs d JOIN events e ON d.kind = 'event' AND e.id = d.ref_id;
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+type searchMatch struct {
+	kind  domain.SearchHitType
+	refID string
+	score float64
+}
+
+func (r *SearchRepository) SearchLocal(ctx context.Context, query string, hitType domain.SearchHitType, limit int) ([]domain.SearchHit, error) {
+	match := searchMatchQuery(query)
+	if match == "" {
+		return []domain.SearchHit{}, nil
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var matches []searchMatch
+	var err error
+	if r.fts5 {
+		matches, err = r.matchFTS5(ctx, match, hitType, limit)
+	} else {
+		matches, err = r.matchFTS4(ctx, match, hitType, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search local index: %w", err)
+	}
+
+	hits := make([]domain.SearchHit, 0, len(matches))
+	for _, m := range matches {
+		hit := domain.SearchHit{Type: m.kind, Score: m.score}
+
+		switch m.kind {
+		case domain.SearchHitArtist:
+			hit.Artist, err = r.artists.GetByID(ctx, m.refID)
+		case domain.SearchHitEvent:
+			hit.Event, err = r.events.GetByID(ctx, m.refID)
+		default:
+			continue
+		}
+		// Skip rows removed since the match
+		if errors.Is(err, domain.ErrArtistNotFound) || errors.Is(err, domain.ErrEventNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
+
+func (r *SearchRepository) matchFTS5(ctx context.Context, match string, hitType domain.SearchHitType, limit int) ([]searchMatch, error) {
+	weights := make([]string, len(searchColumnWeights))
+	for i, w := range searchColumnWeights {
+		weights[i] = fmt.Sprintf("%g", w)
+	}
+
+	query := fmt.Sprintf(`
+	SELECT d.kind, d.ref_id, bm25(search_index, %s) AS rank
+	FROM search_index
+	JOIN search_docs d ON d.doc_id = search_index.rowid
+	WHERE search_index MATCH ?
+	`, strings.Join(weights, ", "))
+	args := []interface{}{match}
+
+	if hitType != "" {
+		query += " AND d.kind = ?"
+		args = append(args, string(hitType))
+	}
+	query += " ORDER BY rank LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []searchMatch
+	for rows.Next() {
+		var m searchMatch
+		var kind string
+		var rank float64
+		if err := rows.Scan(&kind, &m.refID, &rank); err != nil {
+			return nil, err
+		}
+		// bm25 is lower for better matches
+		m.kind = domain.SearchHitType(kind)
+		m.score = -rank
+		matches = append(matches, m)
+	}
+
+	return matches, rows.Err()
+}
+
+// matchFTS4 scores every match with BM25 over matchinfo, as FTS4 has no
+// built-in ranking
+func (r *SearchRepository) matchFTS4(ctx context.Context, match string, hitType domain.SearchHitType, limit int) ([]searchMatch, error) {
+	query := `
+	SELECT d.kind, d.ref_id, matchinfo(search_index, 'pcnxl') AS info
+	FROM search_index
+	JOIN search_docs d ON d.doc_id = search_index.rowid
+	WHERE search_index MATCH ?
+	`
+	args := []interface{}{match}
+
+	if hitType != "" {
+		query += " AND d.kind = ?"
+		args = append(args, string(hitType))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []searchMatch
+	for rows.Next() {
+		var m searchMatch
+		var kind string
+		var info []byte
+		if err := rows.Scan(&kind, &m.refID, &info); err != nil {
+			return nil, err
+		}
+		m.kind = domain.SearchHitType(kind)
+		m.score = bm25FromMatchinfo(info)
+		matches = append(matches, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// bm25FromMatchinfo computes a weighted BM25 score from a 'pcnxl'
+// matchinfo blob: phrase and column counts, the row count, hit counts per
+// phrase and column, then the row's column lengths in tokens. Column
+// lengths are compared against a fixed average, which is close enough for
+// short names and titles.
+func bm25FromMatchinfo(info []byte) float64 {
+	const k1, b, avgLength = 1.2, 0.75, 4.0
+
+	values := make([]uint32, len(info)/4)
+	for i := range values {
+		values[i] = binary.NativeEndian.Uint32(info[i*4:])
+	}
+	if len(values) < 3 {
+		return 0
+	}
+
+	phrases, columns, rowCount := int(values[0]), int(values[1]), float64(values[2])
+	hits := values[3:]
+	if len(hits) < 3*phrases*columns+columns {
+		return 0
+	}
+	lengths := hits[3*phrases*columns:]
+
+	score := 0.0
+	for p := 0; p < phrases; p++ {
+		for c := 0; c < columns && c < len(searchColumnWeights); c++ {
+			x := hits[3*(p*columns+c):]
+			tf, docs := float64(x[0]), float64(x[2])
+			if tf == 0 {
+				continue
+			}
+
+			idf := math.Log((rowCount-docs+0.5)/(docs+0.5) + 1)
+			norm := k1 * (1 - b + b*float64(lengths[c])/avgLength)
+			score += searchColumnWeights[c] * idf * tf * (k1 + 1) / (tf + norm)
+		}
+	}
+
+	return score
+}
+
+// searchMatchQuery turns free text into a MATCH expression that needs every
+// word, each as a prefix. Punctuation is dropped so user input can't use
+// FTS syntax.
+func searchMatchQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		terms = append(terms, word+"*")
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,137 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestSearchRepository_SearchLocal(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	artists, err := NewArtistRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create artist repository: %v", err)
+	}
+	events, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create event repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	// Stored before the index exists, so it has to be backfilled
+	if err := artists.Create(ctx, &domain.Artist{ID: "a1", Name: "Radiohead", Genres: []string{"art rock", "alternative"}}); err != nil {
+		t.Fatalf("failed to create artist: %v", err)
+	}
+
+	search, err := NewSearchRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create search repository: %v", err)
+	}
+
+	if err := artists.Create(ctx, &domain.Artist{ID: "a2", Name: "Röyksopp", Genres: []string{"electronic"}}); err != nil {
+		t.Fatalf("failed to create artist: %v", err)
+	}
+
+	tour := newTestEvent("e1", "Radiohead", time.Now().Add(24*time.Hour))
+	tour.Title = "In Rainbows Tour"
+	tour.Venue.Name = "Waldbühne"
+	support := newTestEvent("e2", "Someone Else", time.Now().Add(48*time.Hour))
+	support.Title = "Support for Radiohead"
+	if _, err := events.CreateBatch(ctx, []domain.Event{tour, support}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	t.Run("name matches rank first", func(t *testing.T) {
+		hits, err := search.SearchLocal(ctx, "radiohead", "", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(hits) != 3 {
+			t.Fatalf("expected 3 hits, got %+v", hits)
+		}
+		if hits[2].Event == nil || hits[2].Event.ID != "e2" {
+			t.Errorf("expected the title-only match last, got %+v", hits[2])
+		}
+		for i := 1; i < len(hits); i++ {
+			if hits[i].Score > hits[i-1].Score {
+				t.Errorf("expected hits best first, got scores %v then %v", hits[i-1].Score, hits[i].Score)
+			}
+		}
+	})
+
+	t.Run("prefixes, diacritics and every word", func(t *testing.T) {
+		hits, err := search.SearchLocal(ctx, "royk", "", 10)
+		if err != nil || len(hits) != 1 || hits[0].Artist == nil || hits[0].Artist.ID != "a2" {
+			t.Errorf("expected Röyksopp, got %+v (%v)", hits, err)
+		}
+
+		hits, err = search.SearchLocal(ctx, "rainbows waldbuhne", "", 10)
+		if err != nil || len(hits) != 1 || hits[0].Event == nil || hits[0].Event.ID != "e1" {
+			t.Errorf("expected the tour date, got %+v (%v)", hits, err)
+		}
+
+		hits, err = search.SearchLocal(ctx, "rainbows paris", "", 10)
+		if err != nil || len(hits) != 0 {
+			t.Errorf("expected no hits, got %+v (%v)", hits, err)
+		}
+	})
+
+	t.Run("genres and type filter", func(t *testing.T) {
+		hits, err := search.SearchLocal(ctx, "art rock", domain.SearchHitArtist, 10)
+		if err != nil || len(hits) != 1 || hits[0].Type != domain.SearchHitArtist {
+			t.Errorf("expected one artist, got %+v (%v)", hits, err)
+		}
+
+		hits, err = search.SearchLocal(ctx, "radiohead", domain.SearchHitEvent, 10)
+		if err != nil || len(hits) != 2 {
+			t.Errorf("expected two events, got %+v (%v)", hits, err)
+		}
+	})
+
+	t.Run("follows updates and deletes", func(t *testing.T) {
+		tour.Title = "OK Computer Tour"
+		if _, err := events.CreateBatch(ctx, []domain.Event{tour}); err != nil {
+			t.Fatalf("failed to store events: %v", err)
+		}
+		if hits, _ := search.SearchLocal(ctx, "rainbows", "", 10); len(hits) != 0 {
+			t.Errorf("expected the old title to be gone, got %+v", hits)
+		}
+		if hits, _ := search.SearchLocal(ctx, "computer", "", 10); len(hits) != 1 {
+			t.Errorf("expected the new title, got %+v", hits)
+		}
+
+		if err := events.Delete(ctx, "e1"); err != nil {
+			t.Fatalf("failed to delete event: %v", err)
+		}
+		if hits, _ := search.SearchLocal(ctx, "computer", "", 10); len(hits) != 0 {
+			t.Errorf("expected the deleted event to be gone, got %+v", hits)
+		}
+	})
+
+	t.Run("punctuation only", func(t *testing.T) {
+		hits, err := search.SearchLocal(ctx, `"*(`, "", 10)
+		if err != nil || len(hits) != 0 {
+			t.Errorf("expected no hits and no error, got %+v (%v)", hits, err)
+		}
+	})
+}
+
+func TestSearchMatchQuery(t *testing.T) {
+	tests := map[string]string{
+		"Radiohead":         "radiohead*",
+		"  sigur   rós ":    "sigur* rós*",
+		`AC/DC "OR" NOT x*`: "ac* dc* or* not* x*",
+		"":                  "",
+	}
+
+	for input, want := range tests {
+		if got := searchMatchQuery(input); got != want {
+			t.Errorf("searchMatchQuery(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
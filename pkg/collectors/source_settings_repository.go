@@ -0,0 +1,127 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type SourceSettingsRepository struct {
+	db *timedDB
+}
+
+func NewSourceSettingsRepository(db *sql.DB) (*SourceSettingsRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &SourceSettingsRepository{db: newTimedDB(db, "source_settings")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *SourceSettingsRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS source_settings (
+		source TEXT PRIMARY KEY,
+		enabled BOOLEAN NOT NULL,
+		weight REAL,
+		timeout_ms INTEGER NOT NULL DEFAULT 0,
+		max_results INTEGER NOT NULL DEFAULT 0,
+		max_concurrent INTEGER NOT NULL DEFAULT 0,
+		updated_at TIMESTAMP NOT NULL
+	);
+	`
+
+	if _, err := r.db.Exec(query); err != nil {
+		return err
+	}
+	return addMissingIntegerColumns(r.db.DB, "source_settings", []string{"timeout_ms", "max_results", "max_concurrent"})
+}
+
+// Save inserts or replaces the setting for its source
+func (r *SourceSettingsRepository) Save(ctx context.Context, setting *domain.SourceSetting) error {
+	if setting == nil || setting.Source == "" {
+		return fmt.Errorf("setting source is required")
+	}
+
+	query := `
+	INSERT INTO source_settings (source, enabled, weight, timeout_ms, max_results, max_concurrent, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(source) DO UPDATE SET
+		enabled = excluded.enabled,
+		weight = excluded.weight,
+		timeout_ms = excluded.timeout_ms,
+		max_results = excluded.max_results,
+		max_concurrent = excluded.max_concurrent,
+		updated_at = excluded.updated_at
+	`
+
+	setting.UpdatedAt = time.Now()
+
+	var weight sql.NullFloat64
+	if setting.Weight != nil {
+		weight = sql.NullFloat64{Float64: *setting.Weight, Valid: true}
+	}
+
+	if _, err := r.db.ExecContext(ctx, query, setting.Source, setting.Enabled, weight,
+		setting.TimeoutMS, setting.MaxResults, setting.MaxConcurrent, setting.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save source setting: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SourceSettingsRepository) Get(ctx context.Context, source string) (*domain.SourceSetting, error) {
+	query := `SELECT source, enabled, weight, timeout_ms, max_results, max_concurrent, updated_at FROM source_settings WHERE source = ?`
+
+	setting, err := scanSourceSetting(r.db.QueryRowContext(ctx, query, source))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSettingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source setting: %w", err)
+	}
+
+	return setting, nil
+}
+
+func (r *SourceSettingsRepository) List(ctx context.Context) ([]domain.SourceSetting, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT source, enabled, weight, timeout_ms, max_results, max_concurrent, updated_at FROM source_settings ORDER BY source`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list source settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := []domain.SourceSetting{}
+	for rows.Next() {
+		setting, err := scanSourceSetting(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan source setting: %w", err)
+		}
+		settings = append(settings, *setting)
+	}
+
+	return settings, rows.Err()
+}
+
+func scanSourceSetting(row rowScanner) (*domain.SourceSetting, error) {
+	var setting domain.SourceSetting
+	var weight sql.NullFloat64
+
+	if err := row.Scan(&setting.Source, &setting.Enabled, &weight,
+		&setting.TimeoutMS, &setting.MaxResults, &setting.MaxConcurrent, &setting.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if weight.Valid {
+		setting.Weight = &weight.Float64
+	}
+
+	return &setting, nil
+}
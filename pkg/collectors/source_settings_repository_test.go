@@ -0,0 +1,69 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewSourceSettingsRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewSourceSettingsRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestSourceSettingsRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewSourceSettingsRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if _, err := repo.Get(ctx, "songkick"); !errors.Is(err, domain.ErrSettingNotFound) {
+		t.Fatalf("expected ErrSettingNotFound, got %v", err)
+	}
+
+	weight := 0.4
+	if err := repo.Save(ctx, &domain.SourceSetting{Source: "songkick", Enabled: true, Weight: &weight}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := repo.Save(ctx, &domain.SourceSetting{Source: "bandsintown", Enabled: false, TimeoutMS: 500, MaxResults: 10, MaxConcurrent: 2}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	found, err := repo.Get(ctx, "songkick")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !found.Enabled || found.Weight == nil || *found.Weight != 0.4 || found.UpdatedAt.IsZero() {
+		t.Errorf("unexpected setting %+v", found)
+	}
+
+	if err := repo.Save(ctx, &domain.SourceSetting{Source: "songkick", Enabled: false}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	settings, err := repo.List(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(settings) != 2 || settings[0].Source != "bandsintown" || settings[1].Enabled || settings[1].Weight != nil {
+		t.Errorf("expected the replaced setting in source order, got %+v", settings)
+	}
+	if limits := settings[0]; limits.TimeoutMS != 500 || limits.MaxResults != 10 || limits.MaxConcurrent != 2 {
+		t.Errorf("expected the limits to be stored, got %+v", limits)
+	}
+
+	if err := repo.Save(ctx, &domain.SourceSetting{}); err == nil {
+		t.Error("expected an error for a setting without a source")
+	}
+}
@@ -0,0 +1,381 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// StatsRepository reports how much the database holds. It owns no tables.
+type StatsRepository struct {
+	db *timedDB
+}
+
+func NewStatsRepository(db *sql.DB) (*StatsRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	return &StatsRepository{db: newTimedDB(db, "stats")}, nil
+}
+
+// DatabaseStats counts the rows of every table the repositories created and
+// sizes the database from its pages
+func (r *StatsRepository) DatabaseStats(ctx context.Context) (*domain.DatabaseStats, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := &domain.DatabaseStats{Tables: make(map[string]int64, len(tables))}
+	for _, table := range tables {
+		var count int64
+		if err := r.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM "%s"`, table)).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", table, err)
+		}
+		stats.Tables[table] = count
+	}
+
+	err = r.db.QueryRowContext(ctx, `SELECT page_count * page_size FROM pragma_page_count(), pragma_page_size()`).Scan(&stats.SizeBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to size database: %w", err)
+	}
+
+	return stats, nil
+}
+
+// CityOverview counts a city's upcoming events by week, venue and headliner
+// in SQLite. Artists are ranked by how many events they headline, then by
+// the popularity stored for them, matched by ID or name.
+func (r *StatsRepository) CityOverview(ctx context.Context, filter domain.CityOverviewFilter) (*domain.CityOverview, error) {
+	if filter.Weeks <= 0 {
+		filter.Weeks = 12
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	from := filter.From.UTC()
+	to := from.AddDate(0, 0, 7*filter.Weeks)
+
+	// Selects the city's events in [From, To) that are still on, under any
+	// of the city's names
+	cityCondition, args := cityMatch("e.venue_city", filter.City)
+	cityEventsWhere := `
+	WHERE ` + cityCondition + `
+		AND e.datetime >= ? AND e.datetime < ?
+		AND COALESCE(e.status, '') != 'cancelled'
+`
+	args = append(args, from, to)
+
+	overview := &domain.CityOverview{
+		City:            filter.City,
+		Weeks:           make([]domain.WeekCount, filter.Weeks),
+		TopVenues:       []domain.VenueCount{},
+		TrendingArtists: []domain.TrendingArtist{},
+	}
+	for i := range overview.Weeks {
+		overview.Weeks[i].Start = from.AddDate(0, 0, 7*i)
+	}
+
+	weekArgs := append([]interface{}{from}, args...)
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT CAST((julianday(e.datetime) - julianday(?)) / 7 AS INTEGER) AS week, COUNT(*)
+	FROM events e`+cityEventsWhere+`
+	GROUP BY week`, weekArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count city events by week: %w", err)
+	}
+	for rows.Next() {
+		var week, count int
+		if err := rows.Scan(&week, &count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan week count: %w", err)
+		}
+		if week >= 0 && week < len(overview.Weeks) {
+			overview.Weeks[week].Events = count
+			overview.TotalEvents += count
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `
+	SELECT COALESCE(MAX(e.venue_id), ''), MIN(e.venue_name), COUNT(*) AS events
+	FROM events e`+cityEventsWhere+`
+	GROUP BY e.venue_name COLLATE NOCASE
+	ORDER BY events DESC, MIN(e.venue_name)
+	LIMIT ?`, append(args, filter.Limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank city venues: %w", err)
+	}
+	for rows.Next() {
+		var venue domain.VenueCount
+		if err := rows.Scan(&venue.ID, &venue.Name, &venue.Events); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan venue count: %w", err)
+		}
+		overview.TopVenues = append(overview.TopVenues, venue)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// An event can match more than one stored artist, so events are
+	// counted distinctly
+	rows, err = r.db.QueryContext(ctx, `
+	SELECT COALESCE(MAX(a.id), ''), MIN(e.artist_name), COUNT(DISTINCT e.id) AS events,
+		COALESCE(MAX(a.popularity), 0) AS popularity
+	FROM events e
+	LEFT JOIN artists a ON a.id = e.artist_id OR a.name = e.artist_name COLLATE NOCASE`+cityEventsWhere+`
+	GROUP BY e.artist_name COLLATE NOCASE
+	ORDER BY events DESC, popularity DESC, MIN(e.artist_name)
+	LIMIT ?`, append(args, filter.Limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank city artists: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var artist domain.TrendingArtist
+		if err := rows.Scan(&artist.ArtistID, &artist.Name, &artist.Events, &artist.Popularity); err != nil {
+			return nil, fmt.Errorf("failed to scan trending artist: %w", err)
+		}
+		overview.TrendingArtists = append(overview.TrendingArtists, artist)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return overview, nil
+}
+
+// artistShowsQuery selects an artist's shows into distinct_shows: events
+// they headline or support that weren't cancelled, and past concerts. A
+// show more than one source has, on the same day in the same city, is kept
+// once. Its arguments are the artist's ID and name, three times.
+const artistShowsQuery = `
+	WITH shows AS (
+		SELECT e.datetime, e.venue_city AS city, e.venue_country AS country, e.venue_capacity AS capacity
+		FROM events e
+		WHERE (e.artist_id = ? OR e.artist_name = ? COLLATE NOCASE
+				OR e.id IN (SELECT event_id FROM event_artists WHERE artist_id = ? OR artist_name = ? COLLATE NOCASE))
+			AND COALESCE(e.status, '') != 'cancelled'
+		UNION ALL
+		SELECT p.datetime, p.venue_city, p.venue_country, 0
+		FROM past_concerts p
+		WHERE p.artist_id = ? OR p.artist_name = ? COLLATE NOCASE
+	),
+	distinct_shows AS (
+		SELECT MIN(datetime) AS datetime, MIN(city) AS city, MIN(country) AS country, MAX(capacity) AS capacity
+		FROM shows
+		GROUP BY date(datetime), city COLLATE NOCASE
+	)
+`
+
+// ArtistStats counts the artist's shows by year and city, and how big their
+// venues were each year. The artist is the stored one with artistID, or
+// else whoever the stored events with that artist ID are by.
+func (r *StatsRepository) ArtistStats(ctx context.Context, artistID string, limit int) (*domain.ArtistStats, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var name string
+	err := r.db.QueryRowContext(ctx, `
+	SELECT name FROM artists WHERE id = ?
+	UNION ALL
+	SELECT artist_name FROM events WHERE artist_id = ?
+	LIMIT 1`, artistID, artistID).Scan(&name)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrArtistNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find artist: %w", err)
+	}
+
+	args := []interface{}{artistID, name, artistID, name, artistID, name}
+	stats := &domain.ArtistStats{
+		ArtistID:       artistID,
+		Name:           name,
+		EventsPerYear:  []domain.YearCount{},
+		TopCities:      []domain.CityCount{},
+		VenueSizeTrend: []domain.VenueSizeYear{},
+	}
+
+	rows, err := r.db.QueryContext(ctx, artistShowsQuery+`
+	SELECT CAST(strftime('%Y', datetime) AS INTEGER) AS year, COUNT(*),
+		COUNT(NULLIF(capacity, 0)), COALESCE(CAST(AVG(NULLIF(capacity, 0)) AS INTEGER), 0), MAX(capacity)
+	FROM distinct_shows
+	GROUP BY year
+	ORDER BY year`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count artist shows by year: %w", err)
+	}
+	for rows.Next() {
+		var year, events int
+		var size domain.VenueSizeYear
+		if err := rows.Scan(&year, &events, &size.Events, &size.AverageCapacity, &size.LargestCapacity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan year count: %w", err)
+		}
+		// Years without shows in between are counted as empty
+		if count := len(stats.EventsPerYear); count > 0 {
+			for gap := stats.EventsPerYear[count-1].Year + 1; gap < year; gap++ {
+				stats.EventsPerYear = append(stats.EventsPerYear, domain.YearCount{Year: gap})
+			}
+		}
+		stats.EventsPerYear = append(stats.EventsPerYear, domain.YearCount{Year: year, Events: events})
+		stats.TotalEvents += events
+		if size.Events > 0 {
+			size.Year = year
+			stats.VenueSizeTrend = append(stats.VenueSizeTrend, size)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, artistShowsQuery+`
+	SELECT MIN(city), COALESCE(MIN(country), ''), COUNT(*) AS events
+	FROM distinct_shows
+	WHERE city != ''
+	GROUP BY city COLLATE NOCASE
+	ORDER BY events DESC, MIN(city)
+	LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank artist cities: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var city domain.CityCount
+		if err := rows.Scan(&city.City, &city.Country, &city.Events); err != nil {
+			return nil, fmt.Errorf("failed to scan city count: %w", err)
+		}
+		stats.TopCities = append(stats.TopCities, city)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// CityStats counts the city's events by month, under any of the city's
+// names, and ranks the genres of their headliners. Genres come from the
+// pipe-separated genres of the stored artists the events match by ID or
+// name, split in SQL.
+func (r *StatsRepository) CityStats(ctx context.Context, city string, limit int) (*domain.CityStats, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	cityCondition, args := cityMatch("e.venue_city", city)
+	cityEventsWhere := `
+	WHERE ` + cityCondition + `
+		AND COALESCE(e.status, '') != 'cancelled'
+`
+
+	stats := &domain.CityStats{
+		City:           city,
+		EventsPerMonth: []domain.MonthCount{},
+		Genres:         []domain.GenreCount{},
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+	SELECT strftime('%Y-%m', e.datetime) AS month, COUNT(*)
+	FROM events e`+cityEventsWhere+`
+	GROUP BY month
+	ORDER BY month`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count city events by month: %w", err)
+	}
+	for rows.Next() {
+		var month domain.MonthCount
+		if err := rows.Scan(&month.Month, &month.Events); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan month count: %w", err)
+		}
+		// Months without events in between are counted as empty
+		if count := len(stats.EventsPerMonth); count > 0 {
+			stats.EventsPerMonth = append(stats.EventsPerMonth, emptyMonthsBetween(stats.EventsPerMonth[count-1].Month, month.Month)...)
+		}
+		stats.EventsPerMonth = append(stats.EventsPerMonth, month)
+		stats.TotalEvents += month.Events
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = r.db.QueryContext(ctx, `
+	WITH RECURSIVE event_genres(event_id, genre, rest) AS (
+		SELECT e.id, '', COALESCE(a.genres, '') || '|'
+		FROM events e
+		JOIN artists a ON a.id = e.artist_id OR a.name = e.artist_name COLLATE NOCASE`+cityEventsWhere+`
+		UNION ALL
+		SELECT event_id, lower(trim(substr(rest, 1, instr(rest, '|') - 1))), substr(rest, instr(rest, '|') + 1)
+		FROM event_genres
+		WHERE rest != ''
+	)
+	SELECT genre, COUNT(DISTINCT event_id) AS events
+	FROM event_genres
+	WHERE genre != ''
+	GROUP BY genre
+	ORDER BY events DESC, genre
+	LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count city events by genre: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var genre domain.GenreCount
+		if err := rows.Scan(&genre.Genre, &genre.Events); err != nil {
+			return nil, fmt.Errorf("failed to scan genre count: %w", err)
+		}
+		stats.Genres = append(stats.Genres, genre)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// emptyMonthsBetween lists the months after `from` and before `to`, both
+// like "2026-10", with no events
+func emptyMonthsBetween(from, to string) []domain.MonthCount {
+	start, err := time.Parse("2006-01", from)
+	if err != nil {
+		return nil
+	}
+	end, err := time.Parse("2006-01", to)
+	if err != nil {
+		return nil
+	}
+
+	var months []domain.MonthCount
+	for month := start.AddDate(0, 1, 0); month.Before(end); month = month.AddDate(0, 1, 0) {
+		months = append(months, domain.MonthCount{Month: month.Format("2006-01")})
+	}
+	return months
+}
@@ -0,0 +1,314 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewStatsRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewStatsRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestStatsRepository_DatabaseStats(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	events, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create event repository: %v", err)
+	}
+	repo, err := NewStatsRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := time.Now().Add(24 * time.Hour)
+	if _, err := events.CreateBatch(ctx, []domain.Event{newTestEvent("e1", "Test Artist", at), newTestEvent("e2", "Test Artist", at)}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	stats, err := repo.DatabaseStats(ctx)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stats.Tables["events"] != 2 {
+		t.Errorf("expected 2 events, got %v", stats.Tables)
+	}
+	if count, ok := stats.Tables["event_changes"]; !ok || count != 0 {
+		t.Errorf("expected empty tables to be listed, got %v", stats.Tables)
+	}
+	if stats.SizeBytes <= 0 {
+		t.Errorf("expected a database size, got %d", stats.SizeBytes)
+	}
+}
+
+func TestStatsRepository_CityOverview(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	events, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create event repository: %v", err)
+	}
+	artists, err := NewArtistRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create artist repository: %v", err)
+	}
+	repo, err := NewStatsRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	from := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	at := func(id, artist, venue string, days int) domain.Event {
+		event := newTestEvent(id, artist, from.Add(time.Duration(days)*24*time.Hour+20*time.Hour+30*time.Minute+123456789))
+		event.Venue.Name = venue
+		return event
+	}
+	cancelled := at("cancelled", "Fred again..", "Berghain", 2)
+	cancelled.Status = domain.EventCancelled
+	hamburg := at("hamburg", "Fred again..", "Docks", 2)
+	hamburg.Venue.City = "Hamburg"
+	stored := []domain.Event{
+		at("e1", "Fred again..", "Berghain", 0),
+		at("e2", "fred again..", "Berghain", 8),
+		at("e3", "Bicep", "Columbiahalle", 1),
+		at("e4", "Jamie xx", "Columbiahalle", 9),
+		at("e5", "Bicep", "Tempodrom", 20),
+		at("past", "Bicep", "Tempodrom", -3),
+		at("later", "Bicep", "Tempodrom", 40),
+		cancelled,
+		hamburg,
+	}
+	if _, err := events.CreateBatch(ctx, stored); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+	if err := artists.Create(ctx, &domain.Artist{ID: "artist_fred", Name: "Fred Again..", Popularity: 80}); err != nil {
+		t.Fatalf("failed to store artist: %v", err)
+	}
+	if err := artists.Create(ctx, &domain.Artist{ID: "artist_bicep", Name: "Bicep", Popularity: 60}); err != nil {
+		t.Fatalf("failed to store artist: %v", err)
+	}
+
+	overview, err := repo.CityOverview(ctx, domain.CityOverviewFilter{City: "berlin", From: from, Weeks: 4, Limit: 2})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if overview.TotalEvents != 5 {
+		t.Errorf("expected 5 events in the 4 weeks, got %d", overview.TotalEvents)
+	}
+	var weeks []int
+	for _, week := range overview.Weeks {
+		weeks = append(weeks, week.Events)
+	}
+	if len(weeks) != 4 || weeks[0] != 2 || weeks[1] != 2 || weeks[2] != 1 || weeks[3] != 0 {
+		t.Errorf("expected 2, 2, 1, 0 by week, got %v", weeks)
+	}
+	if !overview.Weeks[1].Start.Equal(from.AddDate(0, 0, 7)) {
+		t.Errorf("expected the second week to start a week in, got %v", overview.Weeks[1].Start)
+	}
+
+	if len(overview.TopVenues) != 2 || overview.TopVenues[0].Name != "Berghain" || overview.TopVenues[0].Events != 2 || overview.TopVenues[1].Name != "Columbiahalle" {
+		t.Errorf("unexpected top venues %+v", overview.TopVenues)
+	}
+
+	// Fred again.. and Bicep both headline two; Fred again.. is more popular
+	want := []domain.TrendingArtist{
+		{ArtistID: "artist_fred", Name: "Fred again..", Events: 2, Popularity: 80},
+		{ArtistID: "artist_bicep", Name: "Bicep", Events: 2, Popularity: 60},
+	}
+	if len(overview.TrendingArtists) != 2 || overview.TrendingArtists[0] != want[0] || overview.TrendingArtists[1] != want[1] {
+		t.Errorf("expected %+v, got %+v", want, overview.TrendingArtists)
+	}
+
+	empty, err := repo.CityOverview(ctx, domain.CityOverviewFilter{City: "Nowhere", From: from})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if empty.TotalEvents != 0 || len(empty.Weeks) != 12 || empty.TopVenues == nil || empty.TrendingArtists == nil {
+		t.Errorf("expected an empty overview over 12 weeks, got %+v", empty)
+	}
+}
+
+func TestStatsRepository_ArtistStats(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	events, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create event repository: %v", err)
+	}
+	artists, err := NewArtistRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create artist repository: %v", err)
+	}
+	concerts, err := NewPastConcertRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create past concert repository: %v", err)
+	}
+	repo, err := NewStatsRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := artists.Create(ctx, &domain.Artist{ID: "artist_bicep", Name: "Bicep"}); err != nil {
+		t.Fatalf("failed to store artist: %v", err)
+	}
+
+	show := func(id, artist, city string, at time.Time, capacity int) domain.Event {
+		event := newTestEvent(id, artist, at)
+		event.Venue.City = city
+		event.Venue.Capacity = capacity
+		return event
+	}
+	// Bicep supports Jamie xx at the biggest venue
+	support := show("support", "Jamie xx", "Berlin", time.Date(2026, 11, 1, 20, 0, 0, 0, time.UTC), 5000)
+	support.Lineup = []domain.EventArtist{{Name: "Jamie xx", Headliner: true}, {Name: "Bicep"}}
+	cancelled := show("cancelled", "Bicep", "Berlin", time.Date(2026, 12, 1, 20, 0, 0, 0, time.UTC), 8000)
+	cancelled.Status = domain.EventCancelled
+	stored := []domain.Event{
+		show("e1", "Bicep", "Berlin", time.Date(2024, 3, 10, 20, 0, 0, 0, time.UTC), 1000),
+		show("e2", "Bicep", "Hamburg", time.Date(2024, 7, 1, 20, 0, 0, 0, time.UTC), 0),
+		show("e3", "Bicep", "Berlin", time.Date(2026, 9, 1, 20, 0, 0, 0, time.UTC), 3000),
+		// The same show from another source, without a capacity
+		show("e3_again", "bicep", "berlin", time.Date(2026, 9, 1, 21, 0, 0, 0, time.UTC), 0),
+		support,
+		cancelled,
+		show("other", "Jamie xx", "Berlin", time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC), 0),
+	}
+	if _, err := events.CreateBatch(ctx, stored); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+
+	past := func(id, city string, at time.Time) domain.PastConcert {
+		return domain.PastConcert{Source: "setlistfm", Event: show(id, "Bicep", city, at, 0)}
+	}
+	if err := concerts.SaveBatch(ctx, []domain.PastConcert{
+		past("p1", "London", time.Date(2022, 5, 1, 20, 0, 0, 0, time.UTC)),
+		// Also stored as e1
+		past("p2", "Berlin", time.Date(2024, 3, 10, 19, 0, 0, 0, time.UTC)),
+	}); err != nil {
+		t.Fatalf("failed to store past concerts: %v", err)
+	}
+
+	stats, err := repo.ArtistStats(ctx, "artist_bicep", 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if stats.Name != "Bicep" || stats.TotalEvents != 5 {
+		t.Errorf("expected Bicep's 5 shows, got %s with %d", stats.Name, stats.TotalEvents)
+	}
+	wantYears := []domain.YearCount{{Year: 2022, Events: 1}, {Year: 2023}, {Year: 2024, Events: 2}, {Year: 2025}, {Year: 2026, Events: 2}}
+	if len(stats.EventsPerYear) != len(wantYears) {
+		t.Fatalf("expected %+v, got %+v", wantYears, stats.EventsPerYear)
+	}
+	for i, want := range wantYears {
+		if stats.EventsPerYear[i] != want {
+			t.Errorf("expected %+v, got %+v", wantYears, stats.EventsPerYear)
+			break
+		}
+	}
+
+	wantCities := []domain.CityCount{{City: "Berlin", Country: "Germany", Events: 3}, {City: "Hamburg", Country: "Germany", Events: 1}}
+	if len(stats.TopCities) != 2 || stats.TopCities[0] != wantCities[0] || stats.TopCities[1] != wantCities[1] {
+		t.Errorf("expected %+v, got %+v", wantCities, stats.TopCities)
+	}
+
+	wantSizes := []domain.VenueSizeYear{
+		{Year: 2024, Events: 1, AverageCapacity: 1000, LargestCapacity: 1000},
+		{Year: 2026, Events: 2, AverageCapacity: 4000, LargestCapacity: 5000},
+	}
+	if len(stats.VenueSizeTrend) != 2 || stats.VenueSizeTrend[0] != wantSizes[0] || stats.VenueSizeTrend[1] != wantSizes[1] {
+		t.Errorf("expected %+v, got %+v", wantSizes, stats.VenueSizeTrend)
+	}
+
+	// An artist only known from events is found by the events' artist ID
+	unstored, err := repo.ArtistStats(ctx, "songkick_artist_other", 10)
+	if err != nil || unstored.Name != "Jamie xx" || unstored.TotalEvents != 2 {
+		t.Errorf("expected Jamie xx's 2 shows, got %+v, %v", unstored, err)
+	}
+
+	if _, err := repo.ArtistStats(ctx, "missing", 10); err != domain.ErrArtistNotFound {
+		t.Errorf("expected ErrArtistNotFound, got %v", err)
+	}
+}
+
+func TestStatsRepository_CityStats(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	events, err := NewEventRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create event repository: %v", err)
+	}
+	artists, err := NewArtistRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create artist repository: %v", err)
+	}
+	repo, err := NewStatsRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	at := func(id, artist string, month time.Month) domain.Event {
+		return newTestEvent(id, artist, time.Date(2026, month, 12, 20, 0, 0, 0, time.UTC))
+	}
+	cancelled := at("cancelled", "Bicep", time.December)
+	cancelled.Status = domain.EventCancelled
+	hamburg := at("hamburg", "Bicep", time.September)
+	hamburg.Venue.City = "Hamburg"
+	if _, err := events.CreateBatch(ctx, []domain.Event{
+		at("e1", "Bicep", time.September),
+		at("e2", "Fred again..", time.September),
+		at("e3", "Unknown Act", time.November),
+		cancelled,
+		hamburg,
+	}); err != nil {
+		t.Fatalf("failed to store events: %v", err)
+	}
+	if err := artists.Create(ctx, &domain.Artist{ID: "artist_bicep", Name: "Bicep", Genres: []string{"Electronic", "House"}}); err != nil {
+		t.Fatalf("failed to store artist: %v", err)
+	}
+	if err := artists.Create(ctx, &domain.Artist{ID: "artist_fred", Name: "Fred again..", Genres: []string{"house", "uk garage"}}); err != nil {
+		t.Fatalf("failed to store artist: %v", err)
+	}
+
+	stats, err := repo.CityStats(ctx, "berlin", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if stats.TotalEvents != 3 {
+		t.Errorf("expected 3 events, got %d", stats.TotalEvents)
+	}
+	wantMonths := []domain.MonthCount{{Month: "2026-09", Events: 2}, {Month: "2026-10"}, {Month: "2026-11", Events: 1}}
+	if len(stats.EventsPerMonth) != 3 || stats.EventsPerMonth[0] != wantMonths[0] || stats.EventsPerMonth[1] != wantMonths[1] || stats.EventsPerMonth[2] != wantMonths[2] {
+		t.Errorf("expected %+v, got %+v", wantMonths, stats.EventsPerMonth)
+	}
+	wantGenres := []domain.GenreCount{{Genre: "house", Events: 2}, {Genre: "electronic", Events: 1}, {Genre: "uk garage", Events: 1}}
+	if len(stats.Genres) != 3 || stats.Genres[0] != wantGenres[0] || stats.Genres[1] != wantGenres[1] || stats.Genres[2] != wantGenres[2] {
+		t.Errorf("expected %+v, got %+v", wantGenres, stats.Genres)
+	}
+
+	empty, err := repo.CityStats(ctx, "Nowhere", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if empty.TotalEvents != 0 || empty.EventsPerMonth == nil || empty.Genres == nil {
+		t.Errorf("expected empty stats, got %+v", empty)
+	}
+}
@@ -0,0 +1,215 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// TelegramLinkRepository stores which Telegram chat each user gets alerts
+// in, and the one-time codes that link them
+type TelegramLinkRepository struct {
+	db *timedDB
+}
+
+func NewTelegramLinkRepository(db *sql.DB) (*TelegramLinkRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &TelegramLinkRepository{db: newTimedDB(db, "telegram_links")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *TelegramLinkRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS telegram_links (
+		user_id TEXT PRIMARY KEY,
+		chat_id INTEGER NOT NULL,
+		linked_at TIMESTAMP NOT NULL,
+		last_alert_at TIMESTAMP
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_telegram_links_chat_id ON telegram_links(chat_id);
+
+	CREATE TABLE IF NOT EXISTS telegram_link_codes (
+		code TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		expires_at TIMESTAMP NOT NULL
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *TelegramLinkRepository) CreateLinkCode(ctx context.Context, userID, code string, expiresAt time.Time) error {
+	if userID == "" || code == "" {
+		return fmt.Errorf("user ID and code are required")
+	}
+
+	query := `INSERT INTO telegram_link_codes (code, user_id, expires_at) VALUES (?, ?, ?)`
+
+	if _, err := r.db.ExecContext(ctx, query, code, userID, expiresAt); err != nil {
+		return fmt.Errorf("failed to create link code: %w", err)
+	}
+
+	return nil
+}
+
+// ConsumeLinkCode also clears out expired codes
+func (r *TelegramLinkRepository) ConsumeLinkCode(ctx context.Context, code string, now time.Time) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID string
+	var expiresAt time.Time
+	err = tx.QueryRowContext(ctx, `SELECT user_id, expires_at FROM telegram_link_codes WHERE code = ?`, code).Scan(&userID, &expiresAt)
+	if err != nil && err != sql.ErrNoRows {
+		return "", fmt.Errorf("failed to get link code: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM telegram_link_codes WHERE code = ? OR expires_at <= ?`, code, now); err != nil {
+		return "", fmt.Errorf("failed to delete link code: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if userID == "" || !expiresAt.After(now) {
+		return "", domain.ErrLinkCodeInvalid
+	}
+
+	return userID, nil
+}
+
+// Link points the user's alerts at a chat, replacing any earlier chat
+func (r *TelegramLinkRepository) Link(ctx context.Context, userID string, chatID int64) error {
+	query := `
+	INSERT INTO telegram_links (user_id, chat_id, linked_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(user_id) DO UPDATE SET
+		chat_id = excluded.chat_id,
+		linked_at = excluded.linked_at,
+		last_alert_at = NULL
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, chatID, time.Now()); err != nil {
+		return fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TelegramLinkRepository) Get(ctx context.Context, userID string) (*domain.TelegramLink, error) {
+	query := `SELECT user_id, chat_id, linked_at, last_alert_at FROM telegram_links WHERE user_id = ?`
+
+	link, err := scanTelegramLink(r.db.QueryRowContext(ctx, query, userID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get telegram link: %w", err)
+	}
+
+	return link, nil
+}
+
+func (r *TelegramLinkRepository) Unlink(ctx context.Context, userID string) error {
+	return r.delete(ctx, "user_id", userID)
+}
+
+// UnlinkChat removes every user linked to the chat, e.g. when it blocks
+// the bot
+func (r *TelegramLinkRepository) UnlinkChat(ctx context.Context, chatID int64) error {
+	return r.delete(ctx, "chat_id", chatID)
+}
+
+func (r *TelegramLinkRepository) delete(ctx context.Context, column string, value interface{}) error {
+	query := fmt.Sprintf(`DELETE FROM telegram_links WHERE %s = ?`, column)
+
+	result, err := r.db.ExecContext(ctx, query, value)
+	if err != nil {
+		return fmt.Errorf("failed to unlink telegram chat: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrLinkNotFound
+	}
+
+	return nil
+}
+
+func (r *TelegramLinkRepository) ListLinks(ctx context.Context) ([]domain.TelegramLink, error) {
+	query := `SELECT user_id, chat_id, linked_at, last_alert_at FROM telegram_links ORDER BY linked_at`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list telegram links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []domain.TelegramLink{}
+	for rows.Next() {
+		link, err := scanTelegramLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan telegram link: %w", err)
+		}
+		links = append(links, *link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate telegram links: %w", err)
+	}
+
+	return links, nil
+}
+
+func (r *TelegramLinkRepository) MarkAlerted(ctx context.Context, userID string, alertedAt time.Time) error {
+	query := `UPDATE telegram_links SET last_alert_at = ? WHERE user_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, alertedAt, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark telegram alert: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrLinkNotFound
+	}
+
+	return nil
+}
+
+func scanTelegramLink(row rowScanner) (*domain.TelegramLink, error) {
+	var link domain.TelegramLink
+	var lastAlertAt sql.NullTime
+
+	if err := row.Scan(&link.UserID, &link.ChatID, &link.LinkedAt, &lastAlertAt); err != nil {
+		return nil, err
+	}
+	if lastAlertAt.Valid {
+		link.LastAlertAt = &lastAlertAt.Time
+	}
+
+	return &link, nil
+}
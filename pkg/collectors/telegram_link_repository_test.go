@@ -0,0 +1,113 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestTelegramLinkRepository_LinkCodes(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewTelegramLinkRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+
+	repo.CreateLinkCode(ctx, "user_1", "fresh", now.Add(15*time.Minute))
+	repo.CreateLinkCode(ctx, "user_2", "stale", now.Add(-time.Minute))
+
+	t.Run("codes work once", func(t *testing.T) {
+		userID, err := repo.ConsumeLinkCode(ctx, "fresh", now)
+		if err != nil || userID != "user_1" {
+			t.Fatalf("expected user_1, got %q (%v)", userID, err)
+		}
+		if _, err := repo.ConsumeLinkCode(ctx, "fresh", now); !errors.Is(err, domain.ErrLinkCodeInvalid) {
+			t.Errorf("expected ErrLinkCodeInvalid, got %v", err)
+		}
+	})
+
+	t.Run("expired and unknown codes", func(t *testing.T) {
+		if _, err := repo.ConsumeLinkCode(ctx, "stale", now); !errors.Is(err, domain.ErrLinkCodeInvalid) {
+			t.Errorf("expected ErrLinkCodeInvalid, got %v", err)
+		}
+		if _, err := repo.ConsumeLinkCode(ctx, "unknown", now); !errors.Is(err, domain.ErrLinkCodeInvalid) {
+			t.Errorf("expected ErrLinkCodeInvalid, got %v", err)
+		}
+	})
+}
+
+func TestTelegramLinkRepository_Links(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewTelegramLinkRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+
+	t.Run("link and get", func(t *testing.T) {
+		if _, err := repo.Get(ctx, "user_1"); !errors.Is(err, domain.ErrLinkNotFound) {
+			t.Errorf("expected ErrLinkNotFound, got %v", err)
+		}
+
+		repo.Link(ctx, "user_1", 1001)
+		repo.MarkAlerted(ctx, "user_1", time.Now())
+		if err := repo.Link(ctx, "user_1", 1002); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		link, err := repo.Get(ctx, "user_1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if link.ChatID != 1002 || link.LastAlertAt != nil {
+			t.Errorf("expected relinking to move the chat and reset alerts, got %+v", link)
+		}
+	})
+
+	t.Run("list and mark alerted", func(t *testing.T) {
+		repo.Link(ctx, "user_2", 2001)
+		alertedAt := time.Now()
+		if err := repo.MarkAlerted(ctx, "user_2", alertedAt); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		links, err := repo.ListLinks(ctx)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(links) != 2 || links[1].LastAlertAt == nil || !links[1].LastAlertAt.Equal(alertedAt) {
+			t.Errorf("unexpected links: %+v", links)
+		}
+		if err := repo.MarkAlerted(ctx, "nobody", alertedAt); !errors.Is(err, domain.ErrLinkNotFound) {
+			t.Errorf("expected ErrLinkNotFound, got %v", err)
+		}
+	})
+
+	t.Run("unlink", func(t *testing.T) {
+		if err := repo.UnlinkChat(ctx, 2001); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.Unlink(ctx, "user_1"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.Unlink(ctx, "user_1"); !errors.Is(err, domain.ErrLinkNotFound) {
+			t.Errorf("expected ErrLinkNotFound, got %v", err)
+		}
+
+		links, _ := repo.ListLinks(ctx)
+		if len(links) != 0 {
+			t.Errorf("expected no links, got %+v", links)
+		}
+	})
+}
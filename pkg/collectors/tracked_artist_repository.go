@@ -0,0 +1,126 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// TrackedArtistRepository stores the artists enrolled in event syncing
+type TrackedArtistRepository struct {
+	db *timedDB
+}
+
+func NewTrackedArtistRepository(db *sql.DB) (*TrackedArtistRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &TrackedArtistRepository{db: newTimedDB(db, "tracked_artists")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *TrackedArtistRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS tracked_artists (
+		artist_id TEXT PRIMARY KEY,
+		source TEXT NOT NULL,
+		last_synced_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_tracked_artists_last_synced_at ON tracked_artists(last_synced_at);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+// Track enrolls an artist; tracking an already enrolled artist is a no-op
+func (r *TrackedArtistRepository) Track(ctx context.Context, artistID, source string) error {
+	if artistID == "" {
+		return fmt.Errorf("artist ID is required")
+	}
+
+	query := `
+	INSERT INTO tracked_artists (artist_id, source, created_at)
+	VALUES (?, ?, ?)
+	ON CONFLICT(artist_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, artistID, source, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to track artist: %w", err)
+	}
+
+	return nil
+}
+
+// ListDueForSync returns never-synced artists first, then the ones synced longest ago
+func (r *TrackedArtistRepository) ListDueForSync(ctx context.Context, syncedBefore time.Time, limit int) ([]domain.TrackedArtist, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+	SELECT artist_id, source, last_synced_at, created_at
+	FROM tracked_artists
+	WHERE last_synced_at IS NULL OR last_synced_at < ?
+	ORDER BY last_synced_at IS NOT NULL, last_synced_at ASC, created_at ASC
+	LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, syncedBefore, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracked artists: %w", err)
+	}
+	defer rows.Close()
+
+	tracked := []domain.TrackedArtist{}
+	for rows.Next() {
+		var artist domain.TrackedArtist
+		var lastSyncedAt sql.NullTime
+
+		if err := rows.Scan(&artist.ArtistID, &artist.Source, &lastSyncedAt, &artist.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tracked artist: %w", err)
+		}
+		if lastSyncedAt.Valid {
+			artist.LastSyncedAt = &lastSyncedAt.Time
+		}
+
+		tracked = append(tracked, artist)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate tracked artists: %w", err)
+	}
+
+	return tracked, nil
+}
+
+func (r *TrackedArtistRepository) MarkSynced(ctx context.Context, artistID string, syncedAt time.Time) error {
+	query := `UPDATE tracked_artists SET last_synced_at = ? WHERE artist_id = ?`
+
+	result, err := r.db.ExecContext(ctx, query, syncedAt, artistID)
+	if err != nil {
+		return fmt.Errorf("failed to mark artist synced: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrArtistNotFound
+	}
+
+	return nil
+}
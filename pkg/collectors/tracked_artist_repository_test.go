@@ -0,0 +1,90 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewTrackedArtistRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewTrackedArtistRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestTrackedArtistRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewTrackedArtistRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := repo.Track(ctx, id, "spotify:user-1"); err != nil {
+			t.Fatalf("failed to track artist: %v", err)
+		}
+	}
+
+	t.Run("tracking twice is a no-op", func(t *testing.T) {
+		if err := repo.Track(ctx, "a", "lastfm:user-2"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		due, err := repo.ListDueForSync(ctx, now, 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(due) != 3 {
+			t.Fatalf("expected 3 tracked artists, got %d", len(due))
+		}
+		if due[0].Source != "spotify:user-1" {
+			t.Errorf("expected original source to be kept, got %s", due[0].Source)
+		}
+	})
+
+	t.Run("recently synced artists are not due", func(t *testing.T) {
+		if err := repo.MarkSynced(ctx, "a", now); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if err := repo.MarkSynced(ctx, "b", now.Add(-48*time.Hour)); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		due, err := repo.ListDueForSync(ctx, now.Add(-24*time.Hour), 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(due) != 2 {
+			t.Fatalf("expected 2 due artists, got %d", len(due))
+		}
+		if due[0].ArtistID != "c" || due[0].LastSyncedAt != nil {
+			t.Errorf("expected never-synced artist first, got %+v", due[0])
+		}
+		if due[1].ArtistID != "b" || due[1].LastSyncedAt == nil {
+			t.Errorf("expected stale artist second, got %+v", due[1])
+		}
+	})
+
+	t.Run("mark unknown artist", func(t *testing.T) {
+		err := repo.MarkSynced(ctx, "unknown", now)
+		if err != domain.ErrArtistNotFound {
+			t.Errorf("expected ErrArtistNotFound, got %v", err)
+		}
+	})
+
+	t.Run("missing artist ID", func(t *testing.T) {
+		if err := repo.Track(ctx, "", "manual"); err == nil {
+			t.Error("expected error for missing artist ID")
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type UserRepository struct {
+	db *timedDB
+}
+
+func NewUserRepository(db *sql.DB) (*UserRepository, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database connection is required")
+	}
+
+	repo := &UserRepository{db: newTimedDB(db, "users")}
+	if err := repo.createTables(); err != nil {
+		return nil, fmt.Errorf("failed to create tables: %w", err)
+	}
+
+	return repo, nil
+}
+
+func (r *UserRepository) createTables() error {
+	query := `
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		email TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		updated_at TIMESTAMP NOT NULL
+	);
+	`
+
+	_, err := r.db.Exec(query)
+	return err
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	if user == nil || user.ID == "" || user.Email == "" {
+		return fmt.Errorf("user ID and email are required")
+	}
+
+	query := `
+	INSERT INTO users (id, email, password_hash, created_at, updated_at)
+	VALUES (?, ?, ?, ?, ?)
+	`
+
+	now := time.Now()
+	user.CreatedAt = now
+	user.UpdatedAt = now
+
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Email, user.PasswordHash, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return domain.ErrDuplicateUser
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	return r.getBy(ctx, "id", id)
+}
+
+// GetByEmail matches the address case-insensitively
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return r.getBy(ctx, "email", email)
+}
+
+func (r *UserRepository) getBy(ctx context.Context, column, value string) (*domain.User, error) {
+	query := fmt.Sprintf(`
+	SELECT id, email, password_hash, created_at, updated_at
+	FROM users
+	WHERE %s = ?
+	`, column)
+
+	var user domain.User
+	err := r.db.QueryRowContext(ctx, query, value).Scan(
+		&user.ID,
+		&user.Email,
+		&user.PasswordHash,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
@@ -0,0 +1,64 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNewUserRepository(t *testing.T) {
+	t.Run("nil database", func(t *testing.T) {
+		_, err := NewUserRepository(nil)
+		if err == nil {
+			t.Fatal("expected error for nil database")
+		}
+	})
+}
+
+func TestUserRepository(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	repo, err := NewUserRepository(db)
+	if err != nil {
+		t.Fatalf("failed to create repository: %v", err)
+	}
+
+	ctx := context.Background()
+	user := &domain.User{ID: "user_1", Email: "Kim@Example.com", PasswordHash: "hash"}
+
+	t.Run("create and get", func(t *testing.T) {
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		found, err := repo.GetByID(ctx, "user_1")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if found.Email != "Kim@Example.com" || found.PasswordHash != "hash" || found.CreatedAt.IsZero() {
+			t.Errorf("unexpected user: %+v", found)
+		}
+	})
+
+	t.Run("email lookup ignores case", func(t *testing.T) {
+		found, err := repo.GetByEmail(ctx, "kim@example.com")
+		if err != nil || found.ID != "user_1" {
+			t.Errorf("expected user_1, got %+v (%v)", found, err)
+		}
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		err := repo.Create(ctx, &domain.User{ID: "user_2", Email: "KIM@example.com", PasswordHash: "hash"})
+		if err != domain.ErrDuplicateUser {
+			t.Errorf("expected ErrDuplicateUser, got %v", err)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := repo.GetByID(ctx, "missing"); err != domain.ErrUserNotFound {
+			t.Errorf("expected ErrUserNotFound, got %v", err)
+		}
+	})
+}
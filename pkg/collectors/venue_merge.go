@@ -0,0 +1,245 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const (
+	// defaultVenueSimilarity and defaultVenueDistanceKm are how alike and
+	// how close venues must be to count as duplicates unless the filter
+	// says otherwise
+	defaultVenueSimilarity = 0.6
+	defaultVenueDistanceKm = 1.0
+)
+
+// mergedVenueSlug is the slug a venue is stored under: the one it was
+// merged into, or its own. It takes the venue's slug twice.
+const mergedVenueSlug = `COALESCE((SELECT into_slug FROM venue_merges WHERE from_slug = ?), ?)`
+
+// mergeVenueDetailsQuery fills the kept venue's missing details from the
+// duplicate's, without replacing any it has
+const mergeVenueDetailsQuery = `
+	UPDATE venues SET
+		venue_id = COALESCE(NULLIF(venues.venue_id, ''), d.venue_id),
+		region = COALESCE(NULLIF(venues.region, ''), d.region),
+		country = COALESCE(NULLIF(venues.country, ''), d.country),
+		country_code = COALESCE(NULLIF(venues.country_code, ''), d.country_code),
+		latitude = CASE WHEN venues.latitude != 0 OR venues.longitude != 0 THEN venues.latitude ELSE d.latitude END,
+		longitude = CASE WHEN venues.latitude != 0 OR venues.longitude != 0 THEN venues.longitude ELSE d.longitude END,
+		capacity = CASE WHEN venues.capacity > 0 THEN venues.capacity ELSE d.capacity END,
+		address = COALESCE(NULLIF(venues.address, ''), d.address),
+		phone = COALESCE(NULLIF(venues.phone, ''), d.phone),
+		website = COALESCE(NULLIF(venues.website, ''), d.website),
+		updated_at = ?
+	FROM venues d
+	WHERE venues.slug = ? AND d.slug = ?
+	`
+
+// FindDuplicateVenues compares the stored venues of each city in pairs and
+// returns those alike and close enough to be the same place, the most alike
+// first, then the closest
+func (r *EventRepository) FindDuplicateVenues(ctx context.Context, filter domain.VenueDuplicateFilter, limit int) ([]domain.VenueDuplicate, error) {
+	if filter.MinSimilarity <= 0 {
+		filter.MinSimilarity = defaultVenueSimilarity
+	}
+	if filter.MaxDistanceKm <= 0 {
+		filter.MaxDistanceKm = defaultVenueDistanceKm
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `
+	SELECT v.slug, v.venue_id, v.name, v.city, v.region, v.country, v.country_code,
+		v.latitude, v.longitude, v.capacity, v.address, v.phone, v.website, v.updated_at,
+		(SELECT COUNT(*) FROM events e WHERE e.venue_slug = v.slug AND e.datetime >= ?)
+	FROM venues v
+	WHERE 1 = 1
+	`
+	args := []interface{}{time.Now().UTC()}
+	if city := strings.TrimSpace(filter.City); city != "" {
+		condition, cityArgs := cityMatch("v.city", city)
+		query += " AND " + condition
+		args = append(args, cityArgs...)
+	}
+	query += " ORDER BY v.slug"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list venues: %w", err)
+	}
+	defer rows.Close()
+
+	venues, err := scanVenues(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	byCity := make(map[string][]domain.StoredVenue)
+	for _, venue := range venues {
+		city := domain.Slugify(domain.NormalizeCity(venue.City))
+		byCity[city] = append(byCity[city], venue)
+	}
+
+	var duplicates []domain.VenueDuplicate
+	for _, cityVenues := range byCity {
+		for i := range cityVenues {
+			for j := i + 1; j < len(cityVenues); j++ {
+				if duplicate, ok := venueDuplicate(cityVenues[i], cityVenues[j], filter); ok {
+					duplicates = append(duplicates, duplicate)
+				}
+			}
+		}
+	}
+
+	sort.Slice(duplicates, func(i, j int) bool {
+		a, b := duplicates[i], duplicates[j]
+		if a.Similarity != b.Similarity {
+			return a.Similarity > b.Similarity
+		}
+		if (a.DistanceKm == nil) != (b.DistanceKm == nil) {
+			return a.DistanceKm != nil
+		}
+		if a.DistanceKm != nil && *a.DistanceKm != *b.DistanceKm {
+			return *a.DistanceKm < *b.DistanceKm
+		}
+		return a.Venue.Slug+a.Duplicate.Slug < b.Venue.Slug+b.Duplicate.Slug
+	})
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+	return duplicates, nil
+}
+
+// venueDuplicate pairs two venues of a city when the filter counts them as
+// the same place. The one with more upcoming events is kept, or else the
+// one with the shorter name, which tends to be the plainer one.
+func venueDuplicate(a, b domain.StoredVenue, filter domain.VenueDuplicateFilter) (domain.VenueDuplicate, bool) {
+	similarity := domain.VenueNameSimilarity(a.Name, b.Name)
+	if similarity < filter.MinSimilarity {
+		return domain.VenueDuplicate{}, false
+	}
+
+	var distance *float64
+	if a.HasCoordinates() && b.HasCoordinates() {
+		km := a.DistanceKm(b.Latitude, b.Longitude)
+		if km > filter.MaxDistanceKm {
+			return domain.VenueDuplicate{}, false
+		}
+		distance = &km
+	} else if similarity < 1 {
+		return domain.VenueDuplicate{}, false
+	}
+
+	if b.UpcomingEvents > a.UpcomingEvents || b.UpcomingEvents == a.UpcomingEvents && len(b.Name) < len(a.Name) {
+		a, b = b, a
+	}
+	return domain.VenueDuplicate{Venue: a, Duplicate: b, Similarity: similarity, DistanceKm: distance}, true
+}
+
+// MergeVenues moves the events of the venue from to into in one
+// transaction, fills into's missing details from it, records the merge and
+// drops from. Venues merged into from before now point at into.
+func (r *EventRepository) MergeVenues(ctx context.Context, from, into string) (*domain.VenueMerge, error) {
+	if from == "" || into == "" || from == into {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	merge := domain.VenueMerge{From: from, Into: into, MergedAt: time.Now()}
+	if err := tx.QueryRowContext(ctx, `SELECT name FROM venues WHERE slug = ?`, from).Scan(&merge.FromName); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrVenueNotFound
+		}
+		return nil, fmt.Errorf("failed to get venue: %w", err)
+	}
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT 1 FROM venues WHERE slug = ?`, into).Scan(&exists); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domain.ErrVenueNotFound
+		}
+		return nil, fmt.Errorf("failed to get venue: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `UPDATE events SET venue_slug = ? WHERE venue_slug = ?`, into, from)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move venue events: %w", err)
+	}
+	moved, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	merge.Events = int(moved)
+
+	if _, err := tx.ExecContext(ctx, mergeVenueDetailsQuery, merge.MergedAt, into, from); err != nil {
+		return nil, fmt.Errorf("failed to merge venue details: %w", err)
+	}
+
+	// into is a venue of its own again if it was ever merged away, and
+	// whatever went into from goes into into now
+	if _, err := tx.ExecContext(ctx, `DELETE FROM venue_merges WHERE from_slug = ?`, into); err != nil {
+		return nil, fmt.Errorf("failed to repoint venue merges: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE venue_merges SET into_slug = ? WHERE into_slug = ?`, into, from); err != nil {
+		return nil, fmt.Errorf("failed to repoint venue merges: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO venue_merges (from_slug, from_name, into_slug, events, merged_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, merge.From, merge.FromName, merge.Into, merge.Events, merge.MergedAt); err != nil {
+		return nil, fmt.Errorf("failed to record venue merge: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM venues WHERE slug = ?`, from); err != nil {
+		return nil, fmt.Errorf("failed to delete merged venue: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &merge, nil
+}
+
+// ListVenueMerges returns the recorded venue merges, newest first
+func (r *EventRepository) ListVenueMerges(ctx context.Context, limit int) ([]domain.VenueMerge, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT from_slug, from_name, into_slug, events, merged_at
+		FROM venue_merges
+		ORDER BY merged_at DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list venue merges: %w", err)
+	}
+	defer rows.Close()
+
+	var merges []domain.VenueMerge
+	for rows.Next() {
+		var merge domain.VenueMerge
+		if err := rows.Scan(&merge.From, &merge.FromName, &merge.Into, &merge.Events, &merge.MergedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan venue merge: %w", err)
+		}
+		merges = append(merges, merge)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list venue merges: %w", err)
+	}
+	return merges, nil
+}
@@ -0,0 +1,245 @@
+package collectors
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// Venues are kept by slug as events are written, so the details one source
+// gives aren't lost when another lists the same venue without them. A blank
+// detail never replaces a known one, and the name first seen stays. A
+// venue merged into another is written to that one instead.
+const (
+	createVenuesTableQuery = `
+	CREATE TABLE IF NOT EXISTS venues (
+		slug TEXT PRIMARY KEY,
+		venue_id TEXT,
+		name TEXT NOT NULL,
+		city TEXT,
+		region TEXT,
+		country TEXT,
+		country_code TEXT,
+		latitude REAL,
+		longitude REAL,
+		capacity INTEGER NOT NULL DEFAULT 0,
+		address TEXT,
+		phone TEXT,
+		website TEXT,
+		updated_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_venues_city ON venues(city COLLATE NOCASE);
+
+	CREATE TABLE IF NOT EXISTS venue_merges (
+		from_slug TEXT PRIMARY KEY,
+		from_name TEXT NOT NULL,
+		into_slug TEXT NOT NULL,
+		events INTEGER NOT NULL DEFAULT 0,
+		merged_at TIMESTAMP NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_venue_merges_into_slug ON venue_merges(into_slug);
+	CREATE INDEX IF NOT EXISTS idx_venue_merges_merged_at ON venue_merges(merged_at);
+	`
+	saveVenueQuery = `
+	INSERT INTO venues (
+		slug, venue_id, name, city, region, country, country_code,
+		latitude, longitude, capacity, address, phone, website, updated_at
+	) VALUES (` + mergedVenueSlug + `, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT(slug) DO UPDATE SET
+		venue_id = COALESCE(NULLIF(excluded.venue_id, ''), venues.venue_id),
+		city = COALESCE(NULLIF(excluded.city, ''), venues.city),
+		region = COALESCE(NULLIF(excluded.region, ''), venues.region),
+		country = COALESCE(NULLIF(excluded.country, ''), venues.country),
+		country_code = COALESCE(NULLIF(excluded.country_code, ''), venues.country_code),
+		latitude = CASE WHEN excluded.latitude != 0 OR excluded.longitude != 0 THEN excluded.latitude ELSE venues.latitude END,
+		longitude = CASE WHEN excluded.latitude != 0 OR excluded.longitude != 0 THEN excluded.longitude ELSE venues.longitude END,
+		capacity = CASE WHEN excluded.capacity > 0 THEN excluded.capacity ELSE venues.capacity END,
+		address = COALESCE(NULLIF(excluded.address, ''), venues.address),
+		phone = COALESCE(NULLIF(excluded.phone, ''), venues.phone),
+		website = COALESCE(NULLIF(excluded.website, ''), venues.website),
+		updated_at = excluded.updated_at
+	`
+)
+
+// venueArgs are saveVenueQuery's arguments for the venue of an event
+func venueArgs(slug string, venue domain.Venue, now time.Time) []interface{} {
+	return []interface{}{
+		slug, slug, venue.ID, venue.Name, venue.City, venue.Region, venue.Country, venue.CountryCode,
+		venue.Latitude, venue.Longitude, venue.Capacity, venue.Address, venue.Phone, venue.Website, now,
+	}
+}
+
+// GetVenue returns the stored venue with the slug, counting its events from
+// now on
+func (r *EventRepository) GetVenue(ctx context.Context, slug string) (*domain.StoredVenue, error) {
+	query := `
+	SELECT v.slug, v.venue_id, v.name, v.city, v.region, v.country, v.country_code,
+		v.latitude, v.longitude, v.capacity, v.address, v.phone, v.website, v.updated_at,
+		(SELECT COUNT(*) FROM events e WHERE e.venue_slug = v.slug AND e.datetime >= ?)
+	FROM venues v
+	WHERE v.slug = ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now().UTC(), slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get venue: %w", err)
+	}
+	defer rows.Close()
+
+	venues, err := scanVenues(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(venues) == 0 {
+		return nil, domain.ErrVenueNotFound
+	}
+	return &venues[0], nil
+}
+
+// ListVenues returns stored venues in the filter's city whose names contain
+// its query, the most upcoming events first
+func (r *EventRepository) ListVenues(ctx context.Context, filter domain.VenueFilter, limit int) ([]domain.StoredVenue, error) {
+	from := filter.From
+	if from.IsZero() {
+		from = time.Now()
+	}
+
+	query := `
+	SELECT v.slug, v.venue_id, v.name, v.city, v.region, v.country, v.country_code,
+		v.latitude, v.longitude, v.capacity, v.address, v.phone, v.website, v.updated_at,
+		(SELECT COUNT(*) FROM events e WHERE e.venue_slug = v.slug AND e.datetime >= ?) AS upcoming
+	FROM venues v
+	WHERE 1 = 1
+	`
+	args := []interface{}{from.UTC()}
+
+	if city := strings.TrimSpace(filter.City); city != "" {
+		condition, cityArgs := cityMatch("v.city", city)
+		query += " AND " + condition
+		args = append(args, cityArgs...)
+	}
+	if q := domain.Slugify(filter.Query); q != "" {
+		query += " AND v.slug LIKE ?"
+		args = append(args, "%"+q+"%")
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	query += " ORDER BY upcoming DESC, v.name ASC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list venues: %w", err)
+	}
+	defer rows.Close()
+
+	return scanVenues(rows)
+}
+
+// ListVenueEvents returns the venue's stored events from from on, soonest
+// first
+func (r *EventRepository) ListVenueEvents(ctx context.Context, slug string, from time.Time, limit int) ([]domain.Event, error) {
+	query := `
+	SELECT id, artist_id, artist_name, title, datetime, timezone, date_confidence,
+		venue_id, venue_name, venue_city, venue_region, venue_country,
+		venue_latitude, venue_longitude, venue_capacity, ticket_url, ticket_status, status,
+		on_sale_date, bandsintown_id, ticketmaster_id,
+		songkick_id, eventbrite_id, setlistfm_id,
+		age_restriction, accessibility,
+		created_at, updated_at, cached_until
+	FROM events
+	WHERE venue_slug = ? AND datetime >= ?
+	ORDER BY datetime ASC
+	LIMIT ?
+	`
+
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.db.QueryContext(ctx, query, slug, from.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list venue events: %w", err)
+	}
+	defer rows.Close()
+
+	return r.scanEvents(ctx, rows)
+}
+
+func scanVenues(rows *sql.Rows) ([]domain.StoredVenue, error) {
+	var venues []domain.StoredVenue
+	for rows.Next() {
+		var venue domain.StoredVenue
+		var venueID, city, region, country, countryCode, address, phone, website sql.NullString
+		var latitude, longitude sql.NullFloat64
+
+		err := rows.Scan(
+			&venue.Slug, &venueID, &venue.Name, &city, &region, &country, &countryCode,
+			&latitude, &longitude, &venue.Capacity, &address, &phone, &website, &venue.UpdatedAt,
+			&venue.UpcomingEvents,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan venue: %w", err)
+		}
+		venue.ID = venueID.String
+		venue.City = city.String
+		venue.Region = region.String
+		venue.Country = country.String
+		venue.CountryCode = countryCode.String
+		venue.Latitude = latitude.Float64
+		venue.Longitude = longitude.Float64
+		venue.Address = address.String
+		venue.Phone = phone.String
+		venue.Website = website.String
+		venue.NormalizeCountry()
+
+		venues = append(venues, venue)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list venues: %w", err)
+	}
+	return venues, nil
+}
+
+// loadVenueDetails fills in the details the venues table has and the
+// events' own rows don't keep, given the events' positions by ID and the
+// placeholders and arguments that select their IDs
+func (r *EventRepository) loadVenueDetails(ctx context.Context, events []domain.Event, byID map[string][]int, placeholders string, args []interface{}) error {
+	query := `
+	SELECT e.id, v.capacity, v.address, v.phone, v.website
+	FROM events e
+	JOIN venues v ON v.slug = e.venue_slug
+	WHERE e.id IN (` + placeholders + `)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to get venue details: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var eventID string
+		var details domain.Venue
+		var address, phone, website sql.NullString
+		if err := rows.Scan(&eventID, &details.Capacity, &address, &phone, &website); err != nil {
+			return fmt.Errorf("failed to scan venue details: %w", err)
+		}
+		details.Address = address.String
+		details.Phone = phone.String
+		details.Website = website.String
+
+		for _, i := range byID[eventID] {
+			events[i].Venue.MergeDetails(details)
+		}
+	}
+
+	return rows.Err()
+}
@@ -4,16 +4,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 )
 
+// Environments the app runs in. Development serves fixture data alongside
+// whichever sources are configured, so no API keys are needed.
+const (
+	EnvironmentProduction  = "production"
+	EnvironmentDevelopment = "development"
+)
+
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig   `json:"server"`
-	Database DatabaseConfig `json:"database"`
-	APIs     APIConfig      `json:"apis"`
-	Scrapers ScraperConfig  `json:"scrapers"`
-	Cache    CacheConfig    `json:"cache"`
+	Environment   string              `json:"environment"`
+	Server        ServerConfig        `json:"server"`
+	Database      DatabaseConfig      `json:"database"`
+	APIs          APIConfig           `json:"apis"`
+	Scrapers      ScraperConfig       `json:"scrapers"`
+	Cache         CacheConfig         `json:"cache"`
+	Logging       LoggingConfig       `json:"logging"`
+	Tracing       TracingConfig       `json:"tracing"`
+	Auth          AuthConfig          `json:"auth"`
+	Notifications NotificationsConfig `json:"notifications"`
+	Ranking       RankingConfig       `json:"ranking"`
+	Sources       SourcesConfig       `json:"sources"`
+	Locations     LocationsConfig     `json:"locations"`
+	Jobs          JobsConfig          `json:"jobs"`
 }
 
 // ServerConfig for HTTP server settings
@@ -21,6 +38,19 @@ type ServerConfig struct {
 	Port         string `json:"port"`
 	ReadTimeout  int    `json:"read_timeout_seconds"`
 	WriteTimeout int    `json:"write_timeout_seconds"`
+
+	CORS CORSConfig `json:"cors"`
+}
+
+// CORSConfig lets browser apps on other origins call the API. It is off
+// while AllowedOrigins is empty; "*" allows any origin.
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"`
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	ExposedHeaders   []string `json:"exposed_headers"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAgeSeconds    int      `json:"max_age_seconds"`
 }
 
 // DatabaseConfig for PostgreSQL connection
@@ -31,6 +61,30 @@ type DatabaseConfig struct {
 	Password string `json:"password"`
 	Database string `json:"database"`
 	SSLMode  string `json:"ssl_mode"`
+	// SQLite tunes the SQLite event store the server actually uses
+	SQLite SQLiteConfig `json:"sqlite"`
+}
+
+// SQLiteConfig bounds the event store's connections and schedules its
+// upkeep; zero values keep the defaults. Writes wait BusyTimeoutMS (5000)
+// on each other, over at most MaxOpenConns (8) connections. The server
+// backs the store up into BackupDir every BackupIntervalHours (24), keeping
+// BackupsKept (7), and vacuums it every VacuumIntervalHours (168); without
+// a BackupDir there are no backups.
+type SQLiteConfig struct {
+	BusyTimeoutMS       int    `json:"busy_timeout_ms"`
+	MaxOpenConns        int    `json:"max_open_conns"`
+	BackupDir           string `json:"backup_dir"`
+	BackupIntervalHours int    `json:"backup_interval_hours"`
+	BackupsKept         int    `json:"backups_kept"`
+	VacuumIntervalHours int    `json:"vacuum_interval_hours"`
+
+	// ArchiveRetentionDays is how long events stay in the archive once
+	// they leave the hot table (365); they're dropped before a vacuum
+	ArchiveRetentionDays int `json:"archive_retention_days"`
+	// FeedRetentionDays is how long change feed entries are kept (30);
+	// mirrors further behind than that have to start over
+	FeedRetentionDays int `json:"feed_retention_days"`
 }
 
 // APIConfig holds all external API configurations
@@ -41,10 +95,28 @@ type APIConfig struct {
 	MusicBrainz  MusicBrainzConfig  `json:"musicbrainz"`
 	Deezer       DeezerConfig       `json:"deezer"`
 	SoundCloud   SoundCloudConfig   `json:"soundcloud"`
+	LastFM       LastFMConfig       `json:"lastfm"`
 	Songkick     SongkickConfig     `json:"songkick"`
+	Bandsintown  BandsintownConfig  `json:"bandsintown"`
 	Ticketmaster TicketmasterConfig `json:"ticketmaster"`
 	Eventbrite   EventbriteConfig   `json:"eventbrite"`
 	SetlistFM    SetlistFMConfig    `json:"setlistfm"`
+	Facebook     FacebookConfig     `json:"facebook"`
+	FanartTV     FanartTVConfig     `json:"fanarttv"`
+	Retry        RetryConfig        `json:"retry"`
+
+	// ConditionalCacheMB bounds the upstream responses kept to revalidate
+	// with If-None-Match and If-Modified-Since (32); negative turns
+	// conditional requests off
+	ConditionalCacheMB int `json:"conditional_cache_mb"`
+}
+
+// RetryConfig for retrying upstream API calls that fail with a 429, a 5xx
+// or a network error. A negative MaxRetries turns retries off.
+type RetryConfig struct {
+	MaxRetries  int `json:"max_retries"`
+	BaseDelayMS int `json:"base_delay_ms"`
+	MaxDelayMS  int `json:"max_delay_ms"`
 }
 
 // SpotifyConfig for Spotify API
@@ -54,7 +126,8 @@ type SpotifyConfig struct {
 	RedirectURI  string `json:"redirect_uri"`
 }
 
-// AppleMusicConfig for Apple Music API
+// AppleMusicConfig for Apple Music API. CountryCode is the storefront
+// catalog lookups use unless a request names another, "us" if unset.
 type AppleMusicConfig struct {
 	TeamID      string `json:"team_id"`
 	KeyID       string `json:"key_id"`
@@ -65,6 +138,14 @@ type AppleMusicConfig struct {
 // YouTubeConfig for YouTube Music API
 type YouTubeConfig struct {
 	APIKey string `json:"api_key"`
+	// DailyQuota is the project's daily quota in units, 10000 by default
+	DailyQuota int `json:"daily_quota"`
+}
+
+// FanartTVConfig for fanart.tv, the last place artist pictures are looked
+// for
+type FanartTVConfig struct {
+	APIKey string `json:"api_key"`
 }
 
 // MusicBrainzConfig for MusicBrainz API
@@ -76,11 +157,15 @@ type MusicBrainzConfig struct {
 type DeezerConfig struct {
 	AppID     string `json:"app_id"`
 	AppSecret string `json:"app_secret"`
+	// ChartPlaylists adds chart playlists by country code, for countries
+	// Deezer has no built-in chart for
+	ChartPlaylists map[string]string `json:"chart_playlists"`
 }
 
 // SoundCloudConfig for SoundCloud API
 type SoundCloudConfig struct {
-	ClientID string `json:"client_id"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
 }
 
 // SongkickConfig for Songkick API
@@ -88,9 +173,22 @@ type SongkickConfig struct {
 	APIKey string `json:"api_key"`
 }
 
+// BandsintownConfig for Bandsintown API
+type BandsintownConfig struct {
+	AppID string `json:"app_id"`
+	// LocationArtists are the artists whose events city searches include,
+	// within RadiusMiles of the city (at most 150). Bandsintown only lists
+	// events by artist, so it's left out of city searches without them.
+	LocationArtists []string `json:"location_artists"`
+	RadiusMiles     int      `json:"radius_miles"`
+}
+
 // TicketmasterConfig for Ticketmaster Discovery API
 type TicketmasterConfig struct {
 	APIKey string `json:"api_key"`
+	// MaxPages bounds the result pages of up to 200 events one search
+	// follows (5); paging also stops with a tenth of the daily quota left
+	MaxPages int `json:"max_pages"`
 }
 
 // EventbriteConfig for Eventbrite API
@@ -98,21 +196,219 @@ type EventbriteConfig struct {
 	Token string `json:"token"`
 }
 
+// LastFMConfig for Last.fm API
+type LastFMConfig struct {
+	APIKey string `json:"api_key"`
+}
+
 // SetlistFMConfig for Setlist.fm API
 type SetlistFMConfig struct {
 	APIKey string `json:"api_key"`
 }
 
-// ScraperConfig for web scraper settings
+// FacebookConfig for the Graph API, which venue pages on Facebook are read
+// through when a token is set
+type FacebookConfig struct {
+	AccessToken string `json:"access_token"`
+}
+
+// ScraperConfig for web scraper settings. Fetched pages are cached in
+// CacheDir when it is set. SelectorsFile overrides the built-in selector sets
+// scrapers find events with (see selectors.example.json). VenuePages are the
+// Facebook, Instagram or website pages of venues to pull gigs from.
+// UserAgents and AcceptLanguages, when set, are rotated request by request;
+// UserAgent still names us to robots.txt. Requests to a site are
+// RateLimitSeconds apart, give or take JitterPercent (25; negative for
+// none), and Domains paces sites by domain differently.
 type ScraperConfig struct {
-	UserAgent        string `json:"user_agent"`
-	RateLimitSeconds int    `json:"rate_limit_seconds"`
-	Timeout          int    `json:"timeout_seconds"`
+	UserAgent        string            `json:"user_agent"`
+	RateLimitSeconds int               `json:"rate_limit_seconds"`
+	Timeout          int               `json:"timeout_seconds"`
+	CacheDir         string            `json:"cache_dir"`
+	CacheTTLMinutes  int               `json:"cache_ttl_minutes"`
+	SelectorsFile    string            `json:"selectors_file"`
+	Browser          BrowserConfig     `json:"browser"`
+	VenuePages       []VenuePageConfig `json:"venue_pages"`
+
+	UserAgents      []string                       `json:"user_agents"`
+	AcceptLanguages []string                       `json:"accept_languages"`
+	JitterPercent   int                            `json:"jitter_percent"`
+	Domains         map[string]ScraperDomainConfig `json:"domains"`
+}
+
+// ScraperDomainConfig is the politeness for one site, say "ra.co", which
+// covers its subdomains too. Zeros keep the scrapers' own settings.
+type ScraperDomainConfig struct {
+	RateLimitSeconds int `json:"rate_limit_seconds"`
+	JitterPercent    int `json:"jitter_percent"`
+}
+
+// VenuePageConfig is one venue page. City and Country say where the venue
+// is, for listings that leave it out.
+type VenuePageConfig struct {
+	URL     string `json:"url"`
+	Name    string `json:"name"`
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+// BrowserConfig for rendering scraped pages in headless Chrome. Only the
+// scrapers named in Scrapers use it; the rest fetch pages over HTTP.
+type BrowserConfig struct {
+	Scrapers           []string `json:"scrapers"`
+	ExecPath           string   `json:"exec_path"`
+	MaxTabs            int      `json:"max_tabs"`
+	PageTimeoutSeconds int      `json:"page_timeout_seconds"`
+}
+
+// UsesBrowser reports whether the named scraper renders its pages in Chrome
+func (c *BrowserConfig) UsesBrowser(scraper string) bool {
+	return slices.Contains(c.Scrapers, scraper)
 }
 
 // CacheConfig for caching settings
 type CacheConfig struct {
-	EventCacheDuration int `json:"event_cache_duration_hours"`
+	EventCacheDuration   int `json:"event_cache_duration_hours"`
+	ProfileCacheDuration int `json:"profile_cache_duration_hours"`
+	// SearchMaxEntries and SearchMaxMB bound the in-memory search cache,
+	// least recently used searches going first; zero keeps the defaults of
+	// 10000 searches and 64 MB
+	SearchMaxEntries int `json:"search_cache_max_entries"`
+	SearchMaxMB      int `json:"search_cache_max_mb"`
+	// NoResultsTTLSeconds is how long searches that found nothing are
+	// cached, 120 seconds when zero
+	NoResultsTTLSeconds int `json:"no_results_ttl_seconds"`
+	// Backend is where cached searches and rate limit counts live: "memory",
+	// the default, or "redis" to share them between instances
+	Backend string      `json:"backend"`
+	Redis   RedisConfig `json:"redis"`
+}
+
+// RedisConfig for the redis cache backend
+type RedisConfig struct {
+	Addr     string `json:"addr"`
+	Password string `json:"password"`
+	DB       int    `json:"db"`
+	// Namespace prefixes every key, defaulting to "whereitsat"
+	Namespace string `json:"namespace"`
+}
+
+// LoggingConfig for the application logger
+type LoggingConfig struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// TracingConfig for exporting OpenTelemetry traces over OTLP/HTTP
+type TracingConfig struct {
+	Enabled     bool    `json:"enabled"`
+	Endpoint    string  `json:"endpoint"`
+	Insecure    bool    `json:"insecure"`
+	ServiceName string  `json:"service_name"`
+	SampleRatio float64 `json:"sample_ratio"`
+}
+
+// AuthConfig for user sessions. Without a JWT secret a random one is
+// generated at startup, so sessions don't survive restarts. The admin API
+// is only served when an admin token is set; callers send it as a bearer
+// token.
+type AuthConfig struct {
+	JWTSecret             string `json:"jwt_secret"`
+	AccessTokenTTLMinutes int    `json:"access_token_ttl_minutes"`
+	RefreshTokenTTLHours  int    `json:"refresh_token_ttl_hours"`
+	AdminToken            string `json:"admin_token"`
+}
+
+// NotificationsConfig for email digests, which are only sent when an SMTP
+// host is set. BaseURL is where unsubscribe links in emails point.
+type NotificationsConfig struct {
+	BaseURL            string         `json:"base_url"`
+	DigestCheckMinutes int            `json:"digest_check_minutes"`
+	LiveCheckSeconds   int            `json:"live_check_seconds"`
+	SMTP               SMTPConfig     `json:"smtp"`
+	Telegram           TelegramConfig `json:"telegram"`
+	OnSale             OnSaleConfig   `json:"onsale"`
+	Releases           ReleasesConfig `json:"releases"`
+}
+
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	From     string `json:"from"`
+}
+
+// TelegramConfig for the alert bot, which only runs with a bot token
+type TelegramConfig struct {
+	BotToken          string `json:"bot_token"`
+	AlertCheckMinutes int    `json:"alert_check_minutes"`
+}
+
+// OnSaleConfig for on-sale alerts, which go out LeadMinutes before tickets
+// go on sale over whichever of email, Telegram and webhooks are set up
+type OnSaleConfig struct {
+	CheckMinutes int `json:"check_minutes"`
+	LeadMinutes  int `json:"lead_minutes"`
+}
+
+// ReleasesConfig for new album alerts, which tell followers about albums
+// out in the last WindowDays
+type ReleasesConfig struct {
+	CheckHours int `json:"check_hours"`
+	WindowDays int `json:"window_days"`
+}
+
+// RankingConfig for ordering aggregated search results. SourceWeights set
+// how much each source is trusted, on top of the built-in weights; a source
+// missing from both counts fully.
+type RankingConfig struct {
+	SourceWeights map[string]float64 `json:"source_weights"`
+}
+
+// SourcesConfig bounds the calls searches make to sources. TimeoutMS and
+// MaxResults are the defaults for every source, 30 seconds and 20 results
+// when zero; Limits gives sources by name their own, say longer for slow
+// scrapers and shorter for Deezer. Searches with mode=fast answer once
+// FastQuorum sources have (2 by default) or after FastDeadlineMS (2000).
+// Proxies sends sources and scrapers by name, or all of them under
+// "default", through outbound proxies.
+type SourcesConfig struct {
+	TimeoutMS      int                           `json:"timeout_ms"`
+	MaxResults     int                           `json:"max_results"`
+	Limits         map[string]SourceLimitsConfig `json:"limits"`
+	FastQuorum     int                           `json:"fast_quorum"`
+	FastDeadlineMS int                           `json:"fast_deadline_ms"`
+	Proxies        map[string]ProxyConfig        `json:"proxies"`
+}
+
+// ProxyConfig for one source's egress: http, https, socks5 or socks5h
+// proxy URLs, rotated request by request when there are several. An entry
+// without URLs sends that source out directly despite a default.
+type ProxyConfig struct {
+	URLs []string `json:"urls"`
+}
+
+// SourceLimitsConfig for one source. Zeros keep the defaults, and a source
+// without MaxConcurrent is only limited by how many sources a search calls
+// at once.
+type SourceLimitsConfig struct {
+	TimeoutMS     int `json:"timeout_ms"`
+	MaxResults    int `json:"max_results"`
+	MaxConcurrent int `json:"max_concurrent"`
+}
+
+// LocationsConfig for matching city names. CitiesFile adds cities, aliases
+// and source location codes to the built-in table (see cities.example.json).
+type LocationsConfig struct {
+	CitiesFile string `json:"cities_file"`
+}
+
+// JobsConfig sizes the queue that syncs and enriches artists outside of
+// requests. A job that fails MaxAttempts times is left dead.
+type JobsConfig struct {
+	Workers     int `json:"workers"`
+	MaxAttempts int `json:"max_attempts"`
 }
 
 // Load reads configuration from file and environment variables
@@ -142,7 +438,15 @@ func Load(configPath string) (*Config, error) {
 	return config, nil
 }
 
+// IsDevelopment reports whether fixtures stand in for the sources
+func (c *Config) IsDevelopment() bool {
+	return c.Environment == EnvironmentDevelopment
+}
+
 func applyDefaults(config *Config) {
+	if config.Environment == "" {
+		config.Environment = EnvironmentProduction
+	}
 	if config.Server.Port == "" {
 		config.Server.Port = "8080"
 	}
@@ -152,6 +456,18 @@ func applyDefaults(config *Config) {
 	if config.Server.WriteTimeout == 0 {
 		config.Server.WriteTimeout = 30
 	}
+	if len(config.Server.CORS.AllowedMethods) == 0 {
+		config.Server.CORS.AllowedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	}
+	if len(config.Server.CORS.AllowedHeaders) == 0 {
+		config.Server.CORS.AllowedHeaders = []string{"Authorization", "Content-Type", "If-None-Match", "If-Modified-Since", "X-Request-ID"}
+	}
+	if len(config.Server.CORS.ExposedHeaders) == 0 {
+		config.Server.CORS.ExposedHeaders = []string{"ETag", "X-Request-ID"}
+	}
+	if config.Server.CORS.MaxAgeSeconds == 0 {
+		config.Server.CORS.MaxAgeSeconds = 600
+	}
 	if config.Database.Port == 0 {
 		config.Database.Port = 5432
 	}
@@ -161,6 +477,15 @@ func applyDefaults(config *Config) {
 	if config.APIs.MusicBrainz.UserAgent == "" {
 		config.APIs.MusicBrainz.UserAgent = "WhereItsAt/1.0"
 	}
+	if config.APIs.Retry.MaxRetries == 0 {
+		config.APIs.Retry.MaxRetries = 3
+	}
+	if config.APIs.Retry.BaseDelayMS == 0 {
+		config.APIs.Retry.BaseDelayMS = 500
+	}
+	if config.APIs.Retry.MaxDelayMS == 0 {
+		config.APIs.Retry.MaxDelayMS = 10000
+	}
 	if config.Scrapers.UserAgent == "" {
 		config.Scrapers.UserAgent = "Mozilla/5.0 (compatible; WhereItsAt/1.0)"
 	}
@@ -170,16 +495,158 @@ func applyDefaults(config *Config) {
 	if config.Scrapers.Timeout == 0 {
 		config.Scrapers.Timeout = 30
 	}
+	if config.Scrapers.CacheTTLMinutes == 0 {
+		config.Scrapers.CacheTTLMinutes = 60
+	}
+	if config.Scrapers.Browser.MaxTabs == 0 {
+		config.Scrapers.Browser.MaxTabs = 2
+	}
+	if config.Scrapers.Browser.PageTimeoutSeconds == 0 {
+		config.Scrapers.Browser.PageTimeoutSeconds = 30
+	}
 	if config.Cache.EventCacheDuration == 0 {
 		config.Cache.EventCacheDuration = 24
 	}
+	if config.Cache.ProfileCacheDuration == 0 {
+		config.Cache.ProfileCacheDuration = 7 * 24
+	}
+	if config.Cache.Backend == "" {
+		config.Cache.Backend = "memory"
+	}
+	if config.Cache.Redis.Addr == "" {
+		config.Cache.Redis.Addr = "localhost:6379"
+	}
+	if config.Cache.Redis.Namespace == "" {
+		config.Cache.Redis.Namespace = "whereitsat"
+	}
+	if config.Jobs.Workers == 0 {
+		config.Jobs.Workers = 2
+	}
+	if config.Jobs.MaxAttempts == 0 {
+		config.Jobs.MaxAttempts = 5
+	}
+	if config.Logging.Level == "" {
+		config.Logging.Level = "info"
+	}
+	if config.Logging.Format == "" {
+		config.Logging.Format = "text"
+	}
+	if config.Tracing.Endpoint == "" {
+		config.Tracing.Endpoint = "localhost:4318"
+	}
+	if config.Tracing.ServiceName == "" {
+		config.Tracing.ServiceName = "where-its-at"
+	}
+	if config.Tracing.SampleRatio == 0 {
+		config.Tracing.SampleRatio = 1
+	}
+	if config.Auth.AccessTokenTTLMinutes == 0 {
+		config.Auth.AccessTokenTTLMinutes = 15
+	}
+	if config.Auth.RefreshTokenTTLHours == 0 {
+		config.Auth.RefreshTokenTTLHours = 720
+	}
+	if config.Notifications.BaseURL == "" {
+		config.Notifications.BaseURL = "http://localhost:8080"
+	}
+	if config.Notifications.DigestCheckMinutes == 0 {
+		config.Notifications.DigestCheckMinutes = 15
+	}
+	if config.Notifications.LiveCheckSeconds == 0 {
+		config.Notifications.LiveCheckSeconds = 30
+	}
+	if config.Notifications.Telegram.AlertCheckMinutes == 0 {
+		config.Notifications.Telegram.AlertCheckMinutes = 5
+	}
+	if config.Notifications.OnSale.CheckMinutes == 0 {
+		config.Notifications.OnSale.CheckMinutes = 5
+	}
+	if config.Notifications.OnSale.LeadMinutes == 0 {
+		config.Notifications.OnSale.LeadMinutes = 60
+	}
+	if config.Notifications.Releases.CheckHours == 0 {
+		config.Notifications.Releases.CheckHours = 12
+	}
+	if config.Notifications.Releases.WindowDays == 0 {
+		config.Notifications.Releases.WindowDays = 14
+	}
+	if config.Notifications.SMTP.Port == 0 {
+		config.Notifications.SMTP.Port = 587
+	}
+	if config.Notifications.SMTP.From == "" {
+		config.Notifications.SMTP.From = "Where It's At <digest@localhost>"
+	}
 }
 
 func applyEnvOverrides(config *Config) {
+	if v := os.Getenv("WHEREITS_ENVIRONMENT"); v != "" {
+		config.Environment = v
+	}
+
 	// Server overrides
 	if v := os.Getenv("WHEREITS_SERVER_PORT"); v != "" {
 		config.Server.Port = v
 	}
+	if v := os.Getenv("WHEREITS_CORS_ORIGINS"); v != "" {
+		config.Server.CORS.AllowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				config.Server.CORS.AllowedOrigins = append(config.Server.CORS.AllowedOrigins, origin)
+			}
+		}
+	}
+
+	// Logging overrides
+	if v := os.Getenv("WHEREITS_LOG_LEVEL"); v != "" {
+		config.Logging.Level = v
+	}
+	if v := os.Getenv("WHEREITS_LOG_FORMAT"); v != "" {
+		config.Logging.Format = v
+	}
+
+	// Cache overrides
+	if v := os.Getenv("WHEREITS_CACHE_BACKEND"); v != "" {
+		config.Cache.Backend = v
+	}
+	if v := os.Getenv("WHEREITS_REDIS_ADDR"); v != "" {
+		config.Cache.Redis.Addr = v
+	}
+	if v := os.Getenv("WHEREITS_REDIS_PASSWORD"); v != "" {
+		config.Cache.Redis.Password = v
+	}
+
+	// Tracing overrides
+	if v := os.Getenv("WHEREITS_TRACING_ENABLED"); v != "" {
+		config.Tracing.Enabled = v == "true" || v == "1"
+	}
+	if v := os.Getenv("WHEREITS_TRACING_ENDPOINT"); v != "" {
+		config.Tracing.Endpoint = v
+	}
+
+	// Auth overrides
+	if v := os.Getenv("WHEREITS_AUTH_JWT_SECRET"); v != "" {
+		config.Auth.JWTSecret = v
+	}
+	if v := os.Getenv("WHEREITS_ADMIN_TOKEN"); v != "" {
+		config.Auth.AdminToken = v
+	}
+
+	// Notification overrides
+	if v := os.Getenv("WHEREITS_NOTIFICATIONS_BASE_URL"); v != "" {
+		config.Notifications.BaseURL = v
+	}
+	if v := os.Getenv("WHEREITS_SMTP_HOST"); v != "" {
+		config.Notifications.SMTP.Host = v
+	}
+	if v := os.Getenv("WHEREITS_SMTP_USERNAME"); v != "" {
+		config.Notifications.SMTP.Username = v
+	}
+	if v := os.Getenv("WHEREITS_SMTP_PASSWORD"); v != "" {
+		config.Notifications.SMTP.Password = v
+	}
+	if v := os.Getenv("WHEREITS_TELEGRAM_BOT_TOKEN"); v != "" {
+		config.Notifications.Telegram.BotToken = v
+	}
 
 	// Database overrides
 	if v := os.Getenv("WHEREITS_DATABASE_HOST"); v != "" {
@@ -202,6 +669,9 @@ func applyEnvOverrides(config *Config) {
 	if v := os.Getenv("WHEREITS_SPOTIFY_CLIENT_SECRET"); v != "" {
 		config.APIs.Spotify.ClientSecret = v
 	}
+	if v := os.Getenv("WHEREITS_SPOTIFY_REDIRECT_URI"); v != "" {
+		config.APIs.Spotify.RedirectURI = v
+	}
 	if v := os.Getenv("WHEREITS_APPLE_MUSIC_TEAM_ID"); v != "" {
 		config.APIs.AppleMusic.TeamID = v
 	}
@@ -211,6 +681,9 @@ func applyEnvOverrides(config *Config) {
 	if v := os.Getenv("WHEREITS_APPLE_MUSIC_PRIVATE_KEY"); v != "" {
 		config.APIs.AppleMusic.PrivateKey = v
 	}
+	if v := os.Getenv("WHEREITS_APPLE_MUSIC_COUNTRY_CODE"); v != "" {
+		config.APIs.AppleMusic.CountryCode = v
+	}
 	if v := os.Getenv("WHEREITS_YOUTUBE_API_KEY"); v != "" {
 		config.APIs.YouTube.APIKey = v
 	}
@@ -223,18 +696,38 @@ func applyEnvOverrides(config *Config) {
 	if v := os.Getenv("WHEREITS_SOUNDCLOUD_CLIENT_ID"); v != "" {
 		config.APIs.SoundCloud.ClientID = v
 	}
+	if v := os.Getenv("WHEREITS_SOUNDCLOUD_CLIENT_SECRET"); v != "" {
+		config.APIs.SoundCloud.ClientSecret = v
+	}
 	if v := os.Getenv("WHEREITS_SONGKICK_API_KEY"); v != "" {
 		config.APIs.Songkick.APIKey = v
 	}
+	if v := os.Getenv("WHEREITS_BANDSINTOWN_APP_ID"); v != "" {
+		config.APIs.Bandsintown.AppID = v
+	}
 	if v := os.Getenv("WHEREITS_TICKETMASTER_API_KEY"); v != "" {
 		config.APIs.Ticketmaster.APIKey = v
 	}
 	if v := os.Getenv("WHEREITS_EVENTBRITE_TOKEN"); v != "" {
 		config.APIs.Eventbrite.Token = v
 	}
+	if v := os.Getenv("WHEREITS_LASTFM_API_KEY"); v != "" {
+		config.APIs.LastFM.APIKey = v
+	}
 	if v := os.Getenv("WHEREITS_SETLISTFM_API_KEY"); v != "" {
 		config.APIs.SetlistFM.APIKey = v
 	}
+	if v := os.Getenv("WHEREITS_FACEBOOK_ACCESS_TOKEN"); v != "" {
+		config.APIs.Facebook.AccessToken = v
+	}
+	if v := os.Getenv("WHEREITS_FANARTTV_API_KEY"); v != "" {
+		config.APIs.FanartTV.APIKey = v
+	}
+
+	// Locations
+	if v := os.Getenv("WHEREITS_CITIES_FILE"); v != "" {
+		config.Locations.CitiesFile = v
+	}
 }
 
 // GetDSN returns the PostgreSQL connection string
@@ -258,6 +751,14 @@ func (c *Config) Validate() error {
 		missing = append(missing, "database.database")
 	}
 
+	// Fixtures stand in for missing APIs in development
+	if c.IsDevelopment() {
+		if len(missing) > 0 {
+			return fmt.Errorf("missing required configuration: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	}
+
 	// At least one music API should be configured
 	hasMusic := false
 	if c.APIs.Spotify.ClientID != "" && c.APIs.Spotify.ClientSecret != "" {
@@ -275,6 +776,9 @@ func (c *Config) Validate() error {
 	if c.APIs.SoundCloud.ClientID != "" {
 		hasMusic = true
 	}
+	if c.APIs.LastFM.APIKey != "" {
+		hasMusic = true
+	}
 
 	if !hasMusic {
 		missing = append(missing, "at least one music API")
@@ -285,6 +789,9 @@ func (c *Config) Validate() error {
 	if c.APIs.Songkick.APIKey != "" {
 		hasEvents = true
 	}
+	if c.APIs.Bandsintown.AppID != "" {
+		hasEvents = true
+	}
 	if c.APIs.Ticketmaster.APIKey != "" {
 		hasEvents = true
 	}
@@ -30,6 +30,12 @@ func TestLoad(t *testing.T) {
 					ClientSecret: "test-client-secret",
 				},
 			},
+			Ranking: RankingConfig{
+				SourceWeights: map[string]float64{"songkick": 0.5},
+			},
+			Sources: SourcesConfig{
+				Limits: map[string]SourceLimitsConfig{"deezer": {TimeoutMS: 300}},
+			},
 		}
 
 		data, _ := json.Marshal(testConfig)
@@ -53,6 +59,12 @@ func TestLoad(t *testing.T) {
 		if config.APIs.Spotify.ClientID != "test-client-id" {
 			t.Errorf("expected client ID test-client-id, got %s", config.APIs.Spotify.ClientID)
 		}
+		if config.Ranking.SourceWeights["songkick"] != 0.5 {
+			t.Errorf("expected songkick weight 0.5, got %v", config.Ranking.SourceWeights)
+		}
+		if config.Sources.Limits["deezer"].TimeoutMS != 300 {
+			t.Errorf("expected deezer timeout 300ms, got %v", config.Sources.Limits)
+		}
 	})
 
 	t.Run("applies defaults", func(t *testing.T) {
@@ -64,6 +76,9 @@ func TestLoad(t *testing.T) {
 		if config.Server.Port != "8080" {
 			t.Errorf("expected default port 8080, got %s", config.Server.Port)
 		}
+		if config.Environment != EnvironmentProduction || config.IsDevelopment() {
+			t.Errorf("expected production by default, got %s", config.Environment)
+		}
 		if config.Server.ReadTimeout != 30 {
 			t.Errorf("expected default read timeout 30, got %d", config.Server.ReadTimeout)
 		}
@@ -73,6 +88,30 @@ func TestLoad(t *testing.T) {
 		if config.Cache.EventCacheDuration != 24 {
 			t.Errorf("expected default cache duration 24, got %d", config.Cache.EventCacheDuration)
 		}
+		if config.Logging.Level != "info" || config.Logging.Format != "text" {
+			t.Errorf("expected info/text logging, got %s/%s", config.Logging.Level, config.Logging.Format)
+		}
+		if config.Tracing.Enabled || config.Tracing.Endpoint != "localhost:4318" || config.Tracing.SampleRatio != 1 {
+			t.Errorf("expected tracing off with default endpoint, got %+v", config.Tracing)
+		}
+		if config.Auth.AccessTokenTTLMinutes != 15 || config.Auth.RefreshTokenTTLHours != 720 {
+			t.Errorf("expected 15m/720h token lifetimes, got %+v", config.Auth)
+		}
+		if config.Notifications.SMTP.Host != "" || config.Notifications.SMTP.Port != 587 || config.Notifications.DigestCheckMinutes != 15 {
+			t.Errorf("expected digests off with SMTP defaults, got %+v", config.Notifications)
+		}
+		if len(config.Server.CORS.AllowedOrigins) != 0 || len(config.Server.CORS.AllowedMethods) == 0 || config.Server.CORS.MaxAgeSeconds != 600 {
+			t.Errorf("expected CORS off with default methods, got %+v", config.Server.CORS)
+		}
+		if config.Notifications.LiveCheckSeconds != 30 {
+			t.Errorf("expected live pushes checked every 30s, got %d", config.Notifications.LiveCheckSeconds)
+		}
+		if config.Notifications.OnSale.CheckMinutes != 5 || config.Notifications.OnSale.LeadMinutes != 60 {
+			t.Errorf("expected on-sale checks every 5m an hour ahead, got %+v", config.Notifications.OnSale)
+		}
+		if config.Notifications.Releases.CheckHours != 12 || config.Notifications.Releases.WindowDays != 14 {
+			t.Errorf("expected release checks every 12h over 14 days, got %+v", config.Notifications.Releases)
+		}
 	})
 
 	t.Run("environment overrides", func(t *testing.T) {
@@ -80,7 +119,23 @@ func TestLoad(t *testing.T) {
 		os.Setenv("WHEREITS_SERVER_PORT", "7070")
 		os.Setenv("WHEREITS_DATABASE_HOST", "env-host")
 		os.Setenv("WHEREITS_SPOTIFY_CLIENT_ID", "env-spotify-id")
+		os.Setenv("WHEREITS_LOG_FORMAT", "json")
+		os.Setenv("WHEREITS_TRACING_ENABLED", "true")
+		os.Setenv("WHEREITS_AUTH_JWT_SECRET", "env-secret")
+		os.Setenv("WHEREITS_ADMIN_TOKEN", "env-admin")
+		os.Setenv("WHEREITS_SMTP_HOST", "smtp.example.com")
+		os.Setenv("WHEREITS_TELEGRAM_BOT_TOKEN", "123:abc")
+		os.Setenv("WHEREITS_CORS_ORIGINS", "https://app.example.com, http://localhost:3000")
+		os.Setenv("WHEREITS_ENVIRONMENT", "development")
 		defer func() {
+			os.Unsetenv("WHEREITS_ENVIRONMENT")
+			os.Unsetenv("WHEREITS_CORS_ORIGINS")
+			os.Unsetenv("WHEREITS_TELEGRAM_BOT_TOKEN")
+			os.Unsetenv("WHEREITS_SMTP_HOST")
+			os.Unsetenv("WHEREITS_ADMIN_TOKEN")
+			os.Unsetenv("WHEREITS_AUTH_JWT_SECRET")
+			os.Unsetenv("WHEREITS_TRACING_ENABLED")
+			os.Unsetenv("WHEREITS_LOG_FORMAT")
 			os.Unsetenv("WHEREITS_SERVER_PORT")
 			os.Unsetenv("WHEREITS_DATABASE_HOST")
 			os.Unsetenv("WHEREITS_SPOTIFY_CLIENT_ID")
@@ -100,6 +155,30 @@ func TestLoad(t *testing.T) {
 		if config.APIs.Spotify.ClientID != "env-spotify-id" {
 			t.Errorf("expected env spotify ID env-spotify-id, got %s", config.APIs.Spotify.ClientID)
 		}
+		if config.Logging.Format != "json" {
+			t.Errorf("expected env log format json, got %s", config.Logging.Format)
+		}
+		if !config.Tracing.Enabled {
+			t.Error("expected tracing to be enabled from env")
+		}
+		if config.Auth.JWTSecret != "env-secret" {
+			t.Errorf("expected env JWT secret, got %s", config.Auth.JWTSecret)
+		}
+		if config.Auth.AdminToken != "env-admin" {
+			t.Errorf("expected env admin token, got %s", config.Auth.AdminToken)
+		}
+		if config.Notifications.SMTP.Host != "smtp.example.com" {
+			t.Errorf("expected env SMTP host, got %s", config.Notifications.SMTP.Host)
+		}
+		if config.Notifications.Telegram.BotToken != "123:abc" {
+			t.Errorf("expected env telegram token, got %s", config.Notifications.Telegram.BotToken)
+		}
+		if origins := config.Server.CORS.AllowedOrigins; len(origins) != 2 || origins[1] != "http://localhost:3000" {
+			t.Errorf("expected env CORS origins, got %v", origins)
+		}
+		if !config.IsDevelopment() {
+			t.Errorf("expected env environment development, got %s", config.Environment)
+		}
 	})
 
 	t.Run("handles missing file", func(t *testing.T) {
@@ -213,6 +292,21 @@ func TestConfig_Validate(t *testing.T) {
 			t.Error("expected validation error for missing event API")
 		}
 	})
+
+	t.Run("development needs no APIs", func(t *testing.T) {
+		config := &Config{
+			Environment: EnvironmentDevelopment,
+			Database: DatabaseConfig{
+				Host:     "localhost",
+				User:     "user",
+				Database: "db",
+			},
+		}
+
+		if err := config.Validate(); err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+	})
 }
 
 func TestApplyDefaults(t *testing.T) {
@@ -237,6 +331,9 @@ func TestApplyDefaults(t *testing.T) {
 	if config.APIs.MusicBrainz.UserAgent != "WhereItsAt/1.0" {
 		t.Errorf("expected default MusicBrainz user agent, got %s", config.APIs.MusicBrainz.UserAgent)
 	}
+	if config.APIs.Retry.MaxRetries != 3 || config.APIs.Retry.BaseDelayMS != 500 || config.APIs.Retry.MaxDelayMS != 10000 {
+		t.Errorf("expected default retries 3 from 500ms to 10s, got %+v", config.APIs.Retry)
+	}
 	if config.Scrapers.UserAgent != "Mozilla/5.0 (compatible; WhereItsAt/1.0)" {
 		t.Errorf("expected default scraper user agent, got %s", config.Scrapers.UserAgent)
 	}
@@ -246,9 +343,27 @@ func TestApplyDefaults(t *testing.T) {
 	if config.Scrapers.Timeout != 30 {
 		t.Errorf("expected default timeout 30, got %d", config.Scrapers.Timeout)
 	}
+	if config.Scrapers.CacheTTLMinutes != 60 {
+		t.Errorf("expected default scraper cache TTL 60, got %d", config.Scrapers.CacheTTLMinutes)
+	}
+	if config.Scrapers.Browser.MaxTabs != 2 || config.Scrapers.Browser.PageTimeoutSeconds != 30 {
+		t.Errorf("expected 2 browser tabs with a 30s page timeout, got %+v", config.Scrapers.Browser)
+	}
+	if config.Scrapers.Browser.UsesBrowser("resident_advisor") {
+		t.Error("expected scrapers to fetch over HTTP by default")
+	}
 	if config.Cache.EventCacheDuration != 24 {
 		t.Errorf("expected default cache duration 24, got %d", config.Cache.EventCacheDuration)
 	}
+	if config.Cache.ProfileCacheDuration != 168 {
+		t.Errorf("expected default profile cache duration 168, got %d", config.Cache.ProfileCacheDuration)
+	}
+	if config.Cache.Backend != "memory" || config.Cache.Redis.Addr != "localhost:6379" || config.Cache.Redis.Namespace != "whereitsat" {
+		t.Errorf("expected the memory cache with a local redis namespaced whereitsat, got %+v", config.Cache)
+	}
+	if config.Jobs.Workers != 2 || config.Jobs.MaxAttempts != 5 {
+		t.Errorf("expected 2 job workers and 5 attempts, got %+v", config.Jobs)
+	}
 }
 
 func TestApplyEnvOverrides(t *testing.T) {
@@ -261,6 +376,7 @@ func TestApplyEnvOverrides(t *testing.T) {
 		"WHEREITS_DATABASE_NAME":           "env-db-name",
 		"WHEREITS_SPOTIFY_CLIENT_ID":       "env-spotify-id",
 		"WHEREITS_SPOTIFY_CLIENT_SECRET":   "env-spotify-secret",
+		"WHEREITS_SPOTIFY_REDIRECT_URI":    "env-spotify-redirect",
 		"WHEREITS_APPLE_MUSIC_TEAM_ID":     "env-apple-team",
 		"WHEREITS_APPLE_MUSIC_KEY_ID":      "env-apple-key",
 		"WHEREITS_APPLE_MUSIC_PRIVATE_KEY": "env-apple-private",
@@ -272,6 +388,11 @@ func TestApplyEnvOverrides(t *testing.T) {
 		"WHEREITS_TICKETMASTER_API_KEY":    "env-ticketmaster",
 		"WHEREITS_EVENTBRITE_TOKEN":        "env-eventbrite",
 		"WHEREITS_SETLISTFM_API_KEY":       "env-setlistfm",
+		"WHEREITS_LASTFM_API_KEY":          "env-lastfm",
+		"WHEREITS_FACEBOOK_ACCESS_TOKEN":   "env-facebook",
+		"WHEREITS_CACHE_BACKEND":           "redis",
+		"WHEREITS_REDIS_ADDR":              "redis:6379",
+		"WHEREITS_REDIS_PASSWORD":          "env-redis-pass",
 	}
 
 	for k, v := range envVars {
@@ -287,6 +408,9 @@ func TestApplyEnvOverrides(t *testing.T) {
 	applyEnvOverrides(config)
 
 	// Verify all overrides
+	if config.Cache.Backend != "redis" || config.Cache.Redis.Addr != "redis:6379" || config.Cache.Redis.Password != "env-redis-pass" {
+		t.Errorf("expected env redis cache, got %+v", config.Cache)
+	}
 	if config.Server.Port != "9999" {
 		t.Errorf("expected env port 9999, got %s", config.Server.Port)
 	}
@@ -308,6 +432,9 @@ func TestApplyEnvOverrides(t *testing.T) {
 	if config.APIs.Spotify.ClientSecret != "env-spotify-secret" {
 		t.Errorf("expected env spotify client secret, got %s", config.APIs.Spotify.ClientSecret)
 	}
+	if config.APIs.Spotify.RedirectURI != "env-spotify-redirect" {
+		t.Errorf("expected env spotify redirect URI, got %s", config.APIs.Spotify.RedirectURI)
+	}
 	if config.APIs.AppleMusic.TeamID != "env-apple-team" {
 		t.Errorf("expected env apple team ID, got %s", config.APIs.AppleMusic.TeamID)
 	}
@@ -341,4 +468,10 @@ func TestApplyEnvOverrides(t *testing.T) {
 	if config.APIs.SetlistFM.APIKey != "env-setlistfm" {
 		t.Errorf("expected env setlistfm API key, got %s", config.APIs.SetlistFM.APIKey)
 	}
+	if config.APIs.Facebook.AccessToken != "env-facebook" {
+		t.Errorf("expected env facebook access token, got %s", config.APIs.Facebook.AccessToken)
+	}
+	if config.APIs.LastFM.APIKey != "env-lastfm" {
+		t.Errorf("expected env lastfm API key, got %s", config.APIs.LastFM.APIKey)
+	}
 }
@@ -8,16 +8,67 @@ type Artist struct {
 	ID          string      `json:"id"`
 	Name        string      `json:"name"`
 	ExternalIDs ExternalIDs `json:"external_ids"`
+	Aliases     []string    `json:"aliases,omitempty"`
 	Genres      []string    `json:"genres,omitempty"`
 	Popularity  int         `json:"popularity,omitempty"`
 	ImageURL    string      `json:"image_url,omitempty"`
 	CreatedAt   time.Time   `json:"created_at"`
 	UpdatedAt   time.Time   `json:"updated_at"`
+
+	// SourcePopularity is the popularity each source gave the artist, which
+	// Popularity blends when the artist was found by several
+	SourcePopularity map[string]int `json:"source_popularity,omitempty"`
+}
+
+// LocalArtist is an artist from an area, where they formed or are based,
+// with their upcoming shows
+type LocalArtist struct {
+	Artist
+	Area           string  `json:"area"`
+	UpcomingEvents []Event `json:"upcoming_events"`
+}
+
+// Names returns the artist's name followed by its aliases
+func (a Artist) Names() []string {
+	return append([]string{a.Name}, a.Aliases...)
+}
+
+// KnownAs reports whether name is the artist's name or one of its aliases,
+// ignoring case, accents and punctuation, so "KIASMOS" is Kiasmos
+func (a Artist) KnownAs(name string) bool {
+	key := foldName(name)
+	if key == "" {
+		return false
+	}
+	for _, known := range a.Names() {
+		if foldName(known) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ArtistNameKey folds an artist name the way KnownAs compares them, for
+// indexing names: lowercase, without accents, punctuation as single spaces
+func ArtistNameKey(name string) string {
+	return foldName(name)
 }
 
 type ExternalIDs struct {
 	SpotifyID string `json:"spotify_id,omitempty"`
 	LastFMID  string `json:"lastfm_id,omitempty"`
+	// MusicBrainzID is the artist MBID, which several event sources accept
+	MusicBrainzID string `json:"musicbrainz_id,omitempty"`
+}
+
+// SimilarArtist is an artist like another one. Score ranks candidates;
+// GenreOverlap is the part of it from sharing genres, from 0 to 1, and
+// Sources the music sources that listed the artist as related.
+type SimilarArtist struct {
+	Artist       Artist   `json:"artist"`
+	Score        float64  `json:"score"`
+	GenreOverlap float64  `json:"genre_overlap"`
+	Sources      []string `json:"sources"`
 }
 
 type ArtistSearchRequest struct {
@@ -29,3 +80,32 @@ type ArtistSearchResponse struct {
 	Artists []Artist `json:"artists"`
 	Total   int      `json:"total"`
 }
+
+// TrackedArtist is an artist enrolled in periodic event syncing
+type TrackedArtist struct {
+	ArtistID     string     `json:"artist_id"`
+	Source       string     `json:"source"`
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ArtistIdentity is the MusicBrainz artist a name resolved to, shared by
+// the sources that key on MBIDs so each name is looked up once
+type ArtistIdentity struct {
+	Name string `json:"name"`
+	// MBID is empty when nothing matched the name
+	MBID string `json:"mbid"`
+	// Source is who resolved it, e.g. "musicbrainz" or "setlistfm"
+	Source     string    `json:"source"`
+	ResolvedAt time.Time `json:"resolved_at"`
+}
+
+// ArtistImage is the picture found for an artist name and the source it
+// came from, kept so pictures are only looked for and checked now and then
+type ArtistImage struct {
+	Name string `json:"name"`
+	// ImageURL is empty when no source had a picture that loaded
+	ImageURL  string    `json:"image_url"`
+	Source    string    `json:"source"`
+	CheckedAt time.Time `json:"checked_at"`
+}
@@ -77,3 +77,18 @@ func TestArtist(t *testing.T) {
 		}
 	})
 }
+
+func TestArtist_KnownAs(t *testing.T) {
+	artist := Artist{Name: "Kiasmos", Aliases: []string{"Ólafur Arnalds & Janus Rasmussen"}}
+
+	for _, name := range []string{"Kiasmos", "KIASMOS", " kiasmos ", "ólafur arnalds & janus rasmussen"} {
+		if !artist.KnownAs(name) {
+			t.Errorf("expected %q to be known", name)
+		}
+	}
+	for _, name := range []string{"", "Ólafur Arnalds", "Kiasmos Live"} {
+		if artist.KnownAs(name) {
+			t.Errorf("expected %q not to be known", name)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+package domain
+
+// Chart is a streaming service's most played tracks, for one country or
+// worldwide when Country is empty, with their artists in chart order
+type Chart struct {
+	Country string   `json:"country,omitempty"`
+	Source  string   `json:"source"`
+	Tracks  []Track  `json:"tracks"`
+	Artists []Artist `json:"artists"`
+}
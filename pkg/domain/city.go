@@ -0,0 +1,224 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// City is a city under the English name sources match best, with the local
+// and transliterated names people search for it by. Codes are location codes
+// sources use for it, by source name, like resident_advisor's URL slugs.
+type City struct {
+	Name    string            `json:"name"`
+	Country string            `json:"country,omitempty"`
+	Aliases []string          `json:"aliases,omitempty"`
+	Codes   map[string]string `json:"codes,omitempty"`
+}
+
+// Names returns the city's name followed by its aliases
+func (c City) Names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+//go:embed data/cities.json
+var builtinCities []byte
+
+var (
+	citiesMu sync.RWMutex
+	// cities maps the folded form of every name and alias to its city
+	cities = map[string]*City{}
+)
+
+func init() {
+	var builtin []City
+	if err := json.Unmarshal(builtinCities, &builtin); err != nil {
+		panic(fmt.Sprintf("invalid built-in cities: %v", err))
+	}
+	AddCities(builtin)
+}
+
+// LoadCities reads a JSON list of cities shaped like City, to add to the
+// built-in ones with AddCities
+func LoadCities(path string) ([]City, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cities: %w", err)
+	}
+
+	var loaded []City
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse cities: %w", err)
+	}
+	for i, city := range loaded {
+		if strings.TrimSpace(city.Name) == "" {
+			return nil, fmt.Errorf("city %d has no name", i)
+		}
+	}
+
+	return loaded, nil
+}
+
+// AddCities extends the city table. A city whose name or an alias is already
+// known adds its aliases and codes to that city, with its codes winning, so
+// operators can fill in what the built-in table lacks.
+func AddCities(added []City) {
+	citiesMu.Lock()
+	defer citiesMu.Unlock()
+
+	for _, city := range added {
+		var known *City
+		for _, name := range city.Names() {
+			if known = lookupCityLocked(name); known != nil {
+				break
+			}
+		}
+		if known == nil {
+			known = &City{Name: strings.TrimSpace(city.Name), Country: city.Country}
+			cities[foldName(known.Name)] = known
+		} else if known.Country == "" {
+			known.Country = city.Country
+		}
+
+		// A name already taken by another city stays with that city
+		for _, name := range city.Names() {
+			key := foldName(name)
+			if key == "" || cities[key] != nil {
+				continue
+			}
+			cities[key] = known
+			known.Aliases = append(known.Aliases, strings.TrimSpace(name))
+		}
+		for source, code := range city.Codes {
+			if known.Codes == nil {
+				known.Codes = map[string]string{}
+			}
+			known.Codes[source] = code
+		}
+	}
+}
+
+// LookupCity finds a city by its name or any alias, ignoring case, accents
+// and punctuation
+func LookupCity(name string) (City, bool) {
+	citiesMu.RLock()
+	defer citiesMu.RUnlock()
+
+	if city := lookupCityLocked(name); city != nil {
+		found := *city
+		found.Aliases = slices.Clone(city.Aliases)
+		found.Codes = maps.Clone(city.Codes)
+		return found, true
+	}
+	return City{}, false
+}
+
+func lookupCityLocked(name string) *City {
+	key := foldName(name)
+	if key == "" {
+		return nil
+	}
+	return cities[key]
+}
+
+// NormalizeCity returns the name sources know a city by, so "München" is
+// searched as "Munich". Cities not in the table come back trimmed.
+func NormalizeCity(name string) string {
+	if city, ok := LookupCity(name); ok {
+		return city.Name
+	}
+	return strings.TrimSpace(name)
+}
+
+// CityNames returns every name a city is stored under, for matching events
+// sources listed under the local name. Cities not in the table only match
+// themselves.
+func CityNames(name string) []string {
+	if city, ok := LookupCity(name); ok {
+		return city.Names()
+	}
+	return []string{strings.TrimSpace(name)}
+}
+
+// SameCity reports whether a and b name the same city
+func SameCity(a, b string) bool {
+	if city, ok := LookupCity(a); ok {
+		other, ok := LookupCity(b)
+		return ok && other.Name == city.Name
+	}
+	return foldName(a) == foldName(b) && foldName(a) != ""
+}
+
+// nameFolds transliterates accented Latin letters the way people type them
+// without the accent
+var nameFolds = strings.NewReplacer(
+	"ß", "ss", "æ", "ae", "œ", "oe", "ø", "o", "ł", "l", "đ", "d", "ı", "i",
+)
+
+// foldName lowercases name, drops accents and treats punctuation as spaces,
+// so "St. Petersburg", "st petersburg" and "Zürich", "zurich" match. Place
+// and artist names are matched with it.
+func foldName(name string) string {
+	name = nameFolds.Replace(strings.ToLower(name))
+
+	var b strings.Builder
+	space := false
+	for _, r := range name {
+		if base, ok := accentBase[r]; ok {
+			r = base
+		}
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining accents, like the dot ToLower leaves on İ
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// accentBase maps lowercase accented Latin letters to the letter underneath
+var accentBase = func() map[rune]rune {
+	bases := map[rune]string{
+		'a': "àáâãäåāăą",
+		'c': "çćĉċč",
+		'd': "ď",
+		'e': "èéêëēĕėęě",
+		'g': "ĝğġģ",
+		'h': "ĥħ",
+		'i': "ìíîïĩīĭį",
+		'j': "ĵ",
+		'k': "ķ",
+		'l': "ĺļľŀ",
+		'n': "ñńņňŉ",
+		'o': "òóôõöōŏő",
+		'r': "ŕŗř",
+		's': "śŝşšș",
+		't': "ţťŧț",
+		'u': "ùúûüũūŭůűų",
+		'w': "ŵ",
+		'y': "ýÿŷ",
+		'z': "źżž",
+	}
+
+	m := make(map[rune]rune)
+	for base, accented := range bases {
+		for _, r := range accented {
+			m[r] = base
+		}
+	}
+	return m
+}()
@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeCity(t *testing.T) {
+	tests := map[string]string{
+		"München":          "Munich",
+		"muenchen":         "Munich",
+		"KÖLN":             "Cologne",
+		"Saint Petersburg": "Saint Petersburg",
+		"St. Petersburg":   "Saint Petersburg",
+		"st petersburg":    "Saint Petersburg",
+		"Санкт-Петербург":  "Saint Petersburg",
+		"Zürich":           "Zurich",
+		"İstanbul":         "Istanbul",
+		"sao paulo":        "São Paulo",
+		"Den Haag":         "The Hague",
+		"  Berlin ":        "Berlin",
+		"  Springfield ":   "Springfield",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeCity(input); got != want {
+			t.Errorf("NormalizeCity(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestCityNames(t *testing.T) {
+	names := CityNames("munich")
+	if len(names) < 3 || names[0] != "Munich" || names[1] != "München" {
+		t.Errorf("expected Munich and its aliases, got %v", names)
+	}
+
+	if names := CityNames(" Springfield "); len(names) != 1 || names[0] != "Springfield" {
+		t.Errorf("expected an unknown city to match itself only, got %v", names)
+	}
+}
+
+func TestSameCity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Köln", "Cologne", true},
+		{"Wien", "vienna", true},
+		{"Munich", "Cologne", false},
+		{"Springfield", "springfield", true},
+		{"Springfield", "Munich", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := SameCity(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameCity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestLookupCity_Codes(t *testing.T) {
+	city, ok := LookupCity("New York City")
+	if !ok || city.Codes["resident_advisor"] != "newyork" {
+		t.Errorf("expected New York's resident_advisor code, got %+v", city)
+	}
+
+	// Callers can't change the table through what they're given
+	city.Codes["resident_advisor"] = "changed"
+	if again, _ := LookupCity("nyc"); again.Codes["resident_advisor"] != "newyork" {
+		t.Errorf("expected the table unchanged, got %+v", again)
+	}
+}
+
+func TestLoadCities_AddCities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cities.json")
+	os.WriteFile(path, []byte(`[
+		{"name": "Munich", "aliases": ["Minga"], "codes": {"songkick": "28549"}},
+		{"name": "Gdańsk", "country": "PL", "aliases": ["Danzig"]}
+	]`), 0o644)
+
+	loaded, err := LoadCities(path)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	AddCities(loaded)
+
+	munich, ok := LookupCity("Minga")
+	if !ok || munich.Name != "Munich" || munich.Codes["songkick"] != "28549" {
+		t.Errorf("expected the alias and code added to Munich, got %+v", munich)
+	}
+	if got := NormalizeCity("danzig"); got != "Gdańsk" {
+		t.Errorf("expected a new city, got %q", got)
+	}
+	if got := NormalizeCity("gdansk"); got != "Gdańsk" {
+		t.Errorf("expected a new city matched without its accent, got %q", got)
+	}
+}
+
+func TestLoadCities_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cities.json")
+	os.WriteFile(path, []byte(`[{"aliases": ["Nowhere"]}]`), 0o644)
+
+	if _, err := LoadCities(path); err == nil {
+		t.Error("expected an error for a city without a name")
+	}
+	if _, err := LoadCities(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
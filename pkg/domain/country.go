@@ -0,0 +1,102 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Country is an ISO 3166-1 country under its English short name, with the
+// official and local names sources write it as
+type Country struct {
+	Code    string   `json:"code"`
+	Alpha3  string   `json:"alpha3"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+//go:embed data/countries.json
+var builtinCountries []byte
+
+// countries maps ISO codes and the folded form of every name to its country
+var countries = map[string]*Country{}
+
+func init() {
+	var builtin []Country
+	if err := json.Unmarshal(builtinCountries, &builtin); err != nil {
+		panic(fmt.Sprintf("invalid built-in countries: %v", err))
+	}
+
+	for i := range builtin {
+		country := &builtin[i]
+		countries[country.Code] = country
+		countries[country.Alpha3] = country
+		for _, name := range append([]string{country.Name}, country.Aliases...) {
+			if key := foldName(name); countries[key] == nil {
+				countries[key] = country
+			}
+		}
+	}
+}
+
+// LookupCountry finds a country by its alpha-2 or alpha-3 code or any of its
+// names, ignoring case, accents and punctuation
+func LookupCountry(country string) (Country, bool) {
+	country = strings.TrimSpace(country)
+	if country == "" {
+		return Country{}, false
+	}
+
+	// Codes first, so "UK" isn't mistaken for a name it doesn't have
+	if len(country) <= 3 {
+		if found, ok := countries[strings.ToUpper(country)]; ok {
+			return *found, true
+		}
+	}
+	if found, ok := countries[foldName(country)]; ok {
+		return *found, true
+	}
+	return Country{}, false
+}
+
+// CountryCode returns the ISO 3166-1 alpha-2 code for a country given in
+// any form, or "" when it isn't recognized
+func CountryCode(country string) string {
+	found, _ := LookupCountry(country)
+	return found.Code
+}
+
+// CountryName returns the English short name for a country given in any
+// form, or the input trimmed when it isn't recognized
+func CountryName(country string) string {
+	if found, ok := LookupCountry(country); ok {
+		return found.Name
+	}
+	return strings.TrimSpace(country)
+}
+
+// SameCountry reports whether a and b name the same country, in whatever
+// form each is given
+func SameCountry(a, b string) bool {
+	codeA, codeB := CountryCode(a), CountryCode(b)
+	if codeA != "" || codeB != "" {
+		return codeA == codeB
+	}
+	return foldName(a) == foldName(b) && foldName(a) != ""
+}
+
+// NormalizeCountry fills in CountryCode and Country from whichever of them
+// the source gave, so "DE", "Germany" and "Deutschland" are all stored as
+// Germany, DE. A country that isn't recognized is kept as given.
+func (v *Venue) NormalizeCountry() {
+	found, ok := LookupCountry(v.CountryCode)
+	if !ok {
+		found, ok = LookupCountry(v.Country)
+	}
+	if !ok {
+		v.Country = strings.TrimSpace(v.Country)
+		return
+	}
+	v.Country, v.CountryCode = found.Name, found.Code
+}
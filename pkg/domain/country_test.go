@@ -0,0 +1,93 @@
+package domain
+
+import "testing"
+
+func TestLookupCountry(t *testing.T) {
+	tests := map[string]string{
+		"DE":             "DE",
+		"de":             "DE",
+		"DEU":            "DE",
+		"Germany":        "DE",
+		"Deutschland":    "DE",
+		" deutschland ":  "DE",
+		"UK":             "GB",
+		"England":        "GB",
+		"USA":            "US",
+		"United States":  "US",
+		"Österreich":     "AT",
+		"Osterreich":     "AT",
+		"Czech Republic": "CZ",
+		"South Korea":    "KR",
+		"Россия":         "RU",
+	}
+
+	for input, want := range tests {
+		if got := CountryCode(input); got != want {
+			t.Errorf("CountryCode(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	if got := CountryCode("Atlantis"); got != "" {
+		t.Errorf("expected no code for an unknown country, got %q", got)
+	}
+}
+
+func TestCountryName(t *testing.T) {
+	tests := map[string]string{
+		"DE":                 "Germany",
+		"Russian Federation": "Russia",
+		"KR":                 "South Korea",
+		" Atlantis ":         "Atlantis",
+	}
+
+	for input, want := range tests {
+		if got := CountryName(input); got != want {
+			t.Errorf("CountryName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestSameCountry(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"DE", "Deutschland", true},
+		{"GB", "United Kingdom", true},
+		{"DE", "AT", false},
+		{"DE", "Atlantis", false},
+		{"Atlantis", "atlantis", true},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := SameCountry(tt.a, tt.b); got != tt.want {
+			t.Errorf("SameCountry(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestVenue_NormalizeCountry(t *testing.T) {
+	tests := []struct {
+		name        string
+		venue       Venue
+		wantCountry string
+		wantCode    string
+	}{
+		{"code only", Venue{CountryCode: "de"}, "Germany", "DE"},
+		{"name only", Venue{Country: "Deutschland"}, "Germany", "DE"},
+		{"code wins", Venue{Country: "Unknown Country", CountryCode: "NL"}, "Netherlands", "NL"},
+		{"code in the name", Venue{Country: "US"}, "United States", "US"},
+		{"unknown", Venue{Country: " Atlantis "}, "Atlantis", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			venue := tt.venue
+			venue.NormalizeCountry()
+			if venue.Country != tt.wantCountry || venue.CountryCode != tt.wantCode {
+				t.Errorf("expected %s, %s, got %s, %s", tt.wantCountry, tt.wantCode, venue.Country, venue.CountryCode)
+			}
+		})
+	}
+}
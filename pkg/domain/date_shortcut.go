@@ -0,0 +1,95 @@
+package domain
+
+import "time"
+
+// DateShortcut names a span of days counted from today, like "this-weekend",
+// for searches that take one instead of dates
+type DateShortcut string
+
+const (
+	Tonight  DateShortcut = "tonight"
+	Tomorrow DateShortcut = "tomorrow"
+	// ThisWeekend is Friday to Sunday, the coming one from Monday to
+	// Thursday and the rest of the current one after that
+	ThisWeekend DateShortcut = "this-weekend"
+	// Next7Days is today and the six days after it
+	Next7Days DateShortcut = "next-7-days"
+)
+
+// Valid reports whether s is one of the shortcuts above
+func (s DateShortcut) Valid() bool {
+	_, _, ok := s.days(time.Monday)
+	return ok
+}
+
+// days are the first and last day of the span, as days after today
+func (s DateShortcut) days(today time.Weekday) (first, last int, ok bool) {
+	switch s {
+	case Tonight:
+		return 0, 0, true
+	case Tomorrow:
+		return 1, 1, true
+	case ThisWeekend:
+		untilSunday := int(7-today) % 7
+		if today == time.Sunday || today >= time.Friday {
+			return 0, untilSunday, true
+		}
+		return int(time.Friday - today), untilSunday, true
+	case Next7Days:
+		return 0, 6, true
+	}
+	return 0, 0, false
+}
+
+// Matches reports whether the event falls on one of the span's days, by its
+// date at the venue. Days are counted from today where the requester is, in
+// loc, or when loc is nil from today at each venue.
+func (s DateShortcut) Matches(event Event, now time.Time, loc *time.Location) bool {
+	if !event.DateKnown() {
+		return false
+	}
+
+	local := event.LocalDateTime()
+	today := now.In(local.Location())
+	if loc != nil {
+		today = now.In(loc)
+	}
+
+	first, last, ok := s.days(today.Weekday())
+	if !ok {
+		return false
+	}
+	after := daysBetween(today, local)
+	return after >= first && after <= last
+}
+
+// Window bounds the start of every event the span can match, whatever the
+// venue's and the requester's timezones, so searches can be narrowed before
+// Matches decides
+func (s DateShortcut) Window(now time.Time) (from, to time.Time) {
+	// Today is a day either side of UTC's somewhere, and its weekday with it
+	first, last := 0, 0
+	for i, shift := range []int{-1, 0, 1} {
+		f, l, _ := s.days(now.UTC().AddDate(0, 0, shift).Weekday())
+		f, l = f+shift, l+shift
+		if i == 0 || f < first {
+			first = f
+		}
+		if i == 0 || l > last {
+			last = l
+		}
+	}
+
+	// A local day starts as early as UTC+14 and ends as late as UTC-12, and
+	// now is anywhere in UTC's day, so a day and a half either side is
+	// rounded up to two
+	return now.AddDate(0, 0, first-2), now.AddDate(0, 0, last+2)
+}
+
+// daysBetween counts calendar days from a's date to b's, each on its own
+// clock
+func daysBetween(a, b time.Time) int {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return int(time.Date(by, bm, bd, 0, 0, 0, 0, time.UTC).Sub(time.Date(ay, am, ad, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
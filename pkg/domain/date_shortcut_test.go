@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDateShortcut_Matches(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	// Wednesday 14 October 2026, 20:00 in Berlin, 03:00 Thursday in Tokyo
+	now := time.Date(2026, 10, 14, 18, 0, 0, 0, time.UTC)
+	event := func(timezone string, loc *time.Location, day, hour int) Event {
+		return Event{Timezone: timezone, DateTime: time.Date(2026, 10, day, hour, 0, 0, 0, loc).UTC(), DateConfidence: DateExact}
+	}
+
+	tests := []struct {
+		name  string
+		when  DateShortcut
+		event Event
+		loc   *time.Location
+		want  bool
+	}{
+		{"tonight at the venue", Tonight, event("Europe/Berlin", berlin, 14, 23), nil, true},
+		{"after midnight at the venue is tomorrow", Tonight, event("Europe/Berlin", berlin, 15, 0), nil, false},
+		{"tonight in Tokyo is already Thursday", Tonight, event("Asia/Tokyo", tokyo, 15, 21), nil, true},
+		{"the requester's tonight is still Wednesday", Tonight, event("Asia/Tokyo", tokyo, 15, 21), berlin, false},
+		{"tomorrow", Tomorrow, event("Europe/Berlin", berlin, 15, 20), nil, true},
+		{"the coming weekend starts Friday", ThisWeekend, event("Europe/Berlin", berlin, 16, 20), nil, true},
+		{"and ends Sunday", ThisWeekend, event("Europe/Berlin", berlin, 18, 23), nil, true},
+		{"Thursday isn't the weekend", ThisWeekend, event("Europe/Berlin", berlin, 15, 20), nil, false},
+		{"the next 7 days end on Tuesday", Next7Days, event("Europe/Berlin", berlin, 20, 20), nil, true},
+		{"not the Wednesday after", Next7Days, event("Europe/Berlin", berlin, 21, 20), nil, false},
+		{"events without a date never match", Next7Days, Event{DateConfidence: DateUnknown}, nil, false},
+		{"without a timezone the event's own clock is used", Tonight, Event{DateTime: time.Date(2026, 10, 14, 22, 0, 0, 0, time.UTC)}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.when.Matches(tt.event, now, tt.loc); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestDateShortcut_ThisWeekend(t *testing.T) {
+	// From Friday on it's the weekend under way
+	sunday := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	if !ThisWeekend.Matches(Event{DateTime: sunday.Add(6 * time.Hour)}, sunday, nil) {
+		t.Error("expected Sunday evening to be this weekend on Sunday")
+	}
+	if ThisWeekend.Matches(Event{DateTime: sunday.AddDate(0, 0, 5)}, sunday, nil) {
+		t.Error("expected next Friday not to be this weekend on Sunday")
+	}
+}
+
+func TestDateShortcut_Window(t *testing.T) {
+	now := time.Date(2026, 10, 14, 18, 0, 0, 0, time.UTC)
+	for _, when := range []DateShortcut{Tonight, Tomorrow, ThisWeekend, Next7Days} {
+		from, to := when.Window(now)
+		// Every matching event falls inside the window, wherever the venue
+		// and the requester are
+		timezones := []string{"Pacific/Kiritimati", "Etc/GMT+12", "Europe/Berlin"}
+		for _, timezone := range timezones {
+			for _, requester := range timezones {
+				loc, _ := time.LoadLocation(requester)
+				for hour := -24 * 10; hour <= 24*10; hour++ {
+					event := Event{Timezone: timezone, DateTime: now.Add(time.Duration(hour) * time.Hour)}
+					matches := when.Matches(event, now, nil) || when.Matches(event, now, loc)
+					if matches && (event.DateTime.Before(from) || event.DateTime.After(to)) {
+						t.Fatalf("%s: %v in %s matches but is outside %v to %v", when, event.DateTime, timezone, from, to)
+					}
+				}
+			}
+		}
+	}
+
+	if DateShortcut("someday").Valid() || !Next7Days.Valid() {
+		t.Error("expected only the known shortcuts to be valid")
+	}
+}
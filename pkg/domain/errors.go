@@ -14,6 +14,27 @@ var (
 	ErrDuplicateEvent     = errors.New("event already exists")
 	ErrInvalidLocation    = errors.New("invalid location")
 	ErrRateLimitExceeded  = errors.New("rate limit exceeded")
+	ErrTokenNotFound      = errors.New("oauth token not found")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrDuplicateUser      = errors.New("user already exists")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrFollowNotFound     = errors.New("follow not found")
+	ErrSearchNotFound     = errors.New("saved search not found")
+	ErrPreferencesNotSet  = errors.New("notification preferences not set")
+	ErrLinkNotFound       = errors.New("telegram link not found")
+	ErrLinkCodeInvalid    = errors.New("link code is invalid or expired")
+	ErrNotSubscribed      = errors.New("on-sale alerts not turned on")
+	ErrProfileNotFound    = errors.New("artist profile not found")
+	ErrSourceNotFound     = errors.New("source not found")
+	ErrSettingNotFound    = errors.New("source setting not found")
+	ErrPlaylistNotFound   = errors.New("playlist not found")
+	ErrJobNotFound        = errors.New("job not found")
+	ErrCheckpointNotFound = errors.New("backfill checkpoint not found")
+	ErrVenueNotFound      = errors.New("venue not found")
+	ErrCursorExpired      = errors.New("change feed cursor expired")
+	ErrIdentityNotFound   = errors.New("artist identity not found")
+	ErrChartNotFound      = errors.New("chart not found")
+	ErrImageNotFound      = errors.New("artist image not found")
 )
 
 type ValidationError struct {
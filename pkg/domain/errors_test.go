@@ -20,6 +20,7 @@ func TestErrors(t *testing.T) {
 			{"ErrDuplicateEvent", ErrDuplicateEvent, "event already exists"},
 			{"ErrInvalidLocation", ErrInvalidLocation, "invalid location"},
 			{"ErrRateLimitExceeded", ErrRateLimitExceeded, "rate limit exceeded"},
+			{"ErrTokenNotFound", ErrTokenNotFound, "oauth token not found"},
 		}
 
 		for _, tt := range tests {
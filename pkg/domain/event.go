@@ -1,23 +1,421 @@
 package domain
 
 import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
 )
 
 type Event struct {
-	ID           string           `json:"id"`
-	ArtistID     string           `json:"artist_id"`
-	ArtistName   string           `json:"artist_name"`
-	Title        string           `json:"title"`
-	DateTime     time.Time        `json:"datetime"`
-	Venue        Venue            `json:"venue"`
-	TicketURL    string           `json:"ticket_url,omitempty"`
-	TicketStatus string           `json:"ticket_status,omitempty"`
-	OnSaleDate   *time.Time       `json:"on_sale_date,omitempty"`
-	ExternalIDs  EventExternalIDs `json:"external_ids"`
-	CreatedAt    time.Time        `json:"created_at"`
-	UpdatedAt    time.Time        `json:"updated_at"`
-	CachedUntil  time.Time        `json:"cached_until"`
+	ID           string    `json:"id"`
+	ArtistID     string    `json:"artist_id"`
+	ArtistName   string    `json:"artist_name"`
+	Title        string    `json:"title"`
+	DateTime     time.Time `json:"datetime"`
+	Timezone     string    `json:"timezone,omitempty"`
+	Venue        Venue     `json:"venue"`
+	TicketURL    string    `json:"ticket_url,omitempty"`
+	TicketStatus string    `json:"ticket_status,omitempty"`
+	// DateConfidence is empty for events stored before it was tracked,
+	// which counts as exact
+	DateConfidence DateConfidence `json:"date_confidence,omitempty"`
+	// Status is empty for events stored before statuses were tracked,
+	// which counts as scheduled
+	Status      EventStatus  `json:"status,omitempty"`
+	OnSaleDate  *time.Time   `json:"on_sale_date,omitempty"`
+	PriceRanges []PriceRange `json:"price_ranges,omitempty"`
+	// AgeRestriction is the minimum age as the source words it, e.g. "14+"
+	// or "18 and over", empty when it gives none
+	AgeRestriction string `json:"age_restriction,omitempty"`
+	// Accessibility is what the source says about access for disabled
+	// fans, e.g. wheelchair seating and how to book it
+	Accessibility string `json:"accessibility,omitempty"`
+	// Lineup is everyone on the bill in billing order, headliners first.
+	// ArtistName stays the headliner.
+	Lineup      []EventArtist    `json:"lineup,omitempty"`
+	ExternalIDs EventExternalIDs `json:"external_ids"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	CachedUntil time.Time        `json:"cached_until"`
+	// DiscoveredAt is when the event was first stored, set on discovery listings
+	DiscoveredAt *time.Time `json:"discovered_at,omitempty"`
+	// ArchivedAt is when the event left the hot table, set on archive listings
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// TicketOffers is every source's tickets for the event, so vendors can
+	// be compared when it was listed by several. TicketURL stays the first.
+	TicketOffers []TicketOffer `json:"ticket_offers,omitempty"`
+	// Sources are the sources that listed the event, in the order their
+	// copies were merged, and SourceIDs the ID each of them gave it
+	Sources   []string          `json:"sources,omitempty"`
+	SourceIDs map[string]string `json:"source_ids,omitempty"`
+}
+
+// AddSource records that source listed the event, under the ID it gave the
+// event: its external ID for the source, or else the event's own ID. The
+// event's Sources and SourceIDs are copied rather than changed in place, as
+// copies of the event may share them.
+func (e *Event) AddSource(source string) {
+	id := e.ExternalIDs.For(source)
+	if id == "" {
+		id = e.ID
+	}
+	e.addSource(source, id)
+}
+
+// MergeSources adds the sources of other, a copy of the same event from
+// elsewhere, after the event's own
+func (e *Event) MergeSources(other Event) {
+	for _, source := range other.Sources {
+		e.addSource(source, other.SourceIDs[source])
+	}
+}
+
+func (e *Event) addSource(source, id string) {
+	if source == "" || slices.Contains(e.Sources, source) {
+		return
+	}
+	e.Sources = append(slices.Clip(e.Sources), source)
+	if id == "" {
+		return
+	}
+	ids := make(map[string]string, len(e.SourceIDs)+1)
+	maps.Copy(ids, e.SourceIDs)
+	ids[source] = id
+	e.SourceIDs = ids
+}
+
+// LocalDateTime is DateTime on the venue's clock. Timezone is the IANA name
+// of the venue's timezone, e.g. "Europe/London", when the source gives one;
+// events without it keep DateTime as it is.
+func (e *Event) LocalDateTime() time.Time {
+	return InTimezone(e.DateTime, e.Timezone)
+}
+
+// DateConfidence is how much of an event's start the source actually gave
+type DateConfidence string
+
+const (
+	// DateExact is a day and a start time
+	DateExact DateConfidence = "exact"
+	// DateOnly is a day whose start time isn't announced yet; DateTime is
+	// midnight on it
+	DateOnly DateConfidence = "date_only"
+	// DateUnknown is an event whose date is to be announced or couldn't be
+	// read; DateTime is zero
+	DateUnknown DateConfidence = "unknown"
+)
+
+// DateKnown reports whether the event has a day to sort and filter it by
+func (e *Event) DateKnown() bool {
+	return e.DateConfidence != DateUnknown && !e.DateTime.IsZero()
+}
+
+// MinimumAge is the age AgeRestriction sets, e.g. 18 for "18+" or "Ages
+// 18 and over". It's 0 when there's no age in it.
+func (e *Event) MinimumAge() int {
+	digits := strings.TrimLeftFunc(e.AgeRestriction, func(r rune) bool { return !unicode.IsDigit(r) })
+	end := strings.IndexFunc(digits, func(r rune) bool { return !unicode.IsDigit(r) })
+	if end >= 0 {
+		digits = digits[:end]
+	}
+	age, _ := strconv.Atoi(digits)
+	return age
+}
+
+// AllAges reports whether anyone can go: the source gives no age
+// restriction, or one that says all ages or sets no minimum. A restriction
+// without an age, like Ticketmaster's "legal age enforced", isn't all ages.
+func (e *Event) AllAges() bool {
+	restriction := strings.ToLower(strings.TrimSpace(e.AgeRestriction))
+	if restriction == "" || strings.Contains(restriction, "all ages") {
+		return true
+	}
+	return strings.ContainsFunc(restriction, unicode.IsDigit) && e.MinimumAge() == 0
+}
+
+// InTimezone is t on the clock of the named IANA timezone, or t unchanged
+// when the timezone is empty or unknown
+func InTimezone(t time.Time, timezone string) time.Time {
+	if timezone == "" {
+		return t
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return t
+	}
+	return t.In(loc)
+}
+
+// ParseLocalDateTime parses a wall clock time as it reads at a venue in
+// the named IANA timezone, so the offset follows daylight saving on that
+// date. An empty or unknown timezone reads the time as UTC.
+func ParseLocalDateTime(layout, value, timezone string) (time.Time, error) {
+	loc := time.UTC
+	if timezone != "" {
+		if l, err := time.LoadLocation(timezone); err == nil {
+			loc = l
+		}
+	}
+	return time.ParseInLocation(layout, value, loc)
+}
+
+// EventStatus is whether an event is still going ahead as announced
+type EventStatus string
+
+const (
+	EventScheduled   EventStatus = "scheduled"
+	EventCancelled   EventStatus = "cancelled"
+	EventPostponed   EventStatus = "postponed"
+	EventRescheduled EventStatus = "rescheduled"
+)
+
+// EventChange is a stored event that a later sync found cancelled,
+// postponed or moved to another date. Status is the kind of change.
+type EventChange struct {
+	Event            Event       `json:"event"`
+	Status           EventStatus `json:"status"`
+	PreviousDateTime time.Time   `json:"previous_datetime"`
+	ChangedAt        time.Time   `json:"changed_at"`
+}
+
+// ChangeFrom compares a fresh copy of an event with the stored one and
+// returns the status of the change worth telling people about, or "" when
+// there is none
+func (e *Event) ChangeFrom(stored Event) EventStatus {
+	switch e.Status {
+	case EventCancelled, EventPostponed:
+		if stored.Status != e.Status {
+			return e.Status
+		}
+		return ""
+	}
+
+	if e.DateChangedFrom(stored) {
+		return EventRescheduled
+	}
+	if e.Status == EventRescheduled && stored.Status != EventRescheduled {
+		return EventRescheduled
+	}
+	return ""
+}
+
+// EventUpdate is a stored event that a write moved to another date or
+// venue, with the date and venue it had before
+type EventUpdate struct {
+	Event            Event     `json:"event"`
+	PreviousDateTime time.Time `json:"previous_datetime"`
+	PreviousVenue    Venue     `json:"previous_venue"`
+}
+
+// EventChangeSet is what a batch write did to the stored events: the ones
+// it stored for the first time, and the stored ones it gave a new date or
+// venue. An event both rescheduled and moved is in both lists.
+type EventChangeSet struct {
+	New          []Event       `json:"new"`
+	DateChanged  []EventUpdate `json:"date_changed"`
+	VenueChanged []EventUpdate `json:"venue_changed"`
+}
+
+// Empty reports whether the write changed nothing worth telling anyone
+func (c *EventChangeSet) Empty() bool {
+	return c == nil || len(c.New) == 0 && len(c.DateChanged) == 0 && len(c.VenueChanged) == 0
+}
+
+// FeedChangeKind is what happened to a stored event in the change feed
+type FeedChangeKind string
+
+const (
+	FeedCreate FeedChangeKind = "create"
+	FeedUpdate FeedChangeKind = "update"
+	FeedCancel FeedChangeKind = "cancel"
+	// FeedDelete is an event that left the store, deleted or expired
+	FeedDelete FeedChangeKind = "delete"
+)
+
+// EventFeedEntry is one change in the change feed. Cursor orders entries
+// and is what the next read starts after. Event is the event as it's
+// stored now, and nil once it's deleted.
+type EventFeedEntry struct {
+	Cursor    int64          `json:"cursor"`
+	Kind      FeedChangeKind `json:"kind"`
+	EventID   string         `json:"event_id"`
+	ChangedAt time.Time      `json:"changed_at"`
+	Event     *Event         `json:"event,omitempty"`
+}
+
+// DateChangedFrom reports whether the event's date differs from the stored
+// one's. Unknown dates don't count, nor does the same instant in another
+// zone.
+func (e *Event) DateChangedFrom(stored Event) bool {
+	return !e.DateTime.IsZero() && !stored.DateTime.IsZero() && !e.DateTime.Equal(stored.DateTime)
+}
+
+// VenueChangedFrom reports whether the event moved to another venue than
+// the stored one's. Venues are compared by ID when both have one, and
+// otherwise by name and city, ignoring case and accents; a venue whose name
+// is missing on either side doesn't count.
+func (e *Event) VenueChangedFrom(stored Event) bool {
+	if e.Venue.ID != "" && stored.Venue.ID != "" {
+		return e.Venue.ID != stored.Venue.ID
+	}
+	if strings.TrimSpace(e.Venue.Name) == "" || strings.TrimSpace(stored.Venue.Name) == "" {
+		return false
+	}
+	return foldName(e.Venue.Name) != foldName(stored.Venue.Name) || foldName(e.Venue.City) != foldName(stored.Venue.City)
+}
+
+// EventArtist is one act on an event's bill. Billing is the act's 1-based
+// position on it.
+type EventArtist struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name"`
+	Billing   int    `json:"billing"`
+	Headliner bool   `json:"headliner"`
+}
+
+// HeadlinerLineup is the lineup of an event billed to a single artist
+func HeadlinerLineup(artistID, artistName string) []EventArtist {
+	if strings.TrimSpace(artistName) == "" {
+		return nil
+	}
+	return []EventArtist{{ID: artistID, Name: artistName, Billing: 1, Headliner: true}}
+}
+
+// Performs reports whether the named artist is on the bill, as headliner or
+// support. Names match case-insensitively.
+func (e *Event) Performs(artistName string) bool {
+	artistName = strings.TrimSpace(artistName)
+	if strings.EqualFold(e.ArtistName, artistName) {
+		return true
+	}
+	for _, artist := range e.Lineup {
+		if strings.EqualFold(artist.Name, artistName) {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceRange is a band of ticket prices as a source reports it. Type tells
+// bands apart when a source has several, e.g. "standard" and "vip".
+type PriceRange struct {
+	Type     string  `json:"type,omitempty"`
+	Min      float64 `json:"min"`
+	Max      float64 `json:"max"`
+	Currency string  `json:"currency,omitempty"`
+}
+
+// TicketOffer is one source's link to buy tickets for an event. PriceRange
+// spans the source's price ranges in its first range's currency.
+type TicketOffer struct {
+	Source     string      `json:"source"`
+	URL        string      `json:"url"`
+	Status     string      `json:"status,omitempty"`
+	PriceRange *PriceRange `json:"price_range,omitempty"`
+}
+
+// TicketOffer is the event's own ticket link as an offer from source. ok is
+// false when the event has no link.
+func (e *Event) TicketOffer(source string) (offer TicketOffer, ok bool) {
+	if e.TicketURL == "" {
+		return TicketOffer{}, false
+	}
+
+	offer = TicketOffer{Source: source, URL: e.TicketURL, Status: e.TicketStatus}
+	for _, price := range e.PriceRanges {
+		switch {
+		case offer.PriceRange == nil:
+			offer.PriceRange = &PriceRange{Min: price.Min, Max: price.Max, Currency: price.Currency}
+		case price.Currency == offer.PriceRange.Currency:
+			offer.PriceRange.Min = min(offer.PriceRange.Min, price.Min)
+			offer.PriceRange.Max = max(offer.PriceRange.Max, price.Max)
+		}
+	}
+	return offer, true
+}
+
+// AddTicketOffers adds the offers whose links the event doesn't have yet
+func (e *Event) AddTicketOffers(offers []TicketOffer) {
+	for _, offer := range offers {
+		known := false
+		for _, have := range e.TicketOffers {
+			if have.URL == offer.URL {
+				known = true
+				break
+			}
+		}
+		if !known {
+			e.TicketOffers = append(e.TicketOffers, offer)
+		}
+	}
+}
+
+// PriceSnapshot is the price ranges an event had at one sync
+type PriceSnapshot struct {
+	RecordedAt time.Time    `json:"recorded_at"`
+	Ranges     []PriceRange `json:"ranges"`
+}
+
+// DiscoveryFilter narrows a listing of recently discovered events
+type DiscoveryFilter struct {
+	ArtistID   string
+	ArtistName string
+	City       string
+	// Since leaves out events discovered at or before it
+	Since *time.Time
+}
+
+// OnSaleFilter narrows a listing of events whose tickets go on sale
+// between From and To. Artist and city match the same way as in
+// DiscoveryFilter.
+type OnSaleFilter struct {
+	ArtistID   string
+	ArtistName string
+	City       string
+	From       time.Time
+	To         time.Time
+}
+
+// EventFilter selects stored events for bulk export. Artist matches either
+// the artist ID or name; zero fields don't filter.
+type EventFilter struct {
+	Artist string
+	City   string
+	From   *time.Time
+	To     *time.Time
+}
+
+// Bounds is a map viewport in degrees. West is greater than East when the
+// viewport crosses the antimeridian.
+type Bounds struct {
+	West  float64 `json:"west"`
+	South float64 `json:"south"`
+	East  float64 `json:"east"`
+	North float64 `json:"north"`
+}
+
+// ClusterFilter groups stored events within Bounds into square cells
+// CellSize degrees across, keeping PerCell of each cell's soonest events.
+// Events before From are left out.
+type ClusterFilter struct {
+	Bounds   Bounds
+	CellSize float64
+	From     time.Time
+	PerCell  int
+}
+
+// EventCluster is the events in one cell of a map grid. Latitude and
+// Longitude are the mean position of its events, and Events the soonest of
+// them.
+type EventCluster struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Count     int     `json:"count"`
+	Events    []Event `json:"events"`
 }
 
 type Venue struct {
@@ -28,11 +426,87 @@ type Venue struct {
 	Country   string  `json:"country"`
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
+
+	// CountryCode is Country's ISO 3166-1 alpha-2 code, when it's known
+	CountryCode string `json:"country_code,omitempty"`
+	// Capacity is how many people the venue holds, 0 when no source says
+	Capacity int `json:"capacity,omitempty"`
+	// The venue's street address, phone number and website, when a source
+	// lists them
+	Address string `json:"address,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Website string `json:"website,omitempty"`
+}
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// HasCoordinates reports whether the venue was given a position. Sources
+// that don't know it leave both at zero, which is in the Gulf of Guinea.
+func (v Venue) HasCoordinates() bool {
+	return v.Latitude != 0 || v.Longitude != 0
+}
+
+// DistanceKm is the great-circle distance from the venue to lat, lng
+func (v Venue) DistanceKm(lat, lng float64) float64 {
+	return DistanceKm(v.Latitude, v.Longitude, lat, lng)
+}
+
+// DistanceKm is the great-circle distance between two points in degrees,
+// by the haversine formula
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLng := toRad(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
 }
 
 type EventExternalIDs struct {
 	BandsintownID  string `json:"bandsintown_id,omitempty"`
 	TicketmasterID string `json:"ticketmaster_id,omitempty"`
+	SongkickID     string `json:"songkick_id,omitempty"`
+	EventbriteID   string `json:"eventbrite_id,omitempty"`
+	SetlistFMID    string `json:"setlistfm_id,omitempty"`
+}
+
+// For returns the ID the named source gave the event, or "" for a source
+// that doesn't identify events
+func (ids EventExternalIDs) For(source string) string {
+	switch source {
+	case "bandsintown":
+		return ids.BandsintownID
+	case "ticketmaster":
+		return ids.TicketmasterID
+	case "songkick":
+		return ids.SongkickID
+	case "eventbrite":
+		return ids.EventbriteID
+	case "setlistfm":
+		return ids.SetlistFMID
+	}
+	return ""
+}
+
+// Merge fills any IDs missing here from other, so duplicates of the same
+// event found by different sources keep every source's ID
+func (ids *EventExternalIDs) Merge(other EventExternalIDs) {
+	if ids.BandsintownID == "" {
+		ids.BandsintownID = other.BandsintownID
+	}
+	if ids.TicketmasterID == "" {
+		ids.TicketmasterID = other.TicketmasterID
+	}
+	if ids.SongkickID == "" {
+		ids.SongkickID = other.SongkickID
+	}
+	if ids.EventbriteID == "" {
+		ids.EventbriteID = other.EventbriteID
+	}
+	if ids.SetlistFMID == "" {
+		ids.SetlistFMID = other.SetlistFMID
+	}
 }
 
 type EventSearchRequest struct {
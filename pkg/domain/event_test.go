@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"slices"
 	"testing"
 	"time"
 )
@@ -150,3 +151,293 @@ func TestEvent(t *testing.T) {
 		}
 	})
 }
+
+func TestEventExternalIDs_Merge(t *testing.T) {
+	ids := EventExternalIDs{
+		SongkickID:     "sk-1",
+		TicketmasterID: "tm-1",
+	}
+
+	ids.Merge(EventExternalIDs{
+		TicketmasterID: "tm-2",
+		EventbriteID:   "eb-1",
+	})
+
+	if ids.SongkickID != "sk-1" {
+		t.Errorf("expected songkick ID to be kept, got %s", ids.SongkickID)
+	}
+	if ids.TicketmasterID != "tm-1" {
+		t.Errorf("expected existing ticketmaster ID to win, got %s", ids.TicketmasterID)
+	}
+	if ids.EventbriteID != "eb-1" {
+		t.Errorf("expected eventbrite ID to be filled, got %s", ids.EventbriteID)
+	}
+}
+
+func TestEventExternalIDs_For(t *testing.T) {
+	ids := EventExternalIDs{SongkickID: "sk-1", EventbriteID: "eb-1"}
+
+	if got := ids.For("songkick"); got != "sk-1" {
+		t.Errorf("expected sk-1, got %q", got)
+	}
+	if got := ids.For("ticketmaster"); got != "" {
+		t.Errorf("expected no ticketmaster ID, got %q", got)
+	}
+	if got := ids.For("spotify"); got != "" {
+		t.Errorf("expected no ID for a source without event IDs, got %q", got)
+	}
+}
+
+func TestEvent_AddSource(t *testing.T) {
+	event := Event{ID: "songkick_1", ExternalIDs: EventExternalIDs{SongkickID: "1"}}
+	event.AddSource("songkick")
+	event.AddSource("songkick")
+
+	// A copy sharing the sources isn't changed by the original gaining more
+	shared := event
+	other := Event{ID: "ra_9"}
+	other.AddSource("resident_advisor")
+	event.MergeSources(other)
+
+	if !slices.Equal(event.Sources, []string{"songkick", "resident_advisor"}) {
+		t.Errorf("expected songkick then resident_advisor, got %v", event.Sources)
+	}
+	if event.SourceIDs["songkick"] != "1" || event.SourceIDs["resident_advisor"] != "ra_9" {
+		t.Errorf("expected each source's ID, got %v", event.SourceIDs)
+	}
+	if len(shared.Sources) != 1 || len(shared.SourceIDs) != 1 {
+		t.Errorf("expected the copy to keep one source, got %v, %v", shared.Sources, shared.SourceIDs)
+	}
+}
+
+func TestEvent_Performs(t *testing.T) {
+	event := Event{
+		ArtistName: "Headliner",
+		Lineup: []EventArtist{
+			{Name: "Headliner", Billing: 1, Headliner: true},
+			{Name: "Support Act", Billing: 2},
+		},
+	}
+
+	if !event.Performs("headliner") {
+		t.Error("expected the headliner to perform")
+	}
+	if !event.Performs(" support act ") {
+		t.Error("expected the support act to perform")
+	}
+	if event.Performs("Someone Else") {
+		t.Error("expected an artist off the bill not to perform")
+	}
+
+	if lineup := HeadlinerLineup("a1", ""); lineup != nil {
+		t.Errorf("expected no lineup without an artist, got %+v", lineup)
+	}
+	lineup := HeadlinerLineup("a1", "Solo")
+	if len(lineup) != 1 || !lineup[0].Headliner || lineup[0].Billing != 1 {
+		t.Errorf("expected a single headliner, got %+v", lineup)
+	}
+}
+
+func TestEvent_ChangeFrom(t *testing.T) {
+	at := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	stored := Event{Status: EventScheduled, DateTime: at}
+
+	tests := []struct {
+		name  string
+		fresh Event
+		want  EventStatus
+	}{
+		{"unchanged", Event{Status: EventScheduled, DateTime: at}, ""},
+		{"cancelled", Event{Status: EventCancelled, DateTime: at}, EventCancelled},
+		{"postponed", Event{Status: EventPostponed, DateTime: at}, EventPostponed},
+		{"moved", Event{Status: EventScheduled, DateTime: at.Add(24 * time.Hour)}, EventRescheduled},
+		{"marked rescheduled", Event{Status: EventRescheduled, DateTime: at}, EventRescheduled},
+		{"unknown date", Event{Status: EventScheduled}, ""},
+		{"same time elsewhere", Event{Status: EventScheduled, DateTime: at.In(time.FixedZone("CEST", 2*3600))}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fresh.ChangeFrom(stored); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	cancelled := Event{Status: EventCancelled, DateTime: at}
+	if got := cancelled.ChangeFrom(cancelled); got != "" {
+		t.Errorf("expected an event cancelled twice to change once, got %q", got)
+	}
+}
+
+func TestEvent_VenueChangedFrom(t *testing.T) {
+	stored := Event{Venue: Venue{Name: "Columbiahalle", City: "Berlin"}}
+
+	tests := []struct {
+		name  string
+		fresh Venue
+		want  bool
+	}{
+		{"same venue", Venue{Name: "Columbiahalle", City: "Berlin"}, false},
+		{"spelt differently", Venue{Name: "COLUMBIAHALLE", City: "berlin"}, false},
+		{"another venue", Venue{Name: "Tempodrom", City: "Berlin"}, true},
+		{"another city", Venue{Name: "Columbiahalle", City: "Hamburg"}, true},
+		{"no venue name", Venue{City: "Berlin"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fresh := Event{Venue: tt.fresh}
+			if got := fresh.VenueChangedFrom(stored); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	withID := Event{Venue: Venue{ID: "v1", Name: "Columbia Halle"}}
+	if withID.VenueChangedFrom(Event{Venue: Venue{ID: "v1", Name: "Columbiahalle"}}) {
+		t.Error("expected venues with the same ID to match whatever their names")
+	}
+	if !withID.VenueChangedFrom(Event{Venue: Venue{ID: "v2", Name: "Columbia Halle"}}) {
+		t.Error("expected venues with different IDs to differ")
+	}
+}
+
+func TestParseLocalDateTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		timezone string
+		want     string
+	}{
+		{"summer time", "2026-07-01T20:00:00", "Europe/London", "2026-07-01T19:00:00Z"},
+		{"winter time", "2026-12-01T20:00:00", "Europe/London", "2026-12-01T20:00:00Z"},
+		{"evening the clocks went forward", "2026-03-08T20:00:00", "America/New_York", "2026-03-09T00:00:00Z"},
+		{"no timezone", "2026-07-01T20:00:00", "", "2026-07-01T20:00:00Z"},
+		{"unknown timezone", "2026-07-01T20:00:00", "Mars/Olympus_Mons", "2026-07-01T20:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLocalDateTime("2006-01-02T15:04:05", tt.value, tt.timezone)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if got.UTC().Format(time.RFC3339) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got.UTC().Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestEvent_LocalDateTime(t *testing.T) {
+	event := Event{DateTime: time.Date(2026, 7, 1, 19, 0, 0, 0, time.UTC), Timezone: "Europe/London"}
+	if got := event.LocalDateTime().Format(time.RFC3339); got != "2026-07-01T20:00:00+01:00" {
+		t.Errorf("expected the venue's local time with its offset, got %s", got)
+	}
+
+	event.Timezone = "Mars/Olympus_Mons"
+	if got := event.LocalDateTime().Format(time.RFC3339); got != "2026-07-01T19:00:00Z" {
+		t.Errorf("expected an unknown timezone to leave the time alone, got %s", got)
+	}
+}
+
+func TestEvent_DateKnown(t *testing.T) {
+	at := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		event Event
+		want  bool
+	}{
+		{"exact", Event{DateTime: at, DateConfidence: DateExact}, true},
+		{"day only", Event{DateTime: at, DateConfidence: DateOnly}, true},
+		{"stored before confidence was tracked", Event{DateTime: at}, true},
+		{"unknown", Event{DateConfidence: DateUnknown}, false},
+		{"zero date", Event{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.event.DateKnown(); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEvent_AgeRestriction(t *testing.T) {
+	tests := []struct {
+		restriction string
+		minimumAge  int
+		allAges     bool
+	}{
+		{"", 0, true},
+		{"All Ages", 0, true},
+		{"all ages welcome", 0, true},
+		{"0+", 0, true},
+		{"14+", 14, false},
+		{"Ages 18 and over", 18, false},
+		{"21+ with valid ID", 21, false},
+		{"Legal drinking age", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.restriction, func(t *testing.T) {
+			event := Event{AgeRestriction: tt.restriction}
+			if got := event.MinimumAge(); got != tt.minimumAge {
+				t.Errorf("expected minimum age %d, got %d", tt.minimumAge, got)
+			}
+			if got := event.AllAges(); got != tt.allAges {
+				t.Errorf("expected all ages %v, got %v", tt.allAges, got)
+			}
+		})
+	}
+}
+
+func TestVenue_DistanceKm(t *testing.T) {
+	// Brixton Academy to the Paradiso in Amsterdam is about 360 km
+	brixton := Venue{Latitude: 51.4652, Longitude: -0.1149}
+	if got := brixton.DistanceKm(52.3622, 4.8838); got < 350 || got > 370 {
+		t.Errorf("expected about 360 km, got %.1f", got)
+	}
+	if got := brixton.DistanceKm(brixton.Latitude, brixton.Longitude); got != 0 {
+		t.Errorf("expected 0 km to itself, got %.1f", got)
+	}
+
+	if !brixton.HasCoordinates() || (Venue{Name: "TBA"}).HasCoordinates() {
+		t.Error("expected only the venue with a position to have coordinates")
+	}
+}
+
+func TestEvent_TicketOffer(t *testing.T) {
+	if _, ok := (&Event{}).TicketOffer("songkick"); ok {
+		t.Error("expected no offer without a ticket link")
+	}
+
+	event := Event{
+		TicketURL:    "https://www.ticketmaster.de/event/1",
+		TicketStatus: "onsale",
+		PriceRanges: []PriceRange{
+			{Type: "standard", Min: 45, Max: 65, Currency: "EUR"},
+			{Type: "vip", Min: 120, Max: 150, Currency: "EUR"},
+			{Type: "standard", Min: 40, Max: 55, Currency: "GBP"},
+		},
+	}
+	offer, ok := event.TicketOffer("ticketmaster")
+	if !ok || offer.Source != "ticketmaster" || offer.URL != event.TicketURL || offer.Status != "onsale" {
+		t.Fatalf("unexpected offer %+v", offer)
+	}
+	if price := offer.PriceRange; price == nil || price.Min != 45 || price.Max != 150 || price.Currency != "EUR" {
+		t.Errorf("expected the euro ranges spanned, got %+v", price)
+	}
+
+	event.TicketOffers = []TicketOffer{offer}
+	event.AddTicketOffers([]TicketOffer{
+		{Source: "bandsintown", URL: "https://www.bandsintown.com/t/1"},
+		{Source: "songkick", URL: event.TicketURL},
+	})
+	if len(event.TicketOffers) != 2 || event.TicketOffers[1].Source != "bandsintown" {
+		t.Errorf("expected only the new link added, got %+v", event.TicketOffers)
+	}
+}
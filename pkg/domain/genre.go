@@ -0,0 +1,148 @@
+package domain
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Genre is a canonical genre, under its parent genre unless it's a root,
+// with the other spellings sources tag it with
+type Genre struct {
+	Name    string   `json:"name"`
+	Parent  string   `json:"parent,omitempty"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// GenreNode is a genre with its subgenres, for browsing the taxonomy
+type GenreNode struct {
+	Name     string      `json:"name"`
+	Aliases  []string    `json:"aliases,omitempty"`
+	Children []GenreNode `json:"children,omitempty"`
+}
+
+//go:embed data/genres.json
+var builtinGenres []byte
+
+var (
+	// genres lists the taxonomy in file order, parents before their
+	// subgenres
+	genres []*Genre
+	// genreKeys maps the key of every genre name and alias to its genre
+	genreKeys = map[string]*Genre{}
+)
+
+func init() {
+	if err := json.Unmarshal(builtinGenres, &genres); err != nil {
+		panic(fmt.Sprintf("invalid built-in genres: %v", err))
+	}
+
+	for _, genre := range genres {
+		if genre.Parent != "" {
+			parent := genreKeys[genreKey(genre.Parent)]
+			if parent == nil {
+				panic(fmt.Sprintf("genre %q comes before its parent %q", genre.Name, genre.Parent))
+			}
+			genre.Parent = parent.Name
+		}
+		for _, name := range append([]string{genre.Name}, genre.Aliases...) {
+			key := genreKey(name)
+			if known := genreKeys[key]; known != nil && known != genre {
+				panic(fmt.Sprintf("genre alias %q is taken by %q", name, known.Name))
+			}
+			genreKeys[key] = genre
+		}
+	}
+}
+
+// LookupGenre finds a genre by its name or any alias, ignoring case,
+// accents, punctuation, spacing and how "and" is written, so "Drum & Bass",
+// "drum'n'bass" and "dnb" all find drum and bass
+func LookupGenre(name string) (Genre, bool) {
+	genre := genreKeys[genreKey(name)]
+	if genre == nil {
+		return Genre{}, false
+	}
+	found := *genre
+	found.Aliases = slices.Clone(genre.Aliases)
+	return found, true
+}
+
+// NormalizeGenre returns the canonical name of a genre in the taxonomy.
+// Genres outside it come back trimmed and lowercased, so the many niche
+// genres sources tag with still group together.
+func NormalizeGenre(name string) string {
+	if genre, ok := LookupGenre(name); ok {
+		return genre.Name
+	}
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// NormalizeGenres normalizes every genre, dropping empty ones and the
+// duplicates aliases turn into, in the order they came
+func NormalizeGenres(names []string) []string {
+	var normalized []string
+	for _, name := range names {
+		genre := NormalizeGenre(name)
+		if genre != "" && !slices.Contains(normalized, genre) {
+			normalized = append(normalized, genre)
+		}
+	}
+	return normalized
+}
+
+// GenreAncestors lists the genre's parents up to its root, closest first:
+// deep house is house, then electronic. Genres outside the taxonomy and
+// roots have none.
+func GenreAncestors(name string) []string {
+	var ancestors []string
+	genre := genreKeys[genreKey(name)]
+	for genre != nil && genre.Parent != "" {
+		ancestors = append(ancestors, genre.Parent)
+		genre = genreKeys[genreKey(genre.Parent)]
+	}
+	return ancestors
+}
+
+// GenreTree returns the taxonomy as trees from its root genres, in the order
+// the taxonomy lists them
+func GenreTree() []GenreNode {
+	children := make(map[string][]*Genre)
+	var roots []*Genre
+	for _, genre := range genres {
+		if genre.Parent == "" {
+			roots = append(roots, genre)
+			continue
+		}
+		children[genre.Parent] = append(children[genre.Parent], genre)
+	}
+
+	var build func(genre *Genre) GenreNode
+	build = func(genre *Genre) GenreNode {
+		node := GenreNode{Name: genre.Name, Aliases: slices.Clone(genre.Aliases)}
+		for _, child := range children[genre.Name] {
+			node.Children = append(node.Children, build(child))
+		}
+		return node
+	}
+
+	tree := make([]GenreNode, len(roots))
+	for i, root := range roots {
+		tree[i] = build(root)
+	}
+	return tree
+}
+
+// genreKey folds a genre name like foldName, then reads "&" and a lone "n"
+// as "and" and drops the spaces, so "hip-hop", "Hip Hop" and "hiphop" match
+func genreKey(name string) string {
+	words := strings.Fields(foldName(strings.ReplaceAll(name, "&", " and ")))
+	for i, word := range words {
+		if word == "n" {
+			words[i] = "and"
+		}
+	}
+	return strings.Join(words, "")
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeGenre(t *testing.T) {
+	tests := map[string]string{
+		"dnb":            "drum and bass",
+		"Drum & Bass":    "drum and bass",
+		"drum and bass":  "drum and bass",
+		"Drum'n'Bass":    "drum and bass",
+		"D&B":            "drum and bass",
+		"Hip-Hop":        "hip hop",
+		"hiphop":         "hip hop",
+		"rap":            "hip hop",
+		"RnB":            "r&b",
+		"Rhythm & Blues": "r&b",
+		"Deep House":     "deep house",
+		"Electronica":    "electronic",
+		"reggaetón":      "reggaeton",
+		"  Float House ": "float house",
+		"":               "",
+	}
+
+	for input, want := range tests {
+		if got := NormalizeGenre(input); got != want {
+			t.Errorf("NormalizeGenre(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestNormalizeGenres(t *testing.T) {
+	got := NormalizeGenres([]string{"Hip Hop", "rap", " ", "Trap", "hip-hop"})
+	if want := []string{"hip hop", "trap"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := NormalizeGenres(nil); got != nil {
+		t.Errorf("expected no genres, got %v", got)
+	}
+}
+
+func TestGenreAncestors(t *testing.T) {
+	if got, want := GenreAncestors("deep house"), []string{"house", "electronic"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := GenreAncestors("electronic"); len(got) != 0 {
+		t.Errorf("expected a root to have no ancestors, got %v", got)
+	}
+	if got := GenreAncestors("float house"); len(got) != 0 {
+		t.Errorf("expected an unknown genre to have no ancestors, got %v", got)
+	}
+}
+
+func TestGenreTree(t *testing.T) {
+	tree := GenreTree()
+	if len(tree) == 0 || tree[0].Name != "electronic" {
+		t.Fatalf("expected electronic first, got %+v", tree)
+	}
+
+	// Every genre appears once, under its parent
+	seen := map[string]bool{}
+	var walk func(nodes []GenreNode, parent string)
+	walk = func(nodes []GenreNode, parent string) {
+		for _, node := range nodes {
+			if seen[node.Name] {
+				t.Errorf("genre %q appears twice", node.Name)
+			}
+			seen[node.Name] = true
+			genre, ok := LookupGenre(node.Name)
+			if !ok || genre.Parent != parent {
+				t.Errorf("expected %q under %q, got %+v", node.Name, parent, genre)
+			}
+			walk(node.Children, node.Name)
+		}
+	}
+	walk(tree, "")
+	if len(seen) != len(genres) {
+		t.Errorf("expected all %d genres in the tree, got %d", len(genres), len(seen))
+	}
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// PastConcert is a show an artist has already played, with what they played
+// when the source knows it
+type PastConcert struct {
+	Event
+	Source string        `json:"source"`
+	Tour   string        `json:"tour,omitempty"`
+	Songs  []SetlistSong `json:"songs,omitempty"`
+	URL    string        `json:"url,omitempty"`
+}
+
+// SetlistSong is one song of a setlist, in the order it was played
+type SetlistSong struct {
+	Name       string `json:"name"`
+	SetName    string `json:"set_name"`
+	IsEncore   bool   `json:"is_encore"`
+	Info       string `json:"info,omitempty"`
+	IsTape     bool   `json:"is_tape"`
+	WithArtist string `json:"with_artist,omitempty"`
+	CoverOf    string `json:"cover_of,omitempty"`
+	PreviewURL string `json:"preview_url,omitempty"`
+}
+
+// ConcertHistoryPage is one page of an artist's past concerts from a
+// single source, newest first
+type ConcertHistoryPage struct {
+	Concerts []PastConcert `json:"concerts"`
+	Page     int           `json:"page"`
+	Total    int           `json:"total"`
+	HasMore  bool          `json:"has_more"`
+}
+
+// BackfillCheckpoint is how far a backfill got through one artist's history
+// at one source, so an interrupted backfill resumes where it stopped
+type BackfillCheckpoint struct {
+	Artist string `json:"artist"`
+	Source string `json:"source"`
+	// Page is the last page whose concerts were all stored; pages run newest
+	// first
+	Page int `json:"page"`
+	// OldestConcert is the date of the oldest concert seen so far
+	OldestConcert *time.Time `json:"oldest_concert,omitempty"`
+	// Complete is set once the source had no more pages
+	Complete  bool      `json:"complete"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JobStatus string
+
+const (
+	// JobQueued is waiting for a worker, for the first time or for a retry
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	// JobDead failed every attempt and won't be retried
+	JobDead JobStatus = "dead"
+)
+
+// Job is a unit of slow work, like enriching an artist, run by a worker
+// outside the request that asked for it
+type Job struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+	// Key makes a job unique while it's queued or running, so asking for the
+	// same work twice returns the job already doing it
+	Key         string          `json:"-"`
+	Payload     json.RawMessage `json:"-"`
+	Status      JobStatus       `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	// RunAt is when a queued job is due, later than CreatedAt for a retry
+	RunAt      time.Time  `json:"run_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Done reports whether the job won't run again
+func (j *Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobDead
+}
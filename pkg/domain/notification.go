@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+// DigestFrequency is how often a user gets an email digest of new events
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestDaily  DigestFrequency = "daily"
+	DigestWeekly DigestFrequency = "weekly"
+)
+
+// Period is the time a digest covers, zero when digests are off
+func (f DigestFrequency) Period() time.Duration {
+	switch f {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+func (f DigestFrequency) Valid() bool {
+	return f == DigestOff || f == DigestDaily || f == DigestWeekly
+}
+
+// NotificationPreferences hold a user's digest settings. The unsubscribe
+// token lets a digest link turn digests off without logging in.
+type NotificationPreferences struct {
+	UserID           string          `json:"-"`
+	DigestFrequency  DigestFrequency `json:"digest_frequency"`
+	UnsubscribeToken string          `json:"-"`
+	LastDigestAt     *time.Time      `json:"last_digest_at,omitempty"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+}
+
+// TelegramLink connects a user to the Telegram chat their alerts are
+// pushed to
+type TelegramLink struct {
+	UserID      string     `json:"-"`
+	ChatID      int64      `json:"chat_id"`
+	LinkedAt    time.Time  `json:"linked_at"`
+	LastAlertAt *time.Time `json:"last_alert_at,omitempty"`
+}
+
+// OnSaleSubscription turns on alerts shortly before tickets go on sale for
+// events matching a user's follows and saved searches. Alerts go out by
+// email, to a linked Telegram chat and, when set, to WebhookURL.
+type OnSaleSubscription struct {
+	UserID     string    `json:"-"`
+	WebhookURL string    `json:"webhook_url,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
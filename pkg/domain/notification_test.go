@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDigestFrequency(t *testing.T) {
+	tests := []struct {
+		frequency DigestFrequency
+		valid     bool
+		period    time.Duration
+	}{
+		{DigestOff, true, 0},
+		{DigestDaily, true, 24 * time.Hour},
+		{DigestWeekly, true, 7 * 24 * time.Hour},
+		{"monthly", false, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.frequency.Valid(); got != tt.valid {
+			t.Errorf("%q.Valid() = %v, want %v", tt.frequency, got, tt.valid)
+		}
+		if got := tt.frequency.Period(); got != tt.period {
+			t.Errorf("%q.Period() = %v, want %v", tt.frequency, got, tt.period)
+		}
+	}
+}
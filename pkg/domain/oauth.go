@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// OAuthToken is a user-granted token for a third-party account, connected
+// by the user with UserID
+type OAuthToken struct {
+	Provider     string    `json:"provider"`
+	AccountID    string    `json:"account_id"`
+	UserID       string    `json:"-"`
+	AccessToken  string    `json:"-"`
+	RefreshToken string    `json:"-"`
+	Scope        string    `json:"scope,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Expired reports whether the access token expires within the given margin
+func (t *OAuthToken) Expired(now time.Time, margin time.Duration) bool {
+	return !now.Add(margin).Before(t.ExpiresAt)
+}
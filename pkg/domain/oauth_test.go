@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOAuthToken_Expired(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	token := &OAuthToken{ExpiresAt: now.Add(10 * time.Minute)}
+
+	if token.Expired(now, time.Minute) {
+		t.Error("expected token to be valid 10 minutes before expiry")
+	}
+	if !token.Expired(now, 10*time.Minute) {
+		t.Error("expected token to be expired within the margin")
+	}
+	if !token.Expired(now.Add(time.Hour), 0) {
+		t.Error("expected token to be expired after ExpiresAt")
+	}
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// ArtistProfile is what the music sources know about one artist beyond the
+// basics stored with it, gathered in one go for artist detail pages
+type ArtistProfile struct {
+	Artist    Artist    `json:"artist"`
+	Albums    []Album   `json:"albums"`
+	Releases  []Release `json:"releases"`
+	Tracks    []Track   `json:"tracks"`
+	Videos    []Track   `json:"videos"`
+	Sources   []string  `json:"sources"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// Album is an album as a streaming service lists it
+type Album struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	ArtistName  string   `json:"artist_name,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+	TrackCount  int      `json:"track_count,omitempty"`
+	Genres      []string `json:"genres,omitempty"`
+	ImageURL    string   `json:"image_url,omitempty"`
+	Label       string   `json:"label,omitempty"`
+	Source      string   `json:"source"`
+}
+
+// Release is one edition of a record in a discography database. The same
+// album can have several, one per country or format.
+type Release struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Date    string `json:"date,omitempty"`
+	Country string `json:"country,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Source  string `json:"source"`
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// SourceQuota reports how much of a source's request budget is left in the current window
+type SourceQuota struct {
+	Source    string     `json:"source"`
+	Limit     int        `json:"limit"`
+	Used      int        `json:"used"`
+	Remaining int        `json:"remaining"`
+	Window    string     `json:"window"`
+	ResetsAt  *time.Time `json:"resets_at,omitempty"`
+}
+
+// SourceRequest is a request made to a source, and how much of its budget
+// the request cost
+type SourceRequest struct {
+	RequestedAt time.Time
+	Cost        int
+}
@@ -14,6 +14,19 @@ type ArtistRepository interface {
 	Delete(ctx context.Context, id string) error
 }
 
+// ArtistListRepository walks every cached artist, for indexes kept in memory
+type ArtistListRepository interface {
+	// Each calls fn for every artist, most popular first; an error from fn
+	// stops the iteration and is returned
+	Each(ctx context.Context, fn func(Artist) error) error
+}
+
+// ArtistProfileRepository caches enriched artist profiles by artist ID
+type ArtistProfileRepository interface {
+	Get(ctx context.Context, artistID string) (*ArtistProfile, error)
+	Save(ctx context.Context, profile *ArtistProfile) error
+}
+
 type ArtistService interface {
 	SearchArtists(ctx context.Context, query string, limit int) (*ArtistSearchResponse, error)
 	GetArtist(ctx context.Context, id string) (*Artist, error)
@@ -22,18 +35,270 @@ type ArtistService interface {
 
 type EventRepository interface {
 	Create(ctx context.Context, event *Event) error
-	CreateBatch(ctx context.Context, events []Event) error
+	// CreateBatch stores new events and updates stored ones, keeping when
+	// each was first stored, and returns what the write changed
+	CreateBatch(ctx context.Context, events []Event) (*EventChangeSet, error)
 	GetByID(ctx context.Context, id string) (*Event, error)
 	GetByExternalID(ctx context.Context, externalID string, source string) (*Event, error)
 	SearchByArtist(ctx context.Context, artistID string, startDate, endDate *time.Time) ([]Event, error)
+	SearchByArtistName(ctx context.Context, artistName string, startDate, endDate *time.Time) ([]Event, error)
 	SearchByLocation(ctx context.Context, lat, lng float64, radius int, startDate, endDate *time.Time) ([]Event, error)
+	ListDiscovered(ctx context.Context, filter DiscoveryFilter, limit int) ([]Event, error)
+	// Each calls fn for every matching event in date order without loading
+	// them all at once; an error from fn stops the iteration and is returned
+	Each(ctx context.Context, filter EventFilter, fn func(Event) error) error
 	Update(ctx context.Context, event *Event) error
 	Delete(ctx context.Context, id string) error
 	DeleteExpiredCache(ctx context.Context) error
 }
 
+// EventPurgeRepository removes events whose cache window has passed
+type EventPurgeRepository interface {
+	// PurgeExpired returns how many events it removed
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// EventArchiveRepository lists the events that left the hot table, whether
+// their cache window passed or they were deleted
+type EventArchiveRepository interface {
+	// ListArchived returns matching archived events latest date first
+	ListArchived(ctx context.Context, filter EventFilter, limit int) ([]Event, error)
+}
+
+// VenueRepository keeps the venues stored events are at, their details
+// merged from every source that listed them
+type VenueRepository interface {
+	GetVenue(ctx context.Context, slug string) (*StoredVenue, error)
+	// ListVenues returns matching venues with the most upcoming events
+	// first
+	ListVenues(ctx context.Context, filter VenueFilter, limit int) ([]StoredVenue, error)
+	// ListVenueEvents returns the venue's stored events from from on,
+	// soonest first
+	ListVenueEvents(ctx context.Context, slug string, from time.Time, limit int) ([]Event, error)
+}
+
+// VenueMergeRepository finds venues stored twice under different names and
+// merges them
+type VenueMergeRepository interface {
+	// FindDuplicateVenues returns probable duplicate pairs, the most alike
+	// first
+	FindDuplicateVenues(ctx context.Context, filter VenueDuplicateFilter, limit int) ([]VenueDuplicate, error)
+	// MergeVenues moves the events of the venue with slug from to the one
+	// with slug into, fills into's missing details from it and records the
+	// merge. It returns ErrVenueNotFound when either is missing.
+	MergeVenues(ctx context.Context, from, into string) (*VenueMerge, error)
+	// ListVenueMerges returns past merges, newest first
+	ListVenueMerges(ctx context.Context, limit int) ([]VenueMerge, error)
+}
+
+// LocalSearchRepository searches the cached artists and events without
+// calling any source
+type LocalSearchRepository interface {
+	// SearchLocal ranks artists and events matching every word of query,
+	// treating each word as a prefix. An empty hitType searches both.
+	SearchLocal(ctx context.Context, query string, hitType SearchHitType, limit int) ([]SearchHit, error)
+}
+
+// EventOnSaleRepository finds events by when their tickets go on sale
+type EventOnSaleRepository interface {
+	// ListUpcomingOnSales returns matching events soonest on-sale first
+	ListUpcomingOnSales(ctx context.Context, filter OnSaleFilter, limit int) ([]Event, error)
+}
+
+// EventChangeRepository lists the cancellations, postponements and date
+// changes syncs have found in stored events
+type EventChangeRepository interface {
+	// ListChanges returns changes to matching events newest first. Since
+	// in the filter leaves out changes found at or before it.
+	ListChanges(ctx context.Context, filter DiscoveryFilter, limit int) ([]EventChange, error)
+}
+
+// EventFeedRepository keeps an ordered log of the creates, updates,
+// cancellations and deletes of stored events, for mirroring them
+type EventFeedRepository interface {
+	// ListFeed returns up to limit entries after the cursor since, oldest
+	// first; since 0 starts at the oldest entry kept. It returns
+	// ErrCursorExpired when entries after since were already pruned.
+	ListFeed(ctx context.Context, since int64, limit int) ([]EventFeedEntry, error)
+}
+
+// EventMapRepository clusters stored events for map views
+type EventMapRepository interface {
+	// ClusterEvents returns the filter's non-empty cells, largest first
+	ClusterEvents(ctx context.Context, filter ClusterFilter) ([]EventCluster, error)
+}
+
+// EventPriceRepository keeps the price ranges events had at each sync
+type EventPriceRepository interface {
+	// GetPriceHistory returns an event's snapshots oldest first
+	GetPriceHistory(ctx context.Context, eventID string) ([]PriceSnapshot, error)
+}
+
 type EventService interface {
 	SearchArtistEvents(ctx context.Context, artistName string, location string, radius int) (*EventSearchResponse, error)
 	GetArtistEvents(ctx context.Context, artistID string) (*EventSearchResponse, error)
 	GetEvent(ctx context.Context, id string) (*Event, error)
 }
+
+type QuotaRepository interface {
+	RecordRequest(ctx context.Context, source string, requestedAt time.Time, cost int) error
+	GetRequestsSince(ctx context.Context, source string, since time.Time) ([]SourceRequest, error)
+	DeleteBefore(ctx context.Context, before time.Time) error
+}
+
+// SourceSettingsRepository keeps operators' source overrides across restarts
+type SourceSettingsRepository interface {
+	Get(ctx context.Context, source string) (*SourceSetting, error)
+	Save(ctx context.Context, setting *SourceSetting) error
+	List(ctx context.Context) ([]SourceSetting, error)
+}
+
+// StatsRepository reports on the database for operators
+type StatsRepository interface {
+	DatabaseStats(ctx context.Context) (*DatabaseStats, error)
+}
+
+// CityStatsRepository summarizes a city's stored events
+type CityStatsRepository interface {
+	CityOverview(ctx context.Context, filter CityOverviewFilter) (*CityOverview, error)
+}
+
+// AnalyticsRepository aggregates stored events over all time, `limit` cities
+// or genres ranked
+type AnalyticsRepository interface {
+	ArtistStats(ctx context.Context, artistID string, limit int) (*ArtistStats, error)
+	CityStats(ctx context.Context, city string, limit int) (*CityStats, error)
+}
+
+type OAuthTokenRepository interface {
+	Save(ctx context.Context, token *OAuthToken) error
+	Get(ctx context.Context, provider, accountID string) (*OAuthToken, error)
+	// GetForUser returns the provider account the user connected last
+	GetForUser(ctx context.Context, provider, userID string) (*OAuthToken, error)
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *User) error
+	GetByID(ctx context.Context, id string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+}
+
+type FollowRepository interface {
+	Follow(ctx context.Context, follow *Follow) error
+	Unfollow(ctx context.Context, userID, artistName string) error
+	ListFollows(ctx context.Context, userID string) ([]Follow, error)
+	// ListAllFollows returns every user's follows, for jobs working through
+	// followed artists
+	ListAllFollows(ctx context.Context) ([]Follow, error)
+}
+
+type SavedSearchRepository interface {
+	Create(ctx context.Context, search *SavedSearch) error
+	Delete(ctx context.Context, userID, id string) error
+	List(ctx context.Context, userID string) ([]SavedSearch, error)
+}
+
+type NotificationPreferencesRepository interface {
+	Get(ctx context.Context, userID string) (*NotificationPreferences, error)
+	GetByUnsubscribeToken(ctx context.Context, token string) (*NotificationPreferences, error)
+	Save(ctx context.Context, prefs *NotificationPreferences) error
+	// ListDueDigests returns users whose digest period has passed since the
+	// last one was sent
+	ListDueDigests(ctx context.Context, now time.Time) ([]NotificationPreferences, error)
+	MarkDigestSent(ctx context.Context, userID string, sentAt time.Time) error
+}
+
+type TelegramLinkRepository interface {
+	CreateLinkCode(ctx context.Context, userID, code string, expiresAt time.Time) error
+	// ConsumeLinkCode returns the user a code was issued to and invalidates it
+	ConsumeLinkCode(ctx context.Context, code string, now time.Time) (string, error)
+	Link(ctx context.Context, userID string, chatID int64) error
+	Get(ctx context.Context, userID string) (*TelegramLink, error)
+	Unlink(ctx context.Context, userID string) error
+	UnlinkChat(ctx context.Context, chatID int64) error
+	ListLinks(ctx context.Context) ([]TelegramLink, error)
+	MarkAlerted(ctx context.Context, userID string, alertedAt time.Time) error
+}
+
+type OnSaleAlertRepository interface {
+	Subscribe(ctx context.Context, sub *OnSaleSubscription) error
+	GetSubscription(ctx context.Context, userID string) (*OnSaleSubscription, error)
+	Unsubscribe(ctx context.Context, userID string) error
+	ListSubscriptions(ctx context.Context) ([]OnSaleSubscription, error)
+	// HasAlerted reports whether the user was already told about the event
+	HasAlerted(ctx context.Context, userID, eventID string) (bool, error)
+	MarkAlerted(ctx context.Context, userID, eventID string, alertedAt time.Time) error
+}
+
+// ReleaseAlertRepository remembers which new albums users were told about
+type ReleaseAlertRepository interface {
+	HasAlerted(ctx context.Context, userID, albumID string) (bool, error)
+	MarkAlerted(ctx context.Context, userID, albumID string, alertedAt time.Time) error
+}
+
+type TrackedArtistRepository interface {
+	Track(ctx context.Context, artistID, source string) error
+	ListDueForSync(ctx context.Context, syncedBefore time.Time, limit int) ([]TrackedArtist, error)
+	MarkSynced(ctx context.Context, artistID string, syncedAt time.Time) error
+}
+
+// LeaseRepository hands out named leases, so that of several instances only
+// one runs a scheduled job. A lease its holder stops renewing can be taken
+// over once it expires.
+type LeaseRepository interface {
+	// Acquire takes the lease for holder, or renews it when holder already
+	// has it, until ttl from now. It reports false while another holder's
+	// lease hasn't expired.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// Release gives the lease up early; it's a no-op unless holder has it
+	Release(ctx context.Context, name, holder string) error
+}
+
+// JobRepository is a persistent queue of jobs. Workers claim jobs for a
+// lease, so a job whose worker died is claimed again once the lease expires.
+type JobRepository interface {
+	// Enqueue stores a queued job, unless one with the same key is queued or
+	// running, in which case that job is returned instead
+	Enqueue(ctx context.Context, job *Job) (*Job, error)
+	Get(ctx context.Context, id string) (*Job, error)
+	// Claim marks the next due job running until lease from now and counts
+	// the attempt. It returns nil when no job is due.
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (*Job, error)
+	Complete(ctx context.Context, id string, finishedAt time.Time) error
+	// Retry queues a failed job again for runAt
+	Retry(ctx context.Context, id, message string, runAt time.Time) error
+	// Bury gives up on a job, leaving it dead with message as its last error
+	Bury(ctx context.Context, id, message string, finishedAt time.Time) error
+	// DeleteFinishedBefore drops succeeded and dead jobs finished before the
+	// given time
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PastConcertRepository archives concerts artists have played, kept apart
+// from events so they're never expired with the cache
+type PastConcertRepository interface {
+	// SaveBatch stores concerts, replacing any stored before under the same
+	// source and ID
+	SaveBatch(ctx context.Context, concerts []PastConcert) error
+	// ListByArtist returns the artist's archived concerts, newest first
+	ListByArtist(ctx context.Context, artistName string) ([]PastConcert, error)
+}
+
+// ArtistIdentityRepository keeps the MBIDs artist names resolved to. Names
+// match however they're capitalized.
+type ArtistIdentityRepository interface {
+	GetIdentity(ctx context.Context, name string) (*ArtistIdentity, error)
+	SaveIdentity(ctx context.Context, identity *ArtistIdentity) error
+}
+
+// ArtistImageRepository keeps the pictures artist names resolved to. Names
+// match however they're capitalized.
+type ArtistImageRepository interface {
+	GetImage(ctx context.Context, name string) (*ArtistImage, error)
+	SaveImage(ctx context.Context, image *ArtistImage) error
+}
+
+type BackfillCheckpointRepository interface {
+	Get(ctx context.Context, artist, source string) (*BackfillCheckpoint, error)
+	Save(ctx context.Context, checkpoint *BackfillCheckpoint) error
+}
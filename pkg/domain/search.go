@@ -0,0 +1,32 @@
+package domain
+
+// SearchHitType says whether a local search hit is an artist or an event
+type SearchHitType string
+
+const (
+	SearchHitArtist SearchHitType = "artist"
+	SearchHitEvent  SearchHitType = "event"
+)
+
+func (t SearchHitType) Valid() bool {
+	return t == SearchHitArtist || t == SearchHitEvent
+}
+
+// SearchHit is one match from the local full-text index, best first by
+// Score. Artist or Event is set depending on Type.
+type SearchHit struct {
+	Type   SearchHitType `json:"type"`
+	Score  float64       `json:"score"`
+	Artist *Artist       `json:"artist,omitempty"`
+	Event  *Event        `json:"event,omitempty"`
+}
+
+// Suggestion is an artist name completing what someone is typing, from the
+// local index or, when Source names one, a music source's quick search
+type Suggestion struct {
+	Name       string `json:"name"`
+	ArtistID   string `json:"artist_id,omitempty"`
+	ImageURL   string `json:"image_url,omitempty"`
+	Popularity int    `json:"popularity,omitempty"`
+	Source     string `json:"source,omitempty"`
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// SourceSetting is an operator's runtime override for one source. Weight
+// replaces the source's configured ranking weight when set, and the limits
+// its configured timeout, result budget and concurrency when above zero.
+type SourceSetting struct {
+	Source        string    `json:"source"`
+	Enabled       bool      `json:"enabled"`
+	Weight        *float64  `json:"weight,omitempty"`
+	TimeoutMS     int       `json:"timeout_ms,omitempty"`
+	MaxResults    int       `json:"max_results,omitempty"`
+	MaxConcurrent int       `json:"max_concurrent,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,113 @@
+package domain
+
+import "time"
+
+// DatabaseStats is how much the database holds: the rows in each table and
+// the size of the database file
+type DatabaseStats struct {
+	Tables    map[string]int64 `json:"tables"`
+	SizeBytes int64            `json:"size_bytes"`
+}
+
+// CityOverviewFilter asks for a city's stored events in the Weeks weeks
+// from From, with Limit venues and artists ranked
+type CityOverviewFilter struct {
+	City  string
+	From  time.Time
+	Weeks int
+	Limit int
+}
+
+// CityOverview is what's coming up in a city, from stored events only
+type CityOverview struct {
+	City        string `json:"city"`
+	TotalEvents int    `json:"total_events"`
+	// Weeks counts events in each week from the filter's From, empty weeks
+	// included
+	Weeks           []WeekCount      `json:"weeks"`
+	TopVenues       []VenueCount     `json:"top_venues"`
+	TrendingArtists []TrendingArtist `json:"trending_artists"`
+}
+
+// WeekCount is how many events start in the week from Start
+type WeekCount struct {
+	Start  time.Time `json:"start"`
+	Events int       `json:"events"`
+}
+
+// VenueCount is a venue and how many upcoming events it has
+type VenueCount struct {
+	ID     string `json:"id,omitempty"`
+	Name   string `json:"name"`
+	Events int    `json:"events"`
+}
+
+// TrendingArtist is an artist headlining upcoming events in a city.
+// Popularity is the highest any source gave the artist, 0 when the artist
+// isn't stored.
+type TrendingArtist struct {
+	ArtistID   string `json:"artist_id,omitempty"`
+	Name       string `json:"name"`
+	Events     int    `json:"events"`
+	Popularity int    `json:"popularity"`
+}
+
+// ArtistStats sums up an artist's stored shows, upcoming and past
+type ArtistStats struct {
+	ArtistID    string `json:"artist_id"`
+	Name        string `json:"name"`
+	TotalEvents int    `json:"total_events"`
+	// EventsPerYear runs from the first year with a show to the last, empty
+	// years included
+	EventsPerYear []YearCount `json:"events_per_year"`
+	TopCities     []CityCount `json:"top_cities"`
+	// VenueSizeTrend covers the years with shows at venues a source gave the
+	// capacity of
+	VenueSizeTrend []VenueSizeYear `json:"venue_size_trend"`
+}
+
+// YearCount is how many shows there were in Year
+type YearCount struct {
+	Year   int `json:"year"`
+	Events int `json:"events"`
+}
+
+// CityCount is a city and how many shows were there
+type CityCount struct {
+	City    string `json:"city"`
+	Country string `json:"country,omitempty"`
+	Events  int    `json:"events"`
+}
+
+// VenueSizeYear is how big the venues an artist played in Year were, over
+// the Events whose venue capacity is known
+type VenueSizeYear struct {
+	Year            int `json:"year"`
+	Events          int `json:"events"`
+	AverageCapacity int `json:"average_capacity"`
+	LargestCapacity int `json:"largest_capacity"`
+}
+
+// CityStats sums up every stored event in a city, past and upcoming
+type CityStats struct {
+	City        string `json:"city"`
+	TotalEvents int    `json:"total_events"`
+	// EventsPerMonth runs from the first month with an event to the last,
+	// empty months included
+	EventsPerMonth []MonthCount `json:"events_per_month"`
+	// Genres counts events by the genres stored for their headliner; an
+	// event counts once under each of them
+	Genres []GenreCount `json:"genres"`
+}
+
+// MonthCount is how many events there were in Month, like "2026-10"
+type MonthCount struct {
+	Month  string `json:"month"`
+	Events int    `json:"events"`
+}
+
+// GenreCount is a genre and how many events it had
+type GenreCount struct {
+	Genre  string `json:"genre"`
+	Events int    `json:"events"`
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"sort"
+	"time"
+)
+
+// tourGap is the longest run of days between shows that still counts as one
+// tour. A one-off festival slot months from the last show isn't a tour.
+const tourGap = 30 * 24 * time.Hour
+
+// TouringSummary is where an artist stands between shows: whether they're
+// on tour, what's coming and the last show they played
+type TouringSummary struct {
+	ArtistID      string `json:"artist_id"`
+	ArtistName    string `json:"artist_name"`
+	OnTour        bool   `json:"on_tour"`
+	UpcomingDates int    `json:"upcoming_dates"`
+	// Countries are the upcoming shows' countries, by English name
+	Countries []string     `json:"countries"`
+	NextShow  *Event       `json:"next_show,omitempty"`
+	LastShow  *PastConcert `json:"last_show,omitempty"`
+}
+
+// SummarizeTouring sums up an artist's upcoming events and past concerts as
+// of now. Cancelled and undated events are left out. The artist is on tour
+// when the next show is under 30 days away and another show, played or
+// coming, is under 30 days from it.
+func SummarizeTouring(artist Artist, events []Event, history []PastConcert, now time.Time) TouringSummary {
+	summary := TouringSummary{
+		ArtistID:   artist.ID,
+		ArtistName: artist.Name,
+		Countries:  []string{},
+	}
+
+	var upcoming []Event
+	for _, event := range events {
+		if event.DateKnown() && event.DateTime.After(now) && event.Status != EventCancelled {
+			upcoming = append(upcoming, event)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].DateTime.Before(upcoming[j].DateTime)
+	})
+
+	seen := make(map[string]bool)
+	for _, event := range upcoming {
+		country := CountryName(event.Venue.Country)
+		if country != "" && !seen[country] {
+			seen[country] = true
+			summary.Countries = append(summary.Countries, country)
+		}
+	}
+	sort.Strings(summary.Countries)
+	summary.UpcomingDates = len(upcoming)
+
+	for _, concert := range history {
+		if !concert.DateKnown() || concert.DateTime.After(now) {
+			continue
+		}
+		if summary.LastShow == nil || concert.DateTime.After(summary.LastShow.DateTime) {
+			last := concert
+			summary.LastShow = &last
+		}
+	}
+
+	if len(upcoming) == 0 {
+		return summary
+	}
+	next := upcoming[0]
+	summary.NextShow = &next
+
+	if next.DateTime.Sub(now) < tourGap {
+		playedRecently := summary.LastShow != nil && next.DateTime.Sub(summary.LastShow.DateTime) < tourGap
+		anotherSoon := len(upcoming) > 1 && upcoming[1].DateTime.Sub(next.DateTime) < tourGap
+		summary.OnTour = playedRecently || anotherSoon
+	}
+
+	return summary
+}
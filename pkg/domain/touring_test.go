@@ -0,0 +1,69 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeTouring(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	artist := Artist{ID: "artist-1", Name: "Test Artist"}
+	day := func(days int) time.Time {
+		return now.AddDate(0, 0, days)
+	}
+	show := func(id string, days int, country string) Event {
+		return Event{ID: id, DateTime: day(days), Venue: Venue{Country: country}}
+	}
+	played := func(id string, days int) PastConcert {
+		return PastConcert{Event: Event{ID: id, DateTime: day(days)}, Source: "setlistfm"}
+	}
+
+	t.Run("shows a few days apart are a tour", func(t *testing.T) {
+		events := []Event{
+			show("paris", 9, "FR"),
+			show("berlin", 3, "Germany"),
+			show("munich", 5, "DE"),
+			show("gone", -2, "DE"),
+			{ID: "cancelled", DateTime: day(4), Venue: Venue{Country: "Austria"}, Status: EventCancelled},
+			{ID: "tba", DateConfidence: DateUnknown, Venue: Venue{Country: "Spain"}},
+		}
+		summary := SummarizeTouring(artist, events, []PastConcert{played("older", -30), played("recent", -2)}, now)
+
+		if !summary.OnTour || summary.UpcomingDates != 3 {
+			t.Errorf("expected on tour with 3 dates, got %+v", summary)
+		}
+		if len(summary.Countries) != 2 || summary.Countries[0] != "France" || summary.Countries[1] != "Germany" {
+			t.Errorf("expected France and Germany, got %v", summary.Countries)
+		}
+		if summary.NextShow == nil || summary.NextShow.ID != "berlin" {
+			t.Errorf("expected Berlin next, got %+v", summary.NextShow)
+		}
+		if summary.LastShow == nil || summary.LastShow.ID != "recent" {
+			t.Errorf("expected the most recent concert, got %+v", summary.LastShow)
+		}
+	})
+
+	t.Run("a lone show isn't a tour", func(t *testing.T) {
+		summary := SummarizeTouring(artist, []Event{show("festival", 10, "BE"), show("autumn", 120, "BE")}, []PastConcert{played("spring", -90)}, now)
+		if summary.OnTour {
+			t.Errorf("expected not on tour, got %+v", summary)
+		}
+		if summary.UpcomingDates != 2 || summary.NextShow.ID != "festival" {
+			t.Errorf("expected both dates counted, got %+v", summary)
+		}
+	})
+
+	t.Run("the next show of a tour under way", func(t *testing.T) {
+		summary := SummarizeTouring(artist, []Event{show("amsterdam", 12, "NL")}, []PastConcert{played("yesterday", -1)}, now)
+		if !summary.OnTour {
+			t.Errorf("expected the tour under way, got %+v", summary)
+		}
+	})
+
+	t.Run("nothing coming", func(t *testing.T) {
+		summary := SummarizeTouring(artist, nil, nil, now)
+		if summary.OnTour || summary.NextShow != nil || summary.LastShow != nil || summary.Countries == nil {
+			t.Errorf("expected an empty summary, got %+v", summary)
+		}
+	})
+}
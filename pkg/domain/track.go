@@ -0,0 +1,16 @@
+package domain
+
+// Track is a recording of an artist's from one music service. PreviewURL is a
+// short clip UIs can play inline; VideoURL links to a music video.
+type Track struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	ArtistName string `json:"artist_name"`
+	Album      string `json:"album,omitempty"`
+	DurationMS int    `json:"duration_ms,omitempty"`
+	PreviewURL string `json:"preview_url,omitempty"`
+	URL        string `json:"url,omitempty"`
+	VideoURL   string `json:"video_url,omitempty"`
+	ImageURL   string `json:"image_url,omitempty"`
+	Source     string `json:"source"`
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// User is an account that follows, webhooks and saved searches belong to
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Follow is an artist a user wants to hear about. Events match by the
+// artist ID when known and by name otherwise.
+type Follow struct {
+	UserID     string    `json:"-"`
+	ArtistID   string    `json:"artist_id,omitempty"`
+	ArtistName string    `json:"artist_name"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SavedSearch is an artist and/or city a user wants new events for
+type SavedSearch struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"-"`
+	Artist    string    `json:"artist,omitempty"`
+	City      string    `json:"city,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
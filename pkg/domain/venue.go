@@ -0,0 +1,135 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// unknownVenueName is what sources that found no venue put in its place
+const unknownVenueName = "Unknown Venue"
+
+// Slug identifies the venue across sources by its name and city, ignoring
+// case, accents and punctuation, e.g. "columbiahalle-berlin". It's empty
+// for venues with no name.
+func (v Venue) Slug() string {
+	name := foldName(v.Name)
+	if name == "" || strings.EqualFold(strings.TrimSpace(v.Name), unknownVenueName) {
+		return ""
+	}
+
+	if city := Slugify(v.City); city != "" {
+		return Slugify(name) + "-" + city
+	}
+	return Slugify(name)
+}
+
+// Slugify folds text the way Slug does, for matching names against slugs
+func Slugify(text string) string {
+	return strings.Join(strings.Fields(foldName(text)), "-")
+}
+
+// MergeDetails fills in the capacity, address, phone and website the venue
+// lacks from other, another source's copy of it
+func (v *Venue) MergeDetails(other Venue) {
+	if v.Capacity == 0 {
+		v.Capacity = other.Capacity
+	}
+	if v.Address == "" {
+		v.Address = other.Address
+	}
+	if v.Phone == "" {
+		v.Phone = other.Phone
+	}
+	if v.Website == "" {
+		v.Website = other.Website
+	}
+}
+
+// StoredVenue is a venue from the venue store. Its details are merged from
+// every source that listed it, a detail one source leaves out kept from
+// another.
+type StoredVenue struct {
+	Venue
+	Slug string `json:"slug"`
+	// UpcomingEvents counts its stored events yet to happen
+	UpcomingEvents int       `json:"upcoming_events"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// VenueFilter narrows a venue listing. City matches the way it does for
+// events, and Query a part of the venue's name.
+type VenueFilter struct {
+	City  string
+	Query string
+	// From is when an event counts as upcoming
+	From time.Time
+}
+
+// venueFillerWords don't tell venue names apart
+var venueFillerWords = map[string]bool{
+	"the": true, "and": true, "at": true, "of": true,
+}
+
+// VenueNameSimilarity is how alike two venue names are, from 0 to 1: the
+// share of the shorter name's words that the other has too, ignoring case,
+// accents, punctuation and filler words like "the". "Berghain" and
+// "Berghain / Panorama Bar" are 1.
+func VenueNameSimilarity(a, b string) float64 {
+	wordsA, wordsB := venueWords(a), venueWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	if len(wordsA) > len(wordsB) {
+		wordsA, wordsB = wordsB, wordsA
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA))
+}
+
+func venueWords(name string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(foldName(name)) {
+		if !venueFillerWords[word] {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// VenueDuplicate is a pair of stored venues that are probably the same
+// place. Venue is the one to keep, the one with more upcoming events, and
+// Duplicate the one to merge into it.
+type VenueDuplicate struct {
+	Venue      StoredVenue `json:"venue"`
+	Duplicate  StoredVenue `json:"duplicate"`
+	Similarity float64     `json:"similarity"`
+	// DistanceKm is nil when either venue has no coordinates
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+}
+
+// VenueDuplicateFilter decides which venues count as duplicates: venues in
+// the same city whose names are at least MinSimilarity alike, and at most
+// MaxDistanceKm apart. Venues without coordinates need names that fully
+// match. City narrows the search to one city; zero fields take the
+// repository's defaults.
+type VenueDuplicateFilter struct {
+	City          string
+	MinSimilarity float64
+	MaxDistanceKm float64
+}
+
+// VenueMerge records a venue merged into another. Events counts the stored
+// events that moved; later writes of the merged venue go to Into.
+type VenueMerge struct {
+	From     string    `json:"from"`
+	FromName string    `json:"from_name"`
+	Into     string    `json:"into"`
+	Events   int       `json:"events"`
+	MergedAt time.Time `json:"merged_at"`
+}
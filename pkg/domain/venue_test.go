@@ -0,0 +1,53 @@
+package domain
+
+import "testing"
+
+func TestVenueSlug(t *testing.T) {
+	tests := []struct {
+		venue Venue
+		want  string
+	}{
+		{Venue{Name: "Columbiahalle", City: "Berlin"}, "columbiahalle-berlin"},
+		{Venue{Name: "  O2 Academy, Brixton ", City: "London"}, "o2-academy-brixton-london"},
+		{Venue{Name: "Café de la Danse", City: "Paris"}, "cafe-de-la-danse-paris"},
+		{Venue{Name: "Paradiso"}, "paradiso"},
+		{Venue{Name: "Unknown Venue", City: "Berlin"}, ""},
+		{Venue{City: "Berlin"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.venue.Slug(); got != tt.want {
+			t.Errorf("Slug(%+v) = %q, want %q", tt.venue, got, tt.want)
+		}
+	}
+}
+
+func TestVenueMergeDetails(t *testing.T) {
+	venue := Venue{Name: "Columbiahalle", Capacity: 3500, Phone: "+49 30 123456"}
+	venue.MergeDetails(Venue{Capacity: 3000, Phone: "+49 30 654321", Address: "Columbiadamm 13", Website: "https://columbiahalle.de"})
+
+	want := Venue{Name: "Columbiahalle", Capacity: 3500, Phone: "+49 30 123456", Address: "Columbiadamm 13", Website: "https://columbiahalle.de"}
+	if venue != want {
+		t.Errorf("expected the missing details filled in, got %+v", venue)
+	}
+}
+
+func TestVenueNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"Berghain", "Berghain / Panorama Bar", 1},
+		{"The Roundhouse", "Roundhouse", 1},
+		{"Café de la Danse", "CAFE DE LA DANSE", 1},
+		{"Berghain Kantine", "Berghain / Panorama Bar", 0.5},
+		{"SO36", "Berghain", 0},
+		{"", "Berghain", 0},
+	}
+
+	for _, tt := range tests {
+		if got := VenueNameSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("VenueNameSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const (
+	icsTimeFormat = "20060102T150405Z"
+	icsProductID  = "-//Where It's At//Events//EN"
+
+	// RFC 5545 lines are limited to 75 octets, continued on lines starting
+	// with a space
+	icsLineLimit = 75
+)
+
+// Calendar is an iCalendar feed of events
+type Calendar struct {
+	Name   string
+	Events []domain.Event
+	// Generated is written as each entry's DTSTAMP; zero means now
+	Generated time.Time
+}
+
+// WriteICS renders the calendar as an RFC 5545 iCalendar document
+func WriteICS(w io.Writer, calendar Calendar) error {
+	generated := calendar.Generated
+	if generated.IsZero() {
+		generated = time.Now()
+	}
+
+	iw := &icsWriter{w: bufio.NewWriter(w)}
+
+	iw.line("BEGIN", "VCALENDAR")
+	iw.line("VERSION", "2.0")
+	iw.line("PRODID", icsProductID)
+	iw.line("CALSCALE", "GREGORIAN")
+	iw.line("METHOD", "PUBLISH")
+	if calendar.Name != "" {
+		iw.line("X-WR-CALNAME", escapeICSText(calendar.Name))
+	}
+
+	for _, event := range calendar.Events {
+		writeICSEvent(iw, event, generated)
+	}
+
+	iw.line("END", "VCALENDAR")
+
+	if iw.err != nil {
+		return fmt.Errorf("failed to write calendar: %w", iw.err)
+	}
+	return iw.w.Flush()
+}
+
+// Upcoming returns the events after now in date order, which is what a
+// calendar feed lists
+func Upcoming(events []domain.Event, now time.Time) []domain.Event {
+	upcoming := []domain.Event{}
+	for _, event := range events {
+		if event.DateTime.After(now) {
+			upcoming = append(upcoming, event)
+		}
+	}
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].DateTime.Before(upcoming[j].DateTime)
+	})
+	return upcoming
+}
+
+func writeICSEvent(iw *icsWriter, event domain.Event, generated time.Time) {
+	iw.line("BEGIN", "VEVENT")
+	iw.line("UID", escapeICSText(event.ID+"@where-its-at"))
+	iw.line("DTSTAMP", generated.UTC().Format(icsTimeFormat))
+	iw.line("DTSTART", event.DateTime.UTC().Format(icsTimeFormat))
+	iw.line("SUMMARY", escapeICSText(eventSummary(event)))
+
+	if location := venueLocation(event.Venue); location != "" {
+		iw.line("LOCATION", escapeICSText(location))
+	}
+	if event.Venue.Latitude != 0 || event.Venue.Longitude != 0 {
+		iw.line("GEO", fmt.Sprintf("%f;%f", event.Venue.Latitude, event.Venue.Longitude))
+	}
+
+	var description []string
+	if event.Title != "" && event.ArtistName != "" {
+		description = append(description, event.ArtistName)
+	}
+	if event.TicketURL != "" {
+		description = append(description, "Tickets: "+event.TicketURL)
+		iw.line("URL", event.TicketURL)
+	}
+	if event.TicketStatus != "" {
+		description = append(description, "Status: "+event.TicketStatus)
+	}
+	if len(description) > 0 {
+		iw.line("DESCRIPTION", escapeICSText(strings.Join(description, "\n")))
+	}
+
+	if !event.UpdatedAt.IsZero() {
+		iw.line("LAST-MODIFIED", event.UpdatedAt.UTC().Format(icsTimeFormat))
+	}
+	iw.line("END", "VEVENT")
+}
+
+func eventSummary(event domain.Event) string {
+	if event.Title != "" {
+		return event.Title
+	}
+	if event.Venue.Name != "" {
+		return event.ArtistName + " at " + event.Venue.Name
+	}
+	return event.ArtistName
+}
+
+func venueLocation(venue domain.Venue) string {
+	parts := []string{}
+	for _, part := range []string{venue.Name, venue.City, venue.Region, venue.Country} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// escapeICSText escapes a TEXT value as RFC 5545 section 3.3.11 requires
+func escapeICSText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+type icsWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// line writes a content line, folding it at the octet limit without
+// splitting a UTF-8 sequence
+func (iw *icsWriter) line(name, value string) {
+	if iw.err != nil {
+		return
+	}
+
+	content := name + ":" + value
+	limit := icsLineLimit
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !isUTF8Start(content[cut]) {
+			cut--
+		}
+		if _, iw.err = iw.w.WriteString(content[:cut] + "\r\n "); iw.err != nil {
+			return
+		}
+		content = content[cut:]
+		// Continuation lines lose one octet to the leading space
+		limit = icsLineLimit - 1
+	}
+
+	_, iw.err = iw.w.WriteString(content + "\r\n")
+}
+
+func isUTF8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestWriteICS(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	generated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	calendar := Calendar{
+		Name:      "Radiohead, live",
+		Generated: generated,
+		Events: []domain.Event{
+			{
+				ID:         "songkick_1",
+				ArtistName: "Radiohead",
+				DateTime:   time.Date(2026, 5, 1, 20, 0, 0, 0, berlin),
+				Venue: domain.Venue{
+					Name:      "Columbiahalle",
+					City:      "Berlin",
+					Country:   "Germany",
+					Latitude:  52.4839,
+					Longitude: 13.3921,
+				},
+				TicketURL: "https://tickets.example.com/1",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, calendar); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	output := buf.String()
+
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"X-WR-CALNAME:Radiohead\\, live\r\n",
+		"UID:songkick_1@where-its-at\r\n",
+		"DTSTAMP:20260102T030405Z\r\n",
+		"DTSTART:20260501T180000Z\r\n",
+		"SUMMARY:Radiohead at Columbiahalle\r\n",
+		"LOCATION:Columbiahalle\\, Berlin\\, Germany\r\n",
+		"GEO:52.483900;13.392100\r\n",
+		"URL:https://tickets.example.com/1\r\n",
+		"DESCRIPTION:Tickets: https://tickets.example.com/1\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+
+	if strings.Count(output, "BEGIN:VEVENT") != 1 {
+		t.Errorf("expected one VEVENT, got:\n%s", output)
+	}
+}
+
+func TestWriteICS_Folding(t *testing.T) {
+	calendar := Calendar{
+		Events: []domain.Event{{
+			ID:         "e1",
+			ArtistName: "Sigur Rós",
+			Title:      strings.Repeat("Sigur Rós – Ágætis byrjun anniversary tour ", 4),
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteICS(&buf, calendar); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var unfolded strings.Builder
+	for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > icsLineLimit {
+			t.Errorf("line %d is %d octets", i, len(line))
+		}
+		if !strings.HasPrefix(line, " ") && i > 0 {
+			unfolded.WriteString("\n")
+		}
+		unfolded.WriteString(strings.TrimPrefix(line, " "))
+	}
+
+	expected := "SUMMARY:" + escapeICSText(calendar.Events[0].Title)
+	if !strings.Contains(unfolded.String(), expected+"\n") {
+		t.Errorf("expected folded summary to unfold to %q, got:\n%s", expected, unfolded.String())
+	}
+}
+
+func TestEscapeICSText(t *testing.T) {
+	got := escapeICSText("a\\b;c,d\r\ne")
+	if got != `a\\b\;c\,d\ne` {
+		t.Errorf("unexpected escape: %s", got)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	now := time.Date(2026, 5, 15, 0, 0, 0, 0, time.UTC)
+	events := []domain.Event{
+		{ID: "later", DateTime: now.Add(72 * time.Hour)},
+		{ID: "past", DateTime: now.Add(-time.Hour)},
+		{ID: "sooner", DateTime: now.Add(time.Hour)},
+	}
+
+	upcoming := Upcoming(events, now)
+	if len(upcoming) != 2 || upcoming[0].ID != "sooner" || upcoming[1].ID != "later" {
+		t.Errorf("expected sooner then later, got %+v", upcoming)
+	}
+}
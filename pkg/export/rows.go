@@ -0,0 +1,139 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// EventWriter writes events one row at a time so large exports can be
+// streamed. Flush must be called once writing is done.
+type EventWriter interface {
+	Write(event domain.Event) error
+	Flush() error
+}
+
+// CSVColumns is the header row of CSV exports
+var CSVColumns = []string{
+	"id", "artist_id", "artist_name", "title", "datetime",
+	"venue_id", "venue_name", "venue_city", "venue_region", "venue_country",
+	"venue_latitude", "venue_longitude", "ticket_url", "ticket_status", "on_sale_date",
+	"bandsintown_id", "ticketmaster_id", "songkick_id", "eventbrite_id", "setlistfm_id",
+	"sources",
+}
+
+type csvEventWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func NewCSVEventWriter(w io.Writer) EventWriter {
+	return &csvEventWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvEventWriter) Write(event domain.Event) error {
+	if !c.headerWritten {
+		if err := c.w.Write(CSVColumns); err != nil {
+			return fmt.Errorf("failed to write csv header: %w", err)
+		}
+		c.headerWritten = true
+	}
+
+	onSaleDate := ""
+	if event.OnSaleDate != nil {
+		onSaleDate = event.OnSaleDate.UTC().Format(time.RFC3339)
+	}
+
+	record := []string{
+		event.ID,
+		event.ArtistID,
+		event.ArtistName,
+		event.Title,
+		event.DateTime.UTC().Format(time.RFC3339),
+		event.Venue.ID,
+		event.Venue.Name,
+		event.Venue.City,
+		event.Venue.Region,
+		event.Venue.Country,
+		strconv.FormatFloat(event.Venue.Latitude, 'f', -1, 64),
+		strconv.FormatFloat(event.Venue.Longitude, 'f', -1, 64),
+		event.TicketURL,
+		event.TicketStatus,
+		onSaleDate,
+		event.ExternalIDs.BandsintownID,
+		event.ExternalIDs.TicketmasterID,
+		event.ExternalIDs.SongkickID,
+		event.ExternalIDs.EventbriteID,
+		event.ExternalIDs.SetlistFMID,
+		strings.Join(event.Sources, "|"),
+	}
+
+	if err := c.w.Write(record); err != nil {
+		return fmt.Errorf("failed to write csv row: %w", err)
+	}
+	return nil
+}
+
+// Flush writes buffered rows. An export with no events still gets a header.
+func (c *csvEventWriter) Flush() error {
+	if !c.headerWritten {
+		if err := c.w.Write(CSVColumns); err != nil {
+			return fmt.Errorf("failed to write csv header: %w", err)
+		}
+		c.headerWritten = true
+	}
+
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonlEventWriter struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+// NewJSONLEventWriter writes one JSON-encoded event per line
+func NewJSONLEventWriter(w io.Writer) EventWriter {
+	buf := bufio.NewWriter(w)
+	return &jsonlEventWriter{buf: buf, encoder: json.NewEncoder(buf)}
+}
+
+func (j *jsonlEventWriter) Write(event domain.Event) error {
+	if err := j.encoder.Encode(event); err != nil {
+		return fmt.Errorf("failed to write json line: %w", err)
+	}
+	return nil
+}
+
+func (j *jsonlEventWriter) Flush() error {
+	return j.buf.Flush()
+}
+
+// ParseDate reads the bounds of an export's date range: RFC 3339 times or
+// plain dates. A plain date used as the end of a range includes the whole
+// day. An empty value is an open bound.
+func ParseDate(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
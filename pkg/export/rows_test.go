@@ -0,0 +1,129 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func testEvents() []domain.Event {
+	onSale := time.Date(2026, 2, 1, 10, 0, 0, 0, time.UTC)
+	return []domain.Event{
+		{
+			ID:         "e1",
+			ArtistName: `Guns N' Roses, "live"`,
+			DateTime:   time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC),
+			Venue:      domain.Venue{Name: "Olympiastadion", City: "Berlin", Latitude: 52.5147},
+			OnSaleDate: &onSale,
+			ExternalIDs: domain.EventExternalIDs{
+				SongkickID: "123",
+			},
+			Sources: []string{"songkick", "ticketmaster"},
+		},
+		{ID: "e2", ArtistName: "Radiohead", DateTime: time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)},
+	}
+}
+
+func TestCSVEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewCSVEventWriter(&buf)
+	for _, event := range testEvents() {
+		if err := writer.Write(event); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("expected valid CSV, got %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header and 2 rows, got %d", len(records))
+	}
+	if strings.Join(records[0], ",") != strings.Join(CSVColumns, ",") {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+
+	row := make(map[string]string)
+	for i, column := range CSVColumns {
+		row[column] = records[1][i]
+	}
+	if row["artist_name"] != `Guns N' Roses, "live"` {
+		t.Errorf("expected quoted artist name to round-trip, got %s", row["artist_name"])
+	}
+	if row["datetime"] != "2026-05-01T20:00:00Z" || row["on_sale_date"] != "2026-02-01T10:00:00Z" {
+		t.Errorf("unexpected dates: %s, %s", row["datetime"], row["on_sale_date"])
+	}
+	if row["venue_latitude"] != "52.5147" || row["songkick_id"] != "123" || row["sources"] != "songkick|ticketmaster" {
+		t.Errorf("unexpected row: %v", row)
+	}
+}
+
+func TestCSVEventWriter_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewCSVEventWriter(&buf).Flush(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if strings.TrimSpace(buf.String()) != strings.Join(CSVColumns, ",") {
+		t.Errorf("expected only the header, got %q", buf.String())
+	}
+}
+
+func TestJSONLEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewJSONLEventWriter(&buf)
+	for _, event := range testEvents() {
+		if err := writer.Write(event); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	ids := []string{}
+	for scanner.Scan() {
+		var event domain.Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("expected one JSON object per line, got %v", err)
+		}
+		ids = append(ids, event.ID)
+	}
+	if strings.Join(ids, ",") != "e1,e2" {
+		t.Errorf("expected e1,e2, got %v", ids)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	if date, err := ParseDate("", false); err != nil || date != nil {
+		t.Errorf("expected an open bound, got %v, %v", date, err)
+	}
+
+	from, err := ParseDate("2026-05-01", false)
+	if err != nil || !from.Equal(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the start of the day, got %v, %v", from, err)
+	}
+	to, err := ParseDate("2026-05-01", true)
+	if err != nil || to.Day() != 1 || to.Hour() != 23 {
+		t.Errorf("expected the end of the day, got %v, %v", to, err)
+	}
+
+	exact, err := ParseDate("2026-05-01T20:00:00+02:00", true)
+	if err != nil || exact.Hour() != 20 {
+		t.Errorf("expected the RFC 3339 time as given, got %v, %v", exact, err)
+	}
+
+	if _, err := ParseDate("May 1st", false); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// Feed is an RSS 2.0 channel of events, newest discovery first
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Events      []domain.Event
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteRSS renders the feed as RSS 2.0. Items are dated by when the event was
+// discovered, falling back to when it was stored.
+func WriteRSS(w io.Writer, feed Feed) error {
+	channel := rssChannel{
+		Title:       feed.Title,
+		Link:        feed.Link,
+		Description: feed.Description,
+		Items:       make([]rssItem, 0, len(feed.Events)),
+	}
+
+	var latest time.Time
+	for _, event := range feed.Events {
+		published := event.CreatedAt
+		if event.DiscoveredAt != nil {
+			published = *event.DiscoveredAt
+		}
+		if published.After(latest) {
+			latest = published
+		}
+
+		item := rssItem{
+			Title:       eventSummary(event),
+			Link:        event.TicketURL,
+			Description: rssDescription(event),
+			GUID:        rssGUID{Value: event.ID},
+		}
+		if !published.IsZero() {
+			item.PubDate = published.UTC().Format(time.RFC1123Z)
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	if !latest.IsZero() {
+		channel.LastBuildDate = latest.UTC().Format(time.RFC1123Z)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(rssDocument{Version: "2.0", Channel: channel}); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	return nil
+}
+
+func rssDescription(event domain.Event) string {
+	parts := []string{event.DateTime.Format("Mon, 2 Jan 2006 15:04 MST")}
+	if location := venueLocation(event.Venue); location != "" {
+		parts = append(parts, location)
+	}
+	if event.TicketStatus != "" {
+		parts = append(parts, "Tickets: "+event.TicketStatus)
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,73 @@
+package export
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestWriteRSS(t *testing.T) {
+	discovered := time.Date(2026, 2, 1, 9, 30, 0, 0, time.UTC)
+	stored := time.Date(2026, 1, 15, 8, 0, 0, 0, time.UTC)
+
+	feed := Feed{
+		Title:       "Radiohead & friends",
+		Link:        "https://example.com/api/feeds/artist/1.rss",
+		Description: "New events",
+		Events: []domain.Event{
+			{
+				ID:           "e1",
+				ArtistName:   "Radiohead",
+				DateTime:     time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC),
+				Venue:        domain.Venue{Name: "Columbiahalle", City: "Berlin"},
+				TicketURL:    "https://tickets.example.com/1?a=1&b=2",
+				DiscoveredAt: &discovered,
+			},
+			{
+				ID:         "e2",
+				ArtistName: "Radiohead",
+				DateTime:   time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC),
+				CreatedAt:  stored,
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRSS(&buf, feed); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var doc rssDocument
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("expected valid XML, got %v:\n%s", err, buf.String())
+	}
+
+	if doc.Version != "2.0" || doc.Channel.Title != feed.Title {
+		t.Errorf("unexpected channel: %+v", doc.Channel)
+	}
+	if doc.Channel.LastBuildDate != "Sun, 01 Feb 2026 09:30:00 +0000" {
+		t.Errorf("expected last build date from newest item, got %s", doc.Channel.LastBuildDate)
+	}
+	if len(doc.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(doc.Channel.Items))
+	}
+
+	first := doc.Channel.Items[0]
+	if first.Title != "Radiohead at Columbiahalle" || first.Link != feed.Events[0].TicketURL {
+		t.Errorf("unexpected item: %+v", first)
+	}
+	if first.GUID.Value != "e1" || first.GUID.IsPermaLink {
+		t.Errorf("expected non-permalink guid e1, got %+v", first.GUID)
+	}
+	if !strings.Contains(first.Description, "Columbiahalle, Berlin") {
+		t.Errorf("expected venue in description, got %s", first.Description)
+	}
+
+	if doc.Channel.Items[1].PubDate != "Thu, 15 Jan 2026 08:00:00 +0000" {
+		t.Errorf("expected stored time as fallback, got %s", doc.Channel.Items[1].PubDate)
+	}
+}
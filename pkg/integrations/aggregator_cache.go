@@ -0,0 +1,387 @@
+package integrations
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/cache"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const (
+	defaultCacheMaxEntries = 10000
+	defaultCacheMaxBytes   = 64 << 20
+	defaultNoResultsTTL    = 2 * time.Minute
+	// storeTimeout bounds a shared store call, so a slow Redis costs a
+	// cache miss rather than the search
+	storeTimeout = 2 * time.Second
+)
+
+// Eviction reasons, as reported to the metrics
+const (
+	EvictedExpired  = "expired"
+	EvictedCapacity = "capacity"
+)
+
+type AggregatorCacheConfig struct {
+	TTL        time.Duration
+	MaxEntries int
+	// MaxBytes bounds the estimated size of the cached results, by their
+	// JSON encoding
+	MaxBytes int64
+	Metrics  AggregatorMetrics
+	// NoResultsTTL is how long searches that found nothing are kept, shorter
+	// than TTL so a new artist's first listings show up soon. Two minutes
+	// by default.
+	NoResultsTTL time.Duration
+	// Store keeps the results outside the process, shared by every
+	// instance, instead of in memory. The store expires them, so the bounds
+	// above and sweeps don't apply.
+	Store  cache.Store
+	Logger *slog.Logger
+}
+
+// AggregatorCache holds aggregated results for a TTL, evicting the least
+// recently used searches once it holds MaxEntries or MaxBytes. Artist and
+// event searches share the bounds. With a Store, results are kept there
+// instead.
+type AggregatorCache struct {
+	artistCache map[string]*list.Element
+	eventCache  map[string]*list.Element
+	recent      *list.List // of *CacheEntry, most recently used first
+	bytes       int64
+	mutex       sync.Mutex
+	config      AggregatorCacheConfig
+	now         func() time.Time
+}
+
+type CacheEntry struct {
+	Results   *AggregatedResults
+	ExpiresAt time.Time
+
+	key    string
+	events bool
+	size   int64
+}
+
+func NewAggregatorCache(config AggregatorCacheConfig) *AggregatorCache {
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultCacheMaxEntries
+	}
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultCacheMaxBytes
+	}
+	if config.NoResultsTTL <= 0 {
+		config.NoResultsTTL = defaultNoResultsTTL
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &AggregatorCache{
+		artistCache: make(map[string]*list.Element),
+		eventCache:  make(map[string]*list.Element),
+		recent:      list.New(),
+		config:      config,
+		now:         time.Now,
+	}
+}
+
+func (c *AggregatorCache) GetArtists(query string, limit int) *AggregatedResults {
+	return c.get(false, fmt.Sprintf("%s_%d", query, limit))
+}
+
+func (c *AggregatorCache) SetArtists(query string, limit int, results *AggregatedResults) {
+	c.set(false, fmt.Sprintf("%s_%d", query, limit), results)
+}
+
+func (c *AggregatorCache) GetEvents(artistName, city string, limit int) *AggregatedResults {
+	return c.get(true, fmt.Sprintf("%s_%s_%d", artistName, city, limit))
+}
+
+func (c *AggregatorCache) SetEvents(artistName, city string, limit int, results *AggregatedResults) {
+	c.set(true, fmt.Sprintf("%s_%s_%d", artistName, city, limit), results)
+}
+
+// GetNoResults returns an earlier search for query that found nothing,
+// whatever its limit and however the query was spelled. scope tells apart
+// searches of different sources.
+func (c *AggregatorCache) GetNoResults(events bool, query, scope string) *AggregatedResults {
+	return c.get(events, noResultsKey(query)+scope)
+}
+
+// SetNoResults caches a search that found nothing for the NoResultsTTL
+func (c *AggregatorCache) SetNoResults(events bool, query, scope string, results *AggregatedResults) {
+	c.setFor(events, noResultsKey(query)+scope, results, c.config.NoResultsTTL)
+}
+
+// noResultsKey keys searches that found nothing by the folded query, apart
+// from the keys of other searches
+func noResultsKey(query string) string {
+	return "none:" + domain.ArtistNameKey(query) + "_"
+}
+
+func (c *AggregatorCache) get(events bool, key string) *AggregatedResults {
+	if c.config.Store != nil {
+		return c.getShared(events, key)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, exists := c.entries(events)[key]
+	if !exists {
+		return nil
+	}
+	entry := element.Value.(*CacheEntry)
+	if c.now().After(entry.ExpiresAt) {
+		c.evict(element, EvictedExpired)
+		c.observeSize()
+		return nil
+	}
+
+	c.recent.MoveToFront(element)
+	return entry.Results
+}
+
+func (c *AggregatorCache) set(events bool, key string, results *AggregatedResults) {
+	c.setFor(events, key, results, c.config.TTL)
+}
+
+func (c *AggregatorCache) setFor(events bool, key string, results *AggregatedResults, ttl time.Duration) {
+	if c.config.Store != nil {
+		c.setShared(events, key, results, ttl)
+		return
+	}
+	size := estimateSize(results)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	entries := c.entries(events)
+	if element, exists := entries[key]; exists {
+		c.remove(element)
+	}
+	// Caching a result bigger than the whole cache would only empty it
+	if size > c.config.MaxBytes {
+		c.observeSize()
+		return
+	}
+
+	entry := &CacheEntry{
+		Results:   results,
+		ExpiresAt: c.now().Add(ttl),
+		key:       key,
+		events:    events,
+		size:      size,
+	}
+	entries[key] = c.recent.PushFront(entry)
+	c.bytes += size
+
+	for c.recent.Len() > c.config.MaxEntries || c.bytes > c.config.MaxBytes {
+		c.evict(c.recent.Back(), EvictedCapacity)
+	}
+	c.observeSize()
+}
+
+// Clear drops every cached result
+func (c *AggregatorCache) Clear() {
+	if c.config.Store != nil {
+		c.deleteShared(sharedSearchPrefix)
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.artistCache = make(map[string]*list.Element)
+	c.eventCache = make(map[string]*list.Element)
+	c.recent.Init()
+	c.bytes = 0
+	c.observeSize()
+}
+
+func (c *AggregatorCache) Size() (artists, events int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.artistCache), len(c.eventCache)
+}
+
+// Bytes is the estimated size of the cached results
+func (c *AggregatorCache) Bytes() int64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.bytes
+}
+
+// DeleteEvents drops the artist's event searches, including one that found
+// nothing. Their keys are the name, then a source filter or the empty city.
+func (c *AggregatorCache) DeleteEvents(artistName string) {
+	if artistName == "" {
+		return
+	}
+	none := noResultsKey(artistName)
+	if c.config.Store != nil {
+		c.deleteShared(sharedKey(true, artistName+"__"))
+		c.deleteShared(sharedKey(true, artistName+"|"))
+		c.deleteShared(sharedKey(true, none))
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for key, element := range c.eventCache {
+		if strings.HasPrefix(key, artistName+"__") || strings.HasPrefix(key, artistName+"|") || strings.HasPrefix(key, none) {
+			c.remove(element)
+		}
+	}
+	c.observeSize()
+}
+
+// Sweep drops the expired results and returns how many there were
+func (c *AggregatorCache) Sweep() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	swept := 0
+	for element := c.recent.Back(); element != nil; {
+		previous := element.Prev()
+		if now.After(element.Value.(*CacheEntry).ExpiresAt) {
+			c.evict(element, EvictedExpired)
+			swept++
+		}
+		element = previous
+	}
+	if swept > 0 {
+		c.observeSize()
+	}
+	return swept
+}
+
+// RunSweeps drops expired results every interval until ctx is done, so
+// searches nobody repeats don't hold memory until they're pushed out
+func (c *AggregatorCache) RunSweeps(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Sweep()
+		}
+	}
+}
+
+// evict removes the entry and counts it under reason. The caller holds the
+// mutex.
+func (c *AggregatorCache) evict(element *list.Element, reason string) {
+	entry := c.remove(element)
+	if c.config.Metrics != nil {
+		c.config.Metrics.ObserveCacheEviction(entry.cacheName(), reason)
+	}
+}
+
+func (c *AggregatorCache) remove(element *list.Element) *CacheEntry {
+	entry := c.recent.Remove(element).(*CacheEntry)
+	delete(c.entries(entry.events), entry.key)
+	c.bytes -= entry.size
+	return entry
+}
+
+func (c *AggregatorCache) entries(events bool) map[string]*list.Element {
+	if events {
+		return c.eventCache
+	}
+	return c.artistCache
+}
+
+func (c *AggregatorCache) observeSize() {
+	if c.config.Metrics != nil {
+		c.config.Metrics.ObserveCacheSize(c.recent.Len(), c.bytes)
+	}
+}
+
+// cacheName is the cache label the lookups are observed under
+func (e *CacheEntry) cacheName() string {
+	if e.events {
+		return "events"
+	}
+	return "artists"
+}
+
+// sharedSearchPrefix starts the keys of searches in a Store, next to the
+// rate limit counts
+const sharedSearchPrefix = "search:"
+
+func sharedKey(events bool, key string) string {
+	if events {
+		return sharedSearchPrefix + "events:" + key
+	}
+	return sharedSearchPrefix + "artists:" + key
+}
+
+// getShared treats a failing store like a miss; the search still runs
+func (c *AggregatorCache) getShared(events bool, key string) *AggregatedResults {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	encoded, err := c.config.Store.Get(ctx, sharedKey(events, key))
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			c.config.Logger.Warn("failed to read cached search", "key", key, "error", err)
+		}
+		return nil
+	}
+
+	var results AggregatedResults
+	if err := json.Unmarshal(encoded, &results); err != nil {
+		c.config.Logger.Warn("failed to decode cached search", "key", key, "error", err)
+		return nil
+	}
+	return &results
+}
+
+func (c *AggregatorCache) setShared(events bool, key string, results *AggregatedResults, ttl time.Duration) {
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		c.config.Logger.Warn("failed to encode search for the cache", "key", key, "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := c.config.Store.Set(ctx, sharedKey(events, key), encoded, ttl); err != nil {
+		c.config.Logger.Warn("failed to cache search", "key", key, "error", err)
+	}
+}
+
+func (c *AggregatorCache) deleteShared(prefix string) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+	if err := c.config.Store.DeletePrefix(ctx, prefix); err != nil {
+		c.config.Logger.Warn("failed to drop cached searches", "prefix", prefix, "error", err)
+	}
+}
+
+// estimateSize guesses how much memory results hold from their JSON
+// encoding, which counts the strings that make up most of it
+func estimateSize(results *AggregatedResults) int64 {
+	encoded, err := json.Marshal(results)
+	if err != nil {
+		return 0
+	}
+	return int64(len(encoded))
+}
@@ -0,0 +1,197 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/cache"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func newTestCache(config AggregatorCacheConfig) (*AggregatorCache, *recordingMetrics, *time.Time) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	metrics := &recordingMetrics{}
+	config.Metrics = metrics
+	cache := NewAggregatorCache(config)
+	cache.now = func() time.Time { return now }
+	return cache, metrics, &now
+}
+
+func eventResults(ids ...string) *AggregatedResults {
+	results := &AggregatedResults{}
+	for _, id := range ids {
+		results.Events = append(results.Events, domain.Event{ID: id, Title: "Show"})
+	}
+	return results
+}
+
+func TestAggregatorCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache, metrics, _ := newTestCache(AggregatorCacheConfig{TTL: time.Hour, MaxEntries: 2})
+
+	cache.SetEvents("Radiohead", "", 10, eventResults("1"))
+	cache.SetArtists("Radiohead", 10, &AggregatedResults{})
+	// Reading Radiohead's events makes the artist search the oldest
+	if cache.GetEvents("Radiohead", "", 10) == nil {
+		t.Fatal("expected the cached events")
+	}
+	cache.SetEvents("Kiasmos", "", 10, eventResults("2"))
+
+	if cache.GetArtists("Radiohead", 10) != nil {
+		t.Error("expected the least recently used search to be evicted")
+	}
+	if cache.GetEvents("Radiohead", "", 10) == nil || cache.GetEvents("Kiasmos", "", 10) == nil {
+		t.Error("expected both event searches to stay")
+	}
+	if len(metrics.evictions) != 1 || metrics.evictions[0] != "artists capacity" {
+		t.Errorf("expected one artist search evicted for capacity, got %v", metrics.evictions)
+	}
+	if metrics.entries != 2 || metrics.bytes != cache.Bytes() {
+		t.Errorf("expected 2 entries of %d bytes, got %d of %d", cache.Bytes(), metrics.entries, metrics.bytes)
+	}
+}
+
+func TestAggregatorCache_MaxBytes(t *testing.T) {
+	one := estimateSize(eventResults("1"))
+	cache, _, _ := newTestCache(AggregatorCacheConfig{TTL: time.Hour, MaxBytes: 2*one + one/2})
+
+	cache.SetEvents("a", "", 10, eventResults("1"))
+	cache.SetEvents("b", "", 10, eventResults("2"))
+	cache.SetEvents("c", "", 10, eventResults("3"))
+	if artists, events := cache.Size(); artists != 0 || events != 2 {
+		t.Errorf("expected 2 searches to fit, got %d", events)
+	}
+	if cache.GetEvents("a", "", 10) != nil {
+		t.Error("expected the oldest search to make room")
+	}
+	if cache.Bytes() != 2*one {
+		t.Errorf("expected %d bytes, got %d", 2*one, cache.Bytes())
+	}
+
+	// Too big to cache at all, without emptying the cache trying
+	cache.SetEvents("d", "", 10, eventResults("4", "5", "6"))
+	if _, events := cache.Size(); events != 2 {
+		t.Errorf("expected the oversized search to be skipped, got %d searches", events)
+	}
+
+	// Replacing a search frees what it held
+	cache.SetEvents("b", "", 10, eventResults("2"))
+	if cache.Bytes() != 2*one {
+		t.Errorf("expected %d bytes after replacing, got %d", 2*one, cache.Bytes())
+	}
+}
+
+func TestAggregatorCache_Sweep(t *testing.T) {
+	cache, metrics, now := newTestCache(AggregatorCacheConfig{TTL: time.Hour})
+
+	cache.SetEvents("Radiohead", "", 10, eventResults("1"))
+	*now = now.Add(30 * time.Minute)
+	cache.SetArtists("Radiohead", 10, &AggregatedResults{})
+	*now = now.Add(45 * time.Minute)
+
+	if swept := cache.Sweep(); swept != 1 {
+		t.Errorf("expected 1 expired search swept, got %d", swept)
+	}
+	if artists, events := cache.Size(); artists != 1 || events != 0 {
+		t.Errorf("expected only the artist search left, got %d and %d", artists, events)
+	}
+	if len(metrics.evictions) != 1 || metrics.evictions[0] != "events expired" {
+		t.Errorf("expected one expired event search, got %v", metrics.evictions)
+	}
+
+	*now = now.Add(time.Hour)
+	if cache.GetArtists("Radiohead", 10) != nil {
+		t.Error("expected an expired search not to be returned")
+	}
+	if artists, _ := cache.Size(); artists != 0 || cache.Bytes() != 0 {
+		t.Errorf("expected the expired lookup to drop the search, got %d of %d bytes", artists, cache.Bytes())
+	}
+
+	// Sweeps stop with their context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cache.RunSweeps(ctx, time.Millisecond)
+}
+
+// memoryStore stands in for Redis
+type memoryStore struct {
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	value, ok := s.values[key]
+	if !ok {
+		return nil, cache.ErrMiss
+	}
+	return value, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.values[key], s.ttls[key] = value, ttl
+	return nil
+}
+
+func (s *memoryStore) DeletePrefix(ctx context.Context, prefix string) error {
+	for key := range s.values {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.values, key)
+		}
+	}
+	return s.err
+}
+
+func TestAggregatorCache_Store(t *testing.T) {
+	store := &memoryStore{values: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+	cache, _, _ := newTestCache(AggregatorCacheConfig{TTL: time.Hour, Store: store})
+
+	cache.SetEvents("Radiohead", "", 10, eventResults("1"))
+	cache.SetEvents("Radiohead|only=songkick", "", 10, eventResults("1"))
+	cache.SetEvents("Kiasmos", "", 10, eventResults("2"))
+	cache.SetArtists("Radiohead", 10, &AggregatedResults{Artists: []domain.Artist{{Name: "Radiohead"}}})
+	cache.SetNoResults(true, "Radiohead", "", &AggregatedResults{Suggestion: "Radiohead"})
+
+	if ttl := store.ttls["search:events:Radiohead__10"]; ttl != time.Hour {
+		t.Errorf("expected the store to expire the search in an hour, got %v", ttl)
+	}
+	if ttl := store.ttls["search:events:none:radiohead_"]; ttl != defaultNoResultsTTL {
+		t.Errorf("expected the store to expire the empty search sooner, got %v", ttl)
+	}
+	if results := cache.GetNoResults(true, "RADIOHEAD", ""); results == nil || results.Suggestion != "Radiohead" {
+		t.Errorf("expected the empty search back under any spelling, got %+v", results)
+	}
+	results := cache.GetEvents("Radiohead", "", 10)
+	if results == nil || len(results.Events) != 1 || results.Events[0].ID != "1" {
+		t.Fatalf("expected the stored search back, got %+v", results)
+	}
+	// Nothing is held in memory
+	if artists, events := cache.Size(); artists != 0 || events != 0 || cache.Bytes() != 0 {
+		t.Errorf("expected an empty memory cache, got %d, %d", artists, events)
+	}
+
+	cache.DeleteEvents("Radiohead")
+	if cache.GetEvents("Radiohead", "", 10) != nil || len(store.values) != 2 {
+		t.Errorf("expected only Radiohead's event searches dropped, got %v", store.values)
+	}
+
+	// A failing store is a miss, not a failed search
+	store.err = errors.New("connection refused")
+	if cache.GetEvents("Kiasmos", "", 10) != nil {
+		t.Error("expected a miss while the store is down")
+	}
+	store.err = nil
+
+	cache.Clear()
+	if len(store.values) != 0 {
+		t.Errorf("expected every search dropped, got %v", store.values)
+	}
+}
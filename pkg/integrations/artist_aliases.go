@@ -0,0 +1,271 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ArtistAliasSource finds the artist performing under a name, whether it's
+// the artist's own name or an alias, along with the other names it uses.
+// It returns domain.ErrArtistNotFound when no artist goes by the name.
+type ArtistAliasSource interface {
+	ArtistAliases(ctx context.Context, name string) (*domain.Artist, error)
+}
+
+const (
+	// artistAliasTTL is how long resolved names are trusted; aliases rarely
+	// change, but a new artist taking a name should show up within a day
+	artistAliasTTL = 24 * time.Hour
+	// aliasLookupTimeout bounds how long a search waits for alias sources,
+	// after which it goes ahead under the name it was given
+	aliasLookupTimeout = 5 * time.Second
+	// maxArtistNameSearches caps how many names each source is searched
+	// under, counting the artist's own
+	maxArtistNameSearches = 3
+)
+
+type namedAliasSource struct {
+	name   string
+	source ArtistAliasSource
+}
+
+// ArtistAliasTable remembers the names resolved artists go by. Every name
+// an artist was looked up or is known under leads to the same entry.
+type ArtistAliasTable struct {
+	mu      sync.RWMutex
+	entries map[string]artistAliasEntry
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type artistAliasEntry struct {
+	artist    domain.Artist
+	expiresAt time.Time
+}
+
+func NewArtistAliasTable(ttl time.Duration) *ArtistAliasTable {
+	return &ArtistAliasTable{
+		entries: make(map[string]artistAliasEntry),
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// Lookup returns the artist name resolves to, with its aliases
+func (t *ArtistAliasTable) Lookup(name string) (domain.Artist, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	entry, ok := t.entries[normalizeRankingName(name)]
+	if !ok || t.now().After(entry.expiresAt) {
+		return domain.Artist{}, false
+	}
+	return entry.artist, true
+}
+
+// Add records that name resolves to artist, along with each of the
+// artist's own names
+func (t *ArtistAliasTable) Add(name string, artist domain.Artist) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := artistAliasEntry{artist: artist, expiresAt: t.now().Add(t.ttl)}
+	for _, known := range append(artist.Names(), name) {
+		if key := normalizeRankingName(known); key != "" {
+			t.entries[key] = entry
+		}
+	}
+}
+
+// RegisterAliasSource adds a source of artist aliases for event searches.
+// When sources resolve a name differently, the first registered names the
+// artist.
+func (m *MegaAggregator) RegisterAliasSource(name string, source ArtistAliasSource) {
+	m.sourcesMu.Lock()
+	defer m.sourcesMu.Unlock()
+	// A full slice copies on append, so lookups under way keep theirs
+	m.aliasSources = append(m.aliasSources[:len(m.aliasSources):len(m.aliasSources)], namedAliasSource{name: name, source: source})
+	m.addQuotaReporter(name, source)
+}
+
+// SetArtistIdentities keeps the MBIDs alias sources resolve names to in
+// store, where sources that search by MBID find them. It's set up before
+// searching.
+func (m *MegaAggregator) SetArtistIdentities(store domain.ArtistIdentityRepository) {
+	m.identities = store
+}
+
+// resolveArtist names the artist the way alias sources know it and adds
+// the aliases they list, so "KIASMOS" is searched as Kiasmos. A stored
+// artist keeps its ID and external IDs.
+func (m *MegaAggregator) resolveArtist(ctx context.Context, artist domain.Artist) domain.Artist {
+	if len(m.sources().aliases) == 0 || strings.TrimSpace(artist.Name) == "" {
+		return artist
+	}
+
+	resolved, ok := m.aliases.Lookup(artist.Name)
+	if !ok {
+		var complete bool
+		resolved, complete = m.lookupAliases(ctx, artist.Name)
+		// A failing source might have known the name, so ask again next time
+		if complete {
+			m.aliases.Add(artist.Name, resolved)
+		}
+	}
+
+	merged := artist
+	merged.Name = resolved.Name
+	merged.Aliases = nil
+	for _, name := range append(resolved.Names(), artist.Names()...) {
+		if !merged.KnownAs(name) {
+			merged.Aliases = append(merged.Aliases, strings.TrimSpace(name))
+		}
+	}
+	return merged
+}
+
+// lookupAliases asks every alias source about name at once. complete is
+// false when a source failed rather than not knowing the name.
+func (m *MegaAggregator) lookupAliases(ctx context.Context, name string) (resolved domain.Artist, complete bool) {
+	ctx, cancel := context.WithTimeout(ctx, aliasLookupTimeout)
+	defer cancel()
+
+	aliasSources := m.sources().aliases
+	found := make([]*domain.Artist, len(aliasSources))
+	errs := make([]error, len(aliasSources))
+	var wg sync.WaitGroup
+	for i, source := range aliasSources {
+		wg.Add(1)
+		go func(i int, source ArtistAliasSource) {
+			defer wg.Done()
+			found[i], errs[i] = source.ArtistAliases(ctx, name)
+		}(i, source.source)
+	}
+	wg.Wait()
+
+	resolved = domain.Artist{Name: strings.TrimSpace(name)}
+	matched, complete := false, true
+	for i, artist := range found {
+		if errs[i] != nil {
+			if !errors.Is(errs[i], domain.ErrArtistNotFound) {
+				m.config.Logger.Warn("artist alias lookup failed", "source", aliasSources[i].name, "artist", name, "error", errs[i])
+				complete = false
+			}
+			continue
+		}
+		if artist == nil {
+			continue
+		}
+		if artist.ExternalIDs.MusicBrainzID != "" {
+			m.saveIdentity(ctx, aliasSources[i].name, name, *artist)
+		}
+		if !matched {
+			resolved.Name = artist.Name
+			matched = true
+		}
+		for _, alias := range artist.Names() {
+			if !resolved.KnownAs(alias) {
+				resolved.Aliases = append(resolved.Aliases, alias)
+			}
+		}
+	}
+	if !resolved.KnownAs(name) {
+		resolved.Aliases = append(resolved.Aliases, strings.TrimSpace(name))
+	}
+
+	return resolved, complete
+}
+
+// saveIdentity stores the MBID the name resolved to, under the artist's
+// own name as well
+func (m *MegaAggregator) saveIdentity(ctx context.Context, source, name string, artist domain.Artist) {
+	if m.identities == nil {
+		return
+	}
+	for _, known := range []string{name, artist.Name} {
+		identity := &domain.ArtistIdentity{Name: known, MBID: artist.ExternalIDs.MusicBrainzID, Source: source}
+		if err := m.identities.SaveIdentity(ctx, identity); err != nil {
+			m.config.Logger.Warn("failed to save artist identity", "artist", known, "error", err)
+			return
+		}
+	}
+}
+
+// searchNames are the names to search sources under: the artist's, the one
+// asked for, then aliases, leaving out spellings that differ only in case
+// or punctuation, as sources match those anyway
+func searchNames(artist domain.Artist, query string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, name := range append([]string{artist.Name, query}, artist.Aliases...) {
+		key := normalizeRankingName(name)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, strings.TrimSpace(name))
+		if len(names) == maxArtistNameSearches {
+			break
+		}
+	}
+	return names
+}
+
+// searchEachName runs search under every name and merges what they find.
+// It only fails when every name does.
+func searchEachName(names []string, search func(name string) ([]domain.Event, error)) ([]domain.Event, error) {
+	var events []domain.Event
+	var firstErr error
+	seen := make(map[string]bool)
+	succeeded := false
+
+	for _, name := range names {
+		found, err := search(name)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		succeeded = true
+		for _, event := range found {
+			if event.ID != "" && seen[event.ID] {
+				continue
+			}
+			seen[event.ID] = true
+			events = append(events, event)
+		}
+	}
+
+	if !succeeded && firstErr != nil {
+		return nil, firstErr
+	}
+	return events, nil
+}
+
+// fileUnderArtist renames the artist on an event found under one of its
+// aliases, so results from every name merge as the same artist's
+func fileUnderArtist(event domain.Event, artist domain.Artist) domain.Event {
+	if len(artist.Aliases) == 0 {
+		return event
+	}
+
+	if event.ArtistName != artist.Name && artist.KnownAs(event.ArtistName) {
+		event.ArtistName = artist.Name
+	}
+	for i, performer := range event.Lineup {
+		if performer.Name != artist.Name && artist.KnownAs(performer.Name) {
+			// The lineup is shared with the source's result
+			lineup := make([]domain.EventArtist, len(event.Lineup))
+			copy(lineup, event.Lineup)
+			lineup[i].Name = artist.Name
+			event.Lineup = lineup
+		}
+	}
+	return event
+}
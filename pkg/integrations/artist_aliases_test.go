@@ -0,0 +1,211 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// stubAliasSource knows a fixed set of artists and counts lookups
+type stubAliasSource struct {
+	mu      sync.Mutex
+	artists []domain.Artist
+	err     error
+	calls   int
+}
+
+func (s *stubAliasSource) ArtistAliases(ctx context.Context, name string) (*domain.Artist, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	for _, artist := range s.artists {
+		if artist.KnownAs(name) {
+			return &artist, nil
+		}
+	}
+	return nil, domain.ErrArtistNotFound
+}
+
+// namedEventSource has events by the exact artist name it's searched under
+type namedEventSource struct {
+	mu       sync.Mutex
+	events   map[string][]domain.Event
+	searched []string
+}
+
+func (s *namedEventSource) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.searched = append(s.searched, artistName)
+	return s.events[artistName], nil
+}
+
+func (s *namedEventSource) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+	return nil, nil
+}
+
+func (s *namedEventSource) GetName() string {
+	return "named"
+}
+
+func TestMegaAggregator_SearchEvents_ArtistAliases(t *testing.T) {
+	duo := "Ólafur Arnalds & Janus Rasmussen"
+	date := time.Date(2026, 11, 3, 20, 0, 0, 0, time.UTC)
+	source := &namedEventSource{events: map[string][]domain.Event{
+		"Kiasmos": {{ID: "named_1", ArtistName: "Kiasmos", Venue: domain.Venue{Name: "Barbican"}, DateTime: date}},
+		duo: {
+			{ID: "named_2", ArtistName: duo, Venue: domain.Venue{Name: "Tivoli"}, DateTime: date.AddDate(0, 0, 7),
+				Lineup: []domain.EventArtist{{Name: duo, Headliner: true}}},
+			// The same show, listed under both names
+			{ID: "named_1", ArtistName: "Kiasmos", Venue: domain.Venue{Name: "Barbican"}, DateTime: date},
+		},
+	}}
+	aliases := &stubAliasSource{artists: []domain.Artist{{Name: "Kiasmos", Aliases: []string{duo, "KIASMOS"}}}}
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true, DeduplicationEnabled: true})
+	aggregator.RegisterEventSource("named", source)
+	aggregator.RegisterAliasSource("musicbrainz", aliases)
+
+	results, err := aggregator.SearchEvents(context.Background(), "KIASMOS", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// "KIASMOS" only differs from Kiasmos in case, so it isn't searched
+	if len(source.searched) != 2 || source.searched[0] != "Kiasmos" || source.searched[1] != duo {
+		t.Errorf("expected searches for Kiasmos and the duo, got %q", source.searched)
+	}
+	if len(results.Events) != 2 {
+		t.Fatalf("expected both shows once, got %+v", results.Events)
+	}
+	for _, event := range results.Events {
+		if event.ArtistName != "Kiasmos" {
+			t.Errorf("expected %s merged under Kiasmos, got %q", event.ID, event.ArtistName)
+		}
+	}
+	if lineup := results.Events[1].Lineup; len(lineup) != 1 || lineup[0].Name != "Kiasmos" {
+		t.Errorf("expected the lineup renamed, got %+v", lineup)
+	}
+	if source.events[duo][0].Lineup[0].Name != duo {
+		t.Error("expected the source's lineup left alone")
+	}
+	if len(results.Artists) != 1 || results.Artists[0].Name != "Kiasmos" {
+		t.Errorf("expected the canonical artist, got %+v", results.Artists)
+	}
+
+	// Another spelling resolves from the table and shares the cached search
+	again, err := aggregator.SearchEvents(context.Background(), duo, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if again != results || aliases.calls != 1 || len(source.searched) != 2 {
+		t.Errorf("expected the cached search, got %d lookups and %d searches", aliases.calls, len(source.searched))
+	}
+
+	aggregator.InvalidateArtistEvents(duo)
+	if stats := aggregator.CacheStats(); stats.EventSearches != 0 {
+		t.Errorf("expected the canonical search invalidated, got %d cached", stats.EventSearches)
+	}
+}
+
+func TestMegaAggregator_ResolveArtist(t *testing.T) {
+	t.Run("unknown names are searched as given", func(t *testing.T) {
+		aliases := &stubAliasSource{}
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+		aggregator.RegisterAliasSource("musicbrainz", aliases)
+
+		for range 2 {
+			if artist := aggregator.resolveArtist(context.Background(), domain.Artist{Name: " Nobody "}); artist.Name != "Nobody" || len(artist.Aliases) != 0 {
+				t.Errorf("expected the name alone, got %+v", artist)
+			}
+		}
+		if aliases.calls != 1 {
+			t.Errorf("expected the miss remembered, got %d lookups", aliases.calls)
+		}
+	})
+
+	t.Run("failed lookups are retried", func(t *testing.T) {
+		aliases := &stubAliasSource{err: errors.New("status 503")}
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+		aggregator.RegisterAliasSource("musicbrainz", aliases)
+
+		aggregator.resolveArtist(context.Background(), domain.Artist{Name: "Kiasmos"})
+		aggregator.resolveArtist(context.Background(), domain.Artist{Name: "Kiasmos"})
+		if aliases.calls != 2 {
+			t.Errorf("expected a lookup per search, got %d", aliases.calls)
+		}
+	})
+
+	t.Run("sources registered first name the artist", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+		aggregator.RegisterAliasSource("musicbrainz", &stubAliasSource{artists: []domain.Artist{{Name: "Kiasmos"}}})
+		aggregator.RegisterAliasSource("ticketmaster", &stubAliasSource{artists: []domain.Artist{{Name: "KIASMOS", Aliases: []string{"Kiasmos Live"}}}})
+
+		stored := domain.Artist{ID: "artist-1", Name: "kiasmos", ExternalIDs: domain.ExternalIDs{MusicBrainzID: "mbid"}}
+		artist := aggregator.resolveArtist(context.Background(), stored)
+		if artist.Name != "Kiasmos" || artist.ID != "artist-1" || artist.ExternalIDs.MusicBrainzID != "mbid" {
+			t.Errorf("expected the stored artist under MusicBrainz's name, got %+v", artist)
+		}
+		if len(artist.Aliases) != 1 || artist.Aliases[0] != "Kiasmos Live" {
+			t.Errorf("expected Ticketmaster's alias, got %q", artist.Aliases)
+		}
+	})
+
+	t.Run("MBIDs are kept for other sources", func(t *testing.T) {
+		identities := &memoryIdentityRepository{}
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+		aggregator.SetArtistIdentities(identities)
+		kiasmos := domain.Artist{Name: "Kiasmos", Aliases: []string{"KIASMOS"}, ExternalIDs: domain.ExternalIDs{MusicBrainzID: "kiasmos-mbid"}}
+		aggregator.RegisterAliasSource("musicbrainz", &stubAliasSource{artists: []domain.Artist{kiasmos}})
+
+		aggregator.resolveArtist(context.Background(), domain.Artist{Name: "KIASMOS"})
+		for _, name := range []string{"KIASMOS", "Kiasmos"} {
+			if identity := identities.saved[name]; identity == nil || identity.MBID != "kiasmos-mbid" || identity.Source != "musicbrainz" {
+				t.Errorf("expected %s saved with its MBID, got %+v", name, identity)
+			}
+		}
+	})
+}
+
+// memoryIdentityRepository keeps identities by the name they were saved
+// under
+type memoryIdentityRepository struct {
+	saved map[string]*domain.ArtistIdentity
+}
+
+func (m *memoryIdentityRepository) GetIdentity(ctx context.Context, name string) (*domain.ArtistIdentity, error) {
+	if identity, ok := m.saved[name]; ok {
+		return identity, nil
+	}
+	return nil, domain.ErrIdentityNotFound
+}
+
+func (m *memoryIdentityRepository) SaveIdentity(ctx context.Context, identity *domain.ArtistIdentity) error {
+	if m.saved == nil {
+		m.saved = make(map[string]*domain.ArtistIdentity)
+	}
+	m.saved[identity.Name] = identity
+	return nil
+}
+
+func TestSearchNames(t *testing.T) {
+	artist := domain.Artist{Name: "Kiasmos", Aliases: []string{"KIASMOS", "Kiasmos Live", "Ólafur Arnalds & Janus Rasmussen", "Kiasmos DJ"}}
+
+	names := searchNames(artist, "Ólafur Arnalds & Janus Rasmussen")
+	want := []string{"Kiasmos", "Ólafur Arnalds & Janus Rasmussen", "Kiasmos Live"}
+	if len(names) != len(want) {
+		t.Fatalf("expected %q, got %q", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("expected %q, got %q", want, names)
+		}
+	}
+}
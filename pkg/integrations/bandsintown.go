@@ -6,71 +6,66 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
 )
 
 type BandsintownClient struct {
 	baseURL     string
 	appID       string
 	httpClient  *http.Client
-	rateLimiter *rateLimiter
+	rateLimiter *ratelimit.Limiter
+	// locationArtists are whose events location searches look up, within
+	// locationRadius miles of the city
+	locationArtists []string
+	locationRadius  int
 }
 
 type BandsintownConfig struct {
 	AppID string
-}
-
-type rateLimiter struct {
-	mu          sync.Mutex
-	requests    int
-	windowStart time.Time
-	dailyLimit  int
-}
-
-func newRateLimiter(dailyLimit int) *rateLimiter {
-	return &rateLimiter{
-		dailyLimit:  dailyLimit,
-		windowStart: time.Now(),
-	}
-}
-
-func (r *rateLimiter) Allow() error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	now := time.Now()
-	if now.Sub(r.windowStart) > 24*time.Hour {
-		r.requests = 0
-		r.windowStart = now
-	}
-
-	if r.requests >= r.dailyLimit {
-		return domain.ErrRateLimitExceeded
-	}
-
-	r.requests++
-	return nil
+	// LocationArtists are the artists whose events location searches
+	// include; every one costs a request per search
+	LocationArtists []string
+	// RadiusMiles is how far from the city location searches reach, at
+	// most 150; zero leaves it to Bandsintown
+	RadiusMiles int
 }
 
 func NewBandsintownClient(config BandsintownConfig) (*BandsintownClient, error) {
 	if config.AppID == "" {
 		return nil, fmt.Errorf("bandsintown app ID is required")
 	}
+	if config.RadiusMiles < 0 || config.RadiusMiles > bandsintownMaxRadius {
+		return nil, fmt.Errorf("bandsintown radius must be between 0 and %d miles", bandsintownMaxRadius)
+	}
 
 	return &BandsintownClient{
-		baseURL: "https://rest.bandsintown.com",
-		appID:   config.AppID,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		rateLimiter: newRateLimiter(1000),
+		baseURL:     "https://rest.bandsintown.com",
+		appID:       config.AppID,
+		httpClient:  httpclient.NewFor("bandsintown", 10*time.Second),
+		rateLimiter: ratelimit.PerDay("bandsintown", 1000),
+
+		locationArtists: config.LocationArtists,
+		locationRadius:  config.RadiusMiles,
 	}, nil
 }
 
+// UseQuotaStore restores and persists request counts so the daily quota
+// survives restarts
+func (c *BandsintownClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *BandsintownClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
+}
+
 type bandsintownEvent struct {
 	ID          string             `json:"id"`
 	ArtistID    string             `json:"artist_id"`
@@ -99,15 +94,55 @@ type bandsintownOffer struct {
 	Status string `json:"status"`
 }
 
+// Values of a BandsintownQuery's Date besides a BandsintownDateRange
+const (
+	BandsintownUpcoming = "upcoming"
+	BandsintownPast     = "past"
+	BandsintownAll      = "all"
+)
+
+// bandsintownMaxRadius is the widest radius, in miles, the events endpoint
+// takes
+const bandsintownMaxRadius = 150
+
+// BandsintownQuery narrows an artist's events. Zeros ask for what the
+// endpoint gives by default: upcoming events anywhere.
+type BandsintownQuery struct {
+	// Date is upcoming, past, all or a BandsintownDateRange
+	Date string
+	// Location is "City, Country" or "lat,lng"; Radius is in miles around
+	// it, at most 150, and only applies with a Location
+	Location string
+	Radius   int
+}
+
+// BandsintownDateRange is the Date of events on the days from and to fall
+// on, both included
+func BandsintownDateRange(from, to time.Time) string {
+	return from.Format("2006-01-02") + "," + to.Format("2006-01-02")
+}
+
 func (c *BandsintownClient) SearchEvents(ctx context.Context, artistName string, location string) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
-		return nil, err
-	}
+	return c.SearchArtistEvents(ctx, artistName, BandsintownQuery{Location: location})
+}
 
+func (c *BandsintownClient) GetArtistEvents(ctx context.Context, artistID string) ([]domain.Event, error) {
+	return c.SearchArtistEvents(ctx, artistID, BandsintownQuery{})
+}
+
+// SearchArtistEvents lists the artist's events matching query
+func (c *BandsintownClient) SearchArtistEvents(ctx context.Context, artistName string, query BandsintownQuery) ([]domain.Event, error) {
 	artistName = strings.TrimSpace(artistName)
 	if artistName == "" {
 		return nil, domain.ErrInvalidRequest
 	}
+	if query.Radius < 0 || query.Radius > bandsintownMaxRadius {
+		return nil, fmt.Errorf("%w: radius must be between 0 and %d miles", domain.ErrInvalidRequest, bandsintownMaxRadius)
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
 
 	eventsURL := fmt.Sprintf("%s/artists/%s/events", c.baseURL, url.QueryEscape(artistName))
 	req, err := http.NewRequestWithContext(ctx, "GET", eventsURL, nil)
@@ -117,8 +152,14 @@ func (c *BandsintownClient) SearchEvents(ctx context.Context, artistName string,
 
 	q := req.URL.Query()
 	q.Set("app_id", c.appID)
-	if location != "" {
-		q.Set("location", location)
+	if query.Date != "" {
+		q.Set("date", query.Date)
+	}
+	if query.Location != "" {
+		q.Set("location", query.Location)
+		if query.Radius > 0 {
+			q.Set("radius", strconv.Itoa(query.Radius))
+		}
 	}
 	req.URL.RawQuery = q.Encode()
 
@@ -152,49 +193,92 @@ func (c *BandsintownClient) SearchEvents(ctx context.Context, artistName string,
 	return events, nil
 }
 
-func (c *BandsintownClient) GetArtistEvents(ctx context.Context, artistID string) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
-		return nil, err
-	}
+func (c *BandsintownClient) GetName() string {
+	return "bandsintown"
+}
 
-	eventsURL := fmt.Sprintf("%s/artists/%s/events", c.baseURL, url.QueryEscape(artistID))
-	req, err := http.NewRequestWithContext(ctx, "GET", eventsURL, nil)
+// SearchEventsByArtist lists the artist's upcoming events, soonest first
+func (c *BandsintownClient) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+	events, err := c.SearchArtistEvents(ctx, artistName, BandsintownQuery{Date: BandsintownUpcoming})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
+	return soonestFirst(events, limit), nil
+}
 
-	q := req.URL.Query()
-	q.Set("app_id", c.appID)
-	req.URL.RawQuery = q.Encode()
+// SearchEventsByLocation looks up the upcoming events of the location
+// artists within the radius of the city. Bandsintown only lists events by
+// artist, so without location artists there's nothing to search.
+func (c *BandsintownClient) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+	return c.searchLocation(ctx, city, country, BandsintownUpcoming, limit)
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get artist events: %w", err)
-	}
-	defer resp.Body.Close()
+// SearchEventsByLocationBetween is SearchEventsByLocation for events on the
+// days from and to fall on
+func (c *BandsintownClient) SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) ([]domain.Event, error) {
+	return c.searchLocation(ctx, city, country, BandsintownDateRange(from, to), limit)
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return []domain.Event{}, nil
-	}
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bandsintown get events failed: status %d", resp.StatusCode)
+func (c *BandsintownClient) searchLocation(ctx context.Context, city, country, date string, limit int) ([]domain.Event, error) {
+	city = strings.TrimSpace(city)
+	if city == "" {
+		return nil, domain.ErrInvalidRequest
 	}
-
-	var bandsintownEvents []bandsintownEvent
-	if err := json.NewDecoder(resp.Body).Decode(&bandsintownEvents); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	location := city
+	if country = strings.TrimSpace(country); country != "" {
+		location += ", " + country
 	}
 
-	events := make([]domain.Event, 0, len(bandsintownEvents))
-	for _, btEvent := range bandsintownEvents {
-		event, err := c.convertToDomainEvent(btEvent, artistID)
+	query := BandsintownQuery{Date: date, Location: location, Radius: c.locationRadius}
+	events := []domain.Event{}
+	var firstErr error
+	for _, artistName := range c.locationArtists {
+		found, err := c.SearchArtistEvents(ctx, artistName, query)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
 			continue
 		}
-		events = append(events, event)
+		events = append(events, found...)
+	}
+	// One artist failing still leaves the others' events
+	if len(events) == 0 && firstErr != nil {
+		return nil, firstErr
 	}
+	return soonestFirst(events, limit), nil
+}
 
-	return events, nil
+// soonestFirst sorts events by date and keeps the first limit of them
+func soonestFirst(events []domain.Event, limit int) []domain.Event {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+	if limit > 0 && len(events) > limit {
+		events = events[:limit]
+	}
+	return events
+}
+
+// bandsintownLineup turns the lineup names, which come headliner first, into
+// a lineup. Only the artist that was looked up has a known ID.
+func bandsintownLineup(btEvent bandsintownEvent, artistName string) []domain.EventArtist {
+	if len(btEvent.Lineup) == 0 {
+		return domain.HeadlinerLineup(btEvent.ArtistID, artistName)
+	}
+
+	lineup := make([]domain.EventArtist, 0, len(btEvent.Lineup))
+	for i, name := range btEvent.Lineup {
+		artist := domain.EventArtist{Name: name, Billing: i + 1, Headliner: i == 0}
+		if strings.EqualFold(name, artistName) {
+			artist.ID = btEvent.ArtistID
+		}
+		lineup = append(lineup, artist)
+	}
+	return lineup
 }
 
 func (c *BandsintownClient) convertToDomainEvent(btEvent bandsintownEvent, artistName string) (domain.Event, error) {
@@ -227,11 +311,17 @@ func (c *BandsintownClient) convertToDomainEvent(btEvent bandsintownEvent, artis
 			Latitude:  lat,
 			Longitude: lng,
 		},
+		// Bandsintown drops cancelled events rather than flagging them
+		Status: domain.EventScheduled,
+		Lineup: bandsintownLineup(btEvent, artistName),
 		ExternalIDs: domain.EventExternalIDs{
 			BandsintownID: btEvent.ID,
 		},
-		CachedUntil: time.Now().Add(24 * time.Hour),
+		// Events whose time can't be read are dropped above
+		DateConfidence: domain.DateExact,
+		CachedUntil:    time.Now().Add(24 * time.Hour),
 	}
+	event.Venue.NormalizeCountry()
 
 	if btEvent.OnSaleDate != "" {
 		onSaleTime, err := time.Parse(time.RFC3339, btEvent.OnSaleDate)
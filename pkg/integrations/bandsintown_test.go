@@ -3,12 +3,15 @@ package integrations
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/ratelimit"
 )
 
 func TestNewBandsintownClient(t *testing.T) {
@@ -92,7 +95,7 @@ func TestBandsintownClient_SearchEvents(t *testing.T) {
 		baseURL:     mockServer.URL,
 		appID:       "test-app",
 		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newRateLimiter(1000),
+		rateLimiter: ratelimit.PerDay("bandsintown", 1000),
 	}
 
 	t.Run("successful search", func(t *testing.T) {
@@ -159,7 +162,7 @@ func TestBandsintownClient_GetArtistEvents(t *testing.T) {
 		baseURL:     mockServer.URL,
 		appID:       "test-app",
 		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newRateLimiter(1000),
+		rateLimiter: ratelimit.PerDay("bandsintown", 1000),
 	}
 
 	t.Run("successful get", func(t *testing.T) {
@@ -177,43 +180,111 @@ func TestBandsintownClient_GetArtistEvents(t *testing.T) {
 	})
 }
 
-func TestRateLimiter(t *testing.T) {
-	t.Run("allows requests within limit", func(t *testing.T) {
-		limiter := newRateLimiter(5)
+func TestBandsintownClient_Queries(t *testing.T) {
+	var queries []string
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Path+"?"+r.URL.Query().Encode())
+		when := time.Date(2026, 5, 2, 20, 0, 0, 0, time.UTC)
+		if strings.Contains(r.URL.Path, "Portishead") {
+			when = when.Add(-24 * time.Hour)
+		}
+		json.NewEncoder(w).Encode([]bandsintownEvent{{
+			ID:       r.URL.Path,
+			DateTime: when.Format(time.RFC3339),
+			Venue:    bandsintownVenue{Name: "Tempodrom", City: "Berlin", Country: "Germany"},
+		}})
+	}))
+	defer mockServer.Close()
 
-		for i := 0; i < 5; i++ {
-			err := limiter.Allow()
-			if err != nil {
-				t.Errorf("expected request %d to be allowed, got %v", i+1, err)
-			}
-		}
+	client, err := NewBandsintownClient(BandsintownConfig{
+		AppID:           "test-app",
+		LocationArtists: []string{"Radiohead", "Portishead"},
+		RadiusMiles:     25,
 	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client.baseURL = mockServer.URL
+	ctx := context.Background()
+
+	from := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := client.SearchArtistEvents(ctx, "Radiohead", BandsintownQuery{Date: BandsintownDateRange(from, from.AddDate(0, 0, 6))}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if queries[0] != "/artists/Radiohead/events?app_id=test-app&date=2026-05-01%2C2026-05-07" {
+		t.Errorf("unexpected date range query %s", queries[0])
+	}
+	if _, err := client.SearchArtistEvents(ctx, "Radiohead", BandsintownQuery{Radius: 200}); !errors.Is(err, domain.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest for a radius over 150 miles, got %v", err)
+	}
 
-	t.Run("blocks requests over limit", func(t *testing.T) {
-		limiter := newRateLimiter(2)
+	queries = nil
+	events, err := client.SearchEventsByLocation(ctx, "Berlin", "Germany", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(queries) != 2 || queries[0] != "/artists/Radiohead/events?app_id=test-app&date=upcoming&location=Berlin%2C+Germany&radius=25" {
+		t.Errorf("expected each location artist searched near Berlin, got %v", queries)
+	}
+	if len(events) != 2 || events[0].ID != "bandsintown_/artists/Portishead/events" {
+		t.Errorf("expected both artists' events soonest first, got %+v", events)
+	}
 
-		limiter.Allow()
-		limiter.Allow()
+	if _, err := NewBandsintownClient(BandsintownConfig{AppID: "test-app", RadiusMiles: 151}); err == nil {
+		t.Error("expected an error for a radius over 150 miles")
+	}
+	unconfigured, _ := NewBandsintownClient(BandsintownConfig{AppID: "test-app"})
+	if events, err := unconfigured.SearchEventsByLocation(ctx, "Berlin", "Germany", 10); err != nil || len(events) != 0 {
+		t.Errorf("expected no events without location artists, got %d and %v", len(events), err)
+	}
+}
 
-		err := limiter.Allow()
-		if err != domain.ErrRateLimitExceeded {
-			t.Errorf("expected ErrRateLimitExceeded, got %v", err)
+type memoryQuotaRepository struct {
+	requests map[string][]domain.SourceRequest
+}
+
+func (m *memoryQuotaRepository) RecordRequest(ctx context.Context, source string, requestedAt time.Time, cost int) error {
+	if m.requests == nil {
+		m.requests = make(map[string][]domain.SourceRequest)
+	}
+	m.requests[source] = append(m.requests[source], domain.SourceRequest{RequestedAt: requestedAt, Cost: cost})
+	return nil
+}
+
+func (m *memoryQuotaRepository) GetRequestsSince(ctx context.Context, source string, since time.Time) ([]domain.SourceRequest, error) {
+	found := []domain.SourceRequest{}
+	for _, request := range m.requests[source] {
+		if request.RequestedAt.After(since) {
+			found = append(found, request)
 		}
-	})
+	}
+	return found, nil
+}
 
-	t.Run("resets after 24 hours", func(t *testing.T) {
-		limiter := newRateLimiter(1)
+func (m *memoryQuotaRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	return nil
+}
 
-		limiter.Allow()
+func TestBandsintownClient_Quota(t *testing.T) {
+	store := &memoryQuotaRepository{}
+	store.RecordRequest(context.Background(), "bandsintown", time.Now().Add(-time.Minute), 1)
 
-		// Manually set window start to 25 hours ago
-		limiter.windowStart = time.Now().Add(-25 * time.Hour)
+	client, _ := NewBandsintownClient(BandsintownConfig{AppID: "test"})
+	if err := client.UseQuotaStore(context.Background(), store); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client.rateLimiter.Allow()
 
-		err := limiter.Allow()
-		if err != nil {
-			t.Errorf("expected request to be allowed after reset, got %v", err)
-		}
-	})
+	quota := client.Quota()
+	if quota.Source != "bandsintown" {
+		t.Errorf("expected source bandsintown, got %s", quota.Source)
+	}
+	if quota.Used != 2 || quota.Remaining != 998 {
+		t.Errorf("expected 2 used and 998 remaining, got %d and %d", quota.Used, quota.Remaining)
+	}
+	if len(store.requests["bandsintown"]) != 2 {
+		t.Errorf("expected allowed request to be persisted, got %d stored", len(store.requests["bandsintown"]))
+	}
 }
 
 func TestConvertToDomainEvent(t *testing.T) {
@@ -0,0 +1,227 @@
+// Package cassette records the HTTP responses source clients get and
+// replays them in tests, so the mapping from each provider's JSON can be
+// tested without network access or API keys.
+//
+// A cassette is a JSON file of interactions. In replay mode a request is
+// answered by the interaction with the same method and URL; in record mode
+// requests go out and their responses are saved when the cassette is
+// closed. Credentials are left out of what's saved: request headers aren't
+// kept, query parameters carrying keys are dropped from URLs and token
+// fields are blanked in response bodies.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+)
+
+// Mode is whether a cassette replays or records
+type Mode int
+
+const (
+	ModeReplay Mode = iota
+	ModeRecord
+)
+
+// Interaction is one request and the response it got
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+// Response keeps the body as raw JSON when it is JSON, so cassettes stay
+// readable and easy to edit, and as a string otherwise
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Cassette is an http.RoundTripper that replays or records interactions
+type Cassette struct {
+	path string
+	mode Mode
+	base http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	// played counts how often each key was replayed, so repeated requests
+	// get the interactions recorded for them in order
+	played map[string]int
+}
+
+// Load opens the cassette at path. In replay mode it must exist; in record
+// mode it is overwritten on Close and requests are sent through base, or
+// http.DefaultTransport when base is nil.
+func Load(path string, mode Mode, base http.RoundTripper) (*Cassette, error) {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	c := &Cassette{path: path, mode: mode, base: base, played: make(map[string]int)}
+	if mode == ModeRecord {
+		return c, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	if err := json.Unmarshal(raw, &c.interactions); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Close saves what was recorded. Replaying cassettes have nothing to save.
+func (c *Cassette) Close() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	raw, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(c.path, append(raw, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := Request{Method: req.Method, URL: scrubURL(req.URL)}
+	if c.mode == ModeRecord {
+		return c.record(req, key)
+	}
+	return c.replay(req, key)
+}
+
+func (c *Cassette) replay(req *http.Request, key Request) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matches []Interaction
+	for _, interaction := range c.interactions {
+		if interaction.Request == key {
+			matches = append(matches, interaction)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("cassette %s has no response for %s %s", filepath.Base(c.path), key.Method, key.URL)
+	}
+
+	// Once every recorded response was played, the last one is repeated
+	index := c.played[key.Method+" "+key.URL]
+	c.played[key.Method+" "+key.URL]++
+	if index >= len(matches) {
+		index = len(matches) - 1
+	}
+	return matches[index].Response.toHTTP(req), nil
+}
+
+func (c *Cassette) record(req *http.Request, key Request) (*http.Response, error) {
+	resp, err := c.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response to record: %w", err)
+	}
+
+	recorded := Response{Status: resp.StatusCode, Headers: map[string]string{}}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		recorded.Headers["Content-Type"] = contentType
+	}
+	recorded.Body = encodeBody(httpclient.RedactBody(body))
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, Interaction{Request: key, Response: recorded})
+	c.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+func (r Response) toHTTP(req *http.Request) *http.Response {
+	header := make(http.Header)
+	for name, value := range r.Headers {
+		header.Set(name, value)
+	}
+	body := decodeBody(r.Body)
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// scrubURL drops credentials from u and sorts its query, so the same
+// request matches however its parameters were ordered
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+	scrubbed.User = nil
+
+	query := u.Query()
+	for name := range query {
+		if httpclient.IsSecretParam(name) {
+			query.Del(name)
+		}
+	}
+	// Encode sorts by key
+	scrubbed.RawQuery = query.Encode()
+	return scrubbed.String()
+}
+
+// encodeBody keeps JSON bodies as they are and quotes anything else
+func encodeBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		var compact bytes.Buffer
+		if json.Compact(&compact, body) == nil {
+			return compact.Bytes()
+		}
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// decodeBody undoes encodeBody: a JSON string is the body as text
+func decodeBody(raw json.RawMessage) []byte {
+	var text string
+	if len(raw) > 0 && raw[0] == '"' && json.Unmarshal(raw, &text) == nil {
+		return []byte(text)
+	}
+	return raw
+}
@@ -0,0 +1,95 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestCassette_RecordThenReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch r.URL.Path {
+		case "/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"secret-token","expires_in":3600}`))
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<p>call " + string(rune('0'+calls)) + "</p>"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "test.json")
+	recorder, err := Load(path, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client := &http.Client{Transport: recorder}
+
+	if _, body := get(t, client, server.URL+"/token?apikey=k1&b=2&a=1"); !strings.Contains(body, "secret-token") {
+		t.Errorf("expected the live response while recording, got %s", body)
+	}
+	get(t, client, server.URL+"/page")
+	get(t, client, server.URL+"/page")
+	get(t, client, server.URL+"/missing")
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("expected no error saving, got %v", err)
+	}
+
+	saved, _ := os.ReadFile(path)
+	if strings.Contains(string(saved), "secret-token") || strings.Contains(string(saved), "k1") {
+		t.Fatalf("expected credentials left out of the cassette, got %s", saved)
+	}
+
+	player, err := Load(path, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client = &http.Client{Transport: player}
+	server.Close()
+
+	// Matched without the key and whatever the parameter order
+	if status, body := get(t, client, server.URL+"/token?a=1&b=2&apikey=other"); status != http.StatusOK || !strings.Contains(body, `"REDACTED"`) {
+		t.Errorf("unexpected token response %d %s", status, body)
+	}
+
+	// Repeated requests replay in recorded order, then repeat the last
+	for _, want := range []string{"<p>call 2</p>", "<p>call 3</p>", "<p>call 3</p>"} {
+		if _, body := get(t, client, server.URL+"/page"); body != want {
+			t.Errorf("expected %q, got %q", want, body)
+		}
+	}
+
+	if status, _ := get(t, client, server.URL+"/missing"); status != http.StatusNotFound {
+		t.Errorf("expected the recorded 404, got %d", status)
+	}
+
+	if _, err := client.Get(server.URL + "/unrecorded"); err == nil || !strings.Contains(err.Error(), "no response for GET") {
+		t.Errorf("expected an error for an unrecorded request, got %v", err)
+	}
+}
+
+func TestLoad_MissingCassette(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "none.json"), ModeReplay, nil); err == nil {
+		t.Error("expected an error replaying a cassette that doesn't exist")
+	}
+}
@@ -0,0 +1,143 @@
+package integrations
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+type CircuitBreakerConfig struct {
+	FailureThreshold int
+	CoolDown         time.Duration
+}
+
+// CircuitBreaker stops calling a source after repeated failures and lets a
+// single trial request through once the cool-down has elapsed.
+type CircuitBreaker struct {
+	mu                  sync.Mutex
+	config              CircuitBreakerConfig
+	state               BreakerState
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInFlight       bool
+	now                 func() time.Time
+}
+
+func NewCircuitBreaker(config CircuitBreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = 30 * time.Second
+	}
+
+	return &CircuitBreaker{
+		config: config,
+		state:  BreakerClosed,
+		now:    time.Now,
+	}
+}
+
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.config.CoolDown {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.trialInFlight = true
+		return nil
+	case BreakerHalfOpen:
+		if b.trialInFlight {
+			return ErrCircuitOpen
+		}
+		b.trialInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.consecutiveFailures = 0
+	b.trialInFlight = false
+}
+
+func (b *CircuitBreaker) RecordFailure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.consecutiveFailures++
+	b.trialInFlight = false
+
+	if b.state == BreakerHalfOpen || b.consecutiveFailures >= b.config.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+}
+
+// ReleaseTrial gives up a call that ended without saying anything about the
+// source's health, such as one the caller cancelled. A half-open breaker
+// lets the next request through as its trial instead.
+func (b *CircuitBreaker) ReleaseTrial() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialInFlight = false
+}
+
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BreakerSnapshot{
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+	}
+
+	if b.state == BreakerOpen {
+		retryAt := b.openedAt.Add(b.config.CoolDown)
+		if b.now().Before(retryAt) {
+			snapshot.RetryAt = &retryAt
+		} else {
+			snapshot.State = BreakerHalfOpen
+		}
+	}
+
+	return snapshot
+}
+
+type BreakerSnapshot struct {
+	State               BreakerState
+	ConsecutiveFailures int
+	RetryAt             *time.Time
+}
+
+// SourceStatus maps the breaker snapshot onto the status reported by /api/sources.
+func (s BreakerSnapshot) SourceStatus() string {
+	switch {
+	case s.State == BreakerOpen:
+		return "open"
+	case s.State == BreakerHalfOpen || s.ConsecutiveFailures > 0:
+		return "degraded"
+	default:
+		return "active"
+	}
+}
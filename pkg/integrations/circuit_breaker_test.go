@@ -0,0 +1,223 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubMusicSource struct {
+	name    string
+	artists []domain.Artist
+	err     error
+	calls   int
+}
+
+func (s *stubMusicSource) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
+	s.calls++
+	return s.artists, s.err
+}
+
+func (s *stubMusicSource) GetName() string {
+	return s.name
+}
+
+func newTestBreaker(threshold int, coolDown time.Duration) (*CircuitBreaker, *time.Time) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	breaker := NewCircuitBreaker(CircuitBreakerConfig{
+		FailureThreshold: threshold,
+		CoolDown:         coolDown,
+	})
+	breaker.now = func() time.Time { return now }
+	return breaker, &now
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("opens after threshold", func(t *testing.T) {
+		breaker, _ := newTestBreaker(3, time.Minute)
+
+		for i := 0; i < 2; i++ {
+			breaker.RecordFailure()
+		}
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("expected breaker to allow below threshold, got %v", err)
+		}
+		if status := breaker.Snapshot().SourceStatus(); status != "degraded" {
+			t.Errorf("expected status degraded, got %s", status)
+		}
+
+		breaker.RecordFailure()
+		if err := breaker.Allow(); err != ErrCircuitOpen {
+			t.Errorf("expected ErrCircuitOpen, got %v", err)
+		}
+
+		snapshot := breaker.Snapshot()
+		if snapshot.SourceStatus() != "open" {
+			t.Errorf("expected status open, got %s", snapshot.SourceStatus())
+		}
+		if snapshot.RetryAt == nil {
+			t.Error("expected RetryAt to be set while open")
+		}
+	})
+
+	t.Run("half-open allows a single trial", func(t *testing.T) {
+		breaker, now := newTestBreaker(1, time.Minute)
+		breaker.RecordFailure()
+
+		*now = now.Add(2 * time.Minute)
+
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("expected trial request after cool-down, got %v", err)
+		}
+		if err := breaker.Allow(); err != ErrCircuitOpen {
+			t.Errorf("expected second request to be rejected during trial, got %v", err)
+		}
+	})
+
+	t.Run("successful trial closes breaker", func(t *testing.T) {
+		breaker, now := newTestBreaker(1, time.Minute)
+		breaker.RecordFailure()
+		*now = now.Add(2 * time.Minute)
+
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("expected trial request, got %v", err)
+		}
+		breaker.RecordSuccess()
+
+		snapshot := breaker.Snapshot()
+		if snapshot.State != BreakerClosed {
+			t.Errorf("expected closed state, got %s", snapshot.State)
+		}
+		if snapshot.SourceStatus() != "active" {
+			t.Errorf("expected status active, got %s", snapshot.SourceStatus())
+		}
+	})
+
+	t.Run("failed trial reopens breaker", func(t *testing.T) {
+		breaker, now := newTestBreaker(3, time.Minute)
+		for i := 0; i < 3; i++ {
+			breaker.RecordFailure()
+		}
+		*now = now.Add(2 * time.Minute)
+
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("expected trial request, got %v", err)
+		}
+		breaker.RecordFailure()
+
+		if err := breaker.Allow(); err != ErrCircuitOpen {
+			t.Errorf("expected ErrCircuitOpen after failed trial, got %v", err)
+		}
+	})
+
+	t.Run("released trial lets the next request through", func(t *testing.T) {
+		breaker, now := newTestBreaker(1, time.Minute)
+		breaker.RecordFailure()
+		*now = now.Add(2 * time.Minute)
+
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("expected trial request, got %v", err)
+		}
+		breaker.ReleaseTrial()
+
+		if err := breaker.Allow(); err != nil {
+			t.Fatalf("expected another trial after release, got %v", err)
+		}
+		if snapshot := breaker.Snapshot(); snapshot.State != BreakerHalfOpen || snapshot.ConsecutiveFailures != 1 {
+			t.Errorf("expected half-open with 1 failure, got %s with %d", snapshot.State, snapshot.ConsecutiveFailures)
+		}
+	})
+}
+
+func TestMegaAggregator_CircuitBreaker(t *testing.T) {
+	t.Run("skips open source", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{
+			BreakerThreshold: 2,
+			BreakerCoolDown:  time.Hour,
+		})
+
+		failing := &stubMusicSource{name: "failing", err: errors.New("upstream down")}
+		healthy := &stubMusicSource{name: "healthy", artists: []domain.Artist{{ID: "1", Name: "Test Artist"}}}
+		aggregator.RegisterMusicSource("failing", failing)
+		aggregator.RegisterMusicSource("healthy", healthy)
+
+		ctx := context.Background()
+		for i := 0; i < 3; i++ {
+			if _, err := aggregator.SearchArtists(ctx, "test", 10); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		if failing.calls != 2 {
+			t.Errorf("expected failing source to be called 2 times, got %d", failing.calls)
+		}
+		if healthy.calls != 3 {
+			t.Errorf("expected healthy source to be called 3 times, got %d", healthy.calls)
+		}
+
+		stats := aggregator.GetSourceStats()
+		if stats["failing"].Status != "open" {
+			t.Errorf("expected failing source to be open, got %s", stats["failing"].Status)
+		}
+		if stats["failing"].RetryAt == nil {
+			t.Error("expected RetryAt for open source")
+		}
+		if stats["healthy"].Status != "active" {
+			t.Errorf("expected healthy source to be active, got %s", stats["healthy"].Status)
+		}
+	})
+
+	t.Run("invalid requests do not trip breaker", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{BreakerThreshold: 1})
+		source := &stubMusicSource{name: "picky", err: domain.ErrInvalidRequest}
+		aggregator.RegisterMusicSource("picky", source)
+
+		if _, err := aggregator.SearchArtists(context.Background(), "", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if status := aggregator.GetSourceStats()["picky"].Status; status != "active" {
+			t.Errorf("expected status active, got %s", status)
+		}
+	})
+
+	t.Run("cancelled trial does not leave breaker stuck", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{
+			BreakerThreshold: 1,
+			BreakerCoolDown:  time.Minute,
+		})
+		source := &stubMusicSource{name: "flaky", err: errors.New("upstream down")}
+		aggregator.RegisterMusicSource("flaky", source)
+
+		ctx := context.Background()
+		if _, err := aggregator.SearchArtists(ctx, "test", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		breaker := aggregator.breakerFor("flaky")
+		breaker.mu.Lock()
+		breaker.openedAt = breaker.openedAt.Add(-2 * time.Minute)
+		breaker.mu.Unlock()
+
+		source.err = context.Canceled
+		if _, err := aggregator.SearchArtists(ctx, "test", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		source.err = nil
+		source.artists = []domain.Artist{{ID: "1", Name: "Test Artist"}}
+		if _, err := aggregator.SearchArtists(ctx, "test", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if source.calls != 3 {
+			t.Errorf("expected the trial to be retried after cancellation, got %d calls", source.calls)
+		}
+		if status := aggregator.GetSourceStats()["flaky"].Status; status != "active" {
+			t.Errorf("expected status active, got %s", status)
+		}
+	})
+}
@@ -3,23 +3,34 @@ package integrations
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/yair/where-its-at/pkg/integrations/oauth"
 )
 
-func TestSpotifyClient_getAccessToken(t *testing.T) {
+func TestSpotifyClient_tokens(t *testing.T) {
 	t.Run("token already valid", func(t *testing.T) {
-		client := &SpotifyClient{
-			accessToken: "valid-token",
-			tokenExpiry: time.Now().Add(time.Hour),
-		}
+		tokenCalls := 0
+		mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenCalls++
+			w.Write([]byte(`{"access_token":"valid-token","token_type":"Bearer","expires_in":3600}`))
+		}))
+		defer mockServer.Close()
 
-		err := client.getAccessToken(context.Background())
-		if err != nil {
-			t.Errorf("expected no error, got %v", err)
+		tokens := newSpotifyTokenProvider(mockServer.Client(), mockServer.URL, "test-id", "test-secret")
+		for i := 0; i < 2; i++ {
+			token, err := tokens.Token(context.Background())
+			if err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if token != "valid-token" {
+				t.Errorf("expected token to remain valid-token, got %s", token)
+			}
 		}
-		if client.accessToken != "valid-token" {
-			t.Errorf("expected token to remain valid-token, got %s", client.accessToken)
+		if tokenCalls != 1 {
+			t.Errorf("expected the token fetched once, got %d", tokenCalls)
 		}
 	})
 }
@@ -30,8 +41,7 @@ func TestSpotifyClient_SearchArtists_Limits(t *testing.T) {
 		clientID:     "test-id",
 		clientSecret: "test-secret",
 		httpClient:   &http.Client{Timeout: 10 * time.Second},
-		accessToken:  "test-token",
-		tokenExpiry:  time.Now().Add(time.Hour),
+		tokens:       oauth.StaticToken("test-token"),
 	}
 
 	t.Run("zero limit defaults to 10", func(t *testing.T) {
@@ -0,0 +1,145 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DateRangeEventSource is an EventSource that can narrow a location search
+// to events starting between from and to, so a search for one day doesn't
+// page through a city's whole calendar
+type DateRangeEventSource interface {
+	SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) ([]domain.Event, error)
+}
+
+// SearchEventsByLocationBetween asks only the event sources that filter by
+// date for events in the city starting between from and to. Sources that
+// can't filter, and scrapers, are left out: they'd return the city's whole
+// calendar and be the slowest to answer.
+func (m *MegaAggregator) SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) (*AggregatedResults, error) {
+	startTime := time.Now()
+	city = domain.NormalizeCity(city)
+	if code := domain.CountryCode(country); code != "" {
+		country = code
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "aggregator.search_events_by_location_between", trace.WithAttributes(
+		attribute.String("city", city),
+		attribute.String("country", country),
+		attribute.String("from", from.UTC().Format(time.RFC3339)),
+		attribute.String("to", to.UTC().Format(time.RFC3339)),
+	))
+	defer span.End()
+	filter := SourceFilterFrom(ctx)
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	cacheKey := fmt.Sprintf("%s|%s|%s|%s%s", city, country, from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339), filter.cacheKey())
+	if m.cache != nil {
+		cached := m.cache.GetEvents("", cacheKey, limit)
+		m.observeCacheLookup(span, "events", cached != nil)
+		if cached != nil {
+			return cached, nil
+		}
+	}
+
+	eventSources := m.sources().events
+	resultsChan := make(chan SourceResult, len(eventSources))
+	ctx, cancel := context.WithTimeout(ctx, m.searchTimeout())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, m.config.MaxConcurrentRequests)
+	errors := []string{}
+
+	for name, source := range eventSources {
+		ranged, ok := source.(DateRangeEventSource)
+		if !ok || !m.shouldSearch(filter, name) {
+			continue
+		}
+		if err := m.breakerFor(name).Allow(); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		wg.Add(1)
+		go func(sourceName string, src DateRangeEventSource) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			ctx, maxResults, release := m.limitSource(ctx, sourceName)
+			defer release()
+
+			ctx, span := m.startSourceSpan(ctx, sourceName, "search_events_by_location_between")
+			start := time.Now()
+			events, err := src.SearchEventsByLocationBetween(ctx, city, country, from, to, maxResults)
+			duration := m.finishSourceCall(ctx, span, sourceName, start, err)
+			resultsChan <- SourceResult{
+				SourceName: sourceName,
+				Events:     events,
+				Error:      err,
+				Duration:   duration,
+			}
+		}(name, ranged)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	candidates := []rankedEvent{}
+	sourceStats := make(map[string]int)
+	sourceDurations := make(map[string]time.Duration)
+	rankQuery := EventQuery{City: city}
+	now := time.Now()
+
+	for result := range resultsChan {
+		sourceDurations[result.SourceName] = result.Duration
+
+		if result.Error != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", result.SourceName, result.Error))
+			continue
+		}
+
+		sourceStats[result.SourceName] = 0
+		for _, event := range result.Events {
+			// Sources filter by day at best, so trim to the exact range
+			if event.DateTime.Before(from) || event.DateTime.After(to) {
+				continue
+			}
+			sourceStats[result.SourceName]++
+			candidates = append(candidates, rankedEvent{
+				event: event,
+				score: m.config.Ranker.ScoreEvent(rankQuery, result.SourceName, event, now),
+			})
+		}
+	}
+
+	allEvents, scores := m.rankEvents(candidates, limit)
+
+	results := &AggregatedResults{
+		Artists:         []domain.Artist{},
+		Events:          allEvents,
+		SourceStats:     sourceStats,
+		SourceDurations: sourceDurations,
+		TotalResults:    len(allEvents),
+		SearchTime:      time.Since(startTime),
+		Errors:          errors,
+		Scores:          scores,
+	}
+
+	if m.cache != nil {
+		m.cache.SetEvents("", cacheKey, limit, results)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,74 @@
+package integrations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// stubDateRangeSource records the range it was asked about and returns its
+// events regardless, like a source that filters by day
+type stubDateRangeSource struct {
+	stubEventSource
+	from, to time.Time
+}
+
+func (s *stubDateRangeSource) SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) ([]domain.Event, error) {
+	s.from, s.to = from, to
+	return s.events, s.err
+}
+
+func TestMegaAggregator_SearchEventsByLocationBetween(t *testing.T) {
+	from := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+	ranged := &stubDateRangeSource{stubEventSource: stubEventSource{
+		name: "songkick",
+		events: []domain.Event{
+			{ID: "songkick_1", ArtistName: "Tonight", Venue: domain.Venue{Name: "A", City: "Berlin"}, DateTime: from.Add(20 * time.Hour)},
+			{ID: "songkick_2", ArtistName: "Next Week", Venue: domain.Venue{Name: "B", City: "Berlin"}, DateTime: from.AddDate(0, 0, 7)},
+		},
+	}}
+	unranged := &stubEventSource{
+		name:   "fixtures",
+		events: []domain.Event{{ID: "fixtures_1", ArtistName: "Tonight Too", Venue: domain.Venue{Name: "C", City: "Berlin"}, DateTime: from.Add(21 * time.Hour)}},
+	}
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true})
+	aggregator.RegisterEventSource("songkick", ranged)
+	aggregator.RegisterEventSource("fixtures", unranged)
+
+	results, err := aggregator.SearchEventsByLocationBetween(context.Background(), "Berlin", "DE", from, to, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !ranged.from.Equal(from) || !ranged.to.Equal(to) {
+		t.Errorf("expected the range passed on, got %v to %v", ranged.from, ranged.to)
+	}
+	if len(results.Events) != 1 || results.Events[0].ID != "songkick_1" {
+		t.Errorf("expected only the date-filtering source's event in range, got %+v", results.Events)
+	}
+	if _, asked := results.SourceStats["fixtures"]; asked {
+		t.Errorf("expected sources without date filters skipped, got %v", results.SourceStats)
+	}
+	if results.SourceStats["songkick"] != 1 {
+		t.Errorf("expected the events out of range left out of the count, got %v", results.SourceStats)
+	}
+
+	// Another day is a different search
+	ranged.from = time.Time{}
+	if _, err := aggregator.SearchEventsByLocationBetween(context.Background(), "Berlin", "DE", from, to, 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !ranged.from.IsZero() {
+		t.Error("expected the same range served from the cache")
+	}
+	if _, err := aggregator.SearchEventsByLocationBetween(context.Background(), "Berlin", "DE", to, to.Add(24*time.Hour), 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !ranged.from.Equal(to) {
+		t.Error("expected the next day searched again")
+	}
+}
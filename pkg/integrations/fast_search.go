@@ -0,0 +1,199 @@
+package integrations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFastQuorum       = 2
+	defaultFastSoftDeadline = 2 * time.Second
+	defaultFollowUpTTL      = 10 * time.Minute
+)
+
+// FastSearch makes an event search answer as soon as Quorum of its sources
+// have returned events, or once SoftDeadline passes, instead of waiting on
+// the slowest. The sources still out are listed in the results' Pending and
+// keep searching in the background; the complete results are then cached
+// and can be fetched with the results' FollowUp ID. Zero fields take the
+// aggregator's configured defaults.
+type FastSearch struct {
+	Quorum       int
+	SoftDeadline time.Duration
+}
+
+type fastSearchKey struct{}
+
+// WithFastSearch returns a context whose event searches answer early
+func WithFastSearch(ctx context.Context, fast FastSearch) context.Context {
+	return context.WithValue(ctx, fastSearchKey{}, fast)
+}
+
+// FastSearchFrom returns the fast search set on ctx, if any
+func FastSearchFrom(ctx context.Context) (FastSearch, bool) {
+	fast, ok := ctx.Value(fastSearchKey{}).(FastSearch)
+	return fast, ok
+}
+
+// searchContext bounds the source calls of a search. A fast search's calls
+// may outlive the request, so they only stop at the timeout or once the
+// gathered results call the cancel func.
+func (m *MegaAggregator) searchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, fast := FastSearchFrom(ctx); fast {
+		ctx = context.WithoutCancel(ctx)
+	}
+	return context.WithTimeout(ctx, m.searchTimeout())
+}
+
+// gatherResults hands each source's result to collect as it arrives. It
+// returns once every launched source answered, or for a fast search once
+// the quorum returned events or the soft deadline passed, listing the
+// sources still out. Their results are left to resume, which reads them in
+// the background once the caller is done with what was collected so far and
+// calls finish after the last. cancel is called once every result is in.
+func (m *MegaAggregator) gatherResults(ctx context.Context, cancel context.CancelFunc, launched []string, results <-chan SourceResult, collect func(SourceResult)) ([]string, func(finish func())) {
+	fast, ok := FastSearchFrom(ctx)
+	if !ok {
+		for result := range results {
+			collect(result)
+		}
+		cancel()
+		return nil, nil
+	}
+
+	if fast.Quorum <= 0 {
+		fast.Quorum = m.config.FastQuorum
+	}
+	if fast.SoftDeadline <= 0 {
+		fast.SoftDeadline = m.config.FastSoftDeadline
+	}
+	deadline := time.NewTimer(fast.SoftDeadline)
+	defer deadline.Stop()
+
+	outstanding := make(map[string]int, len(launched))
+	for _, name := range launched {
+		outstanding[name]++
+	}
+	answered := 0
+
+gather:
+	for answered < fast.Quorum {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				cancel()
+				return nil, nil
+			}
+			if outstanding[result.SourceName]--; outstanding[result.SourceName] <= 0 {
+				delete(outstanding, result.SourceName)
+			}
+			if result.Error == nil {
+				answered++
+			}
+			collect(result)
+		case <-deadline.C:
+			break gather
+		}
+	}
+
+	pending := slices.Sorted(maps.Keys(outstanding))
+	resume := func(finish func()) {
+		go func() {
+			for result := range results {
+				collect(result)
+			}
+			cancel()
+			finish()
+		}()
+	}
+	if len(pending) == 0 {
+		// Everyone answered as the quorum was reached
+		resume(func() {})
+		return nil, nil
+	}
+	return pending, resume
+}
+
+// answerEarly marks results as answered without the pending sources and
+// lets the rest of the search finish in the background, where its complete
+// results are handed to store and kept for FollowUp
+func (m *MegaAggregator) answerEarly(results *AggregatedResults, pending []string, resume func(finish func()), build func() *AggregatedResults, store func(complete *AggregatedResults)) {
+	id := m.followUps.add(pending)
+	results.Pending = pending
+	results.FollowUp = id
+
+	resume(func() {
+		complete := build()
+		store(complete)
+		m.followUps.complete(id, complete)
+	})
+}
+
+// followUps keeps the complete results of fast searches that answered
+// before all their sources did, until they're fetched or expire
+type followUps struct {
+	mu      sync.Mutex
+	entries map[string]*followUp
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+type followUp struct {
+	pending   []string
+	results   *AggregatedResults
+	expiresAt time.Time
+}
+
+func newFollowUps(ttl time.Duration) *followUps {
+	return &followUps{entries: make(map[string]*followUp), ttl: ttl, now: time.Now}
+}
+
+// add tracks a fast search still waiting on the pending sources and
+// returns its ID
+func (f *followUps) add(pending []string) string {
+	buf := make([]byte, 12)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.now()
+	for key, entry := range f.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(f.entries, key)
+		}
+	}
+	f.entries[id] = &followUp{pending: pending, expiresAt: now.Add(f.ttl)}
+	return id
+}
+
+// complete records the search's complete results, which are kept for the
+// TTL from then
+func (f *followUps) complete(id string, results *AggregatedResults) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if entry, ok := f.entries[id]; ok {
+		entry.pending = nil
+		entry.results = results
+		entry.expiresAt = f.now().Add(f.ttl)
+	}
+}
+
+// FollowUp returns the complete results of a fast search once its last
+// source answered, and until then the sources it still waits on. ok is
+// false for IDs that are unknown or expired.
+func (m *MegaAggregator) FollowUp(id string) (results *AggregatedResults, pending []string, ok bool) {
+	m.followUps.mu.Lock()
+	defer m.followUps.mu.Unlock()
+
+	entry, found := m.followUps.entries[id]
+	if !found || !m.followUps.now().Before(entry.expiresAt) {
+		return nil, nil, false
+	}
+	return entry.results, slices.Clone(entry.pending), true
+}
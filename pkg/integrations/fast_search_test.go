@@ -0,0 +1,124 @@
+package integrations
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// heldEventSource answers once released, or fails if its context ends first
+type heldEventSource struct {
+	stubEventSource
+	release chan struct{}
+}
+
+func (s *heldEventSource) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+	select {
+	case <-s.release:
+		return s.events, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *heldEventSource) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+	return s.SearchEventsByArtist(ctx, "", limit)
+}
+
+func TestMegaAggregator_FastSearch(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	newAggregator := func() (*MegaAggregator, *heldEventSource) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true, FastSoftDeadline: 5 * time.Second})
+		aggregator.RegisterEventSource("songkick", &stubEventSource{name: "songkick", events: []domain.Event{
+			{ID: "songkick_1", ArtistName: "Bicep", DateTime: future, Venue: domain.Venue{Name: "O2"}},
+		}})
+		aggregator.RegisterEventSource("ticketmaster", &stubEventSource{name: "ticketmaster", events: []domain.Event{
+			{ID: "ticketmaster_1", ArtistName: "Bicep", DateTime: future.Add(time.Hour), Venue: domain.Venue{Name: "Roundhouse"}},
+		}})
+		slow := &heldEventSource{
+			stubEventSource: stubEventSource{name: "venue_pages", events: []domain.Event{
+				{ID: "venue_pages_1", ArtistName: "Bicep", DateTime: future.Add(2 * time.Hour), Venue: domain.Venue{Name: "Fabric"}},
+			}},
+			release: make(chan struct{}),
+		}
+		aggregator.RegisterEventSource("venue_pages", slow)
+		return aggregator, slow
+	}
+	waitForFollowUp := func(t *testing.T, aggregator *MegaAggregator, id string) *AggregatedResults {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if results, _, _ := aggregator.FollowUp(id); results != nil {
+				return results
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatal("expected the follow-up to complete")
+		return nil
+	}
+
+	t.Run("answers at the quorum", func(t *testing.T) {
+		aggregator, slow := newAggregator()
+
+		// The request ends with the early answer; the slow source goes on
+		ctx, cancel := context.WithCancel(WithFastSearch(context.Background(), FastSearch{}))
+		results, err := aggregator.SearchEvents(ctx, "Bicep", 10)
+		cancel()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(results.Events) != 2 || !slices.Equal(results.Pending, []string{"venue_pages"}) || results.FollowUp == "" {
+			t.Fatalf("expected the two fast sources with venue_pages pending, got %+v", results)
+		}
+
+		if complete, pending, ok := aggregator.FollowUp(results.FollowUp); !ok || complete != nil || len(pending) != 1 {
+			t.Errorf("expected the follow-up to wait on venue_pages, got %v, %v, %v", complete, pending, ok)
+		}
+
+		close(slow.release)
+		complete := waitForFollowUp(t, aggregator, results.FollowUp)
+		if len(complete.Events) != 3 || len(complete.Pending) != 0 || len(complete.Errors) != 0 {
+			t.Errorf("expected every source's events, got %+v", complete)
+		}
+		if len(results.Events) != 2 {
+			t.Errorf("expected the early answer to be left alone, got %d events", len(results.Events))
+		}
+
+		// The complete results are cached for the next search
+		cached, err := aggregator.SearchEvents(context.Background(), "Bicep", 10)
+		if err != nil || len(cached.Events) != 3 {
+			t.Errorf("expected the complete results from the cache, got %+v, %v", cached, err)
+		}
+	})
+
+	t.Run("answers at the soft deadline", func(t *testing.T) {
+		aggregator, slow := newAggregator()
+		defer close(slow.release)
+
+		ctx := WithFastSearch(context.Background(), FastSearch{Quorum: 3, SoftDeadline: 50 * time.Millisecond})
+		start := time.Now()
+		results, err := aggregator.SearchEventsByLocation(ctx, "London", "GB", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if time.Since(start) > time.Second || len(results.Events) != 2 || len(results.Pending) != 1 {
+			t.Errorf("expected the two fast sources after the deadline, got %+v", results)
+		}
+	})
+
+	t.Run("waits for every source without it", func(t *testing.T) {
+		aggregator, slow := newAggregator()
+		close(slow.release)
+
+		results, err := aggregator.SearchEvents(context.Background(), "Bicep", 10)
+		if err != nil || len(results.Events) != 3 || results.Pending != nil || results.FollowUp != "" {
+			t.Errorf("expected every source, got %+v, %v", results, err)
+		}
+		if _, _, ok := aggregator.FollowUp("unknown"); ok {
+			t.Error("expected no follow-up for an unknown ID")
+		}
+	})
+}
@@ -1,11 +1,44 @@
 module github.com/yair/where-its-at/pkg/integrations
 
-go 1.23.0
+go 1.24.0
 
 toolchain go1.24.5
 
-require github.com/yair/where-its-at/pkg/domain v0.0.0
+require (
+	github.com/chromedp/chromedp v0.14.2
+	github.com/golang-jwt/jwt/v5 v5.2.3
+	github.com/yair/where-its-at/pkg/cache v0.0.0
+	github.com/yair/where-its-at/pkg/domain v0.0.0
+	github.com/yair/where-its-at/pkg/ratelimit v0.0.0
+	github.com/yair/where-its-at/pkg/scoring v0.0.0
+	go.opentelemetry.io/otel v1.35.0
+	go.opentelemetry.io/otel/sdk v1.35.0
+	go.opentelemetry.io/otel/trace v1.35.0
+	golang.org/x/net v0.42.0
+)
 
-require golang.org/x/net v0.42.0 // indirect
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/chromedp/cdproto v0.0.0-20250724212937-08a3db8b4327 // indirect
+	github.com/chromedp/sysutil v1.1.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-json-experiment/json v0.0.0-20250725192818-e39067aee2d2 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/gobwas/httphead v0.1.0 // indirect
+	github.com/gobwas/pool v0.2.1 // indirect
+	github.com/gobwas/ws v1.4.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/redis/go-redis/v9 v9.17.2 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
+)
 
 replace github.com/yair/where-its-at/pkg/domain => ../domain
+
+replace github.com/yair/where-its-at/pkg/ratelimit => ../ratelimit
+
+replace github.com/yair/where-its-at/pkg/scoring => ../scoring
+
+replace github.com/yair/where-its-at/pkg/cache => ../cache
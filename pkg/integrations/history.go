@@ -0,0 +1,193 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HistorySource looks up concerts an artist has already played. History
+// sources are kept apart from event sources so past shows never end up in
+// searches for upcoming ones.
+type HistorySource interface {
+	ArtistHistory(ctx context.Context, artist domain.Artist, page int) (*domain.ConcertHistoryPage, error)
+	GetName() string
+}
+
+// HistoryResults is one page of an artist's past concerts across every
+// history source. Every source is asked for the same page, so HasMore is
+// set while any of them has more.
+type HistoryResults struct {
+	Concerts        []domain.PastConcert     `json:"concerts"`
+	Page            int                      `json:"page"`
+	HasMore         bool                     `json:"has_more"`
+	SourceStats     map[string]int           `json:"source_stats"`
+	SourceDurations map[string]time.Duration `json:"source_durations,omitempty"`
+	TotalResults    int                      `json:"total_results"`
+	SearchTime      time.Duration            `json:"search_time"`
+	Errors          []string                 `json:"errors,omitempty"`
+}
+
+func (m *MegaAggregator) RegisterHistorySource(name string, source HistorySource) {
+	m.sourcesMu.Lock()
+	defer m.sourcesMu.Unlock()
+	m.historySources = withSource(m.historySources, name, source)
+	m.addQuotaReporter(name, source)
+}
+
+// HistorySources returns the registered history sources by name, for
+// walking an artist's history page by page rather than a page at a time
+// from every source
+func (m *MegaAggregator) HistorySources() map[string]HistorySource {
+	history := m.sources().history
+	sources := make(map[string]HistorySource, len(history))
+	for name, source := range history {
+		sources[name] = source
+	}
+	return sources
+}
+
+// SearchHistory returns a page of the artist's past concerts from the
+// history sources only, newest first
+func (m *MegaAggregator) SearchHistory(ctx context.Context, artist domain.Artist, page int) (*HistoryResults, error) {
+	startTime := time.Now()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "aggregator.search_history", trace.WithAttributes(
+		attribute.String("artist.name", artist.Name),
+		attribute.Int("page", page),
+	))
+	defer span.End()
+	filter := SourceFilterFrom(ctx)
+
+	if page <= 0 {
+		page = 1
+	}
+
+	type historyResult struct {
+		sourceName string
+		page       *domain.ConcertHistoryPage
+		err        error
+		duration   time.Duration
+	}
+
+	historySources := m.sources().history
+	resultsChan := make(chan historyResult, len(historySources))
+	ctx, cancel := context.WithTimeout(ctx, m.searchTimeout())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, m.config.MaxConcurrentRequests)
+	errors := []string{}
+
+	for name, source := range historySources {
+		if !m.shouldSearch(filter, name) {
+			continue
+		}
+		if err := m.breakerFor(name).Allow(); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		wg.Add(1)
+		go func(sourceName string, src HistorySource) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			ctx, _, release := m.limitSource(ctx, sourceName)
+			defer release()
+
+			ctx, span := m.startSourceSpan(ctx, sourceName, "artist_history")
+			start := time.Now()
+			history, err := src.ArtistHistory(ctx, artist, page)
+			duration := m.finishSourceCall(ctx, span, sourceName, start, err)
+			resultsChan <- historyResult{
+				sourceName: sourceName,
+				page:       history,
+				err:        err,
+				duration:   duration,
+			}
+		}(name, source)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	concerts := []domain.PastConcert{}
+	hasMore := false
+	sourceStats := make(map[string]int)
+	sourceDurations := make(map[string]time.Duration)
+
+	for result := range resultsChan {
+		sourceDurations[result.sourceName] = result.duration
+
+		if result.err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", result.sourceName, result.err))
+			continue
+		}
+
+		sourceStats[result.sourceName] = len(result.page.Concerts)
+		concerts = append(concerts, result.page.Concerts...)
+		hasMore = hasMore || result.page.HasMore
+	}
+
+	if m.config.DeduplicationEnabled {
+		concerts = m.deduplicator.DeduplicateConcerts(concerts)
+	}
+
+	sort.SliceStable(concerts, func(i, j int) bool {
+		return concerts[i].DateTime.After(concerts[j].DateTime)
+	})
+
+	return &HistoryResults{
+		Concerts:        concerts,
+		Page:            page,
+		HasMore:         hasMore,
+		SourceStats:     sourceStats,
+		SourceDurations: sourceDurations,
+		TotalResults:    len(concerts),
+		SearchTime:      time.Since(startTime),
+		Errors:          errors,
+	}, nil
+}
+
+// DeduplicateConcerts merges concerts by the same artist on the same day.
+// Sources spell venues differently, and an artist rarely plays twice a day.
+// The one with a setlist is kept, say setlist.fm's over a Songkick
+// gigography's, whichever source answered first, and the other fills in
+// what it lacks.
+func (d *Deduplicator) DeduplicateConcerts(concerts []domain.PastConcert) []domain.PastConcert {
+	seen := make(map[string]int)
+	unique := []domain.PastConcert{}
+
+	for _, concert := range concerts {
+		key := d.normalizeArtistName(concert.ArtistName) + "_" + concert.DateTime.Format("20060102")
+		if i, ok := seen[key]; ok {
+			kept, other := unique[i], concert
+			if len(kept.Songs) == 0 && len(other.Songs) > 0 {
+				kept, other = other, kept
+			}
+			kept.ExternalIDs.Merge(other.ExternalIDs)
+			kept.Venue.MergeDetails(other.Venue)
+			if kept.Tour == "" {
+				kept.Tour = other.Tour
+			}
+			if kept.URL == "" {
+				kept.URL = other.URL
+			}
+			unique[i] = kept
+			continue
+		}
+		seen[key] = len(unique)
+		unique = append(unique, concert)
+	}
+
+	return unique
+}
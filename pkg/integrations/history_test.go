@@ -0,0 +1,115 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubHistorySource struct {
+	name      string
+	pages     map[int]*domain.ConcertHistoryPage
+	err       error
+	requested []int
+}
+
+func (s *stubHistorySource) ArtistHistory(ctx context.Context, artist domain.Artist, page int) (*domain.ConcertHistoryPage, error) {
+	s.requested = append(s.requested, page)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if history, ok := s.pages[page]; ok {
+		return history, nil
+	}
+	return &domain.ConcertHistoryPage{Page: page}, nil
+}
+
+func (s *stubHistorySource) GetName() string {
+	return s.name
+}
+
+func pastConcert(id, source string, at time.Time, songs ...string) domain.PastConcert {
+	concert := domain.PastConcert{
+		Event:  domain.Event{ID: id, ArtistName: "Radiohead", DateTime: at},
+		Source: source,
+	}
+	for _, song := range songs {
+		concert.Songs = append(concert.Songs, domain.SetlistSong{Name: song})
+	}
+	return concert
+}
+
+func TestMegaAggregator_SearchHistory(t *testing.T) {
+	older := time.Date(2017, 7, 8, 21, 0, 0, 0, time.UTC)
+	newer := time.Date(2018, 4, 11, 20, 30, 0, 0, time.UTC)
+
+	setlists := &stubHistorySource{
+		name: "setlistfm",
+		pages: map[int]*domain.ConcertHistoryPage{
+			2: {
+				Concerts: []domain.PastConcert{
+					pastConcert("setlistfm_1", "setlistfm", older, "Airbag"),
+					pastConcert("setlistfm_2", "setlistfm", newer.Truncate(24*time.Hour), "15 Step"),
+				},
+				Page:    2,
+				HasMore: true,
+			},
+		},
+	}
+	gigs := &stubHistorySource{
+		name: "songkick",
+		pages: map[int]*domain.ConcertHistoryPage{
+			2: {Concerts: []domain.PastConcert{pastConcert("songkick_1", "songkick", newer)}, Page: 2},
+		},
+	}
+	gigs.pages[2].Concerts[0].Venue.Capacity = 2300
+	gigs.pages[2].Concerts[0].Tour = "Primavera Sound"
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{DeduplicationEnabled: true})
+	aggregator.RegisterHistorySource("setlistfm", setlists)
+	aggregator.RegisterHistorySource("songkick", gigs)
+
+	results, err := aggregator.SearchHistory(context.Background(), domain.Artist{Name: "Radiohead"}, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(setlists.requested) != 1 || setlists.requested[0] != 2 {
+		t.Errorf("expected page 2 to be requested, got %v", setlists.requested)
+	}
+	if len(results.Concerts) != 2 {
+		t.Fatalf("expected the same day's shows to be merged, got %+v", results.Concerts)
+	}
+	if !results.Concerts[0].DateTime.After(results.Concerts[1].DateTime) {
+		t.Error("expected the newest concert first")
+	}
+	if len(results.Concerts[0].Songs) != 1 || results.Concerts[0].Songs[0].Name != "15 Step" {
+		t.Errorf("expected the setlist to be kept on the merged concert, got %+v", results.Concerts[0])
+	}
+	if merged := results.Concerts[0]; merged.Source != "setlistfm" || merged.Venue.Capacity != 2300 || merged.Tour != "Primavera Sound" {
+		t.Errorf("expected the setlist.fm concert filled in from Songkick's, got %+v", merged)
+	}
+	if !results.HasMore || results.Page != 2 {
+		t.Errorf("expected more pages after page 2, got page %d has_more %v", results.Page, results.HasMore)
+	}
+
+	if stats := aggregator.GetSourceStats(); stats["setlistfm"].Type != "history" {
+		t.Errorf("expected setlistfm to be listed as a history source, got %+v", stats["setlistfm"])
+	}
+
+	t.Run("failing source", func(t *testing.T) {
+		gigs.err = errors.New("upstream down")
+		defer func() { gigs.err = nil }()
+
+		results, err := aggregator.SearchHistory(context.Background(), domain.Artist{Name: "Radiohead"}, 2)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(results.Errors) != 1 || len(results.Concerts) != 2 {
+			t.Errorf("expected setlistfm results and one error, got %+v", results)
+		}
+	})
+}
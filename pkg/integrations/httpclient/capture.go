@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxCapturedBody caps how much of a response body is kept for debugging
+const maxCapturedBody = 1 << 20
+
+// CapturedResponse is an upstream response kept for debugging, with
+// credentials redacted
+type CapturedResponse struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	// Body is the JSON as sent, or a string for anything else, including
+	// JSON cut off at the size cap
+	Body      json.RawMessage `json:"body,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// Capture collects the responses of requests made with its context
+type Capture struct {
+	mu        sync.Mutex
+	responses []CapturedResponse
+}
+
+type captureKey struct{}
+
+// WithCapture returns a context under which clients created by New keep a
+// copy of every response they return in the Capture. Retried attempts
+// aren't kept, only the response the client ends up with.
+func WithCapture(ctx context.Context) (context.Context, *Capture) {
+	capture := &Capture{responses: []CapturedResponse{}}
+	return context.WithValue(ctx, captureKey{}, capture), capture
+}
+
+// Responses returns the captured responses in the order they arrived
+func (c *Capture) Responses() []CapturedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]CapturedResponse(nil), c.responses...)
+}
+
+// record copies the start of resp's body, leaving the body readable in
+// full for the client
+func (c *Capture) record(req *http.Request, resp *http.Response) {
+	// A failed read keeps what arrived; the client sees the same error
+	head, _ := io.ReadAll(io.LimitReader(resp.Body, maxCapturedBody+1))
+	resp.Body = readCloser{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+
+	captured := CapturedResponse{
+		Method:      req.Method,
+		URL:         RedactURL(req.URL),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if len(head) > maxCapturedBody {
+		head, captured.Truncated = head[:maxCapturedBody], true
+	}
+	captured.Body = capturedBody(RedactBody(head))
+
+	c.mu.Lock()
+	c.responses = append(c.responses, captured)
+	c.mu.Unlock()
+}
+
+func captureFrom(ctx context.Context) *Capture {
+	capture, _ := ctx.Value(captureKey{}).(*Capture)
+	return capture
+}
+
+func capturedBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+// readCloser reads from a replayed prefix and the rest of the body, and
+// closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
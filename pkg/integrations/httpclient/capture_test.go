@@ -0,0 +1,64 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithCapture(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"secret","events":[1,2]}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewTransport(nil, RetryConfig{BaseDelay: time.Millisecond})}
+	ctx, capture := WithCapture(context.Background())
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/events?apikey=secret&city=Berlin", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `"access_token":"secret"`) {
+		t.Errorf("expected the client to read the whole body, got %s", body)
+	}
+
+	responses := capture.Responses()
+	if len(responses) != 1 {
+		t.Fatalf("expected only the final response captured, got %d", len(responses))
+	}
+	captured := responses[0]
+	if captured.Status != http.StatusOK || captured.ContentType != "application/json" {
+		t.Errorf("unexpected response %+v", captured)
+	}
+	if strings.Contains(captured.URL, "secret") || !strings.Contains(captured.URL, "city=Berlin") {
+		t.Errorf("expected the key redacted from %s", captured.URL)
+	}
+	if strings.Contains(string(captured.Body), "secret") || !strings.Contains(string(captured.Body), `"events":[1,2]`) {
+		t.Errorf("expected the token redacted from %s", captured.Body)
+	}
+
+	// Requests without the context aren't captured
+	resp, err = client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if len(capture.Responses()) != 1 {
+		t.Error("expected requests without the capture context left alone")
+	}
+}
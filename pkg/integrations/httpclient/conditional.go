@@ -0,0 +1,207 @@
+package httpclient
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// defaultConditionalCacheBytes bounds the bodies kept for conditional
+	// requests unless SetConditionalCacheSize says otherwise
+	defaultConditionalCacheBytes = 32 << 20
+	// maxConditionalBody is the largest body kept; bigger ones are fetched
+	// in full every time
+	maxConditionalBody = 2 << 20
+)
+
+// conditional is the cache of GET responses that came with an ETag or
+// Last-Modified, shared by every Transport. The next request for the URL
+// sends If-None-Match and If-Modified-Since, and a 304 is answered with the
+// kept body as a 200, as if the server had sent it again. Nil turns it off.
+var conditional atomic.Pointer[conditionalCache]
+
+func init() {
+	conditional.Store(newConditionalCache(defaultConditionalCacheBytes))
+}
+
+// SetConditionalCacheSize bounds the bodies kept for conditional requests
+// to maxBytes, dropping what is kept now. Zero restores the default of
+// 32 MB; negative turns conditional requests off.
+func SetConditionalCacheSize(maxBytes int64) {
+	switch {
+	case maxBytes < 0:
+		conditional.Store(nil)
+	case maxBytes == 0:
+		conditional.Store(newConditionalCache(defaultConditionalCacheBytes))
+	default:
+		conditional.Store(newConditionalCache(maxBytes))
+	}
+}
+
+// validatedResponse is a kept response and what validates it
+type validatedResponse struct {
+	key          string
+	etag         string
+	lastModified string
+	header       http.Header
+	body         []byte
+}
+
+func (v *validatedResponse) size() int64 {
+	return int64(len(v.key) + len(v.body))
+}
+
+// response is the kept response as a 200 to req, with the headers a 304
+// refreshed
+func (v *validatedResponse) response(req *http.Request, notModified *http.Response) *http.Response {
+	header := v.header.Clone()
+	for _, name := range []string{"Cache-Control", "Date", "Etag", "Expires", "Last-Modified"} {
+		if values := notModified.Header.Values(name); len(values) > 0 {
+			header[name] = values
+		}
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         notModified.Proto,
+		ProtoMajor:    notModified.ProtoMajor,
+		ProtoMinor:    notModified.ProtoMinor,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(v.body)),
+		ContentLength: int64(len(v.body)),
+		Request:       req,
+	}
+}
+
+// conditionalCache keeps validated responses by request, evicting the
+// least recently used past maxBytes
+type conditionalCache struct {
+	mu       sync.Mutex
+	entries  map[string]*list.Element
+	recent   *list.List // of *validatedResponse, most recently used first
+	bytes    int64
+	maxBytes int64
+}
+
+func newConditionalCache(maxBytes int64) *conditionalCache {
+	return &conditionalCache{
+		entries:  make(map[string]*list.Element),
+		recent:   list.New(),
+		maxBytes: maxBytes,
+	}
+}
+
+// conditionalKey is what a kept response is found by, or "" for requests
+// that aren't made conditional: anything but a plain GET, and requests
+// whose caller validates or ranges them itself. Credentials are part of
+// the key, so one user's response is never served to another.
+func conditionalKey(req *http.Request) string {
+	if req.Method != http.MethodGet {
+		return ""
+	}
+	for _, name := range []string{"If-None-Match", "If-Modified-Since", "Range"} {
+		if req.Header.Get(name) != "" {
+			return ""
+		}
+	}
+	return strings.Join([]string{req.URL.String(), req.Header.Get("Authorization"), req.Header.Get("Accept")}, "\n")
+}
+
+func (c *conditionalCache) get(key string) *validatedResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.recent.MoveToFront(element)
+	return element.Value.(*validatedResponse)
+}
+
+// withValidators returns a copy of req asking whether kept changed
+func withValidators(req *http.Request, kept *validatedResponse) *http.Request {
+	conditional := req.Clone(req.Context())
+	if kept.etag != "" {
+		conditional.Header.Set("If-None-Match", kept.etag)
+	}
+	if kept.lastModified != "" {
+		conditional.Header.Set("If-Modified-Since", kept.lastModified)
+	}
+	return conditional
+}
+
+// update answers a 304 to a conditional request from the kept response,
+// and keeps a validated 200, leaving its body readable in full
+func (c *conditionalCache) update(key string, kept *validatedResponse, req *http.Request, resp *http.Response) *http.Response {
+	if resp.StatusCode == http.StatusNotModified && kept != nil {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		return kept.response(req, resp)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return resp
+	}
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if (etag == "" && lastModified == "") || strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store") ||
+		resp.ContentLength > maxConditionalBody {
+		c.remove(key)
+		return resp
+	}
+
+	// A failed read keeps what arrived; the client sees the same error
+	head, err := io.ReadAll(io.LimitReader(resp.Body, maxConditionalBody+1))
+	resp.Body = readCloser{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if err != nil || len(head) > maxConditionalBody {
+		c.remove(key)
+		return resp
+	}
+
+	c.put(&validatedResponse{
+		key:          key,
+		etag:         etag,
+		lastModified: lastModified,
+		header:       resp.Header.Clone(),
+		body:         head,
+	})
+	return resp
+}
+
+func (c *conditionalCache) put(entry *validatedResponse) {
+	if entry.size() > c.maxBytes {
+		c.remove(entry.key)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[entry.key]; ok {
+		c.bytes -= c.recent.Remove(element).(*validatedResponse).size()
+	}
+	c.entries[entry.key] = c.recent.PushFront(entry)
+	c.bytes += entry.size()
+
+	for c.bytes > c.maxBytes {
+		oldest := c.recent.Back()
+		evicted := c.recent.Remove(oldest).(*validatedResponse)
+		delete(c.entries, evicted.key)
+		c.bytes -= evicted.size()
+	}
+}
+
+func (c *conditionalCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.bytes -= c.recent.Remove(element).(*validatedResponse).size()
+		delete(c.entries, key)
+	}
+}
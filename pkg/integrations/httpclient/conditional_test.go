@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport_ConditionalRequests(t *testing.T) {
+	var version atomic.Value
+	version.Store("v1")
+	var full, notModified atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"` + version.Load().(string) + `"`
+		w.Header().Set("ETag", etag)
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"version":"` + version.Load().(string) + `"}`))
+	}))
+	defer server.Close()
+
+	client := New(time.Second)
+	get := func(header http.Header) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest("GET", server.URL+"/artist/radiohead", nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if status, body := get(nil); status != http.StatusOK || body != `{"version":"v1"}` {
+		t.Fatalf("unexpected first response %d %s", status, body)
+	}
+	// Unchanged: the server says so and the kept body is served
+	status, body := get(nil)
+	if status != http.StatusOK || body != `{"version":"v1"}` {
+		t.Errorf("expected the kept body on a 304, got %d %s", status, body)
+	}
+	if full.Load() != 1 || notModified.Load() != 1 {
+		t.Errorf("expected one full response and one 304, got %d and %d", full.Load(), notModified.Load())
+	}
+
+	version.Store("v2")
+	if _, body := get(nil); body != `{"version":"v2"}` {
+		t.Errorf("expected the changed body, got %s", body)
+	}
+
+	// Callers validating themselves get the server's answer as it is
+	if status, _ := get(http.Header{"If-None-Match": {`"v2"`}}); status != http.StatusNotModified {
+		t.Errorf("expected the caller's own 304, got %d", status)
+	}
+	// Other credentials don't share what's kept
+	before := full.Load()
+	get(http.Header{"Authorization": {"Bearer other"}})
+	if full.Load() != before+1 {
+		t.Error("expected a request with other credentials to be fetched in full")
+	}
+
+	SetConditionalCacheSize(-1)
+	defer SetConditionalCacheSize(0)
+	before = full.Load()
+	get(nil)
+	if full.Load() != before+1 {
+		t.Error("expected no conditional request once turned off")
+	}
+}
+
+func TestConditionalCache_Evicts(t *testing.T) {
+	cache := newConditionalCache(100)
+	for _, key := range []string{"a", "b", "c"} {
+		cache.put(&validatedResponse{key: key, etag: `"1"`, body: make([]byte, 40)})
+	}
+	if cache.get("a") != nil || cache.get("b") == nil || cache.get("c") == nil {
+		t.Error("expected the least recently used response evicted")
+	}
+	if cache.bytes > 100 {
+		t.Errorf("expected at most 100 bytes kept, got %d", cache.bytes)
+	}
+
+	cache.put(&validatedResponse{key: "huge", etag: `"1"`, body: make([]byte, 200)})
+	if cache.get("huge") != nil {
+		t.Error("expected a response bigger than the cache not kept")
+	}
+}
@@ -0,0 +1,101 @@
+package httpclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+)
+
+// DefaultProxy is the SetProxies key for sources without an entry of their
+// own
+const DefaultProxy = "default"
+
+// ProxyConfig sends a source's requests through outbound proxies: http,
+// https, socks5 or socks5h URLs, user info included. Several are rotated
+// request by request, so a scraper's requests come from more than one
+// address. None sends them straight out, even when there's a default.
+type ProxyConfig struct {
+	URLs []string
+}
+
+// proxies are the transports of the sources given a ProxyConfig, by name
+var proxies map[string]http.RoundTripper
+
+// SetProxies routes the requests of clients created by New and NewFor
+// afterwards through the proxies configured for their source, or the
+// DefaultProxy entry. A transport set by SetBaseTransport takes precedence.
+func SetProxies(configs map[string]ProxyConfig) error {
+	transports := make(map[string]http.RoundTripper, len(configs))
+	for source, config := range configs {
+		transport, err := proxyTransport(config)
+		if err != nil {
+			return fmt.Errorf("invalid proxy for %s: %w", source, err)
+		}
+		transports[source] = transport
+	}
+
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	proxies = transports
+	return nil
+}
+
+// proxyTransport is a transport sending requests through the config's
+// proxies in turn. Connections are pooled per proxy.
+func proxyTransport(config ProxyConfig) (http.RoundTripper, error) {
+	rotation := &proxyRotation{}
+	for _, raw := range config.URLs {
+		proxyURL, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse proxy URL: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme %q", proxyURL.Scheme)
+		}
+		if proxyURL.Host == "" {
+			return nil, fmt.Errorf("proxy URL %s has no host", RedactURL(proxyURL))
+		}
+		rotation.urls = append(rotation.urls, proxyURL)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	if len(rotation.urls) > 0 {
+		transport.Proxy = rotation.proxy
+	}
+	return transport, nil
+}
+
+// BaseTransportFor returns what a source's requests are sent through below
+// the retries: the base transport if one is set, else the source's proxies,
+// else the default ones. nil means http.DefaultTransport.
+func BaseTransportFor(source string) http.RoundTripper {
+	defaultsMu.RLock()
+	defer defaultsMu.RUnlock()
+	return baseFor(source)
+}
+
+// baseFor is BaseTransportFor with defaultsMu held
+func baseFor(source string) http.RoundTripper {
+	if baseTransport != nil {
+		return baseTransport
+	}
+	if transport, ok := proxies[source]; ok {
+		return transport
+	}
+	return proxies[DefaultProxy]
+}
+
+// proxyRotation hands out its proxies round robin
+type proxyRotation struct {
+	urls []*url.URL
+	next atomic.Uint64
+}
+
+func (p *proxyRotation) proxy(*http.Request) (*url.URL, error) {
+	i := p.next.Add(1) - 1
+	return p.urls[i%uint64(len(p.urls))], nil
+}
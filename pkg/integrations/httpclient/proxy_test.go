@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// proxyServer counts the requests it's asked to forward and answers them
+// itself
+func proxyServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var forwarded atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Host == "api.example.invalid" {
+			forwarded.Add(1)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server, &forwarded
+}
+
+func TestSetProxies(t *testing.T) {
+	first, firstCalls := proxyServer(t)
+	second, secondCalls := proxyServer(t)
+	fallback, fallbackCalls := proxyServer(t)
+
+	err := SetProxies(map[string]ProxyConfig{
+		"resident_advisor": {URLs: []string{first.URL, second.URL}},
+		DefaultProxy:       {URLs: []string{fallback.URL}},
+		"deezer":           {},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer SetProxies(nil)
+
+	get := func(client *http.Client) {
+		t.Helper()
+		resp, err := client.Get("http://api.example.invalid/events")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// The scraper's requests take turns through its two proxies
+	scraper := NewFor("resident_advisor", time.Second)
+	for i := 0; i < 4; i++ {
+		get(scraper)
+	}
+	if firstCalls.Load() != 2 || secondCalls.Load() != 2 {
+		t.Errorf("expected the proxies rotated, got %d and %d requests", firstCalls.Load(), secondCalls.Load())
+	}
+
+	get(NewFor("songkick", time.Second))
+	get(New(time.Second))
+	if fallbackCalls.Load() != 2 {
+		t.Errorf("expected sources without proxies of their own to use the default, got %d requests", fallbackCalls.Load())
+	}
+
+	if proxy := BaseTransportFor("deezer").(*http.Transport).Proxy; proxy != nil {
+		t.Error("expected a source with no proxy URLs to go out directly")
+	}
+}
+
+func TestSetProxies_Invalid(t *testing.T) {
+	for _, raw := range []string{"ftp://proxy.example.com", "socks5://", "://nope"} {
+		if err := SetProxies(map[string]ProxyConfig{"songkick": {URLs: []string{raw}}}); err == nil {
+			t.Errorf("expected an error for %q", raw)
+		}
+	}
+	if BaseTransportFor("songkick") != nil {
+		t.Error("expected an invalid config to leave the proxies as they were")
+	}
+}
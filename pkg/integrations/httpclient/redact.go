@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces credentials in URLs and bodies shown outside the client
+const Redacted = "REDACTED"
+
+// secretParams are query parameters that carry credentials
+var secretParams = map[string]bool{
+	"apikey":        true,
+	"api_key":       true,
+	"key":           true,
+	"client_id":     true,
+	"client_secret": true,
+	"token":         true,
+	"access_token":  true,
+	"app_id":        true,
+}
+
+// secretFields are response body fields that carry credentials
+var secretFields = []string{"access_token", "refresh_token"}
+
+// IsSecretParam reports whether a query parameter carries credentials
+func IsSecretParam(name string) bool {
+	return secretParams[strings.ToLower(name)]
+}
+
+// RedactURL returns u with its user info dropped and the values of secret
+// query parameters replaced
+func RedactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+
+	query := u.Query()
+	for name := range query {
+		if IsSecretParam(name) {
+			query.Set(name, Redacted)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// RedactBody replaces token fields at the top of a JSON object body, as
+// returned by token endpoints. Other bodies are returned as they are.
+func RedactBody(body []byte) []byte {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return body
+	}
+
+	changed := false
+	for _, name := range secretFields {
+		if _, ok := fields[name]; ok {
+			fields[name] = json.RawMessage(`"` + Redacted + `"`)
+			changed = true
+		}
+	}
+	if !changed {
+		return body
+	}
+
+	redacted, err := json.Marshal(fields)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
@@ -0,0 +1,236 @@
+// Package httpclient builds the HTTP clients source integrations call their
+// upstream APIs with. Requests failing with 429, a 5xx or a network error are
+// retried with exponential backoff and jitter, honoring Retry-After. GETs
+// are made conditional on the ETag or Last-Modified of the response last
+// kept for them.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryConfig controls how failed requests are retried
+type RetryConfig struct {
+	// MaxRetries is how many times a request is retried after the first
+	// attempt. Defaults to 3; negative disables retries.
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry, doubled for each
+	// retry after it. Defaults to 500ms.
+	BaseDelay time.Duration
+	// MaxDelay caps the backoff between two attempts. Defaults to 10s.
+	MaxDelay time.Duration
+	// MaxRetryAfter is the longest Retry-After the client waits out; a
+	// response asking for longer is returned as is. Defaults to 30s.
+	MaxRetryAfter time.Duration
+}
+
+var (
+	defaultsMu    sync.RWMutex
+	defaults      RetryConfig
+	baseTransport http.RoundTripper
+)
+
+// SetDefaults changes the retry config of clients created by New afterwards
+func SetDefaults(config RetryConfig) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	defaults = config
+}
+
+// SetBaseTransport changes what clients created by New afterwards send
+// their requests through, below the retries. nil restores
+// http.DefaultTransport. Tests use it to replay recorded responses.
+func SetBaseTransport(base http.RoundTripper) {
+	defaultsMu.Lock()
+	defer defaultsMu.Unlock()
+	baseTransport = base
+}
+
+// New returns a client with the given overall timeout, which covers every
+// attempt, that retries with the config set by SetDefaults and goes through
+// the default proxies
+func New(timeout time.Duration) *http.Client {
+	return NewFor(DefaultProxy, timeout)
+}
+
+// NewFor is New for a named source, going through its own proxies when
+// SetProxies gave it some
+func NewFor(source string, timeout time.Duration) *http.Client {
+	defaultsMu.RLock()
+	config := defaults
+	base := baseFor(source)
+	defaultsMu.RUnlock()
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewTransport(base, config),
+	}
+}
+
+// Transport is an http.RoundTripper that retries failed requests. Requests
+// with a body are only retried when it can be replayed.
+type Transport struct {
+	base   http.RoundTripper
+	config RetryConfig
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+func NewTransport(base http.RoundTripper, config RetryConfig) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = 3
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = 500 * time.Millisecond
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = 10 * time.Second
+	}
+	if config.MaxRetryAfter <= 0 {
+		config.MaxRetryAfter = 30 * time.Second
+	}
+
+	return &Transport{
+		base:   base,
+		config: config,
+		sleep:  sleepContext,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cache, key := conditional.Load(), conditionalKey(req)
+	var kept *validatedResponse
+	if cache != nil && key != "" {
+		if kept = cache.get(key); kept != nil {
+			req = withValidators(req, kept)
+		}
+	}
+
+	resp, err := t.send(req)
+	if cache != nil && key != "" && err == nil {
+		resp = cache.update(key, kept, req, resp)
+	}
+	if capture := captureFrom(req.Context()); capture != nil && resp != nil {
+		capture.record(req, resp)
+	}
+	return resp, err
+}
+
+// send makes the attempts, returning the response of the last one
+func (t *Transport) send(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.config.MaxRetries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if retryAfter > t.config.MaxRetryAfter {
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+		}
+
+		retry, rewindErr := rewind(req)
+		if rewindErr != nil {
+			return resp, err
+		}
+		if resp != nil {
+			// Drain so the connection can be reused for the retry
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		if err := t.sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		req = retry
+	}
+}
+
+// backoff picks a delay between zero and the attempt's exponential ceiling,
+// so clients that failed together don't all retry together
+func (t *Transport) backoff(attempt int) time.Duration {
+	ceiling := t.config.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > t.config.MaxDelay {
+		ceiling = t.config.MaxDelay
+	}
+	return rand.N(ceiling) + 1
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusNotImplemented:
+		return false
+	default:
+		return resp.StatusCode >= 500
+	}
+}
+
+// rewind returns a copy of req to send again, with its body reset
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("request body can't be replayed")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+// parseRetryAfter reads a Retry-After header in either of its forms, a
+// number of seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
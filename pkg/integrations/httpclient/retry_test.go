@@ -0,0 +1,210 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestTransport records the delays it would have slept instead of sleeping
+func newTestTransport(config RetryConfig) (*Transport, *[]time.Duration) {
+	transport := NewTransport(nil, config)
+	slept := &[]time.Duration{}
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		*slept = append(*slept, d)
+		return ctx.Err()
+	}
+	return transport, slept
+}
+
+func TestTransport_RetriesUntilSuccess(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" && r.Method == "POST" {
+			t.Errorf("expected the body to be replayed, got %q", body)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport, slept := newTestTransport(RetryConfig{BaseDelay: time.Second, MaxDelay: 3 * time.Second})
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Errorf("expected success on the third attempt, got %d after %d", resp.StatusCode, calls.Load())
+	}
+	if len(*slept) != 2 {
+		t.Fatalf("expected 2 backoffs, got %v", *slept)
+	}
+	for i, ceiling := range []time.Duration{time.Second, 2 * time.Second} {
+		if d := (*slept)[i]; d <= 0 || d > ceiling {
+			t.Errorf("backoff %d: expected up to %v, got %v", i, ceiling, d)
+		}
+	}
+}
+
+func TestTransport_GivesUp(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	transport, _ := newTestTransport(RetryConfig{MaxRetries: 2})
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the last response, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway || calls.Load() != 3 {
+		t.Errorf("expected 3 attempts ending in 502, got %d after %d", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransport_DoesNotRetryClientErrors(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusBadRequest, http.StatusNotImplemented} {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(status)
+		}))
+
+		transport, _ := newTestTransport(RetryConfig{})
+		resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		resp.Body.Close()
+		server.Close()
+
+		if calls.Load() != 1 {
+			t.Errorf("%d: expected a single attempt, got %d", status, calls.Load())
+		}
+	}
+}
+
+func TestTransport_RetryAfter(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	transport, slept := newTestTransport(RetryConfig{})
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+
+	if len(*slept) != 1 || (*slept)[0] != 7*time.Second {
+		t.Errorf("expected to wait out the 7s Retry-After, got %v", *slept)
+	}
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 2 {
+		t.Errorf("expected an hour long Retry-After to be handed back, got %d after %d", resp.StatusCode, calls.Load())
+	}
+}
+
+func TestTransport_StopsWhenCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	transport := NewTransport(nil, RetryConfig{BaseDelay: time.Hour, MaxDelay: time.Hour})
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	if _, err := (&http.Client{Transport: transport}).Do(req); err == nil {
+		t.Fatal("expected an error once the request is canceled")
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("expected the backoff to end with the request, waited %v", waited)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"-1", 0, false},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSetBaseTransport(t *testing.T) {
+	var calls atomic.Int32
+	SetBaseTransport(roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("stubbed")), Request: req}, nil
+	}))
+	client := New(time.Second)
+	SetBaseTransport(nil)
+
+	resp, err := client.Get("http://example.invalid/")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 {
+		t.Errorf("expected the request sent through the base transport, got %d calls", calls.Load())
+	}
+
+	if base := New(time.Second).Transport.(*Transport).base; base != http.DefaultTransport {
+		t.Error("expected clients created after resetting to use the default transport")
+	}
+}
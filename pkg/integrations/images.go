@@ -0,0 +1,160 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+)
+
+// artistImageTTL is how long a picture found, or found missing, is trusted
+// before the sources are asked again
+const artistImageTTL = 7 * 24 * time.Hour
+
+// ImageSource finds a picture of an artist, returning "" when it has none
+type ImageSource interface {
+	ArtistImage(ctx context.Context, artist domain.Artist) (string, error)
+}
+
+type namedImageSource struct {
+	name   string
+	source ImageSource
+}
+
+// ArtistImageResolver finds a picture for an artist that actually loads.
+// The artist's own image is tried first, then each source in the order
+// registered; every candidate is requested before it's accepted, since
+// image links go stale.
+type ArtistImageResolver struct {
+	sources []namedImageSource
+	client  *http.Client
+	store   domain.ArtistImageRepository
+	now     func() time.Time
+}
+
+// NewArtistImageResolver gives each image check timeout, 5 seconds when
+// zero
+func NewArtistImageResolver(timeout time.Duration) *ArtistImageResolver {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &ArtistImageResolver{
+		client: httpclient.New(timeout),
+		now:    time.Now,
+	}
+}
+
+func (r *ArtistImageResolver) RegisterSource(name string, source ImageSource) {
+	r.sources = append(r.sources, namedImageSource{name: name, source: source})
+}
+
+// Sources lists the registered sources in the order they're asked
+func (r *ArtistImageResolver) Sources() []string {
+	names := make([]string, 0, len(r.sources))
+	for _, source := range r.sources {
+		names = append(names, source.name)
+	}
+	return names
+}
+
+// UseImageStore keeps what names resolved to in store for a week, so the
+// sources are only asked again after that
+func (r *ArtistImageResolver) UseImageStore(store domain.ArtistImageRepository) {
+	r.store = store
+}
+
+// Resolve returns a picture of artist that loads, or "" when none of the
+// sources has one. A source failing doesn't stop the others; when no
+// picture is found its error is returned and nothing is remembered.
+func (r *ArtistImageResolver) Resolve(ctx context.Context, artist domain.Artist) (string, error) {
+	if r.store != nil {
+		image, err := r.store.GetImage(ctx, artist.Name)
+		if err == nil && r.now().Sub(image.CheckedAt) < artistImageTTL {
+			return image.ImageURL, nil
+		}
+	}
+
+	candidates := append([]namedImageSource{{name: "artist"}}, r.sources...)
+
+	var errs []error
+	for _, candidate := range candidates {
+		imageURL := artist.ImageURL
+		if candidate.source != nil {
+			var err error
+			imageURL, err = candidate.source.ArtistImage(ctx, artist)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", candidate.name, err))
+				continue
+			}
+		}
+		if imageURL == "" {
+			continue
+		}
+
+		ok, err := r.loads(ctx, imageURL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", candidate.name, err))
+			continue
+		}
+		if ok {
+			r.remember(ctx, artist.Name, imageURL, candidate.name)
+			return imageURL, nil
+		}
+	}
+
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	r.remember(ctx, artist.Name, "", "")
+	return "", nil
+}
+
+func (r *ArtistImageResolver) remember(ctx context.Context, name, imageURL, source string) {
+	if r.store == nil {
+		return
+	}
+	// Only costs a lookup next time if it fails
+	r.store.SaveImage(ctx, &domain.ArtistImage{Name: name, ImageURL: imageURL, Source: source, CheckedAt: r.now()})
+}
+
+// loads reports whether imageURL answers with an image. Hosts that don't
+// take HEAD requests are asked with a GET whose body is dropped.
+func (r *ArtistImageResolver) loads(ctx context.Context, imageURL string) (bool, error) {
+	resp, err := r.request(ctx, http.MethodHead, imageURL)
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented {
+		if resp, err = r.request(ctx, http.MethodGet, imageURL); err != nil {
+			return false, err
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, nil
+	}
+	contentType := resp.Header.Get("Content-Type")
+	return contentType == "" || strings.HasPrefix(contentType, "image/"), nil
+}
+
+func (r *ArtistImageResolver) request(ctx context.Context, method, imageURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image request: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check image: %w", err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	return resp, nil
+}
@@ -0,0 +1,147 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubImageSource struct {
+	url   string
+	err   error
+	calls int
+}
+
+func (s *stubImageSource) ArtistImage(ctx context.Context, artist domain.Artist) (string, error) {
+	s.calls++
+	return s.url, s.err
+}
+
+type memoryImageStore map[string]domain.ArtistImage
+
+func (m memoryImageStore) GetImage(ctx context.Context, name string) (*domain.ArtistImage, error) {
+	image, ok := m[strings.ToLower(name)]
+	if !ok {
+		return nil, domain.ErrImageNotFound
+	}
+	return &image, nil
+}
+
+func (m memoryImageStore) SaveImage(ctx context.Context, image *domain.ArtistImage) error {
+	m[strings.ToLower(image.Name)] = *image
+	return nil
+}
+
+func TestArtistImageResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/photo.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+		case "/head-only-get.jpg":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Header().Set("Content-Type", "image/jpeg")
+		case "/error-page":
+			w.Header().Set("Content-Type", "text/html")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	newResolver := func(sources ...*stubImageSource) (*ArtistImageResolver, memoryImageStore) {
+		resolver := NewArtistImageResolver(time.Second)
+		resolver.now = func() time.Time { return now }
+		for i, source := range sources {
+			resolver.RegisterSource([]string{"spotify", "deezer", "coverartarchive"}[i], source)
+		}
+		store := memoryImageStore{}
+		resolver.UseImageStore(store)
+		return resolver, store
+	}
+
+	t.Run("the artist's own image when it loads", func(t *testing.T) {
+		spotify := &stubImageSource{url: server.URL + "/other.jpg"}
+		resolver, _ := newResolver(spotify)
+
+		imageURL, err := resolver.Resolve(context.Background(), domain.Artist{Name: "Radiohead", ImageURL: server.URL + "/photo.jpg"})
+		if err != nil || imageURL != server.URL+"/photo.jpg" {
+			t.Errorf("expected the own image, got %q and %v", imageURL, err)
+		}
+		if spotify.calls != 0 {
+			t.Error("expected no source asked")
+		}
+	})
+
+	t.Run("falls through broken and failing sources", func(t *testing.T) {
+		spotify := &stubImageSource{err: errors.New("spotify search failed: status 500")}
+		deezer := &stubImageSource{url: server.URL + "/gone.jpg"}
+		coverArt := &stubImageSource{url: server.URL + "/head-only-get.jpg"}
+		resolver, store := newResolver(spotify, deezer, coverArt)
+
+		artist := domain.Artist{Name: "Kiasmos", ImageURL: server.URL + "/error-page"}
+		imageURL, err := resolver.Resolve(context.Background(), artist)
+		if err != nil || imageURL != server.URL+"/head-only-get.jpg" {
+			t.Fatalf("expected the Cover Art Archive image, got %q and %v", imageURL, err)
+		}
+		if cached := store["kiasmos"]; cached.Source != "coverartarchive" || !cached.CheckedAt.Equal(now) {
+			t.Errorf("expected the image remembered, got %+v", cached)
+		}
+
+		// Remembered for a week
+		resolver.Resolve(context.Background(), artist)
+		if coverArt.calls != 1 {
+			t.Errorf("expected the cached image served, got %d lookups", coverArt.calls)
+		}
+		now = now.Add(8 * 24 * time.Hour)
+		resolver.Resolve(context.Background(), artist)
+		if coverArt.calls != 2 {
+			t.Errorf("expected a stale image looked up again, got %d lookups", coverArt.calls)
+		}
+	})
+
+	t.Run("nothing found", func(t *testing.T) {
+		resolver, store := newResolver(&stubImageSource{})
+
+		imageURL, err := resolver.Resolve(context.Background(), domain.Artist{Name: "Unknown Band"})
+		if err != nil || imageURL != "" {
+			t.Errorf("expected no image, got %q and %v", imageURL, err)
+		}
+		if _, ok := store["unknown band"]; !ok {
+			t.Error("expected the miss remembered")
+		}
+
+		failing, store := newResolver(&stubImageSource{err: errors.New("timeout")})
+		if _, err := failing.Resolve(context.Background(), domain.Artist{Name: "Unknown Band"}); err == nil {
+			t.Error("expected the source error")
+		}
+		if _, ok := store["unknown band"]; ok {
+			t.Error("expected a miss from a failing source not remembered")
+		}
+	})
+}
+
+func TestProfileAggregator_Image(t *testing.T) {
+	resolver := NewArtistImageResolver(time.Second)
+	resolver.RegisterSource("deezer", &stubImageSource{err: errors.New("upstream down")})
+
+	aggregator := NewProfileAggregator(0)
+	aggregator.UseImageResolver(resolver)
+
+	profile, errs := aggregator.BuildProfile(context.Background(), domain.Artist{Name: "Radiohead", ImageURL: "http://127.0.0.1:1/broken.jpg"})
+	if profile.Artist.ImageURL != "http://127.0.0.1:1/broken.jpg" {
+		t.Errorf("expected a failed lookup to keep the image, got %q", profile.Artist.ImageURL)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0], "images: ") {
+		t.Errorf("expected the image error reported, got %v", errs)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
 )
 
 type LastFMClient struct {
@@ -27,11 +28,9 @@ func NewLastFMClient(config LastFMConfig) (*LastFMClient, error) {
 	}
 
 	return &LastFMClient{
-		baseURL: "http://ws.audioscrobbler.com/2.0",
-		apiKey:  config.APIKey,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		baseURL:    "http://ws.audioscrobbler.com/2.0",
+		apiKey:     config.APIKey,
+		httpClient: httpclient.NewFor("lastfm", 10*time.Second),
 	}, nil
 }
 
@@ -175,6 +174,7 @@ func (c *LastFMClient) GetArtist(ctx context.Context, artistName string) (*domai
 	for _, tag := range infoResp.Artist.Tags.Tag {
 		artist.Genres = append(artist.Genres, tag.Name)
 	}
+	artist.Genres = domain.NormalizeGenres(artist.Genres)
 
 	for _, img := range infoResp.Artist.Image {
 		if img.Size == "extralarge" && img.Text != "" {
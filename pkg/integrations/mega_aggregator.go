@@ -2,33 +2,85 @@ package integrations
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/yair/where-its-at/pkg/cache"
 	"github.com/yair/where-its-at/pkg/domain"
 	"github.com/yair/where-its-at/pkg/integrations/sources/scrapers"
+	"github.com/yair/where-its-at/pkg/scoring"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const tracerName = "github.com/yair/where-its-at/pkg/integrations"
+
 type MegaAggregator struct {
 	musicSources    map[string]MusicSource
 	eventSources    map[string]EventSource
+	historySources  map[string]HistorySource
+	aliasSources    []namedAliasSource
+	aliases         *ArtistAliasTable
+	identities      domain.ArtistIdentityRepository
 	scraperRegistry *scrapers.ScraperRegistry
 	deduplicator    *Deduplicator
 	cache           *AggregatorCache
+	breakers        map[string]*CircuitBreaker
+	breakersMu      sync.Mutex
+	quotaReporters  map[string]QuotaReporter
+	disabled        map[string]bool
+	disabledMu      sync.RWMutex
+	limits          map[string]SourceLimits
+	limitSlots      map[string]chan struct{}
+	limitsMu        sync.Mutex
+	followUps       *followUps
+	speller         SpellingSuggester
 	config          MegaAggregatorConfig
+
+	// sourcesMu guards the source maps, alias sources and quota reporters,
+	// which registering replaces rather than changes
+	sourcesMu sync.RWMutex
 }
 
 type MegaAggregatorConfig struct {
 	MaxConcurrentRequests int
-	RequestTimeout        time.Duration
+	RequestTimeout        time.Duration // per source call, unless SourceLimits sets one
 	CacheEnabled          bool
 	CacheTTL              time.Duration
+	CacheMaxEntries       int   // defaults to 10000 searches
+	CacheMaxBytes         int64 // defaults to 64 MiB of estimated results
+	CacheStore            cache.Store
+	CacheNoResultsTTL     time.Duration // for searches that found nothing, 2 minutes by default
 	DeduplicationEnabled  bool
 	IncludeScrapers       bool
 	MaxResultsPerSource   int
+	SourceLimits          map[string]SourceLimits // by source name
+	FastQuorum            int                     // sources a fast search waits for, defaults to 2
+	FastSoftDeadline      time.Duration           // defaults to 2 seconds
+	FollowUpTTL           time.Duration           // how long fast searches' complete results are kept
+	BreakerThreshold      int
+	BreakerCoolDown       time.Duration
+	Ranker                Ranker // defaults to a WeightedRanker with the default weights
+	Logger                *slog.Logger
+	Metrics               AggregatorMetrics
+}
+
+// AggregatorMetrics receives per-source search timings and cache lookups,
+// evictions and size
+type AggregatorMetrics interface {
+	ObserveSourceSearch(source string, duration time.Duration, err error)
+	ObserveCacheLookup(cache string, hit bool)
+	ObserveCacheEviction(cache, reason string)
+	ObserveCacheSize(entries int, bytes int64)
 }
 
 type MusicSource interface {
@@ -42,19 +94,45 @@ type EventSource interface {
 	GetName() string
 }
 
+// ArtistEventSource is an EventSource that can search by the artist's
+// external IDs, not just its name
+type ArtistEventSource interface {
+	SearchEventsForArtist(ctx context.Context, artist domain.Artist, limit int) ([]domain.Event, error)
+}
+
 type Scraper interface {
 	ScrapeEvents(ctx context.Context, query string, limit int) ([]scrapers.ScrapedEvent, error)
 	ScrapeEventsByLocation(ctx context.Context, city, country string, limit int) ([]scrapers.ScrapedEvent, error)
 	GetName() string
 }
 
+// ParseHealthReporter is implemented by scrapers that can tell when their
+// selectors stopped matching the site's markup
+type ParseHealthReporter interface {
+	ParseHealth() scrapers.ParseHealth
+}
+
+// QuotaReporter is implemented by clients that track an upstream request budget
+type QuotaReporter interface {
+	Quota() domain.SourceQuota
+}
+
 type AggregatedResults struct {
-	Artists      []domain.Artist `json:"artists"`
-	Events       []domain.Event  `json:"events"`
-	SourceStats  map[string]int  `json:"source_stats"`
-	TotalResults int             `json:"total_results"`
-	SearchTime   time.Duration   `json:"search_time"`
-	Errors       []string        `json:"errors,omitempty"`
+	Artists         []domain.Artist          `json:"artists"`
+	Events          []domain.Event           `json:"events"`
+	SourceStats     map[string]int           `json:"source_stats"`
+	SourceDurations map[string]time.Duration `json:"source_durations,omitempty"`
+	TotalResults    int                      `json:"total_results"`
+	SearchTime      time.Duration            `json:"search_time"`
+	Errors          []string                 `json:"errors,omitempty"`
+	Scores          map[string]ResultScore   `json:"scores,omitempty"` // by artist or event ID
+	// Pending lists the sources a fast search answered without; its
+	// complete results can be fetched by FollowUp once they're in
+	Pending  []string `json:"pending,omitempty"`
+	FollowUp string   `json:"follow_up,omitempty"`
+	// Suggestion is the known artist a search that found nothing most
+	// likely misspelled
+	Suggestion string `json:"suggestion,omitempty"`
 }
 
 func NewMegaAggregator(config MegaAggregatorConfig) *MegaAggregator {
@@ -70,28 +148,122 @@ func NewMegaAggregator(config MegaAggregatorConfig) *MegaAggregator {
 	if config.MaxResultsPerSource == 0 {
 		config.MaxResultsPerSource = 20
 	}
+	if config.FastQuorum == 0 {
+		config.FastQuorum = defaultFastQuorum
+	}
+	if config.FastSoftDeadline == 0 {
+		config.FastSoftDeadline = defaultFastSoftDeadline
+	}
+	if config.FollowUpTTL == 0 {
+		config.FollowUpTTL = defaultFollowUpTTL
+	}
+	if config.Ranker == nil {
+		config.Ranker = NewWeightedRanker(WeightedRankerConfig{})
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
 
 	aggregator := &MegaAggregator{
 		musicSources:    make(map[string]MusicSource),
 		eventSources:    make(map[string]EventSource),
+		historySources:  make(map[string]HistorySource),
+		aliases:         NewArtistAliasTable(artistAliasTTL),
 		scraperRegistry: scrapers.NewScraperRegistry(),
 		deduplicator:    NewDeduplicator(),
+		breakers:        make(map[string]*CircuitBreaker),
+		quotaReporters:  make(map[string]QuotaReporter),
+		disabled:        make(map[string]bool),
+		limits:          maps.Clone(config.SourceLimits),
+		limitSlots:      make(map[string]chan struct{}),
+		followUps:       newFollowUps(config.FollowUpTTL),
 		config:          config,
 	}
+	if aggregator.limits == nil {
+		aggregator.limits = make(map[string]SourceLimits)
+	}
 
 	if config.CacheEnabled {
-		aggregator.cache = NewAggregatorCache(config.CacheTTL)
+		aggregator.cache = NewAggregatorCache(AggregatorCacheConfig{
+			TTL:          config.CacheTTL,
+			NoResultsTTL: config.CacheNoResultsTTL,
+			MaxEntries:   config.CacheMaxEntries,
+			MaxBytes:     config.CacheMaxBytes,
+			Metrics:      config.Metrics,
+			Store:        config.CacheStore,
+			Logger:       config.Logger,
+		})
 	}
 
 	return aggregator
 }
 
+// registeredSources are the sources as they were when a search started.
+// Registering swaps in new maps, so these can be ranged over unlocked while
+// sources are added.
+type registeredSources struct {
+	music   map[string]MusicSource
+	events  map[string]EventSource
+	history map[string]HistorySource
+	aliases []namedAliasSource
+	quotas  map[string]QuotaReporter
+}
+
+func (m *MegaAggregator) sources() registeredSources {
+	m.sourcesMu.RLock()
+	defer m.sourcesMu.RUnlock()
+	return registeredSources{
+		music:   m.musicSources,
+		events:  m.eventSources,
+		history: m.historySources,
+		aliases: m.aliasSources,
+		quotas:  m.quotaReporters,
+	}
+}
+
+// withSource copies sources with name added, leaving the map searches may
+// be ranging over untouched
+func withSource[S any](sources map[string]S, name string, source S) map[string]S {
+	copied := make(map[string]S, len(sources)+1)
+	for existing, src := range sources {
+		copied[existing] = src
+	}
+	copied[name] = source
+	return copied
+}
+
+// addQuotaReporter tracks source's quota when it reports one. The caller
+// holds sourcesMu.
+func (m *MegaAggregator) addQuotaReporter(name string, source any) {
+	if reporter, ok := source.(QuotaReporter); ok {
+		m.quotaReporters = withSource(m.quotaReporters, name, reporter)
+	}
+}
+
+// RegisterMusicSource adds a music source. Sources can be registered while
+// searches run; a search already under way keeps the sources it started
+// with.
 func (m *MegaAggregator) RegisterMusicSource(name string, source MusicSource) {
-	m.musicSources[name] = source
+	m.sourcesMu.Lock()
+	defer m.sourcesMu.Unlock()
+	m.musicSources = withSource(m.musicSources, name, source)
+	m.addQuotaReporter(name, source)
 }
 
+// RegisterEventSource adds an event source, like RegisterMusicSource
 func (m *MegaAggregator) RegisterEventSource(name string, source EventSource) {
-	m.eventSources[name] = source
+	m.sourcesMu.Lock()
+	defer m.sourcesMu.Unlock()
+	m.eventSources = withSource(m.eventSources, name, source)
+	m.addQuotaReporter(name, source)
+}
+
+// RegisterQuotaReporter tracks the quota of a client that is not searched
+// directly by the aggregator
+func (m *MegaAggregator) RegisterQuotaReporter(name string, reporter QuotaReporter) {
+	m.sourcesMu.Lock()
+	defer m.sourcesMu.Unlock()
+	m.quotaReporters = withSource(m.quotaReporters, name, reporter)
 }
 
 func (m *MegaAggregator) RegisterScraper(scraper scrapers.Scraper) {
@@ -100,6 +272,9 @@ func (m *MegaAggregator) RegisterScraper(scraper scrapers.Scraper) {
 
 func (m *MegaAggregator) SearchArtists(ctx context.Context, query string, limit int) (*AggregatedResults, error) {
 	startTime := time.Now()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "aggregator.search_artists", trace.WithAttributes(attribute.String("query", query)))
+	defer span.End()
+	filter := SourceFilterFrom(ctx)
 
 	if limit <= 0 {
 		limit = 50
@@ -107,32 +282,54 @@ func (m *MegaAggregator) SearchArtists(ctx context.Context, query string, limit
 
 	// Check cache first
 	if m.cache != nil {
-		if cached := m.cache.GetArtists(query, limit); cached != nil {
+		cached := m.cache.GetArtists(query+filter.cacheKey(), limit)
+		if cached == nil {
+			cached = m.cache.GetNoResults(false, query, filter.cacheKey())
+		}
+		m.observeCacheLookup(span, "artists", cached != nil)
+		if cached != nil {
 			return cached, nil
 		}
 	}
 
 	// Parallel search across all music sources
-	resultsChan := make(chan SourceResult, len(m.musicSources))
-	ctx, cancel := context.WithTimeout(ctx, m.config.RequestTimeout)
+	musicSources := m.sources().music
+	resultsChan := make(chan SourceResult, len(musicSources))
+	ctx, cancel := context.WithTimeout(ctx, m.searchTimeout())
 	defer cancel()
 
 	// Launch parallel searches
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, m.config.MaxConcurrentRequests)
 
-	for name, source := range m.musicSources {
+	errors := []string{}
+
+	for name, source := range musicSources {
+		if !m.shouldSearch(filter, name) {
+			continue
+		}
+		if err := m.breakerFor(name).Allow(); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
 		wg.Add(1)
 		go func(sourceName string, src MusicSource) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
+			ctx, maxResults, release := m.limitSource(ctx, sourceName)
+			defer release()
 
-			artists, err := src.SearchArtists(ctx, query, m.config.MaxResultsPerSource)
+			ctx, span := m.startSourceSpan(ctx, sourceName, "search_artists")
+			start := time.Now()
+			artists, err := src.SearchArtists(ctx, query, maxResults)
+			duration := m.finishSourceCall(ctx, span, sourceName, start, err)
 			resultsChan <- SourceResult{
 				SourceName: sourceName,
 				Artists:    artists,
 				Error:      err,
+				Duration:   duration,
 			}
 		}(name, source)
 	}
@@ -144,54 +341,80 @@ func (m *MegaAggregator) SearchArtists(ctx context.Context, query string, limit
 	}()
 
 	// Collect results
-	allArtists := []domain.Artist{}
+	candidates := []rankedArtist{}
 	sourceStats := make(map[string]int)
-	errors := []string{}
+	sourceDurations := make(map[string]time.Duration)
 
 	for result := range resultsChan {
+		sourceDurations[result.SourceName] = result.Duration
+
 		if result.Error != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", result.SourceName, result.Error))
 			continue
 		}
 
 		sourceStats[result.SourceName] = len(result.Artists)
-		allArtists = append(allArtists, result.Artists...)
-	}
-
-	// Deduplication
-	if m.config.DeduplicationEnabled {
-		allArtists = m.deduplicator.DeduplicateArtists(allArtists)
+		for _, artist := range result.Artists {
+			candidates = append(candidates, rankedArtist{
+				artist: artist,
+				score:  m.config.Ranker.ScoreArtist(query, result.SourceName, artist),
+			})
+		}
 	}
 
-	// Sort by relevance/popularity
-	sort.Slice(allArtists, func(i, j int) bool {
-		return allArtists[i].Popularity > allArtists[j].Popularity
-	})
-
-	// Limit results
-	if len(allArtists) > limit {
-		allArtists = allArtists[:limit]
-	}
+	allArtists, scores := m.rankArtists(candidates, limit)
 
 	results := &AggregatedResults{
-		Artists:      allArtists,
-		Events:       []domain.Event{},
-		SourceStats:  sourceStats,
-		TotalResults: len(allArtists),
-		SearchTime:   time.Since(startTime),
-		Errors:       errors,
+		Artists:         allArtists,
+		Events:          []domain.Event{},
+		SourceStats:     sourceStats,
+		SourceDurations: sourceDurations,
+		TotalResults:    len(allArtists),
+		SearchTime:      time.Since(startTime),
+		Errors:          errors,
+		Scores:          scores,
 	}
 
 	// Cache results
-	if m.cache != nil {
-		m.cache.SetArtists(query, limit, results)
+	if m.noResults(query, results) {
+		if m.cache != nil {
+			m.cache.SetNoResults(false, query, filter.cacheKey(), results)
+		}
+	} else if m.cache != nil {
+		m.cache.SetArtists(query+filter.cacheKey(), limit, results)
 	}
 
 	return results, nil
 }
 
 func (m *MegaAggregator) SearchEvents(ctx context.Context, artistName string, limit int) (*AggregatedResults, error) {
+	return m.StreamEvents(ctx, artistName, limit, nil)
+}
+
+// StreamEvents runs the same search as SearchEvents but hands every source
+// result to onResult as soon as it arrives, before deduplication and limiting.
+// onResult is called from the caller's goroutine; cached searches skip it.
+func (m *MegaAggregator) StreamEvents(ctx context.Context, artistName string, limit int, onResult func(SourceResult)) (*AggregatedResults, error) {
+	return m.streamArtistEvents(ctx, domain.Artist{Name: artistName}, limit, onResult)
+}
+
+// SearchEventsForArtist searches every event source for a stored artist,
+// letting sources that implement ArtistEventSource use its external IDs
+func (m *MegaAggregator) SearchEventsForArtist(ctx context.Context, artist domain.Artist, limit int) (*AggregatedResults, error) {
+	return m.streamArtistEvents(ctx, artist, limit, nil)
+}
+
+func (m *MegaAggregator) streamArtistEvents(ctx context.Context, artist domain.Artist, limit int, onResult func(SourceResult)) (*AggregatedResults, error) {
 	startTime := time.Now()
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "aggregator.search_events", trace.WithAttributes(attribute.String("artist.name", artist.Name)))
+	defer span.End()
+	filter := SourceFilterFrom(ctx)
+
+	// Every spelling of the artist shares a search, cached under its name
+	query := artist.Name
+	artist = m.resolveArtist(ctx, artist)
+	artistName := artist.Name
+	names := searchNames(artist, query)
 
 	if limit <= 0 {
 		limit = 50
@@ -199,31 +422,61 @@ func (m *MegaAggregator) SearchEvents(ctx context.Context, artistName string, li
 
 	// Check cache first
 	if m.cache != nil {
-		if cached := m.cache.GetEvents(artistName, "", limit); cached != nil {
+		cached := m.cache.GetEvents(artistName+filter.cacheKey(), "", limit)
+		if cached == nil {
+			cached = m.cache.GetNoResults(true, artistName, filter.cacheKey())
+		}
+		m.observeCacheLookup(span, "events", cached != nil)
+		if cached != nil {
 			return cached, nil
 		}
 	}
 
 	// Parallel search across all event sources
-	resultsChan := make(chan SourceResult, len(m.eventSources))
-	ctx, cancel := context.WithTimeout(ctx, m.config.RequestTimeout)
-	defer cancel()
+	eventSources := m.sources().events
+	resultsChan := make(chan SourceResult, len(eventSources))
+	ctx, cancel := m.searchContext(ctx)
 
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, m.config.MaxConcurrentRequests)
+	errors := []string{}
+	launched := []string{}
+
+	for name, source := range eventSources {
+		if !m.shouldSearch(filter, name) {
+			continue
+		}
+		if err := m.breakerFor(name).Allow(); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
 
-	for name, source := range m.eventSources {
 		wg.Add(1)
+		launched = append(launched, name)
 		go func(sourceName string, src EventSource) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
-
-			events, err := src.SearchEventsByArtist(ctx, artistName, m.config.MaxResultsPerSource)
+			ctx, maxResults, release := m.limitSource(ctx, sourceName)
+			defer release()
+
+			ctx, span := m.startSourceSpan(ctx, sourceName, "search_events")
+			start := time.Now()
+			var events []domain.Event
+			var err error
+			if artistSource, ok := src.(ArtistEventSource); ok {
+				events, err = artistSource.SearchEventsForArtist(ctx, artist, maxResults)
+			} else {
+				events, err = searchEachName(names, func(name string) ([]domain.Event, error) {
+					return src.SearchEventsByArtist(ctx, name, maxResults)
+				})
+			}
+			duration := m.finishSourceCall(ctx, span, sourceName, start, err)
 			resultsChan <- SourceResult{
 				SourceName: sourceName,
 				Events:     events,
 				Error:      err,
+				Duration:   duration,
 			}
 		}(name, source)
 	}
@@ -232,30 +485,52 @@ func (m *MegaAggregator) SearchEvents(ctx context.Context, artistName string, li
 	if m.config.IncludeScrapers {
 		scrapers := m.scraperRegistry.GetAllScrapers()
 		for _, scraper := range scrapers {
+			if !m.shouldSearch(filter, scraper.GetName()) {
+				continue
+			}
+			if err := m.breakerFor(scraper.GetName()).Allow(); err != nil {
+				errors = append(errors, fmt.Sprintf("%s: %v", scraper.GetName(), err))
+				continue
+			}
+
 			wg.Add(1)
+			launched = append(launched, scraper.GetName())
 			go func(scrpr Scraper) {
 				defer wg.Done()
 				semaphore <- struct{}{}
 				defer func() { <-semaphore }()
+				ctx, maxResults, release := m.limitSource(ctx, scrpr.GetName())
+				defer release()
+
+				ctx, span := m.startSourceSpan(ctx, scrpr.GetName(), "scrape_events")
+				start := time.Now()
+				events, err := searchEachName(names, func(name string) ([]domain.Event, error) {
+					scrapedEvents, err := scrpr.ScrapeEvents(ctx, name, maxResults)
+					if err != nil {
+						return nil, err
+					}
 
-				scrapedEvents, err := scrpr.ScrapeEvents(ctx, artistName, m.config.MaxResultsPerSource)
+					// Convert scraped events to domain events
+					events := make([]domain.Event, 0, len(scrapedEvents))
+					for _, se := range scrapedEvents {
+						events = append(events, se.ToEvent())
+					}
+					return events, nil
+				})
+				duration := m.finishSourceCall(ctx, span, scrpr.GetName(), start, err)
 				if err != nil {
 					resultsChan <- SourceResult{
 						SourceName: scrpr.GetName(),
 						Error:      err,
+						Duration:   duration,
 					}
 					return
 				}
 
-				// Convert scraped events to domain events
-				events := make([]domain.Event, 0, len(scrapedEvents))
-				for _, se := range scrapedEvents {
-					events = append(events, se.ToEvent())
-				}
-
 				resultsChan <- SourceResult{
 					SourceName: scrpr.GetName(),
 					Events:     events,
+					Duration:   duration,
 				}
 			}(scraper)
 		}
@@ -267,66 +542,92 @@ func (m *MegaAggregator) SearchEvents(ctx context.Context, artistName string, li
 	}()
 
 	// Collect results
-	allEvents := []domain.Event{}
+	candidates := []rankedEvent{}
 	sourceStats := make(map[string]int)
-	errors := []string{}
+	sourceDurations := make(map[string]time.Duration)
+	rankQuery := EventQuery{Artist: artistName}
+	now := time.Now()
+
+	collect := func(result SourceResult) {
+		sourceDurations[result.SourceName] = result.Duration
+
+		if onResult != nil {
+			onResult(result)
+		}
 
-	for result := range resultsChan {
 		if result.Error != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", result.SourceName, result.Error))
-			continue
+			return
 		}
 
 		sourceStats[result.SourceName] = len(result.Events)
-		allEvents = append(allEvents, result.Events...)
+		for _, event := range result.Events {
+			event = fileUnderArtist(event, artist)
+			candidates = append(candidates, rankedEvent{
+				event: event,
+				score: m.config.Ranker.ScoreEvent(rankQuery, result.SourceName, event, now),
+			})
+		}
 	}
+	pending, resume := m.gatherResults(ctx, cancel, launched, resultsChan, collect)
 
-	// Deduplication
-	if m.config.DeduplicationEnabled {
-		allEvents = m.deduplicator.DeduplicateEvents(allEvents)
+	// The artist the results were merged under, when it goes by other names
+	resolvedArtists := []domain.Artist{}
+	if len(artist.Aliases) > 0 {
+		resolvedArtists = append(resolvedArtists, artist)
 	}
 
-	// Sort by date (upcoming events first)
-	now := time.Now()
-	sort.Slice(allEvents, func(i, j int) bool {
-		// Upcoming events first, then by date
-		iUpcoming := allEvents[i].DateTime.After(now)
-		jUpcoming := allEvents[j].DateTime.After(now)
-
-		if iUpcoming && !jUpcoming {
-			return true
-		}
-		if !iUpcoming && jUpcoming {
-			return false
+	build := func() *AggregatedResults {
+		allEvents, scores := m.rankEvents(candidates, limit)
+		return &AggregatedResults{
+			Artists:         resolvedArtists,
+			Events:          allEvents,
+			SourceStats:     maps.Clone(sourceStats),
+			SourceDurations: maps.Clone(sourceDurations),
+			TotalResults:    len(allEvents),
+			SearchTime:      time.Since(startTime),
+			Errors:          slices.Clone(errors),
+			Scores:          scores,
 		}
+	}
+	results := build()
 
-		return allEvents[i].DateTime.Before(allEvents[j].DateTime)
-	})
-
-	// Limit results
-	if len(allEvents) > limit {
-		allEvents = allEvents[:limit]
+	store := func(results *AggregatedResults) {
+		if m.noResults(artistName, results) {
+			if m.cache != nil {
+				m.cache.SetNoResults(true, artistName, filter.cacheKey(), results)
+			}
+		} else if m.cache != nil {
+			m.cache.SetEvents(artistName+filter.cacheKey(), "", limit, results)
+		}
 	}
 
-	results := &AggregatedResults{
-		Artists:      []domain.Artist{},
-		Events:       allEvents,
-		SourceStats:  sourceStats,
-		TotalResults: len(allEvents),
-		SearchTime:   time.Since(startTime),
-		Errors:       errors,
+	if len(pending) > 0 {
+		m.answerEarly(results, pending, resume, build, store)
+		return results, nil
 	}
 
 	// Cache results
-	if m.cache != nil {
-		m.cache.SetEvents(artistName, "", limit, results)
-	}
+	store(results)
 
 	return results, nil
 }
 
 func (m *MegaAggregator) SearchEventsByLocation(ctx context.Context, city, country string, limit int) (*AggregatedResults, error) {
 	startTime := time.Now()
+	// Sources are asked for the name they know, and "München" and "Munich"
+	// share a cache entry
+	city = domain.NormalizeCity(city)
+	// "Deutschland" or "Germany" are passed on as "DE"
+	if code := domain.CountryCode(country); code != "" {
+		country = code
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "aggregator.search_events_by_location", trace.WithAttributes(
+		attribute.String("city", city),
+		attribute.String("country", country),
+	))
+	defer span.End()
+	filter := SourceFilterFrom(ctx)
 
 	if limit <= 0 {
 		limit = 50
@@ -334,37 +635,56 @@ func (m *MegaAggregator) SearchEventsByLocation(ctx context.Context, city, count
 
 	// Check cache first
 	if m.cache != nil {
-		if cached := m.cache.GetEvents("", city, limit); cached != nil {
+		cached := m.cache.GetEvents("", city+filter.cacheKey(), limit)
+		m.observeCacheLookup(span, "events", cached != nil)
+		if cached != nil {
 			return cached, nil
 		}
 	}
 
 	// Parallel search across all event sources and scrapers
-	totalSources := len(m.eventSources)
+	eventSources := m.sources().events
+	totalSources := len(eventSources)
 	if m.config.IncludeScrapers {
 		totalSources += len(m.scraperRegistry.GetAllScrapers())
 	}
 
 	resultsChan := make(chan SourceResult, totalSources)
-	ctx, cancel := context.WithTimeout(ctx, m.config.RequestTimeout)
-	defer cancel()
+	ctx, cancel := m.searchContext(ctx)
 
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, m.config.MaxConcurrentRequests)
+	errors := []string{}
+	launched := []string{}
 
 	// Search event sources
-	for name, source := range m.eventSources {
+	for name, source := range eventSources {
+		if !m.shouldSearch(filter, name) {
+			continue
+		}
+		if err := m.breakerFor(name).Allow(); err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
 		wg.Add(1)
+		launched = append(launched, name)
 		go func(sourceName string, src EventSource) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
+			ctx, maxResults, release := m.limitSource(ctx, sourceName)
+			defer release()
 
-			events, err := src.SearchEventsByLocation(ctx, city, country, m.config.MaxResultsPerSource)
+			ctx, span := m.startSourceSpan(ctx, sourceName, "search_events_by_location")
+			start := time.Now()
+			events, err := src.SearchEventsByLocation(ctx, city, country, maxResults)
+			duration := m.finishSourceCall(ctx, span, sourceName, start, err)
 			resultsChan <- SourceResult{
 				SourceName: sourceName,
 				Events:     events,
 				Error:      err,
+				Duration:   duration,
 			}
 		}(name, source)
 	}
@@ -373,17 +693,32 @@ func (m *MegaAggregator) SearchEventsByLocation(ctx context.Context, city, count
 	if m.config.IncludeScrapers {
 		scrapers := m.scraperRegistry.GetAllScrapers()
 		for _, scraper := range scrapers {
+			if !m.shouldSearch(filter, scraper.GetName()) {
+				continue
+			}
+			if err := m.breakerFor(scraper.GetName()).Allow(); err != nil {
+				errors = append(errors, fmt.Sprintf("%s: %v", scraper.GetName(), err))
+				continue
+			}
+
 			wg.Add(1)
+			launched = append(launched, scraper.GetName())
 			go func(scrpr Scraper) {
 				defer wg.Done()
 				semaphore <- struct{}{}
 				defer func() { <-semaphore }()
+				ctx, maxResults, release := m.limitSource(ctx, scrpr.GetName())
+				defer release()
 
-				scrapedEvents, err := scrpr.ScrapeEventsByLocation(ctx, city, country, m.config.MaxResultsPerSource)
+				ctx, span := m.startSourceSpan(ctx, scrpr.GetName(), "scrape_events_by_location")
+				start := time.Now()
+				scrapedEvents, err := scrpr.ScrapeEventsByLocation(ctx, city, country, maxResults)
+				duration := m.finishSourceCall(ctx, span, scrpr.GetName(), start, err)
 				if err != nil {
 					resultsChan <- SourceResult{
 						SourceName: scrpr.GetName(),
 						Error:      err,
+						Duration:   duration,
 					}
 					return
 				}
@@ -396,6 +731,7 @@ func (m *MegaAggregator) SearchEventsByLocation(ctx context.Context, city, count
 				resultsChan <- SourceResult{
 					SourceName: scrpr.GetName(),
 					Events:     events,
+					Duration:   duration,
 				}
 			}(scraper)
 		}
@@ -407,54 +743,56 @@ func (m *MegaAggregator) SearchEventsByLocation(ctx context.Context, city, count
 	}()
 
 	// Collect and process results (same as SearchEvents)
-	allEvents := []domain.Event{}
+	candidates := []rankedEvent{}
 	sourceStats := make(map[string]int)
-	errors := []string{}
+	sourceDurations := make(map[string]time.Duration)
+	rankQuery := EventQuery{City: city}
+	now := time.Now()
+
+	collect := func(result SourceResult) {
+		sourceDurations[result.SourceName] = result.Duration
 
-	for result := range resultsChan {
 		if result.Error != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", result.SourceName, result.Error))
-			continue
+			return
 		}
 
 		sourceStats[result.SourceName] = len(result.Events)
-		allEvents = append(allEvents, result.Events...)
-	}
-
-	if m.config.DeduplicationEnabled {
-		allEvents = m.deduplicator.DeduplicateEvents(allEvents)
-	}
-
-	now := time.Now()
-	sort.Slice(allEvents, func(i, j int) bool {
-		iUpcoming := allEvents[i].DateTime.After(now)
-		jUpcoming := allEvents[j].DateTime.After(now)
-
-		if iUpcoming && !jUpcoming {
-			return true
+		for _, event := range result.Events {
+			candidates = append(candidates, rankedEvent{
+				event: event,
+				score: m.config.Ranker.ScoreEvent(rankQuery, result.SourceName, event, now),
+			})
 		}
-		if !iUpcoming && jUpcoming {
-			return false
+	}
+	pending, resume := m.gatherResults(ctx, cancel, launched, resultsChan, collect)
+
+	build := func() *AggregatedResults {
+		allEvents, scores := m.rankEvents(candidates, limit)
+		return &AggregatedResults{
+			Artists:         []domain.Artist{},
+			Events:          allEvents,
+			SourceStats:     maps.Clone(sourceStats),
+			SourceDurations: maps.Clone(sourceDurations),
+			TotalResults:    len(allEvents),
+			SearchTime:      time.Since(startTime),
+			Errors:          slices.Clone(errors),
+			Scores:          scores,
 		}
-
-		return allEvents[i].DateTime.Before(allEvents[j].DateTime)
-	})
-
-	if len(allEvents) > limit {
-		allEvents = allEvents[:limit]
 	}
+	results := build()
 
-	results := &AggregatedResults{
-		Artists:      []domain.Artist{},
-		Events:       allEvents,
-		SourceStats:  sourceStats,
-		TotalResults: len(allEvents),
-		SearchTime:   time.Since(startTime),
-		Errors:       errors,
+	if len(pending) > 0 {
+		m.answerEarly(results, pending, resume, build, func(complete *AggregatedResults) {
+			if m.cache != nil {
+				m.cache.SetEvents("", city+filter.cacheKey(), limit, complete)
+			}
+		})
+		return results, nil
 	}
 
 	if m.cache != nil {
-		m.cache.SetEvents("", city, limit, results)
+		m.cache.SetEvents("", city+filter.cacheKey(), limit, results)
 	}
 
 	return results, nil
@@ -462,43 +800,249 @@ func (m *MegaAggregator) SearchEventsByLocation(ctx context.Context, city, count
 
 func (m *MegaAggregator) GetSourceStats() map[string]SourceInfo {
 	stats := make(map[string]SourceInfo)
+	sources := m.sources()
 
-	for name := range m.musicSources {
-		stats[name] = SourceInfo{
-			Type:   "music",
-			Status: "active",
-		}
+	for name := range sources.music {
+		stats[name] = m.sourceInfo(name, "music")
 	}
 
-	for name := range m.eventSources {
-		stats[name] = SourceInfo{
-			Type:   "events",
-			Status: "active",
+	for name := range sources.events {
+		stats[name] = m.sourceInfo(name, "events")
+	}
+
+	// A source serving history besides events is listed by what it's
+	// searched for
+	for name := range sources.history {
+		if _, listed := stats[name]; !listed {
+			stats[name] = m.sourceInfo(name, "history")
 		}
 	}
 
 	if m.config.IncludeScrapers {
 		for _, scraper := range m.scraperRegistry.GetAllScrapers() {
-			stats[scraper.GetName()] = SourceInfo{
-				Type:   "scraper",
-				Status: "active",
+			info := m.sourceInfo(scraper.GetName(), "scraper")
+			if reporter, ok := scraper.(ParseHealthReporter); ok {
+				health := reporter.ParseHealth()
+				info.Parse = &health
+				if info.Status == "active" && health.Status == "no_results_parsed" {
+					info.Status = health.Status
+				}
 			}
+			stats[scraper.GetName()] = info
 		}
 	}
 
 	return stats
 }
 
+func (m *MegaAggregator) GetSourceQuotas() map[string]domain.SourceQuota {
+	reporters := m.sources().quotas
+	quotas := make(map[string]domain.SourceQuota, len(reporters))
+	for name, reporter := range reporters {
+		quotas[name] = reporter.Quota()
+	}
+	return quotas
+}
+
+func (m *MegaAggregator) sourceInfo(name, sourceType string) SourceInfo {
+	snapshot := m.breakerFor(name).Snapshot()
+	info := SourceInfo{
+		Type:                sourceType,
+		Status:              snapshot.SourceStatus(),
+		Enabled:             m.sourceEnabled(name),
+		Weight:              1,
+		ConsecutiveFailures: snapshot.ConsecutiveFailures,
+		RetryAt:             snapshot.RetryAt,
+	}
+	if !info.Enabled {
+		info.Status = "disabled"
+	}
+	limits, _ := m.sourceLimits(name)
+	info.TimeoutMS = limits.Timeout.Milliseconds()
+	info.MaxResults = limits.MaxResults
+	info.MaxConcurrent = limits.MaxConcurrent
+	if weighter, ok := m.config.Ranker.(SourceWeighter); ok {
+		info.Weight = weighter.SourceWeight(name)
+	}
+	return info
+}
+
+func (m *MegaAggregator) breakerFor(name string) *CircuitBreaker {
+	m.breakersMu.Lock()
+	defer m.breakersMu.Unlock()
+
+	breaker, exists := m.breakers[name]
+	if !exists {
+		breaker = NewCircuitBreaker(CircuitBreakerConfig{
+			FailureThreshold: m.config.BreakerThreshold,
+			CoolDown:         m.config.BreakerCoolDown,
+		})
+		m.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// startSourceSpan starts the span covering one upstream source call, so a
+// trace shows which of the fanned out calls held a search up
+func (m *MegaAggregator) startSourceSpan(ctx context.Context, name, operation string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name+"."+operation, trace.WithAttributes(
+		attribute.String("source.name", name),
+		attribute.String("source.operation", operation),
+	))
+}
+
+// finishSourceCall records how a source call went in its breaker, span, log
+// and metrics, and returns how long it took
+func (m *MegaAggregator) finishSourceCall(ctx context.Context, span trace.Span, name string, start time.Time, err error) time.Duration {
+	duration := time.Since(start)
+	m.recordOutcome(name, err)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	if m.config.Metrics != nil {
+		m.config.Metrics.ObserveSourceSearch(name, duration, err)
+	}
+
+	if err != nil {
+		m.config.Logger.WarnContext(ctx, "source search failed", "source", name, "duration", duration, "error", err)
+	} else {
+		m.config.Logger.DebugContext(ctx, "source search finished", "source", name, "duration", duration)
+	}
+	return duration
+}
+
+func (m *MegaAggregator) observeCacheLookup(span trace.Span, cache string, hit bool) {
+	span.SetAttributes(attribute.Bool("cache.hit", hit))
+	if m.config.Metrics != nil {
+		m.config.Metrics.ObserveCacheLookup(cache, hit)
+	}
+}
+
+// recordOutcome feeds a source call result into its breaker. Invalid requests
+// and callers hanging up say nothing about the source's health, but still
+// free a half-open breaker's trial.
+func (m *MegaAggregator) recordOutcome(name string, err error) {
+	breaker := m.breakerFor(name)
+	switch {
+	case err == nil:
+		breaker.RecordSuccess()
+	case errors.Is(err, domain.ErrInvalidRequest), errors.Is(err, context.Canceled):
+		breaker.ReleaseTrial()
+	default:
+		breaker.RecordFailure()
+	}
+}
+
+type rankedArtist struct {
+	artist domain.Artist
+	score  ResultScore
+}
+
+type rankedEvent struct {
+	event domain.Event
+	score ResultScore
+}
+
+// rankArtists orders artists best score first. Deduplication runs after
+// sorting, so the copy kept of an artist found by several sources is the
+// best ranked one.
+func (m *MegaAggregator) rankArtists(candidates []rankedArtist, limit int) ([]domain.Artist, map[string]ResultScore) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score.Score != candidates[j].score.Score {
+			return candidates[i].score.Score > candidates[j].score.Score
+		}
+		return candidates[i].artist.ID < candidates[j].artist.ID
+	})
+
+	artists := make([]domain.Artist, len(candidates))
+	byID := make(map[string]ResultScore, len(candidates))
+	for i, candidate := range candidates {
+		artists[i] = candidate.artist
+		// Zero is how sources without a popularity leave it out
+		if candidate.artist.Popularity > 0 && candidate.artist.SourcePopularity == nil {
+			artists[i].SourcePopularity = map[string]int{candidate.score.Source: candidate.artist.Popularity}
+		}
+		if _, seen := byID[candidate.artist.ID]; !seen {
+			byID[candidate.artist.ID] = candidate.score
+		}
+	}
+
+	if m.config.DeduplicationEnabled {
+		artists = m.deduplicator.DeduplicateArtists(artists)
+	}
+	if len(artists) > limit {
+		artists = artists[:limit]
+	}
+
+	scores := make(map[string]ResultScore, len(artists))
+	for _, artist := range artists {
+		scores[artist.ID] = byID[artist.ID]
+	}
+	return artists, scores
+}
+
+// rankEvents orders events best score first, breaking ties by date, and
+// merges duplicates into the best ranked copy
+func (m *MegaAggregator) rankEvents(candidates []rankedEvent, limit int) ([]domain.Event, map[string]ResultScore) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score.Score != candidates[j].score.Score {
+			return candidates[i].score.Score > candidates[j].score.Score
+		}
+		return candidates[i].event.DateTime.Before(candidates[j].event.DateTime)
+	})
+
+	events := make([]domain.Event, len(candidates))
+	byID := make(map[string]ResultScore, len(candidates))
+	for i, candidate := range candidates {
+		events[i] = candidate.event
+		events[i].AddSource(candidate.score.Source)
+		if offer, ok := events[i].TicketOffer(candidate.score.Source); ok && len(events[i].TicketOffers) == 0 {
+			events[i].TicketOffers = []domain.TicketOffer{offer}
+		}
+		if _, seen := byID[candidate.event.ID]; !seen {
+			byID[candidate.event.ID] = candidate.score
+		}
+	}
+
+	if m.config.DeduplicationEnabled {
+		events = m.deduplicator.DeduplicateEvents(events)
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	scores := make(map[string]ResultScore, len(events))
+	for _, event := range events {
+		scores[event.ID] = byID[event.ID]
+	}
+	return events, scores
+}
+
 type SourceResult struct {
 	SourceName string
 	Artists    []domain.Artist
 	Events     []domain.Event
 	Error      error
+	Duration   time.Duration
 }
 
 type SourceInfo struct {
-	Type   string `json:"type"`
-	Status string `json:"status"`
+	Type                string     `json:"type"`
+	Status              string     `json:"status"`
+	Enabled             bool       `json:"enabled"`
+	Weight              float64    `json:"weight"`
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+	RetryAt             *time.Time `json:"retry_at,omitempty"`
+	// The limits the source's calls run under, defaults filled in
+	TimeoutMS     int64 `json:"timeout_ms"`
+	MaxResults    int   `json:"max_results"`
+	MaxConcurrent int   `json:"max_concurrent,omitempty"`
+	// Parse is set for scrapers. A scraper that answers but parses no events
+	// has status "no_results_parsed", which the breaker can't see.
+	Parse *scrapers.ParseHealth `json:"parse,omitempty"`
 }
 
 // Deduplicator handles removing duplicate results
@@ -508,31 +1052,73 @@ func NewDeduplicator() *Deduplicator {
 	return &Deduplicator{}
 }
 
+// DeduplicateArtists keeps the first of the artists with the same name,
+// with Popularity blended from every copy's SourcePopularity
 func (d *Deduplicator) DeduplicateArtists(artists []domain.Artist) []domain.Artist {
-	seen := make(map[string]bool)
+	seen := make(map[string]int)
 	unique := []domain.Artist{}
 
 	for _, artist := range artists {
 		key := d.normalizeArtistName(artist.Name)
-		if !seen[key] {
-			seen[key] = true
+		i, ok := seen[key]
+		if !ok {
+			seen[key] = len(unique)
 			unique = append(unique, artist)
+			continue
+		}
+		if len(artist.SourcePopularity) == 0 {
+			continue
 		}
+
+		merged := make(map[string]int, len(unique[i].SourcePopularity)+len(artist.SourcePopularity))
+		maps.Copy(merged, artist.SourcePopularity)
+		// The copy kept ranked higher, so its own scores win
+		maps.Copy(merged, unique[i].SourcePopularity)
+		unique[i].SourcePopularity = merged
+		unique[i].Popularity = scoring.Blend(merged)
 	}
 
 	return unique
 }
 
 func (d *Deduplicator) DeduplicateEvents(events []domain.Event) []domain.Event {
-	seen := make(map[string]bool)
+	seen := make(map[string]int)
 	unique := []domain.Event{}
 
 	for _, event := range events {
 		key := d.normalizeEventKey(event)
-		if !seen[key] {
-			seen[key] = true
-			unique = append(unique, event)
+		if i, ok := seen[key]; ok {
+			unique[i].ExternalIDs.Merge(event.ExternalIDs)
+			unique[i].MergeSources(event)
+			// Every vendor's link stays, so their prices can be compared
+			if unique[i].TicketURL == "" {
+				unique[i].TicketURL = event.TicketURL
+				unique[i].TicketStatus = event.TicketStatus
+			}
+			unique[i].TicketOffers = slices.Clone(unique[i].TicketOffers)
+			unique[i].AddTicketOffers(event.TicketOffers)
+			if len(unique[i].PriceRanges) == 0 {
+				unique[i].PriceRanges = event.PriceRanges
+			}
+			if len(event.Lineup) > len(unique[i].Lineup) {
+				unique[i].Lineup = event.Lineup
+			}
+			unique[i].Venue.MergeDetails(event.Venue)
+			// Only some sources say who can go, and what access is like
+			if unique[i].AgeRestriction == "" {
+				unique[i].AgeRestriction = event.AgeRestriction
+			}
+			if unique[i].Accessibility == "" {
+				unique[i].Accessibility = event.Accessibility
+			}
+			if unique[i].Timezone == "" {
+				unique[i].Timezone = event.Timezone
+				unique[i].DateTime = unique[i].LocalDateTime()
+			}
+			continue
 		}
+		seen[key] = len(unique)
+		unique = append(unique, event)
 	}
 
 	return unique
@@ -553,71 +1139,64 @@ func (d *Deduplicator) normalizeEventKey(event domain.Event) string {
 	return fmt.Sprintf("%s_%s_%s", artistKey, venueKey, dateKey)
 }
 
-// AggregatorCache provides caching for aggregated results
-type AggregatorCache struct {
-	artistCache map[string]CacheEntry
-	eventCache  map[string]CacheEntry
-	mutex       sync.RWMutex
-	ttl         time.Duration
-}
-
-type CacheEntry struct {
-	Results   *AggregatedResults
-	ExpiresAt time.Time
+// CacheStats is how many searches the aggregator holds cached. Expired
+// entries count until they're looked up again or swept. Searches kept in a
+// shared store aren't counted.
+type CacheStats struct {
+	Enabled        bool `json:"enabled"`
+	ArtistSearches int  `json:"artist_searches"`
+	EventSearches  int  `json:"event_searches"`
+
+	// Backend is "memory", or "shared" for a store every instance uses
+	Backend string `json:"backend,omitempty"`
+	// Bytes is the estimated size of the cached results
+	Bytes      int64 `json:"bytes"`
+	MaxEntries int   `json:"max_entries,omitempty"`
+	MaxBytes   int64 `json:"max_bytes,omitempty"`
 }
 
-func NewAggregatorCache(ttl time.Duration) *AggregatorCache {
-	return &AggregatorCache{
-		artistCache: make(map[string]CacheEntry),
-		eventCache:  make(map[string]CacheEntry),
-		ttl:         ttl,
+func (m *MegaAggregator) CacheStats() CacheStats {
+	if m.cache == nil {
+		return CacheStats{}
 	}
-}
-
-func (c *AggregatorCache) GetArtists(query string, limit int) *AggregatedResults {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-
-	key := fmt.Sprintf("%s_%d", query, limit)
-	entry, exists := c.artistCache[key]
-	if !exists || time.Now().After(entry.ExpiresAt) {
-		return nil
+	artists, events := m.cache.Size()
+	backend := "memory"
+	if m.config.CacheStore != nil {
+		backend = "shared"
+	}
+	return CacheStats{
+		Enabled:        true,
+		Backend:        backend,
+		ArtistSearches: artists,
+		EventSearches:  events,
+		Bytes:          m.cache.Bytes(),
+		MaxEntries:     m.cache.config.MaxEntries,
+		MaxBytes:       m.cache.config.MaxBytes,
 	}
-
-	return entry.Results
 }
 
-func (c *AggregatorCache) SetArtists(query string, limit int, results *AggregatedResults) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	key := fmt.Sprintf("%s_%d", query, limit)
-	c.artistCache[key] = CacheEntry{
-		Results:   results,
-		ExpiresAt: time.Now().Add(c.ttl),
+// RunCacheSweeps drops expired cached searches every interval until ctx is
+// done. It returns at once when caching is off.
+func (m *MegaAggregator) RunCacheSweeps(ctx context.Context, interval time.Duration) {
+	if m.cache != nil {
+		m.cache.RunSweeps(ctx, interval)
 	}
 }
 
-func (c *AggregatorCache) GetEvents(artistName, city string, limit int) *AggregatedResults {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
-
-	key := fmt.Sprintf("%s_%s_%d", artistName, city, limit)
-	entry, exists := c.eventCache[key]
-	if !exists || time.Now().After(entry.ExpiresAt) {
-		return nil
+// ClearCache drops every cached search, so the next ones ask the sources
+func (m *MegaAggregator) ClearCache() {
+	if m.cache != nil {
+		m.cache.Clear()
 	}
-
-	return entry.Results
 }
 
-func (c *AggregatorCache) SetEvents(artistName, city string, limit int, results *AggregatedResults) {
-	c.mutex.Lock()
-	defer c.mutex.Unlock()
-
-	key := fmt.Sprintf("%s_%s_%d", artistName, city, limit)
-	c.eventCache[key] = CacheEntry{
-		Results:   results,
-		ExpiresAt: time.Now().Add(c.ttl),
+// InvalidateArtistEvents drops the cached event searches for an artist,
+// whatever their limit or source filter, or the name it goes by
+func (m *MegaAggregator) InvalidateArtistEvents(artistName string) {
+	if m.cache != nil {
+		m.cache.DeleteEvents(artistName)
+		if artist, ok := m.aliases.Lookup(artistName); ok && artist.Name != artistName {
+			m.cache.DeleteEvents(artist.Name)
+		}
 	}
 }
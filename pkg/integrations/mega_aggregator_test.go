@@ -0,0 +1,548 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/sources/scrapers"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+type stubEventSource struct {
+	name   string
+	events []domain.Event
+	err    error
+}
+
+func (s *stubEventSource) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+	return s.events, s.err
+}
+
+func (s *stubEventSource) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+	return s.events, s.err
+}
+
+func (s *stubEventSource) GetName() string {
+	return s.name
+}
+
+func TestMegaAggregator_StreamEvents(t *testing.T) {
+	t.Run("reports each source before returning", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+		aggregator.RegisterEventSource("songkick", &stubEventSource{
+			name:   "songkick",
+			events: []domain.Event{{ID: "1", Title: "Show"}},
+		})
+		aggregator.RegisterEventSource("ticketmaster", &stubEventSource{
+			name: "ticketmaster",
+			err:  errors.New("upstream down"),
+		})
+
+		seen := make(map[string]SourceResult)
+		results, err := aggregator.StreamEvents(context.Background(), "test", 10, func(result SourceResult) {
+			seen[result.SourceName] = result
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(seen) != 2 {
+			t.Fatalf("expected 2 source results, got %d", len(seen))
+		}
+		if len(seen["songkick"].Events) != 1 {
+			t.Errorf("expected 1 songkick event, got %d", len(seen["songkick"].Events))
+		}
+		if seen["ticketmaster"].Error == nil {
+			t.Error("expected ticketmaster error to be reported")
+		}
+		if results.TotalResults != 1 {
+			t.Errorf("expected 1 total result, got %d", results.TotalResults)
+		}
+		if len(results.Errors) != 1 {
+			t.Errorf("expected 1 error in summary, got %d", len(results.Errors))
+		}
+	})
+
+	t.Run("nil callback behaves like SearchEvents", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+		aggregator.RegisterEventSource("songkick", &stubEventSource{
+			name:   "songkick",
+			events: []domain.Event{{ID: "1", Title: "Show"}},
+		})
+
+		results, err := aggregator.StreamEvents(context.Background(), "test", 10, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if results.TotalResults != 1 {
+			t.Errorf("expected 1 total result, got %d", results.TotalResults)
+		}
+	})
+}
+
+type stubQuotaReporter struct {
+	quota domain.SourceQuota
+}
+
+func (s *stubQuotaReporter) Quota() domain.SourceQuota {
+	return s.quota
+}
+
+func TestMegaAggregator_GetSourceQuotas(t *testing.T) {
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+	aggregator.RegisterQuotaReporter("setlistfm", &stubQuotaReporter{
+		quota: domain.SourceQuota{Source: "setlistfm", Limit: 2000, Used: 10, Remaining: 1990},
+	})
+	aggregator.RegisterEventSource("songkick", &stubEventSource{name: "songkick"})
+
+	quotas := aggregator.GetSourceQuotas()
+	if len(quotas) != 1 {
+		t.Fatalf("expected 1 quota, got %d", len(quotas))
+	}
+	if quotas["setlistfm"].Remaining != 1990 {
+		t.Errorf("expected 1990 remaining, got %d", quotas["setlistfm"].Remaining)
+	}
+}
+
+type stubScraper struct {
+	name   string
+	health scrapers.ParseHealth
+}
+
+func (s *stubScraper) ScrapeEvents(ctx context.Context, query string, limit int) ([]scrapers.ScrapedEvent, error) {
+	return nil, nil
+}
+
+func (s *stubScraper) ScrapeEventsByLocation(ctx context.Context, city, country string, limit int) ([]scrapers.ScrapedEvent, error) {
+	return nil, nil
+}
+
+func (s *stubScraper) GetName() string {
+	return s.name
+}
+
+func (s *stubScraper) ParseHealth() scrapers.ParseHealth {
+	return s.health
+}
+
+func TestMegaAggregator_ScraperParseHealth(t *testing.T) {
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{IncludeScrapers: true})
+	aggregator.RegisterScraper(&stubScraper{name: "bandcamp", health: scrapers.ParseHealth{Status: "ok", SelectorSet: "default"}})
+	aggregator.RegisterScraper(&stubScraper{name: "resident_advisor", health: scrapers.ParseHealth{Status: "no_results_parsed", ConsecutiveEmptyParses: 4}})
+
+	stats := aggregator.GetSourceStats()
+
+	if info := stats["bandcamp"]; info.Status != "active" || info.Parse == nil || info.Parse.SelectorSet != "default" {
+		t.Errorf("expected an active scraper with its parse health, got %+v", info)
+	}
+	if info := stats["resident_advisor"]; info.Status != "no_results_parsed" || info.Parse.ConsecutiveEmptyParses != 4 {
+		t.Errorf("expected empty parses to show in the status, got %+v", info)
+	}
+}
+
+// stubArtistEventSource records which search method the aggregator used
+type stubArtistEventSource struct {
+	stubEventSource
+	searchedArtist *domain.Artist
+}
+
+func (s *stubArtistEventSource) SearchEventsForArtist(ctx context.Context, artist domain.Artist, limit int) ([]domain.Event, error) {
+	s.searchedArtist = &artist
+	return s.events, s.err
+}
+
+func TestMegaAggregator_SearchEventsForArtist(t *testing.T) {
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+	byID := &stubArtistEventSource{
+		stubEventSource: stubEventSource{
+			name:   "songkick",
+			events: []domain.Event{{ID: "songkick_1", ArtistName: "Test Artist", Venue: domain.Venue{Name: "A"}}},
+		},
+	}
+	byName := &stubEventSource{
+		name:   "ticketmaster",
+		events: []domain.Event{{ID: "ticketmaster_1", ArtistName: "Test Artist", Venue: domain.Venue{Name: "B"}}},
+	}
+	aggregator.RegisterEventSource("songkick", byID)
+	aggregator.RegisterEventSource("ticketmaster", byName)
+
+	artist := domain.Artist{
+		ID:          "artist-1",
+		Name:        "Test Artist",
+		ExternalIDs: domain.ExternalIDs{MusicBrainzID: "mbid-1"},
+	}
+
+	results, err := aggregator.SearchEventsForArtist(context.Background(), artist, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if byID.searchedArtist == nil || byID.searchedArtist.ExternalIDs.MusicBrainzID != "mbid-1" {
+		t.Errorf("expected ID-aware source to receive the artist, got %+v", byID.searchedArtist)
+	}
+	if results.TotalResults != 2 {
+		t.Errorf("expected events from both sources, got %d", results.TotalResults)
+	}
+}
+
+func TestMegaAggregator_SourceTiming(t *testing.T) {
+	var buf bytes.Buffer
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{
+		Logger: slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	})
+	aggregator.RegisterEventSource("songkick", &stubEventSource{
+		name:   "songkick",
+		events: []domain.Event{{ID: "1", Title: "Show"}},
+	})
+	aggregator.RegisterEventSource("ticketmaster", &stubEventSource{
+		name: "ticketmaster",
+		err:  errors.New("upstream down"),
+	})
+
+	results, err := aggregator.SearchEventsByLocation(context.Background(), "Berlin", "DE", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if _, ok := results.SourceDurations["songkick"]; !ok {
+		t.Error("expected a duration for songkick")
+	}
+	if _, ok := results.SourceDurations["ticketmaster"]; !ok {
+		t.Error("expected a duration for the failed source too")
+	}
+
+	logged := buf.String()
+	if !strings.Contains(logged, `msg="source search failed" source=ticketmaster`) || !strings.Contains(logged, `error="upstream down"`) {
+		t.Errorf("expected the failure to be logged, got %q", logged)
+	}
+	if !strings.Contains(logged, `msg="source search finished" source=songkick`) {
+		t.Errorf("expected the success to be logged, got %q", logged)
+	}
+}
+
+func TestMegaAggregator_SearchEventsByLocation_PlaceNames(t *testing.T) {
+	var searched []string
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true})
+	aggregator.RegisterEventSource("songkick", eventSourceFuncs{
+		name: "songkick",
+		byLocation: func(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+			searched = append(searched, city+", "+country)
+			return []domain.Event{{ID: "1", Title: "Show", Venue: domain.Venue{City: "München"}}}, nil
+		},
+	})
+
+	results, err := aggregator.SearchEventsByLocation(context.Background(), "München", "Deutschland", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if results.Scores["1"].Similarity != 1 {
+		t.Errorf("expected the local name to match fully, got %+v", results.Scores["1"])
+	}
+
+	// The same city by its English name is served from the cache
+	if _, err := aggregator.SearchEventsByLocation(context.Background(), "munich", "DE", 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(searched) != 1 || searched[0] != "Munich, DE" {
+		t.Errorf("expected one search for Munich, DE, got %v", searched)
+	}
+}
+
+type recordingMetrics struct {
+	searches  map[string]int
+	failures  map[string]int
+	lookups   []bool
+	evictions []string
+	entries   int
+	bytes     int64
+}
+
+func (r *recordingMetrics) ObserveSourceSearch(source string, duration time.Duration, err error) {
+	r.searches[source]++
+	if err != nil {
+		r.failures[source]++
+	}
+}
+
+func (r *recordingMetrics) ObserveCacheLookup(cache string, hit bool) {
+	r.lookups = append(r.lookups, hit)
+}
+
+func (r *recordingMetrics) ObserveCacheEviction(cache, reason string) {
+	r.evictions = append(r.evictions, cache+" "+reason)
+}
+
+func (r *recordingMetrics) ObserveCacheSize(entries int, bytes int64) {
+	r.entries, r.bytes = entries, bytes
+}
+
+func TestMegaAggregator_Metrics(t *testing.T) {
+	metrics := &recordingMetrics{searches: make(map[string]int), failures: make(map[string]int)}
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true, Metrics: metrics})
+	aggregator.RegisterEventSource("songkick", &stubEventSource{name: "songkick", events: []domain.Event{{ID: "1"}}})
+	aggregator.RegisterEventSource("ticketmaster", &stubEventSource{name: "ticketmaster", err: errors.New("upstream down")})
+
+	aggregator.SearchEvents(context.Background(), "test", 10)
+	aggregator.SearchEvents(context.Background(), "test", 10)
+
+	if metrics.searches["songkick"] != 1 || metrics.searches["ticketmaster"] != 1 || metrics.failures["ticketmaster"] != 1 {
+		t.Errorf("expected one search per source, got %v (failures %v)", metrics.searches, metrics.failures)
+	}
+	if len(metrics.lookups) != 2 || metrics.lookups[0] || !metrics.lookups[1] {
+		t.Errorf("expected a miss then a hit, got %v", metrics.lookups)
+	}
+}
+
+func TestMegaAggregator_CacheManagement(t *testing.T) {
+	metrics := &recordingMetrics{searches: make(map[string]int), failures: make(map[string]int)}
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true, Metrics: metrics})
+	aggregator.RegisterEventSource("songkick", &stubEventSource{name: "songkick", events: []domain.Event{{ID: "1"}}})
+
+	ctx := context.Background()
+	aggregator.SearchEvents(ctx, "Radiohead", 10)
+	aggregator.SearchEvents(ctx, "Radiohead", 20)
+	aggregator.SearchEvents(WithSourceFilter(ctx, SourceFilter{Only: []string{"songkick"}}), "Radiohead", 10)
+	aggregator.SearchEvents(ctx, "Radiohead Tribute", 10)
+
+	if stats := aggregator.CacheStats(); !stats.Enabled || stats.EventSearches != 4 {
+		t.Fatalf("expected 4 cached event searches, got %+v", stats)
+	}
+
+	aggregator.InvalidateArtistEvents("Radiohead")
+	if stats := aggregator.CacheStats(); stats.EventSearches != 1 {
+		t.Errorf("expected only the other artist's search to stay, got %+v", stats)
+	}
+	aggregator.SearchEvents(ctx, "Radiohead", 10)
+	if metrics.searches["songkick"] != 5 {
+		t.Errorf("expected the invalidated search to ask the source again, got %d searches", metrics.searches["songkick"])
+	}
+
+	aggregator.ClearCache()
+	if stats := aggregator.CacheStats(); stats.EventSearches != 0 {
+		t.Errorf("expected an empty cache, got %+v", stats)
+	}
+
+	if stats := NewMegaAggregator(MegaAggregatorConfig{}).CacheStats(); stats.Enabled {
+		t.Errorf("expected the cache to be reported disabled, got %+v", stats)
+	}
+}
+
+func TestMegaAggregator_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+	aggregator.RegisterEventSource("songkick", &stubEventSource{name: "songkick", events: []domain.Event{{ID: "1"}}})
+	aggregator.RegisterEventSource("ticketmaster", &stubEventSource{name: "ticketmaster", err: errors.New("upstream down")})
+
+	if _, err := aggregator.SearchEvents(context.Background(), "test", 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recorder.Ended() {
+		spans[span.Name()] = span
+	}
+
+	root, ok := spans["aggregator.search_events"]
+	if !ok {
+		t.Fatalf("expected an aggregator span, got %v", spans)
+	}
+	for _, name := range []string{"songkick.search_events", "ticketmaster.search_events"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Fatalf("expected a %s span", name)
+		}
+		if span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("expected %s to be a child of the aggregator span", name)
+		}
+	}
+	if spans["ticketmaster.search_events"].Status().Code != codes.Error {
+		t.Error("expected the failed source span to be marked as an error")
+	}
+}
+
+func TestDeduplicator_KeepsPriceRanges(t *testing.T) {
+	when := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	venue := domain.Venue{Name: "Berghain", City: "Berlin"}
+	prices := []domain.PriceRange{{Type: "standard", Min: 30, Max: 45, Currency: "EUR"}}
+
+	events := []domain.Event{
+		{ID: "songkick_1", ArtistName: "Test Artist", DateTime: when, Venue: venue},
+		{ID: "ticketmaster_1", ArtistName: "Test Artist", DateTime: when, Venue: venue, PriceRanges: prices},
+	}
+
+	unique := NewDeduplicator().DeduplicateEvents(events)
+	if len(unique) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(unique))
+	}
+	if unique[0].ID != "songkick_1" {
+		t.Errorf("expected the first event to win, got %s", unique[0].ID)
+	}
+	if len(unique[0].PriceRanges) != 1 || unique[0].PriceRanges[0].Max != 45 {
+		t.Errorf("expected prices from the duplicate, got %+v", unique[0].PriceRanges)
+	}
+}
+
+func TestMegaAggregator_KeepsEveryTicketOffer(t *testing.T) {
+	when := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	venue := domain.Venue{Name: "Berghain", City: "Berlin"}
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{DeduplicationEnabled: true})
+	aggregator.RegisterEventSource("ticketmaster", &stubEventSource{name: "ticketmaster", events: []domain.Event{{
+		ID: "ticketmaster_1", ArtistName: "Test Artist", DateTime: when, Venue: venue,
+		TicketURL: "https://www.ticketmaster.de/event/1", TicketStatus: "onsale",
+		PriceRanges: []domain.PriceRange{{Type: "standard", Min: 30, Max: 45, Currency: "EUR"}},
+	}}})
+	aggregator.RegisterEventSource("bandsintown", &stubEventSource{name: "bandsintown", events: []domain.Event{{
+		ID: "bandsintown_1", ArtistName: "Test Artist", DateTime: when, Venue: venue,
+		TicketURL: "https://www.bandsintown.com/t/1", TicketStatus: "available",
+	}}})
+	aggregator.RegisterEventSource("songkick", &stubEventSource{name: "songkick", events: []domain.Event{{
+		ID: "songkick_1", ArtistName: "Test Artist", DateTime: when, Venue: venue,
+	}}})
+
+	results, err := aggregator.SearchEvents(context.Background(), "Test Artist", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results.Events) != 1 {
+		t.Fatalf("expected the show once, got %+v", results.Events)
+	}
+
+	offers := make(map[string]domain.TicketOffer)
+	for _, offer := range results.Events[0].TicketOffers {
+		offers[offer.Source] = offer
+	}
+	if len(offers) != 2 || offers["bandsintown"].URL != "https://www.bandsintown.com/t/1" || offers["bandsintown"].Status != "available" {
+		t.Errorf("expected an offer from each ticketed source, got %+v", results.Events[0].TicketOffers)
+	}
+	if price := offers["ticketmaster"].PriceRange; price == nil || price.Min != 30 || price.Max != 45 {
+		t.Errorf("expected Ticketmaster's prices on its offer, got %+v", price)
+	}
+	if results.Events[0].TicketURL == "" {
+		t.Error("expected a ticket link kept on the event")
+	}
+}
+
+func TestMegaAggregator_AttributesSources(t *testing.T) {
+	when := time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC)
+	venue := domain.Venue{Name: "Berghain", City: "Berlin"}
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{DeduplicationEnabled: true})
+	aggregator.RegisterEventSource("songkick", &stubEventSource{name: "songkick", events: []domain.Event{{
+		ID: "songkick_1", ArtistName: "Test Artist", DateTime: when, Venue: venue,
+		ExternalIDs: domain.EventExternalIDs{SongkickID: "1"},
+	}}})
+	aggregator.RegisterEventSource("resident_advisor", &stubEventSource{name: "resident_advisor", events: []domain.Event{{
+		ID: "ra_9", ArtistName: "Test Artist", DateTime: when, Venue: venue,
+	}}})
+
+	results, err := aggregator.SearchEvents(context.Background(), "Test Artist", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results.Events) != 1 {
+		t.Fatalf("expected the show once, got %+v", results.Events)
+	}
+
+	event := results.Events[0]
+	sources := slices.Sorted(slices.Values(event.Sources))
+	if !slices.Equal(sources, []string{"resident_advisor", "songkick"}) {
+		t.Errorf("expected both sources, got %v", event.Sources)
+	}
+	if event.SourceIDs["songkick"] != "1" || event.SourceIDs["resident_advisor"] != "ra_9" {
+		t.Errorf("expected each source's ID for the event, got %v", event.SourceIDs)
+	}
+}
+
+// blockingEventSource holds location searches until released, to register
+// sources while one is under way
+type blockingEventSource struct {
+	stubEventSource
+	once    sync.Once
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingEventSource) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+	s.once.Do(func() { close(s.started) })
+	<-s.release
+	return s.events, s.err
+}
+
+// Run with -race: registering used to write the maps searches range over
+func TestMegaAggregator_RegisterDuringSearch(t *testing.T) {
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{IncludeScrapers: true})
+	songkick := &blockingEventSource{
+		stubEventSource: stubEventSource{name: "songkick", events: []domain.Event{{ID: "1", Title: "Show"}}},
+		started:         make(chan struct{}),
+		release:         make(chan struct{}),
+	}
+	aggregator.RegisterEventSource("songkick", songkick)
+
+	searched := make(chan *AggregatedResults)
+	go func() {
+		results, _ := aggregator.SearchEventsByLocation(context.Background(), "Berlin", "DE", 10)
+		searched <- results
+	}()
+	<-songkick.started
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		name := fmt.Sprintf("source-%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			aggregator.RegisterEventSource(name, &stubEventSource{name: name, events: []domain.Event{{ID: name, Title: "Show"}}})
+			aggregator.RegisterMusicSource(name, &stubMusicSource{name: name})
+			aggregator.RegisterHistorySource(name, &stubHistorySource{name: name})
+			aggregator.RegisterAliasSource(name, &stubAliasSource{})
+			aggregator.RegisterQuotaReporter(name, &stubQuotaReporter{})
+			aggregator.RegisterScraper(&stubScraper{name: name + "-scraper"})
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := aggregator.SearchEvents(context.Background(), "Kiasmos", 10); err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			aggregator.HasSource(name)
+			aggregator.GetSourceStats()
+			aggregator.GetSourceQuotas()
+		}()
+	}
+	wg.Wait()
+	close(songkick.release)
+
+	// The search under way keeps the sources it started with
+	results := <-searched
+	if len(results.SourceStats) != 1 || results.SourceStats["songkick"] != 1 {
+		t.Errorf("expected only songkick searched, got %v", results.SourceStats)
+	}
+
+	results, err := aggregator.SearchEventsByLocation(context.Background(), "Berlin", "DE", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results.SourceStats) != 21 {
+		t.Errorf("expected songkick, 10 sources and 10 scrapers searched, got %v", results.SourceStats)
+	}
+	if quotas := aggregator.GetSourceQuotas(); len(quotas) != 10 {
+		t.Errorf("expected 10 quotas, got %d", len(quotas))
+	}
+}
@@ -0,0 +1,30 @@
+package integrations
+
+// SpellingSuggester proposes the known artist a query most likely
+// misspells, like ArtistSuggester
+type SpellingSuggester interface {
+	DidYouMean(query string) (string, bool)
+}
+
+// SetSpellingSuggester makes searches that find nothing suggest the artist
+// they most likely meant. It's set up before searching.
+func (m *MegaAggregator) SetSpellingSuggester(speller SpellingSuggester) {
+	m.speller = speller
+}
+
+// noResults reports whether a search for query found nothing though a
+// source answered, filling in its suggestion. Those results are cached for a
+// short while under the query however it's spelled, so repeating a typo
+// doesn't ask every source again. A search every source failed says nothing
+// about the query, so it's left to the usual caching.
+func (m *MegaAggregator) noResults(query string, results *AggregatedResults) bool {
+	if results.TotalResults > 0 || len(results.SourceStats) == 0 {
+		return false
+	}
+	if m.speller != nil {
+		if name, ok := m.speller.DidYouMean(query); ok {
+			results.Suggestion = name
+		}
+	}
+	return true
+}
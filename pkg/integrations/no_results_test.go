@@ -0,0 +1,98 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// countedEventSource counts the artist searches it answers
+type countedEventSource struct {
+	stubEventSource
+	calls atomic.Int32
+}
+
+func (s *countedEventSource) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+	s.calls.Add(1)
+	return s.events, s.err
+}
+
+type stubSpeller map[string]string
+
+func (s stubSpeller) DidYouMean(query string) (string, bool) {
+	name, ok := s[domain.ArtistNameKey(query)]
+	return name, ok
+}
+
+func TestMegaAggregator_NoResults(t *testing.T) {
+	speller := stubSpeller{"radiohed": "Radiohead"}
+
+	t.Run("caches event searches that found nothing briefly", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true, CacheNoResultsTTL: time.Minute})
+		aggregator.SetSpellingSuggester(speller)
+		source := &countedEventSource{stubEventSource: stubEventSource{name: "songkick"}}
+		aggregator.RegisterEventSource("songkick", source)
+		now := time.Now()
+		aggregator.cache.now = func() time.Time { return now }
+
+		results, err := aggregator.SearchEvents(context.Background(), "Radiohed", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if results.TotalResults != 0 || results.Suggestion != "Radiohead" {
+			t.Errorf("expected no events and a suggestion, got %+v", results)
+		}
+
+		// Any spelling and limit of the query is answered from the cache
+		for _, query := range []string{"Radiohed", "RADIOHED!"} {
+			cached, _ := aggregator.SearchEvents(context.Background(), query, 25)
+			if cached.Suggestion != "Radiohead" {
+				t.Errorf("%s: expected the cached suggestion, got %+v", query, cached)
+			}
+		}
+		if calls := source.calls.Load(); calls != 1 {
+			t.Errorf("expected the source to be asked once, got %d", calls)
+		}
+
+		now = now.Add(2 * time.Minute)
+		aggregator.SearchEvents(context.Background(), "Radiohed", 10)
+		if calls := source.calls.Load(); calls != 2 {
+			t.Errorf("expected the source to be asked again after the TTL, got %d", calls)
+		}
+
+		// Invalidating the artist's searches drops it too
+		aggregator.InvalidateArtistEvents("Radiohed")
+		aggregator.SearchEvents(context.Background(), "Radiohed", 10)
+		if calls := source.calls.Load(); calls != 3 {
+			t.Errorf("expected the source to be asked after invalidating, got %d", calls)
+		}
+	})
+
+	t.Run("caches artist searches that found nothing", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true})
+		aggregator.SetSpellingSuggester(speller)
+		source := &stubMusicSource{name: "lastfm"}
+		aggregator.RegisterMusicSource("lastfm", source)
+
+		aggregator.SearchArtists(context.Background(), "radiohed", 10)
+		results, _ := aggregator.SearchArtists(context.Background(), "Radiohed", 5)
+		if source.calls != 1 || results.Suggestion != "Radiohead" {
+			t.Errorf("expected one call and the suggestion, got %d calls, %+v", source.calls, results)
+		}
+	})
+
+	t.Run("doesn't suggest when every source failed", func(t *testing.T) {
+		aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+		aggregator.SetSpellingSuggester(speller)
+		aggregator.RegisterEventSource("songkick", &stubEventSource{name: "songkick", err: errors.New("unavailable")})
+
+		results, _ := aggregator.SearchEvents(context.Background(), "Radiohed", 10)
+		if results.Suggestion != "" || len(results.Errors) != 1 {
+			t.Errorf("expected the error without a suggestion, got %+v", results)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+// Package oauth manages the access tokens source integrations send as
+// bearer tokens. Tokens are fetched once, shared by concurrent requests and
+// refreshed ahead of expiry, so long running syncs don't start failing with
+// 401s partway through.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TokenProvider hands out a current access token
+type TokenProvider interface {
+	// Token returns a token that hasn't expired, fetching one when needed
+	Token(ctx context.Context) (string, error)
+	// Invalidate drops token after the API rejected it, so the next call
+	// to Token fetches a new one. A token other than token, fetched by a
+	// concurrent request in the meantime, is kept.
+	Invalidate(token string)
+}
+
+// Token is an access token and when it stops being accepted. A zero
+// ExpiresAt never expires.
+type Token struct {
+	AccessToken string
+	ExpiresAt   time.Time
+}
+
+// FetchFunc gets a new token from the authorization server
+type FetchFunc func(ctx context.Context) (Token, error)
+
+// CachedTokenProvider caches the token fetch returns until refreshAhead
+// before it expires. Concurrent callers needing a new token wait for a
+// single fetch.
+type CachedTokenProvider struct {
+	fetch        FetchFunc
+	refreshAhead time.Duration
+	now          func() time.Time
+
+	// lock is held while reading, replacing or fetching the token. It's a
+	// channel so waiting can give up with the caller's context.
+	lock  chan struct{}
+	token Token
+}
+
+func NewCachedTokenProvider(fetch FetchFunc, refreshAhead time.Duration) *CachedTokenProvider {
+	return &CachedTokenProvider{
+		fetch:        fetch,
+		refreshAhead: refreshAhead,
+		now:          time.Now,
+		lock:         make(chan struct{}, 1),
+	}
+}
+
+// Token returns the cached token, fetching a new one once it's within
+// refreshAhead of expiring. If that fetch fails while the cached token is
+// still valid, the cached token is returned and the next call tries again.
+func (p *CachedTokenProvider) Token(ctx context.Context) (string, error) {
+	select {
+	case p.lock <- struct{}{}:
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	defer func() { <-p.lock }()
+
+	now := p.now()
+	if p.token.AccessToken != "" && !p.expired(now, p.refreshAhead) {
+		return p.token.AccessToken, nil
+	}
+
+	token, err := p.fetch(ctx)
+	if err != nil {
+		if p.token.AccessToken != "" && !p.expired(now, 0) {
+			return p.token.AccessToken, nil
+		}
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("authorization server returned no access token")
+	}
+
+	p.token = token
+	return token.AccessToken, nil
+}
+
+func (p *CachedTokenProvider) Invalidate(token string) {
+	p.lock <- struct{}{}
+	defer func() { <-p.lock }()
+
+	if p.token.AccessToken == token {
+		p.token = Token{}
+	}
+}
+
+func (p *CachedTokenProvider) expired(now time.Time, leeway time.Duration) bool {
+	return !p.token.ExpiresAt.IsZero() && !now.Add(leeway).Before(p.token.ExpiresAt)
+}
+
+// StaticToken is a token that doesn't expire, such as a personal API token
+type StaticToken string
+
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// Invalidate does nothing, as there's no other token to fetch
+func (t StaticToken) Invalidate(token string) {}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// ClientCredentials fetches tokens with the OAuth 2.0 client credentials
+// grant, authenticating the client with HTTP basic auth
+func ClientCredentials(httpClient *http.Client, tokenURL, clientID, clientSecret string, scopes ...string) FetchFunc {
+	return func(ctx context.Context) (Token, error) {
+		data := url.Values{}
+		data.Set("grant_type", "client_credentials")
+		if len(scopes) > 0 {
+			data.Set("scope", strings.Join(scopes, " "))
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
+		if err != nil {
+			return Token{}, fmt.Errorf("failed to create token request: %w", err)
+		}
+
+		req.SetBasicAuth(clientID, clientSecret)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return Token{}, fmt.Errorf("failed to get access token: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return Token{}, fmt.Errorf("failed to get access token: status %d", resp.StatusCode)
+		}
+
+		var tokenResp tokenResponse
+		if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+			return Token{}, fmt.Errorf("failed to decode token response: %w", err)
+		}
+
+		token := Token{AccessToken: tokenResp.AccessToken}
+		if tokenResp.ExpiresIn > 0 {
+			token.ExpiresAt = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+		}
+		return token, nil
+	}
+}
+
+// Do sends req with a bearer token from tokens. When the API answers 401
+// the token is invalidated and req is sent once more with a new one, as
+// long as there is a new one and req has no body or one that can be
+// replayed.
+func Do(httpClient *http.Client, tokens TokenProvider, req *http.Request) (*http.Response, error) {
+	return DoWithScheme(httpClient, tokens, "Bearer", req)
+}
+
+// DoWithScheme is Do for APIs that want the token under another
+// authorization scheme than Bearer, such as SoundCloud's "OAuth"
+func DoWithScheme(httpClient *http.Client, tokens TokenProvider, scheme string, req *http.Request) (*http.Response, error) {
+	token, err := tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", scheme+" "+token)
+	resp, err := httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	tokens.Invalidate(token)
+	fresh, err := tokens.Token(req.Context())
+	if err != nil || fresh == token {
+		return resp, nil
+	}
+
+	retry := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+
+	retry.Header.Set("Authorization", scheme+" "+fresh)
+	return httpClient.Do(retry)
+}
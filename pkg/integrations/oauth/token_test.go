@@ -0,0 +1,211 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCachedTokenProvider_RefreshesAheadOfExpiry(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	var fetches int
+	var fetchErr error
+	provider := NewCachedTokenProvider(func(ctx context.Context) (Token, error) {
+		if fetchErr != nil {
+			return Token{}, fetchErr
+		}
+		fetches++
+		return Token{AccessToken: string(rune('a' + fetches - 1)), ExpiresAt: now.Add(time.Hour)}, nil
+	}, 5*time.Minute)
+	provider.now = func() time.Time { return now }
+
+	token := func() string {
+		t.Helper()
+		got, err := provider.Token(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return got
+	}
+
+	if token() != "a" || token() != "a" || fetches != 1 {
+		t.Fatalf("expected one fetch for repeat calls, got %d", fetches)
+	}
+
+	// Within the refresh window a failed fetch falls back to the cached token
+	now = now.Add(56 * time.Minute)
+	fetchErr = errors.New("accounts down")
+	if token() != "a" {
+		t.Error("expected the still valid token while the refresh fails")
+	}
+	fetchErr = nil
+	if token() != "b" || fetches != 2 {
+		t.Errorf("expected a refresh ahead of expiry, got %d fetches", fetches)
+	}
+
+	// Once expired a failed fetch is an error
+	now = now.Add(2 * time.Hour)
+	fetchErr = errors.New("accounts down")
+	if _, err := provider.Token(context.Background()); err == nil {
+		t.Error("expected an error with no valid token")
+	}
+}
+
+func TestCachedTokenProvider_Invalidate(t *testing.T) {
+	var fetches int
+	provider := NewCachedTokenProvider(func(ctx context.Context) (Token, error) {
+		fetches++
+		return Token{AccessToken: string(rune('a' + fetches - 1))}, nil
+	}, time.Minute)
+
+	first, _ := provider.Token(context.Background())
+	provider.Invalidate(first)
+	second, _ := provider.Token(context.Background())
+	if first != "a" || second != "b" {
+		t.Fatalf("expected a new token after invalidating, got %q then %q", first, second)
+	}
+
+	// A stale rejection doesn't drop the token fetched since
+	provider.Invalidate(first)
+	if third, _ := provider.Token(context.Background()); third != "b" || fetches != 2 {
+		t.Errorf("expected the newer token kept, got %q after %d fetches", third, fetches)
+	}
+}
+
+func TestCachedTokenProvider_ConcurrentCallersShareOneFetch(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	provider := NewCachedTokenProvider(func(ctx context.Context) (Token, error) {
+		fetches.Add(1)
+		<-release
+		return Token{AccessToken: "shared", ExpiresAt: time.Now().Add(time.Hour)}, nil
+	}, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if token, err := provider.Token(context.Background()); err != nil || token != "shared" {
+				t.Errorf("expected the shared token, got %q, %v", token, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if fetches.Load() != 1 {
+		t.Errorf("expected 1 fetch, got %d", fetches.Load())
+	}
+
+	// A caller waiting on a fetch gives up with its context
+	stop := make(chan struct{})
+	defer close(stop)
+	blocked := NewCachedTokenProvider(func(ctx context.Context) (Token, error) {
+		<-stop
+		return Token{}, errors.New("stopped")
+	}, time.Minute)
+	go blocked.Token(context.Background())
+	time.Sleep(10 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := blocked.Token(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline, got %v", err)
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(tokenResponse{AccessToken: "token", TokenType: "Bearer", ExpiresIn: 3600})
+	}))
+	defer server.Close()
+
+	token, err := ClientCredentials(server.Client(), server.URL, "client", "secret")(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if token.AccessToken != "token" || time.Until(token.ExpiresAt) < 59*time.Minute {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	if _, err := ClientCredentials(server.Client(), server.URL, "client", "wrong")(context.Background()); err == nil {
+		t.Error("expected an error for rejected credentials")
+	}
+}
+
+func TestDo_RetriesWithNewTokenOn401(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer b" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var fetches int
+	tokens := NewCachedTokenProvider(func(ctx context.Context) (Token, error) {
+		fetches++
+		return Token{AccessToken: string(rune('a' + fetches - 1))}, nil
+	}, time.Minute)
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := Do(server.Client(), tokens, req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || fetches != 2 {
+		t.Errorf("expected success with the second token, got %d after %d fetches", resp.StatusCode, fetches)
+	}
+
+	// A static token can't be replaced, so the 401 is returned as is
+	req, _ = http.NewRequest("GET", server.URL, nil)
+	resp, err = Do(server.Client(), StaticToken("a"), req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestDoWithScheme(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "OAuth b" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	var fetches int
+	tokens := NewCachedTokenProvider(func(ctx context.Context) (Token, error) {
+		fetches++
+		return Token{AccessToken: string(rune('a' + fetches - 1))}, nil
+	}, time.Minute)
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := DoWithScheme(server.Client(), tokens, "OAuth", req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the retry to send the token under the OAuth scheme, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,196 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// profileSectionLimit caps how many albums, releases, tracks or videos each
+// source contributes to a profile
+const profileSectionLimit = 25
+
+// AlbumSource lists the albums a streaming service has for an artist
+type AlbumSource interface {
+	ArtistAlbums(ctx context.Context, artist domain.Artist, limit int) ([]domain.Album, error)
+}
+
+// ReleaseSource lists an artist's releases from a discography database
+type ReleaseSource interface {
+	ArtistReleases(ctx context.Context, artist domain.Artist, limit int) ([]domain.Release, error)
+}
+
+// ProfileAggregator builds an artist's profile from every source registered
+// for each part of it. Within a part, earlier registered sources win when
+// two of them list the same album or song.
+type ProfileAggregator struct {
+	fetchers []profileFetcher
+	images   *ArtistImageResolver
+	timeout  time.Duration
+	now      func() time.Time
+}
+
+// profileFetcher fills its part of a blank profile from one source
+type profileFetcher struct {
+	name  string
+	fetch func(ctx context.Context, artist domain.Artist, part *domain.ArtistProfile) error
+}
+
+func NewProfileAggregator(timeout time.Duration) *ProfileAggregator {
+	if timeout <= 0 {
+		timeout = 15 * time.Second
+	}
+	return &ProfileAggregator{
+		timeout: timeout,
+		now:     time.Now,
+	}
+}
+
+func (a *ProfileAggregator) RegisterAlbumSource(name string, source AlbumSource) {
+	a.fetchers = append(a.fetchers, profileFetcher{name: name, fetch: func(ctx context.Context, artist domain.Artist, part *domain.ArtistProfile) error {
+		var err error
+		part.Albums, err = source.ArtistAlbums(ctx, artist, profileSectionLimit)
+		return err
+	}})
+}
+
+func (a *ProfileAggregator) RegisterReleaseSource(name string, source ReleaseSource) {
+	a.fetchers = append(a.fetchers, profileFetcher{name: name, fetch: func(ctx context.Context, artist domain.Artist, part *domain.ArtistProfile) error {
+		var err error
+		part.Releases, err = source.ArtistReleases(ctx, artist, profileSectionLimit)
+		return err
+	}})
+}
+
+func (a *ProfileAggregator) RegisterTrackSource(name string, source TrackSource) {
+	a.fetchers = append(a.fetchers, profileFetcher{name: name, fetch: func(ctx context.Context, artist domain.Artist, part *domain.ArtistProfile) error {
+		var err error
+		part.Tracks, err = source.ArtistTracks(ctx, artist, profileSectionLimit)
+		return err
+	}})
+}
+
+// RegisterVideoSource adds a source whose tracks are music videos
+func (a *ProfileAggregator) RegisterVideoSource(name string, source TrackSource) {
+	a.fetchers = append(a.fetchers, profileFetcher{name: name, fetch: func(ctx context.Context, artist domain.Artist, part *domain.ArtistProfile) error {
+		var err error
+		part.Videos, err = source.ArtistTracks(ctx, artist, profileSectionLimit)
+		return err
+	}})
+}
+
+// UseImageResolver has profiles carry a picture of the artist that loads,
+// found alongside the rest of the profile
+func (a *ProfileAggregator) UseImageResolver(resolver *ArtistImageResolver) {
+	a.images = resolver
+}
+
+// BuildProfile asks every source at once and merges what they return into
+// one profile of the artist. Sources that fail are left out of Sources and
+// reported in the returned errors.
+func (a *ProfileAggregator) BuildProfile(ctx context.Context, artist domain.Artist) (*domain.ArtistProfile, []string) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	parts := make([]domain.ArtistProfile, len(a.fetchers))
+	errs := make([]error, len(a.fetchers))
+
+	var wg sync.WaitGroup
+	imageURL, imageErr := artist.ImageURL, error(nil)
+	if a.images != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			imageURL, imageErr = a.images.Resolve(ctx, artist)
+		}()
+	}
+	for i, fetcher := range a.fetchers {
+		wg.Add(1)
+		go func(i int, fetcher profileFetcher) {
+			defer wg.Done()
+			errs[i] = fetcher.fetch(ctx, artist, &parts[i])
+		}(i, fetcher)
+	}
+	wg.Wait()
+
+	profile := &domain.ArtistProfile{
+		Artist:    artist,
+		Albums:    []domain.Album{},
+		Releases:  []domain.Release{},
+		Sources:   []string{},
+		FetchedAt: a.now(),
+	}
+	errors := []string{}
+	tracks, videos := [][]domain.Track{}, [][]domain.Track{}
+
+	// A failed lookup keeps the image the artist came with
+	if imageErr != nil {
+		errors = append(errors, fmt.Sprintf("images: %v", imageErr))
+	} else {
+		profile.Artist.ImageURL = imageURL
+	}
+
+	seenAlbums := make(map[string]int)
+	seenReleases := make(map[string]bool)
+
+	for i, fetcher := range a.fetchers {
+		if errs[i] != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", fetcher.name, errs[i]))
+			continue
+		}
+		part := parts[i]
+		if !slices.Contains(profile.Sources, fetcher.name) {
+			profile.Sources = append(profile.Sources, fetcher.name)
+		}
+
+		for _, album := range part.Albums {
+			key := normalizeTrackTitle(album.Title)
+			if j, ok := seenAlbums[key]; ok {
+				mergeAlbum(&profile.Albums[j], album)
+				continue
+			}
+			seenAlbums[key] = len(profile.Albums)
+			profile.Albums = append(profile.Albums, album)
+		}
+
+		// A record is released once per country and format; the first
+		// edition listed stands for the rest
+		for _, release := range part.Releases {
+			key := normalizeTrackTitle(release.Title)
+			if !seenReleases[key] {
+				seenReleases[key] = true
+				profile.Releases = append(profile.Releases, release)
+			}
+		}
+
+		tracks = append(tracks, part.Tracks)
+		videos = append(videos, part.Videos)
+	}
+
+	profile.Tracks = mergeTrackLists(tracks)
+	profile.Videos = mergeTrackLists(videos)
+
+	return profile, errors
+}
+
+func mergeAlbum(kept *domain.Album, other domain.Album) {
+	if kept.ReleaseDate == "" {
+		kept.ReleaseDate = other.ReleaseDate
+	}
+	if kept.TrackCount == 0 {
+		kept.TrackCount = other.TrackCount
+	}
+	if len(kept.Genres) == 0 {
+		kept.Genres = other.Genres
+	}
+	if kept.ImageURL == "" {
+		kept.ImageURL = other.ImageURL
+	}
+	if kept.Label == "" {
+		kept.Label = other.Label
+	}
+}
@@ -0,0 +1,68 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubAlbumSource []domain.Album
+
+func (s stubAlbumSource) ArtistAlbums(ctx context.Context, artist domain.Artist, limit int) ([]domain.Album, error) {
+	return s, nil
+}
+
+type stubReleaseSource struct {
+	releases []domain.Release
+	err      error
+}
+
+func (s stubReleaseSource) ArtistReleases(ctx context.Context, artist domain.Artist, limit int) ([]domain.Release, error) {
+	return s.releases, s.err
+}
+
+func TestProfileAggregator_BuildProfile(t *testing.T) {
+	aggregator := NewProfileAggregator(0)
+	aggregator.RegisterAlbumSource("deezer", stubAlbumSource{
+		{ID: "deezer_1", Title: "OK Computer", Label: "Parlophone", Source: "deezer"},
+		{ID: "deezer_2", Title: "Kid A", Source: "deezer"},
+	})
+	aggregator.RegisterAlbumSource("apple_music", stubAlbumSource{
+		{ID: "apple_1", Title: "OK Computer (Remastered)", TrackCount: 12, ImageURL: "https://img.example.com/okc.jpg", Source: "apple_music"},
+		{ID: "apple_2", Title: "In Rainbows", Source: "apple_music"},
+	})
+	aggregator.RegisterReleaseSource("musicbrainz", stubReleaseSource{releases: []domain.Release{
+		{ID: "mb-1", Title: "OK Computer", Country: "GB", Source: "musicbrainz"},
+		{ID: "mb-2", Title: "OK Computer", Country: "US", Source: "musicbrainz"},
+	}})
+	aggregator.RegisterTrackSource("soundcloud", &stubTrackSource{tracks: []domain.Track{{ID: "soundcloud_1", Title: "Daydreaming", Source: "soundcloud"}}})
+	aggregator.RegisterVideoSource("youtube", &stubTrackSource{tracks: []domain.Track{{ID: "youtube_a", Title: "Karma Police", Source: "youtube"}}})
+	aggregator.RegisterReleaseSource("discogs", stubReleaseSource{err: errors.New("upstream down")})
+
+	artist := domain.Artist{ID: "artist-1", Name: "Radiohead"}
+	profile, errs := aggregator.BuildProfile(context.Background(), artist)
+
+	if profile.Artist.ID != "artist-1" || profile.FetchedAt.IsZero() {
+		t.Errorf("expected the stored artist and a fetch time, got %+v", profile)
+	}
+	if len(profile.Albums) != 3 {
+		t.Fatalf("expected 3 albums after merging, got %+v", profile.Albums)
+	}
+	if okc := profile.Albums[0]; okc.ID != "deezer_1" || okc.TrackCount != 12 || okc.ImageURL == "" || okc.Label != "Parlophone" {
+		t.Errorf("expected the Apple Music copy to fill in the Deezer album, got %+v", okc)
+	}
+	if len(profile.Releases) != 1 || profile.Releases[0].Country != "GB" {
+		t.Errorf("expected one release per title, got %+v", profile.Releases)
+	}
+	if len(profile.Tracks) != 1 || len(profile.Videos) != 1 || profile.Videos[0].ID != "youtube_a" {
+		t.Errorf("expected tracks and videos kept apart, got %+v and %+v", profile.Tracks, profile.Videos)
+	}
+	if len(profile.Sources) != 5 {
+		t.Errorf("expected the 5 working sources, got %v", profile.Sources)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected the failed source to be reported, got %v", errs)
+	}
+}
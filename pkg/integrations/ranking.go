@@ -0,0 +1,279 @@
+package integrations
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// Ranker scores merged search results; higher scores are listed first.
+// Results keep the name of the source they came from so a ranker can trust
+// some sources more than others.
+type Ranker interface {
+	ScoreArtist(query string, source string, artist domain.Artist) ResultScore
+	ScoreEvent(query EventQuery, source string, event domain.Event, now time.Time) ResultScore
+}
+
+// EventQuery is what an event search asked for: an artist name, or a city
+// for location searches
+type EventQuery struct {
+	Artist string
+	City   string
+}
+
+// SourceWeighter is a Ranker whose source weights can be changed while
+// searches are running
+type SourceWeighter interface {
+	SourceWeight(source string) float64
+	SetSourceWeight(source string, weight float64)
+}
+
+// ResultScore is how a result was ranked. It is returned with aggregated
+// results so a surprising order can be debugged.
+type ResultScore struct {
+	Source     string  `json:"source"`
+	Score      float64 `json:"score"`
+	Trust      float64 `json:"trust"`
+	Similarity float64 `json:"similarity"`
+	Popularity float64 `json:"popularity,omitempty"`
+	Recency    float64 `json:"recency,omitempty"`
+}
+
+// PopularityScale is the range a source's popularity values actually span,
+// for sources whose scores don't use the whole of 0-100
+type PopularityScale struct {
+	Min int
+	Max int
+}
+
+// DefaultSourceWeights is how much each source's results are trusted.
+// Ticketing and catalogue APIs come first, scraped listings last.
+var DefaultSourceWeights = map[string]float64{
+	"spotify":          1.0,
+	"ticketmaster":     1.0,
+	"musicbrainz":      0.95,
+	"songkick":         0.95,
+	"bandsintown":      0.9,
+	"apple_music":      0.9,
+	"deezer":           0.85,
+	"lastfm":           0.85,
+	"eventbrite":       0.85,
+	"setlistfm":        0.8,
+	"soundcloud":       0.75,
+	"youtube":          0.75,
+	"resident_advisor": 0.7,
+	"bandcamp":         0.7,
+}
+
+// DefaultPopularityScales is empty: every client scores popularity on the
+// curves in pkg/scoring, which already span 0-100
+var DefaultPopularityScales = map[string]PopularityScale{}
+
+type WeightedRankerConfig struct {
+	// SourceWeights override DefaultSourceWeights. Sources in neither get 1.
+	SourceWeights map[string]float64
+	// PopularityScales override DefaultPopularityScales
+	PopularityScales map[string]PopularityScale
+	// How much name similarity counts against popularity for artists
+	// and against recency for events. Defaults to 0.7.
+	SimilarityWeight float64
+	// How quickly upcoming events stop getting a boost for being soon.
+	// Defaults to 30 days.
+	RecencyHalfLife time.Duration
+}
+
+// WeightedRanker is the default Ranker. A result's score is its source's
+// trust weight times a blend of how closely it matches the query and, for
+// artists, its popularity or, for events, how soon it is.
+type WeightedRanker struct {
+	weightsMu        sync.RWMutex
+	weights          map[string]float64
+	scales           map[string]PopularityScale
+	similarityWeight float64
+	halfLife         time.Duration
+}
+
+func NewWeightedRanker(config WeightedRankerConfig) *WeightedRanker {
+	if config.SimilarityWeight <= 0 || config.SimilarityWeight > 1 {
+		config.SimilarityWeight = 0.7
+	}
+	if config.RecencyHalfLife <= 0 {
+		config.RecencyHalfLife = 30 * 24 * time.Hour
+	}
+
+	weights := make(map[string]float64, len(DefaultSourceWeights)+len(config.SourceWeights))
+	for source, weight := range DefaultSourceWeights {
+		weights[source] = weight
+	}
+	for source, weight := range config.SourceWeights {
+		weights[source] = weight
+	}
+
+	scales := make(map[string]PopularityScale, len(DefaultPopularityScales)+len(config.PopularityScales))
+	for source, scale := range DefaultPopularityScales {
+		scales[source] = scale
+	}
+	for source, scale := range config.PopularityScales {
+		scales[source] = scale
+	}
+
+	return &WeightedRanker{
+		weights:          weights,
+		scales:           scales,
+		similarityWeight: config.SimilarityWeight,
+		halfLife:         config.RecencyHalfLife,
+	}
+}
+
+func (r *WeightedRanker) ScoreArtist(query string, source string, artist domain.Artist) ResultScore {
+	score := ResultScore{
+		Source:     source,
+		Trust:      r.trust(source),
+		Similarity: nameSimilarity(query, artist.Name),
+		Popularity: r.normalizePopularity(source, artist.Popularity),
+	}
+	score.Score = score.Trust * (r.similarityWeight*score.Similarity + (1-r.similarityWeight)*score.Popularity)
+	return score
+}
+
+// ScoreEvent matches artist searches on the billed artist, or on a support
+// act in the lineup, and location searches on the venue's city. Upcoming events get a recency score between
+// 0.5 and 1 depending on how soon they are; past events get none.
+func (r *WeightedRanker) ScoreEvent(query EventQuery, source string, event domain.Event, now time.Time) ResultScore {
+	score := ResultScore{
+		Source: source,
+		Trust:  r.trust(source),
+	}
+
+	switch {
+	case query.Artist != "":
+		score.Similarity = lineupSimilarity(query.Artist, event)
+	case query.City != "" && domain.SameCity(query.City, event.Venue.City):
+		// "Köln" for a "Cologne" search is as good as an exact match
+		score.Similarity = 1
+	case query.City != "":
+		score.Similarity = nameSimilarity(query.City, event.Venue.City)
+	}
+
+	if until := event.DateTime.Sub(now); until > 0 {
+		score.Recency = 0.5 + 0.5*math.Exp2(-float64(until)/float64(r.halfLife))
+	}
+
+	score.Score = score.Trust * (r.similarityWeight*score.Similarity + (1-r.similarityWeight)*score.Recency)
+	return score
+}
+
+// SourceWeight returns the trust weight results from source are scored with
+func (r *WeightedRanker) SourceWeight(source string) float64 {
+	return r.trust(source)
+}
+
+// SetSourceWeight changes a source's trust weight for every later search
+func (r *WeightedRanker) SetSourceWeight(source string, weight float64) {
+	r.weightsMu.Lock()
+	defer r.weightsMu.Unlock()
+	r.weights[source] = weight
+}
+
+func (r *WeightedRanker) trust(source string) float64 {
+	r.weightsMu.RLock()
+	defer r.weightsMu.RUnlock()
+
+	if weight, ok := r.weights[source]; ok {
+		return weight
+	}
+	return 1
+}
+
+// normalizePopularity maps a source's popularity onto 0-1 using the range
+// that source's values really span
+func (r *WeightedRanker) normalizePopularity(source string, popularity int) float64 {
+	scale, ok := r.scales[source]
+	if !ok || scale.Max <= scale.Min {
+		scale = PopularityScale{Min: 0, Max: 100}
+	}
+
+	normalized := float64(popularity-scale.Min) / float64(scale.Max-scale.Min)
+	return math.Max(0, math.Min(1, normalized))
+}
+
+// supportActDiscount scales a match on a support act, so that for the same
+// name a headline show ranks above a support slot
+const supportActDiscount = 0.9
+
+// lineupSimilarity is the best match among the event's artists
+func lineupSimilarity(query string, event domain.Event) float64 {
+	best := nameSimilarity(query, event.ArtistName)
+	for _, artist := range event.Lineup {
+		similarity := nameSimilarity(query, artist.Name)
+		if !artist.Headliner {
+			similarity *= supportActDiscount
+		}
+		best = max(best, similarity)
+	}
+	return best
+}
+
+// nameSimilarity scores how closely a result's name matches the query, from
+// 1 for the same name ignoring case and punctuation down to 0. Names that
+// start with or contain the query score higher than the edit distance alone
+// would give them, so "Muse" still finds "Muse (UK)".
+func nameSimilarity(query, name string) float64 {
+	q := []rune(normalizeRankingName(query))
+	n := []rune(normalizeRankingName(name))
+	if len(q) == 0 || len(n) == 0 {
+		return 0
+	}
+	if string(q) == string(n) {
+		return 1
+	}
+
+	longest := max(len(q), len(n))
+	similarity := 1 - float64(levenshtein(q, n))/float64(longest)
+
+	coverage := float64(len(q)) / float64(len(n))
+	switch {
+	case strings.HasPrefix(string(n), string(q)):
+		similarity = math.Max(similarity, 0.5+0.4*coverage)
+	case strings.Contains(string(n), string(q)):
+		similarity = math.Max(similarity, 0.4+0.4*coverage)
+	}
+
+	return similarity
+}
+
+func normalizeRankingName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,182 @@
+package integrations
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		query, name string
+		min, max    float64
+	}{
+		{"radiohead", "Radiohead", 1, 1},
+		{"AC/DC", "acdc", 1, 1},
+		{"muse", "Muse (UK)", 0.7, 0.95},
+		{"muse", "Amuse", 0.6, 0.85},
+		{"radiohed", "Radiohead", 0.85, 0.95},
+		{"radiohead", "Coldplay", 0, 0.3},
+		{"", "Radiohead", 0, 0},
+	}
+
+	for _, tt := range tests {
+		got := nameSimilarity(tt.query, tt.name)
+		if got < tt.min || got > tt.max {
+			t.Errorf("nameSimilarity(%q, %q) = %.2f, want between %.2f and %.2f", tt.query, tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestWeightedRanker_ScoreArtist(t *testing.T) {
+	ranker := NewWeightedRanker(WeightedRankerConfig{
+		SourceWeights: map[string]float64{"lastfm": 0.5},
+	})
+
+	t.Run("popularity is on one scale across sources", func(t *testing.T) {
+		spotify := ranker.ScoreArtist("x", "spotify", domain.Artist{Name: "Someone", Popularity: 60})
+		deezer := ranker.ScoreArtist("x", "deezer", domain.Artist{Name: "Someone", Popularity: 60})
+		if math.Abs(spotify.Popularity-0.6) > 1e-9 || spotify.Popularity != deezer.Popularity {
+			t.Errorf("expected 60 to be 0.6 from both, got %v and %v", spotify.Popularity, deezer.Popularity)
+		}
+	})
+
+	t.Run("configured scales stretch a source's range", func(t *testing.T) {
+		scaled := NewWeightedRanker(WeightedRankerConfig{
+			PopularityScales: map[string]PopularityScale{"deezer": {Min: 60, Max: 100}},
+		})
+		if deezer := scaled.ScoreArtist("x", "deezer", domain.Artist{Name: "Someone", Popularity: 60}); deezer.Popularity != 0 {
+			t.Errorf("expected the bottom of the range to be 0, got %v", deezer.Popularity)
+		}
+	})
+
+	t.Run("configured weights override the defaults", func(t *testing.T) {
+		score := ranker.ScoreArtist("Radiohead", "lastfm", domain.Artist{Name: "Radiohead", Popularity: 100})
+		if score.Trust != 0.5 || math.Abs(score.Score-0.5) > 1e-9 {
+			t.Errorf("expected half trust and a 0.5 score, got %+v", score)
+		}
+		if unknown := ranker.ScoreArtist("Radiohead", "newsource", domain.Artist{}); unknown.Trust != 1 {
+			t.Errorf("expected unknown sources to be fully trusted, got %v", unknown.Trust)
+		}
+	})
+
+	t.Run("an exact name beats a more popular near match", func(t *testing.T) {
+		exact := ranker.ScoreArtist("Muse", "spotify", domain.Artist{Name: "Muse", Popularity: 40})
+		popular := ranker.ScoreArtist("Muse", "spotify", domain.Artist{Name: "Museum of Love", Popularity: 100})
+		if exact.Score <= popular.Score {
+			t.Errorf("expected %+v to beat %+v", exact, popular)
+		}
+	})
+}
+
+func TestWeightedRanker_ScoreEvent(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	ranker := NewWeightedRanker(WeightedRankerConfig{})
+	event := func(city string, at time.Time) domain.Event {
+		return domain.Event{ArtistName: "Radiohead", DateTime: at, Venue: domain.Venue{City: city}}
+	}
+
+	soon := ranker.ScoreEvent(EventQuery{Artist: "Radiohead"}, "songkick", event("Berlin", now.Add(24*time.Hour)), now)
+	later := ranker.ScoreEvent(EventQuery{Artist: "Radiohead"}, "songkick", event("Berlin", now.AddDate(0, 6, 0)), now)
+	past := ranker.ScoreEvent(EventQuery{Artist: "Radiohead"}, "songkick", event("Berlin", now.Add(-24*time.Hour)), now)
+
+	if !(soon.Score > later.Score && later.Score > past.Score) {
+		t.Errorf("expected sooner events first, got %v, %v, %v", soon.Score, later.Score, past.Score)
+	}
+	if past.Recency != 0 || later.Recency < 0.5 {
+		t.Errorf("expected no boost for past events and at least 0.5 for upcoming ones, got %v and %v", past.Recency, later.Recency)
+	}
+
+	byCity := ranker.ScoreEvent(EventQuery{City: "berlin"}, "songkick", event("Berlin", now.Add(time.Hour)), now)
+	if byCity.Similarity != 1 {
+		t.Errorf("expected location searches to match on the city, got %+v", byCity)
+	}
+
+	supporting := event("Berlin", now.Add(24*time.Hour))
+	supporting.ArtistName = "Thom Yorke"
+	supporting.Lineup = []domain.EventArtist{
+		{Name: "Thom Yorke", Billing: 1, Headliner: true},
+		{Name: "Radiohead", Billing: 2},
+	}
+	support := ranker.ScoreEvent(EventQuery{Artist: "Radiohead"}, "songkick", supporting, now)
+	if support.Similarity <= 0.5 || support.Score >= soon.Score {
+		t.Errorf("expected a support slot to match below a headline show, got %+v vs %+v", support, soon)
+	}
+}
+
+func TestMegaAggregator_RanksArtists(t *testing.T) {
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{DeduplicationEnabled: true})
+	aggregator.RegisterMusicSource("spotify", &stubMusicSource{
+		name: "spotify",
+		artists: []domain.Artist{
+			{ID: "spotify_1", Name: "Museum of Love", Popularity: 100},
+			{ID: "spotify_2", Name: "Muse", Popularity: 85},
+		},
+	})
+	aggregator.RegisterMusicSource("soundcloud", &stubMusicSource{
+		name:    "soundcloud",
+		artists: []domain.Artist{{ID: "soundcloud_1", Name: "Muse", Popularity: 100}},
+	})
+
+	results, err := aggregator.SearchArtists(context.Background(), "muse", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(results.Artists) != 2 {
+		t.Fatalf("expected duplicates to be merged, got %+v", results.Artists)
+	}
+	if results.Artists[0].ID != "spotify_2" {
+		t.Errorf("expected the trusted exact match first, got %s", results.Artists[0].ID)
+	}
+
+	score, ok := results.Scores["spotify_2"]
+	if !ok || score.Source != "spotify" || score.Similarity != 1 {
+		t.Errorf("expected the score to be reported, got %+v", results.Scores)
+	}
+	if _, ok := results.Scores["soundcloud_1"]; ok {
+		t.Error("expected no score for the dropped duplicate")
+	}
+
+	// Spotify's 85 counts for more than SoundCloud's 100
+	muse := results.Artists[0]
+	if muse.SourcePopularity["spotify"] != 85 || muse.SourcePopularity["soundcloud"] != 100 || muse.Popularity != 90 {
+		t.Errorf("expected popularity blended across sources, got %d from %v", muse.Popularity, muse.SourcePopularity)
+	}
+	if other := results.Artists[1]; other.Popularity != 100 || len(other.SourcePopularity) != 1 {
+		t.Errorf("expected a single source's popularity kept, got %d from %v", other.Popularity, other.SourcePopularity)
+	}
+}
+
+func TestMegaAggregator_RanksEvents(t *testing.T) {
+	now := time.Now()
+	venue := domain.Venue{Name: "Berghain", City: "Berlin"}
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+	aggregator.RegisterEventSource("songkick", &stubEventSource{
+		name: "songkick",
+		events: []domain.Event{
+			{ID: "songkick_past", ArtistName: "Radiohead", DateTime: now.AddDate(0, -1, 0), Venue: venue},
+			{ID: "songkick_later", ArtistName: "Radiohead", DateTime: now.AddDate(0, 3, 0), Venue: venue},
+			{ID: "songkick_soon", ArtistName: "Radiohead", DateTime: now.AddDate(0, 0, 2), Venue: venue},
+		},
+	})
+
+	results, err := aggregator.SearchEvents(context.Background(), "Radiohead", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	want := []string{"songkick_soon", "songkick_later", "songkick_past"}
+	for i, id := range want {
+		if results.Events[i].ID != id {
+			t.Fatalf("expected %v, got %+v", want, results.Events)
+		}
+	}
+	if len(results.Scores) != 3 {
+		t.Errorf("expected a score per event, got %+v", results.Scores)
+	}
+}
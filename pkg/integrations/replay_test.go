@@ -0,0 +1,374 @@
+package integrations
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/cassette"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/integrations/sources/events"
+	"github.com/yair/where-its-at/pkg/integrations/sources/music"
+)
+
+// These tests run the real source clients against responses recorded in
+// testdata/cassettes, one cassette per test, so a provider changing its JSON
+// shows up as a mapping failure here rather than in production. To record
+// them again against the live APIs, run with WHEREITS_RECORD_CASSETTES=1 and
+// the API keys set in the same WHEREITS_* variables the config reads.
+
+// useCassette sends the requests of clients created afterwards through the
+// test's cassette
+func useCassette(t *testing.T) {
+	t.Helper()
+
+	mode := cassette.ModeReplay
+	if os.Getenv("WHEREITS_RECORD_CASSETTES") == "1" {
+		mode = cassette.ModeRecord
+	}
+
+	c, err := cassette.Load(filepath.Join("testdata", "cassettes", t.Name()+".json"), mode, nil)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %v", err)
+	}
+
+	httpclient.SetBaseTransport(c)
+	t.Cleanup(func() {
+		httpclient.SetBaseTransport(nil)
+		if err := c.Close(); err != nil {
+			t.Errorf("failed to save cassette: %v", err)
+		}
+	})
+}
+
+// recordingKey is the environment variable's value when recording. Replayed
+// requests are matched without their keys, so any value does.
+func recordingKey(name string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return "replay"
+}
+
+// eventSourceFuncs adapts clients that search events but aren't an
+// EventSource themselves
+type eventSourceFuncs struct {
+	name       string
+	byArtist   func(ctx context.Context, artistName string, limit int) ([]domain.Event, error)
+	byLocation func(ctx context.Context, city, country string, limit int) ([]domain.Event, error)
+}
+
+func (s eventSourceFuncs) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+	return s.byArtist(ctx, artistName, limit)
+}
+
+func (s eventSourceFuncs) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+	if s.byLocation == nil {
+		return []domain.Event{}, nil
+	}
+	return s.byLocation(ctx, city, country, limit)
+}
+
+func (s eventSourceFuncs) GetName() string {
+	return s.name
+}
+
+type musicSourceFunc struct {
+	name   string
+	search func(ctx context.Context, query string, limit int) ([]domain.Artist, error)
+}
+
+func (s musicSourceFunc) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
+	return s.search(ctx, query, limit)
+}
+
+func (s musicSourceFunc) GetName() string {
+	return s.name
+}
+
+// newReplayAggregator registers the event sources: Songkick as is, the
+// others through adapters
+func newReplayAggregator(t *testing.T) *MegaAggregator {
+	t.Helper()
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{MaxResultsPerSource: 10})
+
+	songkick, err := events.NewSongkickClient(events.SongkickConfig{APIKey: recordingKey("WHEREITS_SONGKICK_API_KEY")})
+	if err != nil {
+		t.Fatalf("failed to create songkick client: %v", err)
+	}
+	aggregator.RegisterEventSource("songkick", songkick)
+
+	ticketmaster, err := events.NewTicketmasterClient(events.TicketmasterConfig{APIKey: recordingKey("WHEREITS_TICKETMASTER_API_KEY")})
+	if err != nil {
+		t.Fatalf("failed to create ticketmaster client: %v", err)
+	}
+	aggregator.RegisterEventSource("ticketmaster", eventSourceFuncs{
+		name:       "ticketmaster",
+		byArtist:   ticketmaster.SearchEventsByKeyword,
+		byLocation: ticketmaster.SearchEventsByLocation,
+	})
+
+	eventbrite, err := events.NewEventbriteClient(events.EventbriteConfig{Token: recordingKey("WHEREITS_EVENTBRITE_TOKEN")})
+	if err != nil {
+		t.Fatalf("failed to create eventbrite client: %v", err)
+	}
+	aggregator.RegisterEventSource("eventbrite", eventSourceFuncs{
+		name:     "eventbrite",
+		byArtist: eventbrite.SearchEventsByQuery,
+		byLocation: func(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+			return eventbrite.SearchEventsByLocation(ctx, city+", "+country, limit)
+		},
+	})
+
+	bandsintown, err := NewBandsintownClient(BandsintownConfig{AppID: recordingKey("WHEREITS_BANDSINTOWN_APP_ID")})
+	if err != nil {
+		t.Fatalf("failed to create bandsintown client: %v", err)
+	}
+	aggregator.RegisterEventSource("bandsintown", eventSourceFuncs{
+		name: "bandsintown",
+		byArtist: func(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+			return bandsintown.SearchEvents(ctx, artistName, "")
+		},
+	})
+
+	return aggregator
+}
+
+func eventByID(t *testing.T, events []domain.Event, id string) domain.Event {
+	t.Helper()
+	for _, event := range events {
+		if event.ID == id {
+			return event
+		}
+	}
+	t.Fatalf("expected event %s in the results", id)
+	return domain.Event{}
+}
+
+func artistByID(t *testing.T, artists []domain.Artist, id string) domain.Artist {
+	t.Helper()
+	for _, artist := range artists {
+		if artist.ID == id {
+			return artist
+		}
+	}
+	t.Fatalf("expected artist %s in the results", id)
+	return domain.Artist{}
+}
+
+func assertSourceStats(t *testing.T, results *AggregatedResults, want map[string]int) {
+	t.Helper()
+	if len(results.Errors) > 0 {
+		t.Fatalf("expected no source errors, got %v", results.Errors)
+	}
+	for source, count := range want {
+		if results.SourceStats[source] != count {
+			t.Errorf("expected %d results from %s, got %d", count, source, results.SourceStats[source])
+		}
+	}
+}
+
+func TestReplay_SearchEvents(t *testing.T) {
+	useCassette(t)
+	aggregator := newReplayAggregator(t)
+
+	results, err := aggregator.SearchEvents(context.Background(), "Radiohead", 50)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	assertSourceStats(t, results, map[string]int{"songkick": 2, "ticketmaster": 2, "eventbrite": 1, "bandsintown": 1})
+
+	t.Run("songkick", func(t *testing.T) {
+		event := eventByID(t, results.Events, "songkick_40123456")
+		if !event.DateTime.Equal(time.Date(2026, 11, 20, 19, 0, 0, 0, time.UTC)) || event.DateConfidence != domain.DateExact {
+			t.Errorf("unexpected date %v (%s)", event.DateTime, event.DateConfidence)
+		}
+		if event.Venue.Name != "Columbiahalle" || event.Venue.City != "Berlin" || event.Venue.Country != "Germany" || event.Venue.Latitude != 52.4847 {
+			t.Errorf("unexpected venue %+v", event.Venue)
+		}
+		if len(event.Lineup) != 2 || event.Lineup[0].Name != "Radiohead" || !event.Lineup[0].Headliner || event.Lineup[1].Name != "Lanterns on the Lake" || event.Lineup[1].Headliner {
+			t.Errorf("unexpected lineup %+v", event.Lineup)
+		}
+		if event.ExternalIDs.SongkickID != "40123456" || event.Status != domain.EventScheduled {
+			t.Errorf("unexpected event %+v", event)
+		}
+
+		postponed := eventByID(t, results.Events, "songkick_40123999")
+		if postponed.Status != domain.EventPostponed || postponed.DateConfidence != domain.DateOnly {
+			t.Errorf("expected a postponed event with only a date, got %s (%s)", postponed.Status, postponed.DateConfidence)
+		}
+	})
+
+	t.Run("ticketmaster", func(t *testing.T) {
+		event := eventByID(t, results.Events, "ticketmaster_Z698xZ2qZa7bA")
+		if !event.DateTime.Equal(time.Date(2026, 12, 2, 19, 30, 0, 0, time.UTC)) || event.Timezone != "Europe/London" || event.DateConfidence != domain.DateExact {
+			t.Errorf("unexpected date %v %s (%s)", event.DateTime, event.Timezone, event.DateConfidence)
+		}
+		if event.Venue.Name != "The O2" || event.Venue.City != "London" || event.Venue.Country != "United Kingdom" || event.Venue.CountryCode != "GB" || event.Venue.Longitude != 0.003 {
+			t.Errorf("unexpected venue %+v", event.Venue)
+		}
+		if len(event.PriceRanges) != 1 || event.PriceRanges[0].Currency != "GBP" || event.PriceRanges[0].Min != 65 || event.PriceRanges[0].Max != 95 {
+			t.Errorf("unexpected price ranges %+v", event.PriceRanges)
+		}
+		if len(event.Lineup) != 2 || event.Lineup[0].Name != "Radiohead" || event.Lineup[1].Name != "Black Country, New Road" {
+			t.Errorf("unexpected lineup %+v", event.Lineup)
+		}
+
+		tba := eventByID(t, results.Events, "ticketmaster_Z698xZ2qZa7bB")
+		if !tba.DateTime.IsZero() || tba.DateConfidence != domain.DateUnknown || tba.Status != domain.EventRescheduled {
+			t.Errorf("expected a rescheduled event with its date to be announced, got %v %s (%s)", tba.DateTime, tba.Status, tba.DateConfidence)
+		}
+	})
+
+	t.Run("eventbrite", func(t *testing.T) {
+		event := eventByID(t, results.Events, "eventbrite_812345678901")
+		if event.ArtistName != "Radiohead Tribute Night" || event.Timezone != "Europe/Amsterdam" {
+			t.Errorf("unexpected event %+v", event)
+		}
+		if !event.DateTime.Equal(time.Date(2026, 11, 28, 19, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected date %v", event.DateTime)
+		}
+		// The venue comes from a second request
+		if event.Venue.Name != "Paradiso" || event.Venue.City != "Amsterdam" || event.Venue.Country != "Netherlands" || event.Venue.CountryCode != "NL" || event.Venue.Latitude != 52.3622 {
+			t.Errorf("unexpected venue %+v", event.Venue)
+		}
+	})
+
+	t.Run("bandsintown", func(t *testing.T) {
+		event := eventByID(t, results.Events, "bandsintown_1028374655")
+		if !event.DateTime.Equal(time.Date(2026, 11, 22, 19, 0, 0, 0, time.UTC)) {
+			t.Errorf("unexpected date %v", event.DateTime)
+		}
+		if event.Venue.Name != "Ziggo Dome" || event.Venue.Region != "North Holland" || event.Venue.Country != "Netherlands" || event.Venue.CountryCode != "NL" {
+			t.Errorf("unexpected venue %+v", event.Venue)
+		}
+		if event.TicketURL != "https://www.bandsintown.com/t/1028374655" || event.TicketStatus != "available" || event.OnSaleDate == nil {
+			t.Errorf("unexpected tickets %q %q %v", event.TicketURL, event.TicketStatus, event.OnSaleDate)
+		}
+	})
+}
+
+func TestReplay_SearchEventsByLocation(t *testing.T) {
+	useCassette(t)
+	aggregator := newReplayAggregator(t)
+
+	results, err := aggregator.SearchEventsByLocation(context.Background(), "London", "GB", 50)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	assertSourceStats(t, results, map[string]int{"songkick": 1, "ticketmaster": 1, "eventbrite": 1})
+
+	songkick := eventByID(t, results.Events, "songkick_40200001")
+	if songkick.ArtistName != "Fontaines D.C." || songkick.Venue.City != "London" || songkick.Venue.Country != "United Kingdom" || songkick.Venue.CountryCode != "GB" {
+		t.Errorf("expected the headliner and metro area to be mapped, got %+v", songkick)
+	}
+
+	ticketmaster := eventByID(t, results.Events, "ticketmaster_G5vYZ9XkLmN0a")
+	if ticketmaster.ArtistName != "Wet Leg" || ticketmaster.Venue.Name != "Eventim Apollo" || ticketmaster.Status != domain.EventCancelled {
+		t.Errorf("unexpected event %+v", ticketmaster)
+	}
+
+	eventbrite := eventByID(t, results.Events, "eventbrite_812345670002")
+	if eventbrite.Venue.Name != "The Windmill Brixton" || eventbrite.Venue.City != "London" {
+		t.Errorf("unexpected venue %+v", eventbrite.Venue)
+	}
+	// Without a UTC start, the local time is read in the event's timezone
+	if !eventbrite.DateTime.Equal(time.Date(2026, 11, 14, 19, 30, 0, 0, time.UTC)) || eventbrite.DateConfidence != domain.DateExact {
+		t.Errorf("unexpected date %v (%s)", eventbrite.DateTime, eventbrite.DateConfidence)
+	}
+}
+
+func TestReplay_SearchArtists(t *testing.T) {
+	useCassette(t)
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{MaxResultsPerSource: 5})
+
+	lastfm, err := music.NewLastFMClient(music.LastFMConfig{APIKey: recordingKey("WHEREITS_LASTFM_API_KEY")})
+	if err != nil {
+		t.Fatalf("failed to create last.fm client: %v", err)
+	}
+	aggregator.RegisterMusicSource("lastfm", lastfm)
+
+	deezer, err := music.NewDeezerClient(music.DeezerConfig{})
+	if err != nil {
+		t.Fatalf("failed to create deezer client: %v", err)
+	}
+	aggregator.RegisterMusicSource("deezer", musicSourceFunc{name: "deezer", search: deezer.SearchArtists})
+
+	musicBrainz, err := music.NewMusicBrainzClient(music.MusicBrainzConfig{UserAgent: "WhereItsAt/1.0 (https://github.com/yair/where-its-at)"})
+	if err != nil {
+		t.Fatalf("failed to create musicbrainz client: %v", err)
+	}
+	aggregator.RegisterMusicSource("musicbrainz", musicSourceFunc{name: "musicbrainz", search: musicBrainz.SearchArtists})
+
+	spotify, err := NewSpotifyClient(SpotifyConfig{
+		ClientID:     recordingKey("WHEREITS_SPOTIFY_CLIENT_ID"),
+		ClientSecret: recordingKey("WHEREITS_SPOTIFY_CLIENT_SECRET"),
+	})
+	if err != nil {
+		t.Fatalf("failed to create spotify client: %v", err)
+	}
+	aggregator.RegisterMusicSource("spotify", musicSourceFunc{name: "spotify", search: spotify.SearchArtists})
+
+	results, err := aggregator.SearchArtists(context.Background(), "Radiohead", 20)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	assertSourceStats(t, results, map[string]int{"lastfm": 2, "deezer": 1, "musicbrainz": 1, "spotify": 1})
+
+	lastfmArtist := artistByID(t, results.Artists, "lastfm_a74b1b7f-71a5-4011-9441-d0b5e4122711")
+	if lastfmArtist.Popularity != 100 || lastfmArtist.ImageURL != "https://lastfm.freetls.fastly.net/i/u/300x300/radiohead.png" {
+		t.Errorf("unexpected last.fm artist %+v", lastfmArtist)
+	}
+	// Artists without an MBID are keyed by name
+	artistByID(t, results.Artists, "lastfm_Radiohead%20Tribute")
+
+	deezerArtist := artistByID(t, results.Artists, "deezer_399")
+	if deezerArtist.Popularity != 93 || deezerArtist.ImageURL != "https://e-cdns-images.dzcdn.net/images/artist/radiohead/1000x1000.jpg" {
+		t.Errorf("unexpected deezer artist %+v", deezerArtist)
+	}
+
+	mbArtist := artistByID(t, results.Artists, "musicbrainz_a74b1b7f-71a5-4011-9441-d0b5e4122711")
+	if mbArtist.ExternalIDs.MusicBrainzID != "a74b1b7f-71a5-4011-9441-d0b5e4122711" || mbArtist.Popularity != 42 {
+		t.Errorf("unexpected musicbrainz artist %+v", mbArtist)
+	}
+	if len(mbArtist.Genres) != 2 || mbArtist.Genres[0] != "alternative rock" {
+		t.Errorf("expected the tags counted at least 3 times as genres, got %v", mbArtist.Genres)
+	}
+
+	spotifyArtist := artistByID(t, results.Artists, "spotify_4Z8W4fKeB5YxbusRsdQVPb")
+	if spotifyArtist.Popularity != 79 || len(spotifyArtist.Genres) != 3 || spotifyArtist.ImageURL != "https://i.scdn.co/image/radiohead-640" {
+		t.Errorf("unexpected spotify artist %+v", spotifyArtist)
+	}
+}
+
+func TestReplay_SearchHistory(t *testing.T) {
+	useCassette(t)
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+	setlistFM, err := events.NewSetlistFMClient(events.SetlistFMConfig{APIKey: recordingKey("WHEREITS_SETLISTFM_API_KEY")})
+	if err != nil {
+		t.Fatalf("failed to create setlist.fm client: %v", err)
+	}
+	aggregator.RegisterHistorySource("setlistfm", setlistFM)
+
+	results, err := aggregator.SearchHistory(context.Background(), domain.Artist{Name: "Radiohead"}, 1)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results.Errors) > 0 || len(results.Concerts) != 1 || !results.HasMore {
+		t.Fatalf("expected one concert with more pages, got %+v", results)
+	}
+
+	concert := results.Concerts[0]
+	if concert.Tour != "A Moon Shaped Pool" || concert.Venue.Name != "Madison Square Garden" || concert.Venue.City != "New York" {
+		t.Errorf("unexpected concert %+v", concert)
+	}
+	if len(concert.Songs) != 3 || concert.Songs[0].Name != "Daydreaming" || !concert.Songs[2].IsEncore || concert.Songs[1].CoverOf != "Carly Simon" {
+		t.Errorf("unexpected songs %+v", concert.Songs)
+	}
+}
@@ -0,0 +1,173 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// RelatedArtistSource lists the artists a music source relates to an
+// artist, most related first
+type RelatedArtistSource interface {
+	RelatedArtists(ctx context.Context, artist domain.Artist, limit int) ([]domain.Artist, error)
+}
+
+// relatedArtistsPerSource is how many related artists each source is asked for
+const relatedArtistsPerSource = 20
+
+// SimilarArtistFinder ranks artists like a seed artist by how strongly
+// music sources relate them to it and how many genres they share
+type SimilarArtistFinder struct {
+	names   []string
+	sources map[string]RelatedArtistSource
+	weights map[string]float64
+	timeout time.Duration
+}
+
+func NewSimilarArtistFinder(timeout time.Duration) *SimilarArtistFinder {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &SimilarArtistFinder{
+		sources: make(map[string]RelatedArtistSource),
+		weights: make(map[string]float64),
+		timeout: timeout,
+	}
+}
+
+// RegisterSource adds a source whose relations count for weight: a
+// source's top related artist scores weight, its last half of that
+func (f *SimilarArtistFinder) RegisterSource(name string, source RelatedArtistSource, weight float64) {
+	if _, ok := f.sources[name]; !ok {
+		f.names = append(f.names, name)
+	}
+	f.sources[name] = source
+	f.weights[name] = weight
+}
+
+// FindSimilar asks every source at once and returns up to limit artists
+// like seed, best first. An artist's score is the sum of what each source
+// relating it gives, plus its genre overlap with the seed. Failing sources
+// are reported in the returned errors.
+func (f *SimilarArtistFinder) FindSimilar(ctx context.Context, seed domain.Artist, limit int) ([]domain.SimilarArtist, []string) {
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+
+	related := make([][]domain.Artist, len(f.names))
+	errs := make([]error, len(f.names))
+
+	var wg sync.WaitGroup
+	for i, name := range f.names {
+		wg.Add(1)
+		go func(i int, source RelatedArtistSource) {
+			defer wg.Done()
+			related[i], errs[i] = source.RelatedArtists(ctx, seed, relatedArtistsPerSource)
+		}(i, f.sources[name])
+	}
+	wg.Wait()
+
+	errors := []string{}
+	similar := []domain.SimilarArtist{}
+	seen := make(map[string]int)
+	seedKey := normalizeRankingName(seed.Name)
+
+	for i, name := range f.names {
+		if errs[i] != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", name, errs[i]))
+			continue
+		}
+		for rank, artist := range related[i] {
+			key := normalizeRankingName(artist.Name)
+			if key == "" || key == seedKey {
+				continue
+			}
+			score := f.weights[name] * (1 - 0.5*float64(rank)/float64(len(related[i])))
+
+			j, ok := seen[key]
+			if !ok {
+				seen[key] = len(similar)
+				similar = append(similar, domain.SimilarArtist{Artist: artist, Score: score, Sources: []string{name}})
+				continue
+			}
+			mergeArtist(&similar[j].Artist, artist)
+			similar[j].Score += score
+			similar[j].Sources = append(similar[j].Sources, name)
+		}
+	}
+
+	for i := range similar {
+		similar[i].GenreOverlap = GenreOverlap(seed.Genres, similar[i].Artist.Genres)
+		similar[i].Score += similar[i].GenreOverlap
+	}
+	sort.SliceStable(similar, func(i, j int) bool {
+		return similar[i].Score > similar[j].Score
+	})
+	if limit > 0 && len(similar) > limit {
+		similar = similar[:limit]
+	}
+
+	return similar, errors
+}
+
+// mergeArtist fills what the kept copy of an artist lacks from another
+// source's, pooling their genres
+func mergeArtist(kept *domain.Artist, other domain.Artist) {
+	if kept.ExternalIDs.SpotifyID == "" {
+		kept.ExternalIDs.SpotifyID = other.ExternalIDs.SpotifyID
+	}
+	if kept.ExternalIDs.MusicBrainzID == "" {
+		kept.ExternalIDs.MusicBrainzID = other.ExternalIDs.MusicBrainzID
+	}
+	if kept.ImageURL == "" {
+		kept.ImageURL = other.ImageURL
+	}
+	kept.Popularity = max(kept.Popularity, other.Popularity)
+	for _, genre := range other.Genres {
+		if !containsGenre(kept.Genres, genre) {
+			kept.Genres = append(kept.Genres, genre)
+		}
+	}
+}
+
+// GenreOverlap is the Jaccard index of two genre lists: the genres they
+// share over all the genres either has, compared by their canonical names so
+// "dnb" matches "drum and bass". It is 0 when either list is empty.
+func GenreOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(a))
+	for _, genre := range a {
+		set[domain.NormalizeGenre(genre)] = true
+	}
+	union := len(set)
+	shared := 0
+	counted := make(map[string]bool, len(b))
+	for _, genre := range b {
+		genre = domain.NormalizeGenre(genre)
+		if counted[genre] {
+			continue
+		}
+		counted[genre] = true
+		if set[genre] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	return float64(shared) / float64(union)
+}
+
+func containsGenre(genres []string, genre string) bool {
+	for _, g := range genres {
+		if domain.NormalizeGenre(g) == domain.NormalizeGenre(genre) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubRelatedSource struct {
+	related []domain.Artist
+	err     error
+}
+
+func (s *stubRelatedSource) RelatedArtists(ctx context.Context, artist domain.Artist, limit int) ([]domain.Artist, error) {
+	return s.related, s.err
+}
+
+func TestSimilarArtistFinder_FindSimilar(t *testing.T) {
+	finder := NewSimilarArtistFinder(time.Second)
+	finder.RegisterSource("spotify", &stubRelatedSource{related: []domain.Artist{
+		{Name: "Thom Yorke", Genres: []string{"art rock", "electronica"}, ExternalIDs: domain.ExternalIDs{SpotifyID: "ty"}},
+		{Name: "Portishead", Genres: []string{"trip hop"}},
+		{Name: "Radiohead"},
+	}}, 1)
+	finder.RegisterSource("musicbrainz", &stubRelatedSource{related: []domain.Artist{
+		{Name: "The Smile", ExternalIDs: domain.ExternalIDs{MusicBrainzID: "smile"}},
+		{Name: "thom yorke", ExternalIDs: domain.ExternalIDs{MusicBrainzID: "ty-mbid"}},
+	}}, 0.8)
+	finder.RegisterSource("lastfm", &stubRelatedSource{err: errors.New("boom")}, 1)
+
+	seed := domain.Artist{Name: "Radiohead", Genres: []string{"Art Rock", "alternative rock"}}
+	similar, errs := finder.FindSimilar(context.Background(), seed, 10)
+
+	if len(errs) != 1 || errs[0] != "lastfm: boom" {
+		t.Errorf("expected the failing source reported, got %v", errs)
+	}
+	if len(similar) != 3 {
+		t.Fatalf("expected 3 artists without the seed, got %+v", similar)
+	}
+
+	thom := similar[0]
+	if thom.Artist.Name != "Thom Yorke" || len(thom.Sources) != 2 || thom.Artist.ExternalIDs.MusicBrainzID != "ty-mbid" {
+		t.Errorf("expected Thom Yorke first, merged across sources, got %+v", thom)
+	}
+	// One of three genres shared; top of Spotify and second of two on MusicBrainz
+	if want := 1.0/3 + 1 + 0.8*0.75; math.Abs(thom.Score-want) > 1e-9 || math.Abs(thom.GenreOverlap-1.0/3) > 1e-9 {
+		t.Errorf("expected score %.3f, got %.3f (overlap %.3f)", want, thom.Score, thom.GenreOverlap)
+	}
+	if similar[1].Artist.Name != "Portishead" || similar[2].Artist.Name != "The Smile" {
+		t.Errorf("unexpected order %s, %s", similar[1].Artist.Name, similar[2].Artist.Name)
+	}
+
+	if limited, _ := finder.FindSimilar(context.Background(), seed, 1); len(limited) != 1 {
+		t.Errorf("expected the limit applied, got %d", len(limited))
+	}
+}
+
+func TestGenreOverlap(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		want float64
+	}{
+		{[]string{"rock", "pop"}, []string{"Rock", "pop"}, 1},
+		{[]string{"rock", "pop"}, []string{"rock", "jazz"}, 1.0 / 3},
+		{[]string{"rock"}, []string{"jazz"}, 0},
+		{nil, []string{"jazz"}, 0},
+		{[]string{"rock"}, []string{"rock", "rock"}, 1},
+		{[]string{"dnb", "Hip-Hop"}, []string{"Drum & Bass", "rap"}, 1},
+	}
+	for _, tt := range tests {
+		if got := GenreOverlap(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("GenreOverlap(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
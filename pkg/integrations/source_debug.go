@@ -0,0 +1,87 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+)
+
+// SourceDebugResult is what one source sent upstream for a query next to
+// what it was converted to, for tracking down mapping bugs
+type SourceDebugResult struct {
+	Source    string                        `json:"source"`
+	Artist    string                        `json:"artist"`
+	Responses []httpclient.CapturedResponse `json:"responses"`
+	Artists   []domain.Artist               `json:"artists,omitempty"`
+	Events    []domain.Event                `json:"events,omitempty"`
+	Concerts  []domain.PastConcert          `json:"concerts,omitempty"`
+	// Errors are the searches that failed; their responses are still listed
+	Errors   []string      `json:"errors,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// DebugSource runs the searches the named source takes part in for one
+// artist and captures the raw responses along the way. The cache, circuit
+// breaker and runtime settings are bypassed, so a disabled or tripped source
+// can be inspected too.
+func (m *MegaAggregator) DebugSource(ctx context.Context, name, artistName string, limit int) (*SourceDebugResult, error) {
+	artistName = strings.TrimSpace(artistName)
+	if artistName == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+	if !m.HasSource(name) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSourceNotFound, name)
+	}
+	if limit <= 0 {
+		limit = m.config.MaxResultsPerSource
+	}
+
+	ctx, capture := httpclient.WithCapture(ctx)
+	start := time.Now()
+	result := &SourceDebugResult{Source: name, Artist: artistName}
+	failed := func(search string, err error) {
+		result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", search, err))
+	}
+
+	// A source can be registered under more than one kind, like the fixtures
+	sources := m.sources()
+	if src, ok := sources.music[name]; ok {
+		artists, err := src.SearchArtists(ctx, artistName, limit)
+		if err != nil {
+			failed("search_artists", err)
+		}
+		result.Artists = artists
+	}
+	if src, ok := sources.events[name]; ok {
+		events, err := src.SearchEventsByArtist(ctx, artistName, limit)
+		if err != nil {
+			failed("search_events", err)
+		}
+		result.Events = events
+	}
+	if src, ok := sources.history[name]; ok {
+		page, err := src.ArtistHistory(ctx, domain.Artist{Name: artistName}, 1)
+		if err != nil {
+			failed("artist_history", err)
+		} else {
+			result.Concerts = page.Concerts
+		}
+	}
+	if scraper, ok := m.scraperRegistry.GetScraper(name); ok {
+		scraped, err := scraper.ScrapeEvents(ctx, artistName, limit)
+		if err != nil {
+			failed("scrape_events", err)
+		}
+		for _, se := range scraped {
+			result.Events = append(result.Events, se.ToEvent())
+		}
+	}
+
+	result.Responses = capture.Responses()
+	result.Duration = time.Since(start)
+	return result, nil
+}
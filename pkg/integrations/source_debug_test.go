@@ -0,0 +1,67 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestMegaAggregator_DebugSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/artists/Broken/events" {
+			w.Write([]byte(`{"error":"unexpected shape"}`))
+			return
+		}
+		w.Write([]byte(`[{"id":"1","datetime":"2026-11-22T20:00:00+01:00","venue":{"name":"Ziggo Dome","city":"Amsterdam","country":"Netherlands"}}]`))
+	}))
+	defer server.Close()
+
+	bandsintown, _ := NewBandsintownClient(BandsintownConfig{AppID: "secret-app"})
+	bandsintown.baseURL = server.URL
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{})
+	aggregator.RegisterEventSource("bandsintown", eventSourceFuncs{
+		name: "bandsintown",
+		byArtist: func(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+			return bandsintown.SearchEvents(ctx, artistName, "")
+		},
+	})
+	// Disabled sources can still be inspected
+	aggregator.ApplySourceSetting(domain.SourceSetting{Source: "bandsintown", Enabled: false})
+
+	result, err := aggregator.DebugSource(context.Background(), "bandsintown", "Radiohead", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result.Responses) != 1 || len(result.Events) != 1 || len(result.Errors) != 0 {
+		t.Fatalf("expected one response and one event, got %+v", result)
+	}
+	if strings.Contains(result.Responses[0].URL, "secret-app") || !strings.Contains(string(result.Responses[0].Body), "Ziggo Dome") {
+		t.Errorf("expected the raw body with the app ID redacted, got %+v", result.Responses[0])
+	}
+	if result.Events[0].Venue.Name != "Ziggo Dome" {
+		t.Errorf("expected the converted event, got %+v", result.Events[0])
+	}
+
+	// A payload that can't be converted is reported next to the raw response
+	result, err = aggregator.DebugSource(context.Background(), "bandsintown", "Broken", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(result.Errors) != 1 || len(result.Responses) != 1 || !strings.Contains(string(result.Responses[0].Body), "unexpected shape") {
+		t.Errorf("expected the failure and its response, got %+v", result)
+	}
+
+	if _, err := aggregator.DebugSource(context.Background(), "nope", "Radiohead", 10); !errors.Is(err, domain.ErrSourceNotFound) {
+		t.Errorf("expected ErrSourceNotFound, got %v", err)
+	}
+	if _, err := aggregator.DebugSource(context.Background(), "bandsintown", " ", 10); !errors.Is(err, domain.ErrInvalidRequest) {
+		t.Errorf("expected ErrInvalidRequest, got %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package integrations
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/yair/where-its-at/pkg/integrations/sources/music"
+)
+
+// SourceFilter scopes one search to some of the registered sources, e.g. to
+// skip slow scrapers for autocomplete. Only, when set, lists the sources to
+// ask; Exclude lists sources to skip. Names no source has are ignored.
+type SourceFilter struct {
+	Only    []string
+	Exclude []string
+
+	// storefront is the Apple Music catalog the search asked for, "" for
+	// the configured one
+	storefront string
+}
+
+type sourceFilterKey struct{}
+
+// WithSourceFilter returns a context whose searches only ask the sources the
+// filter allows
+func WithSourceFilter(ctx context.Context, filter SourceFilter) context.Context {
+	return context.WithValue(ctx, sourceFilterKey{}, filter)
+}
+
+// SourceFilterFrom returns the filter set on ctx, or an empty one
+func SourceFilterFrom(ctx context.Context) SourceFilter {
+	filter, _ := ctx.Value(sourceFilterKey{}).(SourceFilter)
+	filter.storefront = music.StorefrontFrom(ctx)
+	return filter
+}
+
+// IsZero reports whether the filter allows every source
+func (f SourceFilter) IsZero() bool {
+	return len(f.Only) == 0 && len(f.Exclude) == 0
+}
+
+func (f SourceFilter) Allows(name string) bool {
+	if len(f.Only) > 0 && !slices.Contains(f.Only, name) {
+		return false
+	}
+	return !slices.Contains(f.Exclude, name)
+}
+
+// cacheKey tells cached results of differently scoped searches apart, and
+// of searches of another Apple Music storefront. It is empty for unscoped
+// searches of the configured storefront so they keep sharing their entries.
+func (f SourceFilter) cacheKey() string {
+	key := ""
+	if !f.IsZero() {
+		only := slices.Sorted(slices.Values(f.Only))
+		exclude := slices.Sorted(slices.Values(f.Exclude))
+		key = "|only=" + strings.Join(only, ",") + "|exclude=" + strings.Join(exclude, ",")
+	}
+	if f.storefront != "" && f.Allows("apple_music") {
+		key += "|storefront=" + f.storefront
+	}
+	return key
+}
+
+// shouldSearch reports whether a search under filter asks the named source
+func (m *MegaAggregator) shouldSearch(filter SourceFilter, name string) bool {
+	return m.sourceEnabled(name) && filter.Allows(name)
+}
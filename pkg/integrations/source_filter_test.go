@@ -0,0 +1,93 @@
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/sources/music"
+)
+
+func TestSourceFilter_Allows(t *testing.T) {
+	tests := []struct {
+		filter SourceFilter
+		source string
+		want   bool
+	}{
+		{SourceFilter{}, "songkick", true},
+		{SourceFilter{Only: []string{"spotify", "bandsintown"}}, "bandsintown", true},
+		{SourceFilter{Only: []string{"spotify", "bandsintown"}}, "songkick", false},
+		{SourceFilter{Exclude: []string{"resident_advisor"}}, "resident_advisor", false},
+		{SourceFilter{Exclude: []string{"resident_advisor"}}, "songkick", true},
+		{SourceFilter{Only: []string{"songkick"}, Exclude: []string{"songkick"}}, "songkick", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.Allows(tt.source); got != tt.want {
+			t.Errorf("%+v.Allows(%q) = %v, want %v", tt.filter, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestMegaAggregator_SourceFilter(t *testing.T) {
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true})
+	aggregator.RegisterMusicSource("spotify", &stubMusicSource{name: "spotify", artists: []domain.Artist{{ID: "spotify_1", Name: "Radiohead"}}})
+	aggregator.RegisterMusicSource("lastfm", &stubMusicSource{name: "lastfm", artists: []domain.Artist{{ID: "lastfm_1", Name: "Radiohead Tribute"}}})
+
+	search := func(filter SourceFilter) *AggregatedResults {
+		results, err := aggregator.SearchArtists(WithSourceFilter(context.Background(), filter), "Radiohead", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return results
+	}
+
+	if results := search(SourceFilter{}); len(results.Artists) != 2 {
+		t.Fatalf("expected both sources unscoped, got %+v", results.Artists)
+	}
+
+	results := search(SourceFilter{Only: []string{"spotify"}})
+	if len(results.Artists) != 1 || results.Artists[0].ID != "spotify_1" {
+		t.Errorf("expected only spotify despite the cached unscoped search, got %+v", results.Artists)
+	}
+	if _, searched := results.SourceStats["lastfm"]; searched {
+		t.Errorf("expected lastfm not to be asked, got %v", results.SourceStats)
+	}
+
+	if results := search(SourceFilter{Exclude: []string{"spotify"}}); len(results.Artists) != 1 || results.Artists[0].ID != "lastfm_1" {
+		t.Errorf("expected spotify to be excluded, got %+v", results.Artists)
+	}
+}
+
+func TestMegaAggregator_StorefrontCache(t *testing.T) {
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true})
+	appleMusic := &stubMusicSource{name: "apple_music", artists: []domain.Artist{{ID: "apple_1", Name: "Radiohead"}}}
+	spotify := &stubMusicSource{name: "spotify", artists: []domain.Artist{{ID: "spotify_1", Name: "Radiohead"}}}
+	aggregator.RegisterMusicSource("apple_music", appleMusic)
+	aggregator.RegisterMusicSource("spotify", spotify)
+
+	search := func(ctx context.Context) {
+		if _, err := aggregator.SearchArtists(ctx, "Radiohead", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	ctx := context.Background()
+	search(ctx)
+	search(ctx)
+	search(music.WithStorefront(ctx, "de"))
+	search(music.WithStorefront(ctx, "Germany"))
+	search(music.WithStorefront(ctx, "fr"))
+	if appleMusic.calls != 3 {
+		t.Errorf("expected one search per storefront, got %d", appleMusic.calls)
+	}
+
+	// Without Apple Music the storefront changes nothing
+	spotifyOnly := WithSourceFilter(ctx, SourceFilter{Only: []string{"spotify"}})
+	spotify.calls = 0
+	search(music.WithStorefront(spotifyOnly, "de"))
+	search(music.WithStorefront(spotifyOnly, "fr"))
+	if spotify.calls != 1 {
+		t.Errorf("expected the storefronts to share a search without Apple Music, got %d", spotify.calls)
+	}
+}
@@ -0,0 +1,152 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// HasSource reports whether name is a registered music, event or history
+// source, or a registered scraper
+func (m *MegaAggregator) HasSource(name string) bool {
+	sources := m.sources()
+	if _, ok := sources.music[name]; ok {
+		return true
+	}
+	if _, ok := sources.events[name]; ok {
+		return true
+	}
+	if _, ok := sources.history[name]; ok {
+		return true
+	}
+	_, ok := m.scraperRegistry.GetScraper(name)
+	return ok
+}
+
+// SourceLimits bound the calls searches make to one source. Zero values
+// keep the aggregator's RequestTimeout and MaxResultsPerSource, and leave
+// the source's concurrency limited only by MaxConcurrentRequests.
+type SourceLimits struct {
+	Timeout       time.Duration
+	MaxResults    int
+	MaxConcurrent int
+}
+
+// ApplySourceSetting turns a source on or off and changes its ranking weight
+// and limits without a restart. Disabled sources are skipped by every search
+// until enabled again. Limits the setting leaves at zero go back to the
+// configured ones. Cached results are dropped since they may include, or be
+// ranked by, the old setting.
+func (m *MegaAggregator) ApplySourceSetting(setting domain.SourceSetting) error {
+	if !m.HasSource(setting.Source) {
+		return fmt.Errorf("%w: %s", domain.ErrSourceNotFound, setting.Source)
+	}
+
+	if setting.Weight != nil {
+		weighter, ok := m.config.Ranker.(SourceWeighter)
+		if !ok {
+			return fmt.Errorf("ranker does not support source weights")
+		}
+		weighter.SetSourceWeight(setting.Source, *setting.Weight)
+	}
+
+	m.disabledMu.Lock()
+	m.disabled[setting.Source] = !setting.Enabled
+	m.disabledMu.Unlock()
+
+	limits := m.config.SourceLimits[setting.Source]
+	if setting.TimeoutMS > 0 {
+		limits.Timeout = time.Duration(setting.TimeoutMS) * time.Millisecond
+	}
+	if setting.MaxResults > 0 {
+		limits.MaxResults = setting.MaxResults
+	}
+	if setting.MaxConcurrent > 0 {
+		limits.MaxConcurrent = setting.MaxConcurrent
+	}
+	m.setSourceLimits(setting.Source, limits)
+
+	if m.cache != nil {
+		m.cache.Clear()
+	}
+	return nil
+}
+
+func (m *MegaAggregator) sourceEnabled(name string) bool {
+	m.disabledMu.RLock()
+	defer m.disabledMu.RUnlock()
+	return !m.disabled[name]
+}
+
+func (m *MegaAggregator) setSourceLimits(name string, limits SourceLimits) {
+	m.limitsMu.Lock()
+	defer m.limitsMu.Unlock()
+	if m.limits[name].MaxConcurrent != limits.MaxConcurrent {
+		// Calls under way release the slots they took from the old channel
+		delete(m.limitSlots, name)
+	}
+	m.limits[name] = limits
+}
+
+// sourceLimits returns the limits a source's calls run under, with the
+// defaults filled in, and the slots its concurrent calls take, nil when
+// only MaxConcurrentRequests limits them
+func (m *MegaAggregator) sourceLimits(name string) (SourceLimits, chan struct{}) {
+	m.limitsMu.Lock()
+	defer m.limitsMu.Unlock()
+
+	limits := m.limits[name]
+	if limits.Timeout <= 0 {
+		limits.Timeout = m.config.RequestTimeout
+	}
+	if limits.MaxResults <= 0 {
+		limits.MaxResults = m.config.MaxResultsPerSource
+	}
+	if limits.MaxConcurrent <= 0 {
+		return limits, nil
+	}
+
+	slots, ok := m.limitSlots[name]
+	if !ok {
+		slots = make(chan struct{}, limits.MaxConcurrent)
+		m.limitSlots[name] = slots
+	}
+	return limits, slots
+}
+
+// limitSource bounds one call to a source by its limits. It waits for a
+// slot when the source's concurrency is limited, and returns a context
+// under the source's timeout, how many results to ask for, and a func
+// releasing both. The wait counts against the timeout.
+func (m *MegaAggregator) limitSource(ctx context.Context, name string) (context.Context, int, func()) {
+	limits, slots := m.sourceLimits(name)
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	if slots == nil {
+		return ctx, limits.MaxResults, cancel
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return ctx, limits.MaxResults, func() {
+			<-slots
+			cancel()
+		}
+	case <-ctx.Done():
+		// The call fails straight away on the expired context
+		return ctx, limits.MaxResults, cancel
+	}
+}
+
+// searchTimeout bounds a whole search: the longest any source call may take
+func (m *MegaAggregator) searchTimeout() time.Duration {
+	m.limitsMu.Lock()
+	defer m.limitsMu.Unlock()
+
+	timeout := m.config.RequestTimeout
+	for _, limits := range m.limits {
+		timeout = max(timeout, limits.Timeout)
+	}
+	return timeout
+}
@@ -0,0 +1,155 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestMegaAggregator_ApplySourceSetting(t *testing.T) {
+	future := time.Now().Add(24 * time.Hour)
+	songkick := &stubEventSource{name: "songkick", events: []domain.Event{
+		{ID: "songkick_1", ArtistName: "Radiohead", DateTime: future, Venue: domain.Venue{Name: "O2"}},
+	}}
+	bandsintown := &stubEventSource{name: "bandsintown", events: []domain.Event{
+		{ID: "bandsintown_1", ArtistName: "Radiohead", DateTime: future, Venue: domain.Venue{Name: "Roundhouse"}},
+	}}
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{CacheEnabled: true})
+	aggregator.RegisterEventSource("songkick", songkick)
+	aggregator.RegisterEventSource("bandsintown", bandsintown)
+
+	search := func() *AggregatedResults {
+		results, err := aggregator.SearchEvents(context.Background(), "Radiohead", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		return results
+	}
+
+	if results := search(); len(results.Events) != 2 {
+		t.Fatalf("expected both sources, got %+v", results.Events)
+	}
+
+	if err := aggregator.ApplySourceSetting(domain.SourceSetting{Source: "songkick", Enabled: false}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	results := search()
+	if len(results.Events) != 1 || results.Events[0].ID != "bandsintown_1" {
+		t.Errorf("expected the disabled source to be skipped despite the cache, got %+v", results.Events)
+	}
+	if _, searched := results.SourceStats["songkick"]; searched || len(results.Errors) != 0 {
+		t.Errorf("expected a disabled source to be left out quietly, got %v %v", results.SourceStats, results.Errors)
+	}
+	if info := aggregator.GetSourceStats()["songkick"]; info.Enabled || info.Status != "disabled" {
+		t.Errorf("expected songkick to be reported disabled, got %+v", info)
+	}
+
+	weight := 0.1
+	if err := aggregator.ApplySourceSetting(domain.SourceSetting{Source: "songkick", Enabled: true, Weight: &weight}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	results = search()
+	if len(results.Events) != 2 || results.Events[1].ID != "songkick_1" {
+		t.Errorf("expected the down-weighted source to rank last, got %+v", results.Events)
+	}
+	if info := aggregator.GetSourceStats()["songkick"]; !info.Enabled || info.Weight != 0.1 {
+		t.Errorf("expected songkick enabled with weight 0.1, got %+v", info)
+	}
+
+	if err := aggregator.ApplySourceSetting(domain.SourceSetting{Source: "unknown"}); !errors.Is(err, domain.ErrSourceNotFound) {
+		t.Errorf("expected ErrSourceNotFound, got %v", err)
+	}
+}
+
+// limitedEventSource records the limits it's called with and how many of
+// its calls overlap
+type limitedEventSource struct {
+	stubEventSource
+	delay      time.Duration
+	limit      atomic.Int64
+	running    atomic.Int64
+	maxRunning atomic.Int64
+}
+
+func (s *limitedEventSource) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+	s.limit.Store(int64(limit))
+	running := s.running.Add(1)
+	defer s.running.Add(-1)
+	for {
+		peak := s.maxRunning.Load()
+		if running <= peak || s.maxRunning.CompareAndSwap(peak, running) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(s.delay):
+		return s.events, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestMegaAggregator_SourceLimits(t *testing.T) {
+	slow := &limitedEventSource{stubEventSource: stubEventSource{name: "scraper"}, delay: 5 * time.Second}
+	fast := &limitedEventSource{stubEventSource: stubEventSource{name: "deezer"}, delay: 20 * time.Millisecond}
+
+	aggregator := NewMegaAggregator(MegaAggregatorConfig{
+		RequestTimeout:      time.Second,
+		MaxResultsPerSource: 20,
+		SourceLimits: map[string]SourceLimits{
+			"scraper": {Timeout: 50 * time.Millisecond},
+			"deezer":  {MaxResults: 5},
+		},
+	})
+	aggregator.RegisterEventSource("scraper", slow)
+	aggregator.RegisterEventSource("deezer", fast)
+
+	start := time.Now()
+	results, err := aggregator.SearchEvents(context.Background(), "Bicep", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the slow source to be cut off at its own timeout, took %v", elapsed)
+	}
+	if len(results.Errors) != 1 || slow.limit.Load() != 20 || fast.limit.Load() != 5 {
+		t.Errorf("expected the scraper to time out and deezer to be asked for 5, got %v, %d, %d",
+			results.Errors, slow.limit.Load(), fast.limit.Load())
+	}
+
+	// Operators can tighten a source at runtime
+	if err := aggregator.ApplySourceSetting(domain.SourceSetting{Source: "deezer", Enabled: true, MaxConcurrent: 1, MaxResults: 3}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			aggregator.SearchEvents(context.Background(), "Bicep", 10)
+		}()
+	}
+	wg.Wait()
+	if fast.maxRunning.Load() != 1 || fast.limit.Load() != 3 {
+		t.Errorf("expected one deezer call at a time asking for 3, got %d at once asking for %d", fast.maxRunning.Load(), fast.limit.Load())
+	}
+	info := aggregator.GetSourceStats()["deezer"]
+	if info.TimeoutMS != 1000 || info.MaxResults != 3 || info.MaxConcurrent != 1 {
+		t.Errorf("expected the limits in the source stats, got %+v", info)
+	}
+
+	// Zero goes back to the configured limits
+	if err := aggregator.ApplySourceSetting(domain.SourceSetting{Source: "deezer", Enabled: true}); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if info := aggregator.GetSourceStats()["deezer"]; info.MaxResults != 5 || info.MaxConcurrent != 0 {
+		t.Errorf("expected the configured limits back, got %+v", info)
+	}
+}
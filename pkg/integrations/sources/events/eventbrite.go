@@ -6,35 +6,66 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/integrations/oauth"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+)
+
+const (
+	// eventbriteVenueTTL is how long a looked up venue is reused, as long
+	// as the events converted with it are cached
+	eventbriteVenueTTL = 24 * time.Hour
+	// eventbriteMaxVenues bounds the venues kept
+	eventbriteMaxVenues = 1000
 )
 
 type EventbriteClient struct {
 	baseURL     string
-	token       string
+	tokens      oauth.TokenProvider
 	httpClient  *http.Client
-	rateLimiter *eventRateLimiter
+	rateLimiter *ratelimit.Limiter
+	venues      *eventbriteVenueCache
 }
 
 type EventbriteConfig struct {
 	Token string // Eventbrite OAuth token
+	// TokenProvider replaces Token for apps whose tokens expire and are
+	// refreshed
+	TokenProvider oauth.TokenProvider
 }
 
 func NewEventbriteClient(config EventbriteConfig) (*EventbriteClient, error) {
-	if config.Token == "" {
-		return nil, fmt.Errorf("eventbrite token is required")
+	tokens := config.TokenProvider
+	if tokens == nil {
+		if config.Token == "" {
+			return nil, fmt.Errorf("eventbrite token is required")
+		}
+		tokens = oauth.StaticToken(config.Token)
 	}
 
 	return &EventbriteClient{
 		baseURL:     "https://www.eventbriteapi.com/v3",
-		token:       config.Token,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newEventRateLimiter(1000), // 1000 requests per hour for personal tokens
+		tokens:      tokens,
+		httpClient:  httpclient.NewFor("eventbrite", 10*time.Second),
+		rateLimiter: ratelimit.PerHour("eventbrite", 1000), // 1000 requests per hour for personal tokens
+		venues:      newEventbriteVenueCache(eventbriteVenueTTL, eventbriteMaxVenues),
 	}, nil
 }
 
+// UseQuotaStore restores and persists request counts so the quota window
+// survives restarts
+func (c *EventbriteClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *EventbriteClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
+}
+
 type eventbriteEvent struct {
 	Name                         eventbriteMultiPartText    `json:"name"`
 	Description                  eventbriteMultiPartText    `json:"description"`
@@ -74,6 +105,7 @@ type eventbriteEvent struct {
 	LogoID                       string                     `json:"logo_id"`
 	OrganizationLogoID           string                     `json:"organization_logo_id"`
 	VenueID                      string                     `json:"venue_id"`
+	Venue                        *eventbriteVenue           `json:"venue"` // with expand=venue
 	CategoryID                   string                     `json:"category_id"`
 	SubcategoryID                string                     `json:"subcategory_id"`
 	FormatID                     string                     `json:"format_id"`
@@ -170,7 +202,7 @@ type eventbritePagination struct {
 }
 
 func (c *EventbriteClient) SearchEventsByQuery(ctx context.Context, query string, limit int) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -200,9 +232,7 @@ func (c *EventbriteClient) SearchEventsByQuery(ctx context.Context, query string
 	q.Set("page_size", fmt.Sprintf("%d", limit))
 	req.URL.RawQuery = q.Encode()
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := oauth.Do(c.httpClient, c.tokens, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search events: %w", err)
 	}
@@ -236,7 +266,7 @@ func (c *EventbriteClient) SearchEventsByQuery(ctx context.Context, query string
 }
 
 func (c *EventbriteClient) SearchEventsByLocation(ctx context.Context, location string, limit int) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -266,9 +296,7 @@ func (c *EventbriteClient) SearchEventsByLocation(ctx context.Context, location
 	q.Set("page_size", fmt.Sprintf("%d", limit))
 	req.URL.RawQuery = q.Encode()
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := oauth.Do(c.httpClient, c.tokens, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search events: %w", err)
 	}
@@ -296,7 +324,7 @@ func (c *EventbriteClient) SearchEventsByLocation(ctx context.Context, location
 }
 
 func (c *EventbriteClient) GetEvent(ctx context.Context, eventbriteID string) (*domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -310,9 +338,7 @@ func (c *EventbriteClient) GetEvent(ctx context.Context, eventbriteID string) (*
 	q.Set("expand", "venue,organizer,category,subcategory")
 	req.URL.RawQuery = q.Encode()
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := oauth.Do(c.httpClient, c.tokens, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
@@ -338,9 +364,19 @@ func (c *EventbriteClient) GetEvent(ctx context.Context, eventbriteID string) (*
 	return &event, nil
 }
 
+// eventbriteStatusOf maps an event's status. Eventbrite has no postponed
+// state; organizers change the date instead.
+func eventbriteStatusOf(status string) domain.EventStatus {
+	if status == "canceled" {
+		return domain.EventCancelled
+	}
+	return domain.EventScheduled
+}
+
 func (c *EventbriteClient) convertToEvent(ctx context.Context, ebEvent eventbriteEvent) (domain.Event, error) {
 	// Parse event datetime
-	eventTime := c.parseEventDateTime(ebEvent.Start)
+	eventTime, dateConfidence := c.parseEventDateTime(ebEvent.Start)
+	eventTime = domain.InTimezone(eventTime, ebEvent.Start.Timezone)
 
 	// Use event name as artist name (Eventbrite doesn't separate these well)
 	artistName := ebEvent.Name.Text
@@ -355,36 +391,80 @@ func (c *EventbriteClient) convertToEvent(ctx context.Context, ebEvent eventbrit
 		Country: "Unknown Country",
 	}
 
-	if ebEvent.VenueID != "" {
-		ebVenue, err := c.getVenue(ctx, ebEvent.VenueID)
-		if err == nil {
-			venue.Name = ebVenue.Name
-			venue.City = ebVenue.Address.City
-			venue.Country = ebVenue.Address.Country
+	// Eventbrite sets age restrictions on venues, e.g. "18+"
+	var ageRestriction string
+	if ebVenue := c.venueOf(ctx, ebEvent); ebVenue != nil {
+		venue.Name = ebVenue.Name
+		venue.City = ebVenue.Address.City
+		// A code, like "DE"
+		venue.CountryCode = ebVenue.Address.Country
+		venue.Capacity = ebVenue.Capacity
+		ageRestriction = ebVenue.AgeRestriction
+		venue.Address = ebVenue.Address.LocalizedAddressDisplay
+		if venue.Address == "" {
+			venue.Address = joinNonEmpty(", ", ebVenue.Address.Address1, ebVenue.Address.Address2, ebVenue.Address.PostalCode)
+		}
 
-			// Parse coordinates
-			if ebVenue.Latitude != "" && ebVenue.Longitude != "" {
-				fmt.Sscanf(ebVenue.Latitude, "%f", &venue.Latitude)
-				fmt.Sscanf(ebVenue.Longitude, "%f", &venue.Longitude)
-			}
+		// Parse coordinates
+		if ebVenue.Latitude != "" && ebVenue.Longitude != "" {
+			fmt.Sscanf(ebVenue.Latitude, "%f", &venue.Latitude)
+			fmt.Sscanf(ebVenue.Longitude, "%f", &venue.Longitude)
 		}
 	}
+	venue.NormalizeCountry()
 
 	// Set 24-hour cache
 	cacheUntil := time.Now().Add(24 * time.Hour)
 
+	artistID := fmt.Sprintf("eventbrite_artist_%s", strings.ReplaceAll(strings.ToLower(artistName), " ", "_"))
+
 	return domain.Event{
-		ID:          fmt.Sprintf("eventbrite_%s", ebEvent.ID),
-		ArtistID:    fmt.Sprintf("eventbrite_artist_%s", strings.ReplaceAll(strings.ToLower(artistName), " ", "_")),
-		ArtistName:  artistName,
-		DateTime:    eventTime,
-		Venue:       venue,
-		CachedUntil: cacheUntil,
+		ID:         fmt.Sprintf("eventbrite_%s", ebEvent.ID),
+		ArtistID:   artistID,
+		ArtistName: artistName,
+		DateTime:   eventTime,
+		Timezone:   ebEvent.Start.Timezone,
+		Venue:      venue,
+		Status:     eventbriteStatusOf(ebEvent.Status),
+		// Eventbrite has no lineups; the event name stands in for the artist
+		Lineup: domain.HeadlinerLineup(artistID, artistName),
+		ExternalIDs: domain.EventExternalIDs{
+			EventbriteID: ebEvent.ID,
+		},
+		DateConfidence: dateConfidence,
+		AgeRestriction: ageRestriction,
+		CachedUntil:    cacheUntil,
 	}, nil
 }
 
+// venueOf is the event's venue: expanded in the response when it was asked
+// for, else looked up once and kept for the next events there. Nil when
+// the event has none or the lookup failed.
+func (c *EventbriteClient) venueOf(ctx context.Context, ebEvent eventbriteEvent) *eventbriteVenue {
+	if ebEvent.Venue != nil && ebEvent.Venue.Name != "" {
+		c.venues.put(ebEvent.Venue)
+		return ebEvent.Venue
+	}
+	if ebEvent.VenueID == "" {
+		return nil
+	}
+	if venue := c.venues.get(ebEvent.VenueID); venue != nil {
+		return venue
+	}
+
+	venue, err := c.getVenue(ctx, ebEvent.VenueID)
+	if err != nil {
+		return nil
+	}
+	if venue.ID == "" {
+		venue.ID = ebEvent.VenueID
+	}
+	c.venues.put(venue)
+	return venue
+}
+
 func (c *EventbriteClient) getVenue(ctx context.Context, venueID string) (*eventbriteVenue, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -394,9 +474,7 @@ func (c *EventbriteClient) getVenue(ctx context.Context, venueID string) (*event
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := oauth.Do(c.httpClient, c.tokens, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get venue: %w", err)
 	}
@@ -414,21 +492,87 @@ func (c *EventbriteClient) getVenue(ctx context.Context, venueID string) (*event
 	return &venue, nil
 }
 
-func (c *EventbriteClient) parseEventDateTime(start eventbriteDateTime) time.Time {
+// parseEventDateTime returns a zero time when neither start time can be read
+func (c *EventbriteClient) parseEventDateTime(start eventbriteDateTime) (time.Time, domain.DateConfidence) {
 	// Try UTC first
 	if start.UTC != "" {
 		if t, err := time.Parse("2006-01-02T15:04:05Z", start.UTC); err == nil {
-			return t
+			return t, domain.DateExact
 		}
 	}
 
-	// Try local time
+	// Try local time, on the clock of the event's timezone
 	if start.Local != "" {
-		if t, err := time.Parse("2006-01-02T15:04:05", start.Local); err == nil {
-			return t
+		if t, err := domain.ParseLocalDateTime("2006-01-02T15:04:05", start.Local, start.Timezone); err == nil {
+			return t, domain.DateExact
 		}
 	}
 
-	// Ultimate fallback
-	return time.Now()
+	return time.Time{}, domain.DateUnknown
+}
+
+// eventbriteVenueCache keeps venues by ID, so the many events at one venue
+// cost a single lookup
+type eventbriteVenueCache struct {
+	mu         sync.Mutex
+	venues     map[string]cachedEventbriteVenue
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+type cachedEventbriteVenue struct {
+	venue     *eventbriteVenue
+	expiresAt time.Time
+}
+
+func newEventbriteVenueCache(ttl time.Duration, maxEntries int) *eventbriteVenueCache {
+	return &eventbriteVenueCache{
+		venues:     make(map[string]cachedEventbriteVenue),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (c *eventbriteVenueCache) get(id string) *eventbriteVenue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.venues[id]
+	if !ok {
+		return nil
+	}
+	if c.now().After(cached.expiresAt) {
+		delete(c.venues, id)
+		return nil
+	}
+	return cached.venue
+}
+
+// put keeps venue, making room by dropping expired venues and, when that
+// isn't enough, any other one
+func (c *eventbriteVenueCache) put(venue *eventbriteVenue) {
+	if venue.ID == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if _, ok := c.venues[venue.ID]; !ok && len(c.venues) >= c.maxEntries {
+		for id, cached := range c.venues {
+			if now.After(cached.expiresAt) {
+				delete(c.venues, id)
+			}
+		}
+		for id := range c.venues {
+			if len(c.venues) < c.maxEntries {
+				break
+			}
+			delete(c.venues, id)
+		}
+	}
+	c.venues[venue.ID] = cachedEventbriteVenue{venue: venue, expiresAt: now.Add(c.ttl)}
 }
@@ -0,0 +1,182 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// eventbriteVenueServer answers event searches with the events set on it
+// and venue lookups by ID, counting the lookups per venue
+type eventbriteVenueServer struct {
+	*httptest.Server
+
+	mu           sync.Mutex
+	events       []eventbriteEvent
+	venueLookups map[string]int
+}
+
+func newEventbriteVenueServer(t *testing.T, events []eventbriteEvent) *eventbriteVenueServer {
+	s := &eventbriteVenueServer{events: events, venueLookups: make(map[string]int)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/events/search/":
+			json.NewEncoder(w).Encode(eventbriteEventsResponse{Events: s.events})
+		case strings.HasPrefix(r.URL.Path, "/venues/"):
+			id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/venues/"), "/")
+			s.mu.Lock()
+			s.venueLookups[id]++
+			s.mu.Unlock()
+			json.NewEncoder(w).Encode(eventbriteVenue{
+				ID:      id,
+				Name:    "Venue " + id,
+				Address: eventbriteAddress{City: "Berlin", Country: "DE"},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *eventbriteVenueServer) VenueLookups() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	lookups := make(map[string]int, len(s.venueLookups))
+	for id, count := range s.venueLookups {
+		lookups[id] = count
+	}
+	return lookups
+}
+
+func newTestEventbriteClient(t *testing.T, server *eventbriteVenueServer) *EventbriteClient {
+	client, err := NewEventbriteClient(EventbriteConfig{Token: "eventbrite-token"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client.baseURL = server.URL
+	client.httpClient = server.Client()
+	return client
+}
+
+func eventbriteEventAt(id, venueID string, venue *eventbriteVenue) eventbriteEvent {
+	return eventbriteEvent{
+		ID:      id,
+		Name:    eventbriteMultiPartText{Text: "Show " + id},
+		Start:   eventbriteDateTime{UTC: "2026-06-01T20:00:00Z", Timezone: "Europe/Berlin"},
+		VenueID: venueID,
+		Venue:   venue,
+	}
+}
+
+func TestEventbriteClient_Venues(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("expanded venue needs no lookup", func(t *testing.T) {
+		server := newEventbriteVenueServer(t, []eventbriteEvent{
+			eventbriteEventAt("1", "v1", &eventbriteVenue{ID: "v1", Name: "Lido", Address: eventbriteAddress{City: "Berlin", Country: "DE"}}),
+		})
+		client := newTestEventbriteClient(t, server)
+
+		events, err := client.SearchEventsByQuery(ctx, "techno", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(events) != 1 || events[0].Venue.Name != "Lido" || events[0].Venue.City != "Berlin" {
+			t.Errorf("expected the expanded venue, got %+v", events)
+		}
+		if lookups := server.VenueLookups(); len(lookups) != 0 {
+			t.Errorf("expected no venue lookups, got %v", lookups)
+		}
+	})
+
+	t.Run("repeated venues are looked up once", func(t *testing.T) {
+		server := newEventbriteVenueServer(t, []eventbriteEvent{
+			eventbriteEventAt("1", "v2", nil),
+			eventbriteEventAt("2", "v2", nil),
+			eventbriteEventAt("3", "v3", nil),
+			eventbriteEventAt("4", "v2", nil),
+		})
+		client := newTestEventbriteClient(t, server)
+
+		for i := 0; i < 2; i++ {
+			events, err := client.SearchEventsByQuery(ctx, "techno", 10)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if len(events) != 4 || events[0].Venue.Name != "Venue v2" || events[2].Venue.Name != "Venue v3" {
+				t.Errorf("expected the looked up venues, got %+v", events)
+			}
+		}
+		if lookups := server.VenueLookups(); lookups["v2"] != 1 || lookups["v3"] != 1 {
+			t.Errorf("expected one lookup per venue, got %v", lookups)
+		}
+	})
+
+	t.Run("venues are looked up again after the TTL", func(t *testing.T) {
+		server := newEventbriteVenueServer(t, []eventbriteEvent{eventbriteEventAt("1", "v4", nil)})
+		client := newTestEventbriteClient(t, server)
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		client.venues.now = func() time.Time { return now }
+
+		search := func() {
+			if _, err := client.SearchEventsByQuery(ctx, "techno", 10); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		search()
+		now = now.Add(eventbriteVenueTTL - time.Minute)
+		search()
+		if lookups := server.VenueLookups(); lookups["v4"] != 1 {
+			t.Errorf("expected the venue cached within the TTL, got %v", lookups)
+		}
+
+		now = now.Add(2 * time.Minute)
+		search()
+		if lookups := server.VenueLookups(); lookups["v4"] != 2 {
+			t.Errorf("expected the venue looked up again after the TTL, got %v", lookups)
+		}
+	})
+}
+
+func TestEventbriteVenueCache_MaxEntries(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cache := newEventbriteVenueCache(time.Hour, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.put(&eventbriteVenue{ID: "a"})
+	now = now.Add(30 * time.Minute)
+	cache.put(&eventbriteVenue{ID: "b"})
+
+	// a has expired, so it makes room rather than b
+	now = now.Add(31 * time.Minute)
+	cache.put(&eventbriteVenue{ID: "c"})
+	if len(cache.venues) != 2 || cache.get("b") == nil || cache.get("c") == nil {
+		t.Errorf("expected the expired venue dropped, got %v", cache.venues)
+	}
+
+	// With none expired, some venue still makes room
+	cache.put(&eventbriteVenue{ID: "d"})
+	if len(cache.venues) != 2 || cache.get("d") == nil {
+		t.Errorf("expected the cache to stay at 2 venues with the newest kept, got %v", cache.venues)
+	}
+
+	// Replacing a kept venue drops nothing
+	cache.put(&eventbriteVenue{ID: "d", Name: "Renamed"})
+	if len(cache.venues) != 2 || cache.get("d").Name != "Renamed" {
+		t.Errorf("expected the venue replaced in place, got %v", cache.venues)
+	}
+
+	cache.put(&eventbriteVenue{})
+	if len(cache.venues) != 2 {
+		t.Errorf("expected venues without an ID to be skipped, got %v", cache.venues)
+	}
+}
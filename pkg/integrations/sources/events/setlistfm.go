@@ -5,17 +5,43 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+)
+
+const (
+	// setlistFMMissTTL is how long a name that matched no artist is searched
+	// by name before it's looked up again. MBIDs that were found don't change.
+	setlistFMMissTTL = 7 * 24 * time.Hour
+	// setlistFMLookupTimeout bounds a shared MBID lookup, which runs on
+	// after the search that started it gives up
+	setlistFMLookupTimeout = 15 * time.Second
 )
 
 type SetlistFMClient struct {
 	baseURL     string
 	apiKey      string
 	httpClient  *http.Client
-	rateLimiter *eventRateLimiter
+	rateLimiter *ratelimit.Limiter
+	identities  domain.ArtistIdentityRepository
+	now         func() time.Time
+
+	// lookups are the MBID lookups under way, by name, so concurrent
+	// searches for one artist share a request
+	lookupsMu sync.Mutex
+	lookups   map[string]*mbidLookup
+}
+
+type mbidLookup struct {
+	done chan struct{}
+	mbid string
+	err  error
 }
 
 type SetlistFMConfig struct {
@@ -30,11 +56,34 @@ func NewSetlistFMClient(config SetlistFMConfig) (*SetlistFMClient, error) {
 	return &SetlistFMClient{
 		baseURL:     "https://api.setlist.fm/rest/1.0",
 		apiKey:      config.APIKey,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newEventRateLimiter(2000), // 2000 requests per day
+		httpClient:  httpclient.NewFor("setlistfm", 10*time.Second),
+		rateLimiter: ratelimit.PerDay("setlistfm", 2000), // 2000 requests per day
+		now:         time.Now,
+		lookups:     make(map[string]*mbidLookup),
 	}, nil
 }
 
+// UseIdentityStore keeps the MBIDs artist names resolve to in store, and
+// uses the ones other sources resolved, so a name is looked up once rather
+// than before every search. It's set up before searching.
+func (c *SetlistFMClient) UseIdentityStore(store domain.ArtistIdentityRepository) {
+	c.identities = store
+}
+
+// UseQuotaStore restores and persists request counts so the quota window
+// survives restarts
+func (c *SetlistFMClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *SetlistFMClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
+}
+
+func (c *SetlistFMClient) GetName() string {
+	return "setlistfm"
+}
+
 type setlistFMSetlist struct {
 	ID          string          `json:"id"`
 	VersionID   string          `json:"versionId"`
@@ -130,7 +179,7 @@ type setlistFMVenueSearchResponse struct {
 }
 
 func (c *SetlistFMClient) SearchSetlistsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -147,7 +196,7 @@ func (c *SetlistFMClient) SearchSetlistsByArtist(ctx context.Context, artistName
 	}
 
 	// First, find the artist MBID if possible
-	artistMBID, err := c.findArtistMBID(ctx, artistName)
+	artistMBID, err := c.artistMBID(ctx, artistName)
 	if err != nil {
 		return nil, err
 	}
@@ -211,8 +260,86 @@ func (c *SetlistFMClient) SearchSetlistsByArtist(ctx context.Context, artistName
 	return events, nil
 }
 
+// ArtistHistory returns one page of the artist's setlists, newest first, with
+// the songs played. The artist's MusicBrainz ID is used when we have one,
+// which saves the name lookup.
+func (c *SetlistFMClient) ArtistHistory(ctx context.Context, artist domain.Artist, page int) (*domain.ConcertHistoryPage, error) {
+	artistName := strings.TrimSpace(artist.Name)
+	artistMBID := artist.ExternalIDs.MusicBrainzID
+	if artistName == "" && artistMBID == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	if artistMBID == "" {
+		mbid, err := c.artistMBID(ctx, artistName)
+		if err != nil {
+			return nil, err
+		}
+		artistMBID = mbid
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/search/setlists", c.baseURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	if artistMBID != "" {
+		q.Set("artistMbid", artistMBID)
+	} else {
+		q.Set("artistName", artistName)
+	}
+	q.Set("p", fmt.Sprintf("%d", page))
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "WhereItsAt/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search setlists: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, domain.ErrRateLimitExceeded
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		// Setlist.fm answers pages past the end with a 404 too
+		return &domain.ConcertHistoryPage{Concerts: []domain.PastConcert{}, Page: page}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("setlist.fm search failed: status %d", resp.StatusCode)
+	}
+
+	var searchResp setlistFMSearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	concerts := make([]domain.PastConcert, 0, len(searchResp.Setlist))
+	for _, setlist := range searchResp.Setlist {
+		concerts = append(concerts, c.convertToPastConcert(setlist))
+	}
+
+	return &domain.ConcertHistoryPage{
+		Concerts: concerts,
+		Page:     page,
+		Total:    searchResp.Total,
+		HasMore:  page*searchResp.ItemsPerPage < searchResp.Total,
+	}, nil
+}
+
 func (c *SetlistFMClient) SearchSetlistsByVenue(ctx context.Context, venueName, city string, limit int) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -285,7 +412,7 @@ func (c *SetlistFMClient) SearchSetlistsByVenue(ctx context.Context, venueName,
 }
 
 func (c *SetlistFMClient) GetSetlist(ctx context.Context, setlistID string) (*domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -321,8 +448,57 @@ func (c *SetlistFMClient) GetSetlist(ctx context.Context, setlistID string) (*do
 	return &event, nil
 }
 
+// artistMBID is the MBID of the artist going by name, from the identity
+// store when it's known there, else looked up and stored. Empty when no
+// artist matched; it's searched by name then.
+func (c *SetlistFMClient) artistMBID(ctx context.Context, artistName string) (string, error) {
+	if c.identities != nil {
+		identity, err := c.identities.GetIdentity(ctx, artistName)
+		if err == nil && (identity.MBID != "" || c.now().Sub(identity.ResolvedAt) < setlistFMMissTTL) {
+			return identity.MBID, nil
+		}
+	}
+
+	key := strings.ToLower(artistName)
+	c.lookupsMu.Lock()
+	lookup, ok := c.lookups[key]
+	if !ok {
+		lookup = &mbidLookup{done: make(chan struct{})}
+		c.lookups[key] = lookup
+		go c.lookUpMBID(ctx, key, artistName, lookup)
+	}
+	c.lookupsMu.Unlock()
+
+	select {
+	case <-lookup.done:
+		return lookup.mbid, lookup.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// lookUpMBID runs a lookup every search for the name waits on. It's
+// detached from the search that started it, so that search giving up
+// doesn't fail the others.
+func (c *SetlistFMClient) lookUpMBID(ctx context.Context, key, artistName string, lookup *mbidLookup) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), setlistFMLookupTimeout)
+	defer cancel()
+
+	lookup.mbid, lookup.err = c.findArtistMBID(ctx, artistName)
+	if lookup.err == nil && c.identities != nil {
+		// Failing to remember it only costs a lookup next time
+		c.identities.SaveIdentity(ctx, &domain.ArtistIdentity{Name: artistName, MBID: lookup.mbid, Source: "setlistfm", ResolvedAt: c.now()})
+	}
+
+	// Stored first, so searches from now on find it there
+	c.lookupsMu.Lock()
+	delete(c.lookups, key)
+	c.lookupsMu.Unlock()
+	close(lookup.done)
+}
+
 func (c *SetlistFMClient) findArtistMBID(ctx context.Context, artistName string) (string, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return "", err
 	}
 
@@ -368,7 +544,7 @@ func (c *SetlistFMClient) findArtistMBID(ctx context.Context, artistName string)
 }
 
 func (c *SetlistFMClient) findVenueID(ctx context.Context, venueName, city string) (string, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return "", err
 	}
 
@@ -420,7 +596,7 @@ func (c *SetlistFMClient) findVenueID(ctx context.Context, venueName, city strin
 
 func (c *SetlistFMClient) convertToEvent(setlist setlistFMSetlist) domain.Event {
 	// Parse event date
-	eventTime := c.parseEventDate(setlist.EventDate)
+	eventTime, dateConfidence := c.parseEventDate(setlist.EventDate)
 
 	// Convert venue
 	venue := domain.Venue{
@@ -430,41 +606,55 @@ func (c *SetlistFMClient) convertToEvent(setlist setlistFMSetlist) domain.Event
 		Latitude:  setlist.Venue.City.Coords.Lat,
 		Longitude: setlist.Venue.City.Coords.Long,
 	}
+	venue.CountryCode = setlist.Venue.City.Country.Code
+	venue.NormalizeCountry()
 
 	// Set 24-hour cache
 	cacheUntil := time.Now().Add(24 * time.Hour)
 
+	artistID := fmt.Sprintf("setlistfm_artist_%s", strings.ReplaceAll(strings.ToLower(setlist.Artist.Name), " ", "_"))
+
 	return domain.Event{
-		ID:          fmt.Sprintf("setlistfm_%s", setlist.ID),
-		ArtistID:    fmt.Sprintf("setlistfm_artist_%s", strings.ReplaceAll(strings.ToLower(setlist.Artist.Name), " ", "_")),
-		ArtistName:  setlist.Artist.Name,
-		DateTime:    eventTime,
-		Venue:       venue,
-		CachedUntil: cacheUntil,
+		ID:         fmt.Sprintf("setlistfm_%s", setlist.ID),
+		ArtistID:   artistID,
+		ArtistName: setlist.Artist.Name,
+		DateTime:   eventTime,
+		Venue:      venue,
+		// A setlist is a show that happened
+		Status: domain.EventScheduled,
+		// A setlist is one artist's set, so support acts aren't known
+		Lineup: domain.HeadlinerLineup(artistID, setlist.Artist.Name),
+		ExternalIDs: domain.EventExternalIDs{
+			SetlistFMID: setlist.ID,
+		},
+		DateConfidence: dateConfidence,
+		CachedUntil:    cacheUntil,
 	}
 }
 
-func (c *SetlistFMClient) parseEventDate(eventDate string) time.Time {
+// parseEventDate reads the day of a setlist; setlist.fm doesn't record
+// start times
+func (c *SetlistFMClient) parseEventDate(eventDate string) (time.Time, domain.DateConfidence) {
 	// Setlist.fm uses DD-MM-YYYY format
 	if t, err := time.Parse("02-01-2006", eventDate); err == nil {
-		return t
+		return t, domain.DateOnly
 	}
 
 	// Fallback to other common formats
 	if t, err := time.Parse("2006-01-02", eventDate); err == nil {
-		return t
+		return t, domain.DateOnly
 	}
 
-	// Ultimate fallback
-	return time.Now()
+	return time.Time{}, domain.DateUnknown
 }
 
-func (c *SetlistFMClient) GetSetlistSongs(ctx context.Context, setlistID string) ([]SetlistFMSong, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+// GetPastConcert fetches one setlist with the songs played
+func (c *SetlistFMClient) GetPastConcert(ctx context.Context, setlistID string) (*domain.PastConcert, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
-	setlistURL := fmt.Sprintf("%s/setlist/%s", c.baseURL, setlistID)
+	setlistURL := fmt.Sprintf("%s/setlist/%s", c.baseURL, url.PathEscape(setlistID))
 	req, err := http.NewRequestWithContext(ctx, "GET", setlistURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -480,6 +670,12 @@ func (c *SetlistFMClient) GetSetlistSongs(ctx context.Context, setlistID string)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, domain.ErrRateLimitExceeded
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, domain.ErrEventNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("setlist.fm get setlist failed: status %d", resp.StatusCode)
 	}
@@ -489,10 +685,33 @@ func (c *SetlistFMClient) GetSetlistSongs(ctx context.Context, setlistID string)
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	songs := []SetlistFMSong{}
-	for _, set := range setlist.Sets.Set {
+	concert := c.convertToPastConcert(setlist)
+	return &concert, nil
+}
+
+func (c *SetlistFMClient) GetSetlistSongs(ctx context.Context, setlistID string) ([]domain.SetlistSong, error) {
+	concert, err := c.GetPastConcert(ctx, setlistID)
+	if err != nil {
+		return nil, err
+	}
+	return concert.Songs, nil
+}
+
+func (c *SetlistFMClient) convertToPastConcert(setlist setlistFMSetlist) domain.PastConcert {
+	return domain.PastConcert{
+		Event:  c.convertToEvent(setlist),
+		Source: c.GetName(),
+		Tour:   setlist.Tour.Name,
+		Songs:  c.convertSongs(setlist.Sets),
+		URL:    setlist.URL,
+	}
+}
+
+func (c *SetlistFMClient) convertSongs(sets setlistFMSets) []domain.SetlistSong {
+	songs := []domain.SetlistSong{}
+	for _, set := range sets.Set {
 		for _, song := range set.Song {
-			songData := SetlistFMSong{
+			songData := domain.SetlistSong{
 				Name:     song.Name,
 				SetName:  set.Name,
 				IsEncore: set.Encore > 0,
@@ -511,15 +730,5 @@ func (c *SetlistFMClient) GetSetlistSongs(ctx context.Context, setlistID string)
 		}
 	}
 
-	return songs, nil
-}
-
-type SetlistFMSong struct {
-	Name       string `json:"name"`
-	SetName    string `json:"set_name"`
-	IsEncore   bool   `json:"is_encore"`
-	Info       string `json:"info,omitempty"`
-	IsTape     bool   `json:"is_tape"`
-	WithArtist string `json:"with_artist,omitempty"`
-	CoverOf    string `json:"cover_of,omitempty"`
+	return songs
 }
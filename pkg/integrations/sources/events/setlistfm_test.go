@@ -0,0 +1,223 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// memoryIdentityStore keeps identities by lower-cased name and counts the
+// lookups made in it
+type memoryIdentityStore struct {
+	mu         sync.Mutex
+	identities map[string]domain.ArtistIdentity
+	gets       int
+}
+
+func newMemoryIdentityStore() *memoryIdentityStore {
+	return &memoryIdentityStore{identities: make(map[string]domain.ArtistIdentity)}
+}
+
+func (s *memoryIdentityStore) GetIdentity(ctx context.Context, name string) (*domain.ArtistIdentity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gets++
+	identity, ok := s.identities[strings.ToLower(name)]
+	if !ok {
+		return nil, domain.ErrIdentityNotFound
+	}
+	return &identity, nil
+}
+
+func (s *memoryIdentityStore) SaveIdentity(ctx context.Context, identity *domain.ArtistIdentity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identities[strings.ToLower(identity.Name)] = *identity
+	return nil
+}
+
+func (s *memoryIdentityStore) Gets() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gets
+}
+
+// setlistFMArtistServer answers artist searches with one MBID, holding
+// each answer until release is closed when it's set
+type setlistFMArtistServer struct {
+	*httptest.Server
+	searches atomic.Int32
+	started  chan struct{}
+	release  chan struct{}
+}
+
+func newSetlistFMArtistServer(t *testing.T, hold bool) *setlistFMArtistServer {
+	s := &setlistFMArtistServer{started: make(chan struct{}, 10)}
+	if hold {
+		s.release = make(chan struct{})
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search/artists" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		s.searches.Add(1)
+		s.started <- struct{}{}
+		if s.release != nil {
+			<-s.release
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"artist":[{"mbid":"a74b1b7f-71a5-4011-9441-d0b5e4122711","name":"Radiohead"}]}`))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func newTestSetlistFMClient(t *testing.T, server *setlistFMArtistServer, store domain.ArtistIdentityRepository) *SetlistFMClient {
+	client, err := NewSetlistFMClient(SetlistFMConfig{APIKey: "setlist-key"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client.baseURL = server.URL
+	client.httpClient = server.Client()
+	if store != nil {
+		client.UseIdentityStore(store)
+	}
+	return client
+}
+
+// waitFor polls until done reports true
+func waitFor(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// Past the store, the callers only have the lookup map left to reach
+	time.Sleep(10 * time.Millisecond)
+}
+
+const radioheadMBID = "a74b1b7f-71a5-4011-9441-d0b5e4122711"
+
+func TestSetlistFMClient_ArtistMBID(t *testing.T) {
+	t.Run("concurrent searches share one lookup", func(t *testing.T) {
+		server := newSetlistFMArtistServer(t, true)
+		store := newMemoryIdentityStore()
+		client := newTestSetlistFMClient(t, server, store)
+
+		const callers = 5
+		mbids := make([]string, callers)
+		errs := make([]error, callers)
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				mbids[i], errs[i] = client.artistMBID(context.Background(), "Radiohead")
+			}()
+		}
+		<-server.started
+		waitFor(t, "every caller to check the store", func() bool { return store.Gets() == callers })
+		close(server.release)
+		wg.Wait()
+
+		for i := range mbids {
+			if errs[i] != nil || mbids[i] != radioheadMBID {
+				t.Errorf("caller %d: expected the MBID, got %q, %v", i, mbids[i], errs[i])
+			}
+		}
+		if searches := server.searches.Load(); searches != 1 {
+			t.Errorf("expected 1 artist search, got %d", searches)
+		}
+	})
+
+	t.Run("cancelled leader does not fail the waiters", func(t *testing.T) {
+		server := newSetlistFMArtistServer(t, true)
+		store := newMemoryIdentityStore()
+		client := newTestSetlistFMClient(t, server, store)
+
+		leaderCtx, cancel := context.WithCancel(context.Background())
+		leaderErr := make(chan error, 1)
+		go func() {
+			_, err := client.artistMBID(leaderCtx, "Radiohead")
+			leaderErr <- err
+		}()
+		<-server.started
+
+		var mbid string
+		var err error
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			mbid, err = client.artistMBID(context.Background(), "Radiohead")
+		}()
+		waitFor(t, "the waiter to check the store", func() bool { return store.Gets() == 2 })
+
+		cancel()
+		if err := <-leaderErr; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected the leader to give up, got %v", err)
+		}
+		close(server.release)
+		<-done
+
+		if err != nil || mbid != radioheadMBID {
+			t.Errorf("expected the waiter to get the MBID, got %q, %v", mbid, err)
+		}
+		if searches := server.searches.Load(); searches != 1 {
+			t.Errorf("expected 1 artist search, got %d", searches)
+		}
+		if identity, _ := store.GetIdentity(context.Background(), "Radiohead"); identity == nil || identity.MBID != radioheadMBID {
+			t.Errorf("expected the MBID to be stored, got %+v", identity)
+		}
+	})
+
+	t.Run("known MBIDs skip the lookup", func(t *testing.T) {
+		server := newSetlistFMArtistServer(t, false)
+		store := newMemoryIdentityStore()
+		store.SaveIdentity(context.Background(), &domain.ArtistIdentity{Name: "Radiohead", MBID: radioheadMBID, Source: "musicbrainz"})
+		client := newTestSetlistFMClient(t, server, store)
+
+		mbid, err := client.artistMBID(context.Background(), "radiohead")
+		if err != nil || mbid != radioheadMBID {
+			t.Errorf("expected the stored MBID, got %q, %v", mbid, err)
+		}
+		if searches := server.searches.Load(); searches != 0 {
+			t.Errorf("expected no artist search, got %d", searches)
+		}
+	})
+
+	t.Run("misses are trusted for a week", func(t *testing.T) {
+		server := newSetlistFMArtistServer(t, false)
+		store := newMemoryIdentityStore()
+		now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		store.SaveIdentity(context.Background(), &domain.ArtistIdentity{Name: "Radiohead", Source: "setlistfm", ResolvedAt: now})
+		client := newTestSetlistFMClient(t, server, store)
+
+		client.now = func() time.Time { return now.Add(6 * 24 * time.Hour) }
+		if mbid, err := client.artistMBID(context.Background(), "Radiohead"); err != nil || mbid != "" {
+			t.Errorf("expected the stored miss, got %q, %v", mbid, err)
+		}
+		if searches := server.searches.Load(); searches != 0 {
+			t.Errorf("expected no artist search within the week, got %d", searches)
+		}
+
+		client.now = func() time.Time { return now.Add(8 * 24 * time.Hour) }
+		if mbid, err := client.artistMBID(context.Background(), "Radiohead"); err != nil || mbid != radioheadMBID {
+			t.Errorf("expected the MBID looked up again, got %q, %v", mbid, err)
+		}
+		if searches := server.searches.Load(); searches != 1 {
+			t.Errorf("expected 1 artist search after the week, got %d", searches)
+		}
+	})
+}
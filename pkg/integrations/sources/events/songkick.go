@@ -5,17 +5,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
 )
 
 type SongkickClient struct {
 	baseURL     string
 	apiKey      string
 	httpClient  *http.Client
-	rateLimiter *eventRateLimiter
+	rateLimiter *ratelimit.Limiter
 }
 
 type SongkickConfig struct {
@@ -30,11 +33,25 @@ func NewSongkickClient(config SongkickConfig) (*SongkickClient, error) {
 	return &SongkickClient{
 		baseURL:     "https://api.songkick.com/api/3.0",
 		apiKey:      config.APIKey,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newEventRateLimiter(1000), // 1000 requests per day
+		httpClient:  httpclient.NewFor("songkick", 10*time.Second),
+		rateLimiter: ratelimit.PerDay("songkick", 1000), // 1000 requests per day
 	}, nil
 }
 
+func (c *SongkickClient) GetName() string {
+	return "songkick"
+}
+
+// UseQuotaStore restores and persists request counts so the quota window
+// survives restarts
+func (c *SongkickClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *SongkickClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
+}
+
 type songkickEvent struct {
 	ID             int64                 `json:"id"`
 	Type           string                `json:"type"`
@@ -47,6 +64,12 @@ type songkickEvent struct {
 	Location       songkickLocation      `json:"location,omitempty"`
 	Performance    []songkickPerformance `json:"performance"`
 	AgeRestriction string                `json:"ageRestriction,omitempty"`
+	// Series names the festival a past festival event was an edition of
+	Series *songkickSeries `json:"series,omitempty"`
+}
+
+type songkickSeries struct {
+	DisplayName string `json:"displayName"`
 }
 
 type songkickEventDate struct {
@@ -132,7 +155,7 @@ type songkickArtistSearchResponse struct {
 }
 
 func (c *SongkickClient) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -158,8 +181,35 @@ func (c *SongkickClient) SearchEventsByArtist(ctx context.Context, artistName st
 		return []domain.Event{}, nil // Artist not found
 	}
 
-	// Get upcoming events for the artist
-	eventsURL := fmt.Sprintf("%s/artists/%d/calendar.json", c.baseURL, artistID)
+	return c.artistCalendar(ctx, fmt.Sprintf("%d", artistID), artistName, limit)
+}
+
+// SearchEventsForArtist uses the artist's MusicBrainz ID when we have one,
+// which skips the name lookup and can't match a different artist with the
+// same name
+func (c *SongkickClient) SearchEventsForArtist(ctx context.Context, artist domain.Artist, limit int) ([]domain.Event, error) {
+	if artist.ExternalIDs.MusicBrainzID == "" {
+		return c.SearchEventsByArtist(ctx, artist.Name, limit)
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	return c.artistCalendar(ctx, "mbid:"+artist.ExternalIDs.MusicBrainzID, artist.Name, limit)
+}
+
+// artistCalendar gets upcoming events for a Songkick artist reference, either
+// a numeric artist ID or "mbid:<MBID>"
+func (c *SongkickClient) artistCalendar(ctx context.Context, artistRef, artistName string, limit int) ([]domain.Event, error) {
+	eventsURL := fmt.Sprintf("%s/artists/%s/calendar.json", c.baseURL, artistRef)
 	req, err := http.NewRequestWithContext(ctx, "GET", eventsURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -179,6 +229,9 @@ func (c *SongkickClient) SearchEventsByArtist(ctx context.Context, artistName st
 	if resp.StatusCode == http.StatusTooManyRequests {
 		return nil, domain.ErrRateLimitExceeded
 	}
+	if resp.StatusCode == http.StatusNotFound {
+		return []domain.Event{}, nil // Unknown MBID
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("songkick search failed: status %d", resp.StatusCode)
 	}
@@ -197,8 +250,137 @@ func (c *SongkickClient) SearchEventsByArtist(ctx context.Context, artistName st
 	return events, nil
 }
 
+// songkickGigographyPageSize is how many past events a gigography page
+// holds, Songkick's most
+const songkickGigographyPageSize = 50
+
+// ArtistHistory makes Songkick a history source, through the artist's
+// gigography
+func (c *SongkickClient) ArtistHistory(ctx context.Context, artist domain.Artist, page int) (*domain.ConcertHistoryPage, error) {
+	return c.GetArtistGigography(ctx, artist, page)
+}
+
+// GetArtistGigography returns a page of the concerts the artist has
+// played, newest first. The artist is found by MusicBrainz ID when we have
+// one, or else by name. Cancelled shows never happened, so they're left
+// out, though they still count towards the pages.
+func (c *SongkickClient) GetArtistGigography(ctx context.Context, artist domain.Artist, page int) (*domain.ConcertHistoryPage, error) {
+	artistName := strings.TrimSpace(artist.Name)
+	if artistName == "" && artist.ExternalIDs.MusicBrainzID == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	artistRef := "mbid:" + artist.ExternalIDs.MusicBrainzID
+	if artist.ExternalIDs.MusicBrainzID == "" {
+		artistID, err := c.findArtistID(ctx, artistName)
+		if err != nil {
+			return nil, err
+		}
+		if artistID == 0 {
+			return &domain.ConcertHistoryPage{Concerts: []domain.PastConcert{}, Page: page}, nil
+		}
+		artistRef = fmt.Sprintf("%d", artistID)
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	gigographyURL := fmt.Sprintf("%s/artists/%s/gigography.json", c.baseURL, artistRef)
+	req, err := http.NewRequestWithContext(ctx, "GET", gigographyURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Set("apikey", c.apiKey)
+	q.Set("order", "desc")
+	q.Set("page", fmt.Sprintf("%d", page))
+	q.Set("per_page", fmt.Sprintf("%d", songkickGigographyPageSize))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gigography: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, domain.ErrRateLimitExceeded
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return &domain.ConcertHistoryPage{Concerts: []domain.PastConcert{}, Page: page}, nil // Unknown MBID
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("songkick gigography failed: status %d", resp.StatusCode)
+	}
+
+	var eventsResp songkickEventsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&eventsResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	results := eventsResp.ResultsPage
+	concerts := make([]domain.PastConcert, 0, len(results.Results.Event))
+	for _, skEvent := range results.Results.Event {
+		if songkickStatusOf(skEvent.Status) == domain.EventCancelled {
+			continue
+		}
+		mainArtist := artistName
+		if mainArtist == "" {
+			mainArtist = c.getMainPerformer(skEvent)
+		}
+		concerts = append(concerts, c.convertToPastConcert(skEvent, mainArtist))
+	}
+
+	perPage := results.PerPage
+	if perPage <= 0 {
+		perPage = songkickGigographyPageSize
+	}
+	return &domain.ConcertHistoryPage{
+		Concerts: concerts,
+		Page:     page,
+		Total:    results.TotalEntries,
+		HasMore:  page*perPage < results.TotalEntries,
+	}, nil
+}
+
+// convertToPastConcert maps a gigography event. Past events aren't cached
+// like upcoming ones, and a festival's series stands in for the tour.
+func (c *SongkickClient) convertToPastConcert(skEvent songkickEvent, mainArtist string) domain.PastConcert {
+	event := c.convertToEvent(skEvent, mainArtist)
+	event.Title = skEvent.DisplayName
+	event.CachedUntil = time.Time{}
+
+	concert := domain.PastConcert{
+		Event:  event,
+		Source: c.GetName(),
+		URL:    skEvent.URI,
+	}
+	if skEvent.Series != nil {
+		concert.Tour = skEvent.Series.DisplayName
+	}
+	return concert
+}
+
 func (c *SongkickClient) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	return c.searchMetroArea(ctx, city, country, time.Time{}, time.Time{}, limit)
+}
+
+// SearchEventsByLocationBetween narrows the metro area calendar to the days
+// from and to fall on. Songkick filters by local date, so a day either side
+// is asked for and events outside the range are left to the caller.
+func (c *SongkickClient) SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) ([]domain.Event, error) {
+	return c.searchMetroArea(ctx, city, country, from, to, limit)
+}
+
+// searchMetroArea lists the city's calendar, between from and to when
+// they're set
+func (c *SongkickClient) searchMetroArea(ctx context.Context, city, country string, from, to time.Time, limit int) ([]domain.Event, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -235,6 +417,12 @@ func (c *SongkickClient) SearchEventsByLocation(ctx context.Context, city, count
 	q := req.URL.Query()
 	q.Set("apikey", c.apiKey)
 	q.Set("per_page", fmt.Sprintf("%d", limit))
+	if !from.IsZero() {
+		q.Set("min_date", from.UTC().AddDate(0, 0, -1).Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		q.Set("max_date", to.UTC().AddDate(0, 0, 1).Format("2006-01-02"))
+	}
 	req.URL.RawQuery = q.Encode()
 
 	resp, err := c.httpClient.Do(req)
@@ -264,7 +452,7 @@ func (c *SongkickClient) SearchEventsByLocation(ctx context.Context, city, count
 }
 
 func (c *SongkickClient) GetEvent(ctx context.Context, songkickID string) (*domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -309,7 +497,7 @@ func (c *SongkickClient) GetEvent(ctx context.Context, songkickID string) (*doma
 }
 
 func (c *SongkickClient) findArtistID(ctx context.Context, artistName string) (int64, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return 0, err
 	}
 
@@ -348,7 +536,7 @@ func (c *SongkickClient) findArtistID(ctx context.Context, artistName string) (i
 }
 
 func (c *SongkickClient) findLocationID(ctx context.Context, city, country string) (int64, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return 0, err
 	}
 
@@ -378,10 +566,15 @@ func (c *SongkickClient) findLocationID(ctx context.Context, city, country strin
 		return 0, fmt.Errorf("songkick location search failed: status %d", resp.StatusCode)
 	}
 
+	// Each match is a city with the metro area it belongs to; events are
+	// listed by metro area
 	var searchResp struct {
 		ResultsPage struct {
 			Results struct {
-				Location []songkickLocation `json:"location"`
+				Location []struct {
+					City      songkickLocation `json:"city"`
+					MetroArea songkickLocation `json:"metroArea"`
+				} `json:"location"`
 			} `json:"results"`
 		} `json:"resultsPage"`
 	}
@@ -395,7 +588,7 @@ func (c *SongkickClient) findLocationID(ctx context.Context, city, country strin
 	}
 
 	// Return the first match (best match)
-	return searchResp.ResultsPage.Results.Location[0].ID, nil
+	return searchResp.ResultsPage.Results.Location[0].MetroArea.ID, nil
 }
 
 func (c *SongkickClient) getMainPerformer(event songkickEvent) string {
@@ -416,7 +609,7 @@ func (c *SongkickClient) getMainPerformer(event songkickEvent) string {
 
 func (c *SongkickClient) convertToEvent(skEvent songkickEvent, mainArtist string) domain.Event {
 	// Parse event datetime
-	eventTime := c.parseEventDateTime(skEvent.Start)
+	eventTime, dateConfidence := c.parseEventDateTime(skEvent.Start)
 
 	// Convert venue
 	venue := domain.Venue{
@@ -425,29 +618,83 @@ func (c *SongkickClient) convertToEvent(skEvent songkickEvent, mainArtist string
 		Country:   skEvent.Venue.MetroArea.Country.DisplayName,
 		Latitude:  skEvent.Venue.Lat,
 		Longitude: skEvent.Venue.Lng,
+		Capacity:  skEvent.Venue.Capacity,
+		Phone:     skEvent.Venue.Phone,
+		Website:   skEvent.Venue.Website,
 	}
+	venue.NormalizeCountry()
 
 	// Set 24-hour cache
 	cacheUntil := time.Now().Add(24 * time.Hour)
 
 	return domain.Event{
-		ID:          fmt.Sprintf("songkick_%d", skEvent.ID),
-		ArtistID:    fmt.Sprintf("songkick_artist_%s", strings.ReplaceAll(strings.ToLower(mainArtist), " ", "_")),
-		ArtistName:  mainArtist,
-		DateTime:    eventTime,
-		Venue:       venue,
-		CachedUntil: cacheUntil,
+		ID:         fmt.Sprintf("songkick_%d", skEvent.ID),
+		ArtistID:   songkickArtistID(mainArtist),
+		ArtistName: mainArtist,
+		DateTime:   eventTime,
+		Venue:      venue,
+		Status:     songkickStatusOf(skEvent.Status),
+		Lineup:     c.lineup(skEvent, mainArtist),
+		ExternalIDs: domain.EventExternalIDs{
+			SongkickID: fmt.Sprintf("%d", skEvent.ID),
+		},
+		DateConfidence: dateConfidence,
+		AgeRestriction: skEvent.AgeRestriction,
+		CachedUntil:    cacheUntil,
 	}
 }
 
-func (c *SongkickClient) parseEventDateTime(start songkickEventDate) time.Time {
+// lineup lists the performances by billing index, headliners first
+func (c *SongkickClient) lineup(event songkickEvent, mainArtist string) []domain.EventArtist {
+	if len(event.Performance) == 0 {
+		return domain.HeadlinerLineup(songkickArtistID(mainArtist), mainArtist)
+	}
+
+	performances := make([]songkickPerformance, len(event.Performance))
+	copy(performances, event.Performance)
+	sort.SliceStable(performances, func(i, j int) bool {
+		// Performances without a billing index keep their place at the end
+		bi, bj := performances[i].BillingIndex, performances[j].BillingIndex
+		return bi > 0 && (bj == 0 || bi < bj)
+	})
+
+	lineup := make([]domain.EventArtist, 0, len(performances))
+	for i, perf := range performances {
+		lineup = append(lineup, domain.EventArtist{
+			ID:        songkickArtistID(perf.Artist.DisplayName),
+			Name:      perf.Artist.DisplayName,
+			Billing:   i + 1,
+			Headliner: perf.Billing == "headline" || perf.Artist.DisplayName == mainArtist,
+		})
+	}
+	return lineup
+}
+
+// songkickStatusOf maps an event's status, which is "ok" while it goes ahead
+func songkickStatusOf(status string) domain.EventStatus {
+	switch status {
+	case "cancelled":
+		return domain.EventCancelled
+	case "postponed":
+		return domain.EventPostponed
+	default:
+		return domain.EventScheduled
+	}
+}
+
+func songkickArtistID(name string) string {
+	return fmt.Sprintf("songkick_artist_%s", strings.ReplaceAll(strings.ToLower(name), " ", "_"))
+}
+
+// parseEventDateTime returns a zero time when Songkick gives no readable date
+func (c *SongkickClient) parseEventDateTime(start songkickEventDate) (time.Time, domain.DateConfidence) {
 	// Try to parse full datetime first
 	if start.DateTime != "" {
 		if t, err := time.Parse("2006-01-02T15:04:05-0700", start.DateTime); err == nil {
-			return t
+			return t, domain.DateExact
 		}
 		if t, err := time.Parse("2006-01-02T15:04:05Z", start.DateTime); err == nil {
-			return t
+			return t, domain.DateExact
 		}
 	}
 
@@ -455,55 +702,16 @@ func (c *SongkickClient) parseEventDateTime(start songkickEventDate) time.Time {
 	if start.Date != "" && start.Time != "" {
 		dateTimeStr := start.Date + "T" + start.Time
 		if t, err := time.Parse("2006-01-02T15:04:05", dateTimeStr); err == nil {
-			return t
+			return t, domain.DateExact
 		}
 	}
 
 	// Fallback to date only
 	if start.Date != "" {
 		if t, err := time.Parse("2006-01-02", start.Date); err == nil {
-			return t
+			return t, domain.DateOnly
 		}
 	}
 
-	// Ultimate fallback
-	return time.Now()
-}
-
-// Event rate limiter for APIs with daily limits
-type eventRateLimiter struct {
-	requests   []time.Time
-	limit      int
-	windowSize time.Duration
-}
-
-func newEventRateLimiter(dailyLimit int) *eventRateLimiter {
-	return &eventRateLimiter{
-		requests:   make([]time.Time, 0),
-		limit:      dailyLimit,
-		windowSize: 24 * time.Hour,
-	}
-}
-
-func (r *eventRateLimiter) Allow() error {
-	now := time.Now()
-
-	// Remove requests older than window
-	cutoff := now.Add(-r.windowSize)
-	validRequests := make([]time.Time, 0, len(r.requests))
-	for _, reqTime := range r.requests {
-		if reqTime.After(cutoff) {
-			validRequests = append(validRequests, reqTime)
-		}
-	}
-	r.requests = validRequests
-
-	// Check if we're under the limit
-	if len(r.requests) >= r.limit {
-		return domain.ErrRateLimitExceeded
-	}
-
-	// Add current request
-	r.requests = append(r.requests, now)
-	return nil
+	return time.Time{}, domain.DateUnknown
 }
@@ -5,36 +5,66 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+)
+
+const (
+	// ticketmasterMaxPageSize is the most events a page holds
+	ticketmasterMaxPageSize = 200
+	// ticketmasterMaxResults is how deep Ticketmaster pages: size * page
+	// may not pass 1000
+	ticketmasterMaxResults = 1000
+	// defaultTicketmasterMaxPages bounds the pages one search fetches
+	defaultTicketmasterMaxPages = 5
 )
 
 type TicketmasterClient struct {
 	baseURL     string
 	apiKey      string
 	httpClient  *http.Client
-	rateLimiter *eventRateLimiter
+	rateLimiter *ratelimit.Limiter
+	maxPages    int
 }
 
 type TicketmasterConfig struct {
 	APIKey string // Ticketmaster Discovery API key
+	// MaxPages bounds the pages a search follows _links.next through (5)
+	MaxPages int
 }
 
 func NewTicketmasterClient(config TicketmasterConfig) (*TicketmasterClient, error) {
 	if config.APIKey == "" {
 		return nil, fmt.Errorf("ticketmaster API key is required")
 	}
+	if config.MaxPages <= 0 {
+		config.MaxPages = defaultTicketmasterMaxPages
+	}
 
 	return &TicketmasterClient{
 		baseURL:     "https://app.ticketmaster.com/discovery/v2",
 		apiKey:      config.APIKey,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newEventRateLimiter(5000), // 5000 requests per day
+		httpClient:  httpclient.NewFor("ticketmaster", 10*time.Second),
+		rateLimiter: ratelimit.PerDay("ticketmaster", 5000), // 5000 requests per day
+		maxPages:    config.MaxPages,
 	}, nil
 }
 
+// UseQuotaStore restores and persists request counts so the quota window
+// survives restarts
+func (c *TicketmasterClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *TicketmasterClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
+}
+
 type ticketmasterEvent struct {
 	Name                  string                       `json:"name"`
 	Type                  string                       `json:"type"`
@@ -149,7 +179,8 @@ type ticketmasterSeatmap struct {
 }
 
 type ticketmasterAccessibility struct {
-	TicketLimit int `json:"ticketLimit"`
+	Info        string `json:"info"`
+	TicketLimit int    `json:"ticketLimit"`
 }
 
 type ticketmasterTicketLimit struct {
@@ -214,8 +245,9 @@ type ticketmasterMarket struct {
 	ID   string `json:"id"`
 }
 
+// ticketmasterDma is a designated market area, which the API numbers
 type ticketmasterDma struct {
-	ID string `json:"id"`
+	ID int `json:"id"`
 }
 
 type ticketmasterSocial struct {
@@ -297,35 +329,109 @@ type ticketmasterPage struct {
 }
 
 func (c *TicketmasterClient) SearchEventsByKeyword(ctx context.Context, keyword string, limit int) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
-		return nil, err
-	}
-
 	keyword = strings.TrimSpace(keyword)
 	if keyword == "" {
 		return nil, domain.ErrInvalidRequest
 	}
 
+	q := url.Values{}
+	q.Set("keyword", keyword)
+	q.Set("classificationName", "music") // Focus on music events
+	return c.searchPages(ctx, q, limit, "ticketmaster search")
+}
+
+func (c *TicketmasterClient) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+	return c.searchCity(ctx, city, country, time.Time{}, time.Time{}, limit)
+}
+
+// SearchEventsByLocationBetween only lists events starting between from and
+// to
+func (c *TicketmasterClient) SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) ([]domain.Event, error) {
+	return c.searchCity(ctx, city, country, from, to, limit)
+}
+
+// searchCity lists the city's music events, starting between from and to
+// when they're set
+func (c *TicketmasterClient) searchCity(ctx context.Context, city, country string, from, to time.Time, limit int) ([]domain.Event, error) {
+	city = strings.TrimSpace(city)
+	if city == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	q := url.Values{}
+	q.Set("city", city)
+	if country != "" {
+		// Ticketmaster only takes codes, and "Germany" as one finds nothing
+		if code := domain.CountryCode(country); code != "" {
+			country = code
+		}
+		q.Set("countryCode", strings.ToUpper(country))
+	}
+	q.Set("classificationName", "music")
+	// Ticketmaster wants UTC, without fractional seconds
+	if !from.IsZero() {
+		q.Set("startDateTime", from.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+	if !to.IsZero() {
+		q.Set("endDateTime", to.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+	return c.searchPages(ctx, q, limit, "ticketmaster location search")
+}
+
+// searchPages lists the events matching q, following _links.next until
+// limit events are found, the results run out, or the client's page budget
+// or a tenth of the daily quota is all that's left. A page after the first
+// failing ends the search with what was found.
+func (c *TicketmasterClient) searchPages(ctx context.Context, q url.Values, limit int, what string) ([]domain.Event, error) {
 	if limit <= 0 {
 		limit = 10
 	}
-	if limit > 200 {
-		limit = 200
+	if limit > ticketmasterMaxResults {
+		limit = ticketmasterMaxResults
 	}
+	q.Set("apikey", c.apiKey)
+	q.Set("size", fmt.Sprintf("%d", min(limit, ticketmasterMaxPageSize)))
+
+	pageURL := fmt.Sprintf("%s/events.json?%s", c.baseURL, q.Encode())
+	events := []domain.Event{}
+	for pages := 0; ; pages++ {
+		eventsResp, err := c.searchPage(ctx, pageURL, what)
+		if err != nil {
+			if pages == 0 || ctx.Err() != nil {
+				return nil, err
+			}
+			break
+		}
+		for _, tmEvent := range eventsResp.Embedded.Events {
+			events = append(events, c.convertToEvent(tmEvent))
+		}
 
-	eventsURL := fmt.Sprintf("%s/events.json", c.baseURL)
-	req, err := http.NewRequestWithContext(ctx, "GET", eventsURL, nil)
+		next := eventsResp.Links.Next.Href
+		if len(events) >= limit || next == "" || pages+1 >= c.maxPages || c.quotaLow() {
+			break
+		}
+		if pageURL, err = c.nextPageURL(next); err != nil {
+			break
+		}
+	}
+
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+// searchPage fetches one page of an event search
+func (c *TicketmasterClient) searchPage(ctx context.Context, pageURL, what string) (*ticketmasterEventsResponse, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	q := req.URL.Query()
-	q.Set("apikey", c.apiKey)
-	q.Set("keyword", keyword)
-	q.Set("size", fmt.Sprintf("%d", limit))
-	q.Set("classificationName", "music") // Focus on music events
-	req.URL.RawQuery = q.Encode()
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search events: %w", err)
@@ -336,120 +442,157 @@ func (c *TicketmasterClient) SearchEventsByKeyword(ctx context.Context, keyword
 		return nil, domain.ErrRateLimitExceeded
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ticketmaster search failed: status %d", resp.StatusCode)
+		return nil, fmt.Errorf("%s failed: status %d", what, resp.StatusCode)
 	}
 
 	var eventsResp ticketmasterEventsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&eventsResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	return &eventsResp, nil
+}
 
-	events := make([]domain.Event, 0, len(eventsResp.Embedded.Events))
-	for _, tmEvent := range eventsResp.Embedded.Events {
-		event := c.convertToEvent(tmEvent)
-		events = append(events, event)
+// nextPageURL resolves a _links.next href, which is relative to the API
+// host and leaves out the API key
+func (c *TicketmasterClient) nextPageURL(href string) (string, error) {
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	// Templated links end in a {&sort} placeholder
+	if i := strings.Index(href, "{"); i >= 0 {
+		href = href[:i]
+	}
+	next, err := base.Parse(href)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse next page link: %w", err)
 	}
+	q := next.Query()
+	q.Set("apikey", c.apiKey)
+	next.RawQuery = q.Encode()
+	return next.String(), nil
+}
 
-	return events, nil
+// quotaLow reports whether paging on would eat into the last tenth of the
+// daily quota, which is kept for first pages and lookups
+func (c *TicketmasterClient) quotaLow() bool {
+	quota := c.rateLimiter.Quota()
+	return quota.Remaining <= quota.Limit/10
 }
 
-func (c *TicketmasterClient) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+func (c *TicketmasterClient) GetEvent(ctx context.Context, ticketmasterID string) (*domain.Event, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
-	city = strings.TrimSpace(city)
-	if city == "" {
-		return nil, domain.ErrInvalidRequest
-	}
-
-	if limit <= 0 {
-		limit = 10
-	}
-	if limit > 200 {
-		limit = 200
-	}
-
-	eventsURL := fmt.Sprintf("%s/events.json", c.baseURL)
-	req, err := http.NewRequestWithContext(ctx, "GET", eventsURL, nil)
+	eventURL := fmt.Sprintf("%s/events/%s.json", c.baseURL, ticketmasterID)
+	req, err := http.NewRequestWithContext(ctx, "GET", eventURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	q := req.URL.Query()
 	q.Set("apikey", c.apiKey)
-	q.Set("city", city)
-	if country != "" {
-		q.Set("countryCode", strings.ToUpper(country))
-	}
-	q.Set("size", fmt.Sprintf("%d", limit))
-	q.Set("classificationName", "music")
 	req.URL.RawQuery = q.Encode()
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search events: %w", err)
+		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, domain.ErrEventNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ticketmaster location search failed: status %d", resp.StatusCode)
+		return nil, fmt.Errorf("ticketmaster get event failed: status %d", resp.StatusCode)
 	}
 
-	var eventsResp ticketmasterEventsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&eventsResp); err != nil {
+	var tmEvent ticketmasterEvent
+	if err := json.NewDecoder(resp.Body).Decode(&tmEvent); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	events := make([]domain.Event, 0, len(eventsResp.Embedded.Events))
-	for _, tmEvent := range eventsResp.Embedded.Events {
-		event := c.convertToEvent(tmEvent)
-		events = append(events, event)
-	}
+	event := c.convertToEvent(tmEvent)
+	return &event, nil
+}
 
-	return events, nil
+type ticketmasterAttractionsResponse struct {
+	Embedded struct {
+		Attractions []ticketmasterAttraction `json:"attractions"`
+	} `json:"_embedded"`
 }
 
-func (c *TicketmasterClient) GetEvent(ctx context.Context, ticketmasterID string) (*domain.Event, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+// ArtistAliases finds the attraction performing as name, under its own name
+// or one of its aliases, and returns it as an artist with those aliases
+func (c *TicketmasterClient) ArtistAliases(ctx context.Context, name string) (*domain.Artist, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
-	eventURL := fmt.Sprintf("%s/events/%s.json", c.baseURL, ticketmasterID)
-	req, err := http.NewRequestWithContext(ctx, "GET", eventURL, nil)
+	keyword := strings.TrimSpace(name)
+	if keyword == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	attractionsURL := fmt.Sprintf("%s/attractions.json", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", attractionsURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	q := req.URL.Query()
 	q.Set("apikey", c.apiKey)
+	q.Set("keyword", keyword)
+	q.Set("size", "5")
+	q.Set("classificationName", "music")
 	req.URL.RawQuery = q.Encode()
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get event: %w", err)
+		return nil, fmt.Errorf("failed to search attractions: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, domain.ErrEventNotFound
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, domain.ErrRateLimitExceeded
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("ticketmaster get event failed: status %d", resp.StatusCode)
+		return nil, fmt.Errorf("ticketmaster attraction search failed: status %d", resp.StatusCode)
 	}
 
-	var tmEvent ticketmasterEvent
-	if err := json.NewDecoder(resp.Body).Decode(&tmEvent); err != nil {
+	var attractionsResp ticketmasterAttractionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&attractionsResp); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	event := c.convertToEvent(tmEvent)
-	return &event, nil
+	for _, attraction := range attractionsResp.Embedded.Attractions {
+		artist := domain.Artist{
+			ID:   ticketmasterArtistID(attraction.Name),
+			Name: attraction.Name,
+		}
+		for _, alias := range attraction.Aliases {
+			if alias != "" && !artist.KnownAs(alias) {
+				artist.Aliases = append(artist.Aliases, alias)
+			}
+		}
+		if artist.KnownAs(name) {
+			return &artist, nil
+		}
+	}
+	return nil, domain.ErrArtistNotFound
 }
 
 func (c *TicketmasterClient) convertToEvent(tmEvent ticketmasterEvent) domain.Event {
+	// The event's timezone, or the venue's when the dates leave it out
+	timezone := tmEvent.Dates.Timezone
+	if timezone == "" && len(tmEvent.Embedded.Venues) > 0 {
+		timezone = tmEvent.Embedded.Venues[0].Timezone
+	}
+
 	// Parse event datetime
-	eventTime := c.parseEventDateTime(tmEvent.Dates.Start)
+	eventTime, dateConfidence := c.parseEventDateTime(tmEvent.Dates.Start, timezone)
+	eventTime = domain.InTimezone(eventTime, timezone)
 
 	// Get primary attraction (artist) name
 	artistName := tmEvent.Name
@@ -469,6 +612,10 @@ func (c *TicketmasterClient) convertToEvent(tmEvent ticketmasterEvent) domain.Ev
 		venue.Name = tmVenue.Name
 		venue.City = tmVenue.City.Name
 		venue.Country = tmVenue.Country.Name
+		venue.CountryCode = tmVenue.Country.CountryCode
+		venue.Address = joinNonEmpty(", ", tmVenue.Address.Line1, tmVenue.Address.Line2, tmVenue.PostalCode)
+		// The box office's number, sometimes with a few words around it
+		venue.Phone = strings.TrimSpace(tmVenue.BoxOffice.PhoneNumberDetail)
 
 		// Parse coordinates
 		if tmVenue.Location.Latitude != "" && tmVenue.Location.Longitude != "" {
@@ -476,46 +623,140 @@ func (c *TicketmasterClient) convertToEvent(tmEvent ticketmasterEvent) domain.Ev
 			fmt.Sscanf(tmVenue.Location.Longitude, "%f", &venue.Longitude)
 		}
 	}
+	venue.NormalizeCountry()
+
+	// The event's accessibility notes, or the venue's accessible seating
+	accessibility := strings.TrimSpace(tmEvent.Accessibility.Info)
+	if accessibility == "" && len(tmEvent.Embedded.Venues) > 0 {
+		accessibility = strings.TrimSpace(tmEvent.Embedded.Venues[0].AccessibleSeating.Info)
+	}
+
+	var ageRestriction string
+	if tmEvent.AgeRestrictions.LegalAgeEnforced {
+		ageRestriction = ticketmasterLegalAge
+	}
+
+	var priceRanges []domain.PriceRange
+	for _, pr := range tmEvent.PriceRanges {
+		priceRanges = append(priceRanges, domain.PriceRange{
+			Type:     pr.Type,
+			Min:      pr.Min,
+			Max:      pr.Max,
+			Currency: pr.Currency,
+		})
+	}
 
 	// Set 24-hour cache
 	cacheUntil := time.Now().Add(24 * time.Hour)
 
 	return domain.Event{
-		ID:          fmt.Sprintf("ticketmaster_%s", tmEvent.ID),
-		ArtistID:    fmt.Sprintf("ticketmaster_artist_%s", strings.ReplaceAll(strings.ToLower(artistName), " ", "_")),
-		ArtistName:  artistName,
-		DateTime:    eventTime,
-		Venue:       venue,
-		CachedUntil: cacheUntil,
+		ID:         fmt.Sprintf("ticketmaster_%s", tmEvent.ID),
+		ArtistID:   ticketmasterArtistID(artistName),
+		ArtistName: artistName,
+		DateTime:   eventTime,
+		Timezone:   timezone,
+		Venue:      venue,
+		Status:     ticketmasterStatusOf(tmEvent.Dates.Status.Code),
+		Lineup:     c.lineup(tmEvent, artistName),
+		ExternalIDs: domain.EventExternalIDs{
+			TicketmasterID: tmEvent.ID,
+		},
+		DateConfidence: dateConfidence,
+		PriceRanges:    priceRanges,
+		AgeRestriction: ageRestriction,
+		Accessibility:  accessibility,
+		CachedUntil:    cacheUntil,
+	}
+}
+
+// lineup lists the attractions in the order Ticketmaster bills them, which
+// puts the headliner first
+func (c *TicketmasterClient) lineup(tmEvent ticketmasterEvent, artistName string) []domain.EventArtist {
+	if len(tmEvent.Embedded.Attractions) == 0 {
+		return domain.HeadlinerLineup(ticketmasterArtistID(artistName), artistName)
+	}
+
+	lineup := make([]domain.EventArtist, 0, len(tmEvent.Embedded.Attractions))
+	for i, attraction := range tmEvent.Embedded.Attractions {
+		lineup = append(lineup, domain.EventArtist{
+			ID:        ticketmasterArtistID(attraction.Name),
+			Name:      attraction.Name,
+			Billing:   i + 1,
+			Headliner: i == 0,
+		})
+	}
+	return lineup
+}
+
+// ticketmasterLegalAge is the age restriction of events that enforce the
+// legal drinking age, which Ticketmaster flags without saying what it is
+const ticketmasterLegalAge = "Legal drinking age"
+
+// ticketmasterStatusOf maps dates.status.code; onsale and offsale are about
+// tickets, not whether the event goes ahead
+func ticketmasterStatusOf(code string) domain.EventStatus {
+	switch strings.ToLower(code) {
+	case "cancelled", "canceled":
+		return domain.EventCancelled
+	case "postponed":
+		return domain.EventPostponed
+	case "rescheduled":
+		return domain.EventRescheduled
+	default:
+		return domain.EventScheduled
+	}
+}
+
+func ticketmasterArtistID(name string) string {
+	return fmt.Sprintf("ticketmaster_artist_%s", strings.ReplaceAll(strings.ToLower(name), " ", "_"))
+}
+
+// joinNonEmpty joins the parts of an address that aren't blank
+func joinNonEmpty(sep string, parts ...string) string {
+	var kept []string
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			kept = append(kept, part)
+		}
 	}
+	return strings.Join(kept, sep)
 }
 
-func (c *TicketmasterClient) parseEventDateTime(start ticketmasterEventDate) time.Time {
+// parseEventDateTime prefers the UTC dateTime; localDate and localTime are
+// read on the venue's clock. Dates still to be announced, or that can't be
+// read, come back zero.
+func (c *TicketmasterClient) parseEventDateTime(start ticketmasterEventDate, timezone string) (time.Time, domain.DateConfidence) {
+	if start.DateTBD || start.DateTBA {
+		return time.Time{}, domain.DateUnknown
+	}
+
+	// Without a start time, dateTime is a placeholder
+	timeKnown := !start.TimeTBA && !start.NoSpecificTime
+
 	// Try to parse full datetime first
-	if start.DateTime != "" {
+	if start.DateTime != "" && timeKnown {
 		if t, err := time.Parse("2006-01-02T15:04:05Z", start.DateTime); err == nil {
-			return t
+			return t, domain.DateExact
 		}
 		if t, err := time.Parse("2006-01-02T15:04:05-0700", start.DateTime); err == nil {
-			return t
+			return t, domain.DateExact
 		}
 	}
 
 	// Try date + time combination
-	if start.LocalDate != "" && start.LocalTime != "" {
+	if start.LocalDate != "" && start.LocalTime != "" && timeKnown {
 		dateTimeStr := start.LocalDate + "T" + start.LocalTime
-		if t, err := time.Parse("2006-01-02T15:04:05", dateTimeStr); err == nil {
-			return t
+		if t, err := domain.ParseLocalDateTime("2006-01-02T15:04:05", dateTimeStr, timezone); err == nil {
+			return t, domain.DateExact
 		}
 	}
 
 	// Fallback to date only
 	if start.LocalDate != "" {
-		if t, err := time.Parse("2006-01-02", start.LocalDate); err == nil {
-			return t
+		if t, err := domain.ParseLocalDateTime("2006-01-02", start.LocalDate, timezone); err == nil {
+			return t, domain.DateOnly
 		}
 	}
 
-	// Ultimate fallback
-	return time.Now()
+	return time.Time{}, domain.DateUnknown
 }
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/ratelimit"
+)
+
+// ticketmasterPagingServer answers event searches with two events a page,
+// linking each page to the next until lastPage
+type ticketmasterPagingServer struct {
+	*httptest.Server
+	lastPage int
+
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func newTicketmasterPagingServer(t *testing.T, lastPage int) *ticketmasterPagingServer {
+	s := &ticketmasterPagingServer{lastPage: lastPage}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		s.mu.Unlock()
+
+		if r.URL.Path != "/discovery/v2/events.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+
+		next := ""
+		if page < s.lastPage {
+			next = fmt.Sprintf(`,"next":{"href":"/discovery/v2/events.json?city=Berlin&page=%d&size=2{&sort}","templated":true}`, page+1)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"_embedded":{"events":[{"id":"p%[1]d-a","name":"Show A"},{"id":"p%[1]d-b","name":"Show B"}]},"_links":{"self":{"href":"/discovery/v2/events.json"}%[2]s},"page":{"size":2,"number":%[1]d}}`, page, next)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *ticketmasterPagingServer) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func newPagingTicketmasterClient(t *testing.T, server *ticketmasterPagingServer, maxPages int) *TicketmasterClient {
+	client, err := NewTicketmasterClient(TicketmasterConfig{APIKey: "tm-key", MaxPages: maxPages})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client.baseURL = server.URL + "/discovery/v2"
+	client.httpClient = server.Client()
+	return client
+}
+
+func TestTicketmasterClient_SearchPages(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stops when _links.next is missing", func(t *testing.T) {
+		server := newTicketmasterPagingServer(t, 2)
+		client := newPagingTicketmasterClient(t, server, 10)
+
+		events, err := client.SearchEventsByLocation(ctx, "Berlin", "", 100)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(events) != 6 {
+			t.Errorf("expected 6 events from 3 pages, got %d", len(events))
+		}
+		if got := len(server.Requests()); got != 3 {
+			t.Errorf("expected 3 requests, got %d", got)
+		}
+		for _, r := range server.Requests() {
+			if r.URL.Query().Get("apikey") != "tm-key" {
+				t.Errorf("expected every page to carry the API key, got %q", r.URL.RawQuery)
+			}
+		}
+	})
+
+	t.Run("stops at the page budget", func(t *testing.T) {
+		server := newTicketmasterPagingServer(t, 50)
+		client := newPagingTicketmasterClient(t, server, 2)
+
+		events, err := client.SearchEventsByLocation(ctx, "Berlin", "", 100)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(events) != 4 {
+			t.Errorf("expected 4 events from 2 pages, got %d", len(events))
+		}
+		if got := len(server.Requests()); got != 2 {
+			t.Errorf("expected 2 requests, got %d", got)
+		}
+	})
+
+	t.Run("stops with a tenth of the quota left", func(t *testing.T) {
+		server := newTicketmasterPagingServer(t, 50)
+		client := newPagingTicketmasterClient(t, server, 10)
+		client.rateLimiter = ratelimit.New("ticketmaster", 20, time.Hour)
+		for i := 0; i < 16; i++ {
+			if err := client.rateLimiter.Allow(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		events, err := client.SearchEventsByLocation(ctx, "Berlin", "", 100)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		// 4 left before the search: the first page leaves 3, the second 2,
+		// which is the tenth kept back
+		if got := len(server.Requests()); got != 2 {
+			t.Errorf("expected 2 requests, got %d", got)
+		}
+		if len(events) != 4 {
+			t.Errorf("expected 4 events, got %d", len(events))
+		}
+		if remaining := client.Quota().Remaining; remaining != 2 {
+			t.Errorf("expected 2 requests left, got %d", remaining)
+		}
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		server := newTicketmasterPagingServer(t, 50)
+		client := newPagingTicketmasterClient(t, server, 10)
+
+		events, err := client.SearchEventsByLocation(ctx, "Berlin", "", 3)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(events) != 3 {
+			t.Errorf("expected 3 events, got %d", len(events))
+		}
+		if got := len(server.Requests()); got != 2 {
+			t.Errorf("expected 2 requests, got %d", got)
+		}
+	})
+}
+
+func TestTicketmasterClient_NextPageURL(t *testing.T) {
+	client, err := NewTicketmasterClient(TicketmasterConfig{APIKey: "tm-key"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	next, err := client.nextPageURL("/discovery/v2/events.json?city=Berlin&page=1&size=20{&sort}")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := "https://app.ticketmaster.com/discovery/v2/events.json?apikey=tm-key&city=Berlin&page=1&size=20"
+	if next != want {
+		t.Errorf("expected %s, got %s", want, next)
+	}
+}
+
+func TestTicketmasterClient_QuotaLow(t *testing.T) {
+	client, err := NewTicketmasterClient(TicketmasterConfig{APIKey: "tm-key"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client.rateLimiter = ratelimit.New("ticketmaster", 10, time.Hour)
+
+	for used := 0; used < 10; used++ {
+		// A tenth of 10 is 1 request kept back
+		if low := client.quotaLow(); low != (used >= 9) {
+			t.Errorf("with %d used expected quotaLow %v, got %v", used, used >= 9, low)
+		}
+		if err := client.rateLimiter.Allow(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Package fixtures is a music and event source serving a fixed set of
+// artists and events from embedded JSON. It stands in for the real sources
+// in development, so the whole API can be run without any API keys.
+package fixtures
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// Name is the source name fixtures are registered and reported under
+const Name = "fixtures"
+
+//go:embed data/*.json
+var data embed.FS
+
+// fixtureEvent is an event with its date relative to today, so the fixtures
+// stay upcoming. Without a start time the event is on that day with no
+// time announced.
+type fixtureEvent struct {
+	ID           string              `json:"id"`
+	Artist       string              `json:"artist"`
+	Support      []string            `json:"support"`
+	DaysFromNow  int                 `json:"days_from_now"`
+	StartTime    string              `json:"start_time"`
+	Timezone     string              `json:"timezone"`
+	Venue        domain.Venue        `json:"venue"`
+	TicketURL    string              `json:"ticket_url"`
+	TicketStatus string              `json:"ticket_status"`
+	Status       domain.EventStatus  `json:"status"`
+	PriceRanges  []domain.PriceRange `json:"price_ranges"`
+}
+
+type Client struct {
+	artists []domain.Artist
+	events  []fixtureEvent
+	now     func() time.Time
+}
+
+func NewClient() (*Client, error) {
+	c := &Client{now: time.Now}
+	if err := readFixture("data/artists.json", &c.artists); err != nil {
+		return nil, err
+	}
+	if err := readFixture("data/events.json", &c.events); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func readFixture(path string, target interface{}) error {
+	raw, err := data.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(raw, target); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Client) GetName() string {
+	return Name
+}
+
+// SearchArtists returns the artists whose names contain query, most popular
+// first
+func (c *Client) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	artists := []domain.Artist{}
+	for _, artist := range c.artists {
+		if strings.Contains(strings.ToLower(artist.Name), query) {
+			artists = append(artists, artist)
+		}
+	}
+	sort.SliceStable(artists, func(i, j int) bool {
+		return artists[i].Popularity > artists[j].Popularity
+	})
+
+	return truncate(artists, limit), nil
+}
+
+// SearchEventsByArtist returns the events the artist headlines or supports
+func (c *Client) SearchEventsByArtist(ctx context.Context, artistName string, limit int) ([]domain.Event, error) {
+	return c.matching(limit, func(event fixtureEvent) bool {
+		if strings.EqualFold(event.Artist, artistName) {
+			return true
+		}
+		for _, support := range event.Support {
+			if strings.EqualFold(support, artistName) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// SearchEventsByLocation returns the events in city, and in country when
+// one is given
+func (c *Client) SearchEventsByLocation(ctx context.Context, city, country string, limit int) ([]domain.Event, error) {
+	return c.matching(limit, func(event fixtureEvent) bool {
+		return domain.SameCity(event.Venue.City, city) &&
+			(country == "" || domain.SameCountry(event.Venue.Country, country))
+	}), nil
+}
+
+// SearchEventsByLocationBetween returns the events in city starting between
+// from and to
+func (c *Client) SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) ([]domain.Event, error) {
+	events, _ := c.SearchEventsByLocation(ctx, city, country, 0)
+	between := []domain.Event{}
+	for _, event := range events {
+		if !event.DateTime.Before(from) && !event.DateTime.After(to) {
+			between = append(between, event)
+		}
+	}
+	return truncate(between, limit), nil
+}
+
+// matching converts the events match accepts, soonest first
+func (c *Client) matching(limit int, match func(fixtureEvent) bool) []domain.Event {
+	now := c.now()
+
+	events := []domain.Event{}
+	for _, fixture := range c.events {
+		if match(fixture) {
+			events = append(events, c.toDomain(fixture, now))
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+
+	return truncate(events, limit)
+}
+
+func (c *Client) toDomain(fixture fixtureEvent, now time.Time) domain.Event {
+	localNow := domain.InTimezone(now, fixture.Timezone)
+	day := localNow.AddDate(0, 0, fixture.DaysFromNow).Format("2006-01-02")
+
+	dateTime, _ := domain.ParseLocalDateTime("2006-01-02", day, fixture.Timezone)
+	confidence := domain.DateOnly
+	if fixture.StartTime != "" {
+		if start, err := domain.ParseLocalDateTime("2006-01-02 15:04", day+" "+fixture.StartTime, fixture.Timezone); err == nil {
+			dateTime, confidence = start, domain.DateExact
+		}
+	}
+
+	status := fixture.Status
+	if status == "" {
+		status = domain.EventScheduled
+	}
+
+	lineup := []domain.EventArtist{{ID: artistID(fixture.Artist), Name: fixture.Artist, Billing: 1, Headliner: true}}
+	for i, support := range fixture.Support {
+		lineup = append(lineup, domain.EventArtist{ID: artistID(support), Name: support, Billing: i + 2})
+	}
+
+	venue := fixture.Venue
+	venue.NormalizeCountry()
+
+	return domain.Event{
+		ID:             fixture.ID,
+		ArtistID:       artistID(fixture.Artist),
+		ArtistName:     fixture.Artist,
+		Title:          fixture.Artist + " at " + fixture.Venue.Name,
+		DateTime:       dateTime.UTC(),
+		Timezone:       fixture.Timezone,
+		Venue:          venue,
+		TicketURL:      fixture.TicketURL,
+		TicketStatus:   fixture.TicketStatus,
+		DateConfidence: confidence,
+		Status:         status,
+		PriceRanges:    fixture.PriceRanges,
+		Lineup:         lineup,
+		CachedUntil:    now.Add(24 * time.Hour),
+	}
+}
+
+func artistID(name string) string {
+	return "fixtures_" + strings.ReplaceAll(strings.ToLower(name), " ", "_")
+}
+
+func truncate[T any](items []T, limit int) []T {
+	if limit > 0 && len(items) > limit {
+		return items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,120 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ArtistAlbums returns the artist's albums on Deezer
+func (c *DeezerClient) ArtistAlbums(ctx context.Context, artist domain.Artist, limit int) ([]domain.Album, error) {
+	deezerID, err := sourceArtistID(ctx, artist, "deezer_", c.SearchArtists)
+	if err != nil || deezerID == "" {
+		return nil, err
+	}
+
+	dzAlbums, err := c.GetArtistAlbums(ctx, deezerID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	albums := make([]domain.Album, 0, len(dzAlbums))
+	for _, dzAlbum := range dzAlbums {
+		albums = append(albums, domain.Album{
+			ID:          fmt.Sprintf("deezer_%d", dzAlbum.ID),
+			Title:       dzAlbum.Title,
+			ReleaseDate: dzAlbum.ReleaseDate,
+			TrackCount:  dzAlbum.TrackCount,
+			Genres:      dzAlbum.Genres,
+			ImageURL:    dzAlbum.CoverURL,
+			Label:       dzAlbum.Label,
+			Source:      "deezer",
+		})
+	}
+	return albums, nil
+}
+
+// NewReleases returns the artist's albums on Deezer released on or after
+// since's day, newest first
+func (c *DeezerClient) NewReleases(ctx context.Context, artist domain.Artist, since time.Time) ([]domain.Album, error) {
+	albums, err := c.ArtistAlbums(ctx, artist, 25)
+	if err != nil {
+		return nil, err
+	}
+
+	from := since.Format(time.DateOnly)
+	releases := []domain.Album{}
+	for _, album := range albums {
+		// Dates are YYYY-MM-DD, so they compare as strings
+		if album.ReleaseDate == "" || album.ReleaseDate < from {
+			continue
+		}
+		album.ArtistName = artist.Name
+		releases = append(releases, album)
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].ReleaseDate > releases[j].ReleaseDate
+	})
+	return releases, nil
+}
+
+// ArtistAlbums returns the artist's albums in the storefront's Apple Music catalog
+func (c *AppleMusicClient) ArtistAlbums(ctx context.Context, artist domain.Artist, limit int) ([]domain.Album, error) {
+	appleMusicID, err := sourceArtistID(ctx, artist, "apple_", c.SearchArtists)
+	if err != nil || appleMusicID == "" {
+		return nil, err
+	}
+
+	amAlbums, err := c.GetArtistAlbums(ctx, appleMusicID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	albums := make([]domain.Album, 0, len(amAlbums))
+	for _, amAlbum := range amAlbums {
+		albums = append(albums, domain.Album{
+			ID:          "apple_" + amAlbum.ID,
+			Title:       amAlbum.Name,
+			ReleaseDate: amAlbum.ReleaseDate,
+			TrackCount:  amAlbum.TrackCount,
+			Genres:      amAlbum.GenreNames,
+			ImageURL:    amAlbum.ArtworkURL,
+			Source:      "apple_music",
+		})
+	}
+	return albums, nil
+}
+
+// ArtistReleases returns the artist's releases on MusicBrainz, found by the
+// stored MBID when there is one
+func (c *MusicBrainzClient) ArtistReleases(ctx context.Context, artist domain.Artist, limit int) ([]domain.Release, error) {
+	musicBrainzID := artist.ExternalIDs.MusicBrainzID
+	if musicBrainzID == "" {
+		var err error
+		musicBrainzID, err = sourceArtistID(ctx, artist, "musicbrainz_", c.SearchArtists)
+		if err != nil || musicBrainzID == "" {
+			return nil, err
+		}
+	}
+
+	mbReleases, err := c.GetArtistReleases(ctx, musicBrainzID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]domain.Release, 0, len(mbReleases))
+	for _, mbRelease := range mbReleases {
+		releases = append(releases, domain.Release{
+			ID:      mbRelease.ID,
+			Title:   mbRelease.Title,
+			Date:    mbRelease.Date,
+			Country: mbRelease.Country,
+			Status:  mbRelease.Status,
+			Source:  "musicbrainz",
+		})
+	}
+	return releases, nil
+}
@@ -2,39 +2,194 @@ package music
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+)
+
+const (
+	// defaultStorefront is the catalog searched unless one is configured
+	defaultStorefront = "us"
+	// appleMusicTokenTTL is how long a minted developer token is signed
+	// for; Apple takes up to six months
+	appleMusicTokenTTL = 12 * time.Hour
 )
 
 type AppleMusicClient struct {
 	baseURL     string
-	token       string
 	httpClient  *http.Client
-	rateLimiter *rateLimiter
+	rateLimiter *ratelimit.Limiter
+	storefront  string
+
+	// Developer tokens are minted from the key when there is one
+	teamID string
+	keyID  string
+	key    *ecdsa.PrivateKey
+	now    func() time.Time
+
+	tokenMu      sync.Mutex
+	token        string
+	tokenExpires time.Time
 }
 
 type AppleMusicConfig struct {
-	Token string // Apple Music API requires JWT token
+	Token string // A developer token signed elsewhere
+	// TeamID, KeyID and PrivateKey, the PEM of the MusicKit .p8 key, mint
+	// developer tokens when no Token is given
+	TeamID     string
+	KeyID      string
+	PrivateKey string
+	// Storefront is the country whose catalog is searched, as a code or
+	// name ("de", "Germany"); "us" by default. WithStorefront overrides it
+	// per request.
+	Storefront string
 }
 
 func NewAppleMusicClient(config AppleMusicConfig) (*AppleMusicClient, error) {
+	var key *ecdsa.PrivateKey
 	if config.Token == "" {
-		return nil, fmt.Errorf("apple music token is required")
+		if config.TeamID == "" || config.KeyID == "" || config.PrivateKey == "" {
+			return nil, fmt.Errorf("apple music token, or team ID, key ID and private key, are required")
+		}
+		var err error
+		if key, err = parseAppleMusicKey(config.PrivateKey); err != nil {
+			return nil, err
+		}
+	}
+	storefront := defaultStorefront
+	if config.Storefront != "" {
+		if storefront = NormalizeStorefront(config.Storefront); storefront == "" {
+			return nil, fmt.Errorf("unknown apple music storefront %q", config.Storefront)
+		}
 	}
 
 	return &AppleMusicClient{
 		baseURL:     "https://api.music.apple.com/v1",
+		httpClient:  httpclient.NewFor("apple_music", 10*time.Second),
+		rateLimiter: ratelimit.PerHour("apple_music", 20000), // 20k requests per hour
+		storefront:  storefront,
+		teamID:      config.TeamID,
+		keyID:       config.KeyID,
+		key:         key,
+		now:         time.Now,
 		token:       config.Token,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newRateLimiter(20000), // 20k requests per hour
 	}, nil
 }
 
+// parseAppleMusicKey reads a .p8 key's PEM. Keys set through the
+// environment often have their newlines escaped.
+func parseAppleMusicKey(privateKey string) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(strings.ReplaceAll(privateKey, `\n`, "\n")))
+	if block == nil {
+		return nil, fmt.Errorf("apple music private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse apple music private key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("apple music private key is not an EC key")
+	}
+	return key, nil
+}
+
+func (c *AppleMusicClient) GetName() string {
+	return "apple_music"
+}
+
+// developerToken returns the token requests are signed with, minting a new
+// one shortly before the last expires
+func (c *AppleMusicClient) developerToken() (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if c.key == nil {
+		return c.token, nil
+	}
+	now := c.now()
+	if c.token != "" && now.Add(time.Minute).Before(c.tokenExpires) {
+		return c.token, nil
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.RegisteredClaims{
+		Issuer:    c.teamID,
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(appleMusicTokenTTL)),
+	})
+	token.Header["kid"] = c.keyID
+	signed, err := token.SignedString(c.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign apple music token: %w", err)
+	}
+	c.token, c.tokenExpires = signed, now.Add(appleMusicTokenTTL)
+	return signed, nil
+}
+
+// authorize signs req with the developer token
+func (c *AppleMusicClient) authorize(req *http.Request) error {
+	token, err := c.developerToken()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+type storefrontKey struct{}
+
+// WithStorefront returns a context whose Apple Music lookups use the
+// catalog of the country given, as a code or name. Countries that aren't
+// recognized leave the client's own storefront.
+func WithStorefront(ctx context.Context, country string) context.Context {
+	storefront := NormalizeStorefront(country)
+	if storefront == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, storefrontKey{}, storefront)
+}
+
+// StorefrontFrom returns the storefront set on ctx, or ""
+func StorefrontFrom(ctx context.Context) string {
+	storefront, _ := ctx.Value(storefrontKey{}).(string)
+	return storefront
+}
+
+// NormalizeStorefront returns the storefront for a country given in any
+// form, its lowercase ISO code, or "" when it isn't recognized
+func NormalizeStorefront(country string) string {
+	return strings.ToLower(domain.CountryCode(country))
+}
+
+// storefrontFor is the catalog ctx's lookups use
+func (c *AppleMusicClient) storefrontFor(ctx context.Context) string {
+	if storefront := StorefrontFrom(ctx); storefront != "" {
+		return storefront
+	}
+	return c.storefront
+}
+
+// UseQuotaStore restores and persists request counts so the quota window
+// survives restarts
+func (c *AppleMusicClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *AppleMusicClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
+}
+
 type appleMusicArtist struct {
 	ID         string                     `json:"id"`
 	Type       string                     `json:"type"`
@@ -57,7 +212,7 @@ type appleMusicSearchResponse struct {
 }
 
 func (c *AppleMusicClient) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -73,7 +228,7 @@ func (c *AppleMusicClient) SearchArtists(ctx context.Context, query string, limi
 		limit = 25
 	}
 
-	searchURL := fmt.Sprintf("%s/catalog/us/search", c.baseURL)
+	searchURL := fmt.Sprintf("%s/catalog/%s/search", c.baseURL, c.storefrontFor(ctx))
 	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -85,8 +240,9 @@ func (c *AppleMusicClient) SearchArtists(ctx context.Context, query string, limi
 	q.Set("limit", fmt.Sprintf("%d", limit))
 	req.URL.RawQuery = q.Encode()
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
-	req.Header.Set("Music-User-Token", c.token) // For personalized results
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -111,7 +267,7 @@ func (c *AppleMusicClient) SearchArtists(ctx context.Context, query string, limi
 		artist := domain.Artist{
 			ID:          fmt.Sprintf("apple_%s", amArtist.ID),
 			Name:        amArtist.Attributes.Name,
-			Genres:      amArtist.Attributes.GenreNames,
+			Genres:      domain.NormalizeGenres(amArtist.Attributes.GenreNames),
 			ImageURL:    c.processArtworkURL(amArtist.Attributes.ArtworkURL),
 			ExternalIDs: domain.ExternalIDs{
 				// Apple Music doesn't provide Spotify/LastFM IDs
@@ -125,17 +281,19 @@ func (c *AppleMusicClient) SearchArtists(ctx context.Context, query string, limi
 }
 
 func (c *AppleMusicClient) GetArtist(ctx context.Context, appleMusicID string) (*domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
-	artistURL := fmt.Sprintf("%s/catalog/us/artists/%s", c.baseURL, appleMusicID)
+	artistURL := fmt.Sprintf("%s/catalog/%s/artists/%s", c.baseURL, c.storefrontFor(ctx), appleMusicID)
 	req, err := http.NewRequestWithContext(ctx, "GET", artistURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -166,7 +324,7 @@ func (c *AppleMusicClient) GetArtist(ctx context.Context, appleMusicID string) (
 	artist := &domain.Artist{
 		ID:       fmt.Sprintf("apple_%s", amArtist.ID),
 		Name:     amArtist.Attributes.Name,
-		Genres:   amArtist.Attributes.GenreNames,
+		Genres:   domain.NormalizeGenres(amArtist.Attributes.GenreNames),
 		ImageURL: c.processArtworkURL(amArtist.Attributes.ArtworkURL),
 	}
 
@@ -174,7 +332,7 @@ func (c *AppleMusicClient) GetArtist(ctx context.Context, appleMusicID string) (
 }
 
 func (c *AppleMusicClient) GetArtistAlbums(ctx context.Context, appleMusicID string, limit int) ([]AppleMusicAlbum, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -185,7 +343,7 @@ func (c *AppleMusicClient) GetArtistAlbums(ctx context.Context, appleMusicID str
 		limit = 100
 	}
 
-	albumsURL := fmt.Sprintf("%s/catalog/us/artists/%s/albums", c.baseURL, appleMusicID)
+	albumsURL := fmt.Sprintf("%s/catalog/%s/artists/%s/albums", c.baseURL, c.storefrontFor(ctx), appleMusicID)
 	req, err := http.NewRequestWithContext(ctx, "GET", albumsURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
@@ -195,7 +353,9 @@ func (c *AppleMusicClient) GetArtistAlbums(ctx context.Context, appleMusicID str
 	q.Set("limit", fmt.Sprintf("%d", limit))
 	req.URL.RawQuery = q.Encode()
 
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -271,41 +431,3 @@ func (c *AppleMusicClient) processArtworkURL(artworkURL string) string {
 	processed = strings.Replace(processed, "{h}", "512", -1)
 	return processed
 }
-
-// Rate limiter implementation for Apple Music (20k requests per hour)
-type rateLimiter struct {
-	requests   []time.Time
-	limit      int
-	windowSize time.Duration
-}
-
-func newRateLimiter(hourlyLimit int) *rateLimiter {
-	return &rateLimiter{
-		requests:   make([]time.Time, 0),
-		limit:      hourlyLimit,
-		windowSize: time.Hour,
-	}
-}
-
-func (r *rateLimiter) Allow() error {
-	now := time.Now()
-
-	// Remove requests older than window
-	cutoff := now.Add(-r.windowSize)
-	validRequests := make([]time.Time, 0, len(r.requests))
-	for _, reqTime := range r.requests {
-		if reqTime.After(cutoff) {
-			validRequests = append(validRequests, reqTime)
-		}
-	}
-	r.requests = validRequests
-
-	// Check if we're under the limit
-	if len(r.requests) >= r.limit {
-		return domain.ErrRateLimitExceeded
-	}
-
-	// Add current request
-	r.requests = append(r.requests, now)
-	return nil
-}
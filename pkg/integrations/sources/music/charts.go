@@ -0,0 +1,140 @@
+package music
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// deezerChartPlaylists are the "Top <country>" playlists Deezer's editors
+// keep, by country code. The chart endpoint itself only knows the region
+// the request comes from.
+var deezerChartPlaylists = map[string]string{
+	"BR": "1111141961",
+	"DE": "1111143121",
+	"FR": "1109890291",
+	"GB": "1111142221",
+	"US": "1313621735",
+}
+
+// chartPlaylist is the playlist charting country, from config before the
+// built-in ones, or "" when there is none
+func (c *DeezerClient) chartPlaylist(country string) string {
+	if id, ok := c.chartPlaylists[country]; ok {
+		return id
+	}
+	return deezerChartPlaylists[country]
+}
+
+// Chart returns Deezer's most played tracks in country, given as a code or
+// name, or worldwide when country is empty. Countries without a chart
+// playlist return domain.ErrChartNotFound.
+func (c *DeezerClient) Chart(ctx context.Context, country string, limit int) (*domain.Chart, error) {
+	if limit <= 0 {
+		limit = 25
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	chart := &domain.Chart{Source: "deezer"}
+	if strings.TrimSpace(country) == "" {
+		var response struct {
+			Tracks struct {
+				Data []deezerTrack `json:"data"`
+			} `json:"tracks"`
+			Artists struct {
+				Data []deezerArtist `json:"data"`
+			} `json:"artists"`
+		}
+		if err := c.getChart(ctx, fmt.Sprintf("%s/chart/0", c.baseURL), limit, &response); err != nil {
+			return nil, err
+		}
+
+		chart.Tracks = c.chartTracks(response.Tracks.Data)
+		chart.Artists = make([]domain.Artist, 0, len(response.Artists.Data))
+		for _, dzArtist := range response.Artists.Data {
+			chart.Artists = append(chart.Artists, c.convertToArtist(dzArtist, nil))
+		}
+		return chart, nil
+	}
+
+	chart.Country = domain.CountryCode(country)
+	playlistID := c.chartPlaylist(chart.Country)
+	if playlistID == "" {
+		return nil, domain.ErrChartNotFound
+	}
+
+	var response struct {
+		Data []deezerTrack `json:"data"`
+	}
+	if err := c.getChart(ctx, fmt.Sprintf("%s/playlist/%s/tracks", c.baseURL, playlistID), limit, &response); err != nil {
+		return nil, err
+	}
+
+	// Playlists list tracks only; the artists chart in their first track's
+	// place
+	chart.Tracks = c.chartTracks(response.Data)
+	chart.Artists = []domain.Artist{}
+	seen := make(map[int64]bool)
+	for _, dzTrack := range response.Data {
+		if dzTrack.Artist.ID == 0 || seen[dzTrack.Artist.ID] {
+			continue
+		}
+		seen[dzTrack.Artist.ID] = true
+		chart.Artists = append(chart.Artists, c.convertToArtist(dzTrack.Artist, nil))
+	}
+	return chart, nil
+}
+
+func (c *DeezerClient) getChart(ctx context.Context, chartURL string, limit int, response any) error {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", chartURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Set("limit", fmt.Sprintf("%d", limit))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get chart: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("deezer get chart failed: status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (c *DeezerClient) chartTracks(dzTracks []deezerTrack) []domain.Track {
+	tracks := make([]domain.Track, 0, len(dzTracks))
+	for _, dzTrack := range dzTracks {
+		tracks = append(tracks, domain.Track{
+			ID:         fmt.Sprintf("deezer_%d", dzTrack.ID),
+			Title:      dzTrack.Title,
+			ArtistName: dzTrack.Artist.Name,
+			Album:      dzTrack.Album.Title,
+			DurationMS: dzTrack.Duration * 1000,
+			PreviewURL: dzTrack.Preview,
+			URL:        dzTrack.Link,
+			ImageURL:   dzTrack.Album.Cover,
+			Source:     "deezer",
+		})
+	}
+	return tracks
+}
@@ -9,24 +9,52 @@ import (
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+	"github.com/yair/where-its-at/pkg/scoring"
 )
 
 type DeezerClient struct {
 	baseURL     string
 	httpClient  *http.Client
-	rateLimiter *rateLimiter
+	rateLimiter *ratelimit.Limiter
+	// chartPlaylists add to and override the built-in chart playlists
+	chartPlaylists map[string]string
 }
 
 type DeezerConfig struct {
 	// Deezer API is free and doesn't require API key for basic search
+
+	// ChartPlaylists maps country codes to the playlists Chart reads their
+	// chart from, for countries missing from the built-in ones
+	ChartPlaylists map[string]string
 }
 
 func NewDeezerClient(config DeezerConfig) (*DeezerClient, error) {
-	return &DeezerClient{
+	client := &DeezerClient{
 		baseURL:     "https://api.deezer.com",
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newRateLimiter(50000), // Generous rate limit - Deezer is quite permissive
-	}, nil
+		httpClient:  httpclient.NewFor("deezer", 10*time.Second),
+		rateLimiter: ratelimit.PerHour("deezer", 50000), // Generous rate limit - Deezer is quite permissive
+	}
+	client.chartPlaylists = make(map[string]string, len(config.ChartPlaylists))
+	for country, playlistID := range config.ChartPlaylists {
+		code := domain.CountryCode(country)
+		if code == "" {
+			return nil, fmt.Errorf("unknown deezer chart country %q", country)
+		}
+		client.chartPlaylists[code] = playlistID
+	}
+	return client, nil
+}
+
+// UseQuotaStore restores and persists request counts so the quota window
+// survives restarts
+func (c *DeezerClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *DeezerClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
 }
 
 type deezerArtist struct {
@@ -83,7 +111,7 @@ type deezerGenre struct {
 }
 
 func (c *DeezerClient) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -138,7 +166,7 @@ func (c *DeezerClient) SearchArtists(ctx context.Context, query string, limit in
 }
 
 func (c *DeezerClient) GetArtist(ctx context.Context, deezerID string) (*domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -178,7 +206,7 @@ func (c *DeezerClient) GetArtist(ctx context.Context, deezerID string) (*domain.
 }
 
 func (c *DeezerClient) GetArtistAlbums(ctx context.Context, deezerID string, limit int) ([]DeezerAlbum, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -242,7 +270,7 @@ func (c *DeezerClient) GetArtistAlbums(ctx context.Context, deezerID string, lim
 }
 
 func (c *DeezerClient) GetArtistTopTracks(ctx context.Context, deezerID string, limit int) ([]DeezerTrack, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -290,8 +318,10 @@ func (c *DeezerClient) GetArtistTopTracks(ctx context.Context, deezerID string,
 			Rank:           dzTrack.Rank,
 			ExplicitLyrics: dzTrack.ExplicitLyrics,
 			Preview:        dzTrack.Preview,
+			Link:           dzTrack.Link,
 			ArtistName:     dzTrack.Artist.Name,
 			AlbumTitle:     dzTrack.Album.Title,
+			AlbumCoverURL:  dzTrack.Album.Cover,
 		}
 		tracks = append(tracks, track)
 	}
@@ -299,6 +329,93 @@ func (c *DeezerClient) GetArtistTopTracks(ctx context.Context, deezerID string,
 	return tracks, nil
 }
 
+// SearchTracks looks a song up by artist and title
+func (c *DeezerClient) SearchTracks(ctx context.Context, artistName, title string, limit int) ([]DeezerTrack, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	artistName = strings.TrimSpace(artistName)
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	query := fmt.Sprintf("track:%q", title)
+	if artistName != "" {
+		query = fmt.Sprintf("artist:%q %s", artistName, query)
+	}
+
+	searchURL := fmt.Sprintf("%s/search/track", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Set("q", query)
+	q.Set("limit", fmt.Sprintf("%d", limit))
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tracks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, domain.ErrRateLimitExceeded
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("deezer track search failed: status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Data []deezerTrack `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	tracks := make([]DeezerTrack, 0, len(response.Data))
+	for _, dzTrack := range response.Data {
+		tracks = append(tracks, DeezerTrack{
+			ID:             dzTrack.ID,
+			Title:          dzTrack.Title,
+			Duration:       dzTrack.Duration,
+			Rank:           dzTrack.Rank,
+			ExplicitLyrics: dzTrack.ExplicitLyrics,
+			Preview:        dzTrack.Preview,
+			Link:           dzTrack.Link,
+			ArtistName:     dzTrack.Artist.Name,
+			AlbumTitle:     dzTrack.Album.Title,
+			AlbumCoverURL:  dzTrack.Album.Cover,
+		})
+	}
+
+	return tracks, nil
+}
+
+// PreviewURL returns the 30 second preview of the best match for a song, or
+// an empty string when Deezer doesn't have it
+func (c *DeezerClient) PreviewURL(ctx context.Context, artistName, title string) (string, error) {
+	tracks, err := c.SearchTracks(ctx, artistName, title, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(tracks) == 0 {
+		return "", nil
+	}
+	return tracks[0].Preview, nil
+}
+
 type DeezerAlbum struct {
 	ID          int64    `json:"id"`
 	Title       string   `json:"title"`
@@ -318,8 +435,10 @@ type DeezerTrack struct {
 	Rank           int    `json:"rank"`
 	ExplicitLyrics bool   `json:"explicit_lyrics"`
 	Preview        string `json:"preview"`
+	Link           string `json:"link"`
 	ArtistName     string `json:"artist_name"`
 	AlbumTitle     string `json:"album_title"`
+	AlbumCoverURL  string `json:"album_cover_url"`
 }
 
 type deezerTrack struct {
@@ -329,6 +448,7 @@ type deezerTrack struct {
 	Rank           int          `json:"rank"`
 	ExplicitLyrics bool         `json:"explicit_lyrics"`
 	Preview        string       `json:"preview"`
+	Link           string       `json:"link"`
 	Artist         deezerArtist `json:"artist"`
 	Album          struct {
 		ID    int64  `json:"id"`
@@ -338,39 +458,12 @@ type deezerTrack struct {
 }
 
 func (c *DeezerClient) convertToArtist(dzArtist deezerArtist, albums []DeezerAlbum) domain.Artist {
-	// Calculate popularity based on fan count
-	popularity := 0
-	if dzArtist.NbFan > 10000000 { // 10M+ fans
-		popularity = 100
-	} else if dzArtist.NbFan > 1000000 { // 1M+ fans
-		popularity = 95
-	} else if dzArtist.NbFan > 500000 { // 500k+ fans
-		popularity = 90
-	} else if dzArtist.NbFan > 100000 { // 100k+ fans
-		popularity = 85
-	} else if dzArtist.NbFan > 50000 { // 50k+ fans
-		popularity = 80
-	} else if dzArtist.NbFan > 10000 { // 10k+ fans
-		popularity = 75
-	} else if dzArtist.NbFan > 5000 { // 5k+ fans
-		popularity = 70
-	} else if dzArtist.NbFan > 1000 { // 1k+ fans
-		popularity = 65
-	} else {
-		popularity = 60
-	}
+	popularity := scoring.Popularity("deezer", int64(dzArtist.NbFan))
 
 	// Extract genres from albums
-	genreSet := make(map[string]bool)
+	var genres []string
 	for _, album := range albums {
-		for _, genre := range album.Genres {
-			genreSet[strings.ToLower(genre)] = true
-		}
-	}
-
-	genres := make([]string, 0, len(genreSet))
-	for genre := range genreSet {
-		genres = append(genres, genre)
+		genres = append(genres, album.Genres...)
 	}
 
 	// Use the highest quality image available
@@ -385,7 +478,7 @@ func (c *DeezerClient) convertToArtist(dzArtist deezerArtist, albums []DeezerAlb
 	return domain.Artist{
 		ID:          fmt.Sprintf("deezer_%d", dzArtist.ID),
 		Name:        dzArtist.Name,
-		Genres:      genres,
+		Genres:      domain.NormalizeGenres(genres),
 		Popularity:  popularity,
 		ImageURL:    imageURL,
 		ExternalIDs: domain.ExternalIDs{
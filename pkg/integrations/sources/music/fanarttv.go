@@ -0,0 +1,101 @@
+package music
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+)
+
+// FanartTVClient looks up the artist pictures fanart.tv's community uploads,
+// which are keyed by MusicBrainz ID
+type FanartTVClient struct {
+	baseURL     string
+	apiKey      string
+	httpClient  *http.Client
+	rateLimiter *ratelimit.Limiter
+}
+
+type FanartTVConfig struct {
+	APIKey string
+}
+
+func NewFanartTVClient(config FanartTVConfig) (*FanartTVClient, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("fanart.tv API key is required")
+	}
+
+	return &FanartTVClient{
+		baseURL:     "https://webservice.fanart.tv/v3",
+		apiKey:      config.APIKey,
+		httpClient:  httpclient.NewFor("fanarttv", 10*time.Second),
+		rateLimiter: ratelimit.PerSecond("fanarttv", 5),
+	}, nil
+}
+
+type fanartTVImage struct {
+	URL   string `json:"url"`
+	Likes string `json:"likes"`
+}
+
+type fanartTVArtistResponse struct {
+	ArtistThumbs []fanartTVImage `json:"artistthumb"`
+}
+
+// ArtistImage returns the artist's best liked thumbnail on fanart.tv. Only
+// artists with a MusicBrainz ID can be looked up; others get "".
+func (c *FanartTVClient) ArtistImage(ctx context.Context, artist domain.Artist) (string, error) {
+	musicBrainzID := artist.ExternalIDs.MusicBrainzID
+	if musicBrainzID == "" {
+		return "", nil
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return "", err
+	}
+
+	artistURL := fmt.Sprintf("%s/music/%s", c.baseURL, url.PathEscape(musicBrainzID))
+	req, err := http.NewRequestWithContext(ctx, "GET", artistURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Set("api_key", c.apiKey)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get artist images: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// fanart.tv answers 404 for artists nobody uploaded anything for
+	if resp.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("fanart.tv get artist images failed: status %d", resp.StatusCode)
+	}
+
+	var response fanartTVArtistResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	best, bestLikes := "", -1
+	for _, thumb := range response.ArtistThumbs {
+		likes, _ := strconv.Atoi(thumb.Likes)
+		if thumb.URL != "" && likes > bestLikes {
+			best, bestLikes = thumb.URL, likes
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,88 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// coverArtArchiveURL serves release covers by MusicBrainz release ID
+const coverArtArchiveURL = "https://coverartarchive.org"
+
+// matchingArtist finds artist among the source's search results: the one
+// with its ID on the source, or else the first with its name. It returns
+// nil when none match.
+func matchingArtist(ctx context.Context, artist domain.Artist, prefix string, search func(ctx context.Context, query string, limit int) ([]domain.Artist, error)) (*domain.Artist, error) {
+	candidates, err := search(ctx, artist.Name, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(artist.ID, prefix) {
+		for i := range candidates {
+			if candidates[i].ID == artist.ID {
+				return &candidates[i], nil
+			}
+		}
+	}
+	for i := range candidates {
+		if strings.EqualFold(strings.TrimSpace(candidates[i].Name), strings.TrimSpace(artist.Name)) {
+			return &candidates[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// ArtistImage returns the artist's Deezer picture, or "" when Deezer only
+// has its placeholder
+func (c *DeezerClient) ArtistImage(ctx context.Context, artist domain.Artist) (string, error) {
+	match, err := matchingArtist(ctx, artist, "deezer_", c.SearchArtists)
+	if err != nil || match == nil {
+		return "", err
+	}
+
+	// Artists without a picture get one with an empty image hash, which
+	// loads as a grey silhouette
+	if strings.Contains(match.ImageURL, "/images/artist//") {
+		return "", nil
+	}
+	return match.ImageURL, nil
+}
+
+// ArtistImage returns the artist's artwork in the storefront's Apple Music
+// catalog
+func (c *AppleMusicClient) ArtistImage(ctx context.Context, artist domain.Artist) (string, error) {
+	match, err := matchingArtist(ctx, artist, "apple_", c.SearchArtists)
+	if err != nil || match == nil {
+		return "", err
+	}
+	return match.ImageURL, nil
+}
+
+// ArtistImage returns the Cover Art Archive's front cover of the artist's
+// first official release that has one. MusicBrainz keeps no artist
+// pictures, so a record sleeve is the closest it has.
+func (c *MusicBrainzClient) ArtistImage(ctx context.Context, artist domain.Artist) (string, error) {
+	musicBrainzID := artist.ExternalIDs.MusicBrainzID
+	if musicBrainzID == "" {
+		var err error
+		musicBrainzID, err = sourceArtistID(ctx, artist, "musicbrainz_", c.SearchArtists)
+		if err != nil || musicBrainzID == "" {
+			return "", err
+		}
+	}
+
+	releases, err := c.GetArtistReleases(ctx, musicBrainzID, 25)
+	if err != nil {
+		return "", err
+	}
+
+	for _, release := range releases {
+		if release.Cover && release.Status == "Official" {
+			return fmt.Sprintf("%s/release/%s/front-500", coverArtArchiveURL, release.ID), nil
+		}
+	}
+	return "", nil
+}
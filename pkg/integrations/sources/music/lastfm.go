@@ -0,0 +1,313 @@
+package music
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+	"github.com/yair/where-its-at/pkg/scoring"
+)
+
+// Last.fm error codes returned in the JSON body
+const (
+	lastFMErrorInvalidParameters = 6
+	lastFMErrorRateLimitExceeded = 29
+)
+
+// LastFMPeriods are the periods accepted by user.gettopartists
+var LastFMPeriods = []string{"overall", "7day", "1month", "3month", "6month", "12month"}
+
+type LastFMClient struct {
+	baseURL     string
+	apiKey      string
+	httpClient  *http.Client
+	rateLimiter *ratelimit.Limiter
+}
+
+type LastFMConfig struct {
+	APIKey string
+}
+
+func NewLastFMClient(config LastFMConfig) (*LastFMClient, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("last.fm API key is required")
+	}
+
+	return &LastFMClient{
+		baseURL:     "https://ws.audioscrobbler.com/2.0",
+		apiKey:      config.APIKey,
+		httpClient:  httpclient.NewFor("lastfm", 10*time.Second),
+		rateLimiter: ratelimit.PerSecond("lastfm", 5), // Last.fm asks for no more than 5 requests per second
+	}, nil
+}
+
+func (c *LastFMClient) GetName() string {
+	return "lastfm"
+}
+
+// UseQuotaStore restores and persists request counts so the quota window
+// survives restarts
+func (c *LastFMClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *LastFMClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
+}
+
+type lastFMImage struct {
+	Text string `json:"#text"`
+	Size string `json:"size"`
+}
+
+type lastFMArtist struct {
+	Name      string        `json:"name"`
+	MBID      string        `json:"mbid"`
+	URL       string        `json:"url"`
+	Listeners string        `json:"listeners"`
+	Playcount string        `json:"playcount"`
+	Image     []lastFMImage `json:"image"`
+	Stats     struct {
+		Listeners string `json:"listeners"`
+		Playcount string `json:"playcount"`
+	} `json:"stats"`
+	Tags struct {
+		Tag []struct {
+			Name string `json:"name"`
+		} `json:"tag"`
+	} `json:"tags"`
+}
+
+type lastFMSearchResponse struct {
+	Results struct {
+		ArtistMatches struct {
+			Artist []lastFMArtist `json:"artist"`
+		} `json:"artistmatches"`
+	} `json:"results"`
+}
+
+type lastFMArtistInfoResponse struct {
+	Artist lastFMArtist `json:"artist"`
+}
+
+type lastFMTopArtistsResponse struct {
+	TopArtists struct {
+		Artist []lastFMArtist `json:"artist"`
+	} `json:"topartists"`
+}
+
+type lastFMErrorResponse struct {
+	Error   int    `json:"error"`
+	Message string `json:"message"`
+}
+
+func (c *LastFMClient) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 30 {
+		limit = 30
+	}
+
+	params := url.Values{}
+	params.Set("artist", query)
+	params.Set("limit", strconv.Itoa(limit))
+
+	var searchResp lastFMSearchResponse
+	if err := c.call(ctx, "artist.search", params, &searchResp); err != nil {
+		return nil, err
+	}
+
+	artists := make([]domain.Artist, 0, len(searchResp.Results.ArtistMatches.Artist))
+	for _, lfArtist := range searchResp.Results.ArtistMatches.Artist {
+		artists = append(artists, c.convertToArtist(lfArtist))
+	}
+
+	return artists, nil
+}
+
+// GetArtist looks an artist up by name, since Last.fm keys artists by name
+// rather than by its own ID
+func (c *LastFMClient) GetArtist(ctx context.Context, artistName string) (*domain.Artist, error) {
+	artistName = strings.TrimSpace(artistName)
+	if artistName == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	params := url.Values{}
+	params.Set("artist", artistName)
+	params.Set("autocorrect", "1")
+
+	var infoResp lastFMArtistInfoResponse
+	if err := c.call(ctx, "artist.getinfo", params, &infoResp); err != nil {
+		if err == domain.ErrInvalidRequest {
+			return nil, domain.ErrArtistNotFound
+		}
+		return nil, err
+	}
+
+	artist := c.convertToArtist(infoResp.Artist)
+	return &artist, nil
+}
+
+// GetUserTopArtists returns the artists a Last.fm user has scrobbled most in
+// the given period
+func (c *LastFMClient) GetUserTopArtists(ctx context.Context, username, period string, limit int) ([]domain.Artist, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	if period == "" {
+		period = "overall"
+	}
+	if !isLastFMPeriod(period) {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	params := url.Values{}
+	params.Set("user", username)
+	params.Set("period", period)
+	params.Set("limit", strconv.Itoa(limit))
+
+	var topResp lastFMTopArtistsResponse
+	if err := c.call(ctx, "user.gettopartists", params, &topResp); err != nil {
+		return nil, err
+	}
+
+	artists := make([]domain.Artist, 0, len(topResp.TopArtists.Artist))
+	for _, lfArtist := range topResp.TopArtists.Artist {
+		artists = append(artists, c.convertToArtist(lfArtist))
+	}
+
+	return artists, nil
+}
+
+func (c *LastFMClient) call(ctx context.Context, method string, params url.Values, target interface{}) error {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return err
+	}
+
+	params.Set("method", method)
+	params.Set("api_key", c.apiKey)
+	params.Set("format", "json")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/?"+params.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call last.fm %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return domain.ErrRateLimitExceeded
+	}
+
+	// Last.fm reports most failures in the body, sometimes with a 200 status
+	var body json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("last.fm %s failed: status %d", method, resp.StatusCode)
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var apiErr lastFMErrorResponse
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error != 0 {
+		switch apiErr.Error {
+		case lastFMErrorInvalidParameters:
+			return domain.ErrInvalidRequest
+		case lastFMErrorRateLimitExceeded:
+			return domain.ErrRateLimitExceeded
+		default:
+			return fmt.Errorf("last.fm %s failed: %s (code %d)", method, apiErr.Message, apiErr.Error)
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("last.fm %s failed: status %d", method, resp.StatusCode)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
+func isLastFMPeriod(period string) bool {
+	for _, p := range LastFMPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *LastFMClient) convertToArtist(lfArtist lastFMArtist) domain.Artist {
+	listeners := lfArtist.Listeners
+	if listeners == "" {
+		listeners = lfArtist.Stats.Listeners
+	}
+
+	popularity := 0
+	if count, err := strconv.ParseInt(listeners, 10, 64); err == nil {
+		popularity = scoring.Popularity("lastfm", count)
+	}
+
+	genres := make([]string, 0, len(lfArtist.Tags.Tag))
+	for _, tag := range lfArtist.Tags.Tag {
+		genres = append(genres, strings.ToLower(tag.Name))
+	}
+
+	imageURL := ""
+	for _, img := range lfArtist.Image {
+		if img.Size == "extralarge" && img.Text != "" {
+			imageURL = img.Text
+			break
+		}
+	}
+
+	// Not every Last.fm artist has an MBID; fall back to the name, which is
+	// how Last.fm itself identifies artists
+	lastFMID := lfArtist.MBID
+	if lastFMID == "" {
+		lastFMID = lfArtist.Name
+	}
+
+	return domain.Artist{
+		ID:         fmt.Sprintf("lastfm_%s", url.PathEscape(lastFMID)),
+		Name:       lfArtist.Name,
+		Genres:     domain.NormalizeGenres(genres),
+		Popularity: popularity,
+		ImageURL:   imageURL,
+		ExternalIDs: domain.ExternalIDs{
+			LastFMID:      lastFMID,
+			MusicBrainzID: lfArtist.MBID,
+		},
+	}
+}
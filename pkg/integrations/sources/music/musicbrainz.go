@@ -9,13 +9,16 @@ import (
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+	"github.com/yair/where-its-at/pkg/scoring"
 )
 
 type MusicBrainzClient struct {
 	baseURL     string
 	userAgent   string
 	httpClient  *http.Client
-	rateLimiter *musicBrainzRateLimiter
+	rateLimiter *ratelimit.Limiter
 }
 
 type MusicBrainzConfig struct {
@@ -30,8 +33,8 @@ func NewMusicBrainzClient(config MusicBrainzConfig) (*MusicBrainzClient, error)
 	return &MusicBrainzClient{
 		baseURL:     "https://musicbrainz.org/ws/2",
 		userAgent:   config.UserAgent,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newMusicBrainzRateLimiter(),
+		httpClient:  httpclient.NewFor("musicbrainz", 10*time.Second),
+		rateLimiter: ratelimit.PerSecond("musicbrainz", 1), // MusicBrainz requires 1 second between requests
 	}, nil
 }
 
@@ -102,7 +105,7 @@ type musicBrainzSearchResponse struct {
 }
 
 func (c *MusicBrainzClient) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -118,6 +121,95 @@ func (c *MusicBrainzClient) SearchArtists(ctx context.Context, query string, lim
 		limit = 100
 	}
 
+	mbArtists, err := c.searchArtists(ctx, fmt.Sprintf("artist:%s", query), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	artists := make([]domain.Artist, 0, len(mbArtists))
+	for _, mbArtist := range mbArtists {
+		artist := c.convertToArtist(mbArtist)
+		artists = append(artists, artist)
+	}
+
+	return artists, nil
+}
+
+// ArtistAliases finds the artist performing as name, under its own name or
+// one of its aliases, and returns it with every alias MusicBrainz lists
+func (c *MusicBrainzClient) ArtistAliases(ctx context.Context, name string) (*domain.Artist, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	// Quoted as a phrase, so the name's own quotes have to go
+	phrase := strings.TrimSpace(strings.ReplaceAll(name, `"`, ""))
+	if phrase == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	mbArtists, err := c.searchArtists(ctx, fmt.Sprintf(`artist:"%s" OR alias:"%s"`, phrase, phrase), 5)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mbArtist := range mbArtists {
+		if artist := c.convertToArtist(mbArtist); artist.KnownAs(name) {
+			return &artist, nil
+		}
+	}
+	return nil, domain.ErrArtistNotFound
+}
+
+// ArtistsFromArea finds artists who formed in or are based in area, a city
+// or other place name, best matches first
+func (c *MusicBrainzClient) ArtistsFromArea(ctx context.Context, area string, limit int) ([]domain.LocalArtist, error) {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	phrase := strings.TrimSpace(strings.ReplaceAll(area, `"`, ""))
+	if phrase == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	if limit <= 0 {
+		limit = 25
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	mbArtists, err := c.searchArtists(ctx, fmt.Sprintf(`beginarea:"%s" OR area:"%s"`, phrase, phrase), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// The search also matches areas with the name in them, like Leipzig
+	// District for Leipzig
+	artists := []domain.LocalArtist{}
+	for _, mbArtist := range mbArtists {
+		var matched string
+		for _, candidate := range []musicBrainzArea{mbArtist.BeginArea, mbArtist.Area} {
+			if strings.EqualFold(candidate.Name, phrase) {
+				matched = candidate.Name
+				break
+			}
+		}
+		if matched == "" {
+			continue
+		}
+
+		artists = append(artists, domain.LocalArtist{
+			Artist: c.convertToArtist(mbArtist),
+			Area:   matched,
+		})
+	}
+
+	return artists, nil
+}
+
+func (c *MusicBrainzClient) searchArtists(ctx context.Context, query string, limit int) ([]musicBrainzArtist, error) {
 	searchURL := fmt.Sprintf("%s/artist", c.baseURL)
 	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
@@ -125,7 +217,7 @@ func (c *MusicBrainzClient) SearchArtists(ctx context.Context, query string, lim
 	}
 
 	q := req.URL.Query()
-	q.Set("query", fmt.Sprintf("artist:%s", query))
+	q.Set("query", query)
 	q.Set("limit", fmt.Sprintf("%d", limit))
 	q.Set("fmt", "json")
 	q.Set("inc", "tags+aliases+area-rels+url-rels")
@@ -152,17 +244,11 @@ func (c *MusicBrainzClient) SearchArtists(ctx context.Context, query string, lim
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	artists := make([]domain.Artist, 0, len(searchResp.Artists))
-	for _, mbArtist := range searchResp.Artists {
-		artist := c.convertToArtist(mbArtist)
-		artists = append(artists, artist)
-	}
-
-	return artists, nil
+	return searchResp.Artists, nil
 }
 
 func (c *MusicBrainzClient) GetArtist(ctx context.Context, musicBrainzID string) (*domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -202,8 +288,77 @@ func (c *MusicBrainzClient) GetArtist(ctx context.Context, musicBrainzID string)
 	return &artist, nil
 }
 
+// RelatedArtists returns the artists MusicBrainz links to the artist:
+// bands it plays in or that it's a member of, collaborations and the
+// musicians who support it. The stored MBID is used when there is one.
+func (c *MusicBrainzClient) RelatedArtists(ctx context.Context, artist domain.Artist, limit int) ([]domain.Artist, error) {
+	musicBrainzID := artist.ExternalIDs.MusicBrainzID
+	if musicBrainzID == "" {
+		var err error
+		musicBrainzID, err = sourceArtistID(ctx, artist, "musicbrainz_", c.SearchArtists)
+		if err != nil || musicBrainzID == "" {
+			return nil, err
+		}
+	}
+
+	if err := c.rateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	artistURL := fmt.Sprintf("%s/artist/%s", c.baseURL, musicBrainzID)
+	req, err := http.NewRequestWithContext(ctx, "GET", artistURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Set("fmt", "json")
+	q.Set("inc", "artist-rels")
+	req.URL.RawQuery = q.Encode()
+
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get related artists: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, domain.ErrArtistNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("musicbrainz related artists failed: status %d", resp.StatusCode)
+	}
+
+	var mbArtist musicBrainzArtist
+	if err := json.NewDecoder(resp.Body).Decode(&mbArtist); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	related := []domain.Artist{}
+	seen := make(map[string]bool)
+	for _, relation := range mbArtist.Relations {
+		if relation.Artist.ID == "" || relation.Artist.ID == musicBrainzID || seen[relation.Artist.ID] {
+			continue
+		}
+		seen[relation.Artist.ID] = true
+		related = append(related, domain.Artist{
+			ID:          "musicbrainz_" + relation.Artist.ID,
+			Name:        relation.Artist.Name,
+			ExternalIDs: domain.ExternalIDs{MusicBrainzID: relation.Artist.ID},
+		})
+		if limit > 0 && len(related) == limit {
+			break
+		}
+	}
+
+	return related, nil
+}
+
 func (c *MusicBrainzClient) GetArtistReleases(ctx context.Context, musicBrainzID string, limit int) ([]MusicBrainzRelease, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -257,6 +412,7 @@ func (c *MusicBrainzClient) GetArtistReleases(ctx context.Context, musicBrainzID
 			Country:   mbRelease.Country,
 			Status:    mbRelease.Status,
 			Packaging: mbRelease.Packaging,
+			Cover:     mbRelease.CoverArtArchive.Front,
 		}
 		releases = append(releases, release)
 	}
@@ -264,6 +420,8 @@ func (c *MusicBrainzClient) GetArtistReleases(ctx context.Context, musicBrainzID
 	return releases, nil
 }
 
+// MusicBrainzRelease is a release of the artist's. Cover is set when the
+// Cover Art Archive has its front cover.
 type MusicBrainzRelease struct {
 	ID        string `json:"id"`
 	Title     string `json:"title"`
@@ -271,6 +429,7 @@ type MusicBrainzRelease struct {
 	Country   string `json:"country"`
 	Status    string `json:"status"`
 	Packaging string `json:"packaging"`
+	Cover     bool   `json:"cover"`
 }
 
 type musicBrainzRelease struct {
@@ -280,6 +439,10 @@ type musicBrainzRelease struct {
 	Country   string `json:"country"`
 	Status    string `json:"status"`
 	Packaging string `json:"packaging"`
+
+	CoverArtArchive struct {
+		Front bool `json:"front"`
+	} `json:"cover-art-archive"`
 }
 
 func (c *MusicBrainzClient) convertToArtist(mbArtist musicBrainzArtist) domain.Artist {
@@ -292,37 +455,17 @@ func (c *MusicBrainzClient) convertToArtist(mbArtist musicBrainzArtist) domain.A
 		}
 	}
 
-	// Calculate popularity based on score and tag counts
-	popularity := 0
-	if mbArtist.Score > 0 {
-		popularity = mbArtist.Score
-		if popularity > 100 {
-			popularity = 100
-		}
-	} else {
-		// Fallback: estimate popularity from tag activity
-		totalTagCount := 0
-		for _, tag := range mbArtist.Tags {
-			totalTagCount += tag.Count
-		}
-
-		if totalTagCount > 1000 {
-			popularity = 100
-		} else if totalTagCount > 500 {
-			popularity = 90
-		} else if totalTagCount > 100 {
-			popularity = 80
-		} else if totalTagCount > 50 {
-			popularity = 70
-		} else if totalTagCount > 10 {
-			popularity = 60
-		} else {
-			popularity = 50
-		}
+	// Votes on the artist's tags; the search score only measures the match
+	tagVotes := 0
+	for _, tag := range mbArtist.Tags {
+		tagVotes += tag.Count
 	}
+	popularity := scoring.Popularity("musicbrainz", int64(tagVotes))
 
 	// Extract external URLs from relations
-	externalIDs := domain.ExternalIDs{}
+	externalIDs := domain.ExternalIDs{
+		MusicBrainzID: mbArtist.ID,
+	}
 	for _, relation := range mbArtist.Relations {
 		if relation.Type == "url" && relation.URL.Resource != "" {
 			// Try to extract Spotify/LastFM IDs from URLs
@@ -352,35 +495,22 @@ func (c *MusicBrainzClient) convertToArtist(mbArtist musicBrainzArtist) domain.A
 		}
 	}
 
-	return domain.Artist{
+	artist := domain.Artist{
 		ID:          fmt.Sprintf("musicbrainz_%s", mbArtist.ID),
 		Name:        mbArtist.Name,
-		Genres:      genres,
+		Genres:      domain.NormalizeGenres(genres),
 		Popularity:  popularity,
 		ExternalIDs: externalIDs,
 		// MusicBrainz doesn't provide direct image URLs
 		ImageURL: "",
 	}
-}
-
-// MusicBrainz-specific rate limiter (1 request per second)
-type musicBrainzRateLimiter struct {
-	lastRequest time.Time
-	interval    time.Duration
-}
 
-func newMusicBrainzRateLimiter() *musicBrainzRateLimiter {
-	return &musicBrainzRateLimiter{
-		interval: time.Second, // MusicBrainz requires 1 second between requests
+	// Locale names, former names and search hints, each spelling once
+	for _, alias := range mbArtist.Aliases {
+		if alias.Name != "" && !artist.KnownAs(alias.Name) {
+			artist.Aliases = append(artist.Aliases, alias.Name)
+		}
 	}
-}
 
-func (r *musicBrainzRateLimiter) Allow() error {
-	now := time.Now()
-	if now.Sub(r.lastRequest) < r.interval {
-		sleepTime := r.interval - now.Sub(r.lastRequest)
-		time.Sleep(sleepTime)
-	}
-	r.lastRequest = time.Now()
-	return nil
+	return artist
 }
@@ -9,30 +9,76 @@ import (
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/integrations/oauth"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+	"github.com/yair/where-its-at/pkg/scoring"
 )
 
 type SoundCloudClient struct {
 	baseURL     string
 	clientID    string
 	httpClient  *http.Client
-	rateLimiter *rateLimiter
+	rateLimiter *ratelimit.Limiter
+	// tokens is nil for apps without a client secret
+	tokens oauth.TokenProvider
 }
 
 type SoundCloudConfig struct {
 	ClientID string // SoundCloud API requires client ID
+	// ClientSecret gets OAuth tokens with the client credentials grant,
+	// which apps registered since SoundCloud retired client_id auth need
+	ClientSecret string
 }
 
+// soundCloudTokenURL is where client credentials are exchanged for tokens
+const soundCloudTokenURL = "https://secure.soundcloud.com/oauth/token"
+
 func NewSoundCloudClient(config SoundCloudConfig) (*SoundCloudClient, error) {
 	if config.ClientID == "" {
 		return nil, fmt.Errorf("soundcloud client ID is required")
 	}
 
-	return &SoundCloudClient{
+	httpClient := httpclient.NewFor("soundcloud", 10*time.Second)
+	client := &SoundCloudClient{
 		baseURL:     "https://api.soundcloud.com",
 		clientID:    config.ClientID,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newRateLimiter(15000), // 15k requests per hour for registered apps
-	}, nil
+		httpClient:  httpClient,
+		rateLimiter: ratelimit.PerHour("soundcloud", 15000), // 15k requests per hour for registered apps
+	}
+	if config.ClientSecret != "" {
+		// Tokens last an hour, and SoundCloud limits how often they're
+		// fetched, so they're shared and refreshed five minutes early
+		fetch := oauth.ClientCredentials(httpClient, soundCloudTokenURL, config.ClientID, config.ClientSecret)
+		client.tokens = oauth.NewCachedTokenProvider(fetch, 5*time.Minute)
+	}
+	return client, nil
+}
+
+// do sends req with an OAuth token when the app has a client secret. Apps
+// without one, and requests made while no token can be fetched, fall back
+// to the client_id parameter older registrations are still allowed.
+func (c *SoundCloudClient) do(req *http.Request) (*http.Response, error) {
+	if c.tokens != nil {
+		if _, err := c.tokens.Token(req.Context()); err == nil {
+			return oauth.DoWithScheme(c.httpClient, c.tokens, "OAuth", req)
+		}
+	}
+
+	q := req.URL.Query()
+	q.Set("client_id", c.clientID)
+	req.URL.RawQuery = q.Encode()
+	return c.httpClient.Do(req)
+}
+
+// UseQuotaStore restores and persists request counts so the quota window
+// survives restarts
+func (c *SoundCloudClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+func (c *SoundCloudClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
 }
 
 type soundCloudUser struct {
@@ -100,7 +146,7 @@ type soundCloudSearchResponse struct {
 }
 
 func (c *SoundCloudClient) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -125,10 +171,9 @@ func (c *SoundCloudClient) SearchArtists(ctx context.Context, query string, limi
 	q := req.URL.Query()
 	q.Set("q", query)
 	q.Set("limit", fmt.Sprintf("%d", limit))
-	q.Set("client_id", c.clientID)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search artists: %w", err)
 	}
@@ -138,7 +183,7 @@ func (c *SoundCloudClient) SearchArtists(ctx context.Context, query string, limi
 		return nil, domain.ErrRateLimitExceeded
 	}
 	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("soundcloud unauthorized: invalid client ID")
+		return nil, fmt.Errorf("soundcloud unauthorized: check the client ID, and set the client secret for apps that need OAuth")
 	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("soundcloud search failed: status %d", resp.StatusCode)
@@ -161,7 +206,7 @@ func (c *SoundCloudClient) SearchArtists(ctx context.Context, query string, limi
 }
 
 func (c *SoundCloudClient) GetArtist(ctx context.Context, soundCloudID string) (*domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -171,11 +216,7 @@ func (c *SoundCloudClient) GetArtist(ctx context.Context, soundCloudID string) (
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	q := req.URL.Query()
-	q.Set("client_id", c.clientID)
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get artist: %w", err)
 	}
@@ -198,7 +239,7 @@ func (c *SoundCloudClient) GetArtist(ctx context.Context, soundCloudID string) (
 }
 
 func (c *SoundCloudClient) GetArtistTracks(ctx context.Context, soundCloudID string, limit int) ([]SoundCloudTrack, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -217,10 +258,9 @@ func (c *SoundCloudClient) GetArtistTracks(ctx context.Context, soundCloudID str
 
 	q := req.URL.Query()
 	q.Set("limit", fmt.Sprintf("%d", limit))
-	q.Set("client_id", c.clientID)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tracks: %w", err)
 	}
@@ -258,7 +298,7 @@ func (c *SoundCloudClient) GetArtistTracks(ctx context.Context, soundCloudID str
 }
 
 func (c *SoundCloudClient) SearchTracks(ctx context.Context, query string, limit int) ([]SoundCloudTrack, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.rateLimiter.Wait(ctx); err != nil {
 		return nil, err
 	}
 
@@ -278,10 +318,9 @@ func (c *SoundCloudClient) SearchTracks(ctx context.Context, query string, limit
 	q := req.URL.Query()
 	q.Set("q", query)
 	q.Set("limit", fmt.Sprintf("%d", limit))
-	q.Set("client_id", c.clientID)
 	req.URL.RawQuery = q.Encode()
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search tracks: %w", err)
 	}
@@ -339,43 +378,10 @@ type SoundCloudTrack struct {
 }
 
 func (c *SoundCloudClient) convertToArtist(scUser soundCloudUser) domain.Artist {
-	// Calculate popularity based on followers and track engagement
-	popularity := 0
-
-	// Base popularity on follower count
-	if scUser.FollowersCount > 1000000 { // 1M+ followers
-		popularity = 100
-	} else if scUser.FollowersCount > 500000 { // 500k+ followers
-		popularity = 95
-	} else if scUser.FollowersCount > 100000 { // 100k+ followers
-		popularity = 90
-	} else if scUser.FollowersCount > 50000 { // 50k+ followers
-		popularity = 85
-	} else if scUser.FollowersCount > 10000 { // 10k+ followers
-		popularity = 80
-	} else if scUser.FollowersCount > 5000 { // 5k+ followers
-		popularity = 75
-	} else if scUser.FollowersCount > 1000 { // 1k+ followers
-		popularity = 70
-	} else if scUser.FollowersCount > 500 { // 500+ followers
-		popularity = 65
-	} else if scUser.FollowersCount > 100 { // 100+ followers
-		popularity = 60
-	} else {
-		popularity = 50
-	}
-
-	// Boost popularity based on track count (active artists)
-	if scUser.TrackCount > 100 {
-		popularity = min(100, popularity+5)
-	} else if scUser.TrackCount > 50 {
-		popularity = min(100, popularity+3)
-	} else if scUser.TrackCount > 20 {
-		popularity = min(100, popularity+2)
-	}
+	popularity := scoring.Popularity("soundcloud", int64(scUser.FollowersCount))
 
 	// Extract potential genres from description
-	genres := c.extractGenresFromDescription(scUser.Description)
+	genres := domain.NormalizeGenres(c.extractGenresFromDescription(scUser.Description))
 
 	// Use highest quality avatar available
 	avatarURL := c.processArtworkURL(scUser.AvatarURL)
@@ -0,0 +1,135 @@
+package music
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ArtistTracks returns the artist's most popular Deezer tracks, each with a
+// 30 second preview
+func (c *DeezerClient) ArtistTracks(ctx context.Context, artist domain.Artist, limit int) ([]domain.Track, error) {
+	deezerID, err := sourceArtistID(ctx, artist, "deezer_", c.SearchArtists)
+	if err != nil || deezerID == "" {
+		return nil, err
+	}
+
+	topTracks, err := c.GetArtistTopTracks(ctx, deezerID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]domain.Track, 0, len(topTracks))
+	for _, dzTrack := range topTracks {
+		tracks = append(tracks, domain.Track{
+			ID:         fmt.Sprintf("deezer_%d", dzTrack.ID),
+			Title:      dzTrack.Title,
+			ArtistName: dzTrack.ArtistName,
+			Album:      dzTrack.AlbumTitle,
+			DurationMS: dzTrack.Duration * 1000,
+			PreviewURL: dzTrack.Preview,
+			URL:        dzTrack.Link,
+			ImageURL:   dzTrack.AlbumCoverURL,
+			Source:     "deezer",
+		})
+	}
+	return tracks, nil
+}
+
+// ArtistTracks returns the artist's most played SoundCloud uploads. SoundCloud
+// streams need an OAuth token, so these come without previews.
+func (c *SoundCloudClient) ArtistTracks(ctx context.Context, artist domain.Artist, limit int) ([]domain.Track, error) {
+	soundCloudID, err := sourceArtistID(ctx, artist, "soundcloud_", c.SearchArtists)
+	if err != nil || soundCloudID == "" {
+		return nil, err
+	}
+
+	// The API lists uploads newest first, so fetch a page and rank it here
+	uploads, err := c.GetArtistTracks(ctx, soundCloudID, 50)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(uploads, func(i, j int) bool {
+		return uploads[i].PlaybackCount > uploads[j].PlaybackCount
+	})
+	if limit > 0 && len(uploads) > limit {
+		uploads = uploads[:limit]
+	}
+
+	tracks := make([]domain.Track, 0, len(uploads))
+	for _, scTrack := range uploads {
+		tracks = append(tracks, domain.Track{
+			ID:         fmt.Sprintf("soundcloud_%d", scTrack.ID),
+			Title:      scTrack.Title,
+			ArtistName: artist.Name,
+			DurationMS: scTrack.Duration,
+			URL:        scTrack.PermalinkURL,
+			ImageURL:   scTrack.ArtworkURL,
+			Source:     "soundcloud",
+		})
+	}
+	return tracks, nil
+}
+
+// ArtistTracks returns music videos found by the artist's name, or the
+// channel's uploads for artists found on YouTube, which cost far less quota
+// than a search. Video titles usually read "Artist - Title", and that
+// prefix is dropped.
+func (c *YouTubeMusicClient) ArtistTracks(ctx context.Context, artist domain.Artist, limit int) ([]domain.Track, error) {
+	var (
+		videos []YouTubeMusicVideo
+		found  bool
+		err    error
+	)
+	if channelID, ok := strings.CutPrefix(artist.ID, "youtube_"); ok && channelID != "" {
+		videos, found, err = c.ChannelUploads(ctx, channelID, limit)
+	}
+	if err == nil && !found {
+		videos, err = c.SearchVideos(ctx, artist.Name, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]domain.Track, 0, len(videos))
+	for _, video := range videos {
+		title := html.UnescapeString(video.Title)
+		if head, rest, ok := strings.Cut(title, " - "); ok && strings.EqualFold(strings.TrimSpace(head), artist.Name) {
+			title = strings.TrimSpace(rest)
+		}
+
+		tracks = append(tracks, domain.Track{
+			ID:         "youtube_" + video.ID,
+			Title:      title,
+			ArtistName: artist.Name,
+			VideoURL:   video.URL,
+			ImageURL:   video.ThumbnailURL,
+			Source:     "youtube",
+		})
+	}
+	return tracks, nil
+}
+
+// sourceArtistID returns the ID a source knows the artist by: taken from our
+// ID when the artist came from that source, otherwise from a search result
+// with the same name. It returns "" rather than guess at a different artist.
+func sourceArtistID(ctx context.Context, artist domain.Artist, prefix string, search func(ctx context.Context, query string, limit int) ([]domain.Artist, error)) (string, error) {
+	if id, ok := strings.CutPrefix(artist.ID, prefix); ok && id != "" {
+		return id, nil
+	}
+
+	candidates, err := search(ctx, artist.Name, 5)
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range candidates {
+		if strings.EqualFold(strings.TrimSpace(candidate.Name), strings.TrimSpace(artist.Name)) {
+			return strings.TrimPrefix(candidate.ID, prefix), nil
+		}
+	}
+	return "", nil
+}
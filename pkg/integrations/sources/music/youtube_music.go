@@ -5,40 +5,87 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+	_ "time/tzdata" // the quota day is Pacific time wherever we run
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+	"github.com/yair/where-its-at/pkg/scoring"
 )
 
 type YouTubeMusicClient struct {
 	baseURL     string
 	apiKey      string
 	httpClient  *http.Client
-	rateLimiter *rateLimiter
+	rateLimiter *ratelimit.Limiter
 }
 
 type YouTubeMusicConfig struct {
 	APIKey string // YouTube Data API v3 key
+	// DailyQuota is the project's quota in units; 10,000 unless Google
+	// granted more
+	DailyQuota int
 }
 
+// The YouTube Data API charges calls against the daily quota by method,
+// not by request: a search costs as much as a hundred lookups by ID
+const (
+	defaultYouTubeDailyQuota = 10000
+	youTubeSearchCost        = 100 // search.list
+	youTubeListCost          = 1   // channels.list and playlistItems.list
+)
+
 func NewYouTubeMusicClient(config YouTubeMusicConfig) (*YouTubeMusicClient, error) {
 	if config.APIKey == "" {
 		return nil, fmt.Errorf("youtube music API key is required")
 	}
+	if config.DailyQuota <= 0 {
+		config.DailyQuota = defaultYouTubeDailyQuota
+	}
+	// The quota resets at midnight Pacific time
+	pacific, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the youtube quota timezone: %w", err)
+	}
 
 	return &YouTubeMusicClient{
 		baseURL:     "https://www.googleapis.com/youtube/v3",
 		apiKey:      config.APIKey,
-		httpClient:  &http.Client{Timeout: 10 * time.Second},
-		rateLimiter: newRateLimiter(10000), // 10k requests per day free tier
+		httpClient:  httpclient.NewFor("youtube", 10*time.Second),
+		rateLimiter: ratelimit.PerDayIn("youtube_music", config.DailyQuota, pacific), // counted in quota units
 	}, nil
 }
 
+// UseQuotaStore restores and persists the units spent so the quota window
+// survives restarts
+func (c *YouTubeMusicClient) UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error {
+	return c.rateLimiter.UseStore(ctx, store)
+}
+
+// Quota reports the daily quota in units rather than requests
+func (c *YouTubeMusicClient) Quota() domain.SourceQuota {
+	return c.rateLimiter.Quota()
+}
+
+// spend takes a call's cost in units from the daily quota, waiting for
+// them or failing with domain.ErrRateLimitExceeded like any other limit
+func (c *YouTubeMusicClient) spend(ctx context.Context, units int) error {
+	return c.rateLimiter.WaitN(ctx, units)
+}
+
 type youTubeChannel struct {
 	ID         string                   `json:"id"`
 	Snippet    youTubeChannelSnippet    `json:"snippet"`
 	Statistics youTubeChannelStatistics `json:"statistics,omitempty"`
+	// ContentDetails is only asked for to find the uploads playlist
+	ContentDetails struct {
+		RelatedPlaylists struct {
+			Uploads string `json:"uploads"`
+		} `json:"relatedPlaylists"`
+	} `json:"contentDetails"`
 }
 
 type youTubeChannelSnippet struct {
@@ -76,15 +123,28 @@ type youTubeSearchItem struct {
 	Snippet youTubeChannelSnippet `json:"snippet"`
 }
 
+// SearchArtists searches channels by name. A channel ID or @handle is
+// looked up directly instead, for a hundredth of the quota.
 func (c *YouTubeMusicClient) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
-		return nil, err
-	}
-
 	query = strings.TrimSpace(query)
 	if query == "" {
 		return nil, domain.ErrInvalidRequest
 	}
+	if lookup := youTubeChannelLookup(query); lookup != nil {
+		channels, err := c.listChannels(ctx, lookup, "snippet,statistics")
+		if err != nil {
+			return nil, err
+		}
+		artists := make([]domain.Artist, 0, len(channels))
+		for _, channel := range channels {
+			artists = append(artists, c.convertChannelToArtist(channel))
+		}
+		return artists, nil
+	}
+
+	if err := c.spend(ctx, youTubeSearchCost); err != nil {
+		return nil, err
+	}
 
 	if limit <= 0 {
 		limit = 10
@@ -164,8 +224,26 @@ func (c *YouTubeMusicClient) getChannelDetails(ctx context.Context, channelIDs [
 	if len(channelIDs) == 0 {
 		return []youTubeChannel{}, nil
 	}
+	return c.listChannels(ctx, url.Values{"id": {strings.Join(channelIDs, ",")}}, "snippet,statistics")
+}
 
-	if err := c.rateLimiter.Allow(); err != nil {
+// youTubeChannelLookup is the channels.list filter for a query naming a
+// channel by ID ("UC" and 22 more characters) or @handle, or nil for one
+// that has to be searched
+func youTubeChannelLookup(query string) url.Values {
+	if handle, ok := strings.CutPrefix(query, "@"); ok && handle != "" && !strings.ContainsAny(handle, " /") {
+		return url.Values{"forHandle": {handle}}
+	}
+	if len(query) == 24 && strings.HasPrefix(query, "UC") && !strings.ContainsAny(query, " /") {
+		return url.Values{"id": {query}}
+	}
+	return nil
+}
+
+// listChannels returns the parts of the channels filter selects, for one
+// unit of quota however many there are
+func (c *YouTubeMusicClient) listChannels(ctx context.Context, filter url.Values, part string) ([]youTubeChannel, error) {
+	if err := c.spend(ctx, youTubeListCost); err != nil {
 		return nil, err
 	}
 
@@ -176,8 +254,10 @@ func (c *YouTubeMusicClient) getChannelDetails(ctx context.Context, channelIDs [
 	}
 
 	q := req.URL.Query()
-	q.Set("part", "snippet,statistics")
-	q.Set("id", strings.Join(channelIDs, ","))
+	for name, values := range filter {
+		q[name] = values
+	}
+	q.Set("part", part)
 	q.Set("key", c.apiKey)
 	req.URL.RawQuery = q.Encode()
 
@@ -217,7 +297,7 @@ func (c *YouTubeMusicClient) GetArtist(ctx context.Context, channelID string) (*
 }
 
 func (c *YouTubeMusicClient) SearchVideos(ctx context.Context, artistName string, limit int) ([]YouTubeMusicVideo, error) {
-	if err := c.rateLimiter.Allow(); err != nil {
+	if err := c.spend(ctx, youTubeSearchCost); err != nil {
 		return nil, err
 	}
 
@@ -281,6 +361,99 @@ func (c *YouTubeMusicClient) SearchVideos(ctx context.Context, artistName string
 	return videos, nil
 }
 
+// ChannelUploads returns the channel's latest uploads, for two units of
+// quota where a search costs a hundred. found is false when the channel
+// doesn't exist or shows no uploads.
+func (c *YouTubeMusicClient) ChannelUploads(ctx context.Context, channelID string, limit int) (videos []YouTubeMusicVideo, found bool, err error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	channels, err := c.listChannels(ctx, url.Values{"id": {channelID}}, "contentDetails")
+	if err != nil {
+		return nil, false, err
+	}
+	if len(channels) == 0 || channels[0].ContentDetails.RelatedPlaylists.Uploads == "" {
+		return nil, false, nil
+	}
+
+	if err := c.spend(ctx, youTubeListCost); err != nil {
+		return nil, false, err
+	}
+
+	itemsURL := fmt.Sprintf("%s/playlistItems", c.baseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", itemsURL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	q := req.URL.Query()
+	q.Set("part", "snippet")
+	q.Set("playlistId", channels[0].ContentDetails.RelatedPlaylists.Uploads)
+	q.Set("maxResults", fmt.Sprintf("%d", limit))
+	q.Set("key", c.apiKey)
+	req.URL.RawQuery = q.Encode()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get uploads: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("youtube get uploads failed: status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Items []struct {
+			Snippet struct {
+				Title       string `json:"title"`
+				Description string `json:"description"`
+				Thumbnails  struct {
+					High struct {
+						URL string `json:"url"`
+					} `json:"high"`
+					Medium struct {
+						URL string `json:"url"`
+					} `json:"medium"`
+				} `json:"thumbnails"`
+				ResourceID struct {
+					VideoID string `json:"videoId"`
+				} `json:"resourceId"`
+			} `json:"snippet"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, false, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	videos = make([]YouTubeMusicVideo, 0, len(response.Items))
+	for _, item := range response.Items {
+		videoID := item.Snippet.ResourceID.VideoID
+		if videoID == "" {
+			continue
+		}
+		thumbnail := item.Snippet.Thumbnails.High.URL
+		if thumbnail == "" {
+			thumbnail = item.Snippet.Thumbnails.Medium.URL
+		}
+		videos = append(videos, YouTubeMusicVideo{
+			ID:           videoID,
+			Title:        item.Snippet.Title,
+			Description:  item.Snippet.Description,
+			ThumbnailURL: thumbnail,
+			URL:          fmt.Sprintf("https://www.youtube.com/watch?v=%s", videoID),
+		})
+	}
+	return videos, true, nil
+}
+
 type YouTubeMusicVideo struct {
 	ID           string `json:"id"`
 	Title        string `json:"title"`
@@ -299,7 +472,7 @@ func (c *YouTubeMusicClient) convertSearchItemToArtist(item youTubeSearchItem) d
 		ID:       fmt.Sprintf("youtube_%s", item.ID.ChannelID),
 		Name:     item.Snippet.Title,
 		ImageURL: imageURL,
-		Genres:   c.extractGenresFromDescription(item.Snippet.Description),
+		Genres:   domain.NormalizeGenres(c.extractGenresFromDescription(item.Snippet.Description)),
 	}
 }
 
@@ -309,36 +482,17 @@ func (c *YouTubeMusicClient) convertChannelToArtist(channel youTubeChannel) doma
 		imageURL = channel.Snippet.Thumbnails.Medium.URL
 	}
 
-	// Extract popularity from subscriber count
-	popularity := 0
-	if channel.Statistics.SubscriberCount != "" {
-		// Simple popularity calculation based on subscriber count
-		// This is a rough estimate since YouTube doesn't provide a direct popularity score
-		var subscribers int64
-		fmt.Sscanf(channel.Statistics.SubscriberCount, "%d", &subscribers)
-
-		// Scale to 0-100 range (very rough approximation)
-		if subscribers > 10000000 { // 10M+ subscribers
-			popularity = 100
-		} else if subscribers > 1000000 { // 1M+ subscribers
-			popularity = 90
-		} else if subscribers > 100000 { // 100k+ subscribers
-			popularity = 80
-		} else if subscribers > 10000 { // 10k+ subscribers
-			popularity = 70
-		} else if subscribers > 1000 { // 1k+ subscribers
-			popularity = 60
-		} else {
-			popularity = 50
-		}
-	}
+	// Rounded by YouTube to three significant figures, which is plenty here
+	var subscribers int64
+	fmt.Sscanf(channel.Statistics.SubscriberCount, "%d", &subscribers)
+	popularity := scoring.Popularity("youtube", subscribers)
 
 	return domain.Artist{
 		ID:         fmt.Sprintf("youtube_%s", channel.ID),
 		Name:       channel.Snippet.Title,
 		ImageURL:   imageURL,
 		Popularity: popularity,
-		Genres:     c.extractGenresFromDescription(channel.Snippet.Description),
+		Genres:     domain.NormalizeGenres(c.extractGenresFromDescription(channel.Snippet.Description)),
 	}
 }
 
@@ -6,7 +6,6 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
-	"time"
 
 	"golang.org/x/net/html"
 )
@@ -17,6 +16,9 @@ type BandcampScraper struct {
 }
 
 func NewBandcampScraper(config ScrapingConfig) *BandcampScraper {
+	config.Source = "bandcamp"
+	config.Selectors = selectorSetsFor(config.Source, config.Selectors)
+
 	return &BandcampScraper{
 		BaseScraper: NewBaseScraper(config),
 		baseURL:     "https://bandcamp.com",
@@ -140,7 +142,7 @@ func (b *BandcampScraper) scrapeArtistEvents(ctx context.Context, artistURL stri
 		return nil, fmt.Errorf("failed to parse shows page: %w", err)
 	}
 
-	events := b.parseShowsPage(doc, artistInfo)
+	events := b.parseShowsPage(doc, artistInfo, limit)
 
 	if len(events) == 0 {
 		// Fallback to release-based events
@@ -203,48 +205,28 @@ func (b *BandcampScraper) getArtistInfo(ctx context.Context, artistURL string) (
 	return artist, nil
 }
 
-func (b *BandcampScraper) parseShowsPage(doc *html.Node, artist BandcampArtist) []ScrapedEvent {
-	events := []ScrapedEvent{}
-
-	// Find show listings
-	var findShows func(*html.Node)
-	findShows = func(n *html.Node) {
-		if n.Type == html.ElementNode && b.hasClass(n, "show-item") {
-			event := b.parseShowItem(n, artist)
-			if event.Title != "" {
-				events = append(events, event)
-			}
-		}
-
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			findShows(c)
-		}
-	}
-
-	findShows(doc)
-	return events
+func (b *BandcampScraper) parseShowsPage(doc *html.Node, artist BandcampArtist, limit int) []ScrapedEvent {
+	return b.parseListing(doc, limit, func(node *html.Node, set SelectorSet) ScrapedEvent {
+		return b.parseShowItem(node, set, artist)
+	})
 }
 
-func (b *BandcampScraper) parseShowItem(node *html.Node, artist BandcampArtist) ScrapedEvent {
+func (b *BandcampScraper) parseShowItem(node *html.Node, set SelectorSet, artist BandcampArtist) ScrapedEvent {
 	event := ScrapedEvent{
 		ArtistName: artist.Name,
 		Title:      artist.Name + " Live",
 	}
 
 	// Extract date
-	if dateNode := b.findNodeByClass(node, "date"); dateNode != nil {
-		dateStr := b.ExtractText(b.getTextContent(dateNode))
+	if dateStr := b.selectText(node, set.Date); dateStr != "" {
 		event.Date = b.ParseDate(dateStr)
 	}
 
 	// Extract venue
-	if venueNode := b.findNodeByClass(node, "venue"); venueNode != nil {
-		event.VenueName = b.ExtractText(b.getTextContent(venueNode))
-	}
+	event.VenueName = b.selectText(node, set.Venue)
 
 	// Extract location
-	if locationNode := b.findNodeByClass(node, "location"); locationNode != nil {
-		location := b.ExtractText(b.getTextContent(locationNode))
+	if location := b.selectText(node, set.Location); location != "" {
 		b.parseLocation(location, &event)
 	}
 
@@ -309,9 +291,7 @@ func (b *BandcampScraper) createEventFromRelease(node *html.Node, artist Bandcam
 	// Use release as virtual venue
 	event.VenueName = "Bandcamp Release"
 
-	// Set date to recent (since it's a release, not a live event)
-	event.Date = time.Now().AddDate(0, 0, -30) // 30 days ago
-
+	// A release isn't a show, so it's left without a date
 	return event
 }
 
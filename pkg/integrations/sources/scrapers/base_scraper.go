@@ -5,113 +5,212 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
 )
 
 type ScrapingConfig struct {
-	UserAgent    string
+	// Source names the scraper, for its proxies; the scraper's constructor
+	// sets it
+	Source string
+	// UserAgent names us to robots.txt, and is sent with every request
+	// unless UserAgents are given to rotate through instead
+	UserAgent  string
+	UserAgents []string
+	// AcceptLanguages are rotated like UserAgents; a set of common browser
+	// ones by default
+	AcceptLanguages []string
+	// RequestDelay spaces requests to the same site, varied by up to Jitter
+	// of it either way (a quarter by default; negative for none). Domains
+	// pace particular sites differently.
 	RequestDelay time.Duration
+	Jitter       float64
+	Domains      map[string]DomainPoliteness
 	MaxRetries   int
 	Timeout      time.Duration
+	// CacheDir keeps fetched pages on disk; empty disables the cache
+	CacheDir string
+	// CacheTTL is how long a cached page is served without asking the site
+	// whether it changed
+	CacheTTL time.Duration
+	// Browser renders pages in headless Chrome instead of fetching them, for
+	// sites that build their listings with JavaScript. Nil fetches over HTTP.
+	Browser *BrowserPool
+	// WaitSelector is a CSS selector a rendered page is given time to match
+	// before it is read. Defaults to the event classes of the selector sets.
+	WaitSelector string
+	// Selectors are tried in order on listing pages; empty uses the
+	// scraper's built-in set
+	Selectors []SelectorSet
 }
 
 type BaseScraper struct {
 	httpClient  *http.Client
 	config      ScrapingConfig
-	rateLimiter *scraperRateLimiter
+	domainPacer *pacer
+	robots      *robotsPolicy
+	cache       *pageCache
+	selectors   []SelectorSet
+	parses      parseTracker
 }
 
 func NewBaseScraper(config ScrapingConfig) *BaseScraper {
 	if config.UserAgent == "" {
 		config.UserAgent = "WhereItsAt-Bot/1.0 (+https://whereiatsat.com/bot)"
 	}
+	if len(config.UserAgents) == 0 {
+		config.UserAgents = []string{config.UserAgent}
+	}
+	if len(config.AcceptLanguages) == 0 {
+		config.AcceptLanguages = defaultAcceptLanguages
+	}
 	if config.RequestDelay == 0 {
 		config.RequestDelay = 2 * time.Second // Conservative 2-second delay
 	}
+	if config.Jitter == 0 {
+		config.Jitter = defaultJitter
+	}
 	if config.MaxRetries == 0 {
 		config.MaxRetries = 3
 	}
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
+	if config.CacheTTL == 0 {
+		config.CacheTTL = time.Hour
+	}
+	if config.WaitSelector == "" {
+		config.WaitSelector = waitSelectorFor(config.Selectors)
+	}
+
+	var cache *pageCache
+	if config.CacheDir != "" {
+		cache = newPageCache(config.CacheDir, config.CacheTTL)
+	}
 
 	return &BaseScraper{
 		httpClient: &http.Client{
-			Timeout: config.Timeout,
+			Timeout:   config.Timeout,
+			Transport: httpclient.NewTransport(httpclient.BaseTransportFor(config.Source), httpclient.RetryConfig{MaxRetries: config.MaxRetries}),
 		},
 		config:      config,
-		rateLimiter: newScraperRateLimiter(config.RequestDelay),
+		domainPacer: newPacer(config.RequestDelay, config.Jitter, config.Domains),
+		robots:      newRobotsPolicy(config.UserAgent),
+		cache:       cache,
+		selectors:   config.Selectors,
 	}
 }
 
+// MakeRequest fetches a page the site's robots.txt lets us fetch, returning
+// ErrDisallowedByRobots otherwise. With a cache, fresh pages are served from
+// disk and stale ones are revalidated with a conditional request. Pages
+// rendered by a browser ignore headers and are never revalidated.
 func (b *BaseScraper) MakeRequest(ctx context.Context, url string, headers map[string]string) (*http.Response, error) {
-	if err := b.rateLimiter.Wait(ctx); err != nil {
+	allowed, err := b.robots.Allowed(ctx, url, b.fetchRobots)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check robots.txt: %w", err)
+	}
+	if !allowed {
+		return nil, ErrDisallowedByRobots
+	}
+
+	// Extra headers could change the page, so those requests bypass the cache
+	cache := b.cache
+	if len(headers) > 0 {
+		cache = nil
+	}
+
+	var cached *cachedPage
+	if cache != nil {
+		page, fresh := cache.Get(url)
+		if fresh {
+			return page.response(nil), nil
+		}
+		cached = page
+	}
+
+	if err := b.domainPacer.Wait(ctx, hostOf(url)); err != nil {
 		return nil, err
 	}
 
+	if b.config.Browser != nil {
+		resp, err := b.config.Browser.Render(ctx, url, b.config.WaitSelector)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render page: %w", err)
+		}
+		if cache != nil && resp.StatusCode == http.StatusOK {
+			return cache.Store(url, resp)
+		}
+		return resp, nil
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", b.config.UserAgent)
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Accept-Language", "en-US,en;q=0.5")
-	req.Header.Set("Accept-Encoding", "gzip, deflate")
-	req.Header.Set("DNT", "1")
-	req.Header.Set("Connection", "keep-alive")
-	req.Header.Set("Upgrade-Insecure-Requests", "1")
-
+	setBrowserHeaders(req.Header, b.config.UserAgents, b.config.AcceptLanguages)
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
 
-	var resp *http.Response
-	var lastErr error
-
-	for attempt := 0; attempt <= b.config.MaxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff
-			backoff := time.Duration(attempt*attempt) * time.Second
-			select {
-			case <-ctx.Done():
-				return nil, ctx.Err()
-			case <-time.After(backoff):
-			}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
 		}
-
-		resp, lastErr = b.httpClient.Do(req)
-		if lastErr == nil {
-			if resp.StatusCode == http.StatusTooManyRequests {
-				resp.Body.Close()
-				// Respect rate limits
-				select {
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				case <-time.After(5 * time.Second):
-				}
-				continue
-			}
-			if resp.StatusCode >= 500 {
-				resp.Body.Close()
-				// Server error, retry
-				continue
-			}
-			// Success or non-retryable error
-			break
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
 		}
 	}
 
-	if lastErr != nil {
-		return nil, fmt.Errorf("request failed after %d attempts: %w", b.config.MaxRetries+1, lastErr)
+	// The client's transport retries 429s, server and network errors
+	resp, err := b.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		cache.Refresh(cached)
+		return cached.response(req), nil
+	}
+	if cache != nil && resp.StatusCode == http.StatusOK {
+		return cache.Store(url, resp)
 	}
 
 	return resp, nil
 }
 
+// fetchRobots gets a robots.txt, paced like every other request to the site
+func (b *BaseScraper) fetchRobots(ctx context.Context, robotsURL string) (*http.Response, error) {
+	if err := b.domainPacer.Wait(ctx, hostOf(robotsURL)); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", b.config.UserAgent)
+
+	return b.httpClient.Do(req)
+}
+
+// hostOf is the host a URL points at, or the URL itself when it has none,
+// so pages that can't be parsed are still paced
+func hostOf(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		return u.Hostname()
+	}
+	return rawURL
+}
+
 func (b *BaseScraper) NormalizeURL(baseURL, relativeURL string) (string, error) {
 	base, err := url.Parse(baseURL)
 	if err != nil {
@@ -139,6 +238,8 @@ func (b *BaseScraper) ExtractText(text string) string {
 	return text
 }
 
+// ParseDate reads a date in any of the layouts listings commonly use, or
+// returns a zero time when none matches
 func (b *BaseScraper) ParseDate(dateStr string) time.Time {
 	formats := []string{
 		"2006-01-02",
@@ -162,42 +263,14 @@ func (b *BaseScraper) ParseDate(dateStr string) time.Time {
 		}
 	}
 
-	// Fallback to current time
-	return time.Now()
-}
-
-type scraperRateLimiter struct {
-	lastRequest time.Time
-	delay       time.Duration
-}
-
-func newScraperRateLimiter(delay time.Duration) *scraperRateLimiter {
-	return &scraperRateLimiter{
-		delay: delay,
-	}
-}
-
-func (r *scraperRateLimiter) Wait(ctx context.Context) error {
-	now := time.Now()
-	elapsed := now.Sub(r.lastRequest)
-
-	if elapsed < r.delay {
-		waitTime := r.delay - elapsed
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(waitTime):
-		}
-	}
-
-	r.lastRequest = time.Now()
-	return nil
+	return time.Time{}
 }
 
 type ScrapedEvent struct {
 	Title       string
 	ArtistName  string
 	Date        time.Time
+	Timezone    string
 	VenueName   string
 	City        string
 	Country     string
@@ -205,6 +278,12 @@ type ScrapedEvent struct {
 	Description string
 	Price       string
 	Tags        []string
+	// Status is empty when the page doesn't say, which counts as scheduled
+	Status domain.EventStatus
+	// The venue's details, when the page gives them
+	VenueAddress string
+	VenuePhone   string
+	VenueWebsite string
 }
 
 func (s *ScrapedEvent) ToEvent() domain.Event {
@@ -212,23 +291,92 @@ func (s *ScrapedEvent) ToEvent() domain.Event {
 		Name:    s.VenueName,
 		City:    s.City,
 		Country: s.Country,
+		Address: s.VenueAddress,
+		Phone:   s.VenuePhone,
+		Website: s.VenueWebsite,
 	}
+	venue.NormalizeCountry()
 
 	// Generate IDs based on scraped data
 	artistID := fmt.Sprintf("scraped_artist_%s", strings.ReplaceAll(strings.ToLower(s.ArtistName), " ", "_"))
 	eventID := fmt.Sprintf("scraped_%s_%s", strings.ReplaceAll(strings.ToLower(s.ArtistName), " ", "_"), s.Date.Format("20060102"))
 
+	status := s.Status
+	if status == "" {
+		status = domain.EventScheduled
+	}
+
 	// Set 24-hour cache
 	cacheUntil := time.Now().Add(24 * time.Hour)
 
-	return domain.Event{
+	event := domain.Event{
 		ID:          eventID,
 		ArtistID:    artistID,
 		ArtistName:  s.ArtistName,
-		DateTime:    s.Date,
+		DateTime:    domain.InTimezone(s.Date, s.Timezone),
+		Timezone:    s.Timezone,
 		Venue:       venue,
+		Status:      status,
+		Lineup:      domain.HeadlinerLineup(artistID, s.ArtistName),
+		PriceRanges: parsePrice(s.Price),
 		CachedUntil: cacheUntil,
 	}
+
+	// Listings mostly give a day; a start at midnight is taken as none
+	switch {
+	case s.Date.IsZero():
+		event.DateConfidence = domain.DateUnknown
+	case s.Date.Hour() == 0 && s.Date.Minute() == 0:
+		event.DateConfidence = domain.DateOnly
+	default:
+		event.DateConfidence = domain.DateExact
+	}
+
+	return event
+}
+
+var (
+	priceNumberPattern = regexp.MustCompile(`\d+(?:[.,]\d{1,2})?`)
+	priceCurrencies    = []struct{ marker, code string }{
+		{"£", "GBP"}, {"€", "EUR"}, {"$", "USD"},
+		{"GBP", "GBP"}, {"EUR", "EUR"}, {"USD", "USD"},
+	}
+)
+
+// parsePrice turns scraped text such as "£15 - £25" or "12,50 EUR" into a
+// price range. Free or unparseable prices yield no range.
+func parsePrice(text string) []domain.PriceRange {
+	numbers := priceNumberPattern.FindAllString(text, -1)
+	if len(numbers) == 0 {
+		return nil
+	}
+
+	var values []float64
+	for _, n := range numbers {
+		v, err := strconv.ParseFloat(strings.Replace(n, ",", ".", 1), 64)
+		if err != nil {
+			continue
+		}
+		values = append(values, v)
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	price := domain.PriceRange{Min: values[0], Max: values[len(values)-1]}
+	if price.Max < price.Min {
+		price.Min, price.Max = price.Max, price.Min
+	}
+
+	upper := strings.ToUpper(text)
+	for _, c := range priceCurrencies {
+		if strings.Contains(upper, c.marker) {
+			price.Currency = c.code
+			break
+		}
+	}
+
+	return []domain.PriceRange{price}
 }
 
 type Scraper interface {
@@ -237,8 +385,11 @@ type Scraper interface {
 	GetName() string
 }
 
+// ScraperRegistry is safe for concurrent use, so scrapers can be registered
+// while others scrape
 type ScraperRegistry struct {
 	scrapers map[string]Scraper
+	mu       sync.RWMutex
 }
 
 func NewScraperRegistry() *ScraperRegistry {
@@ -248,15 +399,21 @@ func NewScraperRegistry() *ScraperRegistry {
 }
 
 func (r *ScraperRegistry) Register(scraper Scraper) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.scrapers[scraper.GetName()] = scraper
 }
 
 func (r *ScraperRegistry) GetScraper(name string) (Scraper, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	scraper, exists := r.scrapers[name]
 	return scraper, exists
 }
 
 func (r *ScraperRegistry) GetAllScrapers() []Scraper {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	scrapers := make([]Scraper, 0, len(r.scrapers))
 	for _, scraper := range r.scrapers {
 		scrapers = append(scrapers, scraper)
@@ -267,7 +424,7 @@ func (r *ScraperRegistry) GetAllScrapers() []Scraper {
 func (r *ScraperRegistry) ScrapeAll(ctx context.Context, query string, limit int) ([]ScrapedEvent, error) {
 	allEvents := []ScrapedEvent{}
 
-	for _, scraper := range r.scrapers {
+	for _, scraper := range r.GetAllScrapers() {
 		events, err := scraper.ScrapeEvents(ctx, query, limit)
 		if err != nil {
 			// Log error but continue with other scrapers
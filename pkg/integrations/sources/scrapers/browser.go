@@ -0,0 +1,173 @@
+package scrapers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chromedp/chromedp"
+)
+
+// BrowserConfig for the headless Chrome that renders pages built with
+// JavaScript
+type BrowserConfig struct {
+	// ExecPath is the Chrome binary; empty looks for one on the PATH
+	ExecPath  string
+	UserAgent string
+	// MaxTabs caps how many pages render at once. Defaults to 2.
+	MaxTabs int
+	// PageTimeout bounds loading and rendering a single page. Defaults to 30s.
+	PageTimeout time.Duration
+	// SettleTimeout is how long a render waits for the scraper's wait
+	// selector to show up before taking the page as it is. Defaults to 5s.
+	SettleTimeout time.Duration
+}
+
+// BrowserPool renders pages in tabs of one shared headless Chrome, started
+// on the first render and restarted if it dies. It is safe for concurrent
+// use; Close shuts Chrome down.
+type BrowserPool struct {
+	config BrowserConfig
+	tabs   chan struct{}
+
+	mu            sync.Mutex
+	browserCtx    context.Context
+	cancelBrowser context.CancelFunc
+	closed        bool
+}
+
+var errBrowserClosed = errors.New("browser pool is closed")
+
+func NewBrowserPool(config BrowserConfig) *BrowserPool {
+	if config.MaxTabs <= 0 {
+		config.MaxTabs = 2
+	}
+	if config.PageTimeout == 0 {
+		config.PageTimeout = 30 * time.Second
+	}
+	if config.SettleTimeout == 0 {
+		config.SettleTimeout = 5 * time.Second
+	}
+
+	return &BrowserPool{
+		config: config,
+		tabs:   make(chan struct{}, config.MaxTabs),
+	}
+}
+
+// browser returns the running Chrome, starting it if needed
+func (p *BrowserPool) browser() (context.Context, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return nil, errBrowserClosed
+	}
+	if p.browserCtx != nil && p.browserCtx.Err() == nil {
+		return p.browserCtx, nil
+	}
+
+	opts := append([]chromedp.ExecAllocatorOption{}, chromedp.DefaultExecAllocatorOptions[:]...)
+	if p.config.ExecPath != "" {
+		opts = append(opts, chromedp.ExecPath(p.config.ExecPath))
+	}
+	if p.config.UserAgent != "" {
+		opts = append(opts, chromedp.UserAgent(p.config.UserAgent))
+	}
+
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancelBrowser()
+		cancelAlloc()
+		return nil, fmt.Errorf("failed to start browser: %w", err)
+	}
+
+	p.browserCtx = browserCtx
+	p.cancelBrowser = func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+	return browserCtx, nil
+}
+
+// Render loads pageURL in a new tab and returns the HTML after scripts have
+// run. With a waitSelector it also gives the page up to SettleTimeout to
+// render a matching element, which is how listings loaded after the page
+// itself get picked up.
+func (p *BrowserPool) Render(ctx context.Context, pageURL, waitSelector string) (*http.Response, error) {
+	select {
+	case p.tabs <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-p.tabs }()
+
+	browserCtx, err := p.browser()
+	if err != nil {
+		return nil, err
+	}
+
+	tabCtx, cancelTab := chromedp.NewContext(browserCtx)
+	defer cancelTab()
+	tabCtx, cancelTimeout := context.WithTimeout(tabCtx, p.config.PageTimeout)
+	defer cancelTimeout()
+	// The tab hangs off the browser, so a canceled request has to close it
+	stop := context.AfterFunc(ctx, cancelTab)
+	defer stop()
+
+	navigation, err := chromedp.RunResponse(tabCtx, chromedp.Navigate(pageURL))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to load page: %w", err)
+	}
+
+	if waitSelector != "" {
+		settleCtx, cancelSettle := context.WithTimeout(tabCtx, p.config.SettleTimeout)
+		// Not finding it only means the page has nothing to list
+		_ = chromedp.Run(settleCtx, chromedp.WaitReady(waitSelector, chromedp.ByQuery))
+		cancelSettle()
+	}
+
+	var rendered string
+	if err := chromedp.Run(tabCtx, chromedp.OuterHTML("html", &rendered, chromedp.ByQuery)); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("failed to read rendered page: %w", err)
+	}
+
+	status := http.StatusOK
+	if navigation != nil && navigation.Status != 0 {
+		status = int(navigation.Status)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:          io.NopCloser(strings.NewReader(rendered)),
+		ContentLength: int64(len(rendered)),
+	}, nil
+}
+
+// Close shuts Chrome down; renders after it fail
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	if p.cancelBrowser != nil {
+		p.cancelBrowser()
+	}
+}
@@ -0,0 +1,145 @@
+package scrapers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxCachedPageSize keeps a runaway page from filling the disk
+const maxCachedPageSize = 5 * 1024 * 1024
+
+// cachedPage is a fetched page as kept on disk
+type cachedPage struct {
+	URL          string      `json:"url"`
+	Header       http.Header `json:"header"`
+	Body         []byte      `json:"body"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	FetchedAt    time.Time   `json:"fetched_at"`
+}
+
+// response rebuilds the page as a 200 response callers can read like a
+// fresh one
+func (p *cachedPage) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        p.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(p.Body)),
+		ContentLength: int64(len(p.Body)),
+		Request:       req,
+	}
+}
+
+// pageCache keeps successful page fetches on disk, one JSON file per URL.
+// Within the TTL a page is served without a request; after it, the page is
+// revalidated with If-None-Match/If-Modified-Since. Disk errors only cost a
+// refetch.
+type pageCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+func newPageCache(dir string, ttl time.Duration) *pageCache {
+	return &pageCache{
+		dir: dir,
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+func (c *pageCache) path(pageURL string) string {
+	sum := sha256.Sum256([]byte(pageURL))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the cached page, if any, and whether it is still fresh
+func (c *pageCache) Get(pageURL string) (*cachedPage, bool) {
+	data, err := os.ReadFile(c.path(pageURL))
+	if err != nil {
+		return nil, false
+	}
+
+	var page cachedPage
+	if err := json.Unmarshal(data, &page); err != nil || page.URL != pageURL {
+		return nil, false
+	}
+	return &page, c.now().Sub(page.FetchedAt) < c.ttl
+}
+
+// Put writes the page through a temporary file so a concurrent Get never
+// reads half of it
+func (c *pageCache) Put(page *cachedPage) {
+	data, err := json.Marshal(page)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "page-*.tmp")
+	if err != nil {
+		return
+	}
+	_, writeErr := tmp.Write(data)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), c.path(page.URL)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
+
+// Store reads a 200 response into the cache and hands back a response over
+// the read body. Pages too big to cache are passed through untouched.
+func (c *pageCache) Store(pageURL string, resp *http.Response) (*http.Response, error) {
+	if resp.ContentLength > maxCachedPageSize {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedPageSize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxCachedPageSize {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	c.Put(&cachedPage{
+		URL:          pageURL,
+		Header:       resp.Header,
+		Body:         body,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    c.now(),
+	})
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// Refresh restarts the TTL of a page the server said hasn't changed
+func (c *pageCache) Refresh(page *cachedPage) {
+	page.FetchedAt = c.now()
+	c.Put(page)
+}
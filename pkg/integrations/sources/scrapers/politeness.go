@@ -0,0 +1,142 @@
+package scrapers
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultJitter varies each delay by up to a quarter either way, so a
+// scraper's requests don't arrive like clockwork
+const defaultJitter = 0.25
+
+// defaultAcceptLanguages are Accept-Language headers browsers commonly send
+var defaultAcceptLanguages = []string{
+	"en-US,en;q=0.9",
+	"en-GB,en;q=0.9",
+	"en-GB,en-US;q=0.9,en;q=0.8",
+	"en-US,en;q=0.9,de;q=0.8",
+	"en-US,en;q=0.9,nl;q=0.8",
+	"en-US,en;q=0.9,fr;q=0.8",
+}
+
+// DomainPoliteness paces the requests to one site differently from the
+// scraper's defaults. Zeros keep the defaults.
+type DomainPoliteness struct {
+	RequestDelay time.Duration
+	// Jitter is the fraction a delay varies by either way; negative sends
+	// requests exactly RequestDelay apart
+	Jitter float64
+}
+
+// pacer spaces requests to each host by its delay, give or take its
+// jitter. Sites are paced apart, so a slow one doesn't hold up the others.
+type pacer struct {
+	mu      sync.Mutex
+	next    map[string]time.Time
+	delay   time.Duration
+	jitter  float64
+	domains map[string]DomainPoliteness
+	now     func() time.Time
+}
+
+func newPacer(delay time.Duration, jitter float64, domains map[string]DomainPoliteness) *pacer {
+	normalized := make(map[string]DomainPoliteness, len(domains))
+	for domain, politeness := range domains {
+		normalized[strings.ToLower(strings.TrimPrefix(domain, "www."))] = politeness
+	}
+	return &pacer{
+		next:    make(map[string]time.Time),
+		delay:   delay,
+		jitter:  jitter,
+		domains: normalized,
+		now:     time.Now,
+	}
+}
+
+// Wait blocks until the host's next request may go, and books the one
+// after it
+func (p *pacer) Wait(ctx context.Context, host string) error {
+	host = strings.ToLower(host)
+
+	p.mu.Lock()
+	delay, jitter := p.politeness(host)
+	now := p.now()
+	at := p.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	p.next[host] = at.Add(jittered(delay, jitter))
+	p.mu.Unlock()
+
+	wait := at.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// politeness is the delay and jitter for host, from the most specific
+// domain configured for it: "ra.co" covers "www.ra.co" and "de.ra.co"
+func (p *pacer) politeness(host string) (time.Duration, float64) {
+	delay, jitter := p.delay, p.jitter
+	for domain := host; domain != ""; {
+		if politeness, ok := p.domains[domain]; ok {
+			if politeness.RequestDelay > 0 {
+				delay = politeness.RequestDelay
+			}
+			if politeness.Jitter != 0 {
+				jitter = politeness.Jitter
+			}
+			break
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return delay, jitter
+}
+
+// jittered is delay varied by up to jitter of it either way
+func jittered(delay time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+	spread := float64(delay) * jitter
+	return time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+}
+
+// setBrowserHeaders makes a request look like a browser's, with one of the
+// user agents and Accept-Language headers picked at random. Each request
+// picks again, so no two fingerprints have to match.
+func setBrowserHeaders(header http.Header, userAgents, acceptLanguages []string) {
+	header.Set("User-Agent", pick(userAgents))
+	header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+	header.Set("Accept-Language", pick(acceptLanguages))
+	header.Set("Accept-Encoding", "gzip, deflate")
+	header.Set("DNT", "1")
+	header.Set("Connection", "keep-alive")
+	header.Set("Upgrade-Insecure-Requests", "1")
+}
+
+func pick(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[rand.N(len(values))]
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
-	"time"
 
+	"github.com/yair/where-its-at/pkg/domain"
 	"golang.org/x/net/html"
 )
 
@@ -16,6 +16,9 @@ type ResidentAdvisorScraper struct {
 }
 
 func NewResidentAdvisorScraper(config ScrapingConfig) *ResidentAdvisorScraper {
+	config.Source = "resident_advisor"
+	config.Selectors = selectorSetsFor(config.Source, config.Selectors)
+
 	return &ResidentAdvisorScraper{
 		BaseScraper: NewBaseScraper(config),
 		baseURL:     "https://ra.co",
@@ -112,72 +115,26 @@ func (r *ResidentAdvisorScraper) ScrapeEventsByLocation(ctx context.Context, cit
 }
 
 func (r *ResidentAdvisorScraper) parseEventList(doc *html.Node, limit int) []ScrapedEvent {
-	events := []ScrapedEvent{}
-
-	// Find event containers (RA uses specific CSS classes)
-	eventNodes := r.findEventNodes(doc)
-
-	for i, node := range eventNodes {
-		if i >= limit {
-			break
-		}
-
-		event := r.parseEventNode(node)
-		if event.Title != "" {
-			events = append(events, event)
-		}
-	}
-
-	return events
+	return r.parseListing(doc, limit, r.parseEventNode)
 }
 
-func (r *ResidentAdvisorScraper) findEventNodes(doc *html.Node) []*html.Node {
-	var eventNodes []*html.Node
-
-	var findEvents func(*html.Node)
-	findEvents = func(n *html.Node) {
-		if n.Type == html.ElementNode {
-			// Look for common RA event container classes
-			if r.hasClass(n, "eventListingItem") ||
-				r.hasClass(n, "event-item") ||
-				r.hasClass(n, "listing-item") {
-				eventNodes = append(eventNodes, n)
-				return
-			}
-		}
-
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			findEvents(c)
-		}
-	}
-
-	findEvents(doc)
-	return eventNodes
-}
-
-func (r *ResidentAdvisorScraper) parseEventNode(node *html.Node) ScrapedEvent {
+func (r *ResidentAdvisorScraper) parseEventNode(node *html.Node, set SelectorSet) ScrapedEvent {
 	event := ScrapedEvent{}
 
 	// Extract title/artist
-	if titleNode := r.findNodeByClass(node, "title"); titleNode != nil {
-		event.Title = r.ExtractText(r.getTextContent(titleNode))
-		event.ArtistName = event.Title
-	}
+	event.Title = r.selectText(node, set.Title)
+	event.ArtistName = event.Title
 
 	// Extract date
-	if dateNode := r.findNodeByClass(node, "date"); dateNode != nil {
-		dateStr := r.ExtractText(r.getTextContent(dateNode))
+	if dateStr := r.selectText(node, set.Date); dateStr != "" {
 		event.Date = r.ParseDate(dateStr)
 	}
 
 	// Extract venue
-	if venueNode := r.findNodeByClass(node, "venue"); venueNode != nil {
-		event.VenueName = r.ExtractText(r.getTextContent(venueNode))
-	}
+	event.VenueName = r.selectText(node, set.Venue)
 
 	// Extract location
-	if locationNode := r.findNodeByClass(node, "location"); locationNode != nil {
-		location := r.ExtractText(r.getTextContent(locationNode))
+	if location := r.selectText(node, set.Location); location != "" {
 		r.parseLocation(location, &event)
 	}
 
@@ -194,9 +151,6 @@ func (r *ResidentAdvisorScraper) parseEventNode(node *html.Node) ScrapedEvent {
 	if event.ArtistName == "" {
 		event.ArtistName = "Unknown Artist"
 	}
-	if event.Date.IsZero() {
-		event.Date = time.Now()
-	}
 
 	return event
 }
@@ -249,48 +203,14 @@ func (r *ResidentAdvisorScraper) mergeEventDetails(base, detailed ScrapedEvent)
 	return base
 }
 
+// getLocationCode looks the city up by any of its names in the city table,
+// where operators can add codes for cities it lacks
 func (r *ResidentAdvisorScraper) getLocationCode(city, country string) string {
-	// Common RA location codes
-	locationMap := map[string]string{
-		"london":        "london",
-		"berlin":        "berlin",
-		"new york":      "newyork",
-		"paris":         "paris",
-		"amsterdam":     "amsterdam",
-		"barcelona":     "barcelona",
-		"melbourne":     "melbourne",
-		"sydney":        "sydney",
-		"tokyo":         "tokyo",
-		"montreal":      "montreal",
-		"toronto":       "toronto",
-		"chicago":       "chicago",
-		"los angeles":   "losangeles",
-		"san francisco": "sanfrancisco",
-		"detroit":       "detroit",
-		"miami":         "miami",
-		"dublin":        "dublin",
-		"glasgow":       "glasgow",
-		"manchester":    "manchester",
-		"birmingham":    "birmingham",
-		"bristol":       "bristol",
-		"leeds":         "leeds",
-		"liverpool":     "liverpool",
-		"rome":          "rome",
-		"milan":         "milan",
-		"madrid":        "madrid",
-		"valencia":      "valencia",
-		"lisbon":        "lisbon",
-		"prague":        "prague",
-		"vienna":        "vienna",
-		"zurich":        "zurich",
-		"oslo":          "oslo",
-		"stockholm":     "stockholm",
-		"copenhagen":    "copenhagen",
-		"helsinki":      "helsinki",
-	}
-
-	cityLower := strings.ToLower(city)
-	return locationMap[cityLower]
+	known, ok := domain.LookupCity(city)
+	if !ok {
+		return ""
+	}
+	return known.Codes[r.GetName()]
 }
 
 func (r *ResidentAdvisorScraper) parseLocation(location string, event *ScrapedEvent) {
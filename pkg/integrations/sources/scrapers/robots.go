@@ -0,0 +1,225 @@
+package scrapers
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ErrDisallowedByRobots is returned for pages a site's robots.txt keeps us out of
+var ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+
+const (
+	robotsTTL = 24 * time.Hour
+	// robotsRetryTTL is how long an unreachable robots.txt keeps a site off
+	// limits before it is fetched again
+	robotsRetryTTL = 10 * time.Minute
+	// robotsMaxSize is the most of a robots.txt that is read, as Google does
+	robotsMaxSize = 500 * 1024
+)
+
+type robotsRule struct {
+	pattern *regexp.Regexp
+	length  int
+	allow   bool
+}
+
+// robotsRules are the rules of the group in a robots.txt that applies to us
+type robotsRules struct {
+	rules       []robotsRule
+	disallowAll bool
+	expiresAt   time.Time
+}
+
+// allowed picks the longest matching rule, with Allow winning a tie
+func (r *robotsRules) allowed(path string) bool {
+	if r.disallowAll {
+		return false
+	}
+
+	best := robotsRule{length: -1, allow: true}
+	for _, rule := range r.rules {
+		if !rule.pattern.MatchString(path) {
+			continue
+		}
+		if rule.length > best.length || (rule.length == best.length && rule.allow) {
+			best = rule
+		}
+	}
+	return best.allow
+}
+
+// robotsPolicy fetches and caches robots.txt per host
+type robotsPolicy struct {
+	mu        sync.Mutex
+	hosts     map[string]*robotsRules
+	userAgent string
+	now       func() time.Time
+}
+
+func newRobotsPolicy(userAgent string) *robotsPolicy {
+	return &robotsPolicy{
+		hosts:     make(map[string]*robotsRules),
+		userAgent: userAgent,
+		now:       time.Now,
+	}
+}
+
+// Allowed reports whether pageURL may be fetched, calling fetch for the
+// host's robots.txt the first time the host is seen and once a day after
+func (p *robotsPolicy) Allowed(ctx context.Context, pageURL string, fetch func(ctx context.Context, robotsURL string) (*http.Response, error)) (bool, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return false, fmt.Errorf("invalid URL: %w", err)
+	}
+
+	// Holding the lock across the fetch keeps concurrent searches from all
+	// fetching the same robots.txt
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rules, ok := p.hosts[u.Host]
+	if !ok || p.now().After(rules.expiresAt) {
+		robotsURL := (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}).String()
+		rules, err = p.fetch(ctx, robotsURL, fetch)
+		if err != nil {
+			return false, err
+		}
+		p.hosts[u.Host] = rules
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.allowed(path), nil
+}
+
+// fetch follows the usual conventions: a missing robots.txt allows
+// everything, while a server error or an unreachable site disallows
+// everything for a while
+func (p *robotsPolicy) fetch(ctx context.Context, robotsURL string, fetch func(ctx context.Context, robotsURL string) (*http.Response, error)) (*robotsRules, error) {
+	resp, err := fetch(ctx, robotsURL)
+	if err != nil {
+		// Only a site that can't be reached counts against it
+		if ctx.Err() != nil || errors.Is(err, domain.ErrRateLimitExceeded) {
+			return nil, err
+		}
+		return &robotsRules{disallowAll: true, expiresAt: p.now().Add(robotsRetryTTL)}, nil
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return &robotsRules{disallowAll: true, expiresAt: p.now().Add(robotsRetryTTL)}, nil
+	case resp.StatusCode >= 400:
+		return &robotsRules{expiresAt: p.now().Add(robotsTTL)}, nil
+	}
+
+	rules := parseRobots(io.LimitReader(resp.Body, robotsMaxSize), p.userAgent)
+	rules.expiresAt = p.now().Add(robotsTTL)
+	return rules, nil
+}
+
+// parseRobots keeps the rules of the most specific group naming our user
+// agent, falling back to the * group
+func parseRobots(r io.Reader, userAgent string) *robotsRules {
+	userAgent = strings.ToLower(userAgent)
+
+	var (
+		groups       = map[string][]robotsRule{}
+		current      []string
+		inRules      bool
+		bestAgent    string
+		haveWildcard bool
+	)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// A user-agent line after rules starts a new group
+			if inRules {
+				current = nil
+				inRules = false
+			}
+			agent := strings.ToLower(value)
+			current = append(current, agent)
+			if _, seen := groups[agent]; !seen {
+				groups[agent] = nil
+			}
+		case "allow", "disallow":
+			inRules = true
+			// An empty Disallow allows everything
+			if value == "" {
+				continue
+			}
+			rule := robotsRule{
+				pattern: robotsPattern(value),
+				length:  len(value),
+				allow:   key == "allow",
+			}
+			for _, agent := range current {
+				groups[agent] = append(groups[agent], rule)
+			}
+		}
+	}
+
+	for agent := range groups {
+		if agent == "*" {
+			haveWildcard = true
+			continue
+		}
+		if strings.Contains(userAgent, agent) && len(agent) > len(bestAgent) {
+			bestAgent = agent
+		}
+	}
+
+	switch {
+	case bestAgent != "":
+		return &robotsRules{rules: groups[bestAgent]}
+	case haveWildcard:
+		return &robotsRules{rules: groups["*"]}
+	default:
+		return &robotsRules{}
+	}
+}
+
+// robotsPattern turns a path pattern into a regexp anchored at the start,
+// supporting * wildcards and a trailing $ end anchor
+func robotsPattern(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
@@ -0,0 +1,261 @@
+package scrapers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/html"
+)
+
+// SelectorSet says where a site's listings keep their events. Every field
+// lists class names, any of which matches; a field left empty isn't
+// extracted.
+type SelectorSet struct {
+	Name     string   `json:"name"`
+	Event    []string `json:"event"`
+	Title    []string `json:"title,omitempty"`
+	Date     []string `json:"date,omitempty"`
+	Venue    []string `json:"venue,omitempty"`
+	Location []string `json:"location,omitempty"`
+}
+
+// Selectors holds the selector sets of each scraper by name, in the order
+// they are tried. Later sets are fallbacks for when a site changes markup.
+type Selectors map[string][]SelectorSet
+
+// defaultSelectors are the sets a scraper uses when none are configured
+var defaultSelectors = Selectors{
+	"resident_advisor": {{
+		Name:     "default",
+		Event:    []string{"eventListingItem", "event-item", "listing-item"},
+		Title:    []string{"title"},
+		Date:     []string{"date"},
+		Venue:    []string{"venue"},
+		Location: []string{"location"},
+	}},
+	"bandcamp": {{
+		Name:     "default",
+		Event:    []string{"show-item"},
+		Date:     []string{"date"},
+		Venue:    []string{"venue"},
+		Location: []string{"location"},
+	}},
+}
+
+// LoadSelectors reads selector sets from a JSON file shaped like Selectors
+func LoadSelectors(path string) (Selectors, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read selectors: %w", err)
+	}
+
+	var selectors Selectors
+	if err := json.Unmarshal(data, &selectors); err != nil {
+		return nil, fmt.Errorf("failed to parse selectors: %w", err)
+	}
+
+	for scraper, sets := range selectors {
+		for i, set := range sets {
+			if set.Name == "" {
+				return nil, fmt.Errorf("%s selector set %d has no name", scraper, i)
+			}
+			if len(set.Event) == 0 {
+				return nil, fmt.Errorf("%s selector set %q has no event classes", scraper, set.Name)
+			}
+		}
+	}
+
+	return selectors, nil
+}
+
+// selectorSetsFor returns the configured sets, or the built-in ones
+func selectorSetsFor(scraper string, configured []SelectorSet) []SelectorSet {
+	if len(configured) > 0 {
+		return configured
+	}
+	return defaultSelectors[scraper]
+}
+
+// waitSelectorFor matches the event containers of any of the sets
+func waitSelectorFor(sets []SelectorSet) string {
+	var selectors []string
+	for _, set := range sets {
+		for _, class := range set.Event {
+			selectors = append(selectors, fmt.Sprintf("[class*=%q]", class))
+		}
+	}
+	return strings.Join(selectors, ", ")
+}
+
+// emptyParseThreshold is how many pages in a row have to come back with no
+// events before parsing counts as broken. A single empty page is usually a
+// search without results, not a markup change.
+const emptyParseThreshold = 3
+
+// ParseHealth is how well a scraper's selectors match the pages it fetches
+type ParseHealth struct {
+	// Status is "unknown" before any page is parsed, "no_results_parsed"
+	// after emptyParseThreshold non-empty pages in a row yielded no events,
+	// and "ok" otherwise
+	Status                 string     `json:"status"`
+	ConsecutiveEmptyParses int        `json:"consecutive_empty_parses"`
+	LastEmptyParseAt       *time.Time `json:"last_empty_parse_at,omitempty"`
+	// SelectorSet is the set that last found events
+	SelectorSet string `json:"selector_set,omitempty"`
+}
+
+type parseTracker struct {
+	mu          sync.Mutex
+	parsed      bool
+	emptyParses int
+	lastEmptyAt time.Time
+	selectorSet string
+}
+
+func (t *parseTracker) record(set string, found int, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.parsed = true
+	if found == 0 {
+		t.emptyParses++
+		t.lastEmptyAt = now
+		return
+	}
+	t.emptyParses = 0
+	t.selectorSet = set
+}
+
+func (t *parseTracker) health() ParseHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	health := ParseHealth{
+		Status:                 "ok",
+		ConsecutiveEmptyParses: t.emptyParses,
+		SelectorSet:            t.selectorSet,
+	}
+	switch {
+	case !t.parsed:
+		health.Status = "unknown"
+	case t.emptyParses >= emptyParseThreshold:
+		health.Status = "no_results_parsed"
+	}
+	if !t.lastEmptyAt.IsZero() {
+		lastEmptyAt := t.lastEmptyAt
+		health.LastEmptyParseAt = &lastEmptyAt
+	}
+	return health
+}
+
+// ParseHealth reports whether the scraper's selectors still find events
+func (b *BaseScraper) ParseHealth() ParseHealth {
+	return b.parses.health()
+}
+
+// parseListing runs each selector set over a listing page until one finds
+// events, so a fallback set takes over when the site's markup changes.
+// Pages with no text are not held against the selectors.
+func (b *BaseScraper) parseListing(doc *html.Node, limit int, parse func(node *html.Node, set SelectorSet) ScrapedEvent) []ScrapedEvent {
+	for _, set := range b.selectors {
+		events := []ScrapedEvent{}
+		for _, node := range findNodesByClasses(doc, set.Event) {
+			if len(events) >= limit {
+				break
+			}
+			if event := parse(node, set); event.Title != "" {
+				events = append(events, event)
+			}
+		}
+
+		if len(events) > 0 {
+			b.parses.record(set.Name, len(events), time.Now())
+			return events
+		}
+	}
+
+	if strings.TrimSpace(textContent(doc)) != "" {
+		b.parses.record("", 0, time.Now())
+	}
+	return []ScrapedEvent{}
+}
+
+// selectText returns the trimmed text of the first node below node with one
+// of the classes
+func (b *BaseScraper) selectText(node *html.Node, classes []string) string {
+	if len(classes) == 0 {
+		return ""
+	}
+	if found := findNodeByClasses(node, classes); found != nil {
+		return b.ExtractText(textContent(found))
+	}
+	return ""
+}
+
+func hasAnyClass(node *html.Node, classes []string) bool {
+	for _, attr := range node.Attr {
+		if attr.Key != "class" {
+			continue
+		}
+		for _, class := range classes {
+			if strings.Contains(attr.Val, class) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func findNodeByClasses(node *html.Node, classes []string) *html.Node {
+	if node.Type == html.ElementNode && hasAnyClass(node, classes) {
+		return node
+	}
+
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		if result := findNodeByClasses(c, classes); result != nil {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// findNodesByClasses doesn't look inside matches, so an event container's
+// children aren't counted as events of their own
+func findNodesByClasses(node *html.Node, classes []string) []*html.Node {
+	var nodes []*html.Node
+
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode && hasAnyClass(n, classes) {
+			nodes = append(nodes, n)
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+
+	find(node)
+	return nodes
+}
+
+func textContent(node *html.Node) string {
+	if node.Type == html.TextNode {
+		return node.Data
+	}
+	// Script and style contents aren't page text
+	if node.Type == html.ElementNode && (node.Data == "script" || node.Data == "style") {
+		return ""
+	}
+
+	var text strings.Builder
+	for c := node.FirstChild; c != nil; c = c.NextSibling {
+		text.WriteString(textContent(c))
+	}
+	return text.String()
+}
@@ -0,0 +1,498 @@
+package scrapers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"golang.org/x/net/html"
+)
+
+// VenuePage is a page where a venue announces its gigs: a Facebook page, an
+// Instagram profile or its own website
+type VenuePage struct {
+	URL     string `json:"url"`
+	Name    string `json:"name"`
+	City    string `json:"city"`
+	Country string `json:"country"`
+}
+
+type VenuePagesConfig struct {
+	ScrapingConfig
+	Pages []VenuePage
+	// FacebookToken is a Graph API access token. With one, Facebook pages are
+	// read through the API; without, they are scraped like any other page.
+	FacebookToken string
+}
+
+// VenuePagesScraper pulls upcoming events from venue pages an operator has
+// listed, for small venues that only announce gigs on social media. Facebook
+// pages go through the Graph API when there is a token; every other page is
+// read for schema.org Event markup, which is also the only way to get events
+// off Instagram, as its API has none.
+type VenuePagesScraper struct {
+	*BaseScraper
+	pages         []VenuePage
+	facebookToken string
+	graphURL      string
+
+	mu      sync.Mutex
+	fetched map[string]venueFetch
+}
+
+// venueFetch is one page's events, kept for CacheTTL because every search
+// reads every page
+type venueFetch struct {
+	events    []ScrapedEvent
+	fetchedAt time.Time
+}
+
+func NewVenuePagesScraper(config VenuePagesConfig) *VenuePagesScraper {
+	config.Source = "venue_pages"
+	return &VenuePagesScraper{
+		BaseScraper:   NewBaseScraper(config.ScrapingConfig),
+		pages:         config.Pages,
+		facebookToken: config.FacebookToken,
+		graphURL:      "https://graph.facebook.com/v19.0",
+		fetched:       make(map[string]venueFetch),
+	}
+}
+
+func (v *VenuePagesScraper) GetName() string {
+	return "venue_pages"
+}
+
+// ScrapeEvents returns upcoming events whose title, artist or venue mention
+// the query
+func (v *VenuePagesScraper) ScrapeEvents(ctx context.Context, query string, limit int) ([]ScrapedEvent, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil, fmt.Errorf("query cannot be empty")
+	}
+
+	events, err := v.upcomingEvents(ctx, v.pages)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := []ScrapedEvent{}
+	for _, event := range events {
+		if strings.Contains(strings.ToLower(event.Title), query) ||
+			strings.Contains(strings.ToLower(event.ArtistName), query) ||
+			strings.Contains(strings.ToLower(event.VenueName), query) {
+			matched = append(matched, event)
+		}
+	}
+
+	return limitEvents(matched, limit), nil
+}
+
+// ScrapeEventsByLocation returns upcoming events at the venues in the city
+func (v *VenuePagesScraper) ScrapeEventsByLocation(ctx context.Context, city, country string, limit int) ([]ScrapedEvent, error) {
+	city = strings.TrimSpace(city)
+	if city == "" {
+		return nil, fmt.Errorf("city cannot be empty")
+	}
+
+	var pages []VenuePage
+	for _, page := range v.pages {
+		if !domain.SameCity(page.City, city) {
+			continue
+		}
+		if country != "" && page.Country != "" && !domain.SameCountry(page.Country, country) {
+			continue
+		}
+		pages = append(pages, page)
+	}
+
+	events, err := v.upcomingEvents(ctx, pages)
+	if err != nil {
+		return nil, err
+	}
+	return limitEvents(events, limit), nil
+}
+
+// upcomingEvents reads the pages one at a time, soonest event first. A page
+// that fails is skipped unless every page does.
+func (v *VenuePagesScraper) upcomingEvents(ctx context.Context, pages []VenuePage) ([]ScrapedEvent, error) {
+	now := time.Now()
+	events := []ScrapedEvent{}
+	var lastErr error
+	failed := 0
+
+	for _, page := range pages {
+		pageEvents, err := v.pageEvents(ctx, page)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+			failed++
+			continue
+		}
+		for _, event := range pageEvents {
+			if event.Date.After(now) {
+				events = append(events, event)
+			}
+		}
+	}
+
+	if failed > 0 && failed == len(pages) {
+		return nil, fmt.Errorf("failed to read venue pages: %w", lastErr)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Date.Before(events[j].Date)
+	})
+	return events, nil
+}
+
+func (v *VenuePagesScraper) pageEvents(ctx context.Context, page VenuePage) ([]ScrapedEvent, error) {
+	v.mu.Lock()
+	cached, ok := v.fetched[page.URL]
+	v.mu.Unlock()
+	if ok && time.Since(cached.fetchedAt) < v.config.CacheTTL {
+		return cached.events, nil
+	}
+
+	var events []ScrapedEvent
+	var err error
+	if pageID := facebookPageID(page.URL); pageID != "" && v.facebookToken != "" {
+		events, err = v.graphEvents(ctx, pageID, page)
+	} else {
+		events, err = v.markupEvents(ctx, page)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	v.fetched[page.URL] = venueFetch{events: events, fetchedAt: time.Now()}
+	v.mu.Unlock()
+
+	return events, nil
+}
+
+type graphEventsResponse struct {
+	Data []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		StartTime   string `json:"start_time"`
+		Timezone    string `json:"timezone"`
+		TicketURI   string `json:"ticket_uri"`
+		IsCanceled  bool   `json:"is_canceled"`
+		Place       struct {
+			Name     string `json:"name"`
+			Location struct {
+				City    string `json:"city"`
+				Country string `json:"country"`
+			} `json:"location"`
+		} `json:"place"`
+	} `json:"data"`
+}
+
+// graphTimeLayout is how the Graph API writes event times
+const graphTimeLayout = "2006-01-02T15:04:05-0700"
+
+func (v *VenuePagesScraper) graphEvents(ctx context.Context, pageID string, page VenuePage) ([]ScrapedEvent, error) {
+	params := url.Values{}
+	params.Set("time_filter", "upcoming")
+	params.Set("fields", "id,name,description,start_time,timezone,ticket_uri,is_canceled,place")
+	params.Set("limit", "50")
+	params.Set("access_token", v.facebookToken)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s/events?%s", v.graphURL, url.PathEscape(pageID), params.Encode()), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("graph API returned status %d for %s", resp.StatusCode, pageID)
+	}
+
+	var result graphEventsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	events := make([]ScrapedEvent, 0, len(result.Data))
+	for _, e := range result.Data {
+		date, err := time.Parse(graphTimeLayout, e.StartTime)
+		if err != nil {
+			continue
+		}
+
+		event := ScrapedEvent{
+			Title:       e.Name,
+			ArtistName:  e.Name,
+			Date:        date,
+			Timezone:    e.Timezone,
+			VenueName:   e.Place.Name,
+			City:        e.Place.Location.City,
+			Country:     e.Place.Location.Country,
+			URL:         "https://www.facebook.com/events/" + e.ID,
+			Description: e.Description,
+		}
+		if e.TicketURI != "" {
+			event.URL = e.TicketURI
+		}
+		if e.IsCanceled {
+			event.Status = domain.EventCancelled
+		}
+		events = append(events, withVenueDefaults(event, page))
+	}
+
+	return events, nil
+}
+
+// markupEvents reads the schema.org events a page embeds as JSON-LD
+func (v *VenuePagesScraper) markupEvents(ctx context.Context, page VenuePage) ([]ScrapedEvent, error) {
+	resp, err := v.MakeRequest(ctx, page.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", page.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", page.URL, resp.StatusCode)
+	}
+
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	events := []ScrapedEvent{}
+	for _, script := range jsonLDScripts(doc) {
+		var data any
+		if err := json.Unmarshal([]byte(script), &data); err != nil {
+			continue
+		}
+		for _, object := range schemaEvents(data) {
+			if event, ok := schemaEvent(object); ok {
+				if event.URL == "" {
+					event.URL = page.URL
+				}
+				events = append(events, withVenueDefaults(event, page))
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// withVenueDefaults fills in what the venue's own listing leaves out
+func withVenueDefaults(event ScrapedEvent, page VenuePage) ScrapedEvent {
+	if event.VenueName == "" {
+		event.VenueName = page.Name
+	}
+	if event.City == "" {
+		event.City = page.City
+	}
+	if event.Country == "" {
+		event.Country = page.Country
+	}
+	return event
+}
+
+// facebookPageID returns the page name or numeric ID of a Facebook page URL,
+// or "" for other sites
+func facebookPageID(pageURL string) string {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	if host := u.Hostname(); host != "facebook.com" && !strings.HasSuffix(host, ".facebook.com") {
+		return ""
+	}
+	if id := u.Query().Get("id"); id != "" {
+		return id
+	}
+
+	segment, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	if segment == "profile.php" {
+		return ""
+	}
+	return segment
+}
+
+func jsonLDScripts(node *html.Node) []string {
+	var scripts []string
+
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" {
+			for _, attr := range n.Attr {
+				if attr.Key == "type" && strings.EqualFold(attr.Val, "application/ld+json") && n.FirstChild != nil {
+					scripts = append(scripts, n.FirstChild.Data)
+				}
+			}
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+
+	find(node)
+	return scripts
+}
+
+// schemaEvents finds every Event object in a JSON-LD document, including
+// ones in arrays and @graph lists
+func schemaEvents(data any) []map[string]any {
+	var events []map[string]any
+
+	switch value := data.(type) {
+	case []any:
+		for _, item := range value {
+			events = append(events, schemaEvents(item)...)
+		}
+	case map[string]any:
+		if isSchemaEvent(value["@type"]) {
+			events = append(events, value)
+		}
+		if graph, ok := value["@graph"]; ok {
+			events = append(events, schemaEvents(graph)...)
+		}
+	}
+
+	return events
+}
+
+// isSchemaEvent matches Event and its subtypes such as MusicEvent
+func isSchemaEvent(schemaType any) bool {
+	switch value := schemaType.(type) {
+	case string:
+		return strings.HasSuffix(value, "Event")
+	case []any:
+		for _, item := range value {
+			if isSchemaEvent(item) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func schemaEvent(object map[string]any) (ScrapedEvent, bool) {
+	date, ok := parseSchemaDate(schemaString(object["startDate"]))
+	if !ok {
+		return ScrapedEvent{}, false
+	}
+
+	event := ScrapedEvent{
+		Title:       schemaString(object["name"]),
+		Date:        date,
+		URL:         schemaString(object["url"]),
+		Description: schemaString(object["description"]),
+		Status:      schemaStatus(schemaString(object["eventStatus"])),
+	}
+	if event.Title == "" {
+		return ScrapedEvent{}, false
+	}
+
+	event.ArtistName = event.Title
+	if performer := schemaFirst(object["performer"]); performer != nil {
+		if name := schemaString(performer["name"]); name != "" {
+			event.ArtistName = name
+		}
+	}
+
+	if location := schemaFirst(object["location"]); location != nil {
+		event.VenueName = schemaString(location["name"])
+		event.VenuePhone = schemaString(location["telephone"])
+		event.VenueWebsite = schemaString(location["url"])
+		// The address is a PostalAddress, or just a line of text
+		event.VenueAddress = schemaString(location["address"])
+		if address := schemaFirst(location["address"]); address != nil {
+			event.VenueAddress = strings.TrimSpace(schemaString(address["streetAddress"]) + " " + schemaString(address["postalCode"]))
+			event.City = schemaString(address["addressLocality"])
+			if country := schemaFirst(address["addressCountry"]); country != nil {
+				event.Country = schemaString(country["name"])
+			} else {
+				event.Country = schemaString(address["addressCountry"])
+			}
+		}
+	}
+
+	if offer := schemaFirst(object["offers"]); offer != nil {
+		if price := schemaString(offer["price"]); price != "" {
+			event.Price = strings.TrimSpace(price + " " + schemaString(offer["priceCurrency"]))
+		}
+	}
+
+	return event, true
+}
+
+// schemaStatus maps a schema.org EventStatusType, written as a URL or a bare
+// name. EventMovedOnline still goes ahead, so it counts as scheduled.
+func schemaStatus(value string) domain.EventStatus {
+	switch value[strings.LastIndex(value, "/")+1:] {
+	case "EventCancelled":
+		return domain.EventCancelled
+	case "EventPostponed":
+		return domain.EventPostponed
+	case "EventRescheduled":
+		return domain.EventRescheduled
+	default:
+		return ""
+	}
+}
+
+// parseSchemaDate accepts the ISO 8601 forms venues use, with or without a
+// time and zone
+func parseSchemaDate(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func schemaString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// schemaFirst returns an object, or the first object of a list of them
+func schemaFirst(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case []any:
+		for _, item := range v {
+			if object, ok := item.(map[string]any); ok {
+				return object
+			}
+		}
+	}
+	return nil
+}
+
+func limitEvents(events []ScrapedEvent, limit int) []ScrapedEvent {
+	if limit > 0 && len(events) > limit {
+		return events[:limit]
+	}
+	return events
+}
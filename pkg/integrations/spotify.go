@@ -3,6 +3,7 @@ package integrations
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,20 +11,24 @@ import (
 	"time"
 
 	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/integrations/oauth"
 )
 
 type SpotifyClient struct {
 	baseURL      string
+	accountsURL  string
+	redirectURI  string
 	clientID     string
 	clientSecret string
 	httpClient   *http.Client
-	accessToken  string
-	tokenExpiry  time.Time
+	tokens       oauth.TokenProvider
 }
 
 type SpotifyConfig struct {
 	ClientID     string
 	ClientSecret string
+	RedirectURI  string // Required for the user authorization flow
 }
 
 func NewSpotifyClient(config SpotifyConfig) (*SpotifyClient, error) {
@@ -31,58 +36,25 @@ func NewSpotifyClient(config SpotifyConfig) (*SpotifyClient, error) {
 		return nil, fmt.Errorf("spotify client ID and secret are required")
 	}
 
+	httpClient := httpclient.NewFor("spotify", 10*time.Second)
+	accountsURL := "https://accounts.spotify.com"
+
 	return &SpotifyClient{
 		baseURL:      "https://api.spotify.com/v1",
+		accountsURL:  accountsURL,
+		redirectURI:  config.RedirectURI,
 		clientID:     config.ClientID,
 		clientSecret: config.ClientSecret,
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		httpClient:   httpClient,
+		tokens:       newSpotifyTokenProvider(httpClient, accountsURL, config.ClientID, config.ClientSecret),
 	}, nil
 }
 
-type spotifyTokenResponse struct {
-	AccessToken string `json:"access_token"`
-	TokenType   string `json:"token_type"`
-	ExpiresIn   int    `json:"expires_in"`
-}
-
-func (c *SpotifyClient) getAccessToken(ctx context.Context) error {
-	if c.accessToken != "" && time.Now().Before(c.tokenExpiry) {
-		return nil
-	}
-
-	tokenURL := "https://accounts.spotify.com/api/token"
-	data := url.Values{}
-	data.Set("grant_type", "client_credentials")
-
-	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(data.Encode()))
-	if err != nil {
-		return fmt.Errorf("failed to create token request: %w", err)
-	}
-
-	req.SetBasicAuth(c.clientID, c.clientSecret)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to get access token: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to get access token: status %d", resp.StatusCode)
-	}
-
-	var tokenResp spotifyTokenResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return fmt.Errorf("failed to decode token response: %w", err)
-	}
-
-	c.accessToken = tokenResp.AccessToken
-	c.tokenExpiry = time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second).Add(-5 * time.Minute)
-
-	return nil
+// newSpotifyTokenProvider caches client credentials tokens, which last an
+// hour, and refreshes them five minutes early
+func newSpotifyTokenProvider(httpClient *http.Client, accountsURL, clientID, clientSecret string) *oauth.CachedTokenProvider {
+	fetch := oauth.ClientCredentials(httpClient, accountsURL+"/api/token", clientID, clientSecret)
+	return oauth.NewCachedTokenProvider(fetch, 5*time.Minute)
 }
 
 type spotifyArtist struct {
@@ -103,10 +75,6 @@ type spotifySearchResponse struct {
 }
 
 func (c *SpotifyClient) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
-	if err := c.getAccessToken(ctx); err != nil {
-		return nil, err
-	}
-
 	if limit <= 0 {
 		limit = 10
 	}
@@ -125,9 +93,7 @@ func (c *SpotifyClient) SearchArtists(ctx context.Context, query string, limit i
 		return nil, fmt.Errorf("failed to create search request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := oauth.Do(c.httpClient, c.tokens, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search artists: %w", err)
 	}
@@ -144,31 +110,13 @@ func (c *SpotifyClient) SearchArtists(ctx context.Context, query string, limit i
 
 	artists := make([]domain.Artist, 0, len(searchResp.Artists.Items))
 	for _, spotifyArtist := range searchResp.Artists.Items {
-		artist := domain.Artist{
-			ID:   fmt.Sprintf("spotify_%s", spotifyArtist.ID),
-			Name: spotifyArtist.Name,
-			ExternalIDs: domain.ExternalIDs{
-				SpotifyID: spotifyArtist.ID,
-			},
-			Genres:     spotifyArtist.Genres,
-			Popularity: spotifyArtist.Popularity,
-		}
-
-		if len(spotifyArtist.Images) > 0 {
-			artist.ImageURL = spotifyArtist.Images[0].URL
-		}
-
-		artists = append(artists, artist)
+		artists = append(artists, spotifyArtist.toDomain())
 	}
 
 	return artists, nil
 }
 
 func (c *SpotifyClient) GetArtist(ctx context.Context, spotifyID string) (*domain.Artist, error) {
-	if err := c.getAccessToken(ctx); err != nil {
-		return nil, err
-	}
-
 	artistURL := fmt.Sprintf("%s/artists/%s", c.baseURL, spotifyID)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", artistURL, nil)
@@ -176,9 +124,7 @@ func (c *SpotifyClient) GetArtist(ctx context.Context, spotifyID string) (*domai
 		return nil, fmt.Errorf("failed to create artist request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.accessToken)
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := oauth.Do(c.httpClient, c.tokens, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get artist: %w", err)
 	}
@@ -196,19 +142,227 @@ func (c *SpotifyClient) GetArtist(ctx context.Context, spotifyID string) (*domai
 		return nil, fmt.Errorf("failed to decode artist response: %w", err)
 	}
 
-	artist := &domain.Artist{
-		ID:   fmt.Sprintf("spotify_%s", spotifyArtist.ID),
-		Name: spotifyArtist.Name,
+	artist := spotifyArtist.toDomain()
+	return &artist, nil
+}
+
+// ArtistImage returns the artist's largest Spotify picture, finding the
+// artist by its Spotify ID when it has one and by name otherwise
+func (c *SpotifyClient) ArtistImage(ctx context.Context, artist domain.Artist) (string, error) {
+	if spotifyID := artist.ExternalIDs.SpotifyID; spotifyID != "" {
+		found, err := c.GetArtist(ctx, spotifyID)
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		return found.ImageURL, nil
+	}
+
+	matches, err := c.SearchArtists(ctx, artist.Name, 5)
+	if err != nil {
+		return "", err
+	}
+	for _, match := range matches {
+		if strings.EqualFold(match.Name, artist.Name) {
+			return match.ImageURL, nil
+		}
+	}
+	return "", nil
+}
+
+type spotifyRelatedArtistsResponse struct {
+	Artists []spotifyArtist `json:"artists"`
+}
+
+// RelatedArtists returns the artists Spotify's listeners also play,
+// finding the artist by its Spotify ID when it has one and by name
+// otherwise
+func (c *SpotifyClient) RelatedArtists(ctx context.Context, artist domain.Artist, limit int) ([]domain.Artist, error) {
+	spotifyID := artist.ExternalIDs.SpotifyID
+	if spotifyID == "" {
+		spotifyID = strings.TrimPrefix(artist.ID, "spotify_")
+		if spotifyID == artist.ID {
+			matches, err := c.SearchArtists(ctx, artist.Name, 5)
+			if err != nil {
+				return nil, err
+			}
+			spotifyID = ""
+			for _, match := range matches {
+				if strings.EqualFold(match.Name, artist.Name) {
+					spotifyID = match.ExternalIDs.SpotifyID
+					break
+				}
+			}
+			if spotifyID == "" {
+				return []domain.Artist{}, nil
+			}
+		}
+	}
+
+	relatedURL := fmt.Sprintf("%s/artists/%s/related-artists", c.baseURL, url.PathEscape(spotifyID))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", relatedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create related artists request: %w", err)
+	}
+
+	resp, err := oauth.Do(c.httpClient, c.tokens, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get related artists: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, domain.ErrArtistNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("spotify related artists failed: status %d", resp.StatusCode)
+	}
+
+	var relatedResp spotifyRelatedArtistsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&relatedResp); err != nil {
+		return nil, fmt.Errorf("failed to decode related artists response: %w", err)
+	}
+
+	if limit > 0 && len(relatedResp.Artists) > limit {
+		relatedResp.Artists = relatedResp.Artists[:limit]
+	}
+	artists := make([]domain.Artist, 0, len(relatedResp.Artists))
+	for _, related := range relatedResp.Artists {
+		artists = append(artists, related.toDomain())
+	}
+
+	return artists, nil
+}
+
+// spotifyPlaylistMaxPages caps how many pages of 100 tracks are read from a
+// playlist, as Spotify allows playlists of up to 10,000
+const spotifyPlaylistMaxPages = 20
+
+type spotifyPlaylistTracksResponse struct {
+	Items []struct {
+		Track *struct {
+			Artists []spotifyArtist `json:"artists"`
+		} `json:"track"`
+	} `json:"items"`
+	Next string `json:"next"`
+}
+
+// ParseSpotifyPlaylistID takes a playlist's share link, its spotify:playlist
+// URI or the bare ID and returns the ID
+func ParseSpotifyPlaylistID(input string) (string, error) {
+	input = strings.TrimSpace(input)
+
+	id := input
+	if rest, ok := strings.CutPrefix(input, "spotify:playlist:"); ok {
+		id = rest
+	} else if strings.Contains(input, "/") {
+		parsed, err := url.Parse(input)
+		if err != nil || !strings.HasSuffix(parsed.Host, "spotify.com") {
+			return "", domain.ErrInvalidRequest
+		}
+		// Share links can carry a locale first, as in /intl-de/playlist/ID
+		segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+		id = ""
+		for i := 0; i < len(segments)-1; i++ {
+			if segments[i] == "playlist" {
+				id = segments[i+1]
+				break
+			}
+		}
+	}
+
+	if id == "" || len(id) > 64 {
+		return "", domain.ErrInvalidRequest
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return "", domain.ErrInvalidRequest
+		}
+	}
+	return id, nil
+}
+
+// GetPlaylistArtists returns the distinct artists on a public playlist's
+// tracks, in the order they first appear. Local files, which have no
+// Spotify artist, are skipped.
+func (c *SpotifyClient) GetPlaylistArtists(ctx context.Context, playlistID string) ([]domain.Artist, error) {
+	pageURL := fmt.Sprintf("%s/playlists/%s/tracks?fields=%s&limit=100",
+		c.baseURL,
+		url.PathEscape(playlistID),
+		url.QueryEscape("items(track(artists(id,name))),next"),
+	)
+
+	artists := []domain.Artist{}
+	seen := make(map[string]bool)
+	for page := 0; pageURL != "" && page < spotifyPlaylistMaxPages; page++ {
+		tracksResp, err := c.getPlaylistPage(ctx, pageURL)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range tracksResp.Items {
+			if item.Track == nil {
+				continue
+			}
+			for _, artist := range item.Track.Artists {
+				if artist.ID == "" || seen[artist.ID] {
+					continue
+				}
+				seen[artist.ID] = true
+				artists = append(artists, artist.toDomain())
+			}
+		}
+		pageURL = tracksResp.Next
+	}
+
+	return artists, nil
+}
+
+func (c *SpotifyClient) getPlaylistPage(ctx context.Context, pageURL string) (*spotifyPlaylistTracksResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create playlist request: %w", err)
+	}
+
+	resp, err := oauth.Do(c.httpClient, c.tokens, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist tracks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Private playlists answer 404 to client credentials too
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, domain.ErrPlaylistNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("spotify playlist tracks failed: status %d", resp.StatusCode)
+	}
+
+	var tracksResp spotifyPlaylistTracksResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tracksResp); err != nil {
+		return nil, fmt.Errorf("failed to decode playlist response: %w", err)
+	}
+
+	return &tracksResp, nil
+}
+
+func (a spotifyArtist) toDomain() domain.Artist {
+	artist := domain.Artist{
+		ID:   fmt.Sprintf("spotify_%s", a.ID),
+		Name: a.Name,
 		ExternalIDs: domain.ExternalIDs{
-			SpotifyID: spotifyArtist.ID,
+			SpotifyID: a.ID,
 		},
-		Genres:     spotifyArtist.Genres,
-		Popularity: spotifyArtist.Popularity,
+		Genres:     domain.NormalizeGenres(a.Genres),
+		Popularity: a.Popularity,
 	}
 
-	if len(spotifyArtist.Images) > 0 {
-		artist.ImageURL = spotifyArtist.Images[0].URL
+	if len(a.Images) > 0 {
+		artist.ImageURL = a.Images[0].URL
 	}
 
-	return artist, nil
+	return artist
 }
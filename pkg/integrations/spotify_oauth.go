@@ -0,0 +1,219 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// SpotifyLibraryScopes are the scopes needed to read a user's followed and top artists
+var SpotifyLibraryScopes = []string{"user-follow-read", "user-top-read"}
+
+type spotifyUserTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type spotifyUserProfile struct {
+	ID string `json:"id"`
+}
+
+type spotifyFollowingResponse struct {
+	Artists struct {
+		Items   []spotifyArtist `json:"items"`
+		Cursors struct {
+			After string `json:"after"`
+		} `json:"cursors"`
+	} `json:"artists"`
+}
+
+type spotifyTopArtistsResponse struct {
+	Items []spotifyArtist `json:"items"`
+}
+
+// AuthorizeURL returns the Spotify consent page URL for the authorization-code flow
+func (c *SpotifyClient) AuthorizeURL(state string) string {
+	params := url.Values{}
+	params.Set("client_id", c.clientID)
+	params.Set("response_type", "code")
+	params.Set("redirect_uri", c.redirectURI)
+	params.Set("scope", strings.Join(SpotifyLibraryScopes, " "))
+	params.Set("state", state)
+
+	return c.accountsURL + "/authorize?" + params.Encode()
+}
+
+// ExchangeCode trades an authorization code for a user token. The returned
+// token has no AccountID yet; callers look it up with GetCurrentUserID.
+func (c *SpotifyClient) ExchangeCode(ctx context.Context, code string) (*domain.OAuthToken, error) {
+	if code == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+	if c.redirectURI == "" {
+		return nil, fmt.Errorf("spotify redirect URI is not configured")
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", c.redirectURI)
+
+	return c.requestUserToken(ctx, data)
+}
+
+// RefreshUserToken obtains a new access token for a stored user token
+func (c *SpotifyClient) RefreshUserToken(ctx context.Context, token *domain.OAuthToken) (*domain.OAuthToken, error) {
+	if token == nil || token.RefreshToken == "" {
+		return nil, fmt.Errorf("refresh token is required")
+	}
+
+	data := url.Values{}
+	data.Set("grant_type", "refresh_token")
+	data.Set("refresh_token", token.RefreshToken)
+
+	refreshed, err := c.requestUserToken(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed.AccountID = token.AccountID
+	refreshed.UserID = token.UserID
+	refreshed.CreatedAt = token.CreatedAt
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+
+	return refreshed, nil
+}
+
+func (c *SpotifyClient) requestUserToken(ctx context.Context, data url.Values) (*domain.OAuthToken, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", c.accountsURL+"/api/token", strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token request: %w", err)
+	}
+
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request user token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to request user token: status %d", resp.StatusCode)
+	}
+
+	var tokenResp spotifyUserTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
+	}
+
+	return &domain.OAuthToken{
+		Provider:     "spotify",
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		Scope:        tokenResp.Scope,
+		ExpiresAt:    time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func (c *SpotifyClient) GetCurrentUserID(ctx context.Context, accessToken string) (string, error) {
+	var profile spotifyUserProfile
+	if err := c.getWithUserToken(ctx, c.baseURL+"/me", accessToken, &profile); err != nil {
+		return "", err
+	}
+
+	if profile.ID == "" {
+		return "", fmt.Errorf("spotify profile has no user ID")
+	}
+
+	return profile.ID, nil
+}
+
+// GetFollowedArtists pages through every artist the user follows
+func (c *SpotifyClient) GetFollowedArtists(ctx context.Context, accessToken string) ([]domain.Artist, error) {
+	artists := []domain.Artist{}
+	after := ""
+
+	for {
+		followingURL := fmt.Sprintf("%s/me/following?type=artist&limit=50", c.baseURL)
+		if after != "" {
+			followingURL += "&after=" + url.QueryEscape(after)
+		}
+
+		var page spotifyFollowingResponse
+		if err := c.getWithUserToken(ctx, followingURL, accessToken, &page); err != nil {
+			return nil, err
+		}
+
+		for _, spotifyArtist := range page.Artists.Items {
+			artists = append(artists, spotifyArtist.toDomain())
+		}
+
+		after = page.Artists.Cursors.After
+		if after == "" || len(page.Artists.Items) == 0 {
+			break
+		}
+	}
+
+	return artists, nil
+}
+
+func (c *SpotifyClient) GetTopArtists(ctx context.Context, accessToken string, limit int) ([]domain.Artist, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	topURL := fmt.Sprintf("%s/me/top/artists?limit=%d&time_range=medium_term", c.baseURL, limit)
+
+	var topResp spotifyTopArtistsResponse
+	if err := c.getWithUserToken(ctx, topURL, accessToken, &topResp); err != nil {
+		return nil, err
+	}
+
+	artists := make([]domain.Artist, 0, len(topResp.Items))
+	for _, spotifyArtist := range topResp.Items {
+		artists = append(artists, spotifyArtist.toDomain())
+	}
+
+	return artists, nil
+}
+
+func (c *SpotifyClient) getWithUserToken(ctx context.Context, requestURL, accessToken string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call spotify: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("spotify request failed: status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode spotify response: %w", err)
+	}
+
+	return nil
+}
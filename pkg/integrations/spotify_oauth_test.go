@@ -0,0 +1,206 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func newSpotifyOAuthTestClient(serverURL string) *SpotifyClient {
+	return &SpotifyClient{
+		baseURL:      serverURL + "/v1",
+		accountsURL:  serverURL,
+		redirectURI:  "http://localhost:8080/api/auth/spotify/callback",
+		clientID:     "test-id",
+		clientSecret: "test-secret",
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func TestSpotifyClient_AuthorizeURL(t *testing.T) {
+	client := newSpotifyOAuthTestClient("https://accounts.example.com")
+
+	authURL, err := url.Parse(client.AuthorizeURL("state-123"))
+	if err != nil {
+		t.Fatalf("failed to parse authorize URL: %v", err)
+	}
+
+	query := authURL.Query()
+	if authURL.Path != "/authorize" {
+		t.Errorf("expected /authorize path, got %s", authURL.Path)
+	}
+	if query.Get("state") != "state-123" {
+		t.Errorf("expected state-123, got %s", query.Get("state"))
+	}
+	if query.Get("response_type") != "code" {
+		t.Errorf("expected response_type code, got %s", query.Get("response_type"))
+	}
+	if !strings.Contains(query.Get("scope"), "user-follow-read") {
+		t.Errorf("expected follow scope, got %s", query.Get("scope"))
+	}
+}
+
+func TestSpotifyClient_UserTokens(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Form.Get("grant_type") {
+		case "authorization_code":
+			if r.Form.Get("code") != "good-code" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(spotifyUserTokenResponse{
+				AccessToken:  "user-access",
+				RefreshToken: "user-refresh",
+				Scope:        "user-follow-read user-top-read",
+				ExpiresIn:    3600,
+			})
+		case "refresh_token":
+			json.NewEncoder(w).Encode(spotifyUserTokenResponse{
+				AccessToken: "refreshed-access",
+				ExpiresIn:   3600,
+			})
+		}
+	}))
+	defer mockServer.Close()
+
+	client := newSpotifyOAuthTestClient(mockServer.URL)
+	ctx := context.Background()
+
+	t.Run("exchange code", func(t *testing.T) {
+		token, err := client.ExchangeCode(ctx, "good-code")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if token.AccessToken != "user-access" || token.RefreshToken != "user-refresh" {
+			t.Errorf("unexpected token: %+v", token)
+		}
+		if token.Provider != "spotify" {
+			t.Errorf("expected provider spotify, got %s", token.Provider)
+		}
+	})
+
+	t.Run("rejected code", func(t *testing.T) {
+		if _, err := client.ExchangeCode(ctx, "bad-code"); err == nil {
+			t.Error("expected error for rejected code")
+		}
+	})
+
+	t.Run("empty code", func(t *testing.T) {
+		if _, err := client.ExchangeCode(ctx, ""); err != domain.ErrInvalidRequest {
+			t.Errorf("expected ErrInvalidRequest, got %v", err)
+		}
+	})
+
+	t.Run("refresh keeps refresh token and account", func(t *testing.T) {
+		stored := &domain.OAuthToken{
+			Provider:     "spotify",
+			AccountID:    "user-1",
+			AccessToken:  "old-access",
+			RefreshToken: "user-refresh",
+		}
+
+		refreshed, err := client.RefreshUserToken(ctx, stored)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if refreshed.AccessToken != "refreshed-access" {
+			t.Errorf("expected refreshed-access, got %s", refreshed.AccessToken)
+		}
+		if refreshed.RefreshToken != "user-refresh" || refreshed.AccountID != "user-1" {
+			t.Errorf("expected refresh token and account to be kept, got %+v", refreshed)
+		}
+	})
+
+	t.Run("refresh without refresh token", func(t *testing.T) {
+		if _, err := client.RefreshUserToken(ctx, &domain.OAuthToken{}); err == nil {
+			t.Error("expected error without refresh token")
+		}
+	})
+}
+
+func TestSpotifyClient_UserLibrary(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer user-access" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/me":
+			json.NewEncoder(w).Encode(spotifyUserProfile{ID: "user-1"})
+		case "/v1/me/following":
+			var page spotifyFollowingResponse
+			if r.URL.Query().Get("after") == "" {
+				page.Artists.Items = []spotifyArtist{{ID: "a1", Name: "First"}}
+				page.Artists.Cursors.After = "a1"
+			} else {
+				page.Artists.Items = []spotifyArtist{{ID: "a2", Name: "Second"}}
+			}
+			json.NewEncoder(w).Encode(page)
+		case "/v1/me/top/artists":
+			json.NewEncoder(w).Encode(spotifyTopArtistsResponse{
+				Items: []spotifyArtist{{ID: "a3", Name: "Top", Popularity: 80}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client := newSpotifyOAuthTestClient(mockServer.URL)
+	ctx := context.Background()
+
+	t.Run("current user", func(t *testing.T) {
+		userID, err := client.GetCurrentUserID(ctx, "user-access")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if userID != "user-1" {
+			t.Errorf("expected user-1, got %s", userID)
+		}
+	})
+
+	t.Run("followed artists across pages", func(t *testing.T) {
+		artists, err := client.GetFollowedArtists(ctx, "user-access")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(artists) != 2 {
+			t.Fatalf("expected 2 artists, got %d", len(artists))
+		}
+		if artists[1].ExternalIDs.SpotifyID != "a2" {
+			t.Errorf("expected second page artist a2, got %s", artists[1].ExternalIDs.SpotifyID)
+		}
+	})
+
+	t.Run("top artists", func(t *testing.T) {
+		artists, err := client.GetTopArtists(ctx, "user-access", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(artists) != 1 || artists[0].ID != "spotify_a3" {
+			t.Errorf("unexpected top artists: %+v", artists)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		if _, err := client.GetFollowedArtists(ctx, "expired"); err == nil {
+			t.Error("expected error for invalid token")
+		}
+	})
+}
@@ -3,10 +3,14 @@ package integrations
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/oauth"
 )
 
 func TestNewSpotifyClient(t *testing.T) {
@@ -59,11 +63,7 @@ func TestSpotifyClient_SearchArtists(t *testing.T) {
 		switch r.URL.Path {
 		case "/api/token":
 			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(spotifyTokenResponse{
-				AccessToken: "test-token",
-				TokenType:   "Bearer",
-				ExpiresIn:   3600,
-			})
+			w.Write([]byte(`{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`))
 		case "/v1/search":
 			query := r.URL.Query().Get("q")
 			if query == "" {
@@ -105,8 +105,7 @@ func TestSpotifyClient_SearchArtists(t *testing.T) {
 		clientID:     "test-id",
 		clientSecret: "test-secret",
 		httpClient:   &http.Client{Timeout: 10 * time.Second},
-		accessToken:  "test-token",
-		tokenExpiry:  time.Now().Add(time.Hour),
+		tokens:       oauth.StaticToken("test-token"),
 	}
 
 	t.Run("successful search", func(t *testing.T) {
@@ -132,11 +131,7 @@ func TestSpotifyClient_GetArtist(t *testing.T) {
 		switch r.URL.Path {
 		case "/api/token":
 			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(spotifyTokenResponse{
-				AccessToken: "test-token",
-				TokenType:   "Bearer",
-				ExpiresIn:   3600,
-			})
+			w.Write([]byte(`{"access_token":"test-token","token_type":"Bearer","expires_in":3600}`))
 		case "/v1/artists/test-id":
 			w.Header().Set("Content-Type", "application/json")
 			artist := spotifyArtist{
@@ -164,8 +159,7 @@ func TestSpotifyClient_GetArtist(t *testing.T) {
 		clientID:     "test-id",
 		clientSecret: "test-secret",
 		httpClient:   &http.Client{Timeout: 10 * time.Second},
-		accessToken:  "test-token",
-		tokenExpiry:  time.Now().Add(time.Hour),
+		tokens:       oauth.StaticToken("test-token"),
 	}
 
 	t.Run("successful get", func(t *testing.T) {
@@ -193,3 +187,104 @@ func TestSpotifyClient_GetArtist(t *testing.T) {
 		}
 	})
 }
+
+func TestSpotifyClient_RelatedArtists(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/search":
+			var response spotifySearchResponse
+			response.Artists.Items = []spotifyArtist{{ID: "tribute", Name: "Radiohead Tribute"}, {ID: "rh", Name: "Radiohead"}}
+			json.NewEncoder(w).Encode(response)
+		case "/v1/artists/rh/related-artists":
+			json.NewEncoder(w).Encode(spotifyRelatedArtistsResponse{Artists: []spotifyArtist{
+				{ID: "ty", Name: "Thom Yorke", Genres: []string{"art rock"}},
+				{ID: "ts", Name: "The Smile"},
+				{ID: "pj", Name: "Portishead"},
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer mockServer.Close()
+
+	client := &SpotifyClient{
+		baseURL:    mockServer.URL + "/v1",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		tokens:     oauth.StaticToken("test-token"),
+	}
+	ctx := context.Background()
+
+	related, err := client.RelatedArtists(ctx, domain.Artist{Name: "radiohead"}, 2)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(related) != 2 || related[0].Name != "Thom Yorke" || related[0].ExternalIDs.SpotifyID != "ty" {
+		t.Errorf("expected the first 2 related artists found by exact name, got %+v", related)
+	}
+
+	if related, err := client.RelatedArtists(ctx, domain.Artist{ID: "spotify_rh", Name: "Radiohead"}, 10); err != nil || len(related) != 3 {
+		t.Errorf("expected the stored Spotify ID used, got %d, %v", len(related), err)
+	}
+	if related, err := client.RelatedArtists(ctx, domain.Artist{Name: "Nobody"}, 10); err != nil || len(related) != 0 {
+		t.Errorf("expected nothing for an artist Spotify doesn't have, got %d, %v", len(related), err)
+	}
+}
+
+func TestParseSpotifyPlaylistID(t *testing.T) {
+	for input, want := range map[string]string{
+		"37i9dQZF1DXcBWIGoYBM5M":                                           "37i9dQZF1DXcBWIGoYBM5M",
+		" spotify:playlist:37i9dQZF1DXcBWIGoYBM5M ":                        "37i9dQZF1DXcBWIGoYBM5M",
+		"https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M?si=ab":   "37i9dQZF1DXcBWIGoYBM5M",
+		"https://open.spotify.com/intl-de/playlist/37i9dQZF1DXcBWIGoYBM5M": "37i9dQZF1DXcBWIGoYBM5M",
+	} {
+		if got, err := ParseSpotifyPlaylistID(input); err != nil || got != want {
+			t.Errorf("%q: got %q, %v", input, got, err)
+		}
+	}
+
+	for _, input := range []string{"", "https://example.com/playlist/abc", "https://open.spotify.com/album/abc", "not an id"} {
+		if _, err := ParseSpotifyPlaylistID(input); err == nil {
+			t.Errorf("%q: expected an error", input)
+		}
+	}
+}
+
+func TestSpotifyClient_GetPlaylistArtists(t *testing.T) {
+	var mockServer *httptest.Server
+	mockServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/v1/playlists/gigs/tracks" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("offset") == "" {
+			w.Write([]byte(`{"items":[
+				{"track":{"artists":[{"id":"rh","name":"Radiohead"},{"id":"ty","name":"Thom Yorke"}]}},
+				{"track":null},
+				{"track":{"artists":[{"id":null,"name":"Local File"}]}}
+			],"next":"` + mockServer.URL + `/v1/playlists/gigs/tracks?offset=100"}`))
+			return
+		}
+		w.Write([]byte(`{"items":[{"track":{"artists":[{"id":"rh","name":"Radiohead"},{"id":"pj","name":"Portishead"}]}}],"next":null}`))
+	}))
+	defer mockServer.Close()
+
+	client := &SpotifyClient{
+		baseURL:    mockServer.URL + "/v1",
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		tokens:     oauth.StaticToken("test-token"),
+	}
+
+	artists, err := client.GetPlaylistArtists(context.Background(), "gigs")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(artists) != 3 || artists[0].Name != "Radiohead" || artists[2].ExternalIDs.SpotifyID != "pj" {
+		t.Errorf("expected the distinct artists across pages, got %+v", artists)
+	}
+
+	if _, err := client.GetPlaylistArtists(context.Background(), "private"); !errors.Is(err, domain.ErrPlaylistNotFound) {
+		t.Errorf("expected ErrPlaylistNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,436 @@
+package integrations
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ArtistSearchSource is a music source quick enough to ask while someone
+// types
+type ArtistSearchSource interface {
+	SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error)
+}
+
+const (
+	defaultSuggestLimit       = 8
+	defaultSuggestSourceWait  = 40 * time.Millisecond
+	defaultSuggestSourceLimit = 5
+	defaultSuggestMaxEntries  = 200000
+	// suggestSourceTimeout bounds a source call after the suggestion stopped
+	// waiting on it; what it finds still goes into the index
+	suggestSourceTimeout = 2 * time.Second
+	// suggestAskedTTL is how long a query isn't sent to the source again
+	suggestAskedTTL = 10 * time.Minute
+	// suggestMaxInFlight bounds the source calls type-ahead can have open
+	suggestMaxInFlight = 4
+	// Names are matched on trigrams when no prefix does, for typos, from
+	// this many characters and sharing at least this part of the query's
+	minFuzzySuggestLength = 4
+	minFuzzySuggestShare  = 0.5
+)
+
+// ArtistSuggesterConfig sets up an ArtistSuggester. Artists fills the index;
+// Source, when set, is asked for names the index doesn't complete, waiting
+// at most SourceWait (40ms by default) for up to SourceLimit (5) artists.
+type ArtistSuggesterConfig struct {
+	Artists     domain.ArtistListRepository
+	Source      ArtistSearchSource
+	SourceName  string
+	SourceWait  time.Duration
+	SourceLimit int
+	MaxEntries  int // names kept in the index, 200000 by default
+	Logger      *slog.Logger
+}
+
+// ArtistSuggester completes artist names as they're typed from an index in
+// memory of the cached artists, by the start of any word of their names or,
+// for typos, by the trigrams they share. Names the index lacks are asked of
+// one fast source with a tight cap; the artists it finds join the index, so
+// the next keystroke finds them even when the source answered too late.
+type ArtistSuggester struct {
+	config ArtistSuggesterConfig
+
+	mu    sync.RWMutex
+	index *suggestIndex
+	asked map[string]time.Time
+
+	inFlight chan struct{}
+	now      func() time.Time
+}
+
+func NewArtistSuggester(config ArtistSuggesterConfig) *ArtistSuggester {
+	if config.SourceWait <= 0 {
+		config.SourceWait = defaultSuggestSourceWait
+	}
+	if config.SourceLimit <= 0 {
+		config.SourceLimit = defaultSuggestSourceLimit
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = defaultSuggestMaxEntries
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &ArtistSuggester{
+		config:   config,
+		index:    newSuggestIndex(config.MaxEntries),
+		asked:    make(map[string]time.Time),
+		inFlight: make(chan struct{}, suggestMaxInFlight),
+		now:      time.Now,
+	}
+}
+
+// Refresh rebuilds the index from the cached artists. Names learned from the
+// source since the last refresh are kept.
+func (s *ArtistSuggester) Refresh(ctx context.Context) error {
+	if s.config.Artists == nil {
+		return nil
+	}
+
+	index := newSuggestIndex(s.config.MaxEntries)
+	err := s.config.Artists.Each(ctx, func(artist domain.Artist) error {
+		index.add(suggestionFor(artist, ""))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range s.index.entries {
+		if entry.suggestion.Source != "" {
+			index.add(entry.suggestion)
+		}
+	}
+	index.sortWords()
+	s.index = index
+	return nil
+}
+
+// RunRefresh refreshes the index now and then every interval until ctx is
+// done
+func (s *ArtistSuggester) RunRefresh(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Refresh(ctx); err != nil && ctx.Err() == nil {
+			s.config.Logger.Warn("failed to refresh artist suggestions", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Suggest returns up to limit artist names completing query: names starting
+// with it first, then names with a later word starting with it, then close
+// misspellings, each most popular first
+func (s *ArtistSuggester) Suggest(ctx context.Context, query string, limit int) []domain.Suggestion {
+	key := domain.ArtistNameKey(query)
+	if key == "" {
+		return []domain.Suggestion{}
+	}
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+
+	s.mu.RLock()
+	matches := s.index.lookup(key)
+	s.mu.RUnlock()
+
+	if prefixMatches(matches) < limit {
+		if answered := s.askSource(ctx, query, key); answered != nil {
+			timer := time.NewTimer(s.config.SourceWait)
+			select {
+			case <-answered:
+				s.mu.RLock()
+				matches = s.index.lookup(key)
+				s.mu.RUnlock()
+			case <-timer.C:
+			case <-ctx.Done():
+			}
+			timer.Stop()
+		}
+	}
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	suggestions := make([]domain.Suggestion, len(matches))
+	for i, match := range matches {
+		suggestions[i] = match.entry.suggestion
+	}
+	return suggestions
+}
+
+// DidYouMean returns the known artist a search that found nothing most
+// likely meant: the best completion of query or, for typos, the closest
+// name. Queries naming a known artist and ones too short to tell have none.
+func (s *ArtistSuggester) DidYouMean(query string) (string, bool) {
+	key := domain.ArtistNameKey(query)
+	if len(key) < minFuzzySuggestLength {
+		return "", false
+	}
+
+	s.mu.RLock()
+	matches := s.index.lookup(key)
+	s.mu.RUnlock()
+
+	if len(matches) == 0 || matches[0].tier == suggestExact {
+		return "", false
+	}
+	return matches[0].entry.suggestion.Name, true
+}
+
+// askSource sends query to the source unless it was asked lately or too many
+// calls are open. The returned channel closes once the artists it found are
+// in the index; it's nil when the source isn't asked.
+func (s *ArtistSuggester) askSource(ctx context.Context, query, key string) <-chan struct{} {
+	if s.config.Source == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	now := s.now()
+	if asked, ok := s.asked[key]; ok && now.Sub(asked) < suggestAskedTTL {
+		s.mu.Unlock()
+		return nil
+	}
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		s.mu.Unlock()
+		return nil
+	}
+	for earlier, asked := range s.asked {
+		if now.Sub(asked) >= suggestAskedTTL {
+			delete(s.asked, earlier)
+		}
+	}
+	s.asked[key] = now
+	s.mu.Unlock()
+
+	// The call outlives the suggestion so late answers still feed the index
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), suggestSourceTimeout)
+	answered := make(chan struct{})
+	go func() {
+		defer func() { <-s.inFlight }()
+		defer close(answered)
+		defer cancel()
+
+		artists, err := s.config.Source.SearchArtists(ctx, query, s.config.SourceLimit)
+		if err != nil {
+			s.config.Logger.DebugContext(ctx, "artist suggestion source failed", "source", s.config.SourceName, "error", err)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for _, artist := range artists {
+			s.index.insert(suggestionFor(artist, s.config.SourceName))
+		}
+	}()
+	return answered
+}
+
+func suggestionFor(artist domain.Artist, source string) domain.Suggestion {
+	return domain.Suggestion{
+		Name:       artist.Name,
+		ArtistID:   artist.ID,
+		ImageURL:   artist.ImageURL,
+		Popularity: artist.Popularity,
+		Source:     source,
+	}
+}
+
+// How a name matched a query, best first
+const (
+	suggestExact = iota
+	suggestPrefix
+	suggestWordPrefix
+	suggestFuzzy
+)
+
+type suggestEntry struct {
+	suggestion domain.Suggestion
+	key        string
+}
+
+// suggestWord is an entry's key from the start of one of its words on
+type suggestWord struct {
+	key   string
+	entry *suggestEntry
+	first bool
+}
+
+type suggestMatch struct {
+	entry *suggestEntry
+	tier  int
+	share float64
+}
+
+// suggestIndex finds names by the start of their words, kept sorted, and by
+// their trigrams
+type suggestIndex struct {
+	entries    map[string]*suggestEntry
+	words      []suggestWord
+	trigrams   map[string][]*suggestEntry
+	maxEntries int
+}
+
+func newSuggestIndex(maxEntries int) *suggestIndex {
+	return &suggestIndex{
+		entries:    make(map[string]*suggestEntry),
+		trigrams:   make(map[string][]*suggestEntry),
+		maxEntries: maxEntries,
+	}
+}
+
+// add indexes a name, leaving the words to be sorted with sortWords. A name
+// already indexed keeps whichever suggestion is the more popular, and the
+// local artist's ID over a source's.
+func (x *suggestIndex) add(suggestion domain.Suggestion) *suggestEntry {
+	key := domain.ArtistNameKey(suggestion.Name)
+	if key == "" {
+		return nil
+	}
+	if entry, ok := x.entries[key]; ok {
+		local, wasLocal := suggestion.Source == "", entry.suggestion.Source == ""
+		if local && !wasLocal || local == wasLocal && suggestion.Popularity > entry.suggestion.Popularity {
+			entry.suggestion = suggestion
+		}
+		return nil
+	}
+	if len(x.entries) >= x.maxEntries {
+		return nil
+	}
+
+	entry := &suggestEntry{suggestion: suggestion, key: key}
+	x.entries[key] = entry
+	for i := range key {
+		if i == 0 || key[i-1] == ' ' {
+			x.words = append(x.words, suggestWord{key: key[i:], entry: entry, first: i == 0})
+		}
+	}
+	for _, gram := range trigrams(key) {
+		x.trigrams[gram] = append(x.trigrams[gram], entry)
+	}
+	return entry
+}
+
+// insert adds a name to an index already sorted
+func (x *suggestIndex) insert(suggestion domain.Suggestion) {
+	before := len(x.words)
+	if x.add(suggestion) == nil {
+		return
+	}
+	added := slices.Clone(x.words[before:])
+	x.words = x.words[:before]
+	for _, word := range added {
+		i := sort.Search(len(x.words), func(i int) bool { return x.words[i].key >= word.key })
+		x.words = slices.Insert(x.words, i, word)
+	}
+}
+
+func (x *suggestIndex) sortWords() {
+	sort.Slice(x.words, func(i, j int) bool { return x.words[i].key < x.words[j].key })
+}
+
+// lookup ranks every name matching key
+func (x *suggestIndex) lookup(key string) []suggestMatch {
+	best := make(map[*suggestEntry]int)
+	start := sort.Search(len(x.words), func(i int) bool { return x.words[i].key >= key })
+	for _, word := range x.words[start:] {
+		if !strings.HasPrefix(word.key, key) {
+			break
+		}
+		tier := suggestWordPrefix
+		if word.first {
+			tier = suggestPrefix
+			if word.key == key {
+				tier = suggestExact
+			}
+		}
+		if known, ok := best[word.entry]; !ok || tier < known {
+			best[word.entry] = tier
+		}
+	}
+
+	matches := make([]suggestMatch, 0, len(best))
+	for entry, tier := range best {
+		matches = append(matches, suggestMatch{entry: entry, tier: tier})
+	}
+
+	if len(key) >= minFuzzySuggestLength {
+		grams := trigrams(key)
+		shared := make(map[*suggestEntry]int)
+		for _, gram := range grams {
+			for _, entry := range x.trigrams[gram] {
+				shared[entry]++
+			}
+		}
+		for entry, count := range shared {
+			share := float64(count) / float64(len(grams))
+			if _, ok := best[entry]; ok || share < minFuzzySuggestShare {
+				continue
+			}
+			matches = append(matches, suggestMatch{entry: entry, tier: suggestFuzzy, share: share})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.tier != b.tier {
+			return a.tier < b.tier
+		}
+		if a.share != b.share {
+			return a.share > b.share
+		}
+		if a.entry.suggestion.Popularity != b.entry.suggestion.Popularity {
+			return a.entry.suggestion.Popularity > b.entry.suggestion.Popularity
+		}
+		if len(a.entry.key) != len(b.entry.key) {
+			return len(a.entry.key) < len(b.entry.key)
+		}
+		return a.entry.key < b.entry.key
+	})
+	return matches
+}
+
+// prefixMatches counts the matches that aren't misspellings
+func prefixMatches(matches []suggestMatch) int {
+	count := 0
+	for _, match := range matches {
+		if match.tier < suggestFuzzy {
+			count++
+		}
+	}
+	return count
+}
+
+// trigrams lists the distinct three-letter runs of a key, the first anchored
+// to its start so names beginning alike share more
+func trigrams(key string) []string {
+	runes := []rune(" " + key)
+	var grams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		gram := string(runes[i : i+3])
+		if !slices.Contains(grams, gram) {
+			grams = append(grams, gram)
+		}
+	}
+	return grams
+}
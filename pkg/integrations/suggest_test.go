@@ -0,0 +1,178 @@
+package integrations
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubArtistList struct {
+	artists []domain.Artist
+}
+
+func (s *stubArtistList) Each(ctx context.Context, fn func(domain.Artist) error) error {
+	for _, artist := range s.artists {
+		if err := fn(artist); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stubArtistSearch answers after delay with artists, recording the queries
+type stubArtistSearch struct {
+	mu      sync.Mutex
+	queries []string
+	artists []domain.Artist
+	delay   time.Duration
+}
+
+func (s *stubArtistSearch) SearchArtists(ctx context.Context, query string, limit int) ([]domain.Artist, error) {
+	s.mu.Lock()
+	s.queries = append(s.queries, query)
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if len(s.artists) > limit {
+		return s.artists[:limit], nil
+	}
+	return s.artists, nil
+}
+
+func (s *stubArtistSearch) asked() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.queries...)
+}
+
+func suggestionNames(suggestions []domain.Suggestion) []string {
+	names := make([]string, len(suggestions))
+	for i, suggestion := range suggestions {
+		names[i] = suggestion.Name
+	}
+	return names
+}
+
+func TestArtistSuggester(t *testing.T) {
+	artists := &stubArtistList{artists: []domain.Artist{
+		{ID: "1", Name: "Radiohead", Popularity: 90},
+		{ID: "2", Name: "The Radio Dept.", Popularity: 60},
+		{ID: "3", Name: "Radio Moscow", Popularity: 50},
+		{ID: "4", Name: "Rad", Popularity: 10},
+		{ID: "5", Name: "Portishead", Popularity: 70},
+		{ID: "6", Name: "Sigur Rós", Popularity: 65},
+	}}
+	ctx := context.Background()
+
+	t.Run("completes from the index", func(t *testing.T) {
+		suggester := NewArtistSuggester(ArtistSuggesterConfig{Artists: artists})
+		if err := suggester.Refresh(ctx); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		names := suggestionNames(suggester.Suggest(ctx, "rad", 10))
+		expected := []string{"Rad", "Radiohead", "Radio Moscow", "The Radio Dept."}
+		if len(names) != len(expected) {
+			t.Fatalf("expected %v, got %v", expected, names)
+		}
+		for i := range expected {
+			if names[i] != expected[i] {
+				t.Fatalf("expected %v, got %v", expected, names)
+			}
+		}
+
+		if names := suggestionNames(suggester.Suggest(ctx, "rad", 2)); len(names) != 2 || names[1] != "Radiohead" {
+			t.Errorf("expected the limit to keep the best two, got %v", names)
+		}
+		if names := suggestionNames(suggester.Suggest(ctx, "SIGUR ROS", 10)); len(names) != 1 || names[0] != "Sigur Rós" {
+			t.Errorf("expected case and accents to be ignored, got %v", names)
+		}
+		if names := suggestionNames(suggester.Suggest(ctx, "portihsead", 10)); len(names) != 1 || names[0] != "Portishead" {
+			t.Errorf("expected the misspelling to be matched, got %v", names)
+		}
+		if suggestions := suggester.Suggest(ctx, " .", 10); len(suggestions) != 0 {
+			t.Errorf("expected no suggestions for punctuation, got %v", suggestions)
+		}
+	})
+
+	t.Run("suggests what a search meant", func(t *testing.T) {
+		suggester := NewArtistSuggester(ArtistSuggesterConfig{Artists: artists})
+		suggester.Refresh(ctx)
+
+		for query, expected := range map[string]string{"Radiohed": "Radiohead", "portishaed": "Portishead", "sigur": "Sigur Rós"} {
+			if name, ok := suggester.DidYouMean(query); !ok || name != expected {
+				t.Errorf("%s: expected %s, got %q", query, expected, name)
+			}
+		}
+		for _, query := range []string{"radiohead", "rad", "xyzzy"} {
+			if name, ok := suggester.DidYouMean(query); ok {
+				t.Errorf("%s: expected no suggestion, got %q", query, name)
+			}
+		}
+	})
+
+	t.Run("asks the source for names the index lacks", func(t *testing.T) {
+		source := &stubArtistSearch{artists: []domain.Artist{
+			{ID: "deezer_399", Name: "Bicep", Popularity: 80},
+			{ID: "deezer_1", Name: "Bicep Tribute Band"},
+		}}
+		suggester := NewArtistSuggester(ArtistSuggesterConfig{Artists: artists, Source: source, SourceName: "deezer", SourceWait: time.Second})
+		suggester.Refresh(ctx)
+
+		suggestions := suggester.Suggest(ctx, "bice", 10)
+		if len(suggestions) != 2 || suggestions[0].Name != "Bicep" || suggestions[0].Source != "deezer" {
+			t.Fatalf("expected the source's artists, got %+v", suggestions)
+		}
+
+		// Asked once, then answered from the index, which keeps the
+		// source's names across refreshes
+		suggester.Suggest(ctx, "Bice", 10)
+		suggester.Refresh(ctx)
+		if names := suggestionNames(suggester.Suggest(ctx, "bicep", 10)); len(names) != 2 {
+			t.Errorf("expected the learned names, got %v", names)
+		}
+		if asked := source.asked(); len(asked) != 2 || asked[0] != "bice" || asked[1] != "bicep" {
+			t.Errorf("expected each query asked once, got %v", asked)
+		}
+	})
+
+	t.Run("answers without a slow source", func(t *testing.T) {
+		source := &stubArtistSearch{artists: []domain.Artist{{ID: "deezer_2", Name: "Portico Quartet"}}, delay: 200 * time.Millisecond}
+		suggester := NewArtistSuggester(ArtistSuggesterConfig{Artists: artists, Source: source, SourceName: "deezer", SourceWait: 10 * time.Millisecond})
+		suggester.Refresh(ctx)
+
+		start := time.Now()
+		names := suggestionNames(suggester.Suggest(ctx, "port", 10))
+		if time.Since(start) > 150*time.Millisecond || len(names) != 1 || names[0] != "Portishead" {
+			t.Fatalf("expected the index's answer without waiting, got %v", names)
+		}
+
+		// The late answer still joins the index
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if names := suggestionNames(suggester.Suggest(ctx, "portico", 10)); len(names) == 1 {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Error("expected the slow source's artist to be learned")
+	})
+
+	t.Run("skips the source when the index has enough", func(t *testing.T) {
+		source := &stubArtistSearch{}
+		suggester := NewArtistSuggester(ArtistSuggesterConfig{Artists: artists, Source: source})
+		suggester.Refresh(ctx)
+
+		suggester.Suggest(ctx, "radio", 2)
+		if asked := source.asked(); len(asked) != 0 {
+			t.Errorf("expected no source call, got %v", asked)
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package integrations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// TrackSource lists an artist's best known recordings, most popular first
+type TrackSource interface {
+	ArtistTracks(ctx context.Context, artist domain.Artist, limit int) ([]domain.Track, error)
+}
+
+// TrackResults are an artist's top tracks merged across track sources
+type TrackResults struct {
+	Tracks      []domain.Track `json:"tracks"`
+	SourceStats map[string]int `json:"source_stats"`
+	Errors      []string       `json:"errors,omitempty"`
+}
+
+// TracksAggregator merges the top tracks, uploads and videos music services
+// have for an artist into one list. Sources are asked in the order they were
+// registered, and earlier sources win when the same song comes back twice.
+type TracksAggregator struct {
+	names   []string
+	sources map[string]TrackSource
+	timeout time.Duration
+}
+
+func NewTracksAggregator(timeout time.Duration) *TracksAggregator {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &TracksAggregator{
+		sources: make(map[string]TrackSource),
+		timeout: timeout,
+	}
+}
+
+func (a *TracksAggregator) RegisterSource(name string, source TrackSource) {
+	if _, ok := a.sources[name]; !ok {
+		a.names = append(a.names, name)
+	}
+	a.sources[name] = source
+}
+
+// GetArtistTracks returns up to limit of the artist's tracks, playable ones
+// first and otherwise in the order the sources ranked them. Failing sources
+// are reported in Errors.
+func (a *TracksAggregator) GetArtistTracks(ctx context.Context, artist domain.Artist, limit int) (*TrackResults, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	tracksBySource := make([][]domain.Track, len(a.names))
+	errs := make([]error, len(a.names))
+
+	var wg sync.WaitGroup
+	for i, name := range a.names {
+		wg.Add(1)
+		go func(i int, source TrackSource) {
+			defer wg.Done()
+			tracksBySource[i], errs[i] = source.ArtistTracks(ctx, artist, limit)
+		}(i, a.sources[name])
+	}
+	wg.Wait()
+
+	results := &TrackResults{SourceStats: make(map[string]int)}
+
+	lists := [][]domain.Track{}
+	for i, name := range a.names {
+		if errs[i] != nil {
+			results.Errors = append(results.Errors, fmt.Sprintf("%s: %v", name, errs[i]))
+			continue
+		}
+		results.SourceStats[name] = len(tracksBySource[i])
+		lists = append(lists, tracksBySource[i])
+	}
+
+	results.Tracks = mergeTrackLists(lists)
+	if limit > 0 && len(results.Tracks) > limit {
+		results.Tracks = results.Tracks[:limit]
+	}
+
+	return results, nil
+}
+
+// mergeTrackLists merges copies of the same song across lists, earlier lists
+// winning. Playable tracks come first, then the best position any list gave.
+func mergeTrackLists(lists [][]domain.Track) []domain.Track {
+	type mergedTrack struct {
+		track    domain.Track
+		position int
+	}
+	merged := []mergedTrack{}
+	seen := make(map[string]int)
+
+	for _, tracks := range lists {
+		for position, track := range tracks {
+			key := normalizeTrackTitle(track.Title)
+			if j, ok := seen[key]; ok {
+				mergeTrack(&merged[j].track, track)
+				merged[j].position = min(merged[j].position, position)
+				continue
+			}
+			seen[key] = len(merged)
+			merged = append(merged, mergedTrack{track: track, position: position})
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		iPlayable, jPlayable := merged[i].track.PreviewURL != "", merged[j].track.PreviewURL != ""
+		if iPlayable != jPlayable {
+			return iPlayable
+		}
+		return merged[i].position < merged[j].position
+	})
+
+	tracks := make([]domain.Track, 0, len(merged))
+	for _, m := range merged {
+		tracks = append(tracks, m.track)
+	}
+	return tracks
+}
+
+// mergeTrack fills what the kept copy of a song lacks from another source's
+func mergeTrack(kept *domain.Track, other domain.Track) {
+	if kept.PreviewURL == "" {
+		kept.PreviewURL = other.PreviewURL
+	}
+	if kept.URL == "" {
+		kept.URL = other.URL
+	}
+	if kept.VideoURL == "" {
+		kept.VideoURL = other.VideoURL
+	}
+	if kept.Album == "" {
+		kept.Album = other.Album
+	}
+	if kept.DurationMS == 0 {
+		kept.DurationMS = other.DurationMS
+	}
+	if kept.ImageURL == "" {
+		kept.ImageURL = other.ImageURL
+	}
+}
+
+// normalizeTrackTitle drops what sources add to a song's title, such as
+// "(Official Video)" or "[Remastered]", so copies of it compare equal
+func normalizeTrackTitle(title string) string {
+	for _, brackets := range []string{"()", "[]"} {
+		if i := strings.IndexByte(title, brackets[0]); i > 0 && strings.IndexByte(title[i:], brackets[1]) > 0 {
+			title = title[:i]
+		}
+	}
+	if i := strings.Index(strings.ToLower(title), " - remaster"); i > 0 {
+		title = title[:i]
+	}
+	return normalizeRankingName(title)
+}
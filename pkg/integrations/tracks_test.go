@@ -0,0 +1,65 @@
+package integrations
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubTrackSource struct {
+	tracks []domain.Track
+	err    error
+}
+
+func (s *stubTrackSource) ArtistTracks(ctx context.Context, artist domain.Artist, limit int) ([]domain.Track, error) {
+	return s.tracks, s.err
+}
+
+func TestTracksAggregator_GetArtistTracks(t *testing.T) {
+	aggregator := NewTracksAggregator(0)
+	aggregator.RegisterSource("deezer", &stubTrackSource{tracks: []domain.Track{
+		{ID: "deezer_1", Title: "Creep", PreviewURL: "https://cdn.example.com/creep.mp3", Source: "deezer"},
+		{ID: "deezer_2", Title: "No Surprises (Remastered)", PreviewURL: "https://cdn.example.com/no-surprises.mp3", Source: "deezer"},
+	}})
+	aggregator.RegisterSource("youtube", &stubTrackSource{tracks: []domain.Track{
+		{ID: "youtube_a", Title: "Lotus Flower", VideoURL: "https://www.youtube.com/watch?v=a", Source: "youtube"},
+		{ID: "youtube_b", Title: "No Surprises", VideoURL: "https://www.youtube.com/watch?v=b", Source: "youtube"},
+	}})
+	aggregator.RegisterSource("soundcloud", &stubTrackSource{err: errors.New("upstream down")})
+
+	results, err := aggregator.GetArtistTracks(context.Background(), domain.Artist{Name: "Radiohead"}, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(results.Tracks) != 3 {
+		t.Fatalf("expected 3 tracks after merging, got %+v", results.Tracks)
+	}
+	if results.Tracks[0].ID != "deezer_1" || results.Tracks[1].ID != "deezer_2" || results.Tracks[2].ID != "youtube_a" {
+		t.Errorf("expected playable tracks first, got %+v", results.Tracks)
+	}
+	if results.Tracks[1].VideoURL != "https://www.youtube.com/watch?v=b" {
+		t.Errorf("expected the video to be merged into the Deezer track, got %+v", results.Tracks[1])
+	}
+	if results.SourceStats["deezer"] != 2 || results.SourceStats["youtube"] != 2 {
+		t.Errorf("unexpected source stats %v", results.SourceStats)
+	}
+	if len(results.Errors) != 1 {
+		t.Errorf("expected the failed source to be reported, got %v", results.Errors)
+	}
+
+	limited, _ := aggregator.GetArtistTracks(context.Background(), domain.Artist{Name: "Radiohead"}, 1)
+	if len(limited.Tracks) != 1 || limited.Tracks[0].ID != "deezer_1" {
+		t.Errorf("expected the limit to keep the top track, got %+v", limited.Tracks)
+	}
+}
+
+func TestNormalizeTrackTitle(t *testing.T) {
+	for _, title := range []string{"Karma Police", "Karma Police (Official Video)", "Karma Police [Remastered]", "Karma Police - Remastered 2017", "karma police"} {
+		if got := normalizeTrackTitle(title); got != "karmapolice" {
+			t.Errorf("normalizeTrackTitle(%q) = %q", title, got)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package interfaces
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// RequireAdmin rejects requests that don't carry the admin token as their
+// bearer token
+func RequireAdmin(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			given, ok := bearerToken(r)
+			if !ok {
+				respondUnauthorized(w, "missing bearer token")
+				return
+			}
+			if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				respondUnauthorized(w, "invalid admin token")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AdminCache is the aggregator's in-memory search cache
+type AdminCache interface {
+	CacheStats() integrations.CacheStats
+	ClearCache()
+}
+
+// SourceQuotaReporter reports how much of each source's request budget is left
+type SourceQuotaReporter interface {
+	GetSourceQuotas() map[string]domain.SourceQuota
+}
+
+// Resyncer refreshes an artist's stored events from the sources
+type Resyncer interface {
+	Resync(ctx context.Context, artistName string) (*integrations.AggregatedResults, error)
+}
+
+// AdminHandler lets operators look after caches and stored data without
+// shelling into the database
+type AdminHandler struct {
+	token    string
+	cache    AdminCache
+	events   domain.EventPurgeRepository
+	stats    domain.StatsRepository
+	resyncer Resyncer
+	quotas   SourceQuotaReporter
+}
+
+func NewAdminHandler(token string, cache AdminCache, events domain.EventPurgeRepository, stats domain.StatsRepository, resyncer Resyncer, quotas SourceQuotaReporter) *AdminHandler {
+	return &AdminHandler{
+		token:    token,
+		cache:    cache,
+		events:   events,
+		stats:    stats,
+		resyncer: resyncer,
+		quotas:   quotas,
+	}
+}
+
+func (h *AdminHandler) RegisterRoutes(router *mux.Router) {
+	requireAdmin := RequireAdmin(h.token)
+	handle := func(path string, fn http.HandlerFunc, method string) {
+		router.Handle(path, requireAdmin(fn)).Methods(method)
+	}
+
+	handle("/api/admin/cache", h.ClearCache, "DELETE")
+	handle("/api/admin/events/purge-expired", h.PurgeExpired, "POST")
+	handle("/api/admin/stats", h.GetStats, "GET")
+	handle("/api/admin/resync", h.Resync, "POST")
+	handle("/api/sources/quota", h.GetSourceQuotas, "GET")
+}
+
+// AdminStatsResponse is what's stored and cached right now
+type AdminStatsResponse struct {
+	Database *domain.DatabaseStats   `json:"database"`
+	Cache    integrations.CacheStats `json:"cache"`
+}
+
+// ClearCache empties the aggregator's search cache. Stored events are kept;
+// purge-expired or resync deal with those.
+func (h *AdminHandler) ClearCache(w http.ResponseWriter, r *http.Request) {
+	cleared := h.cache.CacheStats()
+	h.cache.ClearCache()
+
+	h.respondWithJSON(w, http.StatusOK, map[string]int{
+		"artist_searches": cleared.ArtistSearches,
+		"event_searches":  cleared.EventSearches,
+	})
+}
+
+func (h *AdminHandler) PurgeExpired(w http.ResponseWriter, r *http.Request) {
+	purged, err := h.events.PurgeExpired(r.Context())
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to purge expired events")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]int64{"purged": purged})
+}
+
+func (h *AdminHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.stats.DatabaseStats(r.Context())
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get database stats")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, AdminStatsResponse{
+		Database: stats,
+		Cache:    h.cache.CacheStats(),
+	})
+}
+
+// Resync forces a fresh search for ?artist=, for when a source fixed data
+// that we'd otherwise keep serving until it expires
+func (h *AdminHandler) Resync(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Artist string `query:"artist" validate:"required"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	results, err := h.resyncer.Resync(r.Context(), params.Artist)
+	if errors.Is(err, domain.ErrInvalidRequest) {
+		writeValidationError(w, invalidParam("artist", "is required"))
+		return
+	} else if err != nil {
+		h.respondWithError(w, http.StatusBadGateway, "failed to resync artist")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, results)
+}
+
+type QuotaResponse struct {
+	Quotas map[string]domain.SourceQuota `json:"quotas"`
+	Total  int                           `json:"total"`
+}
+
+// GetSourceQuotas shows how much of each source's request budget is left,
+// which tells outsiders how hard the API keys can be pushed
+func (h *AdminHandler) GetSourceQuotas(w http.ResponseWriter, r *http.Request) {
+	quotas := h.quotas.GetSourceQuotas()
+
+	h.respondWithJSON(w, http.StatusOK, QuotaResponse{
+		Quotas: quotas,
+		Total:  len(quotas),
+	})
+}
+
+func (h *AdminHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *AdminHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,167 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+type stubAdminCache struct {
+	stats   integrations.CacheStats
+	cleared bool
+}
+
+func (c *stubAdminCache) CacheStats() integrations.CacheStats {
+	return c.stats
+}
+
+func (c *stubAdminCache) ClearCache() {
+	c.cleared = true
+	c.stats = integrations.CacheStats{Enabled: c.stats.Enabled}
+}
+
+type stubAdminStore struct {
+	purged int64
+}
+
+func (s *stubAdminStore) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.purged, nil
+}
+
+func (s *stubAdminStore) DatabaseStats(ctx context.Context) (*domain.DatabaseStats, error) {
+	return &domain.DatabaseStats{Tables: map[string]int64{"events": 3}, SizeBytes: 4096}, nil
+}
+
+type stubResyncer struct {
+	artist string
+}
+
+func (s *stubResyncer) Resync(ctx context.Context, artistName string) (*integrations.AggregatedResults, error) {
+	s.artist = artistName
+	return &integrations.AggregatedResults{
+		Events:       []domain.Event{{ID: "1", ArtistName: artistName}},
+		TotalResults: 1,
+	}, nil
+}
+
+func TestAdminHandler(t *testing.T) {
+	cache := &stubAdminCache{stats: integrations.CacheStats{Enabled: true, ArtistSearches: 2, EventSearches: 5}}
+	store := &stubAdminStore{purged: 4}
+	resyncer := &stubResyncer{}
+	quotas := &mockMegaAggregator{
+		getSourceQuotasFunc: func() map[string]domain.SourceQuota {
+			return map[string]domain.SourceQuota{
+				"setlistfm": {Source: "setlistfm", Limit: 2000, Used: 1500, Remaining: 500, Window: "24h0m0s"},
+				"songkick":  {Source: "songkick", Limit: 1000, Remaining: 1000, Window: "24h0m0s"},
+			}
+		},
+	}
+
+	router := mux.NewRouter()
+	NewAdminHandler("admin-secret", cache, store, store, resyncer, quotas).RegisterRoutes(router)
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("requires the admin token", func(t *testing.T) {
+		if rr := do("GET", "/api/admin/stats", ""); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 without a token, got %d", rr.Code)
+		}
+		if rr := do("GET", "/api/admin/stats", "wrong"); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 with the wrong token, got %d", rr.Code)
+		}
+		if rr := do("DELETE", "/api/admin/cache", "wrong"); rr.Code != http.StatusUnauthorized || cache.cleared {
+			t.Errorf("expected 401 and an untouched cache, got %d", rr.Code)
+		}
+	})
+
+	t.Run("source quotas", func(t *testing.T) {
+		if rr := do("GET", "/api/sources/quota", ""); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 without a token, got %d", rr.Code)
+		}
+
+		rr := do("GET", "/api/sources/quota", "admin-secret")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+
+		var response QuotaResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.Total != 2 {
+			t.Errorf("expected 2 sources, got %d", response.Total)
+		}
+		if response.Quotas["setlistfm"].Remaining != 500 {
+			t.Errorf("expected 500 remaining for setlistfm, got %d", response.Quotas["setlistfm"].Remaining)
+		}
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		rr := do("GET", "/api/admin/stats", "admin-secret")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		var response AdminStatsResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		if response.Database == nil || response.Database.Tables["events"] != 3 || response.Database.SizeBytes != 4096 {
+			t.Errorf("unexpected database stats: %+v", response.Database)
+		}
+		if response.Cache.EventSearches != 5 {
+			t.Errorf("expected 5 cached event searches, got %d", response.Cache.EventSearches)
+		}
+	})
+
+	t.Run("clear cache", func(t *testing.T) {
+		rr := do("DELETE", "/api/admin/cache", "admin-secret")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		if !cache.cleared {
+			t.Error("expected the cache to be cleared")
+		}
+
+		var response map[string]int
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		if response["event_searches"] != 5 {
+			t.Errorf("expected the cleared counts, got %v", response)
+		}
+	})
+
+	t.Run("purge expired", func(t *testing.T) {
+		rr := do("POST", "/api/admin/events/purge-expired", "admin-secret")
+		var response map[string]int64
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		if rr.Code != http.StatusOK || response["purged"] != 4 {
+			t.Errorf("expected 4 purged events, got %d %v", rr.Code, response)
+		}
+	})
+
+	t.Run("resync", func(t *testing.T) {
+		if rr := do("POST", "/api/admin/resync", "admin-secret"); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 without an artist, got %d", rr.Code)
+		}
+
+		rr := do("POST", "/api/admin/resync?artist=Radiohead", "admin-secret")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		if resyncer.artist != "Radiohead" {
+			t.Errorf("expected Radiohead to be resynced, got %q", resyncer.artist)
+		}
+	})
+}
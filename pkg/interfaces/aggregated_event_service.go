@@ -0,0 +1,471 @@
+package interfaces
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// cacheSourceName is reported in SourceStats when events are served from SQLite
+const cacheSourceName = "cache"
+
+// AggregatedEventService writes aggregated event searches through to the
+// event repository and answers repeat searches from it until the stored
+// events' cached_until passes. It wraps the aggregator, so it can be handed to
+// the AggregatorHandler in its place.
+type AggregatedEventService struct {
+	AggregatorService
+	repository       domain.EventRepository
+	artistRepository domain.ArtistRepository
+	cacheTTL         time.Duration
+	notifier         EventChangeNotifier
+	now              func() time.Time
+}
+
+// EventChangeNotifier is told what each write of search results changed in
+// the event store, such as the live hub pushing new and moved events
+type EventChangeNotifier interface {
+	NotifyChanges(ctx context.Context, changes domain.EventChangeSet)
+}
+
+// artistEventSearcher is implemented by aggregators that can pass a stored
+// artist's external IDs on to their sources
+type artistEventSearcher interface {
+	SearchEventsForArtist(ctx context.Context, artist domain.Artist, limit int) (*integrations.AggregatedResults, error)
+}
+
+// dateRangeSearcher is implemented by aggregators that can ask sources for
+// a city's events between two instants only
+type dateRangeSearcher interface {
+	SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) (*integrations.AggregatedResults, error)
+}
+
+// artistEventsInvalidator is implemented by aggregators that cache searches
+type artistEventsInvalidator interface {
+	InvalidateArtistEvents(artistName string)
+}
+
+func NewAggregatedEventService(
+	aggregator AggregatorService,
+	repository domain.EventRepository,
+	artistRepository domain.ArtistRepository,
+	cacheTTL time.Duration,
+) *AggregatedEventService {
+	if cacheTTL <= 0 {
+		cacheTTL = 24 * time.Hour
+	}
+
+	return &AggregatedEventService{
+		AggregatorService: aggregator,
+		repository:        repository,
+		artistRepository:  artistRepository,
+		cacheTTL:          cacheTTL,
+		now:               time.Now,
+	}
+}
+
+// SetChangeNotifier has the events each search stores, and the stored ones
+// it moved, passed to notifier
+func (s *AggregatedEventService) SetChangeNotifier(notifier EventChangeNotifier) {
+	s.notifier = notifier
+}
+
+func (s *AggregatedEventService) SearchEvents(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+	return s.StreamEvents(ctx, artistName, limit, nil)
+}
+
+// StreamEvents serves the search from SQLite when every matching stored event
+// is still fresh, and otherwise runs the aggregator and stores its results.
+// Like the aggregator's own cache, a cached search doesn't call onResult.
+func (s *AggregatedEventService) StreamEvents(ctx context.Context, artistName string, limit int, onResult func(integrations.SourceResult)) (*integrations.AggregatedResults, error) {
+	startTime := s.now()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	if cached := s.cachedEvents(ctx, artistName, startTime); len(cached) > 0 {
+		if len(cached) > limit {
+			cached = cached[:limit]
+		}
+		return &integrations.AggregatedResults{
+			Artists:      []domain.Artist{},
+			Events:       cached,
+			SourceStats:  map[string]int{cacheSourceName: len(cached)},
+			TotalResults: len(cached),
+			SearchTime:   s.now().Sub(startTime),
+		}, nil
+	}
+
+	results, err := s.AggregatorService.StreamEvents(ctx, artistName, limit, onResult)
+	if err != nil {
+		return nil, err
+	}
+
+	// A fast search's early answer would pass for the whole search once
+	// stored; its complete results are stored by the next search, which the
+	// aggregator answers from its cache
+	if len(results.Pending) > 0 {
+		return results, nil
+	}
+	if err := s.store(ctx, results.Events, startTime); err != nil {
+		results.Errors = append(results.Errors, cacheSourceName+": "+err.Error())
+	}
+
+	return results, nil
+}
+
+// FollowUp passes fast searches' follow-ups through from the aggregator
+func (s *AggregatedEventService) FollowUp(id string) (*integrations.AggregatedResults, []string, bool) {
+	if service, ok := s.AggregatorService.(FollowUpService); ok {
+		return service.FollowUp(id)
+	}
+	return nil, nil, false
+}
+
+// GetArtistEvents searches every source for a stored artist, merges the
+// results with events already stored for it and returns them in date order.
+// Sources are skipped while the stored events are still fresh. Events with no
+// known date are left out unless the request asked for them.
+func (s *AggregatedEventService) GetArtistEvents(ctx context.Context, artistID string, limit int) (*integrations.AggregatedResults, error) {
+	if artistID == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	startTime := s.now()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	artist, err := s.artistRepository.GetByID(ctx, artistID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.storedArtistEvents(ctx, artist)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &integrations.AggregatedResults{
+		Artists:     []domain.Artist{*artist},
+		SourceStats: map[string]int{},
+	}
+
+	events := stored
+	if len(stored) > 0 && allFresh(stored, startTime) && !isSourceScoped(ctx) {
+		results.SourceStats[cacheSourceName] = len(stored)
+	} else {
+		upstream, err := s.searchForArtist(ctx, *artist, limit)
+		if err != nil {
+			return nil, err
+		}
+
+		// Store under our artist ID so the next lookup finds them directly
+		for i := range upstream.Events {
+			upstream.Events[i].ArtistID = artist.ID
+		}
+		if err := s.store(ctx, upstream.Events, startTime); err != nil {
+			upstream.Errors = append(upstream.Errors, cacheSourceName+": "+err.Error())
+		}
+
+		results.SourceStats = upstream.SourceStats
+		results.Errors = upstream.Errors
+		events = upstream.Events
+		if !isSourceScoped(ctx) {
+			events = mergeEvents(upstream.Events, stored)
+		}
+	}
+
+	events = undatedFiltered(ctx, events)
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].DateKnown() != events[j].DateKnown() {
+			return events[i].DateKnown()
+		}
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	results.Events = events
+	results.TotalResults = len(events)
+	results.SearchTime = s.now().Sub(startTime)
+
+	return results, nil
+}
+
+// SearchNearby returns stored upcoming events at venues within radiusKm of
+// lat, lng, nearest first. Only events earlier searches stored are found;
+// no source is asked.
+func (s *AggregatedEventService) SearchNearby(ctx context.Context, lat, lng float64, radiusKm, limit int) (*integrations.AggregatedResults, error) {
+	startTime := s.now()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	events, err := s.repository.SearchByLocation(ctx, lat, lng, radiusKm, &startTime, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	if events == nil {
+		events = []domain.Event{}
+	}
+
+	return &integrations.AggregatedResults{
+		Events:       events,
+		TotalResults: len(events),
+		SourceStats:  map[string]int{cacheSourceName: len(events)},
+		SearchTime:   s.now().Sub(startTime),
+	}, nil
+}
+
+// SearchLocationWhen returns the events in city on the days of when,
+// soonest first. Days are counted from today in loc, the requester's
+// timezone, or on each venue's clock when loc is nil. Stored events are
+// combined with a pass over the sources that can search by date, and what
+// those find is stored too.
+func (s *AggregatedEventService) SearchLocationWhen(ctx context.Context, city, country string, when domain.DateShortcut, loc *time.Location, limit int) (*integrations.AggregatedResults, error) {
+	city = strings.TrimSpace(city)
+	if city == "" || !when.Valid() {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	startTime := s.now()
+
+	if limit <= 0 {
+		limit = 50
+	}
+
+	from, to := when.Window(startTime)
+
+	results := &integrations.AggregatedResults{
+		Artists:     []domain.Artist{},
+		SourceStats: map[string]int{},
+	}
+
+	var stored []domain.Event
+	if !isSourceScoped(ctx) {
+		err := s.repository.Each(ctx, domain.EventFilter{City: city, From: &from, To: &to}, func(event domain.Event) error {
+			if when.Matches(event, startTime, loc) && (country == "" || domain.SameCountry(event.Venue.Country, country)) {
+				stored = append(stored, event)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(stored) > 0 {
+			results.SourceStats[cacheSourceName] = len(stored)
+		}
+	}
+
+	var upstream []domain.Event
+	if searcher, ok := s.AggregatorService.(dateRangeSearcher); ok {
+		// The window takes in days either side, so ask for enough to fill
+		// the limit after they're dropped
+		found, err := searcher.SearchEventsByLocationBetween(ctx, city, country, from, to, 2*limit)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.store(ctx, found.Events, startTime); err != nil {
+			found.Errors = append(found.Errors, cacheSourceName+": "+err.Error())
+		}
+		for name, count := range found.SourceStats {
+			results.SourceStats[name] = count
+		}
+		results.Errors = found.Errors
+		for _, event := range found.Events {
+			if when.Matches(event, startTime, loc) {
+				upstream = append(upstream, event)
+			}
+		}
+	}
+
+	events := mergeEvents(upstream, stored)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+
+	results.Events = events
+	results.TotalResults = len(events)
+	results.SearchTime = s.now().Sub(startTime)
+
+	return results, nil
+}
+
+func (s *AggregatedEventService) searchForArtist(ctx context.Context, artist domain.Artist, limit int) (*integrations.AggregatedResults, error) {
+	if searcher, ok := s.AggregatorService.(artistEventSearcher); ok {
+		return searcher.SearchEventsForArtist(ctx, artist, limit)
+	}
+	return s.AggregatorService.SearchEvents(ctx, artist.Name, limit)
+}
+
+// storedArtistEvents finds events stored under the artist's ID as well as
+// those stored by name from searches made before the artist was known
+func (s *AggregatedEventService) storedArtistEvents(ctx context.Context, artist *domain.Artist) ([]domain.Event, error) {
+	byID, err := s.repository.SearchByArtist(ctx, artist.ID, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	byName, err := s.repository.SearchByArtistName(ctx, artist.Name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeEvents(byID, byName), nil
+}
+
+// isSourceScoped reports whether the search was limited to some sources.
+// Stored events don't record which source found them, so scoped searches
+// can't be answered from, or merged with, the event store.
+func isSourceScoped(ctx context.Context) bool {
+	return !integrations.SourceFilterFrom(ctx).IsZero()
+}
+
+// Resync searches every source for the artist again, skipping both the
+// aggregator's cache and stored events, and stores what comes back
+func (s *AggregatedEventService) Resync(ctx context.Context, artistName string) (*integrations.AggregatedResults, error) {
+	artistName = strings.TrimSpace(artistName)
+	if artistName == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	if invalidator, ok := s.AggregatorService.(artistEventsInvalidator); ok {
+		invalidator.InvalidateArtistEvents(artistName)
+	}
+
+	startTime := s.now()
+	results, err := s.AggregatorService.SearchEvents(ctx, artistName, resyncLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store(ctx, results.Events, startTime); err != nil {
+		results.Errors = append(results.Errors, cacheSourceName+": "+err.Error())
+	}
+
+	return results, nil
+}
+
+// resyncLimit is high enough that a resync refreshes an artist's whole tour
+const resyncLimit = 200
+
+func (s *AggregatedEventService) cachedEvents(ctx context.Context, artistName string, now time.Time) []domain.Event {
+	artistName = strings.TrimSpace(artistName)
+	if artistName == "" || isSourceScoped(ctx) {
+		return nil
+	}
+
+	stored, err := s.repository.SearchByArtistName(ctx, artistName, nil, nil)
+	if err != nil || len(stored) == 0 || !allFresh(stored, now) {
+		return nil
+	}
+
+	// Stored events have no ranking scores, so list upcoming events first,
+	// then by date, which is roughly what the aggregator's ranking gives
+	stored = undatedFiltered(ctx, stored)
+	sort.SliceStable(stored, func(i, j int) bool {
+		iUpcoming := stored[i].DateTime.After(now)
+		jUpcoming := stored[j].DateTime.After(now)
+		if iUpcoming != jUpcoming {
+			return iUpcoming
+		}
+		return stored[i].DateTime.Before(stored[j].DateTime)
+	})
+
+	return stored
+}
+
+func (s *AggregatedEventService) store(ctx context.Context, events []domain.Event, now time.Time) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	batch := make([]domain.Event, len(events))
+	copy(batch, events)
+	for i := range batch {
+		if batch[i].CachedUntil.IsZero() {
+			batch[i].CachedUntil = now.Add(s.cacheTTL)
+		}
+	}
+
+	// Writing before expiring lets the repository compare re-synced events
+	// with their stored state and notice cancellations and date changes
+	changes, err := s.repository.CreateBatch(ctx, batch)
+	if err != nil {
+		return err
+	}
+	if s.notifier != nil && !changes.Empty() {
+		s.notifier.NotifyChanges(ctx, *changes)
+	}
+
+	return s.repository.DeleteExpiredCache(ctx)
+}
+
+type undatedEventsKey struct{}
+
+// withUndatedEvents returns a context whose date ordered results keep events
+// with no known date. They are left out otherwise, as they'd sort first
+// under a zero date.
+func withUndatedEvents(ctx context.Context) context.Context {
+	return context.WithValue(ctx, undatedEventsKey{}, true)
+}
+
+// undatedFiltered drops events without a known date unless ctx asks for them
+func undatedFiltered(ctx context.Context, events []domain.Event) []domain.Event {
+	if include, _ := ctx.Value(undatedEventsKey{}).(bool); include {
+		return events
+	}
+
+	dated := make([]domain.Event, 0, len(events))
+	for _, event := range events {
+		if event.DateKnown() {
+			dated = append(dated, event)
+		}
+	}
+	return dated
+}
+
+// allFresh reports whether every event is inside its cache window. One stale
+// event means the search is due for a refresh.
+func allFresh(events []domain.Event, now time.Time) bool {
+	for _, event := range events {
+		if !event.CachedUntil.After(now) {
+			return false
+		}
+	}
+	return true
+}
+
+// mergeEvents combines event lists by ID, keeping the first copy seen
+func mergeEvents(lists ...[]domain.Event) []domain.Event {
+	seen := make(map[string]bool)
+	merged := []domain.Event{}
+
+	for _, events := range lists {
+		for _, event := range events {
+			if seen[event.ID] {
+				continue
+			}
+			seen[event.ID] = true
+			merged = append(merged, event)
+		}
+	}
+
+	return merged
+}
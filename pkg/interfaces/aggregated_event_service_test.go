@@ -0,0 +1,709 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// memoryEventRepository keeps events in a map so write-through can be checked
+type memoryEventRepository struct {
+	events     map[string]domain.Event
+	discovered map[string]time.Time
+	batchErr   error
+}
+
+func newMemoryEventRepository() *memoryEventRepository {
+	return &memoryEventRepository{
+		events:     make(map[string]domain.Event),
+		discovered: make(map[string]time.Time),
+	}
+}
+
+func (m *memoryEventRepository) Create(ctx context.Context, event *domain.Event) error {
+	m.events[event.ID] = *event
+	m.discover(event.ID)
+	return nil
+}
+
+func (m *memoryEventRepository) CreateBatch(ctx context.Context, events []domain.Event) (*domain.EventChangeSet, error) {
+	if m.batchErr != nil {
+		return nil, m.batchErr
+	}
+	changes := &domain.EventChangeSet{}
+	for _, event := range events {
+		if stored, exists := m.events[event.ID]; !exists {
+			changes.New = append(changes.New, event)
+		} else if event.DateChangedFrom(stored) {
+			changes.DateChanged = append(changes.DateChanged, domain.EventUpdate{Event: event, PreviousDateTime: stored.DateTime, PreviousVenue: stored.Venue})
+		}
+		m.events[event.ID] = event
+		m.discover(event.ID)
+	}
+	return changes, nil
+}
+
+func (m *memoryEventRepository) discover(id string) {
+	if _, exists := m.discovered[id]; !exists {
+		m.discovered[id] = time.Now()
+	}
+}
+
+func (m *memoryEventRepository) GetByID(ctx context.Context, id string) (*domain.Event, error) {
+	event, exists := m.events[id]
+	if !exists {
+		return nil, domain.ErrEventNotFound
+	}
+	return &event, nil
+}
+
+func (m *memoryEventRepository) GetByExternalID(ctx context.Context, externalID string, source string) (*domain.Event, error) {
+	for _, event := range m.events {
+		if event.ExternalIDs.For(source) == externalID {
+			return &event, nil
+		}
+	}
+	return nil, domain.ErrEventNotFound
+}
+
+func (m *memoryEventRepository) SearchByArtist(ctx context.Context, artistID string, startDate, endDate *time.Time) ([]domain.Event, error) {
+	events := []domain.Event{}
+	for _, event := range m.events {
+		if event.ArtistID == artistID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (m *memoryEventRepository) SearchByArtistName(ctx context.Context, artistName string, startDate, endDate *time.Time) ([]domain.Event, error) {
+	events := []domain.Event{}
+	for _, event := range m.events {
+		if strings.EqualFold(event.ArtistName, artistName) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+func (m *memoryEventRepository) SearchByLocation(ctx context.Context, lat, lng float64, radius int, startDate, endDate *time.Time) ([]domain.Event, error) {
+	events := []domain.Event{}
+	for _, event := range m.events {
+		if !event.Venue.HasCoordinates() || event.Venue.DistanceKm(lat, lng) > float64(radius) {
+			continue
+		}
+		if startDate != nil && event.DateTime.Before(*startDate) {
+			continue
+		}
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Venue.DistanceKm(lat, lng) < events[j].Venue.DistanceKm(lat, lng)
+	})
+	return events, nil
+}
+
+func (m *memoryEventRepository) ListDiscovered(ctx context.Context, filter domain.DiscoveryFilter, limit int) ([]domain.Event, error) {
+	events := []domain.Event{}
+	for id, discoveredAt := range m.discovered {
+		event, exists := m.events[id]
+		if !exists {
+			continue
+		}
+		byID := filter.ArtistID != "" && event.ArtistID == filter.ArtistID
+		byName := filter.ArtistName != "" && strings.EqualFold(event.ArtistName, filter.ArtistName)
+		if (filter.ArtistID != "" || filter.ArtistName != "") && !byID && !byName {
+			continue
+		}
+		if filter.City != "" && !strings.EqualFold(event.Venue.City, filter.City) {
+			continue
+		}
+		if filter.Since != nil && !discoveredAt.After(*filter.Since) {
+			continue
+		}
+		discoveredAt := discoveredAt
+		event.DiscoveredAt = &discoveredAt
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DiscoveredAt.After(*events[j].DiscoveredAt)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
+
+func (m *memoryEventRepository) Each(ctx context.Context, filter domain.EventFilter, fn func(domain.Event) error) error {
+	events := []domain.Event{}
+	for _, event := range m.events {
+		if filter.Artist != "" && event.ArtistID != filter.Artist && !strings.EqualFold(event.ArtistName, filter.Artist) {
+			continue
+		}
+		if filter.City != "" && !strings.EqualFold(event.Venue.City, filter.City) {
+			continue
+		}
+		if filter.From != nil && event.DateTime.Before(*filter.From) {
+			continue
+		}
+		if filter.To != nil && event.DateTime.After(*filter.To) {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].DateTime.Before(events[j].DateTime)
+	})
+	for _, event := range events {
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *memoryEventRepository) Update(ctx context.Context, event *domain.Event) error {
+	m.events[event.ID] = *event
+	return nil
+}
+
+func (m *memoryEventRepository) Delete(ctx context.Context, id string) error {
+	delete(m.events, id)
+	return nil
+}
+
+func (m *memoryEventRepository) DeleteExpiredCache(ctx context.Context) error {
+	for id, event := range m.events {
+		if event.CachedUntil.Before(time.Now()) {
+			delete(m.events, id)
+		}
+	}
+	return nil
+}
+
+type recordingChangeNotifier struct {
+	changes []domain.EventChangeSet
+}
+
+func (r *recordingChangeNotifier) NotifyChanges(ctx context.Context, changes domain.EventChangeSet) {
+	r.changes = append(r.changes, changes)
+}
+
+func TestAggregatedEventService_StreamEvents(t *testing.T) {
+	now := time.Now()
+	upstreamEvents := []domain.Event{
+		{
+			ID:          "songkick_1",
+			ArtistName:  "Test Artist",
+			DateTime:    now.Add(48 * time.Hour),
+			ExternalIDs: domain.EventExternalIDs{SongkickID: "1"},
+			CachedUntil: now.Add(time.Hour),
+		},
+		{
+			ID:         "scraper_1",
+			ArtistName: "Test Artist",
+			DateTime:   now.Add(24 * time.Hour),
+		},
+	}
+
+	newService := func() (*AggregatedEventService, *memoryEventRepository, *int) {
+		calls := 0
+		aggregator := &mockMegaAggregator{
+			streamEventsFunc: func(ctx context.Context, artistName string, limit int, onResult func(integrations.SourceResult)) (*integrations.AggregatedResults, error) {
+				calls++
+				return &integrations.AggregatedResults{
+					Events:       upstreamEvents,
+					SourceStats:  map[string]int{"songkick": 2},
+					TotalResults: len(upstreamEvents),
+				}, nil
+			},
+		}
+		repository := newMemoryEventRepository()
+		return NewAggregatedEventService(aggregator, repository, &mockRepository{}, time.Hour), repository, &calls
+	}
+
+	t.Run("writes through and serves repeat searches from the repository", func(t *testing.T) {
+		service, repository, calls := newService()
+		ctx := context.Background()
+
+		first, err := service.SearchEvents(ctx, "Test Artist", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if first.SourceStats["songkick"] != 2 {
+			t.Errorf("expected upstream stats on first search, got %v", first.SourceStats)
+		}
+		if len(repository.events) != 2 {
+			t.Fatalf("expected 2 stored events, got %d", len(repository.events))
+		}
+		if repository.events["scraper_1"].CachedUntil.IsZero() {
+			t.Error("expected events without cached_until to get the default TTL")
+		}
+		if repository.events["songkick_1"].ExternalIDs.SongkickID != "1" {
+			t.Error("expected external IDs to be stored")
+		}
+
+		second, err := service.SearchEvents(ctx, "test artist", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if *calls != 1 {
+			t.Errorf("expected 1 upstream search, got %d", *calls)
+		}
+		if second.SourceStats[cacheSourceName] != 2 {
+			t.Errorf("expected cache stats, got %v", second.SourceStats)
+		}
+		if second.Events[0].ID != "scraper_1" {
+			t.Errorf("expected soonest event first, got %s", second.Events[0].ID)
+		}
+	})
+
+	t.Run("notifies what the write changed", func(t *testing.T) {
+		service, repository, _ := newService()
+		notifier := &recordingChangeNotifier{}
+		service.SetChangeNotifier(notifier)
+		repository.events["songkick_1"] = domain.Event{ID: "songkick_1", ArtistName: "Test Artist", DateTime: now.Add(72 * time.Hour)}
+
+		if _, err := service.SearchEvents(context.Background(), "Test Artist", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(notifier.changes) != 1 {
+			t.Fatalf("expected one notification, got %d", len(notifier.changes))
+		}
+		changes := notifier.changes[0]
+		if len(changes.New) != 1 || changes.New[0].ID != "scraper_1" {
+			t.Errorf("expected scraper_1 new, got %+v", changes.New)
+		}
+		if len(changes.DateChanged) != 1 || changes.DateChanged[0].Event.ID != "songkick_1" {
+			t.Errorf("expected songkick_1 rescheduled, got %+v", changes.DateChanged)
+		}
+	})
+
+	t.Run("limit applies to cached results", func(t *testing.T) {
+		service, _, _ := newService()
+		ctx := context.Background()
+
+		service.SearchEvents(ctx, "Test Artist", 10)
+		cached, err := service.SearchEvents(ctx, "Test Artist", 1)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if cached.TotalResults != 1 {
+			t.Errorf("expected 1 result, got %d", cached.TotalResults)
+		}
+	})
+
+	t.Run("expired events trigger a new search", func(t *testing.T) {
+		service, repository, calls := newService()
+		repository.events["old"] = domain.Event{
+			ID:          "old",
+			ArtistName:  "Test Artist",
+			CachedUntil: now.Add(-time.Minute),
+		}
+
+		if _, err := service.SearchEvents(context.Background(), "Test Artist", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if *calls != 1 {
+			t.Errorf("expected upstream search, got %d calls", *calls)
+		}
+		if _, exists := repository.events["old"]; exists {
+			t.Error("expected expired event to be removed")
+		}
+	})
+
+	t.Run("storage failure is reported but not fatal", func(t *testing.T) {
+		service, repository, _ := newService()
+		repository.batchErr = errors.New("disk full")
+
+		results, err := service.SearchEvents(context.Background(), "Test Artist", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(results.Events) != 2 || len(results.Errors) != 1 {
+			t.Errorf("expected events and one error, got %d events and %v", len(results.Events), results.Errors)
+		}
+	})
+
+	t.Run("aggregator failure", func(t *testing.T) {
+		aggregator := &mockMegaAggregator{
+			streamEventsFunc: func(ctx context.Context, artistName string, limit int, onResult func(integrations.SourceResult)) (*integrations.AggregatedResults, error) {
+				return nil, domain.ErrInvalidRequest
+			},
+		}
+		service := NewAggregatedEventService(aggregator, newMemoryEventRepository(), &mockRepository{}, 0)
+
+		if _, err := service.SearchEvents(context.Background(), "Test Artist", 10); err != domain.ErrInvalidRequest {
+			t.Errorf("expected ErrInvalidRequest, got %v", err)
+		}
+	})
+
+	t.Run("searches scoped to some sources skip the repository", func(t *testing.T) {
+		service, _, calls := newService()
+		ctx := context.Background()
+
+		if _, err := service.SearchEvents(ctx, "Test Artist", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		scoped := integrations.WithSourceFilter(ctx, integrations.SourceFilter{Only: []string{"songkick"}})
+		results, err := service.SearchEvents(scoped, "Test Artist", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if *calls != 2 || results.SourceStats[cacheSourceName] != 0 {
+			t.Errorf("expected the scoped search to go upstream, got %d calls and stats %v", *calls, results.SourceStats)
+		}
+	})
+
+	t.Run("resync goes upstream even when stored events are fresh", func(t *testing.T) {
+		repository := newMemoryEventRepository()
+		repository.events["songkick_1"] = domain.Event{
+			ID:          "songkick_1",
+			ArtistName:  "Test Artist",
+			CachedUntil: now.Add(time.Hour),
+		}
+		searched := ""
+		aggregator := &mockMegaAggregator{
+			searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+				searched = artistName
+				return &integrations.AggregatedResults{Events: upstreamEvents}, nil
+			},
+		}
+		service := NewAggregatedEventService(aggregator, repository, &mockRepository{}, time.Hour)
+
+		results, err := service.Resync(context.Background(), " Test Artist ")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if searched != "Test Artist" || len(results.Events) != 2 {
+			t.Errorf("expected an upstream search, got %q and %d events", searched, len(results.Events))
+		}
+		if _, stored := repository.events["scraper_1"]; !stored {
+			t.Error("expected resynced events to be stored")
+		}
+
+		if _, err := service.Resync(context.Background(), ""); err != domain.ErrInvalidRequest {
+			t.Errorf("expected ErrInvalidRequest, got %v", err)
+		}
+	})
+}
+
+// artistSearchingAggregator is an aggregator that takes the stored artist
+type artistSearchingAggregator struct {
+	mockMegaAggregator
+	searchedArtist *domain.Artist
+	events         []domain.Event
+}
+
+func (a *artistSearchingAggregator) SearchEventsForArtist(ctx context.Context, artist domain.Artist, limit int) (*integrations.AggregatedResults, error) {
+	a.searchedArtist = &artist
+	return &integrations.AggregatedResults{
+		Events:      a.events,
+		SourceStats: map[string]int{"songkick": len(a.events)},
+	}, nil
+}
+
+func TestAggregatedEventService_GetArtistEvents(t *testing.T) {
+	now := time.Now()
+	artist := &domain.Artist{
+		ID:          "artist_1",
+		Name:        "Test Artist",
+		ExternalIDs: domain.ExternalIDs{MusicBrainzID: "mbid-1"},
+	}
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != artist.ID {
+				return nil, domain.ErrArtistNotFound
+			}
+			return artist, nil
+		},
+	}
+
+	t.Run("searches with the stored artist and merges local events", func(t *testing.T) {
+		aggregator := &artistSearchingAggregator{
+			events: []domain.Event{
+				{ID: "songkick_2", ArtistName: "Test Artist", DateTime: now.Add(72 * time.Hour)},
+				{ID: "songkick_1", ArtistName: "Test Artist", DateTime: now.Add(48 * time.Hour), Title: "Updated"},
+			},
+		}
+		repository := newMemoryEventRepository()
+		repository.events["songkick_1"] = domain.Event{ID: "songkick_1", ArtistName: "Test Artist", DateTime: now.Add(48 * time.Hour)}
+		repository.events["scraper_1"] = domain.Event{ID: "scraper_1", ArtistID: "artist_1", ArtistName: "T. Artist", DateTime: now.Add(24 * time.Hour)}
+		service := NewAggregatedEventService(aggregator, repository, artists, time.Hour)
+
+		results, err := service.GetArtistEvents(context.Background(), "artist_1", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if aggregator.searchedArtist == nil || aggregator.searchedArtist.ExternalIDs.MusicBrainzID != "mbid-1" {
+			t.Error("expected the stored artist to be passed to the aggregator")
+		}
+		if len(results.Events) != 3 {
+			t.Fatalf("expected 3 events, got %d", len(results.Events))
+		}
+		if results.Events[0].ID != "scraper_1" || results.Events[2].ID != "songkick_2" {
+			t.Errorf("expected chronological order, got %s ... %s", results.Events[0].ID, results.Events[2].ID)
+		}
+		if results.Events[1].Title != "Updated" {
+			t.Error("expected upstream copy to replace the stored event")
+		}
+		if repository.events["songkick_2"].ArtistID != "artist_1" {
+			t.Error("expected upstream events to be stored under the artist ID")
+		}
+	})
+
+	t.Run("fresh stored events skip the sources", func(t *testing.T) {
+		aggregator := &artistSearchingAggregator{}
+		repository := newMemoryEventRepository()
+		repository.events["e1"] = domain.Event{ID: "e1", ArtistID: "artist_1", DateTime: now, CachedUntil: now.Add(time.Hour)}
+		service := NewAggregatedEventService(aggregator, repository, artists, time.Hour)
+
+		results, err := service.GetArtistEvents(context.Background(), "artist_1", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if aggregator.searchedArtist != nil {
+			t.Error("expected no upstream search")
+		}
+		if results.SourceStats[cacheSourceName] != 1 {
+			t.Errorf("expected cache stats, got %v", results.SourceStats)
+		}
+	})
+
+	t.Run("falls back to a name search", func(t *testing.T) {
+		searched := ""
+		aggregator := &mockMegaAggregator{
+			searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+				searched = artistName
+				return &integrations.AggregatedResults{}, nil
+			},
+		}
+		service := NewAggregatedEventService(aggregator, newMemoryEventRepository(), artists, time.Hour)
+
+		if _, err := service.GetArtistEvents(context.Background(), "artist_1", 10); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if searched != "Test Artist" {
+			t.Errorf("expected search for Test Artist, got %q", searched)
+		}
+	})
+
+	t.Run("events without a date only on request", func(t *testing.T) {
+		aggregator := &artistSearchingAggregator{
+			events: []domain.Event{
+				{ID: "tba", ArtistName: "Test Artist", DateConfidence: domain.DateUnknown},
+				{ID: "dated", ArtistName: "Test Artist", DateTime: now.Add(24 * time.Hour), DateConfidence: domain.DateOnly},
+			},
+		}
+		service := NewAggregatedEventService(aggregator, newMemoryEventRepository(), artists, time.Hour)
+
+		results, err := service.GetArtistEvents(context.Background(), "artist_1", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(results.Events) != 1 || results.Events[0].ID != "dated" {
+			t.Errorf("expected only the dated event, got %v", results.Events)
+		}
+
+		results, err = service.GetArtistEvents(withUndatedEvents(context.Background()), "artist_1", 10)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if len(results.Events) != 2 || results.Events[1].ID != "tba" {
+			t.Errorf("expected the undated event last, got %v", results.Events)
+		}
+	})
+
+	t.Run("unknown artist", func(t *testing.T) {
+		service := NewAggregatedEventService(&artistSearchingAggregator{}, newMemoryEventRepository(), artists, time.Hour)
+
+		if _, err := service.GetArtistEvents(context.Background(), "missing", 10); err != domain.ErrArtistNotFound {
+			t.Errorf("expected ErrArtistNotFound, got %v", err)
+		}
+	})
+}
+
+func TestAggregatorHandler_GetArtistEvents(t *testing.T) {
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != "artist_1" {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &domain.Artist{ID: "artist_1", Name: "Test Artist"}, nil
+		},
+	}
+	aggregator := &artistSearchingAggregator{
+		events: []domain.Event{{ID: "songkick_1", ArtistName: "Test Artist", DateTime: time.Now()}},
+	}
+	service := NewAggregatedEventService(aggregator, newMemoryEventRepository(), artists, time.Hour)
+
+	router := mux.NewRouter()
+	NewAggregatorHandler(service).RegisterRoutes(router)
+
+	t.Run("returns events", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/artists/artist_1/events?limit=5", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+
+		var response integrations.AggregatedResults
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Events) != 1 {
+			t.Errorf("expected 1 event, got %d", len(response.Events))
+		}
+	})
+
+	t.Run("unknown artist", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/artists/missing/events", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("not registered without an artist store", func(t *testing.T) {
+		router := mux.NewRouter()
+		NewAggregatorHandler(&mockMegaAggregator{}).RegisterRoutes(router)
+
+		req, _ := http.NewRequest("GET", "/api/artists/artist_1/events", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+}
+
+// dateRangeAggregator is an aggregator whose sources can search by date
+type dateRangeAggregator struct {
+	mockMegaAggregator
+	from, to time.Time
+	events   []domain.Event
+}
+
+func (a *dateRangeAggregator) SearchEventsByLocationBetween(ctx context.Context, city, country string, from, to time.Time, limit int) (*integrations.AggregatedResults, error) {
+	a.from, a.to = from, to
+	return &integrations.AggregatedResults{
+		Events:      a.events,
+		SourceStats: map[string]int{"songkick": len(a.events)},
+	}, nil
+}
+
+func TestAggregatedEventService_SearchLocationWhen(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	// 20:00 in Berlin
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	fresh := time.Now().Add(time.Hour)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 10, day, hour, minute, 0, 0, berlin)
+	}
+	venue := domain.Venue{Name: "Berghain", City: "Berlin", Country: "Germany"}
+
+	aggregator := &dateRangeAggregator{events: []domain.Event{
+		{ID: "songkick_late", ArtistName: "Late Act", Venue: venue, Timezone: "Europe/Berlin", DateTime: at(16, 23, 30), CachedUntil: fresh},
+		// Still the 16th in UTC, but tomorrow in Berlin
+		{ID: "songkick_tomorrow", ArtistName: "After Midnight", Venue: venue, Timezone: "Europe/Berlin", DateTime: at(17, 0, 30), CachedUntil: fresh},
+	}}
+	repository := newMemoryEventRepository()
+	repository.events["stored_tonight"] = domain.Event{ID: "stored_tonight", ArtistName: "Early Act", Venue: venue, Timezone: "Europe/Berlin", DateTime: at(16, 19, 0), CachedUntil: fresh}
+	repository.events["stored_yesterday"] = domain.Event{ID: "stored_yesterday", ArtistName: "Gone", Venue: venue, Timezone: "Europe/Berlin", DateTime: at(15, 22, 0), CachedUntil: fresh}
+	repository.events["stored_vienna"] = domain.Event{ID: "stored_vienna", ArtistName: "Elsewhere", Venue: domain.Venue{City: "Vienna", Country: "Austria"}, Timezone: "Europe/Vienna", DateTime: at(16, 21, 0), CachedUntil: fresh}
+	service := NewAggregatedEventService(aggregator, repository, &mockRepository{}, time.Hour)
+	service.now = func() time.Time { return now }
+
+	results, err := service.SearchLocationWhen(context.Background(), "Berlin", "DE", domain.Tonight, nil, 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(results.Events) != 2 || results.Events[0].ID != "stored_tonight" || results.Events[1].ID != "songkick_late" {
+		t.Fatalf("expected tonight's two Berlin shows soonest first, got %+v", results.Events)
+	}
+	if results.SourceStats[cacheSourceName] != 1 || results.SourceStats["songkick"] != 2 {
+		t.Errorf("expected stats for the store and the source, got %v", results.SourceStats)
+	}
+	if !aggregator.from.Before(at(16, 0, 0)) || !aggregator.to.After(at(17, 0, 0)) {
+		t.Errorf("expected the source asked about the whole day, got %v to %v", aggregator.from, aggregator.to)
+	}
+	if _, stored := repository.events["songkick_late"]; !stored {
+		t.Error("expected the source's events to be stored")
+	}
+
+	if _, err := service.SearchLocationWhen(context.Background(), " ", "", domain.Tonight, nil, 10); !errors.Is(err, domain.ErrInvalidRequest) {
+		t.Errorf("expected a city to be required, got %v", err)
+	}
+}
+
+func TestAggregatorHandler_GetTonight(t *testing.T) {
+	now := time.Now()
+	aggregator := &dateRangeAggregator{events: []domain.Event{
+		{ID: "songkick_1", ArtistName: "Test Artist", Venue: domain.Venue{City: "Berlin"}, DateTime: now, CachedUntil: now.Add(time.Hour)},
+	}}
+	service := NewAggregatedEventService(aggregator, newMemoryEventRepository(), &mockRepository{}, time.Hour)
+
+	router := mux.NewRouter()
+	NewAggregatorHandler(service).RegisterRoutes(router)
+
+	t.Run("returns today's events", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/events/tonight?city=Berlin", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+
+		var response integrations.AggregatedResults
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Events) != 1 {
+			t.Errorf("expected 1 event, got %d", len(response.Events))
+		}
+	})
+
+	t.Run("location searches with when ask sources by date", func(t *testing.T) {
+		aggregator.from = time.Time{}
+		req, _ := http.NewRequest("GET", "/api/search/events/location?city=Berlin&when=next-7-days", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if aggregator.from.IsZero() {
+			t.Error("expected the date range search")
+		}
+	})
+
+	t.Run("requires a city", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/events/tonight", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+	})
+}
@@ -3,12 +3,17 @@ package interfaces
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
 	"github.com/yair/where-its-at/pkg/integrations"
+	"github.com/yair/where-its-at/pkg/integrations/sources/music"
 )
 
 // AggregatorService defines the interface for the mega aggregator
@@ -16,16 +21,95 @@ type AggregatorService interface {
 	SearchArtists(ctx context.Context, query string, limit int) (*integrations.AggregatedResults, error)
 	SearchEvents(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error)
 	SearchEventsByLocation(ctx context.Context, city, country string, limit int) (*integrations.AggregatedResults, error)
+	StreamEvents(ctx context.Context, artistName string, limit int, onResult func(integrations.SourceResult)) (*integrations.AggregatedResults, error)
 	GetSourceStats() map[string]integrations.SourceInfo
 }
 
+// ArtistEventsService looks up events for an artist we have stored
+type ArtistEventsService interface {
+	GetArtistEvents(ctx context.Context, artistID string, limit int) (*integrations.AggregatedResults, error)
+}
+
+// NearbyEventsService finds stored events around a point
+type NearbyEventsService interface {
+	SearchNearby(ctx context.Context, lat, lng float64, radiusKm, limit int) (*integrations.AggregatedResults, error)
+}
+
+// FollowUpService hands out the complete results of fast searches that
+// answered before all their sources did
+type FollowUpService interface {
+	FollowUp(id string) (*integrations.AggregatedResults, []string, bool)
+}
+
+// DatedEventsService finds a city's events on the days of a date shortcut,
+// asking only the sources that can search by date
+type DatedEventsService interface {
+	SearchLocationWhen(ctx context.Context, city, country string, when domain.DateShortcut, loc *time.Location, limit int) (*integrations.AggregatedResults, error)
+}
+
+type artistSearchParams struct {
+	Query string `query:"q" validate:"required"`
+	Limit int    `query:"limit" limit:"50,200"`
+}
+
+type eventSearchParams struct {
+	Artist string `query:"artist" validate:"required"`
+	Limit  int    `query:"limit" limit:"50,200"`
+}
+
+type locationSearchParams struct {
+	City    string `query:"city" validate:"required"`
+	Country string `query:"country"`
+	Format  string `query:"format" validate:"oneof=json geojson"`
+	Limit   int    `query:"limit" limit:"50,200"`
+}
+
+// nearbySearchParams are checked for lat and lng separately, since 0 is a
+// real latitude and longitude
+type nearbySearchParams struct {
+	Lat    float64 `query:"lat" validate:"min=-90,max=90"`
+	Lng    float64 `query:"lng" validate:"min=-180,max=180"`
+	Radius int     `query:"radius" validate:"min=1,max=500"`
+	Format string  `query:"format" validate:"oneof=json geojson"`
+	Limit  int     `query:"limit" limit:"50,200"`
+}
+
+// whenParams narrow event searches to a span of days. Days are counted on
+// the requester's clock, from tz or the Time-Zone header, and on each
+// venue's clock without either.
+type whenParams struct {
+	When string `query:"when" validate:"oneof=tonight tomorrow this-weekend next-7-days"`
+	TZ   string `query:"tz"`
+}
+
+// audienceParams narrow event searches to who can go. all_ages=true keeps
+// the events without a minimum age, for parents and under-18s.
+type audienceParams struct {
+	AllAges bool `query:"all_ages"`
+}
+
+// modeParams pick how long a search waits on its sources. mode=fast answers
+// once quorum sources have (the configured number without it) or a soft
+// deadline passes, listing the rest as pending.
+type modeParams struct {
+	Mode   string `query:"mode" validate:"oneof=fast full"`
+	Quorum int    `query:"quorum" validate:"min=0,max=20"`
+}
+
+// pageParams is the limit of listings that take nothing else
+type pageParams struct {
+	Limit int `query:"limit" limit:"50,200"`
+}
+
 type AggregatorHandler struct {
 	aggregator AggregatorService
+	now        func() time.Time
 }
 
 func NewAggregatorHandler(aggregator AggregatorService) *AggregatorHandler {
 	return &AggregatorHandler{
 		aggregator: aggregator,
+		now:        time.Now,
 	}
 }
 
@@ -33,93 +117,450 @@ func (h *AggregatorHandler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/api/search/artists", h.SearchArtists).Methods("GET")
 	router.HandleFunc("/api/search/events", h.SearchEvents).Methods("GET")
 	router.HandleFunc("/api/search/events/location", h.SearchEventsByLocation).Methods("GET")
+	router.HandleFunc("/api/search/events/stream", h.StreamEvents).Methods("GET")
 	router.HandleFunc("/api/sources", h.GetSources).Methods("GET")
+
+	// Only aggregators backed by the artist store can resolve artist IDs
+	if _, ok := h.aggregator.(ArtistEventsService); ok {
+		router.HandleFunc("/api/artists/{id}/events", h.GetArtistEvents).Methods("GET")
+	}
+	if _, ok := h.aggregator.(NearbyEventsService); ok {
+		router.HandleFunc("/api/search/events/nearby", h.SearchEventsNearby).Methods("GET")
+	}
+	if _, ok := h.aggregator.(DatedEventsService); ok {
+		router.HandleFunc("/api/events/tonight", h.GetTonight).Methods("GET")
+	}
+	if _, ok := h.aggregator.(FollowUpService); ok {
+		router.HandleFunc("/api/search/follow-up/{id}", h.GetFollowUp).Methods("GET")
+	}
 }
 
 func (h *AggregatorHandler) SearchArtists(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "query parameter 'q' is required")
+	var params artistSearchParams
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	limit := 50
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-			if limit > 200 {
-				limit = 200
-			}
-		}
+	ctx := storefrontContext(sourceScopedContext(r), r)
+	results, err := h.aggregator.SearchArtists(ctx, params.Query, params.Limit)
+	if err != nil {
+		writeSourceError(w, err, "failed to search artists")
+		return
 	}
 
-	ctx := r.Context()
-	results, err := h.aggregator.SearchArtists(ctx, query, limit)
+	h.writeResults(w, r, results)
+}
+
+func (h *AggregatorHandler) SearchEvents(w http.ResponseWriter, r *http.Request) {
+	var params eventSearchParams
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	when, loc, err := requestedDays(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to search artists")
+		writeValidationError(w, err)
+		return
+	}
+	var audience audienceParams
+	if err := bindQuery(r, &audience); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
-	h.writeJSONResponse(w, http.StatusOK, results)
+	ctx, err := searchModeContext(r, searchContext(r))
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	results, err := h.aggregator.SearchEvents(ctx, params.Artist, params.Limit)
+	if err != nil {
+		writeSourceError(w, err, "failed to search events")
+		return
+	}
+
+	h.writeResults(w, r, h.forAudience(h.onDays(results, when, loc), audience))
 }
 
-func (h *AggregatorHandler) SearchEvents(w http.ResponseWriter, r *http.Request) {
-	artistName := r.URL.Query().Get("artist")
-	if artistName == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "query parameter 'artist' is required")
+func (h *AggregatorHandler) SearchEventsByLocation(w http.ResponseWriter, r *http.Request) {
+	var params locationSearchParams
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	limit := 50
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-			if limit > 200 {
-				limit = 200
-			}
+	when, loc, err := requestedDays(r)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	var audience audienceParams
+	if err := bindQuery(r, &audience); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, err := searchModeContext(r, searchContext(r))
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	var results *integrations.AggregatedResults
+	if service, ok := h.aggregator.(DatedEventsService); ok && when != "" {
+		results, err = service.SearchLocationWhen(ctx, params.City, params.Country, when, loc, params.Limit)
+	} else {
+		results, err = h.aggregator.SearchEventsByLocation(ctx, params.City, params.Country, params.Limit)
+		results = h.onDays(results, when, loc)
+	}
+	if err != nil {
+		writeSourceError(w, err, "failed to search events by location")
+		return
+	}
+
+	h.writeLocatedResults(w, r, h.forAudience(results, audience), params.Format)
+}
+
+// SearchEventsNearby finds stored upcoming events within radius km (25 by
+// default) of lat and lng
+func (h *AggregatorHandler) SearchEventsNearby(w http.ResponseWriter, r *http.Request) {
+	service, ok := h.aggregator.(NearbyEventsService)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusNotImplemented, "nearby search is not available")
+		return
+	}
+
+	params := nearbySearchParams{Radius: 25}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	for _, name := range []string{"lat", "lng"} {
+		if strings.TrimSpace(r.URL.Query().Get(name)) == "" {
+			writeValidationError(w, invalidParam(name, "is required"))
+			return
 		}
 	}
 
-	ctx := r.Context()
-	results, err := h.aggregator.SearchEvents(ctx, artistName, limit)
+	when, loc, err := requestedDays(r)
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to search events")
+		writeValidationError(w, err)
+		return
+	}
+	var audience audienceParams
+	if err := bindQuery(r, &audience); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
-	h.writeJSONResponse(w, http.StatusOK, results)
+	results, err := service.SearchNearby(r.Context(), params.Lat, params.Lng, params.Radius, params.Limit)
+	if err != nil {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to search nearby events")
+		return
+	}
+
+	h.writeLocatedResults(w, r, h.forAudience(h.onDays(results, when, loc), audience), params.Format)
 }
 
-func (h *AggregatorHandler) SearchEventsByLocation(w http.ResponseWriter, r *http.Request) {
-	city := r.URL.Query().Get("city")
-	if city == "" {
-		h.writeErrorResponse(w, http.StatusBadRequest, "query parameter 'city' is required")
+// GetTonight lists the events in city happening today, soonest first. It
+// is a location search with when=tonight, so it takes tz too.
+func (h *AggregatorHandler) GetTonight(w http.ResponseWriter, r *http.Request) {
+	service, ok := h.aggregator.(DatedEventsService)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusNotImplemented, "tonight's events are not available")
 		return
 	}
 
-	country := r.URL.Query().Get("country")
+	var params locationSearchParams
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	_, loc, err := requestedDays(r)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	var audience audienceParams
+	if err := bindQuery(r, &audience); err != nil {
+		writeValidationError(w, err)
+		return
+	}
 
-	limitStr := r.URL.Query().Get("limit")
-	limit := 50
-	if limitStr != "" {
-		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
-			limit = parsedLimit
-			if limit > 200 {
-				limit = 200
-			}
+	results, err := service.SearchLocationWhen(sourceScopedContext(r), params.City, params.Country, domain.Tonight, loc, params.Limit)
+	if err != nil {
+		writeSourceError(w, err, "failed to search tonight's events")
+		return
+	}
+
+	h.writeLocatedResults(w, r, h.forAudience(results, audience), params.Format)
+}
+
+func (h *AggregatorHandler) GetArtistEvents(w http.ResponseWriter, r *http.Request) {
+	service, ok := h.aggregator.(ArtistEventsService)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusNotImplemented, "artist events are not available")
+		return
+	}
+
+	artistID := mux.Vars(r)["id"]
+
+	var params pageParams
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	when, loc, err := requestedDays(r)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	var audience audienceParams
+	if err := bindQuery(r, &audience); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	results, err := service.GetArtistEvents(searchContext(r), artistID, params.Limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			h.writeErrorResponse(w, http.StatusNotFound, "artist not found")
+			return
 		}
+		writeSourceError(w, err, "failed to get artist events")
+		return
+	}
+
+	h.writeResults(w, r, h.forAudience(h.onDays(results, when, loc), audience))
+}
+
+// StreamEvents emits a source_result SSE event as each source finishes,
+// followed by a summary event carrying the merged results.
+func (h *AggregatorHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	var params eventSearchParams
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
 	}
 
-	ctx := r.Context()
-	results, err := h.aggregator.SearchEventsByLocation(ctx, city, country, limit)
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx := searchContext(r)
+	results, err := h.aggregator.StreamEvents(ctx, params.Artist, params.Limit, func(result integrations.SourceResult) {
+		chunk := SourceResultEvent{
+			Source: result.SourceName,
+			Events: result.Events,
+			Count:  len(result.Events),
+		}
+		if chunk.Events == nil {
+			chunk.Events = []domain.Event{}
+		}
+		if result.Error != nil {
+			chunk.Error = result.Error.Error()
+		}
+
+		h.writeSSE(w, "source_result", chunk)
+		flusher.Flush()
+	})
 	if err != nil {
-		h.writeErrorResponse(w, http.StatusInternalServerError, "failed to search events by location")
+		h.writeSSE(w, "error", ErrorResponse{
+			Error:     "failed to search events",
+			Code:      CodeInternal,
+			Status:    http.StatusInternalServerError,
+			RequestID: w.Header().Get(RequestIDHeader),
+		})
+		flusher.Flush()
 		return
 	}
 
-	h.writeJSONResponse(w, http.StatusOK, results)
+	h.writeSSE(w, "summary", results)
+	flusher.Flush()
+}
+
+// searchContext applies the request's source scope and storefront, and
+// keeps events with no known date in date ordered results when
+// include_undated=true
+func searchContext(r *http.Request) context.Context {
+	ctx := storefrontContext(sourceScopedContext(r), r)
+	if r.URL.Query().Get("include_undated") == "true" {
+		ctx = withUndatedEvents(ctx)
+	}
+	return ctx
+}
+
+// storefrontContext has the request's Apple Music lookups use the catalog
+// requestStorefront finds
+func storefrontContext(ctx context.Context, r *http.Request) context.Context {
+	storefront, _ := requestStorefront(r)
+	if storefront == "" {
+		return ctx
+	}
+	return music.WithStorefront(ctx, storefront)
+}
+
+// requestStorefront returns the Apple Music storefront of the country in
+// the storefront parameter, or else of the region of the most preferred
+// language in Accept-Language that names one, "" when neither is a known
+// country. fromHeader reports whether Accept-Language was consulted.
+func requestStorefront(r *http.Request) (storefront string, fromHeader bool) {
+	if country := r.URL.Query().Get("storefront"); country != "" {
+		return music.NormalizeStorefront(country), false
+	}
+	return music.NormalizeStorefront(acceptLanguageRegion(r.Header.Get("Accept-Language"))), true
+}
+
+// acceptLanguageRegion returns the region of the preferred language in an
+// Accept-Language header ("DE" for "de-DE"), or "" when no language has
+// one. Languages without a region, like "de", don't say which country.
+func acceptLanguageRegion(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		subtags := strings.Split(strings.TrimSpace(tag), "-")
+		if len(subtags) < 2 {
+			continue
+		}
+		// The region follows the language and an optional script, as in
+		// zh-Hant-TW
+		region := subtags[1]
+		if len(region) == 4 && len(subtags) > 2 {
+			region = subtags[2]
+		}
+		if len(region) != 2 {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > bestQ {
+			best, bestQ = strings.ToUpper(region), q
+		}
+	}
+	return best
+}
+
+// searchModeContext makes the search a fast one when the request asks for
+// mode=fast
+func searchModeContext(r *http.Request, ctx context.Context) (context.Context, error) {
+	var params modeParams
+	if err := bindQuery(r, &params); err != nil {
+		return nil, err
+	}
+	if params.Mode != "fast" {
+		return ctx, nil
+	}
+	return integrations.WithFastSearch(ctx, integrations.FastSearch{Quorum: params.Quorum}), nil
+}
+
+// sourceScopedContext limits the request's searches to the comma separated
+// sources in the sources parameter, minus those in exclude_sources
+func sourceScopedContext(r *http.Request) context.Context {
+	filter := integrations.SourceFilter{
+		Only:    splitSourceNames(r.URL.Query().Get("sources")),
+		Exclude: splitSourceNames(r.URL.Query().Get("exclude_sources")),
+	}
+	if filter.IsZero() {
+		return r.Context()
+	}
+	return integrations.WithSourceFilter(r.Context(), filter)
+}
+
+// timeZoneHeader carries the requester's IANA timezone when tz isn't given
+const timeZoneHeader = "Time-Zone"
+
+// requestedDays reads when and the requester's timezone. when is empty when
+// the search isn't narrowed, and loc nil when the requester gave no timezone.
+func requestedDays(r *http.Request) (domain.DateShortcut, *time.Location, error) {
+	var params whenParams
+	if err := bindQuery(r, &params); err != nil {
+		return "", nil, err
+	}
+
+	name, field := strings.TrimSpace(params.TZ), "tz"
+	if name == "" {
+		name, field = strings.TrimSpace(r.Header.Get(timeZoneHeader)), timeZoneHeader
+	}
+	if name == "" {
+		return domain.DateShortcut(params.When), nil, nil
+	}
+	loc, err := time.LoadLocation(name)
+	// LoadLocation takes "Local" and "" for the server's own timezone
+	if err != nil || name == "Local" {
+		return "", nil, invalidParam(field, "must be an IANA timezone, like Europe/Berlin")
+	}
+	return domain.DateShortcut(params.When), loc, nil
+}
+
+// onDays keeps the events on when's days
+func (h *AggregatorHandler) onDays(results *integrations.AggregatedResults, when domain.DateShortcut, loc *time.Location) *integrations.AggregatedResults {
+	if results == nil || when == "" {
+		return results
+	}
+
+	now := h.now()
+	return narrowResults(results, func(event domain.Event) bool {
+		return when.Matches(event, now, loc)
+	})
+}
+
+// forAudience keeps the events the audience can go to
+func (h *AggregatorHandler) forAudience(results *integrations.AggregatedResults, audience audienceParams) *integrations.AggregatedResults {
+	if results == nil || !audience.AllAges {
+		return results
+	}
+	return narrowResults(results, func(event domain.Event) bool {
+		return event.AllAges()
+	})
+}
+
+// narrowResults keeps the events keep reports true for. Results can be the
+// aggregator's cached copy, so a narrowed copy is returned rather than
+// changing them.
+func narrowResults(results *integrations.AggregatedResults, keep func(domain.Event) bool) *integrations.AggregatedResults {
+	narrowed := *results
+	narrowed.Events = []domain.Event{}
+	narrowed.Scores = nil
+	for _, event := range results.Events {
+		if !keep(event) {
+			continue
+		}
+		narrowed.Events = append(narrowed.Events, event)
+		if score, ok := results.Scores[event.ID]; ok {
+			if narrowed.Scores == nil {
+				narrowed.Scores = make(map[string]integrations.ResultScore)
+			}
+			narrowed.Scores[event.ID] = score
+		}
+	}
+	narrowed.TotalResults = len(narrowed.Events)
+	return &narrowed
+}
+
+func splitSourceNames(param string) []string {
+	var names []string
+	for _, name := range strings.Split(param, ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
 }
 
 func (h *AggregatorHandler) GetSources(w http.ResponseWriter, r *http.Request) {
@@ -133,30 +574,122 @@ func (h *AggregatorHandler) GetSources(w http.ResponseWriter, r *http.Request) {
 	h.writeJSONResponse(w, http.StatusOK, response)
 }
 
+// GetFollowUp returns the complete results of a fast search once its last
+// source answered, and 202 Accepted with the sources still out until then
+func (h *AggregatorHandler) GetFollowUp(w http.ResponseWriter, r *http.Request) {
+	service, ok := h.aggregator.(FollowUpService)
+	if !ok {
+		h.writeErrorResponse(w, http.StatusNotImplemented, "fast searches are not available")
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	results, pending, found := service.FollowUp(id)
+	if !found {
+		h.writeErrorResponse(w, http.StatusNotFound, "follow-up not found or expired")
+		return
+	}
+	if results == nil {
+		w.Header().Set("Cache-Control", "no-store")
+		h.writeJSONResponse(w, http.StatusAccepted, FollowUpResponse{ID: id, Pending: pending})
+		return
+	}
+
+	h.writeResults(w, r, results)
+}
+
+// writeJSONResponse encodes data straight into the response, through
+// compression when the middleware negotiated it, instead of marshalling a
+// copy of a possibly large result set first
 func (h *AggregatorHandler) writeJSONResponse(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Try to encode first to check for errors before writing status
-	encoded, err := json.Marshal(data)
-	if err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
+	// The encoder only writes once the whole value encoded, so the status
+	// waits for that write and a value that can't be encoded still gets a
+	// 500. An error after it is the client going away.
+	sw := &statusOnWrite{ResponseWriter: w, status: status}
+	if err := json.NewEncoder(sw).Encode(data); err != nil && !sw.wrote {
+		writeError(w, http.StatusInternalServerError, "failed to encode response")
+	}
+}
+
+// statusOnWrite sends its status with the first write
+type statusOnWrite struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (s *statusOnWrite) Write(b []byte) (int, error) {
+	if !s.wrote {
+		s.wrote = true
+		s.ResponseWriter.WriteHeader(s.status)
+	}
+	return s.ResponseWriter.Write(b)
+}
+
+// writeResults writes search results with caching headers, or 304 Not
+// Modified when the client's copy is still current. Results a fast search
+// answered without some sources aren't cached, as a follow-up completes
+// them.
+func (h *AggregatorHandler) writeResults(w http.ResponseWriter, r *http.Request, results *integrations.AggregatedResults) {
+	if len(results.Pending) > 0 {
+		w.Header().Set("Cache-Control", "no-store")
+		h.writeJSONResponse(w, http.StatusOK, results)
+		return
+	}
+	if setCacheHeaders(w, r, h.storefrontETag(w, r, results), resultsLastModified(results), searchCacheMaxAge) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.writeJSONResponse(w, http.StatusOK, results)
+}
+
+// writeLocatedResults writes results as a GeoJSON FeatureCollection when
+// format is geojson and like any other search otherwise
+func (h *AggregatorHandler) writeLocatedResults(w http.ResponseWriter, r *http.Request, results *integrations.AggregatedResults, format string) {
+	if format != "geojson" {
+		h.writeResults(w, r, results)
+		return
+	}
+	if len(results.Pending) > 0 {
+		w.Header().Set("Cache-Control", "no-store")
+	} else if setCacheHeaders(w, r, h.storefrontETag(w, r, results), resultsLastModified(results), searchCacheMaxAge) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
+	writeGeoJSON(w, results.Events)
+}
 
-	w.WriteHeader(status)
-	w.Write(encoded)
+// storefrontETag tags results with the storefront they came from. Caches are
+// told the response varies with Accept-Language when it chose the
+// storefront.
+func (h *AggregatorHandler) storefrontETag(w http.ResponseWriter, r *http.Request, results *integrations.AggregatedResults) string {
+	storefront, fromHeader := requestStorefront(r)
+	if fromHeader {
+		w.Header().Add("Vary", "Accept-Language")
+	}
+	return resultsETag(results, storefront)
 }
 
 func (h *AggregatorHandler) writeErrorResponse(w http.ResponseWriter, status int, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
+	writeError(w, status, message)
+}
 
-	response := ErrorResponse{
-		Error:  message,
-		Status: status,
+func (h *AggregatorHandler) writeSSE(w http.ResponseWriter, event string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return
 	}
 
-	json.NewEncoder(w).Encode(response)
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, encoded)
+}
+
+type SourceResultEvent struct {
+	Source string         `json:"source"`
+	Events []domain.Event `json:"events"`
+	Count  int            `json:"count"`
+	Error  string         `json:"error,omitempty"`
 }
 
 type SourcesResponse struct {
@@ -164,9 +697,10 @@ type SourcesResponse struct {
 	Total   int                                `json:"total"`
 }
 
-type ErrorResponse struct {
-	Error  string `json:"error"`
-	Status int    `json:"status"`
+// FollowUpResponse lists the sources a fast search still waits on
+type FollowUpResponse struct {
+	ID      string   `json:"id"`
+	Pending []string `json:"pending"`
 }
 
 func (h *AggregatorHandler) formatLocation(city, country string) string {
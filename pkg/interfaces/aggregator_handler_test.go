@@ -13,13 +13,16 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/yair/where-its-at/pkg/domain"
 	"github.com/yair/where-its-at/pkg/integrations"
+	"github.com/yair/where-its-at/pkg/integrations/sources/music"
 )
 
 type mockMegaAggregator struct {
 	searchArtistsFunc          func(ctx context.Context, query string, limit int) (*integrations.AggregatedResults, error)
 	searchEventsFunc           func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error)
 	searchEventsByLocationFunc func(ctx context.Context, city, country string, limit int) (*integrations.AggregatedResults, error)
+	streamEventsFunc           func(ctx context.Context, artistName string, limit int, onResult func(integrations.SourceResult)) (*integrations.AggregatedResults, error)
 	getSourceStatsFunc         func() map[string]integrations.SourceInfo
+	getSourceQuotasFunc        func() map[string]domain.SourceQuota
 }
 
 func (m *mockMegaAggregator) SearchArtists(ctx context.Context, query string, limit int) (*integrations.AggregatedResults, error) {
@@ -43,6 +46,13 @@ func (m *mockMegaAggregator) SearchEventsByLocation(ctx context.Context, city, c
 	return &integrations.AggregatedResults{}, nil
 }
 
+func (m *mockMegaAggregator) StreamEvents(ctx context.Context, artistName string, limit int, onResult func(integrations.SourceResult)) (*integrations.AggregatedResults, error) {
+	if m.streamEventsFunc != nil {
+		return m.streamEventsFunc(ctx, artistName, limit, onResult)
+	}
+	return &integrations.AggregatedResults{}, nil
+}
+
 func (m *mockMegaAggregator) GetSourceStats() map[string]integrations.SourceInfo {
 	if m.getSourceStatsFunc != nil {
 		return m.getSourceStatsFunc()
@@ -50,6 +60,13 @@ func (m *mockMegaAggregator) GetSourceStats() map[string]integrations.SourceInfo
 	return map[string]integrations.SourceInfo{}
 }
 
+func (m *mockMegaAggregator) GetSourceQuotas() map[string]domain.SourceQuota {
+	if m.getSourceQuotasFunc != nil {
+		return m.getSourceQuotasFunc()
+	}
+	return map[string]domain.SourceQuota{}
+}
+
 func TestAggregatorHandler_SearchArtists(t *testing.T) {
 	t.Run("successful artist search", func(t *testing.T) {
 		mock := &mockMegaAggregator{
@@ -435,6 +452,334 @@ func TestAggregatorHandler_SearchEventsByLocation(t *testing.T) {
 	})
 }
 
+func TestAggregatorHandler_StreamEvents(t *testing.T) {
+	t.Run("emits source results then summary", func(t *testing.T) {
+		mock := &mockMegaAggregator{
+			streamEventsFunc: func(ctx context.Context, artistName string, limit int, onResult func(integrations.SourceResult)) (*integrations.AggregatedResults, error) {
+				onResult(integrations.SourceResult{
+					SourceName: "deezer",
+					Events:     []domain.Event{{ID: "1", Title: "Fast Show"}},
+				})
+				onResult(integrations.SourceResult{
+					SourceName: "resident_advisor",
+					Error:      errors.New("timeout"),
+				})
+				return &integrations.AggregatedResults{
+					Events:       []domain.Event{{ID: "1", Title: "Fast Show"}},
+					TotalResults: 1,
+				}, nil
+			},
+		}
+
+		handler := NewAggregatorHandler(mock)
+		router := mux.NewRouter()
+		handler.RegisterRoutes(router)
+
+		req, _ := http.NewRequest("GET", "/api/search/events/stream?artist=test", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if contentType := rr.Header().Get("Content-Type"); contentType != "text/event-stream" {
+			t.Errorf("expected text/event-stream, got %s", contentType)
+		}
+
+		body := rr.Body.String()
+		if strings.Count(body, "event: source_result\n") != 2 {
+			t.Errorf("expected 2 source_result events, got body %q", body)
+		}
+		if !strings.Contains(body, `"source":"deezer"`) || !strings.Contains(body, `"error":"timeout"`) {
+			t.Errorf("expected per-source payloads, got body %q", body)
+		}
+		if strings.Index(body, "event: summary\n") < strings.LastIndex(body, "event: source_result\n") {
+			t.Errorf("expected summary to be the last event, got body %q", body)
+		}
+	})
+
+	t.Run("missing artist parameter", func(t *testing.T) {
+		handler := NewAggregatorHandler(&mockMegaAggregator{})
+		router := mux.NewRouter()
+		handler.RegisterRoutes(router)
+
+		req, _ := http.NewRequest("GET", "/api/search/events/stream", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+	})
+
+	t.Run("aggregator error", func(t *testing.T) {
+		mock := &mockMegaAggregator{
+			streamEventsFunc: func(ctx context.Context, artistName string, limit int, onResult func(integrations.SourceResult)) (*integrations.AggregatedResults, error) {
+				return nil, errors.New("boom")
+			},
+		}
+
+		handler := NewAggregatorHandler(mock)
+		router := mux.NewRouter()
+		handler.RegisterRoutes(router)
+
+		req, _ := http.NewRequest("GET", "/api/search/events/stream?artist=test", nil)
+		rr := httptest.NewRecorder()
+
+		router.ServeHTTP(rr, req)
+
+		if !strings.Contains(rr.Body.String(), "event: error\n") {
+			t.Errorf("expected error event, got body %q", rr.Body.String())
+		}
+	})
+}
+
+func TestAggregatorHandler_SourceFilter(t *testing.T) {
+	var filter integrations.SourceFilter
+	mock := &mockMegaAggregator{
+		searchArtistsFunc: func(ctx context.Context, query string, limit int) (*integrations.AggregatedResults, error) {
+			filter = integrations.SourceFilterFrom(ctx)
+			return &integrations.AggregatedResults{}, nil
+		},
+	}
+
+	router := mux.NewRouter()
+	NewAggregatorHandler(mock).RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/api/search/artists?q=Radiohead&sources=Spotify,+bandsintown,&exclude_sources=resident_advisor", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(filter.Only) != 2 || filter.Only[0] != "spotify" || filter.Only[1] != "bandsintown" {
+		t.Errorf("expected spotify and bandsintown, got %v", filter.Only)
+	}
+	if len(filter.Exclude) != 1 || filter.Exclude[0] != "resident_advisor" {
+		t.Errorf("expected resident_advisor to be excluded, got %v", filter.Exclude)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/search/artists?q=Radiohead", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if !filter.IsZero() {
+		t.Errorf("expected no filter without the parameters, got %+v", filter)
+	}
+}
+
+func TestAggregatorHandler_Storefront(t *testing.T) {
+	var storefront string
+	mock := &mockMegaAggregator{
+		searchArtistsFunc: func(ctx context.Context, query string, limit int) (*integrations.AggregatedResults, error) {
+			storefront = music.StorefrontFrom(ctx)
+			return &integrations.AggregatedResults{}, nil
+		},
+	}
+
+	router := mux.NewRouter()
+	NewAggregatorHandler(mock).RegisterRoutes(router)
+
+	tests := []struct {
+		query          string
+		acceptLanguage string
+		expected       string
+	}{
+		{"&storefront=Germany", "fr-FR", "de"},
+		{"&storefront=gb", "", "gb"},
+		{"", "fr-CH, fr;q=0.9, de-DE;q=0.8", "ch"},
+		{"", "en;q=0.9, pt-BR;q=0.5, ja-JP;q=0.7", "jp"},
+		{"", "zh-Hant-TW", "tw"},
+		{"", "de, en", ""},
+		{"", "es-419", ""},
+		{"", "nl-NL;q=0", ""},
+		{"&storefront=Atlantis", "", ""},
+	}
+
+	for _, tt := range tests {
+		storefront = "unset"
+		req, _ := http.NewRequest("GET", "/api/search/artists?q=Radiohead"+tt.query, nil)
+		if tt.acceptLanguage != "" {
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+		if storefront != tt.expected {
+			t.Errorf("%q with Accept-Language %q: expected storefront %q, got %q", tt.query, tt.acceptLanguage, tt.expected, storefront)
+		}
+	}
+}
+
+func TestAggregatorHandler_StorefrontCaching(t *testing.T) {
+	mock := &mockMegaAggregator{
+		searchArtistsFunc: func(ctx context.Context, query string, limit int) (*integrations.AggregatedResults, error) {
+			return &integrations.AggregatedResults{Artists: []domain.Artist{{ID: "apple_1", Name: "Radiohead"}}}, nil
+		},
+	}
+
+	router := mux.NewRouter()
+	NewAggregatorHandler(mock).RegisterRoutes(router)
+
+	search := func(query, acceptLanguage string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/search/artists?q=Radiohead"+query, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	german, french := search("", "de-DE"), search("", "fr-FR")
+	if german.Header().Get("Vary") != "Accept-Language" {
+		t.Errorf("expected the response to vary with Accept-Language, got %q", german.Header().Get("Vary"))
+	}
+	if german.Header().Get("ETag") == french.Header().Get("ETag") {
+		t.Error("expected storefronts to get different ETags")
+	}
+
+	named := search("&storefront=de", "fr-FR")
+	if named.Header().Get("Vary") != "" {
+		t.Errorf("expected no Vary when the storefront is named, got %q", named.Header().Get("Vary"))
+	}
+	if named.Header().Get("ETag") != german.Header().Get("ETag") {
+		t.Error("expected the same storefront to get the same ETag")
+	}
+}
+
+func TestAggregatorHandler_When(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	// Friday 16 October 2026, 20:00 in Berlin and 07:00 Saturday in Auckland
+	now := time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC)
+	cached := &integrations.AggregatedResults{
+		Events: []domain.Event{
+			{ID: "tonight", ArtistName: "Test Artist", Timezone: "Europe/Berlin", DateTime: time.Date(2026, 10, 16, 22, 0, 0, 0, berlin)},
+			{ID: "sunday", ArtistName: "Test Artist", Timezone: "Europe/Berlin", DateTime: time.Date(2026, 10, 18, 20, 0, 0, 0, berlin)},
+			{ID: "next_week", ArtistName: "Test Artist", Timezone: "Europe/Berlin", DateTime: time.Date(2026, 10, 21, 20, 0, 0, 0, berlin)},
+		},
+		Scores: map[string]integrations.ResultScore{"tonight": {Score: 1}, "sunday": {Score: 0.5}, "next_week": {Score: 0.2}},
+	}
+	mock := &mockMegaAggregator{
+		searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+			return cached, nil
+		},
+	}
+
+	handler := NewAggregatorHandler(mock)
+	handler.now = func() time.Time { return now }
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	search := func(query string, header http.Header) (*httptest.ResponseRecorder, []string) {
+		req, _ := http.NewRequest("GET", "/api/search/events?artist=Test+Artist"+query, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var response integrations.AggregatedResults
+		json.NewDecoder(rr.Body).Decode(&response)
+		ids := []string{}
+		for _, event := range response.Events {
+			ids = append(ids, event.ID)
+		}
+		return rr, ids
+	}
+
+	tests := []struct {
+		name   string
+		query  string
+		header http.Header
+		want   string
+	}{
+		{"unnarrowed", "", nil, "tonight,sunday,next_week"},
+		{"tonight at the venue", "&when=tonight", nil, "tonight"},
+		{"the weekend under way", "&when=this-weekend", nil, "tonight,sunday"},
+		{"tomorrow in Auckland is Sunday", "&when=tomorrow&tz=Pacific/Auckland", nil, "sunday"},
+		{"the timezone from the header", "&when=tomorrow", http.Header{"Time-Zone": {"Pacific/Auckland"}}, "sunday"},
+		{"the next 7 days", "&when=next-7-days", nil, "tonight,sunday,next_week"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, ids := search(tt.query, tt.header)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d", rr.Code)
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	if len(cached.Events) != 3 || len(cached.Scores) != 3 {
+		t.Error("expected the aggregator's results left alone")
+	}
+
+	for _, query := range []string{"&when=someday", "&when=tonight&tz=Mars/Olympus_Mons"} {
+		if rr, _ := search(query, nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %s, got %d", query, rr.Code)
+		}
+	}
+}
+
+func TestAggregatorHandler_AllAges(t *testing.T) {
+	at := time.Now().Add(48 * time.Hour)
+	cached := &integrations.AggregatedResults{
+		Events: []domain.Event{
+			{ID: "unrestricted", ArtistName: "Test Artist", DateTime: at},
+			{ID: "all_ages", ArtistName: "Test Artist", DateTime: at, AgeRestriction: "All Ages"},
+			{ID: "adults", ArtistName: "Test Artist", DateTime: at, AgeRestriction: "18+"},
+			{ID: "legal_age", ArtistName: "Test Artist", DateTime: at, AgeRestriction: "Legal drinking age"},
+		},
+		Scores: map[string]integrations.ResultScore{"unrestricted": {Score: 1}, "adults": {Score: 0.5}},
+	}
+	mock := &mockMegaAggregator{
+		searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+			return cached, nil
+		},
+		searchEventsByLocationFunc: func(ctx context.Context, city, country string, limit int) (*integrations.AggregatedResults, error) {
+			return cached, nil
+		},
+	}
+	router := mux.NewRouter()
+	NewAggregatorHandler(mock).RegisterRoutes(router)
+
+	search := func(path string) (*httptest.ResponseRecorder, integrations.AggregatedResults) {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var response integrations.AggregatedResults
+		json.NewDecoder(rr.Body).Decode(&response)
+		return rr, response
+	}
+
+	for _, path := range []string{"/api/search/events?artist=Test+Artist", "/api/search/events/location?city=Berlin"} {
+		rr, response := search(path + "&all_ages=true")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200 for %s, got %d", path, rr.Code)
+		}
+		ids := []string{}
+		for _, event := range response.Events {
+			ids = append(ids, event.ID)
+		}
+		if got := strings.Join(ids, ","); got != "unrestricted,all_ages" {
+			t.Errorf("expected only the events anyone can go to from %s, got %s", path, got)
+		}
+		if _, ok := response.Scores["adults"]; ok || response.TotalResults != 2 {
+			t.Errorf("expected the narrowed scores and total from %s, got %+v", path, response)
+		}
+
+		if _, response := search(path + "&all_ages=false"); len(response.Events) != 4 {
+			t.Errorf("expected every event from %s without the filter, got %d", path, len(response.Events))
+		}
+	}
+
+	if len(cached.Events) != 4 {
+		t.Error("expected the aggregator's results left alone")
+	}
+	if rr, _ := search("/api/search/events?artist=Test+Artist&all_ages=maybe"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a bad all_ages, got %d", rr.Code)
+	}
+}
+
 func TestAggregatorHandler_GetSources(t *testing.T) {
 	t.Run("successful get sources", func(t *testing.T) {
 		mock := &mockMegaAggregator{
@@ -474,6 +819,27 @@ func TestAggregatorHandler_GetSources(t *testing.T) {
 			t.Errorf("expected spotify to be music type, got %s", response.Sources["spotify"].Type)
 		}
 	})
+
+	t.Run("quotas stay behind the admin token", func(t *testing.T) {
+		mock := &mockMegaAggregator{
+			getSourceQuotasFunc: func() map[string]domain.SourceQuota {
+				return map[string]domain.SourceQuota{
+					"youtube": {Source: "youtube_music", Limit: 10000, Used: 201, Remaining: 9799},
+				}
+			},
+		}
+
+		router := mux.NewRouter()
+		NewAggregatorHandler(mock).RegisterRoutes(router)
+
+		req, _ := http.NewRequest("GET", "/api/sources", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if strings.Contains(rr.Body.String(), "quota") || strings.Contains(rr.Body.String(), "9799") {
+			t.Errorf("expected no quotas in the public sources list, got %s", rr.Body.String())
+		}
+	})
 }
 
 func TestAggregatorHandler_ErrorResponses(t *testing.T) {
@@ -562,6 +928,71 @@ func TestAggregatorHandler_ContentType(t *testing.T) {
 	}
 }
 
+func TestAggregatorHandler_ConditionalGet(t *testing.T) {
+	updated := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	fetchedAt := updated
+	title := "Radiohead live"
+	mock := &mockMegaAggregator{
+		searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+			return &integrations.AggregatedResults{
+				Events: []domain.Event{
+					{ID: "1", ArtistName: artistName, Title: title, UpdatedAt: updated, CachedUntil: fetchedAt.Add(time.Hour)},
+				},
+				SearchTime: time.Since(fetchedAt),
+			}, nil
+		},
+	}
+
+	router := mux.NewRouter()
+	NewAggregatorHandler(mock).RegisterRoutes(router)
+
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/api/search/events?artist=Radiohead", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := get(nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with an ETag, got %d %q", first.Code, etag)
+	}
+	if got := first.Header().Get("Cache-Control"); got != "public, max-age=60" {
+		t.Errorf("unexpected Cache-Control %q", got)
+	}
+	if got := first.Header().Get("Last-Modified"); got != "Sun, 01 Mar 2026 12:00:00 GMT" {
+		t.Errorf("unexpected Last-Modified %q", got)
+	}
+
+	// A re-fetch of the same events keeps the tag
+	fetchedAt = fetchedAt.Add(time.Minute)
+	if rr := get(map[string]string{"If-None-Match": etag}); rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected an empty 304, got %d with %d bytes", rr.Code, rr.Body.Len())
+	}
+	if rr := get(map[string]string{"If-None-Match": `"other", W/` + etag}); rr.Code != http.StatusNotModified {
+		t.Errorf("expected a weak match in a list to be 304, got %d", rr.Code)
+	}
+	if rr := get(map[string]string{"If-None-Match": `"other"`}); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 for a stale tag, got %d", rr.Code)
+	}
+
+	if rr := get(map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"}); rr.Code != http.StatusNotModified {
+		t.Errorf("expected 304 when unmodified since, got %d", rr.Code)
+	}
+	updated = updated.Add(time.Hour)
+	if rr := get(map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 12:00:00 GMT"}); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 once modified, got %d", rr.Code)
+	}
+	title = "Radiohead live in Berlin"
+	if rr := get(map[string]string{"If-None-Match": etag}); rr.Code != http.StatusOK {
+		t.Errorf("expected a new tag once the events change, got %d", rr.Code)
+	}
+}
+
 func TestAggregatorHandler_FormatLocation(t *testing.T) {
 	handler := &AggregatorHandler{}
 
@@ -743,3 +1174,86 @@ func TestAggregatorHandler_RequestContext(t *testing.T) {
 		}
 	})
 }
+
+// followUpAggregator answers searches early and has one follow-up in
+// progress and one complete
+type followUpAggregator struct {
+	mockMegaAggregator
+}
+
+func (f *followUpAggregator) FollowUp(id string) (*integrations.AggregatedResults, []string, bool) {
+	switch id {
+	case "waiting":
+		return nil, []string{"venue_pages"}, true
+	case "done":
+		return &integrations.AggregatedResults{Events: []domain.Event{{ID: "1"}, {ID: "2"}}, TotalResults: 2}, nil, true
+	}
+	return nil, nil, false
+}
+
+func TestAggregatorHandler_FastSearch(t *testing.T) {
+	var fast integrations.FastSearch
+	var isFast bool
+	aggregator := &followUpAggregator{mockMegaAggregator{
+		searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+			fast, isFast = integrations.FastSearchFrom(ctx)
+			results := &integrations.AggregatedResults{Events: []domain.Event{{ID: "1"}}, TotalResults: 1}
+			if isFast {
+				results.Pending = []string{"venue_pages"}
+				results.FollowUp = "waiting"
+			}
+			return results, nil
+		},
+	}}
+	router := mux.NewRouter()
+	NewAggregatorHandler(aggregator).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/api/search/events?artist=Bicep&mode=fast&quorum=3")
+	if rr.Code != http.StatusOK || !isFast || fast.Quorum != 3 {
+		t.Fatalf("expected a fast search with quorum 3, got %d, %v %+v", rr.Code, isFast, fast)
+	}
+	var results integrations.AggregatedResults
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if results.FollowUp != "waiting" || len(results.Pending) != 1 {
+		t.Errorf("expected the pending sources and follow-up ID, got %+v", results)
+	}
+	if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "no-store" || rr.Header().Get("ETag") != "" {
+		t.Errorf("expected an early answer not to be cached, got %q", cacheControl)
+	}
+
+	if get("/api/search/events?artist=Bicep"); isFast {
+		t.Error("expected searches to wait for every source by default")
+	}
+	if rr := get("/api/search/events?artist=Bicep&mode=quick"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an unknown mode, got %d", rr.Code)
+	}
+
+	rr = get("/api/search/follow-up/waiting")
+	var followUp FollowUpResponse
+	if err := json.NewDecoder(rr.Body).Decode(&followUp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rr.Code != http.StatusAccepted || len(followUp.Pending) != 1 || followUp.Pending[0] != "venue_pages" {
+		t.Errorf("expected 202 with the pending source, got %d %+v", rr.Code, followUp)
+	}
+
+	rr = get("/api/search/follow-up/done")
+	if err := json.NewDecoder(rr.Body).Decode(&results); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rr.Code != http.StatusOK || results.TotalResults != 2 {
+		t.Errorf("expected the complete results, got %d %+v", rr.Code, results)
+	}
+
+	if rr := get("/api/search/follow-up/unknown"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown follow-up, got %d", rr.Code)
+	}
+}
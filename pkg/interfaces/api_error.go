@@ -0,0 +1,122 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// Error codes, the machine-readable part of every error response. Clients
+// should branch on these rather than on messages, which may change.
+const (
+	CodeInvalidParam      = "INVALID_PARAM"
+	CodeInvalidBody       = "INVALID_BODY"
+	CodeUnauthorized      = "UNAUTHORIZED"
+	CodeForbidden         = "FORBIDDEN"
+	CodeNotFound          = "NOT_FOUND"
+	CodeMethodNotAllowed  = "METHOD_NOT_ALLOWED"
+	CodeConflict          = "CONFLICT"
+	CodeRateLimited       = "RATE_LIMITED"
+	CodeSourceTimeout     = "SOURCE_TIMEOUT"
+	CodeSourceUnavailable = "SOURCE_UNAVAILABLE"
+	CodeNotImplemented    = "NOT_IMPLEMENTED"
+	CodeInternal          = "INTERNAL"
+)
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error     string       `json:"error"`
+	Code      string       `json:"code"`
+	Status    int          `json:"status"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+// FieldError is one invalid parameter or body field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// codeForStatus is the code of an error that has nothing more specific
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return CodeInvalidParam
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound, http.StatusGone:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusGatewayTimeout:
+		return CodeSourceTimeout
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return CodeSourceUnavailable
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	default:
+		return CodeInternal
+	}
+}
+
+// writeError writes the error envelope with the code status implies
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeErrorEnvelope(w, ErrorResponse{Error: message, Status: status})
+}
+
+// writeErrorEnvelope fills in the code when it's missing and the request
+// ID the logging middleware put on the response, then writes resp
+func writeErrorEnvelope(w http.ResponseWriter, resp ErrorResponse) {
+	if resp.Code == "" {
+		resp.Code = codeForStatus(resp.Status)
+	}
+	if resp.RequestID == "" {
+		resp.RequestID = w.Header().Get(RequestIDHeader)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.Status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// RouteNotFound answers requests for paths no route serves, for
+// router.NotFoundHandler
+func RouteNotFound() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	})
+}
+
+// MethodNotAllowed answers requests for a path with a method no route
+// serves it with, for router.MethodNotAllowedHandler
+func MethodNotAllowed() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	})
+}
+
+// writeSourceError answers a failed search, telling timeouts, rate limits
+// and unavailable sources apart from other failures, which get message
+func writeSourceError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeErrorEnvelope(w, ErrorResponse{Error: "sources timed out", Code: CodeSourceTimeout, Status: http.StatusGatewayTimeout})
+	case errors.Is(err, domain.ErrRateLimitExceeded):
+		writeErrorEnvelope(w, ErrorResponse{Error: "rate limit exceeded", Code: CodeRateLimited, Status: http.StatusTooManyRequests})
+	case errors.Is(err, domain.ErrExternalAPIFailure), errors.Is(err, integrations.ErrCircuitOpen):
+		writeErrorEnvelope(w, ErrorResponse{Error: "external service unavailable", Code: CodeSourceUnavailable, Status: http.StatusServiceUnavailable})
+	default:
+		writeError(w, http.StatusInternalServerError, message)
+	}
+}
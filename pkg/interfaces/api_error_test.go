@@ -0,0 +1,79 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+	"github.com/yair/where-its-at/pkg/logging"
+)
+
+func decodeError(t *testing.T, rr *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	var response ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode error: %v", err)
+	}
+	return response
+}
+
+func TestWriteSourceError(t *testing.T) {
+	for _, tt := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("search: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, CodeSourceTimeout},
+		{domain.ErrRateLimitExceeded, http.StatusTooManyRequests, CodeRateLimited},
+		{integrations.ErrCircuitOpen, http.StatusServiceUnavailable, CodeSourceUnavailable},
+		{fmt.Errorf("boom"), http.StatusInternalServerError, CodeInternal},
+	} {
+		rr := httptest.NewRecorder()
+		writeSourceError(rr, tt.err, "failed to search events")
+
+		response := decodeError(t, rr)
+		if rr.Code != tt.status || response.Status != tt.status || response.Code != tt.code {
+			t.Errorf("%v: expected %d %s, got %d %+v", tt.err, tt.status, tt.code, rr.Code, response)
+		}
+	}
+}
+
+// Handlers that used to answer in their own shapes all use the envelope
+func TestErrorEnvelope_AcrossHandlers(t *testing.T) {
+	router := mux.NewRouter()
+	router.NotFoundHandler = RouteNotFound()
+	router.MethodNotAllowedHandler = MethodNotAllowed()
+	NewArtistHandler(&mockArtistService{}).RegisterRoutes(router)
+	NewAggregatorHandler(&mockMegaAggregator{
+		searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+			return nil, domain.ErrRateLimitExceeded
+		},
+	}).RegisterRoutes(router)
+	router.Use(RequestLogging(logging.New(io.Discard, "text", "error")))
+
+	for _, tt := range []struct {
+		path   string
+		status int
+		code   string
+	}{
+		{"/api/artists/search", http.StatusBadRequest, CodeInvalidParam},
+		{"/api/search/artists", http.StatusBadRequest, CodeInvalidParam},
+		{"/api/search/events?artist=Radiohead", http.StatusTooManyRequests, CodeRateLimited},
+		{"/api/nope", http.StatusNotFound, CodeNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+
+		response := decodeError(t, rr)
+		if rr.Code != tt.status || response.Code != tt.code || response.Status != tt.status || response.Error == "" {
+			t.Errorf("%s: expected %d %s, got %d %+v", tt.path, tt.status, tt.code, rr.Code, response)
+		}
+	}
+}
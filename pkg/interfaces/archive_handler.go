@@ -0,0 +1,80 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/export"
+)
+
+// ArchiveHandler serves the events that left the event store's hot table,
+// so past events stay around for history and stats
+type ArchiveHandler struct {
+	events domain.EventArchiveRepository
+}
+
+func NewArchiveHandler(events domain.EventArchiveRepository) *ArchiveHandler {
+	return &ArchiveHandler{
+		events: events,
+	}
+}
+
+func (h *ArchiveHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/events/archive", h.ListArchived).Methods("GET")
+}
+
+type ArchivedEventsResponse struct {
+	Events []domain.Event `json:"events"`
+}
+
+// ListArchived returns archived events latest first, optionally narrowed
+// by artist, city and a from/to date range like the export's, at most
+// limit (default 50, at most 200)
+func (h *ArchiveHandler) ListArchived(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Artist string `query:"artist"`
+		City   string `query:"city"`
+		From   string `query:"from"`
+		To     string `query:"to"`
+		Limit  int    `query:"limit" limit:"50,200"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	from, err := export.ParseDate(params.From, false)
+	if err != nil {
+		writeValidationError(w, invalidParam("from", "must be a date (YYYY-MM-DD) or RFC 3339 time"))
+		return
+	}
+	to, err := export.ParseDate(params.To, true)
+	if err != nil {
+		writeValidationError(w, invalidParam("to", "must be a date (YYYY-MM-DD) or RFC 3339 time"))
+		return
+	}
+
+	events, err := h.events.ListArchived(r.Context(), domain.EventFilter{
+		Artist: params.Artist,
+		City:   params.City,
+		From:   from,
+		To:     to,
+	}, params.Limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list archived events")
+		return
+	}
+	if events == nil {
+		events = []domain.Event{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, ArchivedEventsResponse{Events: events})
+}
+
+func (h *ArchiveHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
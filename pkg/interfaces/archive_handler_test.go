@@ -0,0 +1,71 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubArchive struct {
+	filter domain.EventFilter
+	limit  int
+	events []domain.Event
+}
+
+func (s *stubArchive) ListArchived(ctx context.Context, filter domain.EventFilter, limit int) ([]domain.Event, error) {
+	s.filter, s.limit = filter, limit
+	return s.events, nil
+}
+
+func TestArchiveHandler(t *testing.T) {
+	archivedAt := time.Now()
+	archive := &stubArchive{events: []domain.Event{
+		{ID: "e1", ArtistName: "Radiohead", ArchivedAt: &archivedAt},
+	}}
+	router := mux.NewRouter()
+	NewArchiveHandler(archive).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/events/archive?artist=Radiohead&city=London&from=2025-01-01&to=2025-12-31")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if archive.filter.Artist != "Radiohead" || archive.filter.City != "London" || archive.limit != 50 {
+		t.Errorf("unexpected filter %+v, limit %d", archive.filter, archive.limit)
+	}
+	if archive.filter.From == nil || archive.filter.To == nil || !archive.filter.To.After(*archive.filter.From) {
+		t.Errorf("expected the date range, got %v to %v", archive.filter.From, archive.filter.To)
+	}
+
+	var response ArchivedEventsResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if len(response.Events) != 1 || response.Events[0].ArchivedAt == nil {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+
+	if rr := get("/api/events/archive?from=yesterday"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a bad date, got %d", rr.Code)
+	}
+
+	archive.events = nil
+	rr = get("/api/events/archive?limit=500")
+	if archive.limit != 200 {
+		t.Errorf("expected the limit capped at 200, got %d", archive.limit)
+	}
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Events == nil || len(response.Events) != 0 {
+		t.Errorf("expected an empty list, got %s", rr.Body.String())
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/yair/where-its-at/pkg/domain"
 )
 
+// storedArtistSearchParams are strict about limits, unlike the aggregator's
+type storedArtistSearchParams struct {
+	Query string `query:"q" validate:"required"`
+	Limit int    `query:"limit" validate:"min=1"`
+}
+
 type ArtistHandler struct {
 	service domain.ArtistService
 }
@@ -31,24 +36,13 @@ func (h *ArtistHandler) SearchArtists(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		h.respondWithError(w, http.StatusBadRequest, "query parameter 'q' is required")
+	params := storedArtistSearchParams{Limit: 10}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	limit := 10
-	if limitStr != "" {
-		parsedLimit, err := strconv.Atoi(limitStr)
-		if err != nil || parsedLimit <= 0 {
-			h.respondWithError(w, http.StatusBadRequest, "limit must be a positive integer")
-			return
-		}
-		limit = parsedLimit
-	}
-
-	response, err := h.service.SearchArtists(ctx, query, limit)
+	response, err := h.service.SearchArtists(ctx, params.Query, params.Limit)
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidRequest:
@@ -90,13 +84,13 @@ func (h *ArtistHandler) SaveArtist(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	var artist domain.Artist
-	if err := json.NewDecoder(r.Body).Decode(&artist); err != nil {
-		h.respondWithError(w, http.StatusBadRequest, "invalid request body")
+	if err := decodeJSON(r, &artist); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
 	if artist.Name == "" {
-		h.respondWithError(w, http.StatusBadRequest, "artist name is required")
+		writeValidationError(w, invalidField("name", "is required"))
 		return
 	}
 
@@ -116,14 +110,13 @@ func (h *ArtistHandler) SaveArtist(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *ArtistHandler) respondWithError(w http.ResponseWriter, code int, message string) {
-	h.respondWithJSON(w, code, map[string]string{"error": message})
+	writeError(w, code, message)
 }
 
 func (h *ArtistHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"error":"internal server error"}`))
+		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
@@ -0,0 +1,182 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type userIDKey struct{}
+
+// UserIDFromContext returns the ID of the user RequireUser authenticated
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(userIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// RequireUser rejects requests without a valid bearer access token and puts
+// the authenticated user's ID in the request context
+func RequireUser(auth *AuthService) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				respondUnauthorized(w, "missing bearer token")
+				return
+			}
+
+			userID, err := auth.Authenticate(token)
+			if err != nil {
+				respondUnauthorized(w, "invalid or expired token")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userIDKey{}, userID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func respondUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="where-its-at"`)
+	writeError(w, http.StatusUnauthorized, message)
+}
+
+type AuthHandler struct {
+	service *AuthService
+}
+
+func NewAuthHandler(service *AuthService) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+	}
+}
+
+func (h *AuthHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/auth/register", h.Register).Methods("POST")
+	router.HandleFunc("/api/auth/login", h.Login).Methods("POST")
+	router.HandleFunc("/api/auth/refresh", h.Refresh).Methods("POST")
+	router.Handle("/api/me", RequireUser(h.service)(http.HandlerFunc(h.Me))).Methods("GET")
+}
+
+// credentialsRequest only checks presence; AuthService.Register applies the
+// rules for new accounts
+type credentialsRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var request credentialsRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.Register(ctx, request.Email, request.Password)
+	if err != nil {
+		var validationErr domain.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			writeValidationError(w, invalidField(validationErr.Field, validationErr.Message))
+		case errors.Is(err, domain.ErrDuplicateUser):
+			h.respondWithError(w, http.StatusConflict, "an account with this email already exists")
+		default:
+			h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, result)
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var request credentialsRequest
+	if err := decodeJSON(r, &request); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.Login(ctx, request.Email, request.Password)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCredentials) {
+			h.respondWithError(w, http.StatusUnauthorized, "invalid email or password")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var request struct {
+		RefreshToken string `json:"refresh_token" validate:"required"`
+	}
+	if err := decodeJSON(r, &request); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.Refresh(ctx, request.RefreshToken)
+	if err != nil {
+		if errors.Is(err, ErrInvalidToken) {
+			respondUnauthorized(w, "invalid or expired refresh token")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	user, err := h.service.GetUser(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			respondUnauthorized(w, "user no longer exists")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, user)
+}
+
+func (h *AuthHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *AuthHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
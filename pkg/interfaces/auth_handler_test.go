@@ -0,0 +1,81 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestAuthHandler(t *testing.T) {
+	router := mux.NewRouter()
+	NewAuthHandler(newTestAuthService(newMemoryUserRepository())).RegisterRoutes(router)
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := do("POST", "/api/auth/register", `{"email":"kim@example.com","password":"correct horse"}`, "")
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var registered AuthResult
+	json.Unmarshal(rr.Body.Bytes(), &registered)
+	if registered.AccessToken == "" || registered.TokenType != "Bearer" || registered.ExpiresIn != 900 {
+		t.Errorf("unexpected tokens: %+v", registered.AuthTokens)
+	}
+	if strings.Contains(rr.Body.String(), "password") {
+		t.Error("expected the password hash to stay out of the response")
+	}
+
+	t.Run("register errors", func(t *testing.T) {
+		if rr := do("POST", "/api/auth/register", `{"email":"kim@example.com","password":"correct horse"}`, ""); rr.Code != http.StatusConflict {
+			t.Errorf("expected status 409, got %d", rr.Code)
+		}
+		if rr := do("POST", "/api/auth/register", `{"email":"new@example.com","password":"short"}`, ""); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+	})
+
+	t.Run("login", func(t *testing.T) {
+		if rr := do("POST", "/api/auth/login", `{"email":"kim@example.com","password":"correct horse"}`, ""); rr.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rr.Code)
+		}
+		if rr := do("POST", "/api/auth/login", `{"email":"kim@example.com","password":"wrong"}`, ""); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rr.Code)
+		}
+	})
+
+	t.Run("refresh", func(t *testing.T) {
+		if rr := do("POST", "/api/auth/refresh", `{"refresh_token":"`+registered.RefreshToken+`"}`, ""); rr.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rr.Code)
+		}
+		if rr := do("POST", "/api/auth/refresh", `{"refresh_token":"garbage"}`, ""); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rr.Code)
+		}
+	})
+
+	t.Run("me", func(t *testing.T) {
+		rr := do("GET", "/api/me", "", registered.AccessToken)
+		if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"email":"kim@example.com"`) {
+			t.Errorf("expected the current user, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		rr = do("GET", "/api/me", "", "")
+		if rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("expected a bearer challenge, got %d", rr.Code)
+		}
+		if rr := do("GET", "/api/me", "", registered.RefreshToken); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected a refresh token to be refused, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,263 @@
+package interfaces
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/yair/where-its-at/pkg/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	tokenIssuer     = "where-its-at"
+	accessTokenUse  = "access"
+	refreshTokenUse = "refresh"
+	oauthStateUse   = "oauth_state"
+
+	// oauthStateTTL is how long a user has to grant access to a third-party
+	// account they started connecting
+	oauthStateTTL = 10 * time.Minute
+
+	minPasswordLength = 8
+	// bcrypt ignores everything past 72 bytes
+	maxPasswordLength = 72
+)
+
+// ErrInvalidToken is returned for access or refresh tokens that are
+// malformed, expired, signed with another key or of the wrong kind
+var ErrInvalidToken = errors.New("invalid token")
+
+type AuthConfig struct {
+	Secret          []byte
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// AuthTokens is the token pair handed out on register, login and refresh
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+type AuthResult struct {
+	User *domain.User `json:"user"`
+	AuthTokens
+}
+
+type tokenClaims struct {
+	TokenUse string `json:"token_use"`
+	jwt.RegisteredClaims
+}
+
+// AuthService registers users and issues the JWTs that identify them
+type AuthService struct {
+	users      domain.UserRepository
+	config     AuthConfig
+	now        func() time.Time
+	dummyHash  []byte
+	bcryptCost int
+}
+
+func NewAuthService(users domain.UserRepository, config AuthConfig) *AuthService {
+	if config.AccessTokenTTL == 0 {
+		config.AccessTokenTTL = 15 * time.Minute
+	}
+	if config.RefreshTokenTTL == 0 {
+		config.RefreshTokenTTL = 30 * 24 * time.Hour
+	}
+
+	service := &AuthService{
+		users:      users,
+		config:     config,
+		now:        time.Now,
+		bcryptCost: bcrypt.DefaultCost,
+	}
+	// Logins for unknown emails still pay for a bcrypt comparison so response
+	// times don't reveal which addresses have accounts
+	service.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a real password"), service.bcryptCost)
+
+	return service
+}
+
+func (s *AuthService) Register(ctx context.Context, email, password string) (*AuthResult, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if len(password) < minPasswordLength {
+		return nil, domain.ValidationError{Field: "password", Message: fmt.Sprintf("must be at least %d characters", minPasswordLength)}
+	}
+	if len(password) > maxPasswordLength {
+		return nil, domain.ValidationError{Field: "password", Message: fmt.Sprintf("must be at most %d bytes", maxPasswordLength)}
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), s.bcryptCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	id, err := newUserID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate user ID: %w", err)
+	}
+
+	user := &domain.User{ID: id, Email: email, PasswordHash: string(hash)}
+	if err := s.users.Create(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return s.issue(user)
+}
+
+func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthResult, error) {
+	user, err := s.users.GetByEmail(ctx, strings.TrimSpace(email))
+	if errors.Is(err, domain.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(s.dummyHash, []byte(password))
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	return s.issue(user)
+}
+
+// Refresh trades a valid refresh token for a new token pair. Users deleted
+// since the token was issued can't refresh.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthResult, error) {
+	userID, err := s.verify(refreshToken, refreshTokenUse)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return s.issue(user)
+}
+
+// Authenticate returns the ID of the user an access token was issued to
+func (s *AuthService) Authenticate(accessToken string) (string, error) {
+	return s.verify(accessToken, accessTokenUse)
+}
+
+// IssueOAuthState returns the state to send through a third-party
+// authorization, so the callback knows which user connected the account
+func (s *AuthService) IssueOAuthState(userID string) (string, error) {
+	return s.sign(userID, oauthStateUse, oauthStateTTL)
+}
+
+// VerifyOAuthState returns the ID of the user an OAuth state was issued to
+func (s *AuthService) VerifyOAuthState(state string) (string, error) {
+	return s.verify(state, oauthStateUse)
+}
+
+func (s *AuthService) GetUser(ctx context.Context, id string) (*domain.User, error) {
+	return s.users.GetByID(ctx, id)
+}
+
+func (s *AuthService) issue(user *domain.User) (*AuthResult, error) {
+	access, err := s.sign(user.ID, accessTokenUse, s.config.AccessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := s.sign(user.ID, refreshTokenUse, s.config.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthResult{
+		User: user,
+		AuthTokens: AuthTokens{
+			AccessToken:  access,
+			RefreshToken: refresh,
+			TokenType:    "Bearer",
+			ExpiresIn:    int(s.config.AccessTokenTTL.Seconds()),
+		},
+	}, nil
+}
+
+func (s *AuthService) sign(userID, use string, ttl time.Duration) (string, error) {
+	now := s.now()
+	id, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token ID: %w", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		TokenUse: use,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Issuer:    tokenIssuer,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+
+	signed, err := token.SignedString(s.config.Secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, nil
+}
+
+func (s *AuthService) verify(signed, use string) (string, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(signed, &claims, func(token *jwt.Token) (interface{}, error) {
+		return s.config.Secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil || claims.TokenUse != use || claims.Subject == "" {
+		return "", ErrInvalidToken
+	}
+
+	return claims.Subject, nil
+}
+
+func normalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email {
+		return "", domain.ValidationError{Field: "email", Message: "must be a valid email address"}
+	}
+	return email, nil
+}
+
+func newUserID() (string, error) {
+	id, err := randomHex(12)
+	if err != nil {
+		return "", err
+	}
+	return "user_" + id, nil
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,177 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type memoryUserRepository struct {
+	mu    sync.Mutex
+	users map[string]domain.User
+}
+
+func newMemoryUserRepository() *memoryUserRepository {
+	return &memoryUserRepository{users: make(map[string]domain.User)}
+}
+
+func (m *memoryUserRepository) Create(ctx context.Context, user *domain.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if strings.EqualFold(existing.Email, user.Email) {
+			return domain.ErrDuplicateUser
+		}
+	}
+	m.users[user.ID] = *user
+	return nil
+}
+
+func (m *memoryUserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	user, ok := m.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+func (m *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, user := range m.users {
+		if strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func newTestAuthService(users domain.UserRepository) *AuthService {
+	service := NewAuthService(users, AuthConfig{Secret: []byte("test-secret")})
+	service.bcryptCost = bcrypt.MinCost
+	return service
+}
+
+func TestAuthService_RegisterAndLogin(t *testing.T) {
+	users := newMemoryUserRepository()
+	service := newTestAuthService(users)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, " kim@example.com ", "correct horse")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if registered.User.Email != "kim@example.com" || !strings.HasPrefix(registered.User.ID, "user_") {
+		t.Errorf("unexpected user: %+v", registered.User)
+	}
+	if registered.User.PasswordHash == "correct horse" {
+		t.Error("expected the password to be hashed")
+	}
+
+	userID, err := service.Authenticate(registered.AccessToken)
+	if err != nil || userID != registered.User.ID {
+		t.Errorf("expected the access token to identify the user, got %q (%v)", userID, err)
+	}
+
+	t.Run("login", func(t *testing.T) {
+		result, err := service.Login(ctx, "KIM@example.com", "correct horse")
+		if err != nil || result.User.ID != registered.User.ID {
+			t.Errorf("expected login to succeed, got %v", err)
+		}
+	})
+
+	t.Run("wrong password and unknown email look the same", func(t *testing.T) {
+		if _, err := service.Login(ctx, "kim@example.com", "wrong password"); !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Errorf("expected ErrInvalidCredentials, got %v", err)
+		}
+		if _, err := service.Login(ctx, "nobody@example.com", "correct horse"); !errors.Is(err, domain.ErrInvalidCredentials) {
+			t.Errorf("expected ErrInvalidCredentials, got %v", err)
+		}
+	})
+
+	t.Run("duplicate email", func(t *testing.T) {
+		if _, err := service.Register(ctx, "kim@example.com", "another password"); !errors.Is(err, domain.ErrDuplicateUser) {
+			t.Errorf("expected ErrDuplicateUser, got %v", err)
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		for _, c := range []struct{ email, password, field string }{
+			{"not-an-email", "correct horse", "email"},
+			{"Kim <kim2@example.com>", "correct horse", "email"},
+			{"kim2@example.com", "short", "password"},
+			{"kim2@example.com", strings.Repeat("x", 73), "password"},
+		} {
+			_, err := service.Register(ctx, c.email, c.password)
+			var validationErr domain.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Field != c.field {
+				t.Errorf("expected a %s validation error for %q/%q, got %v", c.field, c.email, c.password, err)
+			}
+		}
+	})
+}
+
+func TestAuthService_Tokens(t *testing.T) {
+	users := newMemoryUserRepository()
+	service := newTestAuthService(users)
+	ctx := context.Background()
+
+	registered, err := service.Register(ctx, "kim@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	t.Run("refresh issues a new pair", func(t *testing.T) {
+		refreshed, err := service.Refresh(ctx, registered.RefreshToken)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, err := service.Authenticate(refreshed.AccessToken); err != nil {
+			t.Errorf("expected the new access token to be valid, got %v", err)
+		}
+	})
+
+	t.Run("tokens can't stand in for each other", func(t *testing.T) {
+		if _, err := service.Authenticate(registered.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected a refresh token to be rejected as an access token, got %v", err)
+		}
+		if _, err := service.Refresh(ctx, registered.AccessToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected an access token to be rejected for refresh, got %v", err)
+		}
+	})
+
+	t.Run("other keys are rejected", func(t *testing.T) {
+		other := newTestAuthService(users)
+		other.config.Secret = []byte("another-secret")
+		if _, err := other.Authenticate(registered.AccessToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("expired access token", func(t *testing.T) {
+		service.now = func() time.Time { return time.Now().Add(time.Hour) }
+		defer func() { service.now = time.Now }()
+
+		if _, err := service.Authenticate(registered.AccessToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+
+	t.Run("deleted users can't refresh", func(t *testing.T) {
+		delete(users.users, registered.User.ID)
+		if _, err := service.Refresh(ctx, registered.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("expected ErrInvalidToken, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ChangeFeedHandler serves the ordered stream of changes to stored events,
+// so a downstream mirror can fetch everything once and then only follow
+// what changed since its last cursor
+type ChangeFeedHandler struct {
+	feed domain.EventFeedRepository
+}
+
+func NewChangeFeedHandler(feed domain.EventFeedRepository) *ChangeFeedHandler {
+	return &ChangeFeedHandler{
+		feed: feed,
+	}
+}
+
+func (h *ChangeFeedHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/changes", h.ListChanges).Methods("GET")
+}
+
+type ChangesResponse struct {
+	Changes []domain.EventFeedEntry `json:"changes"`
+	// NextCursor is the since to ask with next; it stays put when
+	// nothing changed
+	NextCursor int64 `json:"next_cursor"`
+	HasMore    bool  `json:"has_more"`
+}
+
+// ListChanges returns the changes after the cursor ?since= (0, the default,
+// for the whole feed), oldest first, at most limit (default 100, at most
+// 1000). A cursor whose changes were already pruned answers 410 Gone, and
+// the mirror has to start over.
+func (h *ChangeFeedHandler) ListChanges(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Since int64 `query:"since" validate:"min=0"`
+		Limit int   `query:"limit" limit:"100,1000"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	// One more than asked for tells whether there are more
+	changes, err := h.feed.ListFeed(r.Context(), params.Since, params.Limit+1)
+	if err != nil {
+		if errors.Is(err, domain.ErrCursorExpired) {
+			writeError(w, http.StatusGone, "cursor expired, start over from since=0")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to list changes")
+		return
+	}
+
+	response := ChangesResponse{Changes: changes, NextCursor: params.Since}
+	if len(changes) > params.Limit {
+		response.Changes, response.HasMore = changes[:params.Limit], true
+	}
+	if response.Changes == nil {
+		response.Changes = []domain.EventFeedEntry{}
+	}
+	if n := len(response.Changes); n > 0 {
+		response.NextCursor = response.Changes[n-1].Cursor
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *ChangeFeedHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
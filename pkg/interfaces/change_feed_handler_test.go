@@ -0,0 +1,85 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubFeed struct {
+	since   int64
+	limit   int
+	entries []domain.EventFeedEntry
+	err     error
+}
+
+func (s *stubFeed) ListFeed(ctx context.Context, since int64, limit int) ([]domain.EventFeedEntry, error) {
+	s.since, s.limit = since, limit
+	if s.err != nil {
+		return nil, s.err
+	}
+	var entries []domain.EventFeedEntry
+	for _, entry := range s.entries {
+		if entry.Cursor > since && len(entries) < limit {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+func TestChangeFeedHandler(t *testing.T) {
+	feed := &stubFeed{entries: []domain.EventFeedEntry{
+		{Cursor: 1, Kind: domain.FeedCreate, EventID: "e1", Event: &domain.Event{ID: "e1"}},
+		{Cursor: 2, Kind: domain.FeedUpdate, EventID: "e1", Event: &domain.Event{ID: "e1"}},
+		{Cursor: 4, Kind: domain.FeedDelete, EventID: "e2"},
+	}}
+	router := mux.NewRouter()
+	NewChangeFeedHandler(feed).RegisterRoutes(router)
+
+	get := func(path string) (*httptest.ResponseRecorder, ChangesResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		var response ChangesResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response
+	}
+
+	rr, page := get("/api/changes?limit=2")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if feed.since != 0 || feed.limit != 3 {
+		t.Errorf("expected one more entry than the limit from the start, got since %d, limit %d", feed.since, feed.limit)
+	}
+	if len(page.Changes) != 2 || page.NextCursor != 2 || !page.HasMore {
+		t.Errorf("unexpected first page %s", rr.Body.String())
+	}
+
+	rr, page = get("/api/changes?since=2&limit=2")
+	if len(page.Changes) != 1 || page.Changes[0].Kind != domain.FeedDelete || page.NextCursor != 4 || page.HasMore {
+		t.Errorf("unexpected second page %s", rr.Body.String())
+	}
+
+	// Nothing new keeps the cursor where it was
+	rr, page = get("/api/changes?since=4")
+	if page.Changes == nil || len(page.Changes) != 0 || page.NextCursor != 4 {
+		t.Errorf("expected no changes at cursor 4, got %s", rr.Body.String())
+	}
+
+	for _, path := range []string{"/api/changes?since=-1", "/api/changes?since=abc"} {
+		if rr, _ := get(path); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400 for %s, got %d", path, rr.Code)
+		}
+	}
+
+	feed.err = domain.ErrCursorExpired
+	if rr, _ := get("/api/changes?since=1"); rr.Code != http.StatusGone {
+		t.Errorf("expected status 410 for an expired cursor, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,73 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// CityHandler serves browse pages for a city from the events already
+// stored, so it doesn't wait on any source
+type CityHandler struct {
+	stats domain.CityStatsRepository
+	now   func() time.Time
+}
+
+func NewCityHandler(stats domain.CityStatsRepository) *CityHandler {
+	return &CityHandler{
+		stats: stats,
+		now:   time.Now,
+	}
+}
+
+func (h *CityHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/cities/{city}/overview", h.GetOverview).Methods("GET")
+}
+
+// GetOverview counts the city's events by week for `weeks` weeks (default
+// 12, at most 52) from today and ranks its top venues and trending artists,
+// `limit` of each (default 10, at most 50)
+func (h *CityHandler) GetOverview(w http.ResponseWriter, r *http.Request) {
+	city := strings.TrimSpace(mux.Vars(r)["city"])
+	if city == "" {
+		h.respondWithError(w, http.StatusBadRequest, "city is required")
+		return
+	}
+
+	params := struct {
+		Weeks int `query:"weeks" validate:"min=1,max=52"`
+		Limit int `query:"limit" limit:"10,50"`
+	}{Weeks: 12}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	now := h.now().UTC()
+	overview, err := h.stats.CityOverview(r.Context(), domain.CityOverviewFilter{
+		City:  city,
+		From:  time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		Weeks: params.Weeks,
+		Limit: params.Limit,
+	})
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get city overview")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, overview)
+}
+
+func (h *CityHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *CityHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
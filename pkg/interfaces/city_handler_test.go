@@ -0,0 +1,64 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubCityStats struct {
+	filter domain.CityOverviewFilter
+}
+
+func (s *stubCityStats) CityOverview(ctx context.Context, filter domain.CityOverviewFilter) (*domain.CityOverview, error) {
+	s.filter = filter
+	return &domain.CityOverview{
+		City:            filter.City,
+		TotalEvents:     3,
+		TrendingArtists: []domain.TrendingArtist{{Name: "Bicep", Events: 2}},
+	}, nil
+}
+
+func TestCityHandler_GetOverview(t *testing.T) {
+	stats := &stubCityStats{}
+	handler := NewCityHandler(stats)
+	handler.now = func() time.Time { return time.Date(2026, 6, 1, 15, 30, 0, 0, time.UTC) }
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/api/cities/New%20York/overview")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var overview domain.CityOverview
+	if err := json.NewDecoder(rr.Body).Decode(&overview); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if overview.City != "New York" || overview.TotalEvents != 3 || len(overview.TrendingArtists) != 1 {
+		t.Errorf("unexpected overview %+v", overview)
+	}
+
+	want := domain.CityOverviewFilter{City: "New York", From: time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC), Weeks: 12, Limit: 10}
+	if stats.filter != want {
+		t.Errorf("expected %+v, got %+v", want, stats.filter)
+	}
+
+	if get("/api/cities/Berlin/overview?weeks=4&limit=500"); stats.filter.Weeks != 4 || stats.filter.Limit != 50 {
+		t.Errorf("expected 4 weeks and the capped limit, got %+v", stats.filter)
+	}
+	if rr := get("/api/cities/Berlin/overview?weeks=100"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for too many weeks, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,209 @@
+package interfaces
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/mux"
+)
+
+// compressionMinSize is the smallest body worth compressing; below it the
+// encoding overhead outweighs the savings
+const compressionMinSize = 1024
+
+// Compression encodes responses with Brotli or gzip, whichever the client
+// prefers in Accept-Encoding, Brotli winning ties. Bodies under
+// compressionMinSize and responses the handler already encoded pass
+// through unchanged.
+func Compression() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, status: http.StatusOK}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// negotiateEncoding picks br or gzip from an Accept-Encoding header, or ""
+// when the client accepts neither
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "br" && name != "gzip" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		// q=0 refuses the encoding
+		if q <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && name == "br") {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// compressWriter holds the status and the start of the body back until
+// there is enough of it to decide whether to compress
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	status      int
+	wroteHeader bool
+	buf         []byte
+	decided     bool
+	encoder     io.WriteCloser
+	hijacked    bool
+}
+
+func (c *compressWriter) WriteHeader(code int) {
+	if c.wroteHeader || c.decided {
+		return
+	}
+	// Informational responses go straight out; the real one follows
+	if code >= 100 && code < 200 {
+		c.ResponseWriter.WriteHeader(code)
+		return
+	}
+	c.status = code
+	c.wroteHeader = true
+}
+
+func (c *compressWriter) Write(b []byte) (int, error) {
+	c.wroteHeader = true
+	if !c.decided {
+		c.buf = append(c.buf, b...)
+		if len(c.buf) < compressionMinSize {
+			return len(b), nil
+		}
+		if err := c.start(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if c.encoder != nil {
+		return c.encoder.Write(b)
+	}
+	return c.ResponseWriter.Write(b)
+}
+
+// start sends the header, compressed when compress is set and the response
+// allows it, followed by whatever was held back
+func (c *compressWriter) start(compress bool) error {
+	c.decided = true
+
+	header := c.Header()
+	if compress && c.compressible() {
+		header.Set("Content-Encoding", c.encoding)
+		header.Del("Content-Length")
+		// The encoded bytes differ, so a strong validator can't be shared
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		if c.encoding == "br" {
+			c.encoder = brotli.NewWriterLevel(c.ResponseWriter, brotli.DefaultCompression)
+		} else {
+			c.encoder = gzip.NewWriter(c.ResponseWriter)
+		}
+	}
+
+	c.ResponseWriter.WriteHeader(c.status)
+	if len(c.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if c.encoder != nil {
+		_, err = c.encoder.Write(c.buf)
+	} else {
+		_, err = c.ResponseWriter.Write(c.buf)
+	}
+	c.buf = nil
+	return err
+}
+
+func (c *compressWriter) compressible() bool {
+	if c.status < http.StatusOK || c.status == http.StatusNoContent || c.status == http.StatusNotModified {
+		return false
+	}
+	return c.Header().Get("Content-Encoding") == ""
+}
+
+// Flush compresses from here on even below compressionMinSize, as a
+// streaming handler will keep writing
+func (c *compressWriter) Flush() {
+	if !c.decided {
+		if err := c.start(true); err != nil {
+			return
+		}
+	}
+	if flusher, ok := c.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close sends a body that stayed under compressionMinSize as is and
+// finishes the encoding of one that didn't
+func (c *compressWriter) Close() error {
+	if c.hijacked {
+		return nil
+	}
+	if !c.decided {
+		if !c.wroteHeader {
+			return nil
+		}
+		return c.start(false)
+	}
+	if c.encoder != nil {
+		return c.encoder.Close()
+	}
+	return nil
+}
+
+// Hijack hands the connection to WebSocket upgrades untouched
+func (c *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := c.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+	c.hijacked = true
+	return hijacker.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (c *compressWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
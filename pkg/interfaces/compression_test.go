@@ -0,0 +1,140 @@
+package interfaces
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gorilla/mux"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	for header, want := range map[string]string{
+		"":                        "",
+		"identity":                "",
+		"gzip":                    "gzip",
+		"gzip, deflate, br":       "br",
+		"br;q=0.5, gzip":          "gzip",
+		"br;q=0, gzip;q=0.1":      "gzip",
+		"GZIP;q=0.8, br;q=0.8":    "br",
+		"gzip;q=0, br;q=0":        "",
+		"gzip;q=bad, br;q=0.2":    "br",
+		"deflate;q=1, gzip;q=0.3": "gzip",
+	} {
+		if got := negotiateEncoding(header); got != want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	large := strings.Repeat(`{"name":"Radiohead","city":"Berlin"},`, 100)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"abc"`)
+		w.WriteHeader(http.StatusCreated)
+		// Written in pieces that only pass the threshold together
+		io.WriteString(w, large[:500])
+		io.WriteString(w, large[500:])
+	})
+	router.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"abc"`)
+		io.WriteString(w, "ok")
+	})
+	router.HandleFunc("/not-modified", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+	router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "data: second\n\n")
+	})
+	router.Use(Compression())
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		rr := get("/large", "gzip")
+		if rr.Code != http.StatusCreated {
+			t.Errorf("expected the handler's status kept, got %d", rr.Code)
+		}
+		if rr.Header().Get("Content-Encoding") != "gzip" || rr.Header().Get("Vary") != "Accept-Encoding" {
+			t.Fatalf("unexpected headers %v", rr.Header())
+		}
+		if got := rr.Header().Get("ETag"); got != `W/"abc"` {
+			t.Errorf("expected the ETag weakened, got %q", got)
+		}
+		if rr.Body.Len() >= len(large) {
+			t.Errorf("expected a smaller body, got %d bytes for %d", rr.Body.Len(), len(large))
+		}
+		reader, err := gzip.NewReader(rr.Body)
+		if err != nil {
+			t.Fatalf("failed to read gzip: %v", err)
+		}
+		if body, _ := io.ReadAll(reader); string(body) != large {
+			t.Error("expected the body to decompress to the original")
+		}
+	})
+
+	t.Run("brotli", func(t *testing.T) {
+		rr := get("/large", "gzip, br")
+		if rr.Header().Get("Content-Encoding") != "br" {
+			t.Fatalf("expected brotli, got %q", rr.Header().Get("Content-Encoding"))
+		}
+		if body, _ := io.ReadAll(brotli.NewReader(rr.Body)); string(body) != large {
+			t.Error("expected the body to decompress to the original")
+		}
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		rr := get("/large", "")
+		if rr.Header().Get("Content-Encoding") != "" || rr.Body.String() != large {
+			t.Error("expected the body unencoded")
+		}
+	})
+
+	t.Run("small body", func(t *testing.T) {
+		rr := get("/small", "gzip")
+		if rr.Header().Get("Content-Encoding") != "" || rr.Body.String() != "ok" {
+			t.Errorf("expected a small body unencoded, got %q", rr.Body.String())
+		}
+		if got := rr.Header().Get("ETag"); got != `"abc"` {
+			t.Errorf("expected the ETag untouched, got %q", got)
+		}
+	})
+
+	t.Run("no body", func(t *testing.T) {
+		rr := get("/not-modified", "gzip")
+		if rr.Code != http.StatusNotModified || rr.Header().Get("Content-Encoding") != "" {
+			t.Errorf("expected a plain 304, got %d %v", rr.Code, rr.Header())
+		}
+	})
+
+	t.Run("flushed stream", func(t *testing.T) {
+		rr := get("/stream", "gzip")
+		if rr.Header().Get("Content-Encoding") != "gzip" {
+			t.Fatal("expected a flushed stream compressed")
+		}
+		reader, err := gzip.NewReader(rr.Body)
+		if err != nil {
+			t.Fatalf("failed to read gzip: %v", err)
+		}
+		if body, _ := io.ReadAll(reader); string(body) != "data: first\n\ndata: second\n\n" {
+			t.Errorf("unexpected stream %q", body)
+		}
+	})
+}
@@ -0,0 +1,101 @@
+package interfaces
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// searchCacheMaxAge is how long clients may reuse a search response without
+// asking again. Searches are served from the aggregator cache for much
+// longer, so revalidating after this is usually a 304.
+const searchCacheMaxAge = 60 * time.Second
+
+// resultsETag hashes the artists and events of a result set and the Apple
+// Music storefront they were looked up in. Timings, and the fetch
+// timestamps sources stamp on every result, are left out so the same
+// results get the same tag across re-fetches.
+func resultsETag(results *integrations.AggregatedResults, storefront string) string {
+	artists := make([]domain.Artist, len(results.Artists))
+	for i, artist := range results.Artists {
+		artist.CreatedAt, artist.UpdatedAt = time.Time{}, time.Time{}
+		artists[i] = artist
+	}
+	events := make([]domain.Event, len(results.Events))
+	for i, event := range results.Events {
+		event.CreatedAt, event.UpdatedAt, event.CachedUntil = time.Time{}, time.Time{}, time.Time{}
+		events[i] = event
+	}
+
+	encoded, err := json.Marshal(struct {
+		Storefront string          `json:"storefront,omitempty"`
+		Artists    []domain.Artist `json:"artists"`
+		Events     []domain.Event  `json:"events"`
+	}{storefront, artists, events})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(encoded)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// resultsLastModified is the latest update of anything in the result set,
+// zero when none carries one
+func resultsLastModified(results *integrations.AggregatedResults) time.Time {
+	var latest time.Time
+	for _, artist := range results.Artists {
+		if artist.UpdatedAt.After(latest) {
+			latest = artist.UpdatedAt
+		}
+	}
+	for _, event := range results.Events {
+		if event.UpdatedAt.After(latest) {
+			latest = event.UpdatedAt
+		}
+	}
+	return latest
+}
+
+// setCacheHeaders sets the caching and validator headers of a cacheable
+// response, and reports whether the request's conditions show the client
+// already has it, in which case the caller answers 304 Not Modified
+func setCacheHeaders(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time, maxAge time.Duration) bool {
+	header := w.Header()
+	header.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-None-Match wins over If-Modified-Since when both are sent
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		return etag != "" && etagMatches(match, etag)
+	}
+	if since := r.Header.Get("If-Modified-Since"); since != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(since)
+		// HTTP dates have second precision
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
+// etagMatches compares an If-None-Match list against etag weakly, as
+// RFC 9110 asks for GET
+func etagMatches(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
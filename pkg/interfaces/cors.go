@@ -0,0 +1,105 @@
+package interfaces
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// CORSConfig lists what browser apps on other origins may do. An empty
+// AllowedOrigins turns CORS off; "*" in it allows any origin.
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // seconds browsers may cache a preflight
+}
+
+// CORS wraps the whole router rather than going through router.Use, as mux
+// only runs middleware on matched routes and answers a preflight OPTIONS for
+// a GET route with 405 before any middleware sees it. Preflights for a path
+// and method some route serves get 204; others fall through to the router.
+func CORS(router *mux.Router, cfg CORSConfig) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return router
+	}
+
+	anyOrigin := false
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+		}
+		origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	methods := make(map[string]bool, len(cfg.AllowedMethods))
+	for _, method := range cfg.AllowedMethods {
+		methods[strings.ToUpper(method)] = true
+	}
+	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
+	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposedHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if !anyOrigin && !origins[strings.ToLower(origin)] {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		// Credentialed requests need the origin echoed, never "*"
+		if anyOrigin && !cfg.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		requestedMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method != http.MethodOptions || requestedMethod == "" {
+			if exposedHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposedHeaders)
+			}
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		// Preflight
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if !methods[strings.ToUpper(requestedMethod)] || !routeExists(router, r, requestedMethod) {
+			router.ServeHTTP(w, r)
+			return
+		}
+		header.Set("Access-Control-Allow-Methods", allowedMethods)
+		if allowedHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowedHeaders)
+		}
+		if cfg.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// routeExists reports whether the router serves r's path for method
+func routeExists(router *mux.Router, r *http.Request, method string) bool {
+	probe := r.Clone(r.Context())
+	probe.Method = strings.ToUpper(method)
+	var match mux.RouteMatch
+	return router.Match(probe, &match) && match.MatchErr == nil
+}
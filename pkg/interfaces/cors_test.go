@@ -0,0 +1,128 @@
+package interfaces
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestCORS(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/search/events", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}).Methods("GET")
+	router.HandleFunc("/api/me/follows", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET", "POST")
+
+	handler := CORS(router, CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         600,
+	})
+
+	serve := func(method, path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("simple request", func(t *testing.T) {
+		rr := serve("GET", "/api/search/events", map[string]string{"Origin": "https://app.example.com"})
+		if rr.Code != http.StatusOK || rr.Body.String() != "ok" {
+			t.Fatalf("expected the route served, got %d", rr.Code)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("expected the origin allowed, got %q", got)
+		}
+		if got := rr.Header().Get("Access-Control-Expose-Headers"); got != "ETag" {
+			t.Errorf("expected ETag exposed, got %q", got)
+		}
+		if rr.Header().Get("Vary") != "Origin" {
+			t.Errorf("expected Vary: Origin, got %v", rr.Header().Values("Vary"))
+		}
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		rr := serve("OPTIONS", "/api/me/follows", map[string]string{
+			"Origin":                         "https://app.example.com",
+			"Access-Control-Request-Method":  "POST",
+			"Access-Control-Request-Headers": "authorization",
+		})
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("expected 204, got %d", rr.Code)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+			t.Errorf("unexpected allowed methods %q", got)
+		}
+		if got := rr.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+			t.Errorf("unexpected allowed headers %q", got)
+		}
+		if got := rr.Header().Get("Access-Control-Max-Age"); got != "600" {
+			t.Errorf("unexpected max age %q", got)
+		}
+	})
+
+	t.Run("preflight for a method the route doesn't serve", func(t *testing.T) {
+		rr := serve("OPTIONS", "/api/search/events", map[string]string{
+			"Origin":                        "https://app.example.com",
+			"Access-Control-Request-Method": "POST",
+		})
+		if rr.Code != http.StatusMethodNotAllowed || rr.Header().Get("Access-Control-Allow-Methods") != "" {
+			t.Errorf("expected the router's 405 without allowed methods, got %d", rr.Code)
+		}
+	})
+
+	t.Run("preflight for an unknown path", func(t *testing.T) {
+		rr := serve("OPTIONS", "/api/nope", map[string]string{
+			"Origin":                        "https://app.example.com",
+			"Access-Control-Request-Method": "GET",
+		})
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		rr := serve("OPTIONS", "/api/me/follows", map[string]string{
+			"Origin":                        "https://evil.example.com",
+			"Access-Control-Request-Method": "POST",
+		})
+		if rr.Header().Get("Access-Control-Allow-Origin") != "" || rr.Code == http.StatusNoContent {
+			t.Errorf("expected no CORS headers for an unlisted origin, got %d %v", rr.Code, rr.Header())
+		}
+	})
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	router := mux.NewRouter()
+	router.HandleFunc("/api/sources", func(w http.ResponseWriter, r *http.Request) {}).Methods("GET")
+
+	for _, tt := range []struct {
+		credentials bool
+		want        string
+	}{
+		{false, "*"},
+		{true, "https://anywhere.example.com"},
+	} {
+		handler := CORS(router, CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: tt.credentials})
+		req := httptest.NewRequest("GET", "/api/sources", nil)
+		req.Header.Set("Origin", "https://anywhere.example.com")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		if got := rr.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("credentials %v: expected %q, got %q", tt.credentials, tt.want, got)
+		}
+	}
+
+	if handler := CORS(router, CORSConfig{}); handler != http.Handler(router) {
+		t.Error("expected the router untouched with no origins configured")
+	}
+}
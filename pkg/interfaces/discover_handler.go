@@ -0,0 +1,153 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ChartSource fetches a country's most played music, worldwide when the
+// country is empty
+type ChartSource interface {
+	Chart(ctx context.Context, country string, limit int) (*domain.Chart, error)
+}
+
+// ReleaseSource finds an artist's albums released since a given day
+type ReleaseSource interface {
+	NewReleases(ctx context.Context, artist domain.Artist, since time.Time) ([]domain.Album, error)
+}
+
+// DiscoverHandler serves music charts and new releases from the artists
+// users follow
+type DiscoverHandler struct {
+	auth     *AuthService
+	follows  domain.FollowRepository
+	charts   ChartSource
+	releases ReleaseSource
+	now      func() time.Time
+}
+
+func NewDiscoverHandler(auth *AuthService, follows domain.FollowRepository, charts ChartSource, releases ReleaseSource) *DiscoverHandler {
+	return &DiscoverHandler{
+		auth:     auth,
+		follows:  follows,
+		charts:   charts,
+		releases: releases,
+		now:      time.Now,
+	}
+}
+
+func (h *DiscoverHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/discover/charts", h.GetChart).Methods("GET")
+
+	requireUser := RequireUser(h.auth)
+	router.Handle("/api/discover/releases", requireUser(http.HandlerFunc(h.ListReleases))).Methods("GET")
+}
+
+// GetChart returns the most played tracks and artists in a country, given
+// as a code or name, or worldwide without one
+func (h *DiscoverHandler) GetChart(w http.ResponseWriter, r *http.Request) {
+	params := struct {
+		Country string `query:"country"`
+		Limit   int    `query:"limit" limit:"25,100"`
+	}{}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	chart, err := h.charts.Chart(r.Context(), params.Country, params.Limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrChartNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "no chart for this country")
+			return
+		}
+		h.respondWithError(w, http.StatusBadGateway, "failed to get chart")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, chart)
+}
+
+type ReleasesResponse struct {
+	Since    string         `json:"since"`
+	Releases []domain.Album `json:"releases"`
+}
+
+// ListReleases returns albums released in the last `days` (default 30, at
+// most 365) by the artists the user follows, newest first
+func (h *DiscoverHandler) ListReleases(w http.ResponseWriter, r *http.Request) {
+	params := struct {
+		Days int `query:"days" validate:"min=1,max=365"`
+	}{Days: 30}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	userID, _ := UserIDFromContext(r.Context())
+	follows, err := h.follows.ListFollows(r.Context(), userID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to list follows")
+		return
+	}
+
+	since := h.now().AddDate(0, 0, -params.Days)
+	h.respondWithJSON(w, http.StatusOK, ReleasesResponse{
+		Since:    since.Format(time.DateOnly),
+		Releases: h.newReleases(r.Context(), follows, since),
+	})
+}
+
+// newReleases looks followed artists up a few at a time. An artist whose
+// lookup fails is left out rather than failing the list.
+func (h *DiscoverHandler) newReleases(ctx context.Context, follows []domain.Follow, since time.Time) []domain.Album {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 4)
+
+	releases := []domain.Album{}
+	for _, follow := range follows {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			albums, err := h.releases.NewReleases(ctx, domain.Artist{ID: follow.ArtistID, Name: follow.ArtistName}, since)
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			releases = append(releases, albums...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		if releases[i].ReleaseDate != releases[j].ReleaseDate {
+			return releases[i].ReleaseDate > releases[j].ReleaseDate
+		}
+		return releases[i].ArtistName < releases[j].ArtistName
+	})
+	return releases
+}
+
+func (h *DiscoverHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *DiscoverHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,126 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubChartSource struct {
+	country string
+	limit   int
+}
+
+func (s *stubChartSource) Chart(ctx context.Context, country string, limit int) (*domain.Chart, error) {
+	s.country, s.limit = country, limit
+	if country == "Atlantis" {
+		return nil, domain.ErrChartNotFound
+	}
+	return &domain.Chart{Country: "DE", Source: "deezer", Tracks: []domain.Track{{ID: "deezer_1", Title: "Glue"}}}, nil
+}
+
+type stubReleaseSource struct {
+	albums map[string][]domain.Album
+	since  time.Time
+}
+
+func (s *stubReleaseSource) NewReleases(ctx context.Context, artist domain.Artist, since time.Time) ([]domain.Album, error) {
+	s.since = since
+	if artist.Name == "Broken" {
+		return nil, errors.New("deezer get artist albums failed: status 500")
+	}
+	return s.albums[artist.Name], nil
+}
+
+func TestDiscoverHandler_GetChart(t *testing.T) {
+	charts := &stubChartSource{}
+	router := mux.NewRouter()
+	NewDiscoverHandler(newTestAuthService(newMemoryUserRepository()), &memoryFollowRepository{}, charts, &stubReleaseSource{}).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/discover/charts?country=DE&limit=500")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if charts.country != "DE" || charts.limit != 100 {
+		t.Errorf("expected DE capped at 100, got %q and %d", charts.country, charts.limit)
+	}
+	var chart domain.Chart
+	json.NewDecoder(rr.Body).Decode(&chart)
+	if chart.Country != "DE" || len(chart.Tracks) != 1 {
+		t.Errorf("unexpected chart %+v", chart)
+	}
+
+	if rr := get("/api/discover/charts?country=Atlantis"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rr.Code)
+	}
+}
+
+func TestDiscoverHandler_ListReleases(t *testing.T) {
+	auth := newTestAuthService(newMemoryUserRepository())
+	registered, err := auth.Register(context.Background(), "kim@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	follows := &memoryFollowRepository{}
+	for _, name := range []string{"Bicep", "Broken", "Radiohead"} {
+		follows.Follow(context.Background(), &domain.Follow{UserID: registered.User.ID, ArtistName: name})
+	}
+	follows.Follow(context.Background(), &domain.Follow{UserID: "someone_else", ArtistName: "Caribou"})
+
+	now := time.Date(2026, 5, 15, 9, 0, 0, 0, time.UTC)
+	releases := &stubReleaseSource{albums: map[string][]domain.Album{
+		"Bicep":     {{ID: "deezer_2", Title: "Isles II", ArtistName: "Bicep", ReleaseDate: "2026-05-10"}},
+		"Radiohead": {{ID: "deezer_1", Title: "Old News", ArtistName: "Radiohead", ReleaseDate: "2026-05-12"}},
+		"Caribou":   {{ID: "deezer_3", Title: "Suddenly", ArtistName: "Caribou", ReleaseDate: "2026-05-14"}},
+	}}
+	handler := NewDiscoverHandler(auth, follows, &stubChartSource{}, releases)
+	handler.now = func() time.Time { return now }
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := get("/api/discover/releases", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected status 401, got %d", rr.Code)
+	}
+
+	rr := get("/api/discover/releases?days=7", registered.AccessToken)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var response ReleasesResponse
+	json.NewDecoder(rr.Body).Decode(&response)
+	if response.Since != "2026-05-08" {
+		t.Errorf("expected a week back, got %s", response.Since)
+	}
+	if len(response.Releases) != 2 || response.Releases[0].ID != "deezer_1" || response.Releases[1].ID != "deezer_2" {
+		t.Errorf("expected the user's follows newest first, got %+v", response.Releases)
+	}
+
+	if rr := get("/api/discover/releases?days=0", registered.AccessToken); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400, got %d", rr.Code)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -56,29 +55,20 @@ func (h *EventHandler) SearchEvents(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	artistName := r.URL.Query().Get("artist")
-	location := r.URL.Query().Get("location")
-	radiusStr := r.URL.Query().Get("radius")
-
-	if artistName == "" {
-		h.respondWithError(w, http.StatusBadRequest, "artist parameter is required")
+	params := struct {
+		Artist   string `query:"artist" validate:"required"`
+		Location string `query:"location"`
+		Radius   int    `query:"radius" validate:"min=1"`
+	}{Radius: 50}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
 		return
 	}
-
-	radius := 50
-	if radiusStr != "" {
-		parsedRadius, err := strconv.Atoi(radiusStr)
-		if err != nil || parsedRadius <= 0 {
-			h.respondWithError(w, http.StatusBadRequest, "radius must be a positive integer")
-			return
-		}
-		if parsedRadius > 500 {
-			parsedRadius = 500
-		}
-		radius = parsedRadius
+	if params.Radius > 500 {
+		params.Radius = 500
 	}
 
-	response, err := h.service.SearchArtistEvents(ctx, artistName, location, radius)
+	response, err := h.service.SearchArtistEvents(ctx, params.Artist, params.Location, params.Radius)
 	if err != nil {
 		switch err {
 		case domain.ErrInvalidRequest:
@@ -120,14 +110,13 @@ func (h *EventHandler) GetEvent(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *EventHandler) respondWithError(w http.ResponseWriter, code int, message string) {
-	h.respondWithJSON(w, code, map[string]string{"error": message})
+	writeError(w, code, message)
 }
 
 func (h *EventHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(`{"error":"internal server error"}`))
+		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
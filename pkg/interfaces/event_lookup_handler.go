@@ -0,0 +1,90 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type EventLookupHandler struct {
+	service *EventLookupService
+}
+
+func NewEventLookupHandler(service *EventLookupService) *EventLookupHandler {
+	return &EventLookupHandler{
+		service: service,
+	}
+}
+
+func (h *EventLookupHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/events/lookup", h.Lookup).Methods("POST")
+}
+
+// Lookup resolves up to 100 {source, external_id} pairs. Each gets its own
+// status, so one missing or failing event doesn't fail the rest.
+func (h *EventLookupHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Events []EventRef `json:"events" validate:"required"`
+	}
+	if err := decodeJSON(r, &request); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	if err := h.validate(request.Events); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	response, err := h.service.Lookup(ctx, request.Events)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to look up events")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *EventLookupHandler) validate(refs []EventRef) error {
+	if len(refs) > maxEventLookups {
+		return invalidField("events", fmt.Sprintf("must have at most %d items", maxEventLookups))
+	}
+
+	errs := &ValidationErrors{Body: true}
+	for i := range refs {
+		refs[i].Source = strings.ToLower(strings.TrimSpace(refs[i].Source))
+		refs[i].ExternalID = strings.TrimSpace(refs[i].ExternalID)
+
+		switch {
+		case refs[i].Source == "":
+			errs.add(fmt.Sprintf("events[%d].source", i), "is required")
+		case !h.service.Supports(refs[i].Source):
+			errs.add(fmt.Sprintf("events[%d].source", i), "must be one of "+strings.Join(eventIDSources, ", "))
+		}
+		if refs[i].ExternalID == "" {
+			errs.add(fmt.Sprintf("events[%d].external_id", i), "is required")
+		}
+	}
+
+	if len(errs.Fields) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (h *EventLookupHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *EventLookupHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
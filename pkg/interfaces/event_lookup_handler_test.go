@@ -0,0 +1,72 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestEventLookupHandler_Lookup(t *testing.T) {
+	repo := newMemoryEventRepository()
+	service := NewEventLookupService(repo, time.Hour)
+	service.RegisterSource("songkick", &stubEventGetter{events: map[string]domain.Event{"1": songkickEvent("1", "Fetched")}})
+
+	router := mux.NewRouter()
+	NewEventLookupHandler(service).RegisterRoutes(router)
+
+	body := `{"events":[{"source":"Songkick","external_id":" 1 "},{"source":"songkick","external_id":"2"}]}`
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/events/lookup", strings.NewReader(body)))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var response EventLookupResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Results) != 2 || response.Results[0].Status != EventLookupFound || response.Results[1].Status != EventLookupNotFound {
+		t.Errorf("unexpected results %+v", response.Results)
+	}
+	if response.Results[0].Source != "songkick" || response.Results[0].ExternalID != "1" {
+		t.Errorf("expected the ref normalized, got %+v", response.Results[0])
+	}
+}
+
+func TestEventLookupHandler_Validation(t *testing.T) {
+	service := NewEventLookupService(newMemoryEventRepository(), time.Hour)
+	router := mux.NewRouter()
+	NewEventLookupHandler(service).RegisterRoutes(router)
+
+	tooMany := `{"events":[` + strings.Repeat(`{"source":"songkick","external_id":"1"},`, maxEventLookups) + `{"source":"songkick","external_id":"1"}]}`
+	tests := []struct {
+		name  string
+		body  string
+		field string
+	}{
+		{"not JSON", `events`, "body"},
+		{"no events", `{"events":[]}`, "events"},
+		{"unknown source", `{"events":[{"source":"spotify","external_id":"1"}]}`, "events[0].source"},
+		{"missing id", `{"events":[{"source":"songkick","external_id":"1"},{"source":"songkick"}]}`, "events[1].external_id"},
+		{"too many", tooMany, "events"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("POST", "/api/events/lookup", strings.NewReader(tt.body)))
+
+			var response ErrorResponse
+			json.NewDecoder(rr.Body).Decode(&response)
+			if rr.Code != http.StatusBadRequest || len(response.Details) != 1 || response.Details[0].Field != tt.field {
+				t.Errorf("expected a 400 for %s, got %d %+v", tt.field, rr.Code, response)
+			}
+		})
+	}
+}
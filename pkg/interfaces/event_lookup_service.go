@@ -0,0 +1,206 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// maxEventLookups caps how many events one lookup can ask for
+const maxEventLookups = 100
+
+// eventLookupsPerSource is how many of a lookup's fetches run at once against
+// each source, so a large batch doesn't use up one source's rate limit
+const eventLookupsPerSource = 4
+
+// Lookup statuses
+const (
+	EventLookupFound    = "found"
+	EventLookupNotFound = "not_found"
+	EventLookupFailed   = "error"
+)
+
+// eventIDSources are the sources whose event IDs are stored with events
+var eventIDSources = []string{"bandsintown", "ticketmaster", "songkick", "eventbrite", "setlistfm"}
+
+// EventGetter fetches one event by the ID its source gave it
+type EventGetter interface {
+	GetEvent(ctx context.Context, externalID string) (*domain.Event, error)
+}
+
+// EventRef names an event by a source's own ID
+type EventRef struct {
+	Source     string `json:"source"`
+	ExternalID string `json:"external_id"`
+}
+
+// EventLookupResult is what became of one EventRef
+type EventLookupResult struct {
+	Source     string `json:"source"`
+	ExternalID string `json:"external_id"`
+	Status     string `json:"status"`
+	// Cached is set when the event came from the event store, including a
+	// stale copy kept because the source couldn't be reached
+	Cached bool          `json:"cached"`
+	Event  *domain.Event `json:"event,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// EventLookupResponse lists results in the order they were asked for
+type EventLookupResponse struct {
+	Results []EventLookupResult `json:"results"`
+	Errors  []string            `json:"errors,omitempty"`
+}
+
+// EventLookupService resolves events by their sources' IDs, for clients that
+// keep those IDs and refresh them now and then. Fresh stored events are
+// served as they are; the rest are fetched from their source and stored.
+type EventLookupService struct {
+	repository domain.EventRepository
+	sources    map[string]EventGetter
+	cacheTTL   time.Duration
+	now        func() time.Time
+}
+
+func NewEventLookupService(repository domain.EventRepository, cacheTTL time.Duration) *EventLookupService {
+	if cacheTTL <= 0 {
+		cacheTTL = 24 * time.Hour
+	}
+
+	return &EventLookupService{
+		repository: repository,
+		sources:    make(map[string]EventGetter),
+		cacheTTL:   cacheTTL,
+		now:        time.Now,
+	}
+}
+
+// RegisterSource lets events from the named source be fetched when the
+// stored copy is missing or stale. Events from other sources are only found
+// if they're stored.
+func (s *EventLookupService) RegisterSource(name string, source EventGetter) {
+	s.sources[name] = source
+}
+
+// Supports reports whether events can be looked up by the source's IDs
+func (s *EventLookupService) Supports(source string) bool {
+	if _, ok := s.sources[source]; ok {
+		return true
+	}
+	return contains(eventIDSources, source)
+}
+
+// Lookup resolves every ref, fetching from different sources in parallel
+// and from each source at most eventLookupsPerSource at a time. A ref asked
+// for more than once is resolved once.
+func (s *EventLookupService) Lookup(ctx context.Context, refs []EventRef) (*EventLookupResponse, error) {
+	if len(refs) == 0 || len(refs) > maxEventLookups {
+		return nil, domain.ErrInvalidRequest
+	}
+	for _, ref := range refs {
+		if ref.ExternalID == "" || !s.Supports(ref.Source) {
+			return nil, domain.ErrInvalidRequest
+		}
+	}
+
+	now := s.now()
+	unique := make(map[EventRef]*EventLookupResult)
+	limits := make(map[string]chan struct{})
+	var wg sync.WaitGroup
+	for _, ref := range refs {
+		if _, seen := unique[ref]; seen {
+			continue
+		}
+		result := &EventLookupResult{Source: ref.Source, ExternalID: ref.ExternalID}
+		unique[ref] = result
+
+		limit, ok := limits[ref.Source]
+		if !ok {
+			limit = make(chan struct{}, eventLookupsPerSource)
+			limits[ref.Source] = limit
+		}
+
+		wg.Add(1)
+		go func(ref EventRef) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			s.resolve(ctx, ref, result, now)
+		}(ref)
+	}
+	wg.Wait()
+
+	response := &EventLookupResponse{Results: make([]EventLookupResult, 0, len(refs))}
+	for _, ref := range refs {
+		response.Results = append(response.Results, *unique[ref])
+	}
+
+	// Stored in one batch once every fetch is done
+	var fetched []domain.Event
+	for _, result := range unique {
+		if result.Status == EventLookupFound && !result.Cached {
+			fetched = append(fetched, *result.Event)
+		}
+	}
+	if len(fetched) > 0 {
+		if _, err := s.repository.CreateBatch(ctx, fetched); err != nil {
+			response.Errors = append(response.Errors, cacheSourceName+": "+err.Error())
+		}
+	}
+
+	return response, nil
+}
+
+// resolve fills result from the event store, or from the source when the
+// stored copy is missing or past its cache window
+func (s *EventLookupService) resolve(ctx context.Context, ref EventRef, result *EventLookupResult, now time.Time) {
+	stored, err := s.repository.GetByExternalID(ctx, ref.ExternalID, ref.Source)
+	if err != nil {
+		// A store that can't be read is no reason not to ask the source
+		stored = nil
+	}
+	if stored != nil && stored.CachedUntil.After(now) {
+		result.Status, result.Cached, result.Event = EventLookupFound, true, stored
+		return
+	}
+
+	source, ok := s.sources[ref.Source]
+	if !ok {
+		if stored != nil {
+			result.Status, result.Cached, result.Event = EventLookupFound, true, stored
+			return
+		}
+		result.Status = EventLookupNotFound
+		return
+	}
+
+	event, err := source.GetEvent(ctx, ref.ExternalID)
+	switch {
+	case errors.Is(err, domain.ErrEventNotFound):
+		result.Status = EventLookupNotFound
+	case err != nil && stored != nil:
+		result.Status, result.Cached, result.Event = EventLookupFound, true, stored
+		result.Error = err.Error()
+	case err != nil:
+		result.Status, result.Error = EventLookupFailed, err.Error()
+	default:
+		event.AddSource(ref.Source)
+		// Keep the stored event's ID, which may come from another source
+		// that found it first, and the IDs and sources other sources gave it
+		if stored != nil {
+			event.ID = stored.ID
+			event.ExternalIDs.Merge(stored.ExternalIDs)
+			event.MergeSources(*stored)
+			if event.ArtistID == "" {
+				event.ArtistID = stored.ArtistID
+			}
+		}
+		if event.CachedUntil.IsZero() {
+			event.CachedUntil = now.Add(s.cacheTTL)
+		}
+		result.Status, result.Event = EventLookupFound, event
+	}
+}
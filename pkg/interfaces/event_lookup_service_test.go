@@ -0,0 +1,226 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// stubEventGetter serves events by ID and counts calls, tracking how many
+// run at once
+type stubEventGetter struct {
+	mu      sync.Mutex
+	events  map[string]domain.Event
+	err     error
+	delay   time.Duration
+	calls   int
+	running int
+	peak    int
+}
+
+func (s *stubEventGetter) GetEvent(ctx context.Context, externalID string) (*domain.Event, error) {
+	s.mu.Lock()
+	s.calls++
+	s.running++
+	if s.running > s.peak {
+		s.peak = s.running
+	}
+	s.mu.Unlock()
+
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running--
+	if s.err != nil {
+		return nil, s.err
+	}
+	event, ok := s.events[externalID]
+	if !ok {
+		return nil, domain.ErrEventNotFound
+	}
+	return &event, nil
+}
+
+func songkickEvent(id, title string) domain.Event {
+	return domain.Event{
+		ID:          "songkick_" + id,
+		Title:       title,
+		ExternalIDs: domain.EventExternalIDs{SongkickID: id},
+	}
+}
+
+func newLookupService(repo *memoryEventRepository, now time.Time) *EventLookupService {
+	service := NewEventLookupService(repo, time.Hour)
+	service.now = func() time.Time { return now }
+	return service
+}
+
+func TestEventLookupService_Lookup(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := newMemoryEventRepository()
+
+	fresh := songkickEvent("1", "Fresh")
+	fresh.CachedUntil = now.Add(time.Hour)
+	repo.events[fresh.ID] = fresh
+
+	// Stored under another source's ID, and stale
+	stale := domain.Event{
+		ID:          "ticketmaster_abc",
+		Title:       "Old title",
+		ArtistID:    "artist-1",
+		ExternalIDs: domain.EventExternalIDs{TicketmasterID: "abc", SongkickID: "2"},
+		Sources:     []string{"ticketmaster"},
+		SourceIDs:   map[string]string{"ticketmaster": "abc"},
+		CachedUntil: now.Add(-time.Minute),
+	}
+	repo.events[stale.ID] = stale
+
+	bandsintown := domain.Event{
+		ID:          "bandsintown_9",
+		ExternalIDs: domain.EventExternalIDs{BandsintownID: "9"},
+		CachedUntil: now.Add(-time.Hour),
+	}
+	repo.events[bandsintown.ID] = bandsintown
+
+	songkick := &stubEventGetter{events: map[string]domain.Event{
+		"1": songkickEvent("1", "Fetched"),
+		"2": songkickEvent("2", "New title"),
+		"3": songkickEvent("3", "Uncached"),
+	}}
+	service := newLookupService(repo, now)
+	service.RegisterSource("songkick", songkick)
+
+	response, err := service.Lookup(context.Background(), []EventRef{
+		{Source: "songkick", ExternalID: "1"},
+		{Source: "songkick", ExternalID: "2"},
+		{Source: "songkick", ExternalID: "3"},
+		{Source: "songkick", ExternalID: "404"},
+		{Source: "bandsintown", ExternalID: "9"},
+		{Source: "eventbrite", ExternalID: "e1"},
+		{Source: "songkick", ExternalID: "3"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	results := response.Results
+	if len(results) != 7 {
+		t.Fatalf("expected a result per ref, got %d", len(results))
+	}
+
+	if r := results[0]; r.Status != EventLookupFound || !r.Cached || r.Event.Title != "Fresh" {
+		t.Errorf("expected the fresh stored event, got %+v", r)
+	}
+
+	r := results[1]
+	if r.Status != EventLookupFound || r.Cached || r.Event.Title != "New title" {
+		t.Errorf("expected the stale event fetched again, got %+v", r)
+	}
+	if r.Event.ID != "ticketmaster_abc" || r.Event.ExternalIDs.TicketmasterID != "abc" || r.Event.ArtistID != "artist-1" {
+		t.Errorf("expected the stored ID, artist and other IDs kept, got %+v", r.Event)
+	}
+	if len(r.Event.Sources) != 2 || r.Event.Sources[0] != "songkick" || r.Event.SourceIDs["songkick"] != "2" || r.Event.SourceIDs["ticketmaster"] != "abc" {
+		t.Errorf("expected Songkick added to the stored sources, got %v, %v", r.Event.Sources, r.Event.SourceIDs)
+	}
+	if stored := repo.events["ticketmaster_abc"]; stored.Title != "New title" || !stored.CachedUntil.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the refreshed event stored, got %+v", stored)
+	}
+
+	if r := results[2]; r.Status != EventLookupFound || r.Cached {
+		t.Errorf("expected the uncached event fetched, got %+v", r)
+	}
+	if _, ok := repo.events["songkick_3"]; !ok {
+		t.Error("expected the fetched event stored")
+	}
+	if r := results[3]; r.Status != EventLookupNotFound || r.Event != nil {
+		t.Errorf("expected not_found, got %+v", r)
+	}
+	// No client for these, so only stored events are found, however stale
+	if r := results[4]; r.Status != EventLookupFound || !r.Cached {
+		t.Errorf("expected the stored bandsintown event, got %+v", r)
+	}
+	if r := results[5]; r.Status != EventLookupNotFound {
+		t.Errorf("expected not_found without a client, got %+v", r)
+	}
+	if r := results[6]; r.ExternalID != "3" || r.Status != EventLookupFound {
+		t.Errorf("expected the repeated ref answered, got %+v", r)
+	}
+
+	// 2, 3 and 404; the fresh event and the repeat aren't fetched
+	if songkick.calls != 3 {
+		t.Errorf("expected 3 fetches, got %d", songkick.calls)
+	}
+}
+
+func TestEventLookupService_SourceFailure(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := newMemoryEventRepository()
+	stale := songkickEvent("1", "Stale")
+	stale.CachedUntil = now.Add(-time.Minute)
+	repo.events[stale.ID] = stale
+
+	service := newLookupService(repo, now)
+	service.RegisterSource("songkick", &stubEventGetter{err: errors.New("songkick get event failed: status 503")})
+
+	response, err := service.Lookup(context.Background(), []EventRef{
+		{Source: "songkick", ExternalID: "1"},
+		{Source: "songkick", ExternalID: "2"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if r := response.Results[0]; r.Status != EventLookupFound || !r.Cached || r.Event.Title != "Stale" || r.Error == "" {
+		t.Errorf("expected the stale copy with the error, got %+v", r)
+	}
+	if r := response.Results[1]; r.Status != EventLookupFailed || !strings.Contains(r.Error, "503") {
+		t.Errorf("expected the error, got %+v", r)
+	}
+}
+
+func TestEventLookupService_PerSourceLimit(t *testing.T) {
+	songkick := &stubEventGetter{delay: 10 * time.Millisecond}
+	ticketmaster := &stubEventGetter{delay: 10 * time.Millisecond}
+	service := newLookupService(newMemoryEventRepository(), time.Now())
+	service.RegisterSource("songkick", songkick)
+	service.RegisterSource("ticketmaster", ticketmaster)
+
+	var refs []EventRef
+	for _, id := range strings.Split("a b c d e f g h i j", " ") {
+		refs = append(refs, EventRef{Source: "songkick", ExternalID: id}, EventRef{Source: "ticketmaster", ExternalID: id})
+	}
+	if _, err := service.Lookup(context.Background(), refs); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for name, source := range map[string]*stubEventGetter{"songkick": songkick, "ticketmaster": ticketmaster} {
+		if source.calls != 10 {
+			t.Errorf("expected 10 %s fetches, got %d", name, source.calls)
+		}
+		if source.peak > eventLookupsPerSource {
+			t.Errorf("expected at most %d %s fetches at once, got %d", eventLookupsPerSource, name, source.peak)
+		}
+	}
+}
+
+func TestEventLookupService_InvalidRefs(t *testing.T) {
+	service := newLookupService(newMemoryEventRepository(), time.Now())
+
+	tests := map[string][]EventRef{
+		"none":           nil,
+		"unknown source": {{Source: "spotify", ExternalID: "1"}},
+		"missing id":     {{Source: "songkick"}},
+		"too many":       make([]EventRef, maxEventLookups+1),
+	}
+	for name, refs := range tests {
+		if _, err := service.Lookup(context.Background(), refs); !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("%s: expected ErrInvalidRequest, got %v", name, err)
+		}
+	}
+}
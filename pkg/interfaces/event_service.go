@@ -74,7 +74,7 @@ func (s *EventService) SearchArtistEvents(ctx context.Context, artistName string
 			// Log error but continue
 		}
 
-		if err := s.repository.CreateBatch(ctx, externalEvents); err != nil {
+		if _, err := s.repository.CreateBatch(ctx, externalEvents); err != nil {
 			// Log error but continue
 		}
 	}
@@ -137,7 +137,7 @@ func (s *EventService) GetArtistEvents(ctx context.Context, artistID string) (*d
 			// Log error but continue
 		}
 
-		if err := s.repository.CreateBatch(ctx, externalEvents); err != nil {
+		if _, err := s.repository.CreateBatch(ctx, externalEvents); err != nil {
 			// Log error but continue
 		}
 	}
@@ -0,0 +1,182 @@
+package interfaces
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/export"
+)
+
+// ExportHandler serves event results in formats other apps can consume
+type ExportHandler struct {
+	aggregator AggregatorService
+	events     domain.EventRepository
+	now        func() time.Time
+}
+
+// exportFlushInterval is how many rows are buffered before a bulk export is
+// flushed to the client
+const exportFlushInterval = 500
+
+func NewExportHandler(aggregator AggregatorService, events domain.EventRepository) *ExportHandler {
+	return &ExportHandler{
+		aggregator: aggregator,
+		events:     events,
+		now:        time.Now,
+	}
+}
+
+func (h *ExportHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/events/export", h.ExportEvents).Methods("GET")
+	router.HandleFunc("/api/events/export.ics", h.ExportArtistCalendar).Methods("GET")
+
+	// A feed per followed artist needs the stored artist to look events up by
+	if _, ok := h.aggregator.(ArtistEventsService); ok {
+		router.HandleFunc("/api/artists/{id}/events.ics", h.ExportStoredArtistCalendar).Methods("GET")
+	}
+}
+
+// ExportArtistCalendar renders an artist's upcoming events as an iCalendar
+// feed calendar apps can subscribe to
+func (h *ExportHandler) ExportArtistCalendar(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Artist string `query:"artist" validate:"required"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	results, err := h.aggregator.SearchEvents(r.Context(), params.Artist, 200)
+	if err != nil {
+		writeSourceError(w, err, "failed to search events")
+		return
+	}
+
+	h.respondWithCalendar(w, params.Artist, results.Events)
+}
+
+func (h *ExportHandler) ExportStoredArtistCalendar(w http.ResponseWriter, r *http.Request) {
+	service, ok := h.aggregator.(ArtistEventsService)
+	if !ok {
+		h.respondWithError(w, http.StatusNotImplemented, "artist events are not available")
+		return
+	}
+
+	results, err := service.GetArtistEvents(r.Context(), mux.Vars(r)["id"], 200)
+	if err != nil {
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "artist not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get artist events")
+		return
+	}
+
+	name := mux.Vars(r)["id"]
+	if len(results.Artists) > 0 {
+		name = results.Artists[0].Name
+	}
+	h.respondWithCalendar(w, name, results.Events)
+}
+
+type exportParams struct {
+	Format string `query:"format" validate:"oneof=csv jsonl"`
+	Artist string `query:"artist"`
+	City   string `query:"city"`
+	From   string `query:"from"`
+	To     string `query:"to"`
+}
+
+// ExportEvents streams stored events as CSV or JSON lines, straight from
+// the repository so large date ranges don't have to fit in memory
+func (h *ExportHandler) ExportEvents(w http.ResponseWriter, r *http.Request) {
+	params := exportParams{Format: "csv"}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	var contentType string
+	var newWriter func(io.Writer) export.EventWriter
+	switch params.Format {
+	case "csv":
+		contentType = "text/csv; charset=utf-8"
+		newWriter = export.NewCSVEventWriter
+	case "jsonl":
+		contentType = "application/x-ndjson"
+		newWriter = export.NewJSONLEventWriter
+	}
+
+	from, err := export.ParseDate(params.From, false)
+	if err != nil {
+		writeValidationError(w, invalidParam("from", "must be a date (YYYY-MM-DD) or RFC 3339 time"))
+		return
+	}
+	to, err := export.ParseDate(params.To, true)
+	if err != nil {
+		writeValidationError(w, invalidParam("to", "must be a date (YYYY-MM-DD) or RFC 3339 time"))
+		return
+	}
+
+	filter := domain.EventFilter{
+		Artist: params.Artist,
+		City:   params.City,
+		From:   from,
+		To:     to,
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="events.%s"`, params.Format))
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	writer := newWriter(w)
+	rows := 0
+
+	// Headers are already sent, so a failure part way can only cut the
+	// export short
+	err = h.events.Each(r.Context(), filter, func(event domain.Event) error {
+		if err := writer.Write(event); err != nil {
+			return err
+		}
+		rows++
+		if rows%exportFlushInterval == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	writer.Flush()
+}
+
+func (h *ExportHandler) respondWithCalendar(w http.ResponseWriter, artistName string, events []domain.Event) {
+	now := h.now()
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="events.ics"`)
+	w.WriteHeader(http.StatusOK)
+
+	export.WriteICS(w, export.Calendar{
+		Name:      artistName + " – Where It's At",
+		Events:    export.Upcoming(events, now),
+		Generated: now,
+	})
+}
+
+func (h *ExportHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
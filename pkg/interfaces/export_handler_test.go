@@ -0,0 +1,172 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+func TestExportHandler_ExportArtistCalendar(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	aggregator := &mockMegaAggregator{
+		searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+			return &integrations.AggregatedResults{Events: []domain.Event{
+				{ID: "later", ArtistName: artistName, DateTime: now.Add(72 * time.Hour), Venue: domain.Venue{Name: "Later Venue"}},
+				{ID: "past", ArtistName: artistName, DateTime: now.Add(-24 * time.Hour)},
+				{ID: "soon", ArtistName: artistName, DateTime: now.Add(24 * time.Hour), TicketURL: "https://tickets.example.com/soon"},
+			}}, nil
+		},
+	}
+
+	handler := NewExportHandler(aggregator, newMemoryEventRepository())
+	handler.now = func() time.Time { return now }
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	t.Run("upcoming events in date order", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/events/export.ics?artist=Radiohead", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/calendar") {
+			t.Errorf("expected text/calendar, got %s", ct)
+		}
+
+		body := rr.Body.String()
+		if strings.Contains(body, "UID:past@") {
+			t.Error("expected past events to be left out")
+		}
+		soon := strings.Index(body, "UID:soon@")
+		later := strings.Index(body, "UID:later@")
+		if soon < 0 || later < 0 || soon > later {
+			t.Errorf("expected soon before later, got:\n%s", body)
+		}
+		if !strings.Contains(body, "DESCRIPTION:Tickets: https://tickets.example.com/soon") {
+			t.Error("expected ticket URL in the description")
+		}
+	})
+
+	t.Run("artist is required", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/events/export.ics", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+	})
+}
+
+func TestExportHandler_ExportStoredArtistCalendar(t *testing.T) {
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != "artist_1" {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &domain.Artist{ID: "artist_1", Name: "Radiohead"}, nil
+		},
+	}
+	aggregator := &artistSearchingAggregator{
+		events: []domain.Event{{ID: "e1", ArtistName: "Radiohead", DateTime: time.Now().Add(24 * time.Hour)}},
+	}
+	service := NewAggregatedEventService(aggregator, newMemoryEventRepository(), artists, time.Hour)
+
+	router := mux.NewRouter()
+	NewExportHandler(service, newMemoryEventRepository()).RegisterRoutes(router)
+
+	t.Run("feed for a stored artist", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/artists/artist_1/events.ics", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		body := rr.Body.String()
+		if !strings.Contains(body, "X-WR-CALNAME:Radiohead") || !strings.Contains(body, "UID:e1@") {
+			t.Errorf("unexpected calendar:\n%s", body)
+		}
+	})
+
+	t.Run("unknown artist", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/artists/missing/events.ics", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+}
+
+func TestExportHandler_ExportEvents(t *testing.T) {
+	repository := newMemoryEventRepository()
+	repository.CreateBatch(context.Background(), []domain.Event{
+		{ID: "may", ArtistName: "Radiohead", DateTime: time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC), Venue: domain.Venue{City: "Berlin"}},
+		{ID: "june", ArtistName: "Radiohead", DateTime: time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC), Venue: domain.Venue{City: "Berlin"}},
+		{ID: "paris", ArtistName: "Radiohead", DateTime: time.Date(2026, 5, 2, 20, 0, 0, 0, time.UTC), Venue: domain.Venue{City: "Paris"}},
+		{ID: "other", ArtistName: "Portishead", DateTime: time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC), Venue: domain.Venue{City: "Berlin"}},
+	})
+
+	router := mux.NewRouter()
+	NewExportHandler(&mockMegaAggregator{}, repository).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("csv with filters", func(t *testing.T) {
+		rr := get("/api/events/export?artist=radiohead&city=Berlin&to=2026-05-01")
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+			t.Errorf("expected text/csv, got %s", ct)
+		}
+
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		if len(lines) != 2 || !strings.HasPrefix(lines[1], "may,") {
+			t.Errorf("expected header and the May show, got %v", lines)
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		rr := get("/api/events/export?format=jsonl&artist=Radiohead&from=2026-05-02T00:00:00Z")
+
+		lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 lines, got %v", lines)
+		}
+		var event domain.Event
+		if err := json.Unmarshal([]byte(lines[0]), &event); err != nil || event.ID != "paris" {
+			t.Errorf("expected paris first, got %s (%v)", lines[0], err)
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, path := range []string{
+			"/api/events/export?format=xml",
+			"/api/events/export?from=yesterday",
+			"/api/events/export?to=2026-13-01",
+		} {
+			if rr := get(path); rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400 for %s, got %d", path, rr.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package interfaces
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/export"
+)
+
+// FeedHandler serves RSS feeds of newly discovered events, for following an
+// artist or a city from a feed reader
+type FeedHandler struct {
+	events  domain.EventRepository
+	artists domain.ArtistRepository
+}
+
+func NewFeedHandler(events domain.EventRepository, artists domain.ArtistRepository) *FeedHandler {
+	return &FeedHandler{
+		events:  events,
+		artists: artists,
+	}
+}
+
+func (h *FeedHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/feeds/artist/{id}.rss", h.GetArtistFeed).Methods("GET")
+	router.HandleFunc("/api/feeds/city/{city}.rss", h.GetCityFeed).Methods("GET")
+}
+
+func (h *FeedHandler) GetArtistFeed(w http.ResponseWriter, r *http.Request) {
+	artist, err := h.artists.GetByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "artist not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get artist")
+		return
+	}
+
+	filter := domain.DiscoveryFilter{ArtistID: artist.ID, ArtistName: artist.Name}
+	h.respondWithFeed(w, r, filter, artist.Name+" – new events", "Newly announced events for "+artist.Name)
+}
+
+func (h *FeedHandler) GetCityFeed(w http.ResponseWriter, r *http.Request) {
+	city := mux.Vars(r)["city"]
+
+	filter := domain.DiscoveryFilter{City: city}
+	h.respondWithFeed(w, r, filter, city+" – new events", "Newly announced events in "+city)
+}
+
+func (h *FeedHandler) respondWithFeed(w http.ResponseWriter, r *http.Request, filter domain.DiscoveryFilter, title, description string) {
+	var params pageParams
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	events, err := h.events.ListDiscovered(r.Context(), filter, params.Limit)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to list events")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+
+	export.WriteRSS(w, export.Feed{
+		Title:       title,
+		Link:        requestURL(r),
+		Description: description,
+		Events:      events,
+	})
+}
+
+func (h *FeedHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+// requestURL rebuilds the absolute URL a feed was requested from
+func requestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.RequestURI()
+}
@@ -0,0 +1,100 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type rssResponse struct {
+	Channel struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+		Items []struct {
+			GUID string `xml:"guid"`
+		} `xml:"item"`
+	} `xml:"channel"`
+}
+
+func TestFeedHandler(t *testing.T) {
+	now := time.Now()
+	repository := newMemoryEventRepository()
+	repository.CreateBatch(context.Background(), []domain.Event{
+		{ID: "old", ArtistID: "artist_1", ArtistName: "Radiohead", DateTime: now, Venue: domain.Venue{City: "Berlin"}},
+	})
+	repository.discovered["old"] = now.Add(-time.Hour)
+	repository.CreateBatch(context.Background(), []domain.Event{
+		{ID: "new", ArtistName: "radiohead", DateTime: now, Venue: domain.Venue{City: "Paris"}},
+		{ID: "other", ArtistName: "Portishead", DateTime: now, Venue: domain.Venue{City: "Berlin"}},
+	})
+
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != "artist_1" {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &domain.Artist{ID: "artist_1", Name: "Radiohead"}, nil
+		},
+	}
+
+	router := mux.NewRouter()
+	NewFeedHandler(repository, artists).RegisterRoutes(router)
+
+	get := func(t *testing.T, path string) (*httptest.ResponseRecorder, rssResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Host = "example.com"
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var feed rssResponse
+		if rr.Code == http.StatusOK {
+			if err := xml.Unmarshal(rr.Body.Bytes(), &feed); err != nil {
+				t.Fatalf("failed to decode feed: %v", err)
+			}
+		}
+		return rr, feed
+	}
+
+	t.Run("artist feed by ID and name, newest first", func(t *testing.T) {
+		rr, feed := get(t, "/api/feeds/artist/artist_1.rss")
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/rss+xml") {
+			t.Errorf("expected RSS content type, got %s", ct)
+		}
+		if len(feed.Channel.Items) != 2 || feed.Channel.Items[0].GUID != "new" || feed.Channel.Items[1].GUID != "old" {
+			t.Errorf("expected new then old, got %+v", feed.Channel.Items)
+		}
+		if feed.Channel.Link != "http://example.com/api/feeds/artist/artist_1.rss" {
+			t.Errorf("unexpected channel link %s", feed.Channel.Link)
+		}
+	})
+
+	t.Run("city feed", func(t *testing.T) {
+		_, feed := get(t, "/api/feeds/city/berlin.rss?limit=1")
+
+		if len(feed.Channel.Items) != 1 || feed.Channel.Items[0].GUID != "other" {
+			t.Errorf("expected the newest Berlin event, got %+v", feed.Channel.Items)
+		}
+		if !strings.Contains(feed.Channel.Title, "berlin") {
+			t.Errorf("expected city in title, got %s", feed.Channel.Title)
+		}
+	})
+
+	t.Run("unknown artist", func(t *testing.T) {
+		rr, _ := get(t, "/api/feeds/artist/missing.rss")
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+}
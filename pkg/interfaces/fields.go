@@ -0,0 +1,259 @@
+package interfaces
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// maxSelectedFields bounds how many fields one request can list
+const maxSelectedFields = 50
+
+// FieldSelection trims JSON responses to the fields the client lists in
+// fields=, e.g. fields=id,artist_name,datetime,venue.city,ticket_url, so
+// mobile clients get just what they show instead of whole events and
+// artists. Dotted names pick fields of nested objects.
+//
+// The selection applies to the resources in a response. An object holding
+// none of the listed fields is taken for an envelope: its counts and other
+// values are kept and the objects within it trimmed, so a search keeps its
+// total while each of its events is cut down. Errors and responses that
+// aren't JSON pass through unchanged.
+func FieldSelection() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw, ok := r.URL.Query()["fields"]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			selection, err := parseFieldSelection(strings.Join(raw, ","))
+			if err != nil {
+				writeValidationError(w, err)
+				return
+			}
+			if selection == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			fw := &fieldsWriter{ResponseWriter: w, selection: selection, status: http.StatusOK}
+			next.ServeHTTP(fw, r)
+			fw.finish()
+		})
+	}
+}
+
+// fieldSelection maps each selected field to the fields picked within it,
+// nil when the whole field is selected
+type fieldSelection map[string]fieldSelection
+
+// parseFieldSelection reads a fields= value into a selection, nil when it
+// lists no fields. Selecting a field whole wins over picking within it.
+func parseFieldSelection(value string) (fieldSelection, error) {
+	var selection fieldSelection
+	count := 0
+	for _, path := range strings.Split(value, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		count++
+		if count > maxSelectedFields {
+			return nil, invalidParam("fields", fmt.Sprintf("must list at most %d fields", maxSelectedFields))
+		}
+
+		names := strings.Split(path, ".")
+		for _, name := range names {
+			if !isFieldName(name) {
+				return nil, invalidParam("fields", "must be comma separated field names such as id,venue.city")
+			}
+		}
+
+		if selection == nil {
+			selection = fieldSelection{}
+		}
+		level := selection
+		for i, name := range names {
+			sub, seen := level[name]
+			if seen && sub == nil {
+				// Already selected whole
+				break
+			}
+			if i == len(names)-1 {
+				level[name] = nil
+				break
+			}
+			if sub == nil {
+				sub = fieldSelection{}
+				level[name] = sub
+			}
+			level = sub
+		}
+	}
+	return selection, nil
+}
+
+func isFieldName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// project applies the selection to a decoded response, treating objects
+// without any selected field as envelopes
+func (s fieldSelection) project(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for name := range s {
+			if _, ok := v[name]; ok {
+				return s.pick(v)
+			}
+		}
+		projected := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			projected[key] = s.project(field)
+		}
+		return projected
+	case []interface{}:
+		projected := make([]interface{}, len(v))
+		for i, item := range v {
+			projected[i] = s.project(item)
+		}
+		return projected
+	default:
+		return value
+	}
+}
+
+// pick keeps only the selected fields of a resource, descending into
+// nested objects and lists of them for dotted names
+func (s fieldSelection) pick(resource map[string]interface{}) map[string]interface{} {
+	picked := make(map[string]interface{}, len(s))
+	for name, sub := range s {
+		field, ok := resource[name]
+		if !ok {
+			continue
+		}
+		if sub == nil {
+			picked[name] = field
+			continue
+		}
+		picked[name] = sub.pickWithin(field)
+	}
+	return picked
+}
+
+func (s fieldSelection) pickWithin(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return s.pick(v)
+	case []interface{}:
+		picked := make([]interface{}, len(v))
+		for i, item := range v {
+			picked[i] = s.pickWithin(item)
+		}
+		return picked
+	default:
+		return value
+	}
+}
+
+// fieldsWriter holds back successful JSON responses so they can be trimmed
+// once complete; anything else goes straight through
+type fieldsWriter struct {
+	http.ResponseWriter
+	selection fieldSelection
+
+	status    int
+	decided   bool
+	buffering bool
+	buf       bytes.Buffer
+}
+
+func (f *fieldsWriter) WriteHeader(code int) {
+	if f.decided {
+		return
+	}
+	// Informational responses go straight out; the real one follows
+	if code >= 100 && code < 200 {
+		f.ResponseWriter.WriteHeader(code)
+		return
+	}
+	f.status = code
+	f.decide()
+}
+
+func (f *fieldsWriter) Write(b []byte) (int, error) {
+	if !f.decided {
+		f.decide()
+	}
+	if f.buffering {
+		return f.buf.Write(b)
+	}
+	return f.ResponseWriter.Write(b)
+}
+
+// decide buffers a successful JSON response and sends the header of any
+// other right away
+func (f *fieldsWriter) decide() {
+	f.decided = true
+	contentType := f.Header().Get("Content-Type")
+	if f.status >= http.StatusOK && f.status < http.StatusMultipleChoices && f.status != http.StatusNoContent &&
+		strings.HasPrefix(contentType, "application/json") && f.Header().Get("Content-Encoding") == "" {
+		f.buffering = true
+		return
+	}
+	f.ResponseWriter.WriteHeader(f.status)
+}
+
+// finish sends a buffered response, trimmed to the selection. A body that
+// doesn't decode is sent as the handler wrote it.
+func (f *fieldsWriter) finish() {
+	if !f.buffering {
+		return
+	}
+
+	body := f.buf.Bytes()
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err == nil {
+		if projected, err := json.Marshal(f.selection.project(value)); err == nil {
+			body = append(projected, '\n')
+		}
+	}
+
+	f.Header().Del("Content-Length")
+	f.ResponseWriter.WriteHeader(f.status)
+	f.ResponseWriter.Write(body)
+}
+
+// Flush passes through for streamed responses; buffered ones are sent
+// whole at the end
+func (f *fieldsWriter) Flush() {
+	if !f.decided {
+		f.decide()
+	}
+	if f.buffering {
+		return
+	}
+	if flusher, ok := f.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (f *fieldsWriter) Unwrap() http.ResponseWriter {
+	return f.ResponseWriter
+}
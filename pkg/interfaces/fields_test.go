@@ -0,0 +1,111 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestFieldSelection(t *testing.T) {
+	event := map[string]interface{}{
+		"id":          "e1",
+		"artist_name": "Bicep",
+		"datetime":    "2026-10-20T20:00:00Z",
+		"ticket_url":  "https://tickets.example.com/e1",
+		"venue":       map[string]interface{}{"name": "Tempodrom", "city": "Berlin", "capacity": 3500},
+		"lineup":      []interface{}{map[string]interface{}{"name": "Bicep", "position": 0}},
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Length", "1000")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"events": []interface{}{event, event},
+			"total":  2,
+		})
+	})
+	router.HandleFunc("/event", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(event)
+	})
+	router.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, "event not found")
+	})
+	router.HandleFunc("/export", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		io.WriteString(w, "id,artist_name\ne1,Bicep\n")
+	})
+	router.Use(FieldSelection())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+	decode := func(rr *httptest.ResponseRecorder) map[string]interface{} {
+		t.Helper()
+		var body map[string]interface{}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return body
+	}
+
+	// The envelope keeps its total while each event is trimmed
+	rr := get("/search?fields=id,artist_name,venue.city")
+	if rr.Code != http.StatusOK || rr.Header().Get("Content-Length") != "" {
+		t.Fatalf("expected 200 without the stale length, got %d, %q", rr.Code, rr.Header().Get("Content-Length"))
+	}
+	body := decode(rr)
+	if body["total"] != float64(2) {
+		t.Errorf("expected the envelope's total to be kept, got %v", body)
+	}
+	events, _ := body["events"].([]interface{})
+	if len(events) != 2 {
+		t.Fatalf("expected both events, got %v", body["events"])
+	}
+	first := events[0].(map[string]interface{})
+	if len(first) != 3 || first["id"] != "e1" || first["artist_name"] != "Bicep" {
+		t.Errorf("expected only the selected fields, got %v", first)
+	}
+	venue := first["venue"].(map[string]interface{})
+	if len(venue) != 1 || venue["city"] != "Berlin" {
+		t.Errorf("expected only the venue's city, got %v", venue)
+	}
+
+	// A single resource is trimmed itself, and selecting a field whole wins
+	// over picking within it
+	body = decode(get("/event?fields=id,venue.city,venue&fields=lineup.name"))
+	if len(body) != 3 || body["id"] != "e1" {
+		t.Errorf("expected id, venue and lineup, got %v", body)
+	}
+	if venue := body["venue"].(map[string]interface{}); len(venue) != 3 {
+		t.Errorf("expected the whole venue, got %v", venue)
+	}
+	lineup := body["lineup"].([]interface{})
+	if entry := lineup[0].(map[string]interface{}); len(entry) != 1 || entry["name"] != "Bicep" {
+		t.Errorf("expected the names of the lineup, got %v", lineup)
+	}
+
+	// Without fields, errors and other formats pass through
+	if body := decode(get("/event")); len(body) != len(event) {
+		t.Errorf("expected the whole event, got %v", body)
+	}
+	if body := decode(get("/missing?fields=id")); body["error"] != "event not found" {
+		t.Errorf("expected the error untouched, got %v", body)
+	}
+	if rr := get("/export?fields=id"); rr.Body.String() != "id,artist_name\ne1,Bicep\n" {
+		t.Errorf("expected the CSV untouched, got %q", rr.Body.String())
+	}
+
+	for _, fields := range []string{"id,venue..city", "id,venue-city", "id,*"} {
+		if rr := get("/event?fields=" + fields); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for fields=%s, got %d", fields, rr.Code)
+		}
+	}
+}
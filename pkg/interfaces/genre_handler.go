@@ -0,0 +1,54 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// genresCacheMaxAge is how long clients may reuse the taxonomy; it only
+// changes with a release
+const genresCacheMaxAge = 24 * time.Hour
+
+// GenreHandler serves the genre taxonomy artists' genres are normalized to,
+// for building genre filters
+type GenreHandler struct{}
+
+func NewGenreHandler() *GenreHandler {
+	return &GenreHandler{}
+}
+
+type GenresResponse struct {
+	Genres []domain.GenreNode `json:"genres"`
+	Total  int                `json:"total"`
+}
+
+func (h *GenreHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/genres", h.GetGenres).Methods("GET")
+}
+
+// GetGenres returns the root genres with their subgenres nested under them,
+// and the aliases each is matched by. Total counts every genre in the tree.
+func (h *GenreHandler) GetGenres(w http.ResponseWriter, r *http.Request) {
+	tree := domain.GenreTree()
+
+	setCacheHeaders(w, r, "", time.Time{}, genresCacheMaxAge)
+	h.respondWithJSON(w, http.StatusOK, GenresResponse{Genres: tree, Total: countGenres(tree)})
+}
+
+func (h *GenreHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
+
+func countGenres(nodes []domain.GenreNode) int {
+	count := len(nodes)
+	for _, node := range nodes {
+		count += countGenres(node.Children)
+	}
+	return count
+}
@@ -0,0 +1,45 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestGenreHandler_GetGenres(t *testing.T) {
+	router := mux.NewRouter()
+	NewGenreHandler().RegisterRoutes(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/genres", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "public, max-age=86400" {
+		t.Errorf("expected the taxonomy to be cacheable for a day, got %q", cacheControl)
+	}
+
+	var response GenresResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(response.Genres) == 0 || response.Total <= len(response.Genres) {
+		t.Fatalf("expected root genres with subgenres, got %d roots of %d", len(response.Genres), response.Total)
+	}
+
+	// electronic → house → deep house
+	electronic := response.Genres[0]
+	if electronic.Name != "electronic" || len(electronic.Children) == 0 || electronic.Children[0].Name != "house" {
+		t.Fatalf("expected house under electronic, got %+v", electronic)
+	}
+	var deepHouse bool
+	for _, child := range electronic.Children[0].Children {
+		deepHouse = deepHouse || child.Name == "deep house"
+	}
+	if !deepHouse {
+		t.Errorf("expected deep house under house, got %+v", electronic.Children[0].Children)
+	}
+}
@@ -0,0 +1,95 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// GeoJSONFeatureCollection is search results as RFC 7946 GeoJSON, which
+// Leaflet and Mapbox can plot as they are
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature is one event at its venue
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id"`
+	Geometry   GeoJSONPoint           `json:"geometry"`
+	Properties GeoJSONEventProperties `json:"properties"`
+}
+
+// GeoJSONPoint is a position. GeoJSON puts longitude first.
+type GeoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+// GeoJSONEventProperties is what a map popup shows of an event, kept flat
+// since map libraries style and filter by top level properties
+type GeoJSONEventProperties struct {
+	Title          string                `json:"title"`
+	ArtistID       string                `json:"artist_id"`
+	ArtistName     string                `json:"artist_name"`
+	DateTime       time.Time             `json:"datetime"`
+	DateConfidence domain.DateConfidence `json:"date_confidence,omitempty"`
+	Status         domain.EventStatus    `json:"status,omitempty"`
+	VenueID        string                `json:"venue_id,omitempty"`
+	VenueName      string                `json:"venue_name"`
+	City           string                `json:"city"`
+	Region         string                `json:"region,omitempty"`
+	Country        string                `json:"country"`
+	CountryCode    string                `json:"country_code,omitempty"`
+	TicketURL      string                `json:"ticket_url,omitempty"`
+	TicketStatus   string                `json:"ticket_status,omitempty"`
+}
+
+// eventFeatures turns events into features. Events whose venue has no
+// position are left out, as there's nowhere to plot them.
+func eventFeatures(events []domain.Event) GeoJSONFeatureCollection {
+	collection := GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []GeoJSONFeature{}}
+	for _, event := range events {
+		if !event.Venue.HasCoordinates() {
+			continue
+		}
+		collection.Features = append(collection.Features, GeoJSONFeature{
+			Type: "Feature",
+			ID:   event.ID,
+			Geometry: GeoJSONPoint{
+				Type:        "Point",
+				Coordinates: [2]float64{event.Venue.Longitude, event.Venue.Latitude},
+			},
+			Properties: GeoJSONEventProperties{
+				Title:          event.Title,
+				ArtistID:       event.ArtistID,
+				ArtistName:     event.ArtistName,
+				DateTime:       event.DateTime,
+				DateConfidence: event.DateConfidence,
+				Status:         event.Status,
+				VenueID:        event.Venue.ID,
+				VenueName:      event.Venue.Name,
+				City:           event.Venue.City,
+				Region:         event.Venue.Region,
+				Country:        event.Venue.Country,
+				CountryCode:    event.Venue.CountryCode,
+				TicketURL:      event.TicketURL,
+				TicketStatus:   event.TicketStatus,
+			},
+		})
+	}
+	return collection
+}
+
+// writeGeoJSON writes events as a FeatureCollection with 200
+func writeGeoJSON(w http.ResponseWriter, events []domain.Event) {
+	w.Header().Set("Content-Type", "application/geo+json")
+
+	sw := &statusOnWrite{ResponseWriter: w, status: http.StatusOK}
+	if err := json.NewEncoder(sw).Encode(eventFeatures(events)); err != nil && !sw.wrote {
+		writeError(w, http.StatusInternalServerError, "failed to encode response")
+	}
+}
@@ -0,0 +1,143 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+func decodeFeatures(t *testing.T, rr *httptest.ResponseRecorder) GeoJSONFeatureCollection {
+	t.Helper()
+	if got := rr.Header().Get("Content-Type"); got != "application/geo+json" {
+		t.Errorf("expected application/geo+json, got %q", got)
+	}
+	var collection GeoJSONFeatureCollection
+	if err := json.NewDecoder(rr.Body).Decode(&collection); err != nil {
+		t.Fatalf("failed to decode GeoJSON: %v", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		t.Errorf("expected a FeatureCollection, got %q", collection.Type)
+	}
+	return collection
+}
+
+func TestAggregatorHandler_LocationGeoJSON(t *testing.T) {
+	mock := &mockMegaAggregator{
+		searchEventsByLocationFunc: func(ctx context.Context, city, country string, limit int) (*integrations.AggregatedResults, error) {
+			return &integrations.AggregatedResults{
+				Events: []domain.Event{
+					{
+						ID:         "1",
+						Title:      "Fontaines D.C. at Columbiahalle",
+						ArtistName: "Fontaines D.C.",
+						Venue:      domain.Venue{Name: "Columbiahalle", City: city, Latitude: 52.4833, Longitude: 13.3889},
+					},
+					{ID: "2", ArtistName: "Fontaines D.C.", Venue: domain.Venue{Name: "TBA", City: city}},
+				},
+				TotalResults: 2,
+			}, nil
+		},
+	}
+	router := mux.NewRouter()
+	NewAggregatorHandler(mock).RegisterRoutes(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/search/events/location?city=Berlin&format=geojson", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+
+	collection := decodeFeatures(t, rr)
+	if len(collection.Features) != 1 {
+		t.Fatalf("expected only the event with a position, got %d features", len(collection.Features))
+	}
+	feature := collection.Features[0]
+	if feature.Type != "Feature" || feature.ID != "1" || feature.Geometry.Type != "Point" {
+		t.Errorf("unexpected feature %+v", feature)
+	}
+	if feature.Geometry.Coordinates != [2]float64{13.3889, 52.4833} {
+		t.Errorf("expected longitude first, got %v", feature.Geometry.Coordinates)
+	}
+	if feature.Properties.VenueName != "Columbiahalle" || feature.Properties.City != "Berlin" {
+		t.Errorf("unexpected properties %+v", feature.Properties)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/search/events/location?city=Berlin&format=kml", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", rr.Code)
+	}
+}
+
+func TestAggregatorHandler_SearchEventsNearby(t *testing.T) {
+	repo := newMemoryEventRepository()
+	now := time.Now()
+	for _, event := range []domain.Event{
+		{ID: "columbiahalle", DateTime: now.Add(24 * time.Hour), Venue: domain.Venue{Latitude: 52.4833, Longitude: 13.3889}},
+		{ID: "berghain", DateTime: now.Add(48 * time.Hour), Venue: domain.Venue{Latitude: 52.5112, Longitude: 13.4430}},
+		{ID: "last_week", DateTime: now.Add(-7 * 24 * time.Hour), Venue: domain.Venue{Latitude: 52.5112, Longitude: 13.4430}},
+		{ID: "hamburg", DateTime: now.Add(24 * time.Hour), Venue: domain.Venue{Latitude: 53.5511, Longitude: 9.9937}},
+	} {
+		repo.Create(context.Background(), &event)
+	}
+	service := NewAggregatedEventService(&mockMegaAggregator{}, repo, &mockRepository{}, time.Hour)
+
+	router := mux.NewRouter()
+	NewAggregatorHandler(service).RegisterRoutes(router)
+
+	serve := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/search/events/nearby?"+query, nil))
+		return rr
+	}
+
+	t.Run("upcoming events in the radius nearest first", func(t *testing.T) {
+		rr := serve("lat=52.5200&lng=13.4050&radius=10")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		var response integrations.AggregatedResults
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if len(response.Events) != 2 || response.Events[0].ID != "berghain" || response.Events[1].ID != "columbiahalle" {
+			t.Errorf("expected berghain then columbiahalle, got %+v", response.Events)
+		}
+	})
+
+	t.Run("geojson", func(t *testing.T) {
+		rr := serve("lat=52.5200&lng=13.4050&radius=500&format=geojson")
+		if collection := decodeFeatures(t, rr); len(collection.Features) != 3 {
+			t.Errorf("expected 3 features, got %d", len(collection.Features))
+		}
+	})
+
+	t.Run("invalid parameters", func(t *testing.T) {
+		for _, query := range []string{"lng=13.4", "lat=52.5", "lat=91&lng=0", "lat=0&lng=0&radius=0", "lat=0&lng=0&format=csv"} {
+			if rr := serve(query); rr.Code != http.StatusBadRequest {
+				t.Errorf("%q: expected status 400, got %d", query, rr.Code)
+			}
+		}
+		if rr := serve("lat=0&lng=0"); rr.Code != http.StatusOK {
+			t.Errorf("expected the equator and prime meridian accepted, got %d", rr.Code)
+		}
+	})
+
+	t.Run("not registered without an event store", func(t *testing.T) {
+		router := mux.NewRouter()
+		NewAggregatorHandler(&mockMegaAggregator{}).RegisterRoutes(router)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/search/events/nearby?lat=52.5&lng=13.4", nil))
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,776 @@
+package interfaces
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// This file holds a small GraphQL executor covering what the /graphql
+// endpoint needs: queries with nested selections, aliases, arguments,
+// variables and __typename. Mutations, fragments and directives are rejected.
+
+// graphQLMaxDepth is how deeply selections may nest. The schema is cyclic
+// (an artist's events have an artist), so queries could otherwise nest
+// without end.
+const graphQLMaxDepth = 6
+
+// graphQLMaxCost bounds the work one query may ask for. Every selected field
+// costs 1, and what's selected under a list field counts once for each value
+// the list may hold.
+const graphQLMaxCost = 10000
+
+// graphQLObject is an object type in the schema
+type graphQLObject struct {
+	name   string
+	fields map[string]graphQLField
+}
+
+// graphQLField resolves one field of an object. Fields with a non-empty
+// object type resolve to a value (or slice of values) of that type and need a
+// selection set; the rest are scalars that are written as returned. listSize
+// is how many values a list field holds when no limit is given, for costing
+// queries.
+type graphQLField struct {
+	object   string
+	listSize int
+	resolve  func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+}
+
+type graphQLSchema struct {
+	query   string
+	objects map[string]*graphQLObject
+}
+
+type graphQLSelection struct {
+	alias     string
+	name      string
+	arguments map[string]graphQLValue
+	children  []graphQLSelection
+}
+
+// graphQLValue is an argument value; variable references are resolved when
+// the query runs
+type graphQLValue struct {
+	variable string
+	literal  interface{}
+	list     []graphQLValue
+}
+
+type graphQLVariable struct {
+	name         string
+	nonNull      bool
+	defaultValue *graphQLValue
+}
+
+type graphQLOperation struct {
+	name       string
+	variables  []graphQLVariable
+	selections []graphQLSelection
+}
+
+// GraphQLError is an entry in a GraphQL response's errors list
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// graphQLResult keeps response keys in the order they were selected, which
+// GraphQL clients expect and a plain map would lose
+type graphQLResult struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newGraphQLResult() *graphQLResult {
+	return &graphQLResult{values: make(map[string]interface{})}
+}
+
+func (r *graphQLResult) set(key string, value interface{}) {
+	if _, exists := r.values[key]; !exists {
+		r.keys = append(r.keys, key)
+	}
+	r.values[key] = value
+}
+
+func (r *graphQLResult) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		value, err := json.Marshal(r.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// execute parses and runs a query document. A non-nil error means the
+// request itself was invalid and nothing was executed; field failures are
+// returned in the errors list alongside partial data.
+func (s *graphQLSchema) execute(ctx context.Context, query, operationName string, variables map[string]interface{}) (*graphQLResult, []GraphQLError, error) {
+	operations, err := parseGraphQL(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	operation, err := selectOperation(operations, operationName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values, err := operation.variableValues(variables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root := s.objects[s.query]
+	cost, err := s.validate(root, operation.selections, values, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cost > graphQLMaxCost {
+		return nil, nil, fmt.Errorf("query costs %d, more than the %d allowed", cost, graphQLMaxCost)
+	}
+
+	exec := &graphQLExecution{schema: s, variables: values}
+	data := exec.resolveObject(ctx, root, nil, operation.selections, nil)
+	return data, exec.errors, nil
+}
+
+func selectOperation(operations []graphQLOperation, name string) (*graphQLOperation, error) {
+	if name == "" {
+		if len(operations) != 1 {
+			return nil, fmt.Errorf("operationName is required when the document has %d operations", len(operations))
+		}
+		return &operations[0], nil
+	}
+
+	for i := range operations {
+		if operations[i].name == name {
+			return &operations[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+func (o *graphQLOperation) variableValues(provided map[string]interface{}) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, variable := range o.variables {
+		if value, ok := provided[variable.name]; ok && value != nil {
+			values[variable.name] = value
+			continue
+		}
+		if variable.defaultValue != nil {
+			value, err := variable.defaultValue.resolve(nil)
+			if err != nil {
+				return nil, err
+			}
+			values[variable.name] = value
+			continue
+		}
+		if variable.nonNull {
+			return nil, fmt.Errorf("variable $%s is required", variable.name)
+		}
+	}
+	return values, nil
+}
+
+// validate checks every selected field exists and the selections aren't
+// nested too deeply before anything runs, so a typo fails the request instead
+// of half-executing it. It returns what the selections cost.
+func (s *graphQLSchema) validate(object *graphQLObject, selections []graphQLSelection, variables map[string]interface{}, depth int) (int, error) {
+	if depth > graphQLMaxDepth {
+		return 0, fmt.Errorf("selections must not be nested more than %d levels deep", graphQLMaxDepth)
+	}
+
+	cost := 0
+	for _, selection := range selections {
+		cost++
+		if selection.name == "__typename" {
+			continue
+		}
+
+		field, ok := object.fields[selection.name]
+		if !ok {
+			return 0, fmt.Errorf("cannot query field %q on type %q", selection.name, object.name)
+		}
+
+		if field.object == "" {
+			if len(selection.children) > 0 {
+				return 0, fmt.Errorf("field %q of type %q must not have a selection", selection.name, object.name)
+			}
+			continue
+		}
+
+		if len(selection.children) == 0 {
+			return 0, fmt.Errorf("field %q of type %q must have a selection of subfields", selection.name, object.name)
+		}
+		childCost, err := s.validate(s.objects[field.object], selection.children, variables, depth+1)
+		if err != nil {
+			return 0, err
+		}
+		cost += selection.size(field, variables) * childCost
+	}
+	return cost, nil
+}
+
+// size is how many values a field may resolve to: its limit argument when
+// one is given, capped like the resolvers cap it
+func (selection graphQLSelection) size(field graphQLField, variables map[string]interface{}) int {
+	size := max(field.listSize, 1)
+	value, ok := selection.arguments["limit"]
+	if !ok {
+		return size
+	}
+
+	limit, err := value.resolve(variables)
+	if err != nil {
+		return size
+	}
+	if n, err := graphQLInt(map[string]interface{}{"limit": limit}, "limit", size); err == nil && n > 0 {
+		size = min(n, graphQLMaxLimit)
+	}
+	return size
+}
+
+type graphQLExecution struct {
+	schema    *graphQLSchema
+	variables map[string]interface{}
+	errors    []GraphQLError
+}
+
+func (e *graphQLExecution) resolveObject(ctx context.Context, object *graphQLObject, source interface{}, selections []graphQLSelection, path []interface{}) *graphQLResult {
+	result := newGraphQLResult()
+
+	for _, selection := range selections {
+		key := selection.alias
+		if key == "" {
+			key = selection.name
+		}
+		fieldPath := append(append([]interface{}{}, path...), key)
+
+		if selection.name == "__typename" {
+			result.set(key, object.name)
+			continue
+		}
+
+		field := object.fields[selection.name]
+		args := make(map[string]interface{}, len(selection.arguments))
+		var argErr error
+		for name, value := range selection.arguments {
+			if args[name], argErr = value.resolve(e.variables); argErr != nil {
+				break
+			}
+		}
+		if argErr != nil {
+			e.addError(argErr, fieldPath)
+			result.set(key, nil)
+			continue
+		}
+
+		value, err := field.resolve(ctx, source, args)
+		if err != nil {
+			e.addError(err, fieldPath)
+			result.set(key, nil)
+			continue
+		}
+
+		if field.object == "" {
+			result.set(key, value)
+			continue
+		}
+		result.set(key, e.resolveValue(ctx, e.schema.objects[field.object], value, selection.children, fieldPath))
+	}
+
+	return result
+}
+
+// resolveValue completes an object-typed field, which may be a single value
+// or a slice of them
+func (e *graphQLExecution) resolveValue(ctx context.Context, object *graphQLObject, value interface{}, selections []graphQLSelection, path []interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil
+	}
+	if v.Kind() != reflect.Slice {
+		return e.resolveObject(ctx, object, value, selections, path)
+	}
+
+	items := make([]interface{}, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		itemPath := append(append([]interface{}{}, path...), i)
+		items[i] = e.resolveObject(ctx, object, v.Index(i).Interface(), selections, itemPath)
+	}
+	return items
+}
+
+func (e *graphQLExecution) addError(err error, path []interface{}) {
+	e.errors = append(e.errors, GraphQLError{Message: err.Error(), Path: path})
+}
+
+func (v graphQLValue) resolve(variables map[string]interface{}) (interface{}, error) {
+	if v.variable != "" {
+		value, ok := variables[v.variable]
+		if !ok {
+			return nil, nil
+		}
+		return value, nil
+	}
+
+	if v.list != nil {
+		items := make([]interface{}, len(v.list))
+		for i, item := range v.list {
+			value, err := item.resolve(variables)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = value
+		}
+		return items, nil
+	}
+
+	return v.literal, nil
+}
+
+// graphQLString reads an optional string argument
+func graphQLString(args map[string]interface{}, name string) (string, error) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return "", nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q must be a string", name)
+	}
+	return s, nil
+}
+
+// graphQLInt reads an optional integer argument. Variables arrive as JSON
+// numbers, so whole float64 values are accepted too.
+func graphQLInt(args map[string]interface{}, name string, defaultValue int) (int, error) {
+	value, ok := args[name]
+	if !ok || value == nil {
+		return defaultValue, nil
+	}
+	switch n := value.(type) {
+	case int:
+		return n, nil
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
+
+// Parsing
+
+type graphQLToken struct {
+	kind  byte // 'n' name, 's' string, 'i' int, 'f' float, 'p' punctuator, 0 end
+	value string
+	pos   int
+}
+
+type graphQLParser struct {
+	tokens []graphQLToken
+	pos    int
+}
+
+func parseGraphQL(query string) ([]graphQLOperation, error) {
+	tokens, err := lexGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &graphQLParser{tokens: tokens}
+	operations := []graphQLOperation{}
+	for p.peek().kind != 0 {
+		operation, err := p.parseOperation()
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, *operation)
+	}
+
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("query document has no operations")
+	}
+	return operations, nil
+}
+
+func (p *graphQLParser) peek() graphQLToken {
+	return p.tokens[p.pos]
+}
+
+func (p *graphQLParser) next() graphQLToken {
+	token := p.tokens[p.pos]
+	if token.kind != 0 {
+		p.pos++
+	}
+	return token
+}
+
+func (p *graphQLParser) isPunct(value string) bool {
+	token := p.peek()
+	return token.kind == 'p' && token.value == value
+}
+
+func (p *graphQLParser) expectPunct(value string) error {
+	token := p.next()
+	if token.kind != 'p' || token.value != value {
+		return p.unexpected(token, fmt.Sprintf("%q", value))
+	}
+	return nil
+}
+
+func (p *graphQLParser) expectName() (string, error) {
+	token := p.next()
+	if token.kind != 'n' {
+		return "", p.unexpected(token, "a name")
+	}
+	return token.value, nil
+}
+
+func (p *graphQLParser) unexpected(token graphQLToken, expected string) error {
+	if token.kind == 0 {
+		return fmt.Errorf("syntax error: unexpected end of query, expected %s", expected)
+	}
+	return fmt.Errorf("syntax error at offset %d: unexpected %q, expected %s", token.pos, token.value, expected)
+}
+
+func (p *graphQLParser) parseOperation() (*graphQLOperation, error) {
+	operation := &graphQLOperation{}
+
+	if p.peek().kind == 'n' {
+		switch keyword := p.next().value; keyword {
+		case "query":
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s operations are not supported", keyword)
+		case "fragment":
+			return nil, fmt.Errorf("fragments are not supported")
+		default:
+			return nil, fmt.Errorf("syntax error: unknown operation type %q", keyword)
+		}
+
+		if p.peek().kind == 'n' {
+			operation.name = p.next().value
+		}
+
+		if p.isPunct("(") {
+			variables, err := p.parseVariableDefinitions()
+			if err != nil {
+				return nil, err
+			}
+			operation.variables = variables
+		}
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	operation.selections = selections
+	return operation, nil
+}
+
+func (p *graphQLParser) parseVariableDefinitions() ([]graphQLVariable, error) {
+	p.next()
+	variables := []graphQLVariable{}
+
+	for !p.isPunct(")") {
+		if err := p.expectPunct("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		nonNull, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+
+		variable := graphQLVariable{name: name, nonNull: nonNull}
+		if p.isPunct("=") {
+			p.next()
+			value, err := p.parseValue(true)
+			if err != nil {
+				return nil, err
+			}
+			variable.defaultValue = &value
+		}
+		variables = append(variables, variable)
+	}
+	p.next()
+
+	return variables, nil
+}
+
+// parseType skips over a type reference, reporting whether it is non-null.
+// Types aren't checked beyond that; resolvers validate their arguments.
+func (p *graphQLParser) parseType() (bool, error) {
+	if p.isPunct("[") {
+		p.next()
+		if _, err := p.parseType(); err != nil {
+			return false, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return false, err
+		}
+	} else if _, err := p.expectName(); err != nil {
+		return false, err
+	}
+
+	if p.isPunct("!") {
+		p.next()
+		return true, nil
+	}
+	return false, nil
+}
+
+func (p *graphQLParser) parseSelectionSet() ([]graphQLSelection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+
+	selections := []graphQLSelection{}
+	for !p.isPunct("}") {
+		if p.isPunct("...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		if p.isPunct("@") {
+			return nil, fmt.Errorf("directives are not supported")
+		}
+
+		selection, err := p.parseField()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, *selection)
+	}
+	p.next()
+
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("syntax error: empty selection set")
+	}
+	return selections, nil
+}
+
+func (p *graphQLParser) parseField() (*graphQLSelection, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	selection := &graphQLSelection{name: name}
+	if p.isPunct(":") {
+		p.next()
+		selection.alias = name
+		if selection.name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunct("(") {
+		p.next()
+		selection.arguments = make(map[string]graphQLValue)
+		for !p.isPunct(")") {
+			argName, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.parseValue(false)
+			if err != nil {
+				return nil, err
+			}
+			selection.arguments[argName] = value
+		}
+		p.next()
+	}
+
+	if p.isPunct("@") {
+		return nil, fmt.Errorf("directives are not supported")
+	}
+
+	if p.isPunct("{") {
+		if selection.children, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+
+	return selection, nil
+}
+
+func (p *graphQLParser) parseValue(constant bool) (graphQLValue, error) {
+	token := p.next()
+
+	switch token.kind {
+	case 's':
+		return graphQLValue{literal: token.value}, nil
+	case 'i':
+		n, err := strconv.Atoi(token.value)
+		if err != nil {
+			return graphQLValue{}, fmt.Errorf("syntax error: invalid integer %q", token.value)
+		}
+		return graphQLValue{literal: n}, nil
+	case 'f':
+		f, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return graphQLValue{}, fmt.Errorf("syntax error: invalid float %q", token.value)
+		}
+		return graphQLValue{literal: f}, nil
+	case 'n':
+		switch token.value {
+		case "true":
+			return graphQLValue{literal: true}, nil
+		case "false":
+			return graphQLValue{literal: false}, nil
+		case "null":
+			return graphQLValue{}, nil
+		default:
+			// Enum values are passed to resolvers as their name
+			return graphQLValue{literal: token.value}, nil
+		}
+	case 'p':
+		switch token.value {
+		case "$":
+			if constant {
+				return graphQLValue{}, fmt.Errorf("syntax error: variables are not allowed in default values")
+			}
+			name, err := p.expectName()
+			if err != nil {
+				return graphQLValue{}, err
+			}
+			return graphQLValue{variable: name}, nil
+		case "[":
+			list := []graphQLValue{}
+			for !p.isPunct("]") {
+				item, err := p.parseValue(constant)
+				if err != nil {
+					return graphQLValue{}, err
+				}
+				list = append(list, item)
+			}
+			p.next()
+			return graphQLValue{list: list}, nil
+		case "{":
+			return graphQLValue{}, fmt.Errorf("input objects are not supported")
+		}
+	}
+
+	return graphQLValue{}, p.unexpected(token, "a value")
+}
+
+func lexGraphQL(query string) ([]graphQLToken, error) {
+	tokens := []graphQLToken{}
+	i := 0
+
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '.':
+			if !strings.HasPrefix(query[i:], "...") {
+				return nil, fmt.Errorf("syntax error at offset %d: unexpected %q", i, string(c))
+			}
+			tokens = append(tokens, graphQLToken{kind: 'p', value: "...", pos: i})
+			i += 3
+		case strings.IndexByte("{}()[]:!$=@|&", c) >= 0:
+			tokens = append(tokens, graphQLToken{kind: 'p', value: string(c), pos: i})
+			i++
+		case c == '_' || isASCIILetter(c):
+			start := i
+			for i < len(query) && (query[i] == '_' || isASCIILetter(query[i]) || isASCIIDigit(query[i])) {
+				i++
+			}
+			tokens = append(tokens, graphQLToken{kind: 'n', value: query[start:i], pos: start})
+		case c == '-' || isASCIIDigit(c):
+			start := i
+			kind := byte('i')
+			i++
+			for i < len(query) {
+				d := query[i]
+				if d == '.' || d == 'e' || d == 'E' || ((d == '+' || d == '-') && (query[i-1] == 'e' || query[i-1] == 'E')) {
+					kind = 'f'
+				} else if !isASCIIDigit(d) {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, graphQLToken{kind: kind, value: query[start:i], pos: start})
+		case c == '"':
+			start := i
+			if strings.HasPrefix(query[i:], `"""`) {
+				end := strings.Index(query[i+3:], `"""`)
+				if end < 0 {
+					return nil, fmt.Errorf("syntax error at offset %d: unterminated string", start)
+				}
+				tokens = append(tokens, graphQLToken{kind: 's', value: query[i+3 : i+3+end], pos: start})
+				i += end + 6
+				continue
+			}
+
+			i++
+			for i < len(query) && query[i] != '"' {
+				if query[i] == '\\' {
+					i++
+				}
+				if i < len(query) && query[i] == '\n' {
+					return nil, fmt.Errorf("syntax error at offset %d: unterminated string", start)
+				}
+				i++
+			}
+			if i >= len(query) {
+				return nil, fmt.Errorf("syntax error at offset %d: unterminated string", start)
+			}
+			i++
+
+			// GraphQL string escapes are a subset of JSON's
+			var value string
+			if err := json.Unmarshal([]byte(query[start:i]), &value); err != nil {
+				return nil, fmt.Errorf("syntax error at offset %d: invalid string", start)
+			}
+			tokens = append(tokens, graphQLToken{kind: 's', value: value, pos: start})
+		default:
+			return nil, fmt.Errorf("syntax error at offset %d: unexpected %q", i, string(c))
+		}
+	}
+
+	return append(tokens, graphQLToken{pos: len(query)}), nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,540 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// GraphQLSchemaSDL documents the schema served at /graphql
+const GraphQLSchemaSDL = `type Query {
+  artists(query: String!, limit: Int = 10): [Artist!]!
+  artist(id: ID!): Artist
+  events(artist: String, city: String, country: String, limit: Int = 50): [Event!]!
+  venues(city: String!, country: String, limit: Int = 50): [Venue!]!
+  sources: [Source!]!
+}
+
+type Artist {
+  id: ID!
+  name: String!
+  genres: [String!]!
+  popularity: Int
+  imageUrl: String
+  spotifyId: String
+  lastfmId: String
+  musicbrainzId: String
+  events(limit: Int = 50): [Event!]!
+}
+
+type Event {
+  id: ID!
+  title: String
+  datetime: String!
+  timezone: String
+  dateConfidence: String
+  ticketUrl: String
+  ticketStatus: String
+  onSaleDate: String
+  artistId: String
+  artistName: String!
+  artist: Artist
+  venue: Venue!
+  lineup: [LineupArtist!]!
+  ticketOffers: [TicketOffer!]!
+  sources: [String!]!
+}
+
+type LineupArtist {
+  id: ID
+  name: String!
+  billing: Int!
+  headliner: Boolean!
+}
+
+type TicketOffer {
+  source: String!
+  url: String!
+  status: String
+  minPrice: Float
+  maxPrice: Float
+  currency: String
+}
+
+type Venue {
+  id: ID
+  name: String!
+  city: String!
+  region: String
+  country: String!
+  latitude: Float
+  longitude: Float
+}
+
+type Source {
+  name: String!
+  type: String!
+  status: String!
+  consecutiveFailures: Int!
+  retryAt: String
+}
+`
+
+const graphQLMaxLimit = 200
+
+// graphQLMaxBodyBytes caps a POSTed request; queries are small
+const graphQLMaxBodyBytes = 1 << 20
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLHandler serves artists, events, venues and sources over GraphQL
+// using the same services as the REST handlers
+type GraphQLHandler struct {
+	artists    domain.ArtistService
+	aggregator AggregatorService
+	schema     *graphQLSchema
+}
+
+// graphQLSource pairs a source's name with its status
+type graphQLSource struct {
+	name string
+	info integrations.SourceInfo
+}
+
+func NewGraphQLHandler(artists domain.ArtistService, aggregator AggregatorService) *GraphQLHandler {
+	h := &GraphQLHandler{
+		artists:    artists,
+		aggregator: aggregator,
+	}
+	h.schema = h.buildSchema()
+	return h
+}
+
+func (h *GraphQLHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/graphql", h.Query).Methods("GET", "POST")
+	router.HandleFunc("/graphql/schema", h.GetSchema).Methods("GET")
+}
+
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	var request GraphQLRequest
+
+	if r.Method == http.MethodGet {
+		request.Query = r.URL.Query().Get("query")
+		request.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				h.respondWithErrors(w, http.StatusBadRequest, "variables must be a JSON object")
+				return
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, graphQLMaxBodyBytes)).Decode(&request); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.respondWithErrors(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		h.respondWithErrors(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(request.Query) == "" {
+		h.respondWithErrors(w, http.StatusBadRequest, "query is required")
+		return
+	}
+
+	data, fieldErrors, err := h.schema.execute(r.Context(), request.Query, request.OperationName, request.Variables)
+	if err != nil {
+		h.respondWithErrors(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, GraphQLResponse{Data: data, Errors: fieldErrors})
+}
+
+func (h *GraphQLHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(GraphQLSchemaSDL))
+}
+
+func (h *GraphQLHandler) buildSchema() *graphQLSchema {
+	return &graphQLSchema{
+		query: "Query",
+		objects: map[string]*graphQLObject{
+			"Query": {name: "Query", fields: map[string]graphQLField{
+				"artists": {object: "Artist", listSize: 10, resolve: h.resolveArtists},
+				"artist":  {object: "Artist", resolve: h.resolveArtist},
+				"events":  {object: "Event", listSize: 50, resolve: h.resolveEvents},
+				"venues":  {object: "Venue", listSize: 50, resolve: h.resolveVenues},
+				"sources": {object: "Source", listSize: 25, resolve: h.resolveSources},
+			}},
+			"Artist": {name: "Artist", fields: map[string]graphQLField{
+				"id":            artistField(func(a domain.Artist) interface{} { return a.ID }),
+				"name":          artistField(func(a domain.Artist) interface{} { return a.Name }),
+				"genres":        artistField(func(a domain.Artist) interface{} { return nonNilStrings(a.Genres) }),
+				"popularity":    artistField(func(a domain.Artist) interface{} { return a.Popularity }),
+				"imageUrl":      artistField(func(a domain.Artist) interface{} { return optionalString(a.ImageURL) }),
+				"spotifyId":     artistField(func(a domain.Artist) interface{} { return optionalString(a.ExternalIDs.SpotifyID) }),
+				"lastfmId":      artistField(func(a domain.Artist) interface{} { return optionalString(a.ExternalIDs.LastFMID) }),
+				"musicbrainzId": artistField(func(a domain.Artist) interface{} { return optionalString(a.ExternalIDs.MusicBrainzID) }),
+				"events":        {object: "Event", listSize: 50, resolve: h.resolveArtistEvents},
+			}},
+			"Event": {name: "Event", fields: map[string]graphQLField{
+				"id":           eventField(func(e domain.Event) interface{} { return e.ID }),
+				"title":        eventField(func(e domain.Event) interface{} { return optionalString(e.Title) }),
+				"datetime":     eventField(func(e domain.Event) interface{} { return e.DateTime.Format(time.RFC3339) }),
+				"timezone":     eventField(func(e domain.Event) interface{} { return optionalString(e.Timezone) }),
+				"ticketUrl":    eventField(func(e domain.Event) interface{} { return optionalString(e.TicketURL) }),
+				"ticketStatus": eventField(func(e domain.Event) interface{} { return optionalString(e.TicketStatus) }),
+				"onSaleDate":   eventField(func(e domain.Event) interface{} { return optionalTime(e.OnSaleDate) }),
+				"artistId":     eventField(func(e domain.Event) interface{} { return optionalString(e.ArtistID) }),
+				"artistName":   eventField(func(e domain.Event) interface{} { return e.ArtistName }),
+				"artist":       {object: "Artist", resolve: h.resolveEventArtist},
+				"venue": {object: "Venue", resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					return source.(domain.Event).Venue, nil
+				}},
+				"lineup": {object: "LineupArtist", listSize: 10, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					lineup := source.(domain.Event).Lineup
+					if lineup == nil {
+						lineup = []domain.EventArtist{}
+					}
+					return lineup, nil
+				}},
+				"ticketOffers": {object: "TicketOffer", listSize: 10, resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+					offers := source.(domain.Event).TicketOffers
+					if offers == nil {
+						offers = []domain.TicketOffer{}
+					}
+					return offers, nil
+				}},
+				"dateConfidence": eventField(func(e domain.Event) interface{} { return optionalString(string(e.DateConfidence)) }),
+				"sources":        eventField(func(e domain.Event) interface{} { return nonNilStrings(e.Sources) }),
+			}},
+			"LineupArtist": {name: "LineupArtist", fields: map[string]graphQLField{
+				"id":        lineupField(func(a domain.EventArtist) interface{} { return optionalString(a.ID) }),
+				"name":      lineupField(func(a domain.EventArtist) interface{} { return a.Name }),
+				"billing":   lineupField(func(a domain.EventArtist) interface{} { return a.Billing }),
+				"headliner": lineupField(func(a domain.EventArtist) interface{} { return a.Headliner }),
+			}},
+			"TicketOffer": {name: "TicketOffer", fields: map[string]graphQLField{
+				"source": ticketOfferField(func(o domain.TicketOffer) interface{} { return o.Source }),
+				"url":    ticketOfferField(func(o domain.TicketOffer) interface{} { return o.URL }),
+				"status": ticketOfferField(func(o domain.TicketOffer) interface{} { return optionalString(o.Status) }),
+				"minPrice": ticketOfferField(func(o domain.TicketOffer) interface{} {
+					if o.PriceRange == nil {
+						return nil
+					}
+					return o.PriceRange.Min
+				}),
+				"maxPrice": ticketOfferField(func(o domain.TicketOffer) interface{} {
+					if o.PriceRange == nil {
+						return nil
+					}
+					return o.PriceRange.Max
+				}),
+				"currency": ticketOfferField(func(o domain.TicketOffer) interface{} {
+					if o.PriceRange == nil {
+						return nil
+					}
+					return optionalString(o.PriceRange.Currency)
+				}),
+			}},
+			"Venue": {name: "Venue", fields: map[string]graphQLField{
+				"id":        venueField(func(v domain.Venue) interface{} { return optionalString(v.ID) }),
+				"name":      venueField(func(v domain.Venue) interface{} { return v.Name }),
+				"city":      venueField(func(v domain.Venue) interface{} { return v.City }),
+				"region":    venueField(func(v domain.Venue) interface{} { return optionalString(v.Region) }),
+				"country":   venueField(func(v domain.Venue) interface{} { return v.Country }),
+				"latitude":  venueField(func(v domain.Venue) interface{} { return v.Latitude }),
+				"longitude": venueField(func(v domain.Venue) interface{} { return v.Longitude }),
+			}},
+			"Source": {name: "Source", fields: map[string]graphQLField{
+				"name":                sourceField(func(s graphQLSource) interface{} { return s.name }),
+				"type":                sourceField(func(s graphQLSource) interface{} { return s.info.Type }),
+				"status":              sourceField(func(s graphQLSource) interface{} { return s.info.Status }),
+				"consecutiveFailures": sourceField(func(s graphQLSource) interface{} { return s.info.ConsecutiveFailures }),
+				"retryAt":             sourceField(func(s graphQLSource) interface{} { return optionalTime(s.info.RetryAt) }),
+			}},
+		},
+	}
+}
+
+func (h *GraphQLHandler) resolveArtists(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	query, err := graphQLString(args, "query")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("argument %q is required", "query")
+	}
+
+	limit, err := graphQLLimit(args, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := h.artists.SearchArtists(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	return response.Artists, nil
+}
+
+func (h *GraphQLHandler) resolveArtist(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	id, err := graphQLString(args, "id")
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, fmt.Errorf("argument %q is required", "id")
+	}
+
+	artist, err := h.artists.GetArtist(ctx, id)
+	if errors.Is(err, domain.ErrArtistNotFound) {
+		return nil, nil
+	}
+	if err != nil || artist == nil {
+		return nil, err
+	}
+	return *artist, nil
+}
+
+func (h *GraphQLHandler) resolveEvents(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	artistName, err := graphQLString(args, "artist")
+	if err != nil {
+		return nil, err
+	}
+	city, err := graphQLString(args, "city")
+	if err != nil {
+		return nil, err
+	}
+	country, err := graphQLString(args, "country")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := graphQLLimit(args, 50)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case artistName != "":
+		results, err := h.aggregator.SearchEvents(ctx, artistName, limit)
+		if err != nil {
+			return nil, err
+		}
+		return results.Events, nil
+	case city != "":
+		results, err := h.aggregator.SearchEventsByLocation(ctx, city, country, limit)
+		if err != nil {
+			return nil, err
+		}
+		return results.Events, nil
+	default:
+		return nil, fmt.Errorf("either %q or %q is required", "artist", "city")
+	}
+}
+
+// resolveVenues lists the distinct venues hosting events in a city
+func (h *GraphQLHandler) resolveVenues(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	city, err := graphQLString(args, "city")
+	if err != nil {
+		return nil, err
+	}
+	if city == "" {
+		return nil, fmt.Errorf("argument %q is required", "city")
+	}
+	country, err := graphQLString(args, "country")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := graphQLLimit(args, 50)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := h.aggregator.SearchEventsByLocation(ctx, city, country, graphQLMaxLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	venues := []domain.Venue{}
+	for _, event := range results.Events {
+		key := strings.ToLower(event.Venue.Name + "|" + event.Venue.City)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		venues = append(venues, event.Venue)
+		if len(venues) == limit {
+			break
+		}
+	}
+	return venues, nil
+}
+
+func (h *GraphQLHandler) resolveSources(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	stats := h.aggregator.GetSourceStats()
+
+	sources := make([]graphQLSource, 0, len(stats))
+	for name, info := range stats {
+		sources = append(sources, graphQLSource{name: name, info: info})
+	}
+
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].name < sources[j].name
+	})
+	return sources, nil
+}
+
+// resolveArtistEvents uses the stored-artist lookup when the aggregator has
+// one, so external IDs are used; artists that were only found by search fall
+// back to a search by name
+func (h *GraphQLHandler) resolveArtistEvents(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	artist := source.(domain.Artist)
+	limit, err := graphQLLimit(args, 50)
+	if err != nil {
+		return nil, err
+	}
+
+	if service, ok := h.aggregator.(ArtistEventsService); ok && artist.ID != "" {
+		results, err := service.GetArtistEvents(ctx, artist.ID, limit)
+		if err == nil {
+			return results.Events, nil
+		}
+		if !errors.Is(err, domain.ErrArtistNotFound) {
+			return nil, err
+		}
+	}
+
+	results, err := h.aggregator.SearchEvents(ctx, artist.Name, limit)
+	if err != nil {
+		return nil, err
+	}
+	return results.Events, nil
+}
+
+func (h *GraphQLHandler) resolveEventArtist(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	event := source.(domain.Event)
+	if event.ArtistID == "" {
+		return nil, nil
+	}
+
+	artist, err := h.artists.GetArtist(ctx, event.ArtistID)
+	if errors.Is(err, domain.ErrArtistNotFound) {
+		return nil, nil
+	}
+	if err != nil || artist == nil {
+		return nil, err
+	}
+	return *artist, nil
+}
+
+func (h *GraphQLHandler) respondWithErrors(w http.ResponseWriter, code int, message string) {
+	h.respondWithJSON(w, code, GraphQLResponse{Errors: []GraphQLError{{Message: message}}})
+}
+
+func (h *GraphQLHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, err := json.Marshal(payload)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"errors":[{"message":"internal server error"}]}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+// graphQLLimit reads a limit argument with the same cap as the REST API
+func graphQLLimit(args map[string]interface{}, defaultLimit int) (int, error) {
+	limit, err := graphQLInt(args, "limit", defaultLimit)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return 0, fmt.Errorf("argument %q must be positive", "limit")
+	}
+	if limit > graphQLMaxLimit {
+		limit = graphQLMaxLimit
+	}
+	return limit, nil
+}
+
+func artistField(get func(domain.Artist) interface{}) graphQLField {
+	return graphQLField{resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return get(source.(domain.Artist)), nil
+	}}
+}
+
+func eventField(get func(domain.Event) interface{}) graphQLField {
+	return graphQLField{resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return get(source.(domain.Event)), nil
+	}}
+}
+
+func lineupField(get func(domain.EventArtist) interface{}) graphQLField {
+	return graphQLField{resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return get(source.(domain.EventArtist)), nil
+	}}
+}
+
+func ticketOfferField(get func(domain.TicketOffer) interface{}) graphQLField {
+	return graphQLField{resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return get(source.(domain.TicketOffer)), nil
+	}}
+}
+
+func venueField(get func(domain.Venue) interface{}) graphQLField {
+	return graphQLField{resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return get(source.(domain.Venue)), nil
+	}}
+}
+
+func sourceField(get func(graphQLSource) interface{}) graphQLField {
+	return graphQLField{resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+		return get(source.(graphQLSource)), nil
+	}}
+}
+
+// optionalString maps empty strings to null
+func optionalString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func optionalTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
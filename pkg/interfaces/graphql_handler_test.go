@@ -0,0 +1,265 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+func newGraphQLTestRouter() *mux.Router {
+	eventTime := time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC)
+	venue := domain.Venue{ID: "v1", Name: "Columbiahalle", City: "Berlin", Country: "Germany"}
+
+	artists := &mockArtistService{
+		searchFunc: func(ctx context.Context, query string, limit int) (*domain.ArtistSearchResponse, error) {
+			return &domain.ArtistSearchResponse{
+				Artists: []domain.Artist{{ID: "artist_1", Name: "Radiohead", Genres: []string{"rock"}}},
+				Total:   1,
+			}, nil
+		},
+		getFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != "artist_1" {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &domain.Artist{ID: "artist_1", Name: "Radiohead"}, nil
+		},
+	}
+
+	aggregator := &mockMegaAggregator{
+		searchEventsFunc: func(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+			return &integrations.AggregatedResults{Events: []domain.Event{
+				{ID: "e1", ArtistID: "artist_1", ArtistName: artistName, DateTime: eventTime, Venue: venue},
+			}}, nil
+		},
+		searchEventsByLocationFunc: func(ctx context.Context, city, country string, limit int) (*integrations.AggregatedResults, error) {
+			return &integrations.AggregatedResults{Events: []domain.Event{
+				{ID: "e1", ArtistName: "Radiohead", DateTime: eventTime, Venue: venue},
+				{ID: "e2", ArtistName: "Portishead", DateTime: eventTime, Venue: venue},
+				{ID: "e3", ArtistName: "Massive Attack", DateTime: eventTime, Venue: domain.Venue{Name: "Tempodrom", City: "Berlin"}},
+			}}, nil
+		},
+		getSourceStatsFunc: func() map[string]integrations.SourceInfo {
+			return map[string]integrations.SourceInfo{
+				"songkick": {Type: "events", Status: "active"},
+				"lastfm":   {Type: "music", Status: "active"},
+			}
+		},
+	}
+
+	router := mux.NewRouter()
+	NewGraphQLHandler(artists, aggregator).RegisterRoutes(router)
+	return router
+}
+
+func postGraphQL(t *testing.T, router *mux.Router, request GraphQLRequest) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest("POST", "/graphql", strings.NewReader(string(body)))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	return rr, strings.TrimSpace(rr.Body.String())
+}
+
+func TestGraphQLHandler_Query(t *testing.T) {
+	router := newGraphQLTestRouter()
+
+	t.Run("nested artist events and venue", func(t *testing.T) {
+		rr, body := postGraphQL(t, router, GraphQLRequest{
+			Query: `query Artist($id: ID!) {
+				artist(id: $id) {
+					name
+					events(limit: 5) { id when: datetime venue { name city } }
+				}
+			}`,
+			Variables: map[string]interface{}{"id": "artist_1"},
+		})
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rr.Code, body)
+		}
+		expected := `{"data":{"artist":{"name":"Radiohead","events":[{"id":"e1","when":"2026-05-01T20:00:00Z","venue":{"name":"Columbiahalle","city":"Berlin"}}]}}}`
+		if body != expected {
+			t.Errorf("unexpected response:\n got %s\nwant %s", body, expected)
+		}
+	})
+
+	t.Run("event back to its artist", func(t *testing.T) {
+		_, body := postGraphQL(t, router, GraphQLRequest{
+			Query: `{ events(artist: "Radiohead") { artist { id __typename } } }`,
+		})
+
+		expected := `{"data":{"events":[{"artist":{"id":"artist_1","__typename":"Artist"}}]}}`
+		if body != expected {
+			t.Errorf("unexpected response:\n got %s\nwant %s", body, expected)
+		}
+	})
+
+	t.Run("venues are distinct", func(t *testing.T) {
+		_, body := postGraphQL(t, router, GraphQLRequest{
+			Query: `{ venues(city: "Berlin") { name } }`,
+		})
+
+		expected := `{"data":{"venues":[{"name":"Columbiahalle"},{"name":"Tempodrom"}]}}`
+		if body != expected {
+			t.Errorf("unexpected response:\n got %s\nwant %s", body, expected)
+		}
+	})
+
+	t.Run("sources", func(t *testing.T) {
+		_, body := postGraphQL(t, router, GraphQLRequest{
+			Query: `{ sources { name status } }`,
+		})
+
+		expected := `{"data":{"sources":[{"name":"lastfm","status":"active"},{"name":"songkick","status":"active"}]}}`
+		if body != expected {
+			t.Errorf("unexpected response:\n got %s\nwant %s", body, expected)
+		}
+	})
+
+	t.Run("quotas stay behind the admin token", func(t *testing.T) {
+		_, body := postGraphQL(t, router, GraphQLRequest{
+			Query: `{ sources { name quota { remaining } } }`,
+		})
+
+		expected := `{"errors":[{"message":"cannot query field \"quota\" on type \"Source\""}]}`
+		if body != expected {
+			t.Errorf("unexpected response:\n got %s\nwant %s", body, expected)
+		}
+	})
+
+	t.Run("missing artist is null", func(t *testing.T) {
+		_, body := postGraphQL(t, router, GraphQLRequest{
+			Query: `{ artist(id: "missing") { name } }`,
+		})
+
+		if body != `{"data":{"artist":null}}` {
+			t.Errorf("unexpected response: %s", body)
+		}
+	})
+
+	t.Run("resolver errors are reported with a path", func(t *testing.T) {
+		rr, body := postGraphQL(t, router, GraphQLRequest{
+			Query: `{ artists(query: "radio") { name } events { id } }`,
+		})
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+
+		var response struct {
+			Data   map[string]json.RawMessage `json:"data"`
+			Errors []GraphQLError             `json:"errors"`
+		}
+		if err := json.Unmarshal([]byte(body), &response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if string(response.Data["artists"]) != `[{"name":"Radiohead"}]` {
+			t.Errorf("expected partial data, got %s", response.Data["artists"])
+		}
+		if len(response.Errors) != 1 || response.Errors[0].Path[0] != "events" {
+			t.Errorf("expected one error on events, got %+v", response.Errors)
+		}
+	})
+
+	t.Run("GET query", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/graphql?query="+strings.ReplaceAll(`{ artists(query: "radio") { name } }`, " ", "%20"), nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rr.Code)
+		}
+	})
+}
+
+func TestGraphQLHandler_InvalidQueries(t *testing.T) {
+	router := newGraphQLTestRouter()
+
+	tests := []struct {
+		name    string
+		request GraphQLRequest
+		message string
+	}{
+		{"empty query", GraphQLRequest{}, "query is required"},
+		{"syntax error", GraphQLRequest{Query: `{ artists(query: "x" { name } }`}, "syntax error"},
+		{"unknown field", GraphQLRequest{Query: `{ artist(id: "1") { name email } }`}, "cannot query field"},
+		{"missing selection", GraphQLRequest{Query: `{ artist(id: "1") }`}, "must have a selection"},
+		{"scalar selection", GraphQLRequest{Query: `{ artist(id: "1") { name { first } } }`}, "must not have a selection"},
+		{"mutation", GraphQLRequest{Query: `mutation { artists }`}, "not supported"},
+		{"fragment", GraphQLRequest{Query: `{ artist(id: "1") { ...Fields } }`}, "fragments are not supported"},
+		{"missing variable", GraphQLRequest{Query: `query ($id: ID!) { artist(id: $id) { name } }`}, "$id is required"},
+		{"ambiguous operation", GraphQLRequest{Query: `query A { sources { name } } query B { sources { name } }`}, "operationName is required"},
+		{"too deep", GraphQLRequest{Query: `{ artist(id: "1") { events { artist { events { artist { events { id } } } } } } }`}, "nested more than 6 levels"},
+		{"too costly", GraphQLRequest{Query: `query ($n: Int) { artists(query: "x", limit: 200) { events(limit: $n) { title } } }`, Variables: map[string]interface{}{"n": 200}}, "more than the 10000 allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, body := postGraphQL(t, router, tt.request)
+
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", rr.Code)
+			}
+			if !strings.Contains(body, tt.message) {
+				t.Errorf("expected %q in %s", tt.message, body)
+			}
+		})
+	}
+}
+
+func TestGraphQLHandler_BodyTooLarge(t *testing.T) {
+	router := newGraphQLTestRouter()
+
+	query := `{ sources { name } }` + strings.Repeat(" ", graphQLMaxBodyBytes)
+	rr, body := postGraphQL(t, router, GraphQLRequest{Query: query})
+
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status 413, got %d: %.200s", rr.Code, body)
+	}
+}
+
+func TestParseGraphQL(t *testing.T) {
+	operations, err := parseGraphQL(`
+		# comments and commas are ignored
+		query Search($q: String = "default", $n: [Int!]) {
+			first: artists(query: $q, limit: -3) { name }
+			events(artist: "say \"hi\"", city: """raw "text" here""") { id }
+		}`)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	operation := operations[0]
+	if operation.name != "Search" || len(operation.variables) != 2 {
+		t.Fatalf("unexpected operation: %+v", operation)
+	}
+	if operation.variables[0].defaultValue.literal != "default" {
+		t.Errorf("expected default value, got %v", operation.variables[0].defaultValue.literal)
+	}
+
+	first := operation.selections[0]
+	if first.alias != "first" || first.name != "artists" {
+		t.Errorf("expected aliased artists field, got %+v", first)
+	}
+	if first.arguments["query"].variable != "q" || first.arguments["limit"].literal != -3 {
+		t.Errorf("unexpected arguments: %+v", first.arguments)
+	}
+
+	events := operation.selections[1]
+	if events.arguments["artist"].literal != `say "hi"` {
+		t.Errorf("expected escaped string, got %v", events.arguments["artist"].literal)
+	}
+	if events.arguments["city"].literal != `raw "text" here` {
+		t.Errorf("expected block string, got %v", events.arguments["city"].literal)
+	}
+}
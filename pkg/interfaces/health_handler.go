@@ -0,0 +1,120 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// Pinger is the database connection the readiness check pings
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// readinessTimeout bounds the database ping so a wedged connection fails the
+// probe rather than hanging it
+const readinessTimeout = 2 * time.Second
+
+// HealthHandler serves the liveness and readiness probes
+type HealthHandler struct {
+	db         Pinger
+	aggregator AggregatorService
+}
+
+func NewHealthHandler(db Pinger, aggregator AggregatorService) *HealthHandler {
+	return &HealthHandler{
+		db:         db,
+		aggregator: aggregator,
+	}
+}
+
+func (h *HealthHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/healthz", h.Liveness).Methods("GET")
+	router.HandleFunc("/health", h.Liveness).Methods("GET")
+	router.HandleFunc("/readyz", h.Readiness).Methods("GET")
+}
+
+type HealthCheck struct {
+	Status string `json:"status"`
+	Count  int    `json:"count,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ReadinessResponse struct {
+	Status  string                             `json:"status"`
+	Checks  map[string]HealthCheck             `json:"checks"`
+	Sources map[string]integrations.SourceInfo `json:"sources"`
+}
+
+// Liveness only says the process is serving requests; it checks no
+// dependencies so a slow upstream never gets the pod restarted
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readiness reports whether the instance can serve searches: the database
+// answers and at least one music and one event source are configured. Breaker
+// state is reported per source but an upstream outage doesn't fail the probe,
+// since every replica would see the same outage.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	checks := make(map[string]HealthCheck)
+	ready := true
+
+	if err := h.db.PingContext(ctx); err != nil {
+		checks["database"] = HealthCheck{Status: "error", Error: err.Error()}
+		ready = false
+	} else {
+		checks["database"] = HealthCheck{Status: "ok"}
+	}
+
+	sources := h.aggregator.GetSourceStats()
+	musicSources, eventSources := 0, 0
+	for _, info := range sources {
+		switch info.Type {
+		case "music":
+			musicSources++
+		case "events", "scraper":
+			eventSources++
+		}
+	}
+
+	checks["music_sources"] = sourceCheck(musicSources, "no music source is configured")
+	checks["event_sources"] = sourceCheck(eventSources, "no event source is configured")
+	if musicSources == 0 || eventSources == 0 {
+		ready = false
+	}
+
+	response := ReadinessResponse{
+		Status:  "ready",
+		Checks:  checks,
+		Sources: sources,
+	}
+	code := http.StatusOK
+	if !ready {
+		response.Status = "not_ready"
+		code = http.StatusServiceUnavailable
+	}
+
+	h.respondWithJSON(w, code, response)
+}
+
+func sourceCheck(count int, missing string) HealthCheck {
+	if count == 0 {
+		return HealthCheck{Status: "error", Error: missing}
+	}
+	return HealthCheck{Status: "ok", Count: count}
+}
+
+func (h *HealthHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
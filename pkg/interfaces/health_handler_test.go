@@ -0,0 +1,87 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+type stubPinger struct {
+	err error
+}
+
+func (s *stubPinger) PingContext(ctx context.Context) error {
+	return s.err
+}
+
+func TestHealthHandler(t *testing.T) {
+	configured := &mockMegaAggregator{
+		getSourceStatsFunc: func() map[string]integrations.SourceInfo {
+			return map[string]integrations.SourceInfo{
+				"lastfm":   {Type: "music", Status: "active"},
+				"songkick": {Type: "events", Status: "open", ConsecutiveFailures: 5},
+			}
+		},
+	}
+
+	get := func(handler *HealthHandler, path string) (*httptest.ResponseRecorder, ReadinessResponse) {
+		router := mux.NewRouter()
+		handler.RegisterRoutes(router)
+
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var response ReadinessResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response
+	}
+
+	t.Run("liveness ignores dependencies", func(t *testing.T) {
+		handler := NewHealthHandler(&stubPinger{err: errors.New("database is locked")}, &mockMegaAggregator{})
+		for _, path := range []string{"/healthz", "/health"} {
+			if rr, _ := get(handler, path); rr.Code != http.StatusOK {
+				t.Errorf("expected status 200 for %s, got %d", path, rr.Code)
+			}
+		}
+	})
+
+	t.Run("ready with open breakers reported", func(t *testing.T) {
+		rr, response := get(NewHealthHandler(&stubPinger{}, configured), "/readyz")
+
+		if rr.Code != http.StatusOK || response.Status != "ready" {
+			t.Fatalf("expected ready, got %d %s", rr.Code, response.Status)
+		}
+		if response.Checks["event_sources"].Count != 1 {
+			t.Errorf("expected 1 event source, got %+v", response.Checks["event_sources"])
+		}
+		if response.Sources["songkick"].Status != "open" {
+			t.Errorf("expected songkick breaker state, got %+v", response.Sources["songkick"])
+		}
+	})
+
+	t.Run("database down", func(t *testing.T) {
+		rr, response := get(NewHealthHandler(&stubPinger{err: errors.New("database is locked")}, configured), "/readyz")
+
+		if rr.Code != http.StatusServiceUnavailable || response.Checks["database"].Error != "database is locked" {
+			t.Errorf("expected 503 with the ping error, got %d %+v", rr.Code, response.Checks)
+		}
+	})
+
+	t.Run("no sources configured", func(t *testing.T) {
+		rr, response := get(NewHealthHandler(&stubPinger{}, &mockMegaAggregator{}), "/readyz")
+
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("expected status 503, got %d", rr.Code)
+		}
+		if response.Checks["music_sources"].Status != "error" || response.Checks["event_sources"].Status != "error" {
+			t.Errorf("expected both source checks to fail, got %+v", response.Checks)
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+const (
+	defaultBackfillPageDelay = time.Second
+	defaultBackfillBackoff   = time.Minute
+	// backfillRateLimitRetries is how many times a rate limited page is
+	// tried again, waiting twice as long each time
+	backfillRateLimitRetries = 3
+)
+
+type HistoryBackfillConfig struct {
+	Sources     map[string]integrations.HistorySource
+	Concerts    domain.PastConcertRepository
+	Checkpoints domain.BackfillCheckpointRepository
+	// PageDelay spaces out the pages asked of a source, on top of its own
+	// rate limit, so a backfill leaves budget for the live service
+	PageDelay time.Duration
+	// Backoff is the first wait after a source says it's rate limited
+	Backoff time.Duration
+	Logger  *slog.Logger
+}
+
+// HistoryBackfiller archives years of an artist's concerts by walking the
+// history sources page by page, newest first, back to a date. Every page
+// stored is checkpointed, so a backfill that's interrupted or rerun picks
+// up after the last page rather than starting over.
+type HistoryBackfiller struct {
+	config HistoryBackfillConfig
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// BackfillResult is what backfilling one artist stored. Skipped lists the
+// sources an earlier run already finished.
+type BackfillResult struct {
+	Artist  string   `json:"artist"`
+	Stored  int      `json:"stored"`
+	Pages   int      `json:"pages"`
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+func NewHistoryBackfiller(config HistoryBackfillConfig) *HistoryBackfiller {
+	if config.PageDelay <= 0 {
+		config.PageDelay = defaultBackfillPageDelay
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = defaultBackfillBackoff
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &HistoryBackfiller{config: config, sleep: sleepContext}
+}
+
+// Backfill stores the artist's concerts since the given date from every
+// history source. A source that fails stops only its own walk; the others
+// still run, and the error is returned once they're done.
+func (b *HistoryBackfiller) Backfill(ctx context.Context, artist domain.Artist, since time.Time) (*BackfillResult, error) {
+	if len(b.config.Sources) == 0 {
+		return nil, fmt.Errorf("no history sources are configured")
+	}
+
+	names := make([]string, 0, len(b.config.Sources))
+	for name := range b.config.Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := &BackfillResult{Artist: artist.Name}
+	var errs []error
+	for _, name := range names {
+		if err := b.backfillSource(ctx, name, artist, since, result); err != nil {
+			if ctx.Err() != nil {
+				return result, ctx.Err()
+			}
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (b *HistoryBackfiller) backfillSource(ctx context.Context, name string, artist domain.Artist, since time.Time, result *BackfillResult) error {
+	key := checkpointKey(artist.Name)
+	checkpoint, err := b.config.Checkpoints.Get(ctx, key, name)
+	if errors.Is(err, domain.ErrCheckpointNotFound) {
+		checkpoint = &domain.BackfillCheckpoint{Artist: key, Source: name}
+	} else if err != nil {
+		return err
+	}
+
+	if checkpoint.Complete || (checkpoint.OldestConcert != nil && checkpoint.OldestConcert.Before(since)) {
+		result.Skipped = append(result.Skipped, name)
+		return nil
+	}
+
+	source := b.config.Sources[name]
+	for page := checkpoint.Page + 1; ; page++ {
+		if page > 1 {
+			if err := b.sleep(ctx, b.config.PageDelay); err != nil {
+				return err
+			}
+		}
+
+		history, err := b.fetchPage(ctx, source, artist, page)
+		if err != nil {
+			return fmt.Errorf("failed to get page %d: %w", page, err)
+		}
+
+		keep := make([]domain.PastConcert, 0, len(history.Concerts))
+		reachedSince := false
+		for _, concert := range history.Concerts {
+			if concert.DateTime.IsZero() {
+				continue
+			}
+			if checkpoint.OldestConcert == nil || concert.DateTime.Before(*checkpoint.OldestConcert) {
+				oldest := concert.DateTime
+				checkpoint.OldestConcert = &oldest
+			}
+			if concert.DateTime.Before(since) {
+				reachedSince = true
+				continue
+			}
+			if artist.ID != "" {
+				concert.ArtistID = artist.ID
+			}
+			keep = append(keep, concert)
+		}
+
+		if err := b.config.Concerts.SaveBatch(ctx, keep); err != nil {
+			return err
+		}
+		result.Stored += len(keep)
+		result.Pages++
+
+		// A page that ran past the date has older concerts that weren't
+		// stored, so a run further back starts on it again
+		checkpoint.Page = page
+		if reachedSince {
+			checkpoint.Page = page - 1
+		}
+		checkpoint.Complete = !history.HasMore || len(history.Concerts) == 0
+		checkpoint.UpdatedAt = time.Now()
+		if err := b.config.Checkpoints.Save(ctx, checkpoint); err != nil {
+			return err
+		}
+
+		b.config.Logger.Info("backfilled page", "artist", artist.Name, "source", name, "page", page, "stored", len(keep))
+		if checkpoint.Complete || reachedSince {
+			return nil
+		}
+	}
+}
+
+// fetchPage waits out a rate limited source, doubling the wait each time,
+// rather than abandoning a walk that may be hours in
+func (b *HistoryBackfiller) fetchPage(ctx context.Context, source integrations.HistorySource, artist domain.Artist, page int) (*domain.ConcertHistoryPage, error) {
+	backoff := b.config.Backoff
+	for attempt := 0; ; attempt++ {
+		history, err := source.ArtistHistory(ctx, artist, page)
+		if !errors.Is(err, domain.ErrRateLimitExceeded) || attempt == backfillRateLimitRetries {
+			return history, err
+		}
+
+		b.config.Logger.Warn("rate limited, backing off", "source", source.GetName(), "wait", backoff)
+		if err := b.sleep(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// checkpointKey is the artist as checkpoints are kept, so the same artist
+// typed differently in two artist files shares its progress
+func checkpointKey(artistName string) string {
+	return strings.ToLower(strings.TrimSpace(artistName))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
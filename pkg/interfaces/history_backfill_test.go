@@ -0,0 +1,166 @@
+package interfaces
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// pagedHistorySource has one concert a month, newest first, three to a page
+type pagedHistorySource struct {
+	newest      time.Time
+	total       int
+	requests    []int
+	rateLimited int
+	failPage    int
+}
+
+func (s *pagedHistorySource) GetName() string { return "setlistfm" }
+
+func (s *pagedHistorySource) ArtistHistory(ctx context.Context, artist domain.Artist, page int) (*domain.ConcertHistoryPage, error) {
+	s.requests = append(s.requests, page)
+	if s.rateLimited > 0 {
+		s.rateLimited--
+		return nil, domain.ErrRateLimitExceeded
+	}
+	if page == s.failPage {
+		return nil, fmt.Errorf("status 500")
+	}
+
+	result := &domain.ConcertHistoryPage{Page: page, Total: s.total, HasMore: page*3 < s.total}
+	for i := (page - 1) * 3; i < page*3 && i < s.total; i++ {
+		result.Concerts = append(result.Concerts, domain.PastConcert{
+			Event:  domain.Event{ID: fmt.Sprintf("setlistfm_%d", i), ArtistName: artist.Name, DateTime: s.newest.AddDate(0, -i, 0)},
+			Source: "setlistfm",
+		})
+	}
+	return result, nil
+}
+
+type memoryPastConcerts map[string]domain.PastConcert
+
+func (m memoryPastConcerts) SaveBatch(ctx context.Context, concerts []domain.PastConcert) error {
+	for _, concert := range concerts {
+		m[concert.ID] = concert
+	}
+	return nil
+}
+
+func (m memoryPastConcerts) ListByArtist(ctx context.Context, artistName string) ([]domain.PastConcert, error) {
+	return nil, nil
+}
+
+type memoryCheckpoints map[string]domain.BackfillCheckpoint
+
+func (m memoryCheckpoints) Get(ctx context.Context, artist, source string) (*domain.BackfillCheckpoint, error) {
+	checkpoint, ok := m[artist+"/"+source]
+	if !ok {
+		return nil, domain.ErrCheckpointNotFound
+	}
+	return &checkpoint, nil
+}
+
+func (m memoryCheckpoints) Save(ctx context.Context, checkpoint *domain.BackfillCheckpoint) error {
+	m[checkpoint.Artist+"/"+checkpoint.Source] = *checkpoint
+	return nil
+}
+
+func TestHistoryBackfiller(t *testing.T) {
+	ctx := context.Background()
+	newest := time.Date(2026, 2, 1, 20, 0, 0, 0, time.UTC)
+	artist := domain.Artist{ID: "artist-1", Name: "Radiohead"}
+
+	newBackfiller := func(source *pagedHistorySource, concerts memoryPastConcerts, checkpoints memoryCheckpoints) (*HistoryBackfiller, *[]time.Duration) {
+		backfiller := NewHistoryBackfiller(HistoryBackfillConfig{
+			Sources:     map[string]integrations.HistorySource{"setlistfm": source},
+			Concerts:    concerts,
+			Checkpoints: checkpoints,
+		})
+		var waits []time.Duration
+		backfiller.sleep = func(ctx context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		}
+		return backfiller, &waits
+	}
+
+	t.Run("walks back to the date", func(t *testing.T) {
+		source := &pagedHistorySource{newest: newest, total: 30}
+		concerts, checkpoints := memoryPastConcerts{}, memoryCheckpoints{}
+		backfiller, waits := newBackfiller(source, concerts, checkpoints)
+
+		// Seven months back is concerts 0 to 7, on pages 1 to 3
+		result, err := backfiller.Backfill(ctx, artist, newest.AddDate(0, -7, 0))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result.Stored != 8 || result.Pages != 3 || len(concerts) != 8 {
+			t.Errorf("expected 8 concerts from 3 pages, got %+v", result)
+		}
+		if concerts["setlistfm_0"].ArtistID != "artist-1" {
+			t.Error("expected the concerts stored under the artist")
+		}
+		if len(*waits) != 2 {
+			t.Errorf("expected a pause between pages, got %v", *waits)
+		}
+		checkpoint := checkpoints["radiohead/setlistfm"]
+		if checkpoint.Page != 2 || checkpoint.Complete || !checkpoint.OldestConcert.Equal(newest.AddDate(0, -8, 0)) {
+			t.Errorf("unexpected checkpoint %+v", checkpoint)
+		}
+
+		// Rerun for the same range: already covered
+		source.requests = nil
+		result, _ = backfiller.Backfill(ctx, artist, newest.AddDate(0, -7, 0))
+		if len(source.requests) != 0 || len(result.Skipped) != 1 {
+			t.Errorf("expected the covered range skipped, got %v", source.requests)
+		}
+
+		// Further back resumes on the page that ran past the date
+		result, err = backfiller.Backfill(ctx, artist, time.Time{})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if source.requests[0] != 3 || len(concerts) != 30 || !checkpoints["radiohead/setlistfm"].Complete {
+			t.Errorf("expected the walk to resume at page 3 and finish, got pages %v and %d concerts", source.requests, len(concerts))
+		}
+	})
+
+	t.Run("resumes after a failure", func(t *testing.T) {
+		source := &pagedHistorySource{newest: newest, total: 12, failPage: 3}
+		concerts, checkpoints := memoryPastConcerts{}, memoryCheckpoints{}
+		backfiller, _ := newBackfiller(source, concerts, checkpoints)
+
+		if _, err := backfiller.Backfill(ctx, artist, time.Time{}); err == nil {
+			t.Fatal("expected the failing page to be reported")
+		}
+		if checkpoints["radiohead/setlistfm"].Page != 2 || len(concerts) != 6 {
+			t.Fatalf("expected the two pages before it kept, got %+v", checkpoints["radiohead/setlistfm"])
+		}
+
+		source.failPage = 0
+		source.requests = nil
+		if _, err := backfiller.Backfill(ctx, artist, time.Time{}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if source.requests[0] != 3 || len(concerts) != 12 {
+			t.Errorf("expected the rerun to start at page 3, got %v", source.requests)
+		}
+	})
+
+	t.Run("backs off when rate limited", func(t *testing.T) {
+		source := &pagedHistorySource{newest: newest, total: 3, rateLimited: 2}
+		backfiller, waits := newBackfiller(source, memoryPastConcerts{}, memoryCheckpoints{})
+
+		result, err := backfiller.Backfill(ctx, artist, time.Time{})
+		if err != nil || result.Stored != 3 {
+			t.Fatalf("expected the page after backing off, got %+v, %v", result, err)
+		}
+		if len(*waits) != 2 || (*waits)[0] != time.Minute || (*waits)[1] != 2*time.Minute {
+			t.Errorf("expected doubling waits, got %v", *waits)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// HistorySearcher looks up concerts an artist has already played
+type HistorySearcher interface {
+	SearchHistory(ctx context.Context, artist domain.Artist, page int) (*integrations.HistoryResults, error)
+}
+
+// HistoryHandler serves the concert archive of stored artists, kept apart
+// from the upcoming event searches
+type HistoryHandler struct {
+	artists domain.ArtistRepository
+	history HistorySearcher
+}
+
+func NewHistoryHandler(artists domain.ArtistRepository, history HistorySearcher) *HistoryHandler {
+	return &HistoryHandler{
+		artists: artists,
+		history: history,
+	}
+}
+
+func (h *HistoryHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/artists/{id}/history", h.GetArtistHistory).Methods("GET")
+}
+
+// GetArtistHistory returns a page (default 1) of the artist's past concerts,
+// newest first, with setlists where the sources have them
+func (h *HistoryHandler) GetArtistHistory(w http.ResponseWriter, r *http.Request) {
+	params := struct {
+		Page int `query:"page" validate:"min=1"`
+	}{Page: 1}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	page := params.Page
+
+	artist, err := h.artists.GetByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "artist not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get artist")
+		return
+	}
+
+	results, err := h.history.SearchHistory(r.Context(), *artist, page)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get concert history")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, results)
+}
+
+func (h *HistoryHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *HistoryHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
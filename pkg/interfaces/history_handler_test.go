@@ -0,0 +1,79 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+type stubHistorySearcher struct {
+	artist domain.Artist
+	page   int
+}
+
+func (s *stubHistorySearcher) SearchHistory(ctx context.Context, artist domain.Artist, page int) (*integrations.HistoryResults, error) {
+	s.artist = artist
+	s.page = page
+	return &integrations.HistoryResults{
+		Concerts: []domain.PastConcert{{
+			Event:  domain.Event{ID: "setlistfm_1", ArtistName: artist.Name, DateTime: time.Date(2017, 7, 8, 0, 0, 0, 0, time.UTC)},
+			Source: "setlistfm",
+			Songs:  []domain.SetlistSong{{Name: "Airbag", SetName: "Main"}},
+		}},
+		Page:    page,
+		HasMore: true,
+	}, nil
+}
+
+func TestHistoryHandler(t *testing.T) {
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != "artist-1" {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &domain.Artist{ID: id, Name: "Radiohead", ExternalIDs: domain.ExternalIDs{MusicBrainzID: "mbid-1"}}, nil
+		},
+	}
+	history := &stubHistorySearcher{}
+
+	router := mux.NewRouter()
+	NewHistoryHandler(artists, history).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/artists/artist-1/history?page=3")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if history.page != 3 || history.artist.ExternalIDs.MusicBrainzID != "mbid-1" {
+		t.Errorf("expected page 3 for the stored artist, got page %d for %+v", history.page, history.artist)
+	}
+
+	var response integrations.HistoryResults
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if len(response.Concerts) != 1 || len(response.Concerts[0].Songs) != 1 || !response.HasMore {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+
+	if rr := get("/api/artists/artist-1/history"); rr.Code != http.StatusOK || history.page != 1 {
+		t.Errorf("expected page 1 by default, got status %d page %d", rr.Code, history.page)
+	}
+	if rr := get("/api/artists/artist-1/history?page=0"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for page 0, got %d", rr.Code)
+	}
+	if rr := get("/api/artists/unknown/history"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown artist, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// JobLookup finds a queued job by its ID
+type JobLookup interface {
+	Get(ctx context.Context, id string) (*domain.Job, error)
+}
+
+type JobHandler struct {
+	jobs JobLookup
+}
+
+func NewJobHandler(jobs JobLookup) *JobHandler {
+	return &JobHandler{jobs: jobs}
+}
+
+func (h *JobHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/jobs/{id}", h.GetJob).Methods("GET")
+}
+
+// GetJob reports a job's status, for clients polling work they started,
+// like an artist profile being built
+func (h *JobHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobs.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrJobNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	// Unfinished jobs change, so pollers mustn't be served a cached answer
+	if !job.Done() {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	h.respondWithJSON(w, http.StatusOK, job)
+}
+
+func (h *JobHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *JobHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,255 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const (
+	defaultJobWorkers     = 2
+	defaultJobMaxAttempts = 5
+	defaultJobTimeout     = 5 * time.Minute
+	defaultJobBackoff     = 30 * time.Second
+	defaultJobPoll        = 2 * time.Second
+	// jobRetention is how long finished jobs can still be polled
+	jobRetention = 7 * 24 * time.Hour
+)
+
+// JobFunc does the work of one kind of job, given the payload it was
+// enqueued with. An error retries the job until it runs out of attempts.
+type JobFunc func(ctx context.Context, payload json.RawMessage) error
+
+// JobEnqueuer hands work to the job queue
+type JobEnqueuer interface {
+	Enqueue(ctx context.Context, kind, key string, payload interface{}) (*domain.Job, error)
+}
+
+type JobQueueConfig struct {
+	Jobs        domain.JobRepository
+	Workers     int
+	MaxAttempts int
+	// Timeout bounds one attempt. A job still running after it plus a
+	// minute is taken to belong to a worker that died, and is run again.
+	Timeout time.Duration
+	// Backoff is the wait before the first retry, doubling with every
+	// attempt after
+	Backoff      time.Duration
+	PollInterval time.Duration
+	Logger       *slog.Logger
+}
+
+// JobQueue runs slow work, like syncing or enriching artists, on a pool of
+// workers instead of in the request that asked for it. Jobs are stored, so
+// they survive a restart, and retried with backoff until they're dead.
+type JobQueue struct {
+	config   JobQueueConfig
+	handlers map[string]JobFunc
+	mutex    sync.RWMutex
+	// wake tells an idle worker a job was just enqueued, so it doesn't wait
+	// out the poll interval
+	wake chan struct{}
+	now  func() time.Time
+}
+
+func NewJobQueue(config JobQueueConfig) *JobQueue {
+	if config.Workers <= 0 {
+		config.Workers = defaultJobWorkers
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultJobMaxAttempts
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultJobTimeout
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = defaultJobBackoff
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaultJobPoll
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &JobQueue{
+		config:   config,
+		handlers: make(map[string]JobFunc),
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+}
+
+// Handle registers the function that runs jobs of kind
+func (q *JobQueue) Handle(kind string, fn JobFunc) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.handlers[kind] = fn
+}
+
+// Enqueue queues a job of kind with payload encoded as JSON. A non-empty key
+// dedupes it: while a job with the same key is queued or running, that job
+// is returned instead.
+func (q *JobQueue) Enqueue(ctx context.Context, kind, key string, payload interface{}) (*domain.Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+	id, err := randomHex(12)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate job ID: %w", err)
+	}
+
+	now := q.now()
+	job, err := q.config.Jobs.Enqueue(ctx, &domain.Job{
+		ID:          "job_" + id,
+		Kind:        kind,
+		Key:         key,
+		Payload:     encoded,
+		MaxAttempts: q.config.MaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+func (q *JobQueue) Get(ctx context.Context, id string) (*domain.Job, error) {
+	return q.config.Jobs.Get(ctx, id)
+}
+
+// Run works the queue with the configured number of workers until ctx is
+// done, and drops jobs finished more than a week ago once an hour
+func (q *JobQueue) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.runWorker(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		q.prune(ctx)
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (q *JobQueue) runWorker(ctx context.Context) {
+	for {
+		if q.work(ctx) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-time.After(q.config.PollInterval):
+		}
+	}
+}
+
+// work claims and runs one due job, reporting whether there was one
+func (q *JobQueue) work(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	job, err := q.config.Jobs.Claim(ctx, q.now(), q.config.Timeout+time.Minute)
+	if err != nil {
+		q.config.Logger.Warn("failed to claim job", "error", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+	logger := q.config.Logger.With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+
+	// Claimed again after its worker died on the last attempt
+	if job.Attempts > job.MaxAttempts {
+		q.bury(ctx, logger, job, "worker stopped before the job finished")
+		return true
+	}
+
+	q.mutex.RLock()
+	handler, ok := q.handlers[job.Kind]
+	q.mutex.RUnlock()
+	if !ok {
+		q.bury(ctx, logger, job, "no handler for job kind "+job.Kind)
+		return true
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, q.config.Timeout)
+	err = q.runHandler(jobCtx, handler, job.Payload)
+	cancel()
+
+	// Record the outcome even while shutting down, so the job isn't run
+	// again once its lease expires
+	recordCtx, cancelRecord := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancelRecord()
+
+	switch {
+	case err == nil:
+		if err := q.config.Jobs.Complete(recordCtx, job.ID, q.now()); err != nil {
+			logger.Warn("failed to complete job", "error", err)
+		}
+	case job.Attempts >= job.MaxAttempts:
+		q.bury(recordCtx, logger, job, err.Error())
+	default:
+		retryAt := q.now().Add(q.config.Backoff << (job.Attempts - 1))
+		logger.Warn("job failed, retrying", "error", err, "retry_at", retryAt)
+		if err := q.config.Jobs.Retry(recordCtx, job.ID, err.Error(), retryAt); err != nil {
+			logger.Warn("failed to retry job", "error", err)
+		}
+	}
+	return true
+}
+
+// runHandler turns a panicking job into a failed one, so it can't take the
+// worker down with it
+func (q *JobQueue) runHandler(ctx context.Context, handler JobFunc, payload json.RawMessage) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+	return handler(ctx, payload)
+}
+
+func (q *JobQueue) bury(ctx context.Context, logger *slog.Logger, job *domain.Job, message string) {
+	logger.Error("job is dead", "error", message)
+	if err := q.config.Jobs.Bury(ctx, job.ID, message, q.now()); err != nil {
+		logger.Warn("failed to bury job", "error", err)
+	}
+}
+
+func (q *JobQueue) prune(ctx context.Context) {
+	deleted, err := q.config.Jobs.DeleteFinishedBefore(ctx, q.now().Add(-jobRetention))
+	if err != nil {
+		q.config.Logger.Warn("failed to prune finished jobs", "error", err)
+		return
+	}
+	if deleted > 0 {
+		q.config.Logger.Info("pruned finished jobs", "count", deleted)
+	}
+}
@@ -0,0 +1,251 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type memoryJobRepository struct {
+	mu     sync.Mutex
+	jobs   map[string]*domain.Job
+	leases map[string]time.Time
+}
+
+func newMemoryJobRepository() *memoryJobRepository {
+	return &memoryJobRepository{jobs: map[string]*domain.Job{}, leases: map[string]time.Time{}}
+}
+
+func (m *memoryJobRepository) Enqueue(ctx context.Context, job *domain.Job) (*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, existing := range m.jobs {
+		if job.Key != "" && existing.Key == job.Key && !existing.Done() {
+			stored := *existing
+			return &stored, nil
+		}
+	}
+	stored := *job
+	stored.Status = domain.JobQueued
+	stored.UpdatedAt = job.CreatedAt
+	m.jobs[job.ID] = &stored
+	copied := stored
+	return &copied, nil
+}
+
+func (m *memoryJobRepository) Get(ctx context.Context, id string) (*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return nil, domain.ErrJobNotFound
+	}
+	copied := *job
+	return &copied, nil
+}
+
+func (m *memoryJobRepository) Claim(ctx context.Context, now time.Time, lease time.Duration) (*domain.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var due []*domain.Job
+	for _, job := range m.jobs {
+		if (job.Status == domain.JobQueued && !job.RunAt.After(now)) || (job.Status == domain.JobRunning && !m.leases[job.ID].After(now)) {
+			due = append(due, job)
+		}
+	}
+	if len(due) == 0 {
+		return nil, nil
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].RunAt.Before(due[j].RunAt) })
+	job := due[0]
+	job.Status = domain.JobRunning
+	job.Attempts++
+	m.leases[job.ID] = now.Add(lease)
+	copied := *job
+	return &copied, nil
+}
+
+func (m *memoryJobRepository) Complete(ctx context.Context, id string, finishedAt time.Time) error {
+	return m.update(id, func(job *domain.Job) {
+		job.Status = domain.JobSucceeded
+		job.FinishedAt = &finishedAt
+	})
+}
+
+func (m *memoryJobRepository) Retry(ctx context.Context, id, message string, runAt time.Time) error {
+	return m.update(id, func(job *domain.Job) {
+		job.Status = domain.JobQueued
+		job.LastError = message
+		job.RunAt = runAt
+	})
+}
+
+func (m *memoryJobRepository) Bury(ctx context.Context, id, message string, finishedAt time.Time) error {
+	return m.update(id, func(job *domain.Job) {
+		job.Status = domain.JobDead
+		job.LastError = message
+		job.FinishedAt = &finishedAt
+	})
+}
+
+func (m *memoryJobRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var deleted int64
+	for id, job := range m.jobs {
+		if job.Done() && job.FinishedAt.Before(before) {
+			delete(m.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (m *memoryJobRepository) update(id string, fn func(*domain.Job)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[id]
+	if !ok {
+		return domain.ErrJobNotFound
+	}
+	fn(job)
+	delete(m.leases, id)
+	return nil
+}
+
+func TestJobQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	queue := NewJobQueue(JobQueueConfig{Jobs: newMemoryJobRepository(), MaxAttempts: 3, Backoff: time.Minute})
+	queue.now = func() time.Time { return now }
+
+	var runs []string
+	queue.Handle("echo", func(ctx context.Context, payload json.RawMessage) error {
+		var name string
+		json.Unmarshal(payload, &name)
+		runs = append(runs, name)
+		if name == "flaky" && len(runs) < 3 {
+			return errors.New("upstream timeout")
+		}
+		if name == "broken" {
+			panic("nil profile")
+		}
+		return nil
+	})
+
+	t.Run("runs a job", func(t *testing.T) {
+		job, err := queue.Enqueue(ctx, "echo", "", "ok")
+		if err != nil {
+			t.Fatalf("failed to enqueue: %v", err)
+		}
+		if !queue.work(ctx) {
+			t.Fatal("expected a job to run")
+		}
+		if job, _ = queue.Get(ctx, job.ID); job.Status != domain.JobSucceeded || runs[0] != "ok" {
+			t.Errorf("expected the job to succeed, got %+v after %v", job, runs)
+		}
+		if queue.work(ctx) {
+			t.Error("expected the queue to be empty")
+		}
+	})
+
+	t.Run("dedupes by key", func(t *testing.T) {
+		first, _ := queue.Enqueue(ctx, "echo", "profile:a", "dedupe")
+		second, _ := queue.Enqueue(ctx, "echo", "profile:a", "dedupe")
+		if first.ID != second.ID {
+			t.Errorf("expected the queued job back, got %s and %s", first.ID, second.ID)
+		}
+		queue.work(ctx)
+	})
+
+	t.Run("retries with backoff", func(t *testing.T) {
+		runs = nil
+		job, _ := queue.Enqueue(ctx, "echo", "", "flaky")
+
+		queue.work(ctx)
+		job, _ = queue.Get(ctx, job.ID)
+		if job.Status != domain.JobQueued || job.LastError != "upstream timeout" || !job.RunAt.Equal(now.Add(time.Minute)) {
+			t.Fatalf("expected a retry in a minute, got %+v", job)
+		}
+		if queue.work(ctx) {
+			t.Fatal("expected the retry to wait for its backoff")
+		}
+
+		now = now.Add(time.Minute)
+		queue.work(ctx)
+		if job, _ = queue.Get(ctx, job.ID); !job.RunAt.Equal(now.Add(2 * time.Minute)) {
+			t.Fatalf("expected the backoff to double, got %+v", job)
+		}
+
+		now = now.Add(2 * time.Minute)
+		queue.work(ctx)
+		if job, _ = queue.Get(ctx, job.ID); job.Status != domain.JobSucceeded || job.Attempts != 3 {
+			t.Errorf("expected the third attempt to succeed, got %+v", job)
+		}
+	})
+
+	t.Run("dead after the last attempt", func(t *testing.T) {
+		job, _ := queue.Enqueue(ctx, "echo", "", "broken")
+		for i := 0; i < 3; i++ {
+			now = now.Add(time.Hour)
+			queue.work(ctx)
+		}
+		if job, _ = queue.Get(ctx, job.ID); job.Status != domain.JobDead || job.LastError != "job panicked: nil profile" {
+			t.Errorf("expected the panicking job dead, got %+v", job)
+		}
+	})
+
+	t.Run("unknown kind", func(t *testing.T) {
+		job, _ := queue.Enqueue(ctx, "resize_image", "", nil)
+		queue.work(ctx)
+		if job, _ = queue.Get(ctx, job.ID); job.Status != domain.JobDead {
+			t.Errorf("expected a job nobody handles dead, got %+v", job)
+		}
+	})
+}
+
+func TestJobHandler(t *testing.T) {
+	jobs := newMemoryJobRepository()
+	queue := NewJobQueue(JobQueueConfig{Jobs: jobs})
+	job, err := queue.Enqueue(context.Background(), JobBuildProfile, "", buildProfilePayload{ArtistID: "artist-1"})
+	if err != nil {
+		t.Fatalf("failed to enqueue: %v", err)
+	}
+
+	router := mux.NewRouter()
+	NewJobHandler(queue).RegisterRoutes(router)
+
+	req := httptest.NewRequest("GET", "/api/jobs/"+job.ID, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var got map[string]interface{}
+	json.NewDecoder(rr.Body).Decode(&got)
+	if got["id"] != job.ID || got["status"] != "queued" || got["kind"] != JobBuildProfile {
+		t.Errorf("unexpected job %v", got)
+	}
+	if _, ok := got["payload"]; ok {
+		t.Error("expected the payload to stay private")
+	}
+	if rr.Header().Get("Cache-Control") != "no-store" {
+		t.Error("expected an unfinished job not to be cached")
+	}
+
+	req = httptest.NewRequest("GET", "/api/jobs/job_missing", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// LastFMImportHandler tracks a Last.fm user's most played artists and
+// follows them for the signed-in user who started the import
+type LastFMImportHandler struct {
+	auth    *AuthService
+	service *LastFMImportService
+}
+
+func NewLastFMImportHandler(auth *AuthService, service *LastFMImportService) *LastFMImportHandler {
+	return &LastFMImportHandler{
+		auth:    auth,
+		service: service,
+	}
+}
+
+func (h *LastFMImportHandler) RegisterRoutes(router *mux.Router) {
+	router.Handle("/api/import/lastfm", RequireUser(h.auth)(http.HandlerFunc(h.ImportTopArtists))).Methods("POST")
+}
+
+func (h *LastFMImportHandler) ImportTopArtists(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	params := struct {
+		Username string `query:"username" validate:"required"`
+		Period   string `query:"period"`
+		Limit    int    `query:"limit" validate:"min=1,max=200"`
+	}{Limit: 50}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	userID, _ := UserIDFromContext(r.Context())
+	result, err := h.service.ImportTopArtists(ctx, userID, params.Username, params.Period, params.Limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidRequest):
+			h.respondWithError(w, http.StatusBadRequest, "invalid username or period")
+		case errors.Is(err, domain.ErrRateLimitExceeded):
+			h.respondWithError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		default:
+			h.respondWithError(w, http.StatusBadGateway, "failed to import last.fm history")
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+func (h *LastFMImportHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *LastFMImportHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, err := json.Marshal(payload)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
@@ -0,0 +1,67 @@
+package interfaces
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// LastFMHistoryClient is the part of the Last.fm client used to import a
+// user's listening history
+type LastFMHistoryClient interface {
+	GetUserTopArtists(ctx context.Context, username, period string, limit int) ([]domain.Artist, error)
+}
+
+type LastFMImportService struct {
+	client           LastFMHistoryClient
+	artistRepository domain.ArtistRepository
+	trackedArtists   domain.TrackedArtistRepository
+	follows          domain.FollowRepository
+}
+
+func NewLastFMImportService(
+	client LastFMHistoryClient,
+	artistRepository domain.ArtistRepository,
+	trackedArtists domain.TrackedArtistRepository,
+	follows domain.FollowRepository,
+) *LastFMImportService {
+	return &LastFMImportService{
+		client:           client,
+		artistRepository: artistRepository,
+		trackedArtists:   trackedArtists,
+		follows:          follows,
+	}
+}
+
+// ImportTopArtists tracks a Last.fm user's most played artists and follows
+// them for userID. Listening history is public, so no authorization is
+// needed from the Last.fm user.
+func (s *LastFMImportService) ImportTopArtists(ctx context.Context, userID, username, period string, limit int) (*LibraryImportResult, error) {
+	username = strings.TrimSpace(username)
+	if userID == "" || username == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	artists, err := s.client.GetUserTopArtists(ctx, username, period, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last.fm top artists: %w", err)
+	}
+
+	result, err := trackImportedArtists(ctx, s.artistRepository, s.trackedArtists, artists, "lastfm", "lastfm:"+username)
+	if err != nil {
+		return nil, err
+	}
+	result.AccountID = username
+
+	for _, artist := range result.Artists {
+		follow := &domain.Follow{UserID: userID, ArtistID: artist.ID, ArtistName: artist.Name}
+		if err := s.follows.Follow(ctx, follow); err != nil {
+			return nil, fmt.Errorf("failed to follow %s: %w", artist.Name, err)
+		}
+		result.Followed++
+	}
+
+	return result, nil
+}
@@ -0,0 +1,175 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type mockLastFMHistoryClient struct {
+	artists []domain.Artist
+	err     error
+	period  string
+}
+
+func (m *mockLastFMHistoryClient) GetUserTopArtists(ctx context.Context, username, period string, limit int) ([]domain.Artist, error) {
+	m.period = period
+	if m.err != nil {
+		return nil, m.err
+	}
+	return m.artists, nil
+}
+
+func newTestLastFMImportService(client *mockLastFMHistoryClient) (*LastFMImportService, *mockTrackedArtistRepository, *memoryFollowRepository) {
+	stored := map[string]domain.Artist{
+		"known-mbid": {ID: "local_1", Name: "Known", ExternalIDs: domain.ExternalIDs{LastFMID: "known-mbid"}},
+	}
+	artists := &mockRepository{
+		getByExternalIDFunc: func(ctx context.Context, externalID string, source string) (*domain.Artist, error) {
+			if source != "lastfm" {
+				return nil, errors.New("unexpected source " + source)
+			}
+			artist, exists := stored[externalID]
+			if !exists {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &artist, nil
+		},
+		createFunc: func(ctx context.Context, artist *domain.Artist) error {
+			stored[artist.ExternalIDs.LastFMID] = *artist
+			return nil
+		},
+	}
+
+	tracked := &mockTrackedArtistRepository{}
+	follows := &memoryFollowRepository{}
+	return NewLastFMImportService(client, artists, tracked, follows), tracked, follows
+}
+
+func TestLastFMImportService_ImportTopArtists(t *testing.T) {
+	t.Run("stores new artists and tracks all", func(t *testing.T) {
+		client := &mockLastFMHistoryClient{
+			artists: []domain.Artist{
+				{ID: "lastfm_known-mbid", Name: "Known", ExternalIDs: domain.ExternalIDs{LastFMID: "known-mbid"}},
+				{ID: "lastfm_new-mbid", Name: "New", ExternalIDs: domain.ExternalIDs{LastFMID: "new-mbid"}},
+				{ID: "lastfm_new-mbid", Name: "New", ExternalIDs: domain.ExternalIDs{LastFMID: "new-mbid"}},
+			},
+		}
+		service, tracked, follows := newTestLastFMImportService(client)
+
+		result, err := service.ImportTopArtists(context.Background(), "user_1", "listener", "3month", 50)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if result.Imported != 1 || result.Tracked != 2 || result.Followed != 2 {
+			t.Errorf("expected 1 imported, 2 tracked and 2 followed, got %d, %d and %d", result.Imported, result.Tracked, result.Followed)
+		}
+		if tracked.tracked["local_1"] != "lastfm:listener" {
+			t.Errorf("expected known artist tracked for listener, got %v", tracked.tracked)
+		}
+		followed, _ := follows.ListFollows(context.Background(), "user_1")
+		if len(followed) != 2 || followed[0].ArtistID != "local_1" || followed[1].ArtistName != "New" {
+			t.Errorf("expected both artists followed for the user, got %+v", followed)
+		}
+		if client.period != "3month" {
+			t.Errorf("expected period 3month to be passed through, got %s", client.period)
+		}
+	})
+
+	t.Run("empty username", func(t *testing.T) {
+		service, _, _ := newTestLastFMImportService(&mockLastFMHistoryClient{})
+
+		if _, err := service.ImportTopArtists(context.Background(), "user_1", " ", "", 50); err != domain.ErrInvalidRequest {
+			t.Errorf("expected ErrInvalidRequest, got %v", err)
+		}
+	})
+
+	t.Run("no user", func(t *testing.T) {
+		service, _, _ := newTestLastFMImportService(&mockLastFMHistoryClient{})
+
+		if _, err := service.ImportTopArtists(context.Background(), "", "listener", "", 50); err != domain.ErrInvalidRequest {
+			t.Errorf("expected ErrInvalidRequest, got %v", err)
+		}
+	})
+}
+
+func TestLastFMImportHandler(t *testing.T) {
+	auth := newTestAuthService(newMemoryUserRepository())
+	registered, err := auth.Register(context.Background(), "kim@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		clientErr      error
+		expectedStatus int
+	}{
+		{"success", "?username=listener", nil, http.StatusOK},
+		{"missing username", "", nil, http.StatusBadRequest},
+		{"invalid limit", "?username=listener&limit=abc", nil, http.StatusBadRequest},
+		{"limit too large", "?username=listener&limit=1000", nil, http.StatusBadRequest},
+		{"unknown user", "?username=nobody", domain.ErrInvalidRequest, http.StatusBadRequest},
+		{"rate limited", "?username=listener", domain.ErrRateLimitExceeded, http.StatusTooManyRequests},
+		{"upstream failure", "?username=listener", errors.New("status 500"), http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _ := newTestLastFMImportService(&mockLastFMHistoryClient{err: tt.clientErr})
+			router := mux.NewRouter()
+			NewLastFMImportHandler(auth, service).RegisterRoutes(router)
+
+			req, _ := http.NewRequest("POST", "/api/import/lastfm"+tt.query, nil)
+			req.Header.Set("Authorization", "Bearer "+registered.AccessToken)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+		})
+	}
+
+	t.Run("follows for the signed-in user", func(t *testing.T) {
+		service, _, follows := newTestLastFMImportService(&mockLastFMHistoryClient{
+			artists: []domain.Artist{{ID: "lastfm_new-mbid", Name: "New", ExternalIDs: domain.ExternalIDs{LastFMID: "new-mbid"}}},
+		})
+		router := mux.NewRouter()
+		NewLastFMImportHandler(auth, service).RegisterRoutes(router)
+
+		req, _ := http.NewRequest("POST", "/api/import/lastfm?username=listener", nil)
+		req.Header.Set("Authorization", "Bearer "+registered.AccessToken)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		followed, _ := follows.ListFollows(context.Background(), registered.User.ID)
+		if len(followed) != 1 || followed[0].ArtistName != "New" {
+			t.Errorf("expected the import followed for the user, got %+v", followed)
+		}
+	})
+
+	t.Run("requires a user", func(t *testing.T) {
+		service, _, _ := newTestLastFMImportService(&mockLastFMHistoryClient{})
+		router := mux.NewRouter()
+		NewLastFMImportHandler(auth, service).RegisterRoutes(router)
+
+		req, _ := http.NewRequest("POST", "/api/import/lastfm?username=listener", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type LibraryImportResult struct {
+	AccountID string          `json:"account_id"`
+	Imported  int             `json:"imported"`
+	Tracked   int             `json:"tracked"`
+	Followed  int             `json:"followed,omitempty"`
+	Artists   []domain.Artist `json:"artists"`
+}
+
+// trackImportedArtists stores any artists we haven't seen before and enrolls
+// all of them in event syncing. externalSource selects which external ID the
+// artists are matched on.
+func trackImportedArtists(
+	ctx context.Context,
+	artistRepository domain.ArtistRepository,
+	trackedArtists domain.TrackedArtistRepository,
+	artists []domain.Artist,
+	externalSource string,
+	trackSource string,
+) (*LibraryImportResult, error) {
+	result := &LibraryImportResult{
+		Artists: []domain.Artist{},
+	}
+
+	seen := make(map[string]bool)
+	for _, artist := range artists {
+		externalID := externalIDFor(artist, externalSource)
+		if externalID == "" || seen[externalID] {
+			continue
+		}
+		seen[externalID] = true
+
+		stored, err := artistRepository.GetByExternalID(ctx, externalID, externalSource)
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			if err := artistRepository.Create(ctx, &artist); err != nil {
+				return nil, fmt.Errorf("failed to store artist %s: %w", artist.Name, err)
+			}
+			stored = &artist
+			result.Imported++
+		} else if err != nil {
+			return nil, err
+		}
+
+		if err := trackedArtists.Track(ctx, stored.ID, trackSource); err != nil {
+			return nil, err
+		}
+
+		result.Tracked++
+		result.Artists = append(result.Artists, *stored)
+	}
+
+	return result, nil
+}
+
+func externalIDFor(artist domain.Artist, source string) string {
+	switch source {
+	case "spotify":
+		return artist.ExternalIDs.SpotifyID
+	case "lastfm":
+		return artist.ExternalIDs.LastFMID
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,204 @@
+package interfaces
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/notifications"
+)
+
+const (
+	// maxLiveTopics caps the subscriptions of one connection
+	maxLiveTopics = 50
+	// maxLiveMessage is the largest client message read; subscription
+	// messages are tiny
+	maxLiveMessage = 4096
+	// livePingInterval keeps idle connections open through proxies
+	livePingInterval = 30 * time.Second
+)
+
+// LiveHandler pushes newly discovered events and on-sale alerts to
+// WebSocket clients for the topics they subscribe to
+type LiveHandler struct {
+	hub    *notifications.Hub
+	logger *slog.Logger
+}
+
+func NewLiveHandler(hub *notifications.Hub, logger *slog.Logger) *LiveHandler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &LiveHandler{
+		hub:    hub,
+		logger: logger,
+	}
+}
+
+func (h *LiveHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/ws", h.Serve).Methods("GET")
+}
+
+// LiveRequest is what clients send, e.g. {"subscribe":"artist:radiohead"}
+type LiveRequest struct {
+	Subscribe   string `json:"subscribe,omitempty"`
+	Unsubscribe string `json:"unsubscribe,omitempty"`
+}
+
+// LiveReply acknowledges a request. Pushes are sent as notifications.Push.
+type LiveReply struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// liveConn serializes writes: every frame is built first and written whole,
+// so pushes, replies and pongs never interleave
+type liveConn struct {
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func (c *liveConn) writeFrame(op ws.OpCode, payload []byte) error {
+	var frame bytes.Buffer
+	if err := wsutil.WriteServerMessage(&frame, op, payload); err != nil {
+		return err
+	}
+	return c.write(frame.Bytes())
+}
+
+func (c *liveConn) write(frame []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+func (c *liveConn) send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeFrame(ws.OpText, data)
+}
+
+// Serve upgrades the request and runs the connection until either side
+// closes it
+func (h *LiveHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	conn, _, _, err := ws.UpgradeHTTP(r, w)
+	if err != nil {
+		// The upgrader has already answered with the reason
+		return
+	}
+	defer conn.Close()
+
+	c := &liveConn{conn: conn}
+	sub := h.hub.Subscribe()
+	defer sub.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go h.push(c, sub, done)
+
+	var control bytes.Buffer
+	handleControl := wsutil.ControlFrameHandler(&control, ws.StateServerSide)
+	reader := &wsutil.Reader{
+		Source:         conn,
+		State:          ws.StateServerSide,
+		CheckUTF8:      true,
+		OnIntermediate: handleControl,
+	}
+
+	for {
+		header, err := reader.NextFrame()
+		if err != nil {
+			return
+		}
+
+		if header.OpCode.IsControl() {
+			err := handleControl(header, reader)
+			if control.Len() > 0 {
+				c.write(control.Bytes())
+				control.Reset()
+			}
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(reader, maxLiveMessage+1))
+		if err != nil {
+			return
+		}
+		if len(data) > maxLiveMessage {
+			reader.Discard()
+			c.send(LiveReply{Type: "error", Error: "message too large"})
+			continue
+		}
+
+		if err := c.send(h.handle(sub, data)); err != nil {
+			return
+		}
+	}
+}
+
+func (h *LiveHandler) handle(sub *notifications.Subscription, data []byte) LiveReply {
+	var req LiveRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return LiveReply{Type: "error", Error: "invalid message"}
+	}
+
+	switch {
+	case req.Subscribe != "":
+		if !notifications.ValidTopic(req.Subscribe) {
+			return LiveReply{Type: "error", Topic: req.Subscribe, Error: "unknown topic, expected artist:<name> in lower case"}
+		}
+		if sub.Topics() >= maxLiveTopics {
+			return LiveReply{Type: "error", Topic: req.Subscribe, Error: "too many subscriptions"}
+		}
+		sub.Add(req.Subscribe)
+		return LiveReply{Type: "subscribed", Topic: req.Subscribe}
+	case req.Unsubscribe != "":
+		sub.Remove(req.Unsubscribe)
+		return LiveReply{Type: "unsubscribed", Topic: req.Unsubscribe}
+	default:
+		return LiveReply{Type: "error", Error: "subscribe or unsubscribe is required"}
+	}
+}
+
+// push forwards the subscription's pushes and pings the client until done
+func (h *LiveHandler) push(c *liveConn, sub *notifications.Subscription, done <-chan struct{}) {
+	ticker := time.NewTicker(livePingInterval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case <-done:
+			return
+		case push, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			err = c.send(push)
+		case <-ticker.C:
+			err = c.writeFrame(ws.OpPing, nil)
+		}
+		if err != nil {
+			h.logger.Debug("live connection write failed", "error", err)
+			// Unblocks the read loop
+			c.conn.Close()
+			return
+		}
+	}
+}
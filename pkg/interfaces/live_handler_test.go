@@ -0,0 +1,123 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsutil"
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/logging"
+	"github.com/yair/where-its-at/pkg/notifications"
+)
+
+func TestLiveHandler(t *testing.T) {
+	hub := notifications.NewHub(nil)
+	router := mux.NewRouter()
+	NewLiveHandler(hub, nil).RegisterRoutes(router)
+	// Upgrades have to make it through the middlewares' response writers
+	router.Use(RequestLogging(logging.New(io.Discard, "text", "error")), Compression())
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, _, err := ws.Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http")+"/ws")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	send := func(message string) {
+		t.Helper()
+		if err := wsutil.WriteClientText(conn, []byte(message)); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+	}
+	receive := func(v interface{}) {
+		t.Helper()
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		data, err := wsutil.ReadServerText(conn)
+		if err != nil {
+			t.Fatalf("failed to receive: %v", err)
+		}
+		if err := json.Unmarshal(data, v); err != nil {
+			t.Fatalf("failed to decode %s: %v", data, err)
+		}
+	}
+
+	send(`{"subscribe":"venue:berghain"}`)
+	var reply LiveReply
+	receive(&reply)
+	if reply.Type != "error" {
+		t.Errorf("expected an error for an unknown topic, got %+v", reply)
+	}
+
+	send(`{"subscribe":"artist:radiohead"}`)
+	receive(&reply)
+	if reply.Type != "subscribed" || reply.Topic != "artist:radiohead" {
+		t.Fatalf("expected the subscription acknowledged, got %+v", reply)
+	}
+
+	hub.Publish(notifications.PushNewEvent, domain.Event{ID: "other", ArtistName: "Bicep"})
+	hub.Publish(notifications.PushNewEvent, domain.Event{ID: "e1", ArtistName: "Radiohead"})
+	var push notifications.Push
+	receive(&push)
+	if push.Type != notifications.PushNewEvent || push.Event.ID != "e1" {
+		t.Errorf("expected Radiohead's event, got %+v", push)
+	}
+
+	send(`{"unsubscribe":"artist:radiohead"}`)
+	receive(&reply)
+	if reply.Type != "unsubscribed" {
+		t.Errorf("expected the unsubscription acknowledged, got %+v", reply)
+	}
+	if delivered := hub.Publish(notifications.PushNewEvent, domain.Event{ID: "e2", ArtistName: "Radiohead"}); delivered != 0 {
+		t.Errorf("expected no subscribers after unsubscribing, got %d", delivered)
+	}
+
+	// Pings are answered by the server while it waits for messages
+	if err := wsutil.WriteClientMessage(conn, ws.OpPing, []byte("hi")); err != nil {
+		t.Fatalf("failed to ping: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	frame, err := ws.ReadFrame(conn)
+	if err != nil || frame.Header.OpCode != ws.OpPong {
+		t.Errorf("expected a pong, got %v %v", frame.Header.OpCode, err)
+	}
+}
+
+func TestLiveHandler_ClosesSubscriptions(t *testing.T) {
+	hub := notifications.NewHub(nil)
+	router := mux.NewRouter()
+	NewLiveHandler(hub, nil).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	conn, _, _, err := ws.Dial(context.Background(), "ws"+strings.TrimPrefix(server.URL, "http")+"/ws")
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	wsutil.WriteClientText(conn, []byte(`{"subscribe":"artist:radiohead"}`))
+	wsutil.ReadServerText(conn)
+	closeConn(conn)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for hub.Publish(notifications.PushNewEvent, domain.Event{ArtistName: "Radiohead"}) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the subscription to end with the connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func closeConn(conn net.Conn) {
+	wsutil.WriteClientMessage(conn, ws.OpClose, ws.NewCloseFrameBody(ws.StatusNormalClosure, ""))
+	conn.Close()
+}
@@ -0,0 +1,137 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// localArtistEventLimit is how many upcoming shows each local artist lists
+const localArtistEventLimit = 5
+
+// AreaArtistSource finds artists who formed in or are based in an area
+type AreaArtistSource interface {
+	ArtistsFromArea(ctx context.Context, area string, limit int) ([]domain.LocalArtist, error)
+}
+
+// LocalArtistHandler discovers artists from a city, with what's stored about
+// them and their upcoming shows
+type LocalArtistHandler struct {
+	areas   AreaArtistSource
+	artists domain.ArtistRepository
+	events  domain.EventRepository
+	now     func() time.Time
+}
+
+func NewLocalArtistHandler(areas AreaArtistSource, artists domain.ArtistRepository, events domain.EventRepository) *LocalArtistHandler {
+	return &LocalArtistHandler{
+		areas:   areas,
+		artists: artists,
+		events:  events,
+		now:     time.Now,
+	}
+}
+
+func (h *LocalArtistHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/discover/artists", h.ListLocalArtists).Methods("GET")
+}
+
+type LocalArtistsResponse struct {
+	City    string               `json:"city"`
+	Artists []domain.LocalArtist `json:"artists"`
+}
+
+// ListLocalArtists returns up to `limit` (default 25, at most 100) artists
+// from the city, those with upcoming shows first and then the most popular.
+// Artists already stored carry their stored ID and popularity.
+func (h *LocalArtistHandler) ListLocalArtists(w http.ResponseWriter, r *http.Request) {
+	params := struct {
+		City  string `query:"city" validate:"required"`
+		Limit int    `query:"limit" limit:"25,100"`
+	}{}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	city := strings.TrimSpace(params.City)
+
+	artists, err := h.areas.ArtistsFromArea(r.Context(), city, params.Limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrRateLimitExceeded) {
+			h.respondWithError(w, http.StatusServiceUnavailable, "musicbrainz is busy, try again shortly")
+			return
+		}
+		h.respondWithError(w, http.StatusBadGateway, "failed to find artists")
+		return
+	}
+
+	now := h.now()
+	for i := range artists {
+		if err := h.addLocalData(r.Context(), &artists[i], now); err != nil {
+			h.respondWithError(w, http.StatusInternalServerError, "failed to get stored artists")
+			return
+		}
+	}
+
+	sort.SliceStable(artists, func(i, j int) bool {
+		iTouring, jTouring := len(artists[i].UpcomingEvents) > 0, len(artists[j].UpcomingEvents) > 0
+		if iTouring != jTouring {
+			return iTouring
+		}
+		return artists[i].Popularity > artists[j].Popularity
+	})
+
+	h.respondWithJSON(w, http.StatusOK, LocalArtistsResponse{
+		City:    city,
+		Artists: artists,
+	})
+}
+
+// addLocalData swaps in the stored artist, whose popularity is blended from
+// every source that found it, and adds the artist's next shows
+func (h *LocalArtistHandler) addLocalData(ctx context.Context, artist *domain.LocalArtist, now time.Time) error {
+	stored, err := h.artists.GetByExternalID(ctx, artist.ExternalIDs.MusicBrainzID, "musicbrainz")
+	switch {
+	case err == nil:
+		if len(stored.Genres) == 0 {
+			stored.Genres = artist.Genres
+		}
+		if len(stored.Aliases) == 0 {
+			stored.Aliases = artist.Aliases
+		}
+		artist.Artist = *stored
+	case !errors.Is(err, domain.ErrArtistNotFound):
+		return err
+	}
+
+	events, err := h.events.SearchByArtistName(ctx, artist.Name, &now, nil)
+	if err != nil {
+		return err
+	}
+	if len(events) > localArtistEventLimit {
+		events = events[:localArtistEventLimit]
+	}
+	if events == nil {
+		events = []domain.Event{}
+	}
+	artist.UpcomingEvents = events
+
+	return nil
+}
+
+func (h *LocalArtistHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *LocalArtistHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
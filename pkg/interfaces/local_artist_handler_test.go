@@ -0,0 +1,95 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubAreaSource struct {
+	area    string
+	artists []domain.LocalArtist
+}
+
+func (s *stubAreaSource) ArtistsFromArea(ctx context.Context, area string, limit int) ([]domain.LocalArtist, error) {
+	s.area = area
+	return append([]domain.LocalArtist(nil), s.artists...), nil
+}
+
+func TestLocalArtistHandler(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	local := func(name, mbid string, popularity int) domain.LocalArtist {
+		return domain.LocalArtist{
+			Artist: domain.Artist{
+				ID:          "musicbrainz_" + mbid,
+				Name:        name,
+				Genres:      []string{"indie"},
+				Popularity:  popularity,
+				ExternalIDs: domain.ExternalIDs{MusicBrainzID: mbid},
+			},
+			Area: "Leipzig",
+		}
+	}
+	areas := &stubAreaSource{artists: []domain.LocalArtist{
+		local("Die Prinzen", "mb_1", 40),
+		local("Kraftklub", "mb_2", 20),
+		local("Unknown Band", "mb_3", 5),
+	}}
+
+	artists := &mockRepository{getByExternalIDFunc: func(ctx context.Context, externalID, source string) (*domain.Artist, error) {
+		if source == "musicbrainz" && externalID == "mb_2" {
+			return &domain.Artist{ID: "artist_7", Name: "Kraftklub", Popularity: 70, ExternalIDs: domain.ExternalIDs{MusicBrainzID: "mb_2"}}, nil
+		}
+		return nil, domain.ErrArtistNotFound
+	}}
+	events := newMemoryEventRepository()
+	events.events["e1"] = domain.Event{ID: "e1", ArtistName: "Unknown Band", DateTime: now.Add(48 * time.Hour)}
+
+	handler := NewLocalArtistHandler(areas, artists, events)
+	handler.now = func() time.Time { return now }
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/discover/artists?city=%20Leipzig%20")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if areas.area != "Leipzig" {
+		t.Errorf("expected the trimmed city asked for, got %q", areas.area)
+	}
+
+	var response LocalArtistsResponse
+	json.NewDecoder(rr.Body).Decode(&response)
+	if len(response.Artists) != 3 {
+		t.Fatalf("expected 3 artists, got %+v", response.Artists)
+	}
+	// Upcoming shows first, then stored popularity over MusicBrainz's
+	got := []string{response.Artists[0].ID, response.Artists[1].ID, response.Artists[2].ID}
+	if got[0] != "musicbrainz_mb_3" || got[1] != "artist_7" || got[2] != "musicbrainz_mb_1" {
+		t.Errorf("unexpected order %v", got)
+	}
+	if len(response.Artists[0].UpcomingEvents) != 1 || response.Artists[0].UpcomingEvents[0].ID != "e1" {
+		t.Errorf("expected the upcoming show linked, got %+v", response.Artists[0].UpcomingEvents)
+	}
+	kraftklub := response.Artists[1]
+	if kraftklub.Area != "Leipzig" || len(kraftklub.Genres) != 1 || kraftklub.UpcomingEvents == nil {
+		t.Errorf("expected the area and genres kept, got %+v", kraftklub)
+	}
+
+	if rr := get("/api/discover/artists"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a city, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,66 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// LocalSearchHandler searches the cached artists and events only, so it
+// answers instantly and works when every source is down
+type LocalSearchHandler struct {
+	search domain.LocalSearchRepository
+}
+
+func NewLocalSearchHandler(search domain.LocalSearchRepository) *LocalSearchHandler {
+	return &LocalSearchHandler{
+		search: search,
+	}
+}
+
+func (h *LocalSearchHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/search/local", h.Search).Methods("GET")
+}
+
+type LocalSearchResponse struct {
+	Query   string             `json:"query"`
+	Results []domain.SearchHit `json:"results"`
+}
+
+// Search takes q, an optional type of artist or event, and limit (default
+// 20, at most 100)
+func (h *LocalSearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query string `query:"q" validate:"required"`
+		Type  string `query:"type" validate:"oneof=artist event"`
+		Limit int    `query:"limit" limit:"20,100"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	query := params.Query
+
+	hits, err := h.search.SearchLocal(r.Context(), query, domain.SearchHitType(params.Type), params.Limit)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to search")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, LocalSearchResponse{
+		Query:   query,
+		Results: hits,
+	})
+}
+
+func (h *LocalSearchHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *LocalSearchHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
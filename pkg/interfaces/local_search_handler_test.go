@@ -0,0 +1,59 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubLocalSearch struct {
+	query   string
+	hitType domain.SearchHitType
+	limit   int
+	hits    []domain.SearchHit
+}
+
+func (s *stubLocalSearch) SearchLocal(ctx context.Context, query string, hitType domain.SearchHitType, limit int) ([]domain.SearchHit, error) {
+	s.query, s.hitType, s.limit = query, hitType, limit
+	return s.hits, nil
+}
+
+func TestLocalSearchHandler(t *testing.T) {
+	search := &stubLocalSearch{hits: []domain.SearchHit{
+		{Type: domain.SearchHitArtist, Score: 4.2, Artist: &domain.Artist{ID: "a1", Name: "Radiohead"}},
+	}}
+	router := mux.NewRouter()
+	NewLocalSearchHandler(search).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/search/local?q=radio&type=artist&limit=500")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if search.query != "radio" || search.hitType != domain.SearchHitArtist || search.limit != 100 {
+		t.Errorf("unexpected search %q %q %d", search.query, search.hitType, search.limit)
+	}
+
+	var response LocalSearchResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if len(response.Results) != 1 || response.Results[0].Artist.Name != "Radiohead" || response.Results[0].Score != 4.2 {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+
+	for _, path := range []string{"/api/search/local", "/api/search/local?q=%20", "/api/search/local?q=x&type=venue"} {
+		if rr := get(path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const (
+	// mapCellPixels is how wide a cluster's cell is on screen, a quarter
+	// of a 256 pixel map tile
+	mapCellPixels = 64
+	// mapEventsPerCell is how many of a cell's soonest events come with it
+	mapEventsPerCell = 3
+)
+
+// MapHandler serves stored upcoming events clustered for map views, so a
+// dense city shows as a few counted markers instead of hundreds of pins
+type MapHandler struct {
+	events domain.EventMapRepository
+	now    func() time.Time
+}
+
+func NewMapHandler(events domain.EventMapRepository) *MapHandler {
+	return &MapHandler{
+		events: events,
+		now:    time.Now,
+	}
+}
+
+func (h *MapHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/events/map", h.GetClusters).Methods("GET")
+}
+
+type EventMapResponse struct {
+	Bounds   domain.Bounds         `json:"bounds"`
+	Zoom     int                   `json:"zoom"`
+	CellSize float64               `json:"cell_size"`
+	Total    int                   `json:"total"`
+	Clusters []domain.EventCluster `json:"clusters"`
+}
+
+// GetClusters takes bbox as west,south,east,north in degrees, the order
+// Leaflet's toBBoxString gives, and the map's zoom from 0 to 22. Cells are
+// a quarter tile wide at that zoom.
+func (h *MapHandler) GetClusters(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		BBox string `query:"bbox" validate:"required"`
+		Zoom int    `query:"zoom" validate:"min=0,max=22"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	// 0 is a zoom level, so a missing one can't be told apart after binding
+	if strings.TrimSpace(r.URL.Query().Get("zoom")) == "" {
+		writeValidationError(w, invalidParam("zoom", "is required"))
+		return
+	}
+
+	bounds, err := parseBBox(params.BBox)
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	cellSize := mapCellSize(params.Zoom)
+	clusters, err := h.events.ClusterEvents(r.Context(), domain.ClusterFilter{
+		Bounds:   bounds,
+		CellSize: cellSize,
+		From:     h.now(),
+		PerCell:  mapEventsPerCell,
+	})
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to cluster events")
+		return
+	}
+	if clusters == nil {
+		clusters = []domain.EventCluster{}
+	}
+
+	total := 0
+	for _, cluster := range clusters {
+		total += cluster.Count
+	}
+
+	h.respondWithJSON(w, http.StatusOK, EventMapResponse{
+		Bounds:   bounds,
+		Zoom:     params.Zoom,
+		CellSize: cellSize,
+		Total:    total,
+		Clusters: clusters,
+	})
+}
+
+// mapCellSize is the width in degrees of mapCellPixels at zoom, where the
+// world is 256 pixels wide at zoom 0 and doubles with each level
+func mapCellSize(zoom int) float64 {
+	return 360 / (256 * math.Exp2(float64(zoom))) * mapCellPixels
+}
+
+// parseBBox reads west,south,east,north. West may be greater than East for
+// a viewport across the antimeridian.
+func parseBBox(raw string) (domain.Bounds, error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return domain.Bounds{}, invalidParam("bbox", "must be west,south,east,north")
+	}
+
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return domain.Bounds{}, invalidParam("bbox", "must be four numbers")
+		}
+		values[i] = value
+	}
+
+	bounds := domain.Bounds{West: values[0], South: values[1], East: values[2], North: values[3]}
+	switch {
+	case bounds.South < -90 || bounds.North > 90 || bounds.South >= bounds.North:
+		return domain.Bounds{}, invalidParam("bbox", "must have south below north, within -90 and 90")
+	case math.Abs(bounds.West) > 180 || math.Abs(bounds.East) > 180 || bounds.West == bounds.East:
+		return domain.Bounds{}, invalidParam("bbox", "must have west and east apart, within -180 and 180")
+	}
+	return bounds, nil
+}
+
+func (h *MapHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *MapHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,76 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubEventMap struct {
+	filter   domain.ClusterFilter
+	clusters []domain.EventCluster
+}
+
+func (s *stubEventMap) ClusterEvents(ctx context.Context, filter domain.ClusterFilter) ([]domain.EventCluster, error) {
+	s.filter = filter
+	return s.clusters, nil
+}
+
+func TestMapHandler_GetClusters(t *testing.T) {
+	events := &stubEventMap{clusters: []domain.EventCluster{
+		{Latitude: 52.52, Longitude: 13.40, Count: 120, Events: []domain.Event{{ID: "e1"}}},
+		{Latitude: 52.39, Longitude: 13.06, Count: 4, Events: []domain.Event{{ID: "e2"}}},
+	}}
+	router := mux.NewRouter()
+	NewMapHandler(events).RegisterRoutes(router)
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/events/map?"+query, nil))
+		return rr
+	}
+
+	rr := get("bbox=13.0,52.3,13.8,52.7&zoom=10")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var response EventMapResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Total != 124 || len(response.Clusters) != 2 || response.Zoom != 10 {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	want := domain.Bounds{West: 13.0, South: 52.3, East: 13.8, North: 52.7}
+	if events.filter.Bounds != want || events.filter.PerCell != mapEventsPerCell {
+		t.Errorf("unexpected filter %+v", events.filter)
+	}
+	// A quarter of a tile at zoom 10 is 360/1024/4 degrees
+	if events.filter.CellSize != 360.0/4096 {
+		t.Errorf("unexpected cell size %v", events.filter.CellSize)
+	}
+
+	for _, query := range []string{
+		"zoom=10",
+		"bbox=13.0,52.3,13.8,52.7",
+		"bbox=13.0,52.3,13.8&zoom=10",
+		"bbox=a,b,c,d&zoom=10",
+		"bbox=13.0,52.7,13.8,52.3&zoom=10",
+		"bbox=13.0,52.3,190,52.7&zoom=10",
+		"bbox=13.0,52.3,13.8,52.7&zoom=23",
+	} {
+		if rr := get(query); rr.Code != http.StatusBadRequest {
+			t.Errorf("%q: expected status 400, got %d", query, rr.Code)
+		}
+	}
+
+	if rr := get("bbox=170,-25,-165,-5&zoom=0"); rr.Code != http.StatusOK {
+		t.Errorf("expected a viewport across the antimeridian at zoom 0, got %d", rr.Code)
+	}
+}
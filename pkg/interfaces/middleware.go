@@ -0,0 +1,170 @@
+package interfaces
+
+import (
+	"bufio"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/yair/where-its-at/pkg/interfaces"
+
+// RequestIDHeader carries the request ID between clients, proxies and logs
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength keeps client-supplied IDs from bloating the logs
+const maxRequestIDLength = 64
+
+// RequestLogging assigns every request an ID, exposes it through the request
+// context and the response header, and logs the request once it completes
+func RequestLogging(logger *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get(RequestIDHeader)
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				requestID = logging.NewRequestID()
+			}
+			ctx := logging.WithRequestID(r.Context(), requestID)
+			w.Header().Set(RequestIDHeader, requestID)
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			route := routeTemplate(r)
+
+			level := slog.LevelInfo
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.Log(ctx, level, "request completed",
+				"method", r.Method,
+				"route", route,
+				"path", r.URL.Path,
+				"status", recorder.status,
+				"bytes", recorder.bytes,
+				"duration", time.Since(start),
+			)
+		})
+	}
+}
+
+// HTTPMetrics receives the outcome of every request
+type HTTPMetrics interface {
+	ObserveHTTPRequest(method, route string, status int, duration time.Duration)
+}
+
+// RequestMetrics reports each request's duration by route template, so
+// /api/artists/{id} is one series rather than one per artist
+func RequestMetrics(metrics HTTPMetrics) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			metrics.ObserveHTTPRequest(r.Method, routeTemplate(r), recorder.status, time.Since(start))
+		})
+	}
+}
+
+// RequestTracing starts a server span per request named after its route,
+// continuing any trace the caller propagated, so handlers, aggregator fan-out
+// and repository calls all land in one trace
+func RequestTracing() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := otel.Tracer(tracerName).Start(ctx, r.Method+" "+route,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("http.route", route),
+					attribute.String("url.path", r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			if id := logging.RequestID(ctx); id != "" {
+				span.SetAttributes(attribute.String("request.id", id))
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+			if recorder.status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(recorder.status))
+			}
+		})
+	}
+}
+
+// routeTemplate returns the path template of the matched route, falling back
+// to the raw path
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// statusRecorder captures the status code and body size of a response while
+// still letting streaming handlers flush
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	if !s.wroteHeader {
+		s.status = code
+		s.wroteHeader = true
+	}
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	s.wroteHeader = true
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hands the connection to WebSocket upgrades, which is recorded as
+// 101 Switching Protocols
+func (s *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := s.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+	s.status = http.StatusSwitchingProtocols
+	s.wroteHeader = true
+	return hijacker.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
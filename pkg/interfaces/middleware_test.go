@@ -0,0 +1,159 @@
+package interfaces
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/logging"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRequestLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "json", "info")
+
+	var seenID string
+	router := mux.NewRouter()
+	router.Use(RequestLogging(logger))
+	router.HandleFunc("/api/artists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		seenID = logging.RequestID(r.Context())
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("missing"))
+	})
+	router.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("expected the wrapped writer to support flushing")
+		}
+	})
+
+	t.Run("assigns an ID and logs the route", func(t *testing.T) {
+		buf.Reset()
+		req, _ := http.NewRequest("GET", "/api/artists/artist_1", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		id := rr.Header().Get(RequestIDHeader)
+		if id == "" || id != seenID {
+			t.Fatalf("expected the response header to match the context ID, got %q and %q", id, seenID)
+		}
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("expected a JSON log record, got %q", buf.String())
+		}
+		if record["request_id"] != id || record["route"] != "/api/artists/{id}" || record["status"] != float64(404) || record["bytes"] != float64(7) {
+			t.Errorf("unexpected log record: %v", record)
+		}
+	})
+
+	t.Run("keeps a client supplied ID", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/artists/artist_1", nil)
+		req.Header.Set(RequestIDHeader, "upstream-id")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Header().Get(RequestIDHeader) != "upstream-id" || seenID != "upstream-id" {
+			t.Errorf("expected upstream-id, got %q", rr.Header().Get(RequestIDHeader))
+		}
+	})
+
+	t.Run("replaces oversized IDs", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/api/artists/artist_1", nil)
+		req.Header.Set(RequestIDHeader, strings.Repeat("x", 200))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if len(rr.Header().Get(RequestIDHeader)) != 16 {
+			t.Errorf("expected a generated ID, got %q", rr.Header().Get(RequestIDHeader))
+		}
+	})
+
+	t.Run("streaming handlers can flush", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/stream", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	})
+}
+
+type recordingHTTPMetrics struct {
+	routes   []string
+	statuses []int
+}
+
+func (r *recordingHTTPMetrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	r.routes = append(r.routes, method+" "+route)
+	r.statuses = append(r.statuses, status)
+}
+
+func TestRequestMetrics(t *testing.T) {
+	metrics := &recordingHTTPMetrics{}
+	router := mux.NewRouter()
+	router.Use(RequestMetrics(metrics))
+	router.HandleFunc("/api/artists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}).Methods("GET")
+
+	for _, id := range []string{"a", "b"} {
+		req, _ := http.NewRequest("GET", "/api/artists/"+id, nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(metrics.routes) != 2 || metrics.routes[0] != "GET /api/artists/{id}" || metrics.routes[1] != metrics.routes[0] {
+		t.Errorf("expected both requests under the route template, got %v", metrics.routes)
+	}
+	if metrics.statuses[0] != http.StatusTeapot {
+		t.Errorf("expected status 418, got %d", metrics.statuses[0])
+	}
+}
+
+func TestRequestTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	}()
+
+	var handlerSpan trace.SpanContext
+	router := mux.NewRouter()
+	router.Use(RequestTracing())
+	router.HandleFunc("/api/artists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req, _ := http.NewRequest("GET", "/api/artists/artist_1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "GET /api/artists/{id}" {
+		t.Errorf("expected span named after the route, got %s", span.Name())
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the propagated trace to continue, got %s", span.SpanContext().TraceID())
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Error("expected the handler context to carry the request span")
+	}
+	if span.Status().Code != codes.Error {
+		t.Errorf("expected a 500 to mark the span as failed, got %v", span.Status())
+	}
+}
@@ -0,0 +1,178 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// OnSaleHandler lists events whose tickets go on sale soon and lets users
+// turn on-sale alerts on and off
+type OnSaleHandler struct {
+	auth   *AuthService
+	events domain.EventOnSaleRepository
+	alerts domain.OnSaleAlertRepository
+	now    func() time.Time
+}
+
+func NewOnSaleHandler(auth *AuthService, events domain.EventOnSaleRepository, alerts domain.OnSaleAlertRepository) *OnSaleHandler {
+	return &OnSaleHandler{
+		auth:   auth,
+		events: events,
+		alerts: alerts,
+		now:    time.Now,
+	}
+}
+
+func (h *OnSaleHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/events/upcoming-onsales", h.ListUpcoming).Methods("GET")
+
+	requireUser := RequireUser(h.auth)
+	router.Handle("/api/me/onsale-alerts", requireUser(http.HandlerFunc(h.GetSubscription))).Methods("GET")
+	router.Handle("/api/me/onsale-alerts", requireUser(http.HandlerFunc(h.Subscribe))).Methods("PUT")
+	router.Handle("/api/me/onsale-alerts", requireUser(http.HandlerFunc(h.Unsubscribe))).Methods("DELETE")
+}
+
+type UpcomingOnSalesResponse struct {
+	From   time.Time      `json:"from"`
+	To     time.Time      `json:"to"`
+	Events []domain.Event `json:"events"`
+}
+
+// ListUpcoming returns stored events going on sale in the next `days`
+// (default 7, at most 90), optionally narrowed by artist and city
+func (h *OnSaleHandler) ListUpcoming(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	days := 7
+	if daysStr := query.Get("days"); daysStr != "" {
+		parsed, err := strconv.Atoi(daysStr)
+		if err != nil || parsed <= 0 || parsed > 90 {
+			h.respondWithError(w, http.StatusBadRequest, "days must be between 1 and 90")
+			return
+		}
+		days = parsed
+	}
+
+	limit := 50
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+			if limit > 200 {
+				limit = 200
+			}
+		}
+	}
+
+	now := h.now()
+	filter := domain.OnSaleFilter{
+		ArtistName: query.Get("artist"),
+		City:       query.Get("city"),
+		From:       now,
+		To:         now.AddDate(0, 0, days),
+	}
+
+	events, err := h.events.ListUpcomingOnSales(r.Context(), filter, limit)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to list upcoming on-sales")
+		return
+	}
+	if events == nil {
+		events = []domain.Event{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, UpcomingOnSalesResponse{
+		From:   filter.From,
+		To:     filter.To,
+		Events: events,
+	})
+}
+
+func (h *OnSaleHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	sub, err := h.alerts.GetSubscription(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotSubscribed) {
+			h.respondWithJSON(w, http.StatusOK, map[string]interface{}{"enabled": false})
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get on-sale alerts")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":      true,
+		"subscription": sub,
+	})
+}
+
+// Subscribe turns alerts on. An empty body is fine; webhook_url adds a
+// webhook to the email and Telegram alerts.
+func (h *OnSaleHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	var request struct {
+		WebhookURL string `json:"webhook_url"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &request); err != nil {
+			writeValidationError(w, err)
+			return
+		}
+	}
+	if request.WebhookURL != "" && !validWebhookURL(request.WebhookURL) {
+		writeValidationError(w, invalidField("webhook_url", "must be an absolute http or https URL"))
+		return
+	}
+
+	sub := &domain.OnSaleSubscription{UserID: userID, WebhookURL: request.WebhookURL}
+	if err := h.alerts.Subscribe(r.Context(), sub); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to turn on on-sale alerts")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":      true,
+		"subscription": sub,
+	})
+}
+
+func (h *OnSaleHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	if err := h.alerts.Unsubscribe(r.Context(), userID); err != nil {
+		if errors.Is(err, domain.ErrNotSubscribed) {
+			h.respondWithError(w, http.StatusNotFound, "on-sale alerts are not turned on")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to turn off on-sale alerts")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func validWebhookURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func (h *OnSaleHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *OnSaleHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,164 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubOnSaleEvents struct {
+	events []domain.Event
+	filter domain.OnSaleFilter
+}
+
+func (s *stubOnSaleEvents) ListUpcomingOnSales(ctx context.Context, filter domain.OnSaleFilter, limit int) ([]domain.Event, error) {
+	s.filter = filter
+	return s.events, nil
+}
+
+type memoryOnSaleAlerts struct {
+	domain.OnSaleAlertRepository
+	subs map[string]domain.OnSaleSubscription
+}
+
+func (m *memoryOnSaleAlerts) Subscribe(ctx context.Context, sub *domain.OnSaleSubscription) error {
+	sub.CreatedAt = time.Now()
+	m.subs[sub.UserID] = *sub
+	return nil
+}
+
+func (m *memoryOnSaleAlerts) GetSubscription(ctx context.Context, userID string) (*domain.OnSaleSubscription, error) {
+	sub, ok := m.subs[userID]
+	if !ok {
+		return nil, domain.ErrNotSubscribed
+	}
+	return &sub, nil
+}
+
+func (m *memoryOnSaleAlerts) Unsubscribe(ctx context.Context, userID string) error {
+	if _, ok := m.subs[userID]; !ok {
+		return domain.ErrNotSubscribed
+	}
+	delete(m.subs, userID)
+	return nil
+}
+
+func TestOnSaleHandler_ListUpcoming(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	events := &stubOnSaleEvents{}
+
+	handler := NewOnSaleHandler(newTestAuthService(newMemoryUserRepository()), events, &memoryOnSaleAlerts{})
+	handler.now = func() time.Time { return now }
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/events/upcoming-onsales?artist=Radiohead&city=Berlin&days=3")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if events.filter.ArtistName != "Radiohead" || events.filter.City != "Berlin" {
+		t.Errorf("expected artist and city to be passed on, got %+v", events.filter)
+	}
+	if !events.filter.From.Equal(now) || !events.filter.To.Equal(now.AddDate(0, 0, 3)) {
+		t.Errorf("expected a three day window, got %v to %v", events.filter.From, events.filter.To)
+	}
+	if !strings.Contains(rr.Body.String(), `"events":[]`) {
+		t.Errorf("expected an empty list, got %s", rr.Body.String())
+	}
+
+	for _, days := range []string{"0", "91", "soon"} {
+		if rr := get("/api/events/upcoming-onsales?days=" + days); rr.Code != http.StatusBadRequest {
+			t.Errorf("days=%s: expected status 400, got %d", days, rr.Code)
+		}
+	}
+}
+
+func TestOnSaleHandler_Subscription(t *testing.T) {
+	auth := newTestAuthService(newMemoryUserRepository())
+	registered, err := auth.Register(context.Background(), "kim@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	alerts := &memoryOnSaleAlerts{subs: map[string]domain.OnSaleSubscription{}}
+	router := mux.NewRouter()
+	NewOnSaleHandler(auth, &stubOnSaleEvents{}, alerts).RegisterRoutes(router)
+
+	do := func(method, body string) *httptest.ResponseRecorder {
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req, _ := http.NewRequest(method, "/api/me/onsale-alerts", reader)
+		req.Header.Set("Authorization", "Bearer "+registered.AccessToken)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do("GET", ""); !strings.Contains(rr.Body.String(), `"enabled":false`) {
+		t.Errorf("expected alerts off, got %s", rr.Body.String())
+	}
+
+	t.Run("subscribe without a webhook", func(t *testing.T) {
+		if rr := do("PUT", ""); rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if _, ok := alerts.subs[registered.User.ID]; !ok {
+			t.Error("expected a subscription")
+		}
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		if rr := do("PUT", `{"webhook_url": "ftp://example.com/hook"}`); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+
+		rr := do("PUT", `{"webhook_url": "https://example.com/hook"}`)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+
+		var response struct {
+			Enabled      bool                      `json:"enabled"`
+			Subscription domain.OnSaleSubscription `json:"subscription"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		if !response.Enabled || response.Subscription.WebhookURL != "https://example.com/hook" {
+			t.Errorf("unexpected response %s", rr.Body.String())
+		}
+	})
+
+	t.Run("unsubscribe", func(t *testing.T) {
+		if rr := do("DELETE", ""); rr.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", rr.Code)
+		}
+		if rr := do("DELETE", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("requires a user", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/api/me/onsale-alerts", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// PriceHandler serves the ticket price history recorded on each sync
+type PriceHandler struct {
+	events domain.EventRepository
+	prices domain.EventPriceRepository
+}
+
+func NewPriceHandler(events domain.EventRepository, prices domain.EventPriceRepository) *PriceHandler {
+	return &PriceHandler{
+		events: events,
+		prices: prices,
+	}
+}
+
+func (h *PriceHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/events/{id}/prices", h.GetPriceHistory).Methods("GET")
+}
+
+type PriceHistoryResponse struct {
+	EventID string                 `json:"event_id"`
+	Current []domain.PriceRange    `json:"current"`
+	History []domain.PriceSnapshot `json:"history"`
+	Changed bool                   `json:"changed"`
+}
+
+// GetPriceHistory returns every recorded snapshot, oldest first, and whether
+// the prices ever differed between syncs
+func (h *PriceHandler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["id"]
+
+	history, err := h.prices.GetPriceHistory(r.Context(), eventID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get price history")
+		return
+	}
+
+	// History outlives the cached event, so only an event we have never
+	// seen is a 404
+	if len(history) == 0 {
+		if _, err := h.events.GetByID(r.Context(), eventID); err != nil {
+			if errors.Is(err, domain.ErrEventNotFound) {
+				h.respondWithError(w, http.StatusNotFound, "event not found")
+				return
+			}
+			h.respondWithError(w, http.StatusInternalServerError, "failed to get event")
+			return
+		}
+	}
+
+	response := PriceHistoryResponse{
+		EventID: eventID,
+		Current: []domain.PriceRange{},
+		History: history,
+	}
+	if len(history) > 0 {
+		response.Current = history[len(history)-1].Ranges
+	}
+	for i := 1; i < len(history); i++ {
+		if !samePriceRanges(history[i-1].Ranges, history[i].Ranges) {
+			response.Changed = true
+			break
+		}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func samePriceRanges(a, b []domain.PriceRange) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *PriceHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *PriceHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,95 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubPriceRepository map[string][]domain.PriceSnapshot
+
+func (s stubPriceRepository) GetPriceHistory(ctx context.Context, eventID string) ([]domain.PriceSnapshot, error) {
+	return s[eventID], nil
+}
+
+func TestPriceHandler(t *testing.T) {
+	synced := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	events := newMemoryEventRepository()
+	events.events["steady"] = domain.Event{ID: "steady"}
+	events.events["unpriced"] = domain.Event{ID: "unpriced"}
+
+	prices := stubPriceRepository{
+		"steady": {
+			{RecordedAt: synced, Ranges: []domain.PriceRange{{Type: "standard", Min: 30, Max: 40, Currency: "EUR"}}},
+			{RecordedAt: synced.Add(24 * time.Hour), Ranges: []domain.PriceRange{{Type: "standard", Min: 30, Max: 40, Currency: "EUR"}}},
+		},
+		// Raised price for an event that has since left the cache
+		"raised": {
+			{RecordedAt: synced, Ranges: []domain.PriceRange{{Type: "standard", Min: 30, Max: 40, Currency: "EUR"}}},
+			{RecordedAt: synced.Add(24 * time.Hour), Ranges: []domain.PriceRange{{Type: "standard", Min: 45, Max: 60, Currency: "EUR"}}},
+		},
+	}
+
+	router := mux.NewRouter()
+	NewPriceHandler(events, prices).RegisterRoutes(router)
+
+	get := func(id string) (*httptest.ResponseRecorder, PriceHistoryResponse) {
+		req, _ := http.NewRequest("GET", "/api/events/"+id+"/prices", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var response PriceHistoryResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		return rr, response
+	}
+
+	t.Run("unchanged prices", func(t *testing.T) {
+		rr, response := get("steady")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		if response.Changed {
+			t.Error("expected prices to be unchanged")
+		}
+		if len(response.History) != 2 {
+			t.Errorf("expected 2 snapshots, got %d", len(response.History))
+		}
+	})
+
+	t.Run("changed prices", func(t *testing.T) {
+		rr, response := get("raised")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		if !response.Changed {
+			t.Error("expected prices to have changed")
+		}
+		if len(response.Current) != 1 || response.Current[0].Min != 45 {
+			t.Errorf("expected the latest prices as current, got %+v", response.Current)
+		}
+	})
+
+	t.Run("known event without prices", func(t *testing.T) {
+		rr, response := get("unpriced")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+		if len(response.History) != 0 || len(response.Current) != 0 {
+			t.Errorf("expected empty history, got %+v", response)
+		}
+	})
+
+	t.Run("unknown event", func(t *testing.T) {
+		rr, _ := get("missing")
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("expected 404, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ProfileBuilder gathers an artist's profile from the music sources,
+// returning the errors of sources that failed alongside it
+type ProfileBuilder interface {
+	BuildProfile(ctx context.Context, artist domain.Artist) (*domain.ArtistProfile, []string)
+}
+
+// ArtistProfileHandler serves enriched artist profiles. Building one calls
+// every music source, so it's done by a job rather than in the request, and
+// discographies rarely change, so profiles are stored and served until they
+// are ttl old.
+type ArtistProfileHandler struct {
+	artists  domain.ArtistRepository
+	profiles domain.ArtistProfileRepository
+	jobs     JobEnqueuer
+	ttl      time.Duration
+	now      func() time.Time
+}
+
+func NewArtistProfileHandler(artists domain.ArtistRepository, profiles domain.ArtistProfileRepository, jobs JobEnqueuer, ttl time.Duration) *ArtistProfileHandler {
+	return &ArtistProfileHandler{
+		artists:  artists,
+		profiles: profiles,
+		jobs:     jobs,
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+func (h *ArtistProfileHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/artists/{id}/full", h.GetArtistProfile).Methods("GET")
+}
+
+type ArtistProfileResponse struct {
+	*domain.ArtistProfile
+	Cached bool `json:"cached"`
+	// RefreshJobID is the job rebuilding a stale profile
+	RefreshJobID string `json:"refresh_job_id,omitempty"`
+}
+
+// ProfileJobResponse answers for a profile that isn't built yet
+type ProfileJobResponse struct {
+	Job *domain.Job `json:"job"`
+}
+
+// GetArtistProfile returns the artist with its albums, releases, tracks and
+// videos from every configured music source. The first request for an
+// artist answers 202 with the job building the profile; poll it, then ask
+// again. A stale profile is served while it's rebuilt.
+func (h *ArtistProfileHandler) GetArtistProfile(w http.ResponseWriter, r *http.Request) {
+	artistID := mux.Vars(r)["id"]
+
+	if profile, err := h.profiles.Get(r.Context(), artistID); err == nil {
+		response := ArtistProfileResponse{ArtistProfile: profile, Cached: true}
+		if h.now().Sub(profile.FetchedAt) >= h.ttl {
+			// The stored profile is still worth serving if the queue fails
+			if job, err := h.enqueueBuild(r.Context(), artistID); err == nil {
+				response.RefreshJobID = job.ID
+			}
+		}
+		h.respondWithJSON(w, http.StatusOK, response)
+		return
+	}
+
+	if _, err := h.artists.GetByID(r.Context(), artistID); err != nil {
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "artist not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get artist")
+		return
+	}
+
+	job, err := h.enqueueBuild(r.Context(), artistID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to queue the artist profile")
+		return
+	}
+
+	w.Header().Set("Location", "/api/jobs/"+job.ID)
+	h.respondWithJSON(w, http.StatusAccepted, ProfileJobResponse{Job: job})
+}
+
+// enqueueBuild is keyed by artist, so requests while a build is queued or
+// running share it
+func (h *ArtistProfileHandler) enqueueBuild(ctx context.Context, artistID string) (*domain.Job, error) {
+	return h.jobs.Enqueue(ctx, JobBuildProfile, "profile:"+artistID, buildProfilePayload{ArtistID: artistID})
+}
+
+// JobBuildProfile gathers and stores an artist's profile
+const JobBuildProfile = "build_profile"
+
+type buildProfilePayload struct {
+	ArtistID string `json:"artist_id"`
+}
+
+// BuildProfileJob builds and stores the artist's profile, and the picture
+// it found on the artist. A profile no source answered for isn't stored;
+// the job fails and is retried instead.
+func BuildProfileJob(artists domain.ArtistRepository, profiles domain.ArtistProfileRepository, builder ProfileBuilder) JobFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var job buildProfilePayload
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return fmt.Errorf("failed to decode payload: %w", err)
+		}
+
+		artist, err := artists.GetByID(ctx, job.ArtistID)
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			// Deleted since it was queued; nothing to build
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get artist: %w", err)
+		}
+
+		profile, sourceErrors := builder.BuildProfile(ctx, *artist)
+		if len(profile.Sources) == 0 {
+			return fmt.Errorf("no music source answered: %s", strings.Join(sourceErrors, "; "))
+		}
+		if err := profiles.Save(ctx, profile); err != nil {
+			return fmt.Errorf("failed to save profile: %w", err)
+		}
+
+		// The profile may have found the artist a picture, or found theirs
+		// broken
+		if profile.Artist.ImageURL != artist.ImageURL {
+			artist.ImageURL = profile.Artist.ImageURL
+			if err := artists.Update(ctx, artist); err != nil {
+				return fmt.Errorf("failed to update artist image: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+func (h *ArtistProfileHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *ArtistProfileHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
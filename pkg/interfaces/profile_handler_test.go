@@ -0,0 +1,165 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type memoryProfileRepository map[string]domain.ArtistProfile
+
+func (m memoryProfileRepository) Get(ctx context.Context, artistID string) (*domain.ArtistProfile, error) {
+	profile, ok := m[artistID]
+	if !ok {
+		return nil, domain.ErrProfileNotFound
+	}
+	return &profile, nil
+}
+
+func (m memoryProfileRepository) Save(ctx context.Context, profile *domain.ArtistProfile) error {
+	m[profile.Artist.ID] = *profile
+	return nil
+}
+
+type stubProfileBuilder struct {
+	now     time.Time
+	sources []string
+	builds  int
+	// image is the picture the build finds for the artist, if any
+	image string
+}
+
+func (s *stubProfileBuilder) BuildProfile(ctx context.Context, artist domain.Artist) (*domain.ArtistProfile, []string) {
+	s.builds++
+	if s.image != "" {
+		artist.ImageURL = s.image
+	}
+	return &domain.ArtistProfile{
+		Artist:    artist,
+		Albums:    []domain.Album{{ID: "deezer_1", Title: "OK Computer", Source: "deezer"}},
+		Sources:   s.sources,
+		FetchedAt: s.now,
+	}, []string{"youtube: quota exceeded"}
+}
+
+func TestArtistProfileHandler(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != "artist-1" {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &domain.Artist{ID: id, Name: "Radiohead"}, nil
+		},
+	}
+	profiles := memoryProfileRepository{}
+	builder := &stubProfileBuilder{now: now, sources: []string{"deezer"}}
+
+	queue := NewJobQueue(JobQueueConfig{Jobs: newMemoryJobRepository()})
+	queue.Handle(JobBuildProfile, BuildProfileJob(artists, profiles, builder))
+	ctx := context.Background()
+
+	handler := NewArtistProfileHandler(artists, profiles, queue, 7*24*time.Hour)
+	handler.now = func() time.Time { return now }
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/artists/artist-1/full")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202 while the profile is built, got %d", rr.Code)
+	}
+	var queued ProfileJobResponse
+	json.Unmarshal(rr.Body.Bytes(), &queued)
+	if queued.Job == nil || queued.Job.Kind != JobBuildProfile || rr.Header().Get("Location") != "/api/jobs/"+queued.Job.ID {
+		t.Fatalf("expected the build job, got %s (Location %q)", rr.Body.String(), rr.Header().Get("Location"))
+	}
+	if builder.builds != 0 {
+		t.Fatal("expected the profile not to be built in the request")
+	}
+
+	// Asking again before it's built shares the job
+	var again ProfileJobResponse
+	json.Unmarshal(get("/api/artists/artist-1/full").Body.Bytes(), &again)
+	if again.Job == nil || again.Job.ID != queued.Job.ID {
+		t.Errorf("expected the queued job back, got %+v", again.Job)
+	}
+
+	queue.work(ctx)
+	if job, _ := queue.Get(ctx, queued.Job.ID); job.Status != domain.JobSucceeded || builder.builds != 1 {
+		t.Fatalf("expected the job to build the profile, got %+v after %d builds", job, builder.builds)
+	}
+
+	rr = get("/api/artists/artist-1/full")
+	var response ArtistProfileResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if rr.Code != http.StatusOK || response.ArtistProfile == nil || response.Artist.Name != "Radiohead" || len(response.Albums) != 1 || !response.Cached || response.RefreshJobID != "" {
+		t.Errorf("expected the stored profile, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	handler.now = func() time.Time { return now.Add(8 * 24 * time.Hour) }
+	response = ArtistProfileResponse{}
+	json.Unmarshal(get("/api/artists/artist-1/full").Body.Bytes(), &response)
+	if response.ArtistProfile == nil || response.RefreshJobID == "" {
+		t.Errorf("expected a stale profile served while it's rebuilt, got %+v", response)
+	}
+	queue.work(ctx)
+	if builder.builds != 2 {
+		t.Errorf("expected the stale profile rebuilt, got %d builds", builder.builds)
+	}
+
+	if rr := get("/api/artists/unknown/full"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown artist, got %d", rr.Code)
+	}
+}
+
+func TestBuildProfileJob_DoesNotStoreFailedProfiles(t *testing.T) {
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			return &domain.Artist{ID: id, Name: "Radiohead"}, nil
+		},
+	}
+	profiles := memoryProfileRepository{}
+	builder := &stubProfileBuilder{now: time.Now()}
+
+	err := BuildProfileJob(artists, profiles, builder)(context.Background(), json.RawMessage(`{"artist_id":"artist-1"}`))
+	if err == nil {
+		t.Error("expected the job to fail so it's retried")
+	}
+	if len(profiles) != 0 {
+		t.Errorf("expected a profile no source answered for not to be stored, got %+v", profiles)
+	}
+}
+
+func TestBuildProfileJob_StoresImage(t *testing.T) {
+	var updated *domain.Artist
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			return &domain.Artist{ID: id, Name: "Radiohead"}, nil
+		},
+		updateFunc: func(ctx context.Context, artist *domain.Artist) error {
+			updated = artist
+			return nil
+		},
+	}
+	builder := &stubProfileBuilder{now: time.Now(), sources: []string{"deezer"}, image: "https://coverartarchive.org/release/1/front-500"}
+
+	if err := BuildProfileJob(artists, memoryProfileRepository{}, builder)(context.Background(), json.RawMessage(`{"artist_id":"artist-1"}`)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if updated == nil || updated.ImageURL != builder.image {
+		t.Errorf("expected the found image stored on the artist, got %+v", updated)
+	}
+}
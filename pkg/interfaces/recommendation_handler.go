@@ -0,0 +1,59 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type RecommendationHandler struct {
+	service *RecommendationService
+}
+
+func NewRecommendationHandler(service *RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{
+		service: service,
+	}
+}
+
+func (h *RecommendationHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/recommendations/events", h.RecommendEvents).Methods("GET")
+}
+
+// RecommendEvents takes artist, city and limit (default 20, at most 100)
+func (h *RecommendationHandler) RecommendEvents(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Artist string `query:"artist" validate:"required"`
+		City   string `query:"city" validate:"required"`
+		Limit  int    `query:"limit" limit:"20,100"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	recommendations, err := h.service.RecommendEvents(r.Context(), params.Artist, params.City, params.Limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondWithError(w, http.StatusBadRequest, "artist and city are required")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to recommend events")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, recommendations)
+}
+
+func (h *RecommendationHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *RecommendationHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,158 @@
+package interfaces
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const (
+	// similarArtistLimit is how many similar artists events are looked for
+	similarArtistLimit = 25
+	// cityEventsLimit is how many of a city's events are searched for the
+	// similar artists' names
+	cityEventsLimit = 200
+)
+
+// SimilarArtistFinder ranks artists like a seed artist, reporting the
+// sources it couldn't ask
+type SimilarArtistFinder interface {
+	FindSimilar(ctx context.Context, seed domain.Artist, limit int) ([]domain.SimilarArtist, []string)
+}
+
+// RecommendationService finds upcoming events in a city by artists like
+// one the user names
+type RecommendationService struct {
+	aggregator AggregatorService
+	similar    SimilarArtistFinder
+	events     domain.EventRepository
+	now        func() time.Time
+}
+
+func NewRecommendationService(aggregator AggregatorService, similar SimilarArtistFinder, events domain.EventRepository) *RecommendationService {
+	return &RecommendationService{
+		aggregator: aggregator,
+		similar:    similar,
+		events:     events,
+		now:        time.Now,
+	}
+}
+
+// EventRecommendations are events by artists like Artist playing in City
+type EventRecommendations struct {
+	Artist         domain.Artist          `json:"artist"`
+	City           string                 `json:"city"`
+	SimilarArtists []domain.SimilarArtist `json:"similar_artists"`
+	Events         []RecommendedEvent     `json:"events"`
+	Errors         []string               `json:"errors,omitempty"`
+}
+
+// RecommendedEvent is an event with the similar artist on its bill that
+// earned it its place
+type RecommendedEvent struct {
+	Event   domain.Event `json:"event"`
+	Because string       `json:"because"`
+	Score   float64      `json:"score"`
+}
+
+// RecommendEvents finds artists like artistName and their upcoming events
+// in city, best match first and then soonest. Events come from a search of
+// the city's sources and from what's stored for each similar artist, so a
+// similar artist missing from the city search is still found. Parts that
+// fail are reported in Errors.
+func (s *RecommendationService) RecommendEvents(ctx context.Context, artistName, city string, limit int) (*EventRecommendations, error) {
+	artistName, city = strings.TrimSpace(artistName), strings.TrimSpace(city)
+	if artistName == "" || city == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	recommendations := &EventRecommendations{
+		City:   city,
+		Events: []RecommendedEvent{},
+		Errors: []string{},
+	}
+
+	seed, err := s.resolveArtist(ctx, artistName)
+	if err != nil {
+		recommendations.Errors = append(recommendations.Errors, "artist search: "+err.Error())
+	}
+	recommendations.Artist = seed
+
+	similar, errs := s.similar.FindSimilar(ctx, seed, similarArtistLimit)
+	recommendations.SimilarArtists = similar
+	recommendations.Errors = append(recommendations.Errors, errs...)
+	if len(similar) == 0 {
+		return recommendations, nil
+	}
+
+	now := s.now()
+	candidates := []domain.Event{}
+	cityResults, err := s.aggregator.SearchEventsByLocation(ctx, city, "", cityEventsLimit)
+	if err != nil {
+		recommendations.Errors = append(recommendations.Errors, "city search: "+err.Error())
+	} else {
+		candidates = append(candidates, cityResults.Events...)
+	}
+	for _, artist := range similar {
+		stored, err := s.events.SearchByArtistName(ctx, artist.Artist.Name, &now, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, event := range stored {
+			if domain.SameCity(event.Venue.City, city) {
+				candidates = append(candidates, event)
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, event := range candidates {
+		if seen[event.ID] || (event.DateKnown() && event.DateTime.Before(now)) || event.Status == domain.EventCancelled {
+			continue
+		}
+		// similar is best first, so the first artist on the bill is the best reason
+		for _, artist := range similar {
+			if event.Performs(artist.Artist.Name) {
+				seen[event.ID] = true
+				recommendations.Events = append(recommendations.Events, RecommendedEvent{
+					Event:   event,
+					Because: artist.Artist.Name,
+					Score:   artist.Score,
+				})
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(recommendations.Events, func(i, j int) bool {
+		a, b := recommendations.Events[i], recommendations.Events[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Event.DateTime.Before(b.Event.DateTime)
+	})
+	if limit > 0 && len(recommendations.Events) > limit {
+		recommendations.Events = recommendations.Events[:limit]
+	}
+
+	return recommendations, nil
+}
+
+// resolveArtist looks the artist up across the music sources for the
+// genres and IDs related artists are found by, falling back to the bare
+// name
+func (s *RecommendationService) resolveArtist(ctx context.Context, name string) (domain.Artist, error) {
+	results, err := s.aggregator.SearchArtists(ctx, name, 5)
+	if err != nil {
+		return domain.Artist{Name: name}, err
+	}
+	for _, artist := range results.Artists {
+		if strings.EqualFold(strings.TrimSpace(artist.Name), name) {
+			return artist, nil
+		}
+	}
+	return domain.Artist{Name: name}, nil
+}
@@ -0,0 +1,110 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+type stubSimilarFinder struct {
+	seed    domain.Artist
+	similar []domain.SimilarArtist
+}
+
+func (s *stubSimilarFinder) FindSimilar(ctx context.Context, seed domain.Artist, limit int) ([]domain.SimilarArtist, []string) {
+	s.seed = seed
+	return s.similar, []string{"lastfm: boom"}
+}
+
+func TestRecommendationService_RecommendEvents(t *testing.T) {
+	now := time.Now()
+	aggregator := &mockMegaAggregator{
+		searchArtistsFunc: func(ctx context.Context, query string, limit int) (*integrations.AggregatedResults, error) {
+			return &integrations.AggregatedResults{Artists: []domain.Artist{
+				{Name: "Radiohead Tribute"},
+				{Name: "Radiohead", Genres: []string{"art rock"}, ExternalIDs: domain.ExternalIDs{SpotifyID: "rh"}},
+			}}, nil
+		},
+		searchEventsByLocationFunc: func(ctx context.Context, city, country string, limit int) (*integrations.AggregatedResults, error) {
+			return &integrations.AggregatedResults{Events: []domain.Event{
+				{ID: "smile", ArtistName: "The Smile", DateTime: now.Add(72 * time.Hour), Venue: domain.Venue{City: city}},
+				{ID: "support", ArtistName: "Someone Else", DateTime: now.Add(24 * time.Hour), Venue: domain.Venue{City: city},
+					Lineup: []domain.EventArtist{{Name: "Someone Else"}, {Name: "Thom Yorke"}}},
+				{ID: "unrelated", ArtistName: "Nobody Like Them", DateTime: now.Add(24 * time.Hour), Venue: domain.Venue{City: city}},
+				{ID: "cancelled", ArtistName: "The Smile", DateTime: now.Add(24 * time.Hour), Venue: domain.Venue{City: city}, Status: domain.EventCancelled},
+			}}, nil
+		},
+	}
+
+	repo := newMemoryEventRepository()
+	for _, event := range []domain.Event{
+		{ID: "stored", ArtistName: "Thom Yorke", DateTime: now.Add(48 * time.Hour), Venue: domain.Venue{City: "berlin"}},
+		{ID: "elsewhere", ArtistName: "Thom Yorke", DateTime: now.Add(48 * time.Hour), Venue: domain.Venue{City: "Paris"}},
+		{ID: "smile", ArtistName: "The Smile", DateTime: now.Add(72 * time.Hour), Venue: domain.Venue{City: "Berlin"}},
+	} {
+		repo.Create(context.Background(), &event)
+	}
+
+	finder := &stubSimilarFinder{similar: []domain.SimilarArtist{
+		{Artist: domain.Artist{Name: "Thom Yorke"}, Score: 2.1},
+		{Artist: domain.Artist{Name: "The Smile"}, Score: 1.5},
+	}}
+	service := NewRecommendationService(aggregator, finder, repo)
+
+	recommendations, err := service.RecommendEvents(context.Background(), " radiohead ", "Berlin", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if finder.seed.ExternalIDs.SpotifyID != "rh" || recommendations.Artist.Name != "Radiohead" {
+		t.Errorf("expected the exact artist match used as the seed, got %+v", finder.seed)
+	}
+	var ids []string
+	for _, event := range recommendations.Events {
+		ids = append(ids, event.Event.ID+":"+event.Because)
+	}
+	want := []string{"support:Thom Yorke", "stored:Thom Yorke", "smile:The Smile"}
+	if len(ids) != len(want) || ids[0] != want[0] || ids[1] != want[1] || ids[2] != want[2] {
+		t.Errorf("expected %v, got %v", want, ids)
+	}
+	if len(recommendations.Errors) != 1 || len(recommendations.SimilarArtists) != 2 {
+		t.Errorf("unexpected recommendations %+v", recommendations)
+	}
+
+	if _, err := service.RecommendEvents(context.Background(), "Radiohead", " ", 10); err != domain.ErrInvalidRequest {
+		t.Errorf("expected ErrInvalidRequest without a city, got %v", err)
+	}
+}
+
+func TestRecommendationHandler(t *testing.T) {
+	finder := &stubSimilarFinder{}
+	service := NewRecommendationService(&mockMegaAggregator{}, finder, newMemoryEventRepository())
+	router := mux.NewRouter()
+	NewRecommendationHandler(service).RegisterRoutes(router)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/recommendations/events?artist=Radiohead&city=Berlin", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var response EventRecommendations
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.City != "Berlin" || response.Events == nil {
+		t.Errorf("unexpected response %+v", response)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/recommendations/events?artist=Radiohead", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without a city, got %d", rr.Code)
+	}
+}
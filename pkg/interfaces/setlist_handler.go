@@ -0,0 +1,150 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const setlistEventPrefix = "setlistfm_"
+
+// SetlistSource fetches a setlist by its setlist.fm ID
+type SetlistSource interface {
+	GetPastConcert(ctx context.Context, setlistID string) (*domain.PastConcert, error)
+}
+
+// TrackPreviewer finds a short audio preview of a song, returning an empty
+// URL when there is none
+type TrackPreviewer interface {
+	PreviewURL(ctx context.Context, artistName, title string) (string, error)
+}
+
+// SetlistHandler serves the songs played at past concerts
+type SetlistHandler struct {
+	events   domain.EventRepository
+	setlists SetlistSource
+	previews TrackPreviewer
+}
+
+// NewSetlistHandler takes an optional previewer; without one songs are
+// returned without preview URLs
+func NewSetlistHandler(events domain.EventRepository, setlists SetlistSource, previews TrackPreviewer) *SetlistHandler {
+	return &SetlistHandler{
+		events:   events,
+		setlists: setlists,
+		previews: previews,
+	}
+}
+
+func (h *SetlistHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/events/{id}/setlist", h.GetSetlist).Methods("GET")
+}
+
+type SetlistResponse struct {
+	EventID string `json:"event_id"`
+	domain.PastConcert
+}
+
+// GetSetlist returns the setlist of a setlist.fm event, or of a stored event
+// another source found that was merged with one. previews=true looks every
+// song up for a preview, one request each.
+func (h *SetlistHandler) GetSetlist(w http.ResponseWriter, r *http.Request) {
+	eventID := mux.Vars(r)["id"]
+
+	setlistID, err := h.setlistID(r.Context(), eventID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get event")
+		return
+	}
+	if setlistID == "" {
+		h.respondWithError(w, http.StatusNotFound, "no setlist for this event")
+		return
+	}
+
+	concert, err := h.setlists.GetPastConcert(r.Context(), setlistID)
+	if err != nil {
+		if errors.Is(err, domain.ErrEventNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "setlist not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get setlist")
+		return
+	}
+	if concert.Songs == nil {
+		concert.Songs = []domain.SetlistSong{}
+	}
+
+	if h.previews != nil && r.URL.Query().Get("previews") == "true" {
+		h.addPreviews(r.Context(), concert)
+	}
+
+	h.respondWithJSON(w, http.StatusOK, SetlistResponse{
+		EventID:     eventID,
+		PastConcert: *concert,
+	})
+}
+
+// setlistID finds the setlist.fm ID behind an event ID, or "" if it has none
+func (h *SetlistHandler) setlistID(ctx context.Context, eventID string) (string, error) {
+	if id, ok := strings.CutPrefix(eventID, setlistEventPrefix); ok {
+		return id, nil
+	}
+
+	event, err := h.events.GetByID(ctx, eventID)
+	if err != nil {
+		if errors.Is(err, domain.ErrEventNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return event.ExternalIDs.SetlistFMID, nil
+}
+
+// addPreviews looks songs up a few at a time. A failed lookup only leaves
+// that song without a preview.
+func (h *SetlistHandler) addPreviews(ctx context.Context, concert *domain.PastConcert) {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, 4)
+
+	for i := range concert.Songs {
+		song := &concert.Songs[i]
+		if song.IsTape {
+			continue
+		}
+
+		// Covers are best previewed by the original artist
+		artistName := concert.ArtistName
+		if song.CoverOf != "" {
+			artistName = song.CoverOf
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			if previewURL, err := h.previews.PreviewURL(ctx, artistName, song.Name); err == nil {
+				song.PreviewURL = previewURL
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (h *SetlistHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *SetlistHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,118 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubSetlistSource map[string]domain.PastConcert
+
+func (s stubSetlistSource) GetPastConcert(ctx context.Context, setlistID string) (*domain.PastConcert, error) {
+	concert, ok := s[setlistID]
+	if !ok {
+		return nil, domain.ErrEventNotFound
+	}
+	concert.Songs = append([]domain.SetlistSong(nil), concert.Songs...)
+	return &concert, nil
+}
+
+type stubPreviewer struct {
+	mu      sync.Mutex
+	lookups map[string]string
+}
+
+func (s *stubPreviewer) PreviewURL(ctx context.Context, artistName, title string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups[title] = artistName
+	if title == "Creep" {
+		return "", errors.New("upstream down")
+	}
+	return "https://cdn.example.com/" + title + ".mp3", nil
+}
+
+func TestSetlistHandler(t *testing.T) {
+	setlists := stubSetlistSource{
+		"63de4613": {
+			Event:  domain.Event{ID: "setlistfm_63de4613", ArtistName: "Radiohead"},
+			Source: "setlistfm",
+			Songs: []domain.SetlistSong{
+				{Name: "Intro", SetName: "Main", IsTape: true},
+				{Name: "Airbag", SetName: "Main"},
+				{Name: "Creep", SetName: "Main"},
+				{Name: "Love Will Tear Us Apart", SetName: "Encore", IsEncore: true, CoverOf: "Joy Division"},
+			},
+		},
+	}
+
+	events := newMemoryEventRepository()
+	events.events["ticketmaster_1"] = domain.Event{ID: "ticketmaster_1", ExternalIDs: domain.EventExternalIDs{SetlistFMID: "63de4613"}}
+	events.events["ticketmaster_2"] = domain.Event{ID: "ticketmaster_2"}
+
+	previews := &stubPreviewer{lookups: map[string]string{}}
+	router := mux.NewRouter()
+	NewSetlistHandler(events, setlists, previews).RegisterRoutes(router)
+
+	get := func(path string) (*httptest.ResponseRecorder, SetlistResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var response SetlistResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response
+	}
+
+	t.Run("setlist.fm event", func(t *testing.T) {
+		rr, response := get("/api/events/setlistfm_63de4613/setlist")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if len(response.Songs) != 4 || !response.Songs[3].IsEncore || response.Songs[3].CoverOf != "Joy Division" {
+			t.Errorf("unexpected songs %+v", response.Songs)
+		}
+		if response.Songs[1].PreviewURL != "" || len(previews.lookups) != 0 {
+			t.Error("expected no previews unless asked for")
+		}
+	})
+
+	t.Run("merged event", func(t *testing.T) {
+		rr, response := get("/api/events/ticketmaster_1/setlist")
+		if rr.Code != http.StatusOK || response.EventID != "ticketmaster_1" || len(response.Songs) != 4 {
+			t.Errorf("expected the merged setlist, got %d %s", rr.Code, rr.Body.String())
+		}
+	})
+
+	t.Run("previews", func(t *testing.T) {
+		_, response := get("/api/events/setlistfm_63de4613/setlist?previews=true")
+
+		if response.Songs[1].PreviewURL != "https://cdn.example.com/Airbag.mp3" {
+			t.Errorf("expected a preview for Airbag, got %+v", response.Songs[1])
+		}
+		if response.Songs[2].PreviewURL != "" {
+			t.Errorf("expected a failed lookup to leave no preview, got %+v", response.Songs[2])
+		}
+		if _, looked := previews.lookups["Intro"]; looked {
+			t.Error("expected tapes not to be looked up")
+		}
+		if previews.lookups["Love Will Tear Us Apart"] != "Joy Division" {
+			t.Errorf("expected covers to be looked up by the original artist, got %q", previews.lookups["Love Will Tear Us Apart"])
+		}
+	})
+
+	t.Run("no setlist", func(t *testing.T) {
+		for _, id := range []string{"ticketmaster_2", "unknown", "setlistfm_missing"} {
+			if rr, _ := get("/api/events/" + id + "/setlist"); rr.Code != http.StatusNotFound {
+				t.Errorf("%s: expected status 404, got %d", id, rr.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// SourceDebugger runs one source's searches and captures its raw responses
+type SourceDebugger interface {
+	DebugSource(ctx context.Context, name, artistName string, limit int) (*integrations.SourceDebugResult, error)
+}
+
+// SourceDebugHandler shows operators what a source received upstream next
+// to what it made of it. Raw payloads can be large and reveal upstream
+// details, so it sits behind the admin token.
+type SourceDebugHandler struct {
+	token    string
+	debugger SourceDebugger
+}
+
+func NewSourceDebugHandler(token string, debugger SourceDebugger) *SourceDebugHandler {
+	return &SourceDebugHandler{
+		token:    token,
+		debugger: debugger,
+	}
+}
+
+func (h *SourceDebugHandler) RegisterRoutes(router *mux.Router) {
+	router.Handle("/api/debug/source/{name}/raw", RequireAdmin(h.token)(http.HandlerFunc(h.GetRaw))).Methods("GET")
+}
+
+// GetRaw searches the source for ?artist= and returns the captured upstream
+// responses with the artists, events or concerts they converted to. A
+// failing search answers 200 with its error, since the response is the
+// point.
+func (h *SourceDebugHandler) GetRaw(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Artist string `query:"artist" validate:"required"`
+		Limit  int    `query:"limit" limit:"10,50"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+	defer cancel()
+
+	result, err := h.debugger.DebugSource(ctx, mux.Vars(r)["name"], params.Artist, params.Limit)
+	switch {
+	case errors.Is(err, domain.ErrSourceNotFound):
+		h.respondWithError(w, http.StatusNotFound, "source not found")
+		return
+	case errors.Is(err, domain.ErrInvalidRequest):
+		writeValidationError(w, invalidParam("artist", "is required"))
+		return
+	case err != nil:
+		h.respondWithError(w, http.StatusInternalServerError, "failed to debug source")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+func (h *SourceDebugHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *SourceDebugHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,72 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+)
+
+type stubSourceDebugger struct {
+	limit int
+}
+
+func (s *stubSourceDebugger) DebugSource(ctx context.Context, name, artistName string, limit int) (*integrations.SourceDebugResult, error) {
+	if name != "songkick" {
+		return nil, fmt.Errorf("%w: %s", domain.ErrSourceNotFound, name)
+	}
+	s.limit = limit
+	return &integrations.SourceDebugResult{
+		Source:    name,
+		Artist:    artistName,
+		Responses: []httpclient.CapturedResponse{{Method: "GET", URL: "https://api.songkick.com/api/3.0/search/artists.json", Status: 200, Body: json.RawMessage(`{"resultsPage":{}}`)}},
+		Events:    []domain.Event{{ID: "songkick_1", ArtistName: artistName}},
+	}, nil
+}
+
+func TestSourceDebugHandler_GetRaw(t *testing.T) {
+	debugger := &stubSourceDebugger{}
+	router := mux.NewRouter()
+	NewSourceDebugHandler("admin-secret", debugger).RegisterRoutes(router)
+
+	do := func(path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do("/api/debug/source/songkick/raw?artist=Bicep", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the admin token, got %d", rr.Code)
+	}
+
+	rr := do("/api/debug/source/songkick/raw?artist=Bicep", "admin-secret")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result integrations.SourceDebugResult
+	json.NewDecoder(rr.Body).Decode(&result)
+	if len(result.Responses) != 1 || string(result.Responses[0].Body) != `{"resultsPage":{}}` || len(result.Events) != 1 {
+		t.Errorf("expected the raw response and the converted event, got %+v", result)
+	}
+	if debugger.limit != 10 {
+		t.Errorf("expected the default limit of 10, got %d", debugger.limit)
+	}
+
+	if rr := do("/api/debug/source/nope/raw?artist=Bicep", "admin-secret"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown source, got %d", rr.Code)
+	}
+	if rr := do("/api/debug/source/songkick/raw", "admin-secret"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without an artist, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,147 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// SourceConfigurer applies operators' source settings to a running aggregator
+type SourceConfigurer interface {
+	HasSource(name string) bool
+	ApplySourceSetting(setting domain.SourceSetting) error
+	GetSourceStats() map[string]integrations.SourceInfo
+}
+
+// SourceSettingsHandler lets operators turn sources off, say a scraper that
+// started failing or getting blocked, or re-weight or re-limit them without
+// a restart. It sits behind the admin token.
+type SourceSettingsHandler struct {
+	token    string
+	sources  SourceConfigurer
+	settings domain.SourceSettingsRepository
+}
+
+func NewSourceSettingsHandler(token string, sources SourceConfigurer, settings domain.SourceSettingsRepository) *SourceSettingsHandler {
+	return &SourceSettingsHandler{
+		token:    token,
+		sources:  sources,
+		settings: settings,
+	}
+}
+
+func (h *SourceSettingsHandler) RegisterRoutes(router *mux.Router) {
+	router.Handle("/api/sources/{name}", RequireAdmin(h.token)(http.HandlerFunc(h.UpdateSource))).Methods("PATCH")
+}
+
+// maxSourceTimeoutMS caps the timeout operators can give a source
+const maxSourceTimeoutMS = 120000
+
+// UpdateSourceRequest changes only the fields that are set. Setting a limit
+// to 0 goes back to the configured one.
+type UpdateSourceRequest struct {
+	Enabled       *bool    `json:"enabled"`
+	Weight        *float64 `json:"weight"`
+	TimeoutMS     *int     `json:"timeout_ms"`
+	MaxResults    *int     `json:"max_results"`
+	MaxConcurrent *int     `json:"max_concurrent"`
+}
+
+type SourceResponse struct {
+	Name string `json:"name"`
+	integrations.SourceInfo
+}
+
+// UpdateSource applies the new setting before storing it, so a setting the
+// aggregator rejects is never kept. When storing fails the previous setting
+// is applied again, so a source is never running with a setting that would
+// be lost on restart.
+func (h *SourceSettingsHandler) UpdateSource(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if !h.sources.HasSource(name) {
+		h.respondWithError(w, http.StatusNotFound, "source not found")
+		return
+	}
+
+	var req UpdateSourceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	if req.Enabled == nil && req.Weight == nil && req.TimeoutMS == nil && req.MaxResults == nil && req.MaxConcurrent == nil {
+		writeValidationError(w, invalidField("enabled", "or weight or a limit is required"))
+		return
+	}
+	if req.Weight != nil && (*req.Weight < 0 || *req.Weight > 1) {
+		writeValidationError(w, invalidField("weight", "must be between 0 and 1"))
+		return
+	}
+	if req.TimeoutMS != nil && (*req.TimeoutMS < 0 || *req.TimeoutMS > maxSourceTimeoutMS) {
+		writeValidationError(w, invalidField("timeout_ms", fmt.Sprintf("must be between 0 and %d", maxSourceTimeoutMS)))
+		return
+	}
+	if req.MaxResults != nil && *req.MaxResults < 0 {
+		writeValidationError(w, invalidField("max_results", "must not be negative"))
+		return
+	}
+	if req.MaxConcurrent != nil && *req.MaxConcurrent < 0 {
+		writeValidationError(w, invalidField("max_concurrent", "must not be negative"))
+		return
+	}
+
+	setting, err := h.settings.Get(r.Context(), name)
+	if errors.Is(err, domain.ErrSettingNotFound) {
+		setting = &domain.SourceSetting{Source: name, Enabled: true}
+	} else if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get source setting")
+		return
+	}
+
+	previous := *setting
+
+	if req.Enabled != nil {
+		setting.Enabled = *req.Enabled
+	}
+	if req.Weight != nil {
+		setting.Weight = req.Weight
+	}
+	if req.TimeoutMS != nil {
+		setting.TimeoutMS = *req.TimeoutMS
+	}
+	if req.MaxResults != nil {
+		setting.MaxResults = *req.MaxResults
+	}
+	if req.MaxConcurrent != nil {
+		setting.MaxConcurrent = *req.MaxConcurrent
+	}
+
+	if err := h.sources.ApplySourceSetting(*setting); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to apply source setting")
+		return
+	}
+	if err := h.settings.Save(r.Context(), setting); err != nil {
+		h.sources.ApplySourceSetting(previous)
+		h.respondWithError(w, http.StatusInternalServerError, "failed to save source setting")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, SourceResponse{
+		Name:       name,
+		SourceInfo: h.sources.GetSourceStats()[name],
+	})
+}
+
+func (h *SourceSettingsHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *SourceSettingsHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
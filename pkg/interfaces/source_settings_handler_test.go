@@ -0,0 +1,148 @@
+package interfaces
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+type memorySourceSettings map[string]domain.SourceSetting
+
+func (m memorySourceSettings) Get(ctx context.Context, source string) (*domain.SourceSetting, error) {
+	setting, ok := m[source]
+	if !ok {
+		return nil, domain.ErrSettingNotFound
+	}
+	return &setting, nil
+}
+
+func (m memorySourceSettings) Save(ctx context.Context, setting *domain.SourceSetting) error {
+	m[setting.Source] = *setting
+	return nil
+}
+
+func (m memorySourceSettings) List(ctx context.Context) ([]domain.SourceSetting, error) {
+	settings := []domain.SourceSetting{}
+	for _, setting := range m {
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// stubSourceConfigurer knows a single source
+type stubSourceConfigurer struct {
+	applied domain.SourceSetting
+	err     error
+}
+
+func (s *stubSourceConfigurer) HasSource(name string) bool {
+	return name == "songkick"
+}
+
+func (s *stubSourceConfigurer) ApplySourceSetting(setting domain.SourceSetting) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.applied = setting
+	return nil
+}
+
+func (s *stubSourceConfigurer) GetSourceStats() map[string]integrations.SourceInfo {
+	info := integrations.SourceInfo{Type: "events", Status: "active", Enabled: s.applied.Enabled, Weight: 0.95}
+	if !info.Enabled {
+		info.Status = "disabled"
+	}
+	if s.applied.Weight != nil {
+		info.Weight = *s.applied.Weight
+	}
+	info.TimeoutMS = int64(s.applied.TimeoutMS)
+	return map[string]integrations.SourceInfo{"songkick": info}
+}
+
+func TestSourceSettingsHandler(t *testing.T) {
+	aggregator := &stubSourceConfigurer{}
+	settings := memorySourceSettings{}
+
+	router := mux.NewRouter()
+	NewSourceSettingsHandler("admin-secret", aggregator, settings).RegisterRoutes(router)
+
+	patch := func(name, body string) (*httptest.ResponseRecorder, SourceResponse) {
+		req, _ := http.NewRequest("PATCH", "/api/sources/"+name, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer admin-secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var response SourceResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr, response
+	}
+
+	req, _ := http.NewRequest("PATCH", "/api/sources/songkick", bytes.NewBufferString(`{"enabled": false}`))
+	anonymous := httptest.NewRecorder()
+	router.ServeHTTP(anonymous, req)
+	if anonymous.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without the admin token, got %d", anonymous.Code)
+	}
+	if _, ok := settings["songkick"]; ok {
+		t.Fatal("expected nothing stored without the admin token")
+	}
+
+	rr, response := patch("songkick", `{"enabled": false}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if response.Name != "songkick" || response.Enabled || response.Status != "disabled" {
+		t.Errorf("expected songkick to be disabled, got %+v", response)
+	}
+	if settings["songkick"].Enabled {
+		t.Error("expected the setting to be stored")
+	}
+
+	rr, response = patch("songkick", `{"weight": 0.5}`)
+	if rr.Code != http.StatusOK || response.Weight != 0.5 || response.Enabled {
+		t.Errorf("expected only the weight to change, got %d %+v", rr.Code, response)
+	}
+	if stored := settings["songkick"]; stored.Enabled || stored.Weight == nil || *stored.Weight != 0.5 {
+		t.Errorf("unexpected stored setting %+v", stored)
+	}
+
+	rr, response = patch("songkick", `{"timeout_ms": 20000, "max_concurrent": 2}`)
+	if rr.Code != http.StatusOK || response.TimeoutMS != 20000 || response.Weight != 0.5 {
+		t.Errorf("expected only the limits to change, got %d %+v", rr.Code, response)
+	}
+	if stored := settings["songkick"]; stored.TimeoutMS != 20000 || stored.MaxConcurrent != 2 || stored.MaxResults != 0 {
+		t.Errorf("unexpected stored limits %+v", stored)
+	}
+
+	for body, code := range map[string]int{
+		`{}`:                     http.StatusBadRequest,
+		`{"weight": 1.5}`:        http.StatusBadRequest,
+		`{"timeout_ms": -1}`:     http.StatusBadRequest,
+		`{"timeout_ms": 600000}`: http.StatusBadRequest,
+		`{"max_results": -5}`:    http.StatusBadRequest,
+		`not json`:               http.StatusBadRequest,
+	} {
+		if rr, _ := patch("songkick", body); rr.Code != code {
+			t.Errorf("%s: expected status %d, got %d", body, code, rr.Code)
+		}
+	}
+	if rr, _ := patch("unknown", `{"enabled": false}`); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown source, got %d", rr.Code)
+	}
+
+	aggregator.err = errors.New("ranker does not support source weights")
+	if rr, _ := patch("songkick", `{"weight": 0.2}`); rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500 when the setting can't be applied, got %d", rr.Code)
+	}
+	if stored := settings["songkick"]; stored.Weight == nil || *stored.Weight != 0.5 {
+		t.Errorf("expected a rejected setting not to be stored, got %+v", stored)
+	}
+}
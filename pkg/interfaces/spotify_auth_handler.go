@@ -0,0 +1,140 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const spotifyStateCookie = "spotify_oauth_state"
+
+// SpotifyAuthHandler connects a user's Spotify account and imports the
+// artists in its library. The OAuth state carries the signed-in user through
+// Spotify's redirect, so the account is only ever imported for that user.
+type SpotifyAuthHandler struct {
+	auth    *AuthService
+	service *SpotifyImportService
+}
+
+func NewSpotifyAuthHandler(auth *AuthService, service *SpotifyImportService) *SpotifyAuthHandler {
+	return &SpotifyAuthHandler{
+		auth:    auth,
+		service: service,
+	}
+}
+
+func (h *SpotifyAuthHandler) RegisterRoutes(router *mux.Router) {
+	requireUser := RequireUser(h.auth)
+	router.Handle("/api/auth/spotify/login", requireUser(http.HandlerFunc(h.Login))).Methods("POST")
+	router.HandleFunc("/api/auth/spotify/callback", h.Callback).Methods("GET")
+	router.Handle("/api/import/spotify", requireUser(http.HandlerFunc(h.ImportLibrary))).Methods("POST")
+}
+
+type SpotifyLoginResponse struct {
+	AuthorizeURL string `json:"authorize_url"`
+}
+
+// Login starts connecting the user's Spotify account. The browser is sent to
+// the returned URL; Spotify redirects back to the callback.
+func (h *SpotifyAuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+	state, err := h.auth.IssueOAuthState(userID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     spotifyStateCookie,
+		Value:    state,
+		Path:     "/api/auth/spotify",
+		MaxAge:   int((10 * time.Minute).Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	h.respondWithJSON(w, http.StatusOK, SpotifyLoginResponse{AuthorizeURL: h.service.AuthorizeURL(state)})
+}
+
+func (h *SpotifyAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	if authErr := r.URL.Query().Get("error"); authErr != "" {
+		h.respondWithError(w, http.StatusBadRequest, "spotify authorization failed: "+authErr)
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(spotifyStateCookie)
+	if err != nil || cookie.Value == "" || cookie.Value != state {
+		h.respondWithError(w, http.StatusBadRequest, "invalid oauth state")
+		return
+	}
+	userID, err := h.auth.VerifyOAuthState(state)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "invalid oauth state")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:   spotifyStateCookie,
+		Path:   "/api/auth/spotify",
+		MaxAge: -1,
+	})
+
+	code := r.URL.Query().Get("code")
+	if code == "" {
+		h.respondWithError(w, http.StatusBadRequest, "query parameter 'code' is required")
+		return
+	}
+
+	result, err := h.service.CompleteAuthorization(ctx, userID, code)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadGateway, "failed to import spotify library")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+// ImportLibrary re-imports the Spotify account the user connected
+func (h *SpotifyAuthHandler) ImportLibrary(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+	userID, _ := UserIDFromContext(ctx)
+
+	result, err := h.service.ImportLibrary(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTokenNotFound):
+			h.respondWithError(w, http.StatusNotFound, "spotify account not connected")
+		default:
+			h.respondWithError(w, http.StatusBadGateway, "failed to import spotify library")
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, result)
+}
+
+func (h *SpotifyAuthHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *SpotifyAuthHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, err := json.Marshal(payload)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
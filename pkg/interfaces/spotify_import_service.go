@@ -0,0 +1,111 @@
+package interfaces
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// SpotifyLibraryClient is the part of the Spotify client used to import a user's library
+type SpotifyLibraryClient interface {
+	AuthorizeURL(state string) string
+	ExchangeCode(ctx context.Context, code string) (*domain.OAuthToken, error)
+	RefreshUserToken(ctx context.Context, token *domain.OAuthToken) (*domain.OAuthToken, error)
+	GetCurrentUserID(ctx context.Context, accessToken string) (string, error)
+	GetFollowedArtists(ctx context.Context, accessToken string) ([]domain.Artist, error)
+	GetTopArtists(ctx context.Context, accessToken string, limit int) ([]domain.Artist, error)
+}
+
+type SpotifyImportService struct {
+	client           SpotifyLibraryClient
+	tokenRepository  domain.OAuthTokenRepository
+	artistRepository domain.ArtistRepository
+	trackedArtists   domain.TrackedArtistRepository
+}
+
+func NewSpotifyImportService(
+	client SpotifyLibraryClient,
+	tokenRepository domain.OAuthTokenRepository,
+	artistRepository domain.ArtistRepository,
+	trackedArtists domain.TrackedArtistRepository,
+) *SpotifyImportService {
+	return &SpotifyImportService{
+		client:           client,
+		tokenRepository:  tokenRepository,
+		artistRepository: artistRepository,
+		trackedArtists:   trackedArtists,
+	}
+}
+
+func (s *SpotifyImportService) AuthorizeURL(state string) string {
+	return s.client.AuthorizeURL(state)
+}
+
+// CompleteAuthorization stores the token for the Spotify account the user
+// connected and runs the first library import
+func (s *SpotifyImportService) CompleteAuthorization(ctx context.Context, userID, code string) (*LibraryImportResult, error) {
+	token, err := s.client.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := s.client.GetCurrentUserID(ctx, token.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spotify user: %w", err)
+	}
+	token.AccountID = accountID
+	token.UserID = userID
+
+	if err := s.tokenRepository.Save(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return s.importWithToken(ctx, token)
+}
+
+// ImportLibrary re-imports the Spotify account the user connected,
+// refreshing its token when needed
+func (s *SpotifyImportService) ImportLibrary(ctx context.Context, userID string) (*LibraryImportResult, error) {
+	if userID == "" {
+		return nil, domain.ErrInvalidRequest
+	}
+
+	token, err := s.tokenRepository.GetForUser(ctx, "spotify", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if token.Expired(time.Now(), time.Minute) {
+		token, err = s.client.RefreshUserToken(ctx, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed to refresh spotify token: %w", err)
+		}
+		if err := s.tokenRepository.Save(ctx, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.importWithToken(ctx, token)
+}
+
+func (s *SpotifyImportService) importWithToken(ctx context.Context, token *domain.OAuthToken) (*LibraryImportResult, error) {
+	followed, err := s.client.GetFollowedArtists(ctx, token.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followed artists: %w", err)
+	}
+
+	top, err := s.client.GetTopArtists(ctx, token.AccessToken, 50)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top artists: %w", err)
+	}
+
+	result, err := trackImportedArtists(ctx, s.artistRepository, s.trackedArtists, append(followed, top...), "spotify", "spotify:"+token.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	result.AccountID = token.AccountID
+
+	return result, nil
+}
@@ -0,0 +1,346 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type mockSpotifyLibraryClient struct {
+	refreshCalls int
+	followed     []domain.Artist
+	top          []domain.Artist
+	followedErr  error
+}
+
+func (m *mockSpotifyLibraryClient) AuthorizeURL(state string) string {
+	return "https://accounts.spotify.com/authorize?state=" + state
+}
+
+func (m *mockSpotifyLibraryClient) ExchangeCode(ctx context.Context, code string) (*domain.OAuthToken, error) {
+	if code != "good-code" {
+		return nil, errors.New("invalid code")
+	}
+	return &domain.OAuthToken{
+		Provider:     "spotify",
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		ExpiresAt:    time.Now().Add(time.Hour),
+	}, nil
+}
+
+func (m *mockSpotifyLibraryClient) RefreshUserToken(ctx context.Context, token *domain.OAuthToken) (*domain.OAuthToken, error) {
+	m.refreshCalls++
+	refreshed := *token
+	refreshed.AccessToken = "refreshed"
+	refreshed.ExpiresAt = time.Now().Add(time.Hour)
+	return &refreshed, nil
+}
+
+func (m *mockSpotifyLibraryClient) GetCurrentUserID(ctx context.Context, accessToken string) (string, error) {
+	return "user-1", nil
+}
+
+func (m *mockSpotifyLibraryClient) GetFollowedArtists(ctx context.Context, accessToken string) ([]domain.Artist, error) {
+	return m.followed, m.followedErr
+}
+
+func (m *mockSpotifyLibraryClient) GetTopArtists(ctx context.Context, accessToken string, limit int) ([]domain.Artist, error) {
+	return m.top, nil
+}
+
+type mockTokenRepository struct {
+	tokens map[string]*domain.OAuthToken
+}
+
+func (m *mockTokenRepository) Save(ctx context.Context, token *domain.OAuthToken) error {
+	if m.tokens == nil {
+		m.tokens = make(map[string]*domain.OAuthToken)
+	}
+	m.tokens[token.Provider+":"+token.AccountID] = token
+	return nil
+}
+
+func (m *mockTokenRepository) Get(ctx context.Context, provider, accountID string) (*domain.OAuthToken, error) {
+	token, exists := m.tokens[provider+":"+accountID]
+	if !exists {
+		return nil, domain.ErrTokenNotFound
+	}
+	return token, nil
+}
+
+func (m *mockTokenRepository) GetForUser(ctx context.Context, provider, userID string) (*domain.OAuthToken, error) {
+	for _, token := range m.tokens {
+		if token.Provider == provider && token.UserID == userID {
+			return token, nil
+		}
+	}
+	return nil, domain.ErrTokenNotFound
+}
+
+type mockTrackedArtistRepository struct {
+	tracked map[string]string
+	synced  []string
+}
+
+func (m *mockTrackedArtistRepository) Track(ctx context.Context, artistID, source string) error {
+	if m.tracked == nil {
+		m.tracked = make(map[string]string)
+	}
+	if _, exists := m.tracked[artistID]; !exists {
+		m.tracked[artistID] = source
+	}
+	return nil
+}
+
+func (m *mockTrackedArtistRepository) ListDueForSync(ctx context.Context, syncedBefore time.Time, limit int) ([]domain.TrackedArtist, error) {
+	return []domain.TrackedArtist{}, nil
+}
+
+func (m *mockTrackedArtistRepository) MarkSynced(ctx context.Context, artistID string, syncedAt time.Time) error {
+	m.synced = append(m.synced, artistID)
+	return nil
+}
+
+func newTestSpotifyImportService(client *mockSpotifyLibraryClient) (*SpotifyImportService, *mockTokenRepository, *mockTrackedArtistRepository, map[string]domain.Artist) {
+	stored := map[string]domain.Artist{
+		"existing": {ID: "local_1", Name: "Already Stored", ExternalIDs: domain.ExternalIDs{SpotifyID: "existing"}},
+	}
+	artists := &mockRepository{
+		getByExternalIDFunc: func(ctx context.Context, externalID string, source string) (*domain.Artist, error) {
+			artist, exists := stored[externalID]
+			if !exists {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &artist, nil
+		},
+		createFunc: func(ctx context.Context, artist *domain.Artist) error {
+			stored[artist.ExternalIDs.SpotifyID] = *artist
+			return nil
+		},
+	}
+
+	tokens := &mockTokenRepository{}
+	tracked := &mockTrackedArtistRepository{}
+
+	return NewSpotifyImportService(client, tokens, artists, tracked), tokens, tracked, stored
+}
+
+func TestSpotifyImportService_CompleteAuthorization(t *testing.T) {
+	t.Run("stores token and imports library", func(t *testing.T) {
+		client := &mockSpotifyLibraryClient{
+			followed: []domain.Artist{
+				{ID: "spotify_new", Name: "New Artist", ExternalIDs: domain.ExternalIDs{SpotifyID: "new"}},
+				{ID: "spotify_existing", Name: "Already Stored", ExternalIDs: domain.ExternalIDs{SpotifyID: "existing"}},
+			},
+			top: []domain.Artist{
+				{ID: "spotify_new", Name: "New Artist", ExternalIDs: domain.ExternalIDs{SpotifyID: "new"}},
+			},
+		}
+		service, tokens, tracked, _ := newTestSpotifyImportService(client)
+
+		result, err := service.CompleteAuthorization(context.Background(), "u1", "good-code")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if result.AccountID != "user-1" {
+			t.Errorf("expected account user-1, got %s", result.AccountID)
+		}
+		if result.Imported != 1 {
+			t.Errorf("expected 1 new artist, got %d", result.Imported)
+		}
+		if result.Tracked != 2 {
+			t.Errorf("expected 2 tracked artists, got %d", result.Tracked)
+		}
+		if token, err := tokens.GetForUser(context.Background(), "spotify", "u1"); err != nil || token.AccountID != "user-1" {
+			t.Errorf("expected token to be stored for the user, got %+v, %v", token, err)
+		}
+		if tracked.tracked["local_1"] != "spotify:user-1" {
+			t.Errorf("expected existing artist to be tracked under its local ID, got %v", tracked.tracked)
+		}
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		service, _, _, _ := newTestSpotifyImportService(&mockSpotifyLibraryClient{})
+
+		if _, err := service.CompleteAuthorization(context.Background(), "u1", "bad-code"); err == nil {
+			t.Error("expected error for invalid code")
+		}
+	})
+}
+
+func TestSpotifyImportService_ImportLibrary(t *testing.T) {
+	t.Run("refreshes expired token", func(t *testing.T) {
+		client := &mockSpotifyLibraryClient{}
+		service, tokens, _, _ := newTestSpotifyImportService(client)
+		tokens.Save(context.Background(), &domain.OAuthToken{
+			Provider:     "spotify",
+			AccountID:    "user-1",
+			UserID:       "u1",
+			AccessToken:  "stale",
+			RefreshToken: "refresh",
+			ExpiresAt:    time.Now().Add(-time.Hour),
+		})
+
+		if _, err := service.ImportLibrary(context.Background(), "u1"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if client.refreshCalls != 1 {
+			t.Errorf("expected 1 refresh, got %d", client.refreshCalls)
+		}
+		token, _ := tokens.Get(context.Background(), "spotify", "user-1")
+		if token.AccessToken != "refreshed" {
+			t.Errorf("expected refreshed token to be stored, got %s", token.AccessToken)
+		}
+	})
+
+	t.Run("user without a connected account", func(t *testing.T) {
+		service, _, _, _ := newTestSpotifyImportService(&mockSpotifyLibraryClient{})
+
+		_, err := service.ImportLibrary(context.Background(), "u2")
+		if !errors.Is(err, domain.ErrTokenNotFound) {
+			t.Errorf("expected ErrTokenNotFound, got %v", err)
+		}
+	})
+
+	t.Run("spotify failure", func(t *testing.T) {
+		client := &mockSpotifyLibraryClient{followedErr: errors.New("status 500")}
+		service, tokens, _, _ := newTestSpotifyImportService(client)
+		tokens.Save(context.Background(), &domain.OAuthToken{
+			Provider:    "spotify",
+			AccountID:   "user-1",
+			UserID:      "u1",
+			AccessToken: "access",
+			ExpiresAt:   time.Now().Add(time.Hour),
+		})
+
+		if _, err := service.ImportLibrary(context.Background(), "u1"); err == nil {
+			t.Error("expected error when spotify fails")
+		}
+	})
+}
+
+func TestSpotifyAuthHandler(t *testing.T) {
+	auth := newTestAuthService(newMemoryUserRepository())
+	registered, err := auth.Register(context.Background(), "kim@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	userID, accessToken := registered.User.ID, registered.AccessToken
+
+	service, tokens, _, _ := newTestSpotifyImportService(&mockSpotifyLibraryClient{})
+	handler := NewSpotifyAuthHandler(auth, service)
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	do := func(method, target, token string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var state string
+	t.Run("login returns authorize URL with state cookie", func(t *testing.T) {
+		rr := do("POST", "/api/auth/spotify/login", accessToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+
+		cookies := rr.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != spotifyStateCookie {
+			t.Fatalf("expected state cookie, got %v", cookies)
+		}
+		state = cookies[0].Value
+
+		var response SpotifyLoginResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		if !strings.HasSuffix(response.AuthorizeURL, "state="+state) {
+			t.Errorf("expected authorize URL to carry state, got %s", response.AuthorizeURL)
+		}
+	})
+
+	t.Run("login requires a user", func(t *testing.T) {
+		if rr := do("POST", "/api/auth/spotify/login", "", nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rr.Code)
+		}
+	})
+
+	t.Run("callback with matching state", func(t *testing.T) {
+		rr := do("GET", "/api/auth/spotify/callback?code=good-code&state="+state, "", &http.Cookie{Name: spotifyStateCookie, Value: state})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		var result LibraryImportResult
+		if err := json.NewDecoder(rr.Body).Decode(&result); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if result.AccountID != "user-1" {
+			t.Errorf("expected account user-1, got %s", result.AccountID)
+		}
+		if token, err := tokens.GetForUser(context.Background(), "spotify", userID); err != nil || token.AccountID != "user-1" {
+			t.Errorf("expected the account to be connected to the user, got %+v, %v", token, err)
+		}
+	})
+
+	t.Run("callback with mismatched state", func(t *testing.T) {
+		rr := do("GET", "/api/auth/spotify/callback?code=good-code&state="+state, "", &http.Cookie{Name: spotifyStateCookie, Value: "other"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+	})
+
+	t.Run("callback with unsigned state", func(t *testing.T) {
+		rr := do("GET", "/api/auth/spotify/callback?code=good-code&state=abc", "", &http.Cookie{Name: spotifyStateCookie, Value: "abc"})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+	})
+
+	t.Run("callback with denied consent", func(t *testing.T) {
+		if rr := do("GET", "/api/auth/spotify/callback?error=access_denied", "", nil); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+	})
+
+	t.Run("import for connected account", func(t *testing.T) {
+		rr := do("POST", "/api/import/spotify", accessToken, nil)
+		if rr.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rr.Code)
+		}
+	})
+
+	t.Run("import requires a user", func(t *testing.T) {
+		if rr := do("POST", "/api/import/spotify?account_id=user-1", "", nil); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rr.Code)
+		}
+	})
+
+	t.Run("import without a connected account", func(t *testing.T) {
+		other, err := auth.Register(context.Background(), "sam@example.com", "correct horse")
+		if err != nil {
+			t.Fatalf("failed to register: %v", err)
+		}
+
+		if rr := do("POST", "/api/import/spotify", other.AccessToken, nil); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type SpotifyPlaylistHandler struct {
+	auth    *AuthService
+	service *SpotifyPlaylistImportService
+}
+
+func NewSpotifyPlaylistHandler(auth *AuthService, service *SpotifyPlaylistImportService) *SpotifyPlaylistHandler {
+	return &SpotifyPlaylistHandler{
+		auth:    auth,
+		service: service,
+	}
+}
+
+func (h *SpotifyPlaylistHandler) RegisterRoutes(router *mux.Router) {
+	router.Handle("/api/import/spotify-playlist", RequireUser(h.auth)(http.HandlerFunc(h.ImportPlaylist))).Methods("POST")
+}
+
+// ImportPlaylist takes {"playlist": "..."} with a share link, spotify URI or
+// ID, and answers 202 as the artists' events are still being synced
+func (h *SpotifyPlaylistHandler) ImportPlaylist(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+	userID, _ := UserIDFromContext(ctx)
+
+	var req struct {
+		Playlist string `json:"playlist" validate:"required"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	result, err := h.service.ImportPlaylist(ctx, userID, req.Playlist)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidRequest):
+			writeValidationError(w, invalidField("playlist", "must be a Spotify playlist link, URI or ID"))
+		case errors.Is(err, domain.ErrPlaylistNotFound):
+			h.respondWithError(w, http.StatusNotFound, "playlist not found or not public")
+		case errors.Is(err, domain.ErrRateLimitExceeded):
+			h.respondWithError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		default:
+			h.respondWithError(w, http.StatusBadGateway, "failed to import spotify playlist")
+		}
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusAccepted, result)
+}
+
+func (h *SpotifyPlaylistHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *SpotifyPlaylistHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
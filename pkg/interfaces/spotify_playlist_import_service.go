@@ -0,0 +1,154 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+const (
+	// playlistSyncLimit is how many events are fetched for each imported artist
+	playlistSyncLimit = 200
+	// playlistSyncTimeout bounds each imported artist's search of the sources
+	playlistSyncTimeout = 30 * time.Second
+)
+
+// SpotifyPlaylistClient is the part of the Spotify client used to import a
+// playlist's artists
+type SpotifyPlaylistClient interface {
+	GetPlaylistArtists(ctx context.Context, playlistID string) ([]domain.Artist, error)
+}
+
+// PlaylistImportResult is what importing a playlist stored and followed.
+// Syncing is how many artists are having their events fetched by the job
+// SyncJobID, which GET /api/jobs/{id} reports on.
+type PlaylistImportResult struct {
+	PlaylistID string          `json:"playlist_id"`
+	Imported   int             `json:"imported"`
+	Tracked    int             `json:"tracked"`
+	Followed   int             `json:"followed"`
+	Syncing    int             `json:"syncing"`
+	SyncJobID  string          `json:"sync_job_id,omitempty"`
+	Artists    []domain.Artist `json:"artists"`
+}
+
+// SpotifyPlaylistImportService turns a playlist into a gig radar: its
+// artists are stored, tracked and followed by the user, and their events
+// are synced straight away rather than on the next scheduled sync
+type SpotifyPlaylistImportService struct {
+	client           SpotifyPlaylistClient
+	artistRepository domain.ArtistRepository
+	trackedArtists   domain.TrackedArtistRepository
+	follows          domain.FollowRepository
+	jobs             JobEnqueuer
+}
+
+func NewSpotifyPlaylistImportService(
+	client SpotifyPlaylistClient,
+	artistRepository domain.ArtistRepository,
+	trackedArtists domain.TrackedArtistRepository,
+	follows domain.FollowRepository,
+	jobs JobEnqueuer,
+) *SpotifyPlaylistImportService {
+	return &SpotifyPlaylistImportService{
+		client:           client,
+		artistRepository: artistRepository,
+		trackedArtists:   trackedArtists,
+		follows:          follows,
+		jobs:             jobs,
+	}
+}
+
+// ImportPlaylist imports a public playlist, given as its share link, URI or
+// ID, for userID
+func (s *SpotifyPlaylistImportService) ImportPlaylist(ctx context.Context, userID, playlist string) (*PlaylistImportResult, error) {
+	playlistID, err := integrations.ParseSpotifyPlaylistID(playlist)
+	if err != nil {
+		return nil, err
+	}
+
+	artists, err := s.client.GetPlaylistArtists(ctx, playlistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlist artists: %w", err)
+	}
+
+	imported, err := trackImportedArtists(ctx, s.artistRepository, s.trackedArtists, artists, "spotify", "spotify_playlist:"+playlistID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PlaylistImportResult{
+		PlaylistID: playlistID,
+		Imported:   imported.Imported,
+		Tracked:    imported.Tracked,
+		Artists:    imported.Artists,
+	}
+
+	artistIDs := make([]string, 0, len(imported.Artists))
+	for _, artist := range imported.Artists {
+		follow := &domain.Follow{UserID: userID, ArtistID: artist.ID, ArtistName: artist.Name}
+		if err := s.follows.Follow(ctx, follow); err != nil {
+			return nil, fmt.Errorf("failed to follow %s: %w", artist.Name, err)
+		}
+		result.Followed++
+		artistIDs = append(artistIDs, artist.ID)
+	}
+
+	if s.jobs != nil && len(artistIDs) > 0 {
+		job, err := s.jobs.Enqueue(ctx, JobSyncArtists, "", syncArtistsPayload{ArtistIDs: artistIDs})
+		if err != nil {
+			return nil, fmt.Errorf("failed to queue the sync: %w", err)
+		}
+		result.Syncing = len(artistIDs)
+		result.SyncJobID = job.ID
+	}
+
+	return result, nil
+}
+
+// JobSyncArtists fetches the events of artists that were just imported,
+// rather than leaving them for the next scheduled sync
+const JobSyncArtists = "sync_artists"
+
+type syncArtistsPayload struct {
+	ArtistIDs []string `json:"artist_ids"`
+}
+
+// SyncArtistsJob fetches each artist's events one after another, so a long
+// playlist doesn't burst the sources. The job fails if any artist did, and
+// its retry is cheap for the rest, whose events are stored and still fresh.
+func SyncArtistsJob(events ArtistEventsService, trackedArtists domain.TrackedArtistRepository) JobFunc {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var job syncArtistsPayload
+		if err := json.Unmarshal(payload, &job); err != nil {
+			return fmt.Errorf("failed to decode payload: %w", err)
+		}
+
+		var failed []string
+		for _, artistID := range job.ArtistIDs {
+			artistCtx, cancel := context.WithTimeout(ctx, playlistSyncTimeout)
+			_, err := events.GetArtistEvents(artistCtx, artistID, playlistSyncLimit)
+			cancel()
+			if err != nil {
+				failed = append(failed, artistID)
+				continue
+			}
+			// An artist untracked since the import has nothing to mark
+			err = trackedArtists.MarkSynced(ctx, artistID, time.Now())
+			if err != nil && !errors.Is(err, domain.ErrArtistNotFound) {
+				return fmt.Errorf("failed to mark %s synced: %w", artistID, err)
+			}
+		}
+
+		if len(failed) > 0 {
+			return fmt.Errorf("failed to sync %d of %d artists: %s", len(failed), len(job.ArtistIDs), strings.Join(failed, ", "))
+		}
+		return nil
+	}
+}
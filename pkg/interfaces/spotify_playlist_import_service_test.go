@@ -0,0 +1,141 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+type mockSpotifyPlaylistClient struct {
+	artists map[string][]domain.Artist
+}
+
+func (m *mockSpotifyPlaylistClient) GetPlaylistArtists(ctx context.Context, playlistID string) ([]domain.Artist, error) {
+	artists, ok := m.artists[playlistID]
+	if !ok {
+		return nil, domain.ErrPlaylistNotFound
+	}
+	return artists, nil
+}
+
+type mockArtistEventsService struct {
+	failing map[string]bool
+	calls   []string
+}
+
+func (m *mockArtistEventsService) GetArtistEvents(ctx context.Context, artistID string, limit int) (*integrations.AggregatedResults, error) {
+	m.calls = append(m.calls, artistID)
+	if m.failing[artistID] {
+		return nil, errors.New("sources down")
+	}
+	return &integrations.AggregatedResults{}, nil
+}
+
+func TestSpotifyPlaylistHandler(t *testing.T) {
+	auth := newTestAuthService(newMemoryUserRepository())
+	registered, err := auth.Register(context.Background(), "kim@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	stored := map[string]domain.Artist{
+		"existing": {ID: "local_1", Name: "Already Stored", ExternalIDs: domain.ExternalIDs{SpotifyID: "existing"}},
+	}
+	artists := &mockRepository{
+		getByExternalIDFunc: func(ctx context.Context, externalID string, source string) (*domain.Artist, error) {
+			artist, exists := stored[externalID]
+			if !exists {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &artist, nil
+		},
+		createFunc: func(ctx context.Context, artist *domain.Artist) error {
+			stored[artist.ExternalIDs.SpotifyID] = *artist
+			return nil
+		},
+	}
+	client := &mockSpotifyPlaylistClient{artists: map[string][]domain.Artist{
+		"37i9dQZF1DXcBWIGoYBM5M": {
+			{ID: "spotify_new", Name: "New Artist", ExternalIDs: domain.ExternalIDs{SpotifyID: "new"}},
+			{ID: "spotify_existing", Name: "Already Stored", ExternalIDs: domain.ExternalIDs{SpotifyID: "existing"}},
+		},
+	}}
+	tracked := &mockTrackedArtistRepository{}
+	follows := &memoryFollowRepository{}
+	events := &mockArtistEventsService{failing: map[string]bool{"local_1": true}}
+
+	queue := NewJobQueue(JobQueueConfig{Jobs: newMemoryJobRepository()})
+	queue.Handle(JobSyncArtists, SyncArtistsJob(events, tracked))
+	service := NewSpotifyPlaylistImportService(client, artists, tracked, follows, queue)
+
+	router := mux.NewRouter()
+	NewSpotifyPlaylistHandler(auth, service).RegisterRoutes(router)
+
+	do := func(body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/import/spotify-playlist", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("imports, follows and syncs", func(t *testing.T) {
+		rr := do(`{"playlist":"https://open.spotify.com/playlist/37i9dQZF1DXcBWIGoYBM5M?si=x"}`, registered.AccessToken)
+		if rr.Code != http.StatusAccepted {
+			t.Fatalf("expected status 202, got %d: %s", rr.Code, rr.Body.String())
+		}
+
+		var result PlaylistImportResult
+		json.NewDecoder(rr.Body).Decode(&result)
+		if result.PlaylistID != "37i9dQZF1DXcBWIGoYBM5M" || result.Imported != 1 || result.Followed != 2 || result.Syncing != 2 || result.SyncJobID == "" {
+			t.Errorf("unexpected result %+v", result)
+		}
+
+		followed, _ := follows.ListFollows(context.Background(), registered.User.ID)
+		if len(followed) != 2 || followed[1].ArtistID != "local_1" {
+			t.Errorf("expected both artists followed under their stored IDs, got %+v", followed)
+		}
+		if tracked.tracked["local_1"] != "spotify_playlist:37i9dQZF1DXcBWIGoYBM5M" {
+			t.Errorf("expected the artists tracked from the playlist, got %v", tracked.tracked)
+		}
+		if len(events.calls) != 0 {
+			t.Fatalf("expected the sync to be left to the job, got %v", events.calls)
+		}
+
+		queue.work(context.Background())
+		if len(events.calls) != 2 || len(tracked.synced) != 1 || tracked.synced[0] != "spotify_new" {
+			t.Errorf("expected both synced and only the successful one marked, got %v and %v", events.calls, tracked.synced)
+		}
+		if job, _ := queue.Get(context.Background(), result.SyncJobID); job.Status != domain.JobQueued || job.LastError == "" {
+			t.Errorf("expected the job queued for a retry of the failed artist, got %+v", job)
+		}
+	})
+
+	for _, tt := range []struct {
+		name   string
+		body   string
+		token  string
+		status int
+	}{
+		{"requires a user", `{"playlist":"37i9dQZF1DXcBWIGoYBM5M"}`, "", http.StatusUnauthorized},
+		{"requires a playlist", `{}`, registered.AccessToken, http.StatusBadRequest},
+		{"rejects other links", `{"playlist":"https://open.spotify.com/album/abc"}`, registered.AccessToken, http.StatusBadRequest},
+		{"unknown playlist", `{"playlist":"spotify:playlist:private"}`, registered.AccessToken, http.StatusNotFound},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := do(tt.body, tt.token); rr.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, rr.Code)
+			}
+		})
+	}
+}
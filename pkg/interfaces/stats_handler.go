@@ -0,0 +1,173 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+const (
+	defaultStatsCacheTTL = 15 * time.Minute
+	// maxStatsCacheEntries bounds the cached stats; expired ones are dropped
+	// first when it's reached
+	maxStatsCacheEntries = 1000
+)
+
+// StatsHandler serves analytics over the stored events. The aggregates scan
+// every event of an artist or city, so each answer is cached for a TTL.
+type StatsHandler struct {
+	analytics domain.AnalyticsRepository
+	ttl       time.Duration
+	cache     map[string]cachedStats
+	mutex     sync.Mutex
+	now       func() time.Time
+}
+
+type cachedStats struct {
+	stats     interface{}
+	expiresAt time.Time
+}
+
+func NewStatsHandler(analytics domain.AnalyticsRepository, ttl time.Duration) *StatsHandler {
+	if ttl <= 0 {
+		ttl = defaultStatsCacheTTL
+	}
+
+	return &StatsHandler{
+		analytics: analytics,
+		ttl:       ttl,
+		cache:     make(map[string]cachedStats),
+		now:       time.Now,
+	}
+}
+
+func (h *StatsHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/stats/artist/{id}", h.GetArtistStats).Methods("GET")
+	router.HandleFunc("/api/stats/city/{city}", h.GetCityStats).Methods("GET")
+}
+
+// GetArtistStats counts the artist's shows by year, ranks the `limit`
+// cities they played most (default 10, at most 50) and shows how big their
+// venues were each year
+func (h *StatsHandler) GetArtistStats(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimSpace(mux.Vars(r)["id"])
+	if id == "" {
+		h.respondWithError(w, http.StatusBadRequest, "artist ID is required")
+		return
+	}
+
+	params := struct {
+		Limit int `query:"limit" limit:"10,50"`
+	}{}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	key := fmt.Sprintf("artist:%s:%d", id, params.Limit)
+	stats, ok := h.cached(key)
+	if !ok {
+		artistStats, err := h.analytics.ArtistStats(r.Context(), id, params.Limit)
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "artist not found")
+			return
+		}
+		if err != nil {
+			h.respondWithError(w, http.StatusInternalServerError, "failed to get artist stats")
+			return
+		}
+		stats = artistStats
+		h.store(key, stats)
+	}
+
+	setCacheHeaders(w, r, "", time.Time{}, h.ttl)
+	h.respondWithJSON(w, http.StatusOK, stats)
+}
+
+// GetCityStats counts the city's events by month and ranks the `limit`
+// genres of their headliners (default 10, at most 50)
+func (h *StatsHandler) GetCityStats(w http.ResponseWriter, r *http.Request) {
+	city := strings.TrimSpace(mux.Vars(r)["city"])
+	if city == "" {
+		h.respondWithError(w, http.StatusBadRequest, "city is required")
+		return
+	}
+
+	params := struct {
+		Limit int `query:"limit" limit:"10,50"`
+	}{}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	key := fmt.Sprintf("city:%s:%d", strings.ToLower(city), params.Limit)
+	stats, ok := h.cached(key)
+	if !ok {
+		cityStats, err := h.analytics.CityStats(r.Context(), city, params.Limit)
+		if err != nil {
+			h.respondWithError(w, http.StatusInternalServerError, "failed to get city stats")
+			return
+		}
+		stats = cityStats
+		h.store(key, stats)
+	}
+
+	setCacheHeaders(w, r, "", time.Time{}, h.ttl)
+	h.respondWithJSON(w, http.StatusOK, stats)
+}
+
+func (h *StatsHandler) cached(key string) (interface{}, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	entry, ok := h.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if !h.now().Before(entry.expiresAt) {
+		delete(h.cache, key)
+		return nil, false
+	}
+	return entry.stats, true
+}
+
+func (h *StatsHandler) store(key string, stats interface{}) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := h.now()
+	if len(h.cache) >= maxStatsCacheEntries {
+		for cachedKey, entry := range h.cache {
+			if !now.Before(entry.expiresAt) {
+				delete(h.cache, cachedKey)
+			}
+		}
+	}
+	// Still full of fresh stats, so any one of them makes room
+	for cachedKey := range h.cache {
+		if len(h.cache) < maxStatsCacheEntries {
+			break
+		}
+		delete(h.cache, cachedKey)
+	}
+
+	h.cache[key] = cachedStats{stats: stats, expiresAt: now.Add(h.ttl)}
+}
+
+func (h *StatsHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *StatsHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
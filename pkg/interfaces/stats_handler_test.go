@@ -0,0 +1,103 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubAnalytics struct {
+	calls int
+	limit int
+}
+
+func (s *stubAnalytics) ArtistStats(ctx context.Context, artistID string, limit int) (*domain.ArtistStats, error) {
+	s.calls++
+	s.limit = limit
+	if artistID != "artist_bicep" {
+		return nil, domain.ErrArtistNotFound
+	}
+	return &domain.ArtistStats{
+		ArtistID:      artistID,
+		Name:          "Bicep",
+		TotalEvents:   3,
+		EventsPerYear: []domain.YearCount{{Year: 2025, Events: 1}, {Year: 2026, Events: 2}},
+	}, nil
+}
+
+func (s *stubAnalytics) CityStats(ctx context.Context, city string, limit int) (*domain.CityStats, error) {
+	s.calls++
+	s.limit = limit
+	return &domain.CityStats{
+		City:        city,
+		TotalEvents: 2,
+		Genres:      []domain.GenreCount{{Genre: "house", Events: 2}},
+	}, nil
+}
+
+func TestStatsHandler(t *testing.T) {
+	analytics := &stubAnalytics{}
+	handler := NewStatsHandler(analytics, time.Minute)
+	now := time.Date(2026, 6, 1, 15, 30, 0, 0, time.UTC)
+	handler.now = func() time.Time { return now }
+	router := mux.NewRouter()
+	handler.RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	rr := get("/api/stats/artist/artist_bicep")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var artist domain.ArtistStats
+	if err := json.NewDecoder(rr.Body).Decode(&artist); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if artist.Name != "Bicep" || artist.TotalEvents != 3 || len(artist.EventsPerYear) != 2 || analytics.limit != 10 {
+		t.Errorf("unexpected artist stats %+v with limit %d", artist, analytics.limit)
+	}
+	if cacheControl := rr.Header().Get("Cache-Control"); cacheControl != "public, max-age=60" {
+		t.Errorf("expected the stats to be cacheable for the TTL, got %q", cacheControl)
+	}
+
+	// Served from the cache until the TTL is up
+	if rr := get("/api/stats/artist/artist_bicep"); rr.Code != http.StatusOK || analytics.calls != 1 {
+		t.Errorf("expected the cached stats, got %d after %d calls", rr.Code, analytics.calls)
+	}
+	now = now.Add(time.Minute)
+	if get("/api/stats/artist/artist_bicep"); analytics.calls != 2 {
+		t.Errorf("expected expired stats to be computed again, got %d calls", analytics.calls)
+	}
+
+	if rr := get("/api/stats/artist/missing"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown artist, got %d", rr.Code)
+	}
+
+	rr = get("/api/stats/city/New%20York?limit=500")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var city domain.CityStats
+	if err := json.NewDecoder(rr.Body).Decode(&city); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if city.City != "New York" || len(city.Genres) != 1 || analytics.limit != 50 {
+		t.Errorf("unexpected city stats %+v with limit %d", city, analytics.limit)
+	}
+
+	// City names are cached case-insensitively
+	calls := analytics.calls
+	if get("/api/stats/city/new%20york?limit=50"); analytics.calls != calls {
+		t.Errorf("expected the cached city stats, got %d calls", analytics.calls)
+	}
+}
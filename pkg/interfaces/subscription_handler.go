@@ -0,0 +1,257 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// SubscriptionHandler manages what a user wants to hear about: followed
+// artists, saved searches and how often the digest email goes out
+type SubscriptionHandler struct {
+	auth        *AuthService
+	follows     domain.FollowRepository
+	searches    domain.SavedSearchRepository
+	preferences domain.NotificationPreferencesRepository
+}
+
+func NewSubscriptionHandler(auth *AuthService, follows domain.FollowRepository, searches domain.SavedSearchRepository, preferences domain.NotificationPreferencesRepository) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		auth:        auth,
+		follows:     follows,
+		searches:    searches,
+		preferences: preferences,
+	}
+}
+
+func (h *SubscriptionHandler) RegisterRoutes(router *mux.Router) {
+	requireUser := RequireUser(h.auth)
+	handle := func(path string, fn http.HandlerFunc, method string) {
+		router.Handle(path, requireUser(fn)).Methods(method)
+	}
+
+	handle("/api/me/follows", h.ListFollows, "GET")
+	handle("/api/me/follows", h.Follow, "POST")
+	handle("/api/me/follows/{artist}", h.Unfollow, "DELETE")
+	handle("/api/me/searches", h.ListSavedSearches, "GET")
+	handle("/api/me/searches", h.CreateSavedSearch, "POST")
+	handle("/api/me/searches/{id}", h.DeleteSavedSearch, "DELETE")
+	handle("/api/me/notifications", h.GetPreferences, "GET")
+	handle("/api/me/notifications", h.UpdatePreferences, "PUT")
+
+	// Linked from digest emails, so the token stands in for a login. POST
+	// is the one-click List-Unsubscribe form mail clients use.
+	router.HandleFunc("/api/notifications/unsubscribe", h.Unsubscribe).Methods("GET", "POST")
+}
+
+func (h *SubscriptionHandler) ListFollows(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	follows, err := h.follows.ListFollows(r.Context(), userID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to list follows")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{"follows": follows})
+}
+
+func (h *SubscriptionHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	var follow domain.Follow
+	if err := decodeJSON(r, &follow); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	if strings.TrimSpace(follow.ArtistName) == "" {
+		writeValidationError(w, invalidField("artist_name", "is required"))
+		return
+	}
+	follow.UserID = userID
+
+	if err := h.follows.Follow(r.Context(), &follow); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to follow artist")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, follow)
+}
+
+func (h *SubscriptionHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	err := h.follows.Unfollow(r.Context(), userID, mux.Vars(r)["artist"])
+	if err != nil {
+		if errors.Is(err, domain.ErrFollowNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "not following this artist")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to unfollow artist")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SubscriptionHandler) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	searches, err := h.searches.List(r.Context(), userID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to list saved searches")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{"searches": searches})
+}
+
+func (h *SubscriptionHandler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	var search domain.SavedSearch
+	if err := decodeJSON(r, &search); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	if strings.TrimSpace(search.Artist) == "" && strings.TrimSpace(search.City) == "" {
+		writeValidationError(w, &ValidationErrors{Body: true, Fields: []FieldError{
+			{Field: "artist", Message: "or city is required"},
+			{Field: "city", Message: "or artist is required"},
+		}})
+		return
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	search.ID = "search_" + id
+	search.UserID = userID
+
+	if err := h.searches.Create(r.Context(), &search); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to save search")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, search)
+}
+
+func (h *SubscriptionHandler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	err := h.searches.Delete(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrSearchNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "saved search not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to delete saved search")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPreferences reports digests as off until the user picks a frequency
+func (h *SubscriptionHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	prefs, err := h.preferences.Get(r.Context(), userID)
+	if errors.Is(err, domain.ErrPreferencesNotSet) {
+		prefs, err = &domain.NotificationPreferences{UserID: userID, DigestFrequency: domain.DigestOff}, nil
+	}
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get notification preferences")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, prefs)
+}
+
+func (h *SubscriptionHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	var request struct {
+		DigestFrequency domain.DigestFrequency `json:"digest_frequency" validate:"required,oneof=off daily weekly"`
+	}
+	if err := decodeJSON(r, &request); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	prefs, err := h.getOrCreatePreferences(r.Context(), userID)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to update notification preferences")
+		return
+	}
+	prefs.DigestFrequency = request.DigestFrequency
+
+	if err := h.preferences.Save(r.Context(), prefs); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to update notification preferences")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, prefs)
+}
+
+func (h *SubscriptionHandler) getOrCreatePreferences(ctx context.Context, userID string) (*domain.NotificationPreferences, error) {
+	prefs, err := h.preferences.Get(ctx, userID)
+	if !errors.Is(err, domain.ErrPreferencesNotSet) {
+		return prefs, err
+	}
+
+	token, err := randomHex(24)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.NotificationPreferences{UserID: userID, UnsubscribeToken: token}, nil
+}
+
+func (h *SubscriptionHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	var params struct {
+		Token string `query:"token" validate:"required"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	prefs, err := h.preferences.GetByUnsubscribeToken(ctx, params.Token)
+	if err != nil {
+		if errors.Is(err, domain.ErrPreferencesNotSet) {
+			h.respondWithError(w, http.StatusNotFound, "unknown unsubscribe token")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to unsubscribe")
+		return
+	}
+
+	prefs.DigestFrequency = domain.DigestOff
+	if err := h.preferences.Save(ctx, prefs); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to unsubscribe")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]string{"status": "unsubscribed"})
+}
+
+func (h *SubscriptionHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *SubscriptionHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,264 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type memoryFollowRepository struct {
+	mu      sync.Mutex
+	follows []domain.Follow
+}
+
+func (m *memoryFollowRepository) Follow(ctx context.Context, follow *domain.Follow) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.follows {
+		if existing.UserID == follow.UserID && strings.EqualFold(existing.ArtistName, follow.ArtistName) {
+			m.follows[i].ArtistID = follow.ArtistID
+			return nil
+		}
+	}
+	follow.CreatedAt = time.Now()
+	m.follows = append(m.follows, *follow)
+	return nil
+}
+
+func (m *memoryFollowRepository) Unfollow(ctx context.Context, userID, artistName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, existing := range m.follows {
+		if existing.UserID == userID && strings.EqualFold(existing.ArtistName, artistName) {
+			m.follows = append(m.follows[:i], m.follows[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrFollowNotFound
+}
+
+func (m *memoryFollowRepository) ListFollows(ctx context.Context, userID string) ([]domain.Follow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	follows := []domain.Follow{}
+	for _, follow := range m.follows {
+		if follow.UserID == userID {
+			follows = append(follows, follow)
+		}
+	}
+	return follows, nil
+}
+
+func (m *memoryFollowRepository) ListAllFollows(ctx context.Context) ([]domain.Follow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]domain.Follow{}, m.follows...), nil
+}
+
+type memorySavedSearchRepository struct {
+	mu       sync.Mutex
+	searches []domain.SavedSearch
+}
+
+func (m *memorySavedSearchRepository) Create(ctx context.Context, search *domain.SavedSearch) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.searches = append(m.searches, *search)
+	return nil
+}
+
+func (m *memorySavedSearchRepository) Delete(ctx context.Context, userID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, search := range m.searches {
+		if search.ID == id && search.UserID == userID {
+			m.searches = append(m.searches[:i], m.searches[i+1:]...)
+			return nil
+		}
+	}
+	return domain.ErrSearchNotFound
+}
+
+func (m *memorySavedSearchRepository) List(ctx context.Context, userID string) ([]domain.SavedSearch, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	searches := []domain.SavedSearch{}
+	for _, search := range m.searches {
+		if search.UserID == userID {
+			searches = append(searches, search)
+		}
+	}
+	return searches, nil
+}
+
+type memoryPreferencesRepository struct {
+	mu    sync.Mutex
+	prefs map[string]domain.NotificationPreferences
+}
+
+func (m *memoryPreferencesRepository) Get(ctx context.Context, userID string) (*domain.NotificationPreferences, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prefs, ok := m.prefs[userID]
+	if !ok {
+		return nil, domain.ErrPreferencesNotSet
+	}
+	return &prefs, nil
+}
+
+func (m *memoryPreferencesRepository) GetByUnsubscribeToken(ctx context.Context, token string) (*domain.NotificationPreferences, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, prefs := range m.prefs {
+		if prefs.UnsubscribeToken == token {
+			return &prefs, nil
+		}
+	}
+	return nil, domain.ErrPreferencesNotSet
+}
+
+func (m *memoryPreferencesRepository) Save(ctx context.Context, prefs *domain.NotificationPreferences) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.prefs[prefs.UserID] = *prefs
+	return nil
+}
+
+func (m *memoryPreferencesRepository) ListDueDigests(ctx context.Context, now time.Time) ([]domain.NotificationPreferences, error) {
+	return nil, nil
+}
+
+func (m *memoryPreferencesRepository) MarkDigestSent(ctx context.Context, userID string, sentAt time.Time) error {
+	return nil
+}
+
+func TestSubscriptionHandler(t *testing.T) {
+	auth := newTestAuthService(newMemoryUserRepository())
+	registered, err := auth.Register(context.Background(), "kim@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+	other, _ := auth.Register(context.Background(), "sam@example.com", "correct horse")
+
+	prefs := &memoryPreferencesRepository{prefs: map[string]domain.NotificationPreferences{}}
+	router := mux.NewRouter()
+	NewSubscriptionHandler(auth, &memoryFollowRepository{}, &memorySavedSearchRepository{}, prefs).RegisterRoutes(router)
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	token := registered.AccessToken
+
+	t.Run("requires a user", func(t *testing.T) {
+		if rr := do("GET", "/api/me/follows", "", ""); rr.Code != http.StatusUnauthorized {
+			t.Errorf("expected status 401, got %d", rr.Code)
+		}
+	})
+
+	t.Run("follows", func(t *testing.T) {
+		if rr := do("POST", "/api/me/follows", `{"artist_name":"Radiohead"}`, token); rr.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", rr.Code)
+		}
+		if rr := do("POST", "/api/me/follows", `{}`, token); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+
+		rr := do("GET", "/api/me/follows", "", token)
+		if !strings.Contains(rr.Body.String(), `"artist_name":"Radiohead"`) {
+			t.Errorf("expected Radiohead, got %s", rr.Body.String())
+		}
+		if rr := do("GET", "/api/me/follows", "", other.AccessToken); strings.Contains(rr.Body.String(), "Radiohead") {
+			t.Error("expected follows to be per user")
+		}
+
+		if rr := do("DELETE", "/api/me/follows/radiohead", "", token); rr.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", rr.Code)
+		}
+		if rr := do("DELETE", "/api/me/follows/radiohead", "", token); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+
+	t.Run("saved searches", func(t *testing.T) {
+		rr := do("POST", "/api/me/searches", `{"city":"Berlin"}`, token)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", rr.Code)
+		}
+		var search domain.SavedSearch
+		json.Unmarshal(rr.Body.Bytes(), &search)
+		if !strings.HasPrefix(search.ID, "search_") {
+			t.Errorf("unexpected search ID %q", search.ID)
+		}
+
+		if rr := do("POST", "/api/me/searches", `{"artist":" "}`, token); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+		if rr := do("DELETE", "/api/me/searches/"+search.ID, "", other.AccessToken); rr.Code != http.StatusNotFound {
+			t.Errorf("expected other users to get 404, got %d", rr.Code)
+		}
+		if rr := do("DELETE", "/api/me/searches/"+search.ID, "", token); rr.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", rr.Code)
+		}
+	})
+
+	t.Run("digest preferences and unsubscribe", func(t *testing.T) {
+		rr := do("GET", "/api/me/notifications", "", token)
+		if !strings.Contains(rr.Body.String(), `"digest_frequency":"off"`) {
+			t.Errorf("expected digests off by default, got %s", rr.Body.String())
+		}
+
+		if rr := do("PUT", "/api/me/notifications", `{"digest_frequency":"hourly"}`, token); rr.Code != http.StatusBadRequest {
+			t.Errorf("expected status 400, got %d", rr.Code)
+		}
+		if rr := do("PUT", "/api/me/notifications", `{"digest_frequency":"weekly"}`, token); rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+
+		saved := prefs.prefs[registered.User.ID]
+		if saved.DigestFrequency != domain.DigestWeekly || saved.UnsubscribeToken == "" {
+			t.Fatalf("unexpected preferences: %+v", saved)
+		}
+		if strings.Contains(rr.Body.String(), saved.UnsubscribeToken) {
+			t.Error("expected the unsubscribe token to stay out of the API")
+		}
+
+		do("PUT", "/api/me/notifications", `{"digest_frequency":"daily"}`, token)
+		if prefs.prefs[registered.User.ID].UnsubscribeToken != saved.UnsubscribeToken {
+			t.Error("expected the unsubscribe token to stay the same")
+		}
+
+		if rr := do("POST", "/api/notifications/unsubscribe?token=wrong", "", ""); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+		if rr := do("GET", "/api/notifications/unsubscribe?token="+saved.UnsubscribeToken, "", ""); rr.Code != http.StatusOK {
+			t.Errorf("expected status 200, got %d", rr.Code)
+		}
+		if prefs.prefs[registered.User.ID].DigestFrequency != domain.DigestOff {
+			t.Error("expected digests to be turned off")
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// suggestCacheMaxAge lets clients and proxies reuse completions while someone
+// types the same prefix again
+const suggestCacheMaxAge = 5 * time.Minute
+
+// ArtistSuggester completes artist names as they're typed
+type ArtistSuggester interface {
+	Suggest(ctx context.Context, query string, limit int) []domain.Suggestion
+}
+
+// SuggestHandler serves type-ahead, which can't wait on a full search of
+// every source
+type SuggestHandler struct {
+	suggester ArtistSuggester
+}
+
+func NewSuggestHandler(suggester ArtistSuggester) *SuggestHandler {
+	return &SuggestHandler{
+		suggester: suggester,
+	}
+}
+
+func (h *SuggestHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/suggest", h.Suggest).Methods("GET")
+}
+
+type SuggestResponse struct {
+	Query       string              `json:"query"`
+	Suggestions []domain.Suggestion `json:"suggestions"`
+}
+
+// Suggest takes q and limit (default 8, at most 20) and returns artist
+// names completing q, best first
+func (h *SuggestHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query string `query:"q" validate:"required"`
+		Limit int    `query:"limit" limit:"8,20"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	suggestions := h.suggester.Suggest(r.Context(), params.Query, params.Limit)
+
+	setCacheHeaders(w, r, "", time.Time{}, suggestCacheMaxAge)
+	h.respondWithJSON(w, http.StatusOK, SuggestResponse{
+		Query:       params.Query,
+		Suggestions: suggestions,
+	})
+}
+
+func (h *SuggestHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
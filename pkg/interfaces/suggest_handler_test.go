@@ -0,0 +1,67 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubSuggester struct {
+	query       string
+	limit       int
+	suggestions []domain.Suggestion
+}
+
+func (s *stubSuggester) Suggest(ctx context.Context, query string, limit int) []domain.Suggestion {
+	s.query, s.limit = query, limit
+	return s.suggestions
+}
+
+func TestSuggestHandler(t *testing.T) {
+	suggester := &stubSuggester{suggestions: []domain.Suggestion{
+		{Name: "Radiohead", ArtistID: "a1", Popularity: 90},
+		{Name: "Radio Moscow", ArtistID: "deezer_7", Source: "deezer"},
+	}}
+	router := mux.NewRouter()
+	NewSuggestHandler(suggester).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/suggest?q=rad")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if suggester.query != "rad" || suggester.limit != 8 {
+		t.Errorf("unexpected suggestion %q %d", suggester.query, suggester.limit)
+	}
+	if rr.Header().Get("Cache-Control") != "public, max-age=300" {
+		t.Errorf("expected completions to be cacheable, got %q", rr.Header().Get("Cache-Control"))
+	}
+
+	var response SuggestResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.Query != "rad" || len(response.Suggestions) != 2 || response.Suggestions[1].Source != "deezer" {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+
+	get("/api/suggest?q=rad&limit=500")
+	if suggester.limit != 20 {
+		t.Errorf("expected the limit capped at 20, got %d", suggester.limit)
+	}
+
+	for _, path := range []string{"/api/suggest", "/api/suggest?q=%20"} {
+		if rr := get(path); rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %d", path, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// telegramLinkCodeTTL is how long a user has to open the bot after asking
+// for a link
+const telegramLinkCodeTTL = 15 * time.Minute
+
+// TelegramHandler lets users link a Telegram chat to their account for
+// event alerts
+type TelegramHandler struct {
+	auth        *AuthService
+	links       domain.TelegramLinkRepository
+	botUsername string
+	now         func() time.Time
+}
+
+func NewTelegramHandler(auth *AuthService, links domain.TelegramLinkRepository, botUsername string) *TelegramHandler {
+	return &TelegramHandler{
+		auth:        auth,
+		links:       links,
+		botUsername: botUsername,
+		now:         time.Now,
+	}
+}
+
+func (h *TelegramHandler) RegisterRoutes(router *mux.Router) {
+	requireUser := RequireUser(h.auth)
+	router.Handle("/api/me/telegram", requireUser(http.HandlerFunc(h.GetLink))).Methods("GET")
+	router.Handle("/api/me/telegram", requireUser(http.HandlerFunc(h.Unlink))).Methods("DELETE")
+	router.Handle("/api/me/telegram/link", requireUser(http.HandlerFunc(h.CreateLink))).Methods("POST")
+}
+
+type TelegramLinkResponse struct {
+	Code      string    `json:"code"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CreateLink issues a one-time code and the bot deep link that sends it as
+// /start <code>
+func (h *TelegramHandler) CreateLink(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	code, err := randomHex(16)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	expiresAt := h.now().Add(telegramLinkCodeTTL)
+
+	if err := h.links.CreateLinkCode(r.Context(), userID, code, expiresAt); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to create telegram link")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, TelegramLinkResponse{
+		Code:      code,
+		URL:       "https://t.me/" + url.PathEscape(h.botUsername) + "?start=" + code,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (h *TelegramHandler) GetLink(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	link, err := h.links.Get(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrLinkNotFound) {
+			h.respondWithJSON(w, http.StatusOK, map[string]interface{}{"linked": false})
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get telegram link")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"linked":    true,
+		"linked_at": link.LinkedAt,
+	})
+}
+
+func (h *TelegramHandler) Unlink(w http.ResponseWriter, r *http.Request) {
+	userID, _ := UserIDFromContext(r.Context())
+
+	if err := h.links.Unlink(r.Context(), userID); err != nil {
+		if errors.Is(err, domain.ErrLinkNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "no telegram chat linked")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to unlink telegram")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TelegramHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *TelegramHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
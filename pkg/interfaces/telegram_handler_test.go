@@ -0,0 +1,95 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type memoryTelegramLinks struct {
+	domain.TelegramLinkRepository
+	codes map[string]string
+	links map[string]domain.TelegramLink
+}
+
+func (m *memoryTelegramLinks) CreateLinkCode(ctx context.Context, userID, code string, expiresAt time.Time) error {
+	m.codes[code] = userID
+	return nil
+}
+
+func (m *memoryTelegramLinks) Get(ctx context.Context, userID string) (*domain.TelegramLink, error) {
+	link, ok := m.links[userID]
+	if !ok {
+		return nil, domain.ErrLinkNotFound
+	}
+	return &link, nil
+}
+
+func (m *memoryTelegramLinks) Unlink(ctx context.Context, userID string) error {
+	if _, ok := m.links[userID]; !ok {
+		return domain.ErrLinkNotFound
+	}
+	delete(m.links, userID)
+	return nil
+}
+
+func TestTelegramHandler(t *testing.T) {
+	auth := newTestAuthService(newMemoryUserRepository())
+	registered, err := auth.Register(context.Background(), "kim@example.com", "correct horse")
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	links := &memoryTelegramLinks{codes: map[string]string{}, links: map[string]domain.TelegramLink{}}
+	router := mux.NewRouter()
+	NewTelegramHandler(auth, links, "WhereItsAtBot").RegisterRoutes(router)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+registered.AccessToken)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("create link", func(t *testing.T) {
+		rr := do("POST", "/api/me/telegram/link")
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("expected status 201, got %d", rr.Code)
+		}
+
+		var response TelegramLinkResponse
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		if links.codes[response.Code] != registered.User.ID {
+			t.Errorf("expected the code to be issued to the user, got %v", links.codes)
+		}
+		if response.URL != "https://t.me/WhereItsAtBot?start="+response.Code {
+			t.Errorf("unexpected deep link %q", response.URL)
+		}
+	})
+
+	t.Run("status and unlink", func(t *testing.T) {
+		if rr := do("GET", "/api/me/telegram"); !strings.Contains(rr.Body.String(), `"linked":false`) {
+			t.Errorf("expected not linked, got %s", rr.Body.String())
+		}
+
+		links.links[registered.User.ID] = domain.TelegramLink{UserID: registered.User.ID, ChatID: 42, LinkedAt: time.Now()}
+		if rr := do("GET", "/api/me/telegram"); !strings.Contains(rr.Body.String(), `"linked":true`) {
+			t.Errorf("expected linked, got %s", rr.Body.String())
+		}
+
+		if rr := do("DELETE", "/api/me/telegram"); rr.Code != http.StatusNoContent {
+			t.Errorf("expected status 204, got %d", rr.Code)
+		}
+		if rr := do("DELETE", "/api/me/telegram"); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+}
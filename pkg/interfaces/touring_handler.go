@@ -0,0 +1,112 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// touringEventLimit is enough upcoming events to count a long world tour
+const touringEventLimit = 200
+
+// TouringHandler sums up whether stored artists are on tour, from the event
+// sources' upcoming dates and the history sources' past concerts
+type TouringHandler struct {
+	artists domain.ArtistRepository
+	events  ArtistEventsService
+	history HistorySearcher
+	now     func() time.Time
+}
+
+func NewTouringHandler(artists domain.ArtistRepository, events ArtistEventsService, history HistorySearcher) *TouringHandler {
+	return &TouringHandler{
+		artists: artists,
+		events:  events,
+		history: history,
+		now:     time.Now,
+	}
+}
+
+func (h *TouringHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/artists/{id}/touring", h.GetTouring).Methods("GET")
+}
+
+// TouringResponse is the summary, with the sources that couldn't be asked
+type TouringResponse struct {
+	domain.TouringSummary
+	Errors []string `json:"errors,omitempty"`
+}
+
+// GetTouring says whether the artist is on tour, with their upcoming dates'
+// count and countries, the next show and the last one played. Upcoming and
+// past shows are looked up at once; when one side fails, the summary is
+// made from the other and the failure listed under errors.
+func (h *TouringHandler) GetTouring(w http.ResponseWriter, r *http.Request) {
+	artist, err := h.artists.GetByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "artist not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get artist")
+		return
+	}
+
+	var (
+		wg                 sync.WaitGroup
+		upcoming           *integrations.AggregatedResults
+		past               *integrations.HistoryResults
+		eventsErr, pastErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		upcoming, eventsErr = h.events.GetArtistEvents(r.Context(), artist.ID, touringEventLimit)
+	}()
+	go func() {
+		defer wg.Done()
+		// The newest concerts are on the first page
+		past, pastErr = h.history.SearchHistory(r.Context(), *artist, 1)
+	}()
+	wg.Wait()
+
+	if eventsErr != nil && pastErr != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get artist shows")
+		return
+	}
+
+	response := TouringResponse{}
+	var events []domain.Event
+	var concerts []domain.PastConcert
+	if eventsErr != nil {
+		response.Errors = append(response.Errors, "events: "+eventsErr.Error())
+	} else {
+		events = upcoming.Events
+		response.Errors = append(response.Errors, upcoming.Errors...)
+	}
+	if pastErr != nil {
+		response.Errors = append(response.Errors, "history: "+pastErr.Error())
+	} else {
+		concerts = past.Concerts
+		response.Errors = append(response.Errors, past.Errors...)
+	}
+
+	response.TouringSummary = domain.SummarizeTouring(*artist, events, concerts, h.now())
+	h.respondWithJSON(w, http.StatusOK, response)
+}
+
+func (h *TouringHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *TouringHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,98 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+type stubArtistEvents struct {
+	events []domain.Event
+	err    error
+}
+
+func (s *stubArtistEvents) GetArtistEvents(ctx context.Context, artistID string, limit int) (*integrations.AggregatedResults, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &integrations.AggregatedResults{Events: s.events}, nil
+}
+
+func TestTouringHandler(t *testing.T) {
+	now := time.Date(2017, 7, 10, 12, 0, 0, 0, time.UTC)
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != "artist-1" {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &domain.Artist{ID: id, Name: "Radiohead"}, nil
+		},
+	}
+	events := &stubArtistEvents{events: []domain.Event{
+		{ID: "songkick_1", DateTime: now.AddDate(0, 0, 4), Venue: domain.Venue{City: "Milan", Country: "IT"}},
+		{ID: "songkick_2", DateTime: now.AddDate(0, 0, 6), Venue: domain.Venue{City: "Florence", Country: "Italy"}},
+	}}
+
+	newRouter := func() *mux.Router {
+		handler := NewTouringHandler(artists, events, &stubHistorySearcher{})
+		handler.now = func() time.Time { return now }
+		router := mux.NewRouter()
+		handler.RegisterRoutes(router)
+		return router
+	}
+	get := func(path string) (*httptest.ResponseRecorder, TouringResponse) {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		newRouter().ServeHTTP(rr, req)
+
+		var response TouringResponse
+		json.NewDecoder(rr.Body).Decode(&response)
+		return rr, response
+	}
+
+	t.Run("sums up upcoming and past shows", func(t *testing.T) {
+		rr, response := get("/api/artists/artist-1/touring")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if !response.OnTour || response.UpcomingDates != 2 || response.ArtistName != "Radiohead" {
+			t.Errorf("expected Radiohead on tour with 2 dates, got %+v", response.TouringSummary)
+		}
+		if len(response.Countries) != 1 || response.Countries[0] != "Italy" {
+			t.Errorf("expected Italy, got %v", response.Countries)
+		}
+		if response.NextShow == nil || response.NextShow.ID != "songkick_1" {
+			t.Errorf("expected Milan next, got %+v", response.NextShow)
+		}
+		if response.LastShow == nil || response.LastShow.ID != "setlistfm_1" || len(response.LastShow.Songs) != 1 {
+			t.Errorf("expected the last setlist, got %+v", response.LastShow)
+		}
+	})
+
+	t.Run("falls back to the history when events fail", func(t *testing.T) {
+		events.err = errors.New("status 503")
+		defer func() { events.err = nil }()
+
+		rr, response := get("/api/artists/artist-1/touring")
+		if rr.Code != http.StatusOK {
+			t.Fatalf("expected status 200, got %d", rr.Code)
+		}
+		if response.UpcomingDates != 0 || response.LastShow == nil || len(response.Errors) != 1 {
+			t.Errorf("expected the last show and the error, got %+v", response)
+		}
+	})
+
+	t.Run("unknown artist", func(t *testing.T) {
+		if rr, _ := get("/api/artists/missing/touring"); rr.Code != http.StatusNotFound {
+			t.Errorf("expected status 404, got %d", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+// TrackLister finds an artist's top tracks across music services
+type TrackLister interface {
+	GetArtistTracks(ctx context.Context, artist domain.Artist, limit int) (*integrations.TrackResults, error)
+}
+
+// TracksHandler serves stored artists' top tracks, so UIs can play a snippet
+// next to an event listing
+type TracksHandler struct {
+	artists domain.ArtistRepository
+	tracks  TrackLister
+}
+
+func NewTracksHandler(artists domain.ArtistRepository, tracks TrackLister) *TracksHandler {
+	return &TracksHandler{
+		artists: artists,
+		tracks:  tracks,
+	}
+}
+
+func (h *TracksHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/artists/{id}/tracks", h.GetArtistTracks).Methods("GET")
+}
+
+type TracksResponse struct {
+	ArtistID string `json:"artist_id"`
+	*integrations.TrackResults
+}
+
+// GetArtistTracks returns up to limit (default 10, max 50) of the artist's
+// top tracks, those with a preview URL first
+func (h *TracksHandler) GetArtistTracks(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Limit int `query:"limit" limit:"10,50"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	limit := params.Limit
+
+	artist, err := h.artists.GetByID(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		if errors.Is(err, domain.ErrArtistNotFound) {
+			h.respondWithError(w, http.StatusNotFound, "artist not found")
+			return
+		}
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get artist")
+		return
+	}
+
+	results, err := h.tracks.GetArtistTracks(r.Context(), *artist, limit)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, "failed to get tracks")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, TracksResponse{
+		ArtistID:     artist.ID,
+		TrackResults: results,
+	})
+}
+
+func (h *TracksHandler) respondWithError(w http.ResponseWriter, code int, message string) {
+	writeError(w, code, message)
+}
+
+func (h *TracksHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
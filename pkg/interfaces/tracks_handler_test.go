@@ -0,0 +1,74 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+)
+
+type stubTrackLister struct {
+	limit int
+}
+
+func (s *stubTrackLister) GetArtistTracks(ctx context.Context, artist domain.Artist, limit int) (*integrations.TrackResults, error) {
+	s.limit = limit
+	return &integrations.TrackResults{
+		Tracks: []domain.Track{{
+			ID:         "deezer_1",
+			Title:      "Creep",
+			ArtistName: artist.Name,
+			PreviewURL: "https://cdn.example.com/creep.mp3",
+			Source:     "deezer",
+		}},
+		SourceStats: map[string]int{"deezer": 1},
+	}, nil
+}
+
+func TestTracksHandler(t *testing.T) {
+	artists := &mockRepository{
+		getByIDFunc: func(ctx context.Context, id string) (*domain.Artist, error) {
+			if id != "artist-1" {
+				return nil, domain.ErrArtistNotFound
+			}
+			return &domain.Artist{ID: id, Name: "Radiohead"}, nil
+		},
+	}
+	tracks := &stubTrackLister{}
+
+	router := mux.NewRouter()
+	NewTracksHandler(artists, tracks).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/artists/artist-1/tracks")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if tracks.limit != 10 {
+		t.Errorf("expected a default limit of 10, got %d", tracks.limit)
+	}
+
+	var response TracksResponse
+	json.Unmarshal(rr.Body.Bytes(), &response)
+	if response.ArtistID != "artist-1" || response.TrackResults == nil || len(response.Tracks) != 1 || response.Tracks[0].PreviewURL == "" {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+
+	if get("/api/artists/artist-1/tracks?limit=500"); tracks.limit != 50 {
+		t.Errorf("expected the limit to be capped at 50, got %d", tracks.limit)
+	}
+	if rr := get("/api/artists/unknown/tracks"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown artist, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,286 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Request structs declare their parameters and rules in tags:
+//
+//	type eventSearchParams struct {
+//		Artist string `query:"artist" validate:"required"`
+//		Format string `query:"format" validate:"oneof=csv jsonl"`
+//		Limit  int    `query:"limit" limit:"50,200"`
+//	}
+//
+// query names the query parameter a field is bound from; body fields use
+// their json name. validate takes comma separated rules: required,
+// min=N and max=N (a number's value or a string's length), oneof=a b c,
+// email and url. Empty strings and lists skip every rule but required.
+//
+// limit:"default,max" marks a result limit, which is forgiving the way
+// clients have always relied on: a value that isn't a positive number gets
+// the default and one above max is capped.
+
+// ValidationErrors lists every invalid field of a request
+type ValidationErrors struct {
+	// Body is set for request bodies, so the response says INVALID_BODY
+	Body   bool
+	Fields []FieldError
+}
+
+func (e *ValidationErrors) Error() string {
+	if len(e.Fields) == 0 {
+		return "invalid request"
+	}
+	return e.Fields[0].describe(e.Body)
+}
+
+func (e *ValidationErrors) add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+func (f FieldError) describe(body bool) string {
+	if body {
+		return f.Field + " " + f.Message
+	}
+	return fmt.Sprintf("query parameter '%s' %s", f.Field, f.Message)
+}
+
+// invalidField is a body field failing a check tags can't express
+func invalidField(field, message string) error {
+	return &ValidationErrors{Body: true, Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// invalidParam is a query parameter failing a check tags can't express
+func invalidParam(name, message string) error {
+	return &ValidationErrors{Fields: []FieldError{{Field: name, Message: message}}}
+}
+
+// bindQuery fills dst, a pointer to a struct, from r's query parameters and
+// validates it
+func bindQuery(r *http.Request, dst interface{}) error {
+	values := r.URL.Query()
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	errs := &ValidationErrors{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" {
+			continue
+		}
+		raw := strings.TrimSpace(values.Get(name))
+
+		if spec := field.Tag.Get("limit"); spec != "" {
+			v.Field(i).SetInt(int64(parseLimit(raw, spec)))
+			continue
+		}
+		if raw == "" {
+			continue
+		}
+		if err := setField(v.Field(i), raw); err != nil {
+			errs.add(name, err.Error())
+		}
+	}
+
+	if len(errs.Fields) > 0 {
+		return errs
+	}
+	return validateStruct(dst, false)
+}
+
+// decodeJSON decodes r's JSON body into dst, a pointer to a struct, and
+// validates it
+func decodeJSON(r *http.Request, dst interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return &ValidationErrors{Body: true, Fields: []FieldError{{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.Kind().String()}}}
+		}
+		return &ValidationErrors{Body: true, Fields: []FieldError{{Field: "body", Message: "must be valid JSON"}}}
+	}
+	return validateStruct(dst, true)
+}
+
+// validateStruct checks the validate tags of v, a pointer to a struct
+func validateStruct(v interface{}, body bool) error {
+	value := reflect.ValueOf(v).Elem()
+	t := value.Type()
+
+	errs := &ValidationErrors{Body: body}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		rules := field.Tag.Get("validate")
+		if rules == "" {
+			continue
+		}
+		if message := checkRules(value.Field(i), rules); message != "" {
+			errs.add(fieldName(field, body), message)
+		}
+	}
+
+	if len(errs.Fields) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func fieldName(field reflect.StructField, body bool) string {
+	if !body {
+		return field.Tag.Get("query")
+	}
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return field.Name
+}
+
+// checkRules returns what's wrong with value, or "" when it passes
+func checkRules(value reflect.Value, rules string) string {
+	// Only strings and lists can be left out; a number given as 0 is
+	// checked like any other, so numbers with a minimum need a default
+	var empty, optional bool
+	switch value.Kind() {
+	case reflect.String:
+		empty, optional = strings.TrimSpace(value.String()) == "", true
+	case reflect.Slice:
+		empty, optional = value.Len() == 0, true
+	default:
+		empty = value.IsZero()
+	}
+
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if empty {
+				return "is required"
+			}
+			continue
+		}
+		if empty && optional {
+			continue
+		}
+
+		switch name {
+		case "min", "max":
+			bound, _ := strconv.ParseFloat(arg, 64)
+			size, unit := measure(value)
+			if name == "min" && size < bound {
+				return "must be at least " + arg + unit
+			}
+			if name == "max" && size > bound {
+				return "must be at most " + arg + unit
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			if !contains(options, fmt.Sprint(value.Interface())) {
+				return "must be one of " + strings.Join(options, ", ")
+			}
+		case "email":
+			if _, err := mail.ParseAddress(value.String()); err != nil {
+				return "must be an email address"
+			}
+		case "url":
+			if u, err := url.Parse(value.String()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return "must be an http or https URL"
+			}
+		}
+	}
+	return ""
+}
+
+// measure is a number's value or the length of a string or slice
+func measure(value reflect.Value) (float64, string) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int64:
+		return float64(value.Int()), ""
+	case reflect.Float64:
+		return value.Float(), ""
+	case reflect.String:
+		return float64(len([]rune(value.String()))), " characters"
+	case reflect.Slice:
+		return float64(value.Len()), " items"
+	}
+	return 0, ""
+}
+
+func contains(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+// setField parses raw into a string, int, float or bool field
+func setField(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		field.SetBool(b)
+	default:
+		return fmt.Errorf("can't be bound from a %s field", field.Kind())
+	}
+	return nil
+}
+
+// parseLimit reads a forgiving limit against its "default,max" spec
+func parseLimit(raw, spec string) int {
+	defaultStr, maxStr, _ := strings.Cut(spec, ",")
+	limit, _ := strconv.Atoi(defaultStr)
+	if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+		limit = parsed
+	}
+	if max, err := strconv.Atoi(maxStr); err == nil && limit > max {
+		limit = max
+	}
+	return limit
+}
+
+// writeValidationError answers a request that failed binding or validation
+// with 400 and every invalid field
+func writeValidationError(w http.ResponseWriter, err error) {
+	var errs *ValidationErrors
+	if !errors.As(err, &errs) {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	code := CodeInvalidParam
+	if errs.Body {
+		code = CodeInvalidBody
+	}
+	writeErrorEnvelope(w, ErrorResponse{
+		Error:   errs.Error(),
+		Code:    code,
+		Status:  http.StatusBadRequest,
+		Details: errs.Fields,
+	})
+}
@@ -0,0 +1,116 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testParams struct {
+	Artist  string  `query:"artist" validate:"required,max=10"`
+	Format  string  `query:"format" validate:"oneof=csv jsonl"`
+	Page    int     `query:"page" validate:"min=1"`
+	Ratio   float64 `query:"ratio" validate:"max=1"`
+	Preview bool    `query:"preview"`
+	Limit   int     `query:"limit" limit:"50,200"`
+}
+
+func TestBindQuery(t *testing.T) {
+	bind := func(query string) (testParams, error) {
+		params := testParams{Page: 1}
+		err := bindQuery(httptest.NewRequest("GET", "/?"+query, nil), &params)
+		return params, err
+	}
+
+	params, err := bind("artist=+Radiohead+&format=jsonl&page=2&ratio=0.5&preview=true&limit=500")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	want := testParams{Artist: "Radiohead", Format: "jsonl", Page: 2, Ratio: 0.5, Preview: true, Limit: 200}
+	if params != want {
+		t.Errorf("got %+v, want %+v", params, want)
+	}
+
+	if params, _ := bind("artist=Bicep&limit=abc"); params.Limit != 50 || params.Page != 1 {
+		t.Errorf("expected the default limit and page, got %+v", params)
+	}
+
+	for _, tt := range []struct {
+		query   string
+		field   string
+		message string
+	}{
+		{"", "artist", "is required"},
+		{"artist=+", "artist", "is required"},
+		{"artist=The+Very+Long+Name", "artist", "must be at most 10 characters"},
+		{"artist=a&format=xml", "format", "must be one of csv, jsonl"},
+		{"artist=a&page=0", "page", "must be at least 1"},
+		{"artist=a&page=two", "page", "must be a whole number"},
+		{"artist=a&ratio=2", "ratio", "must be at most 1"},
+		{"artist=a&preview=maybe", "preview", "must be true or false"},
+	} {
+		_, err := bind(tt.query)
+		errs, ok := err.(*ValidationErrors)
+		if !ok || len(errs.Fields) != 1 {
+			t.Errorf("%q: expected one invalid field, got %v", tt.query, err)
+			continue
+		}
+		if got := errs.Fields[0]; got.Field != tt.field || got.Message != tt.message {
+			t.Errorf("%q: got %+v", tt.query, got)
+		}
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	type request struct {
+		Email   string   `json:"email" validate:"required,email"`
+		Webhook string   `json:"webhook_url" validate:"url"`
+		Tags    []string `json:"tags" validate:"max=2"`
+	}
+	decode := func(body string) error {
+		var req request
+		return decodeJSON(httptest.NewRequest("POST", "/", strings.NewReader(body)), &req)
+	}
+
+	if err := decode(`{"email":"a@example.com","webhook_url":"https://example.com/hook"}`); err != nil {
+		t.Errorf("expected a valid body, got %v", err)
+	}
+
+	err := decode(`{"email":"nope","webhook_url":"ftp://example.com","tags":["a","b","c"]}`)
+	errs, ok := err.(*ValidationErrors)
+	if !ok || !errs.Body || len(errs.Fields) != 3 {
+		t.Fatalf("expected every invalid field reported, got %v", err)
+	}
+	if errs.Error() != "email must be an email address" {
+		t.Errorf("unexpected message %q", errs.Error())
+	}
+
+	for body, field := range map[string]string{
+		`{"email":`:   "body",
+		`{"email":1}`: "email",
+	} {
+		errs, ok := decode(body).(*ValidationErrors)
+		if !ok || errs.Fields[0].Field != field {
+			t.Errorf("%s: expected %s reported, got %v", body, field, errs)
+		}
+	}
+}
+
+func TestWriteValidationError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set(RequestIDHeader, "req-1")
+	writeValidationError(rr, invalidField("artist_name", "is required"))
+
+	var response ErrorResponse
+	if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if rr.Code != http.StatusBadRequest || response.Code != CodeInvalidBody || response.Status != http.StatusBadRequest {
+		t.Errorf("unexpected response %d %+v", rr.Code, response)
+	}
+	if response.Error != "artist_name is required" || len(response.Details) != 1 || response.RequestID != "req-1" {
+		t.Errorf("unexpected response %+v", response)
+	}
+}
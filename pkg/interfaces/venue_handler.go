@@ -0,0 +1,107 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// VenueHandler serves the venues kept from stored events, with the
+// capacity, address, phone and website any source gave them
+type VenueHandler struct {
+	venues domain.VenueRepository
+}
+
+func NewVenueHandler(venues domain.VenueRepository) *VenueHandler {
+	return &VenueHandler{
+		venues: venues,
+	}
+}
+
+func (h *VenueHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/venues", h.ListVenues).Methods("GET")
+	router.HandleFunc("/api/venues/{slug}", h.GetVenue).Methods("GET")
+}
+
+type VenuesResponse struct {
+	Venues []domain.StoredVenue `json:"venues"`
+}
+
+type VenueResponse struct {
+	Venue  domain.StoredVenue `json:"venue"`
+	Events []domain.Event     `json:"events"`
+}
+
+// ListVenues returns venues, the ones with the most upcoming events first,
+// optionally narrowed by city and a name query, at most limit (default 50,
+// at most 200)
+func (h *VenueHandler) ListVenues(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		City  string `query:"city"`
+		Query string `query:"q"`
+		Limit int    `query:"limit" limit:"50,200"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	venues, err := h.venues.ListVenues(r.Context(), domain.VenueFilter{
+		City:  params.City,
+		Query: params.Query,
+	}, params.Limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list venues")
+		return
+	}
+	if venues == nil {
+		venues = []domain.StoredVenue{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, VenuesResponse{Venues: venues})
+}
+
+// GetVenue returns a venue by slug with its upcoming events, soonest first,
+// at most limit (default 50, at most 200)
+func (h *VenueHandler) GetVenue(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Limit int `query:"limit" limit:"50,200"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	slug := strings.ToLower(strings.TrimSpace(mux.Vars(r)["slug"]))
+	venue, err := h.venues.GetVenue(r.Context(), slug)
+	if err != nil {
+		if errors.Is(err, domain.ErrVenueNotFound) {
+			writeError(w, http.StatusNotFound, "venue not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to get venue")
+		return
+	}
+
+	events, err := h.venues.ListVenueEvents(r.Context(), slug, time.Now(), params.Limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list venue events")
+		return
+	}
+	if events == nil {
+		events = []domain.Event{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, VenueResponse{Venue: *venue, Events: events})
+}
+
+func (h *VenueHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,96 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubVenues struct {
+	filter domain.VenueFilter
+	limit  int
+	venues []domain.StoredVenue
+	events []domain.Event
+}
+
+func (s *stubVenues) GetVenue(ctx context.Context, slug string) (*domain.StoredVenue, error) {
+	for _, venue := range s.venues {
+		if venue.Slug == slug {
+			return &venue, nil
+		}
+	}
+	return nil, domain.ErrVenueNotFound
+}
+
+func (s *stubVenues) ListVenues(ctx context.Context, filter domain.VenueFilter, limit int) ([]domain.StoredVenue, error) {
+	s.filter, s.limit = filter, limit
+	return s.venues, nil
+}
+
+func (s *stubVenues) ListVenueEvents(ctx context.Context, slug string, from time.Time, limit int) ([]domain.Event, error) {
+	s.limit = limit
+	return s.events, nil
+}
+
+func TestVenueHandler(t *testing.T) {
+	venues := &stubVenues{
+		venues: []domain.StoredVenue{{
+			Venue:          domain.Venue{Name: "O2 Academy Brixton", City: "London", Capacity: 4921, Phone: "+44 20 7771 3000"},
+			Slug:           "o2-academy-brixton-london",
+			UpcomingEvents: 1,
+		}},
+		events: []domain.Event{{ID: "e1", ArtistName: "Radiohead"}},
+	}
+	router := mux.NewRouter()
+	NewVenueHandler(venues).RegisterRoutes(router)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("/api/venues?city=London&q=brixton")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if venues.filter.City != "London" || venues.filter.Query != "brixton" || venues.limit != 50 {
+		t.Errorf("unexpected filter %+v, limit %d", venues.filter, venues.limit)
+	}
+	var list VenuesResponse
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if len(list.Venues) != 1 || list.Venues[0].Capacity != 4921 {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+
+	rr = get("/api/venues/o2-academy-brixton-london?limit=500")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if venues.limit != 200 {
+		t.Errorf("expected the limit capped at 200, got %d", venues.limit)
+	}
+	var venue VenueResponse
+	json.Unmarshal(rr.Body.Bytes(), &venue)
+	if venue.Venue.Phone != "+44 20 7771 3000" || len(venue.Events) != 1 {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+
+	if rr := get("/api/venues/nowhere-london"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown venue, got %d", rr.Code)
+	}
+
+	venues.venues = nil
+	rr = get("/api/venues")
+	json.Unmarshal(rr.Body.Bytes(), &list)
+	if list.Venues == nil || len(list.Venues) != 0 {
+		t.Errorf("expected an empty list, got %s", rr.Body.String())
+	}
+}
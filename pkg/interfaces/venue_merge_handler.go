@@ -0,0 +1,130 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// VenueMergeHandler lets operators find venues stored twice under
+// different names, like "Berghain" and "Berghain / Panorama Bar", and merge
+// them. It sits behind the admin token.
+type VenueMergeHandler struct {
+	token  string
+	venues domain.VenueMergeRepository
+}
+
+func NewVenueMergeHandler(token string, venues domain.VenueMergeRepository) *VenueMergeHandler {
+	return &VenueMergeHandler{
+		token:  token,
+		venues: venues,
+	}
+}
+
+func (h *VenueMergeHandler) RegisterRoutes(router *mux.Router) {
+	requireAdmin := RequireAdmin(h.token)
+	handle := func(path string, fn http.HandlerFunc, method string) {
+		router.Handle(path, requireAdmin(fn)).Methods(method)
+	}
+
+	handle("/api/admin/venues/duplicates", h.ListDuplicates, "GET")
+	handle("/api/admin/venues/merge", h.Merge, "POST")
+	handle("/api/admin/venues/merges", h.ListMerges, "GET")
+}
+
+type VenueDuplicatesResponse struct {
+	Duplicates []domain.VenueDuplicate `json:"duplicates"`
+}
+
+type VenueMergesResponse struct {
+	Merges []domain.VenueMerge `json:"merges"`
+}
+
+// ListDuplicates returns probable duplicate venues, the most alike first,
+// optionally in one city and with a minimum name similarity (0 to 1) and
+// maximum distance in km other than the defaults
+func (h *VenueMergeHandler) ListDuplicates(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		City          string  `query:"city"`
+		MinSimilarity float64 `query:"min_similarity" validate:"min=0,max=1"`
+		MaxDistanceKm float64 `query:"max_distance_km" validate:"min=0,max=100"`
+		Limit         int     `query:"limit" limit:"50,500"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	duplicates, err := h.venues.FindDuplicateVenues(r.Context(), domain.VenueDuplicateFilter{
+		City:          params.City,
+		MinSimilarity: params.MinSimilarity,
+		MaxDistanceKm: params.MaxDistanceKm,
+	}, params.Limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to find duplicate venues")
+		return
+	}
+	if duplicates == nil {
+		duplicates = []domain.VenueDuplicate{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, VenueDuplicatesResponse{Duplicates: duplicates})
+}
+
+// Merge merges the venue ?from= into ?into=, both slugs, moving its events
+func (h *VenueMergeHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		From string `query:"from" validate:"required"`
+		Into string `query:"into" validate:"required"`
+	}
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	if params.From == params.Into {
+		writeValidationError(w, invalidParam("into", "must be another venue than from"))
+		return
+	}
+
+	merge, err := h.venues.MergeVenues(r.Context(), params.From, params.Into)
+	if err != nil {
+		if errors.Is(err, domain.ErrVenueNotFound) {
+			writeError(w, http.StatusNotFound, "venue not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to merge venues")
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, merge)
+}
+
+// ListMerges returns past merges newest first, at most limit (default 50,
+// at most 200)
+func (h *VenueMergeHandler) ListMerges(w http.ResponseWriter, r *http.Request) {
+	var params pageParams
+	if err := bindQuery(r, &params); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	merges, err := h.venues.ListVenueMerges(r.Context(), params.Limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list venue merges")
+		return
+	}
+	if merges == nil {
+		merges = []domain.VenueMerge{}
+	}
+
+	h.respondWithJSON(w, http.StatusOK, VenueMergesResponse{Merges: merges})
+}
+
+func (h *VenueMergeHandler) respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,100 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubVenueMerges struct {
+	filter     domain.VenueDuplicateFilter
+	duplicates []domain.VenueDuplicate
+	merges     []domain.VenueMerge
+}
+
+func (s *stubVenueMerges) FindDuplicateVenues(ctx context.Context, filter domain.VenueDuplicateFilter, limit int) ([]domain.VenueDuplicate, error) {
+	s.filter = filter
+	return s.duplicates, nil
+}
+
+func (s *stubVenueMerges) MergeVenues(ctx context.Context, from, into string) (*domain.VenueMerge, error) {
+	if from != "berghain-panorama-bar-berlin" || into != "berghain-berlin" {
+		return nil, domain.ErrVenueNotFound
+	}
+	merge := domain.VenueMerge{From: from, Into: into, Events: 3}
+	s.merges = append(s.merges, merge)
+	return &merge, nil
+}
+
+func (s *stubVenueMerges) ListVenueMerges(ctx context.Context, limit int) ([]domain.VenueMerge, error) {
+	return s.merges, nil
+}
+
+func TestVenueMergeHandler(t *testing.T) {
+	venues := &stubVenueMerges{duplicates: []domain.VenueDuplicate{{
+		Venue:      domain.StoredVenue{Slug: "berghain-berlin"},
+		Duplicate:  domain.StoredVenue{Slug: "berghain-panorama-bar-berlin"},
+		Similarity: 1,
+	}}}
+	router := mux.NewRouter()
+	NewVenueMergeHandler("admin-secret", venues).RegisterRoutes(router)
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	if rr := do("GET", "/api/admin/venues/duplicates", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", rr.Code)
+	}
+
+	rr := do("GET", "/api/admin/venues/duplicates?city=Berlin&min_similarity=0.8&max_distance_km=2", "admin-secret")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if venues.filter.City != "Berlin" || venues.filter.MinSimilarity != 0.8 || venues.filter.MaxDistanceKm != 2 {
+		t.Errorf("unexpected filter %+v", venues.filter)
+	}
+	var duplicates VenueDuplicatesResponse
+	json.Unmarshal(rr.Body.Bytes(), &duplicates)
+	if len(duplicates.Duplicates) != 1 || duplicates.Duplicates[0].Duplicate.Slug != "berghain-panorama-bar-berlin" {
+		t.Errorf("unexpected response %s", rr.Body.String())
+	}
+	if rr := do("GET", "/api/admin/venues/duplicates?min_similarity=2", "admin-secret"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a similarity above 1, got %d", rr.Code)
+	}
+
+	rr = do("POST", "/api/admin/venues/merge?from=berghain-panorama-bar-berlin&into=berghain-berlin", "admin-secret")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	var merge domain.VenueMerge
+	json.Unmarshal(rr.Body.Bytes(), &merge)
+	if merge.Events != 3 || merge.Into != "berghain-berlin" {
+		t.Errorf("unexpected merge %s", rr.Body.String())
+	}
+
+	if rr := do("POST", "/api/admin/venues/merge?from=nowhere-berlin&into=berghain-berlin", "admin-secret"); rr.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 for an unknown venue, got %d", rr.Code)
+	}
+	if rr := do("POST", "/api/admin/venues/merge?from=berghain-berlin&into=berghain-berlin", "admin-secret"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for a venue merged into itself, got %d", rr.Code)
+	}
+
+	rr = do("GET", "/api/admin/venues/merges", "admin-secret")
+	var merges VenueMergesResponse
+	json.Unmarshal(rr.Body.Bytes(), &merges)
+	if len(merges.Merges) != 1 {
+		t.Errorf("expected the recorded merge, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("json records carry the request ID", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(&buf, "json", "info")
+
+		ctx := WithRequestID(context.Background(), "abc123")
+		logger.With("component", "test").InfoContext(ctx, "hello", "source", "songkick")
+
+		var record map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatalf("expected a JSON record, got %q", buf.String())
+		}
+		if record["request_id"] != "abc123" || record["source"] != "songkick" || record["component"] != "test" {
+			t.Errorf("unexpected record: %v", record)
+		}
+	})
+
+	t.Run("level filters records", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := New(&buf, "text", "warn")
+
+		logger.Info("dropped")
+		logger.Warn("kept")
+
+		if strings.Contains(buf.String(), "dropped") || !strings.Contains(buf.String(), "kept") {
+			t.Errorf("unexpected output: %q", buf.String())
+		}
+	})
+
+	t.Run("no request ID outside a request", func(t *testing.T) {
+		var buf bytes.Buffer
+		New(&buf, "text", "").Info("startup")
+
+		if strings.Contains(buf.String(), "request_id") {
+			t.Errorf("expected no request_id, got %q", buf.String())
+		}
+	})
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"WARNING": slog.LevelWarn,
+		"error":   slog.LevelError,
+		"":        slog.LevelInfo,
+		"verbose": slog.LevelInfo,
+	}
+	for input, expected := range cases {
+		if got := ParseLevel(input); got != expected {
+			t.Errorf("ParseLevel(%q) = %v, expected %v", input, got, expected)
+		}
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	a, b := NewRequestID(), NewRequestID()
+	if len(a) != 16 || a == b {
+		t.Errorf("expected distinct 16 character IDs, got %q and %q", a, b)
+	}
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "whereitsat"
+
+// Metrics holds the Prometheus collectors for the service. It satisfies the
+// observer interfaces of the HTTP middleware, the aggregator, the repositories
+// and the rate limiters, so none of them depend on Prometheus directly.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestDuration  *prometheus.HistogramVec
+	sourceSearchDuration *prometheus.HistogramVec
+	sourceSearches       *prometheus.CounterVec
+	cacheLookups         *prometheus.CounterVec
+	cacheEvictions       *prometheus.CounterVec
+	cacheEntries         prometheus.Gauge
+	cacheBytes           prometheus.Gauge
+	rateLimitRejections  *prometheus.CounterVec
+	dbQueryDuration      *prometheus.HistogramVec
+	dbQueryErrors        *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		sourceSearchDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "source_search_duration_seconds",
+			Help:      "Latency of searches against each upstream source.",
+			Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+		}, []string{"source"}),
+		sourceSearches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "source_searches_total",
+			Help:      "Searches against each upstream source by result.",
+		}, []string{"source", "result"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "aggregator_cache_lookups_total",
+			Help:      "Aggregator cache lookups by cache and result.",
+		}, []string{"cache", "result"}),
+		cacheEvictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "aggregator_cache_evictions_total",
+			Help:      "Searches dropped from the aggregator cache by cache and reason.",
+		}, []string{"cache", "reason"}),
+		cacheEntries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "aggregator_cache_entries",
+			Help:      "Searches held in the aggregator cache.",
+		}),
+		cacheBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "aggregator_cache_bytes",
+			Help:      "Estimated size of the results in the aggregator cache.",
+		}),
+		rateLimitRejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limit_rejections_total",
+			Help:      "Requests turned away by a source's rate limiter.",
+		}, []string{"source"}),
+		dbQueryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "SQLite query latency by repository and operation.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, 1},
+		}, []string{"repository", "operation"}),
+		dbQueryErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Failed SQLite queries by repository and operation.",
+		}, []string{"repository", "operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequestDuration,
+		m.sourceSearchDuration,
+		m.sourceSearches,
+		m.cacheLookups,
+		m.cacheEvictions,
+		m.cacheEntries,
+		m.cacheBytes,
+		m.rateLimitRejections,
+		m.dbQueryDuration,
+		m.dbQueryErrors,
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveSourceSearch(source string, duration time.Duration, err error) {
+	m.sourceSearchDuration.WithLabelValues(source).Observe(duration.Seconds())
+	m.sourceSearches.WithLabelValues(source, resultLabel(err == nil, "success", "error")).Inc()
+}
+
+func (m *Metrics) ObserveCacheLookup(cache string, hit bool) {
+	m.cacheLookups.WithLabelValues(cache, resultLabel(hit, "hit", "miss")).Inc()
+}
+
+func (m *Metrics) ObserveCacheEviction(cache, reason string) {
+	m.cacheEvictions.WithLabelValues(cache, reason).Inc()
+}
+
+func (m *Metrics) ObserveCacheSize(entries int, bytes int64) {
+	m.cacheEntries.Set(float64(entries))
+	m.cacheBytes.Set(float64(bytes))
+}
+
+func (m *Metrics) RateLimitRejected(source string) {
+	m.rateLimitRejections.WithLabelValues(source).Inc()
+}
+
+func (m *Metrics) ObserveQuery(repository, operation string, duration time.Duration, err error) {
+	m.dbQueryDuration.WithLabelValues(repository, operation).Observe(duration.Seconds())
+	if err != nil {
+		m.dbQueryErrors.WithLabelValues(repository, operation).Inc()
+	}
+}
+
+func resultLabel(ok bool, yes, no string) string {
+	if ok {
+		return yes
+	}
+	return no
+}
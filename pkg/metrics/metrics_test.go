@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.ObserveHTTPRequest("GET", "/api/artists/{id}", 200, 20*time.Millisecond)
+	m.ObserveSourceSearch("songkick", 300*time.Millisecond, nil)
+	m.ObserveSourceSearch("ticketmaster", time.Second, errors.New("upstream down"))
+	m.ObserveCacheLookup("events", true)
+	m.ObserveCacheLookup("events", false)
+	m.ObserveCacheEviction("events", "capacity")
+	m.ObserveCacheSize(12, 4096)
+	m.RateLimitRejected("setlistfm")
+	m.ObserveQuery("events", "query", time.Millisecond, errors.New("locked"))
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+
+	body := rr.Body.String()
+	for _, expected := range []string{
+		`whereitsat_http_request_duration_seconds_count{method="GET",route="/api/artists/{id}",status="200"} 1`,
+		`whereitsat_source_search_duration_seconds_count{source="songkick"} 1`,
+		`whereitsat_source_searches_total{result="error",source="ticketmaster"} 1`,
+		`whereitsat_aggregator_cache_lookups_total{cache="events",result="hit"} 1`,
+		`whereitsat_aggregator_cache_lookups_total{cache="events",result="miss"} 1`,
+		`whereitsat_aggregator_cache_evictions_total{cache="events",reason="capacity"} 1`,
+		`whereitsat_aggregator_cache_entries 12`,
+		`whereitsat_aggregator_cache_bytes 4096`,
+		`whereitsat_rate_limit_rejections_total{source="setlistfm"} 1`,
+		`whereitsat_db_query_duration_seconds_count{operation="query",repository="events"} 1`,
+		`whereitsat_db_query_errors_total{operation="query",repository="events"} 1`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("expected %s in:\n%s", expected, body)
+		}
+	}
+}
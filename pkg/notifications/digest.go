@@ -0,0 +1,111 @@
+package notifications
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// Digest is one user's summary of newly discovered events
+type Digest struct {
+	Frequency      domain.DigestFrequency
+	Sections       []DigestSection
+	UnsubscribeURL string
+}
+
+// DigestSection groups the events found for one follow or saved search
+type DigestSection struct {
+	Title  string
+	Events []domain.Event
+}
+
+func (d Digest) EventCount() int {
+	count := 0
+	for _, section := range d.Sections {
+		count += len(section.Events)
+	}
+	return count
+}
+
+func (d Digest) Subject() string {
+	period := "this week"
+	if d.Frequency == domain.DigestDaily {
+		period = "today"
+	}
+
+	count := d.EventCount()
+	if count == 1 {
+		return fmt.Sprintf("1 new show %s", period)
+	}
+	return fmt.Sprintf("%d new shows %s", count, period)
+}
+
+var digestFuncs = map[string]interface{}{
+	"date": func(t time.Time) string { return t.Format("Mon 2 Jan 2006, 15:04") },
+	"venue": func(v domain.Venue) string {
+		switch {
+		case v.Name != "" && v.City != "":
+			return v.Name + ", " + v.City
+		case v.Name != "":
+			return v.Name
+		default:
+			return v.City
+		}
+	},
+}
+
+var digestHTML = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(digestFuncs).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222;">
+<h1 style="font-size: 20px;">{{.Subject}}</h1>
+{{range .Sections}}
+<h2 style="font-size: 16px; margin-bottom: 4px;">{{.Title}}</h2>
+<ul style="padding-left: 18px; margin-top: 0;">
+{{range .Events}}<li>
+<strong>{{.ArtistName}}</strong>{{if .Title}} – {{.Title}}{{end}}<br>
+{{date .DateTime}} · {{venue .Venue}}{{if .TicketURL}}<br>
+<a href="{{.TicketURL}}">Tickets</a>{{end}}
+</li>
+{{end}}</ul>
+{{end}}
+<p style="font-size: 12px; color: #888;">You get this {{.Frequency}} digest because you follow these artists or saved these searches on Where It's At.
+<a href="{{.UnsubscribeURL}}">Unsubscribe</a></p>
+</body>
+</html>
+`))
+
+var digestText = texttemplate.Must(texttemplate.New("digest.txt").Funcs(digestFuncs).Parse(`{{.Subject}}
+{{range .Sections}}
+{{.Title}}
+{{range .Events}}- {{.ArtistName}}{{if .Title}} – {{.Title}}{{end}}
+  {{date .DateTime}} · {{venue .Venue}}{{if .TicketURL}}
+  Tickets: {{.TicketURL}}{{end}}
+{{end}}{{end}}
+Unsubscribe: {{.UnsubscribeURL}}
+`))
+
+// Render returns the digest as an email, HTML with a plain-text fallback
+func (d Digest) Render(to string) (Message, error) {
+	var html, text bytes.Buffer
+	if err := digestHTML.Execute(&html, d); err != nil {
+		return Message{}, fmt.Errorf("failed to render digest: %w", err)
+	}
+	if err := digestText.Execute(&text, d); err != nil {
+		return Message{}, fmt.Errorf("failed to render digest: %w", err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: d.Subject(),
+		HTML:    html.String(),
+		Text:    text.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + d.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
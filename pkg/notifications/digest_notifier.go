@@ -0,0 +1,205 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// DigestNotifierConfig wires the digest notifier to storage and a mailer.
+// BaseURL is the public address unsubscribe links point at.
+type DigestNotifierConfig struct {
+	Users         domain.UserRepository
+	Follows       domain.FollowRepository
+	SavedSearches domain.SavedSearchRepository
+	Preferences   domain.NotificationPreferencesRepository
+	Events        domain.EventRepository
+	Mailer        Mailer
+	BaseURL       string
+	// MaxEventsPerSection caps each follow or search, default 20
+	MaxEventsPerSection int
+	Logger              *slog.Logger
+}
+
+// DigestNotifier emails users the events discovered for their follows and
+// saved searches since their last digest
+type DigestNotifier struct {
+	config DigestNotifierConfig
+	now    func() time.Time
+}
+
+func NewDigestNotifier(config DigestNotifierConfig) *DigestNotifier {
+	if config.MaxEventsPerSection <= 0 {
+		config.MaxEventsPerSection = 20
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+
+	return &DigestNotifier{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run sends due digests every interval until ctx is done
+func (n *DigestNotifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := n.SendDue(ctx); err != nil {
+			n.config.Logger.Warn("failed to send digests", "error", err)
+		} else if sent > 0 {
+			n.config.Logger.Info("sent digests", "count", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue sends every digest whose period has passed and returns how many
+// went out. Users with nothing new get no email but their period restarts.
+func (n *DigestNotifier) SendDue(ctx context.Context) (int, error) {
+	now := n.now()
+
+	due, err := n.config.Preferences.ListDueDigests(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, prefs := range due {
+		delivered, err := n.sendDigest(ctx, prefs, now)
+		if err != nil {
+			n.config.Logger.Warn("failed to send digest", "user_id", prefs.UserID, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		if delivered {
+			sent++
+		}
+		if err := n.config.Preferences.MarkDigestSent(ctx, prefs.UserID, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+func (n *DigestNotifier) sendDigest(ctx context.Context, prefs domain.NotificationPreferences, now time.Time) (bool, error) {
+	user, err := n.config.Users.GetByID(ctx, prefs.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	digest, err := n.BuildDigest(ctx, prefs, now)
+	if err != nil {
+		return false, err
+	}
+	if digest.EventCount() == 0 {
+		return false, nil
+	}
+
+	msg, err := digest.Render(user.Email)
+	if err != nil {
+		return false, err
+	}
+	if err := n.config.Mailer.Send(ctx, msg); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// BuildDigest collects upcoming events discovered since the user's last
+// digest, one section per follow and saved search. An event only appears
+// in the first section it matches.
+func (n *DigestNotifier) BuildDigest(ctx context.Context, prefs domain.NotificationPreferences, now time.Time) (Digest, error) {
+	since := now.Add(-prefs.DigestFrequency.Period())
+	if prefs.LastDigestAt != nil {
+		since = *prefs.LastDigestAt
+	}
+
+	follows, err := n.config.Follows.ListFollows(ctx, prefs.UserID)
+	if err != nil {
+		return Digest{}, err
+	}
+	searches, err := n.config.SavedSearches.List(ctx, prefs.UserID)
+	if err != nil {
+		return Digest{}, err
+	}
+
+	type query struct {
+		title  string
+		filter domain.DiscoveryFilter
+	}
+	queries := make([]query, 0, len(follows)+len(searches))
+	for _, follow := range follows {
+		queries = append(queries, query{
+			title:  follow.ArtistName,
+			filter: domain.DiscoveryFilter{ArtistID: follow.ArtistID, ArtistName: follow.ArtistName},
+		})
+	}
+	for _, search := range searches {
+		queries = append(queries, query{
+			title:  savedSearchTitle(search),
+			filter: domain.DiscoveryFilter{ArtistName: search.Artist, City: search.City},
+		})
+	}
+
+	digest := Digest{
+		Frequency:      prefs.DigestFrequency,
+		UnsubscribeURL: n.unsubscribeURL(prefs.UnsubscribeToken),
+	}
+	seen := make(map[string]bool)
+
+	for _, q := range queries {
+		q.filter.Since = &since
+		events, err := n.config.Events.ListDiscovered(ctx, q.filter, n.config.MaxEventsPerSection)
+		if err != nil {
+			return Digest{}, err
+		}
+
+		section := DigestSection{Title: q.title}
+		for _, event := range events {
+			if seen[event.ID] || !event.DateTime.After(now) {
+				continue
+			}
+			seen[event.ID] = true
+			section.Events = append(section.Events, event)
+		}
+		if len(section.Events) > 0 {
+			digest.Sections = append(digest.Sections, section)
+		}
+	}
+
+	return digest, nil
+}
+
+func (n *DigestNotifier) unsubscribeURL(token string) string {
+	return n.config.BaseURL + "/api/notifications/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+func savedSearchTitle(search domain.SavedSearch) string {
+	switch {
+	case search.Artist != "" && search.City != "":
+		return search.Artist + " in " + search.City
+	case search.Artist != "":
+		return search.Artist
+	default:
+		return "New in " + search.City
+	}
+}
@@ -0,0 +1,184 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubUsers struct {
+	domain.UserRepository
+	users map[string]domain.User
+}
+
+func (s *stubUsers) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	user, ok := s.users[id]
+	if !ok {
+		return nil, domain.ErrUserNotFound
+	}
+	return &user, nil
+}
+
+type stubFollows struct {
+	domain.FollowRepository
+	follows []domain.Follow
+}
+
+func (s *stubFollows) ListFollows(ctx context.Context, userID string) ([]domain.Follow, error) {
+	return s.follows, nil
+}
+
+func (s *stubFollows) ListAllFollows(ctx context.Context) ([]domain.Follow, error) {
+	return s.follows, nil
+}
+
+type stubSearches struct {
+	domain.SavedSearchRepository
+	searches []domain.SavedSearch
+}
+
+func (s *stubSearches) List(ctx context.Context, userID string) ([]domain.SavedSearch, error) {
+	return s.searches, nil
+}
+
+type stubPreferences struct {
+	domain.NotificationPreferencesRepository
+	due  []domain.NotificationPreferences
+	sent map[string]time.Time
+}
+
+func (s *stubPreferences) ListDueDigests(ctx context.Context, now time.Time) ([]domain.NotificationPreferences, error) {
+	return s.due, nil
+}
+
+func (s *stubPreferences) MarkDigestSent(ctx context.Context, userID string, sentAt time.Time) error {
+	s.sent[userID] = sentAt
+	return nil
+}
+
+// stubEvents matches discovered events the way the SQLite repository does
+type stubEvents struct {
+	domain.EventRepository
+	events []domain.Event
+}
+
+func (s *stubEvents) ListDiscovered(ctx context.Context, filter domain.DiscoveryFilter, limit int) ([]domain.Event, error) {
+	matched := []domain.Event{}
+	for _, event := range s.events {
+		if filter.ArtistName != "" && !strings.EqualFold(event.ArtistName, filter.ArtistName) {
+			continue
+		}
+		if filter.City != "" && !strings.EqualFold(event.Venue.City, filter.City) {
+			continue
+		}
+		if filter.Since != nil && !event.DiscoveredAt.After(*filter.Since) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	return matched, nil
+}
+
+type recordingMailer struct {
+	sent []Message
+	err  error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestDigestNotifier_SendDue(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	lastWeek := now.Add(-7 * 24 * time.Hour)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	events := &stubEvents{events: []domain.Event{
+		{ID: "new", ArtistName: "Radiohead", DateTime: now.Add(48 * time.Hour), Venue: domain.Venue{City: "Berlin"}, DiscoveredAt: at(-time.Hour)},
+		{ID: "old", ArtistName: "Radiohead", DateTime: now.Add(48 * time.Hour), DiscoveredAt: at(-8 * 24 * time.Hour)},
+		{ID: "past", ArtistName: "Radiohead", DateTime: now.Add(-time.Hour), DiscoveredAt: at(-time.Hour)},
+		{ID: "berlin", ArtistName: "Bicep", DateTime: now.Add(72 * time.Hour), Venue: domain.Venue{City: "Berlin"}, DiscoveredAt: at(-2 * time.Hour)},
+	}}
+	prefs := &stubPreferences{
+		due: []domain.NotificationPreferences{
+			{UserID: "user_1", DigestFrequency: domain.DigestWeekly, UnsubscribeToken: "tok en", LastDigestAt: &lastWeek},
+			{UserID: "quiet", DigestFrequency: domain.DigestDaily, UnsubscribeToken: "t2", LastDigestAt: at(-time.Minute)},
+		},
+		sent: map[string]time.Time{},
+	}
+	mailer := &recordingMailer{}
+
+	notifier := NewDigestNotifier(DigestNotifierConfig{
+		Users: &stubUsers{users: map[string]domain.User{
+			"user_1": {ID: "user_1", Email: "kim@example.com"},
+			"quiet":  {ID: "quiet", Email: "quiet@example.com"},
+		}},
+		Follows:       &stubFollows{follows: []domain.Follow{{ArtistName: "Radiohead"}}},
+		SavedSearches: &stubSearches{searches: []domain.SavedSearch{{City: "Berlin"}}},
+		Preferences:   prefs,
+		Events:        events,
+		Mailer:        mailer,
+		BaseURL:       "https://whereitsat.example.com/",
+	})
+	notifier.now = func() time.Time { return now }
+
+	sent, err := notifier.SendDue(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sent != 1 || len(mailer.sent) != 1 {
+		t.Fatalf("expected one digest, got %d", len(mailer.sent))
+	}
+
+	msg := mailer.sent[0]
+	if msg.To != "kim@example.com" || msg.Subject != "2 new shows this week" {
+		t.Errorf("unexpected message: %s / %s", msg.To, msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "Radiohead\n") || !strings.Contains(msg.Text, "New in Berlin\n- Bicep") {
+		t.Errorf("expected a section per follow and search, each event once, got:\n%s", msg.Text)
+	}
+	if !strings.Contains(msg.Text, "https://whereitsat.example.com/api/notifications/unsubscribe?token=tok+en") {
+		t.Errorf("expected an escaped unsubscribe link, got:\n%s", msg.Text)
+	}
+	if !prefs.sent["user_1"].Equal(now) || !prefs.sent["quiet"].Equal(now) {
+		t.Errorf("expected both periods to restart, got %v", prefs.sent)
+	}
+}
+
+func TestDigestNotifier_MailerFailure(t *testing.T) {
+	now := time.Now()
+	discovered := now.Add(-time.Hour)
+	prefs := &stubPreferences{
+		due:  []domain.NotificationPreferences{{UserID: "user_1", DigestFrequency: domain.DigestDaily, UnsubscribeToken: "t"}},
+		sent: map[string]time.Time{},
+	}
+
+	notifier := NewDigestNotifier(DigestNotifierConfig{
+		Users:         &stubUsers{users: map[string]domain.User{"user_1": {ID: "user_1", Email: "kim@example.com"}}},
+		Follows:       &stubFollows{follows: []domain.Follow{{ArtistName: "Radiohead"}}},
+		SavedSearches: &stubSearches{},
+		Preferences:   prefs,
+		Events: &stubEvents{events: []domain.Event{
+			{ID: "e1", ArtistName: "Radiohead", DateTime: now.Add(time.Hour), DiscoveredAt: &discovered},
+		}},
+		Mailer: &recordingMailer{err: errors.New("connection refused")},
+	})
+
+	if _, err := notifier.SendDue(context.Background()); err == nil {
+		t.Error("expected the mailer error")
+	}
+	if _, marked := prefs.sent["user_1"]; marked {
+		t.Error("expected a failed digest to be retried next time")
+	}
+}
@@ -0,0 +1,60 @@
+package notifications
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func TestDigest_Render(t *testing.T) {
+	digest := Digest{
+		Frequency:      domain.DigestWeekly,
+		UnsubscribeURL: "https://whereitsat.example.com/api/notifications/unsubscribe?token=abc",
+		Sections: []DigestSection{{
+			Title: "Radiohead",
+			Events: []domain.Event{{
+				ID:         "e1",
+				ArtistName: "Radiohead",
+				Title:      "<script>alert(1)</script>",
+				DateTime:   time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC),
+				Venue:      domain.Venue{Name: "Olympiastadion", City: "Berlin"},
+				TicketURL:  "https://tickets.example.com/e1",
+			}},
+		}},
+	}
+
+	msg, err := digest.Render("kim@example.com")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if msg.Subject != "1 new show this week" {
+		t.Errorf("unexpected subject %q", msg.Subject)
+	}
+	if strings.Contains(msg.HTML, "<script>") {
+		t.Error("expected event fields to be escaped in HTML")
+	}
+	for _, want := range []string{"Fri 1 May 2026, 20:00 · Olympiastadion, Berlin", `href="https://tickets.example.com/e1"`, "token=abc"} {
+		if !strings.Contains(msg.HTML, want) {
+			t.Errorf("expected HTML to contain %q", want)
+		}
+	}
+	if !strings.Contains(msg.Text, "Tickets: https://tickets.example.com/e1") {
+		t.Errorf("expected ticket link in the text part, got:\n%s", msg.Text)
+	}
+	if msg.Headers["List-Unsubscribe"] != "<"+digest.UnsubscribeURL+">" {
+		t.Errorf("unexpected List-Unsubscribe %q", msg.Headers["List-Unsubscribe"])
+	}
+}
+
+func TestDigest_Subject(t *testing.T) {
+	digest := Digest{
+		Frequency: domain.DigestDaily,
+		Sections:  []DigestSection{{Events: make([]domain.Event, 2)}, {Events: make([]domain.Event, 1)}},
+	}
+	if got := digest.Subject(); got != "3 new shows today" {
+		t.Errorf("unexpected subject %q", got)
+	}
+}
@@ -0,0 +1,9 @@
+module github.com/yair/where-its-at/pkg/notifications
+
+go 1.23.0
+
+toolchain go1.24.5
+
+require github.com/yair/where-its-at/pkg/domain v0.0.0
+
+replace github.com/yair/where-its-at/pkg/domain => ../domain
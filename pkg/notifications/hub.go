@@ -0,0 +1,331 @@
+package notifications
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// Push types
+const (
+	PushNewEvent     = "event"
+	PushOnSale       = "onsale"
+	PushEventChanged = "changed"
+)
+
+// Push is one message for live subscribers
+type Push struct {
+	Type  string       `json:"type"`
+	Topic string       `json:"topic"`
+	Event domain.Event `json:"event"`
+
+	// A changed event's previous date or venue, whichever it changed
+	PreviousDateTime *time.Time    `json:"previous_datetime,omitempty"`
+	PreviousVenue    *domain.Venue `json:"previous_venue,omitempty"`
+}
+
+// artistTopicPrefix is the only kind of topic there is so far
+const artistTopicPrefix = "artist:"
+
+// ArtistTopic is the topic pushes about an artist go to. Names are matched
+// case-insensitively, so "artist:radiohead" gets Radiohead's events.
+func ArtistTopic(artistName string) string {
+	return artistTopicPrefix + strings.ToLower(strings.TrimSpace(artistName))
+}
+
+// ValidTopic reports whether subscribing to topic can ever get pushes
+func ValidTopic(topic string) bool {
+	name, ok := strings.CutPrefix(topic, artistTopicPrefix)
+	return ok && strings.TrimSpace(name) != "" && topic == ArtistTopic(name)
+}
+
+// eventTopics are the topics of everyone on the event's bill
+func eventTopics(event domain.Event) []string {
+	topics := []string{ArtistTopic(event.ArtistName)}
+	for _, artist := range event.Lineup {
+		topics = append(topics, ArtistTopic(artist.Name))
+	}
+	return topics
+}
+
+// subscriptionBuffer is how many pushes wait for a slow subscriber before
+// newer ones are dropped
+const subscriptionBuffer = 64
+
+// Hub fans pushes out to subscribers by topic. It is fed newly discovered
+// events by RunDiscoveries, the changes searches make to stored events by
+// NotifyChanges and on-sale alerts as an OnSaleNotifier.
+// Publishing never blocks: a subscriber that falls behind misses pushes.
+type Hub struct {
+	mu     sync.RWMutex
+	topics map[string]map[*Subscription]struct{}
+
+	// onSaleSent keeps events already pushed as going on sale until their
+	// on-sale time passes, as the watcher alerts each user separately
+	onSaleMu   sync.Mutex
+	onSaleSent map[string]time.Time
+
+	// newSent keeps the new events NotifyChanges pushed, so RunDiscoveries
+	// doesn't push them again when it finds them stored
+	newMu   sync.Mutex
+	newSent map[string]time.Time
+
+	// since is when discoveries were last published
+	since  time.Time
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+func NewHub(logger *slog.Logger) *Hub {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Hub{
+		topics:     make(map[string]map[*Subscription]struct{}),
+		onSaleSent: make(map[string]time.Time),
+		newSent:    make(map[string]time.Time),
+		since:      time.Now(),
+		now:        time.Now,
+		logger:     logger,
+	}
+}
+
+// Subscription receives the pushes of the topics it's added to on C until
+// it's closed
+type Subscription struct {
+	C <-chan Push
+
+	hub    *Hub
+	ch     chan Push
+	topics map[string]struct{}
+	closed bool
+}
+
+// Subscribe returns a subscription to no topics yet
+func (h *Hub) Subscribe() *Subscription {
+	ch := make(chan Push, subscriptionBuffer)
+	return &Subscription{C: ch, hub: h, ch: ch, topics: make(map[string]struct{})}
+}
+
+// Add subscribes to topic
+func (s *Subscription) Add(topic string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	if s.hub.topics[topic] == nil {
+		s.hub.topics[topic] = make(map[*Subscription]struct{})
+	}
+	s.hub.topics[topic][s] = struct{}{}
+	s.topics[topic] = struct{}{}
+}
+
+// Remove unsubscribes from topic
+func (s *Subscription) Remove(topic string) {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	s.hub.remove(s, topic)
+}
+
+// Topics returns how many topics the subscription has
+func (s *Subscription) Topics() int {
+	s.hub.mu.RLock()
+	defer s.hub.mu.RUnlock()
+
+	return len(s.topics)
+}
+
+// Close unsubscribes from every topic and closes C
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	for topic := range s.topics {
+		s.hub.remove(s, topic)
+	}
+	s.closed = true
+	close(s.ch)
+}
+
+func (h *Hub) remove(s *Subscription, topic string) {
+	delete(s.topics, topic)
+	if subscribers := h.topics[topic]; subscribers != nil {
+		delete(subscribers, s)
+		if len(subscribers) == 0 {
+			delete(h.topics, topic)
+		}
+	}
+}
+
+// Publish sends the event to the subscribers of every artist on its bill,
+// once per subscriber, and returns how many got it
+func (h *Hub) Publish(pushType string, event domain.Event) int {
+	return h.publish(Push{Type: pushType, Event: event})
+}
+
+// publish sends push to the subscribers of every artist on its event's
+// bill, under the topic they subscribed to
+func (h *Hub) publish(push Push) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	sent := map[*Subscription]bool{}
+	for _, topic := range eventTopics(push.Event) {
+		for s := range h.topics[topic] {
+			if sent[s] {
+				continue
+			}
+			sent[s] = true
+
+			push.Topic = topic
+			select {
+			case s.ch <- push:
+			default:
+				h.logger.Debug("live subscriber is behind, dropping push", "topic", topic)
+			}
+		}
+	}
+	return len(sent)
+}
+
+// NotifyOnSale pushes the alert's events to their artists' subscribers.
+// Alerts for several users list the same event; it's pushed once.
+func (h *Hub) NotifyOnSale(ctx context.Context, alert OnSaleAlert) error {
+	now := h.now()
+
+	h.onSaleMu.Lock()
+	for id, onSale := range h.onSaleSent {
+		if onSale.Before(now) {
+			delete(h.onSaleSent, id)
+		}
+	}
+	var fresh []domain.Event
+	for _, event := range alert.Events {
+		if _, sent := h.onSaleSent[event.ID]; sent {
+			continue
+		}
+		expires := now.Add(24 * time.Hour)
+		if event.OnSaleDate != nil && event.OnSaleDate.After(now) {
+			expires = *event.OnSaleDate
+		}
+		h.onSaleSent[event.ID] = expires
+		fresh = append(fresh, event)
+	}
+	h.onSaleMu.Unlock()
+
+	for _, event := range fresh {
+		h.Publish(PushOnSale, event)
+	}
+	return nil
+}
+
+// newSentTTL is how long NotifyChanges remembers a new event it pushed;
+// discovery checks run far more often than that
+const newSentTTL = time.Hour
+
+// NotifyChanges pushes the events a search stored for the first time, and
+// the stored ones it moved to another date or venue, as soon as it stored
+// them. A moved event is pushed once, with whatever it had before.
+func (h *Hub) NotifyChanges(ctx context.Context, changes domain.EventChangeSet) {
+	now := h.now()
+
+	h.newMu.Lock()
+	for id, sentAt := range h.newSent {
+		if now.Sub(sentAt) > newSentTTL {
+			delete(h.newSent, id)
+		}
+	}
+	for _, event := range changes.New {
+		h.newSent[event.ID] = now
+	}
+	h.newMu.Unlock()
+
+	for _, event := range changes.New {
+		h.Publish(PushNewEvent, event)
+	}
+
+	var order []string
+	changed := make(map[string]*Push)
+	for _, update := range changes.DateChanged {
+		previous := update.PreviousDateTime
+		changed[update.Event.ID] = &Push{Type: PushEventChanged, Event: update.Event, PreviousDateTime: &previous}
+		order = append(order, update.Event.ID)
+	}
+	for _, update := range changes.VenueChanged {
+		push, ok := changed[update.Event.ID]
+		if !ok {
+			push = &Push{Type: PushEventChanged, Event: update.Event}
+			changed[update.Event.ID] = push
+			order = append(order, update.Event.ID)
+		}
+		previous := update.PreviousVenue
+		push.PreviousVenue = &previous
+	}
+	for _, id := range order {
+		h.publish(*changed[id])
+	}
+}
+
+// sentAsNew reports whether NotifyChanges already pushed the event as new,
+// forgetting it as discovery checks only list an event once
+func (h *Hub) sentAsNew(id string) bool {
+	h.newMu.Lock()
+	defer h.newMu.Unlock()
+
+	_, sent := h.newSent[id]
+	delete(h.newSent, id)
+	return sent
+}
+
+// maxDiscoveriesPerCheck bounds one check's query; a sync storing more
+// than this in one interval has the rest left unpushed
+const maxDiscoveriesPerCheck = 500
+
+// PublishDiscovered pushes the events stored since the last check that
+// NotifyChanges didn't push already, and returns how many were stored
+func (h *Hub) PublishDiscovered(ctx context.Context, events domain.EventRepository) (int, error) {
+	since := h.since
+	discovered, err := events.ListDiscovered(ctx, domain.DiscoveryFilter{Since: &since}, maxDiscoveriesPerCheck)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range discovered {
+		if event.DiscoveredAt != nil && event.DiscoveredAt.After(h.since) {
+			h.since = *event.DiscoveredAt
+		}
+		if !h.sentAsNew(event.ID) {
+			h.Publish(PushNewEvent, event)
+		}
+	}
+	return len(discovered), nil
+}
+
+// RunDiscoveries pushes newly stored events every interval until ctx is
+// done. Events stored before the hub was created aren't pushed.
+func (h *Hub) RunDiscoveries(ctx context.Context, events domain.EventRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := h.PublishDiscovered(ctx, events); err != nil {
+			h.logger.Warn("failed to publish discovered events", "error", err)
+		}
+	}
+}
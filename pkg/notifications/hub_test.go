@@ -0,0 +1,204 @@
+package notifications
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func receive(t *testing.T, s *Subscription) (Push, bool) {
+	t.Helper()
+	select {
+	case push, ok := <-s.C:
+		return push, ok
+	default:
+		return Push{}, false
+	}
+}
+
+func TestHub_Publish(t *testing.T) {
+	hub := NewHub(nil)
+
+	radiohead := hub.Subscribe()
+	radiohead.Add(ArtistTopic("Radiohead"))
+	both := hub.Subscribe()
+	both.Add(ArtistTopic("radiohead"))
+	both.Add(ArtistTopic("Caribou"))
+	other := hub.Subscribe()
+	other.Add(ArtistTopic("Bicep"))
+
+	event := domain.Event{
+		ID:         "e1",
+		ArtistName: "Radiohead",
+		Lineup: []domain.EventArtist{
+			{Name: "Radiohead", Headliner: true},
+			{Name: "Caribou"},
+		},
+	}
+	if delivered := hub.Publish(PushNewEvent, event); delivered != 2 {
+		t.Errorf("expected 2 subscribers to get the event, got %d", delivered)
+	}
+
+	push, ok := receive(t, radiohead)
+	if !ok || push.Type != PushNewEvent || push.Event.ID != "e1" || push.Topic != "artist:radiohead" {
+		t.Errorf("unexpected push %+v", push)
+	}
+	if _, ok := receive(t, both); !ok {
+		t.Error("expected the event once for a subscriber to headliner and support")
+	}
+	if _, ok := receive(t, both); ok {
+		t.Error("expected no second push for the support act's topic")
+	}
+	if _, ok := receive(t, other); ok {
+		t.Error("expected nothing for other artists' subscribers")
+	}
+
+	both.Remove(ArtistTopic("Radiohead"))
+	radiohead.Close()
+	if delivered := hub.Publish(PushNewEvent, domain.Event{ID: "e2", ArtistName: "Radiohead"}); delivered != 0 {
+		t.Errorf("expected no subscribers left, got %d", delivered)
+	}
+	if _, ok := <-radiohead.C; ok {
+		t.Error("expected a closed subscription's channel to be closed")
+	}
+	radiohead.Add(ArtistTopic("Radiohead"))
+	if delivered := hub.Publish(PushNewEvent, domain.Event{ID: "e3", ArtistName: "Radiohead"}); delivered != 0 {
+		t.Error("expected a closed subscription to stay unsubscribed")
+	}
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	hub := NewHub(nil)
+	s := hub.Subscribe()
+	s.Add(ArtistTopic("Radiohead"))
+
+	// Publishing must not block on a subscriber that doesn't read
+	for i := 0; i < subscriptionBuffer*2; i++ {
+		hub.Publish(PushNewEvent, domain.Event{ArtistName: "Radiohead"})
+	}
+	if len(s.C) != subscriptionBuffer {
+		t.Errorf("expected a full buffer, got %d", len(s.C))
+	}
+}
+
+func TestValidTopic(t *testing.T) {
+	for topic, valid := range map[string]bool{
+		"artist:radiohead":    true,
+		"artist:the national": true,
+		"artist:Radiohead":    false,
+		"artist:":             false,
+		"venue:berghain":      false,
+		"radiohead":           false,
+	} {
+		if ValidTopic(topic) != valid {
+			t.Errorf("ValidTopic(%q) = %v, want %v", topic, !valid, valid)
+		}
+	}
+}
+
+func TestHub_NotifyOnSale(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	hub := NewHub(nil)
+	hub.now = func() time.Time { return now }
+	s := hub.Subscribe()
+	s.Add(ArtistTopic("Radiohead"))
+
+	onSale := now.Add(time.Hour)
+	event := domain.Event{ID: "e1", ArtistName: "Radiohead", OnSaleDate: &onSale}
+
+	// The watcher sends one alert per user, each listing the event
+	for _, userID := range []string{"u1", "u2"} {
+		alert := OnSaleAlert{User: domain.User{ID: userID}, Events: []domain.Event{event}}
+		if err := hub.NotifyOnSale(context.Background(), alert); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+
+	if push, ok := receive(t, s); !ok || push.Type != PushOnSale {
+		t.Errorf("expected an on-sale push, got %+v", push)
+	}
+	if _, ok := receive(t, s); ok {
+		t.Error("expected the event pushed once across users")
+	}
+}
+
+func TestHub_PublishDiscovered(t *testing.T) {
+	now := time.Now()
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	hub := NewHub(nil)
+	hub.since = now.Add(-time.Hour)
+	s := hub.Subscribe()
+	s.Add(ArtistTopic("Radiohead"))
+
+	events := &stubEvents{events: []domain.Event{
+		{ID: "new", ArtistName: "Radiohead", DiscoveredAt: at(-time.Minute)},
+		{ID: "before", ArtistName: "Radiohead", DiscoveredAt: at(-2 * time.Hour)},
+	}}
+
+	count, err := hub.PublishDiscovered(context.Background(), events)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 discovered event, got %d", count)
+	}
+	if push, ok := receive(t, s); !ok || push.Event.ID != "new" {
+		t.Errorf("expected the new event pushed, got %+v", push)
+	}
+
+	// Already pushed events aren't pushed again
+	if count, _ := hub.PublishDiscovered(context.Background(), events); count != 0 {
+		t.Errorf("expected nothing new on the second check, got %d", count)
+	}
+}
+
+func TestHub_NotifyChanges(t *testing.T) {
+	now := time.Now()
+	hub := NewHub(nil)
+	hub.since = now.Add(-time.Hour)
+	s := hub.Subscribe()
+	s.Add(ArtistTopic("Radiohead"))
+
+	at := now.Add(48 * time.Hour)
+	moved := domain.Event{ID: "moved", ArtistName: "Radiohead", DateTime: at.Add(24 * time.Hour), Venue: domain.Venue{Name: "O2"}}
+	hub.NotifyChanges(context.Background(), domain.EventChangeSet{
+		New: []domain.Event{{ID: "new", ArtistName: "Radiohead"}},
+		DateChanged: []domain.EventUpdate{
+			{Event: moved, PreviousDateTime: at, PreviousVenue: domain.Venue{Name: "Roundhouse"}},
+		},
+		VenueChanged: []domain.EventUpdate{
+			{Event: moved, PreviousDateTime: at, PreviousVenue: domain.Venue{Name: "Roundhouse"}},
+		},
+	})
+
+	if push, ok := receive(t, s); !ok || push.Type != PushNewEvent || push.Event.ID != "new" {
+		t.Errorf("expected the new event pushed, got %+v", push)
+	}
+	push, ok := receive(t, s)
+	if !ok || push.Type != PushEventChanged || push.Event.ID != "moved" {
+		t.Fatalf("expected the moved event pushed, got %+v", push)
+	}
+	if push.PreviousDateTime == nil || !push.PreviousDateTime.Equal(at) || push.PreviousVenue == nil || push.PreviousVenue.Name != "Roundhouse" {
+		t.Errorf("expected the previous date and venue, got %+v", push)
+	}
+	if _, ok := receive(t, s); ok {
+		t.Error("expected an event both rescheduled and moved pushed once")
+	}
+
+	// The discovery check finds the new event stored and doesn't push it again
+	events := &stubEvents{events: []domain.Event{
+		{ID: "new", ArtistName: "Radiohead", DiscoveredAt: &now},
+	}}
+	if count, err := hub.PublishDiscovered(context.Background(), events); err != nil || count != 1 {
+		t.Errorf("expected 1 discovered event, got %d, %v", count, err)
+	}
+	if push, ok := receive(t, s); ok {
+		t.Errorf("expected no second push of the new event, got %+v", push)
+	}
+}
@@ -0,0 +1,162 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Message is an HTML email with a plain-text alternative
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+	// Headers are added as-is, e.g. List-Unsubscribe
+	Headers map[string]string
+}
+
+// Mailer delivers email
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPConfig is where digests are sent through. Username is optional for
+// relays that don't authenticate.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTPMailer sends mail through an SMTP server, upgrading to TLS when the
+// server offers STARTTLS
+type SMTPMailer struct {
+	config SMTPConfig
+	from   *mail.Address
+	send   func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	now    func() time.Time
+}
+
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if config.Port == 0 {
+		config.Port = 587
+	}
+
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+
+	return &SMTPMailer{
+		config: config,
+		from:   from,
+		send:   smtp.SendMail,
+		now:    time.Now,
+	}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	body, err := m.buildMessage(to, msg)
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	if err := m.send(addr, auth, m.from.Address, []string{to.Address}, body); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	return nil
+}
+
+func (m *SMTPMailer) buildMessage(to *mail.Address, msg Message) ([]byte, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	headers := map[string]string{
+		"From":         m.from.String(),
+		"To":           to.String(),
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         m.now().Format(time.RFC1123Z),
+		"MIME-Version": "1.0",
+		"Content-Type": fmt.Sprintf(`multipart/alternative; boundary="%s"`, boundary),
+	}
+	for key, value := range msg.Headers {
+		headers[key] = value
+	}
+
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, headers[key])
+	}
+	buf.WriteString("\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		if part.body == "" {
+			continue
+		}
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; charset=utf-8\r\n", part.contentType)
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+func randomBoundary() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"context"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", Username: "user", Password: "pass", From: "Where It's At <digest@example.com>"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	mailer.now = func() time.Time { return time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC) }
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotAuth smtp.Auth
+	var gotBody string
+	mailer.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotBody = addr, a, from, to, string(msg)
+		return nil
+	}
+
+	err = mailer.Send(context.Background(), Message{
+		To:      "kim@example.com",
+		Subject: "3 new shows – this week",
+		HTML:    "<p>Hello</p>",
+		Text:    "Hello",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>"},
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if gotAddr != "smtp.example.com:587" || gotFrom != "digest@example.com" || len(gotTo) != 1 || gotTo[0] != "kim@example.com" {
+		t.Errorf("unexpected envelope: %s %s %v", gotAddr, gotFrom, gotTo)
+	}
+	if gotAuth == nil {
+		t.Error("expected PLAIN auth when a username is set")
+	}
+	for _, want := range []string{
+		"Subject: =?utf-8?q?3_new_shows_=E2=80=93_this_week?=\r\n",
+		"Date: Fri, 01 May 2026 09:00:00 +0000\r\n",
+		"List-Unsubscribe: <https://example.com/u>\r\n",
+		"Content-Type: multipart/alternative;",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Type: text/html; charset=utf-8",
+		"<p>Hello</p>",
+	} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("expected message to contain %q, got:\n%s", want, gotBody)
+		}
+	}
+}
+
+func TestSMTPMailer_Errors(t *testing.T) {
+	if _, err := NewSMTPMailer(SMTPConfig{From: "digest@example.com"}); err == nil {
+		t.Error("expected an error without a host")
+	}
+	if _, err := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", From: "not an address"}); err == nil {
+		t.Error("expected an error for a bad from address")
+	}
+
+	mailer, _ := NewSMTPMailer(SMTPConfig{Host: "smtp.example.com", From: "digest@example.com"})
+	mailer.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if a != nil {
+			t.Error("expected no auth without a username")
+		}
+		return nil
+	}
+	if err := mailer.Send(context.Background(), Message{To: "kim@example.com", Text: "hi"}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if err := mailer.Send(context.Background(), Message{To: "nobody"}); err == nil {
+		t.Error("expected an error for a bad recipient")
+	}
+}
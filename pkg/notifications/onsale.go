@@ -0,0 +1,171 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"net/http"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// OnSaleAlert is one user's events whose tickets go on sale soon
+type OnSaleAlert struct {
+	User         domain.User
+	Subscription domain.OnSaleSubscription
+	Events       []domain.Event
+}
+
+func (a OnSaleAlert) Subject() string {
+	if len(a.Events) == 1 {
+		return fmt.Sprintf("Tickets for %s go on sale soon", a.Events[0].ArtistName)
+	}
+	return fmt.Sprintf("Tickets for %d shows go on sale soon", len(a.Events))
+}
+
+// OnSaleNotifier delivers on-sale alerts over one channel. Notifiers skip
+// users they have no address for and return nil.
+type OnSaleNotifier interface {
+	NotifyOnSale(ctx context.Context, alert OnSaleAlert) error
+}
+
+var onSaleFuncs = map[string]interface{}{
+	"date":  digestFuncs["date"],
+	"venue": digestFuncs["venue"],
+	"onsale": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("Mon 2 Jan, 15:04 MST")
+	},
+}
+
+var onSaleHTML = htmltemplate.Must(htmltemplate.New("onsale.html").Funcs(onSaleFuncs).Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222;">
+<h1 style="font-size: 20px;">{{.Subject}}</h1>
+<ul style="padding-left: 18px;">
+{{range .Events}}<li>
+<strong>{{.ArtistName}}</strong>{{if .Title}} – {{.Title}}{{end}}<br>
+{{date .DateTime}} · {{venue .Venue}}<br>
+On sale {{onsale .OnSaleDate}}{{if .TicketURL}} · <a href="{{.TicketURL}}">Tickets</a>{{end}}
+</li>
+{{end}}</ul>
+<p style="font-size: 12px; color: #888;">You get this because you turned on on-sale alerts on Where It's At.</p>
+</body>
+</html>
+`))
+
+var onSaleText = texttemplate.Must(texttemplate.New("onsale.txt").Funcs(onSaleFuncs).Parse(`{{.Subject}}
+{{range .Events}}
+- {{.ArtistName}}{{if .Title}} – {{.Title}}{{end}}
+  {{date .DateTime}} · {{venue .Venue}}
+  On sale {{onsale .OnSaleDate}}{{if .TicketURL}}
+  Tickets: {{.TicketURL}}{{end}}
+{{end}}`))
+
+// Render returns the alert as an email, HTML with a plain-text fallback
+func (a OnSaleAlert) Render() (Message, error) {
+	var html, text bytes.Buffer
+	if err := onSaleHTML.Execute(&html, a); err != nil {
+		return Message{}, fmt.Errorf("failed to render on-sale alert: %w", err)
+	}
+	if err := onSaleText.Execute(&text, a); err != nil {
+		return Message{}, fmt.Errorf("failed to render on-sale alert: %w", err)
+	}
+
+	return Message{
+		To:      a.User.Email,
+		Subject: a.Subject(),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// EmailOnSaleNotifier mails on-sale alerts to the user's account address
+type EmailOnSaleNotifier struct {
+	mailer Mailer
+}
+
+func NewEmailOnSaleNotifier(mailer Mailer) *EmailOnSaleNotifier {
+	return &EmailOnSaleNotifier{mailer: mailer}
+}
+
+func (n *EmailOnSaleNotifier) NotifyOnSale(ctx context.Context, alert OnSaleAlert) error {
+	if alert.User.Email == "" {
+		return nil
+	}
+
+	msg, err := alert.Render()
+	if err != nil {
+		return err
+	}
+	return n.mailer.Send(ctx, msg)
+}
+
+// WebhookPayload is the JSON body posted to a subscription's webhook
+type WebhookPayload struct {
+	Type   string         `json:"type"`
+	SentAt time.Time      `json:"sent_at"`
+	Events []domain.Event `json:"events"`
+}
+
+// WebhookNotifier posts on-sale alerts as JSON to the webhook URL the user
+// subscribed with
+type WebhookNotifier struct {
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhookNotifier uses a client with a 10 second timeout when client is
+// nil
+func NewWebhookNotifier(client *http.Client) *WebhookNotifier {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &WebhookNotifier{
+		client: client,
+		now:    time.Now,
+	}
+}
+
+func (n *WebhookNotifier) NotifyOnSale(ctx context.Context, alert OnSaleAlert) error {
+	if alert.Subscription.WebhookURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(WebhookPayload{
+		Type:   "onsale",
+		SentAt: n.now(),
+		Events: alert.Events,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, alert.Subscription.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "WhereItsAt/1.0")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
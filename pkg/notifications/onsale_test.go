@@ -0,0 +1,81 @@
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func testOnSaleAlert(webhookURL string) OnSaleAlert {
+	onSale := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	return OnSaleAlert{
+		User:         domain.User{ID: "user_1", Email: "kim@example.com"},
+		Subscription: domain.OnSaleSubscription{UserID: "user_1", WebhookURL: webhookURL},
+		Events: []domain.Event{{
+			ID:         "tm_1",
+			ArtistName: "Radiohead",
+			DateTime:   time.Date(2026, 9, 12, 20, 0, 0, 0, time.UTC),
+			Venue:      domain.Venue{Name: "Waldbühne", City: "Berlin"},
+			TicketURL:  "https://tickets.example.com/1",
+			OnSaleDate: &onSale,
+		}},
+	}
+}
+
+func TestOnSaleAlert_Render(t *testing.T) {
+	msg, err := testOnSaleAlert("").Render()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if msg.To != "kim@example.com" || msg.Subject != "Tickets for Radiohead go on sale soon" {
+		t.Errorf("unexpected message: %s / %s", msg.To, msg.Subject)
+	}
+	if !strings.Contains(msg.Text, "On sale Fri 1 May, 10:00 UTC") {
+		t.Errorf("expected the on-sale time, got:\n%s", msg.Text)
+	}
+	if !strings.Contains(msg.HTML, `<a href="https://tickets.example.com/1">Tickets</a>`) {
+		t.Errorf("expected a ticket link, got:\n%s", msg.HTML)
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var received WebhookPayload
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.Client())
+
+	if err := notifier.NotifyOnSale(context.Background(), testOnSaleAlert(server.URL)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if received.Type != "onsale" || len(received.Events) != 1 || received.Events[0].ID != "tm_1" {
+		t.Errorf("unexpected payload %+v", received)
+	}
+
+	t.Run("error status", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		if err := notifier.NotifyOnSale(context.Background(), testOnSaleAlert(server.URL)); err == nil {
+			t.Error("expected an error for a 500")
+		}
+	})
+
+	t.Run("no webhook", func(t *testing.T) {
+		if err := notifier.NotifyOnSale(context.Background(), testOnSaleAlert("")); err != nil {
+			t.Errorf("expected users without a webhook to be skipped, got %v", err)
+		}
+	})
+}
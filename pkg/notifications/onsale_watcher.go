@@ -0,0 +1,200 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// OnSaleWatcherConfig wires the watcher to storage and the channels alerts
+// go out on
+type OnSaleWatcherConfig struct {
+	Users         domain.UserRepository
+	Follows       domain.FollowRepository
+	SavedSearches domain.SavedSearchRepository
+	Alerts        domain.OnSaleAlertRepository
+	Events        domain.EventOnSaleRepository
+	Notifiers     []OnSaleNotifier
+	// Lead is how long before tickets go on sale users hear about it,
+	// default one hour
+	Lead time.Duration
+	// MaxEventsPerAlert caps how many events one alert lists, default 20
+	MaxEventsPerAlert int
+	Logger            *slog.Logger
+}
+
+// OnSaleWatcher alerts subscribed users shortly before tickets go on sale
+// for events matching their follows and saved searches. Every event is
+// alerted at most once per user.
+type OnSaleWatcher struct {
+	config OnSaleWatcherConfig
+	now    func() time.Time
+}
+
+func NewOnSaleWatcher(config OnSaleWatcherConfig) *OnSaleWatcher {
+	if config.Lead <= 0 {
+		config.Lead = time.Hour
+	}
+	if config.MaxEventsPerAlert <= 0 {
+		config.MaxEventsPerAlert = 20
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &OnSaleWatcher{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run sends due alerts every interval until ctx is done. The interval
+// should be well under the lead time or on-sales can slip past unseen.
+func (w *OnSaleWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := w.SendDue(ctx); err != nil {
+			w.config.Logger.Warn("failed to send on-sale alerts", "error", err)
+		} else if sent > 0 {
+			w.config.Logger.Info("sent on-sale alerts", "count", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue alerts every subscriber about matching events going on sale
+// within the lead time and returns how many users were alerted. Events are
+// only marked as alerted when at least one channel delivered them.
+func (w *OnSaleWatcher) SendDue(ctx context.Context) (int, error) {
+	subs, err := w.config.Alerts.ListSubscriptions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, sub := range subs {
+		now := w.now()
+
+		events, err := w.Upcoming(ctx, sub.UserID, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", sub.UserID, err))
+			continue
+		}
+		if len(events) == 0 {
+			continue
+		}
+
+		delivered, err := w.alert(ctx, sub, events)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", sub.UserID, err))
+		}
+		if !delivered {
+			continue
+		}
+		sent++
+
+		for _, event := range events {
+			if err := w.config.Alerts.MarkAlerted(ctx, sub.UserID, event.ID, now); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+func (w *OnSaleWatcher) alert(ctx context.Context, sub domain.OnSaleSubscription, events []domain.Event) (bool, error) {
+	user, err := w.config.Users.GetByID(ctx, sub.UserID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	alert := OnSaleAlert{User: *user, Subscription: sub, Events: events}
+
+	delivered := false
+	var errs []error
+	for _, notifier := range w.config.Notifiers {
+		if err := notifier.NotifyOnSale(ctx, alert); err != nil {
+			w.config.Logger.Warn("failed to send on-sale alert", "user_id", sub.UserID, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		delivered = true
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+// Upcoming returns the user's matching events going on sale within the
+// lead time that they haven't been alerted about, soonest first
+func (w *OnSaleWatcher) Upcoming(ctx context.Context, userID string, now time.Time) ([]domain.Event, error) {
+	follows, err := w.config.Follows.ListFollows(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	searches, err := w.config.SavedSearches.List(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	window := domain.OnSaleFilter{From: now, To: now.Add(w.config.Lead)}
+	filters := make([]domain.OnSaleFilter, 0, len(follows)+len(searches))
+	for _, follow := range follows {
+		filter := window
+		filter.ArtistID = follow.ArtistID
+		filter.ArtistName = follow.ArtistName
+		filters = append(filters, filter)
+	}
+	for _, search := range searches {
+		filter := window
+		filter.ArtistName = search.Artist
+		filter.City = search.City
+		filters = append(filters, filter)
+	}
+
+	seen := make(map[string]bool)
+	var events []domain.Event
+	for _, filter := range filters {
+		found, err := w.config.Events.ListUpcomingOnSales(ctx, filter, w.config.MaxEventsPerAlert)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, event := range found {
+			if seen[event.ID] {
+				continue
+			}
+			seen[event.ID] = true
+
+			alerted, err := w.config.Alerts.HasAlerted(ctx, userID, event.ID)
+			if err != nil {
+				return nil, err
+			}
+			if !alerted {
+				events = append(events, event)
+			}
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OnSaleDate.Before(*events[j].OnSaleDate)
+	})
+	if len(events) > w.config.MaxEventsPerAlert {
+		events = events[:w.config.MaxEventsPerAlert]
+	}
+
+	return events, nil
+}
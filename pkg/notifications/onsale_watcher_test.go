@@ -0,0 +1,161 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+func (s *stubEvents) ListUpcomingOnSales(ctx context.Context, filter domain.OnSaleFilter, limit int) ([]domain.Event, error) {
+	matched := []domain.Event{}
+	for _, event := range s.events {
+		if event.OnSaleDate == nil || !event.OnSaleDate.After(filter.From) || event.OnSaleDate.After(filter.To) {
+			continue
+		}
+		if filter.ArtistName != "" && !strings.EqualFold(event.ArtistName, filter.ArtistName) {
+			continue
+		}
+		if filter.City != "" && !strings.EqualFold(event.Venue.City, filter.City) {
+			continue
+		}
+		matched = append(matched, event)
+	}
+	return matched, nil
+}
+
+type stubOnSaleAlerts struct {
+	domain.OnSaleAlertRepository
+	subs    []domain.OnSaleSubscription
+	alerted map[string]bool
+}
+
+func (s *stubOnSaleAlerts) ListSubscriptions(ctx context.Context) ([]domain.OnSaleSubscription, error) {
+	return s.subs, nil
+}
+
+func (s *stubOnSaleAlerts) HasAlerted(ctx context.Context, userID, eventID string) (bool, error) {
+	return s.alerted[userID+"/"+eventID], nil
+}
+
+func (s *stubOnSaleAlerts) MarkAlerted(ctx context.Context, userID, eventID string, alertedAt time.Time) error {
+	s.alerted[userID+"/"+eventID] = true
+	return nil
+}
+
+type recordingOnSaleNotifier struct {
+	alerts []OnSaleAlert
+	err    error
+}
+
+func (n *recordingOnSaleNotifier) NotifyOnSale(ctx context.Context, alert OnSaleAlert) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestOnSaleWatcher_SendDue(t *testing.T) {
+	now := time.Date(2026, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	events := &stubEvents{events: []domain.Event{
+		{ID: "later", ArtistName: "Radiohead", OnSaleDate: at(50 * time.Minute)},
+		{ID: "soon", ArtistName: "Bicep", Venue: domain.Venue{City: "Berlin"}, OnSaleDate: at(10 * time.Minute)},
+		{ID: "too-far", ArtistName: "Radiohead", OnSaleDate: at(3 * time.Hour)},
+		{ID: "on-sale", ArtistName: "Radiohead", OnSaleDate: at(-time.Minute)},
+		{ID: "elsewhere", ArtistName: "Bicep", Venue: domain.Venue{City: "Paris"}, OnSaleDate: at(10 * time.Minute)},
+	}}
+	alerts := &stubOnSaleAlerts{
+		subs:    []domain.OnSaleSubscription{{UserID: "user_1"}},
+		alerted: map[string]bool{},
+	}
+	notifier := &recordingOnSaleNotifier{}
+
+	watcher := NewOnSaleWatcher(OnSaleWatcherConfig{
+		Users:         &stubUsers{users: map[string]domain.User{"user_1": {ID: "user_1", Email: "kim@example.com"}}},
+		Follows:       &stubFollows{follows: []domain.Follow{{ArtistName: "Radiohead"}}},
+		SavedSearches: &stubSearches{searches: []domain.SavedSearch{{Artist: "Bicep", City: "Berlin"}}},
+		Alerts:        alerts,
+		Events:        events,
+		Notifiers:     []OnSaleNotifier{notifier},
+	})
+	watcher.now = func() time.Time { return now }
+
+	sent, err := watcher.SendDue(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sent != 1 || len(notifier.alerts) != 1 {
+		t.Fatalf("expected one alert, got %d", len(notifier.alerts))
+	}
+
+	alert := notifier.alerts[0]
+	if alert.User.Email != "kim@example.com" {
+		t.Errorf("expected the user on the alert, got %+v", alert.User)
+	}
+	if len(alert.Events) != 2 || alert.Events[0].ID != "soon" || alert.Events[1].ID != "later" {
+		t.Errorf("expected soon then later, got %+v", alert.Events)
+	}
+
+	t.Run("events are alerted once", func(t *testing.T) {
+		sent, err := watcher.SendDue(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if sent != 0 || len(notifier.alerts) != 1 {
+			t.Errorf("expected no repeat alert, got %d", len(notifier.alerts))
+		}
+	})
+}
+
+func TestOnSaleWatcher_NotifierFailure(t *testing.T) {
+	now := time.Now()
+	onSale := now.Add(30 * time.Minute)
+	alerts := &stubOnSaleAlerts{
+		subs:    []domain.OnSaleSubscription{{UserID: "user_1"}},
+		alerted: map[string]bool{},
+	}
+
+	config := OnSaleWatcherConfig{
+		Users:         &stubUsers{users: map[string]domain.User{"user_1": {ID: "user_1", Email: "kim@example.com"}}},
+		Follows:       &stubFollows{follows: []domain.Follow{{ArtistName: "Radiohead"}}},
+		SavedSearches: &stubSearches{},
+		Alerts:        alerts,
+		Events:        &stubEvents{events: []domain.Event{{ID: "e1", ArtistName: "Radiohead", OnSaleDate: &onSale}}},
+	}
+
+	t.Run("every channel failed", func(t *testing.T) {
+		config.Notifiers = []OnSaleNotifier{&recordingOnSaleNotifier{err: errors.New("connection refused")}}
+
+		if _, err := NewOnSaleWatcher(config).SendDue(context.Background()); err == nil {
+			t.Error("expected the notifier error")
+		}
+		if alerts.alerted["user_1/e1"] {
+			t.Error("expected an undelivered alert to be retried next time")
+		}
+	})
+
+	t.Run("one channel delivered", func(t *testing.T) {
+		delivered := &recordingOnSaleNotifier{}
+		config.Notifiers = []OnSaleNotifier{&recordingOnSaleNotifier{err: errors.New("webhook returned status 500")}, delivered}
+
+		sent, err := NewOnSaleWatcher(config).SendDue(context.Background())
+		if err == nil {
+			t.Error("expected the failed channel to be reported")
+		}
+		if sent != 1 || len(delivered.alerts) != 1 {
+			t.Errorf("expected the other channel to deliver, got %d", len(delivered.alerts))
+		}
+		if !alerts.alerted["user_1/e1"] {
+			t.Error("expected a delivered alert not to repeat")
+		}
+	})
+}
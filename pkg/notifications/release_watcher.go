@@ -0,0 +1,173 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ReleaseSource finds an artist's albums released since a given day
+type ReleaseSource interface {
+	NewReleases(ctx context.Context, artist domain.Artist, since time.Time) ([]domain.Album, error)
+}
+
+// ReleaseWatcherConfig wires the watcher to storage, a release source and
+// the channels alerts go out on
+type ReleaseWatcherConfig struct {
+	Users     domain.UserRepository
+	Follows   domain.FollowRepository
+	Alerts    domain.ReleaseAlertRepository
+	Releases  ReleaseSource
+	Notifiers []ReleaseNotifier
+	// Window is how recently an album must have come out to be news,
+	// default 14 days
+	Window time.Duration
+	Logger *slog.Logger
+}
+
+// ReleaseWatcher alerts users to new albums from the artists they follow.
+// Every album is alerted at most once per user.
+type ReleaseWatcher struct {
+	config ReleaseWatcherConfig
+	now    func() time.Time
+}
+
+func NewReleaseWatcher(config ReleaseWatcherConfig) *ReleaseWatcher {
+	if config.Window <= 0 {
+		config.Window = 14 * 24 * time.Hour
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &ReleaseWatcher{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run sends due alerts every interval until ctx is done
+func (w *ReleaseWatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := w.SendDue(ctx); err != nil {
+			w.config.Logger.Warn("failed to send release alerts", "error", err)
+		} else if sent > 0 {
+			w.config.Logger.Info("sent release alerts", "count", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue alerts followers about albums released within the window that
+// they haven't heard about and returns how many users were alerted. Each
+// followed artist is looked up once, however many users follow them, and
+// albums are only marked as alerted when at least one channel delivered
+// them.
+func (w *ReleaseWatcher) SendDue(ctx context.Context) (int, error) {
+	follows, err := w.config.Follows.ListAllFollows(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	now := w.now()
+	since := now.Add(-w.config.Window)
+
+	found := make(map[string][]domain.Album)
+	pending := make(map[string][]domain.Album)
+	seen := make(map[string]bool)
+	var userIDs []string
+	var errs []error
+	for _, follow := range follows {
+		key := strings.ToLower(strings.TrimSpace(follow.ArtistName))
+		albums, ok := found[key]
+		if !ok {
+			albums, err = w.config.Releases.NewReleases(ctx, domain.Artist{ID: follow.ArtistID, Name: follow.ArtistName}, since)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("artist %s: %w", follow.ArtistName, err))
+			}
+			found[key] = albums
+		}
+
+		for _, album := range albums {
+			if seen[follow.UserID+"/"+album.ID] {
+				continue
+			}
+			seen[follow.UserID+"/"+album.ID] = true
+
+			alerted, err := w.config.Alerts.HasAlerted(ctx, follow.UserID, album.ID)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("user %s: %w", follow.UserID, err))
+				continue
+			}
+			if alerted {
+				continue
+			}
+
+			if _, ok := pending[follow.UserID]; !ok {
+				userIDs = append(userIDs, follow.UserID)
+			}
+			pending[follow.UserID] = append(pending[follow.UserID], album)
+		}
+	}
+
+	sent := 0
+	for _, userID := range userIDs {
+		releases := pending[userID]
+		sort.SliceStable(releases, func(i, j int) bool {
+			return releases[i].ReleaseDate > releases[j].ReleaseDate
+		})
+
+		delivered, err := w.alert(ctx, userID, releases)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", userID, err))
+		}
+		if !delivered {
+			continue
+		}
+		sent++
+
+		for _, album := range releases {
+			if err := w.config.Alerts.MarkAlerted(ctx, userID, album.ID, now); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+func (w *ReleaseWatcher) alert(ctx context.Context, userID string, releases []domain.Album) (bool, error) {
+	user, err := w.config.Users.GetByID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	alert := ReleaseAlert{User: *user, Releases: releases}
+
+	delivered := false
+	var errs []error
+	for _, notifier := range w.config.Notifiers {
+		if err := notifier.NotifyReleases(ctx, alert); err != nil {
+			w.config.Logger.Warn("failed to send release alert", "user_id", userID, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		delivered = true
+	}
+
+	return delivered, errors.Join(errs...)
+}
@@ -0,0 +1,129 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type stubReleases struct {
+	albums map[string][]domain.Album
+	calls  []string
+	since  time.Time
+}
+
+func (s *stubReleases) NewReleases(ctx context.Context, artist domain.Artist, since time.Time) ([]domain.Album, error) {
+	s.calls = append(s.calls, artist.Name)
+	s.since = since
+	if artist.Name == "Broken" {
+		return nil, errors.New("deezer get artist albums failed: status 500")
+	}
+	return s.albums[strings.ToLower(artist.Name)], nil
+}
+
+type stubReleaseAlerts struct {
+	alerted map[string]bool
+}
+
+func (s *stubReleaseAlerts) HasAlerted(ctx context.Context, userID, albumID string) (bool, error) {
+	return s.alerted[userID+"/"+albumID], nil
+}
+
+func (s *stubReleaseAlerts) MarkAlerted(ctx context.Context, userID, albumID string, alertedAt time.Time) error {
+	s.alerted[userID+"/"+albumID] = true
+	return nil
+}
+
+type recordingReleaseNotifier struct {
+	alerts []ReleaseAlert
+	err    error
+}
+
+func (n *recordingReleaseNotifier) NotifyReleases(ctx context.Context, alert ReleaseAlert) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestReleaseWatcher_SendDue(t *testing.T) {
+	now := time.Date(2026, 5, 15, 9, 0, 0, 0, time.UTC)
+
+	releases := &stubReleases{albums: map[string][]domain.Album{
+		"radiohead": {{ID: "deezer_1", Title: "Old News", ArtistName: "Radiohead", ReleaseDate: "2026-05-02"}},
+		"bicep":     {{ID: "deezer_2", Title: "Isles II", ArtistName: "Bicep", ReleaseDate: "2026-05-10"}},
+	}}
+	alerts := &stubReleaseAlerts{alerted: map[string]bool{"user_2/deezer_1": true}}
+	notifier := &recordingReleaseNotifier{}
+
+	watcher := NewReleaseWatcher(ReleaseWatcherConfig{
+		Users: &stubUsers{users: map[string]domain.User{
+			"user_1": {ID: "user_1", Email: "kim@example.com"},
+			"user_2": {ID: "user_2", Email: "sam@example.com"},
+		}},
+		Follows: &stubFollows{follows: []domain.Follow{
+			{UserID: "user_1", ArtistName: "Bicep"},
+			{UserID: "user_1", ArtistName: "Broken"},
+			{UserID: "user_1", ArtistName: "Radiohead"},
+			{UserID: "user_2", ArtistName: "radiohead"},
+		}},
+		Alerts:    alerts,
+		Releases:  releases,
+		Notifiers: []ReleaseNotifier{notifier},
+	})
+	watcher.now = func() time.Time { return now }
+
+	sent, err := watcher.SendDue(context.Background())
+	if err == nil {
+		t.Error("expected the failed artist to be reported")
+	}
+	if sent != 1 || len(notifier.alerts) != 1 {
+		t.Fatalf("expected one alert, got %d", len(notifier.alerts))
+	}
+	if !releases.since.Equal(now.Add(-14 * 24 * time.Hour)) {
+		t.Errorf("expected a 14 day window, got %v", releases.since)
+	}
+	if len(releases.calls) != 3 {
+		t.Errorf("expected each artist looked up once, got %v", releases.calls)
+	}
+
+	alert := notifier.alerts[0]
+	if alert.User.ID != "user_1" {
+		t.Errorf("expected user_1 alerted, got %+v", alert.User)
+	}
+	if len(alert.Releases) != 2 || alert.Releases[0].ID != "deezer_2" || alert.Releases[1].ID != "deezer_1" {
+		t.Errorf("expected newest first, got %+v", alert.Releases)
+	}
+	if alert.Subject() != "2 new releases from artists you follow" {
+		t.Errorf("unexpected subject %q", alert.Subject())
+	}
+
+	t.Run("albums are alerted once", func(t *testing.T) {
+		sent, _ := watcher.SendDue(context.Background())
+		if sent != 0 || len(notifier.alerts) != 1 {
+			t.Errorf("expected no repeat alert, got %d", len(notifier.alerts))
+		}
+	})
+
+	t.Run("undelivered alerts are retried", func(t *testing.T) {
+		failing := NewReleaseWatcher(ReleaseWatcherConfig{
+			Users:     &stubUsers{users: map[string]domain.User{"user_3": {ID: "user_3"}}},
+			Follows:   &stubFollows{follows: []domain.Follow{{UserID: "user_3", ArtistName: "Bicep"}}},
+			Alerts:    alerts,
+			Releases:  releases,
+			Notifiers: []ReleaseNotifier{&recordingReleaseNotifier{err: errors.New("connection refused")}},
+		})
+
+		if _, err := failing.SendDue(context.Background()); err == nil {
+			t.Error("expected the notifier error")
+		}
+		if alerts.alerted["user_3/deezer_2"] {
+			t.Error("expected an undelivered alert to be retried next time")
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	texttemplate "text/template"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// ReleaseAlert is one user's new albums from artists they follow
+type ReleaseAlert struct {
+	User     domain.User
+	Releases []domain.Album
+}
+
+func (a ReleaseAlert) Subject() string {
+	if len(a.Releases) == 1 {
+		return fmt.Sprintf("New from %s: %s", a.Releases[0].ArtistName, a.Releases[0].Title)
+	}
+	return fmt.Sprintf("%d new releases from artists you follow", len(a.Releases))
+}
+
+// ReleaseNotifier delivers new release alerts over one channel. Notifiers
+// skip users they have no address for and return nil.
+type ReleaseNotifier interface {
+	NotifyReleases(ctx context.Context, alert ReleaseAlert) error
+}
+
+var releaseHTML = htmltemplate.Must(htmltemplate.New("releases.html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif; color: #222;">
+<h1 style="font-size: 20px;">{{.Subject}}</h1>
+<ul style="padding-left: 18px;">
+{{range .Releases}}<li>
+<strong>{{.ArtistName}}</strong> – {{.Title}}<br>
+Out {{.ReleaseDate}}{{if .TrackCount}} · {{.TrackCount}} tracks{{end}}
+</li>
+{{end}}</ul>
+<p style="font-size: 12px; color: #888;">You get this because you follow these artists and turned on digests on Where It's At.</p>
+</body>
+</html>
+`))
+
+var releaseText = texttemplate.Must(texttemplate.New("releases.txt").Parse(`{{.Subject}}
+{{range .Releases}}
+- {{.ArtistName}} – {{.Title}}
+  Out {{.ReleaseDate}}{{if .TrackCount}} · {{.TrackCount}} tracks{{end}}
+{{end}}`))
+
+// Render returns the alert as an email, HTML with a plain-text fallback
+func (a ReleaseAlert) Render() (Message, error) {
+	var html, text bytes.Buffer
+	if err := releaseHTML.Execute(&html, a); err != nil {
+		return Message{}, fmt.Errorf("failed to render release alert: %w", err)
+	}
+	if err := releaseText.Execute(&text, a); err != nil {
+		return Message{}, fmt.Errorf("failed to render release alert: %w", err)
+	}
+
+	return Message{
+		To:      a.User.Email,
+		Subject: a.Subject(),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// EmailReleaseNotifier mails release alerts to users who get digests, so
+// turning digests off stops these emails too
+type EmailReleaseNotifier struct {
+	mailer      Mailer
+	preferences domain.NotificationPreferencesRepository
+}
+
+func NewEmailReleaseNotifier(mailer Mailer, preferences domain.NotificationPreferencesRepository) *EmailReleaseNotifier {
+	return &EmailReleaseNotifier{
+		mailer:      mailer,
+		preferences: preferences,
+	}
+}
+
+func (n *EmailReleaseNotifier) NotifyReleases(ctx context.Context, alert ReleaseAlert) error {
+	if alert.User.Email == "" {
+		return nil
+	}
+
+	prefs, err := n.preferences.Get(ctx, alert.User.ID)
+	if errors.Is(err, domain.ErrPreferencesNotSet) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if prefs.DigestFrequency.Period() == 0 {
+		return nil
+	}
+
+	msg, err := alert.Render()
+	if err != nil {
+		return err
+	}
+	return n.mailer.Send(ctx, msg)
+}
@@ -0,0 +1,406 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/notifications"
+)
+
+// BotConfig wires the bot to the Bot API and storage
+type BotConfig struct {
+	Client  *Client
+	Links   domain.TelegramLinkRepository
+	Follows domain.FollowRepository
+	Events  domain.EventRepository
+	// Changes, when set, has followed artists' cancellations, postponements
+	// and date changes pushed along with new events
+	Changes domain.EventChangeRepository
+	// MaxEventsPerAlert caps how many events one user gets per check,
+	// default 10
+	MaxEventsPerAlert int
+	Logger            *slog.Logger
+}
+
+// Bot links Telegram chats to accounts through /start and pushes newly
+// discovered events for followed artists, and changes to their events, to
+// them
+type Bot struct {
+	config BotConfig
+	now    func() time.Time
+}
+
+func NewBot(config BotConfig) *Bot {
+	if config.MaxEventsPerAlert <= 0 {
+		config.MaxEventsPerAlert = 10
+	}
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+
+	return &Bot{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Run answers chat messages until ctx is done
+func (b *Bot) Run(ctx context.Context) {
+	var offset int64
+	for {
+		updates, err := b.config.Client.GetUpdates(ctx, offset)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.config.Logger.Warn("failed to get telegram updates", "error", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			offset = update.UpdateID + 1
+			if update.Message != nil {
+				b.HandleMessage(ctx, *update.Message)
+			}
+		}
+	}
+}
+
+const (
+	welcomeText = "Hi! Link this chat from your Where It's At account to get new shows for the artists you follow."
+	linkedText  = "Linked. New shows for the artists you follow will show up here. Send /stop to turn alerts off."
+	badCodeText = "That link has expired. Open a new one from your Where It's At account."
+	stoppedText = "Alerts stopped. Link the chat again any time to turn them back on."
+	notStopText = "This chat isn't getting alerts."
+	helpText    = "Send /stop to turn alerts off."
+)
+
+// HandleMessage answers /start <code>, which links the chat to the account
+// the code was issued to, and /stop, which unlinks it
+func (b *Bot) HandleMessage(ctx context.Context, msg Message) {
+	command, arg := parseCommand(msg.Text)
+	chatID := msg.Chat.ID
+
+	var reply string
+	switch command {
+	case "start":
+		reply = b.link(ctx, chatID, arg)
+	case "stop":
+		err := b.config.Links.UnlinkChat(ctx, chatID)
+		switch {
+		case errors.Is(err, domain.ErrLinkNotFound):
+			reply = notStopText
+		case err != nil:
+			b.config.Logger.Warn("failed to unlink telegram chat", "error", err)
+			return
+		default:
+			reply = stoppedText
+		}
+	default:
+		reply = helpText
+	}
+
+	if err := b.config.Client.SendMessage(ctx, chatID, reply); err != nil {
+		b.config.Logger.Warn("failed to reply on telegram", "error", err)
+	}
+}
+
+func (b *Bot) link(ctx context.Context, chatID int64, code string) string {
+	if code == "" {
+		return welcomeText
+	}
+
+	userID, err := b.config.Links.ConsumeLinkCode(ctx, code, b.now())
+	if err != nil {
+		if !errors.Is(err, domain.ErrLinkCodeInvalid) {
+			b.config.Logger.Warn("failed to check telegram link code", "error", err)
+		}
+		return badCodeText
+	}
+
+	if err := b.config.Links.Link(ctx, userID, chatID); err != nil {
+		b.config.Logger.Warn("failed to link telegram chat", "user_id", userID, "error", err)
+		return badCodeText
+	}
+
+	return linkedText
+}
+
+// parseCommand splits "/start@SomeBot abc" into "start" and "abc"
+func parseCommand(text string) (string, string) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "/") {
+		return "", text
+	}
+
+	command, arg, _ := strings.Cut(text[1:], " ")
+	command, _, _ = strings.Cut(command, "@")
+	return strings.ToLower(command), strings.TrimSpace(arg)
+}
+
+// RunAlerts pushes new events every interval until ctx is done
+func (b *Bot) RunAlerts(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := b.PushNewEvents(ctx); err != nil {
+			b.config.Logger.Warn("failed to push telegram alerts", "error", err)
+		} else if sent > 0 {
+			b.config.Logger.Info("sent telegram alerts", "count", sent)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PushNewEvents messages every linked chat the upcoming events discovered
+// for its user's follows since the last check, and returns how many went
+// out. Chats that blocked the bot are unlinked.
+func (b *Bot) PushNewEvents(ctx context.Context) (int, error) {
+	links, err := b.config.Links.ListLinks(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, link := range links {
+		now := b.now()
+		count, err := b.alert(ctx, link, now)
+		sent += count
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Blocked() {
+			b.config.Logger.Info("telegram chat blocked the bot, unlinking", "user_id", link.UserID)
+			if err := b.config.Links.UnlinkChat(ctx, link.ChatID); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", link.UserID, err))
+			continue
+		}
+
+		if err := b.config.Links.MarkAlerted(ctx, link.UserID, now); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return sent, errors.Join(errs...)
+}
+
+func (b *Bot) alert(ctx context.Context, link domain.TelegramLink, now time.Time) (int, error) {
+	since := link.LinkedAt
+	if link.LastAlertAt != nil {
+		since = *link.LastAlertAt
+	}
+
+	follows, err := b.config.Follows.ListFollows(ctx, link.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[string]bool)
+	var events []domain.Event
+	for _, follow := range follows {
+		found, err := b.config.Events.ListDiscovered(ctx, domain.DiscoveryFilter{
+			ArtistID:   follow.ArtistID,
+			ArtistName: follow.ArtistName,
+			Since:      &since,
+		}, b.config.MaxEventsPerAlert)
+		if err != nil {
+			return 0, err
+		}
+		for _, event := range found {
+			if seen[event.ID] || !event.DateTime.After(now) {
+				continue
+			}
+			seen[event.ID] = true
+			events = append(events, event)
+		}
+	}
+
+	if len(events) > b.config.MaxEventsPerAlert {
+		events = events[:b.config.MaxEventsPerAlert]
+	}
+
+	changes, err := b.changes(ctx, follows, since, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, event := range events {
+		if err := b.config.Client.SendMessage(ctx, link.ChatID, FormatEvent(event)); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	for _, change := range changes {
+		if err := b.config.Client.SendMessage(ctx, link.ChatID, FormatChange(change)); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// changes finds the follows' events that were cancelled, postponed or
+// moved since the last check, skipping shows that were already over
+func (b *Bot) changes(ctx context.Context, follows []domain.Follow, since, now time.Time) ([]domain.EventChange, error) {
+	if b.config.Changes == nil {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	var changes []domain.EventChange
+	for _, follow := range follows {
+		found, err := b.config.Changes.ListChanges(ctx, domain.DiscoveryFilter{
+			ArtistID:   follow.ArtistID,
+			ArtistName: follow.ArtistName,
+			Since:      &since,
+		}, b.config.MaxEventsPerAlert)
+		if err != nil {
+			return nil, err
+		}
+		// Newest first, so an event changed twice is told its latest state
+		for _, change := range found {
+			if seen[change.Event.ID] || !change.PreviousDateTime.After(now) {
+				continue
+			}
+			seen[change.Event.ID] = true
+			changes = append(changes, change)
+		}
+	}
+
+	if len(changes) > b.config.MaxEventsPerAlert {
+		changes = changes[:b.config.MaxEventsPerAlert]
+	}
+	return changes, nil
+}
+
+// NotifyOnSale sends an on-sale alert to the user's linked chat, if any,
+// one message per event. A chat that blocked the bot is unlinked.
+func (b *Bot) NotifyOnSale(ctx context.Context, alert notifications.OnSaleAlert) error {
+	messages := make([]string, 0, len(alert.Events))
+	for _, event := range alert.Events {
+		messages = append(messages, FormatOnSale(event))
+	}
+	return b.notifyLinked(ctx, alert.User.ID, messages)
+}
+
+// NotifyReleases pushes each new album to the user's linked chat, skipping
+// users without one
+func (b *Bot) NotifyReleases(ctx context.Context, alert notifications.ReleaseAlert) error {
+	messages := make([]string, 0, len(alert.Releases))
+	for _, album := range alert.Releases {
+		messages = append(messages, FormatRelease(album))
+	}
+	return b.notifyLinked(ctx, alert.User.ID, messages)
+}
+
+// notifyLinked sends messages to the user's linked chat, unlinking chats
+// that blocked the bot
+func (b *Bot) notifyLinked(ctx context.Context, userID string, messages []string) error {
+	link, err := b.config.Links.Get(ctx, userID)
+	if errors.Is(err, domain.ErrLinkNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		err := b.config.Client.SendMessage(ctx, link.ChatID, message)
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Blocked() {
+			b.config.Logger.Info("telegram chat blocked the bot, unlinking", "user_id", link.UserID)
+			return b.config.Links.UnlinkChat(ctx, link.ChatID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FormatOnSale renders an event with when its tickets go on sale
+func FormatOnSale(event domain.Event) string {
+	message := FormatEvent(event)
+	if event.OnSaleDate != nil {
+		message = fmt.Sprintf("⏰ <b>Tickets on sale %s</b>\n", event.OnSaleDate.Format("Mon 2 Jan, 15:04 MST")) + message
+	}
+	return message
+}
+
+// FormatRelease renders a new album with its release date
+func FormatRelease(album domain.Album) string {
+	message := fmt.Sprintf("💿 <b>%s</b> – %s", html.EscapeString(album.ArtistName), html.EscapeString(album.Title))
+	if released, err := time.Parse(time.DateOnly, album.ReleaseDate); err == nil {
+		message += fmt.Sprintf("\n📅 Out %s", released.Format("Mon 2 Jan 2006"))
+	}
+	return message
+}
+
+// FormatChange renders a change to an event, followed by the event as it
+// now stands
+func FormatChange(change domain.EventChange) string {
+	var headline string
+	switch change.Status {
+	case domain.EventCancelled:
+		headline = "❌ <b>Cancelled</b>"
+	case domain.EventPostponed:
+		headline = "⏸ <b>Postponed</b>"
+	default:
+		headline = fmt.Sprintf("🔁 <b>Moved from %s</b>", change.PreviousDateTime.Format("Mon 2 Jan 2006, 15:04"))
+	}
+	return headline + "\n" + FormatEvent(change.Event)
+}
+
+// FormatEvent renders an event as a Telegram HTML message with the venue,
+// date and ticket link
+func FormatEvent(event domain.Event) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "🎤 <b>%s</b>", html.EscapeString(event.ArtistName))
+	if event.Title != "" && event.Title != event.ArtistName {
+		fmt.Fprintf(&b, " – %s", html.EscapeString(event.Title))
+	}
+	fmt.Fprintf(&b, "\n📅 %s", event.DateTime.Format("Mon 2 Jan 2006, 15:04"))
+
+	venue := []string{}
+	for _, part := range []string{event.Venue.Name, event.Venue.City, event.Venue.Country} {
+		if part != "" {
+			venue = append(venue, part)
+		}
+	}
+	if len(venue) > 0 {
+		fmt.Fprintf(&b, "\n📍 %s", html.EscapeString(strings.Join(venue, ", ")))
+	}
+
+	if event.TicketURL != "" {
+		fmt.Fprintf(&b, "\n🎟 <a href=\"%s\">Tickets</a>", html.EscapeString(event.TicketURL))
+	}
+
+	return b.String()
+}
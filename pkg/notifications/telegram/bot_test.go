@@ -0,0 +1,318 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/notifications"
+)
+
+// fakeBotAPI records sendMessage calls and fails for blocked chats
+type fakeBotAPI struct {
+	mu      sync.Mutex
+	sent    map[int64][]string
+	blocked map[int64]bool
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		ChatID int64  `json:"chat_id"`
+		Text   string `json:"text"`
+	}
+	json.NewDecoder(r.Body).Decode(&params)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case !strings.HasSuffix(r.URL.Path, "/sendMessage"):
+		w.Write([]byte(`{"ok":true,"result":[]}`))
+	case f.blocked[params.ChatID]:
+		w.Write([]byte(`{"ok":false,"error_code":403,"description":"Forbidden: bot was blocked by the user"}`))
+	default:
+		f.sent[params.ChatID] = append(f.sent[params.ChatID], params.Text)
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	}
+}
+
+type memoryLinks struct {
+	domain.TelegramLinkRepository
+	codes    map[string]string
+	links    map[string]domain.TelegramLink
+	alerted  map[string]time.Time
+	unlinked []int64
+}
+
+func (m *memoryLinks) ConsumeLinkCode(ctx context.Context, code string, now time.Time) (string, error) {
+	userID, ok := m.codes[code]
+	if !ok {
+		return "", domain.ErrLinkCodeInvalid
+	}
+	delete(m.codes, code)
+	return userID, nil
+}
+
+func (m *memoryLinks) Link(ctx context.Context, userID string, chatID int64) error {
+	m.links[userID] = domain.TelegramLink{UserID: userID, ChatID: chatID, LinkedAt: time.Now()}
+	return nil
+}
+
+func (m *memoryLinks) Get(ctx context.Context, userID string) (*domain.TelegramLink, error) {
+	link, ok := m.links[userID]
+	if !ok {
+		return nil, domain.ErrLinkNotFound
+	}
+	return &link, nil
+}
+
+func (m *memoryLinks) UnlinkChat(ctx context.Context, chatID int64) error {
+	m.unlinked = append(m.unlinked, chatID)
+	for userID, link := range m.links {
+		if link.ChatID == chatID {
+			delete(m.links, userID)
+			return nil
+		}
+	}
+	return domain.ErrLinkNotFound
+}
+
+func (m *memoryLinks) ListLinks(ctx context.Context) ([]domain.TelegramLink, error) {
+	links := []domain.TelegramLink{}
+	for _, link := range m.links {
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+func (m *memoryLinks) MarkAlerted(ctx context.Context, userID string, alertedAt time.Time) error {
+	m.alerted[userID] = alertedAt
+	return nil
+}
+
+type memoryFollows struct {
+	domain.FollowRepository
+	follows map[string][]domain.Follow
+}
+
+func (m *memoryFollows) ListFollows(ctx context.Context, userID string) ([]domain.Follow, error) {
+	return m.follows[userID], nil
+}
+
+type memoryEvents struct {
+	domain.EventRepository
+	events []domain.Event
+}
+
+func (m *memoryEvents) ListDiscovered(ctx context.Context, filter domain.DiscoveryFilter, limit int) ([]domain.Event, error) {
+	matched := []domain.Event{}
+	for _, event := range m.events {
+		if strings.EqualFold(event.ArtistName, filter.ArtistName) && event.DiscoveredAt.After(*filter.Since) {
+			matched = append(matched, event)
+		}
+	}
+	return matched, nil
+}
+
+type memoryChanges struct {
+	changes []domain.EventChange
+}
+
+func (m *memoryChanges) ListChanges(ctx context.Context, filter domain.DiscoveryFilter, limit int) ([]domain.EventChange, error) {
+	matched := []domain.EventChange{}
+	for _, change := range m.changes {
+		if strings.EqualFold(change.Event.ArtistName, filter.ArtistName) && change.ChangedAt.After(*filter.Since) {
+			matched = append(matched, change)
+		}
+	}
+	return matched, nil
+}
+
+func newTestBot(t *testing.T, api *fakeBotAPI, links *memoryLinks, follows *memoryFollows, events *memoryEvents) *Bot {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	client, _ := NewClient("test-token")
+	client.baseURL = server.URL + "/bottest-token"
+
+	return NewBot(BotConfig{Client: client, Links: links, Follows: follows, Events: events})
+}
+
+func TestBot_HandleMessage(t *testing.T) {
+	api := &fakeBotAPI{sent: map[int64][]string{}}
+	links := &memoryLinks{codes: map[string]string{"abc123": "user_1"}, links: map[string]domain.TelegramLink{}}
+	bot := newTestBot(t, api, links, &memoryFollows{}, &memoryEvents{})
+	ctx := context.Background()
+
+	bot.HandleMessage(ctx, Message{Chat: Chat{ID: 42}, Text: "/start@WhereItsAtBot abc123"})
+	if links.links["user_1"].ChatID != 42 {
+		t.Fatalf("expected user_1 to be linked to chat 42, got %+v", links.links)
+	}
+
+	bot.HandleMessage(ctx, Message{Chat: Chat{ID: 43}, Text: "/start abc123"})
+	if links.links["user_1"].ChatID != 42 {
+		t.Error("expected a used code not to relink")
+	}
+
+	bot.HandleMessage(ctx, Message{Chat: Chat{ID: 42}, Text: "/stop"})
+	if _, linked := links.links["user_1"]; linked {
+		t.Error("expected /stop to unlink the chat")
+	}
+
+	replies := []string{api.sent[42][0], api.sent[43][0], api.sent[42][1]}
+	if replies[0] != linkedText || replies[1] != badCodeText || replies[2] != stoppedText {
+		t.Errorf("unexpected replies: %q", replies)
+	}
+}
+
+func TestBot_PushNewEvents(t *testing.T) {
+	now := time.Now()
+	linkedAt := now.Add(-time.Hour)
+	discovered := now.Add(-time.Minute)
+	before := now.Add(-2 * time.Hour)
+
+	api := &fakeBotAPI{sent: map[int64][]string{}, blocked: map[int64]bool{99: true}}
+	links := &memoryLinks{
+		links: map[string]domain.TelegramLink{
+			"user_1":  {UserID: "user_1", ChatID: 42, LinkedAt: linkedAt},
+			"blocked": {UserID: "blocked", ChatID: 99, LinkedAt: linkedAt},
+		},
+		alerted: map[string]time.Time{},
+	}
+	follows := &memoryFollows{follows: map[string][]domain.Follow{
+		"user_1":  {{ArtistName: "Radiohead"}},
+		"blocked": {{ArtistName: "Radiohead"}},
+	}}
+	events := &memoryEvents{events: []domain.Event{
+		{ID: "new", ArtistName: "Radiohead", DateTime: now.Add(48 * time.Hour), Venue: domain.Venue{Name: "O2 Arena", City: "London"}, TicketURL: "https://tickets.example.com/?a=1&b=2", DiscoveredAt: &discovered},
+		{ID: "old", ArtistName: "Radiohead", DateTime: now.Add(48 * time.Hour), DiscoveredAt: &before},
+		{ID: "past", ArtistName: "Radiohead", DateTime: now.Add(-time.Hour), DiscoveredAt: &discovered},
+	}}
+
+	bot := newTestBot(t, api, links, follows, events)
+
+	sent, err := bot.PushNewEvents(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sent != 1 || len(api.sent[42]) != 1 {
+		t.Fatalf("expected one alert, got %v", api.sent)
+	}
+	if !strings.Contains(api.sent[42][0], "O2 Arena, London") || !strings.Contains(api.sent[42][0], `href="https://tickets.example.com/?a=1&amp;b=2"`) {
+		t.Errorf("unexpected message: %s", api.sent[42][0])
+	}
+	if _, ok := links.alerted["user_1"]; !ok {
+		t.Error("expected the alert time to move on")
+	}
+	if len(links.unlinked) != 1 || links.unlinked[0] != 99 {
+		t.Errorf("expected the blocked chat to be unlinked, got %v", links.unlinked)
+	}
+}
+
+func TestBot_PushEventChanges(t *testing.T) {
+	now := time.Now()
+	linkedAt := now.Add(-time.Hour)
+	changedAt := now.Add(-time.Minute)
+	moved := now.Add(24 * time.Hour)
+
+	api := &fakeBotAPI{sent: map[int64][]string{}, blocked: map[int64]bool{}}
+	links := &memoryLinks{
+		links:   map[string]domain.TelegramLink{"user_1": {UserID: "user_1", ChatID: 42, LinkedAt: linkedAt}},
+		alerted: map[string]time.Time{},
+	}
+	follows := &memoryFollows{follows: map[string][]domain.Follow{"user_1": {{ArtistName: "Radiohead"}}}}
+	changes := &memoryChanges{changes: []domain.EventChange{
+		{Event: domain.Event{ID: "cancelled", ArtistName: "Radiohead", DateTime: moved}, Status: domain.EventCancelled, PreviousDateTime: moved, ChangedAt: changedAt},
+		{Event: domain.Event{ID: "moved", ArtistName: "Radiohead", DateTime: now.Add(72 * time.Hour)}, Status: domain.EventRescheduled, PreviousDateTime: moved, ChangedAt: changedAt},
+		{Event: domain.Event{ID: "moved", ArtistName: "Radiohead", DateTime: moved}, Status: domain.EventRescheduled, PreviousDateTime: now.Add(48 * time.Hour), ChangedAt: changedAt.Add(-time.Second)},
+		{Event: domain.Event{ID: "past", ArtistName: "Radiohead", DateTime: now.Add(time.Hour)}, Status: domain.EventRescheduled, PreviousDateTime: now.Add(-time.Hour), ChangedAt: changedAt},
+	}}
+
+	bot := newTestBot(t, api, links, follows, &memoryEvents{})
+	bot.config.Changes = changes
+
+	sent, err := bot.PushNewEvents(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if sent != 2 || len(api.sent[42]) != 2 {
+		t.Fatalf("expected two alerts, got %v", api.sent)
+	}
+	if !strings.HasPrefix(api.sent[42][0], "❌ <b>Cancelled</b>\n🎤") {
+		t.Errorf("unexpected message: %s", api.sent[42][0])
+	}
+	if !strings.HasPrefix(api.sent[42][1], "🔁 <b>Moved from "+moved.Format("Mon 2 Jan 2006, 15:04")+"</b>") {
+		t.Errorf("unexpected message: %s", api.sent[42][1])
+	}
+}
+
+func TestBot_NotifyOnSale(t *testing.T) {
+	onSale := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	event := domain.Event{ID: "tm_1", ArtistName: "Radiohead", DateTime: onSale.Add(90 * 24 * time.Hour), OnSaleDate: &onSale}
+
+	api := &fakeBotAPI{sent: map[int64][]string{}, blocked: map[int64]bool{99: true}}
+	links := &memoryLinks{links: map[string]domain.TelegramLink{
+		"user_1":  {UserID: "user_1", ChatID: 42},
+		"blocked": {UserID: "blocked", ChatID: 99},
+	}}
+	bot := newTestBot(t, api, links, &memoryFollows{}, &memoryEvents{})
+
+	alert := func(userID string) notifications.OnSaleAlert {
+		return notifications.OnSaleAlert{User: domain.User{ID: userID}, Events: []domain.Event{event}}
+	}
+
+	if err := bot.NotifyOnSale(context.Background(), alert("user_1")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(api.sent[42]) != 1 || !strings.HasPrefix(api.sent[42][0], "⏰ <b>Tickets on sale Fri 1 May, 10:00 UTC</b>\n🎤") {
+		t.Errorf("unexpected messages: %v", api.sent[42])
+	}
+
+	t.Run("unlinked user", func(t *testing.T) {
+		if err := bot.NotifyOnSale(context.Background(), alert("nobody")); err != nil {
+			t.Errorf("expected users without a chat to be skipped, got %v", err)
+		}
+	})
+
+	t.Run("blocked chat", func(t *testing.T) {
+		bot.NotifyOnSale(context.Background(), alert("blocked"))
+		if len(links.unlinked) != 1 || links.unlinked[0] != 99 {
+			t.Errorf("expected the blocked chat to be unlinked, got %v", links.unlinked)
+		}
+	})
+}
+
+func TestBot_NotifyReleases(t *testing.T) {
+	api := &fakeBotAPI{sent: map[int64][]string{}}
+	links := &memoryLinks{links: map[string]domain.TelegramLink{"user_1": {UserID: "user_1", ChatID: 42}}}
+	bot := newTestBot(t, api, links, &memoryFollows{}, &memoryEvents{})
+
+	alert := notifications.ReleaseAlert{User: domain.User{ID: "user_1"}, Releases: []domain.Album{
+		{ID: "deezer_1", Title: "Isles", ArtistName: "Bicep", ReleaseDate: "2026-05-15"},
+	}}
+	if err := bot.NotifyReleases(context.Background(), alert); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(api.sent[42]) != 1 || api.sent[42][0] != "💿 <b>Bicep</b> – Isles\n📅 Out Fri 15 May 2026" {
+		t.Errorf("unexpected messages: %v", api.sent[42])
+	}
+}
+
+func TestFormatEvent(t *testing.T) {
+	text := FormatEvent(domain.Event{
+		ArtistName: "Simon & Garfunkel",
+		Title:      "<Reunion>",
+		DateTime:   time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC),
+	})
+
+	want := "🎤 <b>Simon &amp; Garfunkel</b> – &lt;Reunion&gt;\n📅 Fri 1 May 2026, 20:00"
+	if text != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+}
@@ -0,0 +1,158 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// pollTimeout is how long getUpdates waits for a message before returning
+// empty, so the bot isn't hammering the API while idle
+const pollTimeout = 30 * time.Second
+
+// Client talks to the Telegram Bot API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewClient(token string) (*Client, error) {
+	if token == "" {
+		return nil, fmt.Errorf("telegram bot token is required")
+	}
+
+	// The token is part of every request URL, so this client keeps its own
+	// transport rather than the shared one that records URLs in traces
+	return &Client{
+		baseURL: "https://api.telegram.org/bot" + token,
+		httpClient: &http.Client{
+			Timeout:   pollTimeout + 10*time.Second,
+			Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, ForceAttemptHTTP2: true},
+		},
+	}, nil
+}
+
+type User struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	From      *User  `json:"from,omitempty"`
+	Text      string `json:"text"`
+}
+
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message,omitempty"`
+}
+
+type apiResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+// APIError is an error reported by the Bot API
+type APIError struct {
+	Code        int
+	Description string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram API error %d: %s", e.Code, e.Description)
+}
+
+// Blocked reports whether the chat can't be messaged any more, e.g. the
+// user blocked the bot or deleted the chat
+func (e *APIError) Blocked() bool {
+	return e.Code == http.StatusForbidden
+}
+
+// GetMe returns the bot's own account, whose username deep links need
+func (c *Client) GetMe(ctx context.Context) (*User, error) {
+	var me User
+	if err := c.call(ctx, "getMe", struct{}{}, &me); err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// GetUpdates long-polls for messages after offset
+func (c *Client) GetUpdates(ctx context.Context, offset int64) ([]Update, error) {
+	params := map[string]interface{}{
+		"offset":          offset,
+		"timeout":         int(pollTimeout.Seconds()),
+		"allowed_updates": []string{"message"},
+	}
+
+	var updates []Update
+	if err := c.call(ctx, "getUpdates", params, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// SendMessage sends an HTML-formatted message to a chat
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	params := map[string]interface{}{
+		"chat_id":                  chatID,
+		"text":                     text,
+		"parse_mode":               "HTML",
+		"disable_web_page_preview": true,
+	}
+
+	return c.call(ctx, "sendMessage", params, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// Drop the URL, and the token with it, from transport errors
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to call %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp apiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", method, err)
+	}
+	if !apiResp.OK {
+		return &APIError{Code: apiResp.ErrorCode, Description: apiResp.Description}
+	}
+
+	if result != nil {
+		if err := json.Unmarshal(apiResp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode %s result: %w", method, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,463 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+// Limiter is a token bucket holding up to limit tokens that refills at
+// limit tokens per window, or for daily quotas a count of the requests made
+// since the provider's day started. It is safe for concurrent use.
+type Limiter struct {
+	mu       sync.Mutex
+	source   string
+	limit    int
+	window   time.Duration
+	tokens   float64
+	last     time.Time
+	store    domain.QuotaRepository
+	now      func() time.Time
+	capacity float64
+	rate     float64 // tokens per second
+
+	// dayStartsIn is where the provider's day starts at midnight, nil for
+	// buckets. used counts the cost spent since dayStart.
+	dayStartsIn *time.Location
+	dayStart    time.Time
+	used        int
+}
+
+// RejectionObserver is told every time a limiter turns a request away
+type RejectionObserver interface {
+	RateLimitRejected(source string)
+}
+
+var rejectionObserver RejectionObserver
+
+// SetRejectionObserver reports rejections from every limiter to observer. It
+// must be called before the limiters are used.
+func SetRejectionObserver(observer RejectionObserver) {
+	rejectionObserver = observer
+}
+
+// Counter counts requests under keys that expire, shared by every instance
+// of the service
+type Counter interface {
+	Increment(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error)
+	Count(ctx context.Context, key string) (int64, error)
+}
+
+var sharedCounter Counter
+
+// SetSharedCounter counts every limiter's requests in counter, so instances
+// sharing an API key share its budget. Shared limits count requests in fixed
+// windows rather than refilling a bucket; when the counter can't be reached
+// a limiter falls back to its own bucket. It must be called before the
+// limiters are used, and nil goes back to the buckets.
+func SetSharedCounter(counter Counter) {
+	sharedCounter = counter
+}
+
+func New(source string, limit int, window time.Duration) *Limiter {
+	if limit <= 0 {
+		limit = 1
+	}
+	if window <= 0 {
+		window = time.Second
+	}
+
+	l := &Limiter{
+		source:   source,
+		limit:    limit,
+		window:   window,
+		capacity: float64(limit),
+		rate:     float64(limit) / window.Seconds(),
+		now:      time.Now,
+	}
+	l.tokens = l.capacity
+	l.last = l.now()
+
+	return l
+}
+
+func PerSecond(source string, limit int) *Limiter {
+	return New(source, limit, time.Second)
+}
+
+func PerHour(source string, limit int) *Limiter {
+	return New(source, limit, time.Hour)
+}
+
+// PerDay counts requests in days starting at midnight UTC. Daily quotas
+// reset all at once, so a bucket refilling through the day would let up to
+// twice the quota through in any 24 hours.
+func PerDay(source string, limit int) *Limiter {
+	return PerDayIn(source, limit, time.UTC)
+}
+
+// PerDayIn is PerDay for a provider whose day starts at midnight in loc
+func PerDayIn(source string, limit int, loc *time.Location) *Limiter {
+	l := New(source, limit, 24*time.Hour)
+	l.dayStartsIn = loc
+	l.dayStart, _ = l.windowBounds(l.now())
+	return l
+}
+
+// Allow takes a token, or returns domain.ErrRateLimitExceeded when the bucket is empty
+func (l *Limiter) Allow() error {
+	if allowed, _, ok := l.takeShared(context.Background(), 1); ok {
+		if !allowed {
+			l.rejected()
+			return domain.ErrRateLimitExceeded
+		}
+		return nil
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	if !l.take(now, 1) {
+		l.mu.Unlock()
+		l.rejected()
+		return domain.ErrRateLimitExceeded
+	}
+	l.mu.Unlock()
+
+	l.persist(now, 1)
+	return nil
+}
+
+// maxWait caps how long Wait queues for a token. Past it the quota is
+// exhausted rather than briefly busy, and failing is better than holding the
+// request open.
+const maxWait = 30 * time.Second
+
+// Wait takes a token, sleeping until one is free. It returns
+// domain.ErrRateLimitExceeded right away when the token would come after
+// ctx's deadline or more than maxWait from now, and ctx's error if ctx is
+// done first, handing the token back either way.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN is Wait for a request that costs n tokens, for APIs that charge
+// their calls differently against one quota. A request costing more than
+// the limit is always rejected.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if n > l.limit {
+		l.rejected()
+		return domain.ErrRateLimitExceeded
+	}
+	if handled, err := l.waitShared(ctx, n); handled {
+		return err
+	}
+
+	cost := float64(n)
+	l.mu.Lock()
+
+	now := l.now()
+	if l.take(now, n) {
+		l.mu.Unlock()
+		l.persist(now, n)
+		return nil
+	}
+	if l.dayStartsIn != nil {
+		l.mu.Unlock()
+		return l.waitForNextDay(ctx, n, now)
+	}
+
+	wait := time.Duration((cost - l.tokens) / l.rate * float64(time.Second))
+	if !l.canWait(ctx, wait) {
+		l.mu.Unlock()
+		l.rejected()
+		return domain.ErrRateLimitExceeded
+	}
+
+	// Taking the tokens now queues later callers behind this one
+	l.tokens -= cost
+	l.mu.Unlock()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens = math.Min(l.capacity, l.tokens+cost)
+		l.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	l.persist(now.Add(wait), n)
+	return nil
+}
+
+// waitForNextDay waits for the provider's next day when today's quota has
+// run out, which is only worth it in the last moments before midnight
+func (l *Limiter) waitForNextDay(ctx context.Context, n int, now time.Time) error {
+	_, dayEnd := l.windowBounds(now)
+	wait := dayEnd.Sub(now)
+	if !l.canWait(ctx, wait) {
+		l.rejected()
+		return domain.ErrRateLimitExceeded
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	return l.WaitN(ctx, n)
+}
+
+// UseStore replays the requests recorded inside the last window, or since
+// the day started for daily quotas, so a restart doesn't hand out a full
+// quota, then persists every request from now on
+func (l *Limiter) UseStore(ctx context.Context, store domain.QuotaRepository) error {
+	now := l.now()
+	since := now.Add(-l.window)
+	if l.dayStartsIn != nil {
+		since, _ = l.windowBounds(now)
+	}
+	requests, err := store.GetRequestsSince(ctx, l.source, since)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s quota: %w", l.source, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.dayStartsIn != nil {
+		l.dayStart = since
+		l.used = 0
+		for _, request := range requests {
+			l.used += request.Cost
+		}
+		l.store = store
+		return nil
+	}
+
+	l.tokens = l.capacity
+	l.last = now.Add(-l.window)
+	for _, request := range requests {
+		l.refill(request.RequestedAt)
+		l.tokens -= float64(request.Cost)
+	}
+	l.refill(now)
+	l.store = store
+
+	return nil
+}
+
+func (l *Limiter) Quota() domain.SourceQuota {
+	if quota, ok := l.sharedQuota(); ok {
+		return quota
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if l.dayStartsIn != nil {
+		dayStart, dayEnd := l.windowBounds(now)
+		used := 0
+		if dayStart.Equal(l.dayStart) {
+			used = min(l.used, l.limit)
+		}
+		return l.countedQuota(used, dayEnd)
+	}
+	l.refill(now)
+
+	remaining := int(math.Floor(l.tokens))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	quota := domain.SourceQuota{
+		Source:    l.source,
+		Limit:     l.limit,
+		Used:      l.limit - remaining,
+		Remaining: remaining,
+		Window:    l.window.String(),
+	}
+	if l.tokens < l.capacity {
+		resetsAt := now.Add(time.Duration((l.capacity - l.tokens) / l.rate * float64(time.Second)))
+		quota.ResetsAt = &resetsAt
+	}
+
+	return quota
+}
+
+// waitShared is Wait against the shared counter. handled is false without
+// a counter or when it couldn't be reached, and the bucket should decide.
+func (l *Limiter) waitShared(ctx context.Context, n int) (handled bool, err error) {
+	for {
+		allowed, windowEnd, ok := l.takeShared(ctx, n)
+		if !ok {
+			return false, nil
+		}
+		if allowed {
+			return true, nil
+		}
+
+		wait := windowEnd.Sub(l.now())
+		if !l.canWait(ctx, wait) {
+			l.rejected()
+			return true, domain.ErrRateLimitExceeded
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return true, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// takeShared counts a request costing n in the current window of the shared
+// counter. allowed is whether the window had room, windowEnd when the next
+// one starts; ok is false without a counter or when it failed.
+func (l *Limiter) takeShared(ctx context.Context, n int) (allowed bool, windowEnd time.Time, ok bool) {
+	counter := sharedCounter
+	if counter == nil {
+		return false, time.Time{}, false
+	}
+
+	key, windowEnd := l.sharedWindow(l.now())
+	ttl := windowEnd.Sub(l.now()) + time.Second
+	count, err := counter.Increment(ctx, key, int64(n), ttl)
+	if err != nil {
+		return false, time.Time{}, false
+	}
+	if count <= int64(l.limit) {
+		l.persist(l.now(), n)
+		return true, windowEnd, true
+	}
+	if n > 1 {
+		// A costly request turned away mustn't use up what cheaper ones
+		// could still have. Best effort, like the count itself.
+		counter.Increment(ctx, key, int64(-n), ttl)
+	}
+	return false, windowEnd, true
+}
+
+func (l *Limiter) sharedQuota() (domain.SourceQuota, bool) {
+	counter := sharedCounter
+	if counter == nil {
+		return domain.SourceQuota{}, false
+	}
+
+	key, windowEnd := l.sharedWindow(l.now())
+	count, err := counter.Count(context.Background(), key)
+	if err != nil {
+		return domain.SourceQuota{}, false
+	}
+
+	return l.countedQuota(min(int(count), l.limit), windowEnd), true
+}
+
+// countedQuota is the quota of a fixed window ending at windowEnd with used
+// of it spent
+func (l *Limiter) countedQuota(used int, windowEnd time.Time) domain.SourceQuota {
+	quota := domain.SourceQuota{
+		Source:    l.source,
+		Limit:     l.limit,
+		Used:      used,
+		Remaining: l.limit - used,
+		Window:    l.window.String(),
+	}
+	if used > 0 {
+		quota.ResetsAt = &windowEnd
+	}
+	return quota
+}
+
+// sharedWindow is the shared counter's key for the fixed window holding
+// now, and when that window ends
+func (l *Limiter) sharedWindow(now time.Time) (string, time.Time) {
+	start, end := l.windowBounds(now)
+	return fmt.Sprintf("ratelimit:%s:%d", l.source, start.Unix()), end
+}
+
+// windowBounds is when the fixed window holding now starts and ends. Daily
+// quotas follow the provider's calendar day, which isn't always 24 hours
+// long where clocks change.
+func (l *Limiter) windowBounds(now time.Time) (time.Time, time.Time) {
+	if l.dayStartsIn == nil {
+		start := now.Truncate(l.window)
+		return start, start.Add(l.window)
+	}
+	local := now.In(l.dayStartsIn)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, l.dayStartsIn)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// canWait reports whether a token wait from now is short enough to queue for
+func (l *Limiter) canWait(ctx context.Context, wait time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return wait <= maxWait && (!ok || time.Until(deadline) >= wait)
+}
+
+func (l *Limiter) rejected() {
+	if rejectionObserver != nil {
+		rejectionObserver.RateLimitRejected(l.source)
+	}
+}
+
+// take spends n from the bucket or today's count when there's room for
+// all of it. Callers must hold l.mu.
+func (l *Limiter) take(now time.Time, n int) bool {
+	if l.dayStartsIn != nil {
+		if dayStart, _ := l.windowBounds(now); !dayStart.Equal(l.dayStart) {
+			l.dayStart = dayStart
+			l.used = 0
+		}
+		if l.used+n > l.limit {
+			return false
+		}
+		l.used += n
+		return true
+	}
+
+	l.refill(now)
+	if l.tokens < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+func (l *Limiter) refill(now time.Time) {
+	elapsed := now.Sub(l.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	l.tokens = math.Min(l.capacity, l.tokens+elapsed*l.rate)
+	l.last = now
+}
+
+// persist records a request costing n, so UseStore replays it at its cost.
+// Callers must not hold l.mu: the write can be slow and other requests
+// shouldn't queue behind it. It is best effort; a failed write must not fail
+// the API call.
+func (l *Limiter) persist(now time.Time, n int) {
+	l.mu.Lock()
+	store := l.store
+	l.mu.Unlock()
+
+	if store != nil {
+		store.RecordRequest(context.Background(), l.source, now, n)
+	}
+}
@@ -0,0 +1,490 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yair/where-its-at/pkg/domain"
+)
+
+type memoryQuotaRepository struct {
+	mu       sync.Mutex
+	requests map[string][]domain.SourceRequest
+}
+
+func (m *memoryQuotaRepository) RecordRequest(ctx context.Context, source string, requestedAt time.Time, cost int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.requests == nil {
+		m.requests = make(map[string][]domain.SourceRequest)
+	}
+	m.requests[source] = append(m.requests[source], domain.SourceRequest{RequestedAt: requestedAt, Cost: cost})
+	return nil
+}
+
+func (m *memoryQuotaRepository) GetRequestsSince(ctx context.Context, source string, since time.Time) ([]domain.SourceRequest, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	found := []domain.SourceRequest{}
+	for _, request := range m.requests[source] {
+		if request.RequestedAt.After(since) {
+			found = append(found, request)
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryQuotaRepository) DeleteBefore(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func newTestLimiter(limit int, window time.Duration) (*Limiter, *time.Time) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	limiter := New("test", limit, window)
+	limiter.now = func() time.Time { return now }
+	limiter.last = now
+	return limiter, &now
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	t.Run("allows requests within limit", func(t *testing.T) {
+		limiter, _ := newTestLimiter(5, time.Hour)
+
+		for i := 0; i < 5; i++ {
+			if err := limiter.Allow(); err != nil {
+				t.Errorf("expected request %d to be allowed, got %v", i+1, err)
+			}
+		}
+	})
+
+	t.Run("blocks requests over limit", func(t *testing.T) {
+		limiter, _ := newTestLimiter(2, time.Hour)
+
+		limiter.Allow()
+		limiter.Allow()
+
+		if err := limiter.Allow(); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected ErrRateLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("refills over the window", func(t *testing.T) {
+		limiter, now := newTestLimiter(24, 24*time.Hour)
+
+		for i := 0; i < 24; i++ {
+			limiter.Allow()
+		}
+
+		*now = now.Add(time.Hour)
+		if err := limiter.Allow(); err != nil {
+			t.Errorf("expected one token after an hour, got %v", err)
+		}
+		if err := limiter.Allow(); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected ErrRateLimitExceeded, got %v", err)
+		}
+	})
+
+	t.Run("is safe for concurrent use", func(t *testing.T) {
+		limiter := PerDay("test", 100)
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+		for i := 0; i < 200; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if limiter.Allow() == nil {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if allowed != 100 {
+			t.Errorf("expected exactly 100 allowed requests, got %d", allowed)
+		}
+	})
+}
+
+func newTestDayLimiter(limit int, loc *time.Location) (*Limiter, *time.Time) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	limiter := PerDayIn("test", limit, loc)
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+func TestLimiter_PerDay(t *testing.T) {
+	t.Run("does not refill during the day", func(t *testing.T) {
+		limiter, now := newTestDayLimiter(24, time.UTC)
+
+		for i := 0; i < 24; i++ {
+			if err := limiter.Allow(); err != nil {
+				t.Fatalf("request %d: expected no error, got %v", i+1, err)
+			}
+		}
+
+		// A bucket would have a token back every hour
+		*now = time.Date(2024, 6, 1, 23, 59, 0, 0, time.UTC)
+		if err := limiter.Allow(); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected ErrRateLimitExceeded until midnight, got %v", err)
+		}
+
+		*now = time.Date(2024, 6, 2, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 24; i++ {
+			if err := limiter.Allow(); err != nil {
+				t.Fatalf("request %d of the next day: expected no error, got %v", i+1, err)
+			}
+		}
+		if err := limiter.Allow(); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected the next day's quota capped too, got %v", err)
+		}
+	})
+
+	t.Run("resets at the provider's midnight", func(t *testing.T) {
+		pacific := time.FixedZone("PDT", -7*60*60)
+		limiter, now := newTestDayLimiter(100, pacific)
+
+		if err := limiter.WaitN(context.Background(), 100); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		midnight := time.Date(2024, 6, 2, 0, 0, 0, 0, pacific)
+		quota := limiter.Quota()
+		if quota.Used != 100 || quota.Remaining != 0 || quota.ResetsAt == nil || !quota.ResetsAt.Equal(midnight) {
+			t.Errorf("expected the quota used up until midnight Pacific, got %+v", quota)
+		}
+
+		*now = midnight.Add(-time.Minute)
+		if err := limiter.WaitN(context.Background(), 1); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected ErrRateLimitExceeded before midnight Pacific, got %v", err)
+		}
+
+		*now = midnight
+		if quota := limiter.Quota(); quota.Used != 0 || quota.ResetsAt != nil {
+			t.Errorf("expected a fresh quota at midnight Pacific, got %+v", quota)
+		}
+		if err := limiter.WaitN(context.Background(), 1); err != nil {
+			t.Errorf("expected a new day, got %v", err)
+		}
+	})
+
+	t.Run("restores only today's requests", func(t *testing.T) {
+		limiter, now := newTestDayLimiter(10, time.UTC)
+		store := &memoryQuotaRepository{}
+		store.RecordRequest(context.Background(), "test", now.Add(-13*time.Hour), 5)
+		store.RecordRequest(context.Background(), "test", now.Add(-11*time.Hour), 4)
+		store.RecordRequest(context.Background(), "test", now.Add(-time.Minute), 5)
+
+		if err := limiter.UseStore(context.Background(), store); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if quota := limiter.Quota(); quota.Used != 9 {
+			t.Errorf("expected the 9 units spent today restored, got %+v", quota)
+		}
+		if err := limiter.WaitN(context.Background(), 2); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected ErrRateLimitExceeded, got %v", err)
+		}
+		if err := limiter.Allow(); err != nil {
+			t.Errorf("expected the last unit allowed, got %v", err)
+		}
+		if len(store.requests["test"]) != 4 {
+			t.Errorf("expected the allowed request persisted, got %d stored", len(store.requests["test"]))
+		}
+	})
+
+	t.Run("shares the provider's day", func(t *testing.T) {
+		counter := &memoryCounter{counts: make(map[string]int64), ttls: make(map[string]time.Duration)}
+		SetSharedCounter(counter)
+		defer SetSharedCounter(nil)
+
+		pacific := time.FixedZone("PDT", -7*60*60)
+		limiter, _ := newTestDayLimiter(2, pacific)
+		limiter.Allow()
+
+		// The Pacific day runs from 07:00 UTC, ending 19 hours after 12:00 UTC
+		dayStart := time.Date(2024, 6, 1, 0, 0, 0, 0, pacific)
+		key := fmt.Sprintf("ratelimit:test:%d", dayStart.Unix())
+		if counter.counts[key] != 1 || counter.ttls[key] != 19*time.Hour+time.Second {
+			t.Errorf("expected the count kept until midnight Pacific, got %v and %v", counter.counts, counter.ttls)
+		}
+	})
+}
+
+func TestLimiter_Wait(t *testing.T) {
+	t.Run("waits for the next token", func(t *testing.T) {
+		limiter, _ := newTestLimiter(1, 50*time.Millisecond)
+		store := &memoryQuotaRepository{}
+		limiter.store = store
+
+		start := time.Now()
+		for i := 0; i < 2; i++ {
+			if err := limiter.Wait(context.Background()); err != nil {
+				t.Fatalf("request %d: expected no error, got %v", i+1, err)
+			}
+		}
+
+		if waited := time.Since(start); waited < 50*time.Millisecond {
+			t.Errorf("expected the second request to wait 50ms, waited %v", waited)
+		}
+		if len(store.requests["test"]) != 2 {
+			t.Errorf("expected both requests recorded, got %v", store.requests["test"])
+		}
+	})
+
+	t.Run("stops when the context is canceled", func(t *testing.T) {
+		limiter, _ := newTestLimiter(1, 20*time.Second)
+		limiter.Allow()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		start := time.Now()
+		if err := limiter.Wait(ctx); err != context.Canceled {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+		if waited := time.Since(start); waited > 5*time.Second {
+			t.Errorf("expected to return once canceled, waited %v", waited)
+		}
+		if limiter.tokens != 0 {
+			t.Errorf("expected the token handed back, got %v tokens", limiter.tokens)
+		}
+	})
+
+	t.Run("fails fast past the deadline", func(t *testing.T) {
+		limiter, _ := newTestLimiter(1, 20*time.Second)
+		limiter.Allow()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if err := limiter.Wait(ctx); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected ErrRateLimitExceeded, got %v", err)
+		}
+		if limiter.tokens != 0 {
+			t.Errorf("expected no token taken, got %v tokens", limiter.tokens)
+		}
+	})
+
+	t.Run("fails fast when the quota is exhausted", func(t *testing.T) {
+		limiter, _ := newTestLimiter(1, time.Hour)
+		limiter.Allow()
+
+		if err := limiter.Wait(context.Background()); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected ErrRateLimitExceeded, got %v", err)
+		}
+	})
+}
+
+func TestLimiter_WaitN(t *testing.T) {
+	limiter, _ := newTestLimiter(250, 24*time.Hour)
+	store := &memoryQuotaRepository{}
+	limiter.store = store
+	ctx := context.Background()
+
+	if err := limiter.WaitN(ctx, 100); err != nil {
+		t.Fatalf("expected the first search allowed, got %v", err)
+	}
+	if err := limiter.WaitN(ctx, 100); err != nil {
+		t.Fatalf("expected the second search allowed, got %v", err)
+	}
+	// 50 units left: too few for a search, plenty for lookups
+	if err := limiter.WaitN(ctx, 100); err != domain.ErrRateLimitExceeded {
+		t.Errorf("expected a third search rejected, got %v", err)
+	}
+	if err := limiter.WaitN(ctx, 1); err != nil {
+		t.Errorf("expected a lookup allowed, got %v", err)
+	}
+	if quota := limiter.Quota(); quota.Used != 201 || quota.Remaining != 49 {
+		t.Errorf("expected 201 units used, got %+v", quota)
+	}
+	if requests := store.requests["test"]; len(requests) != 3 || requests[0].Cost != 100 || requests[2].Cost != 1 {
+		t.Errorf("expected one row per request with its cost, got %+v", requests)
+	}
+
+	restored, _ := newTestLimiter(250, 24*time.Hour)
+	if err := restored.UseStore(ctx, store); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if quota := restored.Quota(); quota.Used != 201 {
+		t.Errorf("expected the restored limiter to replay 201 units, got %+v", quota)
+	}
+	if err := limiter.WaitN(ctx, 251); err != domain.ErrRateLimitExceeded {
+		t.Errorf("expected a request costing more than the limit rejected, got %v", err)
+	}
+
+	// A shared window hands a rejected search's units back
+	counter := &memoryCounter{counts: make(map[string]int64), ttls: make(map[string]time.Duration)}
+	SetSharedCounter(counter)
+	defer SetSharedCounter(nil)
+	shared, _ := newTestLimiter(150, 24*time.Hour)
+	shared.WaitN(ctx, 100)
+	if err := shared.WaitN(ctx, 100); err != domain.ErrRateLimitExceeded {
+		t.Errorf("expected the shared window to reject a second search, got %v", err)
+	}
+	if quota := shared.Quota(); quota.Used != 100 {
+		t.Errorf("expected only the allowed search counted, got %+v", quota)
+	}
+}
+
+func TestLimiter_UseStore(t *testing.T) {
+	t.Run("restores requests from store", func(t *testing.T) {
+		limiter, now := newTestLimiter(3, 24*time.Hour)
+		store := &memoryQuotaRepository{}
+		store.RecordRequest(context.Background(), "test", now.Add(-30*time.Hour), 1)
+		store.RecordRequest(context.Background(), "test", now.Add(-time.Minute), 1)
+		store.RecordRequest(context.Background(), "test", now.Add(-time.Second), 1)
+
+		if err := limiter.UseStore(context.Background(), store); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := limiter.Allow(); err != nil {
+			t.Fatalf("expected one remaining request, got %v", err)
+		}
+		if err := limiter.Allow(); err != domain.ErrRateLimitExceeded {
+			t.Errorf("expected ErrRateLimitExceeded after restore, got %v", err)
+		}
+		if len(store.requests["test"]) != 4 {
+			t.Errorf("expected allowed request to be persisted, got %d stored", len(store.requests["test"]))
+		}
+	})
+}
+
+func TestLimiter_Quota(t *testing.T) {
+	t.Run("full bucket", func(t *testing.T) {
+		limiter, _ := newTestLimiter(10, time.Hour)
+
+		quota := limiter.Quota()
+		if quota.Remaining != 10 || quota.Used != 0 {
+			t.Errorf("expected 10 remaining and 0 used, got %d and %d", quota.Remaining, quota.Used)
+		}
+		if quota.ResetsAt != nil {
+			t.Error("expected no ResetsAt for a full bucket")
+		}
+		if quota.Window != "1h0m0s" {
+			t.Errorf("expected window 1h0m0s, got %s", quota.Window)
+		}
+	})
+
+	t.Run("partially used bucket", func(t *testing.T) {
+		limiter, now := newTestLimiter(10, time.Hour)
+		limiter.Allow()
+		limiter.Allow()
+
+		quota := limiter.Quota()
+		if quota.Remaining != 8 || quota.Used != 2 {
+			t.Errorf("expected 8 remaining and 2 used, got %d and %d", quota.Remaining, quota.Used)
+		}
+		if quota.ResetsAt == nil || !quota.ResetsAt.Equal(now.Add(12*time.Minute)) {
+			t.Errorf("expected bucket to be full again in 12 minutes, got %v", quota.ResetsAt)
+		}
+	})
+}
+
+type countingObserver struct {
+	rejected map[string]int
+}
+
+func (c *countingObserver) RateLimitRejected(source string) {
+	c.rejected[source]++
+}
+
+func TestSetRejectionObserver(t *testing.T) {
+	observer := &countingObserver{rejected: make(map[string]int)}
+	SetRejectionObserver(observer)
+	defer SetRejectionObserver(nil)
+
+	limiter := New("songkick", 1, time.Hour)
+	limiter.Allow()
+	limiter.Allow()
+	limiter.Allow()
+
+	if observer.rejected["songkick"] != 2 {
+		t.Errorf("expected 2 rejections, got %d", observer.rejected["songkick"])
+	}
+}
+
+// memoryCounter is a Counter shared by limiters in the test, standing in for
+// Redis between instances
+type memoryCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	ttls   map[string]time.Duration
+	err    error
+}
+
+func (c *memoryCounter) Increment(ctx context.Context, key string, n int64, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return 0, c.err
+	}
+	if _, ok := c.ttls[key]; !ok {
+		c.ttls[key] = ttl
+	}
+	c.counts[key] += n
+	return c.counts[key], nil
+}
+
+func (c *memoryCounter) Count(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.counts[key], c.err
+}
+
+func TestSetSharedCounter(t *testing.T) {
+	counter := &memoryCounter{counts: make(map[string]int64), ttls: make(map[string]time.Duration)}
+	SetSharedCounter(counter)
+	defer SetSharedCounter(nil)
+
+	// Two instances' limiters for the same source share the hour's budget
+	first, now := newTestLimiter(3, time.Hour)
+	second, _ := newTestLimiter(3, time.Hour)
+	second.now = first.now
+
+	first.Allow()
+	second.Allow()
+	first.Allow()
+	if err := second.Allow(); err != domain.ErrRateLimitExceeded {
+		t.Errorf("expected the shared budget to run out, got %v", err)
+	}
+	if ttl := counter.ttls["ratelimit:test:1717243200"]; ttl != time.Hour+time.Second {
+		t.Errorf("expected the window to expire with the hour, got %v", ttl)
+	}
+
+	quota := first.Quota()
+	if quota.Used != 3 || quota.Remaining != 0 || quota.ResetsAt == nil || !quota.ResetsAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected the shared quota used up until 13:00, got %+v", quota)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := first.Wait(ctx); err != domain.ErrRateLimitExceeded {
+		t.Errorf("expected Wait not to queue past its deadline, got %v", err)
+	}
+
+	// The next window starts over
+	*now = now.Add(time.Hour)
+	if err := first.Allow(); err != nil {
+		t.Errorf("expected a new window, got %v", err)
+	}
+
+	// Without the counter each limiter falls back to its own bucket
+	counter.err = errors.New("connection refused")
+	if err := second.Allow(); err != nil {
+		t.Errorf("expected the local bucket to allow, got %v", err)
+	}
+	if quota := second.Quota(); quota.Used != 1 {
+		t.Errorf("expected the local quota, got %+v", quota)
+	}
+}
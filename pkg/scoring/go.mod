@@ -0,0 +1,5 @@
+module github.com/yair/where-its-at/pkg/scoring
+
+go 1.23.0
+
+toolchain go1.24.5
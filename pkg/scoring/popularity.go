@@ -0,0 +1,88 @@
+// Package scoring turns what each source counts about an artist into one
+// popularity scale, so artists from different sources sort together.
+package scoring
+
+import (
+	"math"
+	"sort"
+)
+
+// Curve describes how one source's audience count becomes a popularity
+// from 0 to 100. Counts are scored on a log scale, so each tenfold increase
+// in audience adds the same number of points, up to Saturation, which
+// scores 100. A source with no Saturation already reports 0-100.
+type Curve struct {
+	// Metric is what the source counts
+	Metric     string
+	Saturation float64
+	// Weight is how much the source counts in a blended score, by how
+	// closely its metric follows an artist's real audience
+	Weight float64
+}
+
+// Curves are set so each source's biggest artists score around 100 and an
+// artist a few hundred people follow scores around 35-40, whether they're
+// counted as listeners, fans, subscribers or followers.
+var Curves = map[string]Curve{
+	// Spotify's own score, from recent streams
+	"spotify": {Metric: "popularity", Weight: 1},
+	// Top artists have around 5M monthly listeners
+	"lastfm": {Metric: "listeners", Saturation: 5_000_000, Weight: 0.9},
+	// Top artists have 10M+ fans
+	"deezer": {Metric: "fans", Saturation: 10_000_000, Weight: 0.8},
+	// Top artist channels have 20M+ subscribers
+	"youtube": {Metric: "subscribers", Saturation: 20_000_000, Weight: 0.7},
+	// Follower counts are inflated by bots and skewed towards producers
+	"soundcloud": {Metric: "followers", Saturation: 2_000_000, Weight: 0.5},
+	// Votes on the artist's tags. Only loosely tied to audience, but it is
+	// all MusicBrainz has; its search score is relevance, not popularity.
+	"musicbrainz": {Metric: "tag votes", Saturation: 2_000, Weight: 0.3},
+}
+
+// defaultWeight is used in blends for sources without a curve
+const defaultWeight = 0.5
+
+// Popularity scores a source's audience count from 0 to 100 on the
+// source's curve. Sources without a curve, or that report a score already,
+// have count clamped to 0-100.
+func Popularity(source string, count int64) int {
+	curve, ok := Curves[source]
+	if !ok || curve.Saturation <= 0 {
+		return clamp(float64(count))
+	}
+	if count <= 0 {
+		return 0
+	}
+
+	return clamp(100 * math.Log10(1+float64(count)) / math.Log10(1+curve.Saturation))
+}
+
+// Blend combines the popularity each source gave an artist into one score,
+// a mean weighted by each source's curve. It returns 0 for no sources.
+func Blend(bySource map[string]int) int {
+	sources := make([]string, 0, len(bySource))
+	for source := range bySource {
+		sources = append(sources, source)
+	}
+	// Summed in a fixed order, so the same scores always blend the same
+	sort.Strings(sources)
+
+	var total, weights float64
+	for _, source := range sources {
+		weight := defaultWeight
+		if curve, ok := Curves[source]; ok {
+			weight = curve.Weight
+		}
+		total += weight * float64(bySource[source])
+		weights += weight
+	}
+	if weights == 0 {
+		return 0
+	}
+
+	return clamp(total / weights)
+}
+
+func clamp(score float64) int {
+	return int(math.Round(math.Max(0, math.Min(100, score))))
+}
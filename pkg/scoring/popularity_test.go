@@ -0,0 +1,58 @@
+package scoring
+
+import "testing"
+
+func TestPopularity(t *testing.T) {
+	tests := []struct {
+		source string
+		count  int64
+		want   int
+	}{
+		{"deezer", 0, 0},
+		{"deezer", 10_000_000, 100},
+		{"deezer", 50_000_000, 100},
+		{"lastfm", 1_000, 45},
+		{"soundcloud", 1_000, 48},
+		{"spotify", 73, 73},
+		{"spotify", 140, 100},
+		{"bandcamp", 42, 42},
+		{"youtube", -5, 0},
+	}
+	for _, tt := range tests {
+		if got := Popularity(tt.source, tt.count); got != tt.want {
+			t.Errorf("Popularity(%s, %d) = %d, want %d", tt.source, tt.count, got, tt.want)
+		}
+	}
+}
+
+func TestPopularity_SameAudienceScoresAlike(t *testing.T) {
+	// An artist 300 people follow scores within a few points everywhere
+	low, high := 100, 0
+	for _, source := range []string{"lastfm", "deezer", "youtube", "soundcloud"} {
+		score := Popularity(source, 300)
+		low, high = min(low, score), max(high, score)
+	}
+	if high-low > 5 {
+		t.Errorf("expected scores within 5 points, got %d to %d", low, high)
+	}
+}
+
+func TestBlend(t *testing.T) {
+	if got := Blend(nil); got != 0 {
+		t.Errorf("expected 0 for no sources, got %d", got)
+	}
+	if got := Blend(map[string]int{"deezer": 64}); got != 64 {
+		t.Errorf("expected a single score kept, got %d", got)
+	}
+
+	// Spotify's 80 counts for more than MusicBrainz's 20
+	got := Blend(map[string]int{"spotify": 80, "musicbrainz": 20})
+	if want := 66; got != want {
+		t.Errorf("expected %d, got %d", want, got)
+	}
+
+	// Unknown sources still count, at the default weight
+	if got := Blend(map[string]int{"fixtures": 50, "spotify": 50}); got != 50 {
+		t.Errorf("expected 50, got %d", got)
+	}
+}
@@ -0,0 +1,78 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Config describes where spans are exported to
+type Config struct {
+	// Endpoint is the host:port of an OTLP/HTTP collector
+	Endpoint    string
+	Insecure    bool
+	ServiceName string
+	// SampleRatio is the fraction of new traces that are recorded; requests
+	// that arrive with a sampled parent are always recorded
+	SampleRatio float64
+}
+
+// Setup installs an OTLP exporting tracer provider and the W3C trace context
+// propagator as the global defaults, and wraps http.DefaultTransport so
+// upstream API calls show up as child spans. Packages start their spans
+// through the global otel API, so until Setup runs tracing costs nothing.
+// The returned function flushes buffered spans and must be called on shutdown.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(config.Endpoint)}
+	if config.Insecure {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	provider := newProvider(sdktrace.WithBatcher(exporter), config)
+	install(provider)
+
+	return provider.Shutdown, nil
+}
+
+func newProvider(processor sdktrace.TracerProviderOption, config Config) *sdktrace.TracerProvider {
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = "where-its-at"
+	}
+
+	ratio := config.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	return sdktrace.NewTracerProvider(
+		processor,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+}
+
+func install(provider *sdktrace.TracerProvider) {
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if _, wrapped := http.DefaultTransport.(*otelhttp.Transport); !wrapped {
+		http.DefaultTransport = otelhttp.NewTransport(http.DefaultTransport)
+	}
+}
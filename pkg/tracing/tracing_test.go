@@ -0,0 +1,75 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstall(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := newProvider(sdktrace.WithSpanProcessor(recorder), Config{})
+	install(provider)
+	defer provider.Shutdown(context.Background())
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+	}))
+	defer server.Close()
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "search")
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	if traceparent == "" {
+		t.Error("expected the trace context to be propagated upstream")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected a client span and its parent, got %d spans", len(spans))
+	}
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Error("expected the client span to be a child of the search span")
+	}
+	if got := spans[1].Resource().Attributes(); len(got) == 0 || got[0].Value.AsString() != "where-its-at" {
+		t.Errorf("expected the default service name, got %v", got)
+	}
+
+	// A second install must not wrap the transport twice
+	install(provider)
+	resp, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if len(recorder.Ended()) != 3 {
+		t.Errorf("expected one more client span, got %d spans in total", len(recorder.Ended()))
+	}
+}
+
+func TestNewProvider_Sampling(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := newProvider(sdktrace.WithSpanProcessor(recorder), Config{SampleRatio: 0.0000001})
+	defer provider.Shutdown(context.Background())
+
+	for i := 0; i < 100; i++ {
+		_, span := provider.Tracer("test").Start(context.Background(), "search")
+		span.End()
+	}
+
+	if len(recorder.Ended()) > 1 {
+		t.Errorf("expected almost no spans to be sampled, got %d", len(recorder.Ended()))
+	}
+}
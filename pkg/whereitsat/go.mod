@@ -0,0 +1,69 @@
+module github.com/yair/where-its-at/pkg/whereitsat
+
+go 1.24.0
+
+toolchain go1.24.5
+
+require (
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/golang-jwt/jwt/v5 v5.2.3
+	github.com/mattn/go-sqlite3 v1.14.32
+	github.com/yair/where-its-at/pkg/cache v0.0.0
+	github.com/yair/where-its-at/pkg/collectors v0.0.0
+	github.com/yair/where-its-at/pkg/config v0.0.0
+	github.com/yair/where-its-at/pkg/domain v0.0.0
+	github.com/yair/where-its-at/pkg/integrations v0.0.0
+	github.com/yair/where-its-at/pkg/interfaces v0.0.0
+	github.com/yair/where-its-at/pkg/ratelimit v0.0.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.2.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/chromedp/cdproto v0.0.0-20250724212937-08a3db8b4327 // indirect
+	github.com/chromedp/chromedp v0.14.2 // indirect
+	github.com/chromedp/sysutil v1.1.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/go-json-experiment/json v0.0.0-20250725192818-e39067aee2d2 // indirect
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	github.com/gobwas/httphead v0.1.0 // indirect
+	github.com/gobwas/pool v0.2.1 // indirect
+	github.com/gobwas/ws v1.4.0 // indirect
+	github.com/gorilla/mux v1.8.1 // indirect
+	github.com/redis/go-redis/v9 v9.17.2 // indirect
+	github.com/yair/where-its-at/pkg/export v0.0.0 // indirect
+	github.com/yair/where-its-at/pkg/logging v0.0.0 // indirect
+	github.com/yair/where-its-at/pkg/notifications v0.0.0 // indirect
+	github.com/yair/where-its-at/pkg/scoring v0.0.0 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	go.opentelemetry.io/auto/sdk v1.1.0 // indirect
+	go.opentelemetry.io/otel v1.35.0 // indirect
+	go.opentelemetry.io/otel/metric v1.35.0 // indirect
+	go.opentelemetry.io/otel/trace v1.35.0 // indirect
+	golang.org/x/crypto v0.40.0 // indirect
+	golang.org/x/net v0.42.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
+)
+
+replace github.com/yair/where-its-at/pkg/domain => ../domain
+
+replace github.com/yair/where-its-at/pkg/collectors => ../collectors
+
+replace github.com/yair/where-its-at/pkg/config => ../config
+
+replace github.com/yair/where-its-at/pkg/integrations => ../integrations
+
+replace github.com/yair/where-its-at/pkg/interfaces => ../interfaces
+
+replace github.com/yair/where-its-at/pkg/ratelimit => ../ratelimit
+
+replace github.com/yair/where-its-at/pkg/export => ../export
+
+replace github.com/yair/where-its-at/pkg/logging => ../logging
+
+replace github.com/yair/where-its-at/pkg/notifications => ../notifications
+
+replace github.com/yair/where-its-at/pkg/scoring => ../scoring
+
+replace github.com/yair/where-its-at/pkg/cache => ../cache
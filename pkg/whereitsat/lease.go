@@ -0,0 +1,96 @@
+package whereitsat
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// RunExclusive runs job while holding the named lease, so when several
+// instances schedule the same job only one of them runs it. It reports
+// false without running job when another instance holds the lease.
+//
+// The lease is renewed every third of ttl while job runs. If it's lost, say
+// because the database or Redis was unreachable for longer than ttl and
+// another instance took over, job's context is cancelled.
+func (c *Client) RunExclusive(ctx context.Context, name string, ttl time.Duration, job func(ctx context.Context) error) (bool, error) {
+	acquired, err := c.Leases.Acquire(ctx, name, c.holder, ttl)
+	if err != nil {
+		return false, err
+	}
+	if !acquired {
+		return false, nil
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		c.renewLease(jobCtx, cancel, name, ttl)
+	}()
+
+	err = job(jobCtx)
+	cancel()
+	<-renewed
+
+	// The job's context may be done already; the release shouldn't be
+	releaseCtx, cancelRelease := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancelRelease()
+	if releaseErr := c.Leases.Release(releaseCtx, name, c.holder); releaseErr != nil {
+		c.logger.Warn("failed to release lease", "lease", name, "error", releaseErr)
+	}
+
+	return true, err
+}
+
+// renewLease keeps the lease until ctx is done, calling lost when another
+// instance has it or it can't be renewed before it expires
+func (c *Client) renewLease(ctx context.Context, lost context.CancelFunc, name string, ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	expires := time.Now().Add(ttl)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewedAt := time.Now()
+		acquired, err := c.Leases.Acquire(ctx, name, c.holder, ttl)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Warn("failed to renew lease", "lease", name, "error", err)
+			if time.Now().After(expires) {
+				c.logger.Error("lease expired before it could be renewed", "lease", name)
+				lost()
+				return
+			}
+		case !acquired:
+			c.logger.Error("lease was taken over by another instance", "lease", name)
+			lost()
+			return
+		default:
+			expires = renewedAt.Add(ttl)
+		}
+	}
+}
+
+// newLeaseHolder names this process uniquely, even among replicas sharing a
+// hostname
+func newLeaseHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
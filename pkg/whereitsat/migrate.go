@@ -0,0 +1,45 @@
+package whereitsat
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/yair/where-its-at/pkg/collectors"
+)
+
+// Migrate brings every table up to date. Each repository creates its tables
+// and adds missing columns when it's constructed, including the ones the
+// server only sets up when a feature is configured.
+func Migrate(db *sql.DB) error {
+	steps := []struct {
+		name string
+		open func(*sql.DB) error
+	}{
+		{"artist", func(db *sql.DB) error { _, err := collectors.NewArtistRepository(db); return err }},
+		{"event", func(db *sql.DB) error { _, err := collectors.NewEventRepository(db); return err }},
+		// Indexes the two tables above, so it has to come after them
+		{"search", func(db *sql.DB) error { _, err := collectors.NewSearchRepository(db); return err }},
+		{"quota", func(db *sql.DB) error { _, err := collectors.NewQuotaRepository(db); return err }},
+		{"source settings", func(db *sql.DB) error { _, err := collectors.NewSourceSettingsRepository(db); return err }},
+		{"artist profile", func(db *sql.DB) error { _, err := collectors.NewArtistProfileRepository(db); return err }},
+		{"tracked artist", func(db *sql.DB) error { _, err := collectors.NewTrackedArtistRepository(db); return err }},
+		{"past concert", func(db *sql.DB) error { _, err := collectors.NewPastConcertRepository(db); return err }},
+		{"backfill checkpoint", func(db *sql.DB) error { _, err := collectors.NewBackfillCheckpointRepository(db); return err }},
+		{"job", func(db *sql.DB) error { _, err := collectors.NewJobRepository(db); return err }},
+		{"lease", func(db *sql.DB) error { _, err := collectors.NewLeaseRepository(db); return err }},
+		{"oauth token", func(db *sql.DB) error { _, err := collectors.NewOAuthTokenRepository(db); return err }},
+		{"user", func(db *sql.DB) error { _, err := collectors.NewUserRepository(db); return err }},
+		{"follow", func(db *sql.DB) error { _, err := collectors.NewFollowRepository(db); return err }},
+		{"saved search", func(db *sql.DB) error { _, err := collectors.NewSavedSearchRepository(db); return err }},
+		{"notification preferences", func(db *sql.DB) error { _, err := collectors.NewNotificationPreferencesRepository(db); return err }},
+		{"on-sale alert", func(db *sql.DB) error { _, err := collectors.NewOnSaleAlertRepository(db); return err }},
+		{"telegram link", func(db *sql.DB) error { _, err := collectors.NewTelegramLinkRepository(db); return err }},
+	}
+
+	for _, step := range steps {
+		if err := step.open(db); err != nil {
+			return fmt.Errorf("failed to migrate %s tables: %w", step.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,569 @@
+// Package whereitsat embeds the aggregation engine: the event and music
+// sources, the aggregator and the SQLite event store, without the HTTP
+// server.
+package whereitsat
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/yair/where-its-at/pkg/cache"
+	"github.com/yair/where-its-at/pkg/collectors"
+	"github.com/yair/where-its-at/pkg/config"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations"
+	"github.com/yair/where-its-at/pkg/integrations/sources/events"
+	"github.com/yair/where-its-at/pkg/integrations/sources/fixtures"
+	"github.com/yair/where-its-at/pkg/integrations/sources/music"
+	"github.com/yair/where-its-at/pkg/integrations/sources/scrapers"
+	"github.com/yair/where-its-at/pkg/interfaces"
+	"github.com/yair/where-its-at/pkg/ratelimit"
+)
+
+// DefaultDatabasePath is where the event store lives unless Options say
+// otherwise
+const DefaultDatabasePath = "./where-its-at.db"
+
+// QuotaRetention is how long sources' request history is kept, as long as
+// the longest rate limit window
+const QuotaRetention = 24 * time.Hour
+
+// Options are what New needs besides the config. Everything is optional.
+type Options struct {
+	// DB is used instead of opening DatabasePath. The caller keeps
+	// ownership; Close leaves it open.
+	DB           *sql.DB
+	DatabasePath string
+	Logger       *slog.Logger
+	// Metrics receives per-source search timings and cache lookups
+	Metrics integrations.AggregatorMetrics
+}
+
+// Client is the wired engine. Sources that aren't configured are nil.
+type Client struct {
+	Config *config.Config
+	DB     *sql.DB
+
+	Artists        *collectors.ArtistRepository
+	Events         *collectors.EventRepository
+	SearchIndex    *collectors.SearchRepository
+	SourceSettings *collectors.SourceSettingsRepository
+	ArtistProfiles *collectors.ArtistProfileRepository
+	TrackedArtists *collectors.TrackedArtistRepository
+	// Quotas holds the sources' request history, pruned at startup; the
+	// server prunes it as it runs
+	Quotas *collectors.QuotaRepository
+	// Leases keep a scheduled job to one instance at a time: in Redis with
+	// the redis cache backend, in the database otherwise
+	Leases domain.LeaseRepository
+
+	Aggregator        *integrations.MegaAggregator
+	TracksAggregator  *integrations.TracksAggregator
+	ProfileAggregator *integrations.ProfileAggregator
+	SimilarArtists    *integrations.SimilarArtistFinder
+	Images            *integrations.ArtistImageResolver
+	Suggester         *integrations.ArtistSuggester
+	Spotify           *integrations.SpotifyClient
+	SetlistFM         *events.SetlistFMClient
+	Deezer            *music.DeezerClient
+	AppleMusic        *music.AppleMusicClient
+	LastFM            *music.LastFMClient
+	MusicBrainz       *music.MusicBrainzClient
+
+	ArtistService *interfaces.ArtistService
+	// EventService searches through the event store, so repeat searches
+	// are served from SQLite while their events are fresh
+	EventService *interfaces.AggregatedEventService
+	// EventLookup finds events by the IDs their sources gave them
+	EventLookup *interfaces.EventLookupService
+	// Jobs syncs and enriches artists outside of requests. Nothing works
+	// the queue until Run is called on it, as the server does.
+	Jobs *interfaces.JobQueue
+
+	logger  *slog.Logger
+	metrics integrations.AggregatorMetrics
+	closers []func()
+	// holder names this instance to the leases it takes
+	holder string
+}
+
+// quotaTrackedClient is an upstream client whose request budget is persisted
+type quotaTrackedClient interface {
+	integrations.QuotaReporter
+	UseQuotaStore(ctx context.Context, store domain.QuotaRepository) error
+}
+
+// New opens the event store and registers every source cfg configures. A
+// nil cfg is the defaults with WHEREITS_* environment overrides. Close the
+// client when done with it.
+func New(cfg *config.Config, opts Options) (*Client, error) {
+	if cfg == nil {
+		var err error
+		if cfg, err = config.Load(""); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+
+	c := &Client{Config: cfg, DB: opts.DB, logger: opts.Logger, metrics: opts.Metrics, holder: newLeaseHolder()}
+	if c.DB == nil {
+		path := opts.DatabasePath
+		if path == "" {
+			path = DefaultDatabasePath
+		}
+		db, err := collectors.OpenSQLite(collectors.SQLiteConfig{
+			Path:         path,
+			BusyTimeout:  time.Duration(cfg.Database.SQLite.BusyTimeoutMS) * time.Millisecond,
+			MaxOpenConns: cfg.Database.SQLite.MaxOpenConns,
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.DB = db
+		c.closers = append(c.closers, func() { db.Close() })
+	}
+
+	if err := c.init(); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// OpenDatabase opens the SQLite event store at path with the default
+// connection settings
+func OpenDatabase(path string) (*sql.DB, error) {
+	return collectors.OpenSQLite(collectors.SQLiteConfig{Path: path})
+}
+
+// Close releases what the client opened, last opened first
+func (c *Client) Close() {
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		c.closers[i]()
+	}
+	c.closers = nil
+}
+
+// SearchEvents finds an artist's events across every source
+func (c *Client) SearchEvents(ctx context.Context, artistName string, limit int) (*integrations.AggregatedResults, error) {
+	return c.EventService.SearchEvents(ctx, artistName, limit)
+}
+
+// SearchEventsByLocation finds events in a city across every source
+func (c *Client) SearchEventsByLocation(ctx context.Context, city, country string, limit int) (*integrations.AggregatedResults, error) {
+	return c.EventService.SearchEventsByLocation(ctx, city, country, limit)
+}
+
+// SearchArtists finds artists across every music source
+func (c *Client) SearchArtists(ctx context.Context, query string, limit int) (*integrations.AggregatedResults, error) {
+	return c.EventService.SearchArtists(ctx, query, limit)
+}
+
+// ArtistEvents returns a stored artist's events in date order
+func (c *Client) ArtistEvents(ctx context.Context, artistID string, limit int) (*integrations.AggregatedResults, error) {
+	return c.EventService.GetArtistEvents(ctx, artistID, limit)
+}
+
+// Resync searches the sources for an artist again, ignoring what's cached
+func (c *Client) Resync(ctx context.Context, artistName string) (*integrations.AggregatedResults, error) {
+	return c.EventService.Resync(ctx, artistName)
+}
+
+func (c *Client) init() error {
+	cfg, logger, db := c.Config, c.logger, c.DB
+
+	// Cities the built-in table lacks, matched by every location search
+	if cfg.Locations.CitiesFile != "" {
+		cities, err := domain.LoadCities(cfg.Locations.CitiesFile)
+		if err != nil {
+			return err
+		}
+		domain.AddCities(cities)
+	}
+
+	var err error
+	if c.Artists, err = collectors.NewArtistRepository(db); err != nil {
+		return fmt.Errorf("failed to create artist repository: %w", err)
+	}
+	if c.Events, err = collectors.NewEventRepository(db); err != nil {
+		return fmt.Errorf("failed to create event repository: %w", err)
+	}
+	// Indexes the two tables above, so it has to come after them
+	if c.SearchIndex, err = collectors.NewSearchRepository(db); err != nil {
+		return fmt.Errorf("failed to create search index: %w", err)
+	}
+
+	// Initialize integrations (optional - only if configured)
+	var artistAggregator *integrations.ArtistAggregator
+	if cfg.APIs.Spotify.ClientID != "" {
+		spotifyClient, err := integrations.NewSpotifyClient(integrations.SpotifyConfig{
+			ClientID:     cfg.APIs.Spotify.ClientID,
+			ClientSecret: cfg.APIs.Spotify.ClientSecret,
+			RedirectURI:  cfg.APIs.Spotify.RedirectURI,
+		})
+		if err != nil {
+			logger.Warn("failed to create Spotify client", "error", err)
+		} else {
+			c.Spotify = spotifyClient
+			artistAggregator = integrations.NewArtistAggregator(spotifyClient, nil)
+		}
+	}
+
+	// Restore upstream request budgets so restarts don't reset daily quotas
+	quotaRepo, err := collectors.NewQuotaRepository(db)
+	if err != nil {
+		return fmt.Errorf("failed to create quota repository: %w", err)
+	}
+	if err := quotaRepo.DeleteBefore(context.Background(), time.Now().Add(-QuotaRetention)); err != nil {
+		logger.Warn("failed to prune quota history", "error", err)
+	}
+	c.Quotas = quotaRepo
+
+	// Instances behind a load balancer share searches and request budgets
+	// through Redis
+	var sharedCache cache.Store
+	switch cfg.Cache.Backend {
+	case "", "memory":
+	case "redis":
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		store, err := cache.NewRedisStore(ctx, cache.RedisConfig{
+			Addr:      cfg.Cache.Redis.Addr,
+			Password:  cfg.Cache.Redis.Password,
+			DB:        cfg.Cache.Redis.DB,
+			Namespace: cfg.Cache.Redis.Namespace,
+		})
+		cancel()
+		if err != nil {
+			return err
+		}
+		ratelimit.SetSharedCounter(store)
+		c.closers = append(c.closers, func() {
+			ratelimit.SetSharedCounter(nil)
+			store.Close()
+		})
+		sharedCache = store
+		c.Leases = store
+		logger.Info("sharing the cache in redis", "addr", cfg.Cache.Redis.Addr, "namespace", cfg.Cache.Redis.Namespace)
+	default:
+		return fmt.Errorf("unknown cache backend %q", cfg.Cache.Backend)
+	}
+	if c.Leases == nil {
+		if c.Leases, err = collectors.NewLeaseRepository(db); err != nil {
+			return fmt.Errorf("failed to create lease repository: %w", err)
+		}
+	}
+
+	sourceLimits := make(map[string]integrations.SourceLimits, len(cfg.Sources.Limits))
+	for name, limits := range cfg.Sources.Limits {
+		sourceLimits[name] = integrations.SourceLimits{
+			Timeout:       time.Duration(limits.TimeoutMS) * time.Millisecond,
+			MaxResults:    limits.MaxResults,
+			MaxConcurrent: limits.MaxConcurrent,
+		}
+	}
+	megaAggregator := integrations.NewMegaAggregator(integrations.MegaAggregatorConfig{
+		RequestTimeout:       time.Duration(cfg.Sources.TimeoutMS) * time.Millisecond,
+		MaxResultsPerSource:  cfg.Sources.MaxResults,
+		SourceLimits:         sourceLimits,
+		FastQuorum:           cfg.Sources.FastQuorum,
+		FastSoftDeadline:     time.Duration(cfg.Sources.FastDeadlineMS) * time.Millisecond,
+		CacheEnabled:         true,
+		CacheMaxEntries:      cfg.Cache.SearchMaxEntries,
+		CacheMaxBytes:        int64(cfg.Cache.SearchMaxMB) << 20,
+		CacheNoResultsTTL:    time.Duration(cfg.Cache.NoResultsTTLSeconds) * time.Second,
+		CacheStore:           sharedCache,
+		DeduplicationEnabled: true,
+		Ranker: integrations.NewWeightedRanker(integrations.WeightedRankerConfig{
+			SourceWeights: cfg.Ranking.SourceWeights,
+		}),
+		// Venue pages are the only scraped source so far
+		IncludeScrapers: len(cfg.Scrapers.VenuePages) > 0,
+		Logger:          logger,
+		Metrics:         c.metrics,
+	})
+	c.Aggregator = megaAggregator
+
+	// Names resolved to MBIDs by alias lookups are reused by the sources
+	// that search by MBID, and survive restarts
+	identityRepo, err := collectors.NewArtistIdentityRepository(db)
+	if err != nil {
+		return fmt.Errorf("failed to create artist identity repository: %w", err)
+	}
+	megaAggregator.SetArtistIdentities(identityRepo)
+
+	eventCacheTTL := time.Duration(cfg.Cache.EventCacheDuration) * time.Hour
+	c.EventLookup = interfaces.NewEventLookupService(c.Events, eventCacheTTL)
+
+	trackQuota := func(name string, client quotaTrackedClient) {
+		if err := client.UseQuotaStore(context.Background(), quotaRepo); err != nil {
+			logger.Warn("failed to restore quota", "source", name, "error", err)
+		}
+		megaAggregator.RegisterQuotaReporter(name, client)
+	}
+
+	// Embedded artists and events, so the API works end to end without keys
+	if cfg.IsDevelopment() {
+		client, err := fixtures.NewClient()
+		if err != nil {
+			return fmt.Errorf("failed to load fixtures: %w", err)
+		}
+		megaAggregator.RegisterMusicSource(fixtures.Name, client)
+		megaAggregator.RegisterEventSource(fixtures.Name, client)
+		logger.Info("serving fixture data", "environment", cfg.Environment)
+	}
+	if cfg.APIs.Songkick.APIKey != "" {
+		if client, err := events.NewSongkickClient(events.SongkickConfig{APIKey: cfg.APIs.Songkick.APIKey}); err == nil {
+			trackQuota("songkick", client)
+			megaAggregator.RegisterEventSource("songkick", client)
+			// The gigography goes to history searches and backfills, next to
+			// setlist.fm's, which it's deduplicated against
+			megaAggregator.RegisterHistorySource("songkick", client)
+			c.EventLookup.RegisterSource("songkick", client)
+		}
+	}
+	if cfg.APIs.Bandsintown.AppID != "" {
+		client, err := integrations.NewBandsintownClient(integrations.BandsintownConfig{
+			AppID:           cfg.APIs.Bandsintown.AppID,
+			LocationArtists: cfg.APIs.Bandsintown.LocationArtists,
+			RadiusMiles:     cfg.APIs.Bandsintown.RadiusMiles,
+		})
+		if err == nil {
+			trackQuota("bandsintown", client)
+			megaAggregator.RegisterEventSource("bandsintown", client)
+		}
+	}
+	var ticketmaster *events.TicketmasterClient
+	if cfg.APIs.Ticketmaster.APIKey != "" {
+		if client, err := events.NewTicketmasterClient(events.TicketmasterConfig{
+			APIKey:   cfg.APIs.Ticketmaster.APIKey,
+			MaxPages: cfg.APIs.Ticketmaster.MaxPages,
+		}); err == nil {
+			trackQuota("ticketmaster", client)
+			c.EventLookup.RegisterSource("ticketmaster", client)
+			ticketmaster = client
+		}
+	}
+	if cfg.APIs.Eventbrite.Token != "" {
+		if client, err := events.NewEventbriteClient(events.EventbriteConfig{Token: cfg.APIs.Eventbrite.Token}); err == nil {
+			trackQuota("eventbrite", client)
+			c.EventLookup.RegisterSource("eventbrite", client)
+		}
+	}
+	if cfg.APIs.SetlistFM.APIKey != "" {
+		if client, err := events.NewSetlistFMClient(events.SetlistFMConfig{APIKey: cfg.APIs.SetlistFM.APIKey}); err == nil {
+			trackQuota("setlistfm", client)
+			client.UseIdentityStore(identityRepo)
+			// Past concerts only, so they stay out of upcoming event searches
+			megaAggregator.RegisterHistorySource("setlistfm", client)
+			c.SetlistFM = client
+		}
+	}
+	if len(cfg.Scrapers.VenuePages) > 0 {
+		scrapingConfig := scrapers.ScrapingConfig{
+			UserAgent:    cfg.Scrapers.UserAgent,
+			RequestDelay: time.Duration(cfg.Scrapers.RateLimitSeconds) * time.Second,
+			Timeout:      time.Duration(cfg.Scrapers.Timeout) * time.Second,
+			CacheDir:     cfg.Scrapers.CacheDir,
+			CacheTTL:     time.Duration(cfg.Scrapers.CacheTTLMinutes) * time.Minute,
+
+			UserAgents:      cfg.Scrapers.UserAgents,
+			AcceptLanguages: cfg.Scrapers.AcceptLanguages,
+			Jitter:          float64(cfg.Scrapers.JitterPercent) / 100,
+			Domains:         make(map[string]scrapers.DomainPoliteness, len(cfg.Scrapers.Domains)),
+		}
+		for domain, politeness := range cfg.Scrapers.Domains {
+			scrapingConfig.Domains[domain] = scrapers.DomainPoliteness{
+				RequestDelay: time.Duration(politeness.RateLimitSeconds) * time.Second,
+				Jitter:       float64(politeness.JitterPercent) / 100,
+			}
+		}
+		// Instagram builds its pages with JavaScript
+		if cfg.Scrapers.Browser.UsesBrowser("venue_pages") {
+			browser := scrapers.NewBrowserPool(scrapers.BrowserConfig{
+				ExecPath:    cfg.Scrapers.Browser.ExecPath,
+				UserAgent:   cfg.Scrapers.UserAgent,
+				MaxTabs:     cfg.Scrapers.Browser.MaxTabs,
+				PageTimeout: time.Duration(cfg.Scrapers.Browser.PageTimeoutSeconds) * time.Second,
+			})
+			c.closers = append(c.closers, browser.Close)
+			scrapingConfig.Browser = browser
+		}
+
+		pages := make([]scrapers.VenuePage, 0, len(cfg.Scrapers.VenuePages))
+		for _, page := range cfg.Scrapers.VenuePages {
+			pages = append(pages, scrapers.VenuePage(page))
+		}
+		megaAggregator.RegisterScraper(scrapers.NewVenuePagesScraper(scrapers.VenuePagesConfig{
+			ScrapingConfig: scrapingConfig,
+			Pages:          pages,
+			FacebookToken:  cfg.APIs.Facebook.AccessToken,
+		}))
+	}
+
+	// Deezer needs no key; it supplies song previews for setlists and tracks,
+	// charts and new releases
+	deezerClient, err := music.NewDeezerClient(music.DeezerConfig{
+		ChartPlaylists: cfg.APIs.Deezer.ChartPlaylists,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Deezer client: %w", err)
+	}
+	trackQuota("deezer", deezerClient)
+	c.Deezer = deezerClient
+
+	// Type-ahead completes from the cached artists, and asks Deezer, which
+	// answers quickly, for names not cached yet. The server refreshes the
+	// index from the database.
+	c.Suggester = integrations.NewArtistSuggester(integrations.ArtistSuggesterConfig{
+		Artists:    c.Artists,
+		Source:     deezerClient,
+		SourceName: "deezer",
+		Logger:     logger,
+	})
+	// Searches that find nothing suggest the artist they most likely meant
+	megaAggregator.SetSpellingSuggester(c.Suggester)
+
+	// Apple Music searches the configured storefront's catalog unless a
+	// request names another
+	if cfg.APIs.AppleMusic.TeamID != "" && cfg.APIs.AppleMusic.KeyID != "" {
+		client, err := music.NewAppleMusicClient(music.AppleMusicConfig{
+			TeamID:     cfg.APIs.AppleMusic.TeamID,
+			KeyID:      cfg.APIs.AppleMusic.KeyID,
+			PrivateKey: cfg.APIs.AppleMusic.PrivateKey,
+			Storefront: cfg.APIs.AppleMusic.CountryCode,
+		})
+		if err != nil {
+			logger.Warn("failed to create Apple Music client", "error", err)
+		} else {
+			trackQuota("apple_music", client)
+			megaAggregator.RegisterMusicSource("apple_music", client)
+			c.AppleMusic = client
+		}
+	}
+
+	// Registered in order of preference: Deezer tracks have previews
+	c.TracksAggregator = integrations.NewTracksAggregator(10 * time.Second)
+	c.TracksAggregator.RegisterSource("deezer", deezerClient)
+	c.ProfileAggregator = integrations.NewProfileAggregator(15 * time.Second)
+	c.ProfileAggregator.RegisterAlbumSource("deezer", deezerClient)
+	if c.AppleMusic != nil {
+		c.ProfileAggregator.RegisterAlbumSource("apple_music", c.AppleMusic)
+	}
+	// Spotify's related artists come from listening habits, so they count
+	// for more than MusicBrainz's band memberships and collaborations
+	c.SimilarArtists = integrations.NewSimilarArtistFinder(10 * time.Second)
+	if c.Spotify != nil {
+		c.SimilarArtists.RegisterSource("spotify", c.Spotify, 1)
+	}
+	// Profiles carry a picture that loads, looked for in this order:
+	// Spotify, Deezer, Apple Music, the Cover Art Archive through
+	// MusicBrainz, then fanart.tv
+	imageRepo, err := collectors.NewArtistImageRepository(db)
+	if err != nil {
+		return fmt.Errorf("failed to create artist image repository: %w", err)
+	}
+	images := integrations.NewArtistImageResolver(5 * time.Second)
+	images.UseImageStore(imageRepo)
+	if c.Spotify != nil {
+		images.RegisterSource("spotify", c.Spotify)
+	}
+	images.RegisterSource("deezer", deezerClient)
+	if c.AppleMusic != nil {
+		images.RegisterSource("apple_music", c.AppleMusic)
+	}
+	c.ProfileAggregator.UseImageResolver(images)
+	c.Images = images
+
+	if client, err := music.NewMusicBrainzClient(music.MusicBrainzConfig{UserAgent: cfg.APIs.MusicBrainz.UserAgent}); err == nil {
+		c.ProfileAggregator.RegisterReleaseSource("musicbrainz", client)
+		c.SimilarArtists.RegisterSource("musicbrainz", client, 0.8)
+		megaAggregator.RegisterAliasSource("musicbrainz", client)
+		images.RegisterSource("coverartarchive", client)
+		c.MusicBrainz = client
+	}
+	if cfg.APIs.FanartTV.APIKey != "" {
+		if client, err := music.NewFanartTVClient(music.FanartTVConfig{APIKey: cfg.APIs.FanartTV.APIKey}); err == nil {
+			images.RegisterSource("fanarttv", client)
+		}
+	}
+	// After MusicBrainz, whose names win: Ticketmaster's attraction aliases
+	// are mostly the misspellings people search for
+	if ticketmaster != nil {
+		megaAggregator.RegisterAliasSource("ticketmaster", ticketmaster)
+	}
+	if cfg.APIs.SoundCloud.ClientID != "" {
+		if client, err := music.NewSoundCloudClient(music.SoundCloudConfig{
+			ClientID:     cfg.APIs.SoundCloud.ClientID,
+			ClientSecret: cfg.APIs.SoundCloud.ClientSecret,
+		}); err == nil {
+			trackQuota("soundcloud", client)
+			c.TracksAggregator.RegisterSource("soundcloud", client)
+			c.ProfileAggregator.RegisterTrackSource("soundcloud", client)
+		}
+	}
+	if cfg.APIs.YouTube.APIKey != "" {
+		if client, err := music.NewYouTubeMusicClient(music.YouTubeMusicConfig{
+			APIKey:     cfg.APIs.YouTube.APIKey,
+			DailyQuota: cfg.APIs.YouTube.DailyQuota,
+		}); err == nil {
+			trackQuota("youtube", client)
+			c.TracksAggregator.RegisterSource("youtube", client)
+			c.ProfileAggregator.RegisterVideoSource("youtube", client)
+		}
+	}
+
+	if cfg.APIs.LastFM.APIKey != "" {
+		if client, err := music.NewLastFMClient(music.LastFMConfig{APIKey: cfg.APIs.LastFM.APIKey}); err == nil {
+			trackQuota("lastfm", client)
+			megaAggregator.RegisterMusicSource("lastfm", client)
+			c.LastFM = client
+		}
+	}
+
+	// Operators' overrides from PATCH /api/sources/{name}, applied once every
+	// source is registered
+	if c.SourceSettings, err = collectors.NewSourceSettingsRepository(db); err != nil {
+		return fmt.Errorf("failed to create source settings repository: %w", err)
+	}
+	sourceSettings, err := c.SourceSettings.List(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to load source settings: %w", err)
+	}
+	for _, setting := range sourceSettings {
+		if err := megaAggregator.ApplySourceSetting(setting); err != nil {
+			logger.Warn("failed to apply source setting", "source", setting.Source, "error", err)
+		}
+	}
+
+	if c.ArtistProfiles, err = collectors.NewArtistProfileRepository(db); err != nil {
+		return fmt.Errorf("failed to create artist profile repository: %w", err)
+	}
+	if c.TrackedArtists, err = collectors.NewTrackedArtistRepository(db); err != nil {
+		return fmt.Errorf("failed to create tracked artist repository: %w", err)
+	}
+
+	// Initialize services
+	c.ArtistService = interfaces.NewArtistService(c.Artists, artistAggregator)
+	c.EventService = interfaces.NewAggregatedEventService(megaAggregator, c.Events, c.Artists, eventCacheTTL)
+
+	jobRepo, err := collectors.NewJobRepository(db)
+	if err != nil {
+		return fmt.Errorf("failed to create job repository: %w", err)
+	}
+	c.Jobs = interfaces.NewJobQueue(interfaces.JobQueueConfig{
+		Jobs:        jobRepo,
+		Workers:     cfg.Jobs.Workers,
+		MaxAttempts: cfg.Jobs.MaxAttempts,
+		Logger:      logger,
+	})
+	c.Jobs.Handle(interfaces.JobSyncArtists, interfaces.SyncArtistsJob(c.EventService, c.TrackedArtists))
+	c.Jobs.Handle(interfaces.JobBuildProfile, interfaces.BuildProfileJob(c.Artists, c.ArtistProfiles, c.ProfileAggregator))
+
+	return nil
+}
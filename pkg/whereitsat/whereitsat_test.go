@@ -0,0 +1,402 @@
+package whereitsat
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/yair/where-its-at/pkg/config"
+	"github.com/yair/where-its-at/pkg/domain"
+	"github.com/yair/where-its-at/pkg/integrations/httpclient"
+	"github.com/yair/where-its-at/pkg/integrations/sources/music"
+)
+
+func TestNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	client, err := New(&config.Config{}, Options{DatabasePath: path})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer client.Close()
+
+	if client.Aggregator == nil || client.EventService == nil || client.Deezer == nil {
+		t.Fatal("expected the aggregator, event service and keyless sources to be wired")
+	}
+	if client.Spotify != nil || client.SetlistFM != nil || client.LastFM != nil {
+		t.Error("expected unconfigured sources to stay nil")
+	}
+	if _, ok := client.Aggregator.GetSourceStats()["deezer"]; ok {
+		t.Error("expected Deezer to supply tracks only, not events")
+	}
+
+	// Stored events that are still fresh are served without any source
+	ctx := context.Background()
+	event := domain.Event{
+		ID:          "stored_1",
+		ArtistName:  "Bicep",
+		DateTime:    time.Now().Add(48 * time.Hour),
+		CachedUntil: time.Now().Add(time.Hour),
+	}
+	if err := client.Events.Create(ctx, &event); err != nil {
+		t.Fatalf("failed to store event: %v", err)
+	}
+
+	results, err := client.SearchEvents(ctx, "Bicep", 10)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results.Events) != 1 || results.Events[0].ID != "stored_1" {
+		t.Errorf("expected the stored event, got %+v", results.Events)
+	}
+}
+
+func TestNew_Development(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.db")
+
+	client, err := New(&config.Config{Environment: config.EnvironmentDevelopment}, Options{DatabasePath: path})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	artists, err := client.SearchArtists(ctx, "bicep", 10)
+	if err != nil || len(artists.Artists) != 1 || artists.Artists[0].Name != "Bicep" {
+		t.Fatalf("expected Bicep from the fixtures, got %+v, %v", artists, err)
+	}
+
+	results, err := client.SearchEventsByLocation(ctx, "Berlin", "", 50)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(results.Events) == 0 {
+		t.Fatal("expected fixture events in Berlin")
+	}
+	for _, event := range results.Events {
+		if event.Venue.City != "Berlin" || !event.DateTime.After(time.Now().Add(-24*time.Hour)) {
+			t.Errorf("expected upcoming Berlin events, got %s on %v", event.Venue.City, event.DateTime)
+		}
+	}
+
+	// Support acts find the shows they are on the bill of
+	results, err = client.SearchEvents(ctx, "Floating Points", 10)
+	if err != nil || len(results.Events) != 3 {
+		t.Errorf("expected Floating Points' show and the two they support, got %d, %v", len(results.Events), err)
+	}
+}
+
+// appleMusicTransport answers Apple Music catalog searches with one artist,
+// who has one album, and everything else with 404, keeping the requests it
+// saw
+type appleMusicTransport struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (tr *appleMusicTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tr.mu.Lock()
+	tr.requests = append(tr.requests, req)
+	tr.mu.Unlock()
+
+	status, body := http.StatusNotFound, "{}"
+	if req.URL.Host == "api.music.apple.com" && strings.HasSuffix(req.URL.Path, "/search") {
+		status, body = http.StatusOK, `{"results":{"artists":{"data":[{"id":"1","attributes":{"name":"Bicep"}}]}}}`
+	}
+	if req.URL.Host == "api.music.apple.com" && strings.HasSuffix(req.URL.Path, "/artists/1/albums") {
+		status, body = http.StatusOK, `{"data":[{"id":"10","attributes":{"name":"Isles","releaseDate":"2021-01-22","trackCount":12}}]}`
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func (tr *appleMusicTransport) Requests(host string) []*http.Request {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	var requests []*http.Request
+	for _, req := range tr.requests {
+		if req.URL.Host == host {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// newAppleMusicConfig configures Apple Music with a freshly made key, the
+// public half of which is returned to check tokens with
+func newAppleMusicConfig(t *testing.T) (*config.Config, *ecdsa.PublicKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	cfg := &config.Config{}
+	cfg.APIs.AppleMusic = config.AppleMusicConfig{
+		TeamID:      "TEAM123",
+		KeyID:       "KEY456",
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		CountryCode: "Germany",
+	}
+	return cfg, &key.PublicKey
+}
+
+func TestNew_AppleMusic(t *testing.T) {
+	transport := &appleMusicTransport{}
+	httpclient.SetBaseTransport(transport)
+	t.Cleanup(func() { httpclient.SetBaseTransport(nil) })
+
+	cfg, publicKey := newAppleMusicConfig(t)
+	client, err := New(cfg, Options{DatabasePath: filepath.Join(t.TempDir(), "events.db")})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer client.Close()
+
+	if client.AppleMusic == nil {
+		t.Fatal("expected Apple Music to be wired from the config")
+	}
+	if _, ok := client.Aggregator.GetSourceStats()["apple_music"]; !ok {
+		t.Error("expected Apple Music to be registered as a music source")
+	}
+
+	ctx := context.Background()
+	if _, err := client.Aggregator.SearchArtists(ctx, "Bicep", 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := client.Aggregator.SearchArtists(music.WithStorefront(ctx, "fr"), "Four Tet", 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	requests := transport.Requests("api.music.apple.com")
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 Apple Music requests, got %d", len(requests))
+	}
+	if requests[0].URL.Path != "/v1/catalog/de/search" {
+		t.Errorf("expected the configured storefront, got %s", requests[0].URL.Path)
+	}
+	if requests[1].URL.Path != "/v1/catalog/fr/search" {
+		t.Errorf("expected the request's storefront, got %s", requests[1].URL.Path)
+	}
+
+	signed := strings.TrimPrefix(requests[0].Header.Get("Authorization"), "Bearer ")
+	var claims jwt.RegisteredClaims
+	token, err := jwt.ParseWithClaims(signed, &claims, func(token *jwt.Token) (interface{}, error) {
+		return publicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		t.Fatalf("expected a developer token signed with the key, got %v", err)
+	}
+	if token.Header["kid"] != "KEY456" || claims.Issuer != "TEAM123" {
+		t.Errorf("expected the key and team IDs in the token, got %v and %q", token.Header["kid"], claims.Issuer)
+	}
+
+	// Profiles list the artist's albums from the configured storefront
+	profile, _ := client.ProfileAggregator.BuildProfile(ctx, domain.Artist{Name: "Bicep"})
+	var albums []domain.Album
+	for _, album := range profile.Albums {
+		if album.Source == "apple_music" {
+			albums = append(albums, album)
+		}
+	}
+	if len(albums) != 1 || albums[0].ID != "apple_10" || albums[0].Title != "Isles" {
+		t.Errorf("expected Isles from Apple Music, got %+v", profile.Albums)
+	}
+	fetched := false
+	for _, req := range transport.Requests("api.music.apple.com") {
+		fetched = fetched || req.URL.Path == "/v1/catalog/de/artists/1/albums"
+	}
+	if !fetched {
+		t.Error("expected the albums from the configured storefront")
+	}
+}
+
+func TestNew_ImageSources(t *testing.T) {
+	cfg, _ := newAppleMusicConfig(t)
+	cfg.APIs.Spotify = config.SpotifyConfig{ClientID: "spotify-id", ClientSecret: "spotify-secret"}
+	cfg.APIs.FanartTV.APIKey = "fanart-key"
+	cfg.APIs.MusicBrainz.UserAgent = "WhereItsAt/test"
+
+	client, err := New(cfg, Options{DatabasePath: filepath.Join(t.TempDir(), "events.db")})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer client.Close()
+
+	expected := []string{"spotify", "deezer", "apple_music", "coverartarchive", "fanarttv"}
+	if got := client.Images.Sources(); !slices.Equal(got, expected) {
+		t.Errorf("expected images looked for in %v, got %v", expected, got)
+	}
+}
+
+func TestNew_RedisCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	cfg := &config.Config{Environment: config.EnvironmentDevelopment}
+	cfg.Cache.Backend = "redis"
+	cfg.Cache.Redis = config.RedisConfig{Addr: server.Addr(), Namespace: "test"}
+
+	client, err := New(cfg, Options{DatabasePath: filepath.Join(t.TempDir(), "events.db")})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer client.Close()
+
+	if _, err := client.Aggregator.SearchArtists(context.Background(), "bicep", 10); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if stats := client.Aggregator.CacheStats(); stats.Backend != "shared" {
+		t.Errorf("expected the shared cache, got %+v", stats)
+	}
+	keys := server.Keys()
+	if len(keys) != 1 || !strings.HasPrefix(keys[0], "test:search:artists:bicep") {
+		t.Errorf("expected the search cached in redis, got %v", keys)
+	}
+
+	cfg.Cache.Backend = "memcached"
+	if _, err := New(cfg, Options{DatabasePath: filepath.Join(t.TempDir(), "events.db")}); err == nil {
+		t.Error("expected an unknown cache backend to be rejected")
+	}
+}
+
+func TestNew_SharedDB(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "events.db"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer db.Close()
+
+	client, err := New(&config.Config{}, Options{DB: db})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	client.Close()
+
+	if err := db.Ping(); err != nil {
+		t.Errorf("expected the caller's database to stay open, got %v", err)
+	}
+}
+
+func TestClient_RunExclusive(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "events.db"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer db.Close()
+
+	// Two replicas sharing the database
+	first, err := New(&config.Config{}, Options{DB: db})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer first.Close()
+	second, err := New(&config.Config{}, Options{DB: db})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer second.Close()
+
+	ctx := context.Background()
+	started, finish := make(chan struct{}), make(chan struct{})
+	done := make(chan bool)
+	go func() {
+		ran, err := first.RunExclusive(ctx, "sync:tracked", time.Minute, func(ctx context.Context) error {
+			close(started)
+			<-finish
+			return nil
+		})
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		done <- ran
+	}()
+	<-started
+
+	ran, err := second.RunExclusive(ctx, "sync:tracked", time.Minute, func(ctx context.Context) error {
+		t.Error("expected the job not to run while the first replica holds the lease")
+		return nil
+	})
+	if err != nil || ran {
+		t.Fatalf("expected the second replica to skip, got %v, %v", ran, err)
+	}
+
+	close(finish)
+	if !<-done {
+		t.Fatal("expected the first replica to run the job")
+	}
+
+	// Released, so the second replica runs the next one
+	ran, err = second.RunExclusive(ctx, "sync:tracked", time.Minute, func(ctx context.Context) error { return nil })
+	if err != nil || !ran {
+		t.Fatalf("expected the second replica to run after the release, got %v, %v", ran, err)
+	}
+}
+
+func TestClient_RunExclusive_LostLease(t *testing.T) {
+	client, err := New(&config.Config{}, Options{DatabasePath: filepath.Join(t.TempDir(), "events.db")})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer client.Close()
+
+	ran, err := client.RunExclusive(context.Background(), "sync:tracked", 300*time.Millisecond, func(ctx context.Context) error {
+		// Another instance takes over, as if this one had stalled
+		if _, err := client.DB.Exec(`UPDATE leases SET holder = 'other'`); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil
+		}
+	})
+	if !ran {
+		t.Fatal("expected the job to run")
+	}
+	if err != context.Canceled {
+		t.Errorf("expected losing the lease to cancel the job, got %v", err)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	db, err := OpenDatabase(filepath.Join(t.TempDir(), "events.db"))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer db.Close()
+
+	// Running twice must be safe, as every start runs the same steps
+	for i := 0; i < 2; i++ {
+		if err := Migrate(db); err != nil {
+			t.Fatalf("migration %d failed: %v", i+1, err)
+		}
+	}
+
+	for _, table := range []string{"events", "artists", "users", "telegram_links"} {
+		var name string
+		err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&name)
+		if err != nil {
+			t.Errorf("expected table %s, got %v", table, err)
+		}
+	}
+}